@@ -19,11 +19,13 @@ package admin
 
 import (
 	"context"
+	"time"
 
 	apiservice "github.com/polarismesh/specification/source/go/api/v1/service_manage"
 
 	connlimit "github.com/polarismesh/polaris/common/conn/limit"
 	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/federation"
 )
 
 type ConnReq struct {
@@ -47,17 +49,41 @@ type ConnStatsResp struct {
 	Stats           []*connlimit.HostConnStat
 }
 
+// ConnDetail 客户端连接的详情，ClientVersions 为该 host 上报的全部 SDK 版本
+type ConnDetail struct {
+	Address           string
+	ConnectedAt       time.Time
+	ConnectedDuration string
+	Subscriptions     []string
+	ClientVersions    []string
+}
+
+// ConnDetailsResp 某个协议当前持有的全部连接
+type ConnDetailsResp struct {
+	Protocol    string
+	Total       int
+	Connections []*ConnDetail
+}
+
 type ScopeLevel struct {
 	Name  string
 	Level string
 }
 
+// AuditRecordsResp 操作记录的分页查询结果
+type AuditRecordsResp struct {
+	Total   uint32
+	Records []*model.RecordEntry
+}
+
 // AdminOperateServer Maintain related operation
 type AdminOperateServer interface {
 	// GetServerConnections Get connection count
 	GetServerConnections(ctx context.Context, req *ConnReq) (*ConnCountResp, error)
 	// GetServerConnStats 获取连接缓存里面的统计信息
 	GetServerConnStats(ctx context.Context, req *ConnReq) (*ConnStatsResp, error)
+	// GetServerConnDetails 获取连接的详情，未指定协议时返回全部协议的连接
+	GetServerConnDetails(ctx context.Context, req *ConnReq) ([]*ConnDetailsResp, error)
 	// CloseConnections Close connection by ip
 	CloseConnections(ctx context.Context, reqs []ConnReq) error
 	// FreeOSMemory Free system memory
@@ -80,4 +106,16 @@ type AdminOperateServer interface {
 	ReleaseLeaderElection(ctx context.Context, electKey string) error
 	// GetCMDBInfo get cmdb info
 	GetCMDBInfo(ctx context.Context) ([]model.LocationView, error)
+	// GetChangeSetRecords 查询同一个变更集下的全部操作记录
+	GetChangeSetRecords(ctx context.Context, changeSetID string) ([]*model.RecordEntry, error)
+	// GetAuditRecords 按照操作人、资源类型、操作类型以及时间范围分页查询操作记录
+	GetAuditRecords(ctx context.Context, query map[string]string) (*AuditRecordsResp, error)
+	// ExportAuthPolicies 以 json 或者 yaml 格式导出全部用户、用户组以及鉴权策略，用于在集群之间迁移或者保存在 git 中
+	ExportAuthPolicies(ctx context.Context, format string, withCredentials bool) ([]byte, error)
+	// ImportAuthPolicies 导入 ExportAuthPolicies 导出的用户、用户组以及鉴权策略，重复导入不会产生变更
+	ImportAuthPolicies(ctx context.Context, format string, data []byte) (*AuthPolicyImportResp, error)
+	// GetFederationSnapshot 获取本集群自身拥有的服务以及实例，供远端集群拉取
+	GetFederationSnapshot(ctx context.Context) (*federation.Snapshot, error)
+	// ApplyFederationSnapshot 接收远端集群推送的快照，以副本的形式写入本集群
+	ApplyFederationSnapshot(ctx context.Context, snapshot *federation.Snapshot) (*federation.ApplyResult, error)
 }
@@ -0,0 +1,446 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package admin
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"go.uber.org/zap"
+
+	"github.com/polarismesh/polaris/auth/policy"
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/common/utils"
+)
+
+// AuthPolicyImportCount 某一类数据的导入结果
+type AuthPolicyImportCount struct {
+	Created   int
+	Updated   int
+	Unchanged int
+	Failed    int
+}
+
+// AuthPolicyImportResp 用户、用户组以及鉴权策略的导入结果，单条数据导入失败不影响其他数据，失败原因记录在 Errors 中
+type AuthPolicyImportResp struct {
+	Users      AuthPolicyImportCount
+	Groups     AuthPolicyImportCount
+	Strategies AuthPolicyImportCount
+	Errors     []string
+}
+
+// ExportAuthPolicies 在同一个读事务中导出全部用户、用户组以及鉴权策略，format 为 json 或者 yaml
+// withCredentials 为 false 时不导出密码以及 token，适合保存在 git 中
+func (svr *Server) ExportAuthPolicies(ctx context.Context, format string, withCredentials bool) ([]byte, error) {
+	policies, err := svr.loadAuthPolicies()
+	if err != nil {
+		log.Error("[MAINTAIN][AuthPolicy] load auth policies", utils.RequestID(ctx), zap.Error(err))
+		return nil, err
+	}
+	data, err := policy.MarshalAuthPolicies(format, policies, withCredentials)
+	if err != nil {
+		return nil, err
+	}
+	log.Info("[MAINTAIN][AuthPolicy] export auth policies", utils.RequestID(ctx),
+		zap.Int("users", len(policies.Users)), zap.Int("groups", len(policies.Groups)),
+		zap.Int("strategies", len(policies.Strategies)), zap.Bool("credentials", withCredentials))
+	return data, nil
+}
+
+// ImportAuthPolicies 按照 ID 导入用户、用户组以及鉴权策略，不存在时创建，存在且有差异时更新，重复导入不会产生变更
+// 新建用户、用户组时必须携带凭据；已存在的数据未携带凭据时保留原有的密码以及 token
+// 存储层不支持修改用户、用户组以及鉴权策略的名称、owner，这类差异视为导入失败
+func (svr *Server) ImportAuthPolicies(ctx context.Context, format string,
+	data []byte) (*AuthPolicyImportResp, error) {
+	imported, err := policy.UnmarshalAuthPolicies(format, data)
+	if err != nil {
+		return nil, err
+	}
+	current, err := svr.loadAuthPolicies()
+	if err != nil {
+		log.Error("[MAINTAIN][AuthPolicy] load auth policies", utils.RequestID(ctx), zap.Error(err))
+		return nil, err
+	}
+
+	resp := &AuthPolicyImportResp{}
+	svr.importAuthPolicyUsers(imported.Users, current.Users, resp)
+	svr.importAuthPolicyGroups(imported.Groups, current.Groups, resp)
+	svr.importAuthPolicyStrategies(imported.Strategies, resp)
+
+	log.Info("[MAINTAIN][AuthPolicy] import auth policies", utils.RequestID(ctx),
+		zap.Any("users", resp.Users), zap.Any("groups", resp.Groups),
+		zap.Any("strategies", resp.Strategies), zap.Strings("errors", resp.Errors))
+	return resp, nil
+}
+
+// loadAuthPolicies 在同一个读事务中读取全部用户、用户组以及鉴权策略
+func (svr *Server) loadAuthPolicies() (*policy.AuthPolicies, error) {
+	tx, err := svr.storage.StartReadTx()
+	if err != nil {
+		if tx != nil {
+			_ = tx.Rollback()
+		}
+		return nil, err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+	if err := tx.CreateReadView(); err != nil {
+		return nil, err
+	}
+	policies := &policy.AuthPolicies{}
+	if policies.Users, err = svr.storage.GetAllUsersTx(tx); err != nil {
+		return nil, err
+	}
+	if policies.Groups, err = svr.storage.GetAllGroupsTx(tx); err != nil {
+		return nil, err
+	}
+	if policies.Strategies, err = svr.storage.GetAllStrategyDetailsTx(tx); err != nil {
+		return nil, err
+	}
+	return policies, nil
+}
+
+func (resp *AuthPolicyImportResp) fail(count *AuthPolicyImportCount, format string, args ...interface{}) {
+	count.Failed++
+	resp.Errors = append(resp.Errors, fmt.Sprintf(format, args...))
+}
+
+// importAuthPolicyUsers 主账户先于子账户导入
+func (svr *Server) importAuthPolicyUsers(items []*model.User, current []*model.User, resp *AuthPolicyImportResp) {
+	byID := make(map[string]*model.User, len(current))
+	byName := make(map[string]*model.User, len(current))
+	for _, user := range current {
+		byID[user.ID] = user
+		byName[user.Owner+"/"+user.Name] = user
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].Owner == "" && items[j].Owner != ""
+	})
+
+	for _, item := range items {
+		if item.ID == "" || item.Name == "" {
+			resp.fail(&resp.Users, "user %s(%s): invalid id or name", item.Name, item.ID)
+			continue
+		}
+		old, exist := byID[item.ID]
+		if !exist {
+			if conflict, ok := byName[item.Owner+"/"+item.Name]; ok {
+				resp.fail(&resp.Users, "user %s(%s): name used by user %s", item.Name, item.ID, conflict.ID)
+				continue
+			}
+			if item.Password == "" || item.Token == "" {
+				resp.fail(&resp.Users, "user %s(%s): create user requires credentials", item.Name, item.ID)
+				continue
+			}
+			if err := svr.storage.AddUser(item); err != nil {
+				resp.fail(&resp.Users, "user %s(%s): %s", item.Name, item.ID, err.Error())
+				continue
+			}
+			byName[item.Owner+"/"+item.Name] = item
+			resp.Users.Created++
+			continue
+		}
+		if old.Name != item.Name || old.Owner != item.Owner || old.Type != item.Type {
+			resp.fail(&resp.Users, "user %s(%s): name, owner or type can not be modified", item.Name, item.ID)
+			continue
+		}
+		user := *old
+		user.Mobile, user.Email, user.Comment = item.Mobile, item.Email, item.Comment
+		user.TokenEnable = item.TokenEnable
+		if item.Password != "" {
+			user.Password = item.Password
+		}
+		if item.Token != "" {
+			user.Token = item.Token
+		}
+		if user.Mobile == old.Mobile && user.Email == old.Email && user.Comment == old.Comment &&
+			user.TokenEnable == old.TokenEnable && user.Password == old.Password && user.Token == old.Token {
+			resp.Users.Unchanged++
+			continue
+		}
+		if err := svr.storage.UpdateUser(&user); err != nil {
+			resp.fail(&resp.Users, "user %s(%s): %s", item.Name, item.ID, err.Error())
+			continue
+		}
+		resp.Users.Updated++
+	}
+}
+
+func (svr *Server) importAuthPolicyGroups(items []*model.UserGroupDetail, current []*model.UserGroupDetail,
+	resp *AuthPolicyImportResp) {
+	byID := make(map[string]*model.UserGroupDetail, len(current))
+	byName := make(map[string]*model.UserGroupDetail, len(current))
+	for _, group := range current {
+		byID[group.ID] = group
+		byName[group.Owner+"/"+group.Name] = group
+	}
+
+	for _, item := range items {
+		if item.ID == "" || item.Name == "" || item.Owner == "" {
+			resp.fail(&resp.Groups, "group %s(%s): invalid id, name or owner", item.Name, item.ID)
+			continue
+		}
+		old, exist := byID[item.ID]
+		if !exist {
+			if conflict, ok := byName[item.Owner+"/"+item.Name]; ok {
+				resp.fail(&resp.Groups, "group %s(%s): name used by group %s", item.Name, item.ID, conflict.ID)
+				continue
+			}
+			if item.Token == "" {
+				resp.fail(&resp.Groups, "group %s(%s): create group requires credentials", item.Name, item.ID)
+				continue
+			}
+			if err := svr.storage.AddGroup(item); err != nil {
+				resp.fail(&resp.Groups, "group %s(%s): %s", item.Name, item.ID, err.Error())
+				continue
+			}
+			byName[item.Owner+"/"+item.Name] = item
+			resp.Groups.Created++
+			continue
+		}
+		if old.Name != item.Name || old.Owner != item.Owner {
+			resp.fail(&resp.Groups, "group %s(%s): name or owner can not be modified", item.Name, item.ID)
+			continue
+		}
+		modify := &model.ModifyUserGroup{
+			ID:          old.ID,
+			Owner:       old.Owner,
+			Token:       old.Token,
+			TokenEnable: item.TokenEnable,
+			Comment:     item.Comment,
+		}
+		if item.Token != "" {
+			modify.Token = item.Token
+		}
+		for id := range item.UserIds {
+			if _, ok := old.UserIds[id]; !ok {
+				modify.AddUserIds = append(modify.AddUserIds, id)
+			}
+		}
+		for id := range old.UserIds {
+			if _, ok := item.UserIds[id]; !ok {
+				modify.RemoveUserIds = append(modify.RemoveUserIds, id)
+			}
+		}
+		if modify.Token == old.Token && modify.TokenEnable == old.TokenEnable && modify.Comment == old.Comment &&
+			len(modify.AddUserIds) == 0 && len(modify.RemoveUserIds) == 0 {
+			resp.Groups.Unchanged++
+			continue
+		}
+		if err := svr.storage.UpdateGroup(modify); err != nil {
+			resp.fail(&resp.Groups, "group %s(%s): %s", item.Name, item.ID, err.Error())
+			continue
+		}
+		resp.Groups.Updated++
+	}
+}
+
+// importAuthPolicyStrategies 新建的用户、用户组会同时创建默认策略，因此导入之后重新读取鉴权策略
+// 默认策略在不同集群中的 ID 不同，按照关联的用户、用户组匹配；关联了不存在的用户、用户组的策略视为导入失败
+func (svr *Server) importAuthPolicyStrategies(items []*model.StrategyDetail, resp *AuthPolicyImportResp) {
+	current, err := svr.loadAuthPolicies()
+	if err != nil {
+		resp.fail(&resp.Strategies, "reload auth policies: %s", err.Error())
+		return
+	}
+	principals := make(map[string]struct{}, len(current.Users)+len(current.Groups))
+	for _, user := range current.Users {
+		principals[principalKey(model.PrincipalUser, user.ID)] = struct{}{}
+	}
+	for _, group := range current.Groups {
+		principals[principalKey(model.PrincipalGroup, group.ID)] = struct{}{}
+	}
+	byID := make(map[string]*model.StrategyDetail, len(current.Strategies))
+	byName := make(map[string]*model.StrategyDetail, len(current.Strategies))
+	defaults := make(map[string]*model.StrategyDetail)
+	for _, strategy := range current.Strategies {
+		byID[strategy.ID] = strategy
+		if strategy.Default {
+			for _, principal := range strategy.Principals {
+				defaults[principalKey(principal.PrincipalRole, principal.PrincipalID)] = strategy
+			}
+			continue
+		}
+		byName[strategy.Owner+"/"+strategy.Name] = strategy
+	}
+
+	for _, item := range items {
+		if item.ID == "" || item.Name == "" || item.Owner == "" {
+			resp.fail(&resp.Strategies, "strategy %s(%s): invalid id, name or owner", item.Name, item.ID)
+			continue
+		}
+		if missing, ok := missingPrincipal(item, principals); ok {
+			resp.fail(&resp.Strategies, "strategy %s(%s): principal %s not found", item.Name, item.ID, missing)
+			continue
+		}
+		var old *model.StrategyDetail
+		switch {
+		case item.Default:
+			if len(item.Principals) != 1 {
+				resp.fail(&resp.Strategies, "strategy %s(%s): default strategy requires one principal",
+					item.Name, item.ID)
+				continue
+			}
+			principal := item.Principals[0]
+			if old = defaults[principalKey(principal.PrincipalRole, principal.PrincipalID)]; old == nil {
+				resp.fail(&resp.Strategies, "strategy %s(%s): default strategy of %s not found", item.Name,
+					item.ID, principal.PrincipalID)
+				continue
+			}
+		case byID[item.ID] == nil:
+			if conflict, ok := byName[item.Owner+"/"+item.Name]; ok {
+				resp.fail(&resp.Strategies, "strategy %s(%s): name used by strategy %s", item.Name, item.ID,
+					conflict.ID)
+				continue
+			}
+			item.Revision = utils.NewUUID()
+			if err := svr.storage.AddStrategy(item); err != nil {
+				resp.fail(&resp.Strategies, "strategy %s(%s): %s", item.Name, item.ID, err.Error())
+				continue
+			}
+			byName[item.Owner+"/"+item.Name] = item
+			resp.Strategies.Created++
+			continue
+		default:
+			if old = byID[item.ID]; old.Default || old.Name != item.Name || old.Owner != item.Owner {
+				resp.fail(&resp.Strategies, "strategy %s(%s): name, owner or default can not be modified",
+					item.Name, item.ID)
+				continue
+			}
+		}
+
+		changed, err := svr.updateAuthPolicyStrategy(old, item)
+		if err != nil {
+			resp.fail(&resp.Strategies, "strategy %s(%s): %s", item.Name, item.ID, err.Error())
+			continue
+		}
+		if changed {
+			resp.Strategies.Updated++
+		} else {
+			resp.Strategies.Unchanged++
+		}
+	}
+}
+
+// updateAuthPolicyStrategy 将 old 更新为 target，默认策略保留原有的 ID 以及关联的用户、用户组
+func (svr *Server) updateAuthPolicyStrategy(old, target *model.StrategyDetail) (bool, error) {
+	modify := &model.ModifyStrategyDetail{
+		ID:      old.ID,
+		Name:    old.Name,
+		Action:  target.Action,
+		Comment: target.Comment,
+	}
+	if !old.Default {
+		oldPrincipals := make(map[string]struct{}, len(old.Principals))
+		for _, principal := range old.Principals {
+			oldPrincipals[principalKey(principal.PrincipalRole, principal.PrincipalID)] = struct{}{}
+		}
+		targetPrincipals := make(map[string]struct{}, len(target.Principals))
+		for _, principal := range target.Principals {
+			key := principalKey(principal.PrincipalRole, principal.PrincipalID)
+			targetPrincipals[key] = struct{}{}
+			if _, ok := oldPrincipals[key]; !ok {
+				modify.AddPrincipals = append(modify.AddPrincipals, principal)
+			}
+		}
+		for _, principal := range old.Principals {
+			if _, ok := targetPrincipals[principalKey(principal.PrincipalRole, principal.PrincipalID)]; !ok {
+				modify.RemovePrincipals = append(modify.RemovePrincipals, principal)
+			}
+		}
+	}
+	oldResources := make(map[string]model.StrategyResource, len(old.Resources))
+	for _, res := range old.Resources {
+		oldResources[resourceKey(res)] = res
+	}
+	targetResources := make(map[string]struct{}, len(target.Resources))
+	for _, res := range target.Resources {
+		res.StrategyID = old.ID
+		targetResources[resourceKey(res)] = struct{}{}
+		// 过期时间不同的资源直接覆盖
+		if exist, ok := oldResources[resourceKey(res)]; !ok || !exist.ExpireTime.Equal(res.ExpireTime) {
+			modify.AddResources = append(modify.AddResources, res)
+		}
+	}
+	for _, res := range old.Resources {
+		if _, ok := targetResources[resourceKey(res)]; !ok {
+			modify.RemoveResources = append(modify.RemoveResources, res)
+		}
+	}
+
+	changed := false
+	if modify.Action != old.Action || modify.Comment != old.Comment || len(modify.AddPrincipals) != 0 ||
+		len(modify.RemovePrincipals) != 0 || len(modify.AddResources) != 0 || len(modify.RemoveResources) != 0 {
+		if err := svr.storage.UpdateStrategy(modify); err != nil {
+			return false, err
+		}
+		changed = true
+	}
+	if effectOf(target) != effectOf(old) || target.Priority != old.Priority {
+		if err := svr.storage.UpdateStrategyEffect(old.ID, target.Effect, target.Priority); err != nil {
+			return changed, err
+		}
+		changed = true
+	}
+	if !equalFunctions(target.Functions, old.Functions) {
+		if err := svr.storage.UpdateStrategyFunctions(old.ID, target.Functions); err != nil {
+			return changed, err
+		}
+		changed = true
+	}
+	return changed, nil
+}
+
+func missingPrincipal(strategy *model.StrategyDetail, principals map[string]struct{}) (string, bool) {
+	for _, principal := range strategy.Principals {
+		if _, ok := principals[principalKey(principal.PrincipalRole, principal.PrincipalID)]; !ok {
+			return principal.PrincipalID, true
+		}
+	}
+	return "", false
+}
+
+func principalKey(role model.PrincipalType, id string) string {
+	return fmt.Sprintf("%d/%s", role, id)
+}
+
+func resourceKey(res model.StrategyResource) string {
+	return fmt.Sprintf("%d/%s", res.ResType, res.ResID)
+}
+
+// effectOf 授权效果为空时视为 ALLOW
+func effectOf(strategy *model.StrategyDetail) model.StrategyEffect {
+	if strategy.Effect == "" {
+		return model.StrategyEffectAllow
+	}
+	return strategy.Effect
+}
+
+func equalFunctions(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,42 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+package admin
+
+import (
+	"context"
+
+	"github.com/polarismesh/polaris/federation"
+)
+
+// GetFederationSnapshot 获取本集群自身拥有的服务以及实例，供远端集群拉取
+func (svr *Server) GetFederationSnapshot(ctx context.Context) (*federation.Snapshot, error) {
+	fedSvr, err := federation.GetServer()
+	if err != nil {
+		return nil, err
+	}
+	return fedSvr.Snapshot(ctx)
+}
+
+// ApplyFederationSnapshot 接收远端集群推送的快照
+func (svr *Server) ApplyFederationSnapshot(ctx context.Context,
+	snapshot *federation.Snapshot) (*federation.ApplyResult, error) {
+	fedSvr, err := federation.GetServer()
+	if err != nil {
+		return nil, err
+	}
+	return fedSvr.Apply(ctx, snapshot)
+}
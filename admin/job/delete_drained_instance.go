@@ -0,0 +1,119 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package job
+
+import (
+	"time"
+
+	"github.com/mitchellh/mapstructure"
+	apiservice "github.com/polarismesh/specification/source/go/api/v1/service_manage"
+
+	"github.com/polarismesh/polaris/cache"
+	api "github.com/polarismesh/polaris/common/api/v1"
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/common/utils"
+	"github.com/polarismesh/polaris/service"
+	"github.com/polarismesh/polaris/store"
+)
+
+type DeleteDrainedInstanceJobConfig struct {
+	Interval time.Duration `mapstructure:"interval"`
+}
+
+// deleteDrainedInstanceJob 删除优雅下线已经到期的实例
+type deleteDrainedInstanceJob struct {
+	cfg          *DeleteDrainedInstanceJobConfig
+	namingServer service.DiscoverServer
+	cacheMgn     *cache.CacheManager
+	storage      store.Store
+}
+
+func (job *deleteDrainedInstanceJob) init(raw map[string]interface{}) error {
+	cfg := &DeleteDrainedInstanceJobConfig{
+		Interval: 10 * time.Second,
+	}
+	decodeConfig := &mapstructure.DecoderConfig{
+		DecodeHook: mapstructure.StringToTimeDurationHookFunc(),
+		Result:     cfg,
+	}
+	decoder, err := mapstructure.NewDecoder(decodeConfig)
+	if err != nil {
+		log.Errorf("[Maintain][Job][DeleteDrainedInstance] new config decoder err: %v", err)
+		return err
+	}
+	err = decoder.Decode(raw)
+	if err != nil {
+		log.Errorf("[Maintain][Job][DeleteDrainedInstance] parse config err: %v", err)
+		return err
+	}
+	job.cfg = cfg
+	return nil
+}
+
+func (job *deleteDrainedInstanceJob) interval() time.Duration {
+	return job.cfg.Interval
+}
+
+func (job *deleteDrainedInstanceJob) execute() {
+	var instances []*model.Instance
+	_ = job.cacheMgn.Instance().IteratorInstances(func(key string, ins *model.Instance) (bool, error) {
+		instances = append(instances, ins)
+		return true, nil
+	})
+	instanceIds := filterDrainedInstances(instances, time.Now())
+
+	batchSize := 100
+	for i := 0; i < len(instanceIds); i += batchSize {
+		j := i + batchSize
+		if j > len(instanceIds) {
+			j = len(instanceIds)
+		}
+		var req []*apiservice.Instance
+		for _, id := range instanceIds[i:j] {
+			req = append(req, &apiservice.Instance{Id: utils.NewStringValue(id)})
+		}
+
+		ctx, err := buildContext(job.storage)
+		if err != nil {
+			log.Errorf("[Maintain][Job][DeleteDrainedInstance] build conetxt, err: %v", err)
+			return
+		}
+		resp := job.namingServer.DeleteInstances(ctx, req)
+		if api.CalcCode(resp) != 200 {
+			log.Errorf("[Maintain][Job][DeleteDrainedInstance] delete instance list: %v, err: %d %s",
+				instanceIds[i:j], resp.Code.GetValue(), resp.Info.GetValue())
+			return
+		}
+		log.Infof("[Maintain][Job][DeleteDrainedInstance] delete instance count %d, list: %v",
+			j-i, instanceIds[i:j])
+	}
+}
+
+func (job *deleteDrainedInstanceJob) clear() {
+}
+
+// filterDrainedInstances 筛选出优雅下线已经到期的实例
+func filterDrainedInstances(instances []*model.Instance, now time.Time) []string {
+	var ids []string
+	for _, ins := range instances {
+		if ins.IsDrained(now) {
+			ids = append(ids, ins.ID())
+		}
+	}
+	return ids
+}
@@ -0,0 +1,69 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package job
+
+import (
+	"testing"
+	"time"
+
+	apiservice "github.com/polarismesh/specification/source/go/api/v1/service_manage"
+
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/common/utils"
+)
+
+func Test_DeleteDrainedInstanceJobConfigInit(t *testing.T) {
+	job := deleteDrainedInstanceJob{}
+	if err := job.init(map[string]interface{}{}); err != nil {
+		t.Errorf("init deleteDrainedInstanceJob config, err: %v", err)
+	}
+	if job.interval() != 10*time.Second {
+		t.Errorf("init deleteDrainedInstanceJob default interval, actual: %s", job.interval())
+	}
+
+	if err := job.init(map[string]interface{}{"interval": "1m"}); err != nil {
+		t.Errorf("init deleteDrainedInstanceJob config, err: %v", err)
+	}
+	if job.interval() != time.Minute {
+		t.Errorf("init deleteDrainedInstanceJob config. expect: %s, actual: %s", time.Minute, job.interval())
+	}
+
+	if err := job.init(map[string]interface{}{"interval": "xx"}); err == nil {
+		t.Errorf("init deleteDrainedInstanceJob config should err")
+	}
+}
+
+func Test_FilterDrainedInstances(t *testing.T) {
+	now := time.Now()
+	newInstance := func(id string, metadata map[string]string) *model.Instance {
+		return &model.Instance{Proto: &apiservice.Instance{Id: utils.NewStringValue(id), Metadata: metadata}}
+	}
+	instances := []*model.Instance{
+		newInstance("normal", nil),
+		newInstance("draining", map[string]string{
+			model.MetadataInstanceDrainingDeadline: model.FormatDrainingDeadline(now.Add(time.Minute)),
+		}),
+		newInstance("drained", map[string]string{
+			model.MetadataInstanceDrainingDeadline: model.FormatDrainingDeadline(now.Add(-time.Second)),
+		}),
+	}
+	ids := filterDrainedInstances(instances, now)
+	if len(ids) != 1 || ids[0] != "drained" {
+		t.Errorf("filter drained instances, actual: %v", ids)
+	}
+}
@@ -18,30 +18,45 @@
 package job
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/mitchellh/mapstructure"
 	apiservice "github.com/polarismesh/specification/source/go/api/v1/service_manage"
 
+	"github.com/polarismesh/polaris/cache"
 	api "github.com/polarismesh/polaris/common/api/v1"
+	"github.com/polarismesh/polaris/common/metrics"
+	"github.com/polarismesh/polaris/common/model"
 	"github.com/polarismesh/polaris/common/utils"
+	"github.com/polarismesh/polaris/plugin"
 	"github.com/polarismesh/polaris/service"
 	"github.com/polarismesh/polaris/store"
 )
 
 type DeleteUnHealthyInstanceJobConfig struct {
+	// InstanceDeleteTimeout 实例不健康超过该时长后被删除
 	InstanceDeleteTimeout time.Duration `mapstructure:"instanceDeleteTimeout"`
+	// Interval 任务的执行间隔，不配置时与 InstanceDeleteTimeout 相同
+	Interval time.Duration `mapstructure:"interval"`
+	// BatchSize 单次删除的实例数量
+	BatchSize uint32 `mapstructure:"batchSize"`
+	// DryRun 试运行模式，只记录需要删除的实例而不删除，每次执行最多记录 BatchSize 个实例
+	DryRun bool `mapstructure:"dryRun"`
 }
 
 type deleteUnHealthyInstanceJob struct {
 	cfg          *DeleteUnHealthyInstanceJobConfig
 	namingServer service.DiscoverServer
+	cacheMgn     *cache.CacheManager
 	storage      store.Store
+	history      plugin.History
 }
 
 func (job *deleteUnHealthyInstanceJob) init(raw map[string]interface{}) error {
 	cfg := &DeleteUnHealthyInstanceJobConfig{
 		InstanceDeleteTimeout: 60 * time.Minute,
+		BatchSize:             100,
 	}
 	decodeConfig := &mapstructure.DecoderConfig{
 		DecodeHook: mapstructure.StringToTimeDurationHookFunc(),
@@ -57,19 +72,31 @@ func (job *deleteUnHealthyInstanceJob) init(raw map[string]interface{}) error {
 		log.Errorf("[Maintain][Job][DeleteUnHealthyInstance] parse config err: %v", err)
 		return err
 	}
+	if cfg.InstanceDeleteTimeout <= 0 {
+		return fmt.Errorf("[Maintain][Job][DeleteUnHealthyInstance] invalid instanceDeleteTimeout: %s",
+			cfg.InstanceDeleteTimeout)
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = cfg.InstanceDeleteTimeout
+	}
+	if cfg.BatchSize == 0 {
+		cfg.BatchSize = 100
+	}
 	job.cfg = cfg
+	if job.history == nil {
+		job.history = plugin.GetHistory()
+	}
 	return nil
 }
 
 func (job *deleteUnHealthyInstanceJob) interval() time.Duration {
-	return job.cfg.InstanceDeleteTimeout
+	return job.cfg.Interval
 }
 
 func (job *deleteUnHealthyInstanceJob) execute() {
-	batchSize := uint32(100)
 	var count int = 0
 	for {
-		instanceIds, err := job.storage.GetUnHealthyInstances(job.cfg.InstanceDeleteTimeout, batchSize)
+		instanceIds, err := job.storage.GetUnHealthyInstances(job.cfg.InstanceDeleteTimeout, job.cfg.BatchSize)
 		if err != nil {
 			log.Errorf("[Maintain][Job][DeleteUnHealthyInstance] get unhealthy instances, err: %v", err)
 			break
@@ -77,6 +104,14 @@ func (job *deleteUnHealthyInstanceJob) execute() {
 		if len(instanceIds) == 0 {
 			break
 		}
+		// 试运行模式下实例不会被删除，再次查询得到的仍然是同一批实例
+		if job.cfg.DryRun {
+			log.Infof("[Maintain][Job][DeleteUnHealthyInstance] dry run, instance count %d, list: %v",
+				len(instanceIds), instanceIds)
+			metrics.ReportStaleInstanceCleaned(metrics.StaleInstanceDryRun, len(instanceIds))
+			job.recordEntries(job.buildRecords(instanceIds, model.ODryRun))
+			return
+		}
 
 		var req []*apiservice.Instance
 		for _, id := range instanceIds {
@@ -88,6 +123,8 @@ func (job *deleteUnHealthyInstanceJob) execute() {
 			log.Errorf("[Maintain][Job][DeleteUnHealthyInstance] build conetxt, err: %v", err)
 			return
 		}
+		// 记录需要在删除之前生成，删除之后实例可能已经从缓存中移除
+		entries := job.buildRecords(instanceIds, model.ODelete)
 		resp := job.namingServer.DeleteInstances(ctx, req)
 		if api.CalcCode(resp) == 200 {
 			log.Infof("[Maintain][Job][DeleteUnHealthyInstance] delete instance count %d, list: %v",
@@ -95,8 +132,11 @@ func (job *deleteUnHealthyInstanceJob) execute() {
 		} else {
 			log.Errorf("[Maintain][Job][DeleteUnHealthyInstance] delete instance list: %v, err: %d %s",
 				instanceIds, resp.Code.GetValue(), resp.Info.GetValue())
+			metrics.ReportStaleInstanceCleaned(metrics.StaleInstanceFail, len(instanceIds))
 			break
 		}
+		metrics.ReportStaleInstanceCleaned(metrics.StaleInstanceDelete, len(instanceIds))
+		job.recordEntries(entries)
 		count += len(instanceIds)
 	}
 
@@ -106,3 +146,41 @@ func (job *deleteUnHealthyInstanceJob) execute() {
 
 func (job *deleteUnHealthyInstanceJob) clear() {
 }
+
+func (job *deleteUnHealthyInstanceJob) recordEntries(entries []*model.RecordEntry) {
+	if job.history == nil {
+		return
+	}
+	for i := range entries {
+		job.history.Record(entries[i])
+	}
+}
+
+// buildRecords 生成清理长时间不健康实例的操作记录
+func (job *deleteUnHealthyInstanceJob) buildRecords(instanceIds []string,
+	operation model.OperationType) []*model.RecordEntry {
+	if job.history == nil {
+		return nil
+	}
+	entries := make([]*model.RecordEntry, 0, len(instanceIds))
+	for _, id := range instanceIds {
+		entry := &model.RecordEntry{
+			ResourceType:  model.RInstance,
+			ResourceName:  id,
+			Operator:      "maintain-job",
+			OperationType: operation,
+			Detail: fmt.Sprintf("delete stale instance unhealthy for more than %s, dry_run=%v",
+				job.cfg.InstanceDeleteTimeout, job.cfg.DryRun),
+			HappenTime: time.Now(),
+		}
+		if job.cacheMgn != nil {
+			if ins := job.cacheMgn.Instance().GetInstance(id); ins != nil {
+				entry.ResourceName = fmt.Sprintf("%s(%s:%d)", ins.Service(), ins.Host(), ins.Port())
+				entry.Namespace = ins.Namespace()
+				entry.Detail = fmt.Sprintf("%s, id=%s", entry.Detail, id)
+			}
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
@@ -20,6 +20,11 @@ package job
 import (
 	"testing"
 	"time"
+
+	"github.com/golang/mock/gomock"
+
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/store/mock"
 )
 
 func Test_DeleteUnHealthyInstanceJobConfigInit(t *testing.T) {
@@ -28,7 +33,7 @@ func Test_DeleteUnHealthyInstanceJobConfigInit(t *testing.T) {
 		"instanceDeleteTimeout": "10m",
 	}
 
-	job := deleteUnHealthyInstanceJob{}
+	job := deleteUnHealthyInstanceJob{history: &recordCollector{}}
 	err := job.init(raw)
 	if err != nil {
 		t.Errorf("init deleteUnHealthyInstanceJob config, err: %v", err)
@@ -38,6 +43,19 @@ func Test_DeleteUnHealthyInstanceJobConfigInit(t *testing.T) {
 		t.Errorf("init deleteUnHealthyInstanceJob config. expect: %s, actual: %s",
 			expectValue, job.cfg.InstanceDeleteTimeout)
 	}
+	// 未配置执行间隔时与删除超时时间相同
+	if job.interval() != expectValue || job.cfg.BatchSize != 100 {
+		t.Errorf("init deleteUnHealthyInstanceJob default config, interval: %s, batchSize: %d",
+			job.interval(), job.cfg.BatchSize)
+	}
+
+	raw["interval"] = "1m"
+	if err := job.init(raw); err != nil {
+		t.Errorf("init deleteUnHealthyInstanceJob config, err: %v", err)
+	}
+	if job.interval() != time.Minute {
+		t.Errorf("init deleteUnHealthyInstanceJob config. expect: %s, actual: %s", time.Minute, job.interval())
+	}
 }
 
 func Test_DeleteUnHealthyInstanceJobConfigInitErr(t *testing.T) {
@@ -45,9 +63,40 @@ func Test_DeleteUnHealthyInstanceJobConfigInitErr(t *testing.T) {
 		"instanceDeleteTimeout": "xx",
 	}
 
-	job := deleteUnHealthyInstanceJob{}
+	job := deleteUnHealthyInstanceJob{history: &recordCollector{}}
 	err := job.init(raw)
 	if err == nil {
 		t.Errorf("init deleteUnHealthyInstanceJob config should err")
 	}
 }
+
+func Test_DeleteUnHealthyInstanceJobDryRun(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	storage := mock.NewMockStore(ctrl)
+	history := &recordCollector{}
+	job := deleteUnHealthyInstanceJob{storage: storage, history: history}
+	raw := map[string]interface{}{
+		"instanceDeleteTimeout": "10m",
+		"batchSize":             2,
+		"dryRun":                true,
+	}
+	if err := job.init(raw); err != nil {
+		t.Fatal(err)
+	}
+
+	// 试运行模式下只查询一次，不删除实例
+	storage.EXPECT().GetUnHealthyInstances(10*time.Minute, uint32(2)).Return([]string{"ins-1", "ins-2"}, nil).Times(1)
+	job.execute()
+
+	if len(history.entries) != 2 {
+		t.Fatalf("each stale instance should be recorded, actual: %d", len(history.entries))
+	}
+	for i, entry := range history.entries {
+		if entry.ResourceType != model.RInstance || entry.OperationType != model.ODryRun ||
+			entry.ResourceName != []string{"ins-1", "ins-2"}[i] {
+			t.Errorf("unexpect dry run record: %s", entry.String())
+		}
+	}
+}
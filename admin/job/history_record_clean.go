@@ -0,0 +1,136 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package job
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/mitchellh/mapstructure"
+
+	"github.com/polarismesh/polaris/common/metrics"
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/plugin"
+	"github.com/polarismesh/polaris/store"
+)
+
+// 默认保存操作记录的时长
+const defaultRecordRetentionTime = 30 * 24 * time.Hour
+
+type CleanHistoryRecordJobConfig struct {
+	// RetentionTime 操作记录的保留时长，小于等于 0 表示不按照时长清理
+	RetentionTime time.Duration `mapstructure:"retentionTime"`
+	// RetainCount 最多保留的操作记录条数，0 表示不按照条数清理
+	RetainCount uint64 `mapstructure:"retainCount"`
+	// ExemptTypes 不参与清理的操作类型
+	ExemptTypes []string `mapstructure:"exemptTypes"`
+	// BatchSize 单次执行最多清理的条数
+	BatchSize uint64 `mapstructure:"batchSize"`
+}
+
+type cleanHistoryRecordJob struct {
+	cfg         *CleanHistoryRecordJobConfig
+	exemptTypes []model.OperationType
+	storage     store.Store
+	history     plugin.History
+}
+
+func (job *cleanHistoryRecordJob) init(raw map[string]interface{}) error {
+	cfg := &CleanHistoryRecordJobConfig{
+		RetentionTime: defaultRecordRetentionTime,
+		BatchSize:     1000,
+	}
+	decodeConfig := &mapstructure.DecoderConfig{
+		DecodeHook: mapstructure.StringToTimeDurationHookFunc(),
+		Result:     cfg,
+	}
+	decoder, err := mapstructure.NewDecoder(decodeConfig)
+	if err != nil {
+		log.Errorf("[Maintain][Job][CleanHistoryRecord] new config decoder err: %v", err)
+		return err
+	}
+	if err = decoder.Decode(raw); err != nil {
+		log.Errorf("[Maintain][Job][CleanHistoryRecord] parse config err: %v", err)
+		return err
+	}
+	if cfg.RetentionTime <= 0 && cfg.RetainCount == 0 {
+		return errors.New("[Maintain][Job][CleanHistoryRecord] retentionTime or retainCount must be set")
+	}
+	if cfg.RetentionTime > 0 && cfg.RetentionTime < time.Minute {
+		cfg.RetentionTime = time.Minute
+	}
+	if cfg.BatchSize == 0 {
+		cfg.BatchSize = 1000
+	}
+	exemptTypes := make([]model.OperationType, 0, len(cfg.ExemptTypes))
+	for i := range cfg.ExemptTypes {
+		exemptTypes = append(exemptTypes, model.OperationType(cfg.ExemptTypes[i]))
+	}
+	job.cfg = cfg
+	job.exemptTypes = exemptTypes
+	if job.history == nil {
+		job.history = plugin.GetHistory()
+	}
+	return nil
+}
+
+func (job *cleanHistoryRecordJob) execute() {
+	if job.cfg.RetentionTime > 0 {
+		endTime := time.Now().Add(-1 * job.cfg.RetentionTime)
+		count, err := job.storage.CleanRecordEntries(endTime, job.exemptTypes, job.cfg.BatchSize)
+		if err != nil {
+			log.Errorf("[Maintain][Job][CleanHistoryRecord] clean by retention time err: %v", err)
+		}
+		job.afterPrune("age", count, fmt.Sprintf("records before %s", endTime.Format(time.RFC3339)))
+	}
+	if job.cfg.RetainCount > 0 {
+		count, err := job.storage.CleanExceedRecordEntries(job.cfg.RetainCount, job.exemptTypes, job.cfg.BatchSize)
+		if err != nil {
+			log.Errorf("[Maintain][Job][CleanHistoryRecord] clean by retain count err: %v", err)
+		}
+		job.afterPrune("count", count, fmt.Sprintf("records exceed %d", job.cfg.RetainCount))
+	}
+}
+
+// afterPrune 清理动作本身也需要记录操作记录以及上报指标
+func (job *cleanHistoryRecordJob) afterPrune(policy string, count uint64, detail string) {
+	if count == 0 {
+		return
+	}
+	log.Infof("[Maintain][Job][CleanHistoryRecord] prune %d %s", count, detail)
+	metrics.ReportHistoryPruned(policy, count)
+	if job.history == nil {
+		return
+	}
+	job.history.Record(&model.RecordEntry{
+		ResourceType:  model.RRecordEntry,
+		ResourceName:  policy,
+		Operator:      "maintain-job",
+		OperationType: model.ODelete,
+		Detail:        fmt.Sprintf("prune %d %s", count, detail),
+		HappenTime:    time.Now(),
+	})
+}
+
+func (job *cleanHistoryRecordJob) interval() time.Duration {
+	return time.Minute
+}
+
+func (job *cleanHistoryRecordJob) clear() {
+}
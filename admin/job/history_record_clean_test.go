@@ -0,0 +1,119 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package job
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/plugin"
+	"github.com/polarismesh/polaris/store/mock"
+)
+
+type recordCollector struct {
+	entries []*model.RecordEntry
+}
+
+func (r *recordCollector) Name() string {
+	return "recordCollector"
+}
+
+func (r *recordCollector) Initialize(c *plugin.ConfigEntry) error {
+	return nil
+}
+
+func (r *recordCollector) Destroy() error {
+	return nil
+}
+
+func (r *recordCollector) Record(entry *model.RecordEntry) {
+	r.entries = append(r.entries, entry)
+}
+
+func Test_CleanHistoryRecordJobConfigInit(t *testing.T) {
+	raw := map[string]interface{}{
+		"retentionTime": "24h",
+		"retainCount":   100,
+		"exemptTypes":   []interface{}{"Delete", "UpdateToken"},
+	}
+
+	job := cleanHistoryRecordJob{history: &recordCollector{}}
+	if err := job.init(raw); err != nil {
+		t.Errorf("init cleanHistoryRecordJob config, err: %v", err)
+	}
+	if job.cfg.RetentionTime != 24*time.Hour {
+		t.Errorf("init cleanHistoryRecordJob config. expect: %s, actual: %s", 24*time.Hour, job.cfg.RetentionTime)
+	}
+	if job.cfg.RetainCount != 100 {
+		t.Errorf("init cleanHistoryRecordJob config. expect: %d, actual: %d", 100, job.cfg.RetainCount)
+	}
+	if len(job.exemptTypes) != 2 || job.exemptTypes[0] != model.ODelete {
+		t.Errorf("init cleanHistoryRecordJob config. unexpect exempt types: %v", job.exemptTypes)
+	}
+}
+
+func Test_CleanHistoryRecordJobConfigInitErr(t *testing.T) {
+	raw := map[string]interface{}{
+		"retentionTime": "0s",
+		"retainCount":   0,
+	}
+
+	job := cleanHistoryRecordJob{history: &recordCollector{}}
+	if err := job.init(raw); err == nil {
+		t.Errorf("init cleanHistoryRecordJob config should err")
+	}
+}
+
+func Test_CleanHistoryRecordJobExecute(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	storage := mock.NewMockStore(ctrl)
+	history := &recordCollector{}
+	job := cleanHistoryRecordJob{storage: storage, history: history}
+	if err := job.init(map[string]interface{}{
+		"retentionTime": "24h",
+		"retainCount":   100,
+		"exemptTypes":   []interface{}{"Delete"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	exempt := []model.OperationType{model.ODelete}
+	storage.EXPECT().CleanRecordEntries(gomock.Any(), exempt, uint64(1000)).DoAndReturn(
+		func(endTime time.Time, _ []model.OperationType, _ uint64) (uint64, error) {
+			if time.Since(endTime) < 24*time.Hour {
+				t.Errorf("clean end time should before retention window, actual: %s", endTime)
+			}
+			return 10, nil
+		})
+	storage.EXPECT().CleanExceedRecordEntries(uint64(100), exempt, uint64(1000)).Return(uint64(0), nil)
+
+	job.execute()
+
+	// 只有真正清理了记录才会生成操作记录
+	if len(history.entries) != 1 {
+		t.Fatalf("prune should be recorded once, actual: %d", len(history.entries))
+	}
+	if history.entries[0].ResourceType != model.RRecordEntry || history.entries[0].OperationType != model.ODelete {
+		t.Errorf("unexpect prune record: %s", history.entries[0].String())
+	}
+}
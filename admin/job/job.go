@@ -25,6 +25,7 @@ import (
 
 	"github.com/polarismesh/polaris/cache"
 	commonlog "github.com/polarismesh/polaris/common/log"
+	"github.com/polarismesh/polaris/common/readiness"
 	"github.com/polarismesh/polaris/common/utils"
 	"github.com/polarismesh/polaris/service"
 	"github.com/polarismesh/polaris/store"
@@ -46,11 +47,17 @@ func NewMaintainJobs(namingServer service.DiscoverServer, cacheMgn *cache.CacheM
 	return &MaintainJobs{
 		jobs: map[string]maintainJob{
 			"DeleteUnHealthyInstance": &deleteUnHealthyInstanceJob{
-				namingServer: namingServer, storage: storage},
+				namingServer: namingServer, cacheMgn: cacheMgn, storage: storage},
+			"DeleteDrainedInstance": &deleteDrainedInstanceJob{
+				namingServer: namingServer, cacheMgn: cacheMgn, storage: storage},
 			"DeleteEmptyService": &deleteEmptyServiceJob{
 				namingServer: namingServer, cacheMgn: cacheMgn, storage: storage},
 			"CleanConfigReleaseHistory": &cleanConfigFileHistoryJob{
 				storage: storage},
+			"CleanHistoryRecord": &cleanHistoryRecordJob{
+				storage: storage},
+			"CleanExpiredStrategyResource": &cleanExpiredStrategyResourceJob{
+				storage: storage},
 			"CleanDeletedResources": &cleanDeletedResourceJob{
 				storage: storage},
 		},
@@ -65,6 +72,8 @@ func (mj *MaintainJobs) StartMaintianJobs(configs []JobConfig) error {
 		log.Errorf("[Maintain][Job] start leader election err: %v", err)
 		return err
 	}
+	readiness.Register(readiness.ComponentLeader(store.ElectionKeyMaintainJob),
+		readiness.LeaderElected(mj.storage, store.ElectionKeyMaintainJob))
 
 	ctx, cancel := context.WithCancel(context.Background())
 	mj.cancel = cancel
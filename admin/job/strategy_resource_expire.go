@@ -0,0 +1,107 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package job
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/mitchellh/mapstructure"
+	apisecurity "github.com/polarismesh/specification/source/go/api/v1/security"
+
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/plugin"
+	"github.com/polarismesh/polaris/store"
+)
+
+type CleanExpiredStrategyResourceJobConfig struct {
+	// BatchSize 单次执行最多清理的资源关联关系数量
+	BatchSize uint32 `mapstructure:"batchSize"`
+	// GracePeriod 过期超过该时长后才清理, 需要不小于鉴权配置中的 expireGraceInSecs, 以免宽限期内的关联关系被提前清理
+	GracePeriod time.Duration `mapstructure:"gracePeriod"`
+}
+
+// cleanExpiredStrategyResourceJob 清理鉴权策略中已经过期的资源关联关系
+type cleanExpiredStrategyResourceJob struct {
+	cfg     *CleanExpiredStrategyResourceJobConfig
+	storage store.Store
+	history plugin.History
+}
+
+func (job *cleanExpiredStrategyResourceJob) init(raw map[string]interface{}) error {
+	cfg := &CleanExpiredStrategyResourceJobConfig{
+		BatchSize: 1000,
+	}
+	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		DecodeHook: mapstructure.StringToTimeDurationHookFunc(),
+		Result:     cfg,
+	})
+	if err != nil {
+		log.Errorf("[Maintain][Job][CleanExpiredStrategyResource] new config decoder err: %v", err)
+		return err
+	}
+	if err := decoder.Decode(raw); err != nil {
+		log.Errorf("[Maintain][Job][CleanExpiredStrategyResource] parse config err: %v", err)
+		return err
+	}
+	if cfg.BatchSize == 0 {
+		cfg.BatchSize = 1000
+	}
+	job.cfg = cfg
+	if job.history == nil {
+		job.history = plugin.GetHistory()
+	}
+	return nil
+}
+
+func (job *cleanExpiredStrategyResourceJob) execute() {
+	// 过期条件与删除在同一个事务中判断，查询之后被续期的关联关系不会被误删
+	resources, err := job.storage.RemoveExpiredStrategyResources(time.Now().Add(-job.cfg.GracePeriod),
+		job.cfg.BatchSize)
+	if err != nil {
+		log.Errorf("[Maintain][Job][CleanExpiredStrategyResource] remove expired resources err: %v", err)
+		return
+	}
+	if len(resources) == 0 {
+		return
+	}
+	log.Infof("[Maintain][Job][CleanExpiredStrategyResource] remove %d expired resources", len(resources))
+	if job.history == nil {
+		return
+	}
+	// 每一条被清理的资源关联关系都需要记录操作记录
+	for i := range resources {
+		res := resources[i]
+		job.history.Record(&model.RecordEntry{
+			ResourceType:  model.RAuthStrategy,
+			ResourceName:  res.StrategyID,
+			Operator:      "maintain-job",
+			OperationType: model.ODelete,
+			Detail: fmt.Sprintf("remove expired resource link, type=%s id=%s expire=%s",
+				apisecurity.ResourceType(res.ResType).String(), res.ResID, res.ExpireTime.Format(time.RFC3339)),
+			HappenTime: time.Now(),
+		})
+	}
+}
+
+func (job *cleanExpiredStrategyResourceJob) interval() time.Duration {
+	return time.Minute
+}
+
+func (job *cleanExpiredStrategyResourceJob) clear() {
+}
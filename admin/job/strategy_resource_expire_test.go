@@ -0,0 +1,98 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package job
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	apisecurity "github.com/polarismesh/specification/source/go/api/v1/security"
+
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/store/mock"
+)
+
+func Test_CleanExpiredStrategyResourceJobExecute(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	storage := mock.NewMockStore(ctrl)
+	history := &recordCollector{}
+	job := cleanExpiredStrategyResourceJob{storage: storage, history: history}
+	if err := job.init(map[string]interface{}{"batchSize": 10}); err != nil {
+		t.Fatal(err)
+	}
+
+	expired := []model.StrategyResource{
+		{
+			StrategyID: "strategy-1",
+			ResType:    int32(apisecurity.ResourceType_Services),
+			ResID:      "service-1",
+			ExpireTime: time.Now().Add(-time.Minute),
+		},
+		{
+			StrategyID: "strategy-2",
+			ResType:    int32(apisecurity.ResourceType_Namespaces),
+			ResID:      "namespace-1",
+			ExpireTime: time.Now().Add(-time.Hour),
+		},
+	}
+	storage.EXPECT().RemoveExpiredStrategyResources(gomock.Any(), uint32(10)).Return(expired, nil)
+	storage.EXPECT().RemoveStrategyResources(gomock.Any()).Times(0)
+
+	job.execute()
+
+	if len(history.entries) != len(expired) {
+		t.Fatalf("each removed link should be recorded, actual: %d", len(history.entries))
+	}
+	for i, entry := range history.entries {
+		if entry.ResourceType != model.RAuthStrategy || entry.OperationType != model.ODelete ||
+			entry.ResourceName != expired[i].StrategyID {
+			t.Errorf("unexpect remove record: %s", entry.String())
+		}
+	}
+
+	// 没有过期的关联关系时不做清理
+	storage.EXPECT().RemoveExpiredStrategyResources(gomock.Any(), uint32(10)).Return(nil, nil)
+	job.execute()
+	if len(history.entries) != len(expired) {
+		t.Errorf("no record expected without expired links, actual: %d", len(history.entries))
+	}
+}
+
+func Test_CleanExpiredStrategyResourceJobGracePeriod(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	storage := mock.NewMockStore(ctrl)
+	job := cleanExpiredStrategyResourceJob{storage: storage, history: &recordCollector{}}
+	if err := job.init(map[string]interface{}{"gracePeriod": "10m"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// 只清理过期时间早于宽限期的关联关系
+	storage.EXPECT().RemoveExpiredStrategyResources(gomock.Any(), uint32(1000)).DoAndReturn(
+		func(now time.Time, limit uint32) ([]model.StrategyResource, error) {
+			if delta := time.Since(now); delta < 10*time.Minute || delta > 11*time.Minute {
+				t.Errorf("unexpect expire deadline: %s", now)
+			}
+			return nil, nil
+		})
+	job.execute()
+}
@@ -20,7 +20,10 @@ package admin
 import (
 	"context"
 	"errors"
+	"fmt"
 	"runtime/debug"
+	"sort"
+	"strconv"
 	"time"
 
 	apimodel "github.com/polarismesh/specification/source/go/api/v1/model"
@@ -28,6 +31,7 @@ import (
 	"go.uber.org/zap"
 
 	api "github.com/polarismesh/polaris/common/api/v1"
+	connhook "github.com/polarismesh/polaris/common/conn/hook"
 	connlimit "github.com/polarismesh/polaris/common/conn/limit"
 	commonlog "github.com/polarismesh/polaris/common/log"
 	"github.com/polarismesh/polaris/common/model"
@@ -100,6 +104,61 @@ func (s *Server) GetServerConnStats(_ context.Context, req *ConnReq) (*ConnStats
 	return &resp, nil
 }
 
+func (s *Server) GetServerConnDetails(_ context.Context, req *ConnReq) ([]*ConnDetailsResp, error) {
+	var trackers []*connhook.ConnTracker
+	if req.Protocol != "" {
+		tracker := connhook.GetConnTracker(req.Protocol)
+		if tracker == nil {
+			return nil, errors.New("not found the protocol")
+		}
+		trackers = append(trackers, tracker)
+	} else {
+		trackers = connhook.ListConnTrackers()
+	}
+
+	// 客户端上报的信息只有 host，同一个 host 上可能运行了多个不同版本的 SDK
+	hostVersions := map[string]map[string]struct{}{}
+	s.cacheMgn.Client().IteratorClients(func(_ string, client *model.Client) bool {
+		host := client.Proto().GetHost().GetValue()
+		version := client.Proto().GetVersion().GetValue()
+		if host == "" || version == "" {
+			return true
+		}
+		if _, ok := hostVersions[host]; !ok {
+			hostVersions[host] = map[string]struct{}{}
+		}
+		hostVersions[host][client.Proto().GetType().String()+"/"+version] = struct{}{}
+		return true
+	})
+
+	now := time.Now()
+	ret := make([]*ConnDetailsResp, 0, len(trackers))
+	for _, tracker := range trackers {
+		conns := tracker.Conns(req.Host)
+		resp := &ConnDetailsResp{
+			Protocol:    tracker.Protocol(),
+			Total:       len(conns),
+			Connections: make([]*ConnDetail, 0, len(conns)),
+		}
+		for _, conn := range conns {
+			versions := make([]string, 0, len(hostVersions[conn.Host]))
+			for version := range hostVersions[conn.Host] {
+				versions = append(versions, version)
+			}
+			sort.Strings(versions)
+			resp.Connections = append(resp.Connections, &ConnDetail{
+				Address:           conn.Address,
+				ConnectedAt:       conn.ConnectedAt,
+				ConnectedDuration: now.Sub(conn.ConnectedAt).Truncate(time.Second).String(),
+				Subscriptions:     conn.Subscriptions(),
+				ClientVersions:    versions,
+			})
+		}
+		ret = append(ret, resp)
+	}
+	return ret, nil
+}
+
 func (s *Server) CloseConnections(_ context.Context, reqs []ConnReq) error {
 	for _, entry := range reqs {
 		listener := connlimit.GetLimitListener(entry.Protocol)
@@ -220,3 +279,50 @@ func (svr *Server) GetCMDBInfo(ctx context.Context) ([]model.LocationView, error
 
 	return ret, nil
 }
+
+// GetChangeSetRecords 查询同一个变更集下的全部操作记录
+func (svr *Server) GetChangeSetRecords(_ context.Context, changeSetID string) ([]*model.RecordEntry, error) {
+	if changeSetID == "" {
+		return nil, errors.New("change set id is empty")
+	}
+	return svr.storage.GetRecordEntriesByChangeSet(changeSetID)
+}
+
+// GetAuditRecords 按照操作人、资源类型、操作类型以及时间范围分页查询操作记录，按照发生时间从新到旧返回
+// 时间范围 [start_time, end_time) 为 unix 秒，不设置时不限制
+func (svr *Server) GetAuditRecords(_ context.Context, query map[string]string) (*AuditRecordsResp, error) {
+	offset, limit, err := utils.ParseOffsetAndLimit(query)
+	if err != nil {
+		return nil, err
+	}
+	filter := &model.RecordEntryFilter{
+		Operator:      query["operator"],
+		ResourceType:  model.Resource(query["resource_type"]),
+		OperationType: model.OperationType(query["operation_type"]),
+	}
+	if filter.StartTime, err = parseAuditTime(query["start_time"]); err != nil {
+		return nil, fmt.Errorf("invalid start_time: %w", err)
+	}
+	if filter.EndTime, err = parseAuditTime(query["end_time"]); err != nil {
+		return nil, fmt.Errorf("invalid end_time: %w", err)
+	}
+	if !filter.StartTime.IsZero() && !filter.EndTime.IsZero() && !filter.EndTime.After(filter.StartTime) {
+		return nil, errors.New("end_time must be after start_time")
+	}
+	total, records, err := svr.storage.GetRecordEntries(filter, offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	return &AuditRecordsResp{Total: total, Records: records}, nil
+}
+
+func parseAuditTime(val string) (time.Time, error) {
+	if val == "" {
+		return time.Time{}, nil
+	}
+	sec, err := strconv.ParseInt(val, 10, 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(sec, 0), nil
+}
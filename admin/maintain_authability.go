@@ -25,6 +25,7 @@ import (
 	api "github.com/polarismesh/polaris/common/api/v1"
 	"github.com/polarismesh/polaris/common/model"
 	"github.com/polarismesh/polaris/common/utils"
+	"github.com/polarismesh/polaris/federation"
 )
 
 var _ AdminOperateServer = (*serverAuthAbility)(nil)
@@ -55,6 +56,19 @@ func (svr *serverAuthAbility) GetServerConnStats(ctx context.Context, req *ConnR
 	return svr.targetServer.GetServerConnStats(ctx, req)
 }
 
+func (svr *serverAuthAbility) GetServerConnDetails(ctx context.Context, req *ConnReq) ([]*ConnDetailsResp, error) {
+	authCtx := svr.collectMaintainAuthContext(ctx, model.Read, "GetServerConnDetails")
+	_, err := svr.strategyMgn.GetAuthChecker().CheckConsolePermission(authCtx)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx = authCtx.GetRequestContext()
+	ctx = context.WithValue(ctx, utils.ContextAuthContextKey, authCtx)
+
+	return svr.targetServer.GetServerConnDetails(ctx, req)
+}
+
 func (svr *serverAuthAbility) CloseConnections(ctx context.Context, reqs []ConnReq) error {
 	authCtx := svr.collectMaintainAuthContext(ctx, model.Delete, "CloseConnections")
 	_, err := svr.strategyMgn.GetAuthChecker().CheckConsolePermission(authCtx)
@@ -178,3 +192,88 @@ func (svr *serverAuthAbility) GetCMDBInfo(ctx context.Context) ([]model.Location
 
 	return svr.targetServer.GetCMDBInfo(ctx)
 }
+
+func (svr *serverAuthAbility) GetChangeSetRecords(ctx context.Context,
+	changeSetID string) ([]*model.RecordEntry, error) {
+	authCtx := svr.collectMaintainAuthContext(ctx, model.Read, "GetChangeSetRecords")
+	_, err := svr.strategyMgn.GetAuthChecker().CheckConsolePermission(authCtx)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx = authCtx.GetRequestContext()
+	ctx = context.WithValue(ctx, utils.ContextAuthContextKey, authCtx)
+
+	return svr.targetServer.GetChangeSetRecords(ctx, changeSetID)
+}
+
+// GetAuditRecords 操作记录包含全部资源的变更详情，按照修改操作校验权限，只有主账户可以查询
+func (svr *serverAuthAbility) GetAuditRecords(ctx context.Context,
+	query map[string]string) (*AuditRecordsResp, error) {
+	authCtx := svr.collectMaintainAuthContext(ctx, model.Modify, "GetAuditRecords")
+	_, err := svr.strategyMgn.GetAuthChecker().CheckConsolePermission(authCtx)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx = authCtx.GetRequestContext()
+	ctx = context.WithValue(ctx, utils.ContextAuthContextKey, authCtx)
+
+	return svr.targetServer.GetAuditRecords(ctx, query)
+}
+
+// ExportAuthPolicies 导出的内容可以包含全部用户的凭据，按照修改操作校验权限，只有主账户可以导出
+func (svr *serverAuthAbility) ExportAuthPolicies(ctx context.Context, format string,
+	withCredentials bool) ([]byte, error) {
+	authCtx := svr.collectMaintainAuthContext(ctx, model.Modify, "ExportAuthPolicies")
+	_, err := svr.strategyMgn.GetAuthChecker().CheckConsolePermission(authCtx)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx = authCtx.GetRequestContext()
+	ctx = context.WithValue(ctx, utils.ContextAuthContextKey, authCtx)
+
+	return svr.targetServer.ExportAuthPolicies(ctx, format, withCredentials)
+}
+
+func (svr *serverAuthAbility) ImportAuthPolicies(ctx context.Context, format string,
+	data []byte) (*AuthPolicyImportResp, error) {
+	authCtx := svr.collectMaintainAuthContext(ctx, model.Modify, "ImportAuthPolicies")
+	_, err := svr.strategyMgn.GetAuthChecker().CheckConsolePermission(authCtx)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx = authCtx.GetRequestContext()
+	ctx = context.WithValue(ctx, utils.ContextAuthContextKey, authCtx)
+
+	return svr.targetServer.ImportAuthPolicies(ctx, format, data)
+}
+
+func (svr *serverAuthAbility) GetFederationSnapshot(ctx context.Context) (*federation.Snapshot, error) {
+	authCtx := svr.collectMaintainAuthContext(ctx, model.Read, "GetFederationSnapshot")
+	_, err := svr.strategyMgn.GetAuthChecker().CheckConsolePermission(authCtx)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx = authCtx.GetRequestContext()
+	ctx = context.WithValue(ctx, utils.ContextAuthContextKey, authCtx)
+
+	return svr.targetServer.GetFederationSnapshot(ctx)
+}
+
+func (svr *serverAuthAbility) ApplyFederationSnapshot(ctx context.Context,
+	snapshot *federation.Snapshot) (*federation.ApplyResult, error) {
+	authCtx := svr.collectMaintainAuthContext(ctx, model.Modify, "ApplyFederationSnapshot")
+	_, err := svr.strategyMgn.GetAuthChecker().CheckConsolePermission(authCtx)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx = authCtx.GetRequestContext()
+	ctx = context.WithValue(ctx, utils.ContextAuthContextKey, authCtx)
+
+	return svr.targetServer.ApplyFederationSnapshot(ctx, snapshot)
+}
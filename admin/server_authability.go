@@ -24,6 +24,7 @@ import (
 	apimodel "github.com/polarismesh/specification/source/go/api/v1/model"
 
 	"github.com/polarismesh/polaris/auth"
+	api "github.com/polarismesh/polaris/common/api/v1"
 	"github.com/polarismesh/polaris/common/model"
 )
 
@@ -64,5 +65,9 @@ func convertToErrCode(err error) apimodel.Code {
 		return apimodel.Code_TokenDisabled
 	}
 
+	if errors.Is(err, model.ErrorTokenExpired) {
+		return apimodel.Code(api.TokenExpired)
+	}
+
 	return apimodel.Code_NotAllowedAccess
 }
@@ -0,0 +1,137 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+package consulserver
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/emicklei/go-restful/v3"
+	apiservice "github.com/polarismesh/specification/source/go/api/v1/service_manage"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	api "github.com/polarismesh/polaris/common/api/v1"
+	"github.com/polarismesh/polaris/common/utils"
+)
+
+func (h *ConsulServer) addAgentAccess(ws *restful.WebService) {
+	ws.Route(ws.PUT("/agent/service/register").To(h.RegisterService))
+	ws.Route(ws.PUT("/agent/service/deregister/{service_id}").To(h.DeregisterService))
+	ws.Route(ws.PUT("/agent/check/pass/{check_id}").To(h.PassCheck))
+	ws.Route(ws.GET("/status/leader").To(h.GetLeader))
+}
+
+// RegisterService PUT /v1/agent/service/register 注册服务实例
+func (h *ConsulServer) RegisterService(req *restful.Request, rsp *restful.Response) {
+	reg := &AgentServiceRegistration{}
+	if err := json.NewDecoder(req.Request.Body).Decode(reg); err != nil {
+		consullog.Errorf("[CONSUL] fail to parse register request, client: %s, err: %v",
+			req.Request.RemoteAddr, err)
+		writeError(req, rsp, http.StatusBadRequest, api.ParseException, "Request decode failed: "+err.Error())
+		return
+	}
+	if reg.Name == "" {
+		writeError(req, rsp, http.StatusBadRequest, api.InvalidServiceName, "Missing service name")
+		return
+	}
+	namespace := h.readNamespace(req)
+	if reg.Namespace != "" {
+		namespace = reg.Namespace
+	}
+	ctx := h.requestContext(req)
+	instance := convertRegistration(reg, namespace, h.namespace, utils.ParseClientIP(ctx))
+	consullog.Infof("[CONSUL] received register request, client: %s, namespace: %s, service: %s, id: %s",
+		req.Request.RemoteAddr, namespace, reg.Name, instance.GetId().GetValue())
+
+	code := h.registerInstance(ctx, instance)
+	if code != api.ExecuteSuccess {
+		consullog.Errorf("[CONSUL] register instance fail, service: %s, id: %s, code: %d",
+			reg.Name, instance.GetId().GetValue(), code)
+		writeError(req, rsp, httpStatus(code), code, api.Code2Info(code))
+		return
+	}
+	req.SetAttribute(statusCodeHeader, code)
+	rsp.WriteHeader(http.StatusOK)
+}
+
+// registerInstance 注册实例，服务不存在时先创建服务
+func (h *ConsulServer) registerInstance(ctx context.Context, instance *apiservice.Instance) uint32 {
+	resp := h.namingServer.RegisterInstance(ctx, instance)
+	code := resp.GetCode().GetValue()
+	if code == api.ExecuteSuccess || code == api.ExistedResource || code == api.SameInstanceRequest {
+		return api.ExecuteSuccess
+	}
+	if code != api.NotFoundResource {
+		return code
+	}
+	svcResp := h.namingServer.CreateServices(ctx, []*apiservice.Service{{
+		Namespace: instance.GetNamespace(),
+		Name:      instance.GetService(),
+	}})
+	svcCode := svcResp.GetCode().GetValue()
+	if svcCode != api.ExecuteSuccess && svcCode != api.ExistedResource {
+		return svcCode
+	}
+	resp = h.namingServer.RegisterInstance(ctx, instance)
+	code = resp.GetCode().GetValue()
+	if code == api.ExistedResource || code == api.SameInstanceRequest {
+		return api.ExecuteSuccess
+	}
+	return code
+}
+
+// DeregisterService PUT /v1/agent/service/deregister/:service_id 反注册服务实例
+func (h *ConsulServer) DeregisterService(req *restful.Request, rsp *restful.Response) {
+	serviceID := req.PathParameter("service_id")
+	instanceID := buildInstanceID(h.readNamespace(req), h.namespace, serviceID)
+	consullog.Infof("[CONSUL] received deregister request, client: %s, id: %s", req.Request.RemoteAddr, instanceID)
+
+	resp := h.namingServer.DeregisterInstance(h.requestContext(req),
+		&apiservice.Instance{Id: wrapperspb.String(instanceID)})
+	code := resp.GetCode().GetValue()
+	if code != api.ExecuteSuccess && code != api.NotFoundResource && code != api.NotFoundInstance {
+		writeError(req, rsp, httpStatus(code), code, resp.GetInfo().GetValue())
+		return
+	}
+	req.SetAttribute(statusCodeHeader, code)
+	rsp.WriteHeader(http.StatusOK)
+}
+
+// PassCheck PUT /v1/agent/check/pass/:check_id 上报 TTL 健康检查通过，对应北极星的实例心跳
+func (h *ConsulServer) PassCheck(req *restful.Request, rsp *restful.Response) {
+	serviceID := parseCheckServiceID(req.PathParameter("check_id"))
+	instanceID := buildInstanceID(h.readNamespace(req), h.namespace, serviceID)
+
+	resp := h.healthCheckServer.Report(h.requestContext(req), &apiservice.Instance{Id: wrapperspb.String(instanceID)})
+	code := resp.GetCode().GetValue()
+	// 实例没有开启心跳时，对 consul 来说仍然属于上报成功
+	if code == api.HeartbeatOnDisabledIns {
+		code = api.ExecuteSuccess
+	}
+	if code != api.ExecuteSuccess {
+		writeError(req, rsp, httpStatus(code), code, resp.GetInfo().GetValue())
+		return
+	}
+	req.SetAttribute(statusCodeHeader, code)
+	rsp.WriteHeader(http.StatusOK)
+}
+
+// GetLeader GET /v1/status/leader 北极星没有 leader 的概念，返回当前服务器的监听地址
+func (h *ConsulServer) GetLeader(req *restful.Request, rsp *restful.Response) {
+	writeJSON(req, rsp, api.ExecuteSuccess, req.Request.Host)
+}
@@ -0,0 +1,43 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+package consulserver
+
+import "time"
+
+const (
+	optionListenIP   = "listenIP"
+	optionListenPort = "listenPort"
+	optionNamespace  = "namespace"
+	optionKVGroup    = "kvGroup"
+	optionDatacenter = "datacenter"
+	optionConnLimit  = "connLimit"
+	optionTLS        = "tls"
+)
+
+const (
+	DefaultListenIP   = "0.0.0.0"
+	DefaultListenPort = 8500
+	DefaultNamespace  = "default"
+	// DefaultKVGroup consul KV 存储所使用的配置分组
+	DefaultKVGroup = "consul-kv"
+	// DefaultDatacenter 返回给客户端的数据中心名称
+	DefaultDatacenter = "dc1"
+	// DefaultBlockingWait 阻塞查询未指定 wait 参数时的默认等待时间
+	DefaultBlockingWait = 5 * time.Minute
+	// MaxBlockingWait 阻塞查询的最长等待时间
+	MaxBlockingWait = 10 * time.Minute
+)
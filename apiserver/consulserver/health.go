@@ -0,0 +1,70 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+package consulserver
+
+import (
+	"github.com/emicklei/go-restful/v3"
+
+	api "github.com/polarismesh/polaris/common/api/v1"
+	"github.com/polarismesh/polaris/common/model"
+)
+
+func (h *ConsulServer) addHealthAccess(ws *restful.WebService) {
+	ws.Route(ws.GET("/health/service/{service}").To(h.GetHealthService))
+}
+
+// GetHealthService GET /v1/health/service/:service 查询服务实例以及健康状态
+// 支持 passing 只返回健康实例，tag 按照实例的 tag 过滤，index、wait 阻塞查询
+func (h *ConsulServer) GetHealthService(req *restful.Request, rsp *restful.Response) {
+	namespace := h.readNamespace(req)
+	serviceName := req.PathParameter("service")
+
+	var instances []*model.Instance
+	revisionFunc := func() string {
+		instances = nil
+		svc := h.namingServer.Cache().Service().GetServiceByName(serviceName, namespace)
+		if svc == nil {
+			return ""
+		}
+		instances = h.namingServer.Cache().Instance().GetInstancesByServiceID(svc.ID)
+		revision, err := h.namingServer.GetServiceInstanceRevision(svc.ID, instances)
+		if err != nil {
+			consullog.Errorf("[CONSUL] fail to get revision for service %s, err: %v", serviceName, err)
+		}
+		return revision
+	}
+	index := h.blockingQuery(req.Request.Context(), req, "health/"+namespace+"/"+serviceName, revisionFunc)
+
+	_, passingOnly := req.Request.URL.Query()["passing"]
+	if req.QueryParameter("passing") == "false" {
+		passingOnly = false
+	}
+	tags := req.QueryParameters("tag")
+	entries := make([]*ServiceEntry, 0, len(instances))
+	for i := range instances {
+		entry := buildServiceEntry(instances[i], h.datacenter)
+		if passingOnly && entry.Checks[0].Status != HealthPassing {
+			continue
+		}
+		if !hasTags(entry, tags) {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	writeIndex(rsp, index)
+	writeJSON(req, rsp, api.ExecuteSuccess, entries)
+}
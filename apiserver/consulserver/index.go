@@ -0,0 +1,106 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+package consulserver
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/emicklei/go-restful/v3"
+)
+
+// indexTracker 为每一个查询的资源维护单调递增的索引, 资源的版本号发生变化时索引递增
+// 北极星的资源版本号是字符串，consul 客户端需要可以比较大小的数字索引
+type indexTracker struct {
+	lock    sync.Mutex
+	counter uint64
+	entries map[string]*indexEntry
+}
+
+type indexEntry struct {
+	revision string
+	index    uint64
+}
+
+func newIndexTracker() *indexTracker {
+	// 以启动时间作为初始值，服务端重启后索引不会小于重启前返回给客户端的索引
+	return &indexTracker{
+		counter: uint64(time.Now().UnixMilli()),
+		entries: map[string]*indexEntry{},
+	}
+}
+
+// index 获取资源当前版本对应的索引
+func (t *indexTracker) index(key, revision string) uint64 {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	entry, ok := t.entries[key]
+	if ok && entry.revision == revision {
+		return entry.index
+	}
+	t.counter++
+	t.entries[key] = &indexEntry{revision: revision, index: t.counter}
+	return t.counter
+}
+
+// blockingQuery 请求携带了 index 参数，并且与资源当前的索引一致时，等待资源发生变化或者超时
+// revisionFunc 返回资源当前的版本号，返回值为资源最终的索引
+func (h *ConsulServer) blockingQuery(ctx context.Context, req *restful.Request, key string,
+	revisionFunc func() string) uint64 {
+	current := h.indexes.index(key, revisionFunc())
+	clientIndex, err := strconv.ParseUint(req.QueryParameter("index"), 10, 64)
+	if err != nil || clientIndex == 0 || clientIndex != current {
+		return current
+	}
+	timer := time.NewTimer(parseWait(req.QueryParameter("wait")))
+	defer timer.Stop()
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return current
+		case <-timer.C:
+			return current
+		case <-ticker.C:
+			if index := h.indexes.index(key, revisionFunc()); index != current {
+				return index
+			}
+		}
+	}
+}
+
+// parseWait 解析阻塞查询的等待时间, 例如 10s、5m, 不合法时使用默认值
+func parseWait(wait string) time.Duration {
+	if wait == "" {
+		return DefaultBlockingWait
+	}
+	duration, err := time.ParseDuration(wait)
+	if err != nil || duration <= 0 {
+		return DefaultBlockingWait
+	}
+	if duration > MaxBlockingWait {
+		return MaxBlockingWait
+	}
+	return duration
+}
+
+func writeIndex(rsp *restful.Response, index uint64) {
+	rsp.AddHeader(headerConsulIndex, strconv.FormatUint(index, 10))
+}
@@ -0,0 +1,206 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+package consulserver
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/emicklei/go-restful/v3"
+	apiconfig "github.com/polarismesh/specification/source/go/api/v1/config_manage"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	api "github.com/polarismesh/polaris/common/api/v1"
+)
+
+const (
+	// kvFormat KV 存储的配置文件格式
+	kvFormat = "text"
+)
+
+func (h *ConsulServer) addKVAccess(ws *restful.WebService) {
+	ws.Route(ws.GET("/kv").To(h.GetKV))
+	ws.Route(ws.GET("/kv/{key:*}").To(h.GetKV))
+	ws.Route(ws.PUT("/kv/{key:*}").To(h.PutKV))
+	ws.Route(ws.DELETE("/kv").To(h.DeleteKV))
+	ws.Route(ws.DELETE("/kv/{key:*}").To(h.DeleteKV))
+}
+
+// GetKV GET /v1/kv/:key 读取 KV，对应 kvGroup 配置分组下已发布的配置文件
+// 支持 recurse 按照前缀读取、keys 只返回 key 列表、raw 直接返回 value，以及 index、wait 阻塞查询
+func (h *ConsulServer) GetKV(req *restful.Request, rsp *restful.Response) {
+	ctx := h.requestContext(req)
+	namespace := h.readNamespace(req)
+	key := strings.TrimPrefix(req.PathParameter("key"), "/")
+	query := req.Request.URL.Query()
+	_, recurse := query["recurse"]
+	_, keysOnly := query["keys"]
+	_, raw := query["raw"]
+
+	var files []*apiconfig.ClientConfigFileInfo
+	revisionFunc := func() string {
+		resp := h.configServer.GetConfigFileNamesWithCache(ctx, &apiconfig.ConfigFileGroupRequest{
+			ConfigFileGroup: &apiconfig.ConfigFileGroup{
+				Namespace: wrapperspb.String(namespace),
+				Name:      wrapperspb.String(h.kvGroup),
+			},
+		})
+		files = resp.GetConfigFileInfos()
+		return resp.GetRevision().GetValue()
+	}
+	index := h.blockingQuery(req.Request.Context(), req, "kv/"+namespace+"/"+h.kvGroup, revisionFunc)
+	writeIndex(rsp, index)
+
+	if !recurse && !keysOnly {
+		pair, code := h.getKVPair(ctx, namespace, key)
+		if pair == nil {
+			status := httpStatus(code)
+			if code == api.NotFoundResource {
+				status = http.StatusNotFound
+			}
+			writeError(req, rsp, status, code, "")
+			return
+		}
+		if raw {
+			req.SetAttribute(statusCodeHeader, code)
+			rsp.AddHeader(restful.HEADER_ContentType, restful.MIME_OCTET)
+			_, _ = rsp.Write(pair.Value)
+			return
+		}
+		writeJSON(req, rsp, code, []*KVPair{pair})
+		return
+	}
+
+	names := matchKeys(files, key)
+	if len(names) == 0 {
+		writeError(req, rsp, http.StatusNotFound, api.NotFoundResource, "")
+		return
+	}
+	if keysOnly {
+		writeJSON(req, rsp, api.ExecuteSuccess, names)
+		return
+	}
+	pairs := make([]*KVPair, 0, len(names))
+	for i := range names {
+		if pair, _ := h.getKVPair(ctx, namespace, names[i]); pair != nil {
+			pairs = append(pairs, pair)
+		}
+	}
+	writeJSON(req, rsp, api.ExecuteSuccess, pairs)
+}
+
+// matchKeys 按照前缀匹配配置分组下的配置文件名称, 结果按照名称排序
+func matchKeys(files []*apiconfig.ClientConfigFileInfo, prefix string) []string {
+	names := make([]string, 0, len(files))
+	for i := range files {
+		name := files[i].GetFileName().GetValue()
+		if strings.HasPrefix(name, prefix) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// getKVPair 读取单个 key 已发布的内容，发布版本号作为 consul 的 ModifyIndex
+func (h *ConsulServer) getKVPair(ctx context.Context, namespace, key string) (*KVPair, uint32) {
+	resp := h.configServer.GetConfigFileWithCache(ctx, &apiconfig.ClientConfigFileInfo{
+		Namespace: wrapperspb.String(namespace),
+		Group:     wrapperspb.String(h.kvGroup),
+		FileName:  wrapperspb.String(key),
+	})
+	code := resp.GetCode().GetValue()
+	if code != api.ExecuteSuccess {
+		return nil, code
+	}
+	file := resp.GetConfigFile()
+	version := file.GetVersion().GetValue()
+	return &KVPair{
+		Key:         key,
+		CreateIndex: version,
+		ModifyIndex: version,
+		Value:       []byte(file.GetContent().GetValue()),
+	}, code
+}
+
+// PutKV PUT /v1/kv/:key 写入 KV，创建或者更新配置文件并直接发布
+func (h *ConsulServer) PutKV(req *restful.Request, rsp *restful.Response) {
+	key := strings.TrimPrefix(req.PathParameter("key"), "/")
+	if key == "" {
+		writeError(req, rsp, http.StatusBadRequest, api.InvalidConfigFileName, "Missing key name")
+		return
+	}
+	value, err := io.ReadAll(req.Request.Body)
+	if err != nil {
+		writeError(req, rsp, http.StatusBadRequest, api.ParseException, "Request decode failed: "+err.Error())
+		return
+	}
+	namespace := h.readNamespace(req)
+	resp := h.configServer.UpsertAndReleaseConfigFileFromClient(h.requestContext(req),
+		&apiconfig.ConfigFilePublishInfo{
+			Namespace: wrapperspb.String(namespace),
+			Group:     wrapperspb.String(h.kvGroup),
+			FileName:  wrapperspb.String(key),
+			Content:   wrapperspb.String(string(value)),
+			Format:    wrapperspb.String(kvFormat),
+		})
+	code := resp.GetCode().GetValue()
+	if code != api.ExecuteSuccess {
+		consullog.Errorf("[CONSUL] put kv fail, namespace: %s, key: %s, code: %d, msg: %s",
+			namespace, key, code, resp.GetInfo().GetValue())
+		writeError(req, rsp, httpStatus(code), code, resp.GetInfo().GetValue())
+		return
+	}
+	writeJSON(req, rsp, code, true)
+}
+
+// DeleteKV DELETE /v1/kv/:key 删除 KV, 携带 recurse 时删除前缀匹配的全部 key
+func (h *ConsulServer) DeleteKV(req *restful.Request, rsp *restful.Response) {
+	ctx := h.requestContext(req)
+	namespace := h.readNamespace(req)
+	key := strings.TrimPrefix(req.PathParameter("key"), "/")
+	_, recurse := req.Request.URL.Query()["recurse"]
+
+	keys := []string{key}
+	if recurse {
+		resp := h.configServer.GetConfigFileNamesWithCache(ctx, &apiconfig.ConfigFileGroupRequest{
+			ConfigFileGroup: &apiconfig.ConfigFileGroup{
+				Namespace: wrapperspb.String(namespace),
+				Name:      wrapperspb.String(h.kvGroup),
+			},
+		})
+		keys = matchKeys(resp.GetConfigFileInfos(), key)
+	}
+	for i := range keys {
+		resp := h.configServer.DeleteConfigFileFromClient(ctx, &apiconfig.ConfigFile{
+			Namespace: wrapperspb.String(namespace),
+			Group:     wrapperspb.String(h.kvGroup),
+			Name:      wrapperspb.String(keys[i]),
+		})
+		code := resp.GetCode().GetValue()
+		if code != api.ExecuteSuccess && code != api.NotFoundResource {
+			consullog.Errorf("[CONSUL] delete kv fail, namespace: %s, key: %s, code: %d, msg: %s",
+				namespace, keys[i], code, resp.GetInfo().GetValue())
+			writeError(req, rsp, httpStatus(code), code, resp.GetInfo().GetValue())
+			return
+		}
+	}
+	writeJSON(req, rsp, api.ExecuteSuccess, true)
+}
@@ -0,0 +1,302 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+package consulserver
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	apiservice "github.com/polarismesh/specification/source/go/api/v1/service_manage"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	"github.com/polarismesh/polaris/common/model"
+)
+
+const (
+	MetadataRegisterFrom = "internal-register-from"
+	MetadataServiceID    = "internal-consul-service-id"
+	MetadataTags         = "internal-consul-tags"
+
+	// internalMetadataPrefix 北极星内部使用的元数据前缀，不返回给 consul 客户端
+	internalMetadataPrefix = "internal-"
+
+	// serviceCheckPrefix consul 为注册时携带的健康检查生成的 CheckID 前缀, 例如 service:web-1
+	serviceCheckPrefix = "service:"
+
+	HealthPassing  = "passing"
+	HealthWarning  = "warning"
+	HealthCritical = "critical"
+
+	DefaultWeight = 100
+)
+
+// AgentServiceRegistration consul 注册服务实例的请求
+type AgentServiceRegistration struct {
+	ID        string
+	Name      string
+	Tags      []string
+	Port      int
+	Address   string
+	Meta      map[string]string
+	Weights   *AgentWeights
+	Check     *AgentServiceCheck
+	Checks    []*AgentServiceCheck
+	Namespace string
+}
+
+// AgentWeights 服务实例的权重
+type AgentWeights struct {
+	Passing int
+	Warning int
+}
+
+// AgentServiceCheck 注册服务实例时携带的健康检查，只支持 TTL 类型的检查
+type AgentServiceCheck struct {
+	CheckID  string
+	Name     string
+	TTL      string
+	HTTP     string
+	TCP      string
+	Interval string
+	Status   string
+}
+
+// ServiceEntry /v1/health/service/:service 返回的服务实例
+type ServiceEntry struct {
+	Node    *Node
+	Service *AgentService
+	Checks  []*HealthCheck
+}
+
+// Node 服务实例所在的节点，北极星中没有节点的概念，使用实例的地址表示节点
+type Node struct {
+	ID         string
+	Node       string
+	Address    string
+	Datacenter string
+	Meta       map[string]string
+}
+
+// AgentService 服务实例
+type AgentService struct {
+	ID         string
+	Service    string
+	Tags       []string
+	Address    string
+	Port       int
+	Meta       map[string]string
+	Weights    AgentWeights
+	Namespace  string
+	Datacenter string
+}
+
+// HealthCheck 服务实例的健康检查状态
+type HealthCheck struct {
+	Node        string
+	CheckID     string
+	Name        string
+	Status      string
+	Output      string
+	ServiceID   string
+	ServiceName string
+	ServiceTags []string
+	Type        string
+}
+
+// KVPair consul KV 存储中的一个键值对，Value 按照 base64 编码输出
+type KVPair struct {
+	Key         string
+	CreateIndex uint64
+	ModifyIndex uint64
+	LockIndex   uint64
+	Flags       uint64
+	Value       []byte
+	Session     string
+}
+
+// serviceID 注册请求未携带 ID 时，consul 使用服务名作为实例 ID
+func (r *AgentServiceRegistration) serviceID() string {
+	if r.ID != "" {
+		return r.ID
+	}
+	return r.Name
+}
+
+// ttlCheck 获取注册请求中的 TTL 健康检查，HTTP、TCP 等主动探测类型的检查不支持
+func (r *AgentServiceRegistration) ttlCheck() (time.Duration, bool) {
+	checks := append([]*AgentServiceCheck{r.Check}, r.Checks...)
+	for i := range checks {
+		if checks[i] == nil || checks[i].TTL == "" {
+			continue
+		}
+		ttl, err := time.ParseDuration(checks[i].TTL)
+		if err != nil || ttl < time.Second {
+			continue
+		}
+		return ttl, true
+	}
+	return 0, false
+}
+
+// buildInstanceID 非默认命名空间下的实例 ID 加上命名空间前缀，避免不同命名空间下同名的实例 ID 冲突
+func buildInstanceID(namespace, defaultNamespace, serviceID string) string {
+	if namespace != defaultNamespace {
+		return namespace + ":" + serviceID
+	}
+	return serviceID
+}
+
+// parseCheckServiceID 从 consul 的 CheckID 中解析出实例 ID, 例如 service:web-1 以及 service:web-1:2
+func parseCheckServiceID(checkID string) string {
+	if !strings.HasPrefix(checkID, serviceCheckPrefix) {
+		return checkID
+	}
+	serviceID := strings.TrimPrefix(checkID, serviceCheckPrefix)
+	if idx := strings.LastIndex(serviceID, ":"); idx > 0 {
+		if _, err := strconv.Atoi(serviceID[idx+1:]); err == nil {
+			serviceID = serviceID[:idx]
+		}
+	}
+	return serviceID
+}
+
+// convertRegistration 将 consul 的注册请求转换为北极星的实例
+func convertRegistration(reg *AgentServiceRegistration, namespace, defaultNamespace,
+	clientIP string) *apiservice.Instance {
+	metadata := make(map[string]string, len(reg.Meta)+3)
+	for k, v := range reg.Meta {
+		metadata[k] = v
+	}
+	metadata[MetadataRegisterFrom] = ServerConsul
+	metadata[MetadataServiceID] = reg.serviceID()
+	if len(reg.Tags) > 0 {
+		tags, _ := json.Marshal(reg.Tags)
+		metadata[MetadataTags] = string(tags)
+	}
+	host := reg.Address
+	if host == "" {
+		host = clientIP
+	}
+	weight := uint32(DefaultWeight)
+	if reg.Weights != nil && reg.Weights.Passing > 0 {
+		weight = uint32(reg.Weights.Passing)
+	}
+	instance := &apiservice.Instance{
+		Id:        wrapperspb.String(buildInstanceID(namespace, defaultNamespace, reg.serviceID())),
+		Service:   wrapperspb.String(reg.Name),
+		Namespace: wrapperspb.String(namespace),
+		Host:      wrapperspb.String(host),
+		Port:      wrapperspb.UInt32(uint32(reg.Port)),
+		Weight:    wrapperspb.UInt32(weight),
+		Healthy:   wrapperspb.Bool(true),
+		Isolate:   wrapperspb.Bool(false),
+		Metadata:  metadata,
+	}
+	if ttl, ok := reg.ttlCheck(); ok {
+		instance.EnableHealthCheck = wrapperspb.Bool(true)
+		instance.HealthCheck = &apiservice.HealthCheck{
+			Type:      apiservice.HealthCheck_HEARTBEAT,
+			Heartbeat: &apiservice.HeartbeatHealthCheck{Ttl: wrapperspb.UInt32(uint32(ttl / time.Second))},
+		}
+	}
+	return instance
+}
+
+// instanceTags 获取实例注册时携带的 tags
+func instanceTags(metadata map[string]string) []string {
+	tags := []string{}
+	if raw, ok := metadata[MetadataTags]; ok {
+		_ = json.Unmarshal([]byte(raw), &tags)
+	}
+	return tags
+}
+
+// instanceStatus 隔离以及不健康的实例均视为 critical
+func instanceStatus(instance *model.Instance) string {
+	if instance.Isolate() || !instance.Healthy() {
+		return HealthCritical
+	}
+	return HealthPassing
+}
+
+// buildServiceEntry 将北极星的实例转换为 consul 的服务实例
+func buildServiceEntry(instance *model.Instance, datacenter string) *ServiceEntry {
+	metadata := instance.Metadata()
+	serviceID := instance.ID()
+	if id, ok := metadata[MetadataServiceID]; ok && id != "" {
+		serviceID = id
+	}
+	meta := make(map[string]string, len(metadata))
+	for k, v := range metadata {
+		if !strings.HasPrefix(k, internalMetadataPrefix) {
+			meta[k] = v
+		}
+	}
+	tags := instanceTags(metadata)
+	weight := int(instance.Weight())
+	return &ServiceEntry{
+		Node: &Node{
+			ID:         instance.Host(),
+			Node:       instance.Host(),
+			Address:    instance.Host(),
+			Datacenter: datacenter,
+			Meta:       map[string]string{},
+		},
+		Service: &AgentService{
+			ID:         serviceID,
+			Service:    instance.Service(),
+			Tags:       tags,
+			Address:    instance.Host(),
+			Port:       int(instance.Port()),
+			Meta:       meta,
+			Weights:    AgentWeights{Passing: weight, Warning: weight},
+			Namespace:  instance.Namespace(),
+			Datacenter: datacenter,
+		},
+		Checks: []*HealthCheck{
+			{
+				Node:        instance.Host(),
+				CheckID:     serviceCheckPrefix + serviceID,
+				Name:        "Service '" + instance.Service() + "' check",
+				Status:      instanceStatus(instance),
+				ServiceID:   serviceID,
+				ServiceName: instance.Service(),
+				ServiceTags: tags,
+				Type:        "ttl",
+			},
+		},
+	}
+}
+
+// hasTags 实例是否包含全部指定的 tag
+func hasTags(entry *ServiceEntry, tags []string) bool {
+	for _, tag := range tags {
+		found := false
+		for _, t := range entry.Service.Tags {
+			if t == tag {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
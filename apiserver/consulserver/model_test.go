@@ -0,0 +1,129 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+package consulserver
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	apiconfig "github.com/polarismesh/specification/source/go/api/v1/config_manage"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	"github.com/polarismesh/polaris/common/model"
+)
+
+func Test_convertRegistration(t *testing.T) {
+	reg := &AgentServiceRegistration{}
+	err := json.Unmarshal([]byte(`{
+		"ID": "web-1",
+		"Name": "web",
+		"Tags": ["primary", "v1"],
+		"Port": 8080,
+		"Meta": {"env": "prod"},
+		"Check": {"TTL": "15s"}
+	}`), reg)
+	assert.NoError(t, err)
+
+	t.Run("默认命名空间下注册", func(t *testing.T) {
+		instance := convertRegistration(reg, DefaultNamespace, DefaultNamespace, "10.0.0.1")
+		assert.Equal(t, "web-1", instance.GetId().GetValue())
+		assert.Equal(t, "web", instance.GetService().GetValue())
+		// 未携带地址时使用客户端的地址
+		assert.Equal(t, "10.0.0.1", instance.GetHost().GetValue())
+		assert.Equal(t, uint32(8080), instance.GetPort().GetValue())
+		assert.Equal(t, uint32(DefaultWeight), instance.GetWeight().GetValue())
+		assert.Equal(t, "prod", instance.GetMetadata()["env"])
+		assert.Equal(t, ServerConsul, instance.GetMetadata()[MetadataRegisterFrom])
+		assert.True(t, instance.GetEnableHealthCheck().GetValue())
+		assert.Equal(t, uint32(15), instance.GetHealthCheck().GetHeartbeat().GetTtl().GetValue())
+	})
+
+	t.Run("非默认命名空间下实例 ID 带上命名空间前缀", func(t *testing.T) {
+		instance := convertRegistration(reg, "prod", DefaultNamespace, "10.0.0.1")
+		assert.Equal(t, "prod:web-1", instance.GetId().GetValue())
+		assert.Equal(t, "prod", instance.GetNamespace().GetValue())
+	})
+
+	t.Run("没有 TTL 健康检查时不开启心跳", func(t *testing.T) {
+		instance := convertRegistration(&AgentServiceRegistration{
+			Name:    "web",
+			Address: "10.0.0.2",
+			Check:   &AgentServiceCheck{HTTP: "http://10.0.0.2/health", Interval: "10s"},
+		}, DefaultNamespace, DefaultNamespace, "10.0.0.1")
+		assert.Equal(t, "web", instance.GetId().GetValue())
+		assert.Equal(t, "10.0.0.2", instance.GetHost().GetValue())
+		assert.False(t, instance.GetEnableHealthCheck().GetValue())
+	})
+}
+
+func Test_buildServiceEntry(t *testing.T) {
+	reg := &AgentServiceRegistration{ID: "web-1", Name: "web", Tags: []string{"primary"}, Port: 8080,
+		Meta: map[string]string{"env": "prod"}}
+	proto := convertRegistration(reg, "prod", DefaultNamespace, "10.0.0.1")
+	instance := &model.Instance{Proto: proto}
+
+	entry := buildServiceEntry(instance, DefaultDatacenter)
+	// 返回给客户端的是注册时的实例 ID，并且不包含内部使用的元数据
+	assert.Equal(t, "web-1", entry.Service.ID)
+	assert.Equal(t, []string{"primary"}, entry.Service.Tags)
+	assert.Equal(t, map[string]string{"env": "prod"}, entry.Service.Meta)
+	assert.Equal(t, "10.0.0.1", entry.Node.Address)
+	assert.Equal(t, HealthPassing, entry.Checks[0].Status)
+	assert.Equal(t, "service:web-1", entry.Checks[0].CheckID)
+	assert.True(t, hasTags(entry, []string{"primary"}))
+	assert.False(t, hasTags(entry, []string{"primary", "v2"}))
+
+	proto.Isolate = wrapperspb.Bool(true)
+	assert.Equal(t, HealthCritical, buildServiceEntry(instance, DefaultDatacenter).Checks[0].Status)
+}
+
+func Test_parseCheckServiceID(t *testing.T) {
+	assert.Equal(t, "web-1", parseCheckServiceID("service:web-1"))
+	assert.Equal(t, "web-1", parseCheckServiceID("service:web-1:2"))
+	assert.Equal(t, "web-1", parseCheckServiceID("web-1"))
+}
+
+func Test_indexTracker(t *testing.T) {
+	tracker := newIndexTracker()
+	first := tracker.index("health/default/web", "r1")
+	assert.Equal(t, first, tracker.index("health/default/web", "r1"))
+	second := tracker.index("health/default/web", "r2")
+	assert.Greater(t, second, first)
+	// 不同资源的索引相互独立
+	assert.Greater(t, tracker.index("kv/default/consul-kv", "r1"), second)
+	assert.Equal(t, second, tracker.index("health/default/web", "r2"))
+}
+
+func Test_parseWait(t *testing.T) {
+	assert.Equal(t, DefaultBlockingWait, parseWait(""))
+	assert.Equal(t, DefaultBlockingWait, parseWait("abc"))
+	assert.Equal(t, 10*time.Second, parseWait("10s"))
+	assert.Equal(t, MaxBlockingWait, parseWait("1h"))
+}
+
+func Test_matchKeys(t *testing.T) {
+	files := []*apiconfig.ClientConfigFileInfo{
+		{FileName: wrapperspb.String("app/db/url")},
+		{FileName: wrapperspb.String("app/cache")},
+		{FileName: wrapperspb.String("other")},
+	}
+	assert.Equal(t, []string{"app/cache", "app/db/url"}, matchKeys(files, "app/"))
+	assert.Equal(t, []string{"app/cache", "app/db/url", "other"}, matchKeys(files, ""))
+	assert.Empty(t, matchKeys(files, "none"))
+}
@@ -0,0 +1,378 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+package consulserver
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/emicklei/go-restful/v3"
+	"go.uber.org/zap"
+
+	"github.com/polarismesh/polaris/apiserver"
+	"github.com/polarismesh/polaris/common/conn/keepalive"
+	connlimit "github.com/polarismesh/polaris/common/conn/limit"
+	"github.com/polarismesh/polaris/common/metrics"
+	"github.com/polarismesh/polaris/common/secure"
+	"github.com/polarismesh/polaris/common/utils"
+	"github.com/polarismesh/polaris/config"
+	"github.com/polarismesh/polaris/plugin"
+	"github.com/polarismesh/polaris/service"
+	"github.com/polarismesh/polaris/service/healthcheck"
+)
+
+const (
+	ServerConsul = "consul"
+
+	// headerConsulIndex 阻塞查询使用的索引
+	headerConsulIndex = "X-Consul-Index"
+	// headerConsulToken consul 客户端携带 ACL token 的请求头
+	headerConsulToken = "X-Consul-Token"
+	// headerConsulKnownLeader 以及 headerConsulLastContact 客户端解析查询元数据时使用
+	headerConsulKnownLeader = "X-Consul-Knownleader"
+	headerConsulLastContact = "X-Consul-Lastcontact"
+
+	statusCodeHeader = utils.PolarisCode
+)
+
+// ConsulServer 兼容 consul HTTP API 的服务器，服务注册发现对接 naming server，KV 对接 config server
+type ConsulServer struct {
+	server            *http.Server
+	namingServer      service.DiscoverServer
+	originDiscoverSvr service.DiscoverServer
+	healthCheckServer *healthcheck.Server
+	configServer      config.ConfigCenterServer
+	connLimitConfig   *connlimit.Config
+	tlsInfo           *secure.TLSInfo
+	option            map[string]interface{}
+	openAPI           map[string]apiserver.APIConfig
+	listenPort        uint32
+	listenIP          string
+	exitCh            chan struct{}
+	start             bool
+	restart           bool
+	statis            plugin.Statis
+	namespace         string
+	kvGroup           string
+	datacenter        string
+	indexes           *indexTracker
+}
+
+// GetPort 获取端口
+func (h *ConsulServer) GetPort() uint32 {
+	return h.listenPort
+}
+
+// GetProtocol 获取协议
+func (h *ConsulServer) GetProtocol() string {
+	return ServerConsul
+}
+
+// Initialize 初始化 consul API 服务器
+func (h *ConsulServer) Initialize(ctx context.Context, option map[string]interface{},
+	api map[string]apiserver.APIConfig) error {
+	h.listenIP = DefaultListenIP
+	if ipValue, ok := option[optionListenIP].(string); ok && ipValue != "" {
+		h.listenIP = ipValue
+	}
+	h.listenPort = uint32(DefaultListenPort)
+	if portValue, ok := option[optionListenPort].(int); ok {
+		h.listenPort = uint32(portValue)
+	}
+	h.option = option
+	h.openAPI = api
+	h.namespace = stringOption(option, optionNamespace, DefaultNamespace)
+	h.kvGroup = stringOption(option, optionKVGroup, DefaultKVGroup)
+	h.datacenter = stringOption(option, optionDatacenter, DefaultDatacenter)
+	h.indexes = newIndexTracker()
+
+	// 连接数限制的配置
+	if raw, _ := option[optionConnLimit].(map[interface{}]interface{}); raw != nil {
+		connLimitConfig, err := connlimit.ParseConnLimitConfig(raw)
+		if err != nil {
+			return err
+		}
+		h.connLimitConfig = connLimitConfig
+	}
+	if raw, _ := option[optionTLS].(map[interface{}]interface{}); raw != nil {
+		tlsConfig, err := secure.ParseTLSConfig(raw)
+		if err != nil {
+			return err
+		}
+		h.tlsInfo = &secure.TLSInfo{
+			CertFile:      tlsConfig.CertFile,
+			KeyFile:       tlsConfig.KeyFile,
+			TrustedCAFile: tlsConfig.TrustedCAFile,
+		}
+	}
+	consullog.Infof("[CONSUL] namespace: %s, kv group: %s, datacenter: %s", h.namespace, h.kvGroup, h.datacenter)
+	return nil
+}
+
+func stringOption(option map[string]interface{}, key, defaultValue string) string {
+	if value, ok := option[key].(string); ok && value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+// Run 启动 consul API 服务器
+func (h *ConsulServer) Run(errCh chan error) {
+	consullog.Infof("start ConsulServer")
+	h.exitCh = make(chan struct{})
+	h.start = true
+	defer func() {
+		close(h.exitCh)
+		h.start = false
+	}()
+	var err error
+	// 引入功能模块和插件
+	h.namingServer, err = service.GetServer()
+	if err != nil {
+		consullog.Errorf("%v", err)
+		errCh <- err
+		return
+	}
+	h.originDiscoverSvr, err = service.GetOriginServer()
+	if err != nil {
+		consullog.Errorf("%v", err)
+		errCh <- err
+		return
+	}
+	h.healthCheckServer, err = healthcheck.GetServer()
+	if err != nil {
+		consullog.Errorf("%v", err)
+		errCh <- err
+		return
+	}
+	h.configServer, err = config.GetServer()
+	if err != nil {
+		consullog.Errorf("%v", err)
+		errCh <- err
+		return
+	}
+	h.statis = plugin.GetStatis()
+	address := fmt.Sprintf("%v:%v", h.listenIP, h.listenPort)
+
+	// 阻塞查询最长会等待 MaxBlockingWait，写超时需要大于该时间
+	server := http.Server{
+		Addr:         address,
+		Handler:      h.createRestfulContainer(),
+		WriteTimeout: MaxBlockingWait + time.Minute,
+	}
+
+	ln, err := net.Listen("tcp", address)
+	if err != nil {
+		consullog.Errorf("net listen(%s) err: %s", address, err.Error())
+		errCh <- err
+		return
+	}
+	ln = keepalive.NewTcpKeepAliveListener(3*time.Minute, ln.(*net.TCPListener))
+	// 开启最大连接数限制
+	if h.connLimitConfig != nil && h.connLimitConfig.OpenConnLimit {
+		consullog.Infof("http server use max connection limit per ip: %d, http max limit: %d",
+			h.connLimitConfig.MaxConnPerHost, h.connLimitConfig.MaxConnLimit)
+		ln, err = connlimit.NewListener(ln, h.GetProtocol(), h.connLimitConfig)
+		if err != nil {
+			consullog.Errorf("conn limit init err: %s", err.Error())
+			errCh <- err
+			return
+		}
+	}
+	h.server = &server
+
+	// 开始对外服务
+	if h.tlsInfo.IsEmpty() {
+		err = server.Serve(ln)
+	} else {
+		err = server.ServeTLS(ln, h.tlsInfo.CertFile, h.tlsInfo.KeyFile)
+	}
+	if err != nil && err != http.ErrServerClosed {
+		consullog.Errorf("%+v", err)
+		if !h.restart {
+			consullog.Infof("not in restart progress, broadcast error")
+			errCh <- err
+		}
+		return
+	}
+	consullog.Infof("ConsulServer stop")
+}
+
+// createRestfulContainer 创建handler
+func (h *ConsulServer) createRestfulContainer() *restful.Container {
+	wsContainer := restful.NewContainer()
+	wsContainer.Filter(h.process)
+	wsContainer.Add(h.GetConsulV1Server())
+	wsContainer.RecoverHandler(h.recoverFunc)
+	return wsContainer
+}
+
+// GetConsulV1Server consul v1 web server
+func (h *ConsulServer) GetConsulV1Server() *restful.WebService {
+	ws := new(restful.WebService)
+	ws.Path("/v1").Consumes(restful.MIME_JSON, restful.MIME_OCTET, "text/plain").Produces(restful.MIME_JSON)
+	h.addAgentAccess(ws)
+	h.addHealthAccess(ws)
+	h.addKVAccess(ws)
+	return ws
+}
+
+func (h *ConsulServer) recoverFunc(i interface{}, w http.ResponseWriter) {
+	consullog.Errorf("panic %+v", i)
+	w.WriteHeader(http.StatusInternalServerError)
+	w.Header().Add(restful.HEADER_ContentType, restful.MIME_JSON)
+}
+
+// process 在接收和回复时统一处理请求
+func (h *ConsulServer) process(req *restful.Request, rsp *restful.Response, chain *restful.FilterChain) {
+	req.SetAttribute("start-time", time.Now())
+	if req.Request.Method != http.MethodGet {
+		accesslog.Info("receive request",
+			zap.String("client-address", req.Request.RemoteAddr),
+			zap.String("user-agent", req.HeaderParameter("User-Agent")),
+			zap.String("method", req.Request.Method),
+			zap.String("url", req.Request.URL.String()),
+		)
+	}
+	// consul 客户端解析查询元数据时依赖以下响应头
+	rsp.AddHeader(headerConsulKnownLeader, "true")
+	rsp.AddHeader(headerConsulLastContact, "0")
+	chain.ProcessFilter(req, rsp)
+	h.postprocess(req, rsp)
+}
+
+// postprocess 请求后处理：统计
+func (h *ConsulServer) postprocess(req *restful.Request, rsp *restful.Response) {
+	startTime, _ := req.Attribute("start-time").(time.Time)
+	diff := time.Since(startTime)
+	code, ok := req.Attribute(statusCodeHeader).(uint32)
+	if !ok {
+		code = uint32(rsp.StatusCode())
+	}
+	if h.statis != nil {
+		h.statis.ReportCallMetrics(metrics.CallMetric{
+			API:      getConsulApi(req),
+			Protocol: "HTTP",
+			Code:     int(code),
+			Duration: diff,
+		})
+	}
+}
+
+// getConsulApi 聚合 consul 接口，不暴露服务名、实例 id 以及 KV 的 key
+func getConsulApi(req *restful.Request) string {
+	path := req.SelectedRoutePath()
+	if path == "" {
+		path = strings.TrimSuffix(req.Request.URL.Path, "/")
+	}
+	return req.Request.Method + ":" + path
+}
+
+// Stop 结束 consul API 服务器
+func (h *ConsulServer) Stop() {
+	// 释放connLimit的数据，如果没有开启，也需要执行一下
+	// 目的：防止restart的时候，connLimit冲突
+	connlimit.RemoveLimitListener(h.GetProtocol())
+	if h.server != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+		defer cancel()
+		if err := h.server.Shutdown(ctx); nil != err {
+			consullog.Errorf("ConsulServer shutdown failed, err: %v\n", err)
+		}
+	}
+}
+
+// Restart 重启 consul API 服务器
+func (h *ConsulServer) Restart(
+	option map[string]interface{}, api map[string]apiserver.APIConfig, errCh chan error) error {
+	consullog.Infof("restart consul server new config: %+v", option)
+	backupOption := h.option
+	backupAPI := h.openAPI
+
+	// 设置restart标记，防止stop的时候把错误抛出
+	h.restart = true
+	h.Stop()
+	if h.start {
+		<-h.exitCh
+	}
+
+	if err := h.Initialize(context.Background(), option, api); err != nil {
+		h.restart = false
+		if initErr := h.Initialize(context.Background(), backupOption, backupAPI); initErr != nil {
+			consullog.Errorf("start consul server with backup cfg err: %s", initErr.Error())
+			return initErr
+		}
+		go h.Run(errCh)
+
+		consullog.Errorf("restart consul server initialize err: %s", err.Error())
+		return err
+	}
+
+	consullog.Infof("init consul server successfully, restart it")
+	h.restart = false
+	go h.Run(errCh)
+	return nil
+}
+
+// requestContext 构建请求上下文，token 依次从 X-Consul-Token、Authorization 以及 token 参数中获取
+func (h *ConsulServer) requestContext(req *restful.Request) context.Context {
+	token := req.HeaderParameter(headerConsulToken)
+	if token == "" {
+		token = strings.TrimPrefix(req.HeaderParameter("Authorization"), "Bearer ")
+	}
+	if token == "" {
+		token = req.QueryParameter("token")
+	}
+	ctx := context.WithValue(context.Background(), utils.ContextAuthTokenKey, token)
+	ctx = context.WithValue(ctx, utils.ContextClientAddress, req.Request.RemoteAddr)
+	ctx = context.WithValue(ctx, utils.StringContext("request-id"), req.HeaderParameter("Request-Id"))
+	ctx = context.WithValue(ctx, utils.StringContext("user-agent"), req.HeaderParameter("User-Agent"))
+	return ctx
+}
+
+// readNamespace 请求携带 ns 参数时使用指定的命名空间
+func (h *ConsulServer) readNamespace(req *restful.Request) string {
+	if ns := req.QueryParameter("ns"); ns != "" {
+		return ns
+	}
+	return h.namespace
+}
+
+// writeError 以纯文本的形式返回错误信息，与 consul 保持一致
+func writeError(req *restful.Request, rsp *restful.Response, status int, code uint32, msg string) {
+	req.SetAttribute(statusCodeHeader, code)
+	rsp.AddHeader(restful.HEADER_ContentType, "text/plain; charset=utf-8")
+	rsp.WriteHeader(status)
+	_, _ = rsp.Write([]byte(msg))
+}
+
+// writeJSON 返回 JSON 格式的结果
+func writeJSON(req *restful.Request, rsp *restful.Response, code uint32, value interface{}) {
+	req.SetAttribute(statusCodeHeader, code)
+	if err := rsp.WriteAsJson(value); err != nil {
+		consullog.Errorf("[CONSUL] write response err: %v", err)
+	}
+}
+
+// httpStatus 北极星返回码的前三位即为对应的 HTTP 状态码
+func httpStatus(code uint32) int {
+	return int(code / 1000)
+}
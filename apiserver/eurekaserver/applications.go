@@ -370,6 +370,7 @@ func buildInstance(appName string, instance *apiservice.Instance, lastModifyTime
 		instanceInfo.HostName = instance.GetHost().GetValue()
 	}
 	buildLocationInfo(instanceInfo, instance)
+	buildDataCenterMetadata(instanceInfo, instance)
 	instanceInfo.LastUpdatedTimestamp = strconv.Itoa(int(lastModifyTime))
 	instanceInfo.ActionType = ActionAdded
 	return instanceInfo
@@ -417,6 +418,29 @@ func buildLocationInfo(instanceInfo *InstanceInfo, instance *apiservice.Instance
 	}
 }
 
+// buildDataCenterMetadata 还原注册时上报的数据中心元数据，Amazon 数据中心补充实例所在的可用区
+func buildDataCenterMetadata(instanceInfo *InstanceInfo, instance *apiservice.Instance) {
+	meta := make(map[string]interface{})
+	for metaKey, metaValue := range instance.GetMetadata() {
+		if strings.HasPrefix(metaKey, MetadataDataCenterInfoMeta) {
+			meta[metaKey[len(MetadataDataCenterInfoMeta):]] = metaValue
+		}
+	}
+	zone := instance.GetLocation().GetZone().GetValue()
+	if _, ok := meta[keyAvailabilityZone]; !ok && len(zone) > 0 && instanceInfo.DataCenterInfo.Name == AmazonDciName {
+		meta[keyAvailabilityZone] = zone
+	}
+	if len(meta) == 0 {
+		return
+	}
+	// DataCenterInfo 可能是共享的默认对象，这里需要拷贝一份
+	instanceInfo.DataCenterInfo = &DataCenterInfo{
+		Clazz:    instanceInfo.DataCenterInfo.Clazz,
+		Name:     instanceInfo.DataCenterInfo.Name,
+		Metadata: &Metadata{Meta: meta},
+	}
+}
+
 func newApplications() *Applications {
 	return &Applications{
 		ApplicationMap: make(map[string]*Application),
@@ -265,3 +265,57 @@ func TestBuildInstance(t *testing.T) {
 	instanceInfo := buildInstance(svc.Name, instance.Proto, 123345550)
 	assert.Equal(t, CustomEurekaParameters[CustomKeyDciClass], instanceInfo.DataCenterInfo.Clazz)
 }
+
+// TestBuildAmazonAvailabilityZone 测试 Amazon 数据中心的可用区与北极星实例 zone 的映射
+func TestBuildAmazonAvailabilityZone(t *testing.T) {
+	instanceInfo := &InstanceInfo{
+		InstanceId: "amazon-inst",
+		AppName:    "AMAZON-APP",
+		IpAddr:     "127.0.0.1",
+		Port:       &PortWrapper{Port: "8080", Enabled: "true"},
+		DataCenterInfo: &DataCenterInfo{
+			Clazz:    "com.netflix.appinfo.AmazonInfo",
+			Name:     AmazonDciName,
+			Metadata: &Metadata{Meta: map[string]interface{}{keyAvailabilityZone: "us-east-1a"}},
+		},
+	}
+	target := buildBaseInstance(instanceInfo, "default", "default", "AMAZON-APP", false)
+	assert.Equal(t, "us-east-1a", target.GetLocation().GetZone().GetValue())
+	assert.Equal(t, "us-east-1a", target.GetMetadata()[MetadataDataCenterInfoMeta+keyAvailabilityZone])
+
+	// 元数据中指定的 zone 优先
+	instanceInfo.Metadata = &Metadata{Meta: map[string]interface{}{keyZone: "us-east-1b"}}
+	target = buildBaseInstance(instanceInfo, "default", "default", "AMAZON-APP", false)
+	assert.Equal(t, "us-east-1b", target.GetLocation().GetZone().GetValue())
+
+	// 北极星实例的 zone 映射为 Amazon 数据中心的可用区以及元数据中的 zone
+	target.Metadata = map[string]string{
+		MetadataDataCenterInfoClazz: "com.netflix.appinfo.AmazonInfo",
+		MetadataDataCenterInfoName:  AmazonDciName,
+	}
+	info := buildInstance("AMAZON-APP", target, 123345550)
+	assert.Equal(t, "us-east-1b", info.DataCenterInfo.Metadata.Meta[keyAvailabilityZone])
+	assert.Equal(t, "us-east-1b", info.Metadata.Meta[keyZone])
+
+	// 非 Amazon 数据中心不返回数据中心的元数据
+	target.Metadata = map[string]string{}
+	info = buildInstance("AMAZON-APP", target, 123345550)
+	assert.Nil(t, info.DataCenterInfo.Metadata)
+	assert.Equal(t, "us-east-1b", info.Metadata.Meta[keyZone])
+}
+
+// TestInstanceInfo_Equals 测试基于实例版本号判断实例是否发生变更
+func TestInstanceInfo_Equals(t *testing.T) {
+	oldInstance := &InstanceInfo{InstanceId: "inst", Status: StatusUp,
+		RealInstance: &apiservice.Instance{Revision: &wrappers.StringValue{Value: "1"}}}
+	newInstance := &InstanceInfo{InstanceId: "inst", Status: StatusUp,
+		RealInstance: &apiservice.Instance{Revision: &wrappers.StringValue{Value: "2"}}}
+	assert.False(t, oldInstance.Equals(newInstance))
+	assert.True(t, newInstance.Clone(ActionModified).Equals(newInstance))
+
+	// 没有版本号时比较状态
+	oldInstance = &InstanceInfo{InstanceId: "inst", Status: StatusUp}
+	newInstance = &InstanceInfo{InstanceId: "inst", Status: StatusOutOfService}
+	assert.False(t, oldInstance.Equals(newInstance))
+	assert.True(t, oldInstance.Equals(oldInstance.Clone(ActionModified)))
+}
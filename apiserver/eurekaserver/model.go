@@ -107,6 +107,9 @@ type DataCenterInfo struct {
 	Clazz string `json:"@class" xml:"class,attr"`
 
 	Name string `json:"name" xml:"name"`
+
+	// Amazon 数据中心的元数据，例如 availability-zone
+	Metadata *Metadata `json:"metadata,omitempty" xml:"metadata,omitempty"`
 }
 
 // LeaseInfo 租约信息
@@ -327,12 +330,20 @@ func (i *InstanceInfo) Clone(actionType string) *InstanceInfo {
 		LastUpdatedTimestamp:          i.LastUpdatedTimestamp,
 		LastDirtyTimestamp:            i.LastDirtyTimestamp,
 		ActionType:                    actionType,
+		RealInstance:                  i.RealInstance,
 	}
 }
 
 // Equals 判断实例是否发生变更
+// 没有北极星实例版本号时，比较 eureka 客户端可见的状态以及更新时间
 func (i *InstanceInfo) Equals(another *InstanceInfo) bool {
-	return i.RealInstance.GetRevision().GetValue() == another.RealInstance.GetRevision().GetValue()
+	revision := i.RealInstance.GetRevision().GetValue()
+	anotherRevision := another.RealInstance.GetRevision().GetValue()
+	if len(revision) > 0 || len(anotherRevision) > 0 {
+		return revision == anotherRevision
+	}
+	return i.Status == another.Status && i.OverriddenStatus == another.OverriddenStatus &&
+		ObjectToString(i.LastUpdatedTimestamp) == ObjectToString(another.LastUpdatedTimestamp)
 }
 
 // Application 服务数据
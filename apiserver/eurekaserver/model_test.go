@@ -18,6 +18,7 @@
 package eurekaserver
 
 import (
+	"encoding/json"
 	"encoding/xml"
 	"fmt"
 	"strings"
@@ -115,3 +116,37 @@ func TestInstanceInfo_UnmarshalXML(t *testing.T) {
 	}
 	fmt.Printf("xml values is %s\n", builder.String())
 }
+
+// TestDataCenterInfo_Metadata 测试 Amazon 数据中心元数据的序列化
+func TestDataCenterInfo_Metadata(t *testing.T) {
+	xmlStr := "<dataCenterInfo class=\"com.netflix.appinfo.AmazonInfo\"><name>Amazon</name>" +
+		"<metadata><availability-zone>us-east-1a</availability-zone></metadata></dataCenterInfo>"
+	dci := &DataCenterInfo{}
+	if err := xml.Unmarshal([]byte(xmlStr), dci); err != nil {
+		t.Fatal(err)
+	}
+	if dci.Metadata == nil || dci.Metadata.Meta[keyAvailabilityZone] != "us-east-1a" {
+		t.Fatalf("unexpected data center metadata %+v", dci.Metadata)
+	}
+
+	jsonStr := `{"@class":"com.netflix.appinfo.AmazonInfo","name":"Amazon",` +
+		`"metadata":{"availability-zone":"us-east-1a"}}`
+	dci = &DataCenterInfo{}
+	if err := json.Unmarshal([]byte(jsonStr), dci); err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(dci)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != jsonStr {
+		t.Fatalf("unexpected data center json %s", string(data))
+	}
+	data, err = json.Marshal(DefaultDataCenterInfo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "metadata") {
+		t.Fatalf("unexpected default data center json %s", string(data))
+	}
+}
@@ -51,6 +51,7 @@ const (
 	MetadataCountryId           = "internal-eureka-country-id"
 	MetadataDataCenterInfoClazz = "internal-eureka-dci-clazz"
 	MetadataDataCenterInfoName  = "internal-eureka-dci-name"
+	MetadataDataCenterInfoMeta  = "internal-eureka-dci-meta-"
 	MetadataHostName            = "internal-eureka-hostname"
 	MetadataRenewalInterval     = "internal-eureka-renewal-interval"
 	MetadataDuration            = "internal-eureka-duration"
@@ -75,6 +76,9 @@ const (
 	keyZone   = "zone"
 	keyCampus = "campus"
 
+	// keyAvailabilityZone Amazon 数据中心元数据中的可用区，与北极星实例的 zone 对应
+	keyAvailabilityZone = "availability-zone"
+
 	StatusOutOfService = "OUT_OF_SERVICE"
 	StatusUp           = "UP"
 	StatusDown         = "DOWN"
@@ -87,6 +91,7 @@ const (
 	DefaultCountryIdInt            = 1
 	DefaultDciClazz                = "com.netflix.appinfo.InstanceInfo$DefaultDataCenterInfo"
 	DefaultDciName                 = "MyOwn"
+	AmazonDciName                  = "Amazon"
 	DefaultRenewInterval           = 30
 	DefaultDuration                = 90
 	DefaultUnhealthyExpireInterval = 180
@@ -81,6 +81,11 @@ func buildBaseInstance(
 		if DefaultDciName != instance.DataCenterInfo.Name {
 			eurekaMetadata[MetadataDataCenterInfoName] = instance.DataCenterInfo.Name
 		}
+		if instance.DataCenterInfo.Metadata != nil {
+			for k, v := range instance.DataCenterInfo.Metadata.Meta {
+				eurekaMetadata[MetadataDataCenterInfoMeta+k] = ObjectToString(v)
+			}
+		}
 	}
 	if len(instance.HostName) > 0 {
 		eurekaMetadata[MetadataHostName] = instance.HostName
@@ -123,6 +128,14 @@ func buildBaseInstance(
 			targetInstance.Metadata[k] = strValue
 		}
 	}
+	// 元数据中没有指定 zone 时，使用 Amazon 数据中心的可用区，便于 ribbon 按照可用区就近访问
+	if zone := eurekaMetadata[MetadataDataCenterInfoMeta+keyAvailabilityZone]; len(zone) > 0 &&
+		len(targetInstance.GetLocation().GetZone().GetValue()) == 0 {
+		if targetInstance.Location == nil {
+			targetInstance.Location = &apimodel.Location{}
+		}
+		targetInstance.Location.Zone = &wrappers.StringValue{Value: zone}
+	}
 	targetInstance.Weight = &wrappers.UInt32Value{Value: 100}
 	buildHealthCheck(instance, targetInstance, eurekaMetadata)
 	buildStatus(instance, targetInstance)
@@ -19,10 +19,13 @@ package grpcserver
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"net"
 	"net/http"
 	"runtime"
+	"strconv"
+	"strings"
 	"time"
 
 	apimodel "github.com/polarismesh/specification/source/go/api/v1/model"
@@ -30,8 +33,10 @@ import (
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 
+	grpcutils "github.com/polarismesh/polaris/apiserver/grpcserver/utils"
 	api "github.com/polarismesh/polaris/common/api/v1"
 	connhook "github.com/polarismesh/polaris/common/conn/hook"
 	connlimit "github.com/polarismesh/polaris/common/conn/limit"
@@ -39,6 +44,7 @@ import (
 	"github.com/polarismesh/polaris/common/metrics"
 	"github.com/polarismesh/polaris/common/model"
 	"github.com/polarismesh/polaris/common/secure"
+	"github.com/polarismesh/polaris/common/trace"
 	"github.com/polarismesh/polaris/common/utils"
 	"github.com/polarismesh/polaris/plugin"
 )
@@ -55,12 +61,19 @@ type BaseGrpcServer struct {
 	start           bool
 	restart         bool
 	exitCh          chan struct{}
+	// enableWeb 开启 gRPC-Web 以及 HTTP/JSON 转码
+	enableWeb bool
+	// drainTimeout 停止时等待长连接排空的最长时间
+	drainTimeout time.Duration
+	// compressor Discover 响应使用的压缩算法，为空时不压缩
+	compressor string
 
 	protocol string
 
 	bz model.BzModule
 
 	server     *grpc.Server
+	webServer  *http.Server
 	statis     plugin.Statis
 	ratelimit  plugin.Ratelimit
 	OpenMethod map[string]bool
@@ -99,12 +112,25 @@ func (b *BaseGrpcServer) Initialize(ctx context.Context, conf map[string]interfa
 			return err
 		}
 		b.tlsInfo = &secure.TLSInfo{
-			CertFile:      tlsConfig.CertFile,
-			KeyFile:       tlsConfig.KeyFile,
-			TrustedCAFile: tlsConfig.TrustedCAFile,
+			CertFile:       tlsConfig.CertFile,
+			KeyFile:        tlsConfig.KeyFile,
+			TrustedCAFile:  tlsConfig.TrustedCAFile,
+			ClientCertAuth: tlsConfig.ClientCertAuth,
 		}
 	}
 
+	b.enableWeb, _ = conf[enableWebKey].(bool)
+
+	drainTimeout, err := grpcutils.ParseDrainTimeout(conf)
+	if err != nil {
+		return err
+	}
+	b.drainTimeout = drainTimeout
+
+	if b.compressor, err = grpcutils.ParseCompressor(conf); err != nil {
+		return err
+	}
+
 	if ratelimit := plugin.GetRatelimit(); ratelimit != nil {
 		b.log.Infof("[API-Server] %s server open the ratelimit", b.protocol)
 		b.ratelimit = ratelimit
@@ -113,11 +139,31 @@ func (b *BaseGrpcServer) Initialize(ctx context.Context, conf map[string]interfa
 	return nil
 }
 
-// Stop stopping the gRPC server
+// Stop stopping the gRPC server, 先排空已有的连接，超时后再强制关闭
 func (b *BaseGrpcServer) Stop(protocol string) {
 	connlimit.RemoveLimitListener(protocol)
-	if b.server != nil {
+	connhook.RemoveConnTracker(protocol)
+	deadline := time.Now().Add(b.drainTimeout)
+	webDrained := true
+	if b.webServer != nil {
+		ctx, cancel := context.WithDeadline(context.Background(), deadline)
+		if err := b.webServer.Shutdown(ctx); err != nil {
+			webDrained = false
+			_ = b.webServer.Close()
+		}
+		cancel()
+	}
+	if b.server == nil {
+		return
+	}
+	// 通过 ServeHTTP 接入的连接不支持 GOAWAY，这类连接没有全部结束时只能直接关闭
+	if !webDrained {
 		b.server.Stop()
+		return
+	}
+	b.log.Infof("[API-Server][GRPC] %s server begin draining connections, timeout: %s", protocol, b.drainTimeout)
+	if drained := grpcutils.GracefulStop(b.server, time.Until(deadline)); !drained {
+		b.log.Warnf("[API-Server][GRPC] %s server drain timeout, force close remaining streams", protocol)
 	}
 }
 
@@ -155,17 +201,18 @@ func (b *BaseGrpcServer) Run(errCh chan error, protocol string, initServer InitS
 	b.log.Infof("[API-Server][GRPC] open connection counter net.Listener")
 	listener = connhook.NewHookListener(listener, &connCounterHook{
 		bz: b.bz,
-	})
+	}, connhook.NewConnTracker(protocol))
 
-	// 指定使用服务端证书创建一个 TLS credentials
+	// 指定使用服务端证书创建一个 TLS credentials，设置了 trustedCAFile 时校验客户端证书
+	tlsConfig, err := b.serverTLSConfig()
+	if err != nil {
+		b.log.Error("failed to create credentials: %v", zap.Error(err))
+		errCh <- err
+		return
+	}
 	var creds credentials.TransportCredentials
-	if !b.tlsInfo.IsEmpty() {
-		creds, err = credentials.NewServerTLSFromFile(b.tlsInfo.CertFile, b.tlsInfo.KeyFile)
-		if err != nil {
-			b.log.Error("failed to create credentials: %v", zap.Error(err))
-			errCh <- err
-			return
-		}
+	if tlsConfig != nil {
+		creds = credentials.NewTLS(tlsConfig)
 	}
 
 	// 设置 grpc server options
@@ -183,10 +230,21 @@ func (b *BaseGrpcServer) Run(errCh chan error, protocol string, initServer InitS
 		errCh <- err
 		return
 	}
+	grpcutils.RegisterHealthServer(server)
 	b.server = server
 
 	b.statis = plugin.GetStatis()
 
+	if b.enableWeb {
+		if err := b.serveWeb(listener, server, tlsConfig); err != nil {
+			b.log.Errorf("[API-Server][GRPC] %v", err)
+			errCh <- err
+			return
+		}
+		b.log.Infof("[API-Server] %s server stop", protocol)
+		return
+	}
+
 	if err := server.Serve(listener); err != nil {
 		b.log.Errorf("[API-Server][GRPC] %v", err)
 		errCh <- err
@@ -196,12 +254,30 @@ func (b *BaseGrpcServer) Run(errCh chan error, protocol string, initServer InitS
 	b.log.Infof("[API-Server] %s server stop", protocol)
 }
 
+// serverTLSConfig 服务端的 TLS 配置，未配置证书时返回 nil
+func (b *BaseGrpcServer) serverTLSConfig() (*tls.Config, error) {
+	if b.tlsInfo.IsEmpty() {
+		return nil, nil
+	}
+	return b.tlsInfo.ServerTLSConfig()
+}
+
 var notPrintableMethods = map[string]bool{
 	"/v1.PolarisGRPC/Heartbeat": true,
 }
 
 func (b *BaseGrpcServer) unaryInterceptor(ctx context.Context, req interface{},
 	info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (rsp interface{}, err error) {
+	if grpcutils.IsHealthMethod(info.FullMethod) {
+		return handler(ctx, req)
+	}
+	ctx, span := startServerSpan(ctx, info.FullMethod)
+	if span != nil {
+		_ = grpc.SetHeader(ctx, metadata.Pairs(trace.HeaderTraceparent, span.Traceparent()))
+		defer func() {
+			endServerSpan(span, rsp, err)
+		}()
+	}
 	stream := newVirtualStream(ctx,
 		WithVirtualStreamBaseServer(b),
 		WithVirtualStreamLogger(b.log),
@@ -224,8 +300,13 @@ func (b *BaseGrpcServer) unaryInterceptor(ctx context.Context, req interface{},
 		}
 
 		// handler执行前，限流
-		if code := b.EnterRatelimit(stream.ClientIP, stream.Method); code != uint32(api.ExecuteSuccess) {
+		token := utils.ParseAuthToken(utils.ConvertGRPCContext(ctx))
+		if code := b.EnterRatelimit(stream.ClientIP, token, stream.Method); code != uint32(api.ExecuteSuccess) {
 			rsp = api.NewResponse(apimodel.Code(code))
+			// IP 以及 token 维度的限流返回 RESOURCE_EXHAUSTED，接口维度的限流保持原有的返回方式
+			if code == api.IPRateLimit || code == api.TokenRateLimit {
+				err = status.Error(codes.ResourceExhausted, api.Code2Info(code))
+			}
 			return
 		}
 		defer func() {
@@ -250,9 +331,20 @@ func (b *BaseGrpcServer) recoverFunc(i interface{}, w http.ResponseWriter) {
 
 func (b *BaseGrpcServer) streamInterceptor(srv interface{}, ss grpc.ServerStream,
 	info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
-	stream := newVirtualStream(ss.Context(),
+	if grpcutils.IsHealthMethod(info.FullMethod) {
+		return handler(srv, ss)
+	}
+	ctx, span := startServerSpan(ss.Context(), info.FullMethod)
+	if span != nil {
+		defer func() {
+			endServerSpan(span, nil, err)
+		}()
+	}
+	stream := newVirtualStream(ctx,
 		WithVirtualStreamBaseServer(b),
 		WithVirtualStreamServerStream(ss),
+		WithVirtualStreamContext(ctx),
+		WithVirtualStreamCompressor(b.sendCompressor(ss.Context(), info.FullMethod)),
 		WithVirtualStreamMethod(info.FullMethod),
 		WithVirtualStreamPreProcessFunc(b.preprocess),
 		WithVirtualStreamPostProcessFunc(b.postprocess),
@@ -300,6 +392,55 @@ func (b *BaseGrpcServer) streamInterceptor(srv interface{}, ss grpc.ServerStream
 	return
 }
 
+// startServerSpan 基于 gRPC metadata 中的 traceparent/tracestate 创建 apiserver 的 span
+func startServerSpan(ctx context.Context, method string) (context.Context, *trace.Span) {
+	var traceparent, tracestate string
+	if meta, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := meta.Get(trace.HeaderTraceparent); len(values) > 0 {
+			traceparent = values[0]
+		}
+		if values := meta.Get(trace.HeaderTracestate); len(values) > 0 {
+			tracestate = values[0]
+		}
+	}
+	ctx, span := trace.StartServerSpan(ctx, method, traceparent, tracestate)
+	span.SetAttribute("rpc.system", "grpc")
+	span.SetAttribute("rpc.method", method)
+	return ctx, span
+}
+
+// endServerSpan 记录 gRPC 调用的结果并结束 span
+func endServerSpan(span *trace.Span, rsp interface{}, err error) {
+	if err != nil {
+		span.SetAttribute("rpc.grpc.status_code", status.Code(err).String())
+		span.SetError(err.Error())
+	}
+	if resp, ok := rsp.(api.ResponseMessage); ok {
+		span.SetAttribute("polaris.code", strconv.FormatUint(uint64(resp.GetCode().GetValue()), 10))
+	}
+	span.End()
+}
+
+// sendCompressor 客户端支持时压缩 Discover 的响应，返回设置的压缩算法
+func (b *BaseGrpcServer) sendCompressor(ctx context.Context, method string) string {
+	if b.compressor == "" || !strings.HasSuffix(method, "/Discover") {
+		return ""
+	}
+	for _, compressor := range grpcutils.CompressorCandidates(b.compressor) {
+		if grpcutils.SetSendCompressor(ctx, compressor) {
+			return compressor
+		}
+	}
+	return ""
+}
+
+// TrackSubscription 记录客户端连接上订阅的资源，用于运维接口查看连接详情
+func (b *BaseGrpcServer) TrackSubscription(address, resource string) {
+	if tracker := connhook.GetConnTracker(b.protocol); tracker != nil {
+		tracker.Subscribe(address, resource)
+	}
+}
+
 // PreProcessFunc preprocess function define
 type PreProcessFunc func(stream *VirtualStream, isPrint bool) error
 
@@ -405,7 +546,7 @@ func (b *BaseGrpcServer) Restart(
 }
 
 // EnterRatelimit api ratelimit
-func (b *BaseGrpcServer) EnterRatelimit(ip string, method string) uint32 {
+func (b *BaseGrpcServer) EnterRatelimit(ip, token, method string) uint32 {
 	if b.ratelimit == nil {
 		return api.ExecuteSuccess
 	}
@@ -416,6 +557,12 @@ func (b *BaseGrpcServer) EnterRatelimit(ip string, method string) uint32 {
 			zap.String("method", method))
 		return api.IPRateLimit
 	}
+	// tokenRatelimit
+	if ok := b.ratelimit.Allow(plugin.TokenRatelimit, token); !ok {
+		b.log.Error("[API-Server][GRPC] token ratelimit is not allow", zap.String("client-ip", ip),
+			zap.String("method", method))
+		return api.TokenRateLimit
+	}
 	// apiRatelimit
 	if ok := b.ratelimit.Allow(plugin.APIRatelimit, method); !ok {
 		b.log.Error("[API-Server][GRPC] api rate limit is not allow", zap.String("client-ip", ip),
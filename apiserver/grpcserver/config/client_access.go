@@ -107,6 +107,11 @@ func (g *ConfigGRPCServer) UpsertAndPublishConfigFile(ctx context.Context,
 func (g *ConfigGRPCServer) WatchConfigFiles(ctx context.Context,
 	request *apiconfig.ClientWatchConfigFileRequest) (*apiconfig.ConfigClientResponse, error) {
 	ctx = utils.ConvertGRPCContext(ctx)
+	clientAddress := utils.ParseClientAddress(ctx)
+	for _, file := range request.GetWatchFiles() {
+		g.TrackSubscription(clientAddress, "CONFIG_FILE:"+file.GetNamespace().GetValue()+"/"+
+			file.GetGroup().GetValue()+"/"+file.GetFileName().GetValue())
+	}
 
 	// 阻塞等待响应
 	callback, err := g.configServer.LongPullWatchFile(ctx, request)
@@ -174,7 +179,8 @@ func (g *ConfigGRPCServer) Discover(svr apiconfig.PolarisConfigGRPC_DiscoverServ
 		}
 
 		// stream模式，需要对每个包进行检测
-		if code := g.enterRateLimit(clientIP, method); code != uint32(apimodel.Code_ExecuteSuccess) {
+		token := utils.ParseAuthToken(ctx)
+		if code := g.enterRateLimit(clientIP, token, method); code != uint32(apimodel.Code_ExecuteSuccess) {
 			resp := api.NewConfigDiscoverResponse(apimodel.Code(code))
 			if err = svr.Send(resp); err != nil {
 				return err
@@ -198,6 +204,9 @@ func (g *ConfigGRPCServer) Discover(svr apiconfig.PolarisConfigGRPC_DiscoverServ
 			})
 		}()
 
+		g.TrackSubscription(clientAddress, in.GetType().String()+":"+in.GetConfigFile().GetNamespace().GetValue()+
+			"/"+in.GetConfigFile().GetGroup().GetValue()+"/"+in.GetConfigFile().GetFileName().GetValue())
+
 		switch in.Type {
 		case apiconfig.ConfigDiscoverRequest_CONFIG_FILE:
 			action = metrics.ActionGetConfigFile
@@ -117,8 +117,8 @@ func (g *ConfigGRPCServer) Restart(option map[string]interface{}, apiConf map[st
 }
 
 // enterRateLimit 限流
-func (g *ConfigGRPCServer) enterRateLimit(ip string, method string) uint32 {
-	return g.BaseGrpcServer.EnterRatelimit(ip, method)
+func (g *ConfigGRPCServer) enterRateLimit(ip, token, method string) uint32 {
+	return g.BaseGrpcServer.EnterRatelimit(ip, token, method)
 }
 
 // allowAccess 限制访问
@@ -92,6 +92,7 @@ func (g *GRPCServer) Initialize(ctx context.Context, option map[string]interface
 	g.v1server = v1.NewDiscoverServer(
 		v1.WithAllowAccess(g.allowAccess),
 		v1.WithEnterRateLimit(g.enterRateLimit),
+		v1.WithTrackSubscription(g.BaseGrpcServer.TrackSubscription),
 		v1.WithHealthCheckerServer(g.healthCheckServer),
 		v1.WithNamingServer(g.namingServer),
 	)
@@ -142,8 +143,8 @@ func (g *GRPCServer) Restart(option map[string]interface{}, api map[string]apise
 }
 
 // enterRateLimit 限流
-func (g *GRPCServer) enterRateLimit(ip string, method string) uint32 {
-	return g.BaseGrpcServer.EnterRatelimit(ip, method)
+func (g *GRPCServer) enterRateLimit(ip, token, method string) uint32 {
+	return g.BaseGrpcServer.EnterRatelimit(ip, token, method)
 }
 
 // allowAccess 限制访问
@@ -120,7 +120,8 @@ func (g *DiscoverServer) Discover(server apiservice.PolarisGRPC_DiscoverServer)
 		}
 
 		// stream模式，需要对每个包进行检测
-		if code := g.enterRateLimit(clientIP, method); code != uint32(apimodel.Code_ExecuteSuccess) {
+		token := utils.ParseAuthToken(ctx)
+		if code := g.enterRateLimit(clientIP, token, method); code != uint32(apimodel.Code_ExecuteSuccess) {
 			resp := api.NewDiscoverResponse(apimodel.Code(code))
 			if err = server.Send(resp); err != nil {
 				return err
@@ -148,6 +149,10 @@ func (g *DiscoverServer) Discover(server apiservice.PolarisGRPC_DiscoverServer)
 		if in.GetService().GetToken().GetValue() != "" {
 			ctx = context.WithValue(ctx, utils.ContextAuthTokenKey, in.GetService().GetToken().GetValue())
 		}
+		if g.trackSubscription != nil {
+			g.trackSubscription(clientAddress, in.GetType().String()+":"+in.GetService().GetNamespace().GetValue()+
+				"/"+in.GetService().GetName().GetValue())
+		}
 
 		switch in.Type {
 		case apiservice.DiscoverRequest_INSTANCE:
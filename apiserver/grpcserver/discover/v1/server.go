@@ -25,8 +25,9 @@ import (
 type DiscoverServer struct {
 	namingServer      service.DiscoverServer
 	healthCheckServer *healthcheck.Server
-	enterRateLimit    func(ip string, method string) uint32
+	enterRateLimit    func(ip, token, method string) uint32
 	allowAccess       func(method string) bool
+	trackSubscription func(address, resource string)
 }
 
 func NewDiscoverServer(options ...Option) *DiscoverServer {
@@ -53,7 +54,7 @@ func WithHealthCheckerServer(svr *healthcheck.Server) Option {
 	}
 }
 
-func WithEnterRateLimit(f func(ip string, method string) uint32) Option {
+func WithEnterRateLimit(f func(ip, token, method string) uint32) Option {
 	return func(s *DiscoverServer) {
 		s.enterRateLimit = f
 	}
@@ -64,3 +65,9 @@ func WithAllowAccess(f func(method string) bool) Option {
 		s.allowAccess = f
 	}
 }
+
+func WithTrackSubscription(f func(address, resource string)) Option {
+	return func(s *DiscoverServer) {
+		s.trackSubscription = f
+	}
+}
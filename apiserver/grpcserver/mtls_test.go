@@ -0,0 +1,173 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package grpcserver
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	apimodel "github.com/polarismesh/specification/source/go/api/v1/model"
+	apiservice "github.com/polarismesh/specification/source/go/api/v1/service_manage"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+
+	api "github.com/polarismesh/polaris/common/api/v1"
+	"github.com/polarismesh/polaris/common/secure"
+	"github.com/polarismesh/polaris/common/utils"
+)
+
+// peerCertDiscoverServer 记录请求上下文中解析出的客户端证书 SAN URI
+type peerCertDiscoverServer struct {
+	mockWebDiscoverServer
+	identities chan []string
+}
+
+func (s *peerCertDiscoverServer) Heartbeat(ctx context.Context, _ *apiservice.Instance) (*apiservice.Response, error) {
+	var identities []string
+	for _, cert := range utils.ParsePeerCertificates(utils.ConvertGRPCContext(ctx)) {
+		for i := range cert.URIs {
+			identities = append(identities, cert.URIs[i].String())
+		}
+		break
+	}
+	s.identities <- identities
+	return api.NewResponse(apimodel.Code_ExecuteSuccess), nil
+}
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func newTestCA(t *testing.T) *testCA {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	tpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: "polaris-test-ca"},
+		NotBefore:             time.Now().Add(-time.Minute),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tpl, tpl, &key.PublicKey, key)
+	assert.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	assert.NoError(t, err)
+	return &testCA{cert: cert, key: key}
+}
+
+// issue 签发证书，返回 PEM 编码的证书以及私钥
+func (ca *testCA) issue(t *testing.T, tpl *x509.Certificate) ([]byte, []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	tpl.SerialNumber = big.NewInt(time.Now().UnixNano())
+	tpl.NotBefore = time.Now().Add(-time.Minute)
+	tpl.NotAfter = time.Now().Add(time.Hour)
+	der, err := x509.CreateCertificate(rand.Reader, tpl, ca.cert, &key.PublicKey, ca.key)
+	assert.NoError(t, err)
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	assert.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer})
+}
+
+func (ca *testCA) clientCert(t *testing.T, spiffeID string) tls.Certificate {
+	uri, err := url.Parse(spiffeID)
+	assert.NoError(t, err)
+	certPEM, keyPEM := ca.issue(t, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "workload"},
+		URIs:        []*url.URL{uri},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	})
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	assert.NoError(t, err)
+	return cert
+}
+
+func TestBaseGrpcServer_MTLS(t *testing.T) {
+	ca := newTestCA(t)
+	dir := t.TempDir()
+	serverCert, serverKey := ca.issue(t, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "polaris.test"},
+		DNSNames:    []string{"polaris.test"},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	})
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "server.pem"), serverCert, 0600))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "server.key"), serverKey, 0600))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "ca.pem"),
+		pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.cert.Raw}), 0600))
+
+	b := &BaseGrpcServer{tlsInfo: &secure.TLSInfo{
+		CertFile:       filepath.Join(dir, "server.pem"),
+		KeyFile:        filepath.Join(dir, "server.key"),
+		TrustedCAFile:  filepath.Join(dir, "ca.pem"),
+		ClientCertAuth: true,
+	}}
+	tlsConfig, err := b.serverTLSConfig()
+	assert.NoError(t, err)
+	discover := &peerCertDiscoverServer{identities: make(chan []string, 1)}
+	server := grpc.NewServer(grpc.Creds(credentials.NewTLS(tlsConfig)))
+	apiservice.RegisterPolarisGRPCServer(server, discover)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	defer server.Stop()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	heartbeat := func(certs ...tls.Certificate) error {
+		conn, err := grpc.Dial(listener.Addr().String(), grpc.WithTransportCredentials(credentials.NewTLS(
+			&tls.Config{ServerName: "polaris.test", RootCAs: roots, Certificates: certs})))
+		assert.NoError(t, err)
+		defer conn.Close()
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_, err = apiservice.NewPolarisGRPCClient(conn).Heartbeat(ctx, &apiservice.Instance{})
+		return err
+	}
+
+	t.Run("客户端证书的 SAN 透传到请求上下文", func(t *testing.T) {
+		err := heartbeat(ca.clientCert(t, "spiffe://polaris.test/ns/default/sa/order"))
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"spiffe://polaris.test/ns/default/sa/order"}, <-discover.identities)
+	})
+
+	t.Run("拒绝未提供以及不受信任的客户端证书", func(t *testing.T) {
+		assert.Error(t, heartbeat())
+		assert.Error(t, heartbeat(newTestCA(t).clientCert(t, "spiffe://evil.test/sa/order")))
+		assert.Empty(t, discover.identities)
+	})
+}
@@ -28,6 +28,7 @@ import (
 	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/peer"
 
+	grpcutils "github.com/polarismesh/polaris/apiserver/grpcserver/utils"
 	commonlog "github.com/polarismesh/polaris/common/log"
 )
 
@@ -48,6 +49,20 @@ func WithVirtualStreamServerStream(stream grpc.ServerStream) initVirtualStream {
 	}
 }
 
+// WithVirtualStreamContext 设置 stream 的 ctx，用于透传链路信息
+func WithVirtualStreamContext(ctx context.Context) initVirtualStream {
+	return func(vStream *VirtualStream) {
+		vStream.ctx = ctx
+	}
+}
+
+// WithVirtualStreamCompressor 设置服务端发送消息使用的压缩算法
+func WithVirtualStreamCompressor(compressor string) initVirtualStream {
+	return func(vStream *VirtualStream) {
+		vStream.compressor = compressor
+	}
+}
+
 // WithVirtualStreamPreProcessFunc 设置 PreProcessFunc
 func WithVirtualStreamPreProcessFunc(preprocess PreProcessFunc) initVirtualStream {
 	return func(vStream *VirtualStream) {
@@ -133,6 +148,9 @@ type VirtualStream struct {
 	RequestID     string
 
 	stream grpc.ServerStream
+	ctx    context.Context
+	// compressor 服务端发送消息使用的压缩算法，为空时与请求消息保持一致
+	compressor string
 
 	Code int
 
@@ -169,6 +187,9 @@ func (v *VirtualStream) SetTrailer(md metadata.MD) {
 
 // Context returns the context for this stream.
 func (v *VirtualStream) Context() context.Context {
+	if v.ctx != nil {
+		return v.ctx
+	}
 	return v.stream.Context()
 }
 
@@ -223,12 +244,20 @@ func (v *VirtualStream) handleResponse(stream grpc.ServerStream, m interface{})
 	if v.server.cache == nil {
 		return m
 	}
+	// 预编码的消息按照请求消息的压缩算法压缩，发送时使用了不同的压缩算法则不能复用
+	recvCompressor := grpcutils.RequestCompressor(stream.Context())
+	if v.compressor != "" && v.compressor != recvCompressor {
+		return m
+	}
 
 	cacheVal := v.server.convert(m)
 	if cacheVal == nil {
 		return m
 	}
 
+	if recvCompressor != "" {
+		cacheVal.Key += "-" + recvCompressor
+	}
 	if saveVal := v.server.cache.Get(cacheVal.CacheType, cacheVal.Key); saveVal != nil {
 		return saveVal.GetPreparedMessage()
 	}
@@ -0,0 +1,90 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+package utils
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/encoding"
+	"google.golang.org/grpc/encoding/gzip"
+)
+
+const (
+	// CompressionKey Discover 响应压缩的配置
+	CompressionKey = "compression"
+)
+
+// ParseCompressor 解析 Discover 响应优先使用的压缩算法，支持 gzip、zstd，未配置算法时使用 gzip，未开启时返回空
+func ParseCompressor(option map[string]interface{}) (string, error) {
+	raw, ok := option[CompressionKey]
+	if !ok || raw == nil {
+		return "", nil
+	}
+	conf, ok := raw.(map[interface{}]interface{})
+	if !ok {
+		return "", fmt.Errorf("invalid %s: %v", CompressionKey, raw)
+	}
+	if open, _ := conf["open"].(bool); !open {
+		return "", nil
+	}
+	algorithm, _ := conf["algorithm"].(string)
+	switch strings.ToLower(algorithm) {
+	case "", gzip.Name:
+		return gzip.Name, nil
+	case ZstdName:
+		return ZstdName, nil
+	default:
+		return "", fmt.Errorf("invalid %s algorithm: %s", CompressionKey, algorithm)
+	}
+}
+
+// CompressorCandidates 按照优先级返回可以使用的压缩算法，客户端不支持 zstd 时回退到 gzip
+func CompressorCandidates(compressor string) []string {
+	if compressor == ZstdName {
+		return []string{ZstdName, gzip.Name}
+	}
+	return []string{compressor}
+}
+
+// SetSendCompressor 客户端支持时，使用 compressor 压缩服务端发送的消息，返回是否设置成功
+func SetSendCompressor(ctx context.Context, compressor string) bool {
+	supported, err := grpc.ClientSupportedCompressors(ctx)
+	if err != nil {
+		return false
+	}
+	for i := range supported {
+		if supported[i] == compressor {
+			return grpc.SetSendCompressor(ctx, compressor) == nil
+		}
+	}
+	return false
+}
+
+// RequestCompressor 客户端请求消息使用的压缩算法，未压缩时返回空
+func RequestCompressor(ctx context.Context) string {
+	stream, ok := grpc.ServerTransportStreamFromContext(ctx).(interface{ RecvCompress() string })
+	if !ok {
+		return ""
+	}
+	if name := stream.RecvCompress(); name != encoding.Identity {
+		return name
+	}
+	return ""
+}
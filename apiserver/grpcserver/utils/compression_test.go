@@ -0,0 +1,112 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+package utils
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/encoding/gzip"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func TestParseCompressor(t *testing.T) {
+	compressor, err := ParseCompressor(map[string]interface{}{})
+	assert.NoError(t, err)
+	assert.Empty(t, compressor)
+
+	compressor, err = ParseCompressor(map[string]interface{}{
+		CompressionKey: map[interface{}]interface{}{"open": true},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, gzip.Name, compressor)
+
+	compressor, err = ParseCompressor(map[string]interface{}{
+		CompressionKey: map[interface{}]interface{}{"open": false},
+	})
+	assert.NoError(t, err)
+	assert.Empty(t, compressor)
+
+	compressor, err = ParseCompressor(map[string]interface{}{
+		CompressionKey: map[interface{}]interface{}{"open": true, "algorithm": "zstd"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, ZstdName, compressor)
+	assert.Equal(t, []string{ZstdName, gzip.Name}, CompressorCandidates(compressor))
+	assert.Equal(t, []string{gzip.Name}, CompressorCandidates(gzip.Name))
+
+	_, err = ParseCompressor(map[string]interface{}{
+		CompressionKey: map[interface{}]interface{}{"open": true, "algorithm": "br"},
+	})
+	assert.Error(t, err)
+
+	_, err = ParseCompressor(map[string]interface{}{CompressionKey: "gzip"})
+	assert.Error(t, err)
+}
+
+func TestSetSendCompressor(t *testing.T) {
+	type result struct {
+		recv string
+		set  bool
+	}
+	results := make(chan result, 1)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	server := grpc.NewServer(grpc.UnaryInterceptor(func(ctx context.Context, req interface{},
+		info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		results <- result{recv: RequestCompressor(ctx), set: SetSendCompressor(ctx, gzip.Name)}
+		return handler(ctx, req)
+	}))
+	healthpb.RegisterHealthServer(server, health.NewServer())
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	defer server.Stop()
+
+	conn, err := grpc.Dial(listener.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	assert.NoError(t, err)
+	defer conn.Close()
+	client := healthpb.NewHealthClient(conn)
+
+	_, err = client.Check(context.Background(), &healthpb.HealthCheckRequest{})
+	assert.NoError(t, err)
+	ret := <-results
+	assert.Empty(t, ret.recv)
+	assert.True(t, ret.set)
+
+	_, err = client.Check(context.Background(), &healthpb.HealthCheckRequest{}, grpc.UseCompressor(gzip.Name))
+	assert.NoError(t, err)
+	ret = <-results
+	assert.Equal(t, gzip.Name, ret.recv)
+	assert.True(t, ret.set)
+
+	// zstd 压缩的请求以及响应
+	rsp, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{}, grpc.UseCompressor(ZstdName))
+	assert.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, rsp.GetStatus())
+	ret = <-results
+	assert.Equal(t, ZstdName, ret.recv)
+	assert.True(t, ret.set)
+
+	// 不是 gRPC 请求的 ctx
+	assert.False(t, SetSendCompressor(context.Background(), gzip.Name))
+}
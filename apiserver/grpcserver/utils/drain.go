@@ -0,0 +1,77 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+package utils
+
+import (
+	"fmt"
+	"time"
+
+	"google.golang.org/grpc"
+)
+
+const (
+	// DrainTimeoutKey apiserver 停止时等待长连接排空的最长时间
+	DrainTimeoutKey = "drainTimeout"
+	// DefaultDrainTimeout 默认的排空时间
+	DefaultDrainTimeout = 15 * time.Second
+)
+
+// ParseDrainTimeout 解析排空时间，支持 30s 这样的时长或者秒数，未配置时使用默认值，配置为 0 表示立即停止
+func ParseDrainTimeout(option map[string]interface{}) (time.Duration, error) {
+	raw, ok := option[DrainTimeoutKey]
+	if !ok || raw == nil {
+		return DefaultDrainTimeout, nil
+	}
+	switch val := raw.(type) {
+	case int:
+		return time.Duration(val) * time.Second, nil
+	case string:
+		timeout, err := time.ParseDuration(val)
+		if err != nil {
+			return 0, fmt.Errorf("invalid %s: %w", DrainTimeoutKey, err)
+		}
+		return timeout, nil
+	default:
+		return 0, fmt.Errorf("invalid %s: %v", DrainTimeoutKey, raw)
+	}
+}
+
+// GracefulStop 排空 gRPC server 上的连接后停止
+// GracefulStop 会关闭监听、向所有连接发送 GOAWAY 并拒绝新的 stream，客户端收到后会重新选择其他节点建立连接
+// 仍未结束的 stream (比如 SDK 的 Discover 长连接) 在超时后被强制关闭，返回是否在超时前完成排空
+func GracefulStop(server *grpc.Server, timeout time.Duration) bool {
+	if timeout <= 0 {
+		server.Stop()
+		return true
+	}
+	done := make(chan struct{})
+	go func() {
+		server.GracefulStop()
+		close(done)
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-done:
+		return true
+	case <-timer.C:
+		server.Stop()
+		<-done
+		return false
+	}
+}
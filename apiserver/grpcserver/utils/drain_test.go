@@ -0,0 +1,85 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+package utils
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func startHealthServer(t *testing.T) (*grpc.Server, string) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	server := grpc.NewServer()
+	healthpb.RegisterHealthServer(server, health.NewServer())
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	return server, listener.Addr().String()
+}
+
+func TestParseDrainTimeout(t *testing.T) {
+	timeout, err := ParseDrainTimeout(map[string]interface{}{})
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultDrainTimeout, timeout)
+
+	timeout, err = ParseDrainTimeout(map[string]interface{}{DrainTimeoutKey: "30s"})
+	assert.NoError(t, err)
+	assert.Equal(t, 30*time.Second, timeout)
+
+	timeout, err = ParseDrainTimeout(map[string]interface{}{DrainTimeoutKey: 5})
+	assert.NoError(t, err)
+	assert.Equal(t, 5*time.Second, timeout)
+
+	_, err = ParseDrainTimeout(map[string]interface{}{DrainTimeoutKey: "abc"})
+	assert.Error(t, err)
+}
+
+func TestGracefulStop(t *testing.T) {
+	t.Run("没有长连接", func(t *testing.T) {
+		server, _ := startHealthServer(t)
+		assert.True(t, GracefulStop(server, time.Second))
+	})
+
+	t.Run("长连接超时后强制关闭", func(t *testing.T) {
+		server, addr := startHealthServer(t)
+		conn, err := grpc.Dial(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		assert.NoError(t, err)
+		defer conn.Close()
+
+		stream, err := healthpb.NewHealthClient(conn).Watch(context.Background(), &healthpb.HealthCheckRequest{})
+		assert.NoError(t, err)
+		_, err = stream.Recv()
+		assert.NoError(t, err)
+
+		start := time.Now()
+		assert.False(t, GracefulStop(server, 200*time.Millisecond))
+		assert.True(t, time.Since(start) >= 200*time.Millisecond)
+
+		// 强制关闭后客户端的 stream 结束
+		_, err = stream.Recv()
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,100 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+package utils
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+
+	"github.com/polarismesh/polaris/common/readiness"
+)
+
+// HealthWatchInterval Watch 接口检查组件就绪状态的间隔
+var HealthWatchInterval = time.Second
+
+// IsHealthMethod 是否为 grpc.health.v1.Health 的接口，探活请求不经过鉴权、限流等处理
+func IsHealthMethod(method string) bool {
+	return strings.HasPrefix(method, "/"+healthpb.Health_ServiceDesc.ServiceName+"/")
+}
+
+// RegisterHealthServer 注册 grpc.health.v1.Health 服务
+// 服务名为空时返回节点整体的就绪状态，否则返回对应组件的就绪状态
+func RegisterHealthServer(server *grpc.Server) {
+	healthpb.RegisterHealthServer(server, &healthServer{})
+}
+
+type healthServer struct {
+	healthpb.UnimplementedHealthServer
+}
+
+// Check 查询就绪状态，组件不存在时返回 NOT_FOUND
+func (h *healthServer) Check(ctx context.Context,
+	req *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+	servingStatus, ok := healthStatus(req.GetService())
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "unknown service %s", req.GetService())
+	}
+	return &healthpb.HealthCheckResponse{Status: servingStatus}, nil
+}
+
+// Watch 就绪状态发生变化时推送给客户端，组件不存在时推送 SERVICE_UNKNOWN
+func (h *healthServer) Watch(req *healthpb.HealthCheckRequest, stream healthpb.Health_WatchServer) error {
+	ticker := time.NewTicker(HealthWatchInterval)
+	defer ticker.Stop()
+
+	lastStatus := healthpb.HealthCheckResponse_UNKNOWN
+	for {
+		servingStatus, ok := healthStatus(req.GetService())
+		if !ok {
+			servingStatus = healthpb.HealthCheckResponse_SERVICE_UNKNOWN
+		}
+		if servingStatus != lastStatus {
+			if err := stream.Send(&healthpb.HealthCheckResponse{Status: servingStatus}); err != nil {
+				return status.Error(codes.Canceled, "stream has ended")
+			}
+			lastStatus = servingStatus
+		}
+		select {
+		case <-stream.Context().Done():
+			return status.Error(codes.Canceled, "stream has ended")
+		case <-ticker.C:
+		}
+	}
+}
+
+func healthStatus(service string) (healthpb.HealthCheckResponse_ServingStatus, bool) {
+	var ready bool
+	if service == "" {
+		ready = readiness.Check().Ready
+	} else {
+		component, ok := readiness.CheckComponent(service)
+		if !ok {
+			return healthpb.HealthCheckResponse_SERVICE_UNKNOWN, false
+		}
+		ready = component.Ready
+	}
+	if ready {
+		return healthpb.HealthCheckResponse_SERVING, true
+	}
+	return healthpb.HealthCheckResponse_NOT_SERVING, true
+}
@@ -0,0 +1,88 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+package utils
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+
+	"github.com/polarismesh/polaris/common/readiness"
+)
+
+func TestHealthServer(t *testing.T) {
+	HealthWatchInterval = 10 * time.Millisecond
+	markReady := readiness.Pending("test-health-cache", "cache is warming up")
+	readiness.Register("test-health-store", func() error { return nil })
+	defer readiness.Deregister("test-health-cache")
+	defer readiness.Deregister("test-health-store")
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	server := grpc.NewServer()
+	RegisterHealthServer(server)
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	defer server.Stop()
+
+	conn, err := grpc.Dial(listener.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	assert.NoError(t, err)
+	defer conn.Close()
+	client := healthpb.NewHealthClient(conn)
+
+	rsp, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{})
+	assert.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, rsp.GetStatus())
+	rsp, err = client.Check(context.Background(), &healthpb.HealthCheckRequest{Service: "test-health-store"})
+	assert.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, rsp.GetStatus())
+	_, err = client.Check(context.Background(), &healthpb.HealthCheckRequest{Service: "not-exist"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	watcher, err := client.Watch(ctx, &healthpb.HealthCheckRequest{})
+	assert.NoError(t, err)
+	rsp, err = watcher.Recv()
+	assert.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, rsp.GetStatus())
+	markReady()
+	rsp, err = watcher.Recv()
+	assert.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, rsp.GetStatus())
+
+	readiness.Register("test-health-store", func() error { return errors.New("store disconnected") })
+	rsp, err = watcher.Recv()
+	assert.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, rsp.GetStatus())
+}
+
+func TestIsHealthMethod(t *testing.T) {
+	assert.True(t, IsHealthMethod("/grpc.health.v1.Health/Check"))
+	assert.True(t, IsHealthMethod("/grpc.health.v1.Health/Watch"))
+	assert.False(t, IsHealthMethod("/v1.PolarisGRPC/Discover"))
+}
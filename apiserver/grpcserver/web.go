@@ -0,0 +1,407 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+package grpcserver
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+
+	"go.uber.org/zap"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/protoadapt"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+
+	api "github.com/polarismesh/polaris/common/api/v1"
+)
+
+const (
+	// enableWebKey 开启 gRPC-Web 以及 HTTP/JSON 转码的配置项
+	enableWebKey = "enableWeb"
+
+	grpcContentType        = "application/grpc"
+	grpcWebContentType     = "application/grpc-web"
+	grpcWebTextContentType = "application/grpc-web-text"
+	jsonContentType        = "application/json"
+
+	// grpcFrameHeaderLen gRPC 消息帧头部长度, 1 字节的标记位以及 4 字节的消息长度
+	grpcFrameHeaderLen = 5
+	// grpcWebTrailerFlag gRPC-Web 中 trailer 帧的标记位
+	grpcWebTrailerFlag = 0x80
+)
+
+var (
+	// grpcTrailers gRPC 通过 http.Handler 提供服务时预先声明的 trailer
+	grpcTrailers = []string{"Grpc-Status", "Grpc-Message", "Grpc-Status-Details-Bin"}
+)
+
+// webHandler 开启 gRPC-Web 后，同一个端口同时支持原生 gRPC、gRPC-Web 以及 HTTP/JSON 转码
+// 明文的 HTTP/2 请求通过 h2c 处理，gRPC-Web 以及 JSON 请求转换为 gRPC 请求后交给 grpc.Server 处理，
+// 因此会经过和原生 gRPC 请求一样的拦截器、限流以及鉴权逻辑
+func (b *BaseGrpcServer) webHandler(server *grpc.Server) http.Handler {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		contentType := r.Header.Get("Content-Type")
+		switch {
+		case r.ProtoMajor == 2 && strings.HasPrefix(contentType, grpcContentType):
+			server.ServeHTTP(w, r)
+		case r.Method == http.MethodOptions:
+			writeCorsPreflight(w, r)
+		case strings.HasPrefix(contentType, grpcWebContentType):
+			b.serveGrpcWeb(server, w, r)
+		default:
+			b.serveJSON(server, w, r)
+		}
+	})
+	return h2c.NewHandler(handler, &http2.Server{})
+}
+
+// serveWeb 通过 http.Server 对外提供服务，TLS 配置与原生 gRPC 保持一致，tlsConfig 为 nil 时不使用 TLS
+func (b *BaseGrpcServer) serveWeb(listener net.Listener, server *grpc.Server, tlsConfig *tls.Config) error {
+	b.log.Infof("[API-Server][GRPC] grpc server open grpc-web and http/json transcoding")
+	b.webServer = &http.Server{Handler: b.webHandler(server), TLSConfig: tlsConfig}
+	var err error
+	if tlsConfig != nil {
+		// 证书已经加载到 TLSConfig 中
+		err = b.webServer.ServeTLS(listener, "", "")
+	} else {
+		err = b.webServer.Serve(listener)
+	}
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
+
+// serveGrpcWeb 将 gRPC-Web 请求转换为 gRPC 请求，并将 gRPC 的 trailer 编码为 gRPC-Web 的 trailer 帧
+func (b *BaseGrpcServer) serveGrpcWeb(server *grpc.Server, w http.ResponseWriter, r *http.Request) {
+	text := strings.HasPrefix(r.Header.Get("Content-Type"), grpcWebTextContentType)
+	req := toGrpcRequest(r)
+	if text {
+		req.Body = io.NopCloser(base64.NewDecoder(base64.StdEncoding, r.Body))
+	}
+	writeCorsHeaders(w, r)
+	rw := newGrpcWebResponseWriter(w, text)
+	server.ServeHTTP(rw, req)
+	if err := rw.finish(); err != nil {
+		b.log.Error("[API-Server][GRPC] write grpc-web trailer", zap.String("method", r.URL.Path),
+			zap.Error(err))
+	}
+}
+
+// serveJSON 请求路径为 gRPC 方法全名，例如 POST /v1.PolarisGRPC/RegisterInstance
+// 请求以及响应的 JSON 与 proto 定义保持一致，流式接口只发送一个请求并返回第一个响应
+func (b *BaseGrpcServer) serveJSON(server *grpc.Server, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSONError(w, http.StatusMethodNotAllowed, codes.Unimplemented, "only support POST method")
+		return
+	}
+	input, output, err := lookupMethodTypes(r.URL.Path)
+	if err != nil {
+		writeJSONError(w, http.StatusNotFound, codes.Unimplemented, err.Error())
+		return
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, codes.InvalidArgument, err.Error())
+		return
+	}
+	reqMsg := input.New().Interface()
+	if len(bytes.TrimSpace(body)) > 0 {
+		if err := (protojson.UnmarshalOptions{DiscardUnknown: true}).Unmarshal(body, reqMsg); err != nil {
+			writeJSONError(w, http.StatusBadRequest, codes.InvalidArgument, err.Error())
+			return
+		}
+	}
+	payload, err := proto.Marshal(reqMsg)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, codes.InvalidArgument, err.Error())
+		return
+	}
+
+	req := toGrpcRequest(r)
+	req.Body = io.NopCloser(bytes.NewReader(encodeGrpcFrame(0, payload)))
+	writeCorsHeaders(w, r)
+	recorder := newGrpcResponseRecorder()
+	server.ServeHTTP(recorder, req)
+
+	if code, message := recorder.status(); code != codes.OK {
+		writeJSONError(w, httpStatusFromCode(code), code, message)
+		return
+	}
+	rspMsg := output.New().Interface()
+	if frame, ok := recorder.firstMessage(); ok {
+		if err := proto.Unmarshal(frame, rspMsg); err != nil {
+			writeJSONError(w, http.StatusInternalServerError, codes.Internal, err.Error())
+			return
+		}
+	}
+	data, err := (protojson.MarshalOptions{EmitUnpopulated: true}).Marshal(rspMsg)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, codes.Internal, err.Error())
+		return
+	}
+	httpStatus := http.StatusOK
+	if rsp, ok := protoadapt.MessageV1Of(rspMsg).(api.ResponseMessage); ok && api.CalcCode(rsp) != 0 {
+		httpStatus = api.CalcCode(rsp)
+	}
+	w.Header().Set("Content-Type", jsonContentType)
+	w.WriteHeader(httpStatus)
+	if _, err := w.Write(data); err != nil {
+		b.log.Error("[API-Server][GRPC] write json response", zap.String("method", r.URL.Path), zap.Error(err))
+	}
+}
+
+// lookupMethodTypes 根据 gRPC 方法全名从 proto 注册表中查找请求以及响应的消息类型
+func lookupMethodTypes(fullMethod string) (protoreflect.MessageType, protoreflect.MessageType, error) {
+	path := strings.TrimPrefix(fullMethod, "/")
+	pos := strings.LastIndex(path, "/")
+	if pos <= 0 || pos == len(path)-1 {
+		return nil, nil, fmt.Errorf("invalid grpc method %q", fullMethod)
+	}
+	desc, err := protoregistry.GlobalFiles.FindDescriptorByName(protoreflect.FullName(path[:pos]))
+	if err != nil {
+		return nil, nil, fmt.Errorf("grpc service %q not found", path[:pos])
+	}
+	svcDesc, ok := desc.(protoreflect.ServiceDescriptor)
+	if !ok {
+		return nil, nil, fmt.Errorf("grpc service %q not found", path[:pos])
+	}
+	methodDesc := svcDesc.Methods().ByName(protoreflect.Name(path[pos+1:]))
+	if methodDesc == nil {
+		return nil, nil, fmt.Errorf("grpc method %q not found", fullMethod)
+	}
+	input, err := protoregistry.GlobalTypes.FindMessageByName(methodDesc.Input().FullName())
+	if err != nil {
+		return nil, nil, err
+	}
+	output, err := protoregistry.GlobalTypes.FindMessageByName(methodDesc.Output().FullName())
+	if err != nil {
+		return nil, nil, err
+	}
+	return input, output, nil
+}
+
+// toGrpcRequest 构造一个可以直接交给 grpc.Server.ServeHTTP 处理的 HTTP/2 gRPC 请求
+func toGrpcRequest(r *http.Request) *http.Request {
+	req := r.Clone(r.Context())
+	req.ProtoMajor, req.ProtoMinor, req.Proto = 2, 0, "HTTP/2.0"
+	req.Method = http.MethodPost
+	req.Header.Set("Content-Type", grpcContentType+"+proto")
+	req.Header.Del("Content-Length")
+	return req
+}
+
+func encodeGrpcFrame(flag byte, payload []byte) []byte {
+	frame := make([]byte, grpcFrameHeaderLen+len(payload))
+	frame[0] = flag
+	binary.BigEndian.PutUint32(frame[1:grpcFrameHeaderLen], uint32(len(payload)))
+	copy(frame[grpcFrameHeaderLen:], payload)
+	return frame
+}
+
+// trailerKeys grpc.Server 写入到 header 中的 trailer，包括预先声明的以及带有 http2.TrailerPrefix 前缀的
+func trailerKeys(header http.Header) map[string]string {
+	keys := map[string]string{}
+	for _, key := range grpcTrailers {
+		keys[key] = key
+	}
+	for key := range header {
+		if strings.HasPrefix(key, http2.TrailerPrefix) {
+			keys[key] = strings.TrimPrefix(key, http2.TrailerPrefix)
+		}
+	}
+	return keys
+}
+
+// grpcWebResponseWriter 转发 gRPC 的响应头以及消息帧，结束时将 trailer 编码为消息帧写在响应体的最后
+type grpcWebResponseWriter struct {
+	w             http.ResponseWriter
+	header        http.Header
+	text          bool
+	headerWritten bool
+}
+
+func newGrpcWebResponseWriter(w http.ResponseWriter, text bool) *grpcWebResponseWriter {
+	return &grpcWebResponseWriter{w: w, header: http.Header{}, text: text}
+}
+
+func (rw *grpcWebResponseWriter) Header() http.Header {
+	return rw.header
+}
+
+func (rw *grpcWebResponseWriter) WriteHeader(code int) {
+	if rw.headerWritten {
+		return
+	}
+	rw.headerWritten = true
+	trailers := trailerKeys(rw.header)
+	for key, values := range rw.header {
+		if _, ok := trailers[key]; ok || key == "Trailer" {
+			continue
+		}
+		rw.w.Header()[key] = values
+	}
+	contentType := grpcWebContentType + "+proto"
+	if rw.text {
+		contentType = grpcWebTextContentType + "+proto"
+	}
+	rw.w.Header().Set("Content-Type", contentType)
+	rw.w.WriteHeader(code)
+}
+
+func (rw *grpcWebResponseWriter) Write(p []byte) (int, error) {
+	rw.WriteHeader(http.StatusOK)
+	if rw.text {
+		if _, err := rw.w.Write([]byte(base64.StdEncoding.EncodeToString(p))); err != nil {
+			return 0, err
+		}
+		return len(p), nil
+	}
+	return rw.w.Write(p)
+}
+
+func (rw *grpcWebResponseWriter) Flush() {
+	rw.WriteHeader(http.StatusOK)
+	if flusher, ok := rw.w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// finish gRPC-Web 的 trailer 帧，每一行为小写的 key: value
+func (rw *grpcWebResponseWriter) finish() error {
+	rw.WriteHeader(http.StatusOK)
+	buf := &bytes.Buffer{}
+	for key, name := range trailerKeys(rw.header) {
+		for _, value := range rw.header[key] {
+			buf.WriteString(strings.ToLower(name) + ": " + value + "\r\n")
+		}
+	}
+	_, err := rw.Write(encodeGrpcFrame(grpcWebTrailerFlag, buf.Bytes()))
+	rw.Flush()
+	return err
+}
+
+// grpcResponseRecorder 记录 gRPC 的响应，用于 HTTP/JSON 转码
+type grpcResponseRecorder struct {
+	header http.Header
+	body   bytes.Buffer
+}
+
+func newGrpcResponseRecorder() *grpcResponseRecorder {
+	return &grpcResponseRecorder{header: http.Header{}}
+}
+
+func (r *grpcResponseRecorder) Header() http.Header {
+	return r.header
+}
+
+func (r *grpcResponseRecorder) WriteHeader(int) {}
+
+func (r *grpcResponseRecorder) Write(p []byte) (int, error) {
+	return r.body.Write(p)
+}
+
+func (r *grpcResponseRecorder) Flush() {}
+
+func (r *grpcResponseRecorder) status() (codes.Code, string) {
+	code := codes.Unknown
+	if value := r.header.Get("Grpc-Status"); value != "" {
+		var parsed uint32
+		if _, err := fmt.Sscanf(value, "%d", &parsed); err == nil {
+			code = codes.Code(parsed)
+		}
+	}
+	return code, r.header.Get("Grpc-Message")
+}
+
+// firstMessage 响应体中第一个 gRPC 消息帧的内容
+func (r *grpcResponseRecorder) firstMessage() ([]byte, bool) {
+	data := r.body.Bytes()
+	if len(data) < grpcFrameHeaderLen {
+		return nil, false
+	}
+	size := int(binary.BigEndian.Uint32(data[1:grpcFrameHeaderLen]))
+	if len(data) < grpcFrameHeaderLen+size {
+		return nil, false
+	}
+	return data[grpcFrameHeaderLen : grpcFrameHeaderLen+size], true
+}
+
+func writeCorsHeaders(w http.ResponseWriter, r *http.Request) {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return
+	}
+	w.Header().Set("Access-Control-Allow-Origin", origin)
+	w.Header().Set("Access-Control-Expose-Headers", "grpc-status,grpc-message,grpc-status-details-bin")
+}
+
+func writeCorsPreflight(w http.ResponseWriter, r *http.Request) {
+	writeCorsHeaders(w, r)
+	w.Header().Set("Access-Control-Allow-Methods", http.MethodPost)
+	if headers := r.Header.Get("Access-Control-Request-Headers"); headers != "" {
+		w.Header().Set("Access-Control-Allow-Headers", headers)
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func writeJSONError(w http.ResponseWriter, httpStatus int, code codes.Code, message string) {
+	data, _ := protojson.Marshal(status.New(code, message).Proto())
+	w.Header().Set("Content-Type", jsonContentType)
+	w.WriteHeader(httpStatus)
+	_, _ = w.Write(data)
+}
+
+// httpStatusFromCode gRPC 状态码对应的 HTTP 状态码
+func httpStatusFromCode(code codes.Code) int {
+	switch code {
+	case codes.InvalidArgument, codes.FailedPrecondition, codes.OutOfRange:
+		return http.StatusBadRequest
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.AlreadyExists, codes.Aborted:
+		return http.StatusConflict
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.Unimplemented:
+		return http.StatusNotImplemented
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,160 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+package grpcserver
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	apimodel "github.com/polarismesh/specification/source/go/api/v1/model"
+	apiservice "github.com/polarismesh/specification/source/go/api/v1/service_manage"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/protoadapt"
+
+	api "github.com/polarismesh/polaris/common/api/v1"
+	commonlog "github.com/polarismesh/polaris/common/log"
+	"github.com/polarismesh/polaris/common/utils"
+)
+
+type mockWebDiscoverServer struct{}
+
+func (s *mockWebDiscoverServer) ReportClient(context.Context, *apiservice.Client) (*apiservice.Response, error) {
+	return nil, status.Error(codes.PermissionDenied, "forbidden")
+}
+
+func (s *mockWebDiscoverServer) RegisterInstance(_ context.Context,
+	in *apiservice.Instance) (*apiservice.Response, error) {
+	rsp := api.NewResponse(apimodel.Code_ExecuteSuccess)
+	rsp.Instance = in
+	return rsp, nil
+}
+
+func (s *mockWebDiscoverServer) DeregisterInstance(context.Context,
+	*apiservice.Instance) (*apiservice.Response, error) {
+	return api.NewResponse(apimodel.Code_NotFoundResource), nil
+}
+
+func (s *mockWebDiscoverServer) Discover(server apiservice.PolarisGRPC_DiscoverServer) error {
+	for {
+		req, err := server.Recv()
+		if err != nil {
+			return nil
+		}
+		rsp := api.NewDiscoverResponse(apimodel.Code_ExecuteSuccess)
+		rsp.Service = req.GetService()
+		if err := server.Send(rsp); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *mockWebDiscoverServer) Heartbeat(context.Context, *apiservice.Instance) (*apiservice.Response, error) {
+	return api.NewResponse(apimodel.Code_ExecuteSuccess), nil
+}
+
+func newMockWebHandler() http.Handler {
+	server := grpc.NewServer()
+	apiservice.RegisterPolarisGRPCServer(server, &mockWebDiscoverServer{})
+	b := &BaseGrpcServer{log: commonlog.FindScope(commonlog.APIServerLoggerName)}
+	return b.webHandler(server)
+}
+
+func TestWebHandler_JSON(t *testing.T) {
+	handler := newMockWebHandler()
+
+	t.Run("unary", func(t *testing.T) {
+		body := `{"service": "svc", "namespace": "default", "host": "127.0.0.1", "port": 8080}`
+		req := httptest.NewRequest(http.MethodPost, "/v1.PolarisGRPC/RegisterInstance", strings.NewReader(body))
+		req.Header.Set("Content-Type", jsonContentType)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+		rsp := map[string]interface{}{}
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &rsp))
+		assert.Equal(t, "svc", rsp["instance"].(map[string]interface{})["service"])
+	})
+
+	t.Run("polaris_code", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/v1.PolarisGRPC/DeregisterInstance", strings.NewReader("{}"))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("stream", func(t *testing.T) {
+		body := `{"type": "INSTANCE", "service": {"name": "svc", "namespace": "default"}}`
+		req := httptest.NewRequest(http.MethodPost, "/v1.PolarisGRPC/Discover", strings.NewReader(body))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"svc"`)
+	})
+
+	t.Run("grpc_error", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/v1.PolarisGRPC/ReportClient", strings.NewReader("{}"))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Contains(t, w.Body.String(), "forbidden")
+	})
+
+	t.Run("unknown_method", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/v1.PolarisGRPC/NotExist", strings.NewReader("{}"))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestWebHandler_GrpcWeb(t *testing.T) {
+	handler := newMockWebHandler()
+
+	in := &apiservice.Instance{Service: utils.NewStringValue("svc"), Namespace: utils.NewStringValue("default")}
+	payload, err := proto.Marshal(protoadapt.MessageV2Of(in))
+	assert.NoError(t, err)
+	req := httptest.NewRequest(http.MethodPost, "/v1.PolarisGRPC/RegisterInstance",
+		bytes.NewReader(encodeGrpcFrame(0, payload)))
+	req.Header.Set("Content-Type", grpcWebContentType+"+proto")
+	req.Header.Set("Origin", "http://console.polaris")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, grpcWebContentType+"+proto", w.Header().Get("Content-Type"))
+	assert.Equal(t, "http://console.polaris", w.Header().Get("Access-Control-Allow-Origin"))
+
+	recorder := &grpcResponseRecorder{}
+	recorder.body.Write(w.Body.Bytes())
+	message, ok := recorder.firstMessage()
+	assert.True(t, ok)
+	rsp := &apiservice.Response{}
+	assert.NoError(t, proto.Unmarshal(message, protoadapt.MessageV2Of(rsp)))
+	assert.Equal(t, "svc", rsp.GetInstance().GetService().GetValue())
+
+	trailer := w.Body.Bytes()[grpcFrameHeaderLen+len(message):]
+	assert.Equal(t, byte(grpcWebTrailerFlag), trailer[0])
+	assert.Contains(t, string(trailer[grpcFrameHeaderLen:]), "grpc-status: 0\r\n")
+}
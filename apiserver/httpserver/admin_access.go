@@ -20,6 +20,7 @@ package httpserver
 import (
 	"context"
 	"encoding/json"
+	"io"
 	"net/http"
 	"strconv"
 
@@ -30,9 +31,11 @@ import (
 	"github.com/polarismesh/polaris/admin"
 	"github.com/polarismesh/polaris/apiserver/httpserver/docs"
 	httpcommon "github.com/polarismesh/polaris/apiserver/httpserver/utils"
+	"github.com/polarismesh/polaris/auth/policy"
 	api "github.com/polarismesh/polaris/common/api/v1"
 	"github.com/polarismesh/polaris/common/model"
 	"github.com/polarismesh/polaris/common/utils"
+	"github.com/polarismesh/polaris/federation"
 )
 
 // GetIndexServer get index server
@@ -49,6 +52,9 @@ func (h *HTTPServer) index(_ *restful.Request, rsp *restful.Response) {
 	_, _ = rsp.Write([]byte("Polaris Server"))
 }
 
+// mimeYAML 用户、用户组以及鉴权策略以 YAML 格式导出导入时的 Content-Type
+const mimeYAML = "application/x-yaml"
+
 // GetMaintainAccessServer 运维接口
 func (h *HTTPServer) GetAdminAccessServer() *restful.WebService {
 	ws := new(restful.WebService)
@@ -56,6 +62,7 @@ func (h *HTTPServer) GetAdminAccessServer() *restful.WebService {
 
 	ws.Route(docs.EnrichGetServerConnectionsApiDocs(ws.GET("/apiserver/conn").To(h.GetServerConnections)))
 	ws.Route(docs.EnrichGetServerConnStatsApiDocs(ws.GET("/apiserver/conn/stats").To(h.GetServerConnStats)))
+	ws.Route(docs.EnrichGetServerConnDetailsApiDocs(ws.GET("/apiserver/connections").To(h.GetServerConnDetails)))
 	ws.Route(docs.EnrichCloseConnectionsApiDocs(ws.POST("apiserver/conn/close").To(h.CloseConnections)))
 	ws.Route(docs.EnrichFreeOSMemoryApiDocs(ws.POST("/memory/free").To(h.FreeOSMemory)))
 	ws.Route(docs.EnrichCleanInstanceApiDocs(ws.POST("/instance/clean").To(h.CleanInstance)))
@@ -66,8 +73,16 @@ func (h *HTTPServer) GetAdminAccessServer() *restful.WebService {
 	ws.Route(docs.EnrichListLeaderElectionsApiDocs(ws.GET("/leaders").To(h.ListLeaderElections)))
 	ws.Route(docs.EnrichReleaseLeaderElectionApiDocs(ws.POST("/leaders/release").To(h.ReleaseLeaderElection)))
 	ws.Route(docs.EnrichGetCMDBInfoApiDocs(ws.GET("/cmdb/info").To(h.GetCMDBInfo)))
+	ws.Route(docs.EnrichGetChangeSetRecordsApiDocs(ws.GET("/history/changeset").To(h.GetChangeSetRecords)))
+	ws.Route(docs.EnrichGetAuditRecordsApiDocs(ws.GET("/audit/records").To(h.GetAuditRecords)))
+	ws.Route(docs.EnrichExportAuthPoliciesApiDocs(ws.GET("/auth/policies").
+		Produces(restful.MIME_JSON, mimeYAML).To(h.ExportAuthPolicies)))
+	ws.Route(docs.EnrichImportAuthPoliciesApiDocs(ws.POST("/auth/policies").
+		Consumes(restful.MIME_JSON, mimeYAML).To(h.ImportAuthPolicies)))
 	ws.Route(docs.EnrichGetReportClientsApiDocs(ws.GET("/report/clients").To(h.GetReportClients)))
 	ws.Route(docs.EnrichEnablePprofApiDocs(ws.POST("/pprof/enable").To(h.EnablePprof)))
+	ws.Route(docs.EnrichGetFederationSnapshotApiDocs(ws.GET("/federation/snapshot").To(h.GetFederationSnapshot)))
+	ws.Route(docs.EnrichApplyFederationSnapshotApiDocs(ws.POST("/federation/snapshot").To(h.ApplyFederationSnapshot)))
 	return ws
 }
 
@@ -117,6 +132,26 @@ func (h *HTTPServer) GetServerConnStats(req *restful.Request, rsp *restful.Respo
 	}
 }
 
+// GetServerConnDetails 查看server当前持有的连接详情
+// query参数：protocol，可选，查看指定协议server，未指定时返回全部协议
+//
+//	host，可选，查看指定host
+func (h *HTTPServer) GetServerConnDetails(req *restful.Request, rsp *restful.Response) {
+	ctx := initContext(req)
+	params := httpcommon.ParseQueryParams(req)
+	connReq := admin.ConnReq{
+		Protocol: params["protocol"],
+		Host:     params["host"],
+	}
+
+	ret, err := h.maintainServer.GetServerConnDetails(ctx, &connReq)
+	if err != nil {
+		_ = rsp.WriteError(http.StatusBadRequest, err)
+	} else {
+		_ = rsp.WriteAsJson(ret)
+	}
+}
+
 // CloseConnections 关闭指定client ip的连接
 func (h *HTTPServer) CloseConnections(req *restful.Request, rsp *restful.Response) {
 	log.Info("[MAINTAIN] Start doing close connections")
@@ -295,6 +330,102 @@ func (h *HTTPServer) GetCMDBInfo(req *restful.Request, rsp *restful.Response) {
 	_ = rsp.WriteAsJson(ret)
 }
 
+// GetChangeSetRecords 查询同一个变更集下的全部操作记录
+// query参数：id，必须，变更集 ID
+func (h *HTTPServer) GetChangeSetRecords(req *restful.Request, rsp *restful.Response) {
+	ctx := initContext(req)
+	queryParams := httpcommon.ParseQueryParams(req)
+	ret, err := h.maintainServer.GetChangeSetRecords(ctx, queryParams["id"])
+	if err != nil {
+		_ = rsp.WriteErrorString(http.StatusBadRequest, err.Error())
+		return
+	}
+	_ = rsp.WriteAsJson(ret)
+}
+
+// GetAuditRecords 分页查询操作记录
+// query参数：operator、resource_type、operation_type、start_time、end_time(unix 秒)、offset、limit，均可选
+func (h *HTTPServer) GetAuditRecords(req *restful.Request, rsp *restful.Response) {
+	ctx := initContext(req)
+	queryParams := httpcommon.ParseQueryParams(req)
+	ret, err := h.maintainServer.GetAuditRecords(ctx, queryParams)
+	if err != nil {
+		_ = rsp.WriteErrorString(http.StatusBadRequest, err.Error())
+		return
+	}
+	_ = rsp.WriteAsJson(ret)
+}
+
+// ExportAuthPolicies 导出全部用户、用户组以及鉴权策略
+// query参数：format，json 或者 yaml，默认为 json；credentials，是否导出密码以及 token，默认为 false
+func (h *HTTPServer) ExportAuthPolicies(req *restful.Request, rsp *restful.Response) {
+	ctx := initContext(req)
+	format := authPolicyFormat(req)
+	withCredentials, _ := strconv.ParseBool(req.QueryParameter("credentials"))
+	data, err := h.maintainServer.ExportAuthPolicies(ctx, format, withCredentials)
+	if err != nil {
+		_ = rsp.WriteErrorString(http.StatusBadRequest, err.Error())
+		return
+	}
+	contentType := restful.MIME_JSON
+	if format == policy.StrategyFormatYAML {
+		contentType = mimeYAML
+	}
+	rsp.Header().Set(restful.HEADER_ContentType, contentType)
+	_, _ = rsp.Write(data)
+}
+
+// ImportAuthPolicies 导入 ExportAuthPolicies 导出的用户、用户组以及鉴权策略
+// query参数：format，json 或者 yaml，默认为 json，必须与请求内容的格式一致
+func (h *HTTPServer) ImportAuthPolicies(req *restful.Request, rsp *restful.Response) {
+	ctx := initContext(req)
+	data, err := io.ReadAll(req.Request.Body)
+	if err != nil {
+		_ = rsp.WriteErrorString(http.StatusBadRequest, err.Error())
+		return
+	}
+	ret, err := h.maintainServer.ImportAuthPolicies(ctx, authPolicyFormat(req), data)
+	if err != nil {
+		_ = rsp.WriteErrorString(http.StatusBadRequest, err.Error())
+		return
+	}
+	_ = rsp.WriteAsJson(ret)
+}
+
+// GetFederationSnapshot 获取本集群自身拥有的服务以及实例，供远端集群拉取
+func (h *HTTPServer) GetFederationSnapshot(req *restful.Request, rsp *restful.Response) {
+	ctx := initContext(req)
+	ret, err := h.maintainServer.GetFederationSnapshot(ctx)
+	if err != nil {
+		_ = rsp.WriteErrorString(http.StatusBadRequest, err.Error())
+		return
+	}
+	_ = rsp.WriteAsJson(ret)
+}
+
+// ApplyFederationSnapshot 接收远端集群推送的快照
+func (h *HTTPServer) ApplyFederationSnapshot(req *restful.Request, rsp *restful.Response) {
+	ctx := initContext(req)
+	snapshot := &federation.Snapshot{}
+	if err := httpcommon.ParseJsonBody(req, snapshot); err != nil {
+		_ = rsp.WriteErrorString(http.StatusBadRequest, err.Error())
+		return
+	}
+	ret, err := h.maintainServer.ApplyFederationSnapshot(ctx, snapshot)
+	if err != nil {
+		_ = rsp.WriteErrorString(http.StatusBadRequest, err.Error())
+		return
+	}
+	_ = rsp.WriteAsJson(ret)
+}
+
+func authPolicyFormat(req *restful.Request) string {
+	if format := req.QueryParameter("format"); format != "" {
+		return format
+	}
+	return policy.StrategyFormatJSON
+}
+
 func (h *HTTPServer) EnablePprof(req *restful.Request, rsp *restful.Response) {
 	var pprofEnable struct {
 		Enable bool `json:"enable"`
@@ -18,7 +18,10 @@
 package httpserver
 
 import (
+	"encoding/json"
+	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/emicklei/go-restful/v3"
 	"github.com/golang/protobuf/proto"
@@ -28,6 +31,7 @@ import (
 
 	"github.com/polarismesh/polaris/apiserver/httpserver/docs"
 	httpcommon "github.com/polarismesh/polaris/apiserver/httpserver/utils"
+	"github.com/polarismesh/polaris/auth"
 	api "github.com/polarismesh/polaris/common/api/v1"
 	"github.com/polarismesh/polaris/common/utils"
 )
@@ -37,6 +41,7 @@ func (h *HTTPServer) GetAuthServer(ws *restful.WebService) error {
 	ws.Route(docs.EnrichAuthStatusApiDocs(ws.GET("/auth/status").To(h.AuthStatus)))
 	//
 	ws.Route(docs.EnrichLoginApiDocs(ws.POST("/user/login").To(h.Login)))
+	ws.Route(docs.EnrichLoginOIDCApiDocs(ws.POST("/user/login/oidc").To(h.LoginOIDC)))
 	ws.Route(docs.EnrichGetUsersApiDocs(ws.GET("/users").To(h.GetUsers)))
 	ws.Route(docs.EnrichCreateUsersApiDocs(ws.POST("/users").To(h.CreateUsers)))
 	ws.Route(docs.EnrichDeleteUsersApiDocs(ws.POST("/users/delete").To(h.DeleteUsers)))
@@ -45,6 +50,10 @@ func (h *HTTPServer) GetAuthServer(ws *restful.WebService) error {
 	ws.Route(docs.EnrichGetUserTokenApiDocs(ws.GET("/user/token").To(h.GetUserToken)))
 	ws.Route(docs.EnrichUpdateUserTokenApiDocs(ws.PUT("/user/token/status").To(h.UpdateUserToken)))
 	ws.Route(docs.EnrichResetUserTokenApiDocs(ws.PUT("/user/token/refresh").To(h.ResetUserToken)))
+	ws.Route(docs.EnrichRefreshTokenApiDocs(ws.POST("/user/token/rotate").To(h.RefreshToken)))
+	ws.Route(docs.EnrichUnlockUserApiDocs(ws.PUT("/user/unlock").To(h.UnlockUser)))
+	ws.Route(docs.EnrichGetUserSessionsApiDocs(ws.GET("/user/sessions").To(h.GetUserSessions)))
+	ws.Route(docs.EnrichRevokeUserSessionsApiDocs(ws.DELETE("/user/sessions").To(h.RevokeUserSessions)))
 	//
 	ws.Route(docs.EnrichCreateGroupApiDocs(ws.POST("/usergroup").To(h.CreateGroup)))
 	ws.Route(docs.EnrichUpdateGroupsApiDocs(ws.PUT("/usergroups").To(h.UpdateGroups)))
@@ -54,9 +63,12 @@ func (h *HTTPServer) GetAuthServer(ws *restful.WebService) error {
 	ws.Route(docs.EnrichGetGroupTokenApiDocs(ws.GET("/usergroup/token").To(h.GetGroupToken)))
 	ws.Route(docs.EnrichUpdateGroupTokenApiDocs(ws.PUT("/usergroup/token/status").To(h.UpdateGroupToken)))
 	ws.Route(docs.EnrichResetGroupTokenApiDocs(ws.PUT("/usergroup/token/refresh").To(h.ResetGroupToken)))
+	ws.Route(docs.EnrichUpdateGroupParentApiDocs(ws.PUT("/usergroup/parent").To(h.UpdateGroupParent)))
 
 	ws.Route(docs.EnrichCreateStrategyApiDocs(ws.POST("/auth/strategy").To(h.CreateStrategy)))
 	ws.Route(docs.EnrichGetStrategyApiDocs(ws.GET("/auth/strategy/detail").To(h.GetStrategy)))
+	ws.Route(docs.EnrichSimulateStrategyApiDocs(ws.POST("/auth/strategy/simulate").To(h.SimulateStrategy)))
+	ws.Route(docs.EnrichCreateStrategiesApiDocs(ws.POST("/auth/strategies").To(h.CreateStrategies)))
 	ws.Route(docs.EnrichUpdateStrategiesApiDocs(ws.PUT("/auth/strategies").To(h.UpdateStrategies)))
 	ws.Route(docs.EnrichDeleteStrategiesApiDocs(ws.POST("/auth/strategies/delete").To(h.DeleteStrategies)))
 	ws.Route(docs.EnrichGetStrategiesApiDocs(ws.GET("/auth/strategies").To(h.GetStrategies)))
@@ -105,6 +117,24 @@ func (h *HTTPServer) Login(req *restful.Request, rsp *restful.Response) {
 	handler.WriteHeaderAndProto(h.userMgn.Login(loginReq))
 }
 
+// LoginOIDC 使用身份提供方签发的 ID Token 登录
+func (h *HTTPServer) LoginOIDC(req *restful.Request, rsp *restful.Response) {
+	handler := &httpcommon.Handler{
+		Request:  req,
+		Response: rsp,
+	}
+
+	loginReq := struct {
+		IDToken string `json:"id_token"`
+	}{}
+	if err := json.NewDecoder(req.Request.Body).Decode(&loginReq); err != nil {
+		handler.WriteHeaderAndProto(api.NewAuthResponseWithMsg(apimodel.Code_ParseException, err.Error()))
+		return
+	}
+
+	handler.WriteHeaderAndProto(h.userMgn.LoginOIDC(handler.ParseHeaderContext(), loginReq.IDToken))
+}
+
 // CreateUsers 批量创建用户
 func (h *HTTPServer) CreateUsers(req *restful.Request, rsp *restful.Response) {
 	handler := &httpcommon.Handler{
@@ -249,6 +279,68 @@ func (h *HTTPServer) ResetUserToken(req *restful.Request, rsp *restful.Response)
 	handler.WriteHeaderAndProto(h.userMgn.ResetUserToken(ctx, user))
 }
 
+// UnlockUser 解除用户因为登录失败次数过多导致的锁定
+func (h *HTTPServer) UnlockUser(req *restful.Request, rsp *restful.Response) {
+	handler := &httpcommon.Handler{
+		Request:  req,
+		Response: rsp,
+	}
+
+	user := &apisecurity.User{}
+
+	ctx, err := handler.Parse(user)
+	if err != nil {
+		handler.WriteHeaderAndProto(api.NewResponseWithMsg(apimodel.Code_ParseException, err.Error()))
+		return
+	}
+
+	handler.WriteHeaderAndProto(h.userMgn.UnlockUser(ctx, user))
+}
+
+// RefreshToken 轮换当前用户自身的 token
+func (h *HTTPServer) RefreshToken(req *restful.Request, rsp *restful.Response) {
+	handler := &httpcommon.Handler{
+		Request:  req,
+		Response: rsp,
+	}
+
+	handler.WriteHeaderAndProto(h.userMgn.RefreshToken(handler.ParseHeaderContext()))
+}
+
+// GetUserSessions 查询用户全部有效的控制台登录会话
+func (h *HTTPServer) GetUserSessions(req *restful.Request, rsp *restful.Response) {
+	handler := &httpcommon.Handler{
+		Request:  req,
+		Response: rsp,
+	}
+
+	sessions, err := h.userMgn.ListUserSessions(handler.ParseHeaderContext(), req.QueryParameter("id"))
+	if err != nil {
+		_ = rsp.WriteErrorString(http.StatusBadRequest, err.Error())
+		return
+	}
+	_ = rsp.WriteAsJson(sessions)
+}
+
+// RevokeUserSessions 注销用户的控制台登录会话，未指定 session 时注销该用户的全部会话
+func (h *HTTPServer) RevokeUserSessions(req *restful.Request, rsp *restful.Response) {
+	handler := &httpcommon.Handler{
+		Request:  req,
+		Response: rsp,
+	}
+
+	var sessionIDs []string
+	if val := req.QueryParameter("session"); val != "" {
+		sessionIDs = strings.Split(val, ",")
+	}
+	err := h.userMgn.RevokeUserSessions(handler.ParseHeaderContext(), req.QueryParameter("id"), sessionIDs)
+	if err != nil {
+		_ = rsp.WriteErrorString(http.StatusBadRequest, err.Error())
+		return
+	}
+	handler.WriteHeaderAndProto(api.NewResponse(apimodel.Code_ExecuteSuccess))
+}
+
 // CreateGroup 创建用户组
 func (h *HTTPServer) CreateGroup(req *restful.Request, rsp *restful.Response) {
 	handler := &httpcommon.Handler{
@@ -394,6 +486,29 @@ func (h *HTTPServer) ResetGroupToken(req *restful.Request, rsp *restful.Response
 	handler.WriteHeaderAndProto(h.userMgn.ResetGroupToken(ctx, group))
 }
 
+// UpdateGroupParent 设置用户组的父用户组
+func (h *HTTPServer) UpdateGroupParent(req *restful.Request, rsp *restful.Response) {
+	handler := &httpcommon.Handler{
+		Request:  req,
+		Response: rsp,
+	}
+
+	parentReq := struct {
+		ID       string `json:"id"`
+		ParentID string `json:"parent_id"`
+	}{}
+	if err := httpcommon.ParseJsonBody(req, &parentReq); err != nil {
+		_ = rsp.WriteErrorString(http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := h.userMgn.UpdateGroupParent(handler.ParseHeaderContext(), parentReq.ID,
+		parentReq.ParentID); err != nil {
+		_ = rsp.WriteErrorString(http.StatusBadRequest, err.Error())
+		return
+	}
+	handler.WriteHeaderAndProto(api.NewResponse(apimodel.Code_ExecuteSuccess))
+}
+
 // CreateStrategy 创建鉴权策略
 func (h *HTTPServer) CreateStrategy(req *restful.Request, rsp *restful.Response) {
 	handler := &httpcommon.Handler{
@@ -412,6 +527,28 @@ func (h *HTTPServer) CreateStrategy(req *restful.Request, rsp *restful.Response)
 	handler.WriteHeaderAndProto(h.strategyMgn.CreateStrategy(ctx, strategy))
 }
 
+// CreateStrategies 批量创建鉴权策略
+func (h *HTTPServer) CreateStrategies(req *restful.Request, rsp *restful.Response) {
+	handler := &httpcommon.Handler{
+		Request:  req,
+		Response: rsp,
+	}
+
+	var strategies StrategyArr
+
+	ctx, err := handler.ParseArray(func() proto.Message {
+		msg := &apisecurity.AuthStrategy{}
+		strategies = append(strategies, msg)
+		return msg
+	})
+	if err != nil {
+		handler.WriteHeaderAndProto(api.NewBatchWriteResponseWithMsg(apimodel.Code_ParseException, err.Error()))
+		return
+	}
+
+	handler.WriteHeaderAndProto(h.strategyMgn.CreateStrategies(ctx, strategies))
+}
+
 // UpdateStrategies 更新鉴权策略
 func (h *HTTPServer) UpdateStrategies(req *restful.Request, rsp *restful.Response) {
 	handler := &httpcommon.Handler{
@@ -456,6 +593,26 @@ func (h *HTTPServer) DeleteStrategies(req *restful.Request, rsp *restful.Respons
 	handler.WriteHeaderAndProto(h.strategyMgn.DeleteStrategies(ctx, strategies))
 }
 
+// SimulateStrategy 模拟一次鉴权请求，返回是否允许以及做出决策的鉴权策略
+func (h *HTTPServer) SimulateStrategy(req *restful.Request, rsp *restful.Response) {
+	handler := &httpcommon.Handler{
+		Request:  req,
+		Response: rsp,
+	}
+
+	simulateReq := &auth.SimulateRequest{}
+	if err := httpcommon.ParseJsonBody(req, simulateReq); err != nil {
+		_ = rsp.WriteErrorString(http.StatusBadRequest, err.Error())
+		return
+	}
+	ret, err := h.strategyMgn.SimulateStrategy(handler.ParseHeaderContext(), simulateReq)
+	if err != nil {
+		_ = rsp.WriteErrorString(http.StatusBadRequest, err.Error())
+		return
+	}
+	_ = rsp.WriteAsJson(ret)
+}
+
 // GetStrategies 批量获取鉴权策略
 func (h *HTTPServer) GetStrategies(req *restful.Request, rsp *restful.Response) {
 	handler := &httpcommon.Handler{
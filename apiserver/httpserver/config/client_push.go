@@ -0,0 +1,310 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+package config
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/emicklei/go-restful/v3"
+	apiconfig "github.com/polarismesh/specification/source/go/api/v1/config_manage"
+	apimodel "github.com/polarismesh/specification/source/go/api/v1/model"
+	"go.uber.org/zap"
+	"golang.org/x/net/websocket"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	httpcommon "github.com/polarismesh/polaris/apiserver/httpserver/utils"
+	"github.com/polarismesh/polaris/common/eventhub"
+	"github.com/polarismesh/polaris/common/model"
+)
+
+const (
+	// pushQueueSize 每个连接待发送消息队列的长度，队列已满说明客户端无法及时接收，直接断开连接由客户端重新订阅
+	pushQueueSize = 128
+
+	pushTypeSubscribe   = "subscribe"
+	pushTypeUnsubscribe = "unsubscribe"
+	pushTypeAck         = "ack"
+	pushTypePublish     = "publish"
+	pushTypeHeartbeat   = "heartbeat"
+)
+
+var (
+	// pushHeartbeatInterval websocket 连接的心跳间隔
+	pushHeartbeatInterval = 30 * time.Second
+	// pushReadTimeout 超过该时间没有收到客户端的任何消息时断开连接，客户端需要回复服务端的心跳
+	pushReadTimeout = 3 * pushHeartbeatInterval
+	// pushWriteTimeout 单条消息的发送超时时间
+	pushWriteTimeout = 10 * time.Second
+)
+
+// pushMessage websocket 推送通道上交互的消息
+// 客户端发送 subscribe/unsubscribe，服务端回复 ack，配置发布时推送 publish
+type pushMessage struct {
+	Type      string   `json:"type"`
+	Namespace string   `json:"namespace,omitempty"`
+	Group     string   `json:"group,omitempty"`
+	FileName  string   `json:"fileName,omitempty"`
+	Name      string   `json:"name,omitempty"`
+	Version   uint64   `json:"version,omitempty"`
+	Md5       string   `json:"md5,omitempty"`
+	Valid     *bool    `json:"valid,omitempty"`
+	Code      uint32   `json:"code,omitempty"`
+	Info      string   `json:"info,omitempty"`
+	Files     []string `json:"files,omitempty"`
+}
+
+// pushGroupKey 订阅的配置分组
+type pushGroupKey struct {
+	namespace string
+	group     string
+}
+
+// configPushSession 单个 websocket 连接的订阅关系，websocket 不支持并发写，所有消息放入 queue 后由 run 串行发送
+type configPushSession struct {
+	conn      *websocket.Conn
+	queue     chan *pushMessage
+	done      chan struct{}
+	closeOnce sync.Once
+	lock      sync.RWMutex
+	// groups 订阅的配置分组，value 为订阅的配置文件，为空表示订阅分组下全部配置文件
+	groups map[pushGroupKey]map[string]struct{}
+}
+
+func newConfigPushSession(conn *websocket.Conn, queueSize int) *configPushSession {
+	return &configPushSession{
+		conn:   conn,
+		queue:  make(chan *pushMessage, queueSize),
+		done:   make(chan struct{}),
+		groups: map[pushGroupKey]map[string]struct{}{},
+	}
+}
+
+// SubscribeConfigFile 通过 websocket 订阅配置分组，配置发布时主动推送通知，替代 WatchConfigFile 的长轮询
+// 灰度发布不会推送，处于灰度中的客户端仍然需要通过长轮询获取
+func (h *HTTPServer) SubscribeConfigFile(req *restful.Request, rsp *restful.Response) {
+	handler := &httpcommon.Handler{
+		Request:  req,
+		Response: rsp,
+	}
+	ctx := handler.ParseHeaderContext()
+	server := websocket.Server{
+		Handshake: checkPushOrigin,
+		Handler: func(conn *websocket.Conn) {
+			h.servePush(ctx, conn)
+		},
+	}
+	server.ServeHTTP(rsp.ResponseWriter, req.Request)
+}
+
+// checkPushOrigin 非浏览器客户端不会携带 Origin，携带 Origin 时只允许同源的页面建立连接，避免跨站劫持 websocket
+func checkPushOrigin(config *websocket.Config, req *http.Request) error {
+	if req.Header.Get("Origin") == "" {
+		return nil
+	}
+	origin, err := websocket.Origin(config, req)
+	if err != nil {
+		return err
+	}
+	if origin == nil || origin.Host != req.Host {
+		return fmt.Errorf("cross origin websocket request from %s is not allowed", req.Header.Get("Origin"))
+	}
+	return nil
+}
+
+func (h *HTTPServer) servePush(ctx context.Context, conn *websocket.Conn) {
+	session := newConfigPushSession(conn, pushQueueSize)
+	defer session.close()
+
+	subCtx, err := eventhub.SubscribeWithFunc(eventhub.ConfigFilePublishTopic,
+		func(_ context.Context, any any) error {
+			event, ok := any.(*eventhub.PublishConfigFileEvent)
+			if !ok || event.Message == nil {
+				return nil
+			}
+			session.onPublish(event.Message)
+			return nil
+		})
+	if err != nil {
+		configLog.Error("[Config][Push] subscribe config publish event", zap.Error(err))
+		return
+	}
+	defer subCtx.Cancel()
+
+	go session.run()
+
+	for {
+		// 长连接不使用 http server 的读写超时，由心跳决定连接是否存活
+		_ = conn.SetReadDeadline(time.Now().Add(pushReadTimeout))
+		msg := &pushMessage{}
+		if err := websocket.JSON.Receive(conn, msg); err != nil {
+			configLog.Debug("[Config][Push] connection closed", zap.String("client", conn.Request().RemoteAddr),
+				zap.Error(err))
+			return
+		}
+		switch msg.Type {
+		case pushTypeHeartbeat:
+		case pushTypeSubscribe:
+			session.write(h.subscribe(ctx, session, msg))
+		case pushTypeUnsubscribe:
+			session.unsubscribe(msg)
+			session.write(&pushMessage{Type: pushTypeAck, Namespace: msg.Namespace, Group: msg.Group,
+				FileName: msg.FileName, Code: uint32(apimodel.Code_ExecuteSuccess)})
+		default:
+			session.write(&pushMessage{Type: pushTypeAck, Code: uint32(apimodel.Code_InvalidParameter),
+				Info: "unknown message type: " + msg.Type})
+		}
+	}
+}
+
+// subscribe 校验配置分组的读权限后记录订阅关系
+func (h *HTTPServer) subscribe(ctx context.Context, session *configPushSession, msg *pushMessage) *pushMessage {
+	ack := &pushMessage{Type: pushTypeAck, Namespace: msg.Namespace, Group: msg.Group, FileName: msg.FileName}
+	if msg.Namespace == "" || msg.Group == "" {
+		ack.Code = uint32(apimodel.Code_InvalidParameter)
+		ack.Info = "namespace and group are required"
+		return ack
+	}
+	resp := h.configServer.GetConfigFileNamesWithCache(ctx, &apiconfig.ConfigFileGroupRequest{
+		ConfigFileGroup: &apiconfig.ConfigFileGroup{
+			Namespace: wrapperspb.String(msg.Namespace),
+			Name:      wrapperspb.String(msg.Group),
+		},
+	})
+	ack.Code = resp.GetCode().GetValue()
+	ack.Info = resp.GetInfo().GetValue()
+	if ack.Code != uint32(apimodel.Code_ExecuteSuccess) && ack.Code != uint32(apimodel.Code_DataNoChange) {
+		return ack
+	}
+	ack.Code = uint32(apimodel.Code_ExecuteSuccess)
+	for _, item := range resp.GetConfigFileInfos() {
+		ack.Files = append(ack.Files, item.GetFileName().GetValue())
+	}
+
+	session.lock.Lock()
+	defer session.lock.Unlock()
+	key := pushGroupKey{namespace: msg.Namespace, group: msg.Group}
+	files, ok := session.groups[key]
+	if msg.FileName == "" {
+		session.groups[key] = map[string]struct{}{}
+		return ack
+	}
+	if ok && len(files) == 0 {
+		// 已经订阅了整个分组
+		return ack
+	}
+	if !ok {
+		files = map[string]struct{}{}
+		session.groups[key] = files
+	}
+	files[msg.FileName] = struct{}{}
+	return ack
+}
+
+// unsubscribe 取消订阅，未指定配置文件时取消整个分组
+func (s *configPushSession) unsubscribe(msg *pushMessage) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	key := pushGroupKey{namespace: msg.Namespace, group: msg.Group}
+	files, ok := s.groups[key]
+	if !ok {
+		return
+	}
+	if msg.FileName == "" {
+		delete(s.groups, key)
+		return
+	}
+	delete(files, msg.FileName)
+	if len(files) == 0 {
+		delete(s.groups, key)
+	}
+}
+
+func (s *configPushSession) subscribed(release *model.SimpleConfigFileRelease) bool {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	files, ok := s.groups[pushGroupKey{namespace: release.Namespace, group: release.Group}]
+	if !ok {
+		return false
+	}
+	if len(files) == 0 {
+		return true
+	}
+	_, ok = files[release.FileName]
+	return ok
+}
+
+func (s *configPushSession) onPublish(release *model.SimpleConfigFileRelease) {
+	if release.ReleaseType == model.ReleaseTypeGray || !s.subscribed(release) {
+		return
+	}
+	valid := release.Valid
+	s.write(&pushMessage{
+		Type:      pushTypePublish,
+		Namespace: release.Namespace,
+		Group:     release.Group,
+		FileName:  release.FileName,
+		Name:      release.Name,
+		Version:   release.Version,
+		Md5:       release.Md5,
+		Valid:     &valid,
+	})
+}
+
+// run 串行发送队列中的消息以及心跳，发送失败或者超时后断开连接
+func (s *configPushSession) run() {
+	ticker := time.NewTicker(pushHeartbeatInterval)
+	defer ticker.Stop()
+	for {
+		var msg *pushMessage
+		select {
+		case <-s.done:
+			return
+		case msg = <-s.queue:
+		case <-ticker.C:
+			msg = &pushMessage{Type: pushTypeHeartbeat}
+		}
+		_ = s.conn.SetWriteDeadline(time.Now().Add(pushWriteTimeout))
+		if err := websocket.JSON.Send(s.conn, msg); err != nil {
+			configLog.Debug("[Config][Push] send message", zap.String("type", msg.Type), zap.Error(err))
+			s.close()
+			return
+		}
+	}
+}
+
+// write 将消息放入待发送队列，不会阻塞调用方，队列已满时断开连接
+func (s *configPushSession) write(msg *pushMessage) {
+	select {
+	case <-s.done:
+	case s.queue <- msg:
+	default:
+		configLog.Warn("[Config][Push] push queue full, close connection",
+			zap.String("client", s.conn.Request().RemoteAddr), zap.String("type", msg.Type))
+		s.close()
+	}
+}
+
+func (s *configPushSession) close() {
+	s.closeOnce.Do(func() {
+		close(s.done)
+		_ = s.conn.Close()
+	})
+}
@@ -0,0 +1,165 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package config
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	apiconfig "github.com/polarismesh/specification/source/go/api/v1/config_manage"
+	apimodel "github.com/polarismesh/specification/source/go/api/v1/model"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/websocket"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	"github.com/polarismesh/polaris/common/eventhub"
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/config"
+)
+
+// mockPushConfigServer 只实现订阅时需要的查询配置文件列表
+type mockPushConfigServer struct {
+	config.ConfigCenterServer
+}
+
+func (s *mockPushConfigServer) GetConfigFileNamesWithCache(context.Context,
+	*apiconfig.ConfigFileGroupRequest) *apiconfig.ConfigClientListResponse {
+	return &apiconfig.ConfigClientListResponse{
+		Code: wrapperspb.UInt32(uint32(apimodel.Code_ExecuteSuccess)),
+		ConfigFileInfos: []*apiconfig.ClientConfigFileInfo{
+			{FileName: wrapperspb.String("app.yaml")},
+		},
+	}
+}
+
+func newPushTestServer(t *testing.T) *httptest.Server {
+	h := &HTTPServer{configServer: &mockPushConfigServer{}}
+	server := httptest.NewServer(websocket.Server{
+		Handshake: checkPushOrigin,
+		Handler: func(conn *websocket.Conn) {
+			h.servePush(context.Background(), conn)
+		},
+	})
+	t.Cleanup(server.Close)
+	return server
+}
+
+func dialPush(server *httptest.Server, origin string) (*websocket.Conn, error) {
+	return websocket.Dial(strings.Replace(server.URL, "http", "ws", 1), "", origin)
+}
+
+// receive 接收一条非心跳的消息
+func receive(t *testing.T, conn *websocket.Conn) *pushMessage {
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	for {
+		msg := &pushMessage{}
+		assert.NoError(t, websocket.JSON.Receive(conn, msg))
+		if msg.Type != pushTypeHeartbeat {
+			return msg
+		}
+	}
+}
+
+func Test_ConfigPush(t *testing.T) {
+	eventhub.InitEventHub()
+	server := newPushTestServer(t)
+
+	t.Run("订阅后推送配置发布", func(t *testing.T) {
+		conn, err := dialPush(server, server.URL)
+		assert.NoError(t, err)
+		defer conn.Close()
+
+		assert.NoError(t, websocket.JSON.Send(conn, &pushMessage{Type: pushTypeSubscribe,
+			Namespace: "default", Group: "group"}))
+		ack := receive(t, conn)
+		assert.Equal(t, pushTypeAck, ack.Type)
+		assert.Equal(t, uint32(apimodel.Code_ExecuteSuccess), ack.Code)
+		assert.Equal(t, []string{"app.yaml"}, ack.Files)
+
+		for _, group := range []string{"other", "group"} {
+			assert.NoError(t, eventhub.Publish(eventhub.ConfigFilePublishTopic, &eventhub.PublishConfigFileEvent{
+				Message: &model.SimpleConfigFileRelease{
+					ConfigFileReleaseKey: &model.ConfigFileReleaseKey{
+						Namespace: "default", Group: group, FileName: "app.yaml", Name: "release-1",
+					},
+					Version: 2,
+					Valid:   true,
+				},
+			}))
+		}
+		msg := receive(t, conn)
+		assert.Equal(t, pushTypePublish, msg.Type)
+		assert.Equal(t, "group", msg.Group)
+		assert.Equal(t, uint64(2), msg.Version)
+	})
+
+	t.Run("拒绝跨站的连接", func(t *testing.T) {
+		_, err := dialPush(server, "http://evil.example.com")
+		assert.Error(t, err)
+	})
+
+	t.Run("客户端不回复心跳时断开连接", func(t *testing.T) {
+		interval, timeout := pushHeartbeatInterval, pushReadTimeout
+		pushHeartbeatInterval, pushReadTimeout = 50*time.Millisecond, 200*time.Millisecond
+		defer func() {
+			pushHeartbeatInterval, pushReadTimeout = interval, timeout
+		}()
+
+		conn, err := dialPush(server, server.URL)
+		assert.NoError(t, err)
+		defer conn.Close()
+		_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		var heartbeat int
+		for {
+			msg := &pushMessage{}
+			if err := websocket.JSON.Receive(conn, msg); err != nil {
+				break
+			}
+			heartbeat++
+		}
+		assert.Greater(t, heartbeat, 0)
+	})
+}
+
+func Test_ConfigPushSession_QueueFull(t *testing.T) {
+	server := httptest.NewServer(websocket.Handler(func(conn *websocket.Conn) {
+		session := newConfigPushSession(conn, 2)
+		// 不启动发送协程，模拟无法及时接收消息的客户端
+		for i := 0; i < 3; i++ {
+			session.write(&pushMessage{Type: pushTypePublish})
+		}
+		select {
+		case <-session.done:
+		default:
+			t.Error("session should be closed when queue is full")
+		}
+		// 关闭之后写入不会阻塞
+		session.write(&pushMessage{Type: pushTypePublish})
+	}))
+	defer server.Close()
+
+	conn, err := dialPush(server, server.URL)
+	assert.NoError(t, err)
+	defer conn.Close()
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	msg := &pushMessage{}
+	assert.Error(t, websocket.JSON.Receive(conn, msg))
+}
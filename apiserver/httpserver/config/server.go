@@ -156,6 +156,7 @@ func (h *HTTPServer) addDiscover(ws *restful.WebService) {
 	ws.Route(docs.EnrichGetConfigFileForClientApiDocs(ws.GET("/GetConfigFile").To(h.ClientGetConfigFile)))
 	ws.Route(docs.EnrichWatchConfigFileForClientApiDocs(ws.POST("/WatchConfigFile").To(h.ClientWatchConfigFile)))
 	ws.Route(docs.EnrichGetConfigFileMetadataList(ws.POST("/GetConfigFileMetadataList").To(h.GetConfigFileMetadataList)))
+	ws.Route(docs.EnrichSubscribeConfigFileForClientApiDocs(ws.GET("/SubscribeConfigFile").To(h.SubscribeConfigFile)))
 }
 
 func (h *HTTPServer) addCreateFile(ws *restful.WebService) {
@@ -21,6 +21,7 @@ import (
 	"context"
 
 	"github.com/emicklei/go-restful/v3"
+	"github.com/golang/protobuf/proto"
 	apimodel "github.com/polarismesh/specification/source/go/api/v1/model"
 	apiservice "github.com/polarismesh/specification/source/go/api/v1/service_manage"
 
@@ -69,6 +70,27 @@ func (h *HTTPServerV1) RegisterInstance(req *restful.Request, rsp *restful.Respo
 	handler.WriteHeaderAndProto(h.namingServer.RegisterInstance(ctx, instance))
 }
 
+// BatchRegisterInstance 批量注册服务实例
+func (h *HTTPServerV1) BatchRegisterInstance(req *restful.Request, rsp *restful.Response) {
+	handler := &httpcommon.Handler{
+		Request:  req,
+		Response: rsp,
+	}
+
+	var instances InstanceArr
+	ctx, err := handler.ParseArray(func() proto.Message {
+		msg := &apiservice.Instance{}
+		instances = append(instances, msg)
+		return msg
+	})
+	if err != nil {
+		handler.WriteHeaderAndProto(api.NewBatchWriteResponseWithMsg(apimodel.Code_ParseException, err.Error()))
+		return
+	}
+
+	handler.WriteHeaderAndProto(h.namingServer.BatchRegisterInstance(ctx, instances))
+}
+
 // DeregisterInstance 反注册服务实例
 func (h *HTTPServerV1) DeregisterInstance(req *restful.Request, rsp *restful.Response) {
 	handler := &httpcommon.Handler{
@@ -161,3 +183,24 @@ func (h *HTTPServerV1) Heartbeat(req *restful.Request, rsp *restful.Response) {
 
 	handler.WriteHeaderAndProto(h.healthCheckServer.Report(ctx, instance))
 }
+
+// BatchHeartbeat 批量上报服务实例心跳
+func (h *HTTPServerV1) BatchHeartbeat(req *restful.Request, rsp *restful.Response) {
+	handler := &httpcommon.Handler{
+		Request:  req,
+		Response: rsp,
+	}
+
+	var instances InstanceArr
+	ctx, err := handler.ParseArray(func() proto.Message {
+		msg := &apiservice.Instance{}
+		instances = append(instances, msg)
+		return msg
+	})
+	if err != nil {
+		handler.WriteHeaderAndProto(api.NewBatchWriteResponseWithMsg(apimodel.Code_ParseException, err.Error()))
+		return
+	}
+
+	handler.WriteHeaderAndProto(h.healthCheckServer.BatchReport(ctx, instances))
+}
@@ -20,6 +20,7 @@ package v1
 import (
 	"context"
 	"net/http"
+	"time"
 
 	"github.com/emicklei/go-restful/v3"
 	"github.com/golang/protobuf/proto"
@@ -31,6 +32,7 @@ import (
 	httpcommon "github.com/polarismesh/polaris/apiserver/httpserver/utils"
 	api "github.com/polarismesh/polaris/common/api/v1"
 	"github.com/polarismesh/polaris/common/utils"
+	"github.com/polarismesh/polaris/service"
 )
 
 // CreateNamespaces 创建命名空间
@@ -382,6 +384,22 @@ func (h *HTTPServerV1) GetServiceAliases(req *restful.Request, rsp *restful.Resp
 	handler.WriteHeaderAndProto(ret)
 }
 
+// GetServiceDependencies 查询服务的上下游依赖关系
+func (h *HTTPServerV1) GetServiceDependencies(req *restful.Request, rsp *restful.Response) {
+	handler := &httpcommon.Handler{
+		Request:  req,
+		Response: rsp,
+	}
+
+	queryParams := httpcommon.ParseQueryParams(req)
+	queryParams["service"] = req.PathParameter("name")
+	if queryParams["namespace"] == "" {
+		queryParams["namespace"] = service.DefaultNamespace
+	}
+	ret := h.namingServer.GetServiceDependencies(handler.ParseHeaderContext(), queryParams)
+	_ = rsp.WriteHeaderAndJson(int(ret.Code/1000), ret, restful.MIME_JSON)
+}
+
 // CreateInstances 创建服务实例
 func (h *HTTPServerV1) CreateInstances(req *restful.Request, rsp *restful.Response) {
 	handler := &httpcommon.Handler{
@@ -511,6 +529,40 @@ func (h *HTTPServerV1) UpdateInstancesIsolate(req *restful.Request, rsp *restful
 	handler.WriteHeaderAndProto(ret)
 }
 
+// defaultDrainingTTL 未指定 ttl 时实例优雅下线的时间
+const defaultDrainingTTL = 30 * time.Second
+
+// DrainInstances 服务实例优雅下线，ttl 到期后实例被自动删除
+func (h *HTTPServerV1) DrainInstances(req *restful.Request, rsp *restful.Response) {
+	handler := &httpcommon.Handler{
+		Request:  req,
+		Response: rsp,
+	}
+
+	ttl := defaultDrainingTTL
+	if val := req.QueryParameter("ttl"); val != "" {
+		parsed, err := time.ParseDuration(val)
+		if err != nil {
+			handler.WriteHeaderAndProto(api.NewBatchWriteResponseWithMsg(apimodel.Code_InvalidParameter, err.Error()))
+			return
+		}
+		ttl = parsed
+	}
+
+	var instances InstanceArr
+	ctx, err := handler.ParseArray(func() proto.Message {
+		msg := &apiservice.Instance{}
+		instances = append(instances, msg)
+		return msg
+	})
+	if err != nil {
+		handler.WriteHeaderAndProto(api.NewBatchWriteResponseWithMsg(apimodel.Code_ParseException, err.Error()))
+		return
+	}
+
+	handler.WriteHeaderAndProto(h.namingServer.DrainInstances(ctx, instances, ttl))
+}
+
 // GetInstances 查询服务实例
 func (h *HTTPServerV1) GetInstances(req *restful.Request, rsp *restful.Response) {
 	handler := &httpcommon.Handler{
@@ -106,6 +106,10 @@ func (h *HTTPServerV1) addDefaultReadAccess(ws *restful.WebService) {
 	ws.Route(docs.EnrichGetNamespacesApiDocs(ws.GET("/namespaces").To(h.GetNamespaces)))
 	ws.Route(docs.EnrichGetServicesApiDocs(ws.GET("/services").To(h.GetServices)))
 	ws.Route(docs.EnrichGetServicesCountApiDocs(ws.GET("/services/count").To(h.GetServicesCount)))
+	ws.Route(docs.EnrichWatchServiceApiDocs(ws.GET("/services/watch").To(h.WatchService).
+		Produces("text/event-stream", restful.MIME_JSON)))
+	ws.Route(docs.EnrichGetServiceDependenciesApiDocs(
+		ws.GET("/services/{name}/dependencies").To(h.GetServiceDependencies)))
 	ws.Route(docs.EnrichGetServiceAliasesApiDocs(ws.GET("/service/aliases").To(h.GetServiceAliases)))
 
 	ws.Route(docs.EnrichGetInstancesApiDocs(ws.GET("/instances").To(h.GetInstances)))
@@ -159,6 +163,10 @@ func (h *HTTPServerV1) addServiceAccess(ws *restful.WebService) {
 	ws.Route(docs.EnrichGetServicesApiDocs(ws.GET("/services").To(h.GetServices)))
 	ws.Route(docs.EnrichGetAllServicesApiDocs(ws.GET("/services/all").To(h.GetAllServices)))
 	ws.Route(docs.EnrichGetServicesCountApiDocs(ws.GET("/services/count").To(h.GetServicesCount)))
+	ws.Route(docs.EnrichWatchServiceApiDocs(ws.GET("/services/watch").To(h.WatchService).
+		Produces("text/event-stream", restful.MIME_JSON)))
+	ws.Route(docs.EnrichGetServiceDependenciesApiDocs(
+		ws.GET("/services/{name}/dependencies").To(h.GetServiceDependencies)))
 	ws.Route(docs.EnrichGetServiceTokenApiDocs(ws.GET("/service/token").To(h.GetServiceToken)))
 	ws.Route(docs.EnrichUpdateServiceTokenApiDocs(ws.PUT("/service/token").To(h.UpdateServiceToken)))
 	ws.Route(docs.EnrichCreateServiceAliasApiDocs(ws.POST("/service/alias").To(h.CreateServiceAlias)))
@@ -174,6 +182,7 @@ func (h *HTTPServerV1) addServiceAccess(ws *restful.WebService) {
 	ws.Route(docs.EnrichUpdateInstancesApiDocs(ws.PUT("/instances").To(h.UpdateInstances)))
 	ws.Route(docs.EnrichUpdateInstancesIsolateApiDocs(
 		ws.PUT("/instances/isolate/host").To(h.UpdateInstancesIsolate)))
+	ws.Route(docs.EnrichDrainInstancesApiDocs(ws.PUT("/instances/drain").To(h.DrainInstances)))
 	ws.Route(docs.EnrichGetInstancesApiDocs(ws.GET("/instances").To(h.GetInstances)))
 	ws.Route(docs.EnrichGetInstancesCountApiDocs(ws.GET("/instances/count").To(h.GetInstancesCount)))
 	ws.Route(docs.EnrichGetInstanceLabelsApiDocs(ws.GET("/instances/labels").To(h.GetInstanceLabels)))
@@ -282,10 +291,13 @@ func (h *HTTPServerV1) addDiscoverAccess(ws *restful.WebService) {
 // addRegisterAccess 增加注册/反注册接口
 func (h *HTTPServerV1) addRegisterAccess(ws *restful.WebService) {
 	ws.Route(docs.EnrichRegisterInstanceApiDocs(ws.POST("/RegisterInstance").To(h.RegisterInstance)))
+	ws.Route(docs.EnrichBatchRegisterInstanceApiDocs(
+		ws.POST("/BatchRegisterInstance").To(h.BatchRegisterInstance)))
 	ws.Route(docs.EnrichDeregisterInstanceApiDocs(ws.POST("/DeregisterInstance").To(h.DeregisterInstance)))
 }
 
 // addHealthCheckAccess 增加健康检查接口
 func (h *HTTPServerV1) addHealthCheckAccess(ws *restful.WebService) {
 	ws.Route(docs.EnrichHeartbeatApiDocs(ws.POST("/Heartbeat").To(h.Heartbeat)))
+	ws.Route(docs.EnrichBatchHeartbeatApiDocs(ws.POST("/BatchHeartbeat").To(h.BatchHeartbeat)))
 }
@@ -0,0 +1,276 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+package v1
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/emicklei/go-restful/v3"
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/golang/protobuf/proto"
+	apimodel "github.com/polarismesh/specification/source/go/api/v1/model"
+	apiservice "github.com/polarismesh/specification/source/go/api/v1/service_manage"
+	"go.uber.org/zap"
+
+	httpcommon "github.com/polarismesh/polaris/apiserver/httpserver/utils"
+	api "github.com/polarismesh/polaris/common/api/v1"
+	"github.com/polarismesh/polaris/common/eventhub"
+	"github.com/polarismesh/polaris/common/utils"
+)
+
+const (
+	// watchEventQueueSize 单个 SSE 连接缓存的实例变更事件数，超过后断开连接由客户端重新同步
+	watchEventQueueSize = 1024
+	// watchKeepaliveInterval SSE 连接的保活间隔，避免被代理断开空闲连接
+	watchKeepaliveInterval = 15 * time.Second
+)
+
+// 推送给客户端的事件类型
+const (
+	watchEventSync         = "sync"
+	watchEventAdd          = "add"
+	watchEventUpdate       = "update"
+	watchEventDelete       = "delete"
+	watchEventHealthChange = "health_change"
+	watchEventReset        = "reset"
+)
+
+// watchEvent SSE 推送的数据内容
+type watchEvent struct {
+	Type      string            `json:"type"`
+	Namespace string            `json:"namespace"`
+	Service   string            `json:"service"`
+	Revision  string            `json:"revision,omitempty"`
+	Instance  json.RawMessage   `json:"instance,omitempty"`
+	Instances []json.RawMessage `json:"instances,omitempty"`
+}
+
+// instanceWatcher 记录单个 SSE 连接已知的实例健康状态，用于区分健康状态变化以及普通的实例更新
+type instanceWatcher struct {
+	lock      sync.RWMutex
+	namespace string
+	service   string
+	healthy   map[string]bool
+	events    chan *eventhub.CacheInstanceEvent
+	overflow  chan struct{}
+}
+
+func newInstanceWatcher(namespace, service string) *instanceWatcher {
+	return &instanceWatcher{
+		namespace: namespace,
+		service:   service,
+		healthy:   map[string]bool{},
+		events:    make(chan *eventhub.CacheInstanceEvent, watchEventQueueSize),
+		overflow:  make(chan struct{}),
+	}
+}
+
+// onEvent eventhub 的回调，不能阻塞 eventhub 的分发，队列满时通知连接处理协程断开
+func (w *instanceWatcher) onEvent(_ context.Context, event any) error {
+	e, ok := event.(*eventhub.CacheInstanceEvent)
+	if !ok || e.Instance == nil {
+		return nil
+	}
+	if !w.match(e.Instance.Namespace(), e.Instance.Service()) {
+		return nil
+	}
+	select {
+	case w.events <- e:
+	default:
+		select {
+		case <-w.overflow:
+		default:
+			close(w.overflow)
+		}
+	}
+	return nil
+}
+
+func (w *instanceWatcher) match(namespace, service string) bool {
+	w.lock.RLock()
+	defer w.lock.RUnlock()
+	return namespace == w.namespace && service == w.service
+}
+
+// watchSource 服务别名的实例变更事件属于源服务
+func (w *instanceWatcher) watchSource(namespace, service string) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	w.namespace = namespace
+	w.service = service
+}
+
+// eventType 将缓存的实例事件转换为推送给客户端的事件类型
+func (w *instanceWatcher) eventType(e *eventhub.CacheInstanceEvent) string {
+	id := e.Instance.ID()
+	switch e.EventType {
+	case eventhub.EventDeleted:
+		delete(w.healthy, id)
+		return watchEventDelete
+	default:
+		healthy := e.Instance.Healthy()
+		old, exist := w.healthy[id]
+		w.healthy[id] = healthy
+		if !exist {
+			return watchEventAdd
+		}
+		if old != healthy {
+			return watchEventHealthChange
+		}
+		return watchEventUpdate
+	}
+}
+
+// WatchService 通过 Server-Sent Events 推送服务实例的新增、删除以及健康状态变化
+// 建立连接后首先推送一个 sync 事件包含当前全部实例，之后只推送变化的实例
+func (h *HTTPServerV1) WatchService(req *restful.Request, rsp *restful.Response) {
+	handler := &httpcommon.Handler{
+		Request:  req,
+		Response: rsp,
+	}
+	namespace := req.QueryParameter("namespace")
+	service := req.QueryParameter("service")
+	if namespace == "" || service == "" {
+		handler.WriteHeaderAndProto(api.NewResponseWithMsg(apimodel.Code_InvalidParameter,
+			"namespace and service is required"))
+		return
+	}
+	flusher, ok := rsp.ResponseWriter.(http.Flusher)
+	if !ok {
+		handler.WriteHeaderAndProto(api.NewResponseWithMsg(apimodel.Code_ExecuteException,
+			"streaming unsupported"))
+		return
+	}
+
+	ctx := handler.ParseHeaderContext()
+	// 先订阅再查询当前的实例列表，避免丢失两者之间发生的变化
+	watcher := newInstanceWatcher(namespace, service)
+	subCtx, err := eventhub.SubscribeWithFunc(eventhub.CacheInstanceEventTopic, watcher.onEvent)
+	if err != nil {
+		handler.WriteHeaderAndProto(api.NewResponseWithMsg(apimodel.Code_ExecuteException, err.Error()))
+		return
+	}
+	defer subCtx.Cancel()
+
+	snapshot := h.namingServer.ServiceInstancesCache(ctx, nil, &apiservice.Service{
+		Namespace: utils.NewStringValue(namespace),
+		Name:      utils.NewStringValue(service),
+	})
+	if snapshot.GetCode().GetValue() != api.ExecuteSuccess {
+		handler.WriteHeaderAndProto(snapshot)
+		return
+	}
+	if aliasFor := snapshot.GetAliasFor(); aliasFor.GetName().GetValue() != "" {
+		watcher.watchSource(aliasFor.GetNamespace().GetValue(), aliasFor.GetName().GetValue())
+	}
+
+	// SSE 为长连接，取消 http.Server 设置的写超时
+	if err := http.NewResponseController(rsp.ResponseWriter).SetWriteDeadline(time.Time{}); err != nil {
+		namingLog.Warn("[HTTP][Watch] clear write deadline", zap.Error(err))
+	}
+	header := rsp.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "keep-alive")
+	header.Set("X-Accel-Buffering", "no")
+	rsp.WriteHeader(http.StatusOK)
+
+	syncEvent := &watchEvent{
+		Type:      watchEventSync,
+		Namespace: namespace,
+		Service:   service,
+		Revision:  snapshot.GetService().GetRevision().GetValue(),
+		Instances: make([]json.RawMessage, 0, len(snapshot.GetInstances())),
+	}
+	for _, ins := range snapshot.GetInstances() {
+		watcher.healthy[ins.GetId().GetValue()] = ins.GetHealthy().GetValue()
+		data, err := marshalWatchProto(ins)
+		if err != nil {
+			namingLog.Error("[HTTP][Watch] marshal instance", zap.String("id", ins.GetId().GetValue()),
+				zap.Error(err))
+			continue
+		}
+		syncEvent.Instances = append(syncEvent.Instances, data)
+	}
+	var seq int64
+	if err := writeWatchEvent(rsp, flusher, seq, syncEvent); err != nil {
+		return
+	}
+
+	keepalive := time.NewTicker(watchKeepaliveInterval)
+	defer keepalive.Stop()
+	for {
+		select {
+		case <-req.Request.Context().Done():
+			return
+		case <-watcher.overflow:
+			namingLog.Warn("[HTTP][Watch] event queue overflow, reset watch connection",
+				zap.String("namespace", namespace), zap.String("service", service))
+			_ = writeWatchEvent(rsp, flusher, seq+1, &watchEvent{
+				Type: watchEventReset, Namespace: namespace, Service: service})
+			return
+		case <-keepalive.C:
+			if _, err := fmt.Fprint(rsp, ": keepalive\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		case e := <-watcher.events:
+			data, err := marshalWatchProto(e.Instance.Proto)
+			if err != nil {
+				namingLog.Error("[HTTP][Watch] marshal instance", zap.String("id", e.Instance.ID()),
+					zap.Error(err))
+				continue
+			}
+			seq++
+			if err := writeWatchEvent(rsp, flusher, seq, &watchEvent{
+				Type:      watcher.eventType(e),
+				Namespace: namespace,
+				Service:   service,
+				Instance:  data,
+			}); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func marshalWatchProto(msg proto.Message) (json.RawMessage, error) {
+	buf := &bytes.Buffer{}
+	if err := (&jsonpb.Marshaler{}).Marshal(buf, msg); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// writeWatchEvent 按照 SSE 的格式写入一个事件，data 为单行 JSON
+func writeWatchEvent(rsp *restful.Response, flusher http.Flusher, seq int64, event *watchEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(rsp, "id: %d\nevent: %s\ndata: %s\n\n", seq, event.Type, data); err != nil {
+		return err
+	}
+	flusher.Flush()
+	return nil
+}
@@ -0,0 +1,246 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package v1
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/emicklei/go-restful/v3"
+	apimodel "github.com/polarismesh/specification/source/go/api/v1/model"
+	apiservice "github.com/polarismesh/specification/source/go/api/v1/service_manage"
+	"github.com/stretchr/testify/assert"
+
+	api "github.com/polarismesh/polaris/common/api/v1"
+	"github.com/polarismesh/polaris/common/eventhub"
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/common/utils"
+	"github.com/polarismesh/polaris/service"
+)
+
+// mockWatchNamingServer 只实现 WatchService 用到的 ServiceInstancesCache
+type mockWatchNamingServer struct {
+	service.DiscoverServer
+	snapshot func() *apiservice.DiscoverResponse
+}
+
+func (m *mockWatchNamingServer) ServiceInstancesCache(_ context.Context, _ *apiservice.DiscoverFilter,
+	_ *apiservice.Service) *apiservice.DiscoverResponse {
+	return m.snapshot()
+}
+
+// watchRecorder 记录 SSE 的输出，gate 不为空时 sync 事件之后的写入阻塞到 gate 关闭
+type watchRecorder struct {
+	lock   sync.Mutex
+	header http.Header
+	buf    bytes.Buffer
+	gate   chan struct{}
+}
+
+func (w *watchRecorder) Header() http.Header {
+	return w.header
+}
+
+func (w *watchRecorder) WriteHeader(int) {
+}
+
+func (w *watchRecorder) Write(b []byte) (int, error) {
+	w.lock.Lock()
+	synced := w.buf.Len() > 0
+	w.lock.Unlock()
+	if synced && w.gate != nil {
+		<-w.gate
+	}
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	return w.buf.Write(b)
+}
+
+func (w *watchRecorder) Flush() {
+}
+
+type sseEvent struct {
+	id    string
+	event string
+	data  *watchEvent
+}
+
+func (w *watchRecorder) events(t *testing.T) []sseEvent {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	ret := make([]sseEvent, 0, 4)
+	for _, block := range strings.Split(w.buf.String(), "\n\n") {
+		if !strings.HasPrefix(block, "id: ") {
+			continue
+		}
+		item := sseEvent{}
+		for _, line := range strings.Split(block, "\n") {
+			switch {
+			case strings.HasPrefix(line, "id: "):
+				item.id = strings.TrimPrefix(line, "id: ")
+			case strings.HasPrefix(line, "event: "):
+				item.event = strings.TrimPrefix(line, "event: ")
+			case strings.HasPrefix(line, "data: "):
+				item.data = &watchEvent{}
+				assert.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), item.data))
+			}
+		}
+		ret = append(ret, item)
+	}
+	return ret
+}
+
+func mockWatchInstance(id, svc string, healthy bool) *model.Instance {
+	return &model.Instance{Proto: &apiservice.Instance{
+		Id:        utils.NewStringValue(id),
+		Namespace: utils.NewStringValue("default"),
+		Service:   utils.NewStringValue(svc),
+		Host:      utils.NewStringValue("127.0.0.1"),
+		Healthy:   utils.NewBoolValue(healthy),
+	}}
+}
+
+func publishInstanceEvent(ins *model.Instance, eventType eventhub.EventType) {
+	_ = eventhub.Publish(eventhub.CacheInstanceEventTopic, &eventhub.CacheInstanceEvent{
+		Instance: ins, EventType: eventType})
+}
+
+func startWatch(svr *HTTPServerV1, recorder *watchRecorder) (context.CancelFunc, chan struct{}) {
+	ctx, cancel := context.WithCancel(context.Background())
+	httpReq := httptest.NewRequest(http.MethodGet, "/v1/Watch?namespace=default&service=svc", nil).WithContext(ctx)
+	req := restful.NewRequest(httpReq)
+	rsp := restful.NewResponse(recorder)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		svr.WatchService(req, rsp)
+	}()
+	return cancel, done
+}
+
+func TestWatchService_SnapshotThenDelta(t *testing.T) {
+	eventhub.InitEventHub()
+	ins1 := mockWatchInstance("ins-1", "svc", true)
+	ins2 := mockWatchInstance("ins-2", "svc", true)
+	svr := &HTTPServerV1{namingServer: &mockWatchNamingServer{
+		snapshot: func() *apiservice.DiscoverResponse {
+			// 查询快照期间发生的变化在 sync 事件之后推送，不会丢失
+			publishInstanceEvent(ins2, eventhub.EventCreated)
+			rsp := api.NewDiscoverInstanceResponse(apimodel.Code_ExecuteSuccess, &apiservice.Service{
+				Namespace: utils.NewStringValue("default"),
+				Name:      utils.NewStringValue("svc"),
+				Revision:  utils.NewStringValue("rev-1"),
+			})
+			rsp.Instances = []*apiservice.Instance{ins1.Proto}
+			return rsp
+		},
+	}}
+	recorder := &watchRecorder{header: http.Header{}}
+	cancel, done := startWatch(svr, recorder)
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	waitEvents := func(count int) []sseEvent {
+		var events []sseEvent
+		assert.Eventually(t, func() bool {
+			events = recorder.events(t)
+			return len(events) >= count
+		}, 5*time.Second, 10*time.Millisecond)
+		return events
+	}
+
+	events := waitEvents(2)
+	assert.Equal(t, "text/event-stream", recorder.Header().Get("Content-Type"))
+	assert.Equal(t, "0", events[0].id)
+	assert.Equal(t, watchEventSync, events[0].event)
+	assert.Equal(t, "rev-1", events[0].data.Revision)
+	assert.Equal(t, 1, len(events[0].data.Instances))
+	assert.Contains(t, string(events[0].data.Instances[0]), "ins-1")
+	assert.Equal(t, watchEventAdd, events[1].event)
+	assert.Contains(t, string(events[1].data.Instance), "ins-2")
+
+	// eventhub 不保证并发发布的事件顺序，逐个发布并等待推送
+	publishInstanceEvent(mockWatchInstance("ins-3", "other-svc", true), eventhub.EventCreated)
+	expects := []struct {
+		ins       *model.Instance
+		eventType eventhub.EventType
+		expect    string
+	}{
+		{ins: mockWatchInstance("ins-1", "svc", false), eventType: eventhub.EventUpdated,
+			expect: watchEventHealthChange},
+		{ins: mockWatchInstance("ins-1", "svc", false), eventType: eventhub.EventUpdated, expect: watchEventUpdate},
+		{ins: ins1, eventType: eventhub.EventDeleted, expect: watchEventDelete},
+	}
+	for i, item := range expects {
+		publishInstanceEvent(item.ins, item.eventType)
+		events = waitEvents(3 + i)
+		assert.Equal(t, item.expect, events[2+i].event)
+		assert.Contains(t, string(events[2+i].data.Instance), item.ins.ID())
+	}
+	// 其他服务的事件不推送，序号连续递增
+	events = recorder.events(t)
+	assert.Equal(t, 5, len(events))
+	for i := range events {
+		assert.Equal(t, strconv.Itoa(i), events[i].id)
+	}
+}
+
+func TestWatchService_OverflowReset(t *testing.T) {
+	eventhub.InitEventHub()
+	svr := &HTTPServerV1{namingServer: &mockWatchNamingServer{
+		snapshot: func() *apiservice.DiscoverResponse {
+			return api.NewDiscoverInstanceResponse(apimodel.Code_ExecuteSuccess, &apiservice.Service{
+				Namespace: utils.NewStringValue("default"),
+				Name:      utils.NewStringValue("svc"),
+			})
+		},
+	}}
+	// sync 之后的写入被阻塞，模拟消费缓慢的客户端
+	recorder := &watchRecorder{header: http.Header{}, gate: make(chan struct{})}
+	cancel, done := startWatch(svr, recorder)
+	defer cancel()
+
+	assert.Eventually(t, func() bool {
+		return len(recorder.events(t)) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	for i := 0; i < watchEventQueueSize+256; i++ {
+		publishInstanceEvent(mockWatchInstance("ins-1", "svc", i%2 == 0), eventhub.EventUpdated)
+	}
+	time.Sleep(200 * time.Millisecond)
+	close(recorder.gate)
+
+	// 队列溢出后推送 reset 事件并断开连接，由客户端重新同步
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("watch connection not reset")
+	}
+	events := recorder.events(t)
+	assert.True(t, len(events) < watchEventQueueSize+256)
+	assert.Equal(t, watchEventReset, events[len(events)-1].event)
+}
@@ -24,7 +24,9 @@ import (
 	apiservice "github.com/polarismesh/specification/source/go/api/v1/service_manage"
 
 	"github.com/polarismesh/polaris/admin"
+	"github.com/polarismesh/polaris/auth/policy"
 	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/federation"
 )
 
 var (
@@ -50,6 +52,15 @@ func EnrichGetServerConnStatsApiDocs(r *restful.RouteBuilder) *restful.RouteBuil
 		Returns(0, "", admin.ConnStatsResp{})
 }
 
+func EnrichGetServerConnDetailsApiDocs(r *restful.RouteBuilder) *restful.RouteBuilder {
+	return r.
+		Doc("获取服务端连接详情").
+		Metadata(restfulspec.KeyOpenAPITags, maintainApiTags).
+		Param(restful.QueryParameter("protocol", "查看指定协议，不填时返回全部协议").DataType(typeNameString).Required(false)).
+		Param(restful.QueryParameter("host", "查看指定host").DataType(typeNameString).Required(false)).
+		Returns(0, "", []admin.ConnDetailsResp{})
+}
+
 func EnrichCloseConnectionsApiDocs(r *restful.RouteBuilder) *restful.RouteBuilder {
 	return r.
 		Doc("关闭指定client ip的连接").
@@ -127,6 +138,54 @@ func EnrichReleaseLeaderElectionApiDocs(r *restful.RouteBuilder) *restful.RouteB
 		}{})
 }
 
+func EnrichGetChangeSetRecordsApiDocs(r *restful.RouteBuilder) *restful.RouteBuilder {
+	return r.
+		Doc("查询同一个变更集下的全部操作记录").
+		Metadata(restfulspec.KeyOpenAPITags, maintainApiTags).
+		Param(restful.QueryParameter("id", "变更集 ID").DataType(typeNameString).Required(true)).
+		Returns(0, "", []model.RecordEntry{})
+}
+
+func EnrichGetAuditRecordsApiDocs(r *restful.RouteBuilder) *restful.RouteBuilder {
+	return r.
+		Doc("分页查询操作记录").
+		Metadata(restfulspec.KeyOpenAPITags, maintainApiTags).
+		Param(restful.QueryParameter("operator", "操作人").DataType(typeNameString).Required(false)).
+		Param(restful.QueryParameter("resource_type", "资源类型").DataType(typeNameString).Required(false)).
+		Param(restful.QueryParameter("operation_type", "操作类型").DataType(typeNameString).Required(false)).
+		Param(restful.QueryParameter("start_time", "起始时间, unix 秒").DataType(typeNameInteger).Required(false)).
+		Param(restful.QueryParameter("end_time", "结束时间(不包含), unix 秒").DataType(typeNameInteger).Required(false)).
+		Param(restful.QueryParameter("offset", "查询偏移量").DataType(typeNameInteger).
+			Required(false).DefaultValue("0")).
+		Param(restful.QueryParameter("limit", "查询条数，**最多查询100条**").DataType(typeNameInteger).
+			Required(false)).
+		Returns(0, "", struct {
+			Total   uint32
+			Records []model.RecordEntry
+		}{})
+}
+
+func EnrichExportAuthPoliciesApiDocs(r *restful.RouteBuilder) *restful.RouteBuilder {
+	return r.
+		Doc("导出用户、用户组以及鉴权策略").
+		Metadata(restfulspec.KeyOpenAPITags, maintainApiTags).
+		Param(restful.QueryParameter("format", "导出格式, json 或者 yaml").DataType(typeNameString).
+			Required(false).DefaultValue("json")).
+		Param(restful.QueryParameter("credentials", "是否导出密码以及 token，保存在 git 中时不建议导出").
+			DataType(typeNameBool).Required(false).DefaultValue("false")).
+		Returns(0, "", policy.AuthPolicyDocument{})
+}
+
+func EnrichImportAuthPoliciesApiDocs(r *restful.RouteBuilder) *restful.RouteBuilder {
+	return r.
+		Doc("导入用户、用户组以及鉴权策略").
+		Metadata(restfulspec.KeyOpenAPITags, maintainApiTags).
+		Param(restful.QueryParameter("format", "请求内容的格式, json 或者 yaml").DataType(typeNameString).
+			Required(false).DefaultValue("json")).
+		Reads(policy.AuthPolicyDocument{}).
+		Returns(0, "", admin.AuthPolicyImportResp{})
+}
+
 func EnrichGetCMDBInfoApiDocs(r *restful.RouteBuilder) *restful.RouteBuilder {
 	return r.
 		Doc("查询CMDB信息").
@@ -149,3 +208,18 @@ func EnrichEnablePprofApiDocs(r *restful.RouteBuilder) *restful.RouteBuilder {
 			Enable bool `json:"enable"`
 		}{})
 }
+
+func EnrichGetFederationSnapshotApiDocs(r *restful.RouteBuilder) *restful.RouteBuilder {
+	return r.
+		Doc("获取本集群自身拥有的服务以及实例, 供远端集群拉取").
+		Metadata(restfulspec.KeyOpenAPITags, maintainApiTags).
+		Returns(0, "", federation.Snapshot{})
+}
+
+func EnrichApplyFederationSnapshotApiDocs(r *restful.RouteBuilder) *restful.RouteBuilder {
+	return r.
+		Doc("接收远端集群推送的快照, 以副本的形式写入本集群").
+		Metadata(restfulspec.KeyOpenAPITags, maintainApiTags).
+		Reads(federation.Snapshot{}).
+		Returns(0, "", federation.ApplyResult{})
+}
@@ -21,6 +21,9 @@ import (
 	"github.com/emicklei/go-restful/v3"
 	restfulspec "github.com/polarismesh/go-restful-openapi/v2"
 	apisecurity "github.com/polarismesh/specification/source/go/api/v1/security"
+
+	"github.com/polarismesh/polaris/auth"
+	"github.com/polarismesh/polaris/common/model"
 )
 
 var (
@@ -56,6 +59,28 @@ func EnrichCreateStrategyApiDocs(r *restful.RouteBuilder) *restful.RouteBuilder
 		}{})
 }
 
+func EnrichCreateStrategiesApiDocs(r *restful.RouteBuilder) *restful.RouteBuilder {
+	return r.
+		Doc("批量创建鉴权策略").
+		Metadata(restfulspec.KeyOpenAPITags, authApiTags).
+		Reads([]apisecurity.AuthStrategy{}, "create auth strategies").
+		Returns(0, "", struct {
+			BatchWriteResponse
+			Responses []struct {
+				BaseResponse
+				AuthStrategy apisecurity.AuthStrategy `json:"authStrategy"`
+			} `json:"responses"`
+		}{})
+}
+
+func EnrichSimulateStrategyApiDocs(r *restful.RouteBuilder) *restful.RouteBuilder {
+	return r.
+		Doc("模拟鉴权请求").
+		Metadata(restfulspec.KeyOpenAPITags, authApiTags).
+		Reads(auth.SimulateRequest{}, "simulate auth request").
+		Returns(0, "", auth.StrategySimulation{})
+}
+
 func EnrichUpdateStrategiesApiDocs(r *restful.RouteBuilder) *restful.RouteBuilder {
 	return r.
 		Doc("更新鉴权策略").
@@ -152,6 +177,19 @@ func EnrichLoginApiDocs(r *restful.RouteBuilder) *restful.RouteBuilder {
 		}{})
 }
 
+func EnrichLoginOIDCApiDocs(r *restful.RouteBuilder) *restful.RouteBuilder {
+	return r.
+		Doc("通过 OIDC ID Token 登录").
+		Metadata(restfulspec.KeyOpenAPITags, usersApiTags).
+		Reads(struct {
+			IDToken string `json:"id_token"`
+		}{}, "身份提供方签发的 ID Token").
+		Returns(0, "", struct {
+			BaseResponse
+			LoginResponse *apisecurity.LoginResponse `json:"loginResponse"`
+		}{})
+}
+
 func EnrichGetUsersApiDocs(r *restful.RouteBuilder) *restful.RouteBuilder {
 	return r.
 		Doc("根据相关条件对用户列表进行查询").
@@ -253,6 +291,45 @@ func EnrichResetUserTokenApiDocs(r *restful.RouteBuilder) *restful.RouteBuilder
 		}{})
 }
 
+func EnrichUnlockUserApiDocs(r *restful.RouteBuilder) *restful.RouteBuilder {
+	return r.
+		Doc("解除用户的登录锁定").
+		Metadata(restfulspec.KeyOpenAPITags, usersApiTags).
+		Reads(apisecurity.User{}, "unlock user, only id is required").
+		Returns(0, "", struct {
+			BaseResponse
+			User apisecurity.User `json:"user"`
+		}{})
+}
+
+func EnrichRefreshTokenApiDocs(r *restful.RouteBuilder) *restful.RouteBuilder {
+	return r.
+		Doc("轮换当前用户的Token").
+		Metadata(restfulspec.KeyOpenAPITags, usersApiTags).
+		Returns(0, "", struct {
+			BaseResponse
+			User apisecurity.User `json:"user"`
+		}{})
+}
+
+func EnrichGetUserSessionsApiDocs(r *restful.RouteBuilder) *restful.RouteBuilder {
+	return r.
+		Doc("查询用户的控制台登录会话").
+		Metadata(restfulspec.KeyOpenAPITags, usersApiTags).
+		Param(restful.QueryParameter("id", "用户ID").DataType(typeNameString).Required(true)).
+		Returns(0, "", []model.UserSession{})
+}
+
+func EnrichRevokeUserSessionsApiDocs(r *restful.RouteBuilder) *restful.RouteBuilder {
+	return r.
+		Doc("注销用户的控制台登录会话").
+		Metadata(restfulspec.KeyOpenAPITags, usersApiTags).
+		Param(restful.QueryParameter("id", "用户ID").DataType(typeNameString).Required(true)).
+		Param(restful.QueryParameter("session", "会话ID, 多个以逗号分隔, 不填时注销该用户的全部会话").
+			DataType(typeNameString).Required(false)).
+		Returns(0, "", BaseResponse{})
+}
+
 func EnrichCreateGroupApiDocs(r *restful.RouteBuilder) *restful.RouteBuilder {
 	return r.
 		Doc("创建用户组").
@@ -350,3 +427,14 @@ func EnrichResetGroupTokenApiDocs(r *restful.RouteBuilder) *restful.RouteBuilder
 			UserGroup apisecurity.UserGroup `json:"userGroup"`
 		}{})
 }
+
+func EnrichUpdateGroupParentApiDocs(r *restful.RouteBuilder) *restful.RouteBuilder {
+	return r.
+		Doc("设置用户组的父用户组").
+		Metadata(restfulspec.KeyOpenAPITags, userGroupApiTags).
+		Reads(struct {
+			ID       string `json:"id"`
+			ParentID string `json:"parent_id"`
+		}{}, "user group id and parent group id, empty parent_id removes the parent").
+		Returns(0, "", BaseResponse{})
+}
@@ -292,6 +292,17 @@ func EnrichWatchConfigFileForClientApiDocs(r *restful.RouteBuilder) *restful.Rou
 		Returns(0, "", config_manage.ConfigClientResponse{})
 }
 
+func EnrichSubscribeConfigFileForClientApiDocs(r *restful.RouteBuilder) *restful.RouteBuilder {
+	return r.
+		Doc("推送配置").
+		Metadata(restfulspec.KeyOpenAPITags, configClientApiTags).
+		Notes("通过 WebSocket 订阅配置分组，客户端发送 {\"type\":\"subscribe\",\"namespace\":\"\",\"group\":\"\",\"fileName\":\"\"} "+
+			"订阅（fileName 为空表示整个分组），配置发布时服务端推送 type 为 publish 的消息，灰度发布仍需通过 WatchConfigFile 获取。"+
+			"客户端需要回复服务端每 30 秒发送的 {\"type\":\"heartbeat\"}，90 秒内没有收到客户端的消息时断开连接；"+
+			"携带 Origin 时只允许同源的连接。").
+		Returns(101, "Switching Protocols", nil)
+}
+
 func EnrichGetConfigFileMetadataList(r *restful.RouteBuilder) *restful.RouteBuilder {
 	return r.
 		Doc("监听配置").
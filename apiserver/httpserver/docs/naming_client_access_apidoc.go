@@ -46,6 +46,19 @@ func EnrichRegisterInstanceApiDocs(r *restful.RouteBuilder) *restful.RouteBuilde
 		Returns(0, "", service_manage.Instance{})
 }
 
+func EnrichBatchRegisterInstanceApiDocs(r *restful.RouteBuilder) *restful.RouteBuilder {
+	return r.Doc("批量注册实例，单次最多 500 个实例，校验通过的实例在同一个事务中写入").
+		Metadata(restfulspec.KeyOpenAPITags, registerInstanceApiTags).
+		Reads([]apiservice.Instance{}).
+		Returns(0, "", struct {
+			BatchWriteResponse
+			Responses []struct {
+				BaseResponse
+				Instance service_manage.Instance `json:"instance"`
+			} `json:"responses"`
+		}{})
+}
+
 func EnrichDeregisterInstanceApiDocs(r *restful.RouteBuilder) *restful.RouteBuilder {
 	return r.Doc("注销实例").
 		Metadata(restfulspec.KeyOpenAPITags, registerInstanceApiTags).
@@ -60,6 +73,19 @@ func EnrichHeartbeatApiDocs(r *restful.RouteBuilder) *restful.RouteBuilder {
 		Returns(0, "", service_manage.Instance{})
 }
 
+func EnrichBatchHeartbeatApiDocs(r *restful.RouteBuilder) *restful.RouteBuilder {
+	return r.Doc("批量上报心跳，单次最多 500 个实例").
+		Metadata(restfulspec.KeyOpenAPITags, registerInstanceApiTags).
+		Reads([]apiservice.Instance{}).
+		Returns(0, "", struct {
+			BatchWriteResponse
+			Responses []struct {
+				BaseResponse
+				Instance service_manage.Instance `json:"instance"`
+			} `json:"responses"`
+		}{})
+}
+
 func EnrichDiscoverApiDocs(r *restful.RouteBuilder) *restful.RouteBuilder {
 	return r.Doc("服务发现").
 		Metadata(restfulspec.KeyOpenAPITags, registerInstanceApiTags).
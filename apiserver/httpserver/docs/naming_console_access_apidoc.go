@@ -27,6 +27,8 @@ import (
 	apiservice "github.com/polarismesh/specification/source/go/api/v1/service_manage"
 	"github.com/polarismesh/specification/source/go/api/v1/traffic_manage"
 	apitraffic "github.com/polarismesh/specification/source/go/api/v1/traffic_manage"
+
+	"github.com/polarismesh/polaris/common/model"
 )
 
 var (
@@ -127,6 +129,17 @@ func EnrichGetAllServicesApiDocs(r *restful.RouteBuilder) *restful.RouteBuilder
 		}{})
 }
 
+func EnrichWatchServiceApiDocs(r *restful.RouteBuilder) *restful.RouteBuilder {
+	return r.Doc("通过 Server-Sent Events 监听服务实例变化").
+		Metadata(restfulspec.KeyOpenAPITags, servicesApiTags).
+		Notes("建立连接后先推送 sync 事件包含当前全部实例, 之后推送 add、update、delete、health_change 事件, " +
+			"收到 reset 事件时需要重新建立连接").
+		Param(restful.QueryParameter("namespace", "命名空间").DataType(typeNameString).
+			Required(true)).
+		Param(restful.QueryParameter("service", "服务名").DataType(typeNameString).
+			Required(true))
+}
+
 func EnrichGetServicesApiDocs(r *restful.RouteBuilder) *restful.RouteBuilder {
 	return r.Doc("获取服务列表").
 		Metadata(restfulspec.KeyOpenAPITags, servicesApiTags).
@@ -157,6 +170,10 @@ func EnrichGetServicesApiDocs(r *restful.RouteBuilder) *restful.RouteBuilder {
 			" 和 instance_values 需要同时填写且必须元素个数一致").
 			DataType(typeNameString).
 			Required(false)).
+		Param(restful.QueryParameter("label_selector", "服务元数据标签选择器, 多个条件以逗号分隔, 支持 "+
+			"key=v, key!=v, key in (v1,v2), key notin (v1,v2), key, !key").
+			DataType(typeNameString).
+			Required(false)).
 		Param(restful.QueryParameter("offset", "查询偏移量").DataType(typeNameInteger).
 			Required(false).DefaultValue("0")).
 		Param(restful.QueryParameter("limit", "查询条数，**最多查询100条**").DataType(typeNameInteger).
@@ -216,6 +233,19 @@ func EnrichGetServicesCountApiDocs(r *restful.RouteBuilder) *restful.RouteBuilde
 		Returns(0, "", BatchQueryResponse{})
 }
 
+func EnrichGetServiceDependenciesApiDocs(r *restful.RouteBuilder) *restful.RouteBuilder {
+	return r.
+		Doc("查询服务的上下游依赖关系").
+		Metadata(restfulspec.KeyOpenAPITags, servicesApiTags).
+		Param(restful.PathParameter("name", "服务名").DataType(typeNameString).
+			Required(true).DefaultValue("demo-service")).
+		Param(restful.QueryParameter("namespace", "命名空间").DataType(typeNameString).
+			Required(false).DefaultValue("default")).
+		Param(restful.QueryParameter("window", "统计窗口，例如 10m，默认为依赖关系的保留时长").
+			DataType(typeNameString).Required(false)).
+		Returns(0, "", model.ServiceDependencies{})
+}
+
 func EnrichGetServiceTokenApiDocs(r *restful.RouteBuilder) *restful.RouteBuilder {
 	return r.
 		Doc("查询服务Token").
@@ -334,6 +364,21 @@ func EnrichUpdateInstancesIsolateApiDocs(r *restful.RouteBuilder) *restful.Route
 		}{})
 }
 
+func EnrichDrainInstancesApiDocs(r *restful.RouteBuilder) *restful.RouteBuilder {
+	return r.Doc("服务实例优雅下线，下线期间实例权重为 0，ttl 到期后自动删除").
+		Metadata(restfulspec.KeyOpenAPITags, instancesApiTags).
+		Param(restful.QueryParameter("ttl", "优雅下线的时间，例如 30s，默认 30s，最长 1h").
+			DataType(typeNameString).Required(false)).
+		Reads([]apiservice.Instance{}, "drain instances").
+		Returns(0, "", struct {
+			BatchWriteResponse
+			Responses []struct {
+				BaseResponse
+				Instance service_manage.Instance `json:"instance"`
+			} `json:"responses"`
+		}{})
+}
+
 func EnrichGetInstancesApiDocs(r *restful.RouteBuilder) *restful.RouteBuilder {
 	return r.Doc("查询服务实例").
 		Metadata(restfulspec.KeyOpenAPITags, instancesApiTags).
@@ -0,0 +1,392 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+package docs
+
+import (
+	"encoding/json"
+	"hash/fnv"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/go-openapi/spec"
+)
+
+const (
+	// OpenAPIVersion 生成的 OpenAPI 文档版本
+	OpenAPIVersion = "3.0.3"
+
+	// OpenAPIGroupNaming 服务注册发现相关的接口
+	OpenAPIGroupNaming = "naming"
+	// OpenAPIGroupConfig 配置中心相关的接口
+	OpenAPIGroupConfig = "config"
+	// OpenAPIGroupAuth 用户、用户组以及鉴权策略相关的接口
+	OpenAPIGroupAuth = "auth"
+	// OpenAPIGroupMaintain 运维相关的接口
+	OpenAPIGroupMaintain = "maintain"
+
+	swaggerRefPrefix = "#/definitions/"
+	openAPIRefPrefix = "#/components/schemas/"
+)
+
+var (
+	// OpenAPIGroups 支持单独导出的接口分组
+	OpenAPIGroups = []string{OpenAPIGroupNaming, OpenAPIGroupConfig, OpenAPIGroupAuth, OpenAPIGroupMaintain}
+
+	openAPIRefRegex = regexp.MustCompile(`"\$ref":"` + regexp.QuoteMeta(openAPIRefPrefix) + `([^"]+)"`)
+	swaggerRefRegex = regexp.MustCompile(`"\$ref":"` + regexp.QuoteMeta(swaggerRefPrefix) + `([^"]+)"`)
+	// componentNameRegex OpenAPI 3.0 对 components 中名称的约束
+	componentNameRegex = regexp.MustCompile(`^[a-zA-Z0-9._-]+$`)
+)
+
+// OpenAPI OpenAPI 3.0 文档
+type OpenAPI struct {
+	OpenAPI    string                     `json:"openapi"`
+	Info       *spec.Info                 `json:"info"`
+	Servers    []OpenAPIServer            `json:"servers,omitempty"`
+	Tags       []spec.Tag                 `json:"tags,omitempty"`
+	Paths      map[string]OpenAPIPathItem `json:"paths"`
+	Components OpenAPIComponents          `json:"components"`
+	Security   []map[string][]string      `json:"security,omitempty"`
+}
+
+// OpenAPIServer 接口的访问地址
+type OpenAPIServer struct {
+	URL string `json:"url"`
+}
+
+// OpenAPIPathItem 同一个路径下不同 HTTP 方法的接口，key 为小写的 HTTP 方法
+type OpenAPIPathItem map[string]*OpenAPIOperation
+
+// OpenAPIOperation 单个接口
+type OpenAPIOperation struct {
+	Tags        []string                   `json:"tags,omitempty"`
+	Summary     string                     `json:"summary,omitempty"`
+	Description string                     `json:"description,omitempty"`
+	OperationID string                     `json:"operationId,omitempty"`
+	Parameters  []OpenAPIParameter         `json:"parameters,omitempty"`
+	RequestBody *OpenAPIRequestBody        `json:"requestBody,omitempty"`
+	Responses   map[string]OpenAPIResponse `json:"responses"`
+	Deprecated  bool                       `json:"deprecated,omitempty"`
+	Security    []map[string][]string      `json:"security,omitempty"`
+}
+
+// OpenAPIParameter path、query、header 参数
+type OpenAPIParameter struct {
+	Name        string       `json:"name"`
+	In          string       `json:"in"`
+	Description string       `json:"description,omitempty"`
+	Required    bool         `json:"required,omitempty"`
+	Schema      *spec.Schema `json:"schema,omitempty"`
+}
+
+// OpenAPIRequestBody 请求体
+type OpenAPIRequestBody struct {
+	Description string                      `json:"description,omitempty"`
+	Required    bool                        `json:"required,omitempty"`
+	Content     map[string]OpenAPIMediaType `json:"content"`
+}
+
+// OpenAPIResponse 响应
+type OpenAPIResponse struct {
+	Description string                      `json:"description"`
+	Content     map[string]OpenAPIMediaType `json:"content,omitempty"`
+}
+
+// OpenAPIMediaType 请求体、响应在某种 Content-Type 下的结构
+type OpenAPIMediaType struct {
+	Schema *spec.Schema `json:"schema,omitempty"`
+}
+
+// OpenAPIComponents 公共的数据结构以及鉴权方式
+type OpenAPIComponents struct {
+	Schemas         map[string]spec.Schema           `json:"schemas,omitempty"`
+	SecuritySchemes map[string]OpenAPISecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+// OpenAPISecurityScheme 鉴权方式
+type OpenAPISecurityScheme struct {
+	Type        string `json:"type"`
+	Description string `json:"description,omitempty"`
+	Name        string `json:"name,omitempty"`
+	In          string `json:"in,omitempty"`
+}
+
+// ConvertToOpenAPI 将 go-restful 生成的 swagger 2.0 文档转换为 OpenAPI 3.0 文档
+func ConvertToOpenAPI(swo *spec.Swagger) *OpenAPI {
+	doc := &OpenAPI{
+		OpenAPI:  OpenAPIVersion,
+		Info:     swo.Info,
+		Tags:     swo.Tags,
+		Paths:    map[string]OpenAPIPathItem{},
+		Security: swo.Security,
+		Components: OpenAPIComponents{
+			Schemas:         map[string]spec.Schema{},
+			SecuritySchemes: map[string]OpenAPISecurityScheme{},
+		},
+	}
+	if swo.BasePath != "" && swo.BasePath != "/" {
+		doc.Servers = []OpenAPIServer{{URL: swo.BasePath}}
+	}
+	for name, schema := range swo.Definitions {
+		doc.Components.Schemas[componentName(name)] = schema
+	}
+	for name, scheme := range swo.SecurityDefinitions {
+		doc.Components.SecuritySchemes[name] = OpenAPISecurityScheme{
+			Type:        scheme.Type,
+			Description: scheme.Description,
+			Name:        scheme.Name,
+			In:          scheme.In,
+		}
+	}
+	if swo.Paths == nil {
+		return doc
+	}
+	for path, item := range swo.Paths.Paths {
+		pathItem := OpenAPIPathItem{}
+		for method, op := range map[string]*spec.Operation{
+			"get":     item.Get,
+			"put":     item.Put,
+			"post":    item.Post,
+			"delete":  item.Delete,
+			"options": item.Options,
+			"head":    item.Head,
+			"patch":   item.Patch,
+		} {
+			if op == nil {
+				continue
+			}
+			pathItem[method] = convertOperation(swo, append(item.Parameters, op.Parameters...), op)
+		}
+		doc.Paths[path] = pathItem
+	}
+	return doc
+}
+
+func convertOperation(swo *spec.Swagger, params []spec.Parameter, op *spec.Operation) *OpenAPIOperation {
+	ret := &OpenAPIOperation{
+		Tags:        op.Tags,
+		Summary:     op.Summary,
+		Description: op.Description,
+		OperationID: op.ID,
+		Responses:   map[string]OpenAPIResponse{},
+		Deprecated:  op.Deprecated,
+		Security:    op.Security,
+	}
+	consumes := op.Consumes
+	if len(consumes) == 0 {
+		consumes = swo.Consumes
+	}
+	produces := op.Produces
+	if len(produces) == 0 {
+		produces = swo.Produces
+	}
+
+	var form *spec.Schema
+	for i := range params {
+		param := params[i]
+		switch param.In {
+		case "body":
+			ret.RequestBody = &OpenAPIRequestBody{
+				Description: param.Description,
+				Required:    param.Required,
+				Content:     mediaTypes(consumes, param.Schema),
+			}
+		case "formData":
+			if form == nil {
+				form = &spec.Schema{}
+				form.Type = spec.StringOrArray{"object"}
+				form.Properties = spec.SchemaProperties{}
+			}
+			form.Properties[param.Name] = *simpleSchema(param.SimpleSchema, param.Enum)
+			if param.Required {
+				form.Required = append(form.Required, param.Name)
+			}
+		default:
+			ret.Parameters = append(ret.Parameters, OpenAPIParameter{
+				Name:        param.Name,
+				In:          param.In,
+				Description: param.Description,
+				// path 参数在 OpenAPI 3.0 中必须是必填的
+				Required: param.Required || param.In == "path",
+				Schema:   simpleSchema(param.SimpleSchema, param.Enum),
+			})
+		}
+	}
+	if form != nil {
+		ret.RequestBody = &OpenAPIRequestBody{
+			Content: map[string]OpenAPIMediaType{"multipart/form-data": {Schema: form}},
+		}
+	}
+
+	if op.Responses != nil {
+		if op.Responses.Default != nil {
+			ret.Responses["default"] = convertResponse(produces, op.Responses.Default)
+		}
+		for code, rsp := range op.Responses.StatusCodeResponses {
+			rsp := rsp
+			key := strconv.Itoa(code)
+			// 北极星的接口通过响应体中的 code 表示结果，文档中的 0 对应 HTTP 200
+			if code == 0 {
+				key = "200"
+				if _, ok := op.Responses.StatusCodeResponses[200]; ok {
+					continue
+				}
+			}
+			ret.Responses[key] = convertResponse(produces, &rsp)
+		}
+	}
+	if len(ret.Responses) == 0 {
+		ret.Responses["200"] = OpenAPIResponse{Description: "OK"}
+	}
+	return ret
+}
+
+func convertResponse(produces []string, rsp *spec.Response) OpenAPIResponse {
+	ret := OpenAPIResponse{Description: rsp.Description}
+	if ret.Description == "" {
+		ret.Description = "OK"
+	}
+	if rsp.Schema != nil {
+		ret.Content = mediaTypes(produces, rsp.Schema)
+	}
+	return ret
+}
+
+func mediaTypes(mimes []string, schema *spec.Schema) map[string]OpenAPIMediaType {
+	if len(mimes) == 0 {
+		mimes = []string{"application/json"}
+	}
+	ret := make(map[string]OpenAPIMediaType, len(mimes))
+	for i := range mimes {
+		ret[mimes[i]] = OpenAPIMediaType{Schema: schema}
+	}
+	return ret
+}
+
+func simpleSchema(s spec.SimpleSchema, enum []interface{}) *spec.Schema {
+	ret := &spec.Schema{}
+	if s.Type != "" {
+		ret.Type = spec.StringOrArray{s.Type}
+	}
+	ret.Format = s.Format
+	ret.Default = s.Default
+	ret.Example = s.Example
+	ret.Enum = enum
+	if s.Items != nil {
+		ret.Items = &spec.SchemaOrArray{Schema: simpleSchema(s.Items.SimpleSchema, s.Items.Enum)}
+	}
+	return ret
+}
+
+// Group 筛选出属于某个分组的接口，只保留这些接口引用到的数据结构
+func (o *OpenAPI) Group(group string) *OpenAPI {
+	ret := *o
+	ret.Paths = map[string]OpenAPIPathItem{}
+	for path, item := range o.Paths {
+		pathItem := OpenAPIPathItem{}
+		for method, op := range item {
+			if OperationGroup(path, op.Tags) == group {
+				pathItem[method] = op
+			}
+		}
+		if len(pathItem) != 0 {
+			ret.Paths[path] = pathItem
+		}
+	}
+	ret.Components.Schemas = o.referencedSchemas(ret.Paths)
+	return &ret
+}
+
+// referencedSchemas 接口直接或者间接引用到的数据结构
+func (o *OpenAPI) referencedSchemas(paths map[string]OpenAPIPathItem) map[string]spec.Schema {
+	ret := map[string]spec.Schema{}
+	pending := []interface{}{paths}
+	for len(pending) != 0 {
+		data, err := marshalOpenAPI(pending[0])
+		pending = pending[1:]
+		if err != nil {
+			continue
+		}
+		for _, match := range openAPIRefRegex.FindAllSubmatch(data, -1) {
+			name := string(match[1])
+			if _, ok := ret[name]; ok {
+				continue
+			}
+			schema, ok := o.Components.Schemas[name]
+			if !ok {
+				continue
+			}
+			ret[name] = schema
+			pending = append(pending, schema)
+		}
+	}
+	return ret
+}
+
+// OperationGroup 接口所属的分组
+func OperationGroup(path string, tags []string) string {
+	for _, tag := range tags {
+		switch tag {
+		case "AuthRule", "Users":
+			return OpenAPIGroupAuth
+		case "Maintain":
+			return OpenAPIGroupMaintain
+		case "ConfigConsole":
+			return OpenAPIGroupConfig
+		}
+	}
+	switch {
+	case strings.HasPrefix(path, "/maintain/"):
+		return OpenAPIGroupMaintain
+	case strings.HasPrefix(path, "/config/"), strings.Contains(path, "Config"):
+		// 客户端的配置接口与服务发现接口共用 /v1 前缀
+		return OpenAPIGroupConfig
+	default:
+		return OpenAPIGroupNaming
+	}
+}
+
+// Marshal 序列化为 JSON, swagger 2.0 中的数据结构引用同时改写为 OpenAPI 3.0 的格式
+func (o *OpenAPI) Marshal() ([]byte, error) {
+	return marshalOpenAPI(o)
+}
+
+func marshalOpenAPI(v interface{}) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return swaggerRefRegex.ReplaceAllFunc(data, func(ref []byte) []byte {
+		name := string(swaggerRefRegex.FindSubmatch(ref)[1])
+		if unescaped, err := url.PathUnescape(name); err == nil {
+			name = unescaped
+		}
+		return []byte(`"$ref":"` + openAPIRefPrefix + componentName(name) + `"`)
+	}), nil
+}
+
+// componentName 匿名结构体生成的数据结构名称不符合 OpenAPI 3.0 的约束，替换为基于原名称的摘要
+func componentName(name string) string {
+	if componentNameRegex.MatchString(name) {
+		return name
+	}
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(name))
+	return "Anonymous_" + strconv.FormatUint(h.Sum64(), 16)
+}
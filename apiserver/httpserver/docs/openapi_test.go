@@ -0,0 +1,102 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+package docs
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/emicklei/go-restful/v3"
+	restfulspec "github.com/polarismesh/go-restful-openapi/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func noopRoute(*restful.Request, *restful.Response) {}
+
+func buildTestOpenAPI(t *testing.T) *OpenAPI {
+	naming := new(restful.WebService)
+	naming.Path("/naming/v1").Consumes(restful.MIME_JSON).Produces(restful.MIME_JSON)
+	naming.Route(EnrichGetNamespacesApiDocsOld(naming.GET("/namespaces").To(noopRoute)))
+	naming.Route(EnrichCreateServicesApiDocs(naming.POST("/services").To(noopRoute)))
+
+	config := new(restful.WebService)
+	config.Path("/config/v1").Consumes(restful.MIME_JSON).Produces(restful.MIME_JSON)
+	config.Route(EnrichWatchConfigFileForClientApiDocs(config.POST("/WatchConfigFile").To(noopRoute)))
+
+	swo := restfulspec.BuildSwagger(restfulspec.Config{WebServices: []*restful.WebService{naming, config}})
+	return ConvertToOpenAPI(swo)
+}
+
+func TestConvertToOpenAPI(t *testing.T) {
+	doc := buildTestOpenAPI(t)
+	assert.Equal(t, OpenAPIVersion, doc.OpenAPI)
+
+	list := doc.Paths["/naming/v1/namespaces"]["get"]
+	if assert.NotNil(t, list) {
+		assert.Nil(t, list.RequestBody)
+		assert.NotEmpty(t, list.Parameters)
+		for _, param := range list.Parameters {
+			assert.Equal(t, "query", param.In)
+			assert.NotNil(t, param.Schema)
+		}
+		_, ok := list.Responses["200"]
+		assert.True(t, ok)
+	}
+
+	create := doc.Paths["/naming/v1/services"]["post"]
+	if assert.NotNil(t, create) && assert.NotNil(t, create.RequestBody) {
+		_, ok := create.RequestBody.Content[restful.MIME_JSON]
+		assert.True(t, ok)
+	}
+
+	for name := range doc.Components.Schemas {
+		assert.Regexp(t, componentNameRegex, name)
+	}
+	data, err := doc.Marshal()
+	assert.NoError(t, err)
+	assert.False(t, strings.Contains(string(data), swaggerRefPrefix))
+	assert.True(t, json.Valid(data))
+	for _, match := range openAPIRefRegex.FindAllSubmatch(data, -1) {
+		_, ok := doc.Components.Schemas[string(match[1])]
+		assert.True(t, ok, string(match[1]))
+	}
+}
+
+func TestOpenAPI_Group(t *testing.T) {
+	doc := buildTestOpenAPI(t)
+
+	naming := doc.Group(OpenAPIGroupNaming)
+	assert.Len(t, naming.Paths, 2)
+	_, ok := naming.Paths["/config/v1/WatchConfigFile"]
+	assert.False(t, ok)
+
+	config := doc.Group(OpenAPIGroupConfig)
+	assert.Len(t, config.Paths, 1)
+	assert.True(t, len(config.Components.Schemas) < len(doc.Components.Schemas))
+	data, err := config.Marshal()
+	assert.NoError(t, err)
+	for _, match := range openAPIRefRegex.FindAllSubmatch(data, -1) {
+		_, ok := config.Components.Schemas[string(match[1])]
+		assert.True(t, ok, string(match[1]))
+	}
+
+	assert.Empty(t, doc.Group(OpenAPIGroupMaintain).Paths)
+	assert.Equal(t, OpenAPIGroupAuth, OperationGroup("/core/v1/users", []string{"Users"}))
+	assert.Equal(t, OpenAPIGroupConfig, OperationGroup("/v1/GetConfigFile", []string{"Client"}))
+	assert.Equal(t, OpenAPIGroupNaming, OperationGroup("/v1/Discover", []string{"Client"}))
+}
@@ -22,6 +22,7 @@ import (
 	"fmt"
 	"net"
 	"net/http"
+	"strconv"
 	"strings"
 	"sync/atomic"
 	"time"
@@ -42,11 +43,13 @@ import (
 	httpcommon "github.com/polarismesh/polaris/apiserver/httpserver/utils"
 	"github.com/polarismesh/polaris/auth"
 	api "github.com/polarismesh/polaris/common/api/v1"
+	connhook "github.com/polarismesh/polaris/common/conn/hook"
 	"github.com/polarismesh/polaris/common/conn/keepalive"
 	connlimit "github.com/polarismesh/polaris/common/conn/limit"
 	commonlog "github.com/polarismesh/polaris/common/log"
 	"github.com/polarismesh/polaris/common/metrics"
 	"github.com/polarismesh/polaris/common/secure"
+	"github.com/polarismesh/polaris/common/trace"
 	"github.com/polarismesh/polaris/common/utils"
 	"github.com/polarismesh/polaris/config"
 	"github.com/polarismesh/polaris/namespace"
@@ -80,6 +83,8 @@ type HTTPServer struct {
 
 	enablePprof   *atomic.Bool
 	enableSwagger bool
+	idempotency   *httpcommon.Idempotency
+	compression   *httpcommon.Compression
 
 	server            *http.Server
 	maintainServer    admin.AdminOperateServer
@@ -130,6 +135,20 @@ func (h *HTTPServer) Initialize(ctx context.Context, option map[string]interface
 		h.enablePprof.Store(true)
 	}
 	h.enableSwagger, _ = option["enableSwagger"].(bool)
+	raw, _ := option["idempotency"].(map[interface{}]interface{})
+	idempotencyConfig, err := httpcommon.ParseIdempotencyConfig(raw)
+	if err != nil {
+		return err
+	}
+	if h.idempotency, err = httpcommon.NewIdempotency(idempotencyConfig); err != nil {
+		return err
+	}
+	raw, _ = option["compression"].(map[interface{}]interface{})
+	compressionConfig, err := httpcommon.ParseCompressionConfig(raw)
+	if err != nil {
+		return err
+	}
+	h.compression = httpcommon.NewCompression(compressionConfig)
 	h.apiserverSlots, _ = ctx.Value(utils.ContextAPIServerSlot{}).(map[string]apiserver.Apiserver)
 	// 连接数限制的配置
 	if raw, _ := option["connLimit"].(map[interface{}]interface{}); raw != nil {
@@ -164,9 +183,10 @@ func (h *HTTPServer) Initialize(ctx context.Context, option map[string]interface
 			return err
 		}
 		h.tlsInfo = &secure.TLSInfo{
-			CertFile:      tlsConfig.CertFile,
-			KeyFile:       tlsConfig.KeyFile,
-			TrustedCAFile: tlsConfig.TrustedCAFile,
+			CertFile:       tlsConfig.CertFile,
+			KeyFile:        tlsConfig.KeyFile,
+			TrustedCAFile:  tlsConfig.TrustedCAFile,
+			ClientCertAuth: tlsConfig.ClientCertAuth,
 		}
 	}
 
@@ -277,13 +297,15 @@ func (h *HTTPServer) Run(errCh chan error) {
 			return
 		}
 	}
+	ln = connhook.NewHookListener(ln, connhook.NewConnTracker(h.GetProtocol()))
 	h.server = &server
 
 	// 开始对外服务
 	if h.tlsInfo.IsEmpty() {
 		err = server.Serve(ln)
-	} else {
-		err = server.ServeTLS(ln, h.tlsInfo.CertFile, h.tlsInfo.KeyFile)
+	} else if server.TLSConfig, err = h.tlsInfo.ServerTLSConfig(); err == nil {
+		// 证书已经加载到 TLSConfig 中
+		err = server.ServeTLS(ln, "", "")
 	}
 	if err != nil {
 		log.Errorf("%+v", err)
@@ -303,6 +325,7 @@ func (h *HTTPServer) Stop() {
 	// 释放connLimit的数据，如果没有开启，也需要执行一下
 	// 目的：防止restart的时候，connLimit冲突
 	connlimit.RemoveLimitListener(h.GetProtocol())
+	connhook.RemoveConnTracker(h.GetProtocol())
 	// stop http server
 	if h.server != nil {
 		// 延迟三秒，等待http server关闭，做到流量无损。
@@ -363,7 +386,8 @@ func (h *HTTPServer) createRestfulContainer() (*restful.Container, error) {
 	// 增加CORS TODO
 	cors := restful.CrossOriginResourceSharing{
 		// ExposeHeaders:  []string{"X-My-Header"},
-		AllowedHeaders: []string{"Content-Type", "Accept", "Request-Id"},
+		AllowedHeaders: []string{"Content-Type", "Accept", "Request-Id", httpcommon.HeaderIdempotencyKey,
+			trace.HeaderTraceparent, trace.HeaderTracestate},
 		AllowedMethods: []string{http.MethodGet, http.MethodPost, http.MethodPut},
 		CookiesAllowed: false,
 		Container:      wsContainer}
@@ -373,6 +397,8 @@ func (h *HTTPServer) createRestfulContainer() (*restful.Container, error) {
 	wsContainer.Filter(wsContainer.OPTIONSFilter)
 
 	wsContainer.Filter(h.process)
+	wsContainer.Filter(h.compression.Filter)
+	wsContainer.Filter(h.idempotency.Filter)
 
 	for name, apiConfig := range h.openAPI {
 		switch name {
@@ -444,6 +470,7 @@ func (h *HTTPServer) createRestfulContainer() (*restful.Container, error) {
 	// 收集插件的 endpoint 数据
 	h.enablePluginDebugAccess(wsContainer)
 	h.enablePrometheusAccess(wsContainer)
+	h.enableHealthAccess(wsContainer)
 	return wsContainer, nil
 }
 
@@ -514,6 +541,17 @@ func (h *HTTPServer) preprocess(req *restful.Request, rsp *restful.Response) err
 	// 设置开始时间
 	req.SetAttribute("start-time", time.Now())
 
+	// 链路追踪，透传调用方的 traceparent/tracestate
+	ctx, span := trace.StartServerSpan(req.Request.Context(), req.Request.Method+" "+req.Request.URL.Path,
+		req.HeaderParameter(trace.HeaderTraceparent), req.HeaderParameter(trace.HeaderTracestate))
+	if span != nil {
+		req.Request = req.Request.WithContext(ctx)
+		span.SetAttribute("http.method", req.Request.Method)
+		span.SetAttribute("http.target", req.Request.URL.Path)
+		span.SetAttribute("net.peer.addr", req.Request.RemoteAddr)
+		rsp.AddHeader(trace.HeaderTraceparent, span.Traceparent())
+	}
+
 	// 处理请求ID
 	requestID := req.HeaderParameter("Request-Id")
 	if requestID == "" {
@@ -570,6 +608,14 @@ func (h *HTTPServer) postProcess(req *restful.Request, rsp *restful.Response) {
 	}
 
 	diff := now.Sub(startTime)
+	if span := trace.SpanFromContext(req.Request.Context()); span != nil {
+		span.SetAttribute("http.status_code", strconv.Itoa(rsp.StatusCode()))
+		span.SetAttribute("polaris.code", strconv.FormatUint(uint64(code), 10))
+		if rsp.StatusCode() >= http.StatusInternalServerError {
+			span.SetError(http.StatusText(rsp.StatusCode()))
+		}
+		span.End()
+	}
 	// 打印耗时超过1s的请求
 	if diff > time.Second {
 		var scope *commonlog.Scope
@@ -635,15 +681,21 @@ func (h *HTTPServer) enterRateLimit(req *restful.Request, rsp *restful.Response)
 	// IP级限流
 	// 先获取当前请求的address
 	address := req.Request.RemoteAddr
-	segments := strings.Split(address, ":")
-	if len(segments) != 2 {
-		return nil
+	if ip, _, err := net.SplitHostPort(address); err == nil {
+		if ok := h.rateLimit.Allow(plugin.IPRatelimit, ip); !ok {
+			log.Error("ip ratelimit is not allow", zap.String("client", address),
+				utils.ZapRequestID(rid))
+			httpcommon.HTTPResponse(req, rsp, api.IPRateLimit)
+			return errors.New("ip ratelimit is not allow")
+		}
 	}
-	if ok := h.rateLimit.Allow(plugin.IPRatelimit, segments[0]); !ok {
-		log.Error("ip ratelimit is not allow", zap.String("client", address),
+
+	// token级限流，不同的 token 拥有独立的配额
+	if ok := h.rateLimit.Allow(plugin.TokenRatelimit, req.HeaderParameter(utils.HeaderAuthTokenKey)); !ok {
+		log.Error("token ratelimit is not allow", zap.String("client", address),
 			utils.ZapRequestID(rid))
-		httpcommon.HTTPResponse(req, rsp, api.IPRateLimit)
-		return errors.New("ip ratelimit is not allow")
+		httpcommon.HTTPResponse(req, rsp, api.TokenRateLimit)
+		return errors.New("token ratelimit is not allow")
 	}
 
 	// 接口级限流
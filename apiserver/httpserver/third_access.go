@@ -20,12 +20,18 @@ package httpserver
 import (
 	"net/http"
 	"net/http/pprof"
+	"slices"
+	"sync"
 
 	"github.com/emicklei/go-restful/v3"
 	"github.com/go-openapi/spec"
 	restfulspec "github.com/polarismesh/go-restful-openapi/v2"
 
+	"github.com/polarismesh/polaris/apiserver/httpserver/docs"
 	"github.com/polarismesh/polaris/common/metrics"
+	"github.com/polarismesh/polaris/common/readiness"
+	"github.com/polarismesh/polaris/common/utils"
+	"github.com/polarismesh/polaris/common/version"
 )
 
 // enablePprofAccess 开启pprof接口
@@ -75,6 +81,30 @@ func (h *HTTPServer) enablePrometheusAccess(wsContainer *restful.Container) {
 	wsContainer.Handle("/metrics", metrics.GetHttpHandler())
 }
 
+// enableHealthAccess 开启探活接口，不经过鉴权、限流等处理
+// /healthz 只要进程可以处理请求就返回 200，/readyz 全部组件就绪时返回 200，否则返回 503，两者都会返回各个组件的就绪状态
+func (h *HTTPServer) enableHealthAccess(wsContainer *restful.Container) {
+	log.Infof("open http access for health probe")
+
+	wsContainer.Handle("/healthz", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeReadinessReport(w, readiness.Check(), http.StatusOK)
+	}))
+	wsContainer.Handle("/readyz", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		report := readiness.Check()
+		code := http.StatusOK
+		if !report.Ready {
+			code = http.StatusServiceUnavailable
+		}
+		writeReadinessReport(w, report, code)
+	}))
+}
+
+func writeReadinessReport(w http.ResponseWriter, report *readiness.Report, code int) {
+	w.Header().Set("Content-Type", restful.MIME_JSON)
+	w.WriteHeader(code)
+	_, _ = w.Write([]byte(utils.MustJson(report)))
+}
+
 func (h *HTTPServer) enableSwaggerAPI(wsContainer *restful.Container) {
 	log.Infof("[HTTPServer] open http access for swagger API")
 	config := restfulspec.Config{
@@ -84,11 +114,60 @@ func (h *HTTPServer) enableSwaggerAPI(wsContainer *restful.Container) {
 	}
 
 	if h.enableSwagger {
+		// 保留 swagger 2.0 的文档，兼容已有的 swagger-ui
 		wsContainer.Add(restfulspec.NewOpenAPIService(config))
+		wsContainer.Handle("/apidocs/openapi.json", newOpenAPIHandler(config))
 	}
 }
 
+// newOpenAPIHandler 基于已注册的 WebService 生成 OpenAPI 3.0 文档，支持通过 group 参数只导出某一类接口
+// 接口在 HTTPServer 启动时已经全部注册完成，文档只在第一次访问时生成
+func newOpenAPIHandler(config restfulspec.Config) http.Handler {
+	var (
+		once   sync.Once
+		doc    *docs.OpenAPI
+		groups = map[string][]byte{}
+		lock   sync.Mutex
+	)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		once.Do(func() {
+			doc = docs.ConvertToOpenAPI(restfulspec.BuildSwagger(config))
+		})
+		group := r.URL.Query().Get("group")
+		if group != "" && !slices.Contains(docs.OpenAPIGroups, group) {
+			http.Error(w, "unknown openapi group: "+group, http.StatusBadRequest)
+			return
+		}
+
+		lock.Lock()
+		data, ok := groups[group]
+		if !ok {
+			target := doc
+			if group != "" {
+				target = doc.Group(group)
+			}
+			var err error
+			if data, err = target.Marshal(); err != nil {
+				lock.Unlock()
+				log.Errorf("[HTTPServer] marshal openapi document: %s", err.Error())
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			groups[group] = data
+		}
+		lock.Unlock()
+
+		w.Header().Set("Content-Type", restful.MIME_JSON)
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		_, _ = w.Write(data)
+	})
+}
+
 func enrichSwaggerObject(swo *spec.Swagger) {
+	swo.Info = &spec.Info{InfoProps: spec.InfoProps{
+		Title:   "Polaris API",
+		Version: version.Get(),
+	}}
 	swo.Tags = []spec.Tag{
 		{TagProps: spec.TagProps{
 			Name:        "Client",
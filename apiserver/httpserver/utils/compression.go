@@ -0,0 +1,271 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+package utils
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/emicklei/go-restful/v3"
+	"github.com/klauspost/compress/zstd"
+)
+
+const (
+	// HeaderAcceptEncoding 客户端支持的压缩算法
+	HeaderAcceptEncoding = "Accept-Encoding"
+	// HeaderContentEncoding 响应体使用的压缩算法
+	HeaderContentEncoding = "Content-Encoding"
+
+	encodingGzip = "gzip"
+	encodingZstd = "zstd"
+
+	defaultCompressionMinSize = 1024
+)
+
+// CompressionConfig Discover 响应压缩的配置
+type CompressionConfig struct {
+	// Open 是否开启
+	Open bool
+	// MinSize 响应体超过该大小时才压缩，单位字节
+	MinSize int
+	// Level gzip 的压缩级别
+	Level int
+	// Algorithm 优先使用的压缩算法，支持 gzip、zstd，为空时使用 gzip
+	// 客户端不支持 zstd 时回退到 gzip
+	Algorithm string
+}
+
+// ParseCompressionConfig 解析响应压缩的配置，未配置时不压缩
+func ParseCompressionConfig(raw map[interface{}]interface{}) (*CompressionConfig, error) {
+	conf := &CompressionConfig{MinSize: defaultCompressionMinSize, Level: gzip.DefaultCompression}
+	if raw == nil {
+		return conf, nil
+	}
+	if open, ok := raw["open"].(bool); ok {
+		conf.Open = open
+	}
+	if minSize, ok := raw["minSize"].(int); ok {
+		if minSize < 0 {
+			return nil, fmt.Errorf("invalid compression minSize: %d", minSize)
+		}
+		conf.MinSize = minSize
+	}
+	if level, ok := raw["level"].(int); ok {
+		if level < gzip.HuffmanOnly || level > gzip.BestCompression {
+			return nil, fmt.Errorf("invalid compression level: %d", level)
+		}
+		conf.Level = level
+	}
+	if algorithm, ok := raw["algorithm"].(string); ok {
+		algorithm = strings.ToLower(algorithm)
+		if algorithm != encodingGzip && algorithm != encodingZstd {
+			return nil, fmt.Errorf("invalid compression algorithm: %s", algorithm)
+		}
+		conf.Algorithm = algorithm
+	}
+	return conf, nil
+}
+
+// encoder gzip.Writer 以及 zstd.Encoder 共同的方法
+type encoder interface {
+	io.WriteCloser
+	Reset(w io.Writer)
+	Flush() error
+}
+
+// Compression 根据 Accept-Encoding 协商压缩 Discover 接口的响应
+// 实例数量较多的服务，Discover 的响应体可以达到数 MB，压缩后可以显著降低出口带宽
+type Compression struct {
+	conf    *CompressionConfig
+	writers map[string]*sync.Pool
+}
+
+// NewCompression 新建响应压缩过滤器
+func NewCompression(conf *CompressionConfig) *Compression {
+	c := &Compression{conf: conf, writers: map[string]*sync.Pool{
+		encodingGzip: {New: func() interface{} {
+			// 压缩级别已经在解析配置时校验过
+			w, _ := gzip.NewWriterLevel(io.Discard, conf.Level)
+			return w
+		}},
+	}}
+	if conf.Algorithm == encodingZstd {
+		c.writers[encodingZstd] = &sync.Pool{New: func() interface{} {
+			w, _ := zstd.NewWriter(io.Discard, zstd.WithEncoderConcurrency(1))
+			return w
+		}}
+	}
+	return c
+}
+
+// Filter go-restful 的过滤器
+func (c *Compression) Filter(req *restful.Request, rsp *restful.Response, chain *restful.FilterChain) {
+	if !c.conf.Open || !strings.Contains(req.Request.URL.Path, "Discover") {
+		chain.ProcessFilter(req, rsp)
+		return
+	}
+	encoding := negotiateEncoding(req.HeaderParameter(HeaderAcceptEncoding), c.conf.Algorithm == encodingZstd)
+	if encoding == "" {
+		chain.ProcessFilter(req, rsp)
+		return
+	}
+
+	rsp.Header().Add("Vary", HeaderAcceptEncoding)
+	writer := &compressWriter{ResponseWriter: rsp.ResponseWriter, owner: c, encoding: encoding}
+	rsp.ResponseWriter = writer
+	defer func() {
+		rsp.ResponseWriter = writer.ResponseWriter
+		writer.close()
+	}()
+	chain.ProcessFilter(req, rsp)
+}
+
+// negotiateEncoding 从 Accept-Encoding 中选择支持的压缩算法，q=0 表示客户端不接受
+// 开启 zstd 并且客户端明确接受 zstd 时优先使用 zstd，否则使用 gzip
+func negotiateEncoding(accept string, zstdEnabled bool) string {
+	ret := ""
+	for _, item := range strings.Split(accept, ",") {
+		parts := strings.Split(item, ";")
+		encoding := strings.ToLower(strings.TrimSpace(parts[0]))
+		switch {
+		case encoding == encodingZstd && zstdEnabled:
+		case encoding == encodingGzip || encoding == "*":
+		default:
+			continue
+		}
+		accepted := true
+		for _, param := range parts[1:] {
+			param = strings.TrimSpace(param)
+			if !strings.HasPrefix(param, "q=") {
+				continue
+			}
+			if q, err := strconv.ParseFloat(strings.TrimPrefix(param, "q="), 64); err == nil && q <= 0 {
+				accepted = false
+			}
+		}
+		if !accepted {
+			continue
+		}
+		if encoding == encodingZstd {
+			return encodingZstd
+		}
+		ret = encodingGzip
+	}
+	return ret
+}
+
+// compressWriter 缓存响应体，超过 MinSize 之后才开始压缩，较小的响应体直接原样返回
+type compressWriter struct {
+	http.ResponseWriter
+	owner       *Compression
+	encoding    string
+	status      int
+	buf         bytes.Buffer
+	encoder     encoder
+	wroteHeader bool
+	passthrough bool
+}
+
+// WriteHeader 延迟到确定是否压缩之后再写入响应头
+func (w *compressWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+}
+
+func (w *compressWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	if w.encoder != nil {
+		return w.encoder.Write(b)
+	}
+	if w.passthrough {
+		return w.ResponseWriter.Write(b)
+	}
+	// 已经被处理函数压缩过的响应体不再压缩
+	if w.Header().Get(HeaderContentEncoding) != "" {
+		w.flushHeader(false)
+		return w.ResponseWriter.Write(b)
+	}
+	w.buf.Write(b)
+	if w.buf.Len() < w.owner.conf.MinSize {
+		return len(b), nil
+	}
+	w.flushHeader(true)
+	if _, err := w.encoder.Write(w.buf.Bytes()); err != nil {
+		return 0, err
+	}
+	w.buf.Reset()
+	return len(b), nil
+}
+
+func (w *compressWriter) flushHeader(compress bool) {
+	if compress {
+		w.Header().Set(HeaderContentEncoding, w.encoding)
+		w.Header().Del("Content-Length")
+		w.encoder = w.owner.writers[w.encoding].Get().(encoder)
+		w.encoder.Reset(w.ResponseWriter)
+	} else {
+		w.passthrough = true
+	}
+	w.wroteHeader = true
+	w.ResponseWriter.WriteHeader(w.status)
+}
+
+// close 结束压缩，未达到 MinSize 的响应体原样写入
+func (w *compressWriter) close() {
+	if w.encoder != nil {
+		_ = w.encoder.Close()
+		w.encoder.Reset(io.Discard)
+		w.owner.writers[w.encoding].Put(w.encoder)
+		w.encoder = nil
+		return
+	}
+	if w.wroteHeader {
+		return
+	}
+	if w.status != 0 {
+		w.ResponseWriter.WriteHeader(w.status)
+	}
+	if w.buf.Len() > 0 {
+		_, _ = w.ResponseWriter.Write(w.buf.Bytes())
+	}
+}
+
+// Flush 长轮询等场景需要及时将数据发送给客户端
+func (w *compressWriter) Flush() {
+	if !w.wroteHeader {
+		w.flushHeader(w.buf.Len() > 0 && w.Header().Get(HeaderContentEncoding) == "")
+		if w.encoder != nil {
+			_, _ = w.encoder.Write(w.buf.Bytes())
+			w.buf.Reset()
+		}
+	}
+	if w.encoder != nil {
+		_ = w.encoder.Flush()
+	}
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
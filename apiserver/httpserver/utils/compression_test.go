@@ -0,0 +1,146 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+package utils
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/emicklei/go-restful/v3"
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+)
+
+func newCompressionContainer(conf *CompressionConfig, body string) *restful.Container {
+	container := restful.NewContainer()
+	container.Filter(NewCompression(conf).Filter)
+	ws := new(restful.WebService)
+	handler := func(req *restful.Request, rsp *restful.Response) {
+		rsp.WriteHeader(http.StatusOK)
+		_, _ = rsp.Write([]byte(body))
+	}
+	ws.Route(ws.POST("/v1/Discover").To(handler))
+	ws.Route(ws.GET("/naming/v1/instances").To(handler))
+	container.Add(ws)
+	return container
+}
+
+func doCompressionRequest(container *restful.Container, method, path, accept string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	if accept != "" {
+		req.Header.Set(HeaderAcceptEncoding, accept)
+	}
+	rsp := httptest.NewRecorder()
+	container.ServeHTTP(rsp, req)
+	return rsp
+}
+
+func TestCompression_Gzip(t *testing.T) {
+	body := strings.Repeat("instance", 1024)
+	container := newCompressionContainer(&CompressionConfig{Open: true, MinSize: 1024, Level: gzip.BestSpeed}, body)
+
+	rsp := doCompressionRequest(container, http.MethodPost, "/v1/Discover", "deflate, gzip;q=0.8")
+	assert.Equal(t, http.StatusOK, rsp.Code)
+	assert.Equal(t, "gzip", rsp.Header().Get(HeaderContentEncoding))
+	assert.Less(t, rsp.Body.Len(), len(body))
+	reader, err := gzip.NewReader(rsp.Body)
+	assert.NoError(t, err)
+	data, err := io.ReadAll(reader)
+	assert.NoError(t, err)
+	assert.Equal(t, body, string(data))
+
+	// 客户端不支持 gzip
+	for _, accept := range []string{"", "deflate", "gzip;q=0"} {
+		rsp = doCompressionRequest(container, http.MethodPost, "/v1/Discover", accept)
+		assert.Empty(t, rsp.Header().Get(HeaderContentEncoding), accept)
+		assert.Equal(t, body, rsp.Body.String(), accept)
+	}
+
+	// 只压缩 Discover 的响应
+	rsp = doCompressionRequest(container, http.MethodGet, "/naming/v1/instances", "gzip")
+	assert.Empty(t, rsp.Header().Get(HeaderContentEncoding))
+	assert.Equal(t, body, rsp.Body.String())
+}
+
+func TestCompression_Zstd(t *testing.T) {
+	body := strings.Repeat("instance", 1024)
+	container := newCompressionContainer(&CompressionConfig{Open: true, MinSize: 1024, Algorithm: "zstd"}, body)
+
+	rsp := doCompressionRequest(container, http.MethodPost, "/v1/Discover", "gzip, zstd")
+	assert.Equal(t, http.StatusOK, rsp.Code)
+	assert.Equal(t, "zstd", rsp.Header().Get(HeaderContentEncoding))
+	assert.Less(t, rsp.Body.Len(), len(body))
+	reader, err := zstd.NewReader(rsp.Body)
+	assert.NoError(t, err)
+	data, err := io.ReadAll(reader)
+	reader.Close()
+	assert.NoError(t, err)
+	assert.Equal(t, body, string(data))
+
+	// 客户端不支持 zstd 时回退到 gzip
+	for _, accept := range []string{"gzip", "zstd;q=0, gzip", "*"} {
+		rsp = doCompressionRequest(container, http.MethodPost, "/v1/Discover", accept)
+		assert.Equal(t, "gzip", rsp.Header().Get(HeaderContentEncoding), accept)
+	}
+
+	// 未开启 zstd 时不使用 zstd
+	container = newCompressionContainer(&CompressionConfig{Open: true, MinSize: 1024}, body)
+	rsp = doCompressionRequest(container, http.MethodPost, "/v1/Discover", "zstd")
+	assert.Empty(t, rsp.Header().Get(HeaderContentEncoding))
+	assert.Equal(t, body, rsp.Body.String())
+}
+
+func TestCompression_MinSize(t *testing.T) {
+	container := newCompressionContainer(&CompressionConfig{Open: true, MinSize: 1024}, "small")
+	rsp := doCompressionRequest(container, http.MethodPost, "/v1/Discover", "gzip")
+	assert.Equal(t, http.StatusOK, rsp.Code)
+	assert.Empty(t, rsp.Header().Get(HeaderContentEncoding))
+	assert.Equal(t, "small", rsp.Body.String())
+
+	// 未开启时不压缩
+	container = newCompressionContainer(&CompressionConfig{MinSize: 0}, "small")
+	rsp = doCompressionRequest(container, http.MethodPost, "/v1/Discover", "gzip")
+	assert.Empty(t, rsp.Header().Get(HeaderContentEncoding))
+}
+
+func TestParseCompressionConfig(t *testing.T) {
+	conf, err := ParseCompressionConfig(nil)
+	assert.NoError(t, err)
+	assert.False(t, conf.Open)
+	assert.Equal(t, defaultCompressionMinSize, conf.MinSize)
+
+	conf, err = ParseCompressionConfig(map[interface{}]interface{}{"open": true, "minSize": 0, "level": 9})
+	assert.NoError(t, err)
+	assert.True(t, conf.Open)
+	assert.Equal(t, 0, conf.MinSize)
+	assert.Equal(t, gzip.BestCompression, conf.Level)
+
+	_, err = ParseCompressionConfig(map[interface{}]interface{}{"level": 10})
+	assert.Error(t, err)
+	_, err = ParseCompressionConfig(map[interface{}]interface{}{"minSize": -1})
+	assert.Error(t, err)
+
+	conf, err = ParseCompressionConfig(map[interface{}]interface{}{"algorithm": "ZSTD"})
+	assert.NoError(t, err)
+	assert.Equal(t, "zstd", conf.Algorithm)
+	_, err = ParseCompressionConfig(map[interface{}]interface{}{"algorithm": "br"})
+	assert.Error(t, err)
+}
@@ -25,6 +25,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"path"
 	"strings"
@@ -40,6 +41,8 @@ import (
 	"github.com/polarismesh/polaris/apiserver/httpserver/i18n"
 	api "github.com/polarismesh/polaris/common/api/v1"
 	commonlog "github.com/polarismesh/polaris/common/log"
+	"github.com/polarismesh/polaris/common/secure"
+	"github.com/polarismesh/polaris/common/trace"
 	"github.com/polarismesh/polaris/common/utils"
 )
 
@@ -92,7 +95,8 @@ func (h *Handler) postParseMessage(requestID string) (context.Context, error) {
 	platformToken := h.Request.HeaderParameter("Platform-Token")
 	token := h.Request.HeaderParameter("Polaris-Token")
 	authToken := h.Request.HeaderParameter(utils.HeaderAuthTokenKey)
-	ctx := context.Background()
+	// 透传 apiserver 创建的 span
+	ctx := trace.ContextWithSpan(context.Background(), trace.SpanFromContext(h.Request.Request.Context()))
 	ctx = context.WithValue(ctx, utils.StringContext("request-id"), requestID)
 	ctx = context.WithValue(ctx, utils.StringContext("platform-id"), platformID)
 	ctx = context.WithValue(ctx, utils.StringContext("platform-token"), platformToken)
@@ -102,6 +106,25 @@ func (h *Handler) postParseMessage(requestID string) (context.Context, error) {
 	if authToken != "" {
 		ctx = context.WithValue(ctx, utils.ContextAuthTokenKey, authToken)
 	}
+	if breakGlassToken := h.Request.HeaderParameter(utils.HeaderBreakGlassTokenKey); breakGlassToken != "" {
+		ctx = context.WithValue(ctx, utils.ContextBreakGlassTokenKey, breakGlassToken)
+	}
+	if changeSetID := h.Request.HeaderParameter(utils.HeaderChangeSetIDKey); changeSetID != "" {
+		// 客户端指定的 ID 只在当前调用方的范围内生效，避免向其他操作者的变更集中追加记录
+		credential := authToken
+		if credential == "" {
+			credential, _, _ = net.SplitHostPort(h.Request.Request.RemoteAddr)
+		}
+		changeSetID = utils.ScopedChangeSetID(credential, changeSetID)
+		ctx = context.WithValue(ctx, utils.ContextChangeSetIDKey, changeSetID)
+		if h.Response != nil {
+			h.Response.AddHeader(utils.HeaderChangeSetIDKey, changeSetID)
+		}
+	}
+	// 只使用经过 CA 校验的证书链，未校验的客户端证书不能作为身份
+	if certs := secure.VerifiedPeerCertificates(h.Request.Request.TLS); len(certs) > 0 {
+		ctx = context.WithValue(ctx, utils.ContextPeerCertificates, certs)
+	}
 
 	var operator string
 	addrSlice := strings.Split(h.Request.Request.RemoteAddr, ":")
@@ -137,7 +160,8 @@ func (h *Handler) ParseHeaderContext() context.Context {
 	token := h.Request.HeaderParameter("Polaris-Token")
 	authToken := h.Request.HeaderParameter(utils.HeaderAuthTokenKey)
 
-	ctx := context.Background()
+	// 透传 apiserver 创建的 span
+	ctx := trace.ContextWithSpan(context.Background(), trace.SpanFromContext(h.Request.Request.Context()))
 	ctx = context.WithValue(ctx, utils.StringContext("request-id"), requestID)
 	ctx = context.WithValue(ctx, utils.StringContext("platform-id"), platformID)
 	ctx = context.WithValue(ctx, utils.StringContext("platform-token"), platformToken)
@@ -102,6 +102,32 @@ func Test_ParseJsonBody(t *testing.T) {
 
 }
 
+func Test_ParseChangeSetID(t *testing.T) {
+	parse := func(authToken, changeSetID string) (string, string) {
+		httpReq := httptest.NewRequest(http.MethodPost, "http://example.com", strings.NewReader("{}"))
+		httpReq.Header.Set(utils.HeaderAuthTokenKey, authToken)
+		httpReq.Header.Set(utils.HeaderChangeSetIDKey, changeSetID)
+		recorder := httptest.NewRecorder()
+		h := &Handler{Request: restful.NewRequest(httpReq), Response: restful.NewResponse(recorder)}
+		ctx, err := h.Parse(&apimodel.Namespace{})
+		assert.NoError(t, err)
+		return utils.ParseChangeSetID(ctx), recorder.Header().Get(utils.HeaderChangeSetIDKey)
+	}
+
+	first, header := parse("token-a", "change-set-1")
+	assert.NotEqual(t, "change-set-1", first)
+	assert.Equal(t, first, header)
+	// 同一调用方携带相同的 ID 归属同一个变更集
+	second, _ := parse("token-a", "change-set-1")
+	assert.Equal(t, first, second)
+	// 其他调用方即使携带相同的 ID 也无法写入该变更集
+	foreign, _ := parse("token-b", "change-set-1")
+	assert.NotEqual(t, first, foreign)
+	// 直接携带服务端派生出的 ID 也不能加入该变更集
+	replay, _ := parse("token-b", first)
+	assert.NotEqual(t, first, replay)
+}
+
 func TestParseQueryParams(t *testing.T) {
 	hreq, _ := http.NewRequest(http.MethodGet, "http://localhost:8090/naming/v1/instances?namespace=default&service=mysql&healthy=true&isolate=false&keys=region&values=cn&keys=zone&values=1a&keys=version&values=v1.0.0&keys=environment&values=prod", nil)
 	req := restful.NewRequest(hreq)
@@ -0,0 +1,326 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+package utils
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/emicklei/go-restful/v3"
+	lru "github.com/hashicorp/golang-lru"
+
+	"github.com/polarismesh/polaris/common/utils"
+)
+
+const (
+	// HeaderIdempotencyKey 写请求携带的幂等键
+	HeaderIdempotencyKey = "Idempotency-Key"
+	// HeaderIdempotentReplayed 响应是否为之前请求结果的重放
+	HeaderIdempotentReplayed = "Idempotent-Replayed"
+
+	defaultIdempotencyTTL       = 10 * time.Minute
+	defaultIdempotencySize      = 10240
+	defaultIdempotencyBodySize  = 4 * 1024 * 1024
+	defaultIdempotencyCacheSize = 64 * 1024 * 1024
+	maxIdempotencyKeyLen        = 255
+)
+
+// idempotentRoutes 支持幂等键的写接口：服务、实例以及配置的增删改，按照 method 以及完整路径匹配
+// 读接口、长轮询以及导入导出等接口即使使用写方法也不做去重
+var idempotentRoutes = map[string]struct{}{
+	"POST /naming/v1/services":                      {},
+	"POST /naming/v1/services/delete":               {},
+	"PUT /naming/v1/services":                       {},
+	"POST /naming/v1/instances":                     {},
+	"POST /naming/v1/instances/delete":              {},
+	"POST /naming/v1/instances/delete/host":         {},
+	"PUT /naming/v1/instances":                      {},
+	"PUT /naming/v1/instances/isolate/host":         {},
+	"PUT /naming/v1/instances/drain":                {},
+	"POST /v1/RegisterInstance":                     {},
+	"POST /v1/BatchRegisterInstance":                {},
+	"POST /v1/DeregisterInstance":                   {},
+	"POST /config/v1/configfilegroups":              {},
+	"PUT /config/v1/configfilegroups":               {},
+	"DELETE /config/v1/configfilegroups":            {},
+	"POST /config/v1/configfiles":                   {},
+	"PUT /config/v1/configfiles":                    {},
+	"DELETE /config/v1/configfiles":                 {},
+	"POST /config/v1/configfiles/batchdelete":       {},
+	"POST /config/v1/configfiles/release":           {},
+	"POST /config/v1/configfiles/createandpub":      {},
+	"PUT /config/v1/configfiles/releases/rollback":  {},
+	"POST /config/v1/configfiles/releases/delete":   {},
+	"POST /config/v1/configfiles/releases/stopbeta": {},
+}
+
+// IdempotencyConfig 幂等键的配置
+type IdempotencyConfig struct {
+	// Open 是否开启
+	Open bool
+	// TTL 幂等键以及响应结果的保存时间
+	TTL time.Duration
+	// MaxKeys 最多保存的幂等键数量
+	MaxKeys int
+	// MaxBodySize 携带幂等键的请求体的最大字节数，超出时拒绝请求
+	MaxBodySize int64
+	// MaxCacheSize 保存的响应体的最大总字节数，超出时淘汰最早的幂等键，单个响应超出时不保存
+	MaxCacheSize int64
+}
+
+// ParseIdempotencyConfig 解析幂等键配置，未配置时默认关闭
+func ParseIdempotencyConfig(raw map[interface{}]interface{}) (*IdempotencyConfig, error) {
+	conf := &IdempotencyConfig{
+		TTL:          defaultIdempotencyTTL,
+		MaxKeys:      defaultIdempotencySize,
+		MaxBodySize:  defaultIdempotencyBodySize,
+		MaxCacheSize: defaultIdempotencyCacheSize,
+	}
+	if raw == nil {
+		return conf, nil
+	}
+	if open, ok := raw["open"].(bool); ok {
+		conf.Open = open
+	}
+	if ttl, ok := raw["ttl"].(string); ok && ttl != "" {
+		val, err := time.ParseDuration(ttl)
+		if err != nil {
+			return nil, fmt.Errorf("invalid idempotency ttl: %w", err)
+		}
+		conf.TTL = val
+	}
+	if maxKeys, ok := raw["maxKeys"].(int); ok {
+		if maxKeys <= 0 {
+			return nil, fmt.Errorf("invalid idempotency maxKeys: %d", maxKeys)
+		}
+		conf.MaxKeys = maxKeys
+	}
+	if size, ok := raw["maxBodySize"].(int); ok {
+		if size <= 0 {
+			return nil, fmt.Errorf("invalid idempotency maxBodySize: %d", size)
+		}
+		conf.MaxBodySize = int64(size)
+	}
+	if size, ok := raw["maxCacheSize"].(int); ok {
+		if size <= 0 {
+			return nil, fmt.Errorf("invalid idempotency maxCacheSize: %d", size)
+		}
+		conf.MaxCacheSize = int64(size)
+	}
+	if conf.TTL <= 0 {
+		return nil, fmt.Errorf("invalid idempotency ttl: %s", conf.TTL)
+	}
+	return conf, nil
+}
+
+// idempotentRecord 幂等键对应的请求以及响应
+type idempotentRecord struct {
+	// fingerprint 请求体的摘要，同一个幂等键不允许用于不同的请求
+	fingerprint string
+	done        bool
+	expireAt    time.Time
+	status      int
+	header      http.Header
+	body        []byte
+}
+
+// Idempotency 基于 Idempotency-Key 的写请求去重
+// SDK 重试携带相同幂等键的请求时，直接返回第一次请求的结果，避免重复创建实例或者重复发布配置
+// 幂等键只保存在当前节点的内存中，只能对重试到同一个节点的请求去重
+type Idempotency struct {
+	conf    *IdempotencyConfig
+	lock    sync.Mutex
+	records *lru.Cache
+	// size 已保存的响应体总字节数
+	size int64
+}
+
+// NewIdempotency 新建幂等键过滤器
+func NewIdempotency(conf *IdempotencyConfig) (*Idempotency, error) {
+	i := &Idempotency{conf: conf}
+	records, err := lru.NewWithEvict(conf.MaxKeys, i.onEvicted)
+	if err != nil {
+		return nil, err
+	}
+	i.records = records
+	return i, nil
+}
+
+// onEvicted 幂等键被删除或者淘汰时扣减响应体的字节数，调用方已经持有 lock
+func (i *Idempotency) onEvicted(_ interface{}, value interface{}) {
+	i.size -= int64(len(value.(*idempotentRecord).body))
+}
+
+// isIdempotentTarget 是否为支持幂等键的写接口
+func isIdempotentTarget(req *http.Request) bool {
+	_, ok := idempotentRoutes[req.Method+" "+strings.TrimSuffix(req.URL.Path, "/")]
+	return ok
+}
+
+// Filter go-restful 的过滤器
+func (i *Idempotency) Filter(req *restful.Request, rsp *restful.Response, chain *restful.FilterChain) {
+	key := req.HeaderParameter(HeaderIdempotencyKey)
+	if !i.conf.Open || key == "" || !isIdempotentTarget(req.Request) {
+		chain.ProcessFilter(req, rsp)
+		return
+	}
+	if len(key) > maxIdempotencyKeyLen {
+		http.Error(rsp.ResponseWriter, "idempotency key is too long", http.StatusBadRequest)
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(rsp.ResponseWriter, req.Request.Body, i.conf.MaxBodySize))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			http.Error(rsp.ResponseWriter, "request body is too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(rsp.ResponseWriter, err.Error(), http.StatusBadRequest)
+		return
+	}
+	req.Request.Body = io.NopCloser(bytes.NewReader(body))
+	sum := sha256.Sum256(body)
+	fingerprint := hex.EncodeToString(sum[:])
+
+	// 不同用户的幂等键相互隔离
+	recordKey := strings.Join([]string{req.HeaderParameter(utils.HeaderAuthTokenKey), req.Request.Method,
+		req.Request.URL.Path, key}, "|")
+	record, status := i.acquire(recordKey, fingerprint)
+	switch status {
+	case http.StatusOK:
+		replay(rsp, record)
+		return
+	case http.StatusConflict:
+		http.Error(rsp.ResponseWriter, "request with the same idempotency key is in progress", http.StatusConflict)
+		return
+	case http.StatusUnprocessableEntity:
+		http.Error(rsp.ResponseWriter, "idempotency key is already used for a different request",
+			http.StatusUnprocessableEntity)
+		return
+	}
+
+	recorder := &responseRecorder{ResponseWriter: rsp.ResponseWriter, limit: i.conf.MaxCacheSize}
+	rsp.ResponseWriter = recorder
+	completed := false
+	defer func() {
+		rsp.ResponseWriter = recorder.ResponseWriter
+		// 处理请求时发生 panic，释放幂等键允许客户端重试
+		if !completed {
+			i.release(recordKey)
+		}
+	}()
+	chain.ProcessFilter(req, rsp)
+	if recorder.overflow {
+		// 响应体超出保存上限，无法重放，释放幂等键
+		i.release(recordKey)
+	} else {
+		i.complete(recordKey, rsp.StatusCode(), rsp.Header(), recorder.body.Bytes())
+	}
+	completed = true
+}
+
+// acquire 查询幂等键的记录，不存在时占用该幂等键
+// 返回 200 表示重放已有的结果，409 表示相同的请求正在处理，422 表示幂等键已经被其他请求使用，0 表示继续处理请求
+func (i *Idempotency) acquire(key, fingerprint string) (*idempotentRecord, int) {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+	if val, ok := i.records.Get(key); ok {
+		record := val.(*idempotentRecord)
+		if time.Now().Before(record.expireAt) {
+			if record.fingerprint != fingerprint {
+				return nil, http.StatusUnprocessableEntity
+			}
+			if !record.done {
+				return nil, http.StatusConflict
+			}
+			return record, http.StatusOK
+		}
+		// 覆盖已过期的记录时 lru 不会回调淘汰，需要先删除以扣减响应体的字节数
+		i.records.Remove(key)
+	}
+	i.records.Add(key, &idempotentRecord{fingerprint: fingerprint, expireAt: time.Now().Add(i.conf.TTL)})
+	return nil, 0
+}
+
+// complete 保存请求的结果，服务端异常的结果不保存，允许客户端重试
+func (i *Idempotency) complete(key string, status int, header http.Header, body []byte) {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+	val, ok := i.records.Peek(key)
+	if !ok {
+		return
+	}
+	record := val.(*idempotentRecord)
+	if status >= http.StatusInternalServerError {
+		i.records.Remove(key)
+		return
+	}
+	record.done = true
+	record.status = status
+	record.header = header.Clone()
+	record.body = body
+	i.size += int64(len(body))
+	// 超出保存上限时从最早的幂等键开始淘汰
+	for i.size > i.conf.MaxCacheSize && i.records.Len() > 0 {
+		i.records.RemoveOldest()
+	}
+}
+
+func (i *Idempotency) release(key string) {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+	i.records.Remove(key)
+}
+
+func replay(rsp *restful.Response, record *idempotentRecord) {
+	header := rsp.Header()
+	for k, v := range record.header {
+		header[k] = v
+	}
+	header.Set(HeaderIdempotentReplayed, "true")
+	rsp.WriteHeader(record.status)
+	_, _ = rsp.Write(record.body)
+}
+
+// responseRecorder 记录写入的响应体，超出 limit 后不再记录
+type responseRecorder struct {
+	http.ResponseWriter
+	body     bytes.Buffer
+	limit    int64
+	overflow bool
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	if !r.overflow {
+		if int64(r.body.Len()+len(b)) > r.limit {
+			r.overflow = true
+			r.body.Reset()
+		} else {
+			r.body.Write(b)
+		}
+	}
+	return r.ResponseWriter.Write(b)
+}
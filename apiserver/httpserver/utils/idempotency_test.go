@@ -0,0 +1,198 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+package utils
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/emicklei/go-restful/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestIdempotencyConfig() *IdempotencyConfig {
+	return &IdempotencyConfig{Open: true, TTL: time.Minute, MaxKeys: 16, MaxBodySize: 1024, MaxCacheSize: 1024}
+}
+
+func newIdempotencyContainer(t *testing.T, conf *IdempotencyConfig, status int) (*restful.Container, *int32) {
+	idempotency, err := NewIdempotency(conf)
+	assert.NoError(t, err)
+
+	var calls int32
+	container := restful.NewContainer()
+	container.Filter(idempotency.Filter)
+	ws := new(restful.WebService)
+	ws.Path("/naming/v1")
+	ws.Route(ws.POST("/instances").To(func(req *restful.Request, rsp *restful.Response) {
+		n := atomic.AddInt32(&calls, 1)
+		body, _ := io.ReadAll(req.Request.Body)
+		rsp.WriteHeader(status)
+		_, _ = rsp.Write([]byte(fmt.Sprintf("%d:%s", n, body)))
+	}))
+	ws.Route(ws.GET("/instances").To(func(req *restful.Request, rsp *restful.Response) {
+		atomic.AddInt32(&calls, 1)
+	}))
+	ws.Route(ws.POST("/service/owner").To(func(req *restful.Request, rsp *restful.Response) {
+		n := atomic.AddInt32(&calls, 1)
+		_, _ = rsp.Write([]byte(fmt.Sprintf("%d", n)))
+	}))
+	container.Add(ws)
+	return container, &calls
+}
+
+func doIdempotentRequest(container *restful.Container, method, key, body string) *httptest.ResponseRecorder {
+	return doIdempotentPathRequest(container, method, "/naming/v1/instances", key, body)
+}
+
+func doIdempotentPathRequest(container *restful.Container, method, path, key,
+	body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if key != "" {
+		req.Header.Set(HeaderIdempotencyKey, key)
+	}
+	rsp := httptest.NewRecorder()
+	container.ServeHTTP(rsp, req)
+	return rsp
+}
+
+func TestIdempotency_Replay(t *testing.T) {
+	conf := newTestIdempotencyConfig()
+	container, calls := newIdempotencyContainer(t, conf, http.StatusOK)
+
+	first := doIdempotentRequest(container, http.MethodPost, "key-1", "a")
+	assert.Equal(t, "1:a", first.Body.String())
+
+	// 相同的幂等键重放第一次的结果
+	second := doIdempotentRequest(container, http.MethodPost, "key-1", "a")
+	assert.Equal(t, http.StatusOK, second.Code)
+	assert.Equal(t, "1:a", second.Body.String())
+	assert.Equal(t, "true", second.Header().Get(HeaderIdempotentReplayed))
+	assert.Equal(t, int32(1), atomic.LoadInt32(calls))
+
+	// 幂等键被用于不同的请求
+	conflict := doIdempotentRequest(container, http.MethodPost, "key-1", "b")
+	assert.Equal(t, http.StatusUnprocessableEntity, conflict.Code)
+
+	// 没有幂等键或者读请求不做处理
+	assert.Equal(t, "2:a", doIdempotentRequest(container, http.MethodPost, "", "a").Body.String())
+	doIdempotentRequest(container, http.MethodGet, "key-1", "")
+	doIdempotentRequest(container, http.MethodGet, "key-1", "")
+	assert.Equal(t, int32(4), atomic.LoadInt32(calls))
+}
+
+func TestIdempotency_ServerError(t *testing.T) {
+	conf := newTestIdempotencyConfig()
+	container, calls := newIdempotencyContainer(t, conf, http.StatusInternalServerError)
+
+	doIdempotentRequest(container, http.MethodPost, "key-1", "a")
+	rsp := doIdempotentRequest(container, http.MethodPost, "key-1", "a")
+	// 服务端异常不保存结果，重试会重新执行
+	assert.Equal(t, "2:a", rsp.Body.String())
+	assert.Equal(t, int32(2), atomic.LoadInt32(calls))
+}
+
+func TestIdempotency_Expire(t *testing.T) {
+	conf := newTestIdempotencyConfig()
+	conf.TTL = 50 * time.Millisecond
+	container, calls := newIdempotencyContainer(t, conf, http.StatusOK)
+
+	doIdempotentRequest(container, http.MethodPost, "key-1", "a")
+	time.Sleep(100 * time.Millisecond)
+	rsp := doIdempotentRequest(container, http.MethodPost, "key-1", "a")
+	assert.Equal(t, "2:a", rsp.Body.String())
+	assert.Equal(t, int32(2), atomic.LoadInt32(calls))
+}
+
+func TestParseIdempotencyConfig(t *testing.T) {
+	// 未配置时默认关闭
+	conf, err := ParseIdempotencyConfig(nil)
+	assert.NoError(t, err)
+	assert.False(t, conf.Open)
+	assert.Equal(t, defaultIdempotencyTTL, conf.TTL)
+	assert.Equal(t, int64(defaultIdempotencyBodySize), conf.MaxBodySize)
+	assert.Equal(t, int64(defaultIdempotencyCacheSize), conf.MaxCacheSize)
+
+	conf, err = ParseIdempotencyConfig(map[interface{}]interface{}{"open": true, "ttl": "1m", "maxKeys": 10,
+		"maxBodySize": 100, "maxCacheSize": 200})
+	assert.NoError(t, err)
+	assert.True(t, conf.Open)
+	assert.Equal(t, time.Minute, conf.TTL)
+	assert.Equal(t, 10, conf.MaxKeys)
+	assert.Equal(t, int64(100), conf.MaxBodySize)
+	assert.Equal(t, int64(200), conf.MaxCacheSize)
+
+	_, err = ParseIdempotencyConfig(map[interface{}]interface{}{"ttl": "abc"})
+	assert.Error(t, err)
+	_, err = ParseIdempotencyConfig(map[interface{}]interface{}{"maxCacheSize": 0})
+	assert.Error(t, err)
+}
+
+func TestIdempotency_Routes(t *testing.T) {
+	container, calls := newIdempotencyContainer(t, newTestIdempotencyConfig(), http.StatusOK)
+
+	// 路径中包含 service 的读接口不做去重
+	doIdempotentPathRequest(container, http.MethodPost, "/naming/v1/service/owner", "key-1", "a")
+	rsp := doIdempotentPathRequest(container, http.MethodPost, "/naming/v1/service/owner", "key-1", "a")
+	assert.Equal(t, "2", rsp.Body.String())
+	assert.Empty(t, rsp.Header().Get(HeaderIdempotentReplayed))
+	assert.Equal(t, int32(2), atomic.LoadInt32(calls))
+
+	assert.True(t, isIdempotentTarget(httptest.NewRequest(http.MethodPost, "/naming/v1/instances/", nil)))
+	assert.True(t, isIdempotentTarget(httptest.NewRequest(http.MethodPost, "/v1/RegisterInstance", nil)))
+	assert.False(t, isIdempotentTarget(httptest.NewRequest(http.MethodPost, "/config/v1/WatchConfigFile", nil)))
+	assert.False(t, isIdempotentTarget(httptest.NewRequest(http.MethodGet, "/config/v1/configfiles", nil)))
+}
+
+func TestIdempotency_BodyLimit(t *testing.T) {
+	conf := newTestIdempotencyConfig()
+	conf.MaxBodySize = 4
+	container, calls := newIdempotencyContainer(t, conf, http.StatusOK)
+
+	rsp := doIdempotentRequest(container, http.MethodPost, "key-1", "12345")
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rsp.Code)
+	assert.Equal(t, int32(0), atomic.LoadInt32(calls))
+
+	rsp = doIdempotentRequest(container, http.MethodPost, "key-1", "1234")
+	assert.Equal(t, "1:1234", rsp.Body.String())
+}
+
+func TestIdempotency_CacheSize(t *testing.T) {
+	conf := newTestIdempotencyConfig()
+	conf.MaxCacheSize = 8
+	container, calls := newIdempotencyContainer(t, conf, http.StatusOK)
+
+	// 响应体超出保存上限时不保存，重试会重新执行
+	doIdempotentRequest(container, http.MethodPost, "key-1", "123456789")
+	rsp := doIdempotentRequest(container, http.MethodPost, "key-1", "123456789")
+	assert.Equal(t, "2:123456789", rsp.Body.String())
+	assert.Equal(t, int32(2), atomic.LoadInt32(calls))
+
+	// 超出总字节数时淘汰最早的幂等键
+	doIdempotentRequest(container, http.MethodPost, "key-2", "abc")
+	doIdempotentRequest(container, http.MethodPost, "key-3", "cde")
+	rsp = doIdempotentRequest(container, http.MethodPost, "key-3", "cde")
+	assert.Equal(t, "true", rsp.Header().Get(HeaderIdempotentReplayed))
+	rsp = doIdempotentRequest(container, http.MethodPost, "key-2", "abc")
+	assert.Empty(t, rsp.Header().Get(HeaderIdempotentReplayed))
+	assert.Equal(t, int32(5), atomic.LoadInt32(calls))
+}
@@ -142,6 +142,12 @@ func (h *NacosV2Server) RequestBiStream(svr nacospb.BiRequestStream_RequestBiStr
 		}
 		ctx = injectPayloadHeader(ctx, req)
 		_, val, err := h.UnmarshalPayload(req)
+		if errors.Is(err, ErrorNoSuchPayloadType) {
+			// 新版本的客户端可能会回复服务端尚未支持的消息类型，忽略即可，不能因此断开双向流
+			nacoslog.Warn("[NACOS-V2] ignore unknown client birequest", zap.String("conn-id", connID),
+				zap.String("type", req.GetMetadata().GetType()))
+			continue
+		}
 		if err != nil {
 			return err
 		}
@@ -161,11 +167,11 @@ func (h *NacosV2Server) RequestBiStream(svr nacospb.BiRequestStream_RequestBiStr
 			)
 			// 刷新链接的最近一次更新时间
 			h.connectionManager.RefreshClient(ctx)
-			if _, ok := msg.(*nacospb.NotifySubscriberResponse); !ok {
-				continue
+			switch msg.(type) {
+			case *nacospb.NotifySubscriberResponse, *nacospb.ClientDetectionResponse:
+				// notify ack msg to callback
+				h.connectionManager.InFlights().NotifyInFlight(connID, msg)
 			}
-			// notify ack msg to callback
-			h.connectionManager.InFlights().NotifyInFlight(connID, msg)
 		}
 	}
 }
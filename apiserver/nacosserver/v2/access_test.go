@@ -0,0 +1,120 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+package v2
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/stats"
+	"google.golang.org/protobuf/types/known/anypb"
+
+	nacosmodel "github.com/polarismesh/polaris/apiserver/nacosserver/model"
+	"github.com/polarismesh/polaris/apiserver/nacosserver/v2/config"
+	"github.com/polarismesh/polaris/apiserver/nacosserver/v2/discover"
+	nacospb "github.com/polarismesh/polaris/apiserver/nacosserver/v2/pb"
+	"github.com/polarismesh/polaris/apiserver/nacosserver/v2/remote"
+)
+
+type mockBiRequestStream struct {
+	grpc.ServerStream
+	ctx      context.Context
+	payloads []*nacospb.Payload
+}
+
+func (m *mockBiRequestStream) Context() context.Context {
+	return m.ctx
+}
+
+func (m *mockBiRequestStream) Recv() (*nacospb.Payload, error) {
+	if len(m.payloads) == 0 {
+		return nil, io.EOF
+	}
+	payload := m.payloads[0]
+	m.payloads = m.payloads[1:]
+	return payload, nil
+}
+
+func (m *mockBiRequestStream) Send(*nacospb.Payload) error {
+	return nil
+}
+
+func newTestPayload(t *testing.T, msgType string, msg interface{}) *nacospb.Payload {
+	data, err := json.Marshal(msg)
+	assert.NoError(t, err)
+	return &nacospb.Payload{
+		Metadata: &nacospb.Metadata{Type: msgType},
+		Body:     &anypb.Any{Value: data},
+	}
+}
+
+func TestNacosV2Server_RequestBiStream(t *testing.T) {
+	h := &NacosV2Server{
+		connectionManager: remote.NewConnectionManager(),
+		discoverSvr:       &discover.DiscoverServer{},
+		configSvr:         &config.ConfigServer{},
+	}
+	h.initHandlers()
+
+	addr := &net.TCPAddr{IP: net.ParseIP("127.0.0.1"), Port: 8848}
+	connCtx := h.connectionManager.TagConn(context.Background(), &stats.ConnTagInfo{RemoteAddr: addr})
+	connID := remote.ValueConnID(connCtx)
+
+	var received nacospb.BaseResponse
+	err := h.connectionManager.InFlights().AddInFlight(&remote.InFlight{
+		ConnID:    connID,
+		RequestID: "detect-1",
+		Callback: func(_ map[string]interface{}, resp nacospb.BaseResponse, err error) {
+			assert.NoError(t, err)
+			received = resp
+		},
+	})
+	assert.NoError(t, err)
+
+	stream := &mockBiRequestStream{
+		ctx: peer.NewContext(context.Background(), &peer.Peer{Addr: addr}),
+		payloads: []*nacospb.Payload{
+			// 服务端尚未支持的消息类型不能断开双向流
+			newTestPayload(t, "UnknownResponse", map[string]interface{}{"requestId": "unknown-1"}),
+			newTestPayload(t, nacospb.TypeClientDetectionResponse, &nacospb.ClientDetectionResponse{
+				Response: &nacospb.Response{
+					ResultCode: int(nacosmodel.Response_Success.Code),
+					Success:    true,
+					RequestId:  "detect-1",
+				},
+			}),
+		},
+	}
+	assert.NoError(t, h.RequestBiStream(stream))
+	assert.Empty(t, stream.payloads)
+
+	// 客户端探测应答需要通过 Inflight 回调通知
+	assert.NotNil(t, received)
+	assert.Equal(t, "detect-1", received.GetRequestId())
+	assert.True(t, received.IsSuccess())
+
+	client, ok := h.connectionManager.GetClient(connID)
+	assert.True(t, ok)
+	_, ok = client.LoadStream()
+	assert.True(t, ok)
+}
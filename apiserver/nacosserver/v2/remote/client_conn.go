@@ -30,6 +30,7 @@ import (
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/stats"
 
+	nacosmodel "github.com/polarismesh/polaris/apiserver/nacosserver/model"
 	nacospb "github.com/polarismesh/polaris/apiserver/nacosserver/v2/pb"
 	"github.com/polarismesh/polaris/common/eventhub"
 	commontime "github.com/polarismesh/polaris/common/time"
@@ -405,7 +406,7 @@ func (h *ConnectionManager) ejectOutdateConnection() {
 		outDateConnectionId := connID
 		outDateConnection := outDatedConnections[outDateConnectionId]
 		// add inflight first
-		_ = h.inFlights.AddInFlight(&InFlight{
+		if err := h.inFlights.AddInFlight(&InFlight{
 			ConnID:     connID,
 			RequestID:  req.RequestId,
 			ExpireTime: time.Now().Add(5 * time.Second),
@@ -422,7 +423,22 @@ func (h *ConnectionManager) ejectOutdateConnection() {
 					}
 				}
 			},
-		})
+		}); err != nil {
+			wait.Done()
+			continue
+		}
+		// 发送探测请求失败，直接触发 Inflight 结束
+		if err := sendClientDetection(outDateConnection, req); err != nil {
+			nacoslog.Warn("[NACOS-V2][ConnectionManager] send client detection fail",
+				zap.String("conn-id", connID), zap.Error(err))
+			h.inFlights.NotifyInFlight(connID, &nacospb.ClientDetectionResponse{
+				Response: &nacospb.Response{
+					ResultCode: int(nacosmodel.Response_Fail.Code),
+					Message:    err.Error(),
+					RequestId:  req.RequestId,
+				},
+			})
+		}
 	}
 	go func() {
 		defer cancel()
@@ -430,7 +446,8 @@ func (h *ConnectionManager) ejectOutdateConnection() {
 	}()
 	<-ctx.Done()
 	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
-		// TODO log
+		nacoslog.Warn("[NACOS-V2][ConnectionManager] wait client detection response timeout",
+			zap.Strings("conn-ids", connIds))
 	}
 	for connID := range outDatedConnections {
 		if _, ok := successConnections.Load(connID); !ok {
@@ -439,6 +456,19 @@ func (h *ConnectionManager) ejectOutdateConnection() {
 	}
 }
 
+// sendClientDetection 通过客户端注册的双向流发送探测请求，客户端回复后由双向流触发 Inflight 回调
+func sendClientDetection(client *Client, req *nacospb.ClientDetectionRequest) error {
+	stream, ok := client.loadStream()
+	if !ok {
+		return errors.New("client not register gRPC stream")
+	}
+	payload, err := MarshalPayload(req)
+	if err != nil {
+		return err
+	}
+	return stream.SendMsg(payload)
+}
+
 func ValueConnID(ctx context.Context) string {
 	ret, _ := ctx.Value(ConnIDKey{}).(string)
 	return ret
@@ -0,0 +1,136 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+package remote
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+
+	nacosmodel "github.com/polarismesh/polaris/apiserver/nacosserver/model"
+	nacospb "github.com/polarismesh/polaris/apiserver/nacosserver/v2/pb"
+)
+
+// mockDetectionStream 收到探测请求后按 reply 决定是否回复客户端探测应答
+type mockDetectionStream struct {
+	grpc.ServerStream
+	mgr     *ConnectionManager
+	connID  string
+	reply   bool
+	sendErr error
+	sent    []*nacospb.Payload
+}
+
+func (m *mockDetectionStream) SendMsg(msg interface{}) error {
+	if m.sendErr != nil {
+		return m.sendErr
+	}
+	payload := msg.(*nacospb.Payload)
+	m.sent = append(m.sent, payload)
+	if !m.reply {
+		return nil
+	}
+	req := &nacospb.ClientDetectionRequest{}
+	if err := json.Unmarshal(payload.GetBody().GetValue(), req); err != nil {
+		return err
+	}
+	resp := &nacospb.ClientDetectionResponse{Response: &nacospb.Response{
+		ResultCode: int(nacosmodel.Response_Success.Code),
+		Success:    true,
+		RequestId:  req.RequestId,
+	}}
+	go m.mgr.InFlights().NotifyInFlight(m.connID, resp)
+	return nil
+}
+
+func newTestConnectionManager(t *testing.T) *ConnectionManager {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	return &ConnectionManager{
+		connections: map[string]*Client{},
+		clients:     map[string]*Client{},
+		tcpConns:    make(map[string]net.Conn),
+		inFlights:   NewInFlights(ctx),
+		cancel:      cancel,
+	}
+}
+
+func addOutdatedClient(mgr *ConnectionManager, connID string, port int) *Client {
+	client := &Client{
+		ID:   connID,
+		Addr: &net.TCPAddr{IP: net.ParseIP("127.0.0.1"), Port: port},
+	}
+	client.refreshTimeRef.Store(time.Now().Add(-time.Minute))
+	mgr.clients[connID] = client
+	mgr.connections[client.Addr.String()] = client
+	return client
+}
+
+func Test_sendClientDetection(t *testing.T) {
+	t.Run("没有注册双向流", func(t *testing.T) {
+		err := sendClientDetection(&Client{ID: "conn-1"}, nacospb.NewClientDetectionRequest())
+		assert.Error(t, err)
+	})
+
+	t.Run("通过双向流发送探测请求", func(t *testing.T) {
+		stream := &mockDetectionStream{}
+		client := &Client{ID: "conn-1"}
+		client.SetStreamRef(&SyncServerStream{Stream: stream})
+
+		req := nacospb.NewClientDetectionRequest()
+		req.RequestId = "req-1"
+		assert.NoError(t, sendClientDetection(client, req))
+		assert.Len(t, stream.sent, 1)
+		assert.Equal(t, nacospb.TypeClientDetectionRequest, stream.sent[0].GetMetadata().GetType())
+
+		ret := &nacospb.ClientDetectionRequest{}
+		assert.NoError(t, json.Unmarshal(stream.sent[0].GetBody().GetValue(), ret))
+		assert.Equal(t, "req-1", ret.RequestId)
+	})
+}
+
+func TestConnectionManager_ejectOutdateConnection(t *testing.T) {
+	mgr := newTestConnectionManager(t)
+
+	// 客户端正常回复探测应答，保留连接并刷新时间
+	alive := addOutdatedClient(mgr, "alive", 1001)
+	alive.SetStreamRef(&SyncServerStream{Stream: &mockDetectionStream{mgr: mgr, connID: "alive", reply: true}})
+	// 客户端没有注册双向流，无法发送探测请求
+	addOutdatedClient(mgr, "no-stream", 1002)
+	// 发送探测请求失败
+	broken := addOutdatedClient(mgr, "broken", 1003)
+	broken.SetStreamRef(&SyncServerStream{Stream: &mockDetectionStream{sendErr: errors.New("mock send fail")}})
+
+	start := time.Now()
+	mgr.ejectOutdateConnection()
+	// 所有探测都已结束，不需要等待探测超时
+	assert.Less(t, time.Since(start), 5*time.Second)
+
+	_, ok := mgr.GetClient("alive")
+	assert.True(t, ok)
+	assert.True(t, time.Since(alive.loadRefreshTime()) < time.Minute)
+	_, ok = mgr.GetClient("no-stream")
+	assert.False(t, ok)
+	_, ok = mgr.GetClient("broken")
+	assert.False(t, ok)
+}
@@ -33,6 +33,7 @@ import (
 	"google.golang.org/grpc/status"
 
 	"github.com/polarismesh/polaris/apiserver"
+	grpcutils "github.com/polarismesh/polaris/apiserver/grpcserver/utils"
 	"github.com/polarismesh/polaris/apiserver/nacosserver/core"
 	v1 "github.com/polarismesh/polaris/apiserver/nacosserver/v1"
 	"github.com/polarismesh/polaris/apiserver/nacosserver/v2/config"
@@ -160,6 +161,12 @@ func (h *NacosV2Server) initHandlers() {
 				return nacospb.NewHealthCheckRequest()
 			},
 		},
+		// RequestBiStream
+		nacospb.TypeClientDetectionResponse: {
+			PayloadBuilder: func() nacospb.CustomerPayload {
+				return &nacospb.ClientDetectionResponse{Response: &nacospb.Response{}}
+			},
+		},
 	}
 
 	for k, v := range h.discoverSvr.ListGRPCHandlers() {
@@ -230,6 +237,7 @@ func (h *NacosV2Server) Run(errCh chan error) {
 	h.server = grpc.NewServer(opts...)
 	nacospb.RegisterRequestServer(h.server, h)
 	nacospb.RegisterBiRequestStreamServer(h.server, h)
+	grpcutils.RegisterHealthServer(h.server)
 
 	if err := h.server.Serve(listener); err != nil {
 		nacoslog.Errorf("[API-Server][NACOS-V2] %v", err)
@@ -244,6 +252,9 @@ var notPrintableMethods map[string]struct{}
 
 func (b *NacosV2Server) unaryInterceptor(ctx context.Context, req interface{},
 	info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (rsp interface{}, err error) {
+	if grpcutils.IsHealthMethod(info.FullMethod) {
+		return handler(ctx, req)
+	}
 	stream := newVirtualStream(ctx,
 		WithVirtualStreamBaseServer(b),
 		WithVirtualStreamLogger(nacoslog),
@@ -277,6 +288,9 @@ func (b *NacosV2Server) unaryInterceptor(ctx context.Context, req interface{},
 
 func (b *NacosV2Server) streamInterceptor(srv interface{}, ss grpc.ServerStream,
 	info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+	if grpcutils.IsHealthMethod(info.FullMethod) {
+		return handler(srv, ss)
+	}
 	stream := newVirtualStream(ss.Context(),
 		WithVirtualStreamBaseServer(b),
 		WithVirtualStreamServerStream(ss),
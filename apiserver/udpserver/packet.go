@@ -0,0 +1,145 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+package udpserver
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/polarismesh/polaris/service/healthcheck"
+)
+
+// 心跳报文的格式，多字节整数均为大端序
+//
+//	+--------+---------+-------+-----------+-------+----------------------------+-----------+
+//	| magic  | version | flags | timestamp | count | instances                  | signature |
+//	| 2 byte | 1 byte  | 1 byte| 8 byte    | 2 byte| count * (1 byte len + id)  | 32 byte   |
+//	+--------+---------+-------+-----------+-------+----------------------------+-----------+
+//
+// timestamp 为客户端发送时的 unix 毫秒级时间戳，signature 为使用共享密钥对前面全部字节计算的 HMAC-SHA256
+const (
+	// PacketVersion 当前的报文版本
+	PacketVersion byte = 1
+
+	headerSize    = 14
+	signatureSize = sha256.Size
+	// MaxPacketSize UDP 报文的最大长度
+	MaxPacketSize = 65507
+)
+
+var (
+	packetMagic = [2]byte{'P', 'H'}
+
+	// ErrInvalidPacket 报文格式不正确
+	ErrInvalidPacket = errors.New("invalid heartbeat packet")
+	// ErrInvalidSignature 签名与任何一个密钥都不匹配
+	ErrInvalidSignature = errors.New("invalid heartbeat packet signature")
+	// ErrPacketExpired 报文的时间戳超出了允许的时钟偏差，避免报文被长期重放
+	ErrPacketExpired = errors.New("heartbeat packet expired")
+)
+
+// EncodePacket 构建一个签名的心跳报文，供客户端批量上报实例心跳
+func EncodePacket(secret []byte, timestamp time.Time, instanceIDs []string) ([]byte, error) {
+	if len(instanceIDs) == 0 || len(instanceIDs) > healthcheck.MaxBatchHeartbeatSize {
+		return nil, fmt.Errorf("%w: instance count %d", ErrInvalidPacket, len(instanceIDs))
+	}
+	size := headerSize + signatureSize
+	for _, id := range instanceIDs {
+		if len(id) == 0 || len(id) > 255 {
+			return nil, fmt.Errorf("%w: instance id %q", ErrInvalidPacket, id)
+		}
+		size += 1 + len(id)
+	}
+	if size > MaxPacketSize {
+		return nil, fmt.Errorf("%w: packet size %d", ErrInvalidPacket, size)
+	}
+	buf := make([]byte, headerSize, size)
+	copy(buf, packetMagic[:])
+	buf[2] = PacketVersion
+	binary.BigEndian.PutUint64(buf[4:12], uint64(timestamp.UnixMilli()))
+	binary.BigEndian.PutUint16(buf[12:14], uint16(len(instanceIDs)))
+	for _, id := range instanceIDs {
+		buf = append(buf, byte(len(id)))
+		buf = append(buf, id...)
+	}
+	return append(buf, sign(secret, buf)...), nil
+}
+
+// heartbeatPacket 解析后的心跳报文
+type heartbeatPacket struct {
+	timestamp   time.Time
+	instanceIDs []string
+}
+
+// decodePacket 校验签名以及时间戳后解析心跳报文，任何一个密钥签名匹配即可，便于密钥轮换
+func decodePacket(data []byte, secrets [][]byte, now time.Time, maxSkew time.Duration) (*heartbeatPacket, error) {
+	if len(data) < headerSize+signatureSize || data[0] != packetMagic[0] || data[1] != packetMagic[1] {
+		return nil, ErrInvalidPacket
+	}
+	if data[2] != PacketVersion {
+		return nil, fmt.Errorf("%w: unsupported version %d", ErrInvalidPacket, data[2])
+	}
+	body, signature := data[:len(data)-signatureSize], data[len(data)-signatureSize:]
+	if !verify(secrets, body, signature) {
+		return nil, ErrInvalidSignature
+	}
+	timestamp := time.UnixMilli(int64(binary.BigEndian.Uint64(body[4:12])))
+	if skew := now.Sub(timestamp); skew > maxSkew || skew < -maxSkew {
+		return nil, ErrPacketExpired
+	}
+	count := int(binary.BigEndian.Uint16(body[12:14]))
+	if count == 0 || count > healthcheck.MaxBatchHeartbeatSize {
+		return nil, fmt.Errorf("%w: instance count %d", ErrInvalidPacket, count)
+	}
+	packet := &heartbeatPacket{timestamp: timestamp, instanceIDs: make([]string, 0, count)}
+	offset := headerSize
+	for i := 0; i < count; i++ {
+		if offset >= len(body) {
+			return nil, ErrInvalidPacket
+		}
+		size := int(body[offset])
+		offset++
+		if size == 0 || offset+size > len(body) {
+			return nil, ErrInvalidPacket
+		}
+		packet.instanceIDs = append(packet.instanceIDs, string(body[offset:offset+size]))
+		offset += size
+	}
+	if offset != len(body) {
+		return nil, ErrInvalidPacket
+	}
+	return packet, nil
+}
+
+func sign(secret, body []byte) []byte {
+	mac := hmac.New(sha256.New, secret)
+	_, _ = mac.Write(body)
+	return mac.Sum(nil)
+}
+
+func verify(secrets [][]byte, body, signature []byte) bool {
+	for _, secret := range secrets {
+		if hmac.Equal(sign(secret, body), signature) {
+			return true
+		}
+	}
+	return false
+}
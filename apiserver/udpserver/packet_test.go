@@ -0,0 +1,107 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package udpserver
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncodeDecodePacket(t *testing.T) {
+	secret := []byte("secret")
+	now := time.Now()
+	ids := []string{"ins-1", "ins-2", strings.Repeat("a", 255)}
+	data, err := EncodePacket(secret, now, ids)
+	assert.NoError(t, err)
+
+	packet, err := decodePacket(data, [][]byte{[]byte("old"), secret}, now, time.Second)
+	assert.NoError(t, err)
+	assert.Equal(t, ids, packet.instanceIDs)
+	assert.Equal(t, now.UnixMilli(), packet.timestamp.UnixMilli())
+
+	t.Run("签名不匹配", func(t *testing.T) {
+		_, err := decodePacket(data, [][]byte{[]byte("other")}, now, time.Second)
+		assert.True(t, errors.Is(err, ErrInvalidSignature))
+
+		tampered := append([]byte{}, data...)
+		tampered[headerSize+1] = 'x'
+		_, err = decodePacket(tampered, [][]byte{secret}, now, time.Second)
+		assert.True(t, errors.Is(err, ErrInvalidSignature))
+	})
+	t.Run("超出允许的时钟偏差", func(t *testing.T) {
+		_, err := decodePacket(data, [][]byte{secret}, now.Add(2*time.Second), time.Second)
+		assert.True(t, errors.Is(err, ErrPacketExpired))
+		_, err = decodePacket(data, [][]byte{secret}, now.Add(-2*time.Second), time.Second)
+		assert.True(t, errors.Is(err, ErrPacketExpired))
+	})
+	t.Run("报文格式不正确", func(t *testing.T) {
+		_, err := decodePacket(data[:10], [][]byte{secret}, now, time.Second)
+		assert.True(t, errors.Is(err, ErrInvalidPacket))
+
+		other := append([]byte{}, data...)
+		other[2] = PacketVersion + 1
+		_, err = decodePacket(other, [][]byte{secret}, now, time.Second)
+		assert.True(t, errors.Is(err, ErrInvalidPacket))
+
+		// 签名正确但是实例数量与内容不一致
+		body := append([]byte{}, data[:len(data)-signatureSize]...)
+		body[13]++
+		_, err = decodePacket(append(body, sign(secret, body)...), [][]byte{secret}, now, time.Second)
+		assert.True(t, errors.Is(err, ErrInvalidPacket))
+	})
+}
+
+func TestEncodePacket_Invalid(t *testing.T) {
+	_, err := EncodePacket([]byte("secret"), time.Now(), nil)
+	assert.True(t, errors.Is(err, ErrInvalidPacket))
+	_, err = EncodePacket([]byte("secret"), time.Now(), []string{""})
+	assert.True(t, errors.Is(err, ErrInvalidPacket))
+	_, err = EncodePacket([]byte("secret"), time.Now(), []string{strings.Repeat("a", 256)})
+	assert.True(t, errors.Is(err, ErrInvalidPacket))
+	ids := make([]string, 501)
+	for i := range ids {
+		ids[i] = "ins"
+	}
+	_, err = EncodePacket([]byte("secret"), time.Now(), ids)
+	assert.True(t, errors.Is(err, ErrInvalidPacket))
+}
+
+func TestUDPServer_Initialize(t *testing.T) {
+	svr := &UDPServer{}
+	assert.Error(t, svr.Initialize(context.Background(), map[string]interface{}{}, nil))
+	assert.Error(t, svr.Initialize(context.Background(), map[string]interface{}{
+		optionSecrets:      []interface{}{"secret"},
+		optionMaxClockSkew: "abc",
+	}, nil))
+	assert.NoError(t, svr.Initialize(context.Background(), map[string]interface{}{
+		optionListenPort:   18093,
+		optionSecrets:      []interface{}{"secret", "new-secret"},
+		optionWorkers:      2,
+		optionMaxClockSkew: "10s",
+	}, nil))
+	assert.Equal(t, uint32(18093), svr.GetPort())
+	assert.Len(t, svr.secrets, 2)
+	assert.Equal(t, 2, svr.workers)
+	assert.Equal(t, DefaultQueueSize, svr.queueSize)
+	assert.Equal(t, 10*time.Second, svr.maxClockSkew)
+}
@@ -0,0 +1,275 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+package udpserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"runtime"
+	"sync"
+	"time"
+
+	apiservice "github.com/polarismesh/specification/source/go/api/v1/service_manage"
+	"go.uber.org/zap"
+
+	"github.com/polarismesh/polaris/apiserver"
+	api "github.com/polarismesh/polaris/common/api/v1"
+	"github.com/polarismesh/polaris/common/metrics"
+	"github.com/polarismesh/polaris/common/utils"
+	"github.com/polarismesh/polaris/plugin"
+	"github.com/polarismesh/polaris/service/healthcheck"
+)
+
+const (
+	optionListenIP     = "listenIP"
+	optionListenPort   = "listenPort"
+	optionSecrets      = "secrets"
+	optionWorkers      = "workers"
+	optionQueueSize    = "queueSize"
+	optionReadBuffer   = "readBuffer"
+	optionMaxClockSkew = "maxClockSkew"
+
+	DefaultListenIP   = "0.0.0.0"
+	DefaultListenPort = 8093
+	// DefaultQueueSize 等待处理的报文数，队列满时直接丢弃报文，客户端在下一个心跳周期重新上报
+	DefaultQueueSize = 10240
+	// DefaultReadBuffer socket 的接收缓冲区大小
+	DefaultReadBuffer = 4 * 1024 * 1024
+	// DefaultMaxClockSkew 报文时间戳与服务端时间允许的最大偏差
+	DefaultMaxClockSkew = 30 * time.Second
+
+	protocolUDP  = "udp"
+	apiHeartbeat = "UDPHeartbeat"
+)
+
+// packet 等待处理的报文
+type packet struct {
+	buf  *[]byte
+	size int
+	addr net.Addr
+}
+
+// UDPServer 基于 UDP 的实例心跳上报服务器
+// 每个报文批量携带多个实例的心跳，不建立连接也不返回应答，减少大规模实例场景下心跳占用的 CPU 以及连接数
+// 报文需要使用共享密钥签名，时间戳超出允许偏差的报文会被丢弃
+type UDPServer struct {
+	listenIP     string
+	listenPort   uint32
+	secrets      [][]byte
+	workers      int
+	queueSize    int
+	readBuffer   int
+	maxClockSkew time.Duration
+
+	conn              net.PacketConn
+	bufPool           sync.Pool
+	healthCheckServer *healthcheck.Server
+	statis            plugin.Statis
+	stopped           bool
+	lock              sync.Mutex
+}
+
+// GetPort 获取端口
+func (u *UDPServer) GetPort() uint32 {
+	return u.listenPort
+}
+
+// GetProtocol 获取协议
+func (u *UDPServer) GetProtocol() string {
+	return protocolUDP
+}
+
+// Initialize 初始化 UDP 心跳服务器
+func (u *UDPServer) Initialize(_ context.Context, option map[string]interface{},
+	_ map[string]apiserver.APIConfig) error {
+	u.listenIP = DefaultListenIP
+	if ipValue, ok := option[optionListenIP].(string); ok && ipValue != "" {
+		u.listenIP = ipValue
+	}
+	u.listenPort = uint32(DefaultListenPort)
+	if portValue, ok := option[optionListenPort].(int); ok {
+		u.listenPort = uint32(portValue)
+	}
+	u.secrets = nil
+	secrets, _ := option[optionSecrets].([]interface{})
+	for _, item := range secrets {
+		if secret, _ := item.(string); secret != "" {
+			u.secrets = append(u.secrets, []byte(secret))
+		}
+	}
+	if len(u.secrets) == 0 {
+		return errors.New("udp heartbeat server secrets is empty")
+	}
+	u.workers = intOption(option, optionWorkers, runtime.NumCPU())
+	u.queueSize = intOption(option, optionQueueSize, DefaultQueueSize)
+	u.readBuffer = intOption(option, optionReadBuffer, DefaultReadBuffer)
+	u.maxClockSkew = DefaultMaxClockSkew
+	if value, _ := option[optionMaxClockSkew].(string); value != "" {
+		skew, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("udp heartbeat server maxClockSkew %q is invalid: %w", value, err)
+		}
+		u.maxClockSkew = skew
+	}
+	u.bufPool.New = func() interface{} {
+		buf := make([]byte, MaxPacketSize)
+		return &buf
+	}
+	return nil
+}
+
+func intOption(option map[string]interface{}, key string, defaultValue int) int {
+	if value, ok := option[key].(int); ok && value > 0 {
+		return value
+	}
+	return defaultValue
+}
+
+// Run 启动 UDP 心跳服务器
+func (u *UDPServer) Run(errCh chan error) {
+	log.Infof("start udp heartbeat server")
+	var err error
+	u.healthCheckServer, err = healthcheck.GetServer()
+	if err != nil {
+		log.Errorf("%v", err)
+		errCh <- err
+		return
+	}
+	u.statis = plugin.GetStatis()
+
+	address := fmt.Sprintf("%v:%v", u.listenIP, u.listenPort)
+	conn, err := net.ListenPacket(protocolUDP, address)
+	if err != nil {
+		log.Errorf("listen udp(%s) error: %v", address, err)
+		errCh <- err
+		return
+	}
+	if udpConn, ok := conn.(*net.UDPConn); ok {
+		if err := udpConn.SetReadBuffer(u.readBuffer); err != nil {
+			log.Warn("[UDP][Heartbeat] set read buffer", zap.Int("size", u.readBuffer), zap.Error(err))
+		}
+	}
+	u.lock.Lock()
+	u.conn = conn
+	u.stopped = false
+	u.lock.Unlock()
+
+	queue := make(chan *packet, u.queueSize)
+	var wg sync.WaitGroup
+	for i := 0; i < u.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for item := range queue {
+				u.handlePacket(item)
+			}
+		}()
+	}
+	defer func() {
+		close(queue)
+		wg.Wait()
+	}()
+
+	for {
+		buf := u.bufPool.Get().(*[]byte)
+		n, addr, err := conn.ReadFrom(*buf)
+		if err != nil {
+			u.bufPool.Put(buf)
+			if u.isClosed(conn) {
+				return
+			}
+			log.Errorf("read udp error: %v", err)
+			errCh <- err
+			return
+		}
+		select {
+		case queue <- &packet{buf: buf, size: n, addr: addr}:
+		default:
+			// 处理能力不足时丢弃报文，不阻塞读取
+			u.bufPool.Put(buf)
+			u.reportMetrics(http.StatusTooManyRequests, 0)
+		}
+	}
+}
+
+// handlePacket 校验并处理一个心跳报文
+func (u *UDPServer) handlePacket(item *packet) {
+	start := time.Now()
+	heartbeat, err := decodePacket((*item.buf)[:item.size], u.secrets, time.Now(), u.maxClockSkew)
+	u.bufPool.Put(item.buf)
+	if err != nil {
+		log.Debug("[UDP][Heartbeat] drop packet", zap.String("client", item.addr.String()), zap.Error(err))
+		code := http.StatusBadRequest
+		if errors.Is(err, ErrInvalidSignature) {
+			code = http.StatusUnauthorized
+		}
+		u.reportMetrics(code, time.Since(start))
+		return
+	}
+	ctx := context.WithValue(context.Background(), utils.ContextClientAddress, item.addr.String())
+	beats := make([]*apiservice.InstanceHeartbeat, 0, len(heartbeat.instanceIDs))
+	for _, id := range heartbeat.instanceIDs {
+		beats = append(beats, &apiservice.InstanceHeartbeat{InstanceId: id})
+	}
+	resp := u.healthCheckServer.Reports(ctx, beats)
+	u.reportMetrics(api.CalcCode(resp), time.Since(start))
+}
+
+func (u *UDPServer) reportMetrics(code int, duration time.Duration) {
+	if u.statis == nil {
+		return
+	}
+	u.statis.ReportCallMetrics(metrics.CallMetric{
+		Type:     metrics.ServerCallMetric,
+		API:      apiHeartbeat,
+		Protocol: "UDP",
+		Code:     code,
+		Duration: duration,
+	})
+}
+
+// isClosed 监听是否已经被 Stop 或者 Restart 主动关闭
+func (u *UDPServer) isClosed(conn net.PacketConn) bool {
+	u.lock.Lock()
+	defer u.lock.Unlock()
+	return u.stopped || u.conn != conn
+}
+
+// Stop 停止 UDP 心跳服务器
+func (u *UDPServer) Stop() {
+	u.lock.Lock()
+	defer u.lock.Unlock()
+	u.stopped = true
+	if u.conn != nil {
+		_ = u.conn.Close()
+	}
+}
+
+// Restart 重启 UDP 心跳服务器
+func (u *UDPServer) Restart(option map[string]interface{}, apiConf map[string]apiserver.APIConfig,
+	errCh chan error) error {
+	log.Infof("restart udp heartbeat server")
+	u.Stop()
+	if err := u.Initialize(context.Background(), option, apiConf); err != nil {
+		return err
+	}
+	go u.Run(errCh)
+	return nil
+}
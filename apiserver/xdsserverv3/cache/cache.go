@@ -147,7 +147,7 @@ func NewNamespaceUpdateResourcesRequest() *NamespaceUpdateResourcesRequest {
 
 // NamespaceUpdateResourcesRequest 记录命名空间下的待更新的 XDS 资源
 type NamespaceUpdateResourcesRequest struct {
-	// NormalResources CDS/EDS/RDS/VHDS 相关的资源
+	// NormalResources CDS/EDS/RDS/VHDS/ECDS 相关的资源
 	NormalResources map[resource.XDSType]*TypeResources
 	// DemandResources .
 	DemandResources map[resource.XDSType]*TypeResources
@@ -251,6 +251,24 @@ func (s *ResourcesContainer) ConstructVersionMap(modified []string) error {
 	return nil
 }
 
+// ensureVersionMap 仅在第一次被 Delta Watch 使用时全量计算 VersionMap，后续由资源变更增量维护
+func (s *ResourcesContainer) ensureVersionMap() error {
+	if s.VersionMap != nil {
+		return nil
+	}
+	return s.ConstructVersionMap(nil)
+}
+
+// updateVersionMap 只重新计算发生变更的资源版本，VersionMap 尚未初始化时说明没有 Delta Watch 在使用，直接跳过
+func (s *ResourcesContainer) updateVersionMap(modified []string) {
+	if s.VersionMap == nil || len(modified) == 0 {
+		return
+	}
+	if err := s.ConstructVersionMap(modified); err != nil {
+		log.Errorf("failed to compute version for modified resources: %s", err)
+	}
+}
+
 func newNamespaceResourcesContainer(ns string) *NamespaceResourcesContainer {
 	return &NamespaceResourcesContainer{
 		namespace:          ns,
@@ -382,6 +400,7 @@ func (sc *ResourceCache) updateResourceContainer(ctx context.Context, req *Updat
 						container.Resources[name] = res
 						modified = append(modified, name)
 					}
+					container.updateVersionMap(modified)
 				}
 				namespaceContainer.tlsResources[tlsMode][typeUrl].updateGlobalRevision()
 			}
@@ -404,6 +423,7 @@ func (sc *ResourceCache) updateResourceContainer(ctx context.Context, req *Updat
 					container.Resources[name] = res
 					modified = append(modified, name)
 				}
+				container.updateVersionMap(modified)
 			}
 
 			namespaceContainer.resourcesContainer[typeUrl].updateGlobalRevision()
@@ -443,21 +463,9 @@ func (sc *ResourceCache) UpdateResources(ctx context.Context, req *UpdateResourc
 				}
 			}
 
-			namespaceContainer, exist := sc.namespaceContainer[ns]
-			if !exist {
+			if _, exist := sc.namespaceContainer[ns]; !exist {
 				continue
 			}
-			// We only calculate version hashes when using delta. We don't
-			// want to do this when using SOTW so we can avoid unnecessary
-			// computational cost if not using delta.
-			if len(info.deltaWatches) > 0 {
-				for _, container := range namespaceContainer.resourcesContainer {
-					if err := container.ConstructVersionMap(nil); err != nil {
-						log.Errorf("failed to compute version for snapshot resources inline: %s", err)
-						return err
-					}
-				}
-			}
 
 			// process our delta watches
 			for id, watch := range info.deltaWatches {
@@ -466,6 +474,13 @@ func (sc *ResourceCache) UpdateResources(ctx context.Context, req *UpdateResourc
 				if !exist {
 					continue
 				}
+				// We only calculate version hashes when using delta. The version map is
+				// built once and then maintained incrementally by updateResourceContainer,
+				// so thousands of unchanged resources are not rehashed on every push.
+				if err := container.ensureVersionMap(); err != nil {
+					log.Errorf("failed to compute version for snapshot resources inline: %s", err)
+					return err
+				}
 				res, err := sc.respondDelta(
 					ctx,
 					container,
@@ -681,7 +696,7 @@ func (sc *ResourceCache) CreateDeltaWatch(request *cachev3.DeltaRequest, state s
 	// - we attempted to issue a response, but the caller is already up to date
 	delayedResponse := !exists
 	if exists {
-		if err := container.ConstructVersionMap(nil); err != nil {
+		if err := container.ensureVersionMap(); err != nil {
 			log.Errorf("failed to compute version for snapshot resources inline: %s", err)
 		}
 		response, err := sc.respondDelta(context.Background(), container, request, value, state)
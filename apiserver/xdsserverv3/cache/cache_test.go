@@ -0,0 +1,64 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+package cache
+
+import (
+	"context"
+	"testing"
+
+	clusterv3 "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
+	"github.com/envoyproxy/go-control-plane/pkg/cache/types"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/polarismesh/polaris/apiserver/xdsserverv3/resource"
+)
+
+func Test_ResourcesContainer_IncrementalVersionMap(t *testing.T) {
+	sc := NewResourceCache(nil)
+	ctx := context.Background()
+
+	req := NewUpdateResourcesRequest()
+	req.AddNormalNamespaces("default", resource.CDS, []types.Resource{
+		&clusterv3.Cluster{Name: "svc-a"},
+		&clusterv3.Cluster{Name: "svc-b"},
+	})
+	assert.NoError(t, sc.UpdateResources(ctx, req))
+
+	container := sc.namespaceContainer["default"].resourcesContainer[resource.CDS]
+	// 没有 Delta Watch 使用时不计算资源版本
+	assert.Nil(t, container.VersionMap)
+	assert.NoError(t, container.ensureVersionMap())
+	versionA := container.VersionMap["svc-a"]
+	versionB := container.VersionMap["svc-b"]
+	assert.NotEmpty(t, versionA)
+	assert.NotEmpty(t, versionB)
+
+	req = NewUpdateResourcesRequest()
+	req.AddNormalNamespaces("default", resource.CDS, []types.Resource{
+		&clusterv3.Cluster{Name: "svc-b", AltStatName: "changed"},
+		&clusterv3.Cluster{Name: "svc-c"},
+	})
+	req.RemoveNormalNamespaces("default", resource.TLSModeNone, resource.CDS, []types.Resource{
+		&clusterv3.Cluster{Name: "svc-a"},
+	})
+	assert.NoError(t, sc.UpdateResources(ctx, req))
+
+	_, ok := container.VersionMap["svc-a"]
+	assert.False(t, ok)
+	assert.NotEqual(t, versionB, container.VersionMap["svc-b"])
+	assert.NotEmpty(t, container.VersionMap["svc-c"])
+}
@@ -77,54 +77,72 @@ func (cds *CDSBuilder) GenerateByDirection(option *resource.BuildOption,
 	var clusters []types.Resource
 
 	services := option.Services
-	// 每一个 polaris service 对应一个 envoy cluster
+	// 每一个 polaris service 对应一个 envoy cluster，出流量场景下实例的每一个命名端口、每一个实例分组额外对应一个 envoy cluster
 	for _, svc := range services {
-		c := cds.makeCluster(svc, direction, option)
-		switch option.TLSMode {
-		case resource.TLSModePermissive:
-			// In permissive mode, we should use `TLSTransportSocket` to connect to mtls enabled endpoints.
-			// Or we use rawbuffer transport for those endpoints which not enabled mtls.
-			c.TransportSocketMatches = []*cluster.Cluster_TransportSocketMatch{
-				{
-					Name:  "tls-mode",
-					Match: resource.MTLSTransportSocketMatch,
-					TransportSocket: resource.MakeTLSTransportSocket(&tlstrans.UpstreamTlsContext{
-						CommonTlsContext: resource.OutboundCommonTLSContext,
-						Sni:              fmt.Sprintf(SniTemp, svc.Name, svc.Namespace),
-					}),
-				},
-				{
-					Name:  "rawbuffer",
-					Match: &structpb.Struct{},
-					TransportSocket: &core.TransportSocket{
-						Name: wellknown.TransportSocketRawBuffer,
-						ConfigType: &core.TransportSocket_TypedConfig{
-							TypedConfig: resource.MustNewAny(&rawbuffer.RawBuffer{}),
-						},
+		portNames := []string{""}
+		if direction == corev3.TrafficDirection_OUTBOUND {
+			portNames = append(portNames, resource.ServiceNamedPorts(svc)...)
+		}
+		for _, portName := range portNames {
+			name := resource.MakeNamedPortServiceName(svc.ServiceKey, portName, direction, option)
+			clusters = append(clusters, cds.makeServiceCluster(svc, name, direction, option))
+		}
+		if direction != corev3.TrafficDirection_OUTBOUND {
+			continue
+		}
+		for _, subset := range svc.Subsets {
+			name := resource.MakeSubsetServiceName(svc.ServiceKey, subset.Name, direction, option)
+			clusters = append(clusters, cds.makeServiceCluster(svc, name, direction, option))
+		}
+	}
+	return clusters, nil
+}
+
+func (cds *CDSBuilder) makeServiceCluster(svc *resource.ServiceInfo, name string,
+	direction corev3.TrafficDirection, option *resource.BuildOption) *cluster.Cluster {
+	c := cds.makeCluster(svc, name, direction, option)
+	switch option.TLSMode {
+	case resource.TLSModePermissive:
+		// In permissive mode, we should use `TLSTransportSocket` to connect to mtls enabled endpoints.
+		// Or we use rawbuffer transport for those endpoints which not enabled mtls.
+		c.TransportSocketMatches = []*cluster.Cluster_TransportSocketMatch{
+			{
+				Name:  "tls-mode",
+				Match: resource.MTLSTransportSocketMatch,
+				TransportSocket: resource.MakeTLSTransportSocket(&tlstrans.UpstreamTlsContext{
+					CommonTlsContext: resource.OutboundCommonTLSContext,
+					Sni:              fmt.Sprintf(SniTemp, svc.Name, svc.Namespace),
+				}),
+			},
+			{
+				Name:  "rawbuffer",
+				Match: &structpb.Struct{},
+				TransportSocket: &core.TransportSocket{
+					Name: wellknown.TransportSocketRawBuffer,
+					ConfigType: &core.TransportSocket_TypedConfig{
+						TypedConfig: resource.MustNewAny(&rawbuffer.RawBuffer{}),
 					},
 				},
-			}
-		case resource.TLSModeStrict:
-			// In strict mode, we should only use `TLSTransportSocket` to connect to mtls enabled endpoints.
-			c.TransportSocketMatches = []*cluster.Cluster_TransportSocketMatch{
-				{
-					Name: "tls-mode",
-					TransportSocket: resource.MakeTLSTransportSocket(&tlstrans.UpstreamTlsContext{
-						CommonTlsContext: resource.OutboundCommonTLSContext,
-						Sni:              fmt.Sprintf(SniTemp, svc.Name, svc.Namespace),
-					}),
-				},
-			}
+			},
+		}
+	case resource.TLSModeStrict:
+		// In strict mode, we should only use `TLSTransportSocket` to connect to mtls enabled endpoints.
+		c.TransportSocketMatches = []*cluster.Cluster_TransportSocketMatch{
+			{
+				Name: "tls-mode",
+				TransportSocket: resource.MakeTLSTransportSocket(&tlstrans.UpstreamTlsContext{
+					CommonTlsContext: resource.OutboundCommonTLSContext,
+					Sni:              fmt.Sprintf(SniTemp, svc.Name, svc.Namespace),
+				}),
+			},
 		}
-		clusters = append(clusters, c)
 	}
-	return clusters, nil
+	return c
 }
 
-func (cds *CDSBuilder) makeCluster(svcInfo *resource.ServiceInfo,
+func (cds *CDSBuilder) makeCluster(svcInfo *resource.ServiceInfo, name string,
 	trafficDirection corev3.TrafficDirection, opt *resource.BuildOption) *cluster.Cluster {
 
-	name := resource.MakeServiceName(svcInfo.ServiceKey, trafficDirection, opt)
 	c := &cluster.Cluster{
 		Name:                 name,
 		ConnectTimeout:       durationpb.New(5 * time.Second),
@@ -0,0 +1,55 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+package xdsserverv3
+
+import (
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	"github.com/envoyproxy/go-control-plane/pkg/cache/types"
+
+	"github.com/polarismesh/polaris/apiserver/xdsserverv3/resource"
+	"github.com/polarismesh/polaris/service"
+)
+
+// ECDSBuilder 将服务的限流规则构建为 Envoy ExtensionConfig 资源
+type ECDSBuilder struct {
+	svr service.DiscoverServer
+}
+
+// Init
+func (ecds *ECDSBuilder) Init(svr service.DiscoverServer) {
+	ecds.svr = svr
+}
+
+// Generate 每个服务生成一份 local_ratelimit filter 配置，限流规则变更时只需要推送对应服务的 ExtensionConfig
+func (ecds *ECDSBuilder) Generate(option *resource.BuildOption) (interface{}, error) {
+	var extensionConfigs []types.Resource
+	if option.RunType != resource.RunTypeSidecar {
+		return extensionConfigs, nil
+	}
+	rateLimitCache := ecds.svr.Cache().RateLimit()
+	for svcKey := range option.Services {
+		if option.ForceDelete {
+			extensionConfigs = append(extensionConfigs, &corev3.TypedExtensionConfig{
+				Name: resource.MakeLocalRateLimitExtensionConfigName(svcKey),
+			})
+			continue
+		}
+		extensionConfigs = append(extensionConfigs,
+			resource.MakeSidecarLocalRateLimitExtensionConfig(rateLimitCache, svcKey))
+	}
+	return extensionConfigs, nil
+}
@@ -0,0 +1,81 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+package xdsserverv3
+
+import (
+	"testing"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	lrl "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/local_ratelimit/v3"
+	"github.com/golang/mock/gomock"
+	"github.com/golang/protobuf/ptypes/wrappers"
+	apimodel "github.com/polarismesh/specification/source/go/api/v1/model"
+	apitraffic "github.com/polarismesh/specification/source/go/api/v1/traffic_manage"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/polarismesh/polaris/apiserver/xdsserverv3/resource"
+	mockcache "github.com/polarismesh/polaris/cache/mock"
+	"github.com/polarismesh/polaris/common/model"
+)
+
+func TestMakeSidecarLocalRateLimitExtensionConfig(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	svcKey := model.ServiceKey{Namespace: "Test", Name: "TestService1"}
+	newRule := func(ruleType apitraffic.Rule_Type, disable bool) *model.RateLimit {
+		return &model.RateLimit{
+			Proto: &apitraffic.Rule{
+				Type:    ruleType,
+				Method:  &apimodel.MatchString{Value: &wrappers.StringValue{Value: "/info"}},
+				Disable: &wrappers.BoolValue{Value: disable},
+				Amounts: []*apitraffic.Amount{
+					{MaxAmount: &wrappers.UInt32Value{Value: 100}},
+				},
+			},
+		}
+	}
+	rateLimitCache := mockcache.NewMockRateLimitCache(ctrl)
+	rateLimitCache.EXPECT().GetRateLimitRules(svcKey).Return([]*model.RateLimit{
+		newRule(apitraffic.Rule_LOCAL, false),
+		newRule(apitraffic.Rule_LOCAL, false),
+		// 分布式限流以及已禁用的规则不会生成本地的 descriptor
+		newRule(apitraffic.Rule_GLOBAL, false),
+		newRule(apitraffic.Rule_LOCAL, true),
+	}, "revision").AnyTimes()
+
+	extensionConfig := resource.MakeSidecarLocalRateLimitExtensionConfig(rateLimitCache, svcKey)
+	assert.Equal(t, "INBOUND|SIDECAR|Test|TestService1|local_ratelimit", extensionConfig.GetName())
+	rateLimitConf := &lrl.LocalRateLimit{}
+	assert.NoError(t, extensionConfig.GetTypedConfig().UnmarshalTo(rateLimitConf))
+	assert.Equal(t, uint32(resource.LocalRateLimitStage), rateLimitConf.GetStage())
+	assert.Len(t, rateLimitConf.GetDescriptors(), 2)
+
+	// 开启 ECDS 的 sidecar，local_ratelimit filter 通过 ADS 获取配置
+	hcm := resource.MakeSidecarBoundHCM(svcKey, corev3.TrafficDirection_INBOUND, &resource.BuildOption{
+		Client: &resource.XDSClient{OpenRateLimitECDS: true},
+	})
+	filter := hcm.GetHttpFilters()[0]
+	assert.Equal(t, extensionConfig.GetName(), filter.GetName())
+	assert.NotNil(t, filter.GetConfigDiscovery().GetConfigSource().GetAds())
+
+	hcm = resource.MakeSidecarBoundHCM(svcKey, corev3.TrafficDirection_INBOUND, &resource.BuildOption{
+		Client: &resource.XDSClient{},
+	})
+	assert.Equal(t, "envoy.filters.http.local_ratelimit", hcm.GetHttpFilters()[0].GetName())
+	assert.NotNil(t, hcm.GetHttpFilters()[0].GetTypedConfig())
+}
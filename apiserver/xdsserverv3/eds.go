@@ -25,6 +25,7 @@ import (
 	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
 	endpoint "github.com/envoyproxy/go-control-plane/envoy/config/endpoint/v3"
 	"github.com/envoyproxy/go-control-plane/pkg/cache/types"
+	apiservice "github.com/polarismesh/specification/source/go/api/v1/service_manage"
 	"google.golang.org/protobuf/types/known/wrapperspb"
 
 	"github.com/polarismesh/polaris/apiserver/xdsserverv3/resource"
@@ -60,27 +61,64 @@ func (eds *EDSBuilder) makeBoundEndpoints(option *resource.BuildOption,
 	services := option.Services
 	var clusterLoads []types.Resource
 	for svcKey, serviceInfo := range services {
-		var lbEndpoints []*endpoint.LocalityLbEndpoints
-		if !option.ForceDelete {
-			lbEndpoints = eds.buildServiceEndpoint(serviceInfo)
-		}
+		// 实例的每一个命名端口对应一个单独的 cluster，只下发声明了该端口的实例
+		portNames := append([]string{""}, resource.ServiceNamedPorts(serviceInfo)...)
+		for _, portName := range portNames {
+			var lbEndpoints []*endpoint.LocalityLbEndpoints
+			if !option.ForceDelete {
+				lbEndpoints = eds.buildServiceEndpoint(serviceInfo, portName, nil)
+			}
 
-		cla := &endpoint.ClusterLoadAssignment{
-			ClusterName: resource.MakeServiceName(svcKey, direction, option),
-			Endpoints:   lbEndpoints,
+			cla := &endpoint.ClusterLoadAssignment{
+				ClusterName: resource.MakeNamedPortServiceName(svcKey, portName, direction, option),
+				Endpoints:   lbEndpoints,
+			}
+			clusterLoads = append(clusterLoads, cla)
+		}
+		// 实例的每一个分组对应一个单独的 cluster，只下发属于该分组的实例
+		for _, subset := range serviceInfo.Subsets {
+			var lbEndpoints []*endpoint.LocalityLbEndpoints
+			if !option.ForceDelete {
+				lbEndpoints = eds.buildServiceEndpoint(serviceInfo, "", subset)
+			}
+			clusterLoads = append(clusterLoads, &endpoint.ClusterLoadAssignment{
+				ClusterName: resource.MakeSubsetServiceName(svcKey, subset.Name, direction, option),
+				Endpoints:   lbEndpoints,
+			})
 		}
-		clusterLoads = append(clusterLoads, cla)
 	}
 	return clusterLoads
 }
 
-func (eds *EDSBuilder) buildServiceEndpoint(serviceInfo *resource.ServiceInfo) []*endpoint.LocalityLbEndpoints {
+// instanceEndpointPort 实例在 cluster 中的端口，端口名为空时使用实例的端口
+func instanceEndpointPort(instance *apiservice.Instance, portName string) (uint32, bool) {
+	if portName == "" {
+		return instance.GetPort().GetValue(), true
+	}
+	for _, port := range model.InstanceNamedPorts(instance) {
+		if port.Name == portName {
+			return port.Port, true
+		}
+	}
+	return 0, false
+}
+
+// buildServiceEndpoint subset 不为空时只下发属于该分组的实例
+func (eds *EDSBuilder) buildServiceEndpoint(serviceInfo *resource.ServiceInfo,
+	portName string, subset *model.ServiceSubset) []*endpoint.LocalityLbEndpoints {
 	locality := map[string]map[string]map[string][]*endpoint.LbEndpoint{}
 	for _, instance := range serviceInfo.Instances {
 		// 处于隔离状态或者权重为0的实例不进行下发
 		if !resource.IsNormalEndpoint(instance) {
 			continue
 		}
+		if subset != nil && !subset.Matches(instance) {
+			continue
+		}
+		port, ok := instanceEndpointPort(instance, portName)
+		if !ok {
+			continue
+		}
 		region := instance.GetLocation().GetRegion().GetValue()
 		zone := instance.GetLocation().GetZone().GetValue()
 		campus := instance.GetLocation().GetCampus().GetValue()
@@ -102,7 +140,7 @@ func (eds *EDSBuilder) buildServiceEndpoint(serviceInfo *resource.ServiceInfo) [
 								Protocol: core.SocketAddress_TCP,
 								Address:  instance.Host.Value,
 								PortSpecifier: &core.SocketAddress_PortValue{
-									PortValue: instance.Port.Value,
+									PortValue: port,
 								},
 							},
 						},
@@ -0,0 +1,226 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+package xdsserverv3
+
+import (
+	"testing"
+	"time"
+
+	cluster "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	endpoint "github.com/envoyproxy/go-control-plane/envoy/config/endpoint/v3"
+	apimodel "github.com/polarismesh/specification/source/go/api/v1/model"
+	apiservice "github.com/polarismesh/specification/source/go/api/v1/service_manage"
+	"github.com/polarismesh/specification/source/go/api/v1/traffic_manage"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/polarismesh/polaris/apiserver/xdsserverv3/resource"
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/common/utils"
+)
+
+func TestNamedPortClusters(t *testing.T) {
+	svcKey := model.ServiceKey{Namespace: "Test", Name: "TestService1"}
+	newInstance := func(host string, namedPorts string) *apiservice.Instance {
+		ins := &apiservice.Instance{
+			Host:     utils.NewStringValue(host),
+			Port:     utils.NewUInt32Value(8080),
+			Weight:   utils.NewUInt32Value(100),
+			Healthy:  utils.NewBoolValue(true),
+			Metadata: map[string]string{},
+		}
+		if namedPorts != "" {
+			ins.Metadata[model.MetadataInstanceNamedPorts] = namedPorts
+		}
+		return ins
+	}
+	option := &resource.BuildOption{
+		TrafficDirection: corev3.TrafficDirection_OUTBOUND,
+		Services: map[model.ServiceKey]*resource.ServiceInfo{
+			svcKey: {
+				Name:       svcKey.Name,
+				Namespace:  svcKey.Namespace,
+				ServiceKey: svcKey,
+				Instances: []*apiservice.Instance{
+					newInstance("127.0.0.1", "http:8080,grpc:9090/GRPC,metrics:15020"),
+					newInstance("127.0.0.2", "grpc:9091"),
+					newInstance("127.0.0.3", ""),
+				},
+			},
+		},
+	}
+
+	cds := &CDSBuilder{}
+	clusters, err := cds.GenerateByDirection(option, corev3.TrafficDirection_OUTBOUND)
+	assert.NoError(t, err)
+	var clusterNames []string
+	for i := range clusters {
+		clusterNames = append(clusterNames, clusters[i].(*cluster.Cluster).GetName())
+	}
+	assert.Equal(t, []string{"OUTBOUND|Test|TestService1", "OUTBOUND|Test|TestService1|grpc",
+		"OUTBOUND|Test|TestService1|http", "OUTBOUND|Test|TestService1|metrics"}, clusterNames)
+
+	eds := &EDSBuilder{}
+	loads := eds.makeBoundEndpoints(option, corev3.TrafficDirection_OUTBOUND)
+	assert.Len(t, loads, 4)
+	endpoints := map[string][]string{}
+	for i := range loads {
+		cla := loads[i].(*endpoint.ClusterLoadAssignment)
+		for _, locality := range cla.GetEndpoints() {
+			for _, ep := range locality.GetLbEndpoints() {
+				addr := ep.GetEndpoint().GetAddress().GetSocketAddress()
+				endpoints[cla.GetClusterName()] = append(endpoints[cla.GetClusterName()],
+					addr.GetAddress()+":"+utils.MustJson(addr.GetPortValue()))
+			}
+		}
+	}
+	assert.ElementsMatch(t, []string{"127.0.0.1:8080", "127.0.0.2:8080", "127.0.0.3:8080"},
+		endpoints["OUTBOUND|Test|TestService1"])
+	assert.ElementsMatch(t, []string{"127.0.0.1:9090", "127.0.0.2:9091"}, endpoints["OUTBOUND|Test|TestService1|grpc"])
+	assert.Equal(t, []string{"127.0.0.1:8080"}, endpoints["OUTBOUND|Test|TestService1|http"])
+	assert.Equal(t, []string{"127.0.0.1:15020"}, endpoints["OUTBOUND|Test|TestService1|metrics"])
+}
+
+func TestSubsetClusters(t *testing.T) {
+	svcKey := model.ServiceKey{Namespace: "Test", Name: "TestService1"}
+	newInstance := func(host string, version string) *apiservice.Instance {
+		return &apiservice.Instance{
+			Host:     utils.NewStringValue(host),
+			Port:     utils.NewUInt32Value(8080),
+			Weight:   utils.NewUInt32Value(100),
+			Healthy:  utils.NewBoolValue(true),
+			Metadata: map[string]string{"version": version},
+		}
+	}
+	option := &resource.BuildOption{
+		TrafficDirection: corev3.TrafficDirection_OUTBOUND,
+		Services: map[model.ServiceKey]*resource.ServiceInfo{
+			svcKey: {
+				Name:       svcKey.Name,
+				Namespace:  svcKey.Namespace,
+				ServiceKey: svcKey,
+				Instances: []*apiservice.Instance{
+					newInstance("127.0.0.1", "v1"),
+					newInstance("127.0.0.2", "v2"),
+				},
+				Subsets: model.ServiceSubsets(map[string]string{
+					model.MetadataServiceSubsetPrefix + "canary": "version=v2",
+				}),
+			},
+		},
+	}
+
+	cds := &CDSBuilder{}
+	clusters, err := cds.GenerateByDirection(option, corev3.TrafficDirection_OUTBOUND)
+	assert.NoError(t, err)
+	var clusterNames []string
+	for i := range clusters {
+		clusterNames = append(clusterNames, clusters[i].(*cluster.Cluster).GetName())
+	}
+	assert.Equal(t, []string{"OUTBOUND|Test|TestService1", "OUTBOUND|Test|TestService1|subset|canary"}, clusterNames)
+
+	eds := &EDSBuilder{}
+	loads := eds.makeBoundEndpoints(option, corev3.TrafficDirection_OUTBOUND)
+	endpoints := map[string][]string{}
+	for i := range loads {
+		cla := loads[i].(*endpoint.ClusterLoadAssignment)
+		for _, locality := range cla.GetEndpoints() {
+			for _, ep := range locality.GetLbEndpoints() {
+				addr := ep.GetEndpoint().GetAddress().GetSocketAddress()
+				endpoints[cla.GetClusterName()] = append(endpoints[cla.GetClusterName()], addr.GetAddress())
+			}
+		}
+	}
+	assert.ElementsMatch(t, []string{"127.0.0.1", "127.0.0.2"}, endpoints["OUTBOUND|Test|TestService1"])
+	assert.Equal(t, []string{"127.0.0.2"}, endpoints["OUTBOUND|Test|TestService1|subset|canary"])
+
+	weightClusters := resource.BuildWeightClustersV2(corev3.TrafficDirection_OUTBOUND,
+		[]*traffic_manage.DestinationGroup{
+			{
+				Service:   svcKey.Name,
+				Namespace: svcKey.Namespace,
+				Weight:    90,
+			},
+			{
+				Service:   svcKey.Name,
+				Namespace: svcKey.Namespace,
+				Weight:    10,
+				Labels: map[string]*apimodel.MatchString{
+					model.SubsetLabelKey: {Value: utils.NewStringValue("canary")},
+				},
+			},
+			{
+				Service:   svcKey.Name,
+				Namespace: svcKey.Namespace,
+				Weight:    1,
+				Labels: map[string]*apimodel.MatchString{
+					model.SubsetLabelKey: {Value: utils.NewStringValue("unknown")},
+				},
+			},
+		}, option)
+	assert.Len(t, weightClusters.GetClusters(), 3)
+	assert.Equal(t, "OUTBOUND|Test|TestService1", weightClusters.GetClusters()[0].GetName())
+	assert.Equal(t, "OUTBOUND|Test|TestService1|subset|canary", weightClusters.GetClusters()[1].GetName())
+	assert.Nil(t, weightClusters.GetClusters()[1].GetMetadataMatch())
+	assert.Equal(t, "OUTBOUND|Test|TestService1", weightClusters.GetClusters()[2].GetName())
+	assert.NotNil(t, weightClusters.GetClusters()[2].GetMetadataMatch())
+}
+
+func TestDrainingEndpoints(t *testing.T) {
+	svcKey := model.ServiceKey{Namespace: "Test", Name: "TestService1"}
+	newInstance := func(host string, draining bool) *apiservice.Instance {
+		ins := &apiservice.Instance{
+			Host:     utils.NewStringValue(host),
+			Port:     utils.NewUInt32Value(8080),
+			Weight:   utils.NewUInt32Value(100),
+			Healthy:  utils.NewBoolValue(true),
+			Metadata: map[string]string{},
+		}
+		if draining {
+			ins.Metadata[model.MetadataInstanceDrainingDeadline] = model.FormatDrainingDeadline(time.Now())
+		}
+		return ins
+	}
+	option := &resource.BuildOption{
+		TrafficDirection: corev3.TrafficDirection_OUTBOUND,
+		Services: map[model.ServiceKey]*resource.ServiceInfo{
+			svcKey: {
+				Name:       svcKey.Name,
+				Namespace:  svcKey.Namespace,
+				ServiceKey: svcKey,
+				Instances: []*apiservice.Instance{
+					newInstance("127.0.0.1", false),
+					newInstance("127.0.0.2", true),
+				},
+			},
+		},
+	}
+
+	eds := &EDSBuilder{}
+	loads := eds.makeBoundEndpoints(option, corev3.TrafficDirection_OUTBOUND)
+	assert.Len(t, loads, 1)
+	status := map[string]corev3.HealthStatus{}
+	for _, locality := range loads[0].(*endpoint.ClusterLoadAssignment).GetEndpoints() {
+		for _, ep := range locality.GetLbEndpoints() {
+			status[ep.GetEndpoint().GetAddress().GetSocketAddress().GetAddress()] = ep.GetHealthStatus()
+		}
+	}
+	assert.Equal(t, map[string]corev3.HealthStatus{
+		"127.0.0.1": corev3.HealthStatus_HEALTHY,
+		"127.0.0.2": corev3.HealthStatus_DRAINING,
+	}, status)
+}
@@ -85,7 +85,7 @@ func (x *XdsResourceGenerator) Generate(versionLocal string, needUpdate, needRem
 			x.buildUpdateRequest(updateRequest, resource.RDS, opt, isRemove)
 		}
 
-		// CDS/EDS/VHDS 一起构建
+		// CDS/EDS/VHDS/ECDS 一起构建
 		for namespace, services := range infos {
 			opt := &resource.BuildOption{
 				RunType:          runType,
@@ -97,6 +97,7 @@ func (x *XdsResourceGenerator) Generate(versionLocal string, needUpdate, needRem
 			if runType == resource.RunTypeSidecar {
 				generate(opt)
 				x.buildUpdateRequest(updateRequest, resource.VHDS, opt, isRemove)
+				x.buildUpdateRequest(updateRequest, resource.ECDS, opt, isRemove)
 			}
 
 			if runType == resource.RunTypeSidecar {
@@ -231,6 +232,8 @@ func (x *XdsResourceGenerator) generateXDSResource(xdsType resource.XDSType,
 		xdsBuilder = &RDSBuilder{}
 	case resource.VHDS:
 		xdsBuilder = &VHDSBuilder{}
+	case resource.ECDS:
+		xdsBuilder = &ECDSBuilder{}
 	default:
 		return nil, ErrorNoSupportXDSType
 	}
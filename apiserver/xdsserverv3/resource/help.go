@@ -21,6 +21,7 @@ import (
 	"encoding/hex"
 	"fmt"
 	"math"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -191,6 +192,14 @@ func BuildCommonRouteMatch(routeMatch *route.RouteMatch, source *traffic_manage.
 
 func BuildWeightClustersV2(trafficDirection corev3.TrafficDirection,
 	destinations []*traffic_manage.DestinationGroup, opt *BuildOption) *route.WeightedCluster {
+	return buildWeightClusters(trafficDirection, destinations, opt, nil)
+}
+
+// buildWeightClusters svcKey 不为空时，全部目标都指向该服务的 cluster
+// 目标标签引用了实例分组时指向分组对应的 cluster，分组内的实例已经在 EDS 中筛选，不再需要 metadata 匹配
+func buildWeightClusters(trafficDirection corev3.TrafficDirection,
+	destinations []*traffic_manage.DestinationGroup, opt *BuildOption,
+	svcKey *model.ServiceKey) *route.WeightedCluster {
 	var (
 		weightedClusters []*route.WeightedCluster_ClusterWeight
 		totalWeight      uint32
@@ -202,23 +211,44 @@ func BuildWeightClustersV2(trafficDirection corev3.TrafficDirection,
 			continue
 		}
 		fields := make(map[string]*_struct.Value)
+		var subset string
 		for k, v := range destination.GetLabels() {
 			if k == utils.MatchAll && v.GetValue().GetValue() == utils.MatchAll {
 				// 重置 cluster 的匹配规则
 				fields = make(map[string]*_struct.Value)
+				subset = ""
 				break
 			}
+			if k == model.SubsetLabelKey {
+				subset = v.GetValue().GetValue()
+				continue
+			}
 			fields[k] = &_struct.Value{
 				Kind: &_struct.Value_StringValue{
 					StringValue: v.Value.Value,
 				},
 			}
 		}
+		dstKey := model.ServiceKey{
+			Namespace: destination.Namespace,
+			Name:      destination.Service,
+		}
+		if svcKey != nil {
+			dstKey = *svcKey
+		}
+		if trafficDirection == corev3.TrafficDirection_INBOUND {
+			// 入流量只会转发到本地的业务进程，不区分实例分组
+			subset = ""
+		}
+		if subset != "" && !hasServiceSubset(opt, dstKey, subset) {
+			// 分组不存在时保留引用，没有实例能够匹配该目标
+			fields[model.SubsetLabelKey] = &_struct.Value{
+				Kind: &_struct.Value_StringValue{StringValue: subset},
+			}
+			subset = ""
+		}
 		weightCluster := &route.WeightedCluster_ClusterWeight{
-			Name: MakeServiceName(model.ServiceKey{
-				Namespace: destination.Namespace,
-				Name:      destination.Service,
-			}, trafficDirection, opt),
+			Name:   MakeSubsetServiceName(dstKey, subset, trafficDirection, opt),
 			Weight: utils.NewUInt32Value(destination.GetWeight()),
 			MetadataMatch: &core.Metadata{
 				FilterMetadata: map[string]*_struct.Struct{
@@ -240,6 +270,15 @@ func BuildWeightClustersV2(trafficDirection corev3.TrafficDirection,
 	}
 }
 
+func hasServiceSubset(opt *BuildOption, svcKey model.ServiceKey, subset string) bool {
+	svcInfo, ok := opt.Services[svcKey]
+	if !ok {
+		return false
+	}
+	_, ok = model.FindServiceSubset(svcInfo.Subsets, subset)
+	return ok
+}
+
 func BuildRateLimitConf(prefix string) *lrl.LocalRateLimit {
 	rateLimitConf := &lrl.LocalRateLimit{
 		StatPrefix: prefix,
@@ -600,10 +639,7 @@ func MakeDefaultRoute(trafficDirection corev3.TrafficDirection, svcKey model.Ser
 
 func MakeSidecarRoute(trafficDirection corev3.TrafficDirection, routeMatch *route.RouteMatch,
 	svcInfo *ServiceInfo, destinations []*traffic_manage.DestinationGroup, opt *BuildOption) *route.Route {
-	weightClusters := BuildWeightClustersV2(trafficDirection, destinations, opt)
-	for i := range weightClusters.Clusters {
-		weightClusters.Clusters[i].Name = MakeServiceName(svcInfo.ServiceKey, trafficDirection, opt)
-	}
+	weightClusters := buildWeightClusters(trafficDirection, destinations, opt, &svcInfo.ServiceKey)
 	currentRoute := &route.Route{
 		Match: routeMatch,
 		Action: &route.Route_Route{
@@ -683,6 +719,42 @@ func MakeServiceName(svcKey model.ServiceKey, trafficDirection corev3.TrafficDir
 		svcKey.Namespace, svcKey.Name)
 }
 
+// MakeNamedPortServiceName 实例命名端口对应的 cluster 名称，端口名为空时与服务的 cluster 名称一致
+func MakeNamedPortServiceName(svcKey model.ServiceKey, portName string, trafficDirection corev3.TrafficDirection,
+	opt *BuildOption) string {
+	name := MakeServiceName(svcKey, trafficDirection, opt)
+	if portName == "" {
+		return name
+	}
+	return name + "|" + portName
+}
+
+// MakeSubsetServiceName 实例分组对应的 cluster 名称，分组名为空时与服务的 cluster 名称一致
+func MakeSubsetServiceName(svcKey model.ServiceKey, subset string, trafficDirection corev3.TrafficDirection,
+	opt *BuildOption) string {
+	name := MakeServiceName(svcKey, trafficDirection, opt)
+	if subset == "" {
+		return name
+	}
+	return name + "|subset|" + subset
+}
+
+// ServiceNamedPorts 服务下全部实例声明的命名端口名称，按照名称排序
+func ServiceNamedPorts(svcInfo *ServiceInfo) []string {
+	names := map[string]struct{}{}
+	for _, ins := range svcInfo.Instances {
+		for _, port := range model.InstanceNamedPorts(ins) {
+			names[port.Name] = struct{}{}
+		}
+	}
+	ret := make([]string, 0, len(names))
+	for name := range names {
+		ret = append(ret, name)
+	}
+	sort.Strings(ret)
+	return ret
+}
+
 // MakeVHDSServiceName .
 func MakeVHDSServiceName(prefix string, svcKey model.ServiceKey) string {
 	return prefix + svcKey.Name + "." + svcKey.Namespace
@@ -707,17 +779,21 @@ func MakeDefaultFilterChain() *listenerv3.FilterChain {
 	}
 }
 
-func makeRateLimitHCMFilter(svcKey model.ServiceKey) []*hcm.HttpFilter {
-	return []*hcm.HttpFilter{
-		{
-			Name: "envoy.filters.http.local_ratelimit",
-			ConfigType: &hcm.HttpFilter_TypedConfig{
-				TypedConfig: MustNewAny(&lrl.LocalRateLimit{
-					StatPrefix: "http_local_rate_limiter",
-					Stage:      LocalRateLimitStage,
-				}),
-			},
+func makeRateLimitHCMFilter(svcKey model.ServiceKey, openECDS bool) []*hcm.HttpFilter {
+	localRateLimit := &hcm.HttpFilter{
+		Name: "envoy.filters.http.local_ratelimit",
+		ConfigType: &hcm.HttpFilter_TypedConfig{
+			TypedConfig: MustNewAny(&lrl.LocalRateLimit{
+				StatPrefix: "http_local_rate_limiter",
+				Stage:      LocalRateLimitStage,
+			}),
 		},
+	}
+	if openECDS {
+		localRateLimit = makeLocalRateLimitECDSFilter(svcKey)
+	}
+	return []*hcm.HttpFilter{
+		localRateLimit,
 		{
 			Name: "envoy.filters.http.ratelimit",
 			ConfigType: &hcm.HttpFilter_TypedConfig{
@@ -743,6 +819,32 @@ func makeRateLimitHCMFilter(svcKey model.ServiceKey) []*hcm.HttpFilter {
 	}
 }
 
+// makeLocalRateLimitECDSFilter local_ratelimit filter 的配置通过 ECDS 获取，filter 名称即为 ECDS 的资源名称
+// 配置尚未下发时使用不限流的默认配置，避免 listener 一直处于 warming 状态
+func makeLocalRateLimitECDSFilter(svcKey model.ServiceKey) *hcm.HttpFilter {
+	return &hcm.HttpFilter{
+		Name: MakeLocalRateLimitExtensionConfigName(svcKey),
+		ConfigType: &hcm.HttpFilter_ConfigDiscovery{
+			ConfigDiscovery: &core.ExtensionConfigSource{
+				ConfigSource: &core.ConfigSource{
+					ConfigSourceSpecifier: &core.ConfigSource_Ads{
+						Ads: &core.AggregatedConfigSource{},
+					},
+					ResourceApiVersion: core.ApiVersion_V3,
+				},
+				DefaultConfig: MustNewAny(&lrl.LocalRateLimit{
+					StatPrefix: "http_local_rate_limiter",
+					Stage:      LocalRateLimitStage,
+				}),
+				ApplyDefaultConfigWithoutWarming: true,
+				TypeUrls: []string{
+					"type.googleapis.com/envoy.extensions.filters.http.local_ratelimit.v3.LocalRateLimit",
+				},
+			},
+		},
+	}
+}
+
 func makeSidecarOnDemandHCMFilter(option *BuildOption) []*hcm.HttpFilter {
 	return []*hcm.HttpFilter{
 		{
@@ -785,7 +887,8 @@ func MakeSidecarBoundHCM(svcKey model.ServiceKey, trafficDirection corev3.Traffi
 		},
 	}
 	if trafficDirection == corev3.TrafficDirection_INBOUND {
-		hcmFilters = append(makeRateLimitHCMFilter(svcKey), hcmFilters...)
+		openECDS := opt.Client != nil && opt.Client.OpenRateLimitECDS
+		hcmFilters = append(makeRateLimitHCMFilter(svcKey, openECDS), hcmFilters...)
 	}
 	if opt.IsDemand() {
 		hcmFilters = append([]*hcm.HttpFilter{
@@ -818,7 +921,7 @@ func MakeSidecarBoundHCM(svcKey model.ServiceKey, trafficDirection corev3.Traffi
 }
 
 func MakeGatewayBoundHCM(svcKey model.ServiceKey, opt *BuildOption) *hcm.HttpConnectionManager {
-	hcmFilters := makeRateLimitHCMFilter(svcKey)
+	hcmFilters := makeRateLimitHCMFilter(svcKey, false)
 	hcmFilters = append(hcmFilters, &hcm.HttpFilter{
 		Name: wellknown.Router,
 		ConfigType: &hcm.HttpFilter_TypedConfig{
@@ -948,6 +1051,33 @@ func MakeSidecarLocalRateLimit(rateLimitCache types.RateLimitCache,
 	return ratelimits, filters, nil
 }
 
+// MakeLocalRateLimitExtensionConfigName 服务 local_ratelimit filter 在 ECDS 中的资源名称
+func MakeLocalRateLimitExtensionConfigName(svcKey model.ServiceKey) string {
+	return fmt.Sprintf("INBOUND|SIDECAR|%s|%s|local_ratelimit", svcKey.Namespace, svcKey.Name)
+}
+
+// MakeSidecarLocalRateLimitExtensionConfig 将服务的单机限流规则转换为通过 ECDS 下发的 local_ratelimit filter 配置
+// Envoy 根据路由上的 RateLimit Actions 匹配 filter 中的 descriptors，在本地完成限流
+func MakeSidecarLocalRateLimitExtensionConfig(rateLimitCache types.RateLimitCache,
+	svcKey model.ServiceKey) *corev3.TypedExtensionConfig {
+	confKey := fmt.Sprintf("INBOUND|SIDECAR|%s|%s", svcKey.Namespace, svcKey.Name)
+	rateLimitConf := BuildRateLimitConf(confKey)
+	rateLimitConf.Stage = LocalRateLimitStage
+	conf, _ := rateLimitCache.GetRateLimitRules(svcKey)
+	for _, c := range conf {
+		rule := c.Proto
+		if rule == nil || rule.GetDisable().GetValue() || rule.GetType() != apitraffic.Rule_LOCAL {
+			continue
+		}
+		_, descriptors := BuildRateLimitDescriptors(rule)
+		rateLimitConf.Descriptors = append(rateLimitConf.Descriptors, descriptors...)
+	}
+	return &corev3.TypedExtensionConfig{
+		Name:        MakeLocalRateLimitExtensionConfigName(svcKey),
+		TypedConfig: MustNewAny(rateLimitConf),
+	}
+}
+
 // Translate the circuit breaker configuration of Polaris into OutlierDetection
 func MakeOutlierDetection(serviceInfo *ServiceInfo) *cluster.OutlierDetection {
 	circuitBreaker := serviceInfo.CircuitBreaker
@@ -1090,6 +1220,8 @@ func GenEndpointMetaFromPolarisIns(ins *apiservice.Instance) *core.Metadata {
 	return meta
 }
 
+// IsNormalEndpoint 隔离或者权重为0的实例不进行下发，优雅下线中的实例仍然下发，
+// 由于 envoy 要求 LoadBalancingWeight 不小于1，通过 FormatEndpointHealth 标记为 DRAINING 摘除流量
 func IsNormalEndpoint(ins *apiservice.Instance) bool {
 	if ins.GetIsolate().GetValue() {
 		return false
@@ -1100,7 +1232,11 @@ func IsNormalEndpoint(ins *apiservice.Instance) bool {
 	return true
 }
 
+// FormatEndpointHealth 优雅下线中的实例标记为 DRAINING，envoy 不再向其分配新的请求
 func FormatEndpointHealth(ins *apiservice.Instance) core.HealthStatus {
+	if model.IsInstanceDraining(ins) {
+		return core.HealthStatus_DRAINING
+	}
 	if ins.GetHealthy().GetValue() {
 		return core.HealthStatus_HEALTHY
 	}
@@ -85,6 +85,7 @@ const (
 	RLS
 	SDS
 	VHDS
+	ECDS
 	UnknownXDS
 )
 
@@ -103,6 +104,8 @@ func FromSimpleXDS(s string) XDSType {
 		return RLS
 	case "vhds":
 		return VHDS
+	case "ecds":
+		return ECDS
 	default:
 		return UnknownXDS
 	}
@@ -122,6 +125,8 @@ func FormatTypeUrl(typeUrl string) XDSType {
 		return RLS
 	case resourcev3.VirtualHostType:
 		return VHDS
+	case resourcev3.ExtensionConfigType:
+		return ECDS
 	default:
 		return UnknownXDS
 	}
@@ -146,6 +151,9 @@ func (x XDSType) ResourceType() resourcev3.Type {
 	if x == VHDS {
 		return resourcev3.VirtualHostType
 	}
+	if x == ECDS {
+		return resourcev3.ExtensionConfigType
+	}
 	return resourcev3.AnyType
 }
 
@@ -168,6 +176,9 @@ func (x XDSType) String() string {
 	if x == VHDS {
 		return resourcev3.VirtualHostType
 	}
+	if x == ECDS {
+		return resourcev3.ExtensionConfigType
+	}
 	return resourcev3.AnyType
 }
 
@@ -213,6 +224,8 @@ type ServiceInfo struct {
 	CircuitBreakerRevision string
 	FaultDetect            *fault_tolerance.FaultDetector
 	FaultDetectRevision    string
+	// Subsets 服务定义的实例分组，每一个分组对应一个单独的 cluster
+	Subsets []*model.ServiceSubset
 }
 
 func (s *ServiceInfo) Equal(o *ServiceInfo) bool {
@@ -232,6 +245,14 @@ func (s *ServiceInfo) Equal(o *ServiceInfo) bool {
 	if s.FaultDetectRevision != o.FaultDetectRevision {
 		return false
 	}
+	if len(s.Subsets) != len(o.Subsets) {
+		return false
+	}
+	for i := range s.Subsets {
+		if s.Subsets[i].Name != o.Subsets[i].Name || s.Subsets[i].Expr != o.Subsets[i].Expr {
+			return false
+		}
+	}
 	return true
 }
 
@@ -68,6 +68,8 @@ const (
 	SidecarOpenOnDemandFeature = "sidecar.polarismesh.cn/openOnDemand"
 	// SidecarOpenOnDemandServer .
 	SidecarOpenOnDemandServer = "sidecar.polarismesh.cn/demandServer"
+	// SidecarOpenRateLimitECDS 限流规则通过 ECDS 下发 local_ratelimit filter 配置
+	SidecarOpenRateLimitECDS = "sidecar.polarismesh.cn/openRateLimitECDS"
 )
 
 type EnvoyNodeView struct {
@@ -81,6 +83,8 @@ type EnvoyNodeView struct {
 	Version      string
 	TLSMode      TLSMode
 	OpenOnDemand bool
+	// OpenRateLimitECDS 是否通过 ECDS 获取限流 filter 配置
+	OpenRateLimitECDS bool
 }
 
 func NewXDSNodeManager() *XDSNodeManager {
@@ -273,20 +277,23 @@ type XDSClient struct {
 	TLSMode      TLSMode
 	OpenOnDemand bool
 	DemandServer string
+	// OpenRateLimitECDS 是否通过 ECDS 获取限流 filter 配置
+	OpenRateLimitECDS bool
 }
 
 func (n *XDSClient) toView() *EnvoyNodeView {
 	return &EnvoyNodeView{
-		ID:           n.ID,
-		RunType:      n.RunType,
-		User:         n.User,
-		Namespace:    n.Namespace,
-		IPAddr:       n.IPAddr,
-		PodIP:        n.PodIP,
-		Metadata:     n.Metadata,
-		Version:      n.Version,
-		TLSMode:      n.TLSMode,
-		OpenOnDemand: n.OpenOnDemand,
+		ID:                n.ID,
+		RunType:           n.RunType,
+		User:              n.User,
+		Namespace:         n.Namespace,
+		IPAddr:            n.IPAddr,
+		PodIP:             n.PodIP,
+		Metadata:          n.Metadata,
+		Version:           n.Version,
+		TLSMode:           n.TLSMode,
+		OpenOnDemand:      n.OpenOnDemand,
+		OpenRateLimitECDS: n.OpenRateLimitECDS,
 	}
 }
 
@@ -395,6 +402,9 @@ func parseNodeProxy(node *core.Node) *XDSClient {
 		if onDemand, ok := getEnvoyMetaField(node.Metadata, SidecarOpenOnDemandFeature, ""); ok {
 			proxy.OpenOnDemand = onDemand == "true"
 		}
+		if ecds, ok := getEnvoyMetaField(node.Metadata, SidecarOpenRateLimitECDS, ""); ok {
+			proxy.OpenRateLimitECDS = ecds == "true"
+		}
 	}
 
 	proxy.Metadata = parseMetadata(node.GetMetadata())
@@ -41,10 +41,12 @@ import (
 	"google.golang.org/grpc"
 
 	"github.com/polarismesh/polaris/apiserver"
+	grpcutils "github.com/polarismesh/polaris/apiserver/grpcserver/utils"
 	xdscache "github.com/polarismesh/polaris/apiserver/xdsserverv3/cache"
 	"github.com/polarismesh/polaris/apiserver/xdsserverv3/resource"
 	"github.com/polarismesh/polaris/cache"
 	api "github.com/polarismesh/polaris/common/api/v1"
+	connhook "github.com/polarismesh/polaris/common/conn/hook"
 	connlimit "github.com/polarismesh/polaris/common/conn/limit"
 	"github.com/polarismesh/polaris/common/model"
 	"github.com/polarismesh/polaris/common/utils"
@@ -70,6 +72,8 @@ type XDSServer struct {
 	versionNum      *atomic.Uint64
 	server          *grpc.Server
 	connLimitConfig *connlimit.Config
+	// drainTimeout 停止时等待 Envoy 长连接排空的最长时间
+	drainTimeout time.Duration
 
 	nodeMgr           *resource.XDSNodeManager
 	registryInfo      *utils.AtomicValue[ServiceInfos]
@@ -114,6 +118,9 @@ func (x *XDSServer) Initialize(ctx context.Context, option map[string]interface{
 		}
 		x.connLimitConfig = connConfig
 	}
+	if x.drainTimeout, err = grpcutils.ParseDrainTimeout(option); err != nil {
+		return err
+	}
 	x.resourceGenerator = &XdsResourceGenerator{
 		namingServer:    x.namingServer,
 		cache:           x.cache,
@@ -153,6 +160,7 @@ func (x *XDSServer) Run(errCh chan error) {
 			return
 		}
 	}
+	listener = connhook.NewHookListener(listener, connhook.NewConnTracker(x.GetProtocol()))
 
 	registerServer(grpcServer, srv, x)
 	log.Infof("management server listening on %d\n", x.listenPort)
@@ -175,13 +183,18 @@ func registerServer(grpcServer *grpc.Server, server serverv3.Server, x *XDSServe
 	secretservice.RegisterSecretDiscoveryServiceServer(grpcServer, server)
 	runtimeservice.RegisterRuntimeDiscoveryServiceServer(grpcServer, server)
 	healthservice.RegisterHealthDiscoveryServiceServer(grpcServer, x)
+	grpcutils.RegisterHealthServer(grpcServer)
 }
 
 // Stop 停止服务
 func (x *XDSServer) Stop() {
 	connlimit.RemoveLimitListener(x.GetProtocol())
+	connhook.RemoveConnTracker(x.GetProtocol())
 	if x.server != nil {
-		x.server.Stop()
+		// 发送 GOAWAY 后 Envoy 会重新连接到其他的 xDS 节点
+		if drained := grpcutils.GracefulStop(x.server, x.drainTimeout); !drained {
+			log.Warnf("xds server drain timeout, force close remaining streams")
+		}
 	}
 }
 
@@ -359,6 +372,7 @@ func (x *XDSServer) getRegistryInfoWithCache(ctx context.Context,
 			ServiceKey: svcKey,
 			Instances:  []*apiservice.Instance{},
 			Ports:      value.ServicePorts,
+			Subsets:    model.ServiceSubsets(value.Meta),
 		}
 		registryInfo[value.Namespace][svcKey] = info
 		return true, nil
@@ -398,6 +412,9 @@ func (x *XDSServer) getRegistryInfoWithCache(ctx context.Context,
 			}
 
 			svc.AliasFor = x.namingServer.Cache().Service().GetAliasFor(svc.Name, svc.Namespace)
+			if svc.AliasFor != nil {
+				svc.Subsets = model.ServiceSubsets(svc.AliasFor.Meta)
+			}
 			svc.SvcInsRevision = resp.Service.Revision.Value
 			svc.Instances = resp.Instances
 			ports := x.namingServer.Cache().Instance().GetServicePorts(svc.ID)
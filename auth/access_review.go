@@ -0,0 +1,171 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package auth
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/polarismesh/polaris/common/model"
+)
+
+// AccessReviewFlag 访问审查报告中需要重点关注的授权
+type AccessReviewFlag string
+
+const (
+	// AccessReviewFlagWildcard 授权关联了该类型的全部资源
+	AccessReviewFlagWildcard AccessReviewFlag = "Wildcard"
+	// AccessReviewFlagBroad 授权通过属性匹配规则关联资源，匹配的范围会随着资源的变化而扩大
+	AccessReviewFlagBroad AccessReviewFlag = "Broad"
+)
+
+// AccessReviewDenySource 访问审查报告中拒绝的来源
+type AccessReviewDenySource string
+
+const (
+	// AccessReviewDenyPin 拒绝的置顶决策
+	AccessReviewDenyPin AccessReviewDenySource = "DecisionPin"
+	// AccessReviewDenyReadOnly 绑定了只读策略，拒绝全部写操作
+	AccessReviewDenyReadOnly AccessReviewDenySource = "ReadOnly"
+	// AccessReviewDenyStrategy 拒绝策略，拒绝关联资源的读写操作
+	AccessReviewDenyStrategy AccessReviewDenySource = "DenyStrategy"
+)
+
+// AccessReviewGrant 访问审查报告中的一条授权
+type AccessReviewGrant struct {
+	// Via 鉴权策略绑定的 principal, 为 principal 本身或者其所在的用户组
+	Via model.Principal `json:"via"`
+	// Path 获得授权的路径，例如 user/{id} -> group/{id} -> strategy/{name}
+	Path string `json:"path"`
+	// StrategyID 授予权限的鉴权策略 ID
+	StrategyID string `json:"strategyId"`
+	// StrategyName 授予权限的鉴权策略名称
+	StrategyName string `json:"strategyName"`
+	// Default 是否为默认策略
+	Default bool `json:"default"`
+	// Action 鉴权策略的动作，READ_WRITE 或者 ONLY_READ
+	Action string `json:"action"`
+	// ResourceType 资源类型
+	ResourceType string `json:"resourceType"`
+	// Rule 资源关联关系, 指定资源 ID、* 或者属性匹配规则
+	Rule string `json:"rule"`
+	// Conditions 鉴权策略携带的请求条件，只有请求满足全部条件时才会授予权限
+	Conditions []string `json:"conditions"`
+	// ExpireTime 资源关联关系的过期时间，零值表示永不过期
+	ExpireTime time.Time `json:"expireTime"`
+	// Flags 需要重点关注的原因
+	Flags []AccessReviewFlag `json:"flags"`
+}
+
+// AccessReviewDeny 访问审查报告中的一条拒绝
+type AccessReviewDeny struct {
+	// Source 拒绝的来源
+	Source AccessReviewDenySource `json:"source"`
+	// Via 拒绝作用的 principal, 为 principal 本身或者其所在的用户组
+	Via model.Principal `json:"via"`
+	// Path 拒绝生效的路径
+	Path string `json:"path"`
+	// StrategyID 只读策略或者拒绝策略的 ID
+	StrategyID string `json:"strategyId"`
+	// StrategyName 只读策略或者拒绝策略的名称
+	StrategyName string `json:"strategyName"`
+	// Priority 拒绝策略的优先级
+	Priority int32 `json:"priority"`
+	// ResourceType 置顶决策以及拒绝策略的资源类型，只读策略拒绝全部类型时为空
+	ResourceType string `json:"resourceType"`
+	// ResourceID 置顶决策的资源 ID，只读策略拒绝全部资源时为 *
+	ResourceID string `json:"resourceId"`
+	// Reason 置顶决策的设置原因
+	Reason string `json:"reason"`
+	// ExpireTime 置顶决策以及拒绝策略资源关联关系的过期时间，零值表示永不过期
+	ExpireTime time.Time `json:"expireTime"`
+}
+
+// AccessReviewReport 单个 principal 的访问审查报告，汇总其直接获得、通过用户组获得的授权以及拒绝，用于定期的权限审查
+type AccessReviewReport struct {
+	// Principal 被审查的 principal
+	Principal model.Principal `json:"principal"`
+	// Name principal 的名称
+	Name string `json:"name"`
+	// Disabled principal 的 token 是否已经被禁用
+	Disabled bool `json:"disabled"`
+	// Groups 用户所在的用户组
+	Groups []string `json:"groups"`
+	// GeneratedAt 报告的生成时间
+	GeneratedAt time.Time `json:"generatedAt"`
+	// Direct principal 本身绑定的鉴权策略授予的权限
+	Direct []AccessReviewGrant `json:"direct"`
+	// Inherited 通过所在用户组获得的权限
+	Inherited []AccessReviewGrant `json:"inherited"`
+	// Denies 生效的拒绝
+	Denies []AccessReviewDeny `json:"denies"`
+	// Attention 需要重点关注的授权数量
+	Attention int `json:"attention"`
+}
+
+// accessReviewCSVHeader 访问审查报告导出为 CSV 时的表头
+var accessReviewCSVHeader = []string{"kind", "path", "strategy_id", "strategy_name", "default", "action",
+	"resource_type", "resource", "conditions", "expire_time", "flags", "reason"}
+
+// WriteCSV 将访问审查报告按照每条授权、拒绝一行导出为 CSV
+func (r *AccessReviewReport) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(accessReviewCSVHeader); err != nil {
+		return err
+	}
+	writeGrants := func(kind string, grants []AccessReviewGrant) error {
+		for _, grant := range grants {
+			flags := make([]string, 0, len(grant.Flags))
+			for _, flag := range grant.Flags {
+				flags = append(flags, string(flag))
+			}
+			if err := writer.Write([]string{kind, grant.Path, grant.StrategyID, grant.StrategyName,
+				strconv.FormatBool(grant.Default), grant.Action, grant.ResourceType, grant.Rule,
+				strings.Join(grant.Conditions, ";"), formatReviewTime(grant.ExpireTime),
+				strings.Join(flags, ";"), ""}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := writeGrants("direct", r.Direct); err != nil {
+		return err
+	}
+	if err := writeGrants("inherited", r.Inherited); err != nil {
+		return err
+	}
+	for _, deny := range r.Denies {
+		if err := writer.Write([]string{"deny", deny.Path, deny.StrategyID, deny.StrategyName, "",
+			string(deny.Source), deny.ResourceType, deny.ResourceID, "", formatReviewTime(deny.ExpireTime),
+			"", deny.Reason}); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func formatReviewTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
@@ -20,10 +20,12 @@ package auth
 import (
 	"context"
 	"fmt"
+	"time"
 
 	apisecurity "github.com/polarismesh/specification/source/go/api/v1/security"
 	apiservice "github.com/polarismesh/specification/source/go/api/v1/service_manage"
 
+	"github.com/polarismesh/polaris/auth/evaluator"
 	cachetypes "github.com/polarismesh/polaris/cache/api"
 	"github.com/polarismesh/polaris/common/model"
 	"github.com/polarismesh/polaris/store"
@@ -41,8 +43,19 @@ type AuthChecker interface {
 	IsOpenClientAuth() bool
 	// AllowResourceOperate 是否允许资源的操作
 	AllowResourceOperate(ctx *model.AcquireContext, opInfo *model.ResourceOpInfo) bool
+	// CheckAsync 在有界的工作池中异步执行鉴权检查，完成后调用 callback，工作池已满时直接以错误回调
+	CheckAsync(ctx context.Context, preCtx *model.AcquireContext, callback DecisionCallback)
 }
 
+// Decision 一次鉴权检查的结果
+type Decision struct {
+	// Allowed 是否允许操作
+	Allowed bool
+}
+
+// DecisionCallback 异步鉴权完成后的回调
+type DecisionCallback func(decision Decision, err error)
+
 // StrategyServer 策略相关操作
 type StrategyServer interface {
 	// Initialize 执行初始化动作
@@ -51,6 +64,8 @@ type StrategyServer interface {
 	Name() string
 	// CreateStrategy 创建策略
 	CreateStrategy(ctx context.Context, strategy *apisecurity.AuthStrategy) *apiservice.Response
+	// CreateStrategies 批量创建策略，单个策略创建失败不影响其他策略
+	CreateStrategies(ctx context.Context, reqs []*apisecurity.AuthStrategy) *apiservice.BatchWriteResponse
 	// UpdateStrategies 批量更新策略
 	UpdateStrategies(ctx context.Context, reqs []*apisecurity.ModifyAuthStrategy) *apiservice.BatchWriteResponse
 	// DeleteStrategies 删除策略
@@ -59,6 +74,8 @@ type StrategyServer interface {
 	// support 1. 支持按照 principal-id + principal-role 进行查询
 	// support 2. 支持普通的鉴权策略查询
 	GetStrategies(ctx context.Context, query map[string]string) *apiservice.BatchQueryResponse
+	// SearchStrategies 按照名称以及描述搜索鉴权策略，结果按照命中程度排序
+	SearchStrategies(ctx context.Context, query string, limit int) *apiservice.BatchQueryResponse
 	// GetStrategy 获取策略详细
 	GetStrategy(ctx context.Context, strategy *apisecurity.AuthStrategy) *apiservice.Response
 	// GetPrincipalResources 获取某个 principal 的所有可操作资源列表
@@ -67,6 +84,62 @@ type StrategyServer interface {
 	GetAuthChecker() AuthChecker
 	// AfterResourceOperation 操作完资源的后置处理逻辑
 	AfterResourceOperation(afterCtx *model.AcquireContext) error
+	// ExportAuthModel 导出完整鉴权模型的时间点快照
+	ExportAuthModel(ctx context.Context) (*AuthModelSnapshot, error)
+	// ExportPolicySnapshot 导出推送给 sidecar 进行本地鉴权的策略快照
+	ExportPolicySnapshot(ctx context.Context) (*evaluator.PolicySnapshot, error)
+	// CapabilityMatrix 一次性计算多个 principal 对多个资源执行多种操作的鉴权结果
+	CapabilityMatrix(ctx context.Context, principals []model.Principal, resources []CapabilityResource,
+		operations []model.ResourceOperation) (*CapabilityMatrix, error)
+	// ExplainGrants 计算 principal 对资源的写权限，并给出 principal 本身以及所在用户组授予该权限的全部路径
+	ExplainGrants(ctx context.Context, principal model.Principal, resource CapabilityResource) (*GrantExplanation, error)
+	// SimulateStrategy 模拟一次鉴权请求，给出开启 ConsoleStrict 后是否允许以及做出决策的鉴权策略，用于上线策略变更前的验证
+	SimulateStrategy(ctx context.Context, req *SimulateRequest) (*StrategySimulation, error)
+	// WhatIfGroupMembership 计算假设用户加入用户组后，相比当前新增以及失去的可编辑资源，不会修改用户组成员
+	WhatIfGroupMembership(ctx context.Context, userID, groupID string) (*MembershipWhatIf, error)
+	// GenerateAccessReview 生成 principal 的访问审查报告，汇总直接授予、通过用户组授予的权限以及拒绝，并标记需要重点关注的授权
+	GenerateAccessReview(ctx context.Context, principal model.Principal) (*AccessReviewReport, error)
+	// HealthReport 汇总策略缓存、存储、历史记录插件、待处理队列以及鉴权决策失败率，生成鉴权模块的健康报告
+	HealthReport(ctx context.Context) (*AuthHealth, error)
+	// AttachStrategyResources 为鉴权策略关联资源，每个资源关联关系可以单独设置过期时间
+	AttachStrategyResources(ctx context.Context, strategyID string,
+		resources []model.StrategyResource) *apiservice.Response
+	// UpdateStrategyEffect 修改鉴权策略的授权效果以及优先级，拒绝策略优先于允许策略，只有优先级更高的允许策略可以覆盖
+	UpdateStrategyEffect(ctx context.Context, strategyID string, effect model.StrategyEffect,
+		priority int32) *apiservice.Response
+	// UpdateStrategyFunctions 修改鉴权策略生效的接口名称，策略只对列出的接口授予或者拒绝权限，为空时对全部接口生效
+	UpdateStrategyFunctions(ctx context.Context, strategyID string, functions []string) *apiservice.Response
+	// BulkEnsureDefaultStrategies 批量确保 principal 存在默认策略，已存在的跳过，可重复执行
+	BulkEnsureDefaultStrategies(ctx context.Context, principals []model.Principal) (*DefaultStrategyReport, error)
+	// RecomputeDefaultStrategies 扫描全部 principal，补齐缺失的默认策略并合并重复的默认策略，可重复执行
+	RecomputeDefaultStrategies(ctx context.Context, dryRun bool) (*DefaultStrategyRecomputeReport, error)
+	// CreateAuditorStrategy 为 principal 创建对所有资源只读的审计策略，已存在时直接返回
+	CreateAuditorStrategy(ctx context.Context, principal model.Principal) *apiservice.Response
+	// TransferOwnership 将资源从 from 的默认策略原子地转移到 to 的默认策略，转移过程中不会出现双方均无权限的间隙
+	TransferOwnership(ctx context.Context, resource model.StrategyResource,
+		from, to model.Principal) *apiservice.Response
+	// TransferAllOwnership 将 from 默认策略中的全部服务、配置分组转移给 to，同时更新资源的负责人，用于人员离职交接
+	TransferAllOwnership(ctx context.Context, from, to model.Principal) (*OwnershipTransferReport, error)
+	// CreateDecisionPin 设置临时置顶的鉴权决策，优先于所有鉴权策略，到期后自动失效
+	CreateDecisionPin(ctx context.Context, pin *DecisionPin) error
+	// DeleteDecisionPin 删除置顶的鉴权决策
+	DeleteDecisionPin(ctx context.Context, principal model.Principal, resType apisecurity.ResourceType,
+		resID string) error
+	// ListDecisionPins 查询尚未过期的置顶鉴权决策
+	ListDecisionPins(ctx context.Context) ([]*DecisionPin, error)
+	// ListDecisionTrace 查询当前节点最近记录的鉴权决策，principal ID 为哈希值
+	ListDecisionTrace(ctx context.Context) ([]DecisionTraceRecord, error)
+	// ReplayDecisionTrace 在当前鉴权配置的基础上覆盖 proposed 中的配置项，重放当前节点最近记录的鉴权决策，
+	// 给出变更后新增拒绝以及新增放通的决策，用于上线鉴权配置变更前评估影响
+	ReplayDecisionTrace(ctx context.Context, proposed map[string]interface{}) (*AccessDelta, error)
+	// CreateRoles 批量创建角色，角色包含一组鉴权策略，被授予角色的用户、用户组拥有这些策略的权限
+	CreateRoles(ctx context.Context, roles []*model.Role) *apiservice.BatchWriteResponse
+	// UpdateRoles 批量更新角色的描述、包含的鉴权策略以及被授予的用户、用户组
+	UpdateRoles(ctx context.Context, roles []*model.Role) *apiservice.BatchWriteResponse
+	// DeleteRoles 批量删除角色
+	DeleteRoles(ctx context.Context, ids []string) *apiservice.BatchWriteResponse
+	// GetRoles 查询角色列表，支持 id、name、offset、limit 参数
+	GetRoles(ctx context.Context, query map[string]string) (uint32, []*model.Role, error)
 }
 
 // UserServer 用户数据管理 server
@@ -77,6 +150,8 @@ type UserServer interface {
 	Name() string
 	// Login 登录动作
 	Login(req *apisecurity.LoginRequest) *apiservice.Response
+	// LoginOIDC 使用外部身份提供方签发的 ID Token 登录
+	LoginOIDC(ctx context.Context, idToken string) *apiservice.Response
 	// CheckCredential 检查当前操作用户凭证
 	CheckCredential(authCtx *model.AcquireContext) error
 	// UserOperator
@@ -104,6 +179,14 @@ type UserOperator interface {
 	UpdateUserToken(ctx context.Context, user *apisecurity.User) *apiservice.Response
 	// ResetUserToken 重置用户的token
 	ResetUserToken(ctx context.Context, user *apisecurity.User) *apiservice.Response
+	// RefreshToken 轮换当前用户自身的token
+	RefreshToken(ctx context.Context) *apiservice.Response
+	// UnlockUser 解除用户因为登录失败次数过多导致的锁定
+	UnlockUser(ctx context.Context, user *apisecurity.User) *apiservice.Response
+	// ListUserSessions 查询用户全部有效的控制台登录会话
+	ListUserSessions(ctx context.Context, userID string) ([]*model.UserSession, error)
+	// RevokeUserSessions 注销用户的控制台登录会话，sessionIDs 为空时注销该用户的全部会话
+	RevokeUserSessions(ctx context.Context, userID string, sessionIDs []string) error
 }
 
 type GroupOperator interface {
@@ -123,6 +206,10 @@ type GroupOperator interface {
 	UpdateGroupToken(ctx context.Context, group *apisecurity.UserGroup) *apiservice.Response
 	// ResetGroupToken 重置用户组的 token
 	ResetGroupToken(ctx context.Context, group *apisecurity.UserGroup) *apiservice.Response
+	// UpdateGroupParent 设置用户组的父用户组，组内的成员同样获得全部祖先用户组的权限，parentID 为空时移出父用户组
+	UpdateGroupParent(ctx context.Context, groupID, parentID string) error
+	// ExportMembershipGraph 导出以 root 为起点、深度不超过 depth 的用户组归属关系子图
+	ExportMembershipGraph(ctx context.Context, root model.Principal, depth int) (*MembershipGraph, error)
 }
 
 type UserHelper interface {
@@ -158,6 +245,19 @@ type OperatorInfo struct {
 	Disable bool
 	// 是否属于匿名操作者
 	Anonymous bool
+	// Kind 操作者的身份类别
+	Kind model.PrincipalKind
+	// IssuedAt 外部 token 的签发时间，北极星自身签发的 token 为零值
+	IssuedAt time.Time
+	// ExpireAt token 自身的过期时间，北极星签发的用户 token 取自 tokenTTLInSecs，零值表示 token 自身不会过期
+	ExpireAt time.Time
+	// SessionID 控制台登录会话签发的 token 对应的会话 ID，用户自身的 token 为空
+	SessionID string
+}
+
+// IsExpired token 自身在 now 时刻是否已经过期
+func (o OperatorInfo) IsExpired(now time.Time) bool {
+	return !o.ExpireAt.IsZero() && !now.Before(o.ExpireAt)
 }
 
 func NewAnonymous() OperatorInfo {
@@ -26,12 +26,15 @@ import (
 
 	"github.com/polarismesh/polaris/cache"
 	cachetypes "github.com/polarismesh/polaris/cache/api"
+	"github.com/polarismesh/polaris/plugin"
 	"github.com/polarismesh/polaris/store"
 )
 
 const (
 	// DefaultUserMgnPluginName default user server name
 	DefaultUserMgnPluginName = "defaultUser"
+	// LDAPUserMgnPluginName 基于 LDAP/AD 登录的 user server name
+	LDAPUserMgnPluginName = "ldapUser"
 	// DefaultPolicyPluginName default strategy server name
 	DefaultPolicyPluginName = "defaultStrategy"
 )
@@ -49,6 +52,22 @@ type Config struct {
 	Strategy *StrategyConfig `yaml:"strategy"`
 	// Interceptors .
 	Interceptors []string `yaml:"-"`
+	// StrictAudit 是否要求必须存在可用的历史记录插件，开启后缺失历史记录插件时启动失败，默认仅打印告警
+	StrictAudit bool `yaml:"strictAudit"`
+}
+
+var (
+	// ErrorHistoryAbsent 开启严格审计时没有可用的历史记录插件
+	ErrorHistoryAbsent = errors.New("history plugin is absent, strict audit requires an available history plugin")
+)
+
+// IsHistoryAbsent 是否没有可用的历史记录插件，未配置或者全部加载失败时组合插件中没有任何插件
+func IsHistoryAbsent(history plugin.History) bool {
+	if history == nil {
+		return true
+	}
+	composite, ok := history.(*plugin.CompositeHistory)
+	return ok && composite.SinkCount() == 0
 }
 
 func (c *Config) SetDefault() {
@@ -0,0 +1,70 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package auth
+
+import (
+	apisecurity "github.com/polarismesh/specification/source/go/api/v1/security"
+
+	"github.com/polarismesh/polaris/common/model"
+)
+
+// CapabilityResource 能力矩阵中的资源
+type CapabilityResource struct {
+	// Type 资源类型
+	Type apisecurity.ResourceType `json:"type"`
+	// ID 资源 ID
+	ID string `json:"id"`
+}
+
+// CapabilityMatrix principal × 资源 × 操作 的鉴权结果矩阵，用于控制台一次性渲染权限表格
+// Decisions 按照 principal、资源、操作的顺序展开为一维数组，通过 Allowed 读取指定单元格的结果
+type CapabilityMatrix struct {
+	// Principals 矩阵的 principal 维度
+	Principals []model.Principal `json:"principals"`
+	// Resources 矩阵的资源维度
+	Resources []CapabilityResource `json:"resources"`
+	// Operations 矩阵的操作维度
+	Operations []model.ResourceOperation `json:"operations"`
+	// Decisions 每个单元格的鉴权结果
+	Decisions []bool `json:"decisions"`
+}
+
+// NewCapabilityMatrix 创建一个所有单元格均为拒绝的矩阵
+func NewCapabilityMatrix(principals []model.Principal, resources []CapabilityResource,
+	operations []model.ResourceOperation) *CapabilityMatrix {
+	return &CapabilityMatrix{
+		Principals: principals,
+		Resources:  resources,
+		Operations: operations,
+		Decisions:  make([]bool, len(principals)*len(resources)*len(operations)),
+	}
+}
+
+func (m *CapabilityMatrix) index(principal, resource, operation int) int {
+	return (principal*len(m.Resources)+resource)*len(m.Operations) + operation
+}
+
+// Allowed 第 principal 个 principal 是否允许对第 resource 个资源执行第 operation 个操作
+func (m *CapabilityMatrix) Allowed(principal, resource, operation int) bool {
+	return m.Decisions[m.index(principal, resource, operation)]
+}
+
+// Set 设置指定单元格的鉴权结果
+func (m *CapabilityMatrix) Set(principal, resource, operation int, allowed bool) {
+	m.Decisions[m.index(principal, resource, operation)] = allowed
+}
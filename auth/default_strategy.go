@@ -0,0 +1,68 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package auth
+
+import (
+	"github.com/polarismesh/polaris/common/model"
+)
+
+// DefaultStrategyReport 批量确保 principal 默认策略存在的处理结果
+type DefaultStrategyReport struct {
+	// Created 本次新创建了默认策略的 principal
+	Created []model.Principal `json:"created"`
+	// Skipped 已经存在默认策略，本次跳过的 principal
+	Skipped []model.Principal `json:"skipped"`
+}
+
+// DefaultStrategyRecomputeReport 重新计算全部默认策略的处理结果
+type DefaultStrategyRecomputeReport struct {
+	// DryRun 为 true 时只给出需要修改的内容，没有实际修改
+	DryRun bool `json:"dryRun"`
+	// Scanned 扫描的 principal 数量
+	Scanned int `json:"scanned"`
+	// Created 缺失默认策略，本次新创建了默认策略的 principal
+	Created []model.Principal `json:"created"`
+	// Merged 存在多个默认策略，本次合并的 principal
+	Merged []DefaultStrategyMerge `json:"merged"`
+}
+
+// DefaultStrategyMerge 同一个 principal 的多个默认策略的合并结果
+type DefaultStrategyMerge struct {
+	// Principal 默认策略所属的 principal
+	Principal model.Principal `json:"principal"`
+	// Kept 保留的默认策略 ID
+	Kept string `json:"kept"`
+	// Removed 资源合并到 Kept 后被删除的默认策略 ID
+	Removed []string `json:"removed"`
+	// Resources 合并到 Kept 中的资源数量
+	Resources int `json:"resources"`
+}
+
+// OwnershipTransferReport 转移 principal 名下全部服务、配置分组的处理结果
+type OwnershipTransferReport struct {
+	// From 资源原来所属的 principal
+	From model.Principal `json:"from"`
+	// To 资源转移后所属的 principal
+	To model.Principal `json:"to"`
+	// Services 默认授权被转移的服务 ID
+	Services []string `json:"services"`
+	// ConfigGroups 默认授权被转移的配置分组 ID
+	ConfigGroups []string `json:"configGroups"`
+	// Owners 负责人字段被更新的资源数量
+	Owners int `json:"owners"`
+}
@@ -0,0 +1,373 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+// Package evaluator 鉴权决策引擎，服务端的鉴权检查与 sidecar 基于策略快照的本地鉴权共用同一套判断逻辑
+package evaluator
+
+import (
+	"time"
+
+	apisecurity "github.com/polarismesh/specification/source/go/api/v1/security"
+
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/common/utils"
+)
+
+// DecisionReason 鉴权决策的原因
+type DecisionReason string
+
+const (
+	// ReasonRead 读操作直接放通
+	ReasonRead DecisionReason = "read"
+	// ReasonReadOnly principal 绑定了只读策略，写操作均拒绝
+	ReasonReadOnly DecisionReason = "read_only"
+	// ReasonNoStrategy 资源没有关联任何鉴权策略，任何人都可以操作
+	ReasonNoStrategy DecisionReason = "no_strategy"
+	// ReasonLink 鉴权策略直接关联了该资源或者该类型下的全部资源
+	ReasonLink DecisionReason = "link"
+	// ReasonAttribute 鉴权策略按照资源属性匹配到该资源
+	ReasonAttribute DecisionReason = "attribute"
+	// ReasonLabel 鉴权策略按照命名空间以及标签选择器匹配到该资源
+	ReasonLabel DecisionReason = "label"
+	// ReasonDeny principal 的拒绝策略匹配到该资源，且没有优先级更高的允许策略
+	ReasonDeny DecisionReason = "deny"
+	// ReasonNoMatch principal 的鉴权策略均没有匹配到该资源
+	ReasonNoMatch DecisionReason = "no_match"
+	// ReasonError 获取资源属性失败
+	ReasonError DecisionReason = "error"
+)
+
+// StrategySource 鉴权策略的来源，服务端使用策略缓存，sidecar 使用推送的策略快照
+type StrategySource interface {
+	// PrincipalStrategies principal 以及用户所属用户组关联的全部鉴权策略
+	PrincipalStrategies(principal model.Principal) []*model.StrategyDetail
+	// IsResourceLinked 资源是否被任意鉴权策略直接关联，已经过期的关联关系同样视为关联
+	IsResourceLinked(resType apisecurity.ResourceType, resID string) bool
+}
+
+// LabelSelectorSource 支持标签选择器的鉴权策略来源，标签选择器匹配到的资源同样视为关联了策略
+type LabelSelectorSource interface {
+	// LabelSelectors 该资源类型下全部允许策略的标签选择器，已经过期的标签选择器同样返回
+	LabelSelectors(resType apisecurity.ResourceType) []string
+}
+
+// AttributeLoader 获取资源的属性，只有存在属性匹配规则时才会调用
+type AttributeLoader func(resType apisecurity.ResourceType, resID string) (map[string]string, error)
+
+// LabelLoader 获取资源所属的命名空间以及标签，只有存在标签选择器时才会调用
+type LabelLoader func(resType apisecurity.ResourceType, resID string) (string, map[string]string, error)
+
+// Request 一次鉴权请求
+type Request struct {
+	// Principal 发起请求的用户或者用户组
+	Principal model.Principal
+	// Operation 操作类型
+	Operation model.ResourceOperation
+	// Method 请求的接口名称，用于匹配策略生效的接口，为空时不按照接口过滤策略
+	Method string
+	// ResourceType 资源类型
+	ResourceType apisecurity.ResourceType
+	// ResourceID 资源 ID
+	ResourceID string
+	// RequestAttrs 请求携带的属性，用于匹配请求条件
+	RequestAttrs map[string]string
+	// ResourceAttrs 资源的属性，用于匹配属性规则, 设置了 AttributeLoader 时忽略
+	ResourceAttrs map[string]string
+	// ResourceNamespace 资源所属的命名空间，用于匹配标签选择器, 设置了 LabelLoader 时忽略
+	ResourceNamespace string
+	// ResourceLabels 资源的标签，用于匹配标签选择器, 设置了 LabelLoader 时忽略
+	ResourceLabels map[string]string
+	// Time 判断资源关联关系是否过期的时间，零值使用当前时间
+	Time time.Time
+}
+
+// Decision 鉴权决策
+type Decision struct {
+	// Allowed 是否允许操作
+	Allowed bool
+	// Reason 决策的原因
+	Reason DecisionReason
+	// StrategyID 授予权限的鉴权策略
+	StrategyID string
+	// Err 获取资源属性失败的原因
+	Err error
+}
+
+// Evaluator 鉴权决策引擎
+type Evaluator struct {
+	source    StrategySource
+	canonical *model.ResourceIDCanonicalizer
+	loader    AttributeLoader
+	labels    LabelLoader
+}
+
+// New 创建鉴权决策引擎, canonical 为空时资源 ID 精确匹配, loader 为空时使用请求中的资源属性
+func New(source StrategySource, canonical *model.ResourceIDCanonicalizer, loader AttributeLoader) *Evaluator {
+	return &Evaluator{source: source, canonical: canonical, loader: loader}
+}
+
+// WithLabelLoader 设置获取资源命名空间以及标签的方法, 为空时使用请求中的命名空间以及标签
+func (e *Evaluator) WithLabelLoader(loader LabelLoader) *Evaluator {
+	e.labels = loader
+	return e
+}
+
+// Evaluate 判断请求是否允许，拒绝策略匹配到的资源读写均拒绝，读操作直接放通，绑定了只读策略的 principal 写操作均拒绝,
+// 请求携带的属性不满足策略的请求条件、或者策略不对请求的接口生效时，该策略不参与决策
+func (e *Evaluator) Evaluate(req Request) Decision {
+	strategies := e.source.PrincipalStrategies(req.Principal)
+	excluded := UnmatchedFunctions(strategies, req.Method, UnmatchedConditions(strategies, req.RequestAttrs, nil))
+	if decision, denied := e.MatchDeny(req, strategies, excluded); denied {
+		return decision
+	}
+	if req.Operation == model.Read {
+		return Decision{Allowed: true, Reason: ReasonRead}
+	}
+	if HasReadOnly(strategies) {
+		return Decision{Reason: ReasonReadOnly}
+	}
+	return e.Decide(req, strategies, excluded)
+}
+
+// MatchDeny 排除 excluded 中的策略后，strategies 中是否存在直接关联该资源或者该类型下全部资源的拒绝策略
+// 只有同样直接匹配到该资源且优先级严格更高的允许策略才能覆盖拒绝策略，属性规则不参与拒绝策略的匹配
+func (e *Evaluator) MatchDeny(req Request, strategies []*model.StrategyDetail,
+	excluded map[string]struct{}) (Decision, bool) {
+	now := req.Time
+	if now.IsZero() {
+		now = time.Now()
+	}
+	var deny, allow *model.StrategyDetail
+	for _, rule := range strategies {
+		if _, skip := excluded[rule.ID]; skip || !e.linkResource(rule, req, now) {
+			continue
+		}
+		if rule.IsDeny() {
+			if deny == nil || rule.Priority > deny.Priority {
+				deny = rule
+			}
+		} else if allow == nil || rule.Priority > allow.Priority {
+			allow = rule
+		}
+	}
+	if deny == nil || (allow != nil && allow.Priority > deny.Priority) {
+		return Decision{}, false
+	}
+	return Decision{Reason: ReasonDeny, StrategyID: deny.ID}, true
+}
+
+// linkResource 策略是否直接关联了该资源或者该类型下的全部资源，已经过期的关联关系不视为匹配
+func (e *Evaluator) linkResource(rule *model.StrategyDetail, req Request, now time.Time) bool {
+	for _, res := range rule.Resources {
+		if res.ResType != int32(req.ResourceType) || res.IsExpired(now) {
+			continue
+		}
+		if e.canonical.Equal(res.ResID, req.ResourceID) || res.ResID == utils.MatchAll {
+			return true
+		}
+	}
+	return false
+}
+
+// Decide 排除 excluded 中的策略以及拒绝策略后，判断 strategies 能否授予操作资源的权限，资源没有关联任何策略时任何人都可以操作
+func (e *Evaluator) Decide(req Request, strategies []*model.StrategyDetail, excluded map[string]struct{}) Decision {
+	if !e.source.IsResourceLinked(req.ResourceType, req.ResourceID) {
+		linked, err := e.IsLabelLinked(req)
+		if err != nil {
+			return Decision{Reason: ReasonError, Err: err}
+		}
+		if !linked {
+			return Decision{Allowed: true, Reason: ReasonNoStrategy}
+		}
+	}
+	now := req.Time
+	if now.IsZero() {
+		now = time.Now()
+	}
+	for _, rule := range strategies {
+		if _, skip := excluded[rule.ID]; skip || rule.IsDeny() {
+			continue
+		}
+		if e.linkResource(rule, req, now) {
+			return Decision{Allowed: true, Reason: ReasonLink, StrategyID: rule.ID}
+		}
+	}
+	return e.MatchAttribute(req, strategies, excluded)
+}
+
+// MatchAttribute 排除 excluded 中的策略、只读策略以及拒绝策略后，是否存在按照属性或者标签选择器匹配到该资源的规则
+// 属性匹配规则仅支持服务，标签选择器支持服务以及配置分组
+func (e *Evaluator) MatchAttribute(req Request, strategies []*model.StrategyDetail,
+	excluded map[string]struct{}) Decision {
+	if req.ResourceType == apisecurity.ResourceType_ConfigGroups {
+		return e.MatchLabels(req, strategies, excluded)
+	}
+	denied := Decision{Reason: ReasonNoMatch}
+	if req.ResourceType != apisecurity.ResourceType_Services {
+		return denied
+	}
+	now := req.Time
+	if now.IsZero() {
+		now = time.Now()
+	}
+	var (
+		attrs  = req.ResourceAttrs
+		loaded = e.loader == nil
+	)
+	for _, rule := range strategies {
+		if _, skip := excluded[rule.ID]; skip || rule.IsReadOnly() || rule.IsDeny() {
+			continue
+		}
+		for _, res := range rule.Resources {
+			if res.ResType != int32(req.ResourceType) || !IsAttributeRule(res.ResID) || res.IsExpired(now) {
+				continue
+			}
+			predicates, err := ParseAttributeRule(res.ResID)
+			if err != nil {
+				continue
+			}
+			// 资源属性只在确实存在属性规则时才加载一次
+			if !loaded {
+				if attrs, err = e.loader(req.ResourceType, req.ResourceID); err != nil {
+					return Decision{Reason: ReasonError, Err: err}
+				}
+				loaded = true
+			}
+			if MatchPredicates(predicates, attrs) {
+				return Decision{Allowed: true, Reason: ReasonAttribute, StrategyID: rule.ID}
+			}
+		}
+	}
+	return e.MatchLabels(req, strategies, excluded)
+}
+
+// MatchLabels 排除 excluded 中的策略、只读策略以及拒绝策略后，是否存在按照命名空间以及标签选择器匹配到该资源的规则
+func (e *Evaluator) MatchLabels(req Request, strategies []*model.StrategyDetail,
+	excluded map[string]struct{}) Decision {
+	now := req.Time
+	if now.IsZero() {
+		now = time.Now()
+	}
+	var (
+		namespace = req.ResourceNamespace
+		labels    = req.ResourceLabels
+		loaded    = e.labels == nil
+	)
+	for _, rule := range strategies {
+		if _, skip := excluded[rule.ID]; skip || rule.IsReadOnly() || rule.IsDeny() {
+			continue
+		}
+		for _, res := range rule.Resources {
+			if res.ResType != int32(req.ResourceType) || !IsLabelSelector(res.ResID) || res.IsExpired(now) {
+				continue
+			}
+			selectNs, predicates, err := ParseLabelSelector(res.ResID)
+			if err != nil {
+				continue
+			}
+			// 资源的命名空间以及标签只在确实存在标签选择器时才加载一次
+			if !loaded {
+				if namespace, labels, err = e.labels(req.ResourceType, req.ResourceID); err != nil {
+					return Decision{Reason: ReasonError, Err: err}
+				}
+				loaded = true
+			}
+			if (selectNs == utils.MatchAll || selectNs == namespace) && MatchPredicates(predicates, labels) {
+				return Decision{Allowed: true, Reason: ReasonLabel, StrategyID: rule.ID}
+			}
+		}
+	}
+	return Decision{Reason: ReasonNoMatch}
+}
+
+// IsLabelLinked 资源是否被任意鉴权策略的标签选择器匹配，策略来源不支持标签选择器时返回 false
+func (e *Evaluator) IsLabelLinked(req Request) (bool, error) {
+	source, ok := e.source.(LabelSelectorSource)
+	if !ok {
+		return false, nil
+	}
+	selectors := source.LabelSelectors(req.ResourceType)
+	if len(selectors) == 0 {
+		return false, nil
+	}
+	namespace, labels := req.ResourceNamespace, req.ResourceLabels
+	if e.labels != nil {
+		var err error
+		if namespace, labels, err = e.labels(req.ResourceType, req.ResourceID); err != nil {
+			return false, err
+		}
+	}
+	for _, selector := range selectors {
+		selectNs, predicates, err := ParseLabelSelector(selector)
+		if err != nil {
+			continue
+		}
+		if (selectNs == utils.MatchAll || selectNs == namespace) && MatchPredicates(predicates, labels) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// HasReadOnly strategies 中是否存在只读策略，只读优先于其他策略授予的写权限
+func HasReadOnly(strategies []*model.StrategyDetail) bool {
+	for _, rule := range strategies {
+		if rule.IsReadOnly() {
+			return true
+		}
+	}
+	return false
+}
+
+// UnmatchedConditions strategies 中请求条件不被 attrs 满足的策略，请求条件不合法的策略同样视为不满足,
+// onInvalid 不为空时回调不合法的请求条件
+func UnmatchedConditions(strategies []*model.StrategyDetail, attrs map[string]string,
+	onInvalid func(rule *model.StrategyDetail, err error)) map[string]struct{} {
+	var ret map[string]struct{}
+	for _, rule := range strategies {
+		predicates, has, err := StrategyConditions(rule)
+		if !has {
+			continue
+		}
+		if err != nil && onInvalid != nil {
+			onInvalid(rule, err)
+		}
+		if err == nil && MatchPredicates(predicates, attrs) {
+			continue
+		}
+		if ret == nil {
+			ret = map[string]struct{}{}
+		}
+		ret[rule.ID] = struct{}{}
+	}
+	return ret
+}
+
+// UnmatchedFunctions strategies 中不对 method 生效的策略, 合并到 excluded 中返回
+func UnmatchedFunctions(strategies []*model.StrategyDetail, method string,
+	excluded map[string]struct{}) map[string]struct{} {
+	for _, rule := range strategies {
+		if rule.MatchFunction(method) {
+			continue
+		}
+		if excluded == nil {
+			excluded = map[string]struct{}{}
+		}
+		excluded[rule.ID] = struct{}{}
+	}
+	return excluded
+}
@@ -0,0 +1,241 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package evaluator_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	apisecurity "github.com/polarismesh/specification/source/go/api/v1/security"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/polarismesh/polaris/auth/evaluator"
+	"github.com/polarismesh/polaris/common/model"
+)
+
+func Test_SnapshotEvaluator(t *testing.T) {
+	expired := time.Now().Add(-time.Minute)
+	user := model.Principal{PrincipalID: "u1", PrincipalRole: model.PrincipalUser}
+	strategies := []*model.StrategyDetail{
+		{
+			ID:         "s-user",
+			Action:     apisecurity.AuthAction_READ_WRITE.String(),
+			Principals: []model.Principal{user},
+			Resources: []model.StrategyResource{
+				{ResType: int32(apisecurity.ResourceType_Services), ResID: "svc-1"},
+				{ResType: int32(apisecurity.ResourceType_Services), ResID: "svc-2", ExpireTime: expired},
+				{ResType: int32(apisecurity.ResourceType_Services), ResID: "attr:tier=gold"},
+			},
+		},
+		{
+			ID:         "s-group",
+			Action:     apisecurity.AuthAction_READ_WRITE.String(),
+			Principals: []model.Principal{{PrincipalID: "g1", PrincipalRole: model.PrincipalGroup}},
+			Resources: []model.StrategyResource{
+				{ResType: int32(apisecurity.ResourceType_Namespaces), ResID: "ns-1"},
+				{ResType: int32(apisecurity.ResourceType_Namespaces), ResID: "req:env=prod"},
+			},
+		},
+		{
+			ID:         "s-other",
+			Action:     apisecurity.AuthAction_READ_WRITE.String(),
+			Principals: []model.Principal{{PrincipalID: "u2", PrincipalRole: model.PrincipalUser}},
+			Resources: []model.StrategyResource{
+				{ResType: int32(apisecurity.ResourceType_Services), ResID: "svc-3"},
+			},
+		},
+	}
+	snapshot := evaluator.BuildSnapshot("1", strategies, map[string][]string{"g1": {"u1"}}, nil)
+
+	// 快照经过序列化以及反序列化后决策保持一致
+	data, err := json.Marshal(snapshot)
+	assert.NoError(t, err)
+	pushed := &evaluator.PolicySnapshot{}
+	assert.NoError(t, json.Unmarshal(data, pushed))
+	engine, err := evaluator.NewSnapshotEvaluator(pushed)
+	assert.NoError(t, err)
+
+	write := func(resType apisecurity.ResourceType, resID string) evaluator.Request {
+		return evaluator.Request{Principal: user, Operation: model.Create, ResourceType: resType, ResourceID: resID}
+	}
+	cases := []struct {
+		req    evaluator.Request
+		reason evaluator.DecisionReason
+	}{
+		{req: evaluator.Request{Principal: user, Operation: model.Read,
+			ResourceType: apisecurity.ResourceType_Services, ResourceID: "svc-3"}, reason: evaluator.ReasonRead},
+		{req: write(apisecurity.ResourceType_Services, "svc-1"), reason: evaluator.ReasonLink},
+		{req: write(apisecurity.ResourceType_Services, "svc-2"), reason: evaluator.ReasonNoMatch},
+		{req: write(apisecurity.ResourceType_Services, "svc-3"), reason: evaluator.ReasonNoMatch},
+		{req: write(apisecurity.ResourceType_Services, "svc-free"), reason: evaluator.ReasonNoStrategy},
+		// 通过用户组获得权限，但是请求条件不满足
+		{req: write(apisecurity.ResourceType_Namespaces, "ns-1"), reason: evaluator.ReasonNoMatch},
+	}
+	for i := range cases {
+		decision := engine.Evaluate(cases[i].req)
+		assert.Equal(t, cases[i].reason, decision.Reason, cases[i].req.ResourceID)
+		assert.Equal(t, cases[i].reason == evaluator.ReasonRead || cases[i].reason == evaluator.ReasonLink ||
+			cases[i].reason == evaluator.ReasonNoStrategy, decision.Allowed, cases[i].req.ResourceID)
+	}
+
+	req := write(apisecurity.ResourceType_Namespaces, "ns-1")
+	req.RequestAttrs = map[string]string{"env": "prod"}
+	decision := engine.Evaluate(req)
+	assert.True(t, decision.Allowed)
+	assert.Equal(t, "s-group", decision.StrategyID)
+
+	req = write(apisecurity.ResourceType_Services, "svc-3")
+	req.ResourceAttrs = map[string]string{"tier": "gold"}
+	decision = engine.Evaluate(req)
+	assert.True(t, decision.Allowed)
+	assert.Equal(t, evaluator.ReasonAttribute, decision.Reason)
+
+	t.Run("不支持的快照格式", func(t *testing.T) {
+		_, err := evaluator.NewSnapshotEvaluator(&evaluator.PolicySnapshot{FormatVersion: 0})
+		assert.Error(t, err)
+		invalid := evaluator.BuildSnapshot("1", strategies, nil, nil)
+		invalid.Strategies[0].Resources[0].Type = "Unknown"
+		_, err = evaluator.NewSnapshotEvaluator(invalid)
+		assert.Error(t, err)
+	})
+}
+
+func Test_SnapshotEvaluatorDeny(t *testing.T) {
+	user := model.Principal{PrincipalID: "u1", PrincipalRole: model.PrincipalUser}
+	group := model.Principal{PrincipalID: "g1", PrincipalRole: model.PrincipalGroup}
+	namespaces := func(ids ...string) []model.StrategyResource {
+		ret := make([]model.StrategyResource, 0, len(ids))
+		for _, id := range ids {
+			ret = append(ret, model.StrategyResource{ResType: int32(apisecurity.ResourceType_Namespaces), ResID: id})
+		}
+		return ret
+	}
+	strategies := []*model.StrategyDetail{
+		{
+			ID:         "s-allow-all",
+			Action:     apisecurity.AuthAction_READ_WRITE.String(),
+			Principals: []model.Principal{group},
+			Resources:  namespaces("*"),
+		},
+		{
+			ID:         "s-deny",
+			Action:     apisecurity.AuthAction_READ_WRITE.String(),
+			Effect:     model.StrategyEffectDeny,
+			Priority:   10,
+			Principals: []model.Principal{group},
+			Resources:  namespaces("ns-blocked", "ns-exempt", "ns-tie"),
+		},
+		{
+			ID:         "s-override",
+			Action:     apisecurity.AuthAction_READ_WRITE.String(),
+			Priority:   20,
+			Principals: []model.Principal{user},
+			Resources:  namespaces("ns-exempt"),
+		},
+		{
+			ID:         "s-tie",
+			Action:     apisecurity.AuthAction_READ_WRITE.String(),
+			Priority:   10,
+			Principals: []model.Principal{user},
+			Resources:  namespaces("ns-tie"),
+		},
+	}
+	snapshot := evaluator.BuildSnapshot("1", strategies, map[string][]string{"g1": {"u1"}}, nil)
+	data, err := json.Marshal(snapshot)
+	assert.NoError(t, err)
+	pushed := &evaluator.PolicySnapshot{}
+	assert.NoError(t, json.Unmarshal(data, pushed))
+	engine, err := evaluator.NewSnapshotEvaluator(pushed)
+	assert.NoError(t, err)
+
+	newReq := func(principal model.Principal, op model.ResourceOperation, resID string) evaluator.Request {
+		return evaluator.Request{Principal: principal, Operation: op,
+			ResourceType: apisecurity.ResourceType_Namespaces, ResourceID: resID}
+	}
+	cases := []struct {
+		req      evaluator.Request
+		allowed  bool
+		reason   evaluator.DecisionReason
+		strategy string
+	}{
+		// 拒绝策略优先于读操作直接放通以及 * 允许策略
+		{req: newReq(user, model.Read, "ns-blocked"), reason: evaluator.ReasonDeny, strategy: "s-deny"},
+		{req: newReq(user, model.Create, "ns-blocked"), reason: evaluator.ReasonDeny, strategy: "s-deny"},
+		// 优先级更高的允许策略覆盖拒绝策略，优先级相同时拒绝策略生效
+		{req: newReq(user, model.Create, "ns-exempt"), allowed: true, reason: evaluator.ReasonLink,
+			strategy: "s-override"},
+		{req: newReq(user, model.Create, "ns-tie"), reason: evaluator.ReasonDeny, strategy: "s-deny"},
+		{req: newReq(user, model.Create, "ns-other"), allowed: true, reason: evaluator.ReasonNoStrategy},
+		// 拒绝策略不影响其他 principal，也不改变资源是否关联了策略
+		{req: newReq(model.Principal{PrincipalID: "u2", PrincipalRole: model.PrincipalUser}, model.Create,
+			"ns-blocked"), allowed: true, reason: evaluator.ReasonNoStrategy},
+	}
+	for i := range cases {
+		decision := engine.Evaluate(cases[i].req)
+		assert.Equal(t, cases[i].reason, decision.Reason, cases[i].req.ResourceID)
+		assert.Equal(t, cases[i].allowed, decision.Allowed, cases[i].req.ResourceID)
+		assert.Equal(t, cases[i].strategy, decision.StrategyID, cases[i].req.ResourceID)
+	}
+}
+
+func Test_SnapshotEvaluatorLabel(t *testing.T) {
+	user := model.Principal{PrincipalID: "u1", PrincipalRole: model.PrincipalUser}
+	other := model.Principal{PrincipalID: "u2", PrincipalRole: model.PrincipalUser}
+	strategies := []*model.StrategyDetail{
+		{
+			ID:         "s-label",
+			Action:     apisecurity.AuthAction_READ_WRITE.String(),
+			Principals: []model.Principal{user},
+			Resources: []model.StrategyResource{
+				{ResType: int32(apisecurity.ResourceType_Services), ResID: "label:prod/env=prod"},
+				{ResType: int32(apisecurity.ResourceType_ConfigGroups), ResID: "label:*/team=infra"},
+			},
+		},
+	}
+	engine, err := evaluator.NewSnapshotEvaluator(evaluator.BuildSnapshot("1", strategies, nil, nil))
+	assert.NoError(t, err)
+
+	newReq := func(principal model.Principal, resType apisecurity.ResourceType, namespace string,
+		labels map[string]string) evaluator.Request {
+		return evaluator.Request{Principal: principal, Operation: model.Modify, ResourceType: resType,
+			ResourceID: "r1", ResourceNamespace: namespace, ResourceLabels: labels}
+	}
+	cases := []struct {
+		name    string
+		req     evaluator.Request
+		allowed bool
+		reason  evaluator.DecisionReason
+	}{
+		{name: "命名空间以及标签均匹配", allowed: true, reason: evaluator.ReasonLabel,
+			req: newReq(user, apisecurity.ResourceType_Services, "prod", map[string]string{"env": "prod"})},
+		{name: "标签选择器匹配到的资源视为关联了策略", reason: evaluator.ReasonNoMatch,
+			req: newReq(other, apisecurity.ResourceType_Services, "prod", map[string]string{"env": "prod"})},
+		{name: "命名空间不匹配", allowed: true, reason: evaluator.ReasonNoStrategy,
+			req: newReq(other, apisecurity.ResourceType_Services, "test", map[string]string{"env": "prod"})},
+		{name: "标签不匹配", allowed: true, reason: evaluator.ReasonNoStrategy,
+			req: newReq(other, apisecurity.ResourceType_Services, "prod", map[string]string{"env": "test"})},
+		{name: "配置分组匹配全部命名空间", allowed: true, reason: evaluator.ReasonLabel,
+			req: newReq(user, apisecurity.ResourceType_ConfigGroups, "test", map[string]string{"team": "infra"})},
+	}
+	for i := range cases {
+		decision := engine.Evaluate(cases[i].req)
+		assert.Equal(t, cases[i].reason, decision.Reason, cases[i].name)
+		assert.Equal(t, cases[i].allowed, decision.Allowed, cases[i].name)
+	}
+}
@@ -0,0 +1,157 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package evaluator
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/polarismesh/polaris/common/model"
+)
+
+const (
+	// AttributePrefix 按照资源属性匹配的资源 ID 前缀, 例如 attr:tier=gold,env!=test|dev
+	AttributePrefix = "attr:"
+	// ConditionPrefix 请求条件的资源 ID 前缀, 例如 req:environment=staging,region!=eu
+	ConditionPrefix = "req:"
+
+	// MaxPredicates 单个规则中最多的条件数量
+	MaxPredicates = 8
+	// MaxPredicateValues 单个条件中最多的候选值数量
+	MaxPredicateValues = 16
+)
+
+// ErrorInvalidRule 属性匹配规则或者请求条件不合法
+var ErrorInvalidRule = errors.New("invalid attribute rule")
+
+// Predicate 单个属性条件, 属性值命中任意一个候选值即满足，Negate 时取反
+type Predicate struct {
+	Key    string
+	Values []string
+	Negate bool
+}
+
+// IsAttributeRule 资源 ID 是否为属性匹配规则
+func IsAttributeRule(resID string) bool {
+	return strings.HasPrefix(resID, AttributePrefix)
+}
+
+// IsCondition 资源 ID 是否为请求条件
+func IsCondition(resID string) bool {
+	return strings.HasPrefix(resID, ConditionPrefix)
+}
+
+// ParseAttributeRule 解析属性匹配规则
+func ParseAttributeRule(resID string) ([]Predicate, error) {
+	return ParsePredicates(strings.TrimPrefix(resID, AttributePrefix), resID)
+}
+
+// IsLabelSelector 资源 ID 是否为命名空间以及标签选择器
+func IsLabelSelector(resID string) bool {
+	return strings.HasPrefix(resID, model.ResourceLabelPrefix)
+}
+
+// ParseLabelSelector 解析命名空间以及标签选择器，例如 label:prod/env=prod，命名空间为 * 时匹配全部命名空间
+func ParseLabelSelector(resID string) (string, []Predicate, error) {
+	namespace, selector, ok := model.StrategyResource{ResID: resID}.LabelSelector()
+	if !ok {
+		return "", nil, fmt.Errorf("%w: %s", ErrorInvalidRule, resID)
+	}
+	predicates, err := ParsePredicates(selector, resID)
+	if err != nil {
+		return "", nil, err
+	}
+	return namespace, predicates, nil
+}
+
+// ParseCondition 解析请求条件，语法与属性匹配规则一致
+func ParseCondition(resID string) ([]Predicate, error) {
+	return ParsePredicates(strings.TrimPrefix(resID, ConditionPrefix), resID)
+}
+
+// ParsePredicates 解析使用 , 分隔且需要同时满足的条件列表, 条件支持 key=v1|v2 以及 key!=v1|v2,
+// resID 为原始的规则，用于错误信息
+func ParsePredicates(expr, resID string) ([]Predicate, error) {
+	items := strings.Split(expr, ",")
+	if expr == "" || len(items) > MaxPredicates {
+		return nil, fmt.Errorf("%w: %s", ErrorInvalidRule, resID)
+	}
+	ret := make([]Predicate, 0, len(items))
+	for _, item := range items {
+		key, val, ok := strings.Cut(item, "=")
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrorInvalidRule, resID)
+		}
+		predicate := Predicate{Key: strings.TrimSpace(key)}
+		if strings.HasSuffix(predicate.Key, "!") {
+			predicate.Negate = true
+			predicate.Key = strings.TrimSpace(strings.TrimSuffix(predicate.Key, "!"))
+		}
+		values := strings.Split(val, "|")
+		if predicate.Key == "" || len(values) > MaxPredicateValues {
+			return nil, fmt.Errorf("%w: %s", ErrorInvalidRule, resID)
+		}
+		for _, v := range values {
+			if v = strings.TrimSpace(v); v == "" {
+				return nil, fmt.Errorf("%w: %s", ErrorInvalidRule, resID)
+			}
+			predicate.Values = append(predicate.Values, v)
+		}
+		ret = append(ret, predicate)
+	}
+	return ret, nil
+}
+
+// MatchPredicates 属性是否满足全部条件, 没有该属性时视为不等于任何值
+func MatchPredicates(predicates []Predicate, attrs map[string]string) bool {
+	for _, predicate := range predicates {
+		val, exist := attrs[predicate.Key]
+		hit := false
+		for _, v := range predicate.Values {
+			if exist && v == val {
+				hit = true
+				break
+			}
+		}
+		if hit == predicate.Negate {
+			return false
+		}
+	}
+	return true
+}
+
+// StrategyConditions 获取鉴权策略中的全部请求条件，没有请求条件时返回 false
+func StrategyConditions(rule *model.StrategyDetail) ([]Predicate, bool, error) {
+	var (
+		ret []Predicate
+		has bool
+	)
+	for _, res := range rule.Resources {
+		if !IsCondition(res.ResID) {
+			continue
+		}
+		has = true
+		predicates, err := ParseCondition(res.ResID)
+		if err != nil {
+			return nil, true, err
+		}
+		ret = append(ret, predicates...)
+	}
+	return ret, has, nil
+}
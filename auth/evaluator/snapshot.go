@@ -0,0 +1,286 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package evaluator
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	apisecurity "github.com/polarismesh/specification/source/go/api/v1/security"
+
+	"github.com/polarismesh/polaris/common/model"
+)
+
+// SnapshotFormatVersion 策略快照的格式版本，格式发生不兼容的变化时递增
+const SnapshotFormatVersion = 1
+
+// PolicySnapshot 服务端导出并推送给 sidecar 的策略快照，sidecar 基于快照在本地完成鉴权
+// 快照只包含鉴权决策需要的数据，所有列表均按照 ID 排序，同一个 revision 的快照内容一致
+//
+//	{
+//	  "format_version": 1,
+//	  "revision": "42",
+//	  "create_time": "2006-01-02T15:04:05Z",
+//	  "resource_id_canonical": {"trim": true, "separators": ["."], "separator": "/", "caseFold": true},
+//	  "memberships": [{"group_id": "...", "user_id": "..."}],
+//	  "strategies": [{"id": "...", "action": "READ_WRITE", "principals": [{"id": "...", "type": "user"}],
+//	    "resources": [{"type": "Services", "id": "...", "expire_time": "2006-01-02T15:04:05Z"}]}]
+//	}
+type PolicySnapshot struct {
+	// FormatVersion 快照的格式版本
+	FormatVersion int `json:"format_version"`
+	// Revision 快照对应的策略集合版本
+	Revision string `json:"revision"`
+	// CreateTime 快照的生成时间
+	CreateTime time.Time `json:"create_time"`
+	// ResourceIDCanonical 资源 ID 的规范化规则，为空时精确匹配
+	ResourceIDCanonical *model.ResourceIDCanonicalizer `json:"resource_id_canonical,omitempty"`
+	// Memberships 鉴权策略关联的用户组与用户的归属关系
+	Memberships []SnapshotMembership `json:"memberships"`
+	// Strategies 全部的鉴权策略
+	Strategies []SnapshotStrategy `json:"strategies"`
+}
+
+// SnapshotMembership 策略快照中用户与用户组的关系
+type SnapshotMembership struct {
+	// GroupID 用户组 ID
+	GroupID string `json:"group_id"`
+	// UserID 用户 ID
+	UserID string `json:"user_id"`
+}
+
+// SnapshotStrategy 策略快照中的鉴权策略
+type SnapshotStrategy struct {
+	// ID 策略 ID
+	ID string `json:"id"`
+	// Action 策略动作, READ_WRITE 等
+	Action string `json:"action"`
+	// Effect 策略的授权效果, 为空表示 ALLOW
+	Effect string `json:"effect,omitempty"`
+	// Priority 策略的优先级, 数值越大优先级越高
+	Priority int32 `json:"priority,omitempty"`
+	// Functions 策略生效的接口名称, 为空表示对全部接口生效
+	Functions []string `json:"functions,omitempty"`
+	// Principals 策略关联的 principal
+	Principals []SnapshotPrincipal `json:"principals"`
+	// Resources 策略关联的资源
+	Resources []SnapshotResource `json:"resources"`
+}
+
+// SnapshotPrincipal 策略快照中鉴权策略关联的 principal
+type SnapshotPrincipal struct {
+	// ID 用户/用户组 ID
+	ID string `json:"id"`
+	// Type principal 类型, user or group
+	Type string `json:"type"`
+}
+
+// SnapshotResource 策略快照中鉴权策略关联的资源
+type SnapshotResource struct {
+	// Type 资源类型, Namespaces / Services / ConfigGroups
+	Type string `json:"type"`
+	// ID 资源 ID, * 表示该类型下的全部资源, 也可以是属性匹配规则或者请求条件
+	ID string `json:"id"`
+	// ExpireTime 资源关联关系的过期时间，为空表示永不过期
+	ExpireTime *time.Time `json:"expire_time,omitempty"`
+}
+
+// BuildSnapshot 根据鉴权策略以及用户组成员构建策略快照, members 为用户组 ID 到用户 ID 列表的映射
+func BuildSnapshot(revision string, strategies []*model.StrategyDetail, members map[string][]string,
+	canonical *model.ResourceIDCanonicalizer) *PolicySnapshot {
+	snapshot := &PolicySnapshot{
+		FormatVersion:       SnapshotFormatVersion,
+		Revision:            revision,
+		CreateTime:          time.Now(),
+		ResourceIDCanonical: canonical,
+		Memberships:         make([]SnapshotMembership, 0, len(members)),
+		Strategies:          make([]SnapshotStrategy, 0, len(strategies)),
+	}
+	for groupID, users := range members {
+		for i := range users {
+			snapshot.Memberships = append(snapshot.Memberships, SnapshotMembership{GroupID: groupID, UserID: users[i]})
+		}
+	}
+	for _, rule := range strategies {
+		item := SnapshotStrategy{
+			ID:         rule.ID,
+			Action:     rule.Action,
+			Effect:     string(rule.Effect),
+			Priority:   rule.Priority,
+			Functions:  rule.Functions,
+			Principals: make([]SnapshotPrincipal, 0, len(rule.Principals)),
+			Resources:  make([]SnapshotResource, 0, len(rule.Resources)),
+		}
+		for _, p := range rule.Principals {
+			item.Principals = append(item.Principals, SnapshotPrincipal{
+				ID:   p.PrincipalID,
+				Type: model.PrincipalNames[p.PrincipalRole],
+			})
+		}
+		for _, res := range rule.Resources {
+			entry := SnapshotResource{Type: apisecurity.ResourceType(res.ResType).String(), ID: res.ResID}
+			if !res.ExpireTime.IsZero() {
+				expireTime := res.ExpireTime
+				entry.ExpireTime = &expireTime
+			}
+			item.Resources = append(item.Resources, entry)
+		}
+		sort.Slice(item.Principals, func(i, j int) bool {
+			if item.Principals[i].Type != item.Principals[j].Type {
+				return item.Principals[i].Type > item.Principals[j].Type
+			}
+			return item.Principals[i].ID < item.Principals[j].ID
+		})
+		sort.Slice(item.Resources, func(i, j int) bool {
+			if item.Resources[i].Type != item.Resources[j].Type {
+				return item.Resources[i].Type < item.Resources[j].Type
+			}
+			return item.Resources[i].ID < item.Resources[j].ID
+		})
+		snapshot.Strategies = append(snapshot.Strategies, item)
+	}
+	sort.Slice(snapshot.Memberships, func(i, j int) bool {
+		if snapshot.Memberships[i].GroupID != snapshot.Memberships[j].GroupID {
+			return snapshot.Memberships[i].GroupID < snapshot.Memberships[j].GroupID
+		}
+		return snapshot.Memberships[i].UserID < snapshot.Memberships[j].UserID
+	})
+	sort.Slice(snapshot.Strategies, func(i, j int) bool {
+		return snapshot.Strategies[i].ID < snapshot.Strategies[j].ID
+	})
+	return snapshot
+}
+
+// NewSnapshotEvaluator 基于策略快照创建鉴权决策引擎，资源属性取自请求中的 ResourceAttrs
+func NewSnapshotEvaluator(snapshot *PolicySnapshot) (*Evaluator, error) {
+	source, err := newSnapshotSource(snapshot)
+	if err != nil {
+		return nil, err
+	}
+	return New(source, snapshot.ResourceIDCanonical, nil), nil
+}
+
+// snapshotSource 基于策略快照的鉴权策略来源
+type snapshotSource struct {
+	canonical       *model.ResourceIDCanonicalizer
+	userStrategies  map[string][]*model.StrategyDetail
+	groupStrategies map[string][]*model.StrategyDetail
+	userGroups      map[string][]string
+	// links 被鉴权策略直接关联的资源, 资源类型 -> 规范化后的资源 ID
+	links map[int32]map[string]struct{}
+	// selectors 允许策略中的标签选择器, 资源类型 -> 标签选择器
+	selectors map[int32][]string
+}
+
+func newSnapshotSource(snapshot *PolicySnapshot) (*snapshotSource, error) {
+	if snapshot == nil {
+		return nil, fmt.Errorf("policy snapshot is nil")
+	}
+	if snapshot.FormatVersion != SnapshotFormatVersion {
+		return nil, fmt.Errorf("unsupported policy snapshot format version: %d", snapshot.FormatVersion)
+	}
+	source := &snapshotSource{
+		canonical:       snapshot.ResourceIDCanonical,
+		userStrategies:  map[string][]*model.StrategyDetail{},
+		groupStrategies: map[string][]*model.StrategyDetail{},
+		userGroups:      map[string][]string{},
+		links:           map[int32]map[string]struct{}{},
+		selectors:       map[int32][]string{},
+	}
+	for _, m := range snapshot.Memberships {
+		source.userGroups[m.UserID] = append(source.userGroups[m.UserID], m.GroupID)
+	}
+	for _, item := range snapshot.Strategies {
+		rule := &model.StrategyDetail{
+			ID:        item.ID,
+			Action:    item.Action,
+			Effect:    model.StrategyEffect(item.Effect),
+			Priority:  item.Priority,
+			Functions: item.Functions,
+			Resources: make([]model.StrategyResource, 0, len(item.Resources)),
+		}
+		for _, res := range item.Resources {
+			resType, ok := apisecurity.ResourceType_value[res.Type]
+			if !ok {
+				return nil, fmt.Errorf("strategy %s has unknown resource type: %s", item.ID, res.Type)
+			}
+			entry := model.StrategyResource{StrategyID: item.ID, ResType: resType, ResID: res.ID}
+			if res.ExpireTime != nil {
+				entry.ExpireTime = *res.ExpireTime
+			}
+			rule.Resources = append(rule.Resources, entry)
+			// 与策略缓存保持一致，拒绝策略不改变资源是否关联了策略
+			if rule.IsDeny() {
+				continue
+			}
+			if IsLabelSelector(res.ID) {
+				source.selectors[resType] = append(source.selectors[resType], res.ID)
+				continue
+			}
+			if _, ok := source.links[resType]; !ok {
+				source.links[resType] = map[string]struct{}{}
+			}
+			source.links[resType][source.canonical.Canonical(res.ID)] = struct{}{}
+		}
+		for _, p := range item.Principals {
+			switch p.Type {
+			case model.PrincipalNames[model.PrincipalUser]:
+				rule.Principals = append(rule.Principals, model.Principal{PrincipalID: p.ID,
+					PrincipalRole: model.PrincipalUser})
+				source.userStrategies[p.ID] = append(source.userStrategies[p.ID], rule)
+			case model.PrincipalNames[model.PrincipalGroup]:
+				rule.Principals = append(rule.Principals, model.Principal{PrincipalID: p.ID,
+					PrincipalRole: model.PrincipalGroup})
+				source.groupStrategies[p.ID] = append(source.groupStrategies[p.ID], rule)
+			default:
+				return nil, fmt.Errorf("strategy %s has unknown principal type: %s", item.ID, p.Type)
+			}
+		}
+	}
+	return source, nil
+}
+
+// PrincipalStrategies principal 以及用户所属用户组关联的全部鉴权策略
+func (s *snapshotSource) PrincipalStrategies(principal model.Principal) []*model.StrategyDetail {
+	if principal.PrincipalRole != model.PrincipalUser {
+		return s.groupStrategies[principal.PrincipalID]
+	}
+	rules := append([]*model.StrategyDetail{}, s.userStrategies[principal.PrincipalID]...)
+	for _, groupID := range s.userGroups[principal.PrincipalID] {
+		rules = append(rules, s.groupStrategies[groupID]...)
+	}
+	return rules
+}
+
+// IsResourceLinked 与策略缓存保持一致，未知的资源类型视为关联了策略
+func (s *snapshotSource) IsResourceLinked(resType apisecurity.ResourceType, resID string) bool {
+	switch resType {
+	case apisecurity.ResourceType_Namespaces, apisecurity.ResourceType_Services,
+		apisecurity.ResourceType_ConfigGroups:
+		_, ok := s.links[int32(resType)][s.canonical.Canonical(resID)]
+		return ok
+	default:
+		return true
+	}
+}
+
+// LabelSelectors 与策略缓存保持一致，该资源类型下全部允许策略的标签选择器
+func (s *snapshotSource) LabelSelectors(resType apisecurity.ResourceType) []string {
+	return s.selectors[int32(resType)]
+}
@@ -0,0 +1,72 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package auth
+
+import (
+	"time"
+
+	"github.com/polarismesh/polaris/common/model"
+)
+
+// GrantMatch 授权路径中鉴权策略匹配到资源的方式
+type GrantMatch string
+
+const (
+	// GrantMatchExact 鉴权策略直接关联了该资源
+	GrantMatchExact GrantMatch = "Exact"
+	// GrantMatchAttribute 鉴权策略通过属性匹配规则关联了该资源
+	GrantMatchAttribute GrantMatch = "Attribute"
+	// GrantMatchLabel 鉴权策略通过命名空间以及标签选择器关联了该资源
+	GrantMatchLabel GrantMatch = "Label"
+	// GrantMatchAll 鉴权策略关联了该类型的全部资源
+	GrantMatchAll GrantMatch = "All"
+)
+
+// GrantPath 授予 principal 资源写权限的一条路径
+type GrantPath struct {
+	// StrategyID 授予权限的鉴权策略 ID
+	StrategyID string `json:"strategyId"`
+	// StrategyName 授予权限的鉴权策略名称
+	StrategyName string `json:"strategyName"`
+	// Default 是否为默认策略
+	Default bool `json:"default"`
+	// Via 鉴权策略绑定的 principal, 为 principal 本身或者其所在的用户组
+	Via model.Principal `json:"via"`
+	// Match 鉴权策略匹配到资源的方式
+	Match GrantMatch `json:"match"`
+	// Rule 匹配到资源的资源关联关系, 指定资源 ID、* 或者属性匹配规则
+	Rule string `json:"rule"`
+	// Conditional 鉴权策略是否携带了请求条件，只有请求满足条件时该路径才会授予权限
+	Conditional bool `json:"conditional"`
+	// ExpireTime 资源关联关系的过期时间，零值表示永不过期
+	ExpireTime time.Time `json:"expireTime"`
+}
+
+// GrantExplanation principal 对单个资源的写权限以及全部的授权路径，用于权限审查时识别冗余、高风险的授权路径
+type GrantExplanation struct {
+	// Principal 被审查的 principal
+	Principal model.Principal `json:"principal"`
+	// Resource 被审查的资源
+	Resource CapabilityResource `json:"resource"`
+	// Allowed 鉴权的结果
+	Allowed bool `json:"allowed"`
+	// Unrestricted 资源没有关联任何鉴权策略，任何人都可以操作
+	Unrestricted bool `json:"unrestricted"`
+	// Paths 全部的授权路径，按照 principal 本身优先、匹配方式越精确越靠前的顺序排列
+	Paths []GrantPath `json:"paths"`
+}
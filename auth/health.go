@@ -0,0 +1,83 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package auth
+
+import (
+	"time"
+)
+
+// HealthStatus 鉴权模块以及各个组件的健康状态
+type HealthStatus string
+
+const (
+	// HealthUp 组件工作正常
+	HealthUp HealthStatus = "Up"
+	// HealthDegraded 组件仍然可用，但是存在需要处理的问题
+	HealthDegraded HealthStatus = "Degraded"
+)
+
+const (
+	// HealthComponentStrategyCache 鉴权策略缓存
+	HealthComponentStrategyCache = "strategyCache"
+	// HealthComponentStore 存储
+	HealthComponentStore = "store"
+	// HealthComponentHistory 历史记录插件
+	HealthComponentHistory = "history"
+	// HealthComponentQueues 异步鉴权、资源使用记录等待处理的队列
+	HealthComponentQueues = "queues"
+	// HealthComponentDecisions 鉴权决策
+	HealthComponentDecisions = "decisions"
+)
+
+// ComponentHealth 单个组件的健康状态
+type ComponentHealth struct {
+	// Name 组件名称
+	Name string `json:"name"`
+	// Status 组件的健康状态
+	Status HealthStatus `json:"status"`
+	// Reasons 组件降级的原因，健康时为空
+	Reasons []string `json:"reasons,omitempty"`
+	// Details 组件的检查数据，例如缓存的最近同步时间、队列的长度
+	Details map[string]interface{} `json:"details,omitempty"`
+}
+
+// Degrade 标记组件降级并记录原因
+func (c *ComponentHealth) Degrade(reason string) {
+	c.Status = HealthDegraded
+	c.Reasons = append(c.Reasons, reason)
+}
+
+// AuthHealth 鉴权模块的健康报告，任意组件降级时整体为降级
+type AuthHealth struct {
+	// Status 整体的健康状态
+	Status HealthStatus `json:"status"`
+	// GeneratedAt 报告的生成时间
+	GeneratedAt time.Time `json:"generatedAt"`
+	// Components 各个组件的健康状态
+	Components []ComponentHealth `json:"components"`
+}
+
+// Component 按照名称获取组件的健康状态，不存在时返回 nil
+func (h *AuthHealth) Component(name string) *ComponentHealth {
+	for i := range h.Components {
+		if h.Components[i].Name == name {
+			return &h.Components[i]
+		}
+	}
+	return nil
+}
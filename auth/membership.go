@@ -0,0 +1,84 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package auth
+
+import (
+	"time"
+
+	"github.com/polarismesh/polaris/common/model"
+)
+
+// MaxMembershipGraphDepth 导出用户组归属关系子图时允许的最大深度
+const MaxMembershipGraphDepth = 8
+
+// MembershipGraph 以某个 principal 为起点的用户组归属关系子图，用于权限排查以及访问评审
+// 用户与用户组之间交替展开: 用户 -> 所在的用户组 -> 用户组下的其他用户 -> ...，每展开一次深度加一
+// 子图中的全部数据均在同一个存储读事务中获取
+//
+//	{
+//	  "root": {"PrincipalID": "...", "PrincipalRole": 1},
+//	  "depth": 2,
+//	  "create_time": "2006-01-02T15:04:05Z",
+//	  "nodes": [{"id": "...", "name": "...", "type": "user", "owner": "...", "depth": 0}],
+//	  "edges": [{"group_id": "...", "user_id": "..."}]
+//	}
+type MembershipGraph struct {
+	// Root 子图的起点
+	Root model.Principal `json:"root"`
+	// Depth 子图展开的深度上限
+	Depth int `json:"depth"`
+	// CreateTime 子图的生成时间
+	CreateTime time.Time `json:"create_time"`
+	// Nodes 子图中的用户以及用户组
+	Nodes []MembershipNode `json:"nodes"`
+	// Edges 子图中用户与用户组的归属关系
+	Edges []SnapshotMembership `json:"edges"`
+}
+
+// MembershipNode 子图中的用户/用户组
+type MembershipNode struct {
+	// ID 用户/用户组 ID
+	ID string `json:"id"`
+	// Name 用户/用户组名称
+	Name string `json:"name"`
+	// Type principal 类型, user or group
+	Type string `json:"type"`
+	// Owner 所属的主账户 ID
+	Owner string `json:"owner"`
+	// Depth 距离起点的深度，起点为 0
+	Depth int `json:"depth"`
+}
+
+// MembershipWhatIf 假设用户加入用户组后可编辑资源的变化，计算过程不会修改任何数据
+// 资源 ID 为鉴权策略中的资源关联关系，可以是指定资源 ID、* 或者属性匹配规则
+type MembershipWhatIf struct {
+	// User 被审查的用户
+	User model.Principal `json:"user"`
+	// GroupID 假设加入的用户组
+	GroupID string `json:"group_id"`
+	// AlreadyMember 用户已经在该用户组中，结果不会有任何变化
+	AlreadyMember bool `json:"already_member"`
+	// ReadOnly 加入用户组后用户是否绑定了只读策略，只读策略优先于其他策略，用户将失去全部写权限
+	ReadOnly bool `json:"read_only"`
+	// Current 当前可编辑的资源
+	Current []CapabilityResource `json:"current"`
+	// Added 加入用户组后新增的可编辑资源
+	Added []CapabilityResource `json:"added"`
+	// Removed 加入用户组后失去的可编辑资源
+	Removed []CapabilityResource `json:"removed"`
+}
@@ -0,0 +1,48 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package auth
+
+import (
+	"time"
+
+	apisecurity "github.com/polarismesh/specification/source/go/api/v1/security"
+
+	"github.com/polarismesh/polaris/common/model"
+)
+
+// DecisionPin 针对 principal 在某个资源上的临时置顶鉴权决策，优先于所有鉴权策略，到期后自动失效
+// 用于故障处理期间立即放通或者拒绝某个 principal，无需等待鉴权策略修改后的同步
+// 置顶决策仅保存在当前节点的内存中，需要在每个节点上分别设置
+type DecisionPin struct {
+	// Principal 用户或者用户组，用户组的置顶决策对组内的用户同样生效
+	Principal model.Principal `json:"principal"`
+	// ResourceType 资源类型
+	ResourceType apisecurity.ResourceType `json:"resource_type"`
+	// ResourceID 资源 ID
+	ResourceID string `json:"resource_id"`
+	// Allow true 为强制放通，false 为强制拒绝
+	Allow bool `json:"allow"`
+	// Reason 设置原因，会记录到操作记录中
+	Reason string `json:"reason"`
+	// ExpireTime 过期时间
+	ExpireTime time.Time `json:"expire_time"`
+	// Operator 设置置顶决策的操作者
+	Operator string `json:"operator"`
+	// CreateTime 设置时间
+	CreateTime time.Time `json:"create_time"`
+}
@@ -22,6 +22,10 @@ import (
 
 	apisecurity "github.com/polarismesh/specification/source/go/api/v1/security"
 	apiservice "github.com/polarismesh/specification/source/go/api/v1/service_manage"
+
+	"github.com/polarismesh/polaris/auth"
+	"github.com/polarismesh/polaris/auth/evaluator"
+	"github.com/polarismesh/polaris/common/model"
 )
 
 // CreateStrategy 创建鉴权策略
@@ -29,6 +33,12 @@ func (svr *Server) CreateStrategy(ctx context.Context, req *apisecurity.AuthStra
 	return svr.handleCreateStrategy(ctx, req)
 }
 
+// CreateStrategies 批量创建鉴权策略
+func (svr *Server) CreateStrategies(
+	ctx context.Context, reqs []*apisecurity.AuthStrategy) *apiservice.BatchWriteResponse {
+	return svr.handleCreateStrategies(ctx, reqs)
+}
+
 // UpdateStrategies 批量修改鉴权
 func (svr *Server) UpdateStrategies(
 	ctx context.Context, reqs []*apisecurity.ModifyAuthStrategy) *apiservice.BatchWriteResponse {
@@ -46,6 +56,11 @@ func (svr *Server) GetStrategies(ctx context.Context, query map[string]string) *
 	return svr.handleGetStrategies(ctx, query)
 }
 
+// SearchStrategies 按照名称以及描述搜索鉴权策略
+func (svr *Server) SearchStrategies(ctx context.Context, query string, limit int) *apiservice.BatchQueryResponse {
+	return svr.handleSearchStrategies(ctx, query, limit)
+}
+
 // GetStrategy 查询单个鉴权策略
 func (svr *Server) GetStrategy(ctx context.Context, req *apisecurity.AuthStrategy) *apiservice.Response {
 	return svr.handleGetStrategy(ctx, req)
@@ -55,3 +70,140 @@ func (svr *Server) GetStrategy(ctx context.Context, req *apisecurity.AuthStrateg
 func (svr *Server) GetPrincipalResources(ctx context.Context, query map[string]string) *apiservice.Response {
 	return svr.handleGetPrincipalResources(ctx, query)
 }
+
+// ExportAuthModel 导出完整鉴权模型的时间点快照
+func (svr *Server) ExportAuthModel(ctx context.Context) (*auth.AuthModelSnapshot, error) {
+	return svr.handleExportAuthModel(ctx)
+}
+
+// ExportPolicySnapshot 导出推送给 sidecar 进行本地鉴权的策略快照
+func (svr *Server) ExportPolicySnapshot(ctx context.Context) (*evaluator.PolicySnapshot, error) {
+	return svr.handleExportPolicySnapshot(ctx)
+}
+
+// CapabilityMatrix 一次性计算 principal × 资源 × 操作 的鉴权结果
+func (svr *Server) CapabilityMatrix(ctx context.Context, principals []model.Principal,
+	resources []auth.CapabilityResource, operations []model.ResourceOperation) (*auth.CapabilityMatrix, error) {
+	return svr.handleCapabilityMatrix(ctx, principals, resources, operations)
+}
+
+// SimulateStrategy 模拟 principal 对资源的一次鉴权请求
+func (svr *Server) SimulateStrategy(ctx context.Context,
+	req *auth.SimulateRequest) (*auth.StrategySimulation, error) {
+	return svr.handleSimulateStrategy(ctx, req)
+}
+
+// ExplainGrants 计算 principal 对资源的写权限以及全部的授权路径
+func (svr *Server) ExplainGrants(ctx context.Context, principal model.Principal,
+	resource auth.CapabilityResource) (*auth.GrantExplanation, error) {
+	return svr.handleExplainGrants(ctx, principal, resource)
+}
+
+// WhatIfGroupMembership 计算假设用户加入用户组后可编辑资源的变化
+func (svr *Server) WhatIfGroupMembership(ctx context.Context, userID, groupID string) (*auth.MembershipWhatIf, error) {
+	return svr.handleWhatIfGroupMembership(ctx, userID, groupID)
+}
+
+// GenerateAccessReview 生成 principal 的访问审查报告
+func (svr *Server) GenerateAccessReview(ctx context.Context, principal model.Principal) (*auth.AccessReviewReport, error) {
+	return svr.handleGenerateAccessReview(ctx, principal)
+}
+
+// HealthReport 生成鉴权模块的健康报告
+func (svr *Server) HealthReport(ctx context.Context) (*auth.AuthHealth, error) {
+	return svr.handleHealthReport(ctx)
+}
+
+// AttachStrategyResources 为鉴权策略关联资源，每个资源关联关系可以单独设置过期时间
+func (svr *Server) AttachStrategyResources(ctx context.Context, strategyID string,
+	resources []model.StrategyResource) *apiservice.Response {
+	return svr.handleAttachStrategyResources(ctx, strategyID, resources)
+}
+
+// UpdateStrategyEffect 修改鉴权策略的授权效果以及优先级
+func (svr *Server) UpdateStrategyEffect(ctx context.Context, strategyID string, effect model.StrategyEffect,
+	priority int32) *apiservice.Response {
+	return svr.handleUpdateStrategyEffect(ctx, strategyID, effect, priority)
+}
+
+// UpdateStrategyFunctions 修改鉴权策略生效的接口名称
+func (svr *Server) UpdateStrategyFunctions(ctx context.Context, strategyID string,
+	functions []string) *apiservice.Response {
+	return svr.handleUpdateStrategyFunctions(ctx, strategyID, functions)
+}
+
+// BulkEnsureDefaultStrategies 批量确保 principal 存在默认策略
+func (svr *Server) BulkEnsureDefaultStrategies(ctx context.Context,
+	principals []model.Principal) (*auth.DefaultStrategyReport, error) {
+	return svr.handleBulkEnsureDefaultStrategies(ctx, principals)
+}
+
+// RecomputeDefaultStrategies 重新计算全部 principal 的默认策略
+func (svr *Server) RecomputeDefaultStrategies(ctx context.Context,
+	dryRun bool) (*auth.DefaultStrategyRecomputeReport, error) {
+	return svr.handleRecomputeDefaultStrategies(ctx, dryRun)
+}
+
+// CreateAuditorStrategy 为 principal 创建审计只读策略
+func (svr *Server) CreateAuditorStrategy(ctx context.Context, principal model.Principal) *apiservice.Response {
+	return svr.handleCreateAuditorStrategy(ctx, principal)
+}
+
+// TransferOwnership 将资源从 from 的默认策略转移到 to 的默认策略
+func (svr *Server) TransferOwnership(ctx context.Context, resource model.StrategyResource,
+	from, to model.Principal) *apiservice.Response {
+	return svr.handleTransferOwnership(ctx, resource, from, to)
+}
+
+// TransferAllOwnership 将 from 名下的全部服务、配置分组转移给 to
+func (svr *Server) TransferAllOwnership(ctx context.Context,
+	from, to model.Principal) (*auth.OwnershipTransferReport, error) {
+	return svr.handleTransferAllOwnership(ctx, from, to)
+}
+
+// CreateDecisionPin 设置临时置顶的鉴权决策
+func (svr *Server) CreateDecisionPin(ctx context.Context, pin *auth.DecisionPin) error {
+	return svr.checker.pins.add(ctx, pin)
+}
+
+// DeleteDecisionPin 删除置顶的鉴权决策
+func (svr *Server) DeleteDecisionPin(ctx context.Context, principal model.Principal,
+	resType apisecurity.ResourceType, resID string) error {
+	return svr.checker.pins.remove(ctx, principal, resType, resID)
+}
+
+// ListDecisionPins 查询尚未过期的置顶鉴权决策
+func (svr *Server) ListDecisionPins(ctx context.Context) ([]*auth.DecisionPin, error) {
+	return svr.checker.pins.list(), nil
+}
+
+// ListDecisionTrace 查询当前节点最近记录的鉴权决策
+func (svr *Server) ListDecisionTrace(ctx context.Context) ([]auth.DecisionTraceRecord, error) {
+	return svr.checker.DecisionTrace(), nil
+}
+
+// ReplayDecisionTrace 使用待变更的鉴权配置重放当前节点最近记录的鉴权决策
+func (svr *Server) ReplayDecisionTrace(ctx context.Context,
+	proposed map[string]interface{}) (*auth.AccessDelta, error) {
+	return svr.handleReplayDecisionTrace(ctx, proposed)
+}
+
+// CreateRoles 批量创建角色
+func (svr *Server) CreateRoles(ctx context.Context, roles []*model.Role) *apiservice.BatchWriteResponse {
+	return svr.handleCreateRoles(ctx, roles)
+}
+
+// UpdateRoles 批量更新角色
+func (svr *Server) UpdateRoles(ctx context.Context, roles []*model.Role) *apiservice.BatchWriteResponse {
+	return svr.handleUpdateRoles(ctx, roles)
+}
+
+// DeleteRoles 批量删除角色
+func (svr *Server) DeleteRoles(ctx context.Context, ids []string) *apiservice.BatchWriteResponse {
+	return svr.handleDeleteRoles(ctx, ids)
+}
+
+// GetRoles 查询角色列表
+func (svr *Server) GetRoles(ctx context.Context, query map[string]string) (uint32, []*model.Role, error) {
+	return svr.handleGetRoles(ctx, query)
+}
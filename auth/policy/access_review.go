@@ -0,0 +1,205 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package policy
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	apisecurity "github.com/polarismesh/specification/source/go/api/v1/security"
+	"go.uber.org/zap"
+
+	"github.com/polarismesh/polaris/auth"
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/common/utils"
+)
+
+// handleGenerateAccessReview 汇总 principal 本身以及所在用户组的鉴权策略、拒绝的置顶决策，生成访问审查报告
+// 已经过期的资源关联关系不再授予权限，不出现在报告中
+func (svr *Server) handleGenerateAccessReview(ctx context.Context,
+	principal model.Principal) (*auth.AccessReviewReport, error) {
+	disabled, err := svr.capabilityPrincipalDisable(principal)
+	if err != nil {
+		log.Error("[Auth][AccessReview] principal not found", utils.RequestID(ctx),
+			zap.String("principal", principal.PrincipalID), zap.Error(err))
+		return nil, err
+	}
+
+	var (
+		userCache     = svr.cacheMgr.User()
+		strategyCache = svr.cacheMgr.AuthStrategy()
+		self          = reviewPrincipalPath(principal)
+		now           = time.Now()
+		denies        []auth.AccessReviewDeny
+	)
+	report := &auth.AccessReviewReport{
+		Principal:   principal,
+		Disabled:    disabled,
+		Groups:      []string{},
+		GeneratedAt: now,
+		Inherited:   []auth.AccessReviewGrant{},
+	}
+	if principal.PrincipalRole == model.PrincipalUser {
+		report.Name = userCache.GetUserByID(principal.PrincipalID).Name
+		report.Direct, report.Denies = reviewStrategies(principal, self,
+			strategyCache.GetStrategyDetailsByUID(principal.PrincipalID), now)
+		report.Groups = append(report.Groups, userCache.GetUserLinkGroupIds(principal.PrincipalID)...)
+		sort.Strings(report.Groups)
+		for _, groupID := range report.Groups {
+			group := model.Principal{PrincipalID: groupID, PrincipalRole: model.PrincipalGroup}
+			grants, groupDenies := reviewStrategies(group, self+" -> "+reviewPrincipalPath(group),
+				strategyCache.GetStrategyDetailsByGroupID(groupID), now)
+			report.Inherited = append(report.Inherited, grants...)
+			report.Denies = append(report.Denies, groupDenies...)
+		}
+	} else {
+		report.Name = userCache.GetGroup(principal.PrincipalID).Name
+		report.Direct, report.Denies = reviewStrategies(principal, self,
+			strategyCache.GetStrategyDetailsByGroupID(principal.PrincipalID), now)
+	}
+
+	principals := svr.checker.pinPrincipals(principal)
+	for _, pin := range svr.checker.pins.list() {
+		if pin.Allow {
+			continue
+		}
+		for i := range principals {
+			if pin.Principal != principals[i] {
+				continue
+			}
+			path := self
+			if pin.Principal != principal {
+				path += " -> " + reviewPrincipalPath(pin.Principal)
+			}
+			denies = append(denies, auth.AccessReviewDeny{
+				Source:       auth.AccessReviewDenyPin,
+				Via:          pin.Principal,
+				Path:         path,
+				ResourceType: pin.ResourceType.String(),
+				ResourceID:   pin.ResourceID,
+				Reason:       pin.Reason,
+				ExpireTime:   pin.ExpireTime,
+			})
+		}
+	}
+	report.Denies = append(report.Denies, denies...)
+
+	for _, grants := range [][]auth.AccessReviewGrant{report.Direct, report.Inherited} {
+		for i := range grants {
+			if len(grants[i].Flags) > 0 {
+				report.Attention++
+			}
+		}
+	}
+	return report, nil
+}
+
+// reviewStrategies 将 via 绑定的鉴权策略展开为授权，只读策略额外作为拒绝全部写操作的记录，拒绝策略只作为拒绝的记录
+// 结果按照策略名称、资源类型以及资源关联关系排序
+func reviewStrategies(via model.Principal, path string, rules []*model.StrategyDetail,
+	now time.Time) ([]auth.AccessReviewGrant, []auth.AccessReviewDeny) {
+	grants := []auth.AccessReviewGrant{}
+	denies := []auth.AccessReviewDeny{}
+	sorted := append([]*model.StrategyDetail{}, rules...)
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Name != sorted[j].Name {
+			return sorted[i].Name < sorted[j].Name
+		}
+		return sorted[i].ID < sorted[j].ID
+	})
+	for _, rule := range sorted {
+		rulePath := path + " -> strategy/" + rule.Name
+		if rule.IsReadOnly() {
+			denies = append(denies, auth.AccessReviewDeny{
+				Source:       auth.AccessReviewDenyReadOnly,
+				Via:          via,
+				Path:         rulePath,
+				StrategyID:   rule.ID,
+				StrategyName: rule.Name,
+				ResourceID:   utils.MatchAll,
+			})
+		}
+		if rule.IsDeny() {
+			for _, res := range rule.Resources {
+				if res.IsExpired(now) || IsRequestCondition(res.ResID) {
+					continue
+				}
+				denies = append(denies, auth.AccessReviewDeny{
+					Source:       auth.AccessReviewDenyStrategy,
+					Via:          via,
+					Path:         rulePath,
+					StrategyID:   rule.ID,
+					StrategyName: rule.Name,
+					Priority:     rule.Priority,
+					ResourceType: apisecurity.ResourceType(res.ResType).String(),
+					ResourceID:   res.ResID,
+					ExpireTime:   res.ExpireTime,
+				})
+			}
+			continue
+		}
+		conditions := []string{}
+		for _, res := range rule.Resources {
+			if IsRequestCondition(res.ResID) {
+				conditions = append(conditions, res.ResID)
+			}
+		}
+		resources := append([]model.StrategyResource{}, rule.Resources...)
+		sort.SliceStable(resources, func(i, j int) bool {
+			if resources[i].ResType != resources[j].ResType {
+				return resources[i].ResType < resources[j].ResType
+			}
+			return resources[i].ResID < resources[j].ResID
+		})
+		for _, res := range resources {
+			if res.IsExpired(now) || IsRequestCondition(res.ResID) {
+				continue
+			}
+			flags := []auth.AccessReviewFlag{}
+			switch {
+			case res.ResID == utils.MatchAll:
+				flags = append(flags, auth.AccessReviewFlagWildcard)
+			case IsAttributeResource(res.ResID), IsLabelSelector(res.ResID):
+				flags = append(flags, auth.AccessReviewFlagBroad)
+			}
+			grants = append(grants, auth.AccessReviewGrant{
+				Via:          via,
+				Path:         rulePath,
+				StrategyID:   rule.ID,
+				StrategyName: rule.Name,
+				Default:      rule.Default,
+				Action:       rule.Action,
+				ResourceType: apisecurity.ResourceType(res.ResType).String(),
+				Rule:         res.ResID,
+				Conditions:   conditions,
+				ExpireTime:   res.ExpireTime,
+				Flags:        flags,
+			})
+		}
+	}
+	return grants, denies
+}
+
+// reviewPrincipalPath principal 在授权路径中的表示，例如 user/{id}、group/{id}
+func reviewPrincipalPath(principal model.Principal) string {
+	if principal.PrincipalRole == model.PrincipalUser {
+		return "user/" + principal.PrincipalID
+	}
+	return "group/" + principal.PrincipalID
+}
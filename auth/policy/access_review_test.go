@@ -0,0 +1,187 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package policy_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"sort"
+	"testing"
+	"time"
+
+	apisecurity "github.com/polarismesh/specification/source/go/api/v1/security"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/polarismesh/polaris/auth"
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/common/utils"
+)
+
+func Test_GenerateAccessReview(t *testing.T) {
+	strategyTest := newStrategyTest(t)
+	defer strategyTest.Clean()
+
+	users := strategyTest.users
+	user, group := users[4], strategyTest.groups[4]
+	userStrategy, groupStrategy := strategyTest.strategies[4], strategyTest.strategies[len(users)+4]
+	userSvc, groupSvc := strategyTest.services[4], strategyTest.services[len(users)+4]
+	// 用户直接关联全部服务并携带请求条件，用户组通过属性规则关联服务，另有一条已经过期的关联
+	userStrategy.Resources = append(userStrategy.Resources,
+		model.StrategyResource{StrategyID: userStrategy.ID, ResType: int32(apisecurity.ResourceType_Services),
+			ResID: utils.MatchAll},
+		model.StrategyResource{StrategyID: userStrategy.ID, ResType: int32(apisecurity.ResourceType_Services),
+			ResID: "req:environment=staging"},
+	)
+	userStrategy.Revision = utils.NewUUID()
+	groupStrategy.Resources = append(groupStrategy.Resources,
+		model.StrategyResource{StrategyID: groupStrategy.ID, ResType: int32(apisecurity.ResourceType_Services),
+			ResID: "attr:env=prod"},
+		model.StrategyResource{StrategyID: groupStrategy.ID, ResType: int32(apisecurity.ResourceType_Services),
+			ResID: "expired-service", ExpireTime: time.Now().Add(-time.Minute)},
+	)
+	groupStrategy.Revision = utils.NewUUID()
+	_ = strategyTest.cacheMgn.TestUpdate()
+
+	groupPrincipal := model.Principal{PrincipalID: group.ID, PrincipalRole: model.PrincipalGroup}
+	pinExpire := time.Now().Add(time.Minute)
+	err := strategyTest.policySvr.CreateDecisionPin(context.Background(), &auth.DecisionPin{
+		Principal:    groupPrincipal,
+		ResourceType: apisecurity.ResourceType_Services,
+		ResourceID:   groupSvc.ID,
+		Reason:       "incident-1",
+		ExpireTime:   pinExpire,
+	})
+	assert.NoError(t, err)
+
+	principal := model.Principal{PrincipalID: user.ID, PrincipalRole: model.PrincipalUser}
+	userPath := "user/" + user.ID + " -> strategy/" + userStrategy.Name
+	groupPath := "user/" + user.ID + " -> group/" + group.ID + " -> strategy/" + groupStrategy.Name
+	userGrant := func(resType apisecurity.ResourceType, rule string,
+		flags ...auth.AccessReviewFlag) auth.AccessReviewGrant {
+		return auth.AccessReviewGrant{
+			Via: principal, Path: userPath, StrategyID: userStrategy.ID, StrategyName: userStrategy.Name,
+			Action: userStrategy.Action, ResourceType: resType.String(), Rule: rule,
+			Conditions: []string{"req:environment=staging"}, Flags: append([]auth.AccessReviewFlag{}, flags...),
+		}
+	}
+	groupGrant := func(resType apisecurity.ResourceType, rule string,
+		flags ...auth.AccessReviewFlag) auth.AccessReviewGrant {
+		return auth.AccessReviewGrant{
+			Via: groupPrincipal, Path: groupPath, StrategyID: groupStrategy.ID, StrategyName: groupStrategy.Name,
+			Action: groupStrategy.Action, ResourceType: resType.String(), Rule: rule,
+			Conditions: []string{}, Flags: append([]auth.AccessReviewFlag{}, flags...),
+		}
+	}
+	expect := &auth.AccessReviewReport{
+		Principal: principal,
+		Name:      user.Name,
+		Groups:    []string{group.ID},
+		Direct: []auth.AccessReviewGrant{
+			userGrant(apisecurity.ResourceType_Namespaces, userSvc.Namespace),
+			userGrant(apisecurity.ResourceType_Services, utils.MatchAll, auth.AccessReviewFlagWildcard),
+			userGrant(apisecurity.ResourceType_Services, userSvc.ID),
+		},
+		Inherited: []auth.AccessReviewGrant{
+			groupGrant(apisecurity.ResourceType_Namespaces, groupSvc.Namespace),
+			groupGrant(apisecurity.ResourceType_Services, "attr:env=prod", auth.AccessReviewFlagBroad),
+			groupGrant(apisecurity.ResourceType_Services, groupSvc.ID),
+		},
+		Denies: []auth.AccessReviewDeny{
+			{
+				Source: auth.AccessReviewDenyPin, Via: groupPrincipal, Path: "user/" + user.ID + " -> group/" + group.ID,
+				ResourceType: apisecurity.ResourceType_Services.String(), ResourceID: groupSvc.ID,
+				Reason: "incident-1", ExpireTime: pinExpire,
+			},
+		},
+		Attention: 2,
+	}
+	// 同一个策略内按照资源类型以及资源关联关系排序
+	sort.Slice(expect.Inherited, func(i, j int) bool {
+		a, b := expect.Inherited[i], expect.Inherited[j]
+		if a.ResourceType != b.ResourceType {
+			return a.ResourceType < b.ResourceType
+		}
+		return a.Rule < b.Rule
+	})
+
+	t.Run("报告与授权一致", func(t *testing.T) {
+		report, err := strategyTest.policySvr.GenerateAccessReview(context.Background(), principal)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.False(t, report.GeneratedAt.IsZero())
+		expect.GeneratedAt = report.GeneratedAt
+		assert.Equal(t, expect, report)
+
+		data, err := json.Marshal(report)
+		assert.NoError(t, err)
+		decoded := &auth.AccessReviewReport{}
+		assert.NoError(t, json.Unmarshal(data, decoded))
+		assert.Equal(t, report.Direct, decoded.Direct)
+		assert.Equal(t, report.Inherited, decoded.Inherited)
+
+		buf := &bytes.Buffer{}
+		assert.NoError(t, report.WriteCSV(buf))
+		records, err := csv.NewReader(buf).ReadAll()
+		assert.NoError(t, err)
+		// 表头、6 条授权以及 1 条拒绝
+		if !assert.Equal(t, 8, len(records)) {
+			return
+		}
+		assert.Equal(t, []string{"direct", userPath, userStrategy.ID, userStrategy.Name, "false",
+			userStrategy.Action, "Services", utils.MatchAll, "req:environment=staging", "", "Wildcard", ""},
+			records[2])
+		assert.Equal(t, "inherited", records[5][0])
+		assert.Equal(t, []string{"deny", expect.Denies[0].Path, "", "", "", "DecisionPin", "Services",
+			groupSvc.ID, "", pinExpire.Format(time.RFC3339), "", "incident-1"}, records[7])
+	})
+
+	t.Run("只读策略拒绝全部写操作", func(t *testing.T) {
+		groupStrategy.Action = apisecurity.AuthAction_ONLY_READ.String()
+		groupStrategy.Revision = utils.NewUUID()
+		_ = strategyTest.cacheMgn.TestUpdate()
+		defer func() {
+			groupStrategy.Action = apisecurity.AuthAction_READ_WRITE.String()
+			groupStrategy.Revision = utils.NewUUID()
+			_ = strategyTest.cacheMgn.TestUpdate()
+		}()
+
+		report, err := strategyTest.policySvr.GenerateAccessReview(context.Background(), groupPrincipal)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, group.Name, report.Name)
+		assert.Equal(t, 0, len(report.Inherited))
+		assert.Equal(t, 3, len(report.Direct))
+		assert.Equal(t, 2, len(report.Denies))
+		assert.Equal(t, auth.AccessReviewDeny{
+			Source: auth.AccessReviewDenyReadOnly, Via: groupPrincipal,
+			Path:       "group/" + group.ID + " -> strategy/" + groupStrategy.Name,
+			StrategyID: groupStrategy.ID, StrategyName: groupStrategy.Name, ResourceID: utils.MatchAll,
+		}, report.Denies[0])
+		assert.Equal(t, auth.AccessReviewDenyPin, report.Denies[1].Source)
+	})
+
+	t.Run("principal 不存在", func(t *testing.T) {
+		_, err := strategyTest.policySvr.GenerateAccessReview(context.Background(),
+			model.Principal{PrincipalID: "not-exist", PrincipalRole: model.PrincipalUser})
+		assert.ErrorIs(t, err, model.ErrorNoUser)
+	})
+}
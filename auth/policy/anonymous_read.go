@@ -0,0 +1,77 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+package policy
+
+import (
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/polarismesh/polaris/auth"
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/common/utils"
+)
+
+// isAnonymousReadable 请求未携带 token 时，是否允许匿名读取
+// 只有读操作并且方法名称命中 AnonymousReadMethods 中任意一个前缀时跳过身份校验，写操作仍然需要正常鉴权
+func (d *DefaultAuthChecker) isAnonymousReadable(authCtx *model.AcquireContext) bool {
+	if len(d.conf.AnonymousReadMethods) == 0 || authCtx.GetOperation() != model.Read {
+		return false
+	}
+	if utils.ParseAuthToken(authCtx.GetRequestContext()) != "" {
+		return false
+	}
+	method := authCtx.GetMethod()
+	if method == "" {
+		return false
+	}
+	for _, prefix := range d.conf.AnonymousReadMethods {
+		if prefix != "" && strings.HasPrefix(method, prefix) {
+			log.Debug("[Auth][Checker] anonymous read allowed", utils.RequestID(authCtx.GetRequestContext()),
+				zap.String("method", method), zap.String("group", prefix))
+			return true
+		}
+	}
+	return false
+}
+
+// checkAnonymousRead 匿名读取的请求跳过身份校验，但是置顶决策、默认拒绝以及拒绝策略仍然生效
+func (d *DefaultAuthChecker) checkAnonymousRead(authCtx *model.AcquireContext) (bool, error) {
+	principal := withAnonymousPrincipal(authCtx)
+	if pass, pinned := d.checkPins(authCtx, principal); pinned {
+		d.tracer.record(authCtx, pass)
+		if !pass {
+			return false, ErrorDecisionPinDeny
+		}
+		return true, nil
+	}
+	pass, err := d.doCheckPermission(authCtx)
+	d.tracer.record(authCtx, pass)
+	return pass, err
+}
+
+// withAnonymousPrincipal 将匿名用户作为本次请求的操作者注入到请求上下文中
+func withAnonymousPrincipal(authCtx *model.AcquireContext) model.Principal {
+	anonymous := auth.NewAnonymous()
+	authCtx.SetAttachment(model.TokenDetailInfoKey, anonymous)
+	authCtx.SetAttachment(model.OperatorIDKey, anonymous.OperatorID)
+	authCtx.SetAttachment(model.OperatorPrincipalType, model.PrincipalUser)
+	return model.Principal{
+		PrincipalID:   anonymous.OperatorID,
+		PrincipalRole: model.PrincipalUser,
+	}
+}
@@ -0,0 +1,61 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+package policy
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/common/utils"
+)
+
+func Test_isAnonymousReadable(t *testing.T) {
+	checker := &DefaultAuthChecker{conf: &AuthConfig{
+		AnonymousReadMethods: []string{"Discover", "DescribeServices"},
+	}}
+	newCtx := func(ctx context.Context, op model.ResourceOperation, method string) *model.AcquireContext {
+		return model.NewAcquireContext(
+			model.WithRequestContext(ctx),
+			model.WithOperation(op),
+			model.WithMethod(method),
+		)
+	}
+
+	t.Run("命中接口分组的匿名读请求跳过身份校验", func(t *testing.T) {
+		assert.True(t, checker.isAnonymousReadable(newCtx(context.Background(), model.Read, "DiscoverInstances")))
+		assert.True(t, checker.isAnonymousReadable(newCtx(context.Background(), model.Read, "DescribeServices")))
+	})
+
+	t.Run("写操作以及未命中接口分组的请求需要鉴权", func(t *testing.T) {
+		assert.False(t, checker.isAnonymousReadable(newCtx(context.Background(), model.Create, "DiscoverInstances")))
+		assert.False(t, checker.isAnonymousReadable(newCtx(context.Background(), model.Read, "DescribeConfigFile")))
+		assert.False(t, checker.isAnonymousReadable(newCtx(context.Background(), model.Read, "")))
+	})
+
+	t.Run("携带 token 的请求按照正常流程鉴权", func(t *testing.T) {
+		ctx := context.WithValue(context.Background(), utils.ContextAuthTokenKey, "token")
+		assert.False(t, checker.isAnonymousReadable(newCtx(ctx, model.Read, "DiscoverInstances")))
+	})
+
+	t.Run("未配置时不允许匿名读取", func(t *testing.T) {
+		empty := &DefaultAuthChecker{conf: &AuthConfig{}}
+		assert.False(t, empty.isAnonymousReadable(newCtx(context.Background(), model.Read, "DiscoverInstances")))
+	})
+}
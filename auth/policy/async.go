@@ -0,0 +1,177 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package policy
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"sync"
+
+	"go.uber.org/zap"
+
+	"github.com/polarismesh/polaris/auth"
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/common/utils"
+)
+
+const (
+	// defaultAsyncCheckQueueSize 默认的异步鉴权等待队列长度
+	defaultAsyncCheckQueueSize = 1024
+)
+
+var (
+	// ErrorAsyncCheckRejected 异步鉴权的工作池已满，请求被拒绝
+	ErrorAsyncCheckRejected = errors.New("async auth check rejected, worker pool is saturated")
+	// ErrorAsyncCheckStopped 鉴权检查器已经停止，不再接收异步鉴权请求
+	ErrorAsyncCheckStopped = errors.New("async auth check rejected, checker stopped")
+	// ErrorAsyncCheckPanic 异步鉴权执行过程中发生 panic
+	ErrorAsyncCheckPanic = errors.New("async auth check failed, panic during check")
+)
+
+type asyncTask struct {
+	ctx      context.Context
+	authCtx  *model.AcquireContext
+	callback auth.DecisionCallback
+}
+
+// asyncCheckPool 固定数量的 worker 以及有界的等待队列，队列满时直接拒绝，不会无限制的创建协程
+// worker 在第一次提交异步鉴权时才启动
+type asyncCheckPool struct {
+	workers int
+	check   func(authCtx *model.AcquireContext) (bool, error)
+	once    sync.Once
+	lock    sync.RWMutex
+	stopped bool
+	queue   chan asyncTask
+	wg      sync.WaitGroup
+}
+
+// newAsyncCheckPool workers、queueSize 小于等于 0 时使用默认值
+func newAsyncCheckPool(workers, queueSize int,
+	check func(authCtx *model.AcquireContext) (bool, error)) *asyncCheckPool {
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	if queueSize <= 0 {
+		queueSize = defaultAsyncCheckQueueSize
+	}
+	return &asyncCheckPool{
+		workers: workers,
+		check:   check,
+		queue:   make(chan asyncTask, queueSize),
+	}
+}
+
+// submit 非阻塞的提交异步鉴权，被拒绝时在调用方的协程中直接回调
+func (p *asyncCheckPool) submit(task asyncTask) {
+	p.once.Do(p.start)
+
+	p.lock.RLock()
+	if p.stopped {
+		p.lock.RUnlock()
+		task.callback(auth.Decision{}, ErrorAsyncCheckStopped)
+		return
+	}
+	select {
+	case p.queue <- task:
+		p.lock.RUnlock()
+	default:
+		p.lock.RUnlock()
+		task.callback(auth.Decision{}, ErrorAsyncCheckRejected)
+	}
+}
+
+func (p *asyncCheckPool) start() {
+	p.wg.Add(p.workers)
+	for i := 0; i < p.workers; i++ {
+		go p.run()
+	}
+}
+
+func (p *asyncCheckPool) run() {
+	defer p.wg.Done()
+	for task := range p.queue {
+		p.execute(task)
+	}
+}
+
+// execute 保证每个异步鉴权的 callback 有且仅有一次调用，鉴权过程中 panic 时以 ErrorAsyncCheckPanic 回调
+func (p *asyncCheckPool) execute(task asyncTask) {
+	var once sync.Once
+	callback := func(decision auth.Decision, err error) {
+		once.Do(func() {
+			task.callback(decision, err)
+		})
+	}
+	defer func() {
+		if err := recover(); err != nil {
+			log.Error("[Auth][Checker] async auth check panic", utils.RequestID(task.ctx), zap.Any("error", err))
+			// callback 自身 panic 时 once 已经执行过，不会再次回调
+			safeCallback(task.ctx, callback, ErrorAsyncCheckPanic)
+		}
+	}()
+	if err := task.ctx.Err(); err != nil {
+		callback(auth.Decision{}, err)
+		return
+	}
+	pass, err := p.check(task.authCtx)
+	callback(auth.Decision{Allowed: pass}, err)
+}
+
+func safeCallback(ctx context.Context, callback auth.DecisionCallback, err error) {
+	defer func() {
+		if err := recover(); err != nil {
+			log.Error("[Auth][Checker] async auth callback panic", utils.RequestID(ctx), zap.Any("error", err))
+		}
+	}()
+	callback(auth.Decision{}, err)
+}
+
+// stop 不再接收新的异步鉴权，等待队列中已经提交的鉴权全部执行完成
+func (p *asyncCheckPool) stop() {
+	if p == nil {
+		return
+	}
+	// 尚未启动的 worker 不再启动，正在启动时等待启动完成
+	p.once.Do(func() {})
+	p.lock.Lock()
+	if p.stopped {
+		p.lock.Unlock()
+		return
+	}
+	p.stopped = true
+	close(p.queue)
+	p.lock.Unlock()
+	p.wg.Wait()
+}
+
+// CheckAsync 在有界的工作池中执行鉴权检查，完成后调用 callback
+// 按照 AcquireContext 的请求来源执行客户端或者控制台的鉴权检查，工作池已满时立即以 ErrorAsyncCheckRejected 回调
+// ctx 在鉴权开始执行前被取消时，以 ctx.Err() 回调
+func (d *DefaultAuthChecker) CheckAsync(ctx context.Context, authCtx *model.AcquireContext,
+	callback auth.DecisionCallback) {
+	d.async.submit(asyncTask{ctx: ctx, authCtx: authCtx, callback: callback})
+}
+
+func (d *DefaultAuthChecker) checkBySource(authCtx *model.AcquireContext) (bool, error) {
+	if authCtx.IsFromClient() {
+		return d.CheckClientPermission(authCtx)
+	}
+	return d.CheckConsolePermission(authCtx)
+}
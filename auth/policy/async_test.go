@@ -0,0 +1,206 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package policy
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/polarismesh/polaris/auth"
+	"github.com/polarismesh/polaris/common/model"
+)
+
+func Test_asyncCheckPool(t *testing.T) {
+	t.Run("回调返回对应请求的鉴权结果", func(t *testing.T) {
+		pool := newAsyncCheckPool(4, 128, func(authCtx *model.AcquireContext) (bool, error) {
+			if authCtx.GetMethod() == "deny" {
+				return false, ErrorNotPermission
+			}
+			return true, nil
+		})
+		defer pool.stop()
+
+		var (
+			wg      sync.WaitGroup
+			lock    sync.Mutex
+			results = map[string][]auth.Decision{}
+		)
+		for i := 0; i < 100; i++ {
+			method := "allow"
+			if i%2 == 0 {
+				method = "deny"
+			}
+			wg.Add(1)
+			pool.submit(asyncTask{
+				ctx:     context.Background(),
+				authCtx: model.NewAcquireContext(model.WithMethod(method)),
+				callback: func(decision auth.Decision, err error) {
+					defer wg.Done()
+					lock.Lock()
+					defer lock.Unlock()
+					results[method] = append(results[method], decision)
+					if method == "deny" {
+						assert.True(t, errors.Is(err, ErrorNotPermission))
+					} else {
+						assert.NoError(t, err)
+					}
+				},
+			})
+		}
+		wg.Wait()
+		assert.Equal(t, 50, len(results["allow"]))
+		assert.Equal(t, 50, len(results["deny"]))
+		for _, decision := range results["allow"] {
+			assert.True(t, decision.Allowed)
+		}
+		for _, decision := range results["deny"] {
+			assert.False(t, decision.Allowed)
+		}
+	})
+
+	t.Run("工作池饱和时拒绝而不是创建更多协程", func(t *testing.T) {
+		block := make(chan struct{})
+		pool := newAsyncCheckPool(2, 8, func(authCtx *model.AcquireContext) (bool, error) {
+			<-block
+			return true, nil
+		})
+
+		before := runtime.NumGoroutine()
+		var rejected, finished int32
+		for i := 0; i < 10000; i++ {
+			pool.submit(asyncTask{
+				ctx:     context.Background(),
+				authCtx: model.NewAcquireContext(),
+				callback: func(decision auth.Decision, err error) {
+					if errors.Is(err, ErrorAsyncCheckRejected) {
+						atomic.AddInt32(&rejected, 1)
+						return
+					}
+					assert.True(t, decision.Allowed)
+					atomic.AddInt32(&finished, 1)
+				},
+			})
+		}
+		// 最多只有 worker 正在执行以及队列中等待的请求被接收
+		assert.True(t, atomic.LoadInt32(&rejected) >= 10000-2-8)
+		assert.True(t, runtime.NumGoroutine() < before+10)
+
+		close(block)
+		pool.stop()
+		assert.Equal(t, int32(10000), atomic.LoadInt32(&rejected)+atomic.LoadInt32(&finished))
+	})
+
+	t.Run("ctx 取消以及停止后的请求", func(t *testing.T) {
+		pool := newAsyncCheckPool(1, 1, func(authCtx *model.AcquireContext) (bool, error) {
+			return true, nil
+		})
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		done := make(chan error, 1)
+		pool.submit(asyncTask{ctx: ctx, authCtx: model.NewAcquireContext(),
+			callback: func(_ auth.Decision, err error) { done <- err }})
+		select {
+		case err := <-done:
+			assert.True(t, errors.Is(err, context.Canceled))
+		case <-time.After(5 * time.Second):
+			t.Fatal("callback not fired")
+		}
+
+		pool.stop()
+		var stopErr error
+		pool.submit(asyncTask{ctx: context.Background(), authCtx: model.NewAcquireContext(),
+			callback: func(_ auth.Decision, err error) { stopErr = err }})
+		assert.True(t, errors.Is(stopErr, ErrorAsyncCheckStopped))
+	})
+
+	t.Run("鉴权 panic 时以错误回调且只回调一次", func(t *testing.T) {
+		pool := newAsyncCheckPool(1, 4, func(authCtx *model.AcquireContext) (bool, error) {
+			panic("mock check panic")
+		})
+		var calls int32
+		done := make(chan error, 2)
+		pool.submit(asyncTask{ctx: context.Background(), authCtx: model.NewAcquireContext(),
+			callback: func(decision auth.Decision, err error) {
+				atomic.AddInt32(&calls, 1)
+				assert.False(t, decision.Allowed)
+				done <- err
+			}})
+		select {
+		case err := <-done:
+			assert.True(t, errors.Is(err, ErrorAsyncCheckPanic))
+		case <-time.After(5 * time.Second):
+			t.Fatal("callback not fired")
+		}
+
+		// callback 自身 panic 时不会被再次调用，worker 继续处理后续请求
+		pool.check = func(authCtx *model.AcquireContext) (bool, error) {
+			return true, nil
+		}
+		var panicCalls int32
+		pool.submit(asyncTask{ctx: context.Background(), authCtx: model.NewAcquireContext(),
+			callback: func(decision auth.Decision, err error) {
+				atomic.AddInt32(&panicCalls, 1)
+				panic("mock callback panic")
+			}})
+		pool.submit(asyncTask{ctx: context.Background(), authCtx: model.NewAcquireContext(),
+			callback: func(decision auth.Decision, err error) { done <- err }})
+		select {
+		case err := <-done:
+			assert.NoError(t, err)
+		case <-time.After(5 * time.Second):
+			t.Fatal("callback not fired")
+		}
+		pool.stop()
+		assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+		assert.Equal(t, int32(1), atomic.LoadInt32(&panicCalls))
+	})
+
+	t.Run("按照请求来源执行鉴权检查", func(t *testing.T) {
+		checker := &DefaultAuthChecker{conf: &AuthConfig{}}
+		checker.async = newAsyncCheckPool(1, 4, checker.checkBySource)
+		defer checker.async.stop()
+
+		done := make(chan auth.Decision, 2)
+		checker.CheckAsync(context.Background(), model.NewAcquireContext(model.WithFromClient()),
+			func(decision auth.Decision, err error) {
+				assert.NoError(t, err)
+				done <- decision
+			})
+		checker.CheckAsync(context.Background(), model.NewAcquireContext(model.WithFromConsole()),
+			func(decision auth.Decision, err error) {
+				assert.NoError(t, err)
+				done <- decision
+			})
+		for i := 0; i < 2; i++ {
+			select {
+			case decision := <-done:
+				// 未开启鉴权时直接放通
+				assert.True(t, decision.Allowed)
+			case <-time.After(5 * time.Second):
+				t.Fatal("callback not fired")
+			}
+		}
+	})
+}
@@ -0,0 +1,120 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package policy
+
+import (
+	"strconv"
+
+	apisecurity "github.com/polarismesh/specification/source/go/api/v1/security"
+	"go.uber.org/zap"
+
+	"github.com/polarismesh/polaris/auth/evaluator"
+	"github.com/polarismesh/polaris/common/model"
+)
+
+const (
+	// AttributeResourcePrefix 按照资源属性匹配的资源 ID 前缀, 例如 attr:tier=gold,env!=test|dev
+	AttributeResourcePrefix = evaluator.AttributePrefix
+
+	// maxAttributePredicates 单个属性匹配规则中最多的条件数量
+	maxAttributePredicates = evaluator.MaxPredicates
+	// maxAttributeValues 单个条件中最多的候选值数量
+	maxAttributeValues = evaluator.MaxPredicateValues
+)
+
+// ErrorInvalidAttributeRule 属性匹配规则不合法
+var ErrorInvalidAttributeRule = evaluator.ErrorInvalidRule
+
+// attributePredicate 单个属性条件, 属性值命中任意一个候选值即满足，negate 时取反
+type attributePredicate = evaluator.Predicate
+
+// IsAttributeResource 资源 ID 是否为属性匹配规则
+func IsAttributeResource(resId string) bool {
+	return evaluator.IsAttributeRule(resId)
+}
+
+// parseAttributeRule 解析属性匹配规则, 多个条件之间使用 , 分隔且需要同时满足, 条件支持 key=v1|v2 以及 key!=v1|v2
+func parseAttributeRule(resId string) ([]attributePredicate, error) {
+	return evaluator.ParseAttributeRule(resId)
+}
+
+// matchAttributes 资源属性是否满足全部条件, 资源没有该属性时视为不等于任何值
+func matchAttributes(predicates []attributePredicate, attrs map[string]string) bool {
+	return evaluator.MatchPredicates(predicates, attrs)
+}
+
+// loadResourceAttributes 从存储中获取资源的属性, 目前仅服务支持按照属性匹配, 使用服务的元数据
+func (d *DefaultAuthChecker) loadResourceAttributes(resType apisecurity.ResourceType,
+	resId string) (map[string]string, error) {
+	switch resType {
+	case apisecurity.ResourceType_Services:
+		svc, err := d.storage.GetServiceByID(resId)
+		if err != nil || svc == nil {
+			return nil, err
+		}
+		return svc.Meta, nil
+	default:
+		return nil, nil
+	}
+}
+
+// loadResourceLabels 从缓存中获取资源所属的命名空间以及标签，服务使用元数据，配置分组使用分组的元数据
+// 资源在缓存中不存在时返回空的命名空间以及标签
+func (d *DefaultAuthChecker) loadResourceLabels(resType apisecurity.ResourceType,
+	resId string) (string, map[string]string, error) {
+	switch resType {
+	case apisecurity.ResourceType_Services:
+		svc := d.cacheMgr.Service().GetServiceByID(resId)
+		if svc == nil {
+			return "", nil, nil
+		}
+		return svc.Namespace, svc.Meta, nil
+	case apisecurity.ResourceType_ConfigGroups:
+		groupID, err := strconv.ParseUint(resId, 10, 64)
+		if err != nil {
+			return "", nil, nil
+		}
+		group := d.cacheMgr.ConfigGroup().GetGroupByID(groupID)
+		if group == nil {
+			return "", nil, nil
+		}
+		return group.Namespace, group.Metadata, nil
+	default:
+		return "", nil, nil
+	}
+}
+
+// isAttributeEditable principal 以及其所属用户组的策略中，是否存在按照属性或者标签选择器匹配到该资源的规则
+func (d *DefaultAuthChecker) isAttributeEditable(principal model.Principal,
+	resType apisecurity.ResourceType, resId string) bool {
+	return d.attributeEditable(principal, resType, resId, nil)
+}
+
+// attributeEditable 同 isAttributeEditable，excluded 中的策略不参与匹配
+func (d *DefaultAuthChecker) attributeEditable(principal model.Principal,
+	resType apisecurity.ResourceType, resId string, excluded map[string]struct{}) bool {
+	if resType != apisecurity.ResourceType_Services && resType != apisecurity.ResourceType_ConfigGroups {
+		return false
+	}
+	req := evaluator.Request{Principal: principal, ResourceType: resType, ResourceID: resId}
+	decision := d.engine().MatchAttribute(req, d.principalStrategies(principal), excluded)
+	if decision.Err != nil {
+		log.Error("[Auth][Checker] load resource attributes", zap.String("resource", resId), zap.Error(decision.Err))
+	}
+	return decision.Allowed
+}
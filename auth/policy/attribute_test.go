@@ -0,0 +1,114 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package policy
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	apisecurity "github.com/polarismesh/specification/source/go/api/v1/security"
+	"github.com/stretchr/testify/assert"
+
+	cachemock "github.com/polarismesh/polaris/cache/mock"
+	"github.com/polarismesh/polaris/common/model"
+	storemock "github.com/polarismesh/polaris/store/mock"
+)
+
+func Test_parseAttributeRule(t *testing.T) {
+	predicates, err := parseAttributeRule("attr:tier=gold, env != test|dev")
+	assert.NoError(t, err)
+	assert.Equal(t, []attributePredicate{
+		{Key: "tier", Values: []string{"gold"}},
+		{Key: "env", Values: []string{"test", "dev"}, Negate: true},
+	}, predicates)
+
+	invalids := []string{
+		"attr:",
+		"attr:tier",
+		"attr:=gold",
+		"attr:tier=",
+		"attr:tier=gold|",
+		"attr:" + strings.Repeat("k=v,", maxAttributePredicates) + "k=v",
+		"attr:k=" + strings.Repeat("v|", maxAttributeValues) + "v",
+	}
+	for _, expr := range invalids {
+		_, err := parseAttributeRule(expr)
+		assert.ErrorIs(t, err, ErrorInvalidAttributeRule, expr)
+	}
+}
+
+func Test_matchAttributes(t *testing.T) {
+	predicates, err := parseAttributeRule("attr:tier=gold|silver,env!=test")
+	assert.NoError(t, err)
+
+	assert.True(t, matchAttributes(predicates, map[string]string{"tier": "gold", "env": "prod"}))
+	assert.True(t, matchAttributes(predicates, map[string]string{"tier": "silver"}))
+	assert.False(t, matchAttributes(predicates, map[string]string{"tier": "bronze", "env": "prod"}))
+	assert.False(t, matchAttributes(predicates, map[string]string{"tier": "gold", "env": "test"}))
+	assert.False(t, matchAttributes(predicates, nil))
+}
+
+func Test_checkActionByAttribute(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	storage := storemock.NewMockStore(ctrl)
+	userCache := cachemock.NewMockUserCache(ctrl)
+	strategyCache := cachemock.NewMockStrategyCache(ctrl)
+	cacheMgr := cachemock.NewMockCacheManager(ctrl)
+	cacheMgr.EXPECT().User().Return(userCache).AnyTimes()
+	cacheMgr.EXPECT().AuthStrategy().Return(strategyCache).AnyTimes()
+	roleCache := cachemock.NewMockRoleCache(ctrl)
+	cacheMgr.EXPECT().AuthRole().Return(roleCache).AnyTimes()
+	roleCache.EXPECT().GetPrincipalRoles(gomock.Any()).Return(nil).AnyTimes()
+	roleCache.EXPECT().Version().Return(uint64(0)).AnyTimes()
+	strategyCache.EXPECT().GetLabelSelectorStrategies(gomock.Any()).Return(nil).AnyTimes()
+
+	checker := &DefaultAuthChecker{conf: DefaultAuthConfig(), storage: storage, cacheMgr: cacheMgr}
+	user := model.Principal{PrincipalID: "user-1", PrincipalRole: model.PrincipalUser}
+	authCtx := model.NewAcquireContext(model.WithOperation(model.Modify))
+
+	// 用户所属的用户组通过属性规则获得 tier=gold 服务的权限
+	strategyCache.EXPECT().IsResourceEditable(user, apisecurity.ResourceType_Services, gomock.Any()).
+		Return(false).AnyTimes()
+	strategyCache.EXPECT().GetStrategyDetailsByUID("user-1").Return(nil).AnyTimes()
+	userCache.EXPECT().GetUserLinkGroupIds("user-1").Return([]string{"group-1"}).AnyTimes()
+	strategyCache.EXPECT().GetStrategyDetailsByGroupID("group-1").Return([]*model.StrategyDetail{
+		{
+			ID: "rule-1",
+			Resources: []model.StrategyResource{
+				{StrategyID: "rule-1", ResType: int32(apisecurity.ResourceType_Services), ResID: "attr:tier=gold"},
+			},
+		},
+	}).AnyTimes()
+	storage.EXPECT().GetServiceByID("svc-gold").
+		Return(&model.Service{ID: "svc-gold", Meta: map[string]string{"tier": "gold"}}, nil).AnyTimes()
+	storage.EXPECT().GetServiceByID("svc-bronze").
+		Return(&model.Service{ID: "svc-bronze", Meta: map[string]string{"tier": "bronze"}}, nil).AnyTimes()
+
+	t.Run("属性匹配的服务允许操作", func(t *testing.T) {
+		assert.NoError(t, checker.checkAction(user, apisecurity.ResourceType_Services,
+			[]model.ResourceEntry{{ID: "svc-gold"}}, authCtx))
+	})
+
+	t.Run("属性不匹配的服务拒绝操作", func(t *testing.T) {
+		assert.ErrorIs(t, checker.checkAction(user, apisecurity.ResourceType_Services,
+			[]model.ResourceEntry{{ID: "svc-gold"}, {ID: "svc-bronze"}}, authCtx), ErrorNotPermission)
+	})
+}
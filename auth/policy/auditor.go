@@ -0,0 +1,153 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package policy
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	apimodel "github.com/polarismesh/specification/source/go/api/v1/model"
+	apisecurity "github.com/polarismesh/specification/source/go/api/v1/security"
+	apiservice "github.com/polarismesh/specification/source/go/api/v1/service_manage"
+	"go.uber.org/zap"
+
+	"github.com/polarismesh/polaris/auth/evaluator"
+	api "github.com/polarismesh/polaris/common/api/v1"
+	"github.com/polarismesh/polaris/common/model"
+	commonstore "github.com/polarismesh/polaris/common/store"
+	"github.com/polarismesh/polaris/common/utils"
+)
+
+// auditorResourceTypes 审计只读策略授予读权限的资源类型
+var auditorResourceTypes = []apisecurity.ResourceType{
+	apisecurity.ResourceType_Namespaces,
+	apisecurity.ResourceType_Services,
+	apisecurity.ResourceType_ConfigGroups,
+}
+
+// handleCreateAuditorStrategy 为 principal 创建审计只读策略，已经存在时直接返回
+// 审计策略对所有资源类型的 * 授予只读权限，绑定了只读策略的 principal 不允许执行任何写操作
+func (svr *Server) handleCreateAuditorStrategy(ctx context.Context, principal model.Principal) *apiservice.Response {
+	requestID := utils.ParseRequestID(ctx)
+	strategy, err := svr.buildAuditorStrategy(principal)
+	if err != nil {
+		log.Error("[Auth][Strategy] build auditor strategy", utils.ZapRequestID(requestID),
+			zap.String("principal", principal.PrincipalID), zap.Error(err))
+		return api.NewAuthResponse(apimodel.Code_InvalidParameter)
+	}
+	existStrategies := svr.cacheMgr.AuthStrategy().GetStrategyDetailsByUID(principal.PrincipalID)
+	if principal.PrincipalRole == model.PrincipalGroup {
+		existStrategies = svr.cacheMgr.AuthStrategy().GetStrategyDetailsByGroupID(principal.PrincipalID)
+	}
+	for _, exist := range existStrategies {
+		if exist.IsReadOnly() && exist.Name == strategy.Name {
+			return api.NewAuthStrategyResponse(apimodel.Code_ExistedResource, svr.authStrategy2Api(exist))
+		}
+	}
+
+	if err := svr.storage.AddStrategy(strategy); err != nil {
+		log.Error("[Auth][Strategy] create auditor strategy into store", utils.ZapRequestID(requestID),
+			zap.String("principal", principal.PrincipalID), zap.Error(err))
+		return api.NewAuthResponse(commonstore.StoreCode2APICode(err))
+	}
+
+	log.Info("[Auth][Strategy] create auditor strategy", utils.ZapRequestID(requestID),
+		zap.String("name", strategy.Name), zap.String("principal", principal.PrincipalID))
+	svr.RecordHistory(&model.RecordEntry{
+		ResourceType:  model.RAuthStrategy,
+		ResourceName:  fmt.Sprintf("%s(%s)", strategy.Name, strategy.ID),
+		OperationType: model.OCreate,
+		Operator:      utils.ParseOperator(ctx),
+		ChangeSetID:   utils.ParseChangeSetID(ctx),
+		Detail:        utils.MustJson(strategy.Principals),
+		HappenTime:    time.Now(),
+	})
+	return api.NewAuthStrategyResponse(apimodel.Code_ExecuteSuccess, svr.authStrategy2Api(strategy))
+}
+
+// buildAuditorStrategy 构建 principal 的审计只读策略
+func (svr *Server) buildAuditorStrategy(principal model.Principal) (*model.StrategyDetail, error) {
+	strategy, err := svr.buildDefaultStrategy(principal)
+	if err != nil {
+		return nil, err
+	}
+	// principal 的存在性已经在构建默认策略时校验
+	var name string
+	if principal.PrincipalRole == model.PrincipalUser {
+		name = svr.cacheMgr.User().GetUserByID(principal.PrincipalID).Name
+	} else {
+		name = svr.cacheMgr.User().GetGroup(principal.PrincipalID).Name
+	}
+	strategy.Name = model.BuildAuditorStrategyName(principal.PrincipalRole, name)
+	strategy.Action = apisecurity.AuthAction_ONLY_READ.String()
+	strategy.Default = false
+	strategy.Comment = "Auditor Strategy"
+	for _, resType := range auditorResourceTypes {
+		strategy.Resources = append(strategy.Resources, model.StrategyResource{
+			StrategyID: strategy.ID,
+			ResType:    int32(resType),
+			ResID:      utils.MatchAll,
+		})
+	}
+	return strategy, nil
+}
+
+// principalStrategies principal 以及用户所属用户组关联的全部鉴权策略，包含通过角色获得的鉴权策略
+func (d *DefaultAuthChecker) principalStrategies(principal model.Principal) []*model.StrategyDetail {
+	strategyCache := d.cacheMgr.AuthStrategy()
+	var rules []*model.StrategyDetail
+	if principal.PrincipalRole == model.PrincipalUser {
+		rules = append(rules, strategyCache.GetStrategyDetailsByUID(principal.PrincipalID)...)
+		for _, groupId := range d.cacheMgr.User().GetUserLinkGroupIds(principal.PrincipalID) {
+			rules = append(rules, strategyCache.GetStrategyDetailsByGroupID(groupId)...)
+		}
+	} else {
+		rules = append(rules, strategyCache.GetStrategyDetailsByGroupID(principal.PrincipalID)...)
+	}
+	linked := make(map[string]struct{}, len(rules))
+	for i := range rules {
+		linked[rules[i].ID] = struct{}{}
+	}
+	return append(rules, d.roleStrategies(principal, linked)...)
+}
+
+// isReadOnlyPrincipal principal 是否绑定了只读策略，只读优先于其他策略授予的写权限，避免通过用户组获得写权限
+func (d *DefaultAuthChecker) isReadOnlyPrincipal(principal model.Principal) bool {
+	return evaluator.HasReadOnly(d.principalStrategies(principal))
+}
+
+// readOnlyMatch principal 绑定的只读策略是否匹配到该资源，只读策略仅对绑定的 principal 视为匹配
+func (d *DefaultAuthChecker) readOnlyMatch(principal model.Principal,
+	resType apisecurity.ResourceType, resId string) bool {
+	now := time.Now()
+	for _, rule := range d.principalStrategies(principal) {
+		if !rule.IsReadOnly() {
+			continue
+		}
+		for _, res := range rule.Resources {
+			if res.ResType != int32(resType) || res.IsExpired(now) {
+				continue
+			}
+			if d.conf.ResourceIDCanonical.Equal(res.ResID, resId) || res.ResID == utils.MatchAll {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,199 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package policy_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	apimodel "github.com/polarismesh/specification/source/go/api/v1/model"
+	apisecurity "github.com/polarismesh/specification/source/go/api/v1/security"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/polarismesh/polaris/auth"
+	"github.com/polarismesh/polaris/auth/policy"
+	defaultuser "github.com/polarismesh/polaris/auth/user"
+	"github.com/polarismesh/polaris/cache"
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/common/utils"
+)
+
+func Test_AuditorStrategy(t *testing.T) {
+	reset(true)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	users := createMockUser(10)
+	groups := createMockUserGroup(users)
+
+	namespaces := createMockNamespace(len(users)+len(groups)+10, users[0].ID)
+	services := createMockService(namespaces)
+	serviceMap := convertServiceSliceToMap(services)
+	strategies, _ := createMockStrategy(users, groups, services[:len(users)+len(groups)])
+
+	cfg, storage := initCache(ctrl)
+
+	storage.EXPECT().GetUsersForCache(gomock.Any(), gomock.Any()).AnyTimes().Return(users, nil)
+	storage.EXPECT().GetGroupsForCache(gomock.Any(), gomock.Any()).AnyTimes().Return(groups, nil)
+	storage.EXPECT().GetUserSessionsForCache(gomock.Any(), gomock.Any()).AnyTimes().Return(nil, nil)
+	storage.EXPECT().GetRolesForCache(gomock.Any(), gomock.Any()).AnyTimes().Return(nil, nil)
+	storage.EXPECT().GetStrategyDetailsForCache(gomock.Any(), gomock.Any()).AnyTimes().DoAndReturn(
+		func(_ interface{}, _ bool) ([]*model.StrategyDetail, error) {
+			return strategies, nil
+		})
+	storage.EXPECT().GetMoreNamespaces(gomock.Any()).AnyTimes().Return(namespaces, nil)
+	storage.EXPECT().GetMoreServices(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes().Return(serviceMap, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cacheMgr, err := cache.TestCacheInitialize(ctx, cfg, storage)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		cancel()
+		cacheMgr.Close()
+	})
+
+	_, proxySvr, err := defaultuser.BuildServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	proxySvr.Initialize(&auth.Config{
+		User: &auth.UserConfig{
+			Name: auth.DefaultUserMgnPluginName,
+			Option: map[string]interface{}{
+				"salt": "polarismesh@2021",
+			},
+		},
+	}, storage, cacheMgr)
+
+	_, svr, err := newPolicyServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := svr.Initialize(&auth.Config{
+		Strategy: &auth.StrategyConfig{
+			Name: auth.DefaultPolicyPluginName,
+		},
+	}, storage, cacheMgr, proxySvr); err != nil {
+		t.Fatal(err)
+	}
+	_ = cacheMgr.TestUpdate()
+
+	checker := svr.GetAuthChecker()
+	dchecker := checker.(*policy.DefaultAuthChecker)
+	oldConf := dchecker.GetConfig()
+	defer func() {
+		dchecker.SetConfig(oldConf)
+	}()
+
+	auditor := users[1]
+	ownerCtx := context.WithValue(context.Background(), utils.ContextAuthTokenKey, users[0].Token)
+	principal := model.Principal{PrincipalID: auditor.ID, PrincipalRole: model.PrincipalUser}
+
+	t.Run("创建审计策略", func(t *testing.T) {
+		storage.EXPECT().AddStrategy(gomock.Any()).Times(1).DoAndReturn(func(strategy *model.StrategyDetail) error {
+			assert.True(t, strategy.IsReadOnly())
+			assert.Equal(t, model.BuildAuditorStrategyName(model.PrincipalUser, auditor.Name), strategy.Name)
+			assert.Equal(t, []model.Principal{principal}, strategy.Principals)
+			assert.Equal(t, 3, len(strategy.Resources))
+			strategies = append(strategies, strategy)
+			return nil
+		})
+		rsp := svr.CreateAuditorStrategy(ownerCtx, principal)
+		assert.Equal(t, uint32(apimodel.Code_ExecuteSuccess), rsp.GetCode().GetValue(), rsp.GetInfo().GetValue())
+		_ = cacheMgr.TestUpdate()
+
+		// 重复创建直接返回已存在的策略
+		rsp = svr.CreateAuditorStrategy(ownerCtx, principal)
+		assert.Equal(t, uint32(apimodel.Code_ExistedResource), rsp.GetCode().GetValue(), rsp.GetInfo().GetValue())
+	})
+
+	t.Run("子账户不能创建审计策略", func(t *testing.T) {
+		subCtx := context.WithValue(context.Background(), utils.ContextAuthTokenKey, users[2].Token)
+		rsp := svr.CreateAuditorStrategy(subCtx, model.Principal{
+			PrincipalID: users[2].ID, PrincipalRole: model.PrincipalUser})
+		assert.NotEqual(t, uint32(apimodel.Code_ExecuteSuccess), rsp.GetCode().GetValue())
+	})
+
+	freeIndex := len(users) + len(groups) + 1
+	check := func(operator *model.User, op model.ResourceOperation, resType apisecurity.ResourceType,
+		id string) (bool, error) {
+		authCtx := model.NewAcquireContext(
+			model.WithRequestContext(context.WithValue(context.Background(), utils.ContextAuthTokenKey, operator.Token)),
+			model.WithMethod("Test_AuditorStrategy"),
+			model.WithOperation(op),
+			model.WithModule(model.DiscoverModule),
+			model.WithAccessResources(map[apisecurity.ResourceType][]model.ResourceEntry{
+				resType: {{ID: id}},
+			}),
+		)
+		return checker.CheckConsolePermission(authCtx)
+	}
+	resources := map[apisecurity.ResourceType][]string{
+		// 分别为没有关联策略、审计者自身的读写策略关联、其他 principal 的策略关联的资源
+		apisecurity.ResourceType_Services:     {services[freeIndex].ID, services[1].ID, services[2].ID},
+		apisecurity.ResourceType_Namespaces:   {namespaces[freeIndex].Name, services[1].Namespace},
+		apisecurity.ResourceType_ConfigGroups: {"1"},
+	}
+
+	for _, denyByDefault := range []bool{false, true} {
+		conf := &policy.AuthConfig{ConsoleOpen: true, ConsoleStrict: true}
+		if denyByDefault {
+			conf.DenyByDefaultTypes = []string{
+				apisecurity.ResourceType_Namespaces.String(),
+				apisecurity.ResourceType_Services.String(),
+				apisecurity.ResourceType_ConfigGroups.String(),
+			}
+		}
+		for resType, ids := range resources {
+			for _, id := range ids {
+				name := fmt.Sprintf("denyByDefault=%v %s %s", denyByDefault, resType.String(), id)
+				t.Run(name, func(t *testing.T) {
+					dchecker.SetConfig(conf)
+					pass, err := check(auditor, model.Read, resType, id)
+					assert.NoError(t, err)
+					assert.True(t, pass)
+
+					for _, op := range []model.ResourceOperation{model.Create, model.Modify, model.Delete} {
+						pass, err := check(auditor, op, resType, id)
+						assert.False(t, pass, op)
+						assert.Error(t, err)
+					}
+				})
+			}
+		}
+	}
+
+	t.Run("审计策略不影响其他 principal", func(t *testing.T) {
+		dchecker.SetConfig(&policy.AuthConfig{
+			ConsoleOpen:        true,
+			ConsoleStrict:      true,
+			DenyByDefaultTypes: []string{apisecurity.ResourceType_Services.String()},
+		})
+		// 审计策略关联的 * 不会使没有关联策略的资源对其他 principal 可见
+		_, err := check(users[2], model.Read, apisecurity.ResourceType_Services, services[freeIndex].ID)
+		assert.ErrorIs(t, err, policy.ErrorDenyByDefault)
+
+		pass, err := check(users[2], model.Modify, apisecurity.ResourceType_Services, services[2].ID)
+		assert.NoError(t, err)
+		assert.True(t, pass)
+	})
+}
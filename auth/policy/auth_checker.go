@@ -18,6 +18,8 @@
 package policy
 
 import (
+	"time"
+
 	"github.com/pkg/errors"
 	apisecurity "github.com/polarismesh/specification/source/go/api/v1/security"
 	"go.uber.org/zap"
@@ -25,8 +27,10 @@ import (
 	"github.com/polarismesh/polaris/auth"
 	cachetypes "github.com/polarismesh/polaris/cache/api"
 	api "github.com/polarismesh/polaris/common/api/v1"
+	"github.com/polarismesh/polaris/common/eventhub"
 	"github.com/polarismesh/polaris/common/model"
 	"github.com/polarismesh/polaris/common/utils"
+	"github.com/polarismesh/polaris/plugin"
 	"github.com/polarismesh/polaris/store"
 )
 
@@ -44,14 +48,62 @@ type DefaultAuthChecker struct {
 	conf     *AuthConfig
 	cacheMgr cachetypes.CacheManager
 	userSvr  auth.UserServer
+	// storage 按照资源属性匹配策略时，从存储中获取资源的属性
+	storage store.Store
+	// certResolver 根据 mTLS 客户端证书解析 principal
+	certResolver *certPrincipalResolver
+	// breakGlass 鉴权拒绝时，允许携带 break-glass token 的请求越过鉴权策略
+	breakGlass *breakGlass
+	// tracer 记录最近的鉴权决策，用于鉴权逻辑变更前的重放比对
+	tracer *decisionTracer
+	// decisions 按照策略集合版本缓存的鉴权决策
+	decisions *decisionCache
+	// subCtxs 策略、用户组以及服务缓存变化的订阅，用于清理旧版本的决策缓存条目
+	subCtxs []*eventhub.SubscribtionContext
+	// usage 异步记录资源关联关系的最近使用时间
+	usage *usageTracker
+	// pins 临时置顶的鉴权决策，优先于所有鉴权策略
+	pins *decisionPins
+	// async 异步鉴权的工作池
+	async *asyncCheckPool
+	// grace 资源关联关系过期后的宽限期放通
+	grace *expireGrace
+	// stats 统计鉴权决策的失败率，用于健康报告
+	stats *decisionStats
 }
 
 // Initialize 执行初始化动作
 func (d *DefaultAuthChecker) Initialize(conf *AuthConfig, s store.Store,
 	cacheMgr cachetypes.CacheManager, userSvr auth.UserServer) error {
 	d.conf = conf
+	d.storage = s
 	d.cacheMgr = cacheMgr
 	d.userSvr = userSvr
+	resolver, err := newCertPrincipalResolver(conf, cacheMgr.User())
+	if err != nil {
+		return err
+	}
+	d.certResolver = resolver
+	bg, err := newBreakGlass(conf, plugin.GetHistory())
+	if err != nil {
+		return err
+	}
+	d.breakGlass = bg
+	d.pins = newDecisionPins(plugin.GetHistory())
+	d.grace = newExpireGrace(plugin.GetHistory(), conf.ResourceIDCanonical)
+	d.stats = newDecisionStats()
+	d.tracer = newDecisionTracer(conf.DecisionTraceSize)
+	d.decisions = newDecisionCache(conf.DecisionCacheSize, conf.DecisionCacheTTLInSecs)
+	if err := checkDenyByDefaultTypes(conf.DenyByDefaultTypes); err != nil {
+		return err
+	}
+	usage, err := newUsageTracker(conf.LastUsedMode, conf.LastUsedWindowInSecs, s, d.resourceGrants)
+	if err != nil {
+		return err
+	}
+	d.usage = usage
+	d.async = newAsyncCheckPool(conf.AsyncCheckWorkers, conf.AsyncCheckQueueSize, d.checkBySource)
+	d.watchStrategyChange()
 	return nil
 }
 
@@ -98,7 +150,21 @@ func (d *DefaultAuthChecker) AllowResourceOperate(ctx *model.AcquireContext, opI
 		}(),
 	}
 
-	editable := d.cacheMgr.AuthStrategy().IsResourceEditable(principal, opInfo.ResourceType, opInfo.ResourceID)
+	if !d.pins.empty() {
+		if pin := d.pins.match(d.pinPrincipals(principal), opInfo.ResourceType, opInfo.ResourceID); pin != nil {
+			return pin.Allow
+		}
+	}
+	if d.matchNoStrategy(principal, opInfo.ResourceType, opInfo.ResourceID) {
+		return false
+	}
+	if d.isReadOnlyPrincipal(principal) {
+		return false
+	}
+	if excluded := d.unmatchedConditionStrategies(principal, ctx.GetRequestAttributes()); len(excluded) != 0 {
+		return d.computeConditionalEditable(principal, opInfo.ResourceType, opInfo.ResourceID, excluded)
+	}
+	editable := d.isLinkEditable(principal, opInfo.ResourceType, opInfo.ResourceID)
 	return editable
 }
 
@@ -147,6 +213,9 @@ func (d *DefaultAuthChecker) checkMaintainPermission(preCtx *model.AcquireContex
 	if tokenInfo.Disable {
 		return false, model.ErrorTokenDisabled
 	}
+	if err := d.checkTokenLifetime(tokenInfo, time.Now()); err != nil {
+		return false, err
+	}
 	if !tokenInfo.IsUserToken {
 		return false, errors.New("only user role can access maintain API")
 	}
@@ -158,14 +227,26 @@ func (d *DefaultAuthChecker) checkMaintainPermission(preCtx *model.AcquireContex
 
 // CheckPermission 执行检查动作判断是否有权限
 //
-//	step 1. 判断是否开启了鉴权
+//	step 1. 判断是否开启了鉴权，未携带 token 的读请求命中匿名读取的接口分组时跳过 token 检查，直接进入 step 4
 //	step 2. 对token进行检查判断
 //		case 1. 如果 token 被禁用
 //				a. 读操作，直接放通
 //				b. 写操作，快速失败
 //	step 3. 拉取token对应的操作者相关信息，注入到请求上下文中
-//	step 4. 进行权限检查
+//	step 4. 进行权限检查，优先级从高到低
+//		a. 命中置顶决策的资源，按照置顶决策放通或者拒绝
+//		b. 默认拒绝的资源类型下，资源没有被任何策略匹配时读写均拒绝，严格模式与匿名访问均不影响该结果
+//		c. 拒绝策略匹配到的资源，没有优先级更高的允许策略时读写均拒绝
+//		d. 读操作，直接放通
+//		e. 绑定了只读策略的 principal，写操作均拒绝
+//		f. 写操作，资源没有关联策略时放通，否则需要策略授予权限
+//	step 5. 权限检查未通过时，校验请求是否携带了合法的 break-glass token
 func (d *DefaultAuthChecker) CheckPermission(authCtx *model.AcquireContext) (bool, error) {
+	if d.isAnonymousReadable(authCtx) {
+		return d.checkAnonymousRead(authCtx)
+	}
+	d.stats.observe(time.Now())
+	d.injectCertPrincipal(authCtx)
 	if err := d.userSvr.CheckCredential(authCtx); err != nil {
 		return false, err
 	}
@@ -182,21 +263,55 @@ func (d *DefaultAuthChecker) CheckPermission(authCtx *model.AcquireContext) (boo
 	if operatorInfo.Disable {
 		return false, model.ErrorTokenDisabled
 	}
+	if err := d.checkTokenLifetime(operatorInfo, time.Now()); err != nil {
+		log.Error("[Auth][Checker] token lifetime exceeded", utils.RequestID(authCtx.GetRequestContext()),
+			zap.String("operator", operatorInfo.OperatorID), zap.Error(err))
+		return false, err
+	}
 
 	log.Debug("[Auth][Checker] check permission args", utils.RequestID(authCtx.GetRequestContext()),
 		zap.String("method", authCtx.GetMethod()), zap.Any("resources", authCtx.GetAccessResources()))
 
+	principalID, _ := authCtx.GetAttachments()[model.OperatorIDKey].(string)
+	principalType, _ := authCtx.GetAttachments()[model.OperatorPrincipalType].(model.PrincipalType)
+	if pass, pinned := d.checkPins(authCtx, model.Principal{
+		PrincipalID:   principalID,
+		PrincipalRole: principalType,
+	}); pinned {
+		d.tracer.record(authCtx, pass)
+		if !pass {
+			return false, ErrorDecisionPinDeny
+		}
+		return true, nil
+	}
+
 	if pass, _ := d.doCheckPermission(authCtx); pass {
+		d.tracer.record(authCtx, true)
 		return ok, nil
 	}
 
 	// 强制同步一次db中strategy数据到cache
 	if err := d.cacheMgr.AuthStrategy().ForceSync(); err != nil {
+		d.stats.fail(time.Now())
 		log.Error("[Auth][Checker] force sync strategy to cache failed",
 			utils.RequestID(authCtx.GetRequestContext()), zap.Error(err))
 		return false, err
 	}
-	return d.doCheckPermission(authCtx)
+	pass, err := d.doCheckPermission(authCtx)
+	d.tracer.record(authCtx, pass)
+	if !pass && d.breakGlass != nil && d.breakGlass.override(authCtx, operatorInfo) {
+		return true, nil
+	}
+	return pass, err
+}
+
+// evaluate 对已经确定 principal 的请求执行置顶决策以及策略检查，不包含身份校验、强制同步缓存以及 break-glass
+func (d *DefaultAuthChecker) evaluate(authCtx *model.AcquireContext, principal model.Principal) bool {
+	if pass, pinned := d.checkPins(authCtx, principal); pinned {
+		return pass
+	}
+	pass, _ := d.doCheckPermission(authCtx)
+	return pass
 }
 
 // doCheckPermission 执行权限检查
@@ -215,35 +330,96 @@ func (d *DefaultAuthChecker) doCheckPermission(authCtx *model.AcquireContext) (b
 		PrincipalID:   principleID,
 		PrincipalRole: principleType,
 	}
-	checkNamespace = d.checkAction(p, apisecurity.ResourceType_Namespaces, nsResEntries, authCtx)
-	checkSvc = d.checkAction(p, apisecurity.ResourceType_Services, svcResEntries, authCtx)
-	checkCfgGroup = d.checkAction(p, apisecurity.ResourceType_ConfigGroups, cfgResEntries, authCtx)
+	// 绑定了只读策略的 principal 不允许执行任何写操作
+	if authCtx.GetOperation() != model.Read && d.isReadOnlyPrincipal(p) {
+		return false, ErrorNotPermission
+	}
+	nsErr := d.checkAction(p, apisecurity.ResourceType_Namespaces, nsResEntries, authCtx)
+	svcErr := d.checkAction(p, apisecurity.ResourceType_Services, svcResEntries, authCtx)
+	cfgGroupErr := d.checkAction(p, apisecurity.ResourceType_ConfigGroups, cfgResEntries, authCtx)
+	checkNamespace, checkSvc, checkCfgGroup = nsErr == nil, svcErr == nil, cfgGroupErr == nil
 
 	checkAllResEntries := checkNamespace && checkSvc && checkCfgGroup
 
 	var err error
 	if !checkAllResEntries {
 		err = ErrorNotPermission
+		// 默认拒绝以及拒绝策略导致的失败需要返回明确的原因
+		for _, actionErr := range []error{nsErr, svcErr, cfgGroupErr} {
+			if errors.Is(actionErr, ErrorDenyByDefault) || errors.Is(actionErr, ErrorDenyByStrategy) {
+				err = actionErr
+			}
+		}
 	}
 	return checkAllResEntries, err
 }
 
+// checkTokenLifetime 检查 token 是否已经过期，token 的实际过期时间取自身的过期时间与
+// 签发时间加上 MaxTokenLifetimeInSecs 两者中较早的一个，没有签发时间的 token 不受最长有效期的限制
+func (d *DefaultAuthChecker) checkTokenLifetime(operator auth.OperatorInfo, now time.Time) error {
+	expireAt := operator.ExpireAt
+	if d.conf.MaxTokenLifetimeInSecs > 0 && !operator.IssuedAt.IsZero() {
+		limit := operator.IssuedAt.Add(time.Duration(d.conf.MaxTokenLifetimeInSecs) * time.Second)
+		if expireAt.IsZero() || limit.Before(expireAt) {
+			expireAt = limit
+		}
+	}
+	if !expireAt.IsZero() && !now.Before(expireAt) {
+		return model.ErrorTokenExpired
+	}
+	return nil
+}
+
 // checkAction 检查操作是否和策略匹配
+// 默认拒绝的资源类型下，没有被任何策略匹配的资源优先于读操作直接放通的逻辑，读写均拒绝
+// 命名空间管理员管辖的资源视为被策略授予了读写权限，拒绝策略仍然生效
+// 命中放通置顶决策的资源不再按照鉴权策略检查，请求携带的属性不满足策略的请求条件时，该策略不授予权限也不拒绝
+// 拒绝策略优先于读操作直接放通的逻辑以及其他允许策略，只有优先级更高的允许策略可以覆盖
+// 策略拒绝时，资源关联关系刚过期且仍在宽限期内的写操作放通
 func (d *DefaultAuthChecker) checkAction(principal model.Principal,
-	resType apisecurity.ResourceType, resources []model.ResourceEntry, ctx *model.AcquireContext) bool {
-	// TODO 后续可针对读写操作进行鉴权, 并且可以针对具体的方法调用进行鉴权控制
+	resType apisecurity.ResourceType, resources []model.ResourceEntry, ctx *model.AcquireContext) error {
+	unpinned := resources
+	if !d.pins.empty() {
+		unpinned = make([]model.ResourceEntry, 0, len(resources))
+		for _, entry := range resources {
+			if !d.isPinnedAllow(principal, resType, entry.ID) {
+				unpinned = append(unpinned, entry)
+			}
+		}
+	}
+	for _, entry := range unpinned {
+		if !d.isNamespaceAdminOf(principal, resType, entry.ID) && d.matchNoStrategy(principal, resType, entry.ID) {
+			return ErrorDenyByDefault
+		}
+	}
+	if len(unpinned) == 0 {
+		return nil
+	}
+
+	// 请求条件不满足、或者不对本次请求的接口生效的策略不参与鉴权
+	excluded := d.unmatchedFunctionStrategies(principal, ctx.GetMethod(),
+		d.unmatchedConditionStrategies(principal, ctx.GetRequestAttributes()))
+	for _, entry := range unpinned {
+		if d.isDenyByStrategy(principal, resType, entry.ID, excluded) {
+			return ErrorDenyByStrategy
+		}
+	}
 
 	switch ctx.GetOperation() {
 	case model.Read:
-		return true
+		return nil
 	default:
-		for _, entry := range resources {
-			if !d.cacheMgr.AuthStrategy().IsResourceEditable(principal, resType, entry.ID) {
-				return false
+		for _, entry := range unpinned {
+			if d.isNamespaceAdminOf(principal, resType, entry.ID) {
+				continue
+			}
+			if !d.isConditionalEditable(principal, resType, entry.ID, excluded) &&
+				!d.inExpireGrace(ctx, principal, resType, entry.ID, excluded) {
+				return ErrorNotPermission
 			}
 		}
 	}
-	return true
+	return nil
 }
 
 func (d *DefaultAuthChecker) SetCacheMgr(mgr cachetypes.CacheManager) {
@@ -19,6 +19,8 @@ package policy_test
 
 import (
 	"context"
+	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/golang/mock/gomock"
@@ -54,6 +56,8 @@ func Test_DefaultAuthChecker_CheckConsolePermission_Write_NoStrict(t *testing.T)
 
 	storage.EXPECT().GetUsersForCache(gomock.Any(), gomock.Any()).AnyTimes().Return(users, nil)
 	storage.EXPECT().GetGroupsForCache(gomock.Any(), gomock.Any()).AnyTimes().Return(groups, nil)
+	storage.EXPECT().GetUserSessionsForCache(gomock.Any(), gomock.Any()).AnyTimes().Return(nil, nil)
+	storage.EXPECT().GetRolesForCache(gomock.Any(), gomock.Any()).AnyTimes().Return(nil, nil)
 	storage.EXPECT().GetStrategyDetailsForCache(gomock.Any(), gomock.Any()).AnyTimes().Return(strategies, nil)
 	storage.EXPECT().GetMoreNamespaces(gomock.Any()).AnyTimes().Return(namespaces, nil)
 	storage.EXPECT().GetMoreServices(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes().Return(serviceMap, nil)
@@ -315,6 +319,8 @@ func Test_DefaultAuthChecker_CheckConsolePermission_Write_Strict(t *testing.T) {
 
 	storage.EXPECT().GetUsersForCache(gomock.Any(), gomock.Any()).AnyTimes().Return(users, nil)
 	storage.EXPECT().GetGroupsForCache(gomock.Any(), gomock.Any()).AnyTimes().Return(groups, nil)
+	storage.EXPECT().GetUserSessionsForCache(gomock.Any(), gomock.Any()).AnyTimes().Return(nil, nil)
+	storage.EXPECT().GetRolesForCache(gomock.Any(), gomock.Any()).AnyTimes().Return(nil, nil)
 	storage.EXPECT().GetStrategyDetailsForCache(gomock.Any(), gomock.Any()).AnyTimes().Return(strategies, nil)
 	storage.EXPECT().GetMoreNamespaces(gomock.Any()).AnyTimes().Return(namespaces, nil)
 	storage.EXPECT().GetMoreServices(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes().Return(serviceMap, nil)
@@ -554,6 +560,8 @@ func Test_DefaultAuthChecker_CheckConsolePermission_Read_NoStrict(t *testing.T)
 
 	storage.EXPECT().GetUsersForCache(gomock.Any(), gomock.Any()).AnyTimes().Return(users, nil)
 	storage.EXPECT().GetGroupsForCache(gomock.Any(), gomock.Any()).AnyTimes().Return(groups, nil)
+	storage.EXPECT().GetUserSessionsForCache(gomock.Any(), gomock.Any()).AnyTimes().Return(nil, nil)
+	storage.EXPECT().GetRolesForCache(gomock.Any(), gomock.Any()).AnyTimes().Return(nil, nil)
 	storage.EXPECT().GetStrategyDetailsForCache(gomock.Any(), gomock.Any()).AnyTimes().Return(strategies, nil)
 	storage.EXPECT().GetMoreNamespaces(gomock.Any()).AnyTimes().Return(namespaces, nil)
 	storage.EXPECT().GetMoreServices(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes().Return(serviceMap, nil)
@@ -792,6 +800,8 @@ func Test_DefaultAuthChecker_CheckConsolePermission_Read_Strict(t *testing.T) {
 
 	storage.EXPECT().GetUsersForCache(gomock.Any(), gomock.Any()).AnyTimes().Return(users, nil)
 	storage.EXPECT().GetGroupsForCache(gomock.Any(), gomock.Any()).AnyTimes().Return(groups, nil)
+	storage.EXPECT().GetUserSessionsForCache(gomock.Any(), gomock.Any()).AnyTimes().Return(nil, nil)
+	storage.EXPECT().GetRolesForCache(gomock.Any(), gomock.Any()).AnyTimes().Return(nil, nil)
 	storage.EXPECT().GetStrategyDetailsForCache(gomock.Any(), gomock.Any()).AnyTimes().Return(strategies, nil)
 	storage.EXPECT().GetMoreNamespaces(gomock.Any()).AnyTimes().Return(namespaces, nil)
 	storage.EXPECT().GetMoreServices(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes().Return(serviceMap, nil)
@@ -1023,6 +1033,188 @@ func Test_DefaultAuthChecker_CheckConsolePermission_Read_Strict(t *testing.T) {
 	})
 }
 
+func Test_DefaultAuthChecker_CheckConsolePermission_DenyByDefault(t *testing.T) {
+	reset(true)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	users := createMockUser(10)
+	groups := createMockUserGroup(users)
+
+	namespaces := createMockNamespace(len(users)+len(groups)+10, users[0].ID)
+	services := createMockService(namespaces)
+	serviceMap := convertServiceSliceToMap(services)
+	strategies, _ := createMockStrategy(users, groups, services[:len(users)+len(groups)])
+
+	cfg, storage := initCache(ctrl)
+
+	storage.EXPECT().GetUsersForCache(gomock.Any(), gomock.Any()).AnyTimes().Return(users, nil)
+	storage.EXPECT().GetGroupsForCache(gomock.Any(), gomock.Any()).AnyTimes().Return(groups, nil)
+	storage.EXPECT().GetUserSessionsForCache(gomock.Any(), gomock.Any()).AnyTimes().Return(nil, nil)
+	storage.EXPECT().GetRolesForCache(gomock.Any(), gomock.Any()).AnyTimes().Return(nil, nil)
+	storage.EXPECT().GetStrategyDetailsForCache(gomock.Any(), gomock.Any()).AnyTimes().Return(strategies, nil)
+	storage.EXPECT().GetMoreNamespaces(gomock.Any()).AnyTimes().Return(namespaces, nil)
+	storage.EXPECT().GetMoreServices(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes().Return(serviceMap, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cacheMgr, err := cache.TestCacheInitialize(ctx, cfg, storage)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		cancel()
+		cacheMgr.Close()
+	})
+
+	_, proxySvr, err := defaultuser.BuildServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	proxySvr.Initialize(&auth.Config{
+		User: &auth.UserConfig{
+			Name: auth.DefaultUserMgnPluginName,
+			Option: map[string]interface{}{
+				"salt": "polarismesh@2021",
+			},
+		},
+	}, storage, cacheMgr)
+
+	_, svr, err := newPolicyServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := svr.Initialize(&auth.Config{
+		Strategy: &auth.StrategyConfig{
+			Name: auth.DefaultPolicyPluginName,
+		},
+	}, storage, cacheMgr, proxySvr); err != nil {
+		t.Fatal(err)
+	}
+	checker := svr.GetAuthChecker()
+	dchecker := checker.(*policy.DefaultAuthChecker)
+	oldConf := dchecker.GetConfig()
+	defer func() {
+		dchecker.SetConfig(oldConf)
+	}()
+
+	_ = cacheMgr.TestUpdate()
+
+	freeIndex := len(users) + len(groups) + 1
+
+	// 默认拒绝 × 严格模式 × 是否携带 token 的组合下，访问没有关联任何策略的服务
+	type denyCase struct {
+		denyByDefault bool
+		strict        bool
+		withToken     bool
+		expectErr     error
+	}
+	cases := []denyCase{
+		{denyByDefault: false, strict: false, withToken: true},
+		{denyByDefault: false, strict: false, withToken: false},
+		{denyByDefault: false, strict: true, withToken: true},
+		{denyByDefault: false, strict: true, withToken: false, expectErr: model.ErrorTokenInvalid},
+		{denyByDefault: true, strict: false, withToken: true, expectErr: policy.ErrorDenyByDefault},
+		{denyByDefault: true, strict: false, withToken: false, expectErr: policy.ErrorDenyByDefault},
+		{denyByDefault: true, strict: true, withToken: true, expectErr: policy.ErrorDenyByDefault},
+		// 严格模式下 token 校验先于默认拒绝
+		{denyByDefault: true, strict: true, withToken: false, expectErr: model.ErrorTokenInvalid},
+	}
+	for _, item := range cases {
+		for _, op := range []model.ResourceOperation{model.Read, model.Modify} {
+			name := fmt.Sprintf("denyByDefault=%v strict=%v token=%v op=%v", item.denyByDefault, item.strict,
+				item.withToken, op)
+			t.Run(name, func(t *testing.T) {
+				conf := &policy.AuthConfig{
+					ConsoleOpen:   true,
+					ConsoleStrict: item.strict,
+				}
+				if item.denyByDefault {
+					conf.DenyByDefaultTypes = []string{apisecurity.ResourceType_Services.String()}
+				}
+				dchecker.SetConfig(conf)
+
+				token := ""
+				if item.withToken {
+					token = users[1].Token
+				}
+				authCtx := model.NewAcquireContext(
+					model.WithRequestContext(context.WithValue(context.Background(), utils.ContextAuthTokenKey, token)),
+					model.WithMethod("Test_DefaultAuthChecker_CheckConsolePermission_DenyByDefault"),
+					model.WithOperation(op),
+					model.WithModule(model.DiscoverModule),
+					model.WithAccessResources(map[apisecurity.ResourceType][]model.ResourceEntry{
+						apisecurity.ResourceType_Services: {
+							{
+								ID:    services[freeIndex].ID,
+								Owner: services[freeIndex].Owner,
+							},
+						},
+					}),
+				)
+				pass, err := checker.CheckConsolePermission(authCtx)
+				if item.expectErr == nil {
+					assert.NoError(t, err)
+					assert.True(t, pass)
+					return
+				}
+				assert.ErrorIs(t, err, item.expectErr)
+				assert.False(t, pass)
+			})
+		}
+	}
+
+	t.Run("默认拒绝的资源类型-资源关联了策略时按照策略判断", func(t *testing.T) {
+		dchecker.SetConfig(&policy.AuthConfig{
+			ConsoleOpen:        true,
+			DenyByDefaultTypes: []string{apisecurity.ResourceType_Services.String()},
+		})
+		authCtx := model.NewAcquireContext(
+			model.WithRequestContext(context.WithValue(context.Background(), utils.ContextAuthTokenKey, users[1].Token)),
+			model.WithMethod("Test_DefaultAuthChecker_CheckConsolePermission_DenyByDefault"),
+			model.WithOperation(model.Modify),
+			model.WithModule(model.DiscoverModule),
+			model.WithAccessResources(map[apisecurity.ResourceType][]model.ResourceEntry{
+				apisecurity.ResourceType_Services: {
+					{
+						ID:    services[1].ID,
+						Owner: services[1].Owner,
+					},
+				},
+			}),
+		)
+		_, err := checker.CheckConsolePermission(authCtx)
+		assert.NoError(t, err)
+	})
+
+	t.Run("默认拒绝的资源类型-匿名读取的接口分组同样生效", func(t *testing.T) {
+		dchecker.SetConfig(&policy.AuthConfig{
+			ConsoleOpen:          true,
+			ConsoleStrict:        true,
+			AnonymousReadMethods: []string{"Test_DefaultAuthChecker"},
+			DenyByDefaultTypes:   []string{apisecurity.ResourceType_Services.String()},
+		})
+		newCtx := func(svc *model.Service) *model.AcquireContext {
+			return model.NewAcquireContext(
+				model.WithRequestContext(context.Background()),
+				model.WithMethod("Test_DefaultAuthChecker_CheckConsolePermission_DenyByDefault"),
+				model.WithOperation(model.Read),
+				model.WithModule(model.DiscoverModule),
+				model.WithAccessResources(map[apisecurity.ResourceType][]model.ResourceEntry{
+					apisecurity.ResourceType_Services: {{ID: svc.ID, Owner: svc.Owner}},
+				}),
+			)
+		}
+		pass, err := checker.CheckConsolePermission(newCtx(services[freeIndex]))
+		assert.ErrorIs(t, err, policy.ErrorDenyByDefault)
+		assert.False(t, pass)
+
+		pass, err = checker.CheckConsolePermission(newCtx(services[1]))
+		assert.NoError(t, err)
+		assert.True(t, pass)
+	})
+}
+
 func Test_DefaultAuthChecker_Initialize(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -1105,3 +1297,45 @@ func Test_DefaultAuthChecker_Initialize(t *testing.T) {
 	})
 
 }
+
+func Test_DefaultAuthChecker_ResourceIDCanonical(t *testing.T) {
+	strategyTest := newStrategyTest(t)
+	defer strategyTest.Clean()
+
+	err := strategyTest.svr.Initialize(&auth.Config{
+		Strategy: &auth.StrategyConfig{
+			Name: auth.DefaultPolicyPluginName,
+			Option: map[string]interface{}{
+				"resourceIdCanonical": map[string]interface{}{
+					"trim":     true,
+					"caseFold": true,
+				},
+			},
+		},
+	}, strategyTest.storage, strategyTest.cacheMgn, strategyTest.userSvr)
+	assert.NoError(t, err)
+	_ = strategyTest.cacheMgn.TestUpdate()
+
+	checker := strategyTest.policySvr.GetAuthChecker()
+	target := strategyTest.services[1]
+	checkModify := func(operator *model.User, resID string) bool {
+		authCtx := model.NewAcquireContext(
+			model.WithRequestContext(context.WithValue(context.Background(), utils.ContextAuthTokenKey,
+				operator.Token)),
+			model.WithMethod("Test_DefaultAuthChecker_ResourceIDCanonical"),
+			model.WithOperation(model.Modify),
+			model.WithModule(model.DiscoverModule),
+			model.WithAccessResources(map[apisecurity.ResourceType][]model.ResourceEntry{
+				apisecurity.ResourceType_Services: {{ID: resID, Owner: target.Owner}},
+			}),
+		)
+		pass, _ := checker.CheckConsolePermission(authCtx)
+		return pass
+	}
+
+	// 请求中的服务 ID 与策略中的服务 ID 只在大小写以及首尾空白、末尾分隔符上不同
+	for _, resID := range []string{target.ID, strings.ToUpper(target.ID), " " + target.ID + "/"} {
+		assert.True(t, checkModify(strategyTest.users[1], resID), resID)
+		assert.False(t, checkModify(strategyTest.users[2], resID), resID)
+	}
+}
@@ -0,0 +1,208 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package policy
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/polarismesh/polaris/auth"
+	"github.com/polarismesh/polaris/common/metrics"
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/common/utils"
+	"github.com/polarismesh/polaris/plugin"
+)
+
+var (
+	// ErrorBreakGlassTokenInvalid break-glass token 签名不合法
+	ErrorBreakGlassTokenInvalid = errors.New("invalid break-glass token")
+	// ErrorBreakGlassTokenExpired break-glass token 已过期
+	ErrorBreakGlassTokenExpired = errors.New("break-glass token expired")
+	// ErrorBreakGlassTokenMismatch break-glass token 签发的操作者与当前操作者不一致
+	ErrorBreakGlassTokenMismatch = errors.New("break-glass token not issued to current operator")
+	// ErrorBreakGlassKindDenied 当前操作者的身份类别不允许使用 break-glass token
+	ErrorBreakGlassKindDenied = errors.New("break-glass token not allowed for principal kind")
+	// ErrorBreakGlassLifetimeExceeded break-glass token 的有效期超出了允许的最长有效期
+	ErrorBreakGlassLifetimeExceeded = errors.New("break-glass token lifetime exceeds max lifetime")
+	// ErrorBreakGlassNoHistory 没有可用的操作记录插件，break-glass 的使用无法被审计
+	ErrorBreakGlassNoHistory = errors.New("break-glass requires history plugin to record usage")
+)
+
+// defaultBreakGlassMaxLifetime break-glass token 默认的最长有效期
+const defaultBreakGlassMaxLifetime = time.Hour
+
+// BreakGlassClaims break-glass token 携带的信息
+type BreakGlassClaims struct {
+	// OperatorID 允许使用该 token 的用户/用户组 ID
+	OperatorID string `json:"operator"`
+	// Reason 签发原因，会记录到操作记录中
+	Reason string `json:"reason"`
+	// IssuedAt token 的签发时间, unix 秒, 未设置时按照校验时间计算有效期
+	IssuedAt int64 `json:"issued,omitempty"`
+	// ExpireAt token 的过期时间, unix 秒
+	ExpireAt int64 `json:"expire"`
+}
+
+// SignBreakGlassToken 使用 secret 签发 break-glass token, 格式为 base64(claims).base64(hmac-sha256)
+func SignBreakGlassToken(secret string, claims BreakGlassClaims) (string, error) {
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + signBreakGlassPayload(secret, encoded), nil
+}
+
+func signBreakGlassPayload(secret, encoded string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	_, _ = mac.Write([]byte(encoded))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// breakGlass 紧急情况下越过鉴权策略的通道，每次使用都会记录高危操作记录以及上报指标
+type breakGlass struct {
+	secret      string
+	denyKinds   map[model.PrincipalKind]struct{}
+	maxLifetime time.Duration
+	history     plugin.History
+	now         func() time.Time
+}
+
+// newBreakGlass 创建 break-glass 校验器, 未开启时返回 nil
+func newBreakGlass(options *AuthConfig, history plugin.History) (*breakGlass, error) {
+	if !options.BreakGlassOpen {
+		return nil, nil
+	}
+	if options.BreakGlassSecret == "" {
+		return nil, errors.New("[Auth][BreakGlass] breakGlassSecret must be set when breakGlassOpen")
+	}
+	denyKinds := make(map[model.PrincipalKind]struct{}, len(options.BreakGlassDenyKinds))
+	for _, kind := range options.BreakGlassDenyKinds {
+		switch model.PrincipalKind(kind) {
+		case model.PrincipalKindHuman, model.PrincipalKindService, model.PrincipalKindUnknown:
+			denyKinds[model.PrincipalKind(kind)] = struct{}{}
+		default:
+			return nil, fmt.Errorf("[Auth][BreakGlass] unsupported principal kind: %s", kind)
+		}
+	}
+	maxLifetime := time.Duration(options.BreakGlassMaxLifetimeInSecs) * time.Second
+	if maxLifetime <= 0 {
+		maxLifetime = defaultBreakGlassMaxLifetime
+	}
+	return &breakGlass{
+		secret:      options.BreakGlassSecret,
+		denyKinds:   denyKinds,
+		maxLifetime: maxLifetime,
+		history:     history,
+		now:         time.Now,
+	}, nil
+}
+
+// verify 校验 break-glass token 的签名、有效期以及签发对象
+// 有效期从签发时间开始计算，没有签发时间或者签发时间晚于当前时间时从当前时间开始计算
+func (b *breakGlass) verify(token, operatorID string) (*BreakGlassClaims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 2 {
+		return nil, ErrorBreakGlassTokenInvalid
+	}
+	expect := signBreakGlassPayload(b.secret, parts[0])
+	if !hmac.Equal([]byte(expect), []byte(parts[1])) {
+		return nil, ErrorBreakGlassTokenInvalid
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, ErrorBreakGlassTokenInvalid
+	}
+	claims := &BreakGlassClaims{}
+	if err := json.Unmarshal(payload, claims); err != nil {
+		return nil, ErrorBreakGlassTokenInvalid
+	}
+	now := b.now().Unix()
+	if now >= claims.ExpireAt {
+		return nil, ErrorBreakGlassTokenExpired
+	}
+	issuedAt := claims.IssuedAt
+	if issuedAt <= 0 || issuedAt > now {
+		issuedAt = now
+	}
+	if time.Duration(claims.ExpireAt-issuedAt)*time.Second > b.maxLifetime {
+		return nil, ErrorBreakGlassLifetimeExceeded
+	}
+	if claims.OperatorID != operatorID {
+		return nil, ErrorBreakGlassTokenMismatch
+	}
+	return claims, nil
+}
+
+// checkKind 未解析出身份类别的操作者按照 Unknown 处理
+func (b *breakGlass) checkKind(kind model.PrincipalKind) error {
+	if kind == "" {
+		kind = model.PrincipalKindUnknown
+	}
+	if _, ok := b.denyKinds[kind]; ok {
+		return fmt.Errorf("%w: %s", ErrorBreakGlassKindDenied, kind)
+	}
+	return nil
+}
+
+// override 鉴权策略拒绝时，判断请求是否携带了合法的 break-glass token
+func (b *breakGlass) override(authCtx *model.AcquireContext, operator auth.OperatorInfo) bool {
+	ctx := authCtx.GetRequestContext()
+	token := utils.ParseBreakGlassToken(ctx)
+	if token == "" {
+		return false
+	}
+	claims, err := b.verify(token, operator.OperatorID)
+	if err == nil {
+		err = b.checkKind(operator.Kind)
+	}
+	// 每次使用 break-glass 都必须留下操作记录，无法记录时拒绝越过鉴权
+	if err == nil && b.history == nil {
+		err = ErrorBreakGlassNoHistory
+	}
+	if err != nil {
+		metrics.ReportBreakGlassUse(false)
+		log.Error("[Auth][BreakGlass] reject break-glass token", utils.RequestID(ctx),
+			zap.String("operator", operator.OperatorID), zap.String("method", authCtx.GetMethod()), zap.Error(err))
+		return false
+	}
+
+	metrics.ReportBreakGlassUse(true)
+	log.Error("[Auth][BreakGlass] allow operation by break-glass token", utils.RequestID(ctx),
+		zap.String("operator", operator.OperatorID), zap.String("method", authCtx.GetMethod()),
+		zap.String("reason", claims.Reason), zap.Any("resources", authCtx.GetAccessResources()))
+	b.history.Record(&model.RecordEntry{
+		ResourceType:  model.RAuthStrategy,
+		ResourceName:  authCtx.GetMethod(),
+		Operator:      utils.ParseOperator(ctx),
+		ChangeSetID:   utils.ParseChangeSetID(ctx),
+		OperationType: model.OBreakGlass,
+		Detail: fmt.Sprintf("operator=%s reason=%s resources=%s", operator.OperatorID, claims.Reason,
+			utils.MustJson(authCtx.GetAccessResources())),
+		HappenTime: b.now(),
+	})
+	return true
+}
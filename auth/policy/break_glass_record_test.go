@@ -0,0 +1,186 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package policy
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/polarismesh/polaris/auth"
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/common/utils"
+	"github.com/polarismesh/polaris/plugin"
+)
+
+type recordCollector struct {
+	entries []*model.RecordEntry
+}
+
+func (r *recordCollector) Name() string {
+	return "recordCollector"
+}
+
+func (r *recordCollector) Initialize(c *plugin.ConfigEntry) error {
+	return nil
+}
+
+func (r *recordCollector) Destroy() error {
+	return nil
+}
+
+func (r *recordCollector) Record(entry *model.RecordEntry) {
+	r.entries = append(r.entries, entry)
+}
+
+func Test_breakGlassRecord(t *testing.T) {
+	history := &recordCollector{}
+	bg, err := newBreakGlass(&AuthConfig{BreakGlassOpen: true, BreakGlassSecret: "secret"}, history)
+	assert.NoError(t, err)
+
+	operator := auth.OperatorInfo{OperatorID: "user-1"}
+	newAuthCtx := func(token string) *model.AcquireContext {
+		ctx := context.WithValue(context.Background(), utils.ContextBreakGlassTokenKey, token)
+		return model.NewAcquireContext(
+			model.WithRequestContext(ctx),
+			model.WithMethod("Test_breakGlassRecord"),
+			model.WithOperation(model.Modify),
+		)
+	}
+
+	token, err := SignBreakGlassToken("secret", BreakGlassClaims{
+		OperatorID: operator.OperatorID,
+		Reason:     "incident-1",
+		ExpireAt:   time.Now().Add(time.Hour).Unix(),
+	})
+	assert.NoError(t, err)
+
+	// 每次使用都需要生成操作记录
+	for i := 0; i < 2; i++ {
+		assert.True(t, bg.override(newAuthCtx(token), operator))
+	}
+	assert.Equal(t, 2, len(history.entries))
+	for _, entry := range history.entries {
+		assert.Equal(t, model.OBreakGlass, entry.OperationType)
+		assert.Contains(t, entry.Detail, "incident-1")
+	}
+
+	// 校验失败时不会放通，也不会生成放通的操作记录
+	assert.False(t, bg.override(newAuthCtx(token+"x"), operator))
+	assert.Equal(t, 2, len(history.entries))
+
+	bg.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	_, err = bg.verify(token, operator.OperatorID)
+	assert.Equal(t, ErrorBreakGlassTokenExpired, err)
+}
+
+func Test_breakGlassMaxLifetime(t *testing.T) {
+	bg, err := newBreakGlass(&AuthConfig{BreakGlassOpen: true, BreakGlassSecret: "secret"}, &recordCollector{})
+	assert.NoError(t, err)
+	assert.Equal(t, defaultBreakGlassMaxLifetime, bg.maxLifetime)
+
+	now := time.Now()
+	sign := func(issuedAt, expireAt time.Time) string {
+		claims := BreakGlassClaims{OperatorID: "user-1", ExpireAt: expireAt.Unix()}
+		if !issuedAt.IsZero() {
+			claims.IssuedAt = issuedAt.Unix()
+		}
+		token, err := SignBreakGlassToken("secret", claims)
+		assert.NoError(t, err)
+		return token
+	}
+
+	// 签发时间开始计算的有效期超出最长有效期，即使尚未过期也不生效
+	_, err = bg.verify(sign(now.Add(-time.Hour), now.Add(time.Minute)), "user-1")
+	assert.Equal(t, ErrorBreakGlassLifetimeExceeded, err)
+	// 没有签发时间以及签发时间晚于当前时间时，从当前时间开始计算
+	_, err = bg.verify(sign(time.Time{}, now.Add(2*time.Hour)), "user-1")
+	assert.Equal(t, ErrorBreakGlassLifetimeExceeded, err)
+	_, err = bg.verify(sign(now.Add(time.Hour), now.Add(2*time.Hour)), "user-1")
+	assert.Equal(t, ErrorBreakGlassLifetimeExceeded, err)
+	_, err = bg.verify(sign(now.Add(-time.Minute), now.Add(30*time.Minute)), "user-1")
+	assert.NoError(t, err)
+
+	bg, err = newBreakGlass(&AuthConfig{BreakGlassOpen: true, BreakGlassSecret: "secret",
+		BreakGlassMaxLifetimeInSecs: 4 * 3600}, &recordCollector{})
+	assert.NoError(t, err)
+	_, err = bg.verify(sign(now.Add(-time.Hour), now.Add(time.Minute)), "user-1")
+	assert.NoError(t, err)
+}
+
+func Test_breakGlassWithoutHistory(t *testing.T) {
+	bg, err := newBreakGlass(&AuthConfig{BreakGlassOpen: true, BreakGlassSecret: "secret"}, nil)
+	assert.NoError(t, err)
+
+	operator := auth.OperatorInfo{OperatorID: "user-1"}
+	token, err := SignBreakGlassToken("secret", BreakGlassClaims{
+		OperatorID: operator.OperatorID,
+		ExpireAt:   time.Now().Add(time.Minute).Unix(),
+	})
+	assert.NoError(t, err)
+	ctx := context.WithValue(context.Background(), utils.ContextBreakGlassTokenKey, token)
+	authCtx := model.NewAcquireContext(model.WithRequestContext(ctx), model.WithOperation(model.Modify))
+	// 无法留下操作记录时不允许越过鉴权
+	assert.False(t, bg.override(authCtx, operator))
+}
+
+func Test_changeSetRecord(t *testing.T) {
+	history := &recordCollector{}
+	bg, err := newBreakGlass(&AuthConfig{BreakGlassOpen: true, BreakGlassSecret: "secret"}, history)
+	assert.NoError(t, err)
+
+	operator := auth.OperatorInfo{OperatorID: "user-1"}
+	token, err := SignBreakGlassToken("secret", BreakGlassClaims{
+		OperatorID: operator.OperatorID,
+		Reason:     "incident-1",
+		ExpireAt:   time.Now().Add(time.Hour).Unix(),
+	})
+	assert.NoError(t, err)
+	newAuthCtx := func(ctx context.Context) *model.AcquireContext {
+		return model.NewAcquireContext(
+			model.WithRequestContext(context.WithValue(ctx, utils.ContextBreakGlassTokenKey, token)),
+			model.WithMethod("Test_changeSetRecord"),
+			model.WithOperation(model.Modify),
+		)
+	}
+
+	// 同一次操作产生的操作记录属于同一个变更集
+	first := newAuthCtx(context.Background())
+	assert.NotEmpty(t, first.GetChangeSetID())
+	assert.True(t, bg.override(first, operator))
+	assert.True(t, bg.override(first, operator))
+	// 不同的操作属于不同的变更集
+	second := newAuthCtx(context.Background())
+	assert.True(t, bg.override(second, operator))
+	// 请求已经携带的变更集 ID 保持不变
+	third := newAuthCtx(context.WithValue(context.Background(), utils.ContextChangeSetIDKey, "change-set-1"))
+	assert.True(t, bg.override(third, operator))
+
+	assert.Equal(t, 4, len(history.entries))
+	assert.Equal(t, first.GetChangeSetID(), history.entries[0].ChangeSetID)
+	assert.Equal(t, first.GetChangeSetID(), history.entries[1].ChangeSetID)
+	assert.Equal(t, second.GetChangeSetID(), history.entries[2].ChangeSetID)
+	assert.NotEqual(t, first.GetChangeSetID(), second.GetChangeSetID())
+	assert.Equal(t, "change-set-1", history.entries[3].ChangeSetID)
+
+	// 读操作不生成变更集
+	readCtx := model.NewAcquireContext(model.WithRequestContext(context.Background()), model.WithOperation(model.Read))
+	assert.Empty(t, readCtx.GetChangeSetID())
+}
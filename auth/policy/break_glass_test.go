@@ -0,0 +1,173 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package policy_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	apisecurity "github.com/polarismesh/specification/source/go/api/v1/security"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/polarismesh/polaris/auth"
+	"github.com/polarismesh/polaris/auth/policy"
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/common/utils"
+)
+
+func Test_BreakGlass(t *testing.T) {
+	strategyTest := newStrategyTest(t)
+	defer strategyTest.Clean()
+
+	const secret = "break-glass-secret"
+	initWithOptions := func(options map[string]interface{}) error {
+		err := strategyTest.svr.Initialize(&auth.Config{
+			Strategy: &auth.StrategyConfig{
+				Name:   auth.DefaultPolicyPluginName,
+				Option: options,
+			},
+		}, strategyTest.storage, strategyTest.cacheMgn, strategyTest.userSvr)
+		_ = strategyTest.cacheMgn.TestUpdate()
+		return err
+	}
+
+	// 子账户修改不属于自己的服务, 正常情况下会被拒绝
+	operator := strategyTest.users[1]
+	target := strategyTest.services[2]
+	checkModify := func(breakGlassToken string) (bool, error) {
+		ctx := context.WithValue(context.Background(), utils.ContextAuthTokenKey, operator.Token)
+		if breakGlassToken != "" {
+			ctx = context.WithValue(ctx, utils.ContextBreakGlassTokenKey, breakGlassToken)
+		}
+		authCtx := model.NewAcquireContext(
+			model.WithRequestContext(ctx),
+			model.WithMethod("Test_BreakGlass"),
+			model.WithOperation(model.Modify),
+			model.WithModule(model.DiscoverModule),
+			model.WithAccessResources(map[apisecurity.ResourceType][]model.ResourceEntry{
+				apisecurity.ResourceType_Services: {{ID: target.ID, Owner: target.Owner}},
+			}),
+		)
+		return strategyTest.policySvr.GetAuthChecker().CheckConsolePermission(authCtx)
+	}
+	sign := func(claims policy.BreakGlassClaims) string {
+		token, err := policy.SignBreakGlassToken(secret, claims)
+		assert.NoError(t, err)
+		return token
+	}
+	validClaims := policy.BreakGlassClaims{
+		OperatorID: operator.ID,
+		Reason:     "incident",
+		ExpireAt:   time.Now().Add(time.Hour).Unix(),
+	}
+
+	t.Run("合法的 token 越过鉴权拒绝", func(t *testing.T) {
+		assert.NoError(t, initWithOptions(map[string]interface{}{
+			"breakGlassOpen":   true,
+			"breakGlassSecret": secret,
+		}))
+
+		pass, _ := checkModify("")
+		assert.False(t, pass)
+
+		pass, err := checkModify(sign(validClaims))
+		assert.NoError(t, err)
+		assert.True(t, pass)
+	})
+
+	t.Run("不合法的 token 不生效", func(t *testing.T) {
+		assert.NoError(t, initWithOptions(map[string]interface{}{
+			"breakGlassOpen":   true,
+			"breakGlassSecret": secret,
+		}))
+
+		expired := validClaims
+		expired.ExpireAt = time.Now().Add(-time.Minute).Unix()
+		pass, _ := checkModify(sign(expired))
+		assert.False(t, pass)
+
+		other := validClaims
+		other.OperatorID = strategyTest.users[2].ID
+		pass, _ = checkModify(sign(other))
+		assert.False(t, pass)
+
+		forged, err := policy.SignBreakGlassToken("other-secret", validClaims)
+		assert.NoError(t, err)
+		pass, _ = checkModify(forged)
+		assert.False(t, pass)
+	})
+
+	t.Run("按照身份类别限制 break-glass", func(t *testing.T) {
+		initUserWithSources := func(key string) {
+			assert.NoError(t, strategyTest.userSvr.Initialize(&auth.Config{
+				User: &auth.UserConfig{
+					Name: auth.DefaultUserMgnPluginName,
+					Option: map[string]interface{}{
+						"salt": "polarismesh@2021",
+						key:    []string{operator.Source},
+					},
+				},
+			}, strategyTest.storage, strategyTest.cacheMgn))
+			_ = strategyTest.cacheMgn.TestUpdate()
+		}
+		defer initUserWithSources("humanSources")
+		assert.NoError(t, initWithOptions(map[string]interface{}{
+			"breakGlassOpen":      true,
+			"breakGlassSecret":    secret,
+			"breakGlassDenyKinds": []string{string(model.PrincipalKindService)},
+		}))
+
+		// 服务身份不允许使用 break-glass
+		initUserWithSources("serviceSources")
+		pass, _ := checkModify(sign(validClaims))
+		assert.False(t, pass)
+
+		// 人员身份不受影响
+		initUserWithSources("humanSources")
+		pass, err := checkModify(sign(validClaims))
+		assert.NoError(t, err)
+		assert.True(t, pass)
+
+		// 未配置来源的存量用户按照 Unknown 处理，与原有逻辑一致
+		initUserWithSources("otherSources")
+		pass, err = checkModify(sign(validClaims))
+		assert.NoError(t, err)
+		assert.True(t, pass)
+	})
+
+	t.Run("不支持的身份类别", func(t *testing.T) {
+		assert.Error(t, initWithOptions(map[string]interface{}{
+			"breakGlassOpen":      true,
+			"breakGlassSecret":    secret,
+			"breakGlassDenyKinds": []string{"Robot"},
+		}))
+	})
+
+	t.Run("未开启时 token 不生效", func(t *testing.T) {
+		assert.NoError(t, initWithOptions(map[string]interface{}{}))
+		pass, _ := checkModify(sign(validClaims))
+		assert.False(t, pass)
+	})
+
+	t.Run("开启时必须设置密钥", func(t *testing.T) {
+		assert.Error(t, initWithOptions(map[string]interface{}{
+			"breakGlassOpen": true,
+		}))
+	})
+}
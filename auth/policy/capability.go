@@ -0,0 +1,127 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package policy
+
+import (
+	"context"
+	"fmt"
+
+	apisecurity "github.com/polarismesh/specification/source/go/api/v1/security"
+	"go.uber.org/zap"
+
+	"github.com/polarismesh/polaris/auth"
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/common/utils"
+)
+
+// maxCapabilityRetry 计算期间策略或者角色发生变化时重新计算的最大次数
+const maxCapabilityRetry = 3
+
+// handleCapabilityMatrix 一次性计算 principal × 资源 × 操作 的鉴权结果
+// 每个单元格都通过 DefaultAuthChecker 的置顶决策以及策略检查计算，与逐个检查的结果保持一致，
+// 计算结束时策略或者角色的版本与开始时不一致则重新计算，保证整个矩阵基于同一个版本的策略以及角色
+func (svr *Server) handleCapabilityMatrix(ctx context.Context, principals []model.Principal,
+	resources []auth.CapabilityResource, operations []model.ResourceOperation) (*auth.CapabilityMatrix, error) {
+	for i := range resources {
+		switch resources[i].Type {
+		case apisecurity.ResourceType_Namespaces, apisecurity.ResourceType_Services,
+			apisecurity.ResourceType_ConfigGroups:
+		default:
+			return nil, fmt.Errorf("%w: unsupported resource type %s", ErrorInvalidParameter, resources[i].Type)
+		}
+	}
+
+	var matrix *auth.CapabilityMatrix
+	for attempt := 0; attempt < maxCapabilityRetry; attempt++ {
+		version := svr.checker.decisionVersion()
+		ret, err := svr.computeCapabilityMatrix(ctx, principals, resources, operations)
+		if err != nil {
+			return nil, err
+		}
+		matrix = ret
+		if svr.checker.decisionVersion() == version {
+			return matrix, nil
+		}
+	}
+	log.Warn("[Auth][Capability] strategies keep changing during computation", utils.RequestID(ctx))
+	return matrix, nil
+}
+
+func (svr *Server) computeCapabilityMatrix(ctx context.Context, principals []model.Principal,
+	resources []auth.CapabilityResource, operations []model.ResourceOperation) (*auth.CapabilityMatrix, error) {
+	matrix := auth.NewCapabilityMatrix(principals, resources, operations)
+	for p := range principals {
+		disable, err := svr.capabilityPrincipalDisable(principals[p])
+		if err != nil {
+			log.Error("[Auth][Capability] principal not found", utils.RequestID(ctx),
+				zap.String("principal", principals[p].PrincipalID), zap.Error(err))
+			return nil, err
+		}
+		if disable {
+			continue
+		}
+		for r := range resources {
+			for o := range operations {
+				matrix.Set(p, r, o, svr.checker.evaluate(capabilityContext(ctx, principals[p], resources[r],
+					operations[o]), principals[p]))
+			}
+		}
+	}
+	return matrix, nil
+}
+
+// capabilityContext 构造单元格对应的鉴权请求，principal 已知，不需要再校验 token
+func capabilityContext(ctx context.Context, principal model.Principal, resource auth.CapabilityResource,
+	operation model.ResourceOperation) *model.AcquireContext {
+	module := model.DiscoverModule
+	if resource.Type == apisecurity.ResourceType_ConfigGroups {
+		module = model.ConfigModule
+	}
+	return model.NewAcquireContext(
+		model.WithRequestContext(ctx),
+		model.WithModule(module),
+		model.WithOperation(operation),
+		model.WithAccessResources(map[apisecurity.ResourceType][]model.ResourceEntry{
+			resource.Type: {{ID: resource.ID}},
+		}),
+		model.WithAttachment(map[string]interface{}{
+			model.OperatorIDKey:         principal.PrincipalID,
+			model.OperatorPrincipalType: principal.PrincipalRole,
+		}),
+	)
+}
+
+// capabilityPrincipalDisable principal 的 token 是否被禁用，被禁用的 principal 无法通过任何鉴权检查
+func (svr *Server) capabilityPrincipalDisable(principal model.Principal) (bool, error) {
+	switch principal.PrincipalRole {
+	case model.PrincipalUser:
+		user := svr.cacheMgr.User().GetUserByID(principal.PrincipalID)
+		if user == nil {
+			return false, model.ErrorNoUser
+		}
+		return !user.TokenEnable, nil
+	case model.PrincipalGroup:
+		group := svr.cacheMgr.User().GetGroup(principal.PrincipalID)
+		if group == nil {
+			return false, model.ErrorNoUserGroup
+		}
+		return !group.TokenEnable, nil
+	default:
+		return false, ErrorInvalidParameter
+	}
+}
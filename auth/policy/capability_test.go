@@ -0,0 +1,277 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package policy_test
+
+import (
+	"context"
+	"testing"
+
+	apisecurity "github.com/polarismesh/specification/source/go/api/v1/security"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/polarismesh/polaris/auth"
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/common/utils"
+)
+
+func Test_CapabilityMatrix(t *testing.T) {
+	strategyTest := newStrategyTest(t)
+	defer strategyTest.Clean()
+
+	_ = strategyTest.cacheMgn.TestUpdate()
+
+	principals := make([]model.Principal, 0, 6)
+	tokens := make([]string, 0, 6)
+	for _, user := range strategyTest.users[:4] {
+		principals = append(principals, model.Principal{PrincipalID: user.ID, PrincipalRole: model.PrincipalUser})
+		tokens = append(tokens, user.Token)
+	}
+	for _, group := range strategyTest.groups[:2] {
+		principals = append(principals, model.Principal{PrincipalID: group.ID, PrincipalRole: model.PrincipalGroup})
+		tokens = append(tokens, group.Token)
+	}
+
+	resources := make([]auth.CapabilityResource, 0, 8)
+	owners := make([]string, 0, 8)
+	for _, svc := range strategyTest.services[:5] {
+		resources = append(resources, auth.CapabilityResource{Type: apisecurity.ResourceType_Services, ID: svc.ID})
+		owners = append(owners, svc.Owner)
+	}
+	for _, ns := range strategyTest.namespaces[:2] {
+		resources = append(resources, auth.CapabilityResource{Type: apisecurity.ResourceType_Namespaces, ID: ns.Name})
+		owners = append(owners, ns.Owner)
+	}
+	// 未关联任何策略的资源
+	resources = append(resources, auth.CapabilityResource{Type: apisecurity.ResourceType_ConfigGroups, ID: "1000"})
+	owners = append(owners, "")
+
+	operations := []model.ResourceOperation{model.Read, model.Modify, model.Delete}
+
+	t.Run("矩阵结果与逐个检查的结果一致", func(t *testing.T) {
+		matrix, err := strategyTest.policySvr.CapabilityMatrix(context.Background(), principals, resources, operations)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, len(principals)*len(resources)*len(operations), len(matrix.Decisions))
+
+		checker := strategyTest.policySvr.GetAuthChecker()
+		allowCount, denyCount := 0, 0
+		for p := range principals {
+			for r := range resources {
+				for o := range operations {
+					ctx := context.WithValue(context.Background(), utils.ContextAuthTokenKey, tokens[p])
+					authCtx := model.NewAcquireContext(
+						model.WithRequestContext(ctx),
+						model.WithMethod("Test_CapabilityMatrix"),
+						model.WithOperation(operations[o]),
+						model.WithModule(model.DiscoverModule),
+						model.WithAccessResources(map[apisecurity.ResourceType][]model.ResourceEntry{
+							resources[r].Type: {{ID: resources[r].ID, Owner: owners[r]}},
+						}),
+					)
+					expect, _ := checker.CheckConsolePermission(authCtx)
+					assert.Equal(t, expect, matrix.Allowed(p, r, o), "principal %s resource %s operation %d",
+						principals[p].PrincipalID, resources[r].ID, operations[o])
+					if expect {
+						allowCount++
+					} else {
+						denyCount++
+					}
+				}
+			}
+		}
+		// 确保矩阵中同时存在放通与拒绝的单元格
+		assert.True(t, allowCount > 0)
+		assert.True(t, denyCount > 0)
+	})
+
+	t.Run("不存在的 principal", func(t *testing.T) {
+		_, err := strategyTest.policySvr.CapabilityMatrix(context.Background(), []model.Principal{
+			{PrincipalID: "not-exist-user", PrincipalRole: model.PrincipalUser},
+		}, resources, operations)
+		assert.Error(t, err)
+	})
+
+	t.Run("不支持的资源类型", func(t *testing.T) {
+		_, err := strategyTest.policySvr.CapabilityMatrix(context.Background(), principals, []auth.CapabilityResource{
+			{Type: apisecurity.ResourceType(100), ID: "1"},
+		}, operations)
+		assert.Error(t, err)
+	})
+
+	t.Run("主账户可以查询，子账户不允许查询", func(t *testing.T) {
+		ownerCtx := context.WithValue(context.Background(), utils.ContextAuthTokenKey, strategyTest.users[0].Token)
+		matrix, err := strategyTest.svr.CapabilityMatrix(ownerCtx, principals, resources, operations)
+		assert.NoError(t, err)
+		assert.NotNil(t, matrix)
+
+		subCtx := context.WithValue(context.Background(), utils.ContextAuthTokenKey, strategyTest.users[1].Token)
+		matrix, err = strategyTest.svr.CapabilityMatrix(subCtx, principals, resources, operations)
+		assert.Error(t, err)
+		assert.Nil(t, matrix)
+	})
+}
+
+func Test_ExplainGrants(t *testing.T) {
+	strategyTest := newStrategyTest(t)
+	defer strategyTest.Clean()
+
+	users := strategyTest.users
+	user, group := users[2], strategyTest.groups[2]
+	svc := strategyTest.services[2]
+	userStrategy, groupStrategy := strategyTest.strategies[2], strategyTest.strategies[len(users)+2]
+	// users[2] 通过自己的策略直接关联、关联全部服务，以及所在用户组的策略获得同一个服务的写权限
+	userStrategy.Resources = append(userStrategy.Resources, model.StrategyResource{
+		StrategyID: userStrategy.ID, ResType: int32(apisecurity.ResourceType_Services), ResID: utils.MatchAll,
+	})
+	userStrategy.Revision = utils.NewUUID()
+	groupStrategy.Resources = append(groupStrategy.Resources, model.StrategyResource{
+		StrategyID: groupStrategy.ID, ResType: int32(apisecurity.ResourceType_Services), ResID: svc.ID,
+	})
+	groupStrategy.Revision = utils.NewUUID()
+	_ = strategyTest.cacheMgn.TestUpdate()
+
+	principal := model.Principal{PrincipalID: user.ID, PrincipalRole: model.PrincipalUser}
+	resource := auth.CapabilityResource{Type: apisecurity.ResourceType_Services, ID: svc.ID}
+
+	t.Run("返回全部的授权路径", func(t *testing.T) {
+		explanation, err := strategyTest.policySvr.ExplainGrants(context.Background(), principal, resource)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.True(t, explanation.Allowed)
+		assert.False(t, explanation.Unrestricted)
+		if !assert.Equal(t, 3, len(explanation.Paths)) {
+			return
+		}
+		groupPrincipal := model.Principal{PrincipalID: group.ID, PrincipalRole: model.PrincipalGroup}
+		expect := []struct {
+			strategy string
+			via      model.Principal
+			match    auth.GrantMatch
+		}{
+			{strategy: userStrategy.ID, via: principal, match: auth.GrantMatchExact},
+			{strategy: userStrategy.ID, via: principal, match: auth.GrantMatchAll},
+			{strategy: groupStrategy.ID, via: groupPrincipal, match: auth.GrantMatchExact},
+		}
+		for i := range expect {
+			assert.Equal(t, expect[i].strategy, explanation.Paths[i].StrategyID)
+			assert.Equal(t, expect[i].via, explanation.Paths[i].Via)
+			assert.Equal(t, expect[i].match, explanation.Paths[i].Match)
+		}
+	})
+
+	t.Run("没有授权路径", func(t *testing.T) {
+		other := model.Principal{PrincipalID: users[3].ID, PrincipalRole: model.PrincipalUser}
+		explanation, err := strategyTest.policySvr.ExplainGrants(context.Background(), other, resource)
+		assert.NoError(t, err)
+		assert.False(t, explanation.Allowed)
+		assert.Equal(t, 0, len(explanation.Paths))
+
+		// 未关联任何策略的资源任何人都可以操作
+		explanation, err = strategyTest.policySvr.ExplainGrants(context.Background(), other,
+			auth.CapabilityResource{Type: apisecurity.ResourceType_ConfigGroups, ID: "1000"})
+		assert.NoError(t, err)
+		assert.True(t, explanation.Allowed)
+		assert.True(t, explanation.Unrestricted)
+	})
+
+	t.Run("子账户不允许查询", func(t *testing.T) {
+		subCtx := context.WithValue(context.Background(), utils.ContextAuthTokenKey, users[1].Token)
+		_, err := strategyTest.svr.ExplainGrants(subCtx, principal, resource)
+		assert.Error(t, err)
+
+		ownerCtx := context.WithValue(context.Background(), utils.ContextAuthTokenKey, users[0].Token)
+		_, err = strategyTest.svr.ExplainGrants(ownerCtx, principal, resource)
+		assert.NoError(t, err)
+	})
+}
+
+func Test_WhatIfGroupMembership(t *testing.T) {
+	strategyTest := newStrategyTest(t)
+	defer strategyTest.Clean()
+
+	users := strategyTest.users
+	user, group := users[1], strategyTest.groups[3]
+	groupStrategy := strategyTest.strategies[len(users)+3]
+	// 用户组的策略同时关联了用户已经可以编辑的服务，该服务不属于新增的资源
+	groupStrategy.Resources = append(groupStrategy.Resources, model.StrategyResource{
+		StrategyID: groupStrategy.ID, ResType: int32(apisecurity.ResourceType_Services),
+		ResID: strategyTest.services[1].ID,
+	})
+	groupStrategy.Revision = utils.NewUUID()
+	_ = strategyTest.cacheMgn.TestUpdate()
+
+	t.Run("返回加入用户组后新增的资源", func(t *testing.T) {
+		whatIf, err := strategyTest.policySvr.WhatIfGroupMembership(context.Background(), user.ID, group.ID)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.False(t, whatIf.AlreadyMember)
+		assert.False(t, whatIf.ReadOnly)
+		assert.Contains(t, whatIf.Current, auth.CapabilityResource{
+			Type: apisecurity.ResourceType_Services, ID: strategyTest.services[1].ID,
+		})
+		target := strategyTest.services[len(users)+3]
+		assert.Equal(t, []auth.CapabilityResource{
+			{Type: apisecurity.ResourceType_Namespaces, ID: target.Namespace},
+			{Type: apisecurity.ResourceType_Services, ID: target.ID},
+		}, whatIf.Added)
+		assert.Equal(t, 0, len(whatIf.Removed))
+		// 假设的成员关系不会写入缓存
+		assert.NotContains(t, strategyTest.cacheMgn.User().GetUserLinkGroupIds(user.ID), group.ID)
+	})
+
+	t.Run("已经在用户组中", func(t *testing.T) {
+		whatIf, err := strategyTest.policySvr.WhatIfGroupMembership(context.Background(), user.ID,
+			strategyTest.groups[1].ID)
+		assert.NoError(t, err)
+		assert.True(t, whatIf.AlreadyMember)
+		assert.Equal(t, 0, len(whatIf.Added))
+	})
+
+	t.Run("用户组绑定了只读策略时失去全部写权限", func(t *testing.T) {
+		groupStrategy.Action = apisecurity.AuthAction_ONLY_READ.String()
+		groupStrategy.Revision = utils.NewUUID()
+		_ = strategyTest.cacheMgn.TestUpdate()
+		defer func() {
+			groupStrategy.Action = apisecurity.AuthAction_READ_WRITE.String()
+			groupStrategy.Revision = utils.NewUUID()
+			_ = strategyTest.cacheMgn.TestUpdate()
+		}()
+
+		whatIf, err := strategyTest.policySvr.WhatIfGroupMembership(context.Background(), user.ID, group.ID)
+		assert.NoError(t, err)
+		assert.True(t, whatIf.ReadOnly)
+		assert.Equal(t, 0, len(whatIf.Added))
+		assert.Equal(t, whatIf.Current, whatIf.Removed)
+	})
+
+	t.Run("主账户只能查询自己名下的用户", func(t *testing.T) {
+		subCtx := context.WithValue(context.Background(), utils.ContextAuthTokenKey, users[1].Token)
+		_, err := strategyTest.svr.WhatIfGroupMembership(subCtx, user.ID, group.ID)
+		assert.Error(t, err)
+
+		ownerCtx := context.WithValue(context.Background(), utils.ContextAuthTokenKey, users[0].Token)
+		_, err = strategyTest.svr.WhatIfGroupMembership(ownerCtx, user.ID, group.ID)
+		assert.NoError(t, err)
+
+		_, err = strategyTest.policySvr.WhatIfGroupMembership(context.Background(), user.ID, "not-exist-group")
+		assert.Error(t, err)
+	})
+}
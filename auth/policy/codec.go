@@ -0,0 +1,425 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package policy
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	apisecurity "github.com/polarismesh/specification/source/go/api/v1/security"
+	"gopkg.in/yaml.v2"
+
+	"github.com/polarismesh/polaris/common/model"
+)
+
+const (
+	// StrategyFormatJSON 鉴权策略以 JSON 格式导出导入
+	StrategyFormatJSON = "json"
+	// StrategyFormatYAML 鉴权策略以 YAML 格式导出导入
+	StrategyFormatYAML = "yaml"
+
+	// strategyDocumentVersion 规范中间模型的版本
+	strategyDocumentVersion = "v1"
+	// authPolicyDocumentVersion 用户、用户组以及鉴权策略导出格式的版本
+	authPolicyDocumentVersion = "v1"
+)
+
+var (
+	// ErrorStrategyFormatUnsupported 不支持的序列化格式
+	ErrorStrategyFormatUnsupported = errors.New("unsupported strategy serialization format")
+	// ErrorStrategyFormatMismatch 声明的序列化格式与内容不一致
+	ErrorStrategyFormatMismatch = errors.New("strategy serialization format mismatch")
+)
+
+// StrategyDocument 鉴权策略导出导入的规范中间模型，JSON 与 YAML 均由该模型序列化，保证两种格式的内容完全一致
+// 列表均按照固定顺序输出，时间统一为 RFC3339 格式的字符串
+type StrategyDocument struct {
+	// Version 中间模型的版本
+	Version string `json:"version" yaml:"version"`
+	// Strategies 鉴权策略
+	Strategies []StrategyDocumentItem `json:"strategies" yaml:"strategies"`
+}
+
+// StrategyDocumentItem 中间模型中的鉴权策略
+type StrategyDocumentItem struct {
+	ID         string                      `json:"id" yaml:"id"`
+	Name       string                      `json:"name" yaml:"name"`
+	Owner      string                      `json:"owner" yaml:"owner"`
+	Action     string                      `json:"action" yaml:"action"`
+	Default    bool                        `json:"default" yaml:"default"`
+	Comment    string                      `json:"comment" yaml:"comment"`
+	Effect     string                      `json:"effect,omitempty" yaml:"effect,omitempty"`
+	Priority   int32                       `json:"priority,omitempty" yaml:"priority,omitempty"`
+	Functions  []string                    `json:"functions,omitempty" yaml:"functions,omitempty"`
+	Principals []StrategyDocumentPrincipal `json:"principals" yaml:"principals"`
+	Resources  []StrategyDocumentResource  `json:"resources" yaml:"resources"`
+}
+
+// StrategyDocumentPrincipal 中间模型中鉴权策略关联的 principal
+type StrategyDocumentPrincipal struct {
+	// ID 用户/用户组 ID
+	ID string `json:"id" yaml:"id"`
+	// Type principal 类型, user or group
+	Type string `json:"type" yaml:"type"`
+}
+
+// StrategyDocumentResource 中间模型中鉴权策略关联的资源
+type StrategyDocumentResource struct {
+	// Type 资源类型, Namespaces / Services / ConfigGroups
+	Type string `json:"type" yaml:"type"`
+	// ID 资源 ID, * 表示该类型下的全部资源
+	ID string `json:"id" yaml:"id"`
+	// ExpireTime 资源关联关系的过期时间，为空表示永不过期
+	ExpireTime string `json:"expire_time,omitempty" yaml:"expire_time,omitempty"`
+}
+
+// MarshalStrategies 将鉴权策略按照 format 序列化
+func MarshalStrategies(format string, strategies []*model.StrategyDetail) ([]byte, error) {
+	return marshalDocument(format, buildStrategyDocument(strategies))
+}
+
+// UnmarshalStrategies 按照 format 反序列化鉴权策略，内容的实际格式必须与 format 一致，且不允许出现未知字段
+func UnmarshalStrategies(format string, data []byte) ([]*model.StrategyDetail, error) {
+	doc := &StrategyDocument{}
+	if err := unmarshalDocument(format, data, doc); err != nil {
+		return nil, err
+	}
+	if doc.Version != strategyDocumentVersion {
+		return nil, fmt.Errorf("unsupported strategy document version: %s", doc.Version)
+	}
+	return parseStrategyItems(doc.Strategies)
+}
+
+func marshalDocument(format string, doc interface{}) ([]byte, error) {
+	switch format {
+	case StrategyFormatJSON:
+		return json.MarshalIndent(doc, "", "  ")
+	case StrategyFormatYAML:
+		return yaml.Marshal(doc)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrorStrategyFormatUnsupported, format)
+	}
+}
+
+func unmarshalDocument(format string, data []byte, doc interface{}) error {
+	if format != StrategyFormatJSON && format != StrategyFormatYAML {
+		return fmt.Errorf("%w: %s", ErrorStrategyFormatUnsupported, format)
+	}
+	if actual := detectStrategyFormat(data); actual != format {
+		return fmt.Errorf("%w: declared %s, content is %s", ErrorStrategyFormatMismatch, format, actual)
+	}
+	if format == StrategyFormatJSON {
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		return decoder.Decode(doc)
+	}
+	return yaml.UnmarshalStrict(data, doc)
+}
+
+// detectStrategyFormat 合法的 JSON 同时也是合法的 YAML，因此优先判断是否为 JSON
+func detectStrategyFormat(data []byte) string {
+	if json.Valid(data) {
+		return StrategyFormatJSON
+	}
+	var content map[string]interface{}
+	if err := yaml.Unmarshal(data, &content); err == nil && len(content) > 0 {
+		return StrategyFormatYAML
+	}
+	return "unknown"
+}
+
+func buildStrategyDocument(strategies []*model.StrategyDetail) *StrategyDocument {
+	return &StrategyDocument{
+		Version:    strategyDocumentVersion,
+		Strategies: buildStrategyItems(strategies),
+	}
+}
+
+func buildStrategyItems(strategies []*model.StrategyDetail) []StrategyDocumentItem {
+	items := make([]StrategyDocumentItem, 0, len(strategies))
+	for _, strategy := range strategies {
+		item := StrategyDocumentItem{
+			ID:         strategy.ID,
+			Name:       strategy.Name,
+			Owner:      strategy.Owner,
+			Action:     strategy.Action,
+			Default:    strategy.Default,
+			Comment:    strategy.Comment,
+			Effect:     string(strategy.Effect),
+			Priority:   strategy.Priority,
+			Functions:  strategy.Functions,
+			Principals: make([]StrategyDocumentPrincipal, 0, len(strategy.Principals)),
+			Resources:  make([]StrategyDocumentResource, 0, len(strategy.Resources)),
+		}
+		for _, principal := range strategy.Principals {
+			item.Principals = append(item.Principals, StrategyDocumentPrincipal{
+				ID:   principal.PrincipalID,
+				Type: model.PrincipalNames[principal.PrincipalRole],
+			})
+		}
+		for _, res := range strategy.Resources {
+			entry := StrategyDocumentResource{
+				Type: apisecurity.ResourceType(res.ResType).String(),
+				ID:   res.ResID,
+			}
+			if !res.ExpireTime.IsZero() {
+				entry.ExpireTime = res.ExpireTime.UTC().Format(time.RFC3339)
+			}
+			item.Resources = append(item.Resources, entry)
+		}
+		sort.Slice(item.Principals, func(i, j int) bool {
+			if item.Principals[i].Type != item.Principals[j].Type {
+				return item.Principals[i].Type > item.Principals[j].Type
+			}
+			return item.Principals[i].ID < item.Principals[j].ID
+		})
+		sort.Slice(item.Resources, func(i, j int) bool {
+			if item.Resources[i].Type != item.Resources[j].Type {
+				return item.Resources[i].Type < item.Resources[j].Type
+			}
+			return item.Resources[i].ID < item.Resources[j].ID
+		})
+		items = append(items, item)
+	}
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].ID < items[j].ID
+	})
+	return items
+}
+
+func parseStrategyItems(items []StrategyDocumentItem) ([]*model.StrategyDetail, error) {
+	principalTypes := make(map[string]model.PrincipalType, len(model.PrincipalNames))
+	for role, name := range model.PrincipalNames {
+		principalTypes[name] = role
+	}
+
+	strategies := make([]*model.StrategyDetail, 0, len(items))
+	for _, item := range items {
+		if effect := model.StrategyEffect(item.Effect); effect != "" && effect != model.StrategyEffectAllow &&
+			effect != model.StrategyEffectDeny {
+			return nil, fmt.Errorf("strategy %s has invalid effect: %s", item.Name, item.Effect)
+		}
+		strategy := &model.StrategyDetail{
+			ID:         item.ID,
+			Name:       item.Name,
+			Owner:      item.Owner,
+			Action:     item.Action,
+			Default:    item.Default,
+			Comment:    item.Comment,
+			Effect:     model.StrategyEffect(item.Effect),
+			Priority:   item.Priority,
+			Functions:  item.Functions,
+			Principals: make([]model.Principal, 0, len(item.Principals)),
+			Resources:  make([]model.StrategyResource, 0, len(item.Resources)),
+			Valid:      true,
+		}
+		for _, principal := range item.Principals {
+			role, ok := principalTypes[principal.Type]
+			if !ok {
+				return nil, fmt.Errorf("strategy %s has invalid principal type: %s", item.Name, principal.Type)
+			}
+			strategy.Principals = append(strategy.Principals, model.Principal{
+				PrincipalID:   principal.ID,
+				PrincipalRole: role,
+			})
+		}
+		for _, res := range item.Resources {
+			resType, ok := apisecurity.ResourceType_value[res.Type]
+			if !ok {
+				return nil, fmt.Errorf("strategy %s has invalid resource type: %s", item.Name, res.Type)
+			}
+			link := model.StrategyResource{
+				StrategyID: item.ID,
+				ResType:    resType,
+				ResID:      res.ID,
+			}
+			if res.ExpireTime != "" {
+				expire, err := time.Parse(time.RFC3339, res.ExpireTime)
+				if err != nil {
+					return nil, fmt.Errorf("strategy %s has invalid resource expire time: %w", item.Name, err)
+				}
+				link.ExpireTime = expire
+			}
+			strategy.Resources = append(strategy.Resources, link)
+		}
+		strategies = append(strategies, strategy)
+	}
+	return strategies, nil
+}
+
+// AuthPolicies 用户、用户组以及鉴权策略
+type AuthPolicies struct {
+	Users      []*model.User
+	Groups     []*model.UserGroupDetail
+	Strategies []*model.StrategyDetail
+}
+
+// AuthPolicyDocument 用户、用户组以及鉴权策略的导出格式，用于在集群之间迁移或者保存在 git 中
+// 鉴权策略与 StrategyDocument 使用同一个模型，不携带凭据导出时不包含密码以及 token
+type AuthPolicyDocument struct {
+	Version    string                    `json:"version" yaml:"version"`
+	Users      []AuthPolicyDocumentUser  `json:"users" yaml:"users"`
+	Groups     []AuthPolicyDocumentGroup `json:"groups" yaml:"groups"`
+	Strategies []StrategyDocumentItem    `json:"strategies" yaml:"strategies"`
+}
+
+// AuthPolicyDocumentUser 导出格式中的用户，Password 为加密后的密码
+type AuthPolicyDocumentUser struct {
+	ID     string `json:"id" yaml:"id"`
+	Name   string `json:"name" yaml:"name"`
+	Owner  string `json:"owner" yaml:"owner"`
+	Source string `json:"source" yaml:"source"`
+	// Type 用户角色, admin / main / sub
+	Type        string `json:"type" yaml:"type"`
+	Mobile      string `json:"mobile,omitempty" yaml:"mobile,omitempty"`
+	Email       string `json:"email,omitempty" yaml:"email,omitempty"`
+	Comment     string `json:"comment" yaml:"comment"`
+	TokenEnable bool   `json:"token_enable" yaml:"token_enable"`
+	Password    string `json:"password,omitempty" yaml:"password,omitempty"`
+	Token       string `json:"token,omitempty" yaml:"token,omitempty"`
+}
+
+// AuthPolicyDocumentGroup 导出格式中的用户组
+type AuthPolicyDocumentGroup struct {
+	ID          string `json:"id" yaml:"id"`
+	Name        string `json:"name" yaml:"name"`
+	Owner       string `json:"owner" yaml:"owner"`
+	Comment     string `json:"comment" yaml:"comment"`
+	TokenEnable bool   `json:"token_enable" yaml:"token_enable"`
+	Token       string `json:"token,omitempty" yaml:"token,omitempty"`
+	// Users 用户组下的用户 ID
+	Users []string `json:"users" yaml:"users"`
+}
+
+// MarshalAuthPolicies 将用户、用户组以及鉴权策略按照 format 序列化，withCredentials 为 false 时不导出密码以及 token
+func MarshalAuthPolicies(format string, policies *AuthPolicies, withCredentials bool) ([]byte, error) {
+	doc := &AuthPolicyDocument{
+		Version:    authPolicyDocumentVersion,
+		Users:      make([]AuthPolicyDocumentUser, 0, len(policies.Users)),
+		Groups:     make([]AuthPolicyDocumentGroup, 0, len(policies.Groups)),
+		Strategies: buildStrategyItems(policies.Strategies),
+	}
+	for _, user := range policies.Users {
+		item := AuthPolicyDocumentUser{
+			ID:          user.ID,
+			Name:        user.Name,
+			Owner:       user.Owner,
+			Source:      user.Source,
+			Type:        model.UserRoleNames[user.Type],
+			Mobile:      user.Mobile,
+			Email:       user.Email,
+			Comment:     user.Comment,
+			TokenEnable: user.TokenEnable,
+		}
+		if withCredentials {
+			item.Password = user.Password
+			item.Token = user.Token
+		}
+		doc.Users = append(doc.Users, item)
+	}
+	for _, group := range policies.Groups {
+		item := AuthPolicyDocumentGroup{
+			ID:          group.ID,
+			Name:        group.Name,
+			Owner:       group.Owner,
+			Comment:     group.Comment,
+			TokenEnable: group.TokenEnable,
+			Users:       group.ToUserIdSlice(),
+		}
+		if withCredentials {
+			item.Token = group.Token
+		}
+		sort.Strings(item.Users)
+		doc.Groups = append(doc.Groups, item)
+	}
+	sort.Slice(doc.Users, func(i, j int) bool {
+		return doc.Users[i].ID < doc.Users[j].ID
+	})
+	sort.Slice(doc.Groups, func(i, j int) bool {
+		return doc.Groups[i].ID < doc.Groups[j].ID
+	})
+	return marshalDocument(format, doc)
+}
+
+// UnmarshalAuthPolicies 按照 format 反序列化用户、用户组以及鉴权策略，校验规则与 UnmarshalStrategies 一致
+func UnmarshalAuthPolicies(format string, data []byte) (*AuthPolicies, error) {
+	doc := &AuthPolicyDocument{}
+	if err := unmarshalDocument(format, data, doc); err != nil {
+		return nil, err
+	}
+	if doc.Version != authPolicyDocumentVersion {
+		return nil, fmt.Errorf("unsupported auth policy document version: %s", doc.Version)
+	}
+	roles := make(map[string]model.UserRoleType, len(model.UserRoleNames))
+	for role, name := range model.UserRoleNames {
+		roles[name] = role
+	}
+
+	policies := &AuthPolicies{
+		Users:  make([]*model.User, 0, len(doc.Users)),
+		Groups: make([]*model.UserGroupDetail, 0, len(doc.Groups)),
+	}
+	for _, item := range doc.Users {
+		role, ok := roles[item.Type]
+		if !ok {
+			return nil, fmt.Errorf("user %s has invalid type: %s", item.Name, item.Type)
+		}
+		policies.Users = append(policies.Users, &model.User{
+			ID:          item.ID,
+			Name:        item.Name,
+			Owner:       item.Owner,
+			Source:      item.Source,
+			Type:        role,
+			Mobile:      item.Mobile,
+			Email:       item.Email,
+			Comment:     item.Comment,
+			TokenEnable: item.TokenEnable,
+			Password:    item.Password,
+			Token:       item.Token,
+			Valid:       true,
+		})
+	}
+	for _, item := range doc.Groups {
+		group := &model.UserGroupDetail{
+			UserGroup: &model.UserGroup{
+				ID:          item.ID,
+				Name:        item.Name,
+				Owner:       item.Owner,
+				Comment:     item.Comment,
+				TokenEnable: item.TokenEnable,
+				Token:       item.Token,
+				Valid:       true,
+			},
+			UserIds: make(map[string]struct{}, len(item.Users)),
+		}
+		for _, id := range item.Users {
+			group.UserIds[id] = struct{}{}
+		}
+		policies.Groups = append(policies.Groups, group)
+	}
+	strategies, err := parseStrategyItems(doc.Strategies)
+	if err != nil {
+		return nil, err
+	}
+	policies.Strategies = strategies
+	return policies, nil
+}
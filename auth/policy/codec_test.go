@@ -0,0 +1,188 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package policy_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	apisecurity "github.com/polarismesh/specification/source/go/api/v1/security"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/polarismesh/polaris/auth/policy"
+	"github.com/polarismesh/polaris/common/model"
+)
+
+func Test_StrategyCodec(t *testing.T) {
+	users := createMockUser(3)
+	groups := createMockUserGroup(users)
+	namespaces := createMockNamespace(len(users)+len(groups), users[0].ID)
+	services := createMockService(namespaces)
+	strategies, _ := createMockStrategy(users, groups, services[:len(users)+len(groups)])
+	// 带有过期时间的资源关联关系
+	strategies[0].Resources = append(strategies[0].Resources, model.StrategyResource{
+		StrategyID: strategies[0].ID,
+		ResType:    int32(apisecurity.ResourceType_ConfigGroups),
+		ResID:      "expire-group",
+		ExpireTime: time.Unix(1900000000, 0).UTC(),
+	})
+
+	t.Run("YAML 与 JSON 导出后导入的结果一致", func(t *testing.T) {
+		jsonData, err := policy.MarshalStrategies(policy.StrategyFormatJSON, strategies)
+		assert.NoError(t, err)
+		yamlData, err := policy.MarshalStrategies(policy.StrategyFormatYAML, strategies)
+		assert.NoError(t, err)
+
+		fromJSON, err := policy.UnmarshalStrategies(policy.StrategyFormatJSON, jsonData)
+		assert.NoError(t, err)
+		fromYAML, err := policy.UnmarshalStrategies(policy.StrategyFormatYAML, yamlData)
+		assert.NoError(t, err)
+		assert.Equal(t, len(strategies), len(fromYAML))
+		assert.Equal(t, fromJSON, fromYAML)
+
+		// 再次导出的内容与首次导出完全一致
+		again, err := policy.MarshalStrategies(policy.StrategyFormatYAML, fromYAML)
+		assert.NoError(t, err)
+		assert.Equal(t, string(yamlData), string(again))
+
+		expect := map[string]*model.StrategyDetail{}
+		for i := range strategies {
+			expect[strategies[i].ID] = strategies[i]
+		}
+		for _, strategy := range fromYAML {
+			origin := expect[strategy.ID]
+			assert.NotNil(t, origin)
+			assert.Equal(t, origin.Name, strategy.Name)
+			assert.Equal(t, origin.Action, strategy.Action)
+			assert.Equal(t, origin.Default, strategy.Default)
+			assert.ElementsMatch(t, origin.Principals, strategy.Principals)
+			assert.ElementsMatch(t, origin.Resources, strategy.Resources)
+		}
+	})
+
+	t.Run("声明的格式与内容不一致", func(t *testing.T) {
+		jsonData, err := policy.MarshalStrategies(policy.StrategyFormatJSON, strategies)
+		assert.NoError(t, err)
+		yamlData, err := policy.MarshalStrategies(policy.StrategyFormatYAML, strategies)
+		assert.NoError(t, err)
+
+		_, err = policy.UnmarshalStrategies(policy.StrategyFormatYAML, jsonData)
+		assert.True(t, errors.Is(err, policy.ErrorStrategyFormatMismatch), err)
+		_, err = policy.UnmarshalStrategies(policy.StrategyFormatJSON, yamlData)
+		assert.True(t, errors.Is(err, policy.ErrorStrategyFormatMismatch), err)
+		_, err = policy.UnmarshalStrategies(policy.StrategyFormatJSON, []byte("not a document"))
+		assert.True(t, errors.Is(err, policy.ErrorStrategyFormatMismatch), err)
+	})
+
+	t.Run("不支持的格式以及未知字段", func(t *testing.T) {
+		_, err := policy.MarshalStrategies("xml", strategies)
+		assert.True(t, errors.Is(err, policy.ErrorStrategyFormatUnsupported))
+		_, err = policy.UnmarshalStrategies("xml", []byte("{}"))
+		assert.True(t, errors.Is(err, policy.ErrorStrategyFormatUnsupported))
+
+		yamlData, err := policy.MarshalStrategies(policy.StrategyFormatYAML, strategies[:1])
+		assert.NoError(t, err)
+		unknown := strings.Replace(string(yamlData), "version: v1", "version: v1\nunknown: true", 1)
+		_, err = policy.UnmarshalStrategies(policy.StrategyFormatYAML, []byte(unknown))
+		assert.Error(t, err)
+	})
+}
+
+func Test_AuthPolicyCodec(t *testing.T) {
+	users := createMockUser(3)
+	groups := createMockUserGroup(users)
+	namespaces := createMockNamespace(len(users)+len(groups), users[0].ID)
+	services := createMockService(namespaces)
+	strategies, _ := createMockStrategy(users, groups, services[:len(users)+len(groups)])
+	strategies[0].Effect = model.StrategyEffectDeny
+	strategies[0].Priority = 10
+	strategies[0].Functions = []string{"Describe*"}
+	policies := &policy.AuthPolicies{Users: users, Groups: groups, Strategies: strategies}
+
+	t.Run("携带凭据导出后导入的结果一致", func(t *testing.T) {
+		yamlData, err := policy.MarshalAuthPolicies(policy.StrategyFormatYAML, policies, true)
+		assert.NoError(t, err)
+		ret, err := policy.UnmarshalAuthPolicies(policy.StrategyFormatYAML, yamlData)
+		assert.NoError(t, err)
+		assert.Equal(t, len(users), len(ret.Users))
+		assert.Equal(t, len(groups), len(ret.Groups))
+		assert.Equal(t, len(strategies), len(ret.Strategies))
+
+		again, err := policy.MarshalAuthPolicies(policy.StrategyFormatYAML, ret, true)
+		assert.NoError(t, err)
+		assert.Equal(t, string(yamlData), string(again))
+
+		expectUsers := map[string]*model.User{}
+		for i := range users {
+			expectUsers[users[i].ID] = users[i]
+		}
+		for _, user := range ret.Users {
+			origin := expectUsers[user.ID]
+			assert.NotNil(t, origin)
+			assert.Equal(t, origin.Type, user.Type)
+			assert.Equal(t, origin.Owner, user.Owner)
+			assert.Equal(t, origin.Password, user.Password)
+			assert.Equal(t, origin.Token, user.Token)
+		}
+		expectGroups := map[string]*model.UserGroupDetail{}
+		for i := range groups {
+			expectGroups[groups[i].ID] = groups[i]
+		}
+		for _, group := range ret.Groups {
+			origin := expectGroups[group.ID]
+			assert.NotNil(t, origin)
+			assert.Equal(t, origin.UserIds, group.UserIds)
+			assert.Equal(t, origin.Token, group.Token)
+		}
+		for _, strategy := range ret.Strategies {
+			if strategy.ID == strategies[0].ID {
+				assert.Equal(t, model.StrategyEffectDeny, strategy.Effect)
+				assert.Equal(t, int32(10), strategy.Priority)
+				assert.Equal(t, []string{"Describe*"}, strategy.Functions)
+			}
+		}
+	})
+
+	t.Run("不携带凭据导出", func(t *testing.T) {
+		jsonData, err := policy.MarshalAuthPolicies(policy.StrategyFormatJSON, policies, false)
+		assert.NoError(t, err)
+		assert.NotContains(t, string(jsonData), users[0].Password)
+		assert.NotContains(t, string(jsonData), groups[0].Token)
+		ret, err := policy.UnmarshalAuthPolicies(policy.StrategyFormatJSON, jsonData)
+		assert.NoError(t, err)
+		for _, user := range ret.Users {
+			assert.Empty(t, user.Password)
+			assert.Empty(t, user.Token)
+		}
+	})
+
+	t.Run("非法的用户类型以及授权效果", func(t *testing.T) {
+		yamlData, err := policy.MarshalAuthPolicies(policy.StrategyFormatYAML, policies, false)
+		assert.NoError(t, err)
+		_, err = policy.UnmarshalAuthPolicies(policy.StrategyFormatYAML,
+			[]byte(strings.Replace(string(yamlData), "type: sub", "type: root", 1)))
+		assert.Error(t, err)
+		_, err = policy.UnmarshalAuthPolicies(policy.StrategyFormatYAML,
+			[]byte(strings.Replace(string(yamlData), "effect: DENY", "effect: MAYBE", 1)))
+		assert.Error(t, err)
+		_, err = policy.UnmarshalAuthPolicies(policy.StrategyFormatJSON, yamlData)
+		assert.True(t, errors.Is(err, policy.ErrorStrategyFormatMismatch), err)
+	})
+}
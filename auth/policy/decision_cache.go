@@ -0,0 +1,264 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package policy
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	apisecurity "github.com/polarismesh/specification/source/go/api/v1/security"
+	"go.uber.org/zap"
+
+	"github.com/polarismesh/polaris/common/eventhub"
+	"github.com/polarismesh/polaris/common/metrics"
+	"github.com/polarismesh/polaris/common/model"
+)
+
+const (
+	// defaultDecisionCacheTTL 鉴权决策缓存条目默认的过期时间
+	defaultDecisionCacheTTL = 5 * time.Second
+)
+
+type decisionKey struct {
+	principal model.Principal
+	resType   apisecurity.ResourceType
+	resID     string
+}
+
+type decisionEntry struct {
+	key      decisionKey
+	allowed  bool
+	version  uint64
+	expireAt time.Time
+}
+
+// decisionCache 缓存 principal 对资源的写权限判断结果，条目记录计算时的策略、角色、用户组以及服务集合的版本，
+// 读取时版本不一致的条目直接视为未命中，这些缓存发生变化时再主动清理旧版本的条目释放容量。
+// 资源关联关系的过期不会递增版本，条目的过期时间不会晚于计算时最早过期的资源关联关系
+type decisionCache struct {
+	lock    sync.Mutex
+	maxSize int
+	ttl     time.Duration
+	items   map[decisionKey]*list.Element
+	lru     *list.List
+	now     func() time.Time
+}
+
+// newDecisionCache maxSize 小于等于 0 时不缓存, ttlInSecs 小于等于 0 时使用默认值
+func newDecisionCache(maxSize, ttlInSecs int) *decisionCache {
+	if maxSize <= 0 {
+		return nil
+	}
+	c := &decisionCache{
+		maxSize: maxSize,
+		ttl:     defaultDecisionCacheTTL,
+		items:   map[decisionKey]*list.Element{},
+		lru:     list.New(),
+		now:     time.Now,
+	}
+	if ttlInSecs > 0 {
+		c.ttl = time.Duration(ttlInSecs) * time.Second
+	}
+	return c
+}
+
+// Get 获取在 version 版本的策略集合下计算出的决策，版本不一致或者已过期的条目视为未命中
+func (c *decisionCache) Get(key decisionKey, version uint64) (bool, bool) {
+	if c == nil {
+		return false, false
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	elem, ok := c.items[key]
+	if !ok {
+		return false, false
+	}
+	entry := elem.Value.(*decisionEntry)
+	if entry.version != version || !c.now().Before(entry.expireAt) {
+		c.removeElement(elem)
+		return false, false
+	}
+	c.lru.MoveToFront(elem)
+	return entry.allowed, true
+}
+
+// Put 写入在 version 版本的策略集合下计算出的决策，超出容量时淘汰最久未被访问的条目
+func (c *decisionCache) Put(key decisionKey, version uint64, allowed bool) {
+	c.PutUntil(key, version, allowed, time.Time{})
+}
+
+// PutUntil 同 Put，条目的过期时间不晚于 until，until 为零值时只按照 ttl 过期
+func (c *decisionCache) PutUntil(key decisionKey, version uint64, allowed bool, until time.Time) {
+	if c == nil {
+		return
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	expireAt := c.now().Add(c.ttl)
+	if !until.IsZero() && until.Before(expireAt) {
+		expireAt = until
+	}
+	if elem, ok := c.items[key]; ok {
+		entry := elem.Value.(*decisionEntry)
+		entry.allowed = allowed
+		entry.version = version
+		entry.expireAt = expireAt
+		c.lru.MoveToFront(elem)
+		return
+	}
+	c.items[key] = c.lru.PushFront(&decisionEntry{
+		key:      key,
+		allowed:  allowed,
+		version:  version,
+		expireAt: expireAt,
+	})
+	for c.lru.Len() > c.maxSize {
+		c.removeElement(c.lru.Back())
+	}
+}
+
+// Purge 清理不是在 version 版本的策略集合下计算出的条目
+func (c *decisionCache) Purge(version uint64) int {
+	if c == nil {
+		return 0
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	purged := 0
+	for elem := c.lru.Front(); elem != nil; {
+		next := elem.Next()
+		if elem.Value.(*decisionEntry).version != version {
+			c.removeElement(elem)
+			purged++
+		}
+		elem = next
+	}
+	return purged
+}
+
+// Len 当前缓存的条目数，包含尚未被清理的旧版本条目
+func (c *decisionCache) Len() int {
+	if c == nil {
+		return 0
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.lru.Len()
+}
+
+func (c *decisionCache) removeElement(elem *list.Element) {
+	c.lru.Remove(elem)
+	delete(c.items, elem.Value.(*decisionEntry).key)
+}
+
+// isResourceEditable 判断 principal 是否可以操作资源，开启决策缓存时按照策略集合版本缓存结果。
+// 计算前先读取版本，计算期间策略发生变化时，结果会被记录在旧版本下，不会被后续的读取使用
+func (d *DefaultAuthChecker) isResourceEditable(principal model.Principal,
+	resType apisecurity.ResourceType, resID string) bool {
+	allowed := d.computeResourceEditable(principal, resType, resID)
+	if allowed {
+		d.usage.touch(principal, resType, resID)
+	}
+	return allowed
+}
+
+func (d *DefaultAuthChecker) computeResourceEditable(principal model.Principal,
+	resType apisecurity.ResourceType, resID string) bool {
+	compute := func() bool {
+		return d.isLinkEditable(principal, resType, resID) ||
+			d.isAttributeEditable(principal, resType, resID) || d.isRoleEditable(principal, resType, resID)
+	}
+	if d.decisions == nil || d.hasLabelSelector(resType) {
+		return compute()
+	}
+	key := decisionKey{principal: principal, resType: resType, resID: resID}
+	version := d.decisionVersion()
+	if allowed, ok := d.decisions.Get(key, version); ok {
+		metrics.ReportDecisionCacheHit()
+		return allowed
+	}
+	metrics.ReportDecisionCacheMiss()
+	allowed := compute()
+	d.decisions.PutUntil(key, version, allowed, d.linkExpireAt(principal, resType))
+	return allowed
+}
+
+// decisionVersion 决策缓存条目的版本，角色、用户组成员以及服务元数据的变化同样会影响鉴权结果
+func (d *DefaultAuthChecker) decisionVersion() uint64 {
+	return d.cacheMgr.AuthStrategy().Version() + d.cacheMgr.AuthRole().Version() +
+		d.cacheMgr.User().Version() + d.cacheMgr.Service().Version()
+}
+
+// linkExpireAt principal 的策略中该类型资源最早的关联关系过期时间，没有会过期的关联关系时返回零值
+func (d *DefaultAuthChecker) linkExpireAt(principal model.Principal, resType apisecurity.ResourceType) time.Time {
+	var expireAt time.Time
+	now := time.Now()
+	for _, rule := range d.principalStrategies(principal) {
+		for _, res := range rule.Resources {
+			if res.ResType != int32(resType) || res.ExpireTime.IsZero() || !res.ExpireTime.After(now) {
+				continue
+			}
+			if expireAt.IsZero() || res.ExpireTime.Before(expireAt) {
+				expireAt = res.ExpireTime
+			}
+		}
+	}
+	return expireAt
+}
+
+// watchStrategyChange 订阅策略、用户组以及服务缓存的变化，及时清理旧版本的决策缓存条目
+func (d *DefaultAuthChecker) watchStrategyChange() {
+	if d.decisions == nil {
+		return
+	}
+	for _, topic := range []string{eventhub.CacheStrategyEventTopic, eventhub.CacheUserGroupEventTopic,
+		eventhub.CacheServiceEventTopic} {
+		subCtx, err := eventhub.SubscribeWithFunc(topic, d.handleStrategyChange)
+		if err != nil {
+			log.Warn("[Auth][Checker] subscribe cache change event, decision cache only expire by version and ttl",
+				zap.String("topic", topic), zap.Error(err))
+			continue
+		}
+		d.subCtxs = append(d.subCtxs, subCtx)
+	}
+}
+
+// handleStrategyChange 鉴权策略、用户组或者服务集合发生变化时，清理旧版本的决策缓存条目
+func (d *DefaultAuthChecker) handleStrategyChange(_ context.Context, args interface{}) error {
+	switch args.(type) {
+	case *eventhub.CacheStrategyEvent, *eventhub.CacheUserGroupEvent, *eventhub.CacheServiceEvent:
+	default:
+		return nil
+	}
+	purged := d.decisions.Purge(d.decisionVersion())
+	log.Debug("[Auth][Checker] cache change, purge decision cache", zap.Int("purged", purged))
+	return nil
+}
+
+// stopWatch 取消缓存变化的订阅
+func (d *DefaultAuthChecker) stopWatch() {
+	for _, subCtx := range d.subCtxs {
+		subCtx.Cancel()
+	}
+	d.subCtxs = nil
+}
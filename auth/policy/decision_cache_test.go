@@ -0,0 +1,192 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package policy
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	apisecurity "github.com/polarismesh/specification/source/go/api/v1/security"
+	"github.com/stretchr/testify/assert"
+
+	cachemock "github.com/polarismesh/polaris/cache/mock"
+	"github.com/polarismesh/polaris/common/eventhub"
+	"github.com/polarismesh/polaris/common/model"
+)
+
+func Test_decisionCache(t *testing.T) {
+	key := decisionKey{
+		principal: model.Principal{PrincipalID: "user-1", PrincipalRole: model.PrincipalUser},
+		resType:   apisecurity.ResourceType_Services,
+		resID:     "svc-1",
+	}
+
+	t.Run("版本递增后旧条目立即失效", func(t *testing.T) {
+		c := newDecisionCache(10, 60)
+		c.Put(key, 1, true)
+		allowed, ok := c.Get(key, 1)
+		assert.True(t, ok)
+		assert.True(t, allowed)
+
+		// 条目未被淘汰也未过期，但是版本已经变化
+		assert.Equal(t, 1, c.Len())
+		_, ok = c.Get(key, 2)
+		assert.False(t, ok)
+		// 旧版本的条目不会再次被使用
+		_, ok = c.Get(key, 1)
+		assert.False(t, ok)
+	})
+
+	t.Run("条目过期", func(t *testing.T) {
+		c := newDecisionCache(10, 1)
+		c.Put(key, 1, true)
+		c.now = func() time.Time { return time.Now().Add(2 * time.Second) }
+		_, ok := c.Get(key, 1)
+		assert.False(t, ok)
+	})
+
+	t.Run("条目不晚于指定的时间过期", func(t *testing.T) {
+		c := newDecisionCache(10, 60)
+		c.PutUntil(key, 1, true, time.Now().Add(time.Second))
+		_, ok := c.Get(key, 1)
+		assert.True(t, ok)
+		c.now = func() time.Time { return time.Now().Add(2 * time.Second) }
+		_, ok = c.Get(key, 1)
+		assert.False(t, ok)
+	})
+
+	t.Run("超出容量淘汰", func(t *testing.T) {
+		c := newDecisionCache(1, 60)
+		other := key
+		other.resID = "svc-2"
+		c.Put(key, 1, true)
+		c.Put(other, 1, false)
+		assert.Equal(t, 1, c.Len())
+		_, ok := c.Get(key, 1)
+		assert.False(t, ok)
+	})
+
+	t.Run("清理旧版本条目", func(t *testing.T) {
+		c := newDecisionCache(10, 60)
+		other := key
+		other.resID = "svc-2"
+		c.Put(key, 1, true)
+		c.Put(other, 2, false)
+		assert.Equal(t, 1, c.Purge(2))
+		assert.Equal(t, 1, c.Len())
+		allowed, ok := c.Get(other, 2)
+		assert.True(t, ok)
+		assert.False(t, allowed)
+	})
+
+	t.Run("未开启", func(t *testing.T) {
+		c := newDecisionCache(0, 0)
+		assert.Nil(t, c)
+		c.Put(key, 1, true)
+		_, ok := c.Get(key, 1)
+		assert.False(t, ok)
+	})
+}
+
+func Test_checkActionByDecisionCache(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	strategyCache := cachemock.NewMockStrategyCache(ctrl)
+	cacheMgr := cachemock.NewMockCacheManager(ctrl)
+	cacheMgr.EXPECT().AuthStrategy().Return(strategyCache).AnyTimes()
+	roleCache := cachemock.NewMockRoleCache(ctrl)
+	cacheMgr.EXPECT().AuthRole().Return(roleCache).AnyTimes()
+	roleCache.EXPECT().GetPrincipalRoles(gomock.Any()).Return(nil).AnyTimes()
+	roleCache.EXPECT().Version().Return(uint64(0)).AnyTimes()
+	strategyCache.EXPECT().GetLabelSelectorStrategies(gomock.Any()).Return(nil).AnyTimes()
+
+	checker := &DefaultAuthChecker{
+		conf:      DefaultAuthConfig(),
+		cacheMgr:  cacheMgr,
+		decisions: newDecisionCache(10, 60),
+	}
+	user := model.Principal{PrincipalID: "user-1", PrincipalRole: model.PrincipalUser}
+	authCtx := model.NewAcquireContext(model.WithOperation(model.Modify))
+	resources := []model.ResourceEntry{{ID: "ns-1"}}
+
+	var version uint64 = 1
+	strategyCache.EXPECT().Version().DoAndReturn(func() uint64 { return version }).AnyTimes()
+	strategyCache.EXPECT().IsResourceLinkStrategy(gomock.Any(), gomock.Any()).Return(true).AnyTimes()
+	// 没有携带请求条件的策略
+	userCache := cachemock.NewMockUserCache(ctrl)
+	cacheMgr.EXPECT().User().Return(userCache).AnyTimes()
+	userCache.EXPECT().GetUserLinkGroupIds(gomock.Any()).Return(nil).AnyTimes()
+	var groupVersion uint64
+	userCache.EXPECT().Version().DoAndReturn(func() uint64 { return groupVersion }).AnyTimes()
+	svcCache := cachemock.NewMockServiceCache(ctrl)
+	cacheMgr.EXPECT().Service().Return(svcCache).AnyTimes()
+	svcCache.EXPECT().Version().Return(uint64(0)).AnyTimes()
+	var linked []*model.StrategyDetail
+	strategyCache.EXPECT().GetStrategyDetailsByUID(gomock.Any()).DoAndReturn(func(string) []*model.StrategyDetail {
+		return linked
+	}).AnyTimes()
+
+	// 同一版本下只计算一次
+	strategyCache.EXPECT().IsResourceEditable(user, apisecurity.ResourceType_Namespaces, "ns-1").Return(true).Times(1)
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, checker.checkAction(user, apisecurity.ResourceType_Namespaces, resources, authCtx))
+	}
+
+	// 策略变化后，缓存的放通结果不再生效
+	version++
+	strategyCache.EXPECT().IsResourceEditable(user, apisecurity.ResourceType_Namespaces, "ns-1").Return(false).Times(1)
+	assert.ErrorIs(t, checker.checkAction(user, apisecurity.ResourceType_Namespaces, resources, authCtx),
+		ErrorNotPermission)
+	assert.ErrorIs(t, checker.checkAction(user, apisecurity.ResourceType_Namespaces, resources, authCtx),
+		ErrorNotPermission)
+
+	// 策略缓存变化的通知会清理旧版本的条目
+	assert.Equal(t, 1, checker.decisions.Len())
+	version++
+	err := checker.handleStrategyChange(context.Background(), &eventhub.CacheStrategyEvent{Version: version})
+	assert.NoError(t, err)
+	assert.Equal(t, 0, checker.decisions.Len())
+
+	// 用户组成员变化后，缓存的结果不再生效，通知同样会清理旧版本的条目
+	strategyCache.EXPECT().IsResourceEditable(user, apisecurity.ResourceType_Namespaces, "ns-1").Return(false).Times(1)
+	assert.Error(t, checker.checkAction(user, apisecurity.ResourceType_Namespaces, resources, authCtx))
+	groupVersion++
+	strategyCache.EXPECT().IsResourceEditable(user, apisecurity.ResourceType_Namespaces, "ns-1").Return(true).Times(1)
+	assert.NoError(t, checker.checkAction(user, apisecurity.ResourceType_Namespaces, resources, authCtx))
+	groupVersion++
+	err = checker.handleStrategyChange(context.Background(), &eventhub.CacheUserGroupEvent{Version: groupVersion})
+	assert.NoError(t, err)
+	assert.Equal(t, 0, checker.decisions.Len())
+
+	// 资源关联关系过期后，缓存的放通结果不再生效
+	linked = []*model.StrategyDetail{{ID: "expire", Resources: []model.StrategyResource{{
+		StrategyID: "expire",
+		ResType:    int32(apisecurity.ResourceType_Namespaces),
+		ResID:      "ns-1",
+		ExpireTime: time.Now().Add(200 * time.Millisecond),
+	}}}}
+	strategyCache.EXPECT().IsResourceEditable(user, apisecurity.ResourceType_Namespaces, "ns-1").Return(true).Times(1)
+	assert.NoError(t, checker.checkAction(user, apisecurity.ResourceType_Namespaces, resources, authCtx))
+	assert.NoError(t, checker.checkAction(user, apisecurity.ResourceType_Namespaces, resources, authCtx))
+	time.Sleep(300 * time.Millisecond)
+	strategyCache.EXPECT().IsResourceEditable(user, apisecurity.ResourceType_Namespaces, "ns-1").Return(false).Times(1)
+	assert.Error(t, checker.checkAction(user, apisecurity.ResourceType_Namespaces, resources, authCtx))
+}
@@ -0,0 +1,405 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package policy
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	apisecurity "github.com/polarismesh/specification/source/go/api/v1/security"
+	"go.uber.org/zap"
+
+	"github.com/polarismesh/polaris/auth"
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/common/utils"
+	"github.com/polarismesh/polaris/store"
+)
+
+const (
+	// defaultStrategyBatchSize 批量创建默认策略时，单个存储事务写入的策略数量
+	defaultStrategyBatchSize = 100
+)
+
+// getDefaultStrategy 查询 principal 的默认策略，开启合并查询时同一个 principal 并发的查询只访问一次存储层并共享结果
+// 只合并正在进行中的查询，查询结束后不保留结果，等待中的 key 数量不会超过并发的请求数
+func (svr *Server) getDefaultStrategy(id string, uType model.PrincipalType) (*model.StrategyDetail, error) {
+	if svr.options.DisableDefaultStrategySingleflight {
+		return svr.storage.GetDefaultStrategyDetailByPrincipal(id, uType)
+	}
+	val, err, _ := svr.defaultStrategyFlight.Do(fmt.Sprintf("%d/%s", uType, id), func() (interface{}, error) {
+		return svr.storage.GetDefaultStrategyDetailByPrincipal(id, uType)
+	})
+	if err != nil {
+		return nil, err
+	}
+	strategy, _ := val.(*model.StrategyDetail)
+	return strategy, nil
+}
+
+// handleBulkEnsureDefaultStrategies 批量确保 principal 存在默认策略
+// step 1. 去重并校验 principal 存在
+// step 2. 跳过已经存在默认策略的 principal
+// step 3. 按批次写入缺失的默认策略，最后记录一条汇总的操作记录
+func (svr *Server) handleBulkEnsureDefaultStrategies(ctx context.Context,
+	principals []model.Principal) (*auth.DefaultStrategyReport, error) {
+	report := &auth.DefaultStrategyReport{
+		Created: []model.Principal{},
+		Skipped: []model.Principal{},
+	}
+	pending := make([]*model.StrategyDetail, 0, len(principals))
+	pendingPrincipals := make([]model.Principal, 0, len(principals))
+
+	visited := map[model.Principal]struct{}{}
+	for i := range principals {
+		principal := principals[i]
+		if _, ok := visited[principal]; ok {
+			continue
+		}
+		visited[principal] = struct{}{}
+
+		strategy, err := svr.buildDefaultStrategy(principal)
+		if err != nil {
+			log.Error("[Auth][Strategy] bulk ensure default strategy", utils.RequestID(ctx),
+				zap.String("principal", principal.PrincipalID), zap.Error(err))
+			return nil, err
+		}
+		exist, err := svr.storage.GetDefaultStrategyDetailByPrincipal(principal.PrincipalID, principal.PrincipalRole)
+		if err != nil {
+			log.Error("[Auth][Strategy] get default strategy", utils.RequestID(ctx),
+				zap.String("principal", principal.PrincipalID), zap.Error(err))
+			return nil, err
+		}
+		if exist != nil {
+			report.Skipped = append(report.Skipped, principal)
+			continue
+		}
+		pending = append(pending, strategy)
+		pendingPrincipals = append(pendingPrincipals, principal)
+	}
+
+	for start := 0; start < len(pending); start += defaultStrategyBatchSize {
+		end := start + defaultStrategyBatchSize
+		if end > len(pending) {
+			end = len(pending)
+		}
+		created, skipped, err := svr.addDefaultStrategies(ctx, pending[start:end], pendingPrincipals[start:end])
+		report.Created = append(report.Created, created...)
+		report.Skipped = append(report.Skipped, skipped...)
+		if err != nil {
+			log.Error("[Auth][Strategy] bulk add default strategies", utils.RequestID(ctx),
+				zap.Int("created", len(report.Created)), zap.Error(err))
+			svr.recordBulkDefaultStrategies(ctx, report)
+			return report, err
+		}
+	}
+
+	svr.recordBulkDefaultStrategies(ctx, report)
+	log.Info("[Auth][Strategy] bulk ensure default strategies", utils.RequestID(ctx),
+		zap.Int("created", len(report.Created)), zap.Int("skipped", len(report.Skipped)))
+	return report, nil
+}
+
+// addDefaultStrategies 在同一个存储事务中写入一批默认策略
+// 并发创建导致唯一键冲突时整个批次回滚，此时逐个重新写入，已经被并发创建的 principal 视为已存在
+func (svr *Server) addDefaultStrategies(ctx context.Context, strategies []*model.StrategyDetail,
+	principals []model.Principal) ([]model.Principal, []model.Principal, error) {
+	err := svr.storage.AddStrategies(strategies)
+	if err == nil {
+		return principals, nil, nil
+	}
+	if store.Code(err) != store.DuplicateEntryErr {
+		return nil, nil, err
+	}
+	log.Warn("[Auth][Strategy] default strategies created concurrently, add one by one", utils.RequestID(ctx),
+		zap.Int("count", len(strategies)), zap.Error(err))
+
+	created := make([]model.Principal, 0, len(principals))
+	skipped := make([]model.Principal, 0, len(principals))
+	for i := range strategies {
+		principal := principals[i]
+		exist, err := svr.storage.GetDefaultStrategyDetailByPrincipal(principal.PrincipalID, principal.PrincipalRole)
+		if err != nil {
+			return created, skipped, err
+		}
+		if exist != nil {
+			skipped = append(skipped, principal)
+			continue
+		}
+		err = svr.storage.AddStrategies(strategies[i : i+1])
+		if store.Code(err) == store.DuplicateEntryErr {
+			skipped = append(skipped, principal)
+			continue
+		}
+		if err != nil {
+			return created, skipped, err
+		}
+		created = append(created, principal)
+	}
+	return created, skipped, nil
+}
+
+// buildDefaultStrategy 构建 principal 的默认策略, 与创建用户、用户组时生成的默认策略保持一致
+func (svr *Server) buildDefaultStrategy(principal model.Principal) (*model.StrategyDetail, error) {
+	var name, owner string
+	switch principal.PrincipalRole {
+	case model.PrincipalUser:
+		user := svr.cacheMgr.User().GetUserByID(principal.PrincipalID)
+		if user == nil {
+			return nil, model.ErrorNoUser
+		}
+		name, owner = user.Name, user.Owner
+		if owner == "" {
+			owner = user.ID
+		}
+	case model.PrincipalGroup:
+		group := svr.cacheMgr.User().GetGroup(principal.PrincipalID)
+		if group == nil {
+			return nil, model.ErrorNoUserGroup
+		}
+		name, owner = group.Name, group.Owner
+	default:
+		return nil, ErrorInvalidParameter
+	}
+	return newDefaultStrategy(principal, name, owner), nil
+}
+
+// newDefaultStrategy 构建 principal 的默认策略
+func newDefaultStrategy(principal model.Principal, name, owner string) *model.StrategyDetail {
+	return &model.StrategyDetail{
+		ID:         utils.NewUUID(),
+		Name:       model.BuildDefaultStrategyName(principal.PrincipalRole, name),
+		Action:     apisecurity.AuthAction_READ_WRITE.String(),
+		Default:    true,
+		Owner:      owner,
+		Revision:   utils.NewUUID(),
+		Principals: []model.Principal{principal},
+		Resources:  []model.StrategyResource{},
+		Valid:      true,
+		Comment:    "Default Strategy",
+	}
+}
+
+// recordBulkDefaultStrategies 批量创建默认策略只记录一条汇总的操作记录, 没有新建时不记录
+func (svr *Server) recordBulkDefaultStrategies(ctx context.Context, report *auth.DefaultStrategyReport) {
+	if len(report.Created) == 0 {
+		return
+	}
+	svr.RecordHistory(&model.RecordEntry{
+		ResourceType:  model.RAuthStrategy,
+		ResourceName:  "default strategies",
+		Operator:      utils.ParseOperator(ctx),
+		ChangeSetID:   utils.ParseChangeSetID(ctx),
+		OperationType: model.OCreate,
+		Detail: fmt.Sprintf("created=%d skipped=%d principals=%s", len(report.Created), len(report.Skipped),
+			utils.MustJson(report.Created)),
+		HappenTime: time.Now(),
+	})
+}
+
+// handleRecomputeDefaultStrategies 扫描全部的用户、用户组，重新计算默认策略，可重复执行
+// step 1. 按批次从存储中分页读取 principal，避免长时间占用存储
+// step 2. 为缺失默认策略的 principal 创建默认策略
+// step 3. 同一个 principal 存在多个默认策略时，将其余默认策略的资源合并到存储返回的默认策略中并删除其余默认策略
+// dryRun 为 true 时只返回需要修改的内容，不做任何修改
+func (svr *Server) handleRecomputeDefaultStrategies(ctx context.Context,
+	dryRun bool) (*auth.DefaultStrategyRecomputeReport, error) {
+	report := &auth.DefaultStrategyRecomputeReport{
+		DryRun:  dryRun,
+		Created: []model.Principal{},
+		Merged:  []auth.DefaultStrategyMerge{},
+	}
+	defer svr.recordRecomputeDefaultStrategies(ctx, report)
+
+	for offset := uint32(0); ; offset += defaultStrategyBatchSize {
+		_, users, err := svr.storage.GetUsers(map[string]string{}, offset, defaultStrategyBatchSize)
+		if err != nil {
+			log.Error("[Auth][Strategy] recompute default strategies list users", utils.RequestID(ctx), zap.Error(err))
+			return report, err
+		}
+		batch := make([]recomputePrincipal, 0, len(users))
+		for i := range users {
+			owner := users[i].Owner
+			if owner == "" {
+				owner = users[i].ID
+			}
+			batch = append(batch, recomputePrincipal{
+				principal: model.Principal{PrincipalID: users[i].ID, PrincipalRole: model.PrincipalUser},
+				name:      users[i].Name,
+				owner:     owner,
+			})
+		}
+		if err := svr.recomputeDefaultStrategies(ctx, batch, report); err != nil {
+			return report, err
+		}
+		if len(users) < defaultStrategyBatchSize {
+			break
+		}
+	}
+	for offset := uint32(0); ; offset += defaultStrategyBatchSize {
+		_, groups, err := svr.storage.GetGroups(map[string]string{}, offset, defaultStrategyBatchSize)
+		if err != nil {
+			log.Error("[Auth][Strategy] recompute default strategies list groups", utils.RequestID(ctx), zap.Error(err))
+			return report, err
+		}
+		batch := make([]recomputePrincipal, 0, len(groups))
+		for i := range groups {
+			batch = append(batch, recomputePrincipal{
+				principal: model.Principal{PrincipalID: groups[i].ID, PrincipalRole: model.PrincipalGroup},
+				name:      groups[i].Name,
+				owner:     groups[i].Owner,
+			})
+		}
+		if err := svr.recomputeDefaultStrategies(ctx, batch, report); err != nil {
+			return report, err
+		}
+		if len(groups) < defaultStrategyBatchSize {
+			break
+		}
+	}
+
+	log.Info("[Auth][Strategy] recompute default strategies", utils.RequestID(ctx), zap.Bool("dry-run", dryRun),
+		zap.Int("scanned", report.Scanned), zap.Int("created", len(report.Created)),
+		zap.Int("merged", len(report.Merged)))
+	return report, nil
+}
+
+// recomputePrincipal 重新计算默认策略时，从存储中读取的 principal 信息
+type recomputePrincipal struct {
+	principal model.Principal
+	name      string
+	owner     string
+}
+
+// recomputeDefaultStrategies 处理一个批次的 principal, 缺失的默认策略在同一个存储事务中写入
+func (svr *Server) recomputeDefaultStrategies(ctx context.Context, batch []recomputePrincipal,
+	report *auth.DefaultStrategyRecomputeReport) error {
+	pending := make([]*model.StrategyDetail, 0, len(batch))
+	pendingPrincipals := make([]model.Principal, 0, len(batch))
+	for i := range batch {
+		principal := batch[i].principal
+		report.Scanned++
+		exist, err := svr.storage.GetDefaultStrategyDetailByPrincipal(principal.PrincipalID, principal.PrincipalRole)
+		if err != nil {
+			log.Error("[Auth][Strategy] get default strategy", utils.RequestID(ctx),
+				zap.String("principal", principal.PrincipalID), zap.Error(err))
+			return err
+		}
+		if exist == nil {
+			pending = append(pending, newDefaultStrategy(principal, batch[i].name, batch[i].owner))
+			pendingPrincipals = append(pendingPrincipals, principal)
+			continue
+		}
+		if err := svr.mergeDefaultStrategies(ctx, principal, exist, report); err != nil {
+			return err
+		}
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+	if report.DryRun {
+		report.Created = append(report.Created, pendingPrincipals...)
+		return nil
+	}
+	created, _, err := svr.addDefaultStrategies(ctx, pending, pendingPrincipals)
+	report.Created = append(report.Created, created...)
+	if err != nil {
+		log.Error("[Auth][Strategy] recompute add default strategies", utils.RequestID(ctx),
+			zap.Int("created", len(report.Created)), zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// mergeDefaultStrategies 将 principal 多余的默认策略合并到 kept 中，只处理仅关联了该 principal 的默认策略
+func (svr *Server) mergeDefaultStrategies(ctx context.Context, principal model.Principal,
+	kept *model.StrategyDetail, report *auth.DefaultStrategyRecomputeReport) error {
+	var rules []*model.StrategyDetail
+	if principal.PrincipalRole == model.PrincipalUser {
+		rules = svr.cacheMgr.AuthStrategy().GetStrategyDetailsByUID(principal.PrincipalID)
+	} else {
+		rules = svr.cacheMgr.AuthStrategy().GetStrategyDetailsByGroupID(principal.PrincipalID)
+	}
+
+	type resKey struct {
+		resType int32
+		resID   string
+	}
+	exist := make(map[resKey]struct{}, len(kept.Resources))
+	for _, res := range kept.Resources {
+		exist[resKey{resType: res.ResType, resID: res.ResID}] = struct{}{}
+	}
+	merge := auth.DefaultStrategyMerge{Principal: principal, Kept: kept.ID, Removed: []string{}}
+	moved := make([]model.StrategyResource, 0, 4)
+	for _, rule := range rules {
+		if !rule.Default || rule.ID == kept.ID || len(rule.Principals) != 1 ||
+			rule.Principals[0].PrincipalID != principal.PrincipalID ||
+			rule.Principals[0].PrincipalRole != principal.PrincipalRole {
+			continue
+		}
+		merge.Removed = append(merge.Removed, rule.ID)
+		for _, res := range rule.Resources {
+			res.StrategyID = kept.ID
+			key := resKey{resType: res.ResType, resID: res.ResID}
+			if _, ok := exist[key]; ok {
+				continue
+			}
+			exist[key] = struct{}{}
+			moved = append(moved, res)
+		}
+	}
+	if len(merge.Removed) == 0 {
+		return nil
+	}
+	merge.Resources = len(moved)
+	if !report.DryRun {
+		if len(moved) != 0 {
+			if err := svr.storage.LooseAddStrategyResources(moved); err != nil {
+				log.Error("[Auth][Strategy] recompute merge default strategy resources", utils.RequestID(ctx),
+					zap.String("principal", principal.PrincipalID), zap.Error(err))
+				return err
+			}
+		}
+		for _, id := range merge.Removed {
+			if err := svr.storage.DeleteStrategy(id); err != nil {
+				log.Error("[Auth][Strategy] recompute delete duplicate default strategy", utils.RequestID(ctx),
+					zap.String("principal", principal.PrincipalID), zap.String("strategy", id), zap.Error(err))
+				return err
+			}
+		}
+	}
+	report.Merged = append(report.Merged, merge)
+	return nil
+}
+
+// recordRecomputeDefaultStrategies 重新计算默认策略只记录一条汇总的操作记录, dryRun 以及没有修改时不记录
+func (svr *Server) recordRecomputeDefaultStrategies(ctx context.Context, report *auth.DefaultStrategyRecomputeReport) {
+	if report.DryRun || (len(report.Created) == 0 && len(report.Merged) == 0) {
+		return
+	}
+	svr.RecordHistory(&model.RecordEntry{
+		ResourceType:  model.RAuthStrategy,
+		ResourceName:  "default strategies",
+		Operator:      utils.ParseOperator(ctx),
+		ChangeSetID:   utils.ParseChangeSetID(ctx),
+		OperationType: model.OUpdate,
+		Detail: fmt.Sprintf("recompute scanned=%d created=%d merged=%s", report.Scanned, len(report.Created),
+			utils.MustJson(report.Merged)),
+		HappenTime: time.Now(),
+	})
+}
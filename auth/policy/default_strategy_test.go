@@ -0,0 +1,116 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package policy
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/polarismesh/polaris/common/model"
+	storemock "github.com/polarismesh/polaris/store/mock"
+)
+
+func Test_getDefaultStrategy(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	storage := storemock.NewMockStore(ctrl)
+	svr := &Server{options: DefaultAuthConfig(), storage: storage}
+
+	// concurrentGet 并发查询同一个 principal 的默认策略，第一个查询到达存储层后等待其余的请求进入再返回
+	concurrentGet := func(n int, id string) ([]*model.StrategyDetail, []error) {
+		var (
+			ready   sync.WaitGroup
+			done    sync.WaitGroup
+			results = make([]*model.StrategyDetail, n)
+			errs    = make([]error, n)
+		)
+		ready.Add(n)
+		done.Add(n)
+		for i := 0; i < n; i++ {
+			go func(i int) {
+				defer done.Done()
+				ready.Done()
+				results[i], errs[i] = svr.getDefaultStrategy(id, model.PrincipalUser)
+			}(i)
+		}
+		ready.Wait()
+		done.Wait()
+		return results, errs
+	}
+
+	t.Run("并发查询只访问一次存储层", func(t *testing.T) {
+		var calls int32
+		expect := &model.StrategyDetail{ID: "default-strategy", Default: true}
+		storage.EXPECT().GetDefaultStrategyDetailByPrincipal("user-1", model.PrincipalUser).
+			DoAndReturn(func(string, model.PrincipalType) (*model.StrategyDetail, error) {
+				atomic.AddInt32(&calls, 1)
+				// 等待其余的并发请求进入合并查询
+				time.Sleep(200 * time.Millisecond)
+				return expect, nil
+			}).Times(1)
+
+		results, errs := concurrentGet(64, "user-1")
+		assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+		for i := range results {
+			assert.NoError(t, errs[i])
+			assert.Equal(t, expect, results[i])
+		}
+	})
+
+	t.Run("存储层的错误同样共享", func(t *testing.T) {
+		mockErr := errors.New("mock store error")
+		storage.EXPECT().GetDefaultStrategyDetailByPrincipal("user-2", model.PrincipalUser).
+			DoAndReturn(func(string, model.PrincipalType) (*model.StrategyDetail, error) {
+				time.Sleep(200 * time.Millisecond)
+				return nil, mockErr
+			}).Times(1)
+
+		results, errs := concurrentGet(16, "user-2")
+		for i := range results {
+			assert.Nil(t, results[i])
+			assert.ErrorIs(t, errs[i], mockErr)
+		}
+	})
+
+	t.Run("查询结束后不保留结果", func(t *testing.T) {
+		storage.EXPECT().GetDefaultStrategyDetailByPrincipal("user-3", model.PrincipalUser).
+			Return(nil, nil).Times(2)
+		for i := 0; i < 2; i++ {
+			strategy, err := svr.getDefaultStrategy("user-3", model.PrincipalUser)
+			assert.NoError(t, err)
+			assert.Nil(t, strategy)
+		}
+	})
+
+	t.Run("关闭合并查询", func(t *testing.T) {
+		svr := &Server{options: &AuthConfig{DisableDefaultStrategySingleflight: true}, storage: storage}
+		storage.EXPECT().GetDefaultStrategyDetailByPrincipal("user-4", model.PrincipalUser).
+			Return(nil, nil).Times(3)
+		for i := 0; i < 3; i++ {
+			_, err := svr.getDefaultStrategy("user-4", model.PrincipalUser)
+			assert.NoError(t, err)
+		}
+	})
+}
@@ -0,0 +1,65 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package policy
+
+import (
+	"errors"
+	"fmt"
+
+	apisecurity "github.com/polarismesh/specification/source/go/api/v1/security"
+
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/common/utils"
+)
+
+// ErrorDenyByDefault 资源类型配置为默认拒绝，且该资源没有关联任何鉴权策略
+var ErrorDenyByDefault = errors.New("resource type is deny by default and resource not matched by any strategy")
+
+// checkDenyByDefaultTypes 校验默认拒绝的资源类型, 取值为 Namespaces、Services、ConfigGroups
+func checkDenyByDefaultTypes(names []string) error {
+	for _, name := range names {
+		if _, ok := apisecurity.ResourceType_value[name]; !ok {
+			return fmt.Errorf("[Auth][Checker] unsupported deny by default resource type: %s", name)
+		}
+	}
+	return nil
+}
+
+// isDenyByDefault 资源类型是否配置为默认拒绝
+func (d *DefaultAuthChecker) isDenyByDefault(resType apisecurity.ResourceType) bool {
+	for _, name := range d.conf.DenyByDefaultTypes {
+		if name == resType.String() {
+			return true
+		}
+	}
+	return false
+}
+
+// matchNoStrategy 默认拒绝的资源类型下，资源是否没有被任何鉴权策略匹配
+// 资源关联了具体的策略、被标签选择器匹配、该类型存在 * 策略、或者 principal 的属性规则匹配到该资源时，均视为已被策略匹配
+func (d *DefaultAuthChecker) matchNoStrategy(principal model.Principal,
+	resType apisecurity.ResourceType, resId string) bool {
+	if !d.isDenyByDefault(resType) {
+		return false
+	}
+	strategyCache := d.cacheMgr.AuthStrategy()
+	if d.isResourceLinked(resType, resId) || strategyCache.IsResourceLinkStrategy(resType, utils.MatchAll) {
+		return false
+	}
+	return !d.isAttributeEditable(principal, resType, resId) && !d.readOnlyMatch(principal, resType, resId)
+}
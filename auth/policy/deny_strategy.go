@@ -0,0 +1,114 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+package policy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	apimodel "github.com/polarismesh/specification/source/go/api/v1/model"
+	apisecurity "github.com/polarismesh/specification/source/go/api/v1/security"
+	apiservice "github.com/polarismesh/specification/source/go/api/v1/service_manage"
+	"go.uber.org/zap"
+
+	"github.com/polarismesh/polaris/auth/evaluator"
+	api "github.com/polarismesh/polaris/common/api/v1"
+	"github.com/polarismesh/polaris/common/model"
+	authcommon "github.com/polarismesh/polaris/common/model/auth"
+	commonstore "github.com/polarismesh/polaris/common/store"
+	"github.com/polarismesh/polaris/common/utils"
+)
+
+// ErrorDenyByStrategy principal 的拒绝策略匹配到了请求的资源
+var ErrorDenyByStrategy = errors.New("resource denied by strategy")
+
+// isDenyByStrategy 排除 excluded 中的策略后，principal 以及其所属用户组、角色的拒绝策略是否匹配到该资源
+// 只有优先级更高、且同样直接匹配到该资源的允许策略才能覆盖拒绝策略
+func (d *DefaultAuthChecker) isDenyByStrategy(principal model.Principal,
+	resType apisecurity.ResourceType, resID string, excluded map[string]struct{}) bool {
+	req := evaluator.Request{Principal: principal, ResourceType: resType, ResourceID: resID}
+	decision, denied := d.engine().MatchDeny(req, d.principalStrategies(principal), excluded)
+	if denied {
+		log.Info("[Auth][Checker] resource denied by strategy", zap.String("principal", principal.PrincipalID),
+			zap.String("resource", resID), zap.String("strategy", decision.StrategyID))
+	}
+	return denied
+}
+
+// handleUpdateStrategyEffect 修改鉴权策略的授权效果以及优先级
+// Case 1. 鉴权策略只能被自己的 owner 对应的用户修改
+// Case 2. 默认策略不允许设置为拒绝策略
+// Case 3. effect 为空时视为 ALLOW
+func (svr *Server) handleUpdateStrategyEffect(ctx context.Context, strategyID string,
+	effect model.StrategyEffect, priority int32) *apiservice.Response {
+	requestID := utils.ParseRequestID(ctx)
+	req := &apisecurity.AuthStrategy{Id: utils.NewStringValue(strategyID)}
+	if effect == "" {
+		effect = model.StrategyEffectAllow
+	}
+	if effect != model.StrategyEffectAllow && effect != model.StrategyEffectDeny {
+		return api.NewAuthStrategyResponseWithMsg(apimodel.Code_InvalidParameter,
+			fmt.Sprintf("unsupported strategy effect: %s", effect), req)
+	}
+	if code := svr.checkStrategyEditor(ctx); code != apimodel.Code_ExecuteSuccess {
+		return api.NewAuthStrategyResponse(code, req)
+	}
+
+	strategy, err := svr.storage.GetStrategyDetail(strategyID)
+	if err != nil {
+		log.Error("[Auth][Strategy] get strategy from store", utils.ZapRequestID(requestID),
+			zap.Error(err))
+		return api.NewAuthStrategyResponse(commonstore.StoreCode2APICode(err), req)
+	}
+	if strategy == nil {
+		return api.NewAuthStrategyResponse(apimodel.Code_NotFoundAuthStrategyRule, req)
+	}
+	if code := svr.checkStrategyEditable(ctx, strategy); code != apimodel.Code_ExecuteSuccess {
+		return api.NewAuthStrategyResponse(code, req)
+	}
+	userId := utils.ParseUserID(ctx)
+	if authcommon.ParseUserRole(ctx) != model.AdminUserRole && utils.ParseIsOwner(ctx) && userId != strategy.Owner {
+		log.Error("[Auth][Strategy] update strategy effect denied, current user not owner",
+			utils.ZapRequestID(requestID), zap.String("user", userId),
+			zap.String("owner", strategy.Owner), zap.String("strategy", strategy.ID))
+		return api.NewAuthStrategyResponse(apimodel.Code_NotAllowedAccess, req)
+	}
+	if strategy.Default && effect == model.StrategyEffectDeny {
+		return api.NewAuthStrategyResponseWithMsg(apimodel.Code_BadRequest, "default strategy can't be deny strategy", req)
+	}
+
+	if err := svr.storage.UpdateStrategyEffect(strategy.ID, effect, priority); err != nil {
+		log.Error("[Auth][Strategy] update strategy effect into store", utils.ZapRequestID(requestID),
+			zap.Error(err))
+		return api.NewAuthResponseWithMsg(commonstore.StoreCode2APICode(err), err.Error())
+	}
+
+	log.Info("[Auth][Strategy] update strategy effect into store", utils.ZapRequestID(requestID),
+		zap.String("name", strategy.Name), zap.String("effect", string(effect)), zap.Int32("priority", priority))
+	svr.RecordHistory(&model.RecordEntry{
+		ResourceType:  model.RAuthStrategy,
+		ResourceName:  fmt.Sprintf("%s(%s)", strategy.Name, strategy.ID),
+		OperationType: model.OUpdate,
+		Operator:      utils.ParseOperator(ctx),
+		ChangeSetID:   utils.ParseChangeSetID(ctx),
+		Detail:        utils.MustJson(map[string]interface{}{"effect": effect, "priority": priority}),
+		HappenTime:    time.Now(),
+	})
+	return api.NewAuthStrategyResponse(apimodel.Code_ExecuteSuccess, req)
+}
@@ -0,0 +1,192 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+package policy_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	apimodel "github.com/polarismesh/specification/source/go/api/v1/model"
+	apisecurity "github.com/polarismesh/specification/source/go/api/v1/security"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/polarismesh/polaris/auth"
+	"github.com/polarismesh/polaris/auth/policy"
+	defaultuser "github.com/polarismesh/polaris/auth/user"
+	"github.com/polarismesh/polaris/cache"
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/common/utils"
+)
+
+func Test_DenyStrategy(t *testing.T) {
+	reset(true)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	users := createMockUser(10)
+	groups := createMockUserGroup(users)
+	namespaces := createMockNamespace(len(users)+len(groups)+10, users[0].ID)
+	services := createMockService(namespaces)
+	serviceMap := convertServiceSliceToMap(services)
+	blocked, exempt, other := namespaces[0].Name, namespaces[1].Name, namespaces[2].Name
+
+	newStrategy := func(name string, principal model.Principal, effect model.StrategyEffect, priority int32,
+		resIDs ...string) *model.StrategyDetail {
+		rule := &model.StrategyDetail{
+			ID:         utils.NewUUID(),
+			Name:       name,
+			Action:     apisecurity.AuthAction_READ_WRITE.String(),
+			Owner:      users[0].ID,
+			Principals: []model.Principal{principal},
+			Effect:     effect,
+			Priority:   priority,
+			Valid:      true,
+			ModifyTime: time.Now(),
+		}
+		for _, resID := range resIDs {
+			rule.Resources = append(rule.Resources, model.StrategyResource{StrategyID: rule.ID,
+				ResType: int32(apisecurity.ResourceType_Namespaces), ResID: resID})
+		}
+		return rule
+	}
+	group := model.Principal{PrincipalID: groups[1].ID, PrincipalRole: model.PrincipalGroup}
+	user := model.Principal{PrincipalID: users[1].ID, PrincipalRole: model.PrincipalUser}
+	override := newStrategy("deny-test-override", user, model.StrategyEffectAllow, 20, exempt)
+	strategies := []*model.StrategyDetail{
+		newStrategy("deny-test-allow-all", group, model.StrategyEffectAllow, 0, utils.MatchAll),
+		newStrategy("deny-test-deny", group, model.StrategyEffectDeny, 10, blocked, exempt),
+		override,
+	}
+
+	cfg, storage := initCache(ctrl)
+	storage.EXPECT().GetUsersForCache(gomock.Any(), gomock.Any()).AnyTimes().Return(users, nil)
+	storage.EXPECT().GetGroupsForCache(gomock.Any(), gomock.Any()).AnyTimes().Return(groups, nil)
+	storage.EXPECT().GetUserSessionsForCache(gomock.Any(), gomock.Any()).AnyTimes().Return(nil, nil)
+	storage.EXPECT().GetRolesForCache(gomock.Any(), gomock.Any()).AnyTimes().Return(nil, nil)
+	storage.EXPECT().GetStrategyDetailsForCache(gomock.Any(), gomock.Any()).AnyTimes().Return(strategies, nil)
+	storage.EXPECT().GetMoreNamespaces(gomock.Any()).AnyTimes().Return(namespaces, nil)
+	storage.EXPECT().GetMoreServices(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes().Return(serviceMap, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cacheMgr, err := cache.TestCacheInitialize(ctx, cfg, storage)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		cancel()
+		cacheMgr.Close()
+	})
+
+	_, proxySvr, err := defaultuser.BuildServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	proxySvr.Initialize(&auth.Config{
+		User: &auth.UserConfig{
+			Name:   auth.DefaultUserMgnPluginName,
+			Option: map[string]interface{}{"salt": "polarismesh@2021"},
+		},
+	}, storage, cacheMgr)
+
+	_, svr, err := newPolicyServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := svr.Initialize(&auth.Config{
+		Strategy: &auth.StrategyConfig{Name: auth.DefaultPolicyPluginName},
+	}, storage, cacheMgr, proxySvr); err != nil {
+		t.Fatal(err)
+	}
+	_ = cacheMgr.TestUpdate()
+
+	checker := svr.GetAuthChecker()
+	checker.(*policy.DefaultAuthChecker).SetConfig(&policy.AuthConfig{ConsoleOpen: true, ConsoleStrict: true})
+
+	check := func(user *model.User, op model.ResourceOperation, namespace string) (bool, error) {
+		authCtx := model.NewAcquireContext(
+			model.WithRequestContext(context.WithValue(context.Background(), utils.ContextAuthTokenKey, user.Token)),
+			model.WithMethod("Test_DenyStrategy"),
+			model.WithOperation(op),
+			model.WithModule(model.DiscoverModule),
+			model.WithAccessResources(map[apisecurity.ResourceType][]model.ResourceEntry{
+				apisecurity.ResourceType_Namespaces: {{ID: namespace, Owner: users[0].ID}},
+			}),
+		)
+		return checker.CheckConsolePermission(authCtx)
+	}
+
+	t.Run("拒绝策略优先于更宽泛的允许策略", func(t *testing.T) {
+		pass, err := check(users[1], model.Read, blocked)
+		assert.False(t, pass)
+		assert.True(t, errors.Is(err, policy.ErrorDenyByStrategy), err)
+		pass, err = check(users[1], model.Modify, blocked)
+		assert.False(t, pass)
+		assert.True(t, errors.Is(err, policy.ErrorDenyByStrategy), err)
+		pass, err = check(users[1], model.Modify, other)
+		assert.True(t, pass, err)
+	})
+
+	t.Run("优先级更高的允许策略覆盖拒绝策略", func(t *testing.T) {
+		pass, err := check(users[1], model.Modify, exempt)
+		assert.True(t, pass, err)
+	})
+
+	t.Run("能力矩阵同样应用拒绝策略", func(t *testing.T) {
+		resources := []auth.CapabilityResource{
+			{Type: apisecurity.ResourceType_Namespaces, ID: blocked},
+			{Type: apisecurity.ResourceType_Namespaces, ID: exempt},
+			{Type: apisecurity.ResourceType_Namespaces, ID: other},
+		}
+		operations := []model.ResourceOperation{model.Read, model.Modify}
+		ownerCtx := context.WithValue(context.Background(), utils.ContextAuthTokenKey, users[0].Token)
+		matrix, err := svr.CapabilityMatrix(ownerCtx, []model.Principal{user}, resources, operations)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.False(t, matrix.Allowed(0, 0, 0))
+		for r := range resources {
+			for o := range operations {
+				expect, _ := check(users[1], operations[o], resources[r].ID)
+				assert.Equal(t, expect, matrix.Allowed(0, r, o), "resource %s operation %d",
+					resources[r].ID, operations[o])
+			}
+		}
+	})
+
+	t.Run("拒绝策略不影响其他用户", func(t *testing.T) {
+		pass, err := check(users[2], model.Modify, blocked)
+		assert.True(t, pass, err)
+	})
+
+	t.Run("修改策略的授权效果", func(t *testing.T) {
+		ownerCtx := context.WithValue(context.Background(), utils.ContextAuthTokenKey, users[0].Token)
+		storage.EXPECT().GetStrategyDetail(override.ID).Return(override, nil).AnyTimes()
+		storage.EXPECT().UpdateStrategyEffect(override.ID, model.StrategyEffectDeny, int32(5)).Return(nil)
+		resp := svr.UpdateStrategyEffect(ownerCtx, override.ID, model.StrategyEffectDeny, 5)
+		assert.Equal(t, uint32(apimodel.Code_ExecuteSuccess), resp.GetCode().GetValue(), resp.GetInfo().GetValue())
+
+		resp = svr.UpdateStrategyEffect(ownerCtx, override.ID, "BLOCK", 5)
+		assert.Equal(t, uint32(apimodel.Code_InvalidParameter), resp.GetCode().GetValue())
+
+		subCtx := context.WithValue(context.Background(), utils.ContextAuthTokenKey, users[2].Token)
+		resp = svr.UpdateStrategyEffect(subCtx, override.ID, model.StrategyEffectDeny, 5)
+		assert.Equal(t, uint32(apimodel.Code_OperationRoleForbidden), resp.GetCode().GetValue())
+	})
+}
@@ -0,0 +1,103 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package policy
+
+import (
+	"fmt"
+	"time"
+
+	apisecurity "github.com/polarismesh/specification/source/go/api/v1/security"
+	"go.uber.org/zap"
+
+	"github.com/polarismesh/polaris/common/metrics"
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/common/utils"
+	"github.com/polarismesh/polaris/plugin"
+)
+
+// expireGrace 有时限的资源关联关系过期后的宽限期，避免过期瞬间中断正在进行中的长时间操作
+// 宽限期内仍然授予权限，但是每次放通都会记录告警的操作记录以及指标，超出宽限期后拒绝
+type expireGrace struct {
+	history   plugin.History
+	canonical *model.ResourceIDCanonicalizer
+	now       func() time.Time
+}
+
+func newExpireGrace(history plugin.History, canonical *model.ResourceIDCanonicalizer) *expireGrace {
+	return &expireGrace{history: history, canonical: canonical, now: time.Now}
+}
+
+// allow rules 中是否存在已经过期、但是仍在宽限期内的资源关联关系，excluded 中的策略以及只读策略不授予权限
+func (g *expireGrace) allow(authCtx *model.AcquireContext, window time.Duration, principal model.Principal,
+	resType apisecurity.ResourceType, resID string, rules []*model.StrategyDetail,
+	excluded map[string]struct{}) bool {
+	if window <= 0 {
+		return false
+	}
+	now := g.now()
+	for _, rule := range rules {
+		if _, skip := excluded[rule.ID]; skip || rule.IsReadOnly() || rule.IsDeny() {
+			continue
+		}
+		for _, res := range rule.Resources {
+			if res.ResType != int32(resType) || (!g.canonical.Equal(res.ResID, resID) && res.ResID != utils.MatchAll) {
+				continue
+			}
+			if !res.IsExpired(now) || !now.Before(res.ExpireTime.Add(window)) {
+				continue
+			}
+			g.warn(authCtx, principal, rule, res, resID)
+			return true
+		}
+	}
+	return false
+}
+
+func (g *expireGrace) warn(authCtx *model.AcquireContext, principal model.Principal, rule *model.StrategyDetail,
+	res model.StrategyResource, resID string) {
+	ctx := authCtx.GetRequestContext()
+	metrics.ReportExpireGraceAllow()
+	log.Warn("[Auth][Checker] allow operation by expired resource link within grace window", utils.RequestID(ctx),
+		zap.String("principal", principal.PrincipalID), zap.String("strategy", rule.ID),
+		zap.String("method", authCtx.GetMethod()), zap.String("resource", resID),
+		zap.Time("expire-time", res.ExpireTime))
+	if g.history == nil {
+		return
+	}
+	g.history.Record(&model.RecordEntry{
+		ResourceType:  model.RAuthStrategy,
+		ResourceName:  fmt.Sprintf("%s(%s)", rule.Name, rule.ID),
+		Operator:      utils.ParseOperator(ctx),
+		ChangeSetID:   utils.ParseChangeSetID(ctx),
+		OperationType: model.OExpireGrace,
+		Detail: fmt.Sprintf("principal=%s method=%s resource=%s/%s expire=%s", principal.PrincipalID,
+			authCtx.GetMethod(), apisecurity.ResourceType(res.ResType).String(), resID,
+			res.ExpireTime.Format(time.RFC3339)),
+		HappenTime: g.now(),
+	})
+}
+
+// inExpireGrace principal 对资源的授权是否刚刚过期，并且仍在宽限期内
+func (d *DefaultAuthChecker) inExpireGrace(authCtx *model.AcquireContext, principal model.Principal,
+	resType apisecurity.ResourceType, resID string, excluded map[string]struct{}) bool {
+	if d.grace == nil || d.conf.ExpireGraceInSecs <= 0 {
+		return false
+	}
+	return d.grace.allow(authCtx, time.Duration(d.conf.ExpireGraceInSecs)*time.Second, principal, resType, resID,
+		d.principalStrategies(principal), excluded)
+}
@@ -0,0 +1,101 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package policy
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	apisecurity "github.com/polarismesh/specification/source/go/api/v1/security"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/common/utils"
+)
+
+func Test_expireGrace(t *testing.T) {
+	now := time.Now()
+	history := &recordCollector{}
+	grace := newExpireGrace(history, nil)
+	grace.now = func() time.Time { return now }
+
+	principal := model.Principal{PrincipalID: "user-1", PrincipalRole: model.PrincipalUser}
+	newRule := func(id string, expireTime time.Time) *model.StrategyDetail {
+		return &model.StrategyDetail{
+			ID:     id,
+			Name:   id,
+			Action: apisecurity.AuthAction_READ_WRITE.String(),
+			Resources: []model.StrategyResource{{
+				StrategyID: id,
+				ResType:    int32(apisecurity.ResourceType_Services),
+				ResID:      "service-1",
+				ExpireTime: expireTime,
+			}},
+		}
+	}
+	authCtx := model.NewAcquireContext(
+		model.WithRequestContext(context.Background()),
+		model.WithMethod("Test_expireGrace"),
+		model.WithOperation(model.Modify),
+	)
+	allow := func(window time.Duration, rules ...*model.StrategyDetail) bool {
+		return grace.allow(authCtx, window, principal, apisecurity.ResourceType_Services, "service-1", rules, nil)
+	}
+
+	t.Run("宽限期内放通并记录告警", func(t *testing.T) {
+		assert.True(t, allow(time.Minute, newRule("strategy-1", now.Add(-30*time.Second))))
+		if assert.Equal(t, 1, len(history.entries)) {
+			assert.Equal(t, model.OExpireGrace, history.entries[0].OperationType)
+			assert.Contains(t, history.entries[0].Detail, "user-1")
+			assert.Contains(t, history.entries[0].Detail, "service-1")
+		}
+	})
+
+	t.Run("超出宽限期拒绝", func(t *testing.T) {
+		history.entries = nil
+		assert.False(t, allow(time.Minute, newRule("strategy-1", now.Add(-time.Minute))))
+		assert.False(t, allow(time.Minute, newRule("strategy-1", now.Add(-time.Hour))))
+		assert.Equal(t, 0, len(history.entries))
+	})
+
+	t.Run("没有宽限期以及不适用宽限期的授权", func(t *testing.T) {
+		history.entries = nil
+		expired := newRule("strategy-1", now.Add(-time.Second))
+		// 默认没有宽限期
+		assert.False(t, allow(0, expired))
+		// 未过期以及永不过期的关联关系由鉴权策略判断
+		assert.False(t, allow(time.Minute, newRule("strategy-2", now.Add(time.Minute))))
+		assert.False(t, allow(time.Minute, newRule("strategy-3", time.Time{})))
+		// 只读策略以及请求条件不满足的策略不授予权限
+		readOnly := newRule("strategy-4", now.Add(-time.Second))
+		readOnly.Action = apisecurity.AuthAction_ONLY_READ.String()
+		assert.False(t, allow(time.Minute, readOnly))
+		assert.False(t, grace.allow(authCtx, time.Minute, principal, apisecurity.ResourceType_Services, "service-1",
+			[]*model.StrategyDetail{expired}, map[string]struct{}{expired.ID: {}}))
+		// 其他资源
+		assert.False(t, grace.allow(authCtx, time.Minute, principal, apisecurity.ResourceType_Services, "service-2",
+			[]*model.StrategyDetail{expired}, nil))
+		assert.Equal(t, 0, len(history.entries))
+
+		// 关联全部资源的授权同样适用宽限期
+		all := newRule("strategy-5", now.Add(-time.Second))
+		all.Resources[0].ResID = utils.MatchAll
+		assert.True(t, allow(time.Minute, all))
+	})
+}
@@ -0,0 +1,177 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package policy
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	apisecurity "github.com/polarismesh/specification/source/go/api/v1/security"
+	"go.uber.org/zap"
+
+	"github.com/polarismesh/polaris/auth"
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/common/utils"
+)
+
+// grantMatchOrder 授权路径的排序权重，匹配方式越精确越靠前
+var grantMatchOrder = map[auth.GrantMatch]int{
+	auth.GrantMatchExact:     0,
+	auth.GrantMatchAttribute: 1,
+	auth.GrantMatchLabel:     2,
+	auth.GrantMatchAll:       3,
+}
+
+// handleExplainGrants 计算 principal 对资源的写权限，并给出全部授予权限的路径
+func (svr *Server) handleExplainGrants(ctx context.Context, principal model.Principal,
+	resource auth.CapabilityResource) (*auth.GrantExplanation, error) {
+	switch resource.Type {
+	case apisecurity.ResourceType_Namespaces, apisecurity.ResourceType_Services,
+		apisecurity.ResourceType_ConfigGroups:
+	default:
+		return nil, fmt.Errorf("%w: unsupported resource type %s", ErrorInvalidParameter, resource.Type)
+	}
+	if _, err := svr.capabilityPrincipalDisable(principal); err != nil {
+		log.Error("[Auth][Grant] principal not found", utils.RequestID(ctx),
+			zap.String("principal", principal.PrincipalID), zap.Error(err))
+		return nil, err
+	}
+
+	paths, err := svr.checker.grantPaths(principal, resource.Type, resource.ID)
+	if err != nil {
+		log.Error("[Auth][Grant] collect grant paths", utils.RequestID(ctx),
+			zap.String("principal", principal.PrincipalID), zap.String("resource", resource.ID), zap.Error(err))
+		return nil, err
+	}
+	unrestricted := !svr.checker.isResourceLinked(resource.Type, resource.ID)
+	return &auth.GrantExplanation{
+		Principal:    principal,
+		Resource:     resource,
+		Allowed:      unrestricted || svr.checker.computeResourceEditable(principal, resource.Type, resource.ID),
+		Unrestricted: unrestricted,
+		Paths:        paths,
+	}, nil
+}
+
+// grantPaths principal 本身以及其所在用户组的鉴权策略中，授予了资源写权限的全部路径
+// 已经过期的资源关联关系以及只读策略不会授予写权限，不作为授权路径
+func (d *DefaultAuthChecker) grantPaths(principal model.Principal,
+	resType apisecurity.ResourceType, resID string) ([]auth.GrantPath, error) {
+	type boundRules struct {
+		via   model.Principal
+		rules []*model.StrategyDetail
+	}
+	strategyCache := d.cacheMgr.AuthStrategy()
+	var bounds []boundRules
+	if principal.PrincipalRole == model.PrincipalUser {
+		bounds = append(bounds, boundRules{via: principal,
+			rules: strategyCache.GetStrategyDetailsByUID(principal.PrincipalID)})
+		for _, groupID := range d.cacheMgr.User().GetUserLinkGroupIds(principal.PrincipalID) {
+			bounds = append(bounds, boundRules{
+				via:   model.Principal{PrincipalID: groupID, PrincipalRole: model.PrincipalGroup},
+				rules: strategyCache.GetStrategyDetailsByGroupID(groupID),
+			})
+		}
+	} else {
+		bounds = append(bounds, boundRules{via: principal,
+			rules: strategyCache.GetStrategyDetailsByGroupID(principal.PrincipalID)})
+	}
+
+	var (
+		paths  = []auth.GrantPath{}
+		attrs  map[string]string
+		loaded bool
+		// 资源所属的命名空间以及标签，只在存在标签选择器时加载一次
+		namespace    string
+		labels       map[string]string
+		labelsLoaded bool
+		now          = time.Now()
+	)
+	for _, bound := range bounds {
+		for _, rule := range bound.rules {
+			if rule.IsReadOnly() || rule.IsDeny() {
+				continue
+			}
+			_, conditional, _ := strategyConditions(rule)
+			for _, res := range rule.Resources {
+				if res.ResType != int32(resType) || res.IsExpired(now) {
+					continue
+				}
+				var match auth.GrantMatch
+				switch {
+				case d.conf.ResourceIDCanonical.Equal(res.ResID, resID):
+					match = auth.GrantMatchExact
+				case res.ResID == utils.MatchAll:
+					match = auth.GrantMatchAll
+				case IsAttributeResource(res.ResID) && resType == apisecurity.ResourceType_Services:
+					predicates, err := parseAttributeRule(res.ResID)
+					if err != nil {
+						continue
+					}
+					if !loaded {
+						if attrs, err = d.loadResourceAttributes(resType, resID); err != nil {
+							return nil, err
+						}
+						loaded = true
+					}
+					if !matchAttributes(predicates, attrs) {
+						continue
+					}
+					match = auth.GrantMatchAttribute
+				case res.IsLabelSelector():
+					selectNs, predicates, err := parseLabelSelector(res.ResID)
+					if err != nil {
+						continue
+					}
+					if !labelsLoaded {
+						if namespace, labels, err = d.loadResourceLabels(resType, resID); err != nil {
+							return nil, err
+						}
+						labelsLoaded = true
+					}
+					if (selectNs != utils.MatchAll && selectNs != namespace) || !matchAttributes(predicates, labels) {
+						continue
+					}
+					match = auth.GrantMatchLabel
+				default:
+					continue
+				}
+				paths = append(paths, auth.GrantPath{
+					StrategyID:   rule.ID,
+					StrategyName: rule.Name,
+					Default:      rule.Default,
+					Via:          bound.via,
+					Match:        match,
+					Rule:         res.ResID,
+					Conditional:  conditional,
+					ExpireTime:   res.ExpireTime,
+				})
+			}
+		}
+	}
+	sort.SliceStable(paths, func(i, j int) bool {
+		iDirect, jDirect := paths[i].Via == principal, paths[j].Via == principal
+		if iDirect != jDirect {
+			return iDirect
+		}
+		return grantMatchOrder[paths[i].Match] < grantMatchOrder[paths[j].Match]
+	})
+	return paths, nil
+}
@@ -0,0 +1,259 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package policy
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/polarismesh/polaris/auth"
+	"github.com/polarismesh/polaris/plugin"
+)
+
+const (
+	// defaultHealthCacheStale 策略缓存默认的过期时间
+	defaultHealthCacheStale = time.Minute
+	// defaultHealthDecisionErrorRate 鉴权决策默认的失败率阈值
+	defaultHealthDecisionErrorRate = 0.05
+	// healthMinDecisions 统计窗口内的决策数少于该值时不计算失败率，避免少量请求导致误报
+	healthMinDecisions = 20
+	// healthStoreSlow 存储响应超过该时间时视为降级
+	healthStoreSlow = time.Second
+	// healthQueueBusyRatio 队列使用率超过该比例时视为降级
+	healthQueueBusyRatio = 0.8
+	// decisionStatsWindow 鉴权决策失败率的统计窗口
+	decisionStatsWindow = time.Minute
+)
+
+// decisionStats 按照固定窗口统计鉴权决策的次数以及无法完成判断的次数，失败率取当前窗口与上一个窗口之和
+type decisionStats struct {
+	lock       sync.Mutex
+	start      time.Time
+	total      int64
+	failed     int64
+	lastTotal  int64
+	lastFailed int64
+}
+
+func newDecisionStats() *decisionStats {
+	return &decisionStats{start: time.Now()}
+}
+
+// observe 记录一次鉴权决策
+func (s *decisionStats) observe(now time.Time) {
+	if s == nil {
+		return
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.roll(now)
+	s.total++
+}
+
+// fail 将已经记录的一次鉴权决策标记为失败
+func (s *decisionStats) fail(now time.Time) {
+	if s == nil {
+		return
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.roll(now)
+	s.failed++
+}
+
+func (s *decisionStats) snapshot(now time.Time) (int64, int64) {
+	if s == nil {
+		return 0, 0
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.roll(now)
+	return s.total + s.lastTotal, s.failed + s.lastFailed
+}
+
+// roll 进入新的统计窗口，调用方需要持有锁
+func (s *decisionStats) roll(now time.Time) {
+	elapsed := now.Sub(s.start)
+	if elapsed < decisionStatsWindow {
+		return
+	}
+	s.lastTotal, s.lastFailed = s.total, s.failed
+	if elapsed >= 2*decisionStatsWindow {
+		s.lastTotal, s.lastFailed = 0, 0
+	}
+	s.total, s.failed = 0, 0
+	s.start = now
+}
+
+// handleHealthReport 生成鉴权模块的健康报告
+func (svr *Server) handleHealthReport(ctx context.Context) (*auth.AuthHealth, error) {
+	return svr.healthReport(time.Now()), nil
+}
+
+// healthReport 依次检查各个组件，任意组件降级时整体为降级
+func (svr *Server) healthReport(now time.Time) *auth.AuthHealth {
+	report := &auth.AuthHealth{
+		Status:      auth.HealthUp,
+		GeneratedAt: now,
+		Components: []auth.ComponentHealth{
+			svr.strategyCacheHealth(now),
+			svr.storeHealth(),
+			svr.historyHealth(),
+			svr.checker.queueHealth(),
+			svr.checker.decisionHealth(now),
+		},
+	}
+	for i := range report.Components {
+		if report.Components[i].Status != auth.HealthUp {
+			report.Status = auth.HealthDegraded
+		}
+	}
+	return report
+}
+
+func newComponentHealth(name string) auth.ComponentHealth {
+	return auth.ComponentHealth{
+		Name:    name,
+		Status:  auth.HealthUp,
+		Details: map[string]interface{}{},
+	}
+}
+
+// strategyCacheHealth 策略缓存超过 HealthCacheStaleInSecs 未同步，或者已经降级为直接查询存储时视为降级
+func (svr *Server) strategyCacheHealth(now time.Time) auth.ComponentHealth {
+	comp := newComponentHealth(auth.HealthComponentStrategyCache)
+	cache := svr.cacheMgr.AuthStrategy()
+	comp.Details["version"] = cache.Version()
+	if _, ok := cache.(*storeStrategyCache); ok {
+		comp.Degrade("strategy cache degraded, auth check query store directly")
+		return comp
+	}
+	fetcher, ok := cache.(interface{ LastFetchTime() time.Time })
+	if !ok {
+		return comp
+	}
+	stale := defaultHealthCacheStale
+	if svr.options.HealthCacheStaleInSecs > 0 {
+		stale = time.Duration(svr.options.HealthCacheStaleInSecs) * time.Second
+	}
+	lastFetch := fetcher.LastFetchTime()
+	age := now.Sub(lastFetch)
+	comp.Details["lastFetchTime"] = lastFetch
+	comp.Details["age"] = age.String()
+	if age > stale {
+		comp.Degrade(fmt.Sprintf("strategy cache not synced for %s, exceed %s", age, stale))
+	}
+	return comp
+}
+
+// storeHealth 存储无法访问或者响应超过 healthStoreSlow 时视为降级
+func (svr *Server) storeHealth() auth.ComponentHealth {
+	comp := newComponentHealth(auth.HealthComponentStore)
+	start := time.Now()
+	_, err := svr.storage.GetUnixSecond(0)
+	latency := time.Since(start)
+	comp.Details["latency"] = latency.String()
+	if err != nil {
+		comp.Degrade(fmt.Sprintf("store unreachable: %s", err.Error()))
+		return comp
+	}
+	if latency > healthStoreSlow {
+		comp.Degrade(fmt.Sprintf("store latency %s exceed %s", latency, healthStoreSlow))
+	}
+	return comp
+}
+
+// historyHealth 没有可用的历史记录插件，或者任意插件最近一次写入失败、死信队列不为空时视为降级
+func (svr *Server) historyHealth() auth.ComponentHealth {
+	comp := newComponentHealth(auth.HealthComponentHistory)
+	if auth.IsHistoryAbsent(svr.history) {
+		comp.Degrade("no history plugin available")
+		return comp
+	}
+	composite, ok := svr.history.(*plugin.CompositeHistory)
+	if !ok {
+		return comp
+	}
+	sinks := composite.SinkStatus()
+	comp.Details["sinks"] = sinks
+	for i := range sinks {
+		sink := sinks[i]
+		if sink.Down {
+			comp.Degrade(fmt.Sprintf("history sink %s down: %s", sink.Name, sink.LastError))
+		}
+		if sink.DeadLetterSize > 0 {
+			comp.Degrade(fmt.Sprintf("history sink %s has %d dead letter records", sink.Name, sink.DeadLetterSize))
+		}
+		if isQueueBusy(sink.QueueDepth, sink.QueueCapacity) {
+			comp.Degrade(fmt.Sprintf("history sink %s queue busy: %d/%d", sink.Name, sink.QueueDepth,
+				sink.QueueCapacity))
+		}
+	}
+	return comp
+}
+
+// queueHealth 异步鉴权以及资源使用记录的等待队列使用率超过 healthQueueBusyRatio 时视为降级
+func (d *DefaultAuthChecker) queueHealth() auth.ComponentHealth {
+	comp := newComponentHealth(auth.HealthComponentQueues)
+	type queueUsage struct {
+		name     string
+		depth    int
+		capacity int
+	}
+	var queues []queueUsage
+	if d.async != nil {
+		queues = append(queues, queueUsage{"asyncCheck", len(d.async.queue), cap(d.async.queue)})
+	}
+	if d.usage != nil {
+		queues = append(queues, queueUsage{"lastUsed", len(d.usage.queue), cap(d.usage.queue)})
+	}
+	for _, queue := range queues {
+		comp.Details[queue.name] = map[string]int{"depth": queue.depth, "capacity": queue.capacity}
+		if isQueueBusy(queue.depth, queue.capacity) {
+			comp.Degrade(fmt.Sprintf("%s queue busy: %d/%d", queue.name, queue.depth, queue.capacity))
+		}
+	}
+	return comp
+}
+
+// decisionHealth 统计窗口内鉴权决策的失败率超过 HealthDecisionErrorRate 时视为降级
+func (d *DefaultAuthChecker) decisionHealth(now time.Time) auth.ComponentHealth {
+	comp := newComponentHealth(auth.HealthComponentDecisions)
+	total, failed := d.stats.snapshot(now)
+	comp.Details["total"] = total
+	comp.Details["failed"] = failed
+	if total < healthMinDecisions {
+		return comp
+	}
+	rate := float64(failed) / float64(total)
+	comp.Details["errorRate"] = rate
+	threshold := defaultHealthDecisionErrorRate
+	if d.conf.HealthDecisionErrorRate > 0 {
+		threshold = d.conf.HealthDecisionErrorRate
+	}
+	if rate > threshold {
+		comp.Degrade(fmt.Sprintf("decision error rate %.4f exceed %.4f", rate, threshold))
+	}
+	return comp
+}
+
+func isQueueBusy(depth, capacity int) bool {
+	return capacity > 0 && float64(depth) >= float64(capacity)*healthQueueBusyRatio
+}
@@ -0,0 +1,161 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package policy
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/polarismesh/polaris/auth"
+	cachetypes "github.com/polarismesh/polaris/cache/api"
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/plugin"
+	"github.com/polarismesh/polaris/store/mock"
+)
+
+type healthStrategyCache struct {
+	cachetypes.StrategyCache
+	lastFetch time.Time
+}
+
+func (c *healthStrategyCache) Version() uint64 {
+	return 1
+}
+
+func (c *healthStrategyCache) LastFetchTime() time.Time {
+	return c.lastFetch
+}
+
+type healthCacheMgr struct {
+	cachetypes.CacheManager
+	strategy *healthStrategyCache
+}
+
+func (m *healthCacheMgr) AuthStrategy() cachetypes.StrategyCache {
+	return m.strategy
+}
+
+// downHistory 始终写入失败的历史记录插件
+type downHistory struct {
+	recordCollector
+}
+
+func (h *downHistory) Name() string {
+	return "downHistory"
+}
+
+func (h *downHistory) TryRecord(entry *model.RecordEntry) error {
+	return errors.New("history backend unreachable")
+}
+
+func Test_HealthReport(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	storage := mock.NewMockStore(ctrl)
+	storage.EXPECT().GetUnixSecond(gomock.Any()).Return(time.Now().Unix(), nil).AnyTimes()
+
+	history := plugin.NewCompositeHistory(nil)
+	assert.NoError(t, history.Initialize(&plugin.ConfigEntry{Option: map[string]interface{}{
+		"sinkRetryTimes":          0,
+		"deadLetterDrainInterval": "0s",
+	}}))
+	history.AddSink(&recordCollector{}, 8)
+	defer func() {
+		_ = history.Destroy()
+	}()
+
+	now := time.Now()
+	conf := &AuthConfig{}
+	strategyCache := &healthStrategyCache{lastFetch: now}
+	svr := &Server{
+		options:  conf,
+		storage:  storage,
+		history:  history,
+		cacheMgr: &healthCacheMgr{strategy: strategyCache},
+		checker: &DefaultAuthChecker{
+			conf:  conf,
+			async: newAsyncCheckPool(1, 4, nil),
+			stats: newDecisionStats(),
+		},
+	}
+
+	t.Run("全部组件健康", func(t *testing.T) {
+		report := svr.healthReport(now)
+		assert.Equal(t, auth.HealthUp, report.Status)
+		assert.Equal(t, 5, len(report.Components))
+		for _, comp := range report.Components {
+			assert.Equal(t, auth.HealthUp, comp.Status, comp.Name)
+			assert.Empty(t, comp.Reasons, comp.Name)
+		}
+	})
+
+	t.Run("策略缓存过期以及历史记录插件不可用", func(t *testing.T) {
+		strategyCache.lastFetch = now.Add(-10 * time.Minute)
+		history.AddSink(&downHistory{}, 8)
+		history.Record(&model.RecordEntry{ResourceName: "health"})
+		assert.Eventually(t, func() bool {
+			for _, sink := range history.SinkStatus() {
+				if sink.Name == "downHistory" {
+					return sink.Down && sink.DeadLetterSize == 1
+				}
+			}
+			return false
+		}, time.Second, 10*time.Millisecond)
+
+		report := svr.healthReport(now)
+		assert.Equal(t, auth.HealthDegraded, report.Status)
+		cacheHealth := report.Component(auth.HealthComponentStrategyCache)
+		if assert.NotNil(t, cacheHealth) {
+			assert.Equal(t, auth.HealthDegraded, cacheHealth.Status)
+			assert.Equal(t, 1, len(cacheHealth.Reasons))
+			assert.Contains(t, cacheHealth.Reasons[0], "strategy cache not synced")
+		}
+		historyHealth := report.Component(auth.HealthComponentHistory)
+		if assert.NotNil(t, historyHealth) {
+			assert.Equal(t, auth.HealthDegraded, historyHealth.Status)
+			assert.Equal(t, 2, len(historyHealth.Reasons))
+			assert.Contains(t, historyHealth.Reasons[0], "downHistory down")
+			assert.Contains(t, historyHealth.Reasons[0], "history backend unreachable")
+		}
+		// 其他组件不受影响
+		for _, name := range []string{auth.HealthComponentStore, auth.HealthComponentQueues,
+			auth.HealthComponentDecisions} {
+			assert.Equal(t, auth.HealthUp, report.Component(name).Status, name)
+		}
+	})
+
+	t.Run("鉴权决策失败率", func(t *testing.T) {
+		stats := svr.checker.stats
+		for i := 0; i < healthMinDecisions; i++ {
+			stats.observe(now)
+		}
+		assert.Equal(t, auth.HealthUp, svr.checker.decisionHealth(now).Status)
+		for i := 0; i < healthMinDecisions/2; i++ {
+			stats.fail(now)
+		}
+		comp := svr.checker.decisionHealth(now)
+		assert.Equal(t, auth.HealthDegraded, comp.Status)
+		assert.Contains(t, comp.Reasons[0], "decision error rate")
+		// 超过两个统计窗口后重新计算
+		assert.Equal(t, auth.HealthUp, svr.checker.decisionHealth(now.Add(3*decisionStatsWindow)).Status)
+	})
+}
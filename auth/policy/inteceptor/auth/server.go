@@ -19,6 +19,8 @@ package auth
 
 import (
 	"context"
+	"errors"
+	"time"
 
 	apimodel "github.com/polarismesh/specification/source/go/api/v1/model"
 	apisecurity "github.com/polarismesh/specification/source/go/api/v1/security"
@@ -26,9 +28,11 @@ import (
 	"go.uber.org/zap"
 
 	"github.com/polarismesh/polaris/auth"
+	"github.com/polarismesh/polaris/auth/evaluator"
 	cachetypes "github.com/polarismesh/polaris/cache/api"
 	api "github.com/polarismesh/polaris/common/api/v1"
 	"github.com/polarismesh/polaris/common/model"
+	authcommon "github.com/polarismesh/polaris/common/model/auth"
 	"github.com/polarismesh/polaris/common/utils"
 	"github.com/polarismesh/polaris/store"
 )
@@ -51,13 +55,15 @@ func NewServer(nextSvr auth.StrategyServer) auth.StrategyServer {
 }
 
 type Server struct {
-	nextSvr auth.StrategyServer
-	userSvr auth.UserServer
+	nextSvr  auth.StrategyServer
+	userSvr  auth.UserServer
+	cacheMgr cachetypes.CacheManager
 }
 
 // Initialize 执行初始化动作
 func (svr *Server) Initialize(options *auth.Config, storage store.Store, cacheMgr cachetypes.CacheManager, userSvr auth.UserServer) error {
 	svr.userSvr = userSvr
+	svr.cacheMgr = cacheMgr
 	return svr.nextSvr.Initialize(options, storage, cacheMgr, userSvr)
 }
 
@@ -66,18 +72,30 @@ func (svr *Server) Name() string {
 	return svr.nextSvr.Name()
 }
 
-// CreateStrategy 创建策略
+// CreateStrategy 创建策略，子账户是否为命名空间管理员由策略模块校验
 func (svr *Server) CreateStrategy(ctx context.Context, strategy *apisecurity.AuthStrategy) *apiservice.Response {
-	ctx, rsp := svr.verifyAuth(ctx, WriteOp, MustOwner)
+	ctx, rsp := svr.verifyAuth(ctx, WriteOp, NotOwner)
 	if rsp != nil {
 		return rsp
 	}
 	return svr.nextSvr.CreateStrategy(ctx, strategy)
 }
 
-// UpdateStrategies 批量更新策略
+// CreateStrategies 批量创建策略，子账户是否为命名空间管理员由策略模块校验
+func (svr *Server) CreateStrategies(ctx context.Context,
+	reqs []*apisecurity.AuthStrategy) *apiservice.BatchWriteResponse {
+	ctx, rsp := svr.verifyAuth(ctx, WriteOp, NotOwner)
+	if rsp != nil {
+		resp := api.NewAuthBatchWriteResponse(apimodel.Code_ExecuteSuccess)
+		api.Collect(resp, rsp)
+		return resp
+	}
+	return svr.nextSvr.CreateStrategies(ctx, reqs)
+}
+
+// UpdateStrategies 批量更新策略，子账户是否为命名空间管理员由策略模块校验
 func (svr *Server) UpdateStrategies(ctx context.Context, reqs []*apisecurity.ModifyAuthStrategy) *apiservice.BatchWriteResponse {
-	ctx, rsp := svr.verifyAuth(ctx, WriteOp, MustOwner)
+	ctx, rsp := svr.verifyAuth(ctx, WriteOp, NotOwner)
 	if rsp != nil {
 		resp := api.NewAuthBatchWriteResponse(apimodel.Code_ExecuteSuccess)
 		api.Collect(resp, rsp)
@@ -86,9 +104,9 @@ func (svr *Server) UpdateStrategies(ctx context.Context, reqs []*apisecurity.Mod
 	return svr.nextSvr.UpdateStrategies(ctx, reqs)
 }
 
-// DeleteStrategies 删除策略
+// DeleteStrategies 删除策略，子账户是否为命名空间管理员由策略模块校验
 func (svr *Server) DeleteStrategies(ctx context.Context, reqs []*apisecurity.AuthStrategy) *apiservice.BatchWriteResponse {
-	ctx, rsp := svr.verifyAuth(ctx, WriteOp, MustOwner)
+	ctx, rsp := svr.verifyAuth(ctx, WriteOp, NotOwner)
 	if rsp != nil {
 		resp := api.NewAuthBatchWriteResponse(apimodel.Code_ExecuteSuccess)
 		api.Collect(resp, rsp)
@@ -108,6 +126,15 @@ func (svr *Server) GetStrategies(ctx context.Context, query map[string]string) *
 	return svr.nextSvr.GetStrategies(ctx, query)
 }
 
+// SearchStrategies 按照名称以及描述搜索鉴权策略
+func (svr *Server) SearchStrategies(ctx context.Context, query string, limit int) *apiservice.BatchQueryResponse {
+	ctx, rsp := svr.verifyAuth(ctx, ReadOp, NotOwner)
+	if rsp != nil {
+		return api.NewAuthBatchQueryResponseWithMsg(apimodel.Code(rsp.GetCode().Value), rsp.Info.Value)
+	}
+	return svr.nextSvr.SearchStrategies(ctx, query, limit)
+}
+
 // GetStrategy 获取策略详细
 func (svr *Server) GetStrategy(ctx context.Context, strategy *apisecurity.AuthStrategy) *apiservice.Response {
 	ctx, rsp := svr.verifyAuth(ctx, ReadOp, NotOwner)
@@ -136,6 +163,375 @@ func (svr *Server) AfterResourceOperation(afterCtx *model.AcquireContext) error
 	return svr.nextSvr.AfterResourceOperation(afterCtx)
 }
 
+// ExportAuthModel 导出完整鉴权模型的时间点快照，仅允许超级管理员操作
+func (svr *Server) ExportAuthModel(ctx context.Context) (*auth.AuthModelSnapshot, error) {
+	ctx, rsp := svr.verifyAuth(ctx, ReadOp, MustOwner)
+	if rsp != nil {
+		return nil, errors.New(rsp.GetInfo().GetValue())
+	}
+	if authcommon.ParseUserRole(ctx) != model.AdminUserRole {
+		log.Error("[Auth][Server] only admin account can export auth model", utils.RequestID(ctx))
+		return nil, errors.New(api.Code2Info(api.OperationRoleException))
+	}
+	return svr.nextSvr.ExportAuthModel(ctx)
+}
+
+// ExportPolicySnapshot 导出推送给 sidecar 进行本地鉴权的策略快照，仅允许超级管理员操作
+func (svr *Server) ExportPolicySnapshot(ctx context.Context) (*evaluator.PolicySnapshot, error) {
+	ctx, rsp := svr.verifyAuth(ctx, ReadOp, MustOwner)
+	if rsp != nil {
+		return nil, errors.New(rsp.GetInfo().GetValue())
+	}
+	if authcommon.ParseUserRole(ctx) != model.AdminUserRole {
+		log.Error("[Auth][Server] only admin account can export policy snapshot", utils.RequestID(ctx))
+		return nil, errors.New(api.Code2Info(api.OperationRoleException))
+	}
+	return svr.nextSvr.ExportPolicySnapshot(ctx)
+}
+
+// CreateDecisionPin 设置临时置顶的鉴权决策，仅允许超级管理员操作
+func (svr *Server) CreateDecisionPin(ctx context.Context, pin *auth.DecisionPin) error {
+	ctx, err := svr.verifyAdmin(ctx, WriteOp)
+	if err != nil {
+		return err
+	}
+	return svr.nextSvr.CreateDecisionPin(ctx, pin)
+}
+
+// DeleteDecisionPin 删除置顶的鉴权决策，仅允许超级管理员操作
+func (svr *Server) DeleteDecisionPin(ctx context.Context, principal model.Principal,
+	resType apisecurity.ResourceType, resID string) error {
+	ctx, err := svr.verifyAdmin(ctx, WriteOp)
+	if err != nil {
+		return err
+	}
+	return svr.nextSvr.DeleteDecisionPin(ctx, principal, resType, resID)
+}
+
+// ListDecisionPins 查询置顶的鉴权决策，仅允许超级管理员操作
+func (svr *Server) ListDecisionPins(ctx context.Context) ([]*auth.DecisionPin, error) {
+	ctx, err := svr.verifyAdmin(ctx, ReadOp)
+	if err != nil {
+		return nil, err
+	}
+	return svr.nextSvr.ListDecisionPins(ctx)
+}
+
+// ListDecisionTrace 查询当前节点最近记录的鉴权决策，仅允许超级管理员操作
+func (svr *Server) ListDecisionTrace(ctx context.Context) ([]auth.DecisionTraceRecord, error) {
+	ctx, err := svr.verifyAdmin(ctx, ReadOp)
+	if err != nil {
+		return nil, err
+	}
+	return svr.nextSvr.ListDecisionTrace(ctx)
+}
+
+// ReplayDecisionTrace 使用待变更的鉴权配置重放当前节点最近记录的鉴权决策，仅允许超级管理员操作
+func (svr *Server) ReplayDecisionTrace(ctx context.Context,
+	proposed map[string]interface{}) (*auth.AccessDelta, error) {
+	ctx, err := svr.verifyAdmin(ctx, ReadOp)
+	if err != nil {
+		return nil, err
+	}
+	return svr.nextSvr.ReplayDecisionTrace(ctx, proposed)
+}
+
+// CreateRoles 批量创建角色，仅允许超级管理员以及主账户操作
+func (svr *Server) CreateRoles(ctx context.Context, roles []*model.Role) *apiservice.BatchWriteResponse {
+	ctx, rsp := svr.verifyAuth(ctx, WriteOp, MustOwner)
+	if rsp != nil {
+		resp := api.NewAuthBatchWriteResponse(apimodel.Code_ExecuteSuccess)
+		api.Collect(resp, rsp)
+		return resp
+	}
+	return svr.nextSvr.CreateRoles(ctx, roles)
+}
+
+// UpdateRoles 批量更新角色，仅允许超级管理员以及主账户操作
+func (svr *Server) UpdateRoles(ctx context.Context, roles []*model.Role) *apiservice.BatchWriteResponse {
+	ctx, rsp := svr.verifyAuth(ctx, WriteOp, MustOwner)
+	if rsp != nil {
+		resp := api.NewAuthBatchWriteResponse(apimodel.Code_ExecuteSuccess)
+		api.Collect(resp, rsp)
+		return resp
+	}
+	return svr.nextSvr.UpdateRoles(ctx, roles)
+}
+
+// DeleteRoles 批量删除角色，仅允许超级管理员以及主账户操作
+func (svr *Server) DeleteRoles(ctx context.Context, ids []string) *apiservice.BatchWriteResponse {
+	ctx, rsp := svr.verifyAuth(ctx, WriteOp, MustOwner)
+	if rsp != nil {
+		resp := api.NewAuthBatchWriteResponse(apimodel.Code_ExecuteSuccess)
+		api.Collect(resp, rsp)
+		return resp
+	}
+	return svr.nextSvr.DeleteRoles(ctx, ids)
+}
+
+// GetRoles 查询角色列表，仅允许超级管理员以及主账户操作
+func (svr *Server) GetRoles(ctx context.Context, query map[string]string) (uint32, []*model.Role, error) {
+	ctx, rsp := svr.verifyAuth(ctx, ReadOp, MustOwner)
+	if rsp != nil {
+		return 0, nil, errors.New(rsp.GetInfo().GetValue())
+	}
+	return svr.nextSvr.GetRoles(ctx, query)
+}
+
+// verifyAdmin 校验当前操作者为超级管理员
+func (svr *Server) verifyAdmin(ctx context.Context, isWrite bool) (context.Context, error) {
+	ctx, rsp := svr.verifyAuth(ctx, isWrite, MustOwner)
+	if rsp != nil {
+		return nil, errors.New(rsp.GetInfo().GetValue())
+	}
+	if authcommon.ParseUserRole(ctx) != model.AdminUserRole {
+		log.Error("[Auth][Server] only admin account can access this API", utils.RequestID(ctx))
+		return nil, errors.New(api.Code2Info(api.OperationRoleException))
+	}
+	return ctx, nil
+}
+
+// CapabilityMatrix 计算鉴权结果矩阵，仅允许超级管理员以及主账户操作，主账户只能查询自己名下的 principal
+func (svr *Server) CapabilityMatrix(ctx context.Context, principals []model.Principal,
+	resources []auth.CapabilityResource, operations []model.ResourceOperation) (*auth.CapabilityMatrix, error) {
+	ctx, rsp := svr.verifyAuth(ctx, ReadOp, MustOwner)
+	if rsp != nil {
+		return nil, errors.New(rsp.GetInfo().GetValue())
+	}
+	if authcommon.ParseUserRole(ctx) != model.AdminUserRole {
+		ownerID := utils.ParseOwnerID(ctx)
+		userCache := svr.cacheMgr.User()
+		for i := range principals {
+			if principalOwner(userCache, principals[i]) != ownerID {
+				log.Error("[Auth][Server] principal not belong to current owner", utils.RequestID(ctx),
+					zap.String("principal", principals[i].PrincipalID))
+				return nil, errors.New(api.Code2Info(api.NotAllowedAccess))
+			}
+		}
+	}
+	return svr.nextSvr.CapabilityMatrix(ctx, principals, resources, operations)
+}
+
+// ExplainGrants 查询授权路径，仅允许超级管理员以及主账户操作，主账户只能查询自己名下的 principal
+func (svr *Server) ExplainGrants(ctx context.Context, principal model.Principal,
+	resource auth.CapabilityResource) (*auth.GrantExplanation, error) {
+	ctx, rsp := svr.verifyAuth(ctx, ReadOp, MustOwner)
+	if rsp != nil {
+		return nil, errors.New(rsp.GetInfo().GetValue())
+	}
+	if authcommon.ParseUserRole(ctx) != model.AdminUserRole &&
+		principalOwner(svr.cacheMgr.User(), principal) != utils.ParseOwnerID(ctx) {
+		log.Error("[Auth][Server] principal not belong to current owner", utils.RequestID(ctx),
+			zap.String("principal", principal.PrincipalID))
+		return nil, errors.New(api.Code2Info(api.NotAllowedAccess))
+	}
+	return svr.nextSvr.ExplainGrants(ctx, principal, resource)
+}
+
+// SimulateStrategy 模拟鉴权请求，仅允许超级管理员以及主账户操作，主账户只能模拟自己名下的 principal
+func (svr *Server) SimulateStrategy(ctx context.Context,
+	req *auth.SimulateRequest) (*auth.StrategySimulation, error) {
+	ctx, rsp := svr.verifyAuth(ctx, ReadOp, MustOwner)
+	if rsp != nil {
+		return nil, errors.New(rsp.GetInfo().GetValue())
+	}
+	if authcommon.ParseUserRole(ctx) != model.AdminUserRole &&
+		principalOwner(svr.cacheMgr.User(), req.Principal) != utils.ParseOwnerID(ctx) {
+		log.Error("[Auth][Server] principal not belong to current owner", utils.RequestID(ctx),
+			zap.String("principal", req.Principal.PrincipalID))
+		return nil, errors.New(api.Code2Info(api.NotAllowedAccess))
+	}
+	return svr.nextSvr.SimulateStrategy(ctx, req)
+}
+
+// WhatIfGroupMembership 计算假设的用户组成员关系，仅允许超级管理员以及主账户操作，主账户只能查询自己名下的用户以及用户组
+func (svr *Server) WhatIfGroupMembership(ctx context.Context, userID, groupID string) (*auth.MembershipWhatIf, error) {
+	ctx, rsp := svr.verifyAuth(ctx, ReadOp, MustOwner)
+	if rsp != nil {
+		return nil, errors.New(rsp.GetInfo().GetValue())
+	}
+	if authcommon.ParseUserRole(ctx) != model.AdminUserRole {
+		ownerID := utils.ParseOwnerID(ctx)
+		userCache := svr.cacheMgr.User()
+		userOwner := principalOwner(userCache, model.Principal{PrincipalID: userID, PrincipalRole: model.PrincipalUser})
+		groupOwner := principalOwner(userCache, model.Principal{PrincipalID: groupID, PrincipalRole: model.PrincipalGroup})
+		if userOwner != ownerID || groupOwner != ownerID {
+			log.Error("[Auth][Server] user or group not belong to current owner", utils.RequestID(ctx),
+				zap.String("user", userID), zap.String("group", groupID))
+			return nil, errors.New(api.Code2Info(api.NotAllowedAccess))
+		}
+	}
+	return svr.nextSvr.WhatIfGroupMembership(ctx, userID, groupID)
+}
+
+// GenerateAccessReview 生成访问审查报告，仅允许超级管理员以及主账户操作，主账户只能查询自己名下的 principal
+func (svr *Server) GenerateAccessReview(ctx context.Context,
+	principal model.Principal) (*auth.AccessReviewReport, error) {
+	ctx, rsp := svr.verifyAuth(ctx, ReadOp, MustOwner)
+	if rsp != nil {
+		return nil, errors.New(rsp.GetInfo().GetValue())
+	}
+	if authcommon.ParseUserRole(ctx) != model.AdminUserRole &&
+		principalOwner(svr.cacheMgr.User(), principal) != utils.ParseOwnerID(ctx) {
+		log.Error("[Auth][Server] principal not belong to current owner", utils.RequestID(ctx),
+			zap.String("principal", principal.PrincipalID))
+		return nil, errors.New(api.Code2Info(api.NotAllowedAccess))
+	}
+	return svr.nextSvr.GenerateAccessReview(ctx, principal)
+}
+
+// HealthReport 生成鉴权模块的健康报告，仅允许超级管理员操作
+func (svr *Server) HealthReport(ctx context.Context) (*auth.AuthHealth, error) {
+	ctx, rsp := svr.verifyAuth(ctx, ReadOp, MustOwner)
+	if rsp != nil {
+		return nil, errors.New(rsp.GetInfo().GetValue())
+	}
+	if authcommon.ParseUserRole(ctx) != model.AdminUserRole {
+		log.Error("[Auth][Server] only admin account can get auth health report", utils.RequestID(ctx))
+		return nil, errors.New(api.Code2Info(api.OperationRoleException))
+	}
+	return svr.nextSvr.HealthReport(ctx)
+}
+
+// AttachStrategyResources 为鉴权策略关联资源，子账户是否为命名空间管理员由策略模块校验
+func (svr *Server) AttachStrategyResources(ctx context.Context, strategyID string,
+	resources []model.StrategyResource) *apiservice.Response {
+	ctx, rsp := svr.verifyAuth(ctx, WriteOp, NotOwner)
+	if rsp != nil {
+		return rsp
+	}
+	return svr.nextSvr.AttachStrategyResources(ctx, strategyID, resources)
+}
+
+// UpdateStrategyEffect 修改鉴权策略的授权效果以及优先级，子账户是否为命名空间管理员由策略模块校验
+func (svr *Server) UpdateStrategyEffect(ctx context.Context, strategyID string, effect model.StrategyEffect,
+	priority int32) *apiservice.Response {
+	ctx, rsp := svr.verifyAuth(ctx, WriteOp, NotOwner)
+	if rsp != nil {
+		return rsp
+	}
+	return svr.nextSvr.UpdateStrategyEffect(ctx, strategyID, effect, priority)
+}
+
+// UpdateStrategyFunctions 修改鉴权策略生效的接口名称，子账户是否为命名空间管理员由策略模块校验
+func (svr *Server) UpdateStrategyFunctions(ctx context.Context, strategyID string,
+	functions []string) *apiservice.Response {
+	ctx, rsp := svr.verifyAuth(ctx, WriteOp, NotOwner)
+	if rsp != nil {
+		return rsp
+	}
+	return svr.nextSvr.UpdateStrategyFunctions(ctx, strategyID, functions)
+}
+
+// BulkEnsureDefaultStrategies 批量确保 principal 存在默认策略，仅允许超级管理员以及主账户操作，主账户只能处理自己名下的 principal
+func (svr *Server) BulkEnsureDefaultStrategies(ctx context.Context,
+	principals []model.Principal) (*auth.DefaultStrategyReport, error) {
+	ctx, rsp := svr.verifyAuth(ctx, WriteOp, MustOwner)
+	if rsp != nil {
+		return nil, errors.New(rsp.GetInfo().GetValue())
+	}
+	if authcommon.ParseUserRole(ctx) != model.AdminUserRole {
+		ownerID := utils.ParseOwnerID(ctx)
+		userCache := svr.cacheMgr.User()
+		for i := range principals {
+			if principalOwner(userCache, principals[i]) != ownerID {
+				log.Error("[Auth][Server] principal not belong to current owner", utils.RequestID(ctx),
+					zap.String("principal", principals[i].PrincipalID))
+				return nil, errors.New(api.Code2Info(api.NotAllowedAccess))
+			}
+		}
+	}
+	return svr.nextSvr.BulkEnsureDefaultStrategies(ctx, principals)
+}
+
+// RecomputeDefaultStrategies 重新计算全部 principal 的默认策略，仅允许超级管理员操作
+func (svr *Server) RecomputeDefaultStrategies(ctx context.Context,
+	dryRun bool) (*auth.DefaultStrategyRecomputeReport, error) {
+	ctx, err := svr.verifyAdmin(ctx, WriteOp)
+	if err != nil {
+		return nil, err
+	}
+	return svr.nextSvr.RecomputeDefaultStrategies(ctx, dryRun)
+}
+
+// CreateAuditorStrategy 为 principal 创建审计只读策略，仅允许超级管理员以及主账户操作，主账户只能处理自己名下的 principal
+func (svr *Server) CreateAuditorStrategy(ctx context.Context, principal model.Principal) *apiservice.Response {
+	ctx, rsp := svr.verifyAuth(ctx, WriteOp, MustOwner)
+	if rsp != nil {
+		return rsp
+	}
+	if authcommon.ParseUserRole(ctx) != model.AdminUserRole &&
+		principalOwner(svr.cacheMgr.User(), principal) != utils.ParseOwnerID(ctx) {
+		log.Error("[Auth][Server] principal not belong to current owner", utils.RequestID(ctx),
+			zap.String("principal", principal.PrincipalID))
+		return api.NewAuthResponse(apimodel.Code_NotAllowedAccess)
+	}
+	return svr.nextSvr.CreateAuditorStrategy(ctx, principal)
+}
+
+// TransferOwnership 转移资源的默认授权，仅允许超级管理员以及主账户操作，主账户只能在自己名下的 principal 之间转移
+func (svr *Server) TransferOwnership(ctx context.Context, resource model.StrategyResource,
+	from, to model.Principal) *apiservice.Response {
+	ctx, rsp := svr.verifyAuth(ctx, WriteOp, MustOwner)
+	if rsp != nil {
+		return rsp
+	}
+	if authcommon.ParseUserRole(ctx) != model.AdminUserRole {
+		userCache := svr.cacheMgr.User()
+		for _, principal := range []model.Principal{from, to} {
+			if principalOwner(userCache, principal) != utils.ParseOwnerID(ctx) {
+				log.Error("[Auth][Server] principal not belong to current owner", utils.RequestID(ctx),
+					zap.String("principal", principal.PrincipalID))
+				return api.NewAuthResponse(apimodel.Code_NotAllowedAccess)
+			}
+		}
+	}
+	return svr.nextSvr.TransferOwnership(ctx, resource, from, to)
+}
+
+// TransferAllOwnership 转移 principal 名下的全部资源，权限要求与 TransferOwnership 一致
+func (svr *Server) TransferAllOwnership(ctx context.Context,
+	from, to model.Principal) (*auth.OwnershipTransferReport, error) {
+	ctx, rsp := svr.verifyAuth(ctx, WriteOp, MustOwner)
+	if rsp != nil {
+		return nil, errors.New(rsp.GetInfo().GetValue())
+	}
+	if authcommon.ParseUserRole(ctx) != model.AdminUserRole {
+		userCache := svr.cacheMgr.User()
+		for _, principal := range []model.Principal{from, to} {
+			if principalOwner(userCache, principal) != utils.ParseOwnerID(ctx) {
+				log.Error("[Auth][Server] principal not belong to current owner", utils.RequestID(ctx),
+					zap.String("principal", principal.PrincipalID))
+				return nil, errors.New(api.Code2Info(api.NotAllowedAccess))
+			}
+		}
+	}
+	return svr.nextSvr.TransferAllOwnership(ctx, from, to)
+}
+
+// principalOwner 获取 principal 所属的主账户 ID，不存在时返回空
+func principalOwner(userCache cachetypes.UserCache, principal model.Principal) string {
+	switch principal.PrincipalRole {
+	case model.PrincipalUser:
+		user := userCache.GetUserByID(principal.PrincipalID)
+		if user == nil {
+			return ""
+		}
+		if user.Owner == "" {
+			return user.ID
+		}
+		return user.Owner
+	case model.PrincipalGroup:
+		group := userCache.GetGroup(principal.PrincipalID)
+		if group == nil {
+			return ""
+		}
+		return group.Owner
+	default:
+		return ""
+	}
+}
+
 // verifyAuth 用于 user、group 以及 strategy 模块的鉴权工作检查
 func (svr *Server) verifyAuth(ctx context.Context, isWrite bool,
 	needOwner bool) (context.Context, *apiservice.Response) {
@@ -147,6 +543,10 @@ func (svr *Server) verifyAuth(ctx context.Context, isWrite bool,
 		return nil, api.NewAuthResponse(apimodel.Code_EmptyAutToken)
 	}
 
+	// 写操作作为一次顶层操作，本次操作产生的全部操作记录归属同一个变更集
+	if isWrite {
+		ctx = utils.WithChangeSetID(ctx)
+	}
 	authCtx := model.NewAcquireContext(
 		model.WithRequestContext(ctx),
 		model.WithModule(model.AuthModule),
@@ -173,6 +573,11 @@ func (svr *Server) verifyAuth(ctx context.Context, isWrite bool,
 			zap.String("operation", authCtx.GetMethod()))
 		return nil, api.NewAuthResponse(apimodel.Code_TokenDisabled)
 	}
+	if operateInfo.IsExpired(time.Now()) {
+		log.Error("[Auth][Server] token is expired", utils.ZapRequestID(reqId),
+			zap.Time("expire", operateInfo.ExpireAt))
+		return nil, api.NewAuthResponse(apimodel.Code(api.TokenExpired))
+	}
 
 	if !operateInfo.IsUserToken {
 		log.Error("[Auth][Server] only user role can access this API", utils.ZapRequestID(reqId))
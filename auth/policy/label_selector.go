@@ -0,0 +1,84 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package policy
+
+import (
+	apimodel "github.com/polarismesh/specification/source/go/api/v1/model"
+	apisecurity "github.com/polarismesh/specification/source/go/api/v1/security"
+	apiservice "github.com/polarismesh/specification/source/go/api/v1/service_manage"
+	"go.uber.org/zap"
+
+	"github.com/polarismesh/polaris/auth/evaluator"
+	api "github.com/polarismesh/polaris/common/api/v1"
+	"github.com/polarismesh/polaris/common/model"
+)
+
+// IsLabelSelector 资源 ID 是否为命名空间以及标签选择器
+func IsLabelSelector(resId string) bool {
+	return evaluator.IsLabelSelector(resId)
+}
+
+// checkLabelSelector 校验标签选择器是否合法
+func checkLabelSelector(resId string) *apiservice.Response {
+	if _, _, err := parseLabelSelector(resId); err != nil {
+		return api.NewAuthResponseWithMsg(apimodel.Code_InvalidParameter, err.Error())
+	}
+	return nil
+}
+
+// parseLabelSelector 解析命名空间以及标签选择器, 例如 label:prod/env=prod，标签选择器的语法与属性匹配规则一致
+func parseLabelSelector(resId string) (string, []attributePredicate, error) {
+	return evaluator.ParseLabelSelector(resId)
+}
+
+// isLabelLinked 资源是否被任意允许策略的标签选择器匹配，标签选择器匹配到的资源视为关联了鉴权策略
+func (d *DefaultAuthChecker) isLabelLinked(resType apisecurity.ResourceType, resID string) bool {
+	if resType != apisecurity.ResourceType_Services && resType != apisecurity.ResourceType_ConfigGroups {
+		return false
+	}
+	req := evaluator.Request{ResourceType: resType, ResourceID: resID}
+	linked, err := d.engine().IsLabelLinked(req)
+	if err != nil {
+		log.Error("[Auth][Checker] load resource labels", zap.String("resource", resID), zap.Error(err))
+		// 获取资源标签失败时视为关联了策略，避免放通
+		return true
+	}
+	return linked
+}
+
+// isResourceLinked 资源是否被鉴权策略直接关联，或者被标签选择器匹配
+func (d *DefaultAuthChecker) isResourceLinked(resType apisecurity.ResourceType, resID string) bool {
+	return d.cacheMgr.AuthStrategy().IsResourceLinkStrategy(resType, resID) || d.isLabelLinked(resType, resID)
+}
+
+// isLinkEditable 按照策略缓存判断 principal 是否可以操作资源，策略缓存将只被标签选择器匹配到的资源视为未关联策略,
+// 这类资源按照决策引擎的逻辑判断
+func (d *DefaultAuthChecker) isLinkEditable(principal model.Principal,
+	resType apisecurity.ResourceType, resID string) bool {
+	strategyCache := d.cacheMgr.AuthStrategy()
+	if !d.isLabelLinked(resType, resID) || strategyCache.IsResourceLinkStrategy(resType, resID) {
+		return strategyCache.IsResourceEditable(principal, resType, resID)
+	}
+	req := evaluator.Request{Principal: principal, ResourceType: resType, ResourceID: resID}
+	return d.engine().Decide(req, d.principalStrategies(principal), nil).Allowed
+}
+
+// hasLabelSelector 该资源类型下是否存在标签选择器，资源的标签变化不会改变策略的版本，此时不能使用决策缓存
+func (d *DefaultAuthChecker) hasLabelSelector(resType apisecurity.ResourceType) bool {
+	return len(d.cacheMgr.AuthStrategy().GetLabelSelectorStrategies(resType)) != 0
+}
@@ -0,0 +1,94 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package policy
+
+import (
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	apisecurity "github.com/polarismesh/specification/source/go/api/v1/security"
+	"github.com/stretchr/testify/assert"
+
+	cachemock "github.com/polarismesh/polaris/cache/mock"
+	"github.com/polarismesh/polaris/common/model"
+)
+
+func Test_checkActionByLabelSelector(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userCache := cachemock.NewMockUserCache(ctrl)
+	strategyCache := cachemock.NewMockStrategyCache(ctrl)
+	svcCache := cachemock.NewMockServiceCache(ctrl)
+	groupCache := cachemock.NewMockConfigGroupCache(ctrl)
+	cacheMgr := cachemock.NewMockCacheManager(ctrl)
+	cacheMgr.EXPECT().User().Return(userCache).AnyTimes()
+	cacheMgr.EXPECT().AuthStrategy().Return(strategyCache).AnyTimes()
+	cacheMgr.EXPECT().Service().Return(svcCache).AnyTimes()
+	cacheMgr.EXPECT().ConfigGroup().Return(groupCache).AnyTimes()
+	roleCache := cachemock.NewMockRoleCache(ctrl)
+	cacheMgr.EXPECT().AuthRole().Return(roleCache).AnyTimes()
+	roleCache.EXPECT().GetPrincipalRoles(gomock.Any()).Return(nil).AnyTimes()
+	roleCache.EXPECT().Version().Return(uint64(0)).AnyTimes()
+
+	checker := &DefaultAuthChecker{conf: DefaultAuthConfig(), cacheMgr: cacheMgr}
+	user := model.Principal{PrincipalID: "user-1", PrincipalRole: model.PrincipalUser}
+	other := model.Principal{PrincipalID: "user-2", PrincipalRole: model.PrincipalUser}
+	authCtx := model.NewAcquireContext(model.WithOperation(model.Modify))
+
+	// user-1 通过标签选择器获得 prod 命名空间下 env=prod 的服务以及全部 team=infra 的配置分组的权限
+	rule := &model.StrategyDetail{
+		ID: "rule-1",
+		Resources: []model.StrategyResource{
+			{StrategyID: "rule-1", ResType: int32(apisecurity.ResourceType_Services), ResID: "label:prod/env=prod"},
+			{StrategyID: "rule-1", ResType: int32(apisecurity.ResourceType_ConfigGroups), ResID: "label:*/team=infra"},
+		},
+	}
+	strategyCache.EXPECT().GetLabelSelectorStrategies(gomock.Any()).Return([]*model.StrategyDetail{rule}).AnyTimes()
+	strategyCache.EXPECT().IsResourceLinkStrategy(gomock.Any(), gomock.Any()).Return(false).AnyTimes()
+	strategyCache.EXPECT().IsResourceEditable(gomock.Any(), gomock.Any(), gomock.Any()).Return(true).AnyTimes()
+	strategyCache.EXPECT().GetStrategyDetailsByUID("user-1").Return([]*model.StrategyDetail{rule}).AnyTimes()
+	strategyCache.EXPECT().GetStrategyDetailsByUID("user-2").Return(nil).AnyTimes()
+	userCache.EXPECT().GetUserLinkGroupIds(gomock.Any()).Return(nil).AnyTimes()
+	svcCache.EXPECT().GetServiceByID("svc-prod").
+		Return(&model.Service{ID: "svc-prod", Namespace: "prod", Meta: map[string]string{"env": "prod"}}).AnyTimes()
+	svcCache.EXPECT().GetServiceByID("svc-test").
+		Return(&model.Service{ID: "svc-test", Namespace: "test", Meta: map[string]string{"env": "prod"}}).AnyTimes()
+	groupCache.EXPECT().GetGroupByID(uint64(1)).Return(&model.ConfigFileGroup{
+		Id: 1, Namespace: "test", Metadata: map[string]string{"team": "infra"},
+	}).AnyTimes()
+
+	t.Run("标签选择器匹配的资源允许操作", func(t *testing.T) {
+		assert.NoError(t, checker.checkAction(user, apisecurity.ResourceType_Services,
+			[]model.ResourceEntry{{ID: "svc-prod"}}, authCtx))
+		assert.NoError(t, checker.checkAction(user, apisecurity.ResourceType_ConfigGroups,
+			[]model.ResourceEntry{{ID: "1"}}, authCtx))
+	})
+
+	t.Run("标签选择器匹配的资源视为关联了策略", func(t *testing.T) {
+		assert.ErrorIs(t, checker.checkAction(other, apisecurity.ResourceType_Services,
+			[]model.ResourceEntry{{ID: "svc-prod"}}, authCtx), ErrorNotPermission)
+		assert.ErrorIs(t, checker.checkAction(other, apisecurity.ResourceType_ConfigGroups,
+			[]model.ResourceEntry{{ID: "1"}}, authCtx), ErrorNotPermission)
+	})
+
+	t.Run("命名空间不匹配的资源不受标签选择器影响", func(t *testing.T) {
+		assert.NoError(t, checker.checkAction(other, apisecurity.ResourceType_Services,
+			[]model.ResourceEntry{{ID: "svc-test"}}, authCtx))
+	})
+}
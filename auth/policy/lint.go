@@ -0,0 +1,239 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+package policy
+
+import (
+	"fmt"
+	golog "log"
+	"strings"
+
+	apisecurity "github.com/polarismesh/specification/source/go/api/v1/security"
+
+	cachetypes "github.com/polarismesh/polaris/cache/api"
+	"github.com/polarismesh/polaris/common/model"
+)
+
+// LintSeverity 鉴权策略检查规则的级别
+type LintSeverity string
+
+const (
+	// LintSeverityWarn 仅提示，不影响鉴权策略的创建、更新
+	LintSeverityWarn LintSeverity = "warn"
+	// LintSeverityBlock 拒绝鉴权策略的创建、更新
+	LintSeverityBlock LintSeverity = "block"
+)
+
+const (
+	// LintRuleNoWildcardResource 非超级管理员的 principal 不允许关联 * 资源
+	LintRuleNoWildcardResource = "noWildcardResource"
+	// LintRuleCommentRequired 读写类型的鉴权策略必须说明授权的原因
+	LintRuleCommentRequired = "commentRequired"
+)
+
+// LintRule 鉴权策略检查规则
+type LintRule interface {
+	// Name 规则名称
+	Name() string
+	// Lint 检查鉴权策略，返回发现的问题，为空表示通过
+	Lint(userCache cachetypes.UserCache, strategy *model.StrategyDetail) []string
+}
+
+// LintIssue 鉴权策略检查发现的问题
+type LintIssue struct {
+	Rule     string
+	Severity LintSeverity
+	Message  string
+}
+
+func (i LintIssue) String() string {
+	return fmt.Sprintf("[%s][%s] %s", i.Severity, i.Rule, i.Message)
+}
+
+var (
+	// lintRules 已注册的鉴权策略检查规则
+	lintRules = map[string]LintRule{}
+	// lintRuleOrder 规则的注册顺序，保证检查结果的输出稳定
+	lintRuleOrder = []string{}
+)
+
+// RegisterLintRule 注册鉴权策略检查规则
+func RegisterLintRule(rule LintRule) {
+	if _, ok := lintRules[rule.Name()]; ok {
+		golog.Printf("duplicate LintRule, name(%s)", rule.Name())
+		return
+	}
+	lintRules[rule.Name()] = rule
+	lintRuleOrder = append(lintRuleOrder, rule.Name())
+}
+
+func init() {
+	RegisterLintRule(&noWildcardResourceRule{})
+	RegisterLintRule(&commentRequiredRule{})
+}
+
+// strategyLinter 根据配置在鉴权策略创建、更新时执行检查规则
+type strategyLinter struct {
+	userCache cachetypes.UserCache
+	severity  map[string]LintSeverity
+}
+
+// newStrategyLinter 默认所有规则的级别均为 warn，可通过 lintBlockRules 提升为 block, lintDisableRules 关闭
+func newStrategyLinter(options *AuthConfig, userCache cachetypes.UserCache) *strategyLinter {
+	linter := &strategyLinter{
+		userCache: userCache,
+		severity:  map[string]LintSeverity{},
+	}
+	for _, name := range lintRuleOrder {
+		linter.severity[name] = LintSeverityWarn
+	}
+	for _, name := range options.LintBlockRules {
+		if _, ok := lintRules[name]; !ok {
+			log.Warnf("[Auth][Lint] lint rule(%s) not found, ignore", name)
+			continue
+		}
+		linter.severity[name] = LintSeverityBlock
+	}
+	for _, name := range options.LintDisableRules {
+		delete(linter.severity, name)
+	}
+	return linter
+}
+
+// Lint 执行全部开启的检查规则
+func (l *strategyLinter) Lint(strategy *model.StrategyDetail) []LintIssue {
+	issues := make([]LintIssue, 0, 2)
+	for _, name := range lintRuleOrder {
+		severity, ok := l.severity[name]
+		if !ok {
+			continue
+		}
+		for _, msg := range lintRules[name].Lint(l.userCache, strategy) {
+			issues = append(issues, LintIssue{
+				Rule:     name,
+				Severity: severity,
+				Message:  msg,
+			})
+		}
+	}
+	return issues
+}
+
+// splitLintIssues 将检查结果按照级别拆分为 block 以及 warn
+func splitLintIssues(issues []LintIssue) ([]LintIssue, []LintIssue) {
+	blocks := make([]LintIssue, 0, len(issues))
+	warns := make([]LintIssue, 0, len(issues))
+	for i := range issues {
+		if issues[i].Severity == LintSeverityBlock {
+			blocks = append(blocks, issues[i])
+		} else {
+			warns = append(warns, issues[i])
+		}
+	}
+	return blocks, warns
+}
+
+func formatLintIssues(issues []LintIssue) string {
+	msgs := make([]string, 0, len(issues))
+	for i := range issues {
+		msgs = append(msgs, issues[i].String())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// mergeModifyStrategy 计算鉴权策略更新后的完整内容，用于更新前的检查
+func mergeModifyStrategy(saved *model.StrategyDetail, modify *model.ModifyStrategyDetail) *model.StrategyDetail {
+	ret := *saved
+	ret.Name = modify.Name
+	ret.Action = modify.Action
+	ret.Comment = modify.Comment
+
+	removePrincipals := map[string]struct{}{}
+	for _, p := range modify.RemovePrincipals {
+		removePrincipals[fmt.Sprintf("%d/%s", p.PrincipalRole, p.PrincipalID)] = struct{}{}
+	}
+	ret.Principals = make([]model.Principal, 0, len(saved.Principals)+len(modify.AddPrincipals))
+	for _, p := range saved.Principals {
+		if _, ok := removePrincipals[fmt.Sprintf("%d/%s", p.PrincipalRole, p.PrincipalID)]; ok {
+			continue
+		}
+		ret.Principals = append(ret.Principals, p)
+	}
+	ret.Principals = append(ret.Principals, modify.AddPrincipals...)
+
+	removeResources := map[string]struct{}{}
+	for _, r := range modify.RemoveResources {
+		removeResources[fmt.Sprintf("%d/%s", r.ResType, r.ResID)] = struct{}{}
+	}
+	ret.Resources = make([]model.StrategyResource, 0, len(saved.Resources)+len(modify.AddResources))
+	for _, r := range saved.Resources {
+		if _, ok := removeResources[fmt.Sprintf("%d/%s", r.ResType, r.ResID)]; ok {
+			continue
+		}
+		ret.Resources = append(ret.Resources, r)
+	}
+	ret.Resources = append(ret.Resources, modify.AddResources...)
+	return &ret
+}
+
+// noWildcardResourceRule 非超级管理员的 principal 不允许关联 * 资源
+type noWildcardResourceRule struct{}
+
+func (r *noWildcardResourceRule) Name() string {
+	return LintRuleNoWildcardResource
+}
+
+func (r *noWildcardResourceRule) Lint(userCache cachetypes.UserCache, strategy *model.StrategyDetail) []string {
+	wildcards := make([]string, 0, 3)
+	for _, res := range strategy.Resources {
+		if res.ResID == "*" {
+			wildcards = append(wildcards, apisecurity.ResourceType(res.ResType).String())
+		}
+	}
+	if len(wildcards) == 0 {
+		return nil
+	}
+
+	ret := make([]string, 0, len(strategy.Principals))
+	for _, p := range strategy.Principals {
+		if p.PrincipalRole == model.PrincipalUser {
+			user := userCache.GetUserByID(p.PrincipalID)
+			if user != nil && user.Type == model.AdminUserRole {
+				continue
+			}
+		}
+		ret = append(ret, fmt.Sprintf("%s(%s) is granted all %s", model.PrincipalNames[p.PrincipalRole],
+			p.PrincipalID, strings.Join(wildcards, ",")))
+	}
+	return ret
+}
+
+// commentRequiredRule 读写类型的鉴权策略必须在 comment 中说明授权的原因
+type commentRequiredRule struct{}
+
+func (r *commentRequiredRule) Name() string {
+	return LintRuleCommentRequired
+}
+
+func (r *commentRequiredRule) Lint(_ cachetypes.UserCache, strategy *model.StrategyDetail) []string {
+	if strategy.Default || strategy.Action != apisecurity.AuthAction_READ_WRITE.String() {
+		return nil
+	}
+	if strings.TrimSpace(strategy.Comment) != "" {
+		return nil
+	}
+	return []string{"read write strategy must have a comment to describe the reason"}
+}
@@ -0,0 +1,102 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+package policy_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	apisecurity "github.com/polarismesh/specification/source/go/api/v1/security"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	"github.com/polarismesh/polaris/auth"
+	"github.com/polarismesh/polaris/auth/policy"
+	api "github.com/polarismesh/polaris/common/api/v1"
+	"github.com/polarismesh/polaris/common/utils"
+)
+
+func Test_StrategyLint(t *testing.T) {
+	strategyTest := newStrategyTest(t)
+	defer strategyTest.Clean()
+
+	// noWildcardResource 规则命中时拒绝，commentRequired 规则命中时仅提示
+	err := strategyTest.svr.Initialize(&auth.Config{
+		Strategy: &auth.StrategyConfig{
+			Name: auth.DefaultPolicyPluginName,
+			Option: map[string]interface{}{
+				"lintBlockRules": []interface{}{policy.LintRuleNoWildcardResource},
+			},
+		},
+	}, strategyTest.storage, strategyTest.cacheMgn, strategyTest.userSvr)
+	assert.NoError(t, err)
+	_ = strategyTest.cacheMgn.TestUpdate()
+
+	valCtx := context.WithValue(context.Background(), utils.ContextAuthTokenKey, strategyTest.users[0].Token)
+	newStrategy := func(name string, resources *apisecurity.StrategyResources) *apisecurity.AuthStrategy {
+		return &apisecurity.AuthStrategy{
+			Name: &wrapperspb.StringValue{Value: name},
+			Principals: &apisecurity.Principals{
+				Users: []*apisecurity.Principal{{
+					Id:   &wrapperspb.StringValue{Value: strategyTest.users[1].ID},
+					Name: &wrapperspb.StringValue{Value: strategyTest.users[1].Name},
+				}},
+			},
+			Resources: resources,
+		}
+	}
+
+	t.Run("命中提示规则-创建成功并返回提示", func(t *testing.T) {
+		strategyTest.storage.EXPECT().AddStrategy(gomock.Any()).Return(nil)
+
+		resp := strategyTest.svr.CreateStrategy(valCtx, newStrategy("lint-warn", &apisecurity.StrategyResources{
+			Namespaces: []*apisecurity.StrategyResourceEntry{
+				{Id: &wrapperspb.StringValue{Value: strategyTest.namespaces[0].Name}},
+			},
+		}))
+		assert.Equal(t, api.ExecuteSuccess, resp.Code.GetValue(), resp.Info.GetValue())
+		assert.True(t, strings.Contains(resp.Info.GetValue(), policy.LintRuleCommentRequired), resp.Info.GetValue())
+	})
+
+	t.Run("命中拒绝规则-创建失败", func(t *testing.T) {
+		resp := strategyTest.svr.CreateStrategy(valCtx, newStrategy("lint-block", &apisecurity.StrategyResources{
+			Services: []*apisecurity.StrategyResourceEntry{
+				{Id: &wrapperspb.StringValue{Value: "*"}},
+			},
+		}))
+		assert.Equal(t, api.InvalidParameter, resp.Code.GetValue(), resp.Info.GetValue())
+		assert.True(t, strings.Contains(resp.Info.GetValue(), policy.LintRuleNoWildcardResource), resp.Info.GetValue())
+	})
+
+	t.Run("命中拒绝规则-更新失败", func(t *testing.T) {
+		strategyTest.storage.EXPECT().GetStrategyDetail(gomock.Any()).Return(strategyTest.strategies[0], nil)
+
+		resp := strategyTest.svr.UpdateStrategies(valCtx, []*apisecurity.ModifyAuthStrategy{
+			{
+				Id: &wrapperspb.StringValue{Value: strategyTest.strategies[0].ID},
+				AddResources: &apisecurity.StrategyResources{
+					Namespaces: []*apisecurity.StrategyResourceEntry{
+						{Id: &wrapperspb.StringValue{Value: "*"}},
+					},
+				},
+			},
+		})
+		assert.Equal(t, api.InvalidParameter, resp.Responses[0].Code.GetValue(), resp.Responses[0].Info.GetValue())
+	})
+}
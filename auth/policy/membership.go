@@ -0,0 +1,130 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package policy
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	apisecurity "github.com/polarismesh/specification/source/go/api/v1/security"
+	"go.uber.org/zap"
+
+	"github.com/polarismesh/polaris/auth"
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/common/utils"
+)
+
+// handleWhatIfGroupMembership 将假设的用户组追加到用户当前所在的用户组中，分别计算前后的可编辑资源并给出差异
+// 假设的成员关系只存在于本次计算中，不会写入存储层以及缓存
+func (svr *Server) handleWhatIfGroupMembership(ctx context.Context,
+	userID, groupID string) (*auth.MembershipWhatIf, error) {
+	userCache := svr.cacheMgr.User()
+	if userCache.GetUserByID(userID) == nil {
+		log.Error("[Auth][Membership] user not found", utils.RequestID(ctx), zap.String("user", userID))
+		return nil, model.ErrorNoUser
+	}
+	if userCache.GetGroup(groupID) == nil {
+		log.Error("[Auth][Membership] group not found", utils.RequestID(ctx), zap.String("group", groupID))
+		return nil, model.ErrorNoUserGroup
+	}
+
+	ret := &auth.MembershipWhatIf{
+		User:    model.Principal{PrincipalID: userID, PrincipalRole: model.PrincipalUser},
+		GroupID: groupID,
+	}
+	groupIDs := userCache.GetUserLinkGroupIds(userID)
+	current, _ := svr.checker.editableResources(userID, groupIDs)
+	ret.Current = current
+	for i := range groupIDs {
+		if groupIDs[i] == groupID {
+			ret.AlreadyMember = true
+		}
+	}
+	if ret.AlreadyMember {
+		ret.Added, ret.Removed = []auth.CapabilityResource{}, []auth.CapabilityResource{}
+		return ret, nil
+	}
+
+	hypothetical := append(append(make([]string, 0, len(groupIDs)+1), groupIDs...), groupID)
+	after, readOnly := svr.checker.editableResources(userID, hypothetical)
+	ret.ReadOnly = readOnly
+	ret.Added = subtractResources(after, current)
+	ret.Removed = subtractResources(current, after)
+	return ret, nil
+}
+
+// editableResources 用户本身以及给定用户组的鉴权策略授予写权限的资源关联关系，按照资源类型、资源 ID 排序
+// 绑定了只读策略时没有任何写权限，返回 true；已经过期的资源关联关系、请求条件以及拒绝策略不作为可编辑的资源
+func (d *DefaultAuthChecker) editableResources(userID string,
+	groupIDs []string) ([]auth.CapabilityResource, bool) {
+	strategyCache := d.cacheMgr.AuthStrategy()
+	rules := append([]*model.StrategyDetail{}, strategyCache.GetStrategyDetailsByUID(userID)...)
+	for i := range groupIDs {
+		rules = append(rules, strategyCache.GetStrategyDetailsByGroupID(groupIDs[i])...)
+	}
+	for i := range rules {
+		if rules[i].IsReadOnly() {
+			return []auth.CapabilityResource{}, true
+		}
+	}
+
+	var (
+		ret  = []auth.CapabilityResource{}
+		seen = map[auth.CapabilityResource]struct{}{}
+		now  = time.Now()
+	)
+	for i := range rules {
+		if rules[i].IsDeny() {
+			continue
+		}
+		for _, res := range rules[i].Resources {
+			if res.IsExpired(now) || IsRequestCondition(res.ResID) {
+				continue
+			}
+			key := auth.CapabilityResource{Type: apisecurity.ResourceType(res.ResType), ID: res.ResID}
+			if _, ok := seen[key]; ok {
+				continue
+			}
+			seen[key] = struct{}{}
+			ret = append(ret, key)
+		}
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		if ret[i].Type != ret[j].Type {
+			return ret[i].Type < ret[j].Type
+		}
+		return ret[i].ID < ret[j].ID
+	})
+	return ret, false
+}
+
+// subtractResources 存在于 a 但是不存在于 b 中的资源，保持 a 中的顺序
+func subtractResources(a, b []auth.CapabilityResource) []auth.CapabilityResource {
+	exclude := make(map[auth.CapabilityResource]struct{}, len(b))
+	for i := range b {
+		exclude[b[i]] = struct{}{}
+	}
+	ret := []auth.CapabilityResource{}
+	for i := range a {
+		if _, ok := exclude[a[i]]; !ok {
+			ret = append(ret, a[i])
+		}
+	}
+	return ret
+}
@@ -0,0 +1,252 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package policy
+
+import (
+	"context"
+	"crypto/x509"
+	"fmt"
+	"regexp"
+
+	"go.uber.org/zap"
+
+	cachetypes "github.com/polarismesh/polaris/cache/api"
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/common/utils"
+)
+
+const (
+	// CertIdentitySubjectCN 使用证书 Subject 的 CommonName 作为身份标识
+	CertIdentitySubjectCN = "subject.cn"
+	// CertIdentitySANDNS 使用证书 SAN 中的 DNS 名称作为身份标识
+	CertIdentitySANDNS = "san.dns"
+	// CertIdentitySANURI 使用证书 SAN 中的 URI 作为身份标识
+	CertIdentitySANURI = "san.uri"
+	// CertIdentitySANEmail 使用证书 SAN 中的邮箱地址作为身份标识
+	CertIdentitySANEmail = "san.email"
+
+	// defaultCertPrincipalRule 默认将整个身份标识作为用户名称
+	defaultCertPrincipalRule = "^(?P<name>.+)$"
+)
+
+// CertPrincipalMapping 证书身份标识到北极星 principal 的映射, 适用于 SPIFFE ID 等无法通过命名分组解析出 principal 的身份标识
+type CertPrincipalMapping struct {
+	// Pattern 匹配身份标识的正则表达式
+	Pattern string `json:"pattern"`
+	// Type principal 类型, user 或者 group, 不设置时为 user
+	Type string `json:"type"`
+	// ID principal 的 ID
+	ID string `json:"id"`
+	// Name 用户名称, 按照名称查找用户时使用
+	Name string `json:"name"`
+	// Owner 用户所属主账户的名称, 不设置时使用 mtlsDefaultOwner
+	Owner string `json:"owner"`
+}
+
+type certPrincipalMapping struct {
+	pattern *regexp.Regexp
+	CertPrincipalMapping
+}
+
+// certPrincipalResolver 根据 mTLS 客户端证书解析出对应的北极星 principal
+// 身份标识优先按照顺序匹配 mtlsPrincipalMappings, 第一个匹配的映射决定 principal,
+// 均不匹配时再通过 mtlsPrincipalRule 正则进行匹配，支持以下命名分组
+//   - type: principal 类型, user 或者 group, 不设置时为 user
+//   - id: principal 的 ID
+//   - name: 用户名称, 需要配合 owner 分组或者 mtlsDefaultOwner 配置使用
+//   - owner: 用户所属主账户的名称
+type certPrincipalResolver struct {
+	source       string
+	mappings     []certPrincipalMapping
+	rule         *regexp.Regexp
+	defaultOwner string
+	userCache    cachetypes.UserCache
+}
+
+// newCertPrincipalResolver 创建证书 principal 解析器, 未开启 mTLS principal 解析时返回 nil
+func newCertPrincipalResolver(options *AuthConfig, userCache cachetypes.UserCache) (*certPrincipalResolver, error) {
+	if !options.MTLSOpen {
+		return nil, nil
+	}
+	source := options.MTLSIdentitySource
+	if source == "" {
+		source = CertIdentitySubjectCN
+	}
+	switch source {
+	case CertIdentitySubjectCN, CertIdentitySANDNS, CertIdentitySANURI, CertIdentitySANEmail:
+	default:
+		return nil, fmt.Errorf("[Auth][mTLS] unsupported identity source: %s", source)
+	}
+	expr := options.MTLSPrincipalRule
+	if expr == "" {
+		expr = defaultCertPrincipalRule
+	}
+	rule, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, fmt.Errorf("[Auth][mTLS] invalid principal rule %s: %w", expr, err)
+	}
+	if rule.SubexpIndex("id") < 0 && rule.SubexpIndex("name") < 0 {
+		return nil, fmt.Errorf("[Auth][mTLS] principal rule %s must contain named group id or name", expr)
+	}
+	mappings, err := compileCertPrincipalMappings(options.MTLSPrincipalMappings)
+	if err != nil {
+		return nil, err
+	}
+	return &certPrincipalResolver{
+		source:       source,
+		mappings:     mappings,
+		rule:         rule,
+		defaultOwner: options.MTLSDefaultOwner,
+		userCache:    userCache,
+	}, nil
+}
+
+func compileCertPrincipalMappings(items []CertPrincipalMapping) ([]certPrincipalMapping, error) {
+	mappings := make([]certPrincipalMapping, 0, len(items))
+	for _, item := range items {
+		pattern, err := regexp.Compile(item.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("[Auth][mTLS] invalid principal mapping pattern %s: %w", item.Pattern, err)
+		}
+		switch item.Type {
+		case "", model.PrincipalNames[model.PrincipalUser]:
+			if item.ID == "" && item.Name == "" {
+				return nil, fmt.Errorf("[Auth][mTLS] principal mapping %s must set id or name", item.Pattern)
+			}
+		case model.PrincipalNames[model.PrincipalGroup]:
+			if item.ID == "" {
+				return nil, fmt.Errorf("[Auth][mTLS] group principal mapping %s must set id", item.Pattern)
+			}
+		default:
+			return nil, fmt.Errorf("[Auth][mTLS] unsupported principal mapping type: %s", item.Type)
+		}
+		mappings = append(mappings, certPrincipalMapping{pattern: pattern, CertPrincipalMapping: item})
+	}
+	return mappings, nil
+}
+
+// identities 获取证书中可用于匹配的身份标识
+func (r *certPrincipalResolver) identities(cert *x509.Certificate) []string {
+	switch r.source {
+	case CertIdentitySANDNS:
+		return cert.DNSNames
+	case CertIdentitySANURI:
+		ret := make([]string, 0, len(cert.URIs))
+		for i := range cert.URIs {
+			ret = append(ret, cert.URIs[i].String())
+		}
+		return ret
+	case CertIdentitySANEmail:
+		return cert.EmailAddresses
+	default:
+		if cert.Subject.CommonName == "" {
+			return nil
+		}
+		return []string{cert.Subject.CommonName}
+	}
+}
+
+// Resolve 根据证书链中的叶子证书解析 principal, 返回该 principal 的 token。
+// certs 必须是 apiserver 使用 trustedCAFile 校验通过的证书链
+func (r *certPrincipalResolver) Resolve(certs []*x509.Certificate) (string, bool) {
+	if len(certs) == 0 || certs[0] == nil {
+		return "", false
+	}
+	for _, identity := range r.identities(certs[0]) {
+		if token, ok := r.resolveIdentity(identity); ok {
+			return token, true
+		}
+	}
+	return "", false
+}
+
+func (r *certPrincipalResolver) resolveIdentity(identity string) (string, bool) {
+	for i := range r.mappings {
+		if mapping := r.mappings[i]; mapping.pattern.MatchString(identity) {
+			return r.lookup(mapping.Type, mapping.ID, mapping.Name, mapping.Owner)
+		}
+	}
+	match := r.rule.FindStringSubmatch(identity)
+	if match == nil {
+		return "", false
+	}
+	group := func(name string) string {
+		if index := r.rule.SubexpIndex(name); index >= 0 {
+			return match[index]
+		}
+		return ""
+	}
+	return r.lookup(group("type"), group("id"), group("name"), group("owner"))
+}
+
+// lookup 查找 principal 并返回其 token, 按照名称查找用户时 owner 为空则使用 mtlsDefaultOwner
+func (r *certPrincipalResolver) lookup(principalType, id, name, owner string) (string, bool) {
+	if principalType == "" {
+		principalType = model.PrincipalNames[model.PrincipalUser]
+	}
+
+	switch principalType {
+	case model.PrincipalNames[model.PrincipalUser]:
+		var user *model.User
+		if id != "" {
+			user = r.userCache.GetUserByID(id)
+		} else if name != "" {
+			if owner == "" {
+				owner = r.defaultOwner
+			}
+			user = r.userCache.GetUserByName(name, owner)
+		}
+		if user == nil || user.Token == "" {
+			return "", false
+		}
+		return user.Token, true
+	case model.PrincipalNames[model.PrincipalGroup]:
+		if id == "" {
+			return "", false
+		}
+		group := r.userCache.GetGroup(id)
+		if group == nil || group.Token == "" {
+			return "", false
+		}
+		return group.Token, true
+	default:
+		return "", false
+	}
+}
+
+// injectCertPrincipal 请求未携带 token 时，尝试根据 mTLS 客户端证书确定 principal, token 的优先级始终高于证书
+func (d *DefaultAuthChecker) injectCertPrincipal(authCtx *model.AcquireContext) {
+	if d.certResolver == nil {
+		return
+	}
+	ctx := authCtx.GetRequestContext()
+	if utils.ParseAuthToken(ctx) != "" {
+		return
+	}
+	certs := utils.ParsePeerCertificates(ctx)
+	if len(certs) == 0 {
+		return
+	}
+	token, ok := d.certResolver.Resolve(certs)
+	if !ok {
+		log.Warn("[Auth][Checker] no principal match peer certificate", utils.RequestID(ctx),
+			zap.String("subject", certs[0].Subject.String()))
+		return
+	}
+	authCtx.SetRequestContext(context.WithValue(ctx, utils.ContextAuthTokenKey, token))
+}
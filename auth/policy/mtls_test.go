@@ -0,0 +1,223 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package policy_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net/url"
+	"testing"
+	"time"
+
+	apisecurity "github.com/polarismesh/specification/source/go/api/v1/security"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/polarismesh/polaris/auth"
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/common/utils"
+)
+
+// newTestClientCert 生成自签名的客户端证书
+func newTestClientCert(t *testing.T, commonName string, uris ...string) *x509.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+
+	tpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Minute),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	for _, raw := range uris {
+		u, err := url.Parse(raw)
+		assert.NoError(t, err)
+		tpl.URIs = append(tpl.URIs, u)
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tpl, tpl, &key.PublicKey, key)
+	assert.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	assert.NoError(t, err)
+	return cert
+}
+
+func Test_CertPrincipal(t *testing.T) {
+	strategyTest := newStrategyTest(t)
+	defer strategyTest.Clean()
+
+	initWithOptions := func(options map[string]interface{}) error {
+		err := strategyTest.svr.Initialize(&auth.Config{
+			Strategy: &auth.StrategyConfig{
+				Name:   auth.DefaultPolicyPluginName,
+				Option: options,
+			},
+		}, strategyTest.storage, strategyTest.cacheMgn, strategyTest.userSvr)
+		_ = strategyTest.cacheMgn.TestUpdate()
+		return err
+	}
+
+	// checkOperator 执行一次鉴权，返回解析出的操作者
+	checkOperator := func(ctx context.Context) (auth.OperatorInfo, bool) {
+		authCtx := model.NewAcquireContext(
+			model.WithRequestContext(ctx),
+			model.WithMethod("Test_CertPrincipal"),
+			model.WithOperation(model.Read),
+			model.WithModule(model.DiscoverModule),
+			model.WithAccessResources(map[apisecurity.ResourceType][]model.ResourceEntry{}),
+		)
+		_, _ = strategyTest.policySvr.GetAuthChecker().CheckConsolePermission(authCtx)
+		val, ok := authCtx.GetAttachment(model.TokenDetailInfoKey)
+		if !ok {
+			return auth.OperatorInfo{}, false
+		}
+		operator, ok := val.(auth.OperatorInfo)
+		return operator, ok
+	}
+	withCerts := func(ctx context.Context, certs ...*x509.Certificate) context.Context {
+		return context.WithValue(ctx, utils.ContextPeerCertificates, certs)
+	}
+
+	t.Run("证书 CN 按照用户名称以及主账户名称映射用户", func(t *testing.T) {
+		err := initWithOptions(map[string]interface{}{
+			"mtlsOpen":          true,
+			"mtlsPrincipalRule": "^(?P<name>[^@]+)@(?P<owner>.+)$",
+		})
+		assert.NoError(t, err)
+
+		cert := newTestClientCert(t, strategyTest.users[1].Name+"@"+strategyTest.users[0].Name)
+		operator, ok := checkOperator(withCerts(context.Background(), cert))
+		assert.True(t, ok)
+		assert.Equal(t, strategyTest.users[1].ID, operator.OperatorID)
+		assert.False(t, operator.Anonymous)
+	})
+
+	t.Run("证书 SAN URI 按照 ID 映射用户组", func(t *testing.T) {
+		err := initWithOptions(map[string]interface{}{
+			"mtlsOpen":           true,
+			"mtlsIdentitySource": "san.uri",
+			"mtlsPrincipalRule":  "^spiffe://polaris/(?P<type>user|group)/(?P<id>[^/]+)$",
+		})
+		assert.NoError(t, err)
+
+		cert := newTestClientCert(t, "unused", "spiffe://polaris/group/"+strategyTest.groups[0].ID)
+		operator, ok := checkOperator(withCerts(context.Background(), cert))
+		assert.True(t, ok)
+		assert.Equal(t, strategyTest.groups[0].ID, operator.OperatorID)
+		assert.False(t, operator.IsUserToken)
+	})
+
+	t.Run("证书 SAN URI 按照映射优先于规则确定用户", func(t *testing.T) {
+		err := initWithOptions(map[string]interface{}{
+			"mtlsOpen":           true,
+			"mtlsIdentitySource": "san.uri",
+			"mtlsDefaultOwner":   strategyTest.users[0].Name,
+			"mtlsPrincipalRule":  "^spiffe://polaris/ns/[^/]+/sa/(?P<name>[^/]+)$",
+			"mtlsPrincipalMappings": []interface{}{
+				map[string]interface{}{"pattern": "^spiffe://polaris/ns/prod/", "name": strategyTest.users[1].Name},
+				map[string]interface{}{"pattern": "^spiffe://polaris/ns/ops/", "type": "group",
+					"id": strategyTest.groups[0].ID},
+			},
+		})
+		assert.NoError(t, err)
+
+		cert := newTestClientCert(t, "unused", "spiffe://polaris/ns/prod/sa/"+strategyTest.users[2].Name)
+		operator, ok := checkOperator(withCerts(context.Background(), cert))
+		assert.True(t, ok)
+		assert.Equal(t, strategyTest.users[1].ID, operator.OperatorID)
+
+		cert = newTestClientCert(t, "unused", "spiffe://polaris/ns/ops/sa/deployer")
+		operator, ok = checkOperator(withCerts(context.Background(), cert))
+		assert.True(t, ok)
+		assert.Equal(t, strategyTest.groups[0].ID, operator.OperatorID)
+
+		// 没有映射匹配时按照规则解析
+		cert = newTestClientCert(t, "unused", "spiffe://polaris/ns/test/sa/"+strategyTest.users[2].Name)
+		operator, ok = checkOperator(withCerts(context.Background(), cert))
+		assert.True(t, ok)
+		assert.Equal(t, strategyTest.users[2].ID, operator.OperatorID)
+	})
+
+	t.Run("同时携带 token 与证书时 token 优先", func(t *testing.T) {
+		err := initWithOptions(map[string]interface{}{
+			"mtlsOpen":         true,
+			"mtlsDefaultOwner": strategyTest.users[0].Name,
+		})
+		assert.NoError(t, err)
+
+		cert := newTestClientCert(t, strategyTest.users[1].Name)
+		ctx := context.WithValue(context.Background(), utils.ContextAuthTokenKey, strategyTest.users[2].Token)
+		operator, ok := checkOperator(withCerts(ctx, cert))
+		assert.True(t, ok)
+		assert.Equal(t, strategyTest.users[2].ID, operator.OperatorID)
+
+		// 没有 token 时才使用证书
+		operator, ok = checkOperator(withCerts(context.Background(), cert))
+		assert.True(t, ok)
+		assert.Equal(t, strategyTest.users[1].ID, operator.OperatorID)
+	})
+
+	t.Run("证书无法映射到 principal 或者未开启时降级为匿名用户", func(t *testing.T) {
+		err := initWithOptions(map[string]interface{}{
+			"mtlsOpen":         true,
+			"mtlsDefaultOwner": strategyTest.users[0].Name,
+		})
+		assert.NoError(t, err)
+
+		operator, ok := checkOperator(withCerts(context.Background(), newTestClientCert(t, "not-exist-user")))
+		assert.True(t, ok)
+		assert.True(t, operator.Anonymous)
+
+		err = initWithOptions(map[string]interface{}{})
+		assert.NoError(t, err)
+		operator, ok = checkOperator(withCerts(context.Background(), newTestClientCert(t, strategyTest.users[1].Name)))
+		assert.True(t, ok)
+		assert.True(t, operator.Anonymous)
+	})
+
+	t.Run("非法的映射规则", func(t *testing.T) {
+		err := initWithOptions(map[string]interface{}{
+			"mtlsOpen":          true,
+			"mtlsPrincipalRule": "^(?P<unknown>.+)$",
+		})
+		assert.Error(t, err)
+
+		err = initWithOptions(map[string]interface{}{
+			"mtlsOpen":           true,
+			"mtlsIdentitySource": "issuer",
+		})
+		assert.Error(t, err)
+
+		for _, mapping := range []map[string]interface{}{
+			{"pattern": "^(spiffe", "name": "user"},
+			{"pattern": "^spiffe://", "type": "user"},
+			{"pattern": "^spiffe://", "type": "group", "name": "group"},
+			{"pattern": "^spiffe://", "type": "role", "id": "1"},
+		} {
+			err = initWithOptions(map[string]interface{}{
+				"mtlsOpen":              true,
+				"mtlsPrincipalMappings": []interface{}{mapping},
+			})
+			assert.Error(t, err, mapping)
+		}
+	})
+}
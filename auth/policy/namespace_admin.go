@@ -0,0 +1,193 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package policy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	apimodel "github.com/polarismesh/specification/source/go/api/v1/model"
+	apisecurity "github.com/polarismesh/specification/source/go/api/v1/security"
+	"go.uber.org/zap"
+
+	cachetypes "github.com/polarismesh/polaris/cache/api"
+	"github.com/polarismesh/polaris/common/model"
+	authcommon "github.com/polarismesh/polaris/common/model/auth"
+	"github.com/polarismesh/polaris/common/utils"
+)
+
+var (
+	// ErrorNamespaceAdminOutOfScope 命名空间管理员修改的鉴权策略超出了其管辖的命名空间
+	ErrorNamespaceAdminOutOfScope = errors.New("strategy out of namespace admin scope")
+)
+
+// namespaceAdminScope 返回当前子账户被授予管理权限的命名空间，超级管理员、主账户以及未授权的子账户返回 false
+func (svr *Server) namespaceAdminScope(ctx context.Context) (map[string]struct{}, bool) {
+	if authcommon.ParseUserRole(ctx) == model.AdminUserRole || utils.ParseIsOwner(ctx) {
+		return nil, false
+	}
+	namespaces := svr.options.NamespaceAdmins[utils.ParseUserID(ctx)]
+	if len(namespaces) == 0 {
+		return nil, false
+	}
+	scope := make(map[string]struct{}, len(namespaces))
+	for i := range namespaces {
+		scope[namespaces[i]] = struct{}{}
+	}
+	return scope, true
+}
+
+// checkNamespaceAdminStrategy 检查命名空间管理员能否修改鉴权策略
+// Case 1. 只能修改与自己同一个主账户下的非默认策略
+// Case 2. 策略关联的资源必须全部属于管辖的命名空间，关联 * 或者属性规则的全局策略不允许修改
+// Case 3. 策略关联的用户、用户组必须属于同一个主账户
+func (svr *Server) checkNamespaceAdminStrategy(ctx context.Context, scope map[string]struct{},
+	strategy *model.StrategyDetail) error {
+	ownerID := utils.ParseOwnerID(ctx)
+	if strategy.Owner != ownerID || strategy.Default {
+		return fmt.Errorf("%w: strategy %s", ErrorNamespaceAdminOutOfScope, strategy.Name)
+	}
+	for i := range strategy.Resources {
+		res := strategy.Resources[i]
+		if IsRequestCondition(res.ResID) {
+			continue
+		}
+		namespace, ok := svr.resourceNamespace(res)
+		if _, inScope := scope[namespace]; !ok || !inScope {
+			return fmt.Errorf("%w: resource %s", ErrorNamespaceAdminOutOfScope, res.ResID)
+		}
+	}
+	userCache := svr.cacheMgr.User()
+	for i := range strategy.Principals {
+		principal := strategy.Principals[i]
+		var owner string
+		switch principal.PrincipalRole {
+		case model.PrincipalUser:
+			if user := userCache.GetUserByID(principal.PrincipalID); user != nil {
+				owner = user.Owner
+				if owner == "" {
+					owner = user.ID
+				}
+			}
+		case model.PrincipalGroup:
+			if group := userCache.GetGroup(principal.PrincipalID); group != nil {
+				owner = group.Owner
+			}
+		}
+		if owner != ownerID {
+			return fmt.Errorf("%w: principal %s", ErrorNamespaceAdminOutOfScope, principal.PrincipalID)
+		}
+	}
+	return nil
+}
+
+// resourceNamespace 获取资源所属的命名空间，* 以及属性规则不属于任何一个命名空间
+// 标签选择器属于其指定的命名空间，选择全部命名空间的标签选择器不属于任何一个命名空间
+func (svr *Server) resourceNamespace(res model.StrategyResource) (string, bool) {
+	if res.ResID == "*" || IsAttributeResource(res.ResID) {
+		return "", false
+	}
+	if res.IsLabelSelector() {
+		namespace, _, ok := res.LabelSelector()
+		return namespace, ok && namespace != utils.MatchAll
+	}
+	return namespaceOfResource(svr.cacheMgr, apisecurity.ResourceType(res.ResType), res.ResID)
+}
+
+// namespaceOfResource 获取具体资源所属的命名空间，资源不存在时返回 false
+func namespaceOfResource(cacheMgr cachetypes.CacheManager, resType apisecurity.ResourceType,
+	resID string) (string, bool) {
+	switch resType {
+	case apisecurity.ResourceType_Namespaces:
+		return resID, true
+	case apisecurity.ResourceType_Services:
+		svc := cacheMgr.Service().GetServiceByID(resID)
+		if svc == nil {
+			return "", false
+		}
+		return svc.Namespace, true
+	case apisecurity.ResourceType_ConfigGroups:
+		groupID, err := strconv.ParseUint(resID, 10, 64)
+		if err != nil {
+			return "", false
+		}
+		group := cacheMgr.ConfigGroup().GetGroupByID(groupID)
+		if group == nil {
+			return "", false
+		}
+		return group.Namespace, true
+	default:
+		return "", false
+	}
+}
+
+// isNamespaceAdminOf principal 是否为资源所属命名空间的管理员
+// 命名空间管理员对管辖命名空间下的全部资源拥有读写权限，等同于被授予了这些资源的鉴权策略
+func (d *DefaultAuthChecker) isNamespaceAdminOf(principal model.Principal, resType apisecurity.ResourceType,
+	resID string) bool {
+	if principal.PrincipalRole != model.PrincipalUser {
+		return false
+	}
+	namespaces := d.conf.NamespaceAdmins[principal.PrincipalID]
+	if len(namespaces) == 0 {
+		return false
+	}
+	namespace, ok := namespaceOfResource(d.cacheMgr, resType, resID)
+	if !ok {
+		return false
+	}
+	for i := range namespaces {
+		if namespaces[i] == namespace {
+			return true
+		}
+	}
+	return false
+}
+
+// checkStrategyEditor 只有超级管理员、主账户以及命名空间管理员可以修改鉴权策略，在读取鉴权策略之前检查
+func (svr *Server) checkStrategyEditor(ctx context.Context) apimodel.Code {
+	if authcommon.ParseUserRole(ctx) == model.AdminUserRole || utils.ParseIsOwner(ctx) {
+		return apimodel.Code_ExecuteSuccess
+	}
+	if _, ok := svr.namespaceAdminScope(ctx); !ok {
+		log.Error("[Auth][Strategy] only admin/owner/namespace admin account can modify strategy",
+			utils.RequestID(ctx), zap.String("user", utils.ParseUserID(ctx)))
+		return apimodel.Code_OperationRoleForbidden
+	}
+	return apimodel.Code_ExecuteSuccess
+}
+
+// checkStrategyEditable 超级管理员以及主账户直接放通，由调用方继续检查 owner
+// 子账户只有被授予命名空间管理权限，且鉴权策略在管辖范围内时才能修改
+func (svr *Server) checkStrategyEditable(ctx context.Context, strategy *model.StrategyDetail) apimodel.Code {
+	if authcommon.ParseUserRole(ctx) == model.AdminUserRole || utils.ParseIsOwner(ctx) {
+		return apimodel.Code_ExecuteSuccess
+	}
+	scope, ok := svr.namespaceAdminScope(ctx)
+	if !ok {
+		return svr.checkStrategyEditor(ctx)
+	}
+	if err := svr.checkNamespaceAdminStrategy(ctx, scope, strategy); err != nil {
+		log.Error("[Auth][Strategy] namespace admin modify strategy denied", utils.RequestID(ctx),
+			zap.String("user", utils.ParseUserID(ctx)), zap.Error(err))
+		return apimodel.Code_NotAllowedAccess
+	}
+	return apimodel.Code_ExecuteSuccess
+}
@@ -0,0 +1,285 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package policy_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	apimodel "github.com/polarismesh/specification/source/go/api/v1/model"
+	apisecurity "github.com/polarismesh/specification/source/go/api/v1/security"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/polarismesh/polaris/auth"
+	"github.com/polarismesh/polaris/auth/policy"
+	defaultuser "github.com/polarismesh/polaris/auth/user"
+	"github.com/polarismesh/polaris/cache"
+	cachetypes "github.com/polarismesh/polaris/cache/api"
+	api "github.com/polarismesh/polaris/common/api/v1"
+	"github.com/polarismesh/polaris/common/eventhub"
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/common/utils"
+)
+
+func Test_NamespaceAdmin(t *testing.T) {
+	strategyTest := newStrategyTest(t)
+	defer strategyTest.Clean()
+
+	// users[1] 是 services[1] 所在命名空间的管理员
+	nsAdmin := strategyTest.users[1]
+	inScope := strategyTest.strategies[1]
+	outScope := strategyTest.strategies[2]
+	err := strategyTest.svr.Initialize(&auth.Config{
+		Strategy: &auth.StrategyConfig{
+			Name: auth.DefaultPolicyPluginName,
+			Option: map[string]interface{}{
+				"namespaceAdmins": map[string]interface{}{
+					nsAdmin.ID: []interface{}{strategyTest.services[1].Namespace},
+				},
+			},
+		},
+	}, strategyTest.storage, strategyTest.cacheMgn, strategyTest.userSvr)
+	assert.NoError(t, err)
+	_ = strategyTest.cacheMgn.TestUpdate()
+
+	adminCtx := context.WithValue(context.Background(), utils.ContextAuthTokenKey, nsAdmin.Token)
+	strategyTest.storage.EXPECT().GetStrategyDetail(inScope.ID).Return(inScope, nil).AnyTimes()
+	strategyTest.storage.EXPECT().GetStrategyDetail(outScope.ID).Return(outScope, nil).AnyTimes()
+
+	serviceEntry := func(svc *model.Service) *apisecurity.StrategyResources {
+		return &apisecurity.StrategyResources{
+			Services: []*apisecurity.StrategyResourceEntry{{Id: utils.NewStringValue(svc.ID)}},
+		}
+	}
+	newModify := func(strategy *model.StrategyDetail, svc *model.Service) *apisecurity.ModifyAuthStrategy {
+		return &apisecurity.ModifyAuthStrategy{
+			Id:               utils.NewStringValue(strategy.ID),
+			AddPrincipals:    &apisecurity.Principals{},
+			RemovePrincipals: &apisecurity.Principals{},
+			AddResources:     serviceEntry(svc),
+		}
+	}
+	newStrategy := func(svc *model.Service) *apisecurity.AuthStrategy {
+		return &apisecurity.AuthStrategy{
+			Id:   utils.NewStringValue(utils.NewUUID()),
+			Name: utils.NewStringValue("namespace-admin-" + svc.Namespace),
+			Principals: &apisecurity.Principals{
+				Users: []*apisecurity.Principal{{Id: utils.NewStringValue(strategyTest.users[3].ID)}},
+			},
+			Resources: serviceEntry(svc),
+		}
+	}
+
+	t.Run("修改管辖命名空间内的鉴权策略", func(t *testing.T) {
+		strategyTest.storage.EXPECT().UpdateStrategy(gomock.Any()).Return(nil)
+		resp := strategyTest.svr.UpdateStrategies(adminCtx, []*apisecurity.ModifyAuthStrategy{
+			{
+				Id:               utils.NewStringValue(inScope.ID),
+				AddPrincipals:    &apisecurity.Principals{},
+				RemovePrincipals: &apisecurity.Principals{},
+				RemoveResources:  serviceEntry(strategyTest.services[1]),
+			},
+		})
+		assert.Equal(t, api.ExecuteSuccess, resp.Responses[0].GetCode().GetValue(),
+			resp.Responses[0].GetInfo().GetValue())
+
+		strategyTest.storage.EXPECT().AddStrategy(gomock.Any()).Return(nil)
+		createResp := strategyTest.svr.CreateStrategy(adminCtx, newStrategy(strategyTest.services[1]))
+		assert.Equal(t, api.ExecuteSuccess, createResp.GetCode().GetValue(), createResp.GetInfo().GetValue())
+	})
+
+	t.Run("不能修改引用其他命名空间的鉴权策略", func(t *testing.T) {
+		strategyTest.storage.EXPECT().UpdateStrategy(gomock.Any()).Times(0)
+		strategyTest.storage.EXPECT().DeleteStrategy(gomock.Any()).Times(0)
+		strategyTest.storage.EXPECT().LooseAddStrategyResources(gomock.Any()).Times(0)
+
+		// 已有的策略引用了其他命名空间
+		resp := strategyTest.svr.UpdateStrategies(adminCtx, []*apisecurity.ModifyAuthStrategy{
+			newModify(outScope, strategyTest.services[1]),
+		})
+		assert.Equal(t, uint32(apimodel.Code_NotAllowedAccess), resp.Responses[0].GetCode().GetValue())
+		delResp := strategyTest.svr.DeleteStrategies(adminCtx, []*apisecurity.AuthStrategy{
+			{Id: utils.NewStringValue(outScope.ID)},
+		})
+		assert.Equal(t, uint32(apimodel.Code_NotAllowedAccess), delResp.Responses[0].GetCode().GetValue())
+
+		// 为管辖范围内的策略增加其他命名空间的资源
+		resp = strategyTest.svr.UpdateStrategies(adminCtx, []*apisecurity.ModifyAuthStrategy{
+			newModify(inScope, strategyTest.services[2]),
+		})
+		assert.Equal(t, uint32(apimodel.Code_NotAllowedAccess), resp.Responses[0].GetCode().GetValue())
+		attachResp := strategyTest.svr.AttachStrategyResources(adminCtx, inScope.ID, []model.StrategyResource{
+			{ResType: int32(apisecurity.ResourceType_Services), ResID: strategyTest.services[2].ID},
+		})
+		assert.Equal(t, uint32(apimodel.Code_NotAllowedAccess), attachResp.GetCode().GetValue())
+
+		// 创建引用其他命名空间以及全部资源的策略
+		createResp := strategyTest.svr.CreateStrategy(adminCtx, newStrategy(strategyTest.services[2]))
+		assert.Equal(t, uint32(apimodel.Code_NotAllowedAccess), createResp.GetCode().GetValue())
+		global := newStrategy(strategyTest.services[1])
+		global.Resources.Namespaces = []*apisecurity.StrategyResourceEntry{{Id: utils.NewStringValue("*")}}
+		createResp = strategyTest.svr.CreateStrategy(adminCtx, global)
+		assert.Equal(t, uint32(apimodel.Code_NotAllowedAccess), createResp.GetCode().GetValue())
+	})
+
+	t.Run("未授权的子账户不能修改鉴权策略", func(t *testing.T) {
+		subCtx := context.WithValue(context.Background(), utils.ContextAuthTokenKey, strategyTest.users[2].Token)
+		createResp := strategyTest.svr.CreateStrategy(subCtx, newStrategy(strategyTest.services[1]))
+		assert.Equal(t, uint32(apimodel.Code_OperationRoleForbidden), createResp.GetCode().GetValue())
+		resp := strategyTest.svr.UpdateStrategies(subCtx, []*apisecurity.ModifyAuthStrategy{
+			newModify(inScope, strategyTest.services[1]),
+		})
+		assert.Equal(t, uint32(apimodel.Code_OperationRoleForbidden), resp.Responses[0].GetCode().GetValue())
+	})
+}
+
+func Test_NamespaceAdminPermission(t *testing.T) {
+	reset(true)
+	eventhub.InitEventHub()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	users := createMockUser(10)
+	groups := createMockUserGroup(users)
+	namespaces := createMockNamespace(len(users)+len(groups)+10, users[0].ID)
+	services := createMockService(namespaces)
+	serviceMap := convertServiceSliceToMap(services)
+	// users[1] 是 namespaces[0] 的管理员，namespaces[0]、namespaces[1] 下的资源都只授权给了 users[2]
+	nsAdmin, managed, other := users[1], namespaces[0].Name, namespaces[1].Name
+
+	newStrategy := func(name string, effect model.StrategyEffect, principal *model.User,
+		resources ...model.StrategyResource) *model.StrategyDetail {
+		return &model.StrategyDetail{
+			ID:         utils.NewUUID(),
+			Name:       name,
+			Action:     apisecurity.AuthAction_READ_WRITE.String(),
+			Owner:      users[0].ID,
+			Principals: []model.Principal{{PrincipalID: principal.ID, PrincipalRole: model.PrincipalUser}},
+			Effect:     effect,
+			Valid:      true,
+			ModifyTime: time.Now(),
+			Resources:  resources,
+		}
+	}
+	nsRes := func(name string) model.StrategyResource {
+		return model.StrategyResource{ResType: int32(apisecurity.ResourceType_Namespaces), ResID: name}
+	}
+	svcRes := func(svc *model.Service) model.StrategyResource {
+		return model.StrategyResource{ResType: int32(apisecurity.ResourceType_Services), ResID: svc.ID}
+	}
+	strategies := []*model.StrategyDetail{
+		newStrategy("namespace-admin-grant", model.StrategyEffectAllow, users[2],
+			nsRes(managed), nsRes(other), svcRes(services[0]), svcRes(services[1])),
+		newStrategy("namespace-admin-deny", model.StrategyEffectDeny, nsAdmin, svcRes(services[0])),
+	}
+
+	cfg, storage := initCache(ctrl)
+	storage.EXPECT().GetUsersForCache(gomock.Any(), gomock.Any()).AnyTimes().Return(users, nil)
+	storage.EXPECT().GetGroupsForCache(gomock.Any(), gomock.Any()).AnyTimes().Return(groups, nil)
+	storage.EXPECT().GetUserSessionsForCache(gomock.Any(), gomock.Any()).AnyTimes().Return(nil, nil)
+	storage.EXPECT().GetRolesForCache(gomock.Any(), gomock.Any()).AnyTimes().Return(nil, nil)
+	storage.EXPECT().GetStrategyDetailsForCache(gomock.Any(), gomock.Any()).AnyTimes().Return(strategies, nil)
+	storage.EXPECT().GetMoreNamespaces(gomock.Any()).AnyTimes().Return(namespaces, nil)
+	storage.EXPECT().GetMoreServices(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		AnyTimes().Return(serviceMap, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cacheMgr, err := cache.TestCacheInitialize(ctx, cfg, storage)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		cancel()
+		cacheMgr.Close()
+	})
+	if err := cacheMgr.OpenResourceCache([]cachetypes.ConfigEntry{
+		{Name: cachetypes.ServiceName},
+		{Name: cachetypes.InstanceName},
+		{Name: cachetypes.NamespaceName},
+	}...); err != nil {
+		t.Fatal(err)
+	}
+
+	_, proxySvr, err := defaultuser.BuildServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	proxySvr.Initialize(&auth.Config{
+		User: &auth.UserConfig{
+			Name:   auth.DefaultUserMgnPluginName,
+			Option: map[string]interface{}{"salt": "polarismesh@2021"},
+		},
+	}, storage, cacheMgr)
+
+	_, svr, err := newPolicyServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := svr.Initialize(&auth.Config{
+		Strategy: &auth.StrategyConfig{Name: auth.DefaultPolicyPluginName},
+	}, storage, cacheMgr, proxySvr); err != nil {
+		t.Fatal(err)
+	}
+	_ = cacheMgr.TestUpdate()
+
+	checker := svr.GetAuthChecker()
+	checker.(*policy.DefaultAuthChecker).SetConfig(&policy.AuthConfig{
+		ConsoleOpen:     true,
+		ConsoleStrict:   true,
+		NamespaceAdmins: map[string][]string{nsAdmin.ID: {managed}},
+	})
+
+	check := func(user *model.User, resType apisecurity.ResourceType, resID string) (bool, error) {
+		authCtx := model.NewAcquireContext(
+			model.WithRequestContext(context.WithValue(context.Background(), utils.ContextAuthTokenKey,
+				user.Token)),
+			model.WithMethod("UpdateServices"),
+			model.WithOperation(model.Modify),
+			model.WithModule(model.DiscoverModule),
+			model.WithAccessResources(map[apisecurity.ResourceType][]model.ResourceEntry{
+				resType: {{ID: resID, Owner: users[0].ID}},
+			}),
+		)
+		return checker.CheckConsolePermission(authCtx)
+	}
+
+	t.Run("命名空间管理员可以操作管辖命名空间下的资源", func(t *testing.T) {
+		pass, err := check(nsAdmin, apisecurity.ResourceType_Namespaces, managed)
+		assert.True(t, pass, err)
+		// 普通子账户没有被授权
+		pass, _ = check(users[3], apisecurity.ResourceType_Namespaces, managed)
+		assert.False(t, pass)
+	})
+
+	t.Run("命名空间管理员不能操作其他命名空间下的资源", func(t *testing.T) {
+		pass, err := check(nsAdmin, apisecurity.ResourceType_Namespaces, other)
+		assert.False(t, pass)
+		assert.True(t, errors.Is(err, policy.ErrorNotPermission), err)
+		pass, _ = check(nsAdmin, apisecurity.ResourceType_Services, services[1].ID)
+		assert.False(t, pass)
+	})
+
+	t.Run("拒绝策略对命名空间管理员仍然生效", func(t *testing.T) {
+		pass, err := check(nsAdmin, apisecurity.ResourceType_Services, services[0].ID)
+		assert.False(t, pass)
+		assert.True(t, errors.Is(err, policy.ErrorDenyByStrategy), err)
+	})
+}
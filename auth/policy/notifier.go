@@ -0,0 +1,273 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+package policy
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/polarismesh/polaris/common/eventhub"
+	"github.com/polarismesh/polaris/common/model"
+)
+
+const (
+	// defaultWebhookTimeoutInMs 单次推送 webhook 的默认超时时间
+	defaultWebhookTimeoutInMs = 3000
+	// defaultWebhookQueueSize 等待推送的事件队列默认长度
+	defaultWebhookQueueSize = 1024
+	// WebhookSignatureHeader 请求体 HMAC-SHA256 签名所在的请求头
+	WebhookSignatureHeader = "X-Polaris-Signature"
+)
+
+// WebhookConfig 鉴权敏感操作的 webhook 通知配置
+type WebhookConfig struct {
+	// URLs 接收通知的 webhook 地址，为空时不通知
+	URLs []string `json:"urls"`
+	// TimeoutInMs 单次推送的超时时间，小于等于 0 时使用默认值
+	TimeoutInMs int `json:"timeoutInMs"`
+	// QueueSize 等待推送的事件队列长度，队列已满时丢弃新的事件，小于等于 0 时使用默认值
+	QueueSize int `json:"queueSize"`
+	// Secret 不为空时使用 HMAC-SHA256 对请求体签名，十六进制的签名放在 X-Polaris-Signature 请求头中
+	Secret string `json:"secret"`
+}
+
+// AuthEvent 推送给 webhook 的鉴权敏感操作事件
+type AuthEvent struct {
+	// Resource 资源类型，AuthStrategy、AuthRole、User、UserGroup
+	Resource model.Resource `json:"resource"`
+	// Name 资源名称，格式为 name(id)
+	Name string `json:"name"`
+	// Operation 操作类型，token 的修改为 UpdateToken
+	Operation model.OperationType `json:"operation"`
+	// Operator 操作人
+	Operator string `json:"operator"`
+	// ChangeSetID 同一次操作产生的全部事件共享同一个变更集 ID
+	ChangeSetID string `json:"changeSetId,omitempty"`
+	// Diff 本次操作提交的变更内容，密码以及 token 已经移除
+	Diff json.RawMessage `json:"diff,omitempty"`
+	// Server 产生事件的服务端节点
+	Server string `json:"server,omitempty"`
+	// HappenTime 操作发生的时间
+	HappenTime time.Time `json:"happenTime"`
+}
+
+// webhookResources 需要推送 webhook 通知的资源类型
+var webhookResources = map[model.Resource]struct{}{
+	model.RAuthStrategy:      {},
+	model.RAuthRole:          {},
+	model.RUser:              {},
+	model.RUserGroup:         {},
+	model.RUserGroupRelation: {},
+}
+
+// webhookSensitiveFields 推送前从变更内容中移除的字段
+var webhookSensitiveFields = map[string]struct{}{
+	"password":    {},
+	"oldPassword": {},
+	"newPassword": {},
+	"authToken":   {},
+	"auth_token":  {},
+	"token":       {},
+}
+
+// webhookNotifier 订阅鉴权相关的操作记录，异步推送给配置的 webhook 地址
+type webhookNotifier struct {
+	conf   *WebhookConfig
+	client *http.Client
+	queue  chan *AuthEvent
+	subCtx *eventhub.SubscribtionContext
+	stopCh chan struct{}
+	wg     sync.WaitGroup
+}
+
+// newWebhookNotifier 未配置 webhook 地址时返回 nil
+func newWebhookNotifier(conf *WebhookConfig) (*webhookNotifier, error) {
+	if conf == nil || len(conf.URLs) == 0 {
+		return nil, nil
+	}
+	for i := range conf.URLs {
+		u, err := url.Parse(conf.URLs[i])
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("[Auth][Webhook] invalid webhook url: %s", conf.URLs[i])
+		}
+	}
+	timeout := conf.TimeoutInMs
+	if timeout <= 0 {
+		timeout = defaultWebhookTimeoutInMs
+	}
+	queueSize := conf.QueueSize
+	if queueSize <= 0 {
+		queueSize = defaultWebhookQueueSize
+	}
+	return &webhookNotifier{
+		conf:   conf,
+		client: &http.Client{Timeout: time.Duration(timeout) * time.Millisecond},
+		queue:  make(chan *AuthEvent, queueSize),
+		stopCh: make(chan struct{}),
+	}, nil
+}
+
+// start 订阅操作记录并启动推送协程
+func (n *webhookNotifier) start() {
+	subCtx, err := eventhub.SubscribeWithFunc(eventhub.AuthRecordEventTopic, n.handleRecordEvent)
+	if err != nil {
+		log.Warn("[Auth][Webhook] subscribe auth record event, webhook will not be notified", zap.Error(err))
+	}
+	n.subCtx = subCtx
+	n.wg.Add(1)
+	go n.run()
+}
+
+// stop 取消订阅并停止推送协程，队列中尚未推送的事件会被丢弃
+func (n *webhookNotifier) stop() {
+	if n.subCtx != nil {
+		n.subCtx.Cancel()
+	}
+	close(n.stopCh)
+	n.wg.Wait()
+}
+
+func (n *webhookNotifier) handleRecordEvent(ctx context.Context, args any) error {
+	event, ok := args.(*eventhub.AuthRecordEvent)
+	if !ok {
+		return nil
+	}
+	n.notify(event.Entry)
+	return nil
+}
+
+// notify 将操作记录转换为事件放入推送队列，非鉴权相关的记录以及重放的记录忽略
+func (n *webhookNotifier) notify(entry *model.RecordEntry) {
+	if entry == nil || entry.Replayed {
+		return
+	}
+	if _, ok := webhookResources[entry.ResourceType]; !ok {
+		return
+	}
+	event := &AuthEvent{
+		Resource:    entry.ResourceType,
+		Name:        entry.ResourceName,
+		Operation:   entry.OperationType,
+		Operator:    entry.Operator,
+		ChangeSetID: entry.ChangeSetID,
+		Diff:        sanitizeRecordDetail(entry.Detail),
+		Server:      entry.Server,
+		HappenTime:  entry.HappenTime,
+	}
+	select {
+	case n.queue <- event:
+	default:
+		log.Error("[Auth][Webhook] event queue full, drop event", zap.String("resource", event.Name),
+			zap.String("operation", string(event.Operation)))
+	}
+}
+
+func (n *webhookNotifier) run() {
+	defer n.wg.Done()
+	for {
+		select {
+		case event := <-n.queue:
+			n.send(event)
+		case <-n.stopCh:
+			return
+		}
+	}
+}
+
+// send 推送事件给全部 webhook 地址，单个地址失败不影响其他地址
+func (n *webhookNotifier) send(event *AuthEvent) {
+	body, err := json.Marshal(event)
+	if err != nil {
+		log.Error("[Auth][Webhook] marshal event", zap.String("resource", event.Name), zap.Error(err))
+		return
+	}
+	var signature string
+	if n.conf.Secret != "" {
+		mac := hmac.New(sha256.New, []byte(n.conf.Secret))
+		_, _ = mac.Write(body)
+		signature = hex.EncodeToString(mac.Sum(nil))
+	}
+	for i := range n.conf.URLs {
+		if err := n.post(n.conf.URLs[i], body, signature); err != nil {
+			log.Error("[Auth][Webhook] post event", zap.String("url", n.conf.URLs[i]),
+				zap.String("resource", event.Name), zap.Error(err))
+		}
+	}
+}
+
+func (n *webhookNotifier) post(target string, body []byte, signature string) error {
+	req, err := http.NewRequest(http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if signature != "" {
+		req.Header.Set(WebhookSignatureHeader, signature)
+	}
+	rsp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer rsp.Body.Close()
+	if rsp.StatusCode < http.StatusOK || rsp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("unexpected status code %d", rsp.StatusCode)
+	}
+	return nil
+}
+
+// sanitizeRecordDetail 移除操作记录中的密码以及 token，操作记录不是 JSON 时作为字符串推送
+func sanitizeRecordDetail(detail string) json.RawMessage {
+	if detail == "" {
+		return nil
+	}
+	var val interface{}
+	if err := json.Unmarshal([]byte(detail), &val); err != nil {
+		ret, _ := json.Marshal(detail)
+		return ret
+	}
+	ret, _ := json.Marshal(stripSensitiveFields(val))
+	return ret
+}
+
+func stripSensitiveFields(val interface{}) interface{} {
+	switch v := val.(type) {
+	case map[string]interface{}:
+		for key := range v {
+			if _, ok := webhookSensitiveFields[key]; ok {
+				delete(v, key)
+				continue
+			}
+			v[key] = stripSensitiveFields(v[key])
+		}
+	case []interface{}:
+		for i := range v {
+			v[i] = stripSensitiveFields(v[i])
+		}
+	}
+	return val
+}
@@ -0,0 +1,100 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+package policy
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/polarismesh/polaris/common/model"
+)
+
+func Test_webhookNotifier(t *testing.T) {
+	type received struct {
+		body      []byte
+		signature string
+	}
+	ch := make(chan received, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		ch <- received{body: body, signature: r.Header.Get(WebhookSignatureHeader)}
+	}))
+	defer srv.Close()
+
+	t.Run("未配置地址时不创建", func(t *testing.T) {
+		n, err := newWebhookNotifier(&WebhookConfig{})
+		assert.NoError(t, err)
+		assert.Nil(t, n)
+	})
+
+	t.Run("地址不合法", func(t *testing.T) {
+		_, err := newWebhookNotifier(&WebhookConfig{URLs: []string{"ftp://127.0.0.1"}})
+		assert.Error(t, err)
+	})
+
+	n, err := newWebhookNotifier(&WebhookConfig{URLs: []string{srv.URL}, Secret: "polaris"})
+	assert.NoError(t, err)
+	n.start()
+	defer n.stop()
+
+	t.Run("推送用户变更并移除密码", func(t *testing.T) {
+		n.notify(&model.RecordEntry{
+			ResourceType:  model.RUser,
+			ResourceName:  "polaris(1)",
+			OperationType: model.OUpdate,
+			Operator:      "admin",
+			Detail:        `{"id":"1","name":"polaris","password":"123456","authToken":"xxx","comment":"test"}`,
+			HappenTime:    time.Now(),
+		})
+		select {
+		case rsp := <-ch:
+			mac := hmac.New(sha256.New, []byte("polaris"))
+			_, _ = mac.Write(rsp.body)
+			assert.Equal(t, hex.EncodeToString(mac.Sum(nil)), rsp.signature)
+
+			event := &AuthEvent{}
+			assert.NoError(t, json.Unmarshal(rsp.body, event))
+			assert.Equal(t, model.RUser, event.Resource)
+			assert.Equal(t, model.OUpdate, event.Operation)
+			assert.Equal(t, "admin", event.Operator)
+			diff := map[string]interface{}{}
+			assert.NoError(t, json.Unmarshal(event.Diff, &diff))
+			assert.Equal(t, map[string]interface{}{"id": "1", "name": "polaris", "comment": "test"}, diff)
+		case <-time.After(5 * time.Second):
+			t.Fatal("webhook not notified")
+		}
+	})
+
+	t.Run("非鉴权相关的记录不推送", func(t *testing.T) {
+		n.notify(&model.RecordEntry{ResourceType: model.RService, OperationType: model.OCreate})
+		n.notify(&model.RecordEntry{ResourceType: model.RAuthStrategy, OperationType: model.OCreate, Replayed: true})
+		select {
+		case <-ch:
+			t.Fatal("unexpected webhook notify")
+		case <-time.After(200 * time.Millisecond):
+		}
+	})
+}
@@ -0,0 +1,169 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+package policy
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/polarismesh/polaris/common/eventhub"
+	"github.com/polarismesh/polaris/common/metrics"
+)
+
+const (
+	// defaultOwnerCacheSize owner 解析缓存默认的最大条目数
+	defaultOwnerCacheSize = 10000
+	// defaultOwnerCacheTTL owner 解析缓存条目默认的过期时间
+	defaultOwnerCacheTTL = 60 * time.Second
+)
+
+type ownerEntry struct {
+	id       string
+	owner    string
+	expireAt time.Time
+}
+
+// ownerCache 缓存 principal -> owner 的解析结果，避免逐条记录调用 GetUser
+type ownerCache struct {
+	lock    sync.Mutex
+	maxSize int
+	ttl     time.Duration
+	items   map[string]*list.Element
+	lru     *list.List
+	hits    uint64
+	misses  uint64
+	now     func() time.Time
+}
+
+// newOwnerCache maxSize 以及 ttlInSecs 小于等于 0 时使用默认值
+func newOwnerCache(maxSize, ttlInSecs int) *ownerCache {
+	c := &ownerCache{
+		maxSize: defaultOwnerCacheSize,
+		ttl:     defaultOwnerCacheTTL,
+		items:   map[string]*list.Element{},
+		lru:     list.New(),
+		now:     time.Now,
+	}
+	if maxSize > 0 {
+		c.maxSize = maxSize
+	}
+	if ttlInSecs > 0 {
+		c.ttl = time.Duration(ttlInSecs) * time.Second
+	}
+	return c
+}
+
+// Get 获取 principal 的 owner，过期的条目视为未命中
+func (c *ownerCache) Get(id string) (string, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	elem, ok := c.items[id]
+	if ok {
+		entry := elem.Value.(*ownerEntry)
+		if c.now().Before(entry.expireAt) {
+			c.lru.MoveToFront(elem)
+			atomic.AddUint64(&c.hits, 1)
+			metrics.ReportOwnerCacheHit()
+			return entry.owner, true
+		}
+		c.removeElement(elem)
+	}
+	atomic.AddUint64(&c.misses, 1)
+	metrics.ReportOwnerCacheMiss()
+	return "", false
+}
+
+// Put 写入 principal 的 owner，超出容量时淘汰最久未被访问的条目
+func (c *ownerCache) Put(id, owner string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	expireAt := c.now().Add(c.ttl)
+	if elem, ok := c.items[id]; ok {
+		entry := elem.Value.(*ownerEntry)
+		entry.owner = owner
+		entry.expireAt = expireAt
+		c.lru.MoveToFront(elem)
+		return
+	}
+	c.items[id] = c.lru.PushFront(&ownerEntry{
+		id:       id,
+		owner:    owner,
+		expireAt: expireAt,
+	})
+	for c.lru.Len() > c.maxSize {
+		c.removeElement(c.lru.Back())
+		metrics.ReportOwnerCacheEviction()
+	}
+}
+
+// Invalidate 移除 principal 的缓存条目
+func (c *ownerCache) Invalidate(id string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if elem, ok := c.items[id]; ok {
+		c.removeElement(elem)
+	}
+}
+
+// Len 当前缓存的条目数
+func (c *ownerCache) Len() int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.lru.Len()
+}
+
+// HitRate 缓存命中率
+func (c *ownerCache) HitRate() float64 {
+	hits := atomic.LoadUint64(&c.hits)
+	total := hits + atomic.LoadUint64(&c.misses)
+	if total == 0 {
+		return 0
+	}
+	return float64(hits) / float64(total)
+}
+
+func (c *ownerCache) removeElement(elem *list.Element) {
+	c.lru.Remove(elem)
+	delete(c.items, elem.Value.(*ownerEntry).id)
+}
+
+// handleUserChange 用户的 owner 发生变化或者被删除时，清理对应的 owner 缓存
+func (svr *Server) handleUserChange(_ context.Context, args interface{}) error {
+	event, ok := args.(*eventhub.CacheUserEvent)
+	if !ok {
+		return nil
+	}
+	user := event.Item
+	if user == nil {
+		user = event.OldItem
+	}
+	if user == nil {
+		return nil
+	}
+	log.Debug("[Auth][Server] user change, invalidate owner cache", zap.String("id", user.ID),
+		zap.Int("event", int(event.EventType)))
+	svr.ownerCache.Invalidate(user.ID)
+	return nil
+}
@@ -0,0 +1,116 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+package policy
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/polarismesh/polaris/auth"
+	"github.com/polarismesh/polaris/common/eventhub"
+	"github.com/polarismesh/polaris/common/model"
+)
+
+func Test_ownerCache(t *testing.T) {
+	t.Run("超出容量时按照LRU淘汰", func(t *testing.T) {
+		c := newOwnerCache(3, 0)
+		for i := 0; i < 3; i++ {
+			c.Put(fmt.Sprintf("user-%d", i), "owner")
+		}
+		// 访问 user-0，使其成为最近使用的条目
+		_, ok := c.Get("user-0")
+		assert.True(t, ok)
+
+		c.Put("user-3", "owner")
+		c.Put("user-4", "owner")
+		assert.Equal(t, 3, c.Len())
+
+		_, ok = c.Get("user-1")
+		assert.False(t, ok)
+		_, ok = c.Get("user-2")
+		assert.False(t, ok)
+		for _, id := range []string{"user-0", "user-3", "user-4"} {
+			_, ok = c.Get(id)
+			assert.True(t, ok, id)
+		}
+		assert.InDelta(t, float64(4)/float64(6), c.HitRate(), 0.0001)
+	})
+
+	t.Run("条目过期后不再命中", func(t *testing.T) {
+		c := newOwnerCache(10, 5)
+		now := time.Now()
+		c.now = func() time.Time { return now }
+		c.Put("user-0", "owner")
+		_, ok := c.Get("user-0")
+		assert.True(t, ok)
+
+		now = now.Add(6 * time.Second)
+		_, ok = c.Get("user-0")
+		assert.False(t, ok)
+		assert.Equal(t, 0, c.Len())
+	})
+
+	t.Run("未配置时使用默认值", func(t *testing.T) {
+		c := newOwnerCache(0, 0)
+		assert.Equal(t, defaultOwnerCacheSize, c.maxSize)
+		assert.Equal(t, defaultOwnerCacheTTL, c.ttl)
+	})
+
+	t.Run("从鉴权配置中解析缓存参数", func(t *testing.T) {
+		svr := &Server{}
+		err := svr.ParseOptions(&auth.Config{
+			Strategy: &auth.StrategyConfig{
+				Option: map[string]interface{}{
+					"ownerCacheMaxSize":   100,
+					"ownerCacheTTLInSecs": 30,
+				},
+			},
+		})
+		assert.NoError(t, err)
+		c := newOwnerCache(svr.GetOptions().OwnerCacheMaxSize, svr.GetOptions().OwnerCacheTTLInSecs)
+		assert.Equal(t, 100, c.maxSize)
+		assert.Equal(t, 30*time.Second, c.ttl)
+	})
+
+	t.Run("用户owner变更时清理缓存", func(t *testing.T) {
+		eventhub.InitEventHub()
+		svr := &Server{ownerCache: newOwnerCache(0, 0)}
+		subCtx, err := eventhub.SubscribeWithFunc(eventhub.CacheUserEventTopic, svr.handleUserChange)
+		assert.NoError(t, err)
+		defer subCtx.Cancel()
+
+		svr.ownerCache.Put("user-0", "owner-0")
+		svr.ownerCache.Put("user-1", "owner-0")
+
+		err = eventhub.Publish(eventhub.CacheUserEventTopic, &eventhub.CacheUserEvent{
+			OldItem:   &model.User{ID: "user-0", Owner: "owner-0"},
+			Item:      &model.User{ID: "user-0", Owner: "owner-1"},
+			EventType: eventhub.EventUpdated,
+		})
+		assert.NoError(t, err)
+
+		assert.Eventually(t, func() bool {
+			return svr.ownerCache.Len() == 1
+		}, 5*time.Second, 10*time.Millisecond)
+		owner, ok := svr.ownerCache.Get("user-1")
+		assert.True(t, ok)
+		assert.Equal(t, "owner-0", owner)
+	})
+}
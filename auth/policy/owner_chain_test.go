@@ -0,0 +1,103 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package policy
+
+import (
+	"context"
+	"testing"
+
+	apisecurity "github.com/polarismesh/specification/source/go/api/v1/security"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/polarismesh/polaris/auth"
+	"github.com/polarismesh/polaris/common/utils"
+)
+
+// ownerChainUsers 按照 用户 ID -> owner ID 返回用户
+type ownerChainUsers struct {
+	auth.UserServer
+	helper *ownerChainHelper
+}
+
+func (u *ownerChainUsers) GetUserHelper() auth.UserHelper {
+	return u.helper
+}
+
+type ownerChainHelper struct {
+	auth.UserHelper
+	owners map[string]string
+}
+
+func (h *ownerChainHelper) GetUser(_ context.Context, user *apisecurity.User) *apisecurity.User {
+	owner, ok := h.owners[user.GetId().GetValue()]
+	if !ok {
+		return nil
+	}
+	return &apisecurity.User{Id: user.GetId(), Owner: utils.NewStringValue(owner)}
+}
+
+func Test_resolveUserOwner(t *testing.T) {
+	newServer := func(owners map[string]string, maxDepth int) *Server {
+		return &Server{
+			options:    &AuthConfig{MaxOwnerChainDepth: maxDepth},
+			userSvr:    &ownerChainUsers{helper: &ownerChainHelper{owners: owners}},
+			ownerCache: newOwnerCache(0, 0),
+		}
+	}
+
+	t.Run("合法的 owner 链", func(t *testing.T) {
+		svr := newServer(map[string]string{"main": "", "sub": "main", "sub-sub": "sub"}, 0)
+		for user, expect := range map[string]string{"main": "main", "sub": "main", "sub-sub": "main"} {
+			owner, err := svr.resolveUserOwner(user)
+			assert.NoError(t, err)
+			assert.Equal(t, expect, owner)
+		}
+		// owner 已经被删除时作为主账户
+		svr = newServer(map[string]string{"sub": "deleted"}, 0)
+		owner, err := svr.resolveUserOwner("sub")
+		assert.NoError(t, err)
+		assert.Equal(t, "deleted", owner)
+		_, err = svr.resolveUserOwner("deleted")
+		assert.Error(t, err)
+	})
+
+	t.Run("owner 指向自己", func(t *testing.T) {
+		svr := newServer(map[string]string{"self": "self"}, 0)
+		_, err := svr.resolveUserOwner("self")
+		assert.ErrorIs(t, err, ErrorOwnerChainCycle)
+		_, ok := svr.ownerCache.Get("self")
+		assert.False(t, ok)
+	})
+
+	t.Run("两跳的 owner 环", func(t *testing.T) {
+		svr := newServer(map[string]string{"a": "b", "b": "a", "c": "a"}, 0)
+		for _, user := range []string{"a", "b", "c"} {
+			_, err := svr.resolveUserOwner(user)
+			assert.ErrorIs(t, err, ErrorOwnerChainCycle)
+		}
+	})
+
+	t.Run("超出最大深度", func(t *testing.T) {
+		owners := map[string]string{"main": "", "sub-1": "main", "sub-2": "sub-1", "sub-3": "sub-2"}
+		_, err := newServer(owners, 2).resolveUserOwner("sub-3")
+		assert.ErrorIs(t, err, ErrorOwnerChainTooDeep)
+		owner, err := newServer(owners, 2).resolveUserOwner("sub-1")
+		assert.NoError(t, err)
+		assert.Equal(t, "main", owner)
+	})
+}
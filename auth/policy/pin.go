@@ -0,0 +1,287 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package policy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	apisecurity "github.com/polarismesh/specification/source/go/api/v1/security"
+	"go.uber.org/zap"
+
+	"github.com/polarismesh/polaris/auth"
+	"github.com/polarismesh/polaris/common/metrics"
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/common/utils"
+	"github.com/polarismesh/polaris/plugin"
+)
+
+// maxDecisionPinTTL 置顶决策允许的最长有效期，置顶决策只用于临时处理故障
+const maxDecisionPinTTL = 24 * time.Hour
+
+var (
+	// ErrorDecisionPinDeny 命中了拒绝的置顶决策
+	ErrorDecisionPinDeny = errors.New("denied by decision pin")
+	// ErrorDecisionPinInvalid 不合法的置顶决策
+	ErrorDecisionPinInvalid = errors.New("invalid decision pin")
+	// ErrorDecisionPinNotFound 置顶决策不存在
+	ErrorDecisionPinNotFound = errors.New("decision pin not found")
+)
+
+type pinKey struct {
+	principal model.Principal
+	resType   apisecurity.ResourceType
+	resID     string
+}
+
+func newPinKey(pin *auth.DecisionPin) pinKey {
+	return pinKey{principal: pin.Principal, resType: pin.ResourceType, resID: pin.ResourceID}
+}
+
+// decisionPins 置顶决策列表，鉴权时优先于所有鉴权策略，设置、删除以及过期均会记录操作记录
+type decisionPins struct {
+	lock    sync.RWMutex
+	items   map[pinKey]*auth.DecisionPin
+	history plugin.History
+	now     func() time.Time
+}
+
+func newDecisionPins(history plugin.History) *decisionPins {
+	return &decisionPins{
+		items:   make(map[pinKey]*auth.DecisionPin),
+		history: history,
+		now:     time.Now,
+	}
+}
+
+// add 设置置顶决策，相同 principal 以及资源的置顶决策会被覆盖
+func (p *decisionPins) add(ctx context.Context, pin *auth.DecisionPin) error {
+	now := p.now()
+	if err := validateDecisionPin(pin, now); err != nil {
+		return err
+	}
+	pin.CreateTime = now
+	pin.Operator = utils.ParseOperator(ctx)
+
+	p.lock.Lock()
+	p.items[newPinKey(pin)] = pin
+	p.lock.Unlock()
+
+	log.Error("[Auth][Pin] set decision pin", utils.RequestID(ctx), zap.String("principal", pin.Principal.PrincipalID),
+		zap.String("resource-type", pin.ResourceType.String()), zap.String("resource", pin.ResourceID),
+		zap.Bool("allow", pin.Allow), zap.Time("expire", pin.ExpireTime), zap.String("reason", pin.Reason))
+	p.record(pin, model.OCreate, pin.Operator)
+	return nil
+}
+
+// remove 删除置顶决策
+func (p *decisionPins) remove(ctx context.Context, principal model.Principal, resType apisecurity.ResourceType,
+	resID string) error {
+	key := pinKey{principal: principal, resType: resType, resID: resID}
+	p.lock.Lock()
+	pin, ok := p.items[key]
+	delete(p.items, key)
+	p.lock.Unlock()
+	if !ok {
+		return ErrorDecisionPinNotFound
+	}
+
+	log.Error("[Auth][Pin] remove decision pin", utils.RequestID(ctx), zap.String("principal", principal.PrincipalID),
+		zap.String("resource-type", resType.String()), zap.String("resource", resID))
+	p.record(pin, model.ODelete, utils.ParseOperator(ctx))
+	return nil
+}
+
+// list 返回尚未过期的置顶决策，按照过期时间排序
+func (p *decisionPins) list() []*auth.DecisionPin {
+	p.prune()
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+	ret := make([]*auth.DecisionPin, 0, len(p.items))
+	for _, pin := range p.items {
+		copied := *pin
+		ret = append(ret, &copied)
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		if !ret[i].ExpireTime.Equal(ret[j].ExpireTime) {
+			return ret[i].ExpireTime.Before(ret[j].ExpireTime)
+		}
+		return ret[i].Principal.PrincipalID < ret[j].Principal.PrincipalID
+	})
+	return ret
+}
+
+// empty 没有任何置顶决策时，鉴权链路可以跳过 principal 所在用户组的解析
+func (p *decisionPins) empty() bool {
+	if p == nil {
+		return true
+	}
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+	return len(p.items) == 0
+}
+
+// match 查找 principals 在资源上生效的置顶决策，同时命中放通与拒绝时拒绝优先
+func (p *decisionPins) match(principals []model.Principal, resType apisecurity.ResourceType,
+	resID string) *auth.DecisionPin {
+	if p.empty() {
+		return nil
+	}
+	now := p.now()
+	var (
+		matched *auth.DecisionPin
+		expired bool
+	)
+	p.lock.RLock()
+	for _, principal := range principals {
+		pin, ok := p.items[pinKey{principal: principal, resType: resType, resID: resID}]
+		if !ok {
+			continue
+		}
+		if !now.Before(pin.ExpireTime) {
+			expired = true
+			continue
+		}
+		if matched == nil || !pin.Allow {
+			matched = pin
+		}
+	}
+	p.lock.RUnlock()
+
+	if expired {
+		p.prune()
+	}
+	return matched
+}
+
+// prune 清理已经过期的置顶决策
+func (p *decisionPins) prune() {
+	now := p.now()
+	expired := make([]*auth.DecisionPin, 0, 1)
+	p.lock.Lock()
+	for key, pin := range p.items {
+		if !now.Before(pin.ExpireTime) {
+			delete(p.items, key)
+			expired = append(expired, pin)
+		}
+	}
+	p.lock.Unlock()
+
+	for _, pin := range expired {
+		log.Warn("[Auth][Pin] decision pin expired", zap.String("principal", pin.Principal.PrincipalID),
+			zap.String("resource-type", pin.ResourceType.String()), zap.String("resource", pin.ResourceID),
+			zap.Bool("allow", pin.Allow))
+		p.record(pin, model.OExpire, pin.Operator)
+	}
+}
+
+func (p *decisionPins) record(pin *auth.DecisionPin, op model.OperationType, operator string) {
+	if p.history == nil {
+		return
+	}
+	p.history.Record(&model.RecordEntry{
+		ResourceType:  model.RAuthDecisionPin,
+		ResourceName:  fmt.Sprintf("%s(%s)", pin.ResourceID, pin.ResourceType.String()),
+		Operator:      operator,
+		OperationType: op,
+		Detail:        utils.MustJson(pin),
+		HappenTime:    p.now(),
+	})
+}
+
+func validateDecisionPin(pin *auth.DecisionPin, now time.Time) error {
+	if pin == nil || pin.Principal.PrincipalID == "" || pin.ResourceID == "" {
+		return ErrorDecisionPinInvalid
+	}
+	if pin.Principal.PrincipalRole != model.PrincipalUser && pin.Principal.PrincipalRole != model.PrincipalGroup {
+		return fmt.Errorf("%w: unsupported principal type %d", ErrorDecisionPinInvalid, pin.Principal.PrincipalRole)
+	}
+	switch pin.ResourceType {
+	case apisecurity.ResourceType_Namespaces, apisecurity.ResourceType_Services,
+		apisecurity.ResourceType_ConfigGroups:
+	default:
+		return fmt.Errorf("%w: unsupported resource type %s", ErrorDecisionPinInvalid, pin.ResourceType.String())
+	}
+	if !pin.ExpireTime.After(now) || pin.ExpireTime.Sub(now) > maxDecisionPinTTL {
+		return fmt.Errorf("%w: expire time must be within %s from now", ErrorDecisionPinInvalid, maxDecisionPinTTL)
+	}
+	return nil
+}
+
+// pinPrincipals 置顶决策需要匹配的 principal，用户同时匹配其所在的用户组
+func (d *DefaultAuthChecker) pinPrincipals(principal model.Principal) []model.Principal {
+	principals := []model.Principal{principal}
+	if principal.PrincipalRole != model.PrincipalUser {
+		return principals
+	}
+	for _, groupID := range d.cacheMgr.User().GetUserLinkGroupIds(principal.PrincipalID) {
+		principals = append(principals, model.Principal{PrincipalID: groupID, PrincipalRole: model.PrincipalGroup})
+	}
+	return principals
+}
+
+// checkPins 在鉴权策略之前检查置顶决策
+// case 1. 任意一个资源命中拒绝的置顶决策，直接拒绝
+// case 2. 全部资源均命中放通的置顶决策，直接放通
+// case 3. 其余情况 pinned 为 false，继续按照鉴权策略检查，已命中放通置顶决策的资源不再检查
+func (d *DefaultAuthChecker) checkPins(authCtx *model.AcquireContext,
+	principal model.Principal) (pass bool, pinned bool) {
+	if d.pins.empty() || principal.PrincipalID == "" {
+		return false, false
+	}
+	principals := d.pinPrincipals(principal)
+	total, allowed := 0, 0
+	for resType, entries := range authCtx.GetAccessResources() {
+		for _, entry := range entries {
+			total++
+			pin := d.pins.match(principals, resType, entry.ID)
+			if pin == nil {
+				continue
+			}
+			metrics.ReportDecisionPinHit(pin.Allow)
+			if !pin.Allow {
+				log.Error("[Auth][Pin] deny operation by decision pin", utils.RequestID(authCtx.GetRequestContext()),
+					zap.String("principal", principal.PrincipalID), zap.String("method", authCtx.GetMethod()),
+					zap.String("resource", entry.ID), zap.String("reason", pin.Reason))
+				return false, true
+			}
+			allowed++
+		}
+	}
+	if total == 0 || allowed != total {
+		return false, false
+	}
+	log.Error("[Auth][Pin] allow operation by decision pin", utils.RequestID(authCtx.GetRequestContext()),
+		zap.String("principal", principal.PrincipalID), zap.String("method", authCtx.GetMethod()),
+		zap.Any("resources", authCtx.GetAccessResources()))
+	return true, true
+}
+
+// isPinnedAllow 资源是否命中了放通的置顶决策
+func (d *DefaultAuthChecker) isPinnedAllow(principal model.Principal, resType apisecurity.ResourceType,
+	resID string) bool {
+	if d.pins.empty() {
+		return false
+	}
+	pin := d.pins.match(d.pinPrincipals(principal), resType, resID)
+	return pin != nil && pin.Allow
+}
@@ -0,0 +1,228 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package policy_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	apisecurity "github.com/polarismesh/specification/source/go/api/v1/security"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/polarismesh/polaris/auth"
+	"github.com/polarismesh/polaris/auth/policy"
+	defaultuser "github.com/polarismesh/polaris/auth/user"
+	"github.com/polarismesh/polaris/cache"
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/common/utils"
+)
+
+func Test_DecisionPins(t *testing.T) {
+	reset(true)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	users := createMockUser(10)
+	groups := createMockUserGroup(users)
+	namespaces := createMockNamespace(len(users)+len(groups)+10, users[0].ID)
+	services := createMockService(namespaces)
+	serviceMap := convertServiceSliceToMap(services)
+	svc := services[len(services)-1]
+
+	ruleID := utils.NewUUID()
+	strategies := []*model.StrategyDetail{
+		{
+			ID:     ruleID,
+			Name:   "pin-test-rule",
+			Action: apisecurity.AuthAction_READ_WRITE.String(),
+			Owner:  users[0].ID,
+			Principals: []model.Principal{
+				{PrincipalID: users[1].ID, PrincipalRole: model.PrincipalUser},
+			},
+			Resources: []model.StrategyResource{
+				{StrategyID: ruleID, ResType: int32(apisecurity.ResourceType_Services), ResID: svc.ID},
+			},
+			Valid:      true,
+			ModifyTime: time.Now(),
+		},
+	}
+
+	cfg, storage := initCache(ctrl)
+	storage.EXPECT().GetUsersForCache(gomock.Any(), gomock.Any()).AnyTimes().Return(users, nil)
+	storage.EXPECT().GetGroupsForCache(gomock.Any(), gomock.Any()).AnyTimes().Return(groups, nil)
+	storage.EXPECT().GetUserSessionsForCache(gomock.Any(), gomock.Any()).AnyTimes().Return(nil, nil)
+	storage.EXPECT().GetRolesForCache(gomock.Any(), gomock.Any()).AnyTimes().Return(nil, nil)
+	storage.EXPECT().GetStrategyDetailsForCache(gomock.Any(), gomock.Any()).AnyTimes().Return(strategies, nil)
+	storage.EXPECT().GetMoreNamespaces(gomock.Any()).AnyTimes().Return(namespaces, nil)
+	storage.EXPECT().GetMoreServices(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes().Return(serviceMap, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cacheMgr, err := cache.TestCacheInitialize(ctx, cfg, storage)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		cancel()
+		cacheMgr.Close()
+	})
+
+	_, proxySvr, err := defaultuser.BuildServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	proxySvr.Initialize(&auth.Config{
+		User: &auth.UserConfig{
+			Name:   auth.DefaultUserMgnPluginName,
+			Option: map[string]interface{}{"salt": "polarismesh@2021"},
+		},
+	}, storage, cacheMgr)
+
+	policySvr, svr, err := newPolicyServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := svr.Initialize(&auth.Config{
+		Strategy: &auth.StrategyConfig{Name: auth.DefaultPolicyPluginName},
+	}, storage, cacheMgr, proxySvr); err != nil {
+		t.Fatal(err)
+	}
+	_ = cacheMgr.TestUpdate()
+
+	checker := svr.GetAuthChecker()
+	checker.(*policy.DefaultAuthChecker).SetConfig(&policy.AuthConfig{ConsoleOpen: true, ConsoleStrict: true})
+
+	canModify := func(user *model.User) (bool, error) {
+		authCtx := model.NewAcquireContext(
+			model.WithRequestContext(context.WithValue(context.Background(), utils.ContextAuthTokenKey, user.Token)),
+			model.WithMethod("Test_DecisionPins"),
+			model.WithOperation(model.Modify),
+			model.WithModule(model.DiscoverModule),
+			model.WithAccessResources(map[apisecurity.ResourceType][]model.ResourceEntry{
+				apisecurity.ResourceType_Services: {{ID: svc.ID, Owner: svc.Owner}},
+			}),
+		)
+		return checker.CheckConsolePermission(authCtx)
+	}
+	newPin := func(user *model.User, allow bool, ttl time.Duration) *auth.DecisionPin {
+		return &auth.DecisionPin{
+			Principal:    model.Principal{PrincipalID: user.ID, PrincipalRole: model.PrincipalUser},
+			ResourceType: apisecurity.ResourceType_Services,
+			ResourceID:   svc.ID,
+			Allow:        allow,
+			Reason:       "incident",
+			ExpireTime:   time.Now().Add(ttl),
+		}
+	}
+
+	pass, _ := canModify(users[1])
+	assert.True(t, pass)
+	pass, _ = canModify(users[2])
+	assert.False(t, pass)
+
+	t.Run("仅允许超级管理员设置", func(t *testing.T) {
+		ownerCtx := context.WithValue(context.Background(), utils.ContextAuthTokenKey, users[0].Token)
+		err := svr.CreateDecisionPin(ownerCtx, newPin(users[1], false, time.Minute))
+		assert.Error(t, err)
+		_, err = svr.ListDecisionPins(ownerCtx)
+		assert.Error(t, err)
+	})
+
+	t.Run("不合法的置顶决策", func(t *testing.T) {
+		err := policySvr.CreateDecisionPin(context.Background(), newPin(users[1], false, -time.Second))
+		assert.True(t, errors.Is(err, policy.ErrorDecisionPinInvalid))
+		err = policySvr.CreateDecisionPin(context.Background(), newPin(users[1], false, 48*time.Hour))
+		assert.True(t, errors.Is(err, policy.ErrorDecisionPinInvalid))
+		err = policySvr.DeleteDecisionPin(context.Background(), model.Principal{PrincipalID: users[1].ID,
+			PrincipalRole: model.PrincipalUser}, apisecurity.ResourceType_Services, svc.ID)
+		assert.True(t, errors.Is(err, policy.ErrorDecisionPinNotFound))
+	})
+
+	t.Run("强制拒绝的置顶决策到期后自动失效", func(t *testing.T) {
+		ttl := 500 * time.Millisecond
+		assert.NoError(t, policySvr.CreateDecisionPin(context.Background(), newPin(users[1], false, ttl)))
+		assert.NoError(t, policySvr.CreateDecisionPin(context.Background(), newPin(users[2], true, ttl)))
+
+		pass, err := canModify(users[1])
+		assert.False(t, pass)
+		assert.True(t, errors.Is(err, policy.ErrorDecisionPinDeny))
+		pass, err = canModify(users[2])
+		assert.True(t, pass)
+		assert.NoError(t, err)
+		pins, err := policySvr.ListDecisionPins(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, 2, len(pins))
+
+		time.Sleep(ttl + 100*time.Millisecond)
+		pass, _ = canModify(users[1])
+		assert.True(t, pass)
+		pass, _ = canModify(users[2])
+		assert.False(t, pass)
+		pins, err = policySvr.ListDecisionPins(context.Background())
+		assert.NoError(t, err)
+		assert.Empty(t, pins)
+	})
+
+	t.Run("用户组的置顶决策对组内用户生效", func(t *testing.T) {
+		pin := newPin(users[1], false, time.Minute)
+		pin.Principal = model.Principal{PrincipalID: groups[1].ID, PrincipalRole: model.PrincipalGroup}
+		assert.NoError(t, policySvr.CreateDecisionPin(context.Background(), pin))
+		// 同时命中放通与拒绝时拒绝优先
+		assert.NoError(t, policySvr.CreateDecisionPin(context.Background(), newPin(users[1], true, time.Minute)))
+
+		pass, err := canModify(users[1])
+		assert.False(t, pass)
+		assert.True(t, errors.Is(err, policy.ErrorDecisionPinDeny))
+
+		assert.NoError(t, policySvr.DeleteDecisionPin(context.Background(), pin.Principal,
+			pin.ResourceType, pin.ResourceID))
+		pass, _ = canModify(users[1])
+		assert.True(t, pass)
+	})
+	t.Run("匿名读取的接口分组同样受置顶决策约束", func(t *testing.T) {
+		dchecker := checker.(*policy.DefaultAuthChecker)
+		dchecker.SetConfig(&policy.AuthConfig{ConsoleOpen: true, ConsoleStrict: true,
+			AnonymousReadMethods: []string{"Test_DecisionPins"}})
+		defer dchecker.SetConfig(&policy.AuthConfig{ConsoleOpen: true, ConsoleStrict: true})
+		canRead := func() (bool, error) {
+			return checker.CheckConsolePermission(model.NewAcquireContext(
+				model.WithRequestContext(context.Background()),
+				model.WithMethod("Test_DecisionPins"),
+				model.WithOperation(model.Read),
+				model.WithModule(model.DiscoverModule),
+				model.WithAccessResources(map[apisecurity.ResourceType][]model.ResourceEntry{
+					apisecurity.ResourceType_Services: {{ID: svc.ID, Owner: svc.Owner}},
+				}),
+			))
+		}
+		pass, err := canRead()
+		assert.NoError(t, err)
+		assert.True(t, pass)
+
+		pin := newPin(users[1], false, time.Minute)
+		pin.Principal = model.Principal{PrincipalID: auth.NewAnonymous().OperatorID, PrincipalRole: model.PrincipalUser}
+		assert.NoError(t, policySvr.CreateDecisionPin(context.Background(), pin))
+		pass, err = canRead()
+		assert.False(t, pass)
+		assert.True(t, errors.Is(err, policy.ErrorDecisionPinDeny))
+		assert.NoError(t, policySvr.DeleteDecisionPin(context.Background(), pin.Principal,
+			pin.ResourceType, pin.ResourceID))
+	})
+}
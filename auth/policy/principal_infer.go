@@ -0,0 +1,94 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package policy
+
+import (
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/polarismesh/polaris/auth"
+	"github.com/polarismesh/polaris/common/model"
+)
+
+const (
+	// PrincipalInferOff 直接按照 token 中的 IsUserToken 确定创建者的 principal 类型
+	PrincipalInferOff = "off"
+	// PrincipalInferAuto IsUserToken 与用户、用户组缓存不一致时，根据 token 前缀以及缓存推断创建者的 principal 类型
+	PrincipalInferAuto = "auto"
+)
+
+// creatorPrincipalRole 资源创建者需要关联到哪一类 principal 的默认策略，无法确定时返回 false，不关联创建者
+func (svr *Server) creatorPrincipalRole(tokenInfo auth.OperatorInfo) (model.PrincipalType, bool) {
+	declared := model.PrincipalGroup
+	if tokenInfo.IsUserToken {
+		declared = model.PrincipalUser
+	}
+	if svr.options.PrincipalInferMode != PrincipalInferAuto {
+		return declared, true
+	}
+
+	userCache := svr.cacheMgr.User()
+	userExist := userCache.GetUserByID(tokenInfo.OperatorID) != nil
+	groupExist := userCache.GetGroup(tokenInfo.OperatorID) != nil
+	// IsUserToken 与缓存中的用户、用户组一致时不需要推断
+	if (declared == model.PrincipalUser && userExist && !groupExist) ||
+		(declared == model.PrincipalGroup && groupExist && !userExist) {
+		return declared, true
+	}
+
+	role, source, ok := svr.inferPrincipalRole(tokenInfo.Origin, userExist, groupExist)
+	if !ok {
+		log.Warn("[Auth][Server] can not infer creator principal type, skip linking creator to resource",
+			zap.String("operator", tokenInfo.OperatorID), zap.Bool("is-user-token", tokenInfo.IsUserToken),
+			zap.Bool("user-exist", userExist), zap.Bool("group-exist", groupExist))
+		return 0, false
+	}
+	log.Warn("[Auth][Server] creator principal type is inferred, token is ambiguous",
+		zap.String("operator", tokenInfo.OperatorID), zap.Bool("is-user-token", tokenInfo.IsUserToken),
+		zap.String("inferred", model.PrincipalNames[role]), zap.String("source", source))
+	return role, true
+}
+
+// inferPrincipalRole 优先根据 token 前缀推断，前缀无法区分时根据缓存中唯一存在的用户或者用户组推断
+func (svr *Server) inferPrincipalRole(token string, userExist, groupExist bool) (model.PrincipalType, string, bool) {
+	userPrefix := hasAnyPrefix(token, svr.options.UserTokenPrefixes)
+	groupPrefix := hasAnyPrefix(token, svr.options.GroupTokenPrefixes)
+	switch {
+	case userPrefix && !groupPrefix:
+		return model.PrincipalUser, "prefix", true
+	case groupPrefix && !userPrefix:
+		return model.PrincipalGroup, "prefix", true
+	}
+	switch {
+	case userExist && !groupExist:
+		return model.PrincipalUser, "cache", true
+	case groupExist && !userExist:
+		return model.PrincipalGroup, "cache", true
+	}
+	return 0, "", false
+}
+
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for i := range prefixes {
+		if prefixes[i] != "" && strings.HasPrefix(s, prefixes[i]) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,149 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package policy
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	cachetypes "github.com/polarismesh/polaris/cache/api"
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/store"
+)
+
+const (
+	// PrincipalSourceID 按照 principal ID 解析
+	PrincipalSourceID = "id"
+	// PrincipalSourceName 按照 principal 名称在操作者所属的主账户下解析
+	PrincipalSourceName = "name"
+	// PrincipalSourceAlias 按照外部别名解析, 格式为 {source}:{name}, 例如 LDAP:alice, 仅支持用户
+	PrincipalSourceAlias = "alias"
+)
+
+var (
+	// ErrorPrincipalAmbiguous principal 引用在不同的解析来源中对应了不同的 principal
+	ErrorPrincipalAmbiguous = errors.New("principal reference is ambiguous")
+
+	defaultPrincipalResolveOrder = []string{PrincipalSourceID}
+)
+
+// principalResolver 按照配置的来源优先级，将请求中的 principal 引用解析为 principal ID
+type principalResolver struct {
+	order     []string
+	userCache cachetypes.UserCache
+	storage   store.Store
+}
+
+func newPrincipalResolver(options *AuthConfig, userCache cachetypes.UserCache,
+	storage store.Store) (*principalResolver, error) {
+	order := options.PrincipalResolveOrder
+	if len(order) == 0 {
+		order = defaultPrincipalResolveOrder
+	}
+	for i := range order {
+		switch order[i] {
+		case PrincipalSourceID, PrincipalSourceName, PrincipalSourceAlias:
+		default:
+			return nil, fmt.Errorf("[Auth][Server] unsupported principal resolve source: %s", order[i])
+		}
+	}
+	return &principalResolver{
+		order:     order,
+		userCache: userCache,
+		storage:   storage,
+	}, nil
+}
+
+// resolveUser 解析用户引用，按照优先级取第一个解析成功的结果，不同来源解析出不同的用户时报错
+func (r *principalResolver) resolveUser(ref, ownerID string) (string, error) {
+	return r.resolve(ref, func(source string) (string, error) {
+		var user *model.User
+		switch source {
+		case PrincipalSourceID:
+			user = r.userCache.GetUserByID(ref)
+		case PrincipalSourceName:
+			user = r.userCache.GetUserByName(ref, r.ownerName(ownerID))
+		case PrincipalSourceAlias:
+			userSource, name, ok := strings.Cut(ref, ":")
+			if !ok {
+				return "", nil
+			}
+			user = r.userCache.GetUserByName(name, r.ownerName(ownerID))
+			if user != nil && !strings.EqualFold(user.Source, userSource) {
+				user = nil
+			}
+		}
+		if user == nil {
+			return "", nil
+		}
+		return user.ID, nil
+	})
+}
+
+// resolveGroup 解析用户组引用，用户组不支持按照别名解析
+func (r *principalResolver) resolveGroup(ref, ownerID string) (string, error) {
+	return r.resolve(ref, func(source string) (string, error) {
+		switch source {
+		case PrincipalSourceID:
+			if group := r.userCache.GetGroup(ref); group != nil {
+				return group.ID, nil
+			}
+		case PrincipalSourceName:
+			if ownerID == "" {
+				return "", nil
+			}
+			group, err := r.storage.GetGroupByName(ref, ownerID)
+			if err != nil {
+				return "", err
+			}
+			if group != nil {
+				return group.ID, nil
+			}
+		}
+		return "", nil
+	})
+}
+
+func (r *principalResolver) resolve(ref string, lookup func(source string) (string, error)) (string, error) {
+	var resolved string
+	for i := range r.order {
+		id, err := lookup(r.order[i])
+		if err != nil {
+			return "", err
+		}
+		if id == "" {
+			continue
+		}
+		if resolved == "" {
+			resolved = id
+			continue
+		}
+		if resolved != id {
+			return "", fmt.Errorf("%w: %s", ErrorPrincipalAmbiguous, ref)
+		}
+	}
+	return resolved, nil
+}
+
+func (r *principalResolver) ownerName(ownerID string) string {
+	if owner := r.userCache.GetUserByID(ownerID); owner != nil {
+		return owner.Name
+	}
+	return ""
+}
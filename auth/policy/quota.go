@@ -0,0 +1,153 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+package policy
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/polarismesh/polaris/common/metrics"
+	"github.com/polarismesh/polaris/common/model"
+)
+
+const (
+	// QuotaStrategyPerOwner 每个主账户可创建的鉴权策略数量
+	QuotaStrategyPerOwner = "strategyPerOwner"
+	// QuotaResourcePerStrategy 每个鉴权策略可关联的资源数量
+	QuotaResourcePerStrategy = "resourcePerStrategy"
+)
+
+// QuotaResult 配额检查结果
+type QuotaResult int
+
+const (
+	// QuotaPass 未超出配额
+	QuotaPass QuotaResult = iota
+	// QuotaWarn 超出配额，但处于宽限期内，仅提示
+	QuotaWarn
+	// QuotaReject 超出配额，拒绝本次操作
+	QuotaReject
+)
+
+// quotaLimit 配额限制，在 enforceTime 之前超出配额仅提示，之后拒绝
+type quotaLimit struct {
+	name        string
+	limit       int
+	enforceTime time.Time
+}
+
+// newQuotaLimit limit 小于等于 0 表示不限制, enforceTime 为空表示立即强制执行
+func newQuotaLimit(name string, limit int, enforceTime string) (*quotaLimit, error) {
+	q := &quotaLimit{
+		name:  name,
+		limit: limit,
+	}
+	if enforceTime == "" {
+		return q, nil
+	}
+	t, err := time.Parse(time.RFC3339, enforceTime)
+	if err != nil {
+		return nil, fmt.Errorf("quota(%s) enforce time(%s) invalid: %w", name, enforceTime, err)
+	}
+	q.enforceTime = t
+	return q, nil
+}
+
+// Check 检查使用量是否超出配额
+func (q *quotaLimit) Check(now time.Time, used int) QuotaResult {
+	if q.limit <= 0 || used <= q.limit {
+		return QuotaPass
+	}
+	if now.Before(q.enforceTime) {
+		metrics.ReportQuotaExceed(q.name, false)
+		return QuotaWarn
+	}
+	metrics.ReportQuotaExceed(q.name, true)
+	return QuotaReject
+}
+
+// Message 超出配额时的描述信息
+func (q *quotaLimit) Message(result QuotaResult, used int) string {
+	if result == QuotaWarn {
+		return fmt.Sprintf("quota(%s) exceeded, used %d limit %d, will be enforced after %s",
+			q.name, used, q.limit, q.enforceTime.Format(time.RFC3339))
+	}
+	return fmt.Sprintf("quota(%s) exceeded, used %d limit %d", q.name, used, q.limit)
+}
+
+// strategyQuota 鉴权策略相关的配额
+type strategyQuota struct {
+	strategyPerOwner    *quotaLimit
+	resourcePerStrategy *quotaLimit
+	now                 func() time.Time
+}
+
+func newStrategyQuota(options *AuthConfig) (*strategyQuota, error) {
+	strategyPerOwner, err := newQuotaLimit(QuotaStrategyPerOwner, options.StrategyQuota,
+		options.StrategyQuotaEnforceTime)
+	if err != nil {
+		return nil, err
+	}
+	resourcePerStrategy, err := newQuotaLimit(QuotaResourcePerStrategy, options.StrategyResourceQuota,
+		options.StrategyResourceQuotaEnforceTime)
+	if err != nil {
+		return nil, err
+	}
+	return &strategyQuota{
+		strategyPerOwner:    strategyPerOwner,
+		resourcePerStrategy: resourcePerStrategy,
+		now:                 time.Now,
+	}, nil
+}
+
+// checkStrategyQuota 检查创建鉴权策略后，主账户下的鉴权策略数量是否超出配额
+func (svr *Server) checkStrategyQuota(owner string) (QuotaResult, string, error) {
+	q := svr.quota.strategyPerOwner
+	if q.limit <= 0 {
+		return QuotaPass, "", nil
+	}
+	total, _, err := svr.storage.GetStrategies(map[string]string{
+		"owner":   owner,
+		"default": "0",
+	}, 0, 1)
+	if err != nil {
+		return QuotaPass, "", err
+	}
+	used := int(total) + 1
+	result := q.Check(svr.quota.now(), used)
+	return result, q.Message(result, used), nil
+}
+
+// checkStrategyResourceQuota 检查鉴权策略关联的资源数量是否超出配额
+func (svr *Server) checkStrategyResourceQuota(strategy *model.StrategyDetail) (QuotaResult, string) {
+	q := svr.quota.resourcePerStrategy
+	used := len(strategy.Resources)
+	result := q.Check(svr.quota.now(), used)
+	return result, q.Message(result, used)
+}
+
+// quotaRecordEntry 超出配额但处于宽限期时，记录一条审计日志
+func quotaRecordEntry(operator string, strategy *model.StrategyDetail, msg string) *model.RecordEntry {
+	return &model.RecordEntry{
+		ResourceType:  model.RAuthStrategy,
+		ResourceName:  fmt.Sprintf("%s(%s)", strategy.Name, strategy.ID),
+		OperationType: model.OQuotaWarn,
+		Operator:      operator,
+		Detail:        msg,
+		HappenTime:    time.Now(),
+	}
+}
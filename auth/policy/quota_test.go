@@ -0,0 +1,123 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+package policy_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	apisecurity "github.com/polarismesh/specification/source/go/api/v1/security"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	"github.com/polarismesh/polaris/auth"
+	"github.com/polarismesh/polaris/auth/policy"
+	api "github.com/polarismesh/polaris/common/api/v1"
+	"github.com/polarismesh/polaris/common/utils"
+)
+
+func Test_StrategyQuota(t *testing.T) {
+	strategyTest := newStrategyTest(t)
+	defer strategyTest.Clean()
+
+	initQuota := func(t *testing.T, enforceTime time.Time) {
+		err := strategyTest.svr.Initialize(&auth.Config{
+			Strategy: &auth.StrategyConfig{
+				Name: auth.DefaultPolicyPluginName,
+				Option: map[string]interface{}{
+					"strategyQuota":                    1,
+					"strategyQuotaEnforceTime":         enforceTime.Format(time.RFC3339),
+					"strategyResourceQuota":            1,
+					"strategyResourceQuotaEnforceTime": enforceTime.Format(time.RFC3339),
+				},
+			},
+		}, strategyTest.storage, strategyTest.cacheMgn, strategyTest.userSvr)
+		assert.NoError(t, err)
+		_ = strategyTest.cacheMgn.TestUpdate()
+	}
+
+	valCtx := context.WithValue(context.Background(), utils.ContextAuthTokenKey, strategyTest.users[0].Token)
+	createReq := func(name string) *apisecurity.AuthStrategy {
+		return &apisecurity.AuthStrategy{
+			Name:    &wrapperspb.StringValue{Value: name},
+			Comment: &wrapperspb.StringValue{Value: "quota test"},
+			Principals: &apisecurity.Principals{
+				Users: []*apisecurity.Principal{{
+					Id: &wrapperspb.StringValue{Value: strategyTest.users[1].ID},
+				}},
+			},
+			Resources: &apisecurity.StrategyResources{
+				Namespaces: []*apisecurity.StrategyResourceEntry{
+					{Id: &wrapperspb.StringValue{Value: strategyTest.namespaces[0].Name}},
+				},
+			},
+		}
+	}
+	updateReq := func() []*apisecurity.ModifyAuthStrategy {
+		return []*apisecurity.ModifyAuthStrategy{{
+			Id: &wrapperspb.StringValue{Value: strategyTest.strategies[0].ID},
+			AddResources: &apisecurity.StrategyResources{
+				Namespaces: []*apisecurity.StrategyResourceEntry{
+					{Id: &wrapperspb.StringValue{Value: strategyTest.namespaces[1].Name}},
+					{Id: &wrapperspb.StringValue{Value: strategyTest.namespaces[2].Name}},
+				},
+			},
+		}}
+	}
+
+	t.Run("宽限期内-超出配额仅提示", func(t *testing.T) {
+		initQuota(t, time.Now().Add(24*time.Hour))
+
+		strategyTest.storage.EXPECT().GetStrategies(gomock.Any(), gomock.Any(), gomock.Any()).Return(uint32(3), nil, nil)
+		strategyTest.storage.EXPECT().AddStrategy(gomock.Any()).Return(nil)
+		resp := strategyTest.svr.CreateStrategy(valCtx, createReq("quota-grace"))
+		assert.Equal(t, api.ExecuteSuccess, resp.Code.GetValue(), resp.Info.GetValue())
+		assert.True(t, strings.Contains(resp.Info.GetValue(), policy.QuotaStrategyPerOwner), resp.Info.GetValue())
+
+		strategyTest.storage.EXPECT().GetStrategyDetail(gomock.Any()).Return(strategyTest.strategies[0], nil)
+		strategyTest.storage.EXPECT().UpdateStrategy(gomock.Any()).Return(nil)
+		batchResp := strategyTest.svr.UpdateStrategies(valCtx, updateReq())
+		assert.Equal(t, api.ExecuteSuccess, batchResp.Responses[0].Code.GetValue(), batchResp.Responses[0].Info.GetValue())
+		assert.True(t, strings.Contains(batchResp.Responses[0].Info.GetValue(), policy.QuotaResourcePerStrategy))
+	})
+
+	t.Run("宽限期后-超出配额拒绝", func(t *testing.T) {
+		initQuota(t, time.Now().Add(-time.Hour))
+
+		strategyTest.storage.EXPECT().GetStrategies(gomock.Any(), gomock.Any(), gomock.Any()).Return(uint32(3), nil, nil)
+		resp := strategyTest.svr.CreateStrategy(valCtx, createReq("quota-enforce"))
+		assert.Equal(t, api.InvalidParameter, resp.Code.GetValue(), resp.Info.GetValue())
+		assert.True(t, strings.Contains(resp.Info.GetValue(), policy.QuotaStrategyPerOwner), resp.Info.GetValue())
+
+		strategyTest.storage.EXPECT().GetStrategyDetail(gomock.Any()).Return(strategyTest.strategies[0], nil)
+		batchResp := strategyTest.svr.UpdateStrategies(valCtx, updateReq())
+		assert.Equal(t, api.InvalidParameter, batchResp.Responses[0].Code.GetValue(), batchResp.Responses[0].Info.GetValue())
+	})
+
+	t.Run("配额未超出-正常创建", func(t *testing.T) {
+		initQuota(t, time.Now().Add(-time.Hour))
+
+		strategyTest.storage.EXPECT().GetStrategies(gomock.Any(), gomock.Any(), gomock.Any()).Return(uint32(0), nil, nil)
+		strategyTest.storage.EXPECT().AddStrategy(gomock.Any()).Return(nil)
+		resp := strategyTest.svr.CreateStrategy(valCtx, createReq("quota-pass"))
+		assert.Equal(t, api.ExecuteSuccess, resp.Code.GetValue(), resp.Info.GetValue())
+		assert.False(t, strings.Contains(resp.Info.GetValue(), "quota"), resp.Info.GetValue())
+	})
+}
@@ -0,0 +1,115 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package policy
+
+import (
+	apisecurity "github.com/polarismesh/specification/source/go/api/v1/security"
+	"go.uber.org/zap"
+
+	"github.com/polarismesh/polaris/auth/evaluator"
+	"github.com/polarismesh/polaris/common/model"
+)
+
+const (
+	// RequestConditionPrefix 请求条件的资源 ID 前缀, 例如 req:environment=staging,region!=eu
+	// 请求条件可以放在任意资源类型下，作用于整个鉴权策略：请求携带的属性满足全部条件时，策略才会授予权限
+	RequestConditionPrefix = evaluator.ConditionPrefix
+)
+
+// IsRequestCondition 资源 ID 是否为请求条件
+func IsRequestCondition(resId string) bool {
+	return evaluator.IsCondition(resId)
+}
+
+// parseRequestCondition 解析请求条件，语法与属性匹配规则一致
+func parseRequestCondition(resId string) ([]attributePredicate, error) {
+	return evaluator.ParseCondition(resId)
+}
+
+// strategyConditions 获取鉴权策略中的全部请求条件，没有请求条件时返回 false
+func strategyConditions(rule *model.StrategyDetail) ([]attributePredicate, bool, error) {
+	return evaluator.StrategyConditions(rule)
+}
+
+// unmatchedConditionStrategies principal 以及其所属用户组的策略中，请求条件不被本次请求满足的策略
+// 请求条件不合法的策略同样视为不满足
+func (d *DefaultAuthChecker) unmatchedConditionStrategies(principal model.Principal,
+	attrs map[string]string) map[string]struct{} {
+	return evaluator.UnmatchedConditions(d.principalStrategies(principal), attrs,
+		func(rule *model.StrategyDetail, err error) {
+			log.Error("[Auth][Checker] parse strategy request condition", zap.String("strategy", rule.ID),
+				zap.Error(err))
+		})
+}
+
+// isConditionalEditable 排除请求条件不满足的策略后，判断 principal 是否可以操作资源
+// 没有需要排除的策略时按照原有的逻辑判断，结果可以被决策缓存复用
+func (d *DefaultAuthChecker) isConditionalEditable(principal model.Principal,
+	resType apisecurity.ResourceType, resID string, excluded map[string]struct{}) bool {
+	if len(excluded) == 0 {
+		return d.isResourceEditable(principal, resType, resID)
+	}
+	allowed := d.computeConditionalEditable(principal, resType, resID, excluded)
+	if allowed {
+		d.usage.touch(principal, resType, resID)
+	}
+	return allowed
+}
+
+// computeConditionalEditable 与策略缓存的判断逻辑保持一致，资源没有关联任何策略时任何人都可以操作
+func (d *DefaultAuthChecker) computeConditionalEditable(principal model.Principal,
+	resType apisecurity.ResourceType, resID string, excluded map[string]struct{}) bool {
+	req := evaluator.Request{Principal: principal, ResourceType: resType, ResourceID: resID}
+	decision := d.engine().Decide(req, d.principalStrategies(principal), excluded)
+	if decision.Err != nil {
+		log.Error("[Auth][Checker] load resource attributes", zap.String("resource", resID), zap.Error(decision.Err))
+	}
+	return decision.Allowed
+}
+
+// engine 鉴权决策引擎，与 sidecar 基于策略快照内嵌的 evaluator 共用同一套判断逻辑
+func (d *DefaultAuthChecker) engine() *evaluator.Evaluator {
+	return evaluator.New(checkerStrategySource{checker: d}, d.conf.ResourceIDCanonical, d.loadResourceAttributes).
+		WithLabelLoader(d.loadResourceLabels)
+}
+
+// checkerStrategySource 基于策略缓存的鉴权策略来源
+type checkerStrategySource struct {
+	checker *DefaultAuthChecker
+}
+
+func (s checkerStrategySource) PrincipalStrategies(principal model.Principal) []*model.StrategyDetail {
+	return s.checker.principalStrategies(principal)
+}
+
+func (s checkerStrategySource) IsResourceLinked(resType apisecurity.ResourceType, resID string) bool {
+	return s.checker.cacheMgr.AuthStrategy().IsResourceLinkStrategy(resType, resID)
+}
+
+// LabelSelectors 策略缓存中该资源类型下全部允许策略的标签选择器
+func (s checkerStrategySource) LabelSelectors(resType apisecurity.ResourceType) []string {
+	var selectors []string
+	for _, rule := range s.checker.cacheMgr.AuthStrategy().GetLabelSelectorStrategies(resType) {
+		for _, res := range rule.Resources {
+			if res.ResType == int32(resType) && res.IsLabelSelector() {
+				selectors = append(selectors, res.ResID)
+			}
+		}
+	}
+	return selectors
+}
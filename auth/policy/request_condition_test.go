@@ -0,0 +1,160 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package policy_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	apisecurity "github.com/polarismesh/specification/source/go/api/v1/security"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/polarismesh/polaris/auth"
+	"github.com/polarismesh/polaris/auth/policy"
+	defaultuser "github.com/polarismesh/polaris/auth/user"
+	"github.com/polarismesh/polaris/cache"
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/common/utils"
+)
+
+func Test_RequestConditions(t *testing.T) {
+	reset(true)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	users := createMockUser(10)
+	groups := createMockUserGroup(users)
+	namespaces := createMockNamespace(len(users)+len(groups)+10, users[0].ID)
+	services := createMockService(namespaces)
+	serviceMap := convertServiceSliceToMap(services)
+	conditionSvc := services[len(services)-1]
+	plainSvc := services[len(services)-2]
+
+	conditionID, plainID := utils.NewUUID(), utils.NewUUID()
+	strategies := []*model.StrategyDetail{
+		{
+			ID:     conditionID,
+			Name:   "request-condition-rule",
+			Action: apisecurity.AuthAction_READ_WRITE.String(),
+			Owner:  users[0].ID,
+			Principals: []model.Principal{
+				{PrincipalID: users[1].ID, PrincipalRole: model.PrincipalUser},
+			},
+			Resources: []model.StrategyResource{
+				{StrategyID: conditionID, ResType: int32(apisecurity.ResourceType_Services), ResID: conditionSvc.ID},
+				{StrategyID: conditionID, ResType: int32(apisecurity.ResourceType_Services),
+					ResID: policy.RequestConditionPrefix + "environment=staging"},
+			},
+			Valid:      true,
+			ModifyTime: time.Now(),
+		},
+		{
+			ID:     plainID,
+			Name:   "plain-rule",
+			Action: apisecurity.AuthAction_READ_WRITE.String(),
+			Owner:  users[0].ID,
+			Principals: []model.Principal{
+				{PrincipalID: users[1].ID, PrincipalRole: model.PrincipalUser},
+			},
+			Resources: []model.StrategyResource{
+				{StrategyID: plainID, ResType: int32(apisecurity.ResourceType_Services), ResID: plainSvc.ID},
+			},
+			Valid:      true,
+			ModifyTime: time.Now(),
+		},
+	}
+
+	cfg, storage := initCache(ctrl)
+	storage.EXPECT().GetUsersForCache(gomock.Any(), gomock.Any()).AnyTimes().Return(users, nil)
+	storage.EXPECT().GetGroupsForCache(gomock.Any(), gomock.Any()).AnyTimes().Return(groups, nil)
+	storage.EXPECT().GetUserSessionsForCache(gomock.Any(), gomock.Any()).AnyTimes().Return(nil, nil)
+	storage.EXPECT().GetRolesForCache(gomock.Any(), gomock.Any()).AnyTimes().Return(nil, nil)
+	storage.EXPECT().GetStrategyDetailsForCache(gomock.Any(), gomock.Any()).AnyTimes().Return(strategies, nil)
+	storage.EXPECT().GetMoreNamespaces(gomock.Any()).AnyTimes().Return(namespaces, nil)
+	storage.EXPECT().GetMoreServices(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes().Return(serviceMap, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cacheMgr, err := cache.TestCacheInitialize(ctx, cfg, storage)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		cancel()
+		cacheMgr.Close()
+	})
+
+	_, proxySvr, err := defaultuser.BuildServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	proxySvr.Initialize(&auth.Config{
+		User: &auth.UserConfig{
+			Name:   auth.DefaultUserMgnPluginName,
+			Option: map[string]interface{}{"salt": "polarismesh@2021"},
+		},
+	}, storage, cacheMgr)
+
+	_, svr, err := newPolicyServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := svr.Initialize(&auth.Config{
+		Strategy: &auth.StrategyConfig{Name: auth.DefaultPolicyPluginName},
+	}, storage, cacheMgr, proxySvr); err != nil {
+		t.Fatal(err)
+	}
+	_ = cacheMgr.TestUpdate()
+
+	checker := svr.GetAuthChecker()
+	checker.(*policy.DefaultAuthChecker).SetConfig(&policy.AuthConfig{ConsoleOpen: true, ConsoleStrict: true})
+
+	canModify := func(reqCtx context.Context, svc *model.Service, attrs map[string]string) bool {
+		authCtx := model.NewAcquireContext(
+			model.WithRequestContext(context.WithValue(reqCtx, utils.ContextAuthTokenKey, users[1].Token)),
+			model.WithMethod("Test_RequestConditions"),
+			model.WithOperation(model.Modify),
+			model.WithModule(model.DiscoverModule),
+			model.WithRequestAttributes(attrs),
+			model.WithAccessResources(map[apisecurity.ResourceType][]model.ResourceEntry{
+				apisecurity.ResourceType_Services: {{ID: svc.ID, Owner: svc.Owner}},
+			}),
+		)
+		pass, _ := checker.CheckConsolePermission(authCtx)
+		return pass
+	}
+
+	t.Run("缺少请求条件要求的属性时拒绝", func(t *testing.T) {
+		assert.False(t, canModify(context.Background(), conditionSvc, nil))
+		assert.False(t, canModify(context.Background(), conditionSvc, map[string]string{"environment": "prod"}))
+		// 没有请求条件的策略不受影响
+		assert.True(t, canModify(context.Background(), plainSvc, nil))
+	})
+
+	t.Run("携带请求条件要求的属性时放通", func(t *testing.T) {
+		assert.True(t, canModify(context.Background(), conditionSvc, map[string]string{"environment": "staging"}))
+		// 请求条件未声明的属性不做限制
+		assert.True(t, canModify(context.Background(), conditionSvc,
+			map[string]string{"environment": "staging", "region": "eu"}))
+		// 从 gRPC metadata 中提取请求属性
+		reqCtx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("environment", "staging"))
+		assert.True(t, canModify(reqCtx, conditionSvc, nil))
+	})
+}
@@ -0,0 +1,305 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+package policy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	apimodel "github.com/polarismesh/specification/source/go/api/v1/model"
+	apisecurity "github.com/polarismesh/specification/source/go/api/v1/security"
+	apiservice "github.com/polarismesh/specification/source/go/api/v1/service_manage"
+	"go.uber.org/zap"
+
+	api "github.com/polarismesh/polaris/common/api/v1"
+	"github.com/polarismesh/polaris/common/model"
+	authcommon "github.com/polarismesh/polaris/common/model/auth"
+	commonstore "github.com/polarismesh/polaris/common/store"
+	"github.com/polarismesh/polaris/common/utils"
+)
+
+// handleCreateRoles 批量创建角色，创建成功后 ID 回填到请求中
+func (svr *Server) handleCreateRoles(ctx context.Context, roles []*model.Role) *apiservice.BatchWriteResponse {
+	resp := api.NewAuthBatchWriteResponse(apimodel.Code_ExecuteSuccess)
+	for i := range roles {
+		api.Collect(resp, svr.createRole(ctx, roles[i]))
+	}
+	return resp
+}
+
+func (svr *Server) createRole(ctx context.Context, role *model.Role) *apiservice.Response {
+	requestID := utils.ParseRequestID(ctx)
+	if err := utils.CheckResourceName(utils.NewStringValue(role.Name)); err != nil {
+		return api.NewAuthResponseWithMsg(apimodel.Code_InvalidParameter, "invalid role name: "+err.Error())
+	}
+	role.Owner = utils.ParseOwnerID(ctx)
+	if role.ID == "" {
+		role.ID = utils.NewUUID()
+	}
+	if resp := svr.checkRoleRelation(role); resp != nil {
+		return resp
+	}
+
+	exist, err := svr.storage.GetRoleByName(role.Name, role.Owner)
+	if err != nil {
+		log.Error("[Auth][Role] get role by name from store", utils.ZapRequestID(requestID), zap.Error(err))
+		return api.NewAuthResponse(commonstore.StoreCode2APICode(err))
+	}
+	if exist != nil {
+		return api.NewAuthResponseWithMsg(apimodel.Code_ExistedResource,
+			api.Code2Info(api.ExistedResource)+":role name "+role.Name)
+	}
+
+	if err := svr.storage.AddRole(role); err != nil {
+		log.Error("[Auth][Role] create role into store", utils.ZapRequestID(requestID), zap.Error(err))
+		return api.NewAuthResponse(commonstore.StoreCode2APICode(err))
+	}
+	log.Info("[Auth][Role] create role", utils.ZapRequestID(requestID), zap.String("name", role.Name))
+	svr.RecordHistory(roleRecordEntry(ctx, role, model.OCreate))
+	return api.NewAuthResponse(apimodel.Code_ExecuteSuccess)
+}
+
+// handleUpdateRoles 批量更新角色，使用请求中的描述、策略以及成员覆盖原有的数据
+func (svr *Server) handleUpdateRoles(ctx context.Context, roles []*model.Role) *apiservice.BatchWriteResponse {
+	resp := api.NewAuthBatchWriteResponse(apimodel.Code_ExecuteSuccess)
+	for i := range roles {
+		api.Collect(resp, svr.updateRole(ctx, roles[i]))
+	}
+	return resp
+}
+
+func (svr *Server) updateRole(ctx context.Context, req *model.Role) *apiservice.Response {
+	requestID := utils.ParseRequestID(ctx)
+	role, resp := svr.loadEditableRole(ctx, req.ID)
+	if resp != nil {
+		return resp
+	}
+	if role == nil {
+		return api.NewAuthResponse(apimodel.Code_NotFoundResource)
+	}
+	role.Comment = req.Comment
+	role.Strategies = req.Strategies
+	role.Principals = req.Principals
+	if resp := svr.checkRoleRelation(role); resp != nil {
+		return resp
+	}
+
+	if err := svr.storage.UpdateRole(role); err != nil {
+		log.Error("[Auth][Role] update role into store", utils.ZapRequestID(requestID), zap.Error(err))
+		return api.NewAuthResponse(commonstore.StoreCode2APICode(err))
+	}
+	log.Info("[Auth][Role] update role", utils.ZapRequestID(requestID), zap.String("name", role.Name))
+	svr.RecordHistory(roleRecordEntry(ctx, role, model.OUpdate))
+	return api.NewAuthResponse(apimodel.Code_ExecuteSuccess)
+}
+
+// handleDeleteRoles 批量删除角色，角色不存在时视为删除成功
+func (svr *Server) handleDeleteRoles(ctx context.Context, ids []string) *apiservice.BatchWriteResponse {
+	resp := api.NewAuthBatchWriteResponse(apimodel.Code_ExecuteSuccess)
+	for i := range ids {
+		api.Collect(resp, svr.deleteRole(ctx, ids[i]))
+	}
+	return resp
+}
+
+func (svr *Server) deleteRole(ctx context.Context, id string) *apiservice.Response {
+	requestID := utils.ParseRequestID(ctx)
+	role, resp := svr.loadEditableRole(ctx, id)
+	if resp != nil {
+		return resp
+	}
+	if role == nil {
+		return api.NewAuthResponse(apimodel.Code_ExecuteSuccess)
+	}
+	if err := svr.storage.DeleteRole(id); err != nil {
+		log.Error("[Auth][Role] delete role from store", utils.ZapRequestID(requestID), zap.Error(err))
+		return api.NewAuthResponse(commonstore.StoreCode2APICode(err))
+	}
+	log.Info("[Auth][Role] delete role", utils.ZapRequestID(requestID), zap.String("name", role.Name))
+	svr.RecordHistory(roleRecordEntry(ctx, role, model.ODelete))
+	return api.NewAuthResponse(apimodel.Code_ExecuteSuccess)
+}
+
+// handleGetRoles 查询角色列表，超级管理员以外只能查看自己主账户下的角色
+func (svr *Server) handleGetRoles(ctx context.Context, query map[string]string) (uint32, []*model.Role, error) {
+	filters := make(map[string]string, len(query))
+	for _, key := range []string{"id", "name"} {
+		if val, ok := query[key]; ok {
+			filters[key] = val
+		}
+	}
+	if authcommon.ParseUserRole(ctx) != model.AdminUserRole {
+		filters["owner"] = utils.ParseOwnerID(ctx)
+	}
+	offset, limit, err := utils.ParseOffsetAndLimit(query)
+	if err != nil {
+		return 0, nil, err
+	}
+	return svr.storage.GetRoles(filters, offset, limit)
+}
+
+// loadEditableRole 查询角色并检查当前账户是否为角色的 owner
+func (svr *Server) loadEditableRole(ctx context.Context, id string) (*model.Role, *apiservice.Response) {
+	if id == "" {
+		return nil, api.NewAuthResponseWithMsg(apimodel.Code_InvalidParameter, "role id is empty")
+	}
+	role, err := svr.storage.GetRole(id)
+	if err != nil {
+		log.Error("[Auth][Role] get role from store", utils.RequestID(ctx), zap.Error(err))
+		return nil, api.NewAuthResponse(commonstore.StoreCode2APICode(err))
+	}
+	if role == nil {
+		return nil, nil
+	}
+	if authcommon.ParseUserRole(ctx) != model.AdminUserRole && role.Owner != utils.ParseOwnerID(ctx) {
+		return nil, api.NewAuthResponse(apimodel.Code_NotAllowedAccess)
+	}
+	return role, nil
+}
+
+// checkRoleRelation 角色包含的鉴权策略以及被授予的用户、用户组都必须属于角色的主账户，重复的关联关系会被合并
+// 默认策略随着 principal 的生命周期管理，不能通过角色授予其他 principal
+func (svr *Server) checkRoleRelation(role *model.Role) *apiservice.Response {
+	strategies := make([]string, 0, len(role.Strategies))
+	seen := make(map[string]struct{}, len(role.Strategies))
+	for _, id := range role.Strategies {
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+		strategy, err := svr.storage.GetStrategyDetail(id)
+		if err != nil {
+			return api.NewAuthResponse(commonstore.StoreCode2APICode(err))
+		}
+		if strategy == nil || strategy.Owner != role.Owner {
+			return api.NewAuthResponseWithMsg(apimodel.Code_NotFoundAuthStrategyRule, "strategy "+id)
+		}
+		if strategy.Default {
+			return api.NewAuthResponseWithMsg(apimodel.Code_BadRequest,
+				"default strategy can't be granted by role: "+strategy.Name)
+		}
+		strategies = append(strategies, id)
+	}
+
+	principals := make([]model.Principal, 0, len(role.Principals))
+	seenPrincipals := make(map[model.Principal]struct{}, len(role.Principals))
+	for _, principal := range role.Principals {
+		principal = model.Principal{PrincipalID: principal.PrincipalID, PrincipalRole: principal.PrincipalRole}
+		if _, ok := seenPrincipals[principal]; ok {
+			continue
+		}
+		seenPrincipals[principal] = struct{}{}
+		if err := svr.checkRolePrincipal(role.Owner, principal); err != nil {
+			code := apimodel.Code_NotFoundUser
+			if principal.PrincipalRole == model.PrincipalGroup {
+				code = apimodel.Code_NotFoundUserGroup
+			}
+			return api.NewAuthResponseWithMsg(code, err.Error())
+		}
+		principals = append(principals, principal)
+	}
+	role.Strategies = strategies
+	role.Principals = principals
+	return nil
+}
+
+func (svr *Server) checkRolePrincipal(owner string, principal model.Principal) error {
+	userCache := svr.cacheMgr.User()
+	switch principal.PrincipalRole {
+	case model.PrincipalUser:
+		user := userCache.GetUserByID(principal.PrincipalID)
+		if user == nil || (user.Owner != owner && user.ID != owner) {
+			return fmt.Errorf("user %s not found", principal.PrincipalID)
+		}
+	case model.PrincipalGroup:
+		group := userCache.GetGroup(principal.PrincipalID)
+		if group == nil || group.Owner != owner {
+			return fmt.Errorf("group %s not found", principal.PrincipalID)
+		}
+	default:
+		return errors.New("unknown principal type " + principal.PrincipalRole.String())
+	}
+	return nil
+}
+
+// roleStrategies principal 以及用户所属用户组被授予的角色中包含的鉴权策略，exclude 中的策略不重复返回
+func (d *DefaultAuthChecker) roleStrategies(principal model.Principal,
+	exclude map[string]struct{}) []*model.StrategyDetail {
+	roleCache := d.cacheMgr.AuthRole()
+	principals := []model.Principal{principal}
+	if principal.PrincipalRole == model.PrincipalUser {
+		for _, groupID := range d.cacheMgr.User().GetUserLinkGroupIds(principal.PrincipalID) {
+			principals = append(principals, model.Principal{PrincipalID: groupID, PrincipalRole: model.PrincipalGroup})
+		}
+	}
+	var rules []*model.StrategyDetail
+	for i := range principals {
+		for _, role := range roleCache.GetPrincipalRoles(principals[i]) {
+			for _, strategyID := range role.Strategies {
+				if _, ok := exclude[strategyID]; ok {
+					continue
+				}
+				rule := d.cacheMgr.AuthStrategy().GetStrategy(strategyID)
+				if rule == nil {
+					continue
+				}
+				if exclude == nil {
+					exclude = make(map[string]struct{})
+				}
+				exclude[strategyID] = struct{}{}
+				rules = append(rules, rule)
+			}
+		}
+	}
+	return rules
+}
+
+// isRoleEditable principal 通过角色获得的鉴权策略是否授予了资源的操作权限，已经过期的资源关联关系不授予权限
+func (d *DefaultAuthChecker) isRoleEditable(principal model.Principal,
+	resType apisecurity.ResourceType, resID string) bool {
+	now := time.Now()
+	for _, rule := range d.roleStrategies(principal, nil) {
+		if rule.IsDeny() {
+			continue
+		}
+		for _, res := range rule.Resources {
+			if res.ResType != int32(resType) || res.IsExpired(now) {
+				continue
+			}
+			if res.ResID == utils.MatchAll || d.conf.ResourceIDCanonical.Equal(res.ResID, resID) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func roleRecordEntry(ctx context.Context, role *model.Role, operationType model.OperationType) *model.RecordEntry {
+	detail, _ := json.Marshal(role)
+	return &model.RecordEntry{
+		ResourceType:  model.RAuthRole,
+		ResourceName:  fmt.Sprintf("%s(%s)", role.Name, role.ID),
+		OperationType: operationType,
+		Operator:      utils.ParseOperator(ctx),
+		ChangeSetID:   utils.ParseChangeSetID(ctx),
+		Detail:        string(detail),
+		HappenTime:    time.Now(),
+	}
+}
@@ -0,0 +1,252 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+package policy_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	apimodel "github.com/polarismesh/specification/source/go/api/v1/model"
+	apisecurity "github.com/polarismesh/specification/source/go/api/v1/security"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/polarismesh/polaris/auth"
+	"github.com/polarismesh/polaris/auth/policy"
+	defaultuser "github.com/polarismesh/polaris/auth/user"
+	"github.com/polarismesh/polaris/cache"
+	api "github.com/polarismesh/polaris/common/api/v1"
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/common/utils"
+)
+
+func Test_Roles(t *testing.T) {
+	reset(true)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	users := createMockUser(10)
+	groups := createMockUserGroup(users)
+	namespaces := createMockNamespace(len(users)+len(groups)+10, users[0].ID)
+	services := createMockService(namespaces)
+	serviceMap := convertServiceSliceToMap(services)
+	svc := services[len(services)-1]
+
+	rule := &model.StrategyDetail{
+		ID:     utils.NewUUID(),
+		Name:   "role-test-rule",
+		Action: apisecurity.AuthAction_READ_WRITE.String(),
+		Owner:  users[0].ID,
+		Principals: []model.Principal{
+			{PrincipalID: users[1].ID, PrincipalRole: model.PrincipalUser},
+		},
+		Valid:      true,
+		ModifyTime: time.Now(),
+	}
+	rule.Resources = []model.StrategyResource{
+		{StrategyID: rule.ID, ResType: int32(apisecurity.ResourceType_Services), ResID: svc.ID},
+	}
+	defaultRule := &model.StrategyDetail{ID: utils.NewUUID(), Name: "default", Owner: users[0].ID, Default: true}
+
+	// roles 模拟存储中的角色数据
+	var (
+		lock  sync.Mutex
+		roles = map[string]*model.Role{}
+	)
+	saveRole := func(role *model.Role, valid bool) error {
+		lock.Lock()
+		defer lock.Unlock()
+		saved := *role
+		saved.Valid = valid
+		saved.ModifyTime = time.Now()
+		roles[role.ID] = &saved
+		return nil
+	}
+
+	cfg, storage := initCache(ctrl)
+	storage.EXPECT().GetUsersForCache(gomock.Any(), gomock.Any()).AnyTimes().Return(users, nil)
+	storage.EXPECT().GetGroupsForCache(gomock.Any(), gomock.Any()).AnyTimes().Return(groups, nil)
+	storage.EXPECT().GetUserSessionsForCache(gomock.Any(), gomock.Any()).AnyTimes().Return(nil, nil)
+	storage.EXPECT().GetRolesForCache(gomock.Any(), gomock.Any()).AnyTimes().DoAndReturn(
+		func(time.Time, bool) ([]*model.Role, error) {
+			lock.Lock()
+			defer lock.Unlock()
+			ret := make([]*model.Role, 0, len(roles))
+			for _, role := range roles {
+				copied := *role
+				ret = append(ret, &copied)
+			}
+			return ret, nil
+		})
+	storage.EXPECT().GetStrategyDetailsForCache(gomock.Any(), gomock.Any()).AnyTimes().
+		Return([]*model.StrategyDetail{rule}, nil)
+	storage.EXPECT().GetMoreNamespaces(gomock.Any()).AnyTimes().Return(namespaces, nil)
+	storage.EXPECT().GetMoreServices(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes().
+		Return(serviceMap, nil)
+	storage.EXPECT().GetStrategyDetail(rule.ID).AnyTimes().Return(rule, nil)
+	storage.EXPECT().GetStrategyDetail(defaultRule.ID).AnyTimes().Return(defaultRule, nil)
+	storage.EXPECT().GetRoleByName(gomock.Any(), gomock.Any()).AnyTimes().Return(nil, nil)
+	storage.EXPECT().GetRole(gomock.Any()).AnyTimes().DoAndReturn(func(id string) (*model.Role, error) {
+		lock.Lock()
+		defer lock.Unlock()
+		if role, ok := roles[id]; ok && role.Valid {
+			copied := *role
+			return &copied, nil
+		}
+		return nil, nil
+	})
+	storage.EXPECT().AddRole(gomock.Any()).AnyTimes().DoAndReturn(func(role *model.Role) error {
+		return saveRole(role, true)
+	})
+	storage.EXPECT().UpdateRole(gomock.Any()).AnyTimes().DoAndReturn(func(role *model.Role) error {
+		return saveRole(role, true)
+	})
+	storage.EXPECT().DeleteRole(gomock.Any()).AnyTimes().DoAndReturn(func(id string) error {
+		lock.Lock()
+		role := roles[id]
+		lock.Unlock()
+		return saveRole(role, false)
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cacheMgr, err := cache.TestCacheInitialize(ctx, cfg, storage)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		cancel()
+		cacheMgr.Close()
+	})
+
+	_, proxySvr, err := defaultuser.BuildServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	proxySvr.Initialize(&auth.Config{
+		User: &auth.UserConfig{
+			Name:   auth.DefaultUserMgnPluginName,
+			Option: map[string]interface{}{"salt": "polarismesh@2021"},
+		},
+	}, storage, cacheMgr)
+
+	_, svr, err := newPolicyServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := svr.Initialize(&auth.Config{
+		Strategy: &auth.StrategyConfig{Name: auth.DefaultPolicyPluginName},
+	}, storage, cacheMgr, proxySvr); err != nil {
+		t.Fatal(err)
+	}
+	_ = cacheMgr.TestUpdate()
+
+	checker := svr.GetAuthChecker()
+	checker.(*policy.DefaultAuthChecker).SetConfig(&policy.AuthConfig{ConsoleOpen: true, ConsoleStrict: true})
+	canModify := func(user *model.User) bool {
+		authCtx := model.NewAcquireContext(
+			model.WithRequestContext(context.WithValue(context.Background(), utils.ContextAuthTokenKey, user.Token)),
+			model.WithMethod("Test_Roles"),
+			model.WithOperation(model.Modify),
+			model.WithModule(model.DiscoverModule),
+			model.WithAccessResources(map[apisecurity.ResourceType][]model.ResourceEntry{
+				apisecurity.ResourceType_Services: {{ID: svc.ID, Owner: svc.Owner}},
+			}),
+		)
+		pass, _ := checker.CheckConsolePermission(authCtx)
+		return pass
+	}
+	ownerCtx := context.WithValue(context.Background(), utils.ContextAuthTokenKey, users[0].Token)
+
+	assert.True(t, canModify(users[1]))
+	assert.False(t, canModify(users[2]))
+	assert.False(t, canModify(users[3]))
+
+	role := &model.Role{
+		Name:       "service-operator",
+		Strategies: []string{rule.ID},
+		Principals: []model.Principal{
+			{PrincipalID: users[2].ID, PrincipalRole: model.PrincipalUser},
+			{PrincipalID: groups[3].ID, PrincipalRole: model.PrincipalGroup},
+		},
+	}
+
+	t.Run("子账户不能创建角色", func(t *testing.T) {
+		subCtx := context.WithValue(context.Background(), utils.ContextAuthTokenKey, users[2].Token)
+		resp := svr.CreateRoles(subCtx, []*model.Role{role})
+		assert.NotEqual(t, api.ExecuteSuccess, resp.GetCode().GetValue())
+		assert.Empty(t, role.ID)
+	})
+
+	t.Run("角色不能包含默认策略以及不存在的成员", func(t *testing.T) {
+		resp := svr.CreateRoles(ownerCtx, []*model.Role{
+			{Name: "with-default", Strategies: []string{defaultRule.ID}},
+			{Name: "unknown-user", Principals: []model.Principal{
+				{PrincipalID: utils.NewUUID(), PrincipalRole: model.PrincipalUser},
+			}},
+		})
+		assert.Equal(t, uint32(apimodel.Code_BadRequest), resp.Responses[0].GetCode().GetValue())
+		assert.Equal(t, uint32(apimodel.Code_NotFoundUser), resp.Responses[1].GetCode().GetValue())
+	})
+
+	t.Run("通过角色获得策略的权限", func(t *testing.T) {
+		resp := svr.CreateRoles(ownerCtx, []*model.Role{role})
+		assert.Equal(t, api.ExecuteSuccess, resp.Responses[0].GetCode().GetValue(),
+			resp.Responses[0].GetInfo().GetValue())
+		assert.NotEmpty(t, role.ID)
+		_ = cacheMgr.TestUpdate()
+
+		assert.True(t, canModify(users[2]))
+		// 用户通过所属的用户组获得角色
+		assert.True(t, canModify(users[3]))
+		assert.False(t, canModify(users[4]))
+
+		// 主账户只能查询自己名下的角色
+		storage.EXPECT().GetRoles(map[string]string{"name": role.Name, "owner": users[0].ID}, gomock.Any(),
+			gomock.Any()).Return(uint32(1), []*model.Role{role}, nil)
+		total, ret, err := svr.GetRoles(ownerCtx, map[string]string{"name": role.Name})
+		assert.NoError(t, err)
+		assert.Equal(t, uint32(1), total)
+		assert.Len(t, ret, 1)
+	})
+
+	t.Run("修改角色成员后权限随之变化", func(t *testing.T) {
+		resp := svr.UpdateRoles(ownerCtx, []*model.Role{{
+			ID:         role.ID,
+			Strategies: []string{rule.ID},
+			Principals: []model.Principal{{PrincipalID: users[4].ID, PrincipalRole: model.PrincipalUser}},
+		}})
+		assert.Equal(t, api.ExecuteSuccess, resp.Responses[0].GetCode().GetValue(),
+			resp.Responses[0].GetInfo().GetValue())
+		_ = cacheMgr.TestUpdate()
+
+		assert.False(t, canModify(users[2]))
+		assert.False(t, canModify(users[3]))
+		assert.True(t, canModify(users[4]))
+	})
+
+	t.Run("删除角色后失去权限", func(t *testing.T) {
+		resp := svr.DeleteRoles(ownerCtx, []string{role.ID})
+		assert.Equal(t, api.ExecuteSuccess, resp.Responses[0].GetCode().GetValue(),
+			resp.Responses[0].GetInfo().GetValue())
+		_ = cacheMgr.TestUpdate()
+
+		assert.False(t, canModify(users[4]))
+		assert.True(t, canModify(users[1]))
+	})
+}
@@ -0,0 +1,162 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package policy
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	apimodel "github.com/polarismesh/specification/source/go/api/v1/model"
+	apiservice "github.com/polarismesh/specification/source/go/api/v1/service_manage"
+
+	api "github.com/polarismesh/polaris/common/api/v1"
+	"github.com/polarismesh/polaris/common/model"
+	authcommon "github.com/polarismesh/polaris/common/model/auth"
+	"github.com/polarismesh/polaris/common/utils"
+)
+
+const (
+	// searchRankNameEqual 策略名称与搜索内容完全一致
+	searchRankNameEqual = iota
+	// searchRankNamePrefix 策略名称以搜索内容开头
+	searchRankNamePrefix
+	// searchRankNameContains 策略名称包含搜索内容
+	searchRankNameContains
+	// searchRankCommentContains 策略描述包含搜索内容
+	searchRankCommentContains
+	// searchRankTerms 搜索内容按空白拆分后的每个词都出现在名称或者描述中
+	searchRankTerms
+	// searchRankMiss 未命中
+	searchRankMiss
+)
+
+type strategySearchHit struct {
+	rank int
+	rule *model.StrategyDetail
+}
+
+// handleSearchStrategies 在缓存的鉴权策略中按照名称以及描述进行不区分大小写的搜索，
+// 结果按照命中程度排序，命中程度相同时按照名称排序，只返回当前操作者可以查看的策略
+func (svr *Server) handleSearchStrategies(ctx context.Context, query string,
+	limit int) *apiservice.BatchQueryResponse {
+	query = strings.ToLower(strings.TrimSpace(query))
+	if query == "" {
+		return api.NewAuthBatchQueryResponse(apimodel.Code_EmptyQueryParameter)
+	}
+	if limit <= 0 {
+		limit = int(utils.QueryDefaultLimit)
+	}
+	if limit > int(utils.QueryMaxLimit) {
+		limit = int(utils.QueryMaxLimit)
+	}
+
+	visible := svr.strategyVisibleFilter(ctx)
+	terms := strings.Fields(query)
+	hits := make([]strategySearchHit, 0, 16)
+	svr.cacheMgr.AuthStrategy().IteratorStrategies(func(rule *model.StrategyDetail) bool {
+		if rank := searchRank(rule, query, terms); rank != searchRankMiss && visible(rule) {
+			hits = append(hits, strategySearchHit{rank: rank, rule: rule})
+		}
+		return true
+	})
+
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].rank != hits[j].rank {
+			return hits[i].rank < hits[j].rank
+		}
+		if hits[i].rule.Name != hits[j].rule.Name {
+			return hits[i].rule.Name < hits[j].rule.Name
+		}
+		return hits[i].rule.ID < hits[j].rule.ID
+	})
+
+	total := len(hits)
+	if len(hits) > limit {
+		hits = hits[:limit]
+	}
+	strategies := make([]*model.StrategyDetail, 0, len(hits))
+	for i := range hits {
+		strategies = append(strategies, hits[i].rule)
+	}
+
+	resp := api.NewAuthBatchQueryResponse(apimodel.Code_ExecuteSuccess)
+	resp.Amount = utils.NewUInt32Value(uint32(total))
+	resp.Size = utils.NewUInt32Value(uint32(len(strategies)))
+	resp.AuthStrategies = enhancedAuthStrategy2Api(strategies, svr.authStrategy2Api)
+	return resp
+}
+
+// searchRank 计算策略对搜索内容的命中程度，query 以及 terms 均已转为小写
+func searchRank(rule *model.StrategyDetail, query string, terms []string) int {
+	name := strings.ToLower(rule.Name)
+	comment := strings.ToLower(rule.Comment)
+	switch {
+	case name == query:
+		return searchRankNameEqual
+	case strings.HasPrefix(name, query):
+		return searchRankNamePrefix
+	case strings.Contains(name, query):
+		return searchRankNameContains
+	case strings.Contains(comment, query):
+		return searchRankCommentContains
+	}
+	if len(terms) <= 1 {
+		return searchRankMiss
+	}
+	for _, term := range terms {
+		if !strings.Contains(name, term) && !strings.Contains(comment, term) {
+			return searchRankMiss
+		}
+	}
+	return searchRankTerms
+}
+
+// strategyVisibleFilter 与查询鉴权策略列表的可见范围保持一致
+// Case 1 admin 角色可以查看所有策略
+// Case 2 owner 角色可以查看自己名下的策略
+// Case 3 子账户只能查看自己名下、自身或者所在用户组被关联到的策略
+func (svr *Server) strategyVisibleFilter(ctx context.Context) func(rule *model.StrategyDetail) bool {
+	if authcommon.ParseUserRole(ctx) == model.AdminUserRole {
+		return func(rule *model.StrategyDetail) bool { return true }
+	}
+	ownerID := utils.ParseOwnerID(ctx)
+	if utils.ParseIsOwner(ctx) {
+		return func(rule *model.StrategyDetail) bool { return rule.Owner == ownerID }
+	}
+
+	userID := utils.ParseUserID(ctx)
+	groupIDs := make(map[string]struct{})
+	for _, groupID := range svr.cacheMgr.User().GetUserLinkGroupIds(userID) {
+		groupIDs[groupID] = struct{}{}
+	}
+	return func(rule *model.StrategyDetail) bool {
+		if rule.Owner != ownerID {
+			return false
+		}
+		for _, principal := range rule.Principals {
+			if principal.PrincipalRole == model.PrincipalUser && principal.PrincipalID == userID {
+				return true
+			}
+			if _, ok := groupIDs[principal.PrincipalID]; ok && principal.PrincipalRole == model.PrincipalGroup {
+				return true
+			}
+		}
+		return false
+	}
+}
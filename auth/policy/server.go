@@ -22,14 +22,18 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"sort"
 	"time"
 
 	apisecurity "github.com/polarismesh/specification/source/go/api/v1/security"
 	"go.uber.org/zap"
+	"golang.org/x/sync/singleflight"
 	"google.golang.org/protobuf/types/known/wrapperspb"
 
 	"github.com/polarismesh/polaris/auth"
 	cachetypes "github.com/polarismesh/polaris/cache/api"
+	"github.com/polarismesh/polaris/common/eventhub"
+	"github.com/polarismesh/polaris/common/metrics"
 	"github.com/polarismesh/polaris/common/model"
 	"github.com/polarismesh/polaris/common/utils"
 	"github.com/polarismesh/polaris/plugin"
@@ -49,8 +53,149 @@ type AuthConfig struct {
 	ConsoleStrict bool `json:"consoleStrict"`
 	// ClientStrict 是否启用鉴权的严格模式，即对于没有任何鉴权策略的资源，也必须带上正确的token才能操作, 默认关闭
 	ClientStrict bool `json:"clientStrict"`
+	// OwnerCacheMaxSize principal owner 解析缓存的最大条目数，超出后按照 LRU 进行淘汰
+	OwnerCacheMaxSize int `json:"ownerCacheMaxSize"`
+	// OwnerCacheTTLInSecs principal owner 解析缓存条目的过期时间，单位为秒
+	OwnerCacheTTLInSecs int `json:"ownerCacheTTLInSecs"`
+	// LintBlockRules 鉴权策略检查规则中命中后拒绝创建、更新的规则，未配置的规则仅提示
+	LintBlockRules []string `json:"lintBlockRules"`
+	// LintDisableRules 关闭的鉴权策略检查规则
+	LintDisableRules []string `json:"lintDisableRules"`
+	// StrategyQuota 每个主账户可创建的鉴权策略数量上限，小于等于 0 表示不限制
+	StrategyQuota int `json:"strategyQuota"`
+	// StrategyQuotaEnforceTime 鉴权策略数量配额开始强制执行的时间(RFC3339)，在此之前超出配额仅提示
+	StrategyQuotaEnforceTime string `json:"strategyQuotaEnforceTime"`
+	// StrategyResourceQuota 每个鉴权策略可关联的资源数量上限，小于等于 0 表示不限制
+	StrategyResourceQuota int `json:"strategyResourceQuota"`
+	// StrategyResourceQuotaEnforceTime 鉴权策略资源数量配额开始强制执行的时间(RFC3339)，在此之前超出配额仅提示
+	StrategyResourceQuotaEnforceTime string `json:"strategyResourceQuotaEnforceTime"`
+	// MTLSOpen 请求未携带 token 时，是否根据 mTLS 客户端证书解析 principal
+	MTLSOpen bool `json:"mtlsOpen"`
+	// MTLSIdentitySource 证书中身份标识的来源, subject.cn / san.dns / san.uri / san.email
+	MTLSIdentitySource string `json:"mtlsIdentitySource"`
+	// MTLSPrincipalRule 身份标识映射为 principal 的正则规则, 支持 type、id、name、owner 命名分组
+	MTLSPrincipalRule string `json:"mtlsPrincipalRule"`
+	// MTLSPrincipalMappings 身份标识到 principal 的映射, 按照顺序优先于 MTLSPrincipalRule 匹配
+	MTLSPrincipalMappings []CertPrincipalMapping `json:"mtlsPrincipalMappings"`
+	// MTLSDefaultOwner 规则中未匹配出 owner 时，按照用户名称查找用户所使用的主账户名称
+	MTLSDefaultOwner string `json:"mtlsDefaultOwner"`
+	// BreakGlassOpen 是否允许携带 break-glass token 的请求越过鉴权策略
+	BreakGlassOpen bool `json:"breakGlassOpen"`
+	// BreakGlassSecret 签发 break-glass token 使用的 HMAC 密钥
+	BreakGlassSecret string `json:"breakGlassSecret"`
+	// BreakGlassDenyKinds 不允许使用 break-glass token 的 principal 身份类别(Human、Service、Unknown)
+	BreakGlassDenyKinds []string `json:"breakGlassDenyKinds"`
+	// BreakGlassMaxLifetimeInSecs break-glass token 的最长有效期，有效期超出该值的 token 不生效, 小于等于 0 时为 1 小时
+	BreakGlassMaxLifetimeInSecs int64 `json:"breakGlassMaxLifetimeInSecs"`
+	// PrincipalResolveOrder 解析默认授权关系中 principal 引用的来源优先级, 支持 id、name、alias, 默认仅按照 id 解析
+	PrincipalResolveOrder []string `json:"principalResolveOrder"`
+	// DecisionTraceSize 记录最近鉴权决策的条数，用于重放比对鉴权逻辑变更的影响, 小于等于 0 表示不记录
+	DecisionTraceSize int `json:"decisionTraceSize"`
+	// DecisionCacheSize 鉴权决策缓存的最大条目数，策略发生变化后旧的决策立即失效, 小于等于 0 表示不缓存
+	DecisionCacheSize int `json:"decisionCacheSize"`
+	// DecisionCacheTTLInSecs 鉴权决策缓存条目的过期时间，单位为秒，默认 5 秒
+	DecisionCacheTTLInSecs int `json:"decisionCacheTTLInSecs"`
+	// StrategyCacheDegrade 鉴权策略缓存开启失败时，是否降级为直接查询存储继续启动, 默认启动失败
+	StrategyCacheDegrade bool `json:"strategyCacheDegrade"`
+	// DenyByDefaultTypes 默认拒绝的资源类型(Namespaces、Services、ConfigGroups), 这些类型下没有被任何策略匹配的资源，
+	// 无论是否开启严格模式、是否携带 token，读写操作均拒绝
+	DenyByDefaultTypes []string `json:"denyByDefaultTypes"`
+	// ResourceAttachmentMode 资源创建、更新后未携带关联资源时的处理方式, lenient 忽略(默认), strict 报错
+	ResourceAttachmentMode string `json:"resourceAttachmentMode"`
+	// LastUsedMode 资源关联关系最近使用时间的更新方式, off 不更新(默认), sampled 每个时间窗口内最多写入一次,
+	// bestEffort 在内存中合并后按照时间窗口定期批量写入
+	LastUsedMode string `json:"lastUsedMode"`
+	// LastUsedWindowInSecs 最近使用时间的写入时间窗口，单位为秒，默认 60 秒
+	LastUsedWindowInSecs int `json:"lastUsedWindowInSecs"`
+	// AsyncCheckWorkers 异步鉴权的 worker 数量，默认为 CPU 核数
+	AsyncCheckWorkers int `json:"asyncCheckWorkers"`
+	// AsyncCheckQueueSize 异步鉴权的等待队列长度，队列满时直接拒绝，默认 1024
+	AsyncCheckQueueSize int `json:"asyncCheckQueueSize"`
+	// DuplicateResourceMode 导入关联资源时已经存在的资源关联关系的处理方式, ignore 忽略(默认),
+	// count 忽略并在返回结果中给出重复的数量, error 存在重复时直接报错
+	DuplicateResourceMode string `json:"duplicateResourceMode"`
+	// NamespaceAdmins 命名空间管理员, 子账户 ID -> 命名空间列表, 命名空间管理员对这些命名空间下的全部资源拥有读写权限,
+	// 并且可以修改同一个主账户下资源全部属于这些命名空间的鉴权策略
+	NamespaceAdmins map[string][]string `json:"namespaceAdmins"`
+	// PrincipalInferMode 资源创建者关联默认策略时 principal 类型的确定方式, off 直接使用 token 中的类型(默认),
+	// auto token 中的类型与用户、用户组不一致时根据 token 前缀以及用户、用户组推断, 无法推断时不关联创建者
+	PrincipalInferMode string `json:"principalInferMode"`
+	// UserTokenPrefixes 推断 principal 类型时，属于用户 token 的前缀
+	UserTokenPrefixes []string `json:"userTokenPrefixes"`
+	// GroupTokenPrefixes 推断 principal 类型时，属于用户组 token 的前缀
+	GroupTokenPrefixes []string `json:"groupTokenPrefixes"`
+	// MaxTokenLifetimeInSecs 外部 token 从签发开始的最长有效时间，单位为秒，与 token 自身的过期时间取较早者，
+	// 小于等于 0 表示不限制
+	MaxTokenLifetimeInSecs int `json:"maxTokenLifetimeInSecs"`
+	// DisableDefaultStrategySingleflight 关闭同一个 principal 并发查询默认策略时合并为一次存储查询, 默认合并
+	DisableDefaultStrategySingleflight bool `json:"disableDefaultStrategySingleflight"`
+	// MaxOwnerChainDepth 解析用户所属主账户时 owner 链的最大深度，超出后拒绝，小于等于 0 时使用默认值
+	MaxOwnerChainDepth int `json:"maxOwnerChainDepth"`
+	// ResourceTypeCheckMode 默认策略关联资源时资源类型未知的处理方式, strict 报错(默认), lenient 忽略未知类型的资源
+	ResourceTypeCheckMode string `json:"resourceTypeCheckMode"`
+	// ExpireGraceInSecs 有时限的资源关联关系过期后仍然授予权限的宽限期，宽限期内放通并记录告警，单位为秒，
+	// 小于等于 0 表示没有宽限期
+	ExpireGraceInSecs int `json:"expireGraceInSecs"`
+	// HealthCacheStaleInSecs 健康报告中策略缓存超过该时间未同步时视为过期，单位为秒，小于等于 0 时使用默认值
+	HealthCacheStaleInSecs int `json:"healthCacheStaleInSecs"`
+	// HealthDecisionErrorRate 健康报告中鉴权决策失败率超过该比例时视为降级，小于等于 0 时使用默认值
+	HealthDecisionErrorRate float64 `json:"healthDecisionErrorRate"`
+	// ResourceIDCanonical 资源 ID 的规范化规则，鉴权策略关联的资源 ID 与请求中的资源 ID 规范化后再比较，为空时精确匹配
+	// 策略缓存降级为直接查询存储时，按照资源 ID 查询存储仍然是精确匹配
+	ResourceIDCanonical *model.ResourceIDCanonicalizer `json:"resourceIdCanonical"`
+	// StrategyNameScope 创建、重命名鉴权策略时名称唯一的范围, global 全局唯一, tenant 同一个主账户下唯一,
+	// namespace 同一个主账户的同一个命名空间下唯一, 为空时不检查(默认)
+	StrategyNameScope string `json:"strategyNameScope"`
+	// MaxStrategyBatchSize 批量创建、修改、删除鉴权策略时单次请求的最大策略数量，小于等于 0 时使用默认值
+	MaxStrategyBatchSize int `json:"maxStrategyBatchSize"`
+	// Webhook 鉴权策略、角色、用户、用户组以及 token 发生变更时推送通知的 webhook, 未配置时不推送
+	Webhook *WebhookConfig `json:"webhook"`
+	// AnonymousReadMethods 允许未携带 token 匿名读取的接口分组，按照方法名称前缀匹配，
+	// 例如 Discover 匹配全部客户端服务发现接口，写操作不受影响, 为空时不允许匿名读取(默认)
+	AnonymousReadMethods []string `json:"anonymousReadMethods"`
 }
 
+const (
+	// defaultMaxOwnerChainDepth owner 链默认的最大深度
+	defaultMaxOwnerChainDepth = 8
+	// defaultMaxStrategyBatchSize 批量操作鉴权策略时单次请求默认的最大策略数量
+	defaultMaxStrategyBatchSize = 500
+)
+
+const (
+	// ResourceAttachmentLenient 未携带关联资源时忽略，不修改默认策略
+	ResourceAttachmentLenient = "lenient"
+	// ResourceAttachmentStrict 资源创建、更新时未携带关联资源直接报错
+	ResourceAttachmentStrict = "strict"
+)
+
+const (
+	// ResourceTypeCheckStrict 关联资源的资源类型未知时直接报错
+	ResourceTypeCheckStrict = "strict"
+	// ResourceTypeCheckLenient 关联资源的资源类型未知时忽略该类型的资源，仅关联已知类型的资源
+	ResourceTypeCheckLenient = "lenient"
+)
+
+const (
+	// DuplicateResourceIgnore 导入时忽略已经存在的资源关联关系
+	DuplicateResourceIgnore = "ignore"
+	// DuplicateResourceCount 导入时忽略已经存在的资源关联关系，并返回重复的数量
+	DuplicateResourceCount = "count"
+	// DuplicateResourceError 导入时存在重复的资源关联关系直接报错
+	DuplicateResourceError = "error"
+)
+
+var (
+	// ErrorEmptyResourceAttachment 严格模式下资源创建、更新未携带关联资源
+	ErrorEmptyResourceAttachment = errors.New("resource attachment is empty")
+	// ErrorOwnerChainCycle 用户的 owner 链直接或者间接指向了自己
+	ErrorOwnerChainCycle = errors.New("owner chain has cycle")
+	// ErrorOwnerChainTooDeep 用户的 owner 链超出了最大深度
+	ErrorOwnerChainTooDeep = errors.New("owner chain too deep")
+	// ErrorUnknownResourceType 关联资源的资源类型不是已知的资源类型，关联后无法被任何鉴权匹配
+	ErrorUnknownResourceType = errors.New("unknown resource type")
+)
+
 // DefaultAuthConfig 返回一个默认的鉴权配置
 func DefaultAuthConfig() *AuthConfig {
 	return &AuthConfig{
@@ -72,28 +217,103 @@ type Server struct {
 	cacheMgr cachetypes.CacheManager
 	checker  *DefaultAuthChecker
 	userSvr  auth.UserServer
+	// ownerCache principal owner 解析缓存
+	ownerCache *ownerCache
+	// linter 鉴权策略创建、更新时的检查
+	linter *strategyLinter
+	// quota 鉴权策略相关的配额
+	quota *strategyQuota
+	// resolver 按照来源优先级解析 principal 引用
+	resolver *principalResolver
+	// defaultStrategyFlight 合并同一个 principal 并发的默认策略查询
+	defaultStrategyFlight singleflight.Group
+	subCtx                *eventhub.SubscribtionContext
+	// notifier 鉴权敏感操作的 webhook 通知
+	notifier *webhookNotifier
 }
 
 // initialize
 func (svr *Server) Initialize(options *auth.Config, storage store.Store, cacheMgr cachetypes.CacheManager, userSvr auth.UserServer) error {
-	svr.cacheMgr = cacheMgr
 	svr.userSvr = userSvr
 	svr.storage = storage
 	if err := svr.ParseOptions(options); err != nil {
 		return err
 	}
 
-	_ = cacheMgr.OpenResourceCache(cachetypes.ConfigEntry{
+	if err := cacheMgr.OpenResourceCache(cachetypes.ConfigEntry{
 		Name: cachetypes.StrategyRuleName,
-	})
+		Option: map[string]interface{}{
+			"resourceIdCanonical": svr.options.ResourceIDCanonical,
+		},
+	}); err != nil {
+		if !svr.options.StrategyCacheDegrade {
+			return fmt.Errorf("[Auth][Server] open auth strategy cache: %w", err)
+		}
+		log.Error("[Auth][Server] open auth strategy cache fail, degrade to query store directly, "+
+			"auth check will put more pressure on the store", zap.Error(err))
+		cacheMgr = newDegradedCacheManager(cacheMgr, storage)
+	}
+	if err := cacheMgr.OpenResourceCache(cachetypes.ConfigEntry{Name: cachetypes.AuthRoleName}); err != nil {
+		if !svr.options.StrategyCacheDegrade {
+			return fmt.Errorf("[Auth][Server] open auth role cache: %w", err)
+		}
+		// 角色只会授予权限，角色缓存不可用时通过角色获得的权限暂时不生效
+		log.Error("[Auth][Server] open auth role cache fail, permissions granted by roles will not take effect",
+			zap.Error(err))
+	}
+	svr.cacheMgr = cacheMgr
 	// 获取History插件，注意：插件的配置在bootstrap已经设置好
 	svr.history = plugin.GetHistory()
-	if svr.history == nil {
+	if auth.IsHistoryAbsent(svr.history) {
+		if options.StrictAudit {
+			log.Errorf("[Auth][Server] strict audit is enabled but no history plugin is available")
+			return auth.ErrorHistoryAbsent
+		}
 		log.Warnf("Not Found History Log Plugin")
 	}
 
+	quota, err := newStrategyQuota(svr.options)
+	if err != nil {
+		return err
+	}
+	svr.quota = quota
+	svr.linter = newStrategyLinter(svr.options, cacheMgr.User())
+	svr.ownerCache = newOwnerCache(svr.options.OwnerCacheMaxSize, svr.options.OwnerCacheTTLInSecs)
+	resolver, err := newPrincipalResolver(svr.options, cacheMgr.User(), storage)
+	if err != nil {
+		return err
+	}
+	svr.resolver = resolver
+	if svr.subCtx != nil {
+		svr.subCtx.Cancel()
+	}
+	subCtx, err := eventhub.SubscribeWithFunc(eventhub.CacheUserEventTopic, svr.handleUserChange)
+	if err != nil {
+		log.Warn("[Auth][Server] subscribe user change event, owner cache only expire by ttl", zap.Error(err))
+	}
+	svr.subCtx = subCtx
+
+	if svr.notifier != nil {
+		svr.notifier.stop()
+	}
+	notifier, err := newWebhookNotifier(svr.options.Webhook)
+	if err != nil {
+		return err
+	}
+	if notifier != nil {
+		notifier.start()
+	}
+	svr.notifier = notifier
+
+	if svr.checker != nil {
+		svr.checker.usage.stop()
+		svr.checker.async.stop()
+		svr.checker.stopWatch()
+	}
 	svr.checker = &DefaultAuthChecker{}
-	svr.checker.Initialize(svr.options, svr.storage, cacheMgr, userSvr)
+	if err := svr.checker.Initialize(svr.options, svr.storage, cacheMgr, userSvr); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -138,6 +358,31 @@ func (svr *Server) ParseOptions(options *auth.Config) error {
 	if cfg.Strict {
 		cfg.ConsoleOpen = cfg.Strict
 	}
+	switch cfg.ResourceAttachmentMode {
+	case "", ResourceAttachmentLenient, ResourceAttachmentStrict:
+	default:
+		return fmt.Errorf("[Auth][Server] unsupported resource attachment mode: %s", cfg.ResourceAttachmentMode)
+	}
+	switch cfg.ResourceTypeCheckMode {
+	case "", ResourceTypeCheckStrict, ResourceTypeCheckLenient:
+	default:
+		return fmt.Errorf("[Auth][Server] unsupported resource type check mode: %s", cfg.ResourceTypeCheckMode)
+	}
+	switch cfg.DuplicateResourceMode {
+	case "", DuplicateResourceIgnore, DuplicateResourceCount, DuplicateResourceError:
+	default:
+		return fmt.Errorf("[Auth][Server] unsupported duplicate resource mode: %s", cfg.DuplicateResourceMode)
+	}
+	switch cfg.PrincipalInferMode {
+	case "", PrincipalInferOff, PrincipalInferAuto:
+	default:
+		return fmt.Errorf("[Auth][Server] unsupported principal infer mode: %s", cfg.PrincipalInferMode)
+	}
+	switch cfg.StrategyNameScope {
+	case "", StrategyNameScopeGlobal, StrategyNameScopeTenant, StrategyNameScopeNamespace:
+	default:
+		return fmt.Errorf("[Auth][Server] unsupported strategy name scope: %s", cfg.StrategyNameScope)
+	}
 	svr.options = cfg
 	return nil
 }
@@ -152,14 +397,16 @@ func (svr *Server) GetAuthChecker() auth.AuthChecker {
 
 // RecordHistory Server对外提供history插件的简单封装
 func (svr *Server) RecordHistory(entry *model.RecordEntry) {
-	// 如果插件没有初始化，那么不记录history
-	if svr.history == nil {
-		return
-	}
 	// 如果数据为空，则不需要打印了
 	if entry == nil {
 		return
 	}
+	// 通知订阅了鉴权操作记录的 webhook
+	_ = eventhub.Publish(eventhub.AuthRecordEventTopic, &eventhub.AuthRecordEvent{Entry: entry})
+	// 如果插件没有初始化，那么不记录history
+	if svr.history == nil {
+		return
+	}
 
 	// 调用插件记录history
 	svr.history.Record(entry)
@@ -201,11 +448,23 @@ func (svr *Server) AfterResourceOperation(afterCtx *model.AcquireContext) error
 	removeGroupIds := afterCtx.GetAttachments()[model.RemoveLinkGroupsKey].([]string)
 
 	// 只有在创建一个资源的时候，才需要把当前的创建者一并加到里面去
+	// principal 类型与 token 一致时直接复用 token 中的身份信息，否则按照普通的关联用户、用户组重新解析
+	var creatorRole *model.PrincipalType
 	if afterCtx.GetOperation() == model.Create {
-		if tokenInfo.IsUserToken {
-			addUserIds = append(addUserIds, tokenInfo.OperatorID)
-		} else {
-			addGroupIds = append(addGroupIds, tokenInfo.OperatorID)
+		if role, ok := svr.creatorPrincipalRole(tokenInfo); ok {
+			switch {
+			case tokenInfo.IsUserToken == (role == model.PrincipalUser):
+				creatorRole = &role
+				if role == model.PrincipalUser {
+					addUserIds = excludeString(addUserIds, tokenInfo.OperatorID)
+				} else {
+					addGroupIds = excludeString(addGroupIds, tokenInfo.OperatorID)
+				}
+			case role == model.PrincipalUser:
+				addUserIds = append(addUserIds, tokenInfo.OperatorID)
+			default:
+				addGroupIds = append(addGroupIds, tokenInfo.OperatorID)
+			}
 		}
 	}
 
@@ -219,40 +478,121 @@ func (svr *Server) AfterResourceOperation(afterCtx *model.AcquireContext) error
 	// 添加某些用户、用户组与资源的默认授权关系
 	if err := svr.handleUserStrategy(addUserIds, afterCtx, false); err != nil {
 		log.Error("[Auth][Server] add user link resource", zap.Error(err))
+		svr.compensateResourceOperation(afterCtx)
 		return err
 	}
 	if err := svr.handleGroupStrategy(addGroupIds, afterCtx, false); err != nil {
 		log.Error("[Auth][Server] add group link resource", zap.Error(err))
+		svr.compensateResourceOperation(afterCtx)
 		return err
 	}
+	if creatorRole != nil {
+		if err := svr.handleCreatorStrategy(tokenInfo, *creatorRole, afterCtx); err != nil {
+			log.Error("[Auth][Server] add creator link resource", zap.Error(err))
+			svr.compensateResourceOperation(afterCtx)
+			return err
+		}
+	}
 
 	// 清理某些用户、用户组与资源的默认授权关系
 	if err := svr.handleUserStrategy(removeUserIds, afterCtx, true); err != nil {
 		log.Error("[Auth][Server] remove user link resource", zap.Error(err))
+		svr.compensateResourceOperation(afterCtx)
 		return err
 	}
 	if err := svr.handleGroupStrategy(removeGroupIds, afterCtx, true); err != nil {
 		log.Error("[Auth][Server] remove group link resource", zap.Error(err))
+		svr.compensateResourceOperation(afterCtx)
 		return err
 	}
 
 	return nil
 }
 
+// compensateResourceOperation 资源创建的后置处理失败时，清理本次已经建立的资源与默认策略的关联关系，
+// 由资源的创建方负责回滚资源本身，从而保证资源与鉴权数据要么同时存在，要么同时不存在。
+// 目前服务、命名空间、配置分组的创建会回滚资源；更新资源时已经变更的关联关系不会回滚，需要重试更新操作修正
+func (svr *Server) compensateResourceOperation(afterCtx *model.AcquireContext) {
+	if afterCtx.GetOperation() != model.Create {
+		return
+	}
+	attachVal, ok := afterCtx.GetAttachment(model.ResourceAttachmentKey)
+	if !ok {
+		return
+	}
+	resources, ok := attachVal.(map[apisecurity.ResourceType][]model.ResourceEntry)
+	if !ok {
+		return
+	}
+
+	strategyResource := make([]model.StrategyResource, 0, len(resources))
+	for rType, rIds := range resources {
+		for i := range rIds {
+			strategyResource = append(strategyResource, model.StrategyResource{
+				ResType: int32(rType),
+				ResID:   rIds[i].ID,
+			})
+		}
+	}
+	if len(strategyResource) == 0 {
+		return
+	}
+	if err := svr.storage.RemoveStrategyResources(strategyResource); err != nil {
+		log.Error("[Auth][Server] compensate resource link on create failure",
+			zap.Any("resource", strategyResource), zap.Error(err))
+		return
+	}
+	log.Info("[Auth][Server] compensate resource link on create failure", zap.Any("resource", strategyResource))
+}
+
+// handleCreatorStrategy 创建者的身份在校验 token 时已经确认，不再重新查询创建者，
+// 只有 token 中没有携带所属的主账户时才需要解析 owner
+func (svr *Server) handleCreatorStrategy(tokenInfo auth.OperatorInfo, role model.PrincipalType,
+	afterCtx *model.AcquireContext) error {
+	ownerId := tokenInfo.OwnerID
+	if ownerId == "" && role == model.PrincipalUser {
+		var err error
+		if ownerId, err = svr.resolveUserOwner(tokenInfo.OperatorID); err != nil {
+			return err
+		}
+	}
+	if ownerId == "" && role == model.PrincipalGroup {
+		group := svr.userSvr.GetUserHelper().GetGroup(context.TODO(), &apisecurity.UserGroup{
+			Id: wrapperspb.String(tokenInfo.OperatorID),
+		})
+		if group == nil {
+			return errors.New("not found target group")
+		}
+		ownerId = group.GetOwner().GetValue()
+	}
+	return svr.handlerModifyDefaultStrategy(tokenInfo.OperatorID, ownerId, role, afterCtx, false)
+}
+
+// excludeString 去掉切片中与 target 相同的元素
+func excludeString(s []string, target string) []string {
+	ret := make([]string, 0, len(s))
+	for i := range s {
+		if s[i] != target {
+			ret = append(ret, s[i])
+		}
+	}
+	return ret
+}
+
 // handleUserStrategy
 func (svr *Server) handleUserStrategy(userIds []string, afterCtx *model.AcquireContext, isRemove bool) error {
-	for index := range utils.StringSliceDeDuplication(userIds) {
-		userId := userIds[index]
-		user := svr.userSvr.GetUserHelper().GetUser(context.TODO(), &apisecurity.User{
-			Id: wrapperspb.String(userId),
-		})
-		if user == nil {
+	operatorOwner := parseOperatorOwner(afterCtx)
+	for _, ref := range utils.StringSliceDeDuplication(userIds) {
+		userId, err := svr.resolver.resolveUser(ref, operatorOwner)
+		if err != nil {
+			return err
+		}
+		if userId == "" {
 			return errors.New("not found target user")
 		}
-
-		ownerId := user.GetOwner().GetValue()
-		if ownerId == "" {
-			ownerId = user.GetId().GetValue()
+		ownerId, err := svr.resolveUserOwner(userId)
+		if err != nil {
+			return err
 		}
 		if err := svr.handlerModifyDefaultStrategy(userId, ownerId, model.PrincipalUser,
 			afterCtx, isRemove); err != nil {
@@ -262,14 +602,65 @@ func (svr *Server) handleUserStrategy(userIds []string, afterCtx *model.AcquireC
 	return nil
 }
 
+// resolveUserOwner 解析用户所属的主账户 ID，优先从 owner 缓存中获取
+// 沿着 owner 链查找到没有 owner 的主账户，owner 链存在环或者超出最大深度时拒绝，避免循环解析或者授权给自己
+func (svr *Server) resolveUserOwner(userId string) (string, error) {
+	if ownerId, ok := svr.ownerCache.Get(userId); ok {
+		return ownerId, nil
+	}
+	maxDepth := svr.options.MaxOwnerChainDepth
+	if maxDepth <= 0 {
+		maxDepth = defaultMaxOwnerChainDepth
+	}
+
+	visited := map[string]struct{}{}
+	current := userId
+	for {
+		user := svr.userSvr.GetUserHelper().GetUser(context.TODO(), &apisecurity.User{
+			Id: wrapperspb.String(current),
+		})
+		if user == nil {
+			if current == userId {
+				return "", errors.New("not found target user")
+			}
+			// owner 已经不存在时，与之前的逻辑保持一致直接作为主账户
+			break
+		}
+		visited[current] = struct{}{}
+		ownerId := user.GetOwner().GetValue()
+		if ownerId == "" {
+			break
+		}
+		if _, ok := visited[ownerId]; ok {
+			metrics.ReportOwnerChainReject("cycle")
+			log.Error("[Auth][Server] user owner chain has cycle", zap.String("user", userId),
+				zap.String("owner", ownerId))
+			return "", fmt.Errorf("%w: user %s", ErrorOwnerChainCycle, userId)
+		}
+		if len(visited) >= maxDepth {
+			metrics.ReportOwnerChainReject("depth")
+			log.Error("[Auth][Server] user owner chain too deep", zap.String("user", userId),
+				zap.Int("max-depth", maxDepth))
+			return "", fmt.Errorf("%w: user %s", ErrorOwnerChainTooDeep, userId)
+		}
+		current = ownerId
+	}
+	svr.ownerCache.Put(userId, current)
+	return current, nil
+}
+
 // handleGroupStrategy
 func (svr *Server) handleGroupStrategy(groupIds []string, afterCtx *model.AcquireContext, isRemove bool) error {
-	for index := range utils.StringSliceDeDuplication(groupIds) {
-		groupId := groupIds[index]
+	operatorOwner := parseOperatorOwner(afterCtx)
+	for _, ref := range utils.StringSliceDeDuplication(groupIds) {
+		groupId, err := svr.resolver.resolveGroup(ref, operatorOwner)
+		if err != nil {
+			return err
+		}
 		group := svr.userSvr.GetUserHelper().GetGroup(context.TODO(), &apisecurity.UserGroup{
 			Id: wrapperspb.String(groupId),
 		})
-		if group == nil {
+		if groupId == "" || group == nil {
 			return errors.New("not found target group")
 		}
 		ownerId := group.GetOwner().GetValue()
@@ -282,12 +673,85 @@ func (svr *Server) handleGroupStrategy(groupIds []string, afterCtx *model.Acquir
 	return nil
 }
 
+// parseOperatorOwner 获取操作者所属的主账户 ID, 按照名称解析 principal 时仅在该主账户下查找
+func parseOperatorOwner(afterCtx *model.AcquireContext) string {
+	attachVal, ok := afterCtx.GetAttachment(model.TokenDetailInfoKey)
+	if !ok {
+		return ""
+	}
+	tokenInfo, ok := attachVal.(auth.OperatorInfo)
+	if !ok {
+		return ""
+	}
+	if tokenInfo.OwnerID != "" {
+		return tokenInfo.OwnerID
+	}
+	return tokenInfo.OperatorID
+}
+
+// checkResourceAttachment 未携带关联资源时，严格模式下资源的创建、更新直接报错，宽松模式下忽略
+func (svr *Server) checkResourceAttachment(afterCtx *model.AcquireContext, id string) error {
+	if svr.options.ResourceAttachmentMode != ResourceAttachmentStrict {
+		return nil
+	}
+	switch afterCtx.GetOperation() {
+	case model.Create, model.Modify:
+		log.Error("[Auth][Server] resource attachment is empty", utils.RequestID(afterCtx.GetRequestContext()),
+			zap.String("method", afterCtx.GetMethod()), zap.String("principal", id))
+		return ErrorEmptyResourceAttachment
+	default:
+		return nil
+	}
+}
+
+// checkResourceTypes 校验关联资源的资源类型均为已知的资源类型，未知类型的关联关系无法被任何鉴权匹配
+// strict 模式下直接报错，lenient 模式下忽略未知类型的资源
+func (svr *Server) checkResourceTypes(afterCtx *model.AcquireContext,
+	resources map[apisecurity.ResourceType][]model.ResourceEntry) (
+	map[apisecurity.ResourceType][]model.ResourceEntry, error) {
+	var unknown []int32
+	for rType := range resources {
+		if _, ok := apisecurity.ResourceType_name[int32(rType)]; !ok {
+			unknown = append(unknown, int32(rType))
+		}
+	}
+	if len(unknown) == 0 {
+		return resources, nil
+	}
+	sort.Slice(unknown, func(i, j int) bool { return unknown[i] < unknown[j] })
+	if svr.options.ResourceTypeCheckMode == ResourceTypeCheckLenient {
+		log.Warn("[Auth][Server] ignore resource attachment with unknown resource type",
+			utils.RequestID(afterCtx.GetRequestContext()), zap.String("method", afterCtx.GetMethod()),
+			zap.Int32s("types", unknown))
+		known := make(map[apisecurity.ResourceType][]model.ResourceEntry, len(resources))
+		for rType, entries := range resources {
+			if _, ok := apisecurity.ResourceType_name[int32(rType)]; ok {
+				known[rType] = entries
+			}
+		}
+		return known, nil
+	}
+	log.Error("[Auth][Server] resource attachment with unknown resource type",
+		utils.RequestID(afterCtx.GetRequestContext()), zap.String("method", afterCtx.GetMethod()),
+		zap.Int32s("types", unknown))
+	return nil, fmt.Errorf("%w: %v", ErrorUnknownResourceType, unknown)
+}
+
+func isEmptyResourceAttachment(resources map[apisecurity.ResourceType][]model.ResourceEntry) bool {
+	for _, entries := range resources {
+		if len(entries) != 0 {
+			return false
+		}
+	}
+	return true
+}
+
 // handlerModifyDefaultStrategy 处理默认策略的修改
 // case 1. 如果默认策略是全部放通
 func (svr *Server) handlerModifyDefaultStrategy(id, ownerId string, uType model.PrincipalType,
 	afterCtx *model.AcquireContext, cleanRealtion bool) error {
 	// Get the default policy rules
-	strategy, err := svr.storage.GetDefaultStrategyDetailByPrincipal(id, uType)
+	strategy, err := svr.getDefaultStrategy(id, uType)
 	if err != nil {
 		log.Error("[Auth][Server] get default strategy",
 			zap.String("owner", ownerId), zap.String("id", id), zap.Error(err))
@@ -303,16 +767,27 @@ func (svr *Server) handlerModifyDefaultStrategy(id, ownerId string, uType model.
 	)
 	attachVal, ok := afterCtx.GetAttachment(model.ResourceAttachmentKey)
 	if !ok {
-		return nil
+		return svr.checkResourceAttachment(afterCtx, id)
 	}
 	resources, ok := attachVal.(map[apisecurity.ResourceType][]model.ResourceEntry)
 	if !ok {
-		return nil
+		return svr.checkResourceAttachment(afterCtx, id)
+	}
+	if isEmptyResourceAttachment(resources) {
+		if err := svr.checkResourceAttachment(afterCtx, id); err != nil {
+			return err
+		}
 	}
 	// 资源删除时，清理该资源与所有策略的关联关系
 	if afterCtx.GetOperation() == model.Delete {
 		strategyId = ""
 	}
+	// 清理关联关系时不校验资源类型，允许清理已经写入的未知类型的关联关系
+	if afterCtx.GetOperation() != model.Delete && !cleanRealtion {
+		if resources, err = svr.checkResourceTypes(afterCtx, resources); err != nil {
+			return err
+		}
+	}
 
 	for rType, rIds := range resources {
 		for i := range rIds {
@@ -329,6 +804,7 @@ func (svr *Server) handlerModifyDefaultStrategy(id, ownerId string, uType model.
 		ResourceType: model.RAuthStrategy,
 		ResourceName: fmt.Sprintf("%s(%s)", strategy.Name, strategy.ID),
 		Operator:     utils.ParseOperator(afterCtx.GetRequestContext()),
+		ChangeSetID:  utils.ParseChangeSetID(afterCtx.GetRequestContext()),
 		Detail:       utils.MustJson(strategyResource),
 		HappenTime:   time.Now(),
 	}
@@ -0,0 +1,620 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+package policy_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	apisecurity "github.com/polarismesh/specification/source/go/api/v1/security"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/polarismesh/polaris/auth"
+	"github.com/polarismesh/polaris/auth/policy"
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/plugin"
+)
+
+func Test_AfterResourceOperation(t *testing.T) {
+	strategyTest := newStrategyTest(t)
+	defer strategyTest.Clean()
+
+	_ = strategyTest.cacheMgn.TestUpdate()
+
+	newAfterCtx := func(serviceId string, linkUsers []string) *model.AcquireContext {
+		return model.NewAcquireContext(
+			model.WithRequestContext(context.Background()),
+			model.WithOperation(model.Create),
+			model.WithFromConsole(),
+			model.WithAttachment(map[string]interface{}{
+				model.TokenDetailInfoKey: auth.OperatorInfo{
+					Origin:      strategyTest.users[1].Token,
+					OperatorID:  strategyTest.users[1].ID,
+					OwnerID:     strategyTest.users[0].ID,
+					Role:        model.SubAccountUserRole,
+					IsUserToken: true,
+				},
+				model.ResourceAttachmentKey: map[apisecurity.ResourceType][]model.ResourceEntry{
+					apisecurity.ResourceType_Services: {{ID: serviceId, Owner: strategyTest.users[0].ID}},
+				},
+				model.LinkUsersKey:        linkUsers,
+				model.LinkGroupsKey:       []string{},
+				model.RemoveLinkUsersKey:  []string{},
+				model.RemoveLinkGroupsKey: []string{},
+			}),
+		)
+	}
+
+	t.Run("正常关联默认策略", func(t *testing.T) {
+		strategyTest.storage.EXPECT().GetDefaultStrategyDetailByPrincipal(gomock.Any(), gomock.Any()).
+			Return(strategyTest.defaultStrategies[0], nil).Times(2)
+		strategyTest.storage.EXPECT().LooseAddStrategyResources(gomock.Any()).Return(nil).Times(2)
+		strategyTest.storage.EXPECT().RemoveStrategyResources(gomock.Any()).Times(0)
+
+		err := strategyTest.svr.AfterResourceOperation(newAfterCtx("mock-svc-1", []string{strategyTest.users[2].ID}))
+		assert.NoError(t, err)
+	})
+
+	t.Run("部分关联失败-回滚已建立的关联关系", func(t *testing.T) {
+		strategyTest.storage.EXPECT().GetDefaultStrategyDetailByPrincipal(gomock.Any(), gomock.Any()).
+			Return(strategyTest.defaultStrategies[0], nil).Times(1)
+		strategyTest.storage.EXPECT().LooseAddStrategyResources(gomock.Any()).Return(nil).Times(1)
+		strategyTest.storage.EXPECT().RemoveStrategyResources(gomock.Any()).
+			DoAndReturn(func(resources []model.StrategyResource) error {
+				assert.Equal(t, []model.StrategyResource{{
+					ResType: int32(apisecurity.ResourceType_Services),
+					ResID:   "mock-svc-2",
+				}}, resources)
+				return nil
+			}).Times(1)
+
+		err := strategyTest.svr.AfterResourceOperation(newAfterCtx("mock-svc-2",
+			[]string{strategyTest.users[2].ID, "not-exist-user"}))
+		assert.Error(t, err)
+	})
+
+	t.Run("清理关联失败-回滚已建立的关联关系", func(t *testing.T) {
+		strategyTest.storage.EXPECT().GetDefaultStrategyDetailByPrincipal(gomock.Any(), gomock.Any()).
+			Return(strategyTest.defaultStrategies[0], nil).Times(2)
+		strategyTest.storage.EXPECT().LooseAddStrategyResources(gomock.Any()).Return(nil).Times(2)
+		strategyTest.storage.EXPECT().RemoveStrategyResources(gomock.Any()).
+			DoAndReturn(func(resources []model.StrategyResource) error {
+				assert.Equal(t, []model.StrategyResource{{
+					ResType: int32(apisecurity.ResourceType_Services),
+					ResID:   "mock-svc-3",
+				}}, resources)
+				return nil
+			}).Times(1)
+
+		afterCtx := newAfterCtx("mock-svc-3", []string{strategyTest.users[2].ID})
+		afterCtx.SetAttachment(model.RemoveLinkUsersKey, []string{"not-exist-user"})
+		err := strategyTest.svr.AfterResourceOperation(afterCtx)
+		assert.Error(t, err)
+	})
+}
+
+func Test_AfterResourceOperation_ResolvePrincipal(t *testing.T) {
+	strategyTest := newStrategyTest(t)
+	defer strategyTest.Clean()
+
+	err := strategyTest.svr.Initialize(&auth.Config{
+		Strategy: &auth.StrategyConfig{
+			Name: auth.DefaultPolicyPluginName,
+			Option: map[string]interface{}{
+				"principalResolveOrder": []interface{}{"id", "name", "alias"},
+			},
+		},
+	}, strategyTest.storage, strategyTest.cacheMgn, strategyTest.userSvr)
+	assert.NoError(t, err)
+	_ = strategyTest.cacheMgn.TestUpdate()
+
+	newAfterCtx := func(linkUsers, linkGroups []string) *model.AcquireContext {
+		return model.NewAcquireContext(
+			model.WithRequestContext(context.Background()),
+			model.WithOperation(model.Modify),
+			model.WithFromConsole(),
+			model.WithAttachment(map[string]interface{}{
+				model.TokenDetailInfoKey: auth.OperatorInfo{
+					Origin:      strategyTest.users[1].Token,
+					OperatorID:  strategyTest.users[1].ID,
+					OwnerID:     strategyTest.users[0].ID,
+					Role:        model.SubAccountUserRole,
+					IsUserToken: true,
+				},
+				model.ResourceAttachmentKey: map[apisecurity.ResourceType][]model.ResourceEntry{
+					apisecurity.ResourceType_Services: {{ID: "mock-svc-1", Owner: strategyTest.users[0].ID}},
+				},
+				model.LinkUsersKey:        linkUsers,
+				model.LinkGroupsKey:       linkGroups,
+				model.RemoveLinkUsersKey:  []string{},
+				model.RemoveLinkGroupsKey: []string{},
+			}),
+		)
+	}
+	// expectLinkPrincipal 校验解析后关联默认策略的 principal
+	expectLinkPrincipal := func(id string, uType model.PrincipalType) {
+		strategyTest.storage.EXPECT().GetDefaultStrategyDetailByPrincipal(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(principalId string, principalType model.PrincipalType) (*model.StrategyDetail, error) {
+				assert.Equal(t, id, principalId)
+				assert.Equal(t, uType, principalType)
+				return strategyTest.defaultStrategies[0], nil
+			}).Times(1)
+		strategyTest.storage.EXPECT().LooseAddStrategyResources(gomock.Any()).Return(nil).Times(1)
+	}
+
+	t.Run("按照 ID 解析用户", func(t *testing.T) {
+		expectLinkPrincipal(strategyTest.users[2].ID, model.PrincipalUser)
+		err := strategyTest.svr.AfterResourceOperation(newAfterCtx([]string{strategyTest.users[2].ID}, []string{}))
+		assert.NoError(t, err)
+	})
+
+	t.Run("按照名称解析用户", func(t *testing.T) {
+		expectLinkPrincipal(strategyTest.users[3].ID, model.PrincipalUser)
+		err := strategyTest.svr.AfterResourceOperation(newAfterCtx([]string{strategyTest.users[3].Name}, []string{}))
+		assert.NoError(t, err)
+	})
+
+	t.Run("按照外部别名解析用户", func(t *testing.T) {
+		expectLinkPrincipal(strategyTest.users[4].ID, model.PrincipalUser)
+		alias := strategyTest.users[4].Source + ":" + strategyTest.users[4].Name
+		err := strategyTest.svr.AfterResourceOperation(newAfterCtx([]string{alias}, []string{}))
+		assert.NoError(t, err)
+
+		// 来源不一致时无法解析
+		err = strategyTest.svr.AfterResourceOperation(newAfterCtx([]string{"LDAP:" + strategyTest.users[4].Name}, []string{}))
+		assert.Error(t, err)
+	})
+
+	t.Run("按照名称解析用户组", func(t *testing.T) {
+		group := strategyTest.groups[1]
+		strategyTest.storage.EXPECT().GetGroupByName(group.Name, strategyTest.users[0].ID).
+			Return(group.UserGroup, nil).Times(1)
+		expectLinkPrincipal(group.ID, model.PrincipalGroup)
+		err := strategyTest.svr.AfterResourceOperation(newAfterCtx([]string{}, []string{group.Name}))
+		assert.NoError(t, err)
+	})
+
+	t.Run("不同来源解析出不同的 principal", func(t *testing.T) {
+		groupRef := strategyTest.groups[1].ID
+		strategyTest.storage.EXPECT().GetGroupByName(groupRef, strategyTest.users[0].ID).
+			Return(strategyTest.groups[2].UserGroup, nil).Times(1)
+		strategyTest.storage.EXPECT().GetDefaultStrategyDetailByPrincipal(gomock.Any(), gomock.Any()).Times(0)
+		err := strategyTest.svr.AfterResourceOperation(newAfterCtx([]string{}, []string{groupRef}))
+		assert.ErrorIs(t, err, policy.ErrorPrincipalAmbiguous)
+	})
+
+	t.Run("不支持的解析来源", func(t *testing.T) {
+		err := strategyTest.svr.Initialize(&auth.Config{
+			Strategy: &auth.StrategyConfig{
+				Name: auth.DefaultPolicyPluginName,
+				Option: map[string]interface{}{
+					"principalResolveOrder": []interface{}{"email"},
+				},
+			},
+		}, strategyTest.storage, strategyTest.cacheMgn, strategyTest.userSvr)
+		assert.Error(t, err)
+	})
+}
+
+func Test_AfterResourceOperation_ResourceAttachmentMode(t *testing.T) {
+	strategyTest := newStrategyTest(t)
+	defer strategyTest.Clean()
+
+	initWithMode := func(mode string) error {
+		err := strategyTest.svr.Initialize(&auth.Config{
+			Strategy: &auth.StrategyConfig{
+				Name: auth.DefaultPolicyPluginName,
+				Option: map[string]interface{}{
+					"resourceAttachmentMode": mode,
+				},
+			},
+		}, strategyTest.storage, strategyTest.cacheMgn, strategyTest.userSvr)
+		_ = strategyTest.cacheMgn.TestUpdate()
+		return err
+	}
+	newAfterCtx := func(op model.ResourceOperation, resources interface{}) *model.AcquireContext {
+		attachments := map[string]interface{}{
+			model.TokenDetailInfoKey: auth.OperatorInfo{
+				Origin:      strategyTest.users[1].Token,
+				OperatorID:  strategyTest.users[1].ID,
+				OwnerID:     strategyTest.users[0].ID,
+				Role:        model.SubAccountUserRole,
+				IsUserToken: true,
+			},
+			model.LinkUsersKey:        []string{strategyTest.users[2].ID},
+			model.LinkGroupsKey:       []string{},
+			model.RemoveLinkUsersKey:  []string{},
+			model.RemoveLinkGroupsKey: []string{},
+		}
+		if resources != nil {
+			attachments[model.ResourceAttachmentKey] = resources
+		}
+		return model.NewAcquireContext(
+			model.WithRequestContext(context.Background()),
+			model.WithOperation(op),
+			model.WithFromConsole(),
+			model.WithAttachment(attachments),
+		)
+	}
+	populated := map[apisecurity.ResourceType][]model.ResourceEntry{
+		apisecurity.ResourceType_Services: {{ID: "mock-svc-1", Owner: strategyTest.users[0].ID}},
+	}
+	empty := map[apisecurity.ResourceType][]model.ResourceEntry{
+		apisecurity.ResourceType_Services: {},
+	}
+
+	strategyTest.storage.EXPECT().GetDefaultStrategyDetailByPrincipal(gomock.Any(), gomock.Any()).
+		Return(strategyTest.defaultStrategies[0], nil).AnyTimes()
+	strategyTest.storage.EXPECT().RemoveStrategyResources(gomock.Any()).Return(nil).AnyTimes()
+	var added [][]model.StrategyResource
+	strategyTest.storage.EXPECT().LooseAddStrategyResources(gomock.Any()).
+		DoAndReturn(func(resources []model.StrategyResource) error {
+			added = append(added, resources)
+			return nil
+		}).AnyTimes()
+
+	t.Run("宽松模式", func(t *testing.T) {
+		assert.NoError(t, initWithMode(policy.ResourceAttachmentLenient))
+
+		// 未携带关联资源时忽略
+		added = nil
+		assert.NoError(t, strategyTest.svr.AfterResourceOperation(newAfterCtx(model.Create, nil)))
+		assert.Equal(t, 0, len(added))
+
+		// 关联资源为空时保持原有的处理逻辑
+		added = nil
+		assert.NoError(t, strategyTest.svr.AfterResourceOperation(newAfterCtx(model.Modify, empty)))
+		for i := range added {
+			assert.Equal(t, 0, len(added[i]))
+		}
+
+		added = nil
+		assert.NoError(t, strategyTest.svr.AfterResourceOperation(newAfterCtx(model.Create, populated)))
+		// 创建者以及关联的用户
+		assert.Equal(t, 2, len(added))
+		assert.Equal(t, "mock-svc-1", added[0][0].ResID)
+	})
+
+	t.Run("严格模式", func(t *testing.T) {
+		assert.NoError(t, initWithMode(policy.ResourceAttachmentStrict))
+
+		for _, op := range []model.ResourceOperation{model.Create, model.Modify} {
+			added = nil
+			err := strategyTest.svr.AfterResourceOperation(newAfterCtx(op, nil))
+			assert.ErrorIs(t, err, policy.ErrorEmptyResourceAttachment)
+
+			err = strategyTest.svr.AfterResourceOperation(newAfterCtx(op, empty))
+			assert.ErrorIs(t, err, policy.ErrorEmptyResourceAttachment)
+			assert.Equal(t, 0, len(added))
+		}
+
+		// 删除操作不要求携带关联资源
+		assert.NoError(t, strategyTest.svr.AfterResourceOperation(newAfterCtx(model.Delete, nil)))
+
+		added = nil
+		assert.NoError(t, strategyTest.svr.AfterResourceOperation(newAfterCtx(model.Create, populated)))
+		assert.Equal(t, 2, len(added))
+	})
+
+	t.Run("不支持的模式", func(t *testing.T) {
+		assert.Error(t, initWithMode("unknown"))
+	})
+}
+
+func Test_AfterResourceOperation_PrincipalInfer(t *testing.T) {
+	strategyTest := newStrategyTest(t)
+	defer strategyTest.Clean()
+
+	initWithMode := func(mode string) error {
+		err := strategyTest.svr.Initialize(&auth.Config{
+			Strategy: &auth.StrategyConfig{
+				Name: auth.DefaultPolicyPluginName,
+				Option: map[string]interface{}{
+					"principalInferMode": mode,
+					"groupTokenPrefixes": []interface{}{"grp-"},
+				},
+			},
+		}, strategyTest.storage, strategyTest.cacheMgn, strategyTest.userSvr)
+		_ = strategyTest.cacheMgn.TestUpdate()
+		return err
+	}
+	newAfterCtx := func(token, operatorID string, isUserToken bool) *model.AcquireContext {
+		return model.NewAcquireContext(
+			model.WithRequestContext(context.Background()),
+			model.WithOperation(model.Create),
+			model.WithFromConsole(),
+			model.WithAttachment(map[string]interface{}{
+				model.TokenDetailInfoKey: auth.OperatorInfo{
+					Origin:      token,
+					OperatorID:  operatorID,
+					OwnerID:     strategyTest.users[0].ID,
+					IsUserToken: isUserToken,
+				},
+				model.ResourceAttachmentKey: map[apisecurity.ResourceType][]model.ResourceEntry{
+					apisecurity.ResourceType_Services: {{ID: "mock-svc-1", Owner: strategyTest.users[0].ID}},
+				},
+				model.LinkUsersKey:        []string{},
+				model.LinkGroupsKey:       []string{},
+				model.RemoveLinkUsersKey:  []string{},
+				model.RemoveLinkGroupsKey: []string{},
+			}),
+		)
+	}
+	// expectCreator 校验创建者关联默认策略时使用的 principal
+	expectCreator := func(id string, uType model.PrincipalType) {
+		strategyTest.storage.EXPECT().GetDefaultStrategyDetailByPrincipal(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(principalId string, principalType model.PrincipalType) (*model.StrategyDetail, error) {
+				assert.Equal(t, id, principalId)
+				assert.Equal(t, uType, principalType)
+				return strategyTest.defaultStrategies[0], nil
+			}).Times(1)
+		strategyTest.storage.EXPECT().LooseAddStrategyResources(gomock.Any()).Return(nil).Times(1)
+	}
+	user, group := strategyTest.users[1], strategyTest.groups[1]
+
+	assert.NoError(t, initWithMode(policy.PrincipalInferAuto))
+
+	t.Run("用户 token", func(t *testing.T) {
+		expectCreator(user.ID, model.PrincipalUser)
+		assert.NoError(t, strategyTest.svr.AfterResourceOperation(newAfterCtx(user.Token, user.ID, true)))
+	})
+
+	t.Run("用户组 token", func(t *testing.T) {
+		expectCreator(group.ID, model.PrincipalGroup)
+		assert.NoError(t, strategyTest.svr.AfterResourceOperation(newAfterCtx(group.Token, group.ID, false)))
+	})
+
+	t.Run("token 类型与用户、用户组不一致", func(t *testing.T) {
+		// 外部 token 未设置 IsUserToken，根据已经存在的用户推断
+		expectCreator(user.ID, model.PrincipalUser)
+		assert.NoError(t, strategyTest.svr.AfterResourceOperation(newAfterCtx("external", user.ID, false)))
+
+		// 根据 token 前缀推断
+		expectCreator(group.ID, model.PrincipalGroup)
+		assert.NoError(t, strategyTest.svr.AfterResourceOperation(newAfterCtx("grp-external", group.ID, true)))
+	})
+
+	t.Run("无法推断时不关联创建者", func(t *testing.T) {
+		strategyTest.storage.EXPECT().GetDefaultStrategyDetailByPrincipal(gomock.Any(), gomock.Any()).Times(0)
+		strategyTest.storage.EXPECT().LooseAddStrategyResources(gomock.Any()).Times(0)
+		assert.NoError(t, strategyTest.svr.AfterResourceOperation(newAfterCtx("external", "unknown-id", false)))
+
+		// 关闭推断时按照 token 中的类型关联
+		assert.NoError(t, initWithMode(policy.PrincipalInferOff))
+		strategyTest.storage.EXPECT().GetDefaultStrategyDetailByPrincipal("unknown-id", model.PrincipalGroup).
+			Return(nil, nil).Times(1)
+		// 关联失败时清理已经建立的关联关系
+		strategyTest.storage.EXPECT().RemoveStrategyResources(gomock.Any()).Return(nil).Times(1)
+		assert.Error(t, strategyTest.svr.AfterResourceOperation(newAfterCtx("external", "unknown-id", false)))
+	})
+
+	t.Run("不支持的模式", func(t *testing.T) {
+		assert.Error(t, initWithMode("unknown"))
+	})
+}
+
+func Test_AfterResourceOperation_CreatorFromToken(t *testing.T) {
+	strategyTest := newStrategyTest(t)
+	defer strategyTest.Clean()
+
+	_ = strategyTest.cacheMgn.TestUpdate()
+
+	newAfterCtx := func(operator auth.OperatorInfo, linkUsers []string) *model.AcquireContext {
+		return model.NewAcquireContext(
+			model.WithRequestContext(context.Background()),
+			model.WithOperation(model.Create),
+			model.WithFromConsole(),
+			model.WithAttachment(map[string]interface{}{
+				model.TokenDetailInfoKey: operator,
+				model.ResourceAttachmentKey: map[apisecurity.ResourceType][]model.ResourceEntry{
+					apisecurity.ResourceType_Services: {{ID: "mock-svc-1", Owner: strategyTest.users[0].ID}},
+				},
+				model.LinkUsersKey:        linkUsers,
+				model.LinkGroupsKey:       []string{},
+				model.RemoveLinkUsersKey:  []string{},
+				model.RemoveLinkGroupsKey: []string{},
+			}),
+		)
+	}
+	expectCreator := func(id string, uType model.PrincipalType) {
+		strategyTest.storage.EXPECT().GetDefaultStrategyDetailByPrincipal(id, uType).
+			Return(strategyTest.defaultStrategies[0], nil).Times(1)
+		strategyTest.storage.EXPECT().LooseAddStrategyResources(gomock.Any()).Return(nil).Times(1)
+	}
+
+	t.Run("token 中的信息足够时不再查询创建者", func(t *testing.T) {
+		// 用户组不在缓存中，重新查询时会访问存储层
+		strategyTest.storage.EXPECT().GetGroup(gomock.Any()).Times(0)
+		expectCreator("token-group", model.PrincipalGroup)
+		assert.NoError(t, strategyTest.svr.AfterResourceOperation(newAfterCtx(auth.OperatorInfo{
+			Origin:     "token-group",
+			OperatorID: "token-group",
+			OwnerID:    strategyTest.users[0].ID,
+		}, []string{})))
+	})
+
+	t.Run("创建者同时出现在关联用户中时只关联一次", func(t *testing.T) {
+		user := strategyTest.users[1]
+		expectCreator(user.ID, model.PrincipalUser)
+		assert.NoError(t, strategyTest.svr.AfterResourceOperation(newAfterCtx(auth.OperatorInfo{
+			Origin:      user.Token,
+			OperatorID:  user.ID,
+			OwnerID:     user.Owner,
+			IsUserToken: true,
+		}, []string{user.ID})))
+	})
+
+	t.Run("token 中没有 owner 时只解析 owner", func(t *testing.T) {
+		user := strategyTest.users[2]
+		expectCreator(user.ID, model.PrincipalUser)
+		assert.NoError(t, strategyTest.svr.AfterResourceOperation(newAfterCtx(auth.OperatorInfo{
+			Origin:      user.Token,
+			OperatorID:  user.ID,
+			IsUserToken: true,
+		}, []string{})))
+	})
+}
+
+func Test_AfterResourceOperation_ResourceType(t *testing.T) {
+	strategyTest := newStrategyTest(t)
+	defer strategyTest.Clean()
+
+	_ = strategyTest.cacheMgn.TestUpdate()
+
+	user := strategyTest.users[1]
+	unknownType := apisecurity.ResourceType(99)
+	newAfterCtx := func(resources map[apisecurity.ResourceType][]model.ResourceEntry) *model.AcquireContext {
+		return model.NewAcquireContext(
+			model.WithRequestContext(context.Background()),
+			model.WithOperation(model.Create),
+			model.WithFromConsole(),
+			model.WithAttachment(map[string]interface{}{
+				model.TokenDetailInfoKey: auth.OperatorInfo{
+					Origin:      user.Token,
+					OperatorID:  user.ID,
+					OwnerID:     user.Owner,
+					IsUserToken: true,
+				},
+				model.ResourceAttachmentKey: resources,
+				model.LinkUsersKey:          []string{},
+				model.LinkGroupsKey:         []string{},
+				model.RemoveLinkUsersKey:    []string{},
+				model.RemoveLinkGroupsKey:   []string{},
+			}),
+		)
+	}
+	strategyTest.storage.EXPECT().GetDefaultStrategyDetailByPrincipal(user.ID, model.PrincipalUser).
+		Return(strategyTest.defaultStrategies[1], nil).AnyTimes()
+	initialize := func(mode string) {
+		err := strategyTest.svr.Initialize(&auth.Config{
+			Strategy: &auth.StrategyConfig{
+				Name:   auth.DefaultPolicyPluginName,
+				Option: map[string]interface{}{"resourceTypeCheckMode": mode},
+			},
+		}, strategyTest.storage, strategyTest.cacheMgn, strategyTest.userSvr)
+		assert.NoError(t, err)
+	}
+
+	t.Run("已知的资源类型正常关联", func(t *testing.T) {
+		initialize(policy.ResourceTypeCheckStrict)
+		strategyTest.storage.EXPECT().LooseAddStrategyResources(gomock.Any()).DoAndReturn(
+			func(resources []model.StrategyResource) error {
+				assert.Equal(t, 3, len(resources))
+				return nil
+			}).Times(1)
+		assert.NoError(t, strategyTest.svr.AfterResourceOperation(newAfterCtx(
+			map[apisecurity.ResourceType][]model.ResourceEntry{
+				apisecurity.ResourceType_Namespaces:   {{ID: "mock-ns-1"}},
+				apisecurity.ResourceType_Services:     {{ID: "mock-svc-1"}},
+				apisecurity.ResourceType_ConfigGroups: {{ID: "1"}},
+			})))
+	})
+
+	t.Run("未知的资源类型直接报错", func(t *testing.T) {
+		initialize("")
+		strategyTest.storage.EXPECT().LooseAddStrategyResources(gomock.Any()).Times(0)
+		// 创建失败时回滚本次资源的关联关系
+		strategyTest.storage.EXPECT().RemoveStrategyResources(gomock.Any()).Return(nil).Times(1)
+		err := strategyTest.svr.AfterResourceOperation(newAfterCtx(
+			map[apisecurity.ResourceType][]model.ResourceEntry{
+				apisecurity.ResourceType_Services: {{ID: "mock-svc-1"}},
+				unknownType:                       {{ID: "mock-unknown-1"}},
+			}))
+		assert.ErrorIs(t, err, policy.ErrorUnknownResourceType)
+		assert.Contains(t, err.Error(), "99")
+	})
+
+	t.Run("宽松模式下忽略未知的资源类型", func(t *testing.T) {
+		initialize(policy.ResourceTypeCheckLenient)
+		strategyTest.storage.EXPECT().LooseAddStrategyResources(gomock.Any()).DoAndReturn(
+			func(resources []model.StrategyResource) error {
+				assert.Equal(t, []model.StrategyResource{{
+					StrategyID: strategyTest.defaultStrategies[1].ID,
+					ResType:    int32(apisecurity.ResourceType_Services),
+					ResID:      "mock-svc-1",
+				}}, resources)
+				return nil
+			}).Times(1)
+		assert.NoError(t, strategyTest.svr.AfterResourceOperation(newAfterCtx(
+			map[apisecurity.ResourceType][]model.ResourceEntry{
+				apisecurity.ResourceType_Services: {{ID: "mock-svc-1"}},
+				unknownType:                       {{ID: "mock-unknown-1"}},
+			})))
+	})
+
+	t.Run("不支持的校验模式", func(t *testing.T) {
+		err := strategyTest.svr.Initialize(&auth.Config{
+			Strategy: &auth.StrategyConfig{
+				Name:   auth.DefaultPolicyPluginName,
+				Option: map[string]interface{}{"resourceTypeCheckMode": "unknown"},
+			},
+		}, strategyTest.storage, strategyTest.cacheMgn, strategyTest.userSvr)
+		assert.Error(t, err)
+	})
+}
+
+type auditHistory struct{}
+
+func (h *auditHistory) Name() string {
+	return "auditHistory"
+}
+
+func (h *auditHistory) Initialize(c *plugin.ConfigEntry) error {
+	return nil
+}
+
+func (h *auditHistory) Destroy() error {
+	return nil
+}
+
+func (h *auditHistory) Record(entry *model.RecordEntry) {
+}
+
+func Test_Initialize_StrictAudit(t *testing.T) {
+	strategyTest := newStrategyTest(t)
+	defer strategyTest.Clean()
+
+	initialize := func(strict bool) error {
+		return strategyTest.svr.Initialize(&auth.Config{
+			StrictAudit: strict,
+			Strategy: &auth.StrategyConfig{
+				Name: auth.DefaultPolicyPluginName,
+			},
+		}, strategyTest.storage, strategyTest.cacheMgn, strategyTest.userSvr)
+	}
+
+	// 历史记录插件全部加载失败时组合插件中没有任何插件
+	prev := plugin.TestInjectHistory(plugin.NewCompositeHistory(nil))
+	defer plugin.TestInjectHistory(prev)
+
+	t.Run("未开启严格审计时仅告警", func(t *testing.T) {
+		assert.NoError(t, initialize(false))
+	})
+
+	t.Run("开启严格审计时启动失败", func(t *testing.T) {
+		assert.ErrorIs(t, initialize(true), auth.ErrorHistoryAbsent)
+	})
+
+	t.Run("存在可用的历史记录插件", func(t *testing.T) {
+		history := plugin.NewCompositeHistory(nil)
+		history.AddSink(&auditHistory{}, 1)
+		defer history.Destroy()
+		plugin.TestInjectHistory(history)
+		assert.NoError(t, initialize(true))
+	})
+}
@@ -0,0 +1,110 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package policy
+
+import (
+	"context"
+	"fmt"
+
+	apisecurity "github.com/polarismesh/specification/source/go/api/v1/security"
+	"go.uber.org/zap"
+
+	"github.com/polarismesh/polaris/auth"
+	"github.com/polarismesh/polaris/auth/evaluator"
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/common/utils"
+)
+
+// handleSimulateStrategy 模拟 principal 对单个资源的鉴权，不要求开启鉴权，也不记录资源关联关系的使用情况
+func (svr *Server) handleSimulateStrategy(ctx context.Context,
+	req *auth.SimulateRequest) (*auth.StrategySimulation, error) {
+	switch req.Resource.Type {
+	case apisecurity.ResourceType_Namespaces, apisecurity.ResourceType_Services,
+		apisecurity.ResourceType_ConfigGroups:
+	default:
+		return nil, fmt.Errorf("%w: unsupported resource type %s", ErrorInvalidParameter, req.Resource.Type)
+	}
+	switch req.Operation {
+	case model.Read, model.Create, model.Modify, model.Delete:
+	default:
+		return nil, fmt.Errorf("%w: unsupported operation %d", ErrorInvalidParameter, req.Operation)
+	}
+	disabled, err := svr.capabilityPrincipalDisable(req.Principal)
+	if err != nil {
+		log.Error("[Auth][Simulate] principal not found", utils.RequestID(ctx),
+			zap.String("principal", req.Principal.PrincipalID), zap.Error(err))
+		return nil, err
+	}
+	if disabled {
+		return &auth.StrategySimulation{Reason: auth.SimulateReasonTokenDisabled}, nil
+	}
+
+	result := svr.checker.simulate(evaluator.Request{
+		Principal:    req.Principal,
+		Operation:    req.Operation,
+		Method:       req.Method,
+		ResourceType: req.Resource.Type,
+		ResourceID:   req.Resource.ID,
+		RequestAttrs: req.Attributes,
+	})
+	if result.StrategyID != "" {
+		if rule := svr.cacheMgr.AuthStrategy().GetStrategy(result.StrategyID); rule != nil {
+			result.StrategyName = rule.Name
+		}
+	}
+	return result, nil
+}
+
+// simulate 按照 doCheckPermission 以及 checkAction 的顺序判断 principal 对单个资源的权限，并给出做出决策的鉴权策略
+// 资源关联关系过期后的宽限期只是临时放通，不参与模拟
+func (d *DefaultAuthChecker) simulate(req evaluator.Request) *auth.StrategySimulation {
+	principal, resType, resID := req.Principal, req.ResourceType, req.ResourceID
+	if req.Operation != model.Read && d.isReadOnlyPrincipal(principal) {
+		return &auth.StrategySimulation{Reason: auth.SimulateReason(evaluator.ReasonReadOnly)}
+	}
+	if d.isPinnedAllow(principal, resType, resID) {
+		return &auth.StrategySimulation{Allowed: true, Reason: auth.SimulateReasonPin}
+	}
+	nsAdmin := d.isNamespaceAdminOf(principal, resType, resID)
+	if !nsAdmin && d.matchNoStrategy(principal, resType, resID) {
+		return &auth.StrategySimulation{Reason: auth.SimulateReasonDenyByDefault}
+	}
+
+	engine := d.engine()
+	strategies := d.principalStrategies(principal)
+	excluded := d.unmatchedFunctionStrategies(principal, req.Method,
+		d.unmatchedConditionStrategies(principal, req.RequestAttrs))
+	if decision, denied := engine.MatchDeny(req, strategies, excluded); denied {
+		return simulation(decision)
+	}
+	if req.Operation == model.Read {
+		return &auth.StrategySimulation{Allowed: true, Reason: auth.SimulateReason(evaluator.ReasonRead)}
+	}
+	if nsAdmin {
+		return &auth.StrategySimulation{Allowed: true, Reason: auth.SimulateReasonNamespaceAdmin}
+	}
+	return simulation(engine.Decide(req, strategies, excluded))
+}
+
+func simulation(decision evaluator.Decision) *auth.StrategySimulation {
+	return &auth.StrategySimulation{
+		Allowed:    decision.Allowed,
+		Reason:     auth.SimulateReason(decision.Reason),
+		StrategyID: decision.StrategyID,
+	}
+}
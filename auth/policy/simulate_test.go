@@ -0,0 +1,135 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package policy_test
+
+import (
+	"context"
+	"testing"
+
+	apisecurity "github.com/polarismesh/specification/source/go/api/v1/security"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/polarismesh/polaris/auth"
+	"github.com/polarismesh/polaris/auth/evaluator"
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/common/utils"
+)
+
+func Test_SimulateStrategy(t *testing.T) {
+	strategyTest := newStrategyTest(t)
+	defer strategyTest.Clean()
+
+	_ = strategyTest.cacheMgn.TestUpdate()
+
+	users := strategyTest.users[:4]
+	resources := make([]auth.CapabilityResource, 0, 4)
+	owners := make([]string, 0, 4)
+	for _, svc := range strategyTest.services[:4] {
+		resources = append(resources, auth.CapabilityResource{Type: apisecurity.ResourceType_Services, ID: svc.ID})
+		owners = append(owners, svc.Owner)
+	}
+
+	t.Run("模拟结果与实际鉴权结果一致", func(t *testing.T) {
+		checker := strategyTest.policySvr.GetAuthChecker()
+		allowCount, denyCount := 0, 0
+		for _, user := range users {
+			principal := model.Principal{PrincipalID: user.ID, PrincipalRole: model.PrincipalUser}
+			for r := range resources {
+				for _, op := range []model.ResourceOperation{model.Read, model.Modify} {
+					ret, err := strategyTest.policySvr.SimulateStrategy(context.Background(), &auth.SimulateRequest{
+						Principal: principal,
+						Resource:  resources[r],
+						Operation: op,
+						Method:    "Test_SimulateStrategy",
+					})
+					if !assert.NoError(t, err) {
+						return
+					}
+					ctx := context.WithValue(context.Background(), utils.ContextAuthTokenKey, user.Token)
+					expect, _ := checker.CheckConsolePermission(model.NewAcquireContext(
+						model.WithRequestContext(ctx),
+						model.WithMethod("Test_SimulateStrategy"),
+						model.WithOperation(op),
+						model.WithModule(model.DiscoverModule),
+						model.WithAccessResources(map[apisecurity.ResourceType][]model.ResourceEntry{
+							resources[r].Type: {{ID: resources[r].ID, Owner: owners[r]}},
+						}),
+					))
+					assert.Equal(t, expect, ret.Allowed, "user %s resource %s operation %d reason %s",
+						user.ID, resources[r].ID, op, ret.Reason)
+					if op == model.Read {
+						continue
+					}
+					if ret.Allowed {
+						allowCount++
+						// 通过直接关联资源的策略授予的写权限需要给出对应的策略
+						if ret.Reason == auth.SimulateReason(evaluator.ReasonLink) {
+							assert.NotEmpty(t, ret.StrategyID)
+							assert.NotEmpty(t, ret.StrategyName)
+						}
+					} else {
+						denyCount++
+					}
+				}
+			}
+		}
+		// 确保同时存在放通与拒绝的写操作
+		assert.True(t, allowCount > 0)
+		assert.True(t, denyCount > 0)
+	})
+
+	t.Run("不存在的 principal", func(t *testing.T) {
+		_, err := strategyTest.policySvr.SimulateStrategy(context.Background(), &auth.SimulateRequest{
+			Principal: model.Principal{PrincipalID: "not-exist-user", PrincipalRole: model.PrincipalUser},
+			Resource:  resources[0],
+			Operation: model.Modify,
+		})
+		assert.Error(t, err)
+	})
+
+	t.Run("不支持的资源类型以及操作类型", func(t *testing.T) {
+		principal := model.Principal{PrincipalID: users[1].ID, PrincipalRole: model.PrincipalUser}
+		_, err := strategyTest.policySvr.SimulateStrategy(context.Background(), &auth.SimulateRequest{
+			Principal: principal,
+			Resource:  auth.CapabilityResource{Type: apisecurity.ResourceType(100), ID: "1"},
+			Operation: model.Modify,
+		})
+		assert.Error(t, err)
+		_, err = strategyTest.policySvr.SimulateStrategy(context.Background(), &auth.SimulateRequest{
+			Principal: principal,
+			Resource:  resources[0],
+			Operation: model.ResourceOperation(1),
+		})
+		assert.Error(t, err)
+	})
+
+	t.Run("主账户可以模拟，子账户不允许模拟", func(t *testing.T) {
+		req := &auth.SimulateRequest{
+			Principal: model.Principal{PrincipalID: users[1].ID, PrincipalRole: model.PrincipalUser},
+			Resource:  resources[1],
+			Operation: model.Modify,
+		}
+		ownerCtx := context.WithValue(context.Background(), utils.ContextAuthTokenKey, users[0].Token)
+		_, err := strategyTest.svr.SimulateStrategy(ownerCtx, req)
+		assert.NoError(t, err)
+
+		subCtx := context.WithValue(context.Background(), utils.ContextAuthTokenKey, users[1].Token)
+		_, err = strategyTest.svr.SimulateStrategy(subCtx, req)
+		assert.Error(t, err)
+	})
+}
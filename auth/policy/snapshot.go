@@ -0,0 +1,212 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package policy
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"time"
+
+	apisecurity "github.com/polarismesh/specification/source/go/api/v1/security"
+	"go.uber.org/zap"
+
+	"github.com/polarismesh/polaris/auth"
+	"github.com/polarismesh/polaris/auth/evaluator"
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/common/utils"
+)
+
+// handleExportAuthModel 在同一个读事务中加载用户、用户组、鉴权策略，构建鉴权模型快照
+func (svr *Server) handleExportAuthModel(ctx context.Context) (*auth.AuthModelSnapshot, error) {
+	tx, err := svr.storage.StartReadTx()
+	if err != nil {
+		if tx != nil {
+			_ = tx.Rollback()
+		}
+		log.Error("[Auth][Snapshot] begin storage read tx", utils.RequestID(ctx), zap.Error(err))
+		return nil, err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	if err := tx.CreateReadView(); err != nil {
+		log.Error("[Auth][Snapshot] create storage snapshot read view", utils.RequestID(ctx), zap.Error(err))
+		return nil, err
+	}
+
+	users, err := svr.storage.GetAllUsersTx(tx)
+	if err != nil {
+		log.Error("[Auth][Snapshot] load users", utils.RequestID(ctx), zap.Error(err))
+		return nil, err
+	}
+	groups, err := svr.storage.GetAllGroupsTx(tx)
+	if err != nil {
+		log.Error("[Auth][Snapshot] load user groups", utils.RequestID(ctx), zap.Error(err))
+		return nil, err
+	}
+	strategies, err := svr.storage.GetAllStrategyDetailsTx(tx)
+	if err != nil {
+		log.Error("[Auth][Snapshot] load strategies", utils.RequestID(ctx), zap.Error(err))
+		return nil, err
+	}
+
+	snapshot := buildAuthModelSnapshot(users, groups, strategies)
+	log.Info("[Auth][Snapshot] export auth model", utils.RequestID(ctx),
+		zap.String("revision", snapshot.Revision), zap.Int("principals", len(snapshot.Principals)),
+		zap.Int("strategies", len(snapshot.Strategies)))
+	return snapshot, nil
+}
+
+// buildAuthModelSnapshot 将存储模型转换为快照模型，输出的所有列表均按照 ID 排序，便于外部工具进行 diff
+func buildAuthModelSnapshot(users []*model.User, groups []*model.UserGroupDetail,
+	strategies []*model.StrategyDetail) *auth.AuthModelSnapshot {
+	snapshot := &auth.AuthModelSnapshot{
+		Revision:      utils.NewUUID(),
+		CreateTime:    time.Now(),
+		Principals:    make([]auth.SnapshotPrincipal, 0, len(users)+len(groups)),
+		Memberships:   make([]auth.SnapshotMembership, 0, len(groups)),
+		Strategies:    make([]auth.SnapshotStrategy, 0, len(strategies)),
+		ResourceLinks: make([]auth.SnapshotResourceLink, 0, len(strategies)),
+	}
+
+	for i := range users {
+		user := users[i]
+		snapshot.Principals = append(snapshot.Principals, auth.SnapshotPrincipal{
+			ID:          user.ID,
+			Name:        user.Name,
+			Type:        model.PrincipalNames[model.PrincipalUser],
+			Owner:       user.Owner,
+			Role:        model.UserRoleNames[user.Type],
+			TokenEnable: user.TokenEnable,
+		})
+	}
+	for i := range groups {
+		group := groups[i]
+		snapshot.Principals = append(snapshot.Principals, auth.SnapshotPrincipal{
+			ID:          group.ID,
+			Name:        group.Name,
+			Type:        model.PrincipalNames[model.PrincipalGroup],
+			Owner:       group.Owner,
+			TokenEnable: group.TokenEnable,
+		})
+		for uid := range group.UserIds {
+			snapshot.Memberships = append(snapshot.Memberships, auth.SnapshotMembership{
+				GroupID: group.ID,
+				UserID:  uid,
+			})
+		}
+	}
+
+	for i := range strategies {
+		strategy := strategies[i]
+		principals := make([]auth.SnapshotPrincipalRef, 0, len(strategy.Principals))
+		for j := range strategy.Principals {
+			principals = append(principals, auth.SnapshotPrincipalRef{
+				ID:   strategy.Principals[j].PrincipalID,
+				Type: model.PrincipalNames[strategy.Principals[j].PrincipalRole],
+			})
+		}
+		sort.Slice(principals, func(i, j int) bool {
+			if principals[i].Type != principals[j].Type {
+				return principals[i].Type > principals[j].Type
+			}
+			return principals[i].ID < principals[j].ID
+		})
+		snapshot.Strategies = append(snapshot.Strategies, auth.SnapshotStrategy{
+			ID:         strategy.ID,
+			Name:       strategy.Name,
+			Owner:      strategy.Owner,
+			Action:     strategy.Action,
+			Default:    strategy.Default,
+			Comment:    strategy.Comment,
+			Revision:   strategy.Revision,
+			Principals: principals,
+		})
+		for j := range strategy.Resources {
+			res := strategy.Resources[j]
+			snapshot.ResourceLinks = append(snapshot.ResourceLinks, auth.SnapshotResourceLink{
+				StrategyID:   strategy.ID,
+				ResourceType: apisecurity.ResourceType(res.ResType).String(),
+				ResourceID:   res.ResID,
+			})
+		}
+	}
+
+	sort.Slice(snapshot.Principals, func(i, j int) bool {
+		if snapshot.Principals[i].Type != snapshot.Principals[j].Type {
+			return snapshot.Principals[i].Type > snapshot.Principals[j].Type
+		}
+		return snapshot.Principals[i].ID < snapshot.Principals[j].ID
+	})
+	sort.Slice(snapshot.Memberships, func(i, j int) bool {
+		if snapshot.Memberships[i].GroupID != snapshot.Memberships[j].GroupID {
+			return snapshot.Memberships[i].GroupID < snapshot.Memberships[j].GroupID
+		}
+		return snapshot.Memberships[i].UserID < snapshot.Memberships[j].UserID
+	})
+	sort.Slice(snapshot.Strategies, func(i, j int) bool {
+		return snapshot.Strategies[i].ID < snapshot.Strategies[j].ID
+	})
+	sort.Slice(snapshot.ResourceLinks, func(i, j int) bool {
+		a, b := snapshot.ResourceLinks[i], snapshot.ResourceLinks[j]
+		if a.StrategyID != b.StrategyID {
+			return a.StrategyID < b.StrategyID
+		}
+		if a.ResourceType != b.ResourceType {
+			return a.ResourceType < b.ResourceType
+		}
+		return a.ResourceID < b.ResourceID
+	})
+	return snapshot
+}
+
+// handleExportPolicySnapshot 基于策略缓存导出推送给 sidecar 的策略快照，与服务端鉴权使用同一份数据,
+// 快照的 revision 为策略集合的版本，版本没有变化时 sidecar 无需重新加载
+func (svr *Server) handleExportPolicySnapshot(ctx context.Context) (*evaluator.PolicySnapshot, error) {
+	strategyCache := svr.cacheMgr.AuthStrategy()
+	userCache := svr.cacheMgr.User()
+	revision := strconv.FormatUint(strategyCache.Version(), 10)
+
+	strategies := make([]*model.StrategyDetail, 0, 64)
+	members := map[string][]string{}
+	strategyCache.IteratorStrategies(func(rule *model.StrategyDetail) bool {
+		strategies = append(strategies, rule)
+		for _, p := range rule.Principals {
+			if p.PrincipalRole != model.PrincipalGroup {
+				continue
+			}
+			if _, ok := members[p.PrincipalID]; ok {
+				continue
+			}
+			members[p.PrincipalID] = nil
+			if group := userCache.GetGroup(p.PrincipalID); group != nil {
+				for uid := range group.UserIds {
+					members[p.PrincipalID] = append(members[p.PrincipalID], uid)
+				}
+			}
+		}
+		return true
+	})
+
+	snapshot := evaluator.BuildSnapshot(revision, strategies, members, svr.options.ResourceIDCanonical)
+	log.Info("[Auth][Snapshot] export policy snapshot", utils.RequestID(ctx),
+		zap.String("revision", snapshot.Revision), zap.Int("strategies", len(snapshot.Strategies)))
+	return snapshot, nil
+}
@@ -0,0 +1,205 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package policy_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	apisecurity "github.com/polarismesh/specification/source/go/api/v1/security"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/polarismesh/polaris/auth/evaluator"
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/common/utils"
+	"github.com/polarismesh/polaris/store"
+)
+
+func Test_ExportAuthModel(t *testing.T) {
+	strategyTest := newStrategyTest(t)
+	defer strategyTest.Clean()
+
+	t.Run("导出完整的鉴权模型快照", func(t *testing.T) {
+		txs := make([]store.Tx, 0, 3)
+		strategyTest.storage.EXPECT().GetAllUsersTx(gomock.Any()).DoAndReturn(
+			func(tx store.Tx) ([]*model.User, error) {
+				txs = append(txs, tx)
+				return strategyTest.users, nil
+			})
+		strategyTest.storage.EXPECT().GetAllGroupsTx(gomock.Any()).DoAndReturn(
+			func(tx store.Tx) ([]*model.UserGroupDetail, error) {
+				txs = append(txs, tx)
+				return strategyTest.groups, nil
+			})
+		strategyTest.storage.EXPECT().GetAllStrategyDetailsTx(gomock.Any()).DoAndReturn(
+			func(tx store.Tx) ([]*model.StrategyDetail, error) {
+				txs = append(txs, tx)
+				return strategyTest.allStrategies, nil
+			})
+
+		snapshot, err := strategyTest.policySvr.ExportAuthModel(context.Background())
+		assert.NoError(t, err)
+		assert.NotEmpty(t, snapshot.Revision)
+
+		// 所有的数据读取必须处于同一个读事务中
+		assert.Equal(t, 3, len(txs))
+		assert.Same(t, txs[0], txs[1])
+		assert.Same(t, txs[1], txs[2])
+
+		// 完整性检查
+		assert.Equal(t, len(strategyTest.users)+len(strategyTest.groups), len(snapshot.Principals))
+		assert.Equal(t, len(strategyTest.allStrategies), len(snapshot.Strategies))
+		expectMembers, expectLinks := 0, 0
+		for i := range strategyTest.groups {
+			expectMembers += len(strategyTest.groups[i].UserIds)
+		}
+		for i := range strategyTest.allStrategies {
+			expectLinks += len(strategyTest.allStrategies[i].Resources)
+		}
+		assert.Equal(t, expectMembers, len(snapshot.Memberships))
+		assert.Equal(t, expectLinks, len(snapshot.ResourceLinks))
+
+		// 一致性检查，快照内的引用关系都必须能在快照内找到
+		principals := map[string]string{}
+		for _, p := range snapshot.Principals {
+			principals[p.ID] = p.Type
+		}
+		for _, m := range snapshot.Memberships {
+			assert.Equal(t, "group", principals[m.GroupID])
+			assert.Equal(t, "user", principals[m.UserID])
+		}
+		strategyIds := map[string]struct{}{}
+		for _, s := range snapshot.Strategies {
+			strategyIds[s.ID] = struct{}{}
+			for _, ref := range s.Principals {
+				assert.Equal(t, ref.Type, principals[ref.ID])
+			}
+		}
+		for _, link := range snapshot.ResourceLinks {
+			_, ok := strategyIds[link.StrategyID]
+			assert.True(t, ok, link.StrategyID)
+		}
+
+		// 快照中不能包含敏感数据
+		data, err := json.Marshal(snapshot)
+		assert.NoError(t, err)
+		for _, user := range strategyTest.users {
+			assert.False(t, strings.Contains(string(data), user.Token))
+			assert.False(t, strings.Contains(string(data), user.Password))
+		}
+	})
+
+	t.Run("读取失败时不返回快照", func(t *testing.T) {
+		strategyTest.storage.EXPECT().GetAllUsersTx(gomock.Any()).Return(strategyTest.users, nil)
+		strategyTest.storage.EXPECT().GetAllGroupsTx(gomock.Any()).Return(nil, errors.New("mock store error"))
+
+		snapshot, err := strategyTest.policySvr.ExportAuthModel(context.Background())
+		assert.Error(t, err)
+		assert.Nil(t, snapshot)
+	})
+
+	t.Run("非超级管理员不允许导出", func(t *testing.T) {
+		valCtx := context.WithValue(context.Background(), utils.ContextAuthTokenKey, strategyTest.users[0].Token)
+		snapshot, err := strategyTest.svr.ExportAuthModel(valCtx)
+		assert.Error(t, err)
+		assert.Nil(t, snapshot)
+	})
+}
+
+func Test_ExportPolicySnapshot(t *testing.T) {
+	strategyTest := newStrategyTest(t)
+	defer strategyTest.Clean()
+	_ = strategyTest.cacheMgn.TestUpdate()
+
+	snapshot, err := strategyTest.policySvr.ExportPolicySnapshot(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, evaluator.SnapshotFormatVersion, snapshot.FormatVersion)
+	assert.NotEmpty(t, snapshot.Revision)
+
+	// sidecar 收到的是序列化后的快照
+	data, err := json.Marshal(snapshot)
+	assert.NoError(t, err)
+	pushed := &evaluator.PolicySnapshot{}
+	assert.NoError(t, json.Unmarshal(data, pushed))
+	engine, err := evaluator.NewSnapshotEvaluator(pushed)
+	assert.NoError(t, err)
+
+	type principal struct {
+		token string
+		model.Principal
+	}
+	principals := make([]principal, 0, len(strategyTest.users)+len(strategyTest.groups))
+	for _, user := range strategyTest.users {
+		principals = append(principals, principal{token: user.Token,
+			Principal: model.Principal{PrincipalID: user.ID, PrincipalRole: model.PrincipalUser}})
+	}
+	for _, group := range strategyTest.groups {
+		principals = append(principals, principal{token: group.Token,
+			Principal: model.Principal{PrincipalID: group.ID, PrincipalRole: model.PrincipalGroup}})
+	}
+	// 包含关联了策略以及没有关联任何策略的资源
+	resources := make([]model.ResourceEntry, 0, len(strategyTest.services))
+	resTypes := make([]apisecurity.ResourceType, 0, len(strategyTest.services))
+	for _, svc := range strategyTest.services {
+		resources = append(resources, model.ResourceEntry{ID: svc.ID, Owner: svc.Owner},
+			model.ResourceEntry{ID: svc.Namespace, Owner: svc.Owner})
+		resTypes = append(resTypes, apisecurity.ResourceType_Services, apisecurity.ResourceType_Namespaces)
+	}
+
+	allowed := 0
+	for _, p := range principals {
+		for i := range resources {
+			ctx := context.WithValue(context.Background(), utils.ContextAuthTokenKey, p.token)
+			authCtx := model.NewAcquireContext(
+				model.WithRequestContext(ctx),
+				model.WithMethod("Test_ExportPolicySnapshot"),
+				model.WithOperation(model.Create),
+				model.WithModule(model.CoreModule),
+				model.WithAccessResources(map[apisecurity.ResourceType][]model.ResourceEntry{
+					resTypes[i]: {resources[i]},
+				}),
+			)
+			serverAllowed, _ := strategyTest.checker.CheckConsolePermission(authCtx)
+			decision := engine.Evaluate(evaluator.Request{
+				Principal:    p.Principal,
+				Operation:    model.Create,
+				ResourceType: resTypes[i],
+				ResourceID:   resources[i].ID,
+			})
+			assert.Equal(t, serverAllowed, decision.Allowed, "principal %s resource %s reason %s",
+				p.PrincipalID, resources[i].ID, decision.Reason)
+			if decision.Allowed {
+				allowed++
+			}
+		}
+	}
+	// 决策中同时存在放通以及拒绝
+	assert.NotZero(t, allowed)
+	assert.NotEqual(t, len(principals)*len(resources), allowed)
+
+	t.Run("非超级管理员不允许导出", func(t *testing.T) {
+		valCtx := context.WithValue(context.Background(), utils.ContextAuthTokenKey, strategyTest.users[0].Token)
+		snapshot, err := strategyTest.svr.ExportPolicySnapshot(valCtx)
+		assert.Error(t, err)
+		assert.Nil(t, snapshot)
+	})
+}
@@ -0,0 +1,246 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package policy
+
+import (
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	apisecurity "github.com/polarismesh/specification/source/go/api/v1/security"
+	"go.uber.org/zap"
+
+	cachetypes "github.com/polarismesh/polaris/cache/api"
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/common/utils"
+	"github.com/polarismesh/polaris/store"
+)
+
+const (
+	// directStorePageSize 降级模式下分页查询鉴权策略的单页大小
+	directStorePageSize = 100
+)
+
+// degradedCacheManager 鉴权策略缓存开启失败时，将鉴权策略的查询切换为直接查询存储
+type degradedCacheManager struct {
+	cachetypes.CacheManager
+	strategy *storeStrategyCache
+}
+
+func newDegradedCacheManager(cacheMgr cachetypes.CacheManager, storage store.Store) *degradedCacheManager {
+	return &degradedCacheManager{
+		CacheManager: cacheMgr,
+		strategy: &storeStrategyCache{
+			StrategyCache: cacheMgr.AuthStrategy(),
+			storage:       storage,
+			userCache:     cacheMgr.User(),
+		},
+	}
+}
+
+// AuthStrategy 返回直接查询存储的鉴权策略视图
+func (m *degradedCacheManager) AuthStrategy() cachetypes.StrategyCache {
+	return m.strategy
+}
+
+// storeStrategyCache 每次查询都直接访问存储的鉴权策略视图，查询失败时按照无权限处理
+type storeStrategyCache struct {
+	cachetypes.StrategyCache
+	storage   store.Store
+	userCache cachetypes.UserCache
+	version   uint64
+}
+
+// ForceSync 数据直接来自存储，无需同步
+func (s *storeStrategyCache) ForceSync() error {
+	return nil
+}
+
+// Version 无法感知存储中策略的变化，每次返回新的版本，使得鉴权决策不会被缓存
+func (s *storeStrategyCache) Version() uint64 {
+	return atomic.AddUint64(&s.version, 1)
+}
+
+// IteratorStrategies 遍历存储中的鉴权策略，查询失败时不返回任何策略
+func (s *storeStrategyCache) IteratorStrategies(iterProc cachetypes.StrategyIterProc) {
+	rules, err := s.listAll(map[string]string{})
+	if err != nil {
+		log.Error("[Auth][Strategy] direct store list strategies", zap.Error(err))
+		return
+	}
+	for i := range rules {
+		if !iterProc(rules[i]) {
+			return
+		}
+	}
+}
+
+// GetStrategyDetailsByUID 查询用户关联的鉴权策略
+func (s *storeStrategyCache) GetStrategyDetailsByUID(uid string) []*model.StrategyDetail {
+	return s.listByPrincipal(uid, model.PrincipalUser)
+}
+
+// GetStrategyDetailsByGroupID 查询用户组关联的鉴权策略
+func (s *storeStrategyCache) GetStrategyDetailsByGroupID(groupId string) []*model.StrategyDetail {
+	return s.listByPrincipal(groupId, model.PrincipalGroup)
+}
+
+// GetStrategy 查询鉴权策略，查询失败时不返回策略
+func (s *storeStrategyCache) GetStrategy(id string) *model.StrategyDetail {
+	rule, err := s.storage.GetStrategyDetail(id)
+	if err != nil {
+		log.Error("[Auth][Strategy] direct store get strategy", zap.String("id", id), zap.Error(err))
+		return nil
+	}
+	return rule
+}
+
+func (s *storeStrategyCache) listByPrincipal(id string, role model.PrincipalType) []*model.StrategyDetail {
+	ret, err := s.listAll(map[string]string{
+		"principal_id":   id,
+		"principal_type": strconv.Itoa(int(role)),
+	})
+	if err != nil {
+		log.Error("[Auth][Strategy] direct store list principal strategies", zap.String("principal", id), zap.Error(err))
+		return nil
+	}
+	return ret
+}
+
+// IsResourceLinkStrategy 查询资源是否关联了鉴权策略，查询失败时视为已关联
+func (s *storeStrategyCache) IsResourceLinkStrategy(resType apisecurity.ResourceType, resId string) bool {
+	total, _, err := s.storage.GetStrategies(map[string]string{
+		"res_type": strconv.Itoa(int(resType)),
+		"res_id":   resId,
+	}, 0, 1)
+	if err != nil {
+		log.Error("[Auth][Strategy] direct store check resource link", zap.String("resource", resId), zap.Error(err))
+		return true
+	}
+	return total > 0
+}
+
+// GetLabelSelectorStrategies 查询通过标签选择器关联了该类型资源的鉴权策略，查询失败时不返回任何策略
+func (s *storeStrategyCache) GetLabelSelectorStrategies(resType apisecurity.ResourceType) []*model.StrategyDetail {
+	rules, err := s.listAll(map[string]string{})
+	if err != nil {
+		log.Error("[Auth][Strategy] direct store list label selector strategies", zap.Error(err))
+		return nil
+	}
+	ret := make([]*model.StrategyDetail, 0, 4)
+	for _, rule := range rules {
+		if rule.IsReadOnly() || rule.IsDeny() {
+			continue
+		}
+		for _, res := range rule.Resources {
+			if res.ResType == int32(resType) && res.IsLabelSelector() {
+				ret = append(ret, rule)
+				break
+			}
+		}
+	}
+	return ret
+}
+
+// IsResourceEditable 与缓存的判断逻辑保持一致，资源没有关联策略时任何人都可以编辑
+func (s *storeStrategyCache) IsResourceEditable(principal model.Principal,
+	resType apisecurity.ResourceType, resId string) bool {
+	rules, err := s.listByResource(resType, resId)
+	if err != nil {
+		log.Error("[Auth][Strategy] direct store check resource editable", zap.String("resource", resId), zap.Error(err))
+		return false
+	}
+	if len(rules) == 0 {
+		return true
+	}
+	allRules, err := s.listByResource(resType, utils.MatchAll)
+	if err != nil {
+		log.Error("[Auth][Strategy] direct store check resource editable", zap.String("resource", resId), zap.Error(err))
+		return false
+	}
+
+	principals := []model.Principal{principal}
+	if principal.PrincipalRole == model.PrincipalUser {
+		groupIds := s.userCache.GetUserLinkGroupIds(principal.PrincipalID)
+		for i := range groupIds {
+			principals = append(principals, model.Principal{
+				PrincipalID:   groupIds[i],
+				PrincipalRole: model.PrincipalGroup,
+			})
+		}
+	}
+	now := time.Now()
+	for i := range principals {
+		if hasGrant(allRules, principals[i], resType, utils.MatchAll, now) ||
+			hasGrant(rules, principals[i], resType, resId, now) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *storeStrategyCache) listByResource(resType apisecurity.ResourceType,
+	resId string) ([]*model.StrategyDetail, error) {
+	return s.listAll(map[string]string{
+		"res_type": strconv.Itoa(int(resType)),
+		"res_id":   resId,
+	})
+}
+
+// listAll 分页查询全部满足条件的鉴权策略详情
+func (s *storeStrategyCache) listAll(filters map[string]string) ([]*model.StrategyDetail, error) {
+	ret := make([]*model.StrategyDetail, 0, 4)
+	for offset := uint32(0); ; offset += directStorePageSize {
+		// 存储层会修改过滤条件，每次查询需要重新构建
+		query := map[string]string{"show_detail": "true"}
+		for k, v := range filters {
+			query[k] = v
+		}
+		total, rules, err := s.storage.GetStrategies(query, offset, directStorePageSize)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, rules...)
+		if len(rules) < directStorePageSize || uint32(len(ret)) >= total {
+			return ret, nil
+		}
+	}
+}
+
+// hasGrant 策略中的 principal 是否被授予了该资源未过期的权限
+func hasGrant(rules []*model.StrategyDetail, principal model.Principal,
+	resType apisecurity.ResourceType, resId string, now time.Time) bool {
+	for _, rule := range rules {
+		linked := false
+		for _, res := range rule.Resources {
+			if res.ResType == int32(resType) && res.ResID == resId && !res.IsExpired(now) {
+				linked = true
+				break
+			}
+		}
+		if !linked {
+			continue
+		}
+		for _, item := range rule.Principals {
+			if item.PrincipalID == principal.PrincipalID && item.PrincipalRole == principal.PrincipalRole {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,130 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package policy
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	apisecurity "github.com/polarismesh/specification/source/go/api/v1/security"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/polarismesh/polaris/auth"
+	cachemock "github.com/polarismesh/polaris/cache/mock"
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/common/utils"
+	storemock "github.com/polarismesh/polaris/store/mock"
+)
+
+func Test_InitializeOpenStrategyCacheFail(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	storage := storemock.NewMockStore(ctrl)
+	userCache := cachemock.NewMockUserCache(ctrl)
+	strategyCache := cachemock.NewMockStrategyCache(ctrl)
+	cacheMgr := cachemock.NewMockCacheManager(ctrl)
+	cacheMgr.EXPECT().OpenResourceCache(gomock.Any()).Return(errors.New("mock open cache fail")).AnyTimes()
+	cacheMgr.EXPECT().User().Return(userCache).AnyTimes()
+	cacheMgr.EXPECT().AuthStrategy().Return(strategyCache).AnyTimes()
+
+	newConfig := func(degrade bool) *auth.Config {
+		return &auth.Config{
+			Strategy: &auth.StrategyConfig{
+				Name: auth.DefaultPolicyPluginName,
+				Option: map[string]interface{}{
+					"strategyCacheDegrade": degrade,
+				},
+			},
+		}
+	}
+
+	t.Run("默认启动失败", func(t *testing.T) {
+		svr := &Server{}
+		err := svr.Initialize(newConfig(false), storage, cacheMgr, nil)
+		assert.Error(t, err)
+	})
+
+	t.Run("开启降级后直接查询存储", func(t *testing.T) {
+		svr := &Server{}
+		err := svr.Initialize(newConfig(true), storage, cacheMgr, nil)
+		assert.NoError(t, err)
+
+		_, ok := svr.cacheMgr.AuthStrategy().(*storeStrategyCache)
+		assert.True(t, ok)
+		_, ok = svr.checker.cacheMgr.AuthStrategy().(*storeStrategyCache)
+		assert.True(t, ok)
+	})
+}
+
+func Test_storeStrategyCache_IsResourceEditable(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	storage := storemock.NewMockStore(ctrl)
+	userCache := cachemock.NewMockUserCache(ctrl)
+	sc := &storeStrategyCache{storage: storage, userCache: userCache}
+
+	user := model.Principal{PrincipalID: "user-1", PrincipalRole: model.PrincipalUser}
+	group := model.Principal{PrincipalID: "group-1", PrincipalRole: model.PrincipalGroup}
+	resType := int32(apisecurity.ResourceType_Services)
+	mockRules := func(resId string, rules ...*model.StrategyDetail) {
+		storage.EXPECT().GetStrategies(gomock.Any(), uint32(0), uint32(directStorePageSize)).
+			DoAndReturn(func(filters map[string]string, _, _ uint32) (uint32, []*model.StrategyDetail, error) {
+				assert.Equal(t, resId, filters["res_id"])
+				assert.Equal(t, "true", filters["show_detail"])
+				return uint32(len(rules)), rules, nil
+			})
+	}
+
+	t.Run("资源没有关联策略", func(t *testing.T) {
+		mockRules("service-1")
+		assert.True(t, sc.IsResourceEditable(user, apisecurity.ResourceType_Services, "service-1"))
+	})
+
+	t.Run("通过用户组获得授权", func(t *testing.T) {
+		mockRules("service-1", &model.StrategyDetail{
+			ID:         "rule-1",
+			Principals: []model.Principal{group},
+			Resources:  []model.StrategyResource{{StrategyID: "rule-1", ResType: resType, ResID: "service-1"}},
+		})
+		mockRules(utils.MatchAll)
+		userCache.EXPECT().GetUserLinkGroupIds("user-1").Return([]string{"group-1"})
+		assert.True(t, sc.IsResourceEditable(user, apisecurity.ResourceType_Services, "service-1"))
+	})
+
+	t.Run("资源关联已过期", func(t *testing.T) {
+		mockRules("service-1", &model.StrategyDetail{
+			ID:         "rule-1",
+			Principals: []model.Principal{user},
+			Resources: []model.StrategyResource{{StrategyID: "rule-1", ResType: resType, ResID: "service-1",
+				ExpireTime: time.Now().Add(-time.Minute)}},
+		})
+		mockRules(utils.MatchAll)
+		userCache.EXPECT().GetUserLinkGroupIds("user-1").Return(nil)
+		assert.False(t, sc.IsResourceEditable(user, apisecurity.ResourceType_Services, "service-1"))
+	})
+
+	t.Run("查询存储失败时拒绝", func(t *testing.T) {
+		storage.EXPECT().GetStrategies(gomock.Any(), gomock.Any(), gomock.Any()).
+			Return(uint32(0), nil, errors.New("mock store fail"))
+		assert.False(t, sc.IsResourceEditable(user, apisecurity.ResourceType_Services, "service-1"))
+	})
+}
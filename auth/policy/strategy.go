@@ -62,9 +62,41 @@ var (
 	}
 )
 
+// handleCreateStrategies 批量创建鉴权策略，逐个创建并收集每个策略的结果
+func (svr *Server) handleCreateStrategies(
+	ctx context.Context, reqs []*apisecurity.AuthStrategy) *apiservice.BatchWriteResponse {
+	if resp := svr.checkStrategyBatch(len(reqs)); resp != nil {
+		return resp
+	}
+	resp := api.NewAuthBatchWriteResponse(apimodel.Code_ExecuteSuccess)
+	for index := range reqs {
+		ret := svr.CreateStrategy(ctx, reqs[index])
+		api.Collect(resp, ret)
+	}
+	return resp
+}
+
+// checkStrategyBatch 检查批量请求的鉴权策略数量
+func (svr *Server) checkStrategyBatch(size int) *apiservice.BatchWriteResponse {
+	if size == 0 {
+		return api.NewAuthBatchWriteResponse(apimodel.Code_EmptyRequest)
+	}
+	maxSize := svr.options.MaxStrategyBatchSize
+	if maxSize <= 0 {
+		maxSize = defaultMaxStrategyBatchSize
+	}
+	if size > maxSize {
+		return api.NewAuthBatchWriteResponse(apimodel.Code_BatchSizeOverLimit)
+	}
+	return nil
+}
+
 // handleCreateStrategy 创建鉴权策略
 func (svr *Server) handleCreateStrategy(ctx context.Context, req *apisecurity.AuthStrategy) *apiservice.Response {
 	requestID := utils.ParseRequestID(ctx)
+	if code := svr.checkStrategyEditor(ctx); code != apimodel.Code_ExecuteSuccess {
+		return api.NewAuthStrategyResponse(code, req)
+	}
 	req.Owner = utils.NewStringValue(utils.ParseOwnerID(ctx))
 
 	if checkErrResp := svr.checkCreateStrategy(req); checkErrResp != nil {
@@ -74,6 +106,34 @@ func (svr *Server) handleCreateStrategy(ctx context.Context, req *apisecurity.Au
 	req.Resources = svr.normalizeResource(req.Resources)
 
 	data := svr.createAuthStrategyModel(req)
+	if code := svr.checkStrategyEditable(ctx, data); code != apimodel.Code_ExecuteSuccess {
+		return api.NewAuthStrategyResponse(code, req)
+	}
+	if conflict := svr.findStrategyNameConflict(data); conflict != nil {
+		log.Error("[Auth][Strategy] create strategy name conflict", utils.ZapRequestID(requestID),
+			zap.String("name", data.Name), zap.String("conflict", conflict.ID),
+			zap.String("scope", svr.options.StrategyNameScope))
+		return api.NewAuthStrategyResponseWithMsg(apimodel.Code_ExistedResource,
+			api.Code2Info(api.ExistedResource)+":strategy name "+data.Name, req)
+	}
+	blocks, warns := splitLintIssues(svr.linter.Lint(data))
+	if len(blocks) != 0 {
+		log.Error("[Auth][Strategy] create strategy blocked by lint rule", utils.ZapRequestID(requestID),
+			zap.String("name", req.Name.GetValue()), zap.String("issues", formatLintIssues(blocks)))
+		return api.NewAuthStrategyResponseWithMsg(apimodel.Code_InvalidParameter,
+			api.Code2Info(api.InvalidParameter)+":"+formatLintIssues(blocks), req)
+	}
+	warnMsgs := make([]string, 0, 2)
+	if len(warns) != 0 {
+		warnMsgs = append(warnMsgs, formatLintIssues(warns))
+	}
+
+	quotaMsgs, errResp := svr.checkCreateStrategyQuota(ctx, req, data)
+	if errResp != nil {
+		return errResp
+	}
+	warnMsgs = append(warnMsgs, quotaMsgs...)
+
 	if err := svr.storage.AddStrategy(data); err != nil {
 		log.Error("[Auth][Strategy] create strategy into store", utils.ZapRequestID(requestID),
 			zap.Error(err))
@@ -83,13 +143,60 @@ func (svr *Server) handleCreateStrategy(ctx context.Context, req *apisecurity.Au
 	log.Info("[Auth][Strategy] create strategy", utils.ZapRequestID(requestID),
 		zap.String("name", req.Name.GetValue()))
 	svr.RecordHistory(authStrategyRecordEntry(ctx, req, data, model.OCreate))
+	for i := range quotaMsgs {
+		svr.RecordHistory(quotaRecordEntry(utils.ParseOperator(ctx), data, quotaMsgs[i]))
+	}
 
+	if len(warnMsgs) != 0 {
+		log.Warn("[Auth][Strategy] create strategy with warning", utils.ZapRequestID(requestID),
+			zap.String("name", req.Name.GetValue()), zap.Strings("warnings", warnMsgs))
+		return api.NewAuthStrategyResponseWithMsg(apimodel.Code_ExecuteSuccess,
+			api.Code2Info(api.ExecuteSuccess)+":"+strings.Join(warnMsgs, "; "), req)
+	}
 	return api.NewAuthStrategyResponse(apimodel.Code_ExecuteSuccess, req)
 }
 
+// checkCreateStrategyQuota 检查创建鉴权策略的配额，返回宽限期内的提示信息
+func (svr *Server) checkCreateStrategyQuota(ctx context.Context, req *apisecurity.AuthStrategy,
+	data *model.StrategyDetail) ([]string, *apiservice.Response) {
+	requestID := utils.ParseRequestID(ctx)
+	warnMsgs := make([]string, 0, 2)
+
+	result, msg, err := svr.checkStrategyQuota(data.Owner)
+	if err != nil {
+		log.Error("[Auth][Strategy] count owner strategies from store", utils.ZapRequestID(requestID),
+			zap.String("owner", data.Owner), zap.Error(err))
+		return nil, api.NewAuthResponse(commonstore.StoreCode2APICode(err))
+	}
+	if result == QuotaReject {
+		log.Error("[Auth][Strategy] create strategy rejected by quota", utils.ZapRequestID(requestID),
+			zap.String("name", req.Name.GetValue()), zap.String("quota", msg))
+		return nil, api.NewAuthStrategyResponseWithMsg(apimodel.Code_InvalidParameter,
+			api.Code2Info(api.InvalidParameter)+":"+msg, req)
+	}
+	if result == QuotaWarn {
+		warnMsgs = append(warnMsgs, msg)
+	}
+
+	result, msg = svr.checkStrategyResourceQuota(data)
+	if result == QuotaReject {
+		log.Error("[Auth][Strategy] create strategy rejected by quota", utils.ZapRequestID(requestID),
+			zap.String("name", req.Name.GetValue()), zap.String("quota", msg))
+		return nil, api.NewAuthStrategyResponseWithMsg(apimodel.Code_InvalidParameter,
+			api.Code2Info(api.InvalidParameter)+":"+msg, req)
+	}
+	if result == QuotaWarn {
+		warnMsgs = append(warnMsgs, msg)
+	}
+	return warnMsgs, nil
+}
+
 // handleUpdateStrategies 批量修改鉴权
 func (svr *Server) handleUpdateStrategies(
 	ctx context.Context, reqs []*apisecurity.ModifyAuthStrategy) *apiservice.BatchWriteResponse {
+	if resp := svr.checkStrategyBatch(len(reqs)); resp != nil {
+		return resp
+	}
 	resp := api.NewAuthBatchWriteResponse(apimodel.Code_ExecuteSuccess)
 
 	for index := range reqs {
@@ -106,6 +213,9 @@ func (svr *Server) handleUpdateStrategies(
 // Case 3. 主账户的默认策略不得修改
 func (svr *Server) UpdateStrategy(ctx context.Context, req *apisecurity.ModifyAuthStrategy) *apiservice.Response {
 	requestID := utils.ParseRequestID(ctx)
+	if code := svr.checkStrategyEditor(ctx); code != apimodel.Code_ExecuteSuccess {
+		return api.NewModifyAuthStrategyResponse(code, req)
+	}
 
 	strategy, err := svr.storage.GetStrategyDetail(req.GetId().GetValue())
 	if err != nil {
@@ -127,6 +237,45 @@ func (svr *Server) UpdateStrategy(ctx context.Context, req *apisecurity.ModifyAu
 		return api.NewModifyAuthStrategyResponse(apimodel.Code_NoNeedUpdate, req)
 	}
 
+	merged := mergeModifyStrategy(strategy, data)
+	if code := svr.checkStrategyEditable(ctx, merged); code != apimodel.Code_ExecuteSuccess {
+		return api.NewModifyAuthStrategyResponse(code, req)
+	}
+	if merged.Name != strategy.Name {
+		if conflict := svr.findStrategyNameConflict(merged); conflict != nil {
+			log.Error("[Auth][Strategy] rename strategy name conflict", utils.ZapRequestID(requestID),
+				zap.String("name", merged.Name), zap.String("conflict", conflict.ID),
+				zap.String("scope", svr.options.StrategyNameScope))
+			resp := api.NewModifyAuthStrategyResponse(apimodel.Code_ExistedResource, req)
+			resp.Info = utils.NewStringValue(resp.GetInfo().GetValue() + ":strategy name " + merged.Name)
+			return resp
+		}
+	}
+	blocks, warns := splitLintIssues(svr.linter.Lint(merged))
+	if len(blocks) != 0 {
+		log.Error("[Auth][Strategy] update strategy blocked by lint rule", utils.ZapRequestID(requestID),
+			zap.String("name", strategy.Name), zap.String("issues", formatLintIssues(blocks)))
+		resp := api.NewModifyAuthStrategyResponse(apimodel.Code_InvalidParameter, req)
+		resp.Info = utils.NewStringValue(resp.GetInfo().GetValue() + ":" + formatLintIssues(blocks))
+		return resp
+	}
+	warnMsgs := make([]string, 0, 2)
+	if len(warns) != 0 {
+		warnMsgs = append(warnMsgs, formatLintIssues(warns))
+	}
+
+	quotaResult, quotaMsg := svr.checkStrategyResourceQuota(merged)
+	if quotaResult == QuotaReject {
+		log.Error("[Auth][Strategy] update strategy rejected by quota", utils.ZapRequestID(requestID),
+			zap.String("name", strategy.Name), zap.String("quota", quotaMsg))
+		resp := api.NewModifyAuthStrategyResponse(apimodel.Code_InvalidParameter, req)
+		resp.Info = utils.NewStringValue(resp.GetInfo().GetValue() + ":" + quotaMsg)
+		return resp
+	}
+	if quotaResult == QuotaWarn {
+		warnMsgs = append(warnMsgs, quotaMsg)
+	}
+
 	if err := svr.storage.UpdateStrategy(data); err != nil {
 		log.Error("[Auth][Strategy] update strategy into store",
 			utils.ZapRequestID(requestID), zap.Error(err))
@@ -137,12 +286,153 @@ func (svr *Server) UpdateStrategy(ctx context.Context, req *apisecurity.ModifyAu
 		zap.String("name", strategy.Name))
 	svr.RecordHistory(authModifyStrategyRecordEntry(ctx, req, data, model.OUpdate))
 
-	return api.NewModifyAuthStrategyResponse(apimodel.Code_ExecuteSuccess, req)
+	if quotaResult == QuotaWarn {
+		svr.RecordHistory(quotaRecordEntry(utils.ParseOperator(ctx), merged, quotaMsg))
+	}
+
+	resp := api.NewModifyAuthStrategyResponse(apimodel.Code_ExecuteSuccess, req)
+	if len(warnMsgs) != 0 {
+		log.Warn("[Auth][Strategy] update strategy with warning", utils.ZapRequestID(requestID),
+			zap.String("name", strategy.Name), zap.Strings("warnings", warnMsgs))
+		resp.Info = utils.NewStringValue(resp.GetInfo().GetValue() + ":" + strings.Join(warnMsgs, "; "))
+	}
+	return resp
+}
+
+// handleAttachStrategyResources 为鉴权策略关联资源，重复关联时以本次设置的过期时间为准
+// Case 1. 鉴权策略只能被自己的 owner 对应的用户修改
+// Case 2. 资源关联关系的过期时间必须晚于当前时间，零值表示永不过期
+// Case 3. 已经存在的资源关联关系按照 DuplicateResourceMode 忽略、计数或者报错
+func (svr *Server) handleAttachStrategyResources(ctx context.Context, strategyID string,
+	resources []model.StrategyResource) *apiservice.Response {
+	requestID := utils.ParseRequestID(ctx)
+	req := &apisecurity.AuthStrategy{Id: utils.NewStringValue(strategyID)}
+	if code := svr.checkStrategyEditor(ctx); code != apimodel.Code_ExecuteSuccess {
+		return api.NewAuthStrategyResponse(code, req)
+	}
+
+	strategy, err := svr.storage.GetStrategyDetail(strategyID)
+	if err != nil {
+		log.Error("[Auth][Strategy] get strategy from store", utils.ZapRequestID(requestID),
+			zap.Error(err))
+		return api.NewAuthStrategyResponse(commonstore.StoreCode2APICode(err), req)
+	}
+	if strategy == nil {
+		return api.NewAuthStrategyResponse(apimodel.Code_NotFoundAuthStrategyRule, req)
+	}
+	userId := utils.ParseUserID(ctx)
+	if code := svr.checkStrategyEditable(ctx, strategy); code != apimodel.Code_ExecuteSuccess {
+		return api.NewAuthStrategyResponse(code, req)
+	}
+	if authcommon.ParseUserRole(ctx) != model.AdminUserRole && utils.ParseIsOwner(ctx) && userId != strategy.Owner {
+		log.Error("[Auth][Strategy] attach strategy resources denied, current user not owner",
+			utils.ZapRequestID(requestID), zap.String("user", userId),
+			zap.String("owner", strategy.Owner), zap.String("strategy", strategy.ID))
+		return api.NewAuthStrategyResponse(apimodel.Code_NotAllowedAccess, req)
+	}
+
+	now := time.Now()
+	attach := &apisecurity.StrategyResources{}
+	for i := range resources {
+		res := &resources[i]
+		if res.ResID == "" || (!res.ExpireTime.IsZero() && !res.ExpireTime.After(now)) {
+			return api.NewAuthStrategyResponse(apimodel.Code_InvalidParameter, req)
+		}
+		entry := &apisecurity.StrategyResourceEntry{Id: utils.NewStringValue(res.ResID)}
+		switch apisecurity.ResourceType(res.ResType) {
+		case apisecurity.ResourceType_Namespaces:
+			attach.Namespaces = append(attach.Namespaces, entry)
+		case apisecurity.ResourceType_Services:
+			attach.Services = append(attach.Services, entry)
+		case apisecurity.ResourceType_ConfigGroups:
+			attach.ConfigGroups = append(attach.ConfigGroups, entry)
+		default:
+			return api.NewAuthStrategyResponse(apimodel.Code_InvalidParameter, req)
+		}
+		res.StrategyID = strategy.ID
+	}
+	if errResp := svr.checkResourceExist(attach); errResp != nil {
+		return errResp
+	}
+	duplicates := countDuplicateResources(strategy.Resources, resources)
+	if duplicates > 0 && svr.options.DuplicateResourceMode == DuplicateResourceError {
+		log.Error("[Auth][Strategy] attach strategy resources rejected, resources already exist",
+			utils.ZapRequestID(requestID), zap.String("name", strategy.Name), zap.Int("duplicates", duplicates))
+		resp := api.NewAuthStrategyResponse(apimodel.Code_ExistedResource, req)
+		resp.Info = utils.NewStringValue(fmt.Sprintf("%s:%d duplicate resources", resp.GetInfo().GetValue(), duplicates))
+		return resp
+	}
+
+	merged := *strategy
+	merged.Resources = resourceDeduplication(append(append([]model.StrategyResource{}, strategy.Resources...),
+		resources...))
+	if code := svr.checkStrategyEditable(ctx, &merged); code != apimodel.Code_ExecuteSuccess {
+		return api.NewAuthStrategyResponse(code, req)
+	}
+	quotaResult, quotaMsg := svr.checkStrategyResourceQuota(&merged)
+	if quotaResult == QuotaReject {
+		log.Error("[Auth][Strategy] attach strategy resources rejected by quota", utils.ZapRequestID(requestID),
+			zap.String("name", strategy.Name), zap.String("quota", quotaMsg))
+		resp := api.NewAuthStrategyResponse(apimodel.Code_InvalidParameter, req)
+		resp.Info = utils.NewStringValue(resp.GetInfo().GetValue() + ":" + quotaMsg)
+		return resp
+	}
+
+	if err := svr.storage.LooseAddStrategyResources(resources); err != nil {
+		log.Error("[Auth][Strategy] attach strategy resources into store",
+			utils.ZapRequestID(requestID), zap.Error(err))
+		return api.NewAuthResponseWithMsg(commonstore.StoreCode2APICode(err), err.Error())
+	}
+
+	log.Info("[Auth][Strategy] attach strategy resources into store", utils.ZapRequestID(requestID),
+		zap.String("name", strategy.Name), zap.Int("count", len(resources)))
+	svr.RecordHistory(&model.RecordEntry{
+		ResourceType:  model.RAuthStrategy,
+		ResourceName:  fmt.Sprintf("%s(%s)", strategy.Name, strategy.ID),
+		OperationType: model.OUpdate,
+		Operator:      utils.ParseOperator(ctx),
+		ChangeSetID:   utils.ParseChangeSetID(ctx),
+		Detail:        utils.MustJson(resources),
+		HappenTime:    now,
+	})
+	if quotaResult == QuotaWarn {
+		svr.RecordHistory(quotaRecordEntry(utils.ParseOperator(ctx), &merged, quotaMsg))
+	}
+	resp := api.NewAuthStrategyResponse(apimodel.Code_ExecuteSuccess, req)
+	if svr.options.DuplicateResourceMode == DuplicateResourceCount {
+		resp.Info = utils.NewStringValue(fmt.Sprintf("%s:%d duplicate resources", resp.GetInfo().GetValue(), duplicates))
+	}
+	return resp
+}
+
+// countDuplicateResources 统计 resources 中已经关联到策略或者在本次导入中重复出现的资源数量
+func countDuplicateResources(exist, resources []model.StrategyResource) int {
+	type resKey struct {
+		resType int32
+		resID   string
+	}
+	seen := make(map[resKey]struct{}, len(exist)+len(resources))
+	for i := range exist {
+		seen[resKey{resType: exist[i].ResType, resID: exist[i].ResID}] = struct{}{}
+	}
+	duplicates := 0
+	for i := range resources {
+		key := resKey{resType: resources[i].ResType, resID: resources[i].ResID}
+		if _, ok := seen[key]; ok {
+			duplicates++
+			continue
+		}
+		seen[key] = struct{}{}
+	}
+	return duplicates
 }
 
 // handleDeleteStrategies 批量删除鉴权策略
 func (svr *Server) handleDeleteStrategies(
 	ctx context.Context, reqs []*apisecurity.AuthStrategy) *apiservice.BatchWriteResponse {
+	if resp := svr.checkStrategyBatch(len(reqs)); resp != nil {
+		return resp
+	}
 	resp := api.NewAuthBatchWriteResponse(apimodel.Code_ExecuteSuccess)
 	for index := range reqs {
 		ret := svr.DeleteStrategy(ctx, reqs[index])
@@ -157,6 +447,9 @@ func (svr *Server) handleDeleteStrategies(
 // Case 2. 默认策略不能被删除，默认策略只能随着账户的删除而被清理
 func (svr *Server) DeleteStrategy(ctx context.Context, req *apisecurity.AuthStrategy) *apiservice.Response {
 	requestID := utils.ParseRequestID(ctx)
+	if code := svr.checkStrategyEditor(ctx); code != apimodel.Code_ExecuteSuccess {
+		return api.NewAuthStrategyResponse(code, req)
+	}
 
 	strategy, err := svr.storage.GetStrategyDetail(req.GetId().GetValue())
 	if err != nil {
@@ -174,7 +467,10 @@ func (svr *Server) DeleteStrategy(ctx context.Context, req *apisecurity.AuthStra
 		return api.NewAuthStrategyResponseWithMsg(apimodel.Code_BadRequest, "default strategy can't delete", req)
 	}
 
-	if strategy.Owner != utils.ParseUserID(ctx) {
+	if code := svr.checkStrategyEditable(ctx, strategy); code != apimodel.Code_ExecuteSuccess {
+		return api.NewAuthStrategyResponse(code, req)
+	}
+	if _, nsAdmin := svr.namespaceAdminScope(ctx); !nsAdmin && strategy.Owner != utils.ParseUserID(ctx) {
 		return api.NewAuthStrategyResponse(apimodel.Code_NotAllowedAccess, req)
 	}
 
@@ -703,12 +999,15 @@ func (svr *Server) checkCreateStrategy(req *apisecurity.AuthStrategy) *apiservic
 
 // checkUpdateStrategy 检查更新鉴权策略的请求
 // Case 1. 修改的是默认鉴权策略的话，只能修改资源，不能添加用户 or 用户组
-// Case 2. 鉴权策略只能被自己的 owner 对应的用户修改
+// Case 2. 鉴权策略只能被自己的 owner 对应的用户或者管辖范围内的命名空间管理员修改
 func (svr *Server) checkUpdateStrategy(ctx context.Context, req *apisecurity.ModifyAuthStrategy,
 	saved *model.StrategyDetail) *apiservice.Response {
 	userId := utils.ParseUserID(ctx)
-	if authcommon.ParseUserRole(ctx) != model.AdminUserRole {
-		if !utils.ParseIsOwner(ctx) || userId != saved.Owner {
+	if code := svr.checkStrategyEditable(ctx, saved); code != apimodel.Code_ExecuteSuccess {
+		return api.NewModifyAuthStrategyResponse(code, req)
+	}
+	if authcommon.ParseUserRole(ctx) != model.AdminUserRole && utils.ParseIsOwner(ctx) {
+		if userId != saved.Owner {
 			log.Error("[Auth][Strategy] modify strategy denied, current user not owner",
 				utils.ZapRequestID(utils.ParseRequestID(ctx)),
 				zap.String("user", userId),
@@ -764,6 +1063,7 @@ func authStrategyRecordEntry(ctx context.Context, req *apisecurity.AuthStrategy,
 		ResourceName:  fmt.Sprintf("%s(%s)", md.Name, md.ID),
 		OperationType: operationType,
 		Operator:      utils.ParseOperator(ctx),
+		ChangeSetID:   utils.ParseChangeSetID(ctx),
 		Detail:        detail,
 		HappenTime:    time.Now(),
 	}
@@ -784,6 +1084,7 @@ func authModifyStrategyRecordEntry(
 		ResourceName:  fmt.Sprintf("%s(%s)", md.Name, md.ID),
 		OperationType: operationType,
 		Operator:      utils.ParseOperator(ctx),
+		ChangeSetID:   utils.ParseChangeSetID(ctx),
 		Detail:        detail,
 		HappenTime:    time.Now(),
 	}
@@ -839,16 +1140,26 @@ func (svr *Server) checkGroupExist(groups []*apisecurity.UserGroup) error {
 	return svr.userSvr.GetUserHelper().CheckGroupsExist(context.TODO(), groups)
 }
 
-// checkResourceExist 检查资源是否存在
+// checkResourceExist 检查资源是否存在, 属性匹配规则仅支持服务资源，只校验规则的合法性
+// 标签选择器支持服务以及配置分组资源，同样只校验选择器的合法性
 func (svr *Server) checkResourceExist(resources *apisecurity.StrategyResources) *apiservice.Response {
 	namespaces := resources.GetNamespaces()
 
 	nsCache := svr.cacheMgr.Namespace()
 	for index := range namespaces {
 		val := namespaces[index]
+		if IsRequestCondition(val.GetId().GetValue()) {
+			if errResp := checkRequestCondition(val.GetId().GetValue()); errResp != nil {
+				return errResp
+			}
+			continue
+		}
 		if val.GetId().GetValue() == "*" {
 			break
 		}
+		if IsAttributeResource(val.GetId().GetValue()) || IsLabelSelector(val.GetId().GetValue()) {
+			return api.NewAuthResponse(apimodel.Code_InvalidParameter)
+		}
 		ns := nsCache.GetNamespace(val.GetId().GetValue())
 		if ns == nil {
 			return api.NewAuthResponse(apimodel.Code_NotFoundNamespace)
@@ -859,27 +1170,61 @@ func (svr *Server) checkResourceExist(resources *apisecurity.StrategyResources)
 	svcCache := svr.cacheMgr.Service()
 	for index := range services {
 		val := services[index]
+		if IsRequestCondition(val.GetId().GetValue()) {
+			if errResp := checkRequestCondition(val.GetId().GetValue()); errResp != nil {
+				return errResp
+			}
+			continue
+		}
 		if val.GetId().GetValue() == "*" {
 			break
 		}
+		if IsAttributeResource(val.GetId().GetValue()) {
+			if _, err := parseAttributeRule(val.GetId().GetValue()); err != nil {
+				return api.NewAuthResponseWithMsg(apimodel.Code_InvalidParameter, err.Error())
+			}
+			continue
+		}
+		if IsLabelSelector(val.GetId().GetValue()) {
+			if errResp := checkLabelSelector(val.GetId().GetValue()); errResp != nil {
+				return errResp
+			}
+			continue
+		}
 		svc := svcCache.GetServiceByID(val.GetId().GetValue())
 		if svc == nil {
 			return api.NewAuthResponse(apimodel.Code_NotFoundService)
 		}
 	}
 
+	groups := resources.GetConfigGroups()
+	for index := range groups {
+		if IsRequestCondition(groups[index].GetId().GetValue()) {
+			if errResp := checkRequestCondition(groups[index].GetId().GetValue()); errResp != nil {
+				return errResp
+			}
+			continue
+		}
+		if IsAttributeResource(groups[index].GetId().GetValue()) {
+			return api.NewAuthResponse(apimodel.Code_InvalidParameter)
+		}
+		if IsLabelSelector(groups[index].GetId().GetValue()) {
+			if errResp := checkLabelSelector(groups[index].GetId().GetValue()); errResp != nil {
+				return errResp
+			}
+		}
+	}
+
 	return nil
 }
 
-// normalizeResource 对于资源进行归一化处理, 如果出现 * 的话，则该资源访问策略就是 *
+// normalizeResource 对于资源进行归一化处理, 如果出现 * 的话，则该资源访问策略就是 *, 请求条件保持不变
 func (svr *Server) normalizeResource(resources *apisecurity.StrategyResources) *apisecurity.StrategyResources {
 	namespaces := resources.GetNamespaces()
 	for index := range namespaces {
 		val := namespaces[index]
 		if val.GetId().GetValue() == "*" {
-			resources.Namespaces = []*apisecurity.StrategyResourceEntry{{
-				Id: utils.NewStringValue("*"),
-			}}
+			resources.Namespaces = normalizeWildcard(namespaces)
 			break
 		}
 	}
@@ -888,9 +1233,7 @@ func (svr *Server) normalizeResource(resources *apisecurity.StrategyResources) *
 	for index := range services {
 		val := services[index]
 		if val.GetId().GetValue() == "*" {
-			resources.Services = []*apisecurity.StrategyResourceEntry{{
-				Id: utils.NewStringValue("*"),
-			}}
+			resources.Services = normalizeWildcard(services)
 			break
 		}
 	}
@@ -898,6 +1241,27 @@ func (svr *Server) normalizeResource(resources *apisecurity.StrategyResources) *
 	return resources
 }
 
+// normalizeWildcard 仅保留 * 以及请求条件
+func normalizeWildcard(entries []*apisecurity.StrategyResourceEntry) []*apisecurity.StrategyResourceEntry {
+	ret := []*apisecurity.StrategyResourceEntry{{
+		Id: utils.NewStringValue("*"),
+	}}
+	for i := range entries {
+		if IsRequestCondition(entries[i].GetId().GetValue()) {
+			ret = append(ret, entries[i])
+		}
+	}
+	return ret
+}
+
+// checkRequestCondition 校验请求条件的语法
+func checkRequestCondition(resId string) *apiservice.Response {
+	if _, err := parseRequestCondition(resId); err != nil {
+		return api.NewAuthResponseWithMsg(apimodel.Code_InvalidParameter, err.Error())
+	}
+	return nil
+}
+
 // fillPrincipalInfo 填充 principal 摘要信息
 func (svr *Server) fillPrincipalInfo(resp *apisecurity.AuthStrategy, data *model.StrategyDetail) {
 	users := make([]*apisecurity.Principal, 0, len(data.Principals))
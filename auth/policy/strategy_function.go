@@ -0,0 +1,129 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package policy
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	apimodel "github.com/polarismesh/specification/source/go/api/v1/model"
+	apisecurity "github.com/polarismesh/specification/source/go/api/v1/security"
+	apiservice "github.com/polarismesh/specification/source/go/api/v1/service_manage"
+	"go.uber.org/zap"
+
+	"github.com/polarismesh/polaris/auth/evaluator"
+	api "github.com/polarismesh/polaris/common/api/v1"
+	"github.com/polarismesh/polaris/common/model"
+	authcommon "github.com/polarismesh/polaris/common/model/auth"
+	commonstore "github.com/polarismesh/polaris/common/store"
+	"github.com/polarismesh/polaris/common/utils"
+)
+
+const (
+	// maxStrategyFunctionsLength 接口名称使用 , 分隔后的最大长度，与存储的字段长度保持一致
+	maxStrategyFunctionsLength = 2048
+)
+
+// unmatchedFunctionStrategies principal 以及其所属用户组的策略中，不对本次请求的接口生效的策略, 合并到 excluded 中返回
+func (d *DefaultAuthChecker) unmatchedFunctionStrategies(principal model.Principal, method string,
+	excluded map[string]struct{}) map[string]struct{} {
+	return evaluator.UnmatchedFunctions(d.principalStrategies(principal), method, excluded)
+}
+
+// normalizeStrategyFunctions 去除接口名称的空白以及重复项，* 只能出现在接口名称的末尾表示前缀匹配
+func normalizeStrategyFunctions(functions []string) ([]string, error) {
+	ret := make([]string, 0, len(functions))
+	exist := make(map[string]struct{}, len(functions))
+	for _, fn := range functions {
+		fn = strings.TrimSpace(fn)
+		if fn == "" || strings.Contains(fn, ",") || strings.Contains(strings.TrimSuffix(fn, "*"), "*") {
+			return nil, fmt.Errorf("invalid strategy function: %q", fn)
+		}
+		if _, ok := exist[fn]; ok {
+			continue
+		}
+		exist[fn] = struct{}{}
+		ret = append(ret, fn)
+	}
+	if len(model.FormatStrategyFunctions(ret)) > maxStrategyFunctionsLength {
+		return nil, fmt.Errorf("strategy functions too long, max length is %d", maxStrategyFunctionsLength)
+	}
+	return ret, nil
+}
+
+// handleUpdateStrategyFunctions 修改鉴权策略生效的接口名称
+// Case 1. 鉴权策略只能被自己的 owner 对应的用户修改
+// Case 2. 默认策略对全部接口生效，不允许限制接口
+// Case 3. functions 为空时策略对全部接口生效
+func (svr *Server) handleUpdateStrategyFunctions(ctx context.Context, strategyID string,
+	functions []string) *apiservice.Response {
+	requestID := utils.ParseRequestID(ctx)
+	req := &apisecurity.AuthStrategy{Id: utils.NewStringValue(strategyID)}
+	functions, err := normalizeStrategyFunctions(functions)
+	if err != nil {
+		return api.NewAuthStrategyResponseWithMsg(apimodel.Code_InvalidParameter, err.Error(), req)
+	}
+	if code := svr.checkStrategyEditor(ctx); code != apimodel.Code_ExecuteSuccess {
+		return api.NewAuthStrategyResponse(code, req)
+	}
+
+	strategy, err := svr.storage.GetStrategyDetail(strategyID)
+	if err != nil {
+		log.Error("[Auth][Strategy] get strategy from store", utils.ZapRequestID(requestID),
+			zap.Error(err))
+		return api.NewAuthStrategyResponse(commonstore.StoreCode2APICode(err), req)
+	}
+	if strategy == nil {
+		return api.NewAuthStrategyResponse(apimodel.Code_NotFoundAuthStrategyRule, req)
+	}
+	if code := svr.checkStrategyEditable(ctx, strategy); code != apimodel.Code_ExecuteSuccess {
+		return api.NewAuthStrategyResponse(code, req)
+	}
+	userId := utils.ParseUserID(ctx)
+	if authcommon.ParseUserRole(ctx) != model.AdminUserRole && utils.ParseIsOwner(ctx) && userId != strategy.Owner {
+		log.Error("[Auth][Strategy] update strategy functions denied, current user not owner",
+			utils.ZapRequestID(requestID), zap.String("user", userId),
+			zap.String("owner", strategy.Owner), zap.String("strategy", strategy.ID))
+		return api.NewAuthStrategyResponse(apimodel.Code_NotAllowedAccess, req)
+	}
+	if strategy.Default && len(functions) != 0 {
+		return api.NewAuthStrategyResponseWithMsg(apimodel.Code_BadRequest,
+			"default strategy can't limit functions", req)
+	}
+
+	if err := svr.storage.UpdateStrategyFunctions(strategy.ID, functions); err != nil {
+		log.Error("[Auth][Strategy] update strategy functions into store", utils.ZapRequestID(requestID),
+			zap.Error(err))
+		return api.NewAuthResponseWithMsg(commonstore.StoreCode2APICode(err), err.Error())
+	}
+
+	log.Info("[Auth][Strategy] update strategy functions into store", utils.ZapRequestID(requestID),
+		zap.String("name", strategy.Name), zap.Strings("functions", functions))
+	svr.RecordHistory(&model.RecordEntry{
+		ResourceType:  model.RAuthStrategy,
+		ResourceName:  fmt.Sprintf("%s(%s)", strategy.Name, strategy.ID),
+		OperationType: model.OUpdate,
+		Operator:      utils.ParseOperator(ctx),
+		ChangeSetID:   utils.ParseChangeSetID(ctx),
+		Detail:        utils.MustJson(map[string]interface{}{"functions": functions}),
+		HappenTime:    time.Now(),
+	})
+	return api.NewAuthStrategyResponse(apimodel.Code_ExecuteSuccess, req)
+}
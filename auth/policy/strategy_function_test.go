@@ -0,0 +1,164 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package policy_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	apimodel "github.com/polarismesh/specification/source/go/api/v1/model"
+	apisecurity "github.com/polarismesh/specification/source/go/api/v1/security"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/polarismesh/polaris/auth"
+	"github.com/polarismesh/polaris/auth/policy"
+	defaultuser "github.com/polarismesh/polaris/auth/user"
+	"github.com/polarismesh/polaris/cache"
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/common/utils"
+)
+
+func Test_StrategyFunctions(t *testing.T) {
+	reset(true)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	users := createMockUser(10)
+	groups := createMockUserGroup(users)
+	namespaces := createMockNamespace(len(users)+len(groups)+10, users[0].ID)
+	services := createMockService(namespaces)
+	serviceMap := convertServiceSliceToMap(services)
+	limited, blocked := namespaces[0].Name, namespaces[1].Name
+
+	newStrategy := func(name string, effect model.StrategyEffect, functions []string,
+		resID string) *model.StrategyDetail {
+		return &model.StrategyDetail{
+			ID:         utils.NewUUID(),
+			Name:       name,
+			Action:     apisecurity.AuthAction_READ_WRITE.String(),
+			Owner:      users[0].ID,
+			Principals: []model.Principal{{PrincipalID: users[1].ID, PrincipalRole: model.PrincipalUser}},
+			Effect:     effect,
+			Functions:  functions,
+			Valid:      true,
+			ModifyTime: time.Now(),
+			Resources: []model.StrategyResource{
+				{ResType: int32(apisecurity.ResourceType_Namespaces), ResID: resID},
+			},
+		}
+	}
+	describeOnly := newStrategy("function-test-describe", model.StrategyEffectAllow, []string{"Describe*"}, limited)
+	strategies := []*model.StrategyDetail{
+		describeOnly,
+		newStrategy("function-test-deny", model.StrategyEffectDeny, []string{"DeleteNamespaces"}, blocked),
+	}
+
+	cfg, storage := initCache(ctrl)
+	storage.EXPECT().GetUsersForCache(gomock.Any(), gomock.Any()).AnyTimes().Return(users, nil)
+	storage.EXPECT().GetGroupsForCache(gomock.Any(), gomock.Any()).AnyTimes().Return(groups, nil)
+	storage.EXPECT().GetUserSessionsForCache(gomock.Any(), gomock.Any()).AnyTimes().Return(nil, nil)
+	storage.EXPECT().GetRolesForCache(gomock.Any(), gomock.Any()).AnyTimes().Return(nil, nil)
+	storage.EXPECT().GetStrategyDetailsForCache(gomock.Any(), gomock.Any()).AnyTimes().Return(strategies, nil)
+	storage.EXPECT().GetMoreNamespaces(gomock.Any()).AnyTimes().Return(namespaces, nil)
+	storage.EXPECT().GetMoreServices(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes().Return(serviceMap, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cacheMgr, err := cache.TestCacheInitialize(ctx, cfg, storage)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		cancel()
+		cacheMgr.Close()
+	})
+
+	_, proxySvr, err := defaultuser.BuildServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	proxySvr.Initialize(&auth.Config{
+		User: &auth.UserConfig{
+			Name:   auth.DefaultUserMgnPluginName,
+			Option: map[string]interface{}{"salt": "polarismesh@2021"},
+		},
+	}, storage, cacheMgr)
+
+	_, svr, err := newPolicyServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := svr.Initialize(&auth.Config{
+		Strategy: &auth.StrategyConfig{Name: auth.DefaultPolicyPluginName},
+	}, storage, cacheMgr, proxySvr); err != nil {
+		t.Fatal(err)
+	}
+	_ = cacheMgr.TestUpdate()
+
+	checker := svr.GetAuthChecker()
+	checker.(*policy.DefaultAuthChecker).SetConfig(&policy.AuthConfig{ConsoleOpen: true, ConsoleStrict: true})
+
+	check := func(method string, op model.ResourceOperation, namespace string) (bool, error) {
+		authCtx := model.NewAcquireContext(
+			model.WithRequestContext(context.WithValue(context.Background(), utils.ContextAuthTokenKey,
+				users[1].Token)),
+			model.WithMethod(method),
+			model.WithOperation(op),
+			model.WithModule(model.DiscoverModule),
+			model.WithAccessResources(map[apisecurity.ResourceType][]model.ResourceEntry{
+				apisecurity.ResourceType_Namespaces: {{ID: namespace, Owner: users[0].ID}},
+			}),
+		)
+		return checker.CheckConsolePermission(authCtx)
+	}
+
+	t.Run("策略只对列出的接口授予权限", func(t *testing.T) {
+		pass, err := check("DescribeNamespaces", model.Modify, limited)
+		assert.True(t, pass, err)
+		pass, err = check("DeleteNamespaces", model.Delete, limited)
+		assert.False(t, pass)
+		assert.True(t, errors.Is(err, policy.ErrorNotPermission), err)
+	})
+
+	t.Run("拒绝策略只对列出的接口生效", func(t *testing.T) {
+		pass, err := check("DescribeNamespaces", model.Read, blocked)
+		assert.True(t, pass, err)
+		pass, err = check("DeleteNamespaces", model.Delete, blocked)
+		assert.False(t, pass)
+		assert.True(t, errors.Is(err, policy.ErrorDenyByStrategy), err)
+	})
+
+	t.Run("修改策略生效的接口", func(t *testing.T) {
+		ownerCtx := context.WithValue(context.Background(), utils.ContextAuthTokenKey, users[0].Token)
+		storage.EXPECT().GetStrategyDetail(describeOnly.ID).Return(describeOnly, nil).AnyTimes()
+		storage.EXPECT().UpdateStrategyFunctions(describeOnly.ID, []string{"DescribeServices", "CreateServices"}).
+			Return(nil)
+		resp := svr.UpdateStrategyFunctions(ownerCtx, describeOnly.ID,
+			[]string{" DescribeServices", "CreateServices", "DescribeServices"})
+		assert.Equal(t, uint32(apimodel.Code_ExecuteSuccess), resp.GetCode().GetValue(), resp.GetInfo().GetValue())
+
+		resp = svr.UpdateStrategyFunctions(ownerCtx, describeOnly.ID, []string{"Describe*Services"})
+		assert.Equal(t, uint32(apimodel.Code_InvalidParameter), resp.GetCode().GetValue())
+
+		subCtx := context.WithValue(context.Background(), utils.ContextAuthTokenKey, users[2].Token)
+		resp = svr.UpdateStrategyFunctions(subCtx, describeOnly.ID, []string{"DescribeServices"})
+		assert.Equal(t, uint32(apimodel.Code_OperationRoleForbidden), resp.GetCode().GetValue())
+	})
+}
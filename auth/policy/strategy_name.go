@@ -0,0 +1,86 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package policy
+
+import (
+	"github.com/polarismesh/polaris/common/model"
+)
+
+const (
+	// StrategyNameScopeGlobal 鉴权策略名称全局唯一
+	StrategyNameScopeGlobal = "global"
+	// StrategyNameScopeTenant 鉴权策略名称在同一个主账户下唯一
+	StrategyNameScopeTenant = "tenant"
+	// StrategyNameScopeNamespace 鉴权策略名称在同一个主账户的同一个命名空间下唯一
+	StrategyNameScopeNamespace = "namespace"
+)
+
+// findStrategyNameConflict 在策略缓存中查找与 strategy 处于同一个名称唯一范围内的同名策略，未开启时不检查
+// 命名空间范围下，策略关联资源所属的命名空间存在交集即视为同一范围，不属于任何命名空间的策略归为同一范围
+func (svr *Server) findStrategyNameConflict(strategy *model.StrategyDetail) *model.StrategyDetail {
+	scope := svr.options.StrategyNameScope
+	if scope == "" {
+		return nil
+	}
+	var (
+		namespaces map[string]struct{}
+		conflict   *model.StrategyDetail
+	)
+	if scope == StrategyNameScopeNamespace {
+		namespaces = svr.strategyNamespaces(strategy)
+	}
+	svr.cacheMgr.AuthStrategy().IteratorStrategies(func(rule *model.StrategyDetail) bool {
+		if rule.ID == strategy.ID || rule.Name != strategy.Name {
+			return true
+		}
+		if scope != StrategyNameScopeGlobal && rule.Owner != strategy.Owner {
+			return true
+		}
+		if scope == StrategyNameScopeNamespace && !intersectNamespaces(namespaces, svr.strategyNamespaces(rule)) {
+			return true
+		}
+		conflict = rule
+		return false
+	})
+	return conflict
+}
+
+// strategyNamespaces 鉴权策略关联资源所属的命名空间，不属于任何命名空间的资源记为空字符串
+func (svr *Server) strategyNamespaces(strategy *model.StrategyDetail) map[string]struct{} {
+	ret := map[string]struct{}{}
+	for i := range strategy.Resources {
+		if IsRequestCondition(strategy.Resources[i].ResID) {
+			continue
+		}
+		namespace, _ := svr.resourceNamespace(strategy.Resources[i])
+		ret[namespace] = struct{}{}
+	}
+	if len(ret) == 0 {
+		ret[""] = struct{}{}
+	}
+	return ret
+}
+
+func intersectNamespaces(a, b map[string]struct{}) bool {
+	for ns := range a {
+		if _, ok := b[ns]; ok {
+			return true
+		}
+	}
+	return false
+}
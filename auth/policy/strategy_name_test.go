@@ -0,0 +1,115 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package policy_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	apisecurity "github.com/polarismesh/specification/source/go/api/v1/security"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/polarismesh/polaris/auth"
+	api "github.com/polarismesh/polaris/common/api/v1"
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/common/utils"
+)
+
+func Test_StrategyNameScope(t *testing.T) {
+	strategyTest := newStrategyTest(t)
+	defer strategyTest.Clean()
+
+	ownerCtx := context.WithValue(context.Background(), utils.ContextAuthTokenKey, strategyTest.users[0].Token)
+	existed := strategyTest.strategies[1]
+	renamed := strategyTest.strategies[2]
+	strategyTest.storage.EXPECT().GetStrategyDetail(renamed.ID).Return(renamed, nil).AnyTimes()
+
+	initScope := func(scope string) {
+		err := strategyTest.svr.Initialize(&auth.Config{
+			Strategy: &auth.StrategyConfig{
+				Name:   auth.DefaultPolicyPluginName,
+				Option: map[string]interface{}{"strategyNameScope": scope},
+			},
+		}, strategyTest.storage, strategyTest.cacheMgn, strategyTest.userSvr)
+		assert.NoError(t, err)
+		_ = strategyTest.cacheMgn.TestUpdate()
+	}
+	newStrategy := func(svc *model.Service) *apisecurity.AuthStrategy {
+		return &apisecurity.AuthStrategy{
+			Id:   utils.NewStringValue(utils.NewUUID()),
+			Name: utils.NewStringValue(existed.Name),
+			Principals: &apisecurity.Principals{
+				Users: []*apisecurity.Principal{{Id: utils.NewStringValue(strategyTest.users[3].ID)}},
+			},
+			Resources: &apisecurity.StrategyResources{
+				Namespaces: []*apisecurity.StrategyResourceEntry{{Id: utils.NewStringValue(svc.Namespace)}},
+			},
+		}
+	}
+	rename := func() *apisecurity.ModifyAuthStrategy {
+		return &apisecurity.ModifyAuthStrategy{
+			Id:               utils.NewStringValue(renamed.ID),
+			Name:             utils.NewStringValue(existed.Name),
+			AddPrincipals:    &apisecurity.Principals{},
+			RemovePrincipals: &apisecurity.Principals{},
+		}
+	}
+
+	t.Run("未开启时允许同名", func(t *testing.T) {
+		initScope("")
+		strategyTest.storage.EXPECT().AddStrategy(gomock.Any()).Return(nil)
+		resp := strategyTest.svr.CreateStrategy(ownerCtx, newStrategy(strategyTest.services[1]))
+		assert.Equal(t, api.ExecuteSuccess, resp.GetCode().GetValue(), resp.GetInfo().GetValue())
+	})
+
+	t.Run("主账户范围内同名", func(t *testing.T) {
+		initScope("tenant")
+		strategyTest.storage.EXPECT().AddStrategy(gomock.Any()).Times(0)
+		strategyTest.storage.EXPECT().UpdateStrategy(gomock.Any()).Times(0)
+		resp := strategyTest.svr.CreateStrategy(ownerCtx, newStrategy(strategyTest.services[5]))
+		assert.Equal(t, api.ExistedResource, resp.GetCode().GetValue(), resp.GetInfo().GetValue())
+		updateResp := strategyTest.svr.UpdateStrategies(ownerCtx, []*apisecurity.ModifyAuthStrategy{rename()})
+		assert.Equal(t, api.ExistedResource, updateResp.Responses[0].GetCode().GetValue())
+	})
+
+	t.Run("命名空间范围内同名", func(t *testing.T) {
+		initScope("namespace")
+		resp := strategyTest.svr.CreateStrategy(ownerCtx, newStrategy(strategyTest.services[1]))
+		assert.Equal(t, api.ExistedResource, resp.GetCode().GetValue(), resp.GetInfo().GetValue())
+
+		// 不同命名空间下允许同名
+		strategyTest.storage.EXPECT().AddStrategy(gomock.Any()).Return(nil)
+		resp = strategyTest.svr.CreateStrategy(ownerCtx, newStrategy(strategyTest.services[5]))
+		assert.Equal(t, api.ExecuteSuccess, resp.GetCode().GetValue(), resp.GetInfo().GetValue())
+		strategyTest.storage.EXPECT().UpdateStrategy(gomock.Any()).Return(nil)
+		updateResp := strategyTest.svr.UpdateStrategies(ownerCtx, []*apisecurity.ModifyAuthStrategy{rename()})
+		assert.Equal(t, api.ExecuteSuccess, updateResp.Responses[0].GetCode().GetValue(),
+			updateResp.Responses[0].GetInfo().GetValue())
+	})
+
+	t.Run("不支持的范围", func(t *testing.T) {
+		err := strategyTest.svr.Initialize(&auth.Config{
+			Strategy: &auth.StrategyConfig{
+				Name:   auth.DefaultPolicyPluginName,
+				Option: map[string]interface{}{"strategyNameScope": "cluster"},
+			},
+		}, strategyTest.storage, strategyTest.cacheMgn, strategyTest.userSvr)
+		assert.Error(t, err)
+	})
+}
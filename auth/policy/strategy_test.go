@@ -21,11 +21,14 @@ import (
 	"context"
 	"math/rand"
 	"reflect"
+	"sort"
 	"testing"
 	"time"
 
 	"github.com/golang/mock/gomock"
+	apimodel "github.com/polarismesh/specification/source/go/api/v1/model"
 	apisecurity "github.com/polarismesh/specification/source/go/api/v1/security"
+	apiservice "github.com/polarismesh/specification/source/go/api/v1/service_manage"
 	"github.com/stretchr/testify/assert"
 	"google.golang.org/protobuf/types/known/wrapperspb"
 
@@ -38,6 +41,7 @@ import (
 	"github.com/polarismesh/polaris/common/eventhub"
 	"github.com/polarismesh/polaris/common/model"
 	"github.com/polarismesh/polaris/common/utils"
+	"github.com/polarismesh/polaris/store"
 	storemock "github.com/polarismesh/polaris/store/mock"
 )
 
@@ -59,7 +63,9 @@ type StrategyTest struct {
 	cacheMgn *cache.CacheManager
 	checker  auth.AuthChecker
 
-	svr auth.StrategyServer
+	svr       auth.StrategyServer
+	policySvr *policy.Server
+	userSvr   auth.UserServer
 
 	cancel context.CancelFunc
 
@@ -90,6 +96,8 @@ func newStrategyTest(t *testing.T) *StrategyTest {
 	storage.EXPECT().GetUnixSecond(gomock.Any()).AnyTimes().Return(time.Now().Unix(), nil)
 	storage.EXPECT().GetUsersForCache(gomock.Any(), gomock.Any()).AnyTimes().Return(users, nil)
 	storage.EXPECT().GetGroupsForCache(gomock.Any(), gomock.Any()).AnyTimes().Return(groups, nil)
+	storage.EXPECT().GetUserSessionsForCache(gomock.Any(), gomock.Any()).AnyTimes().Return(nil, nil)
+	storage.EXPECT().GetRolesForCache(gomock.Any(), gomock.Any()).AnyTimes().Return(nil, nil)
 	storage.EXPECT().GetStrategyDetailsForCache(gomock.Any(), gomock.Any()).AnyTimes().Return(allStrategies, nil)
 	storage.EXPECT().GetMoreNamespaces(gomock.Any()).AnyTimes().Return(namespaces, nil)
 	storage.EXPECT().GetMoreServices(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes().Return(serviceMap, nil)
@@ -143,7 +151,7 @@ func newStrategyTest(t *testing.T) *StrategyTest {
 		},
 	}, storage, cacheMgn)
 
-	_, svr, err := newPolicyServer()
+	policySvr, svr, err := newPolicyServer()
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -178,7 +186,9 @@ func newStrategyTest(t *testing.T) *StrategyTest {
 
 		cancel: cancel,
 
-		svr: svr,
+		svr:       svr,
+		policySvr: policySvr,
+		userSvr:   proxySvr,
 
 		ctrl: ctrl,
 	}
@@ -353,6 +363,37 @@ func Test_CreateStrategy(t *testing.T) {
 		assert.Equal(t, api.NotFoundUserGroup, resp.Code.GetValue(), resp.Info.GetValue())
 	})
 
+	t.Run("批量创建鉴权策略-部分失败", func(t *testing.T) {
+		strategyTest.storage.EXPECT().AddStrategy(gomock.Any()).Return(nil)
+
+		valCtx := context.WithValue(context.Background(), utils.ContextAuthTokenKey, strategyTest.users[0].Token)
+		newReq := func(name, userID string) *apisecurity.AuthStrategy {
+			return &apisecurity.AuthStrategy{
+				Id:   &wrapperspb.StringValue{Value: utils.NewUUID()},
+				Name: &wrapperspb.StringValue{Value: name},
+				Principals: &apisecurity.Principals{
+					Users: []*apisecurity.Principal{{Id: &wrapperspb.StringValue{Value: userID}}},
+				},
+				Resources: &apisecurity.StrategyResources{},
+			}
+		}
+
+		resp := strategyTest.svr.CreateStrategies(valCtx, []*apisecurity.AuthStrategy{
+			newReq("批量创建鉴权策略-成功", strategyTest.users[1].ID),
+			newReq("批量创建鉴权策略-关联用户不存在", utils.NewUUID()),
+		})
+
+		assert.Equal(t, uint32(2), resp.GetSize().GetValue())
+		assert.Equal(t, api.NotFoundUser, resp.Code.GetValue(), resp.Info.GetValue())
+		assert.Equal(t, api.ExecuteSuccess, resp.Responses[0].Code.GetValue(), resp.Responses[0].Info.GetValue())
+		assert.Equal(t, api.NotFoundUser, resp.Responses[1].Code.GetValue(), resp.Responses[1].Info.GetValue())
+	})
+
+	t.Run("批量创建鉴权策略-空请求", func(t *testing.T) {
+		valCtx := context.WithValue(context.Background(), utils.ContextAuthTokenKey, strategyTest.users[0].Token)
+		resp := strategyTest.svr.CreateStrategies(valCtx, nil)
+		assert.Equal(t, api.EmptyRequest, resp.Code.GetValue(), resp.Info.GetValue())
+	})
 }
 
 func Test_UpdateStrategy(t *testing.T) {
@@ -578,6 +619,148 @@ func Test_UpdateStrategy(t *testing.T) {
 
 }
 
+func Test_AttachStrategyResources(t *testing.T) {
+	strategyTest := newStrategyTest(t)
+	defer strategyTest.Clean()
+
+	_ = strategyTest.cacheMgn.TestUpdate()
+
+	strategy := strategyTest.strategies[0]
+	ownerCtx := context.WithValue(context.Background(), utils.ContextAuthTokenKey, strategyTest.users[0].Token)
+
+	t.Run("关联资源并设置过期时间", func(t *testing.T) {
+		expireTime := time.Now().Add(time.Hour)
+		strategyTest.storage.EXPECT().GetStrategyDetail(strategy.ID).Return(strategy, nil)
+		strategyTest.storage.EXPECT().LooseAddStrategyResources(gomock.Any()).
+			DoAndReturn(func(resources []model.StrategyResource) error {
+				assert.Equal(t, []model.StrategyResource{
+					{
+						StrategyID: strategy.ID,
+						ResType:    int32(apisecurity.ResourceType_Services),
+						ResID:      strategyTest.services[1].ID,
+						ExpireTime: expireTime,
+					},
+					{
+						StrategyID: strategy.ID,
+						ResType:    int32(apisecurity.ResourceType_Services),
+						ResID:      strategyTest.services[2].ID,
+					},
+				}, resources)
+				return nil
+			})
+
+		resp := strategyTest.svr.AttachStrategyResources(ownerCtx, strategy.ID, []model.StrategyResource{
+			{
+				ResType:    int32(apisecurity.ResourceType_Services),
+				ResID:      strategyTest.services[1].ID,
+				ExpireTime: expireTime,
+			},
+			{
+				ResType: int32(apisecurity.ResourceType_Services),
+				ResID:   strategyTest.services[2].ID,
+			},
+		})
+		assert.Equal(t, api.ExecuteSuccess, resp.GetCode().GetValue(), resp.GetInfo().GetValue())
+	})
+
+	t.Run("过期时间早于当前时间", func(t *testing.T) {
+		strategyTest.storage.EXPECT().GetStrategyDetail(strategy.ID).Return(strategy, nil)
+		strategyTest.storage.EXPECT().LooseAddStrategyResources(gomock.Any()).Times(0)
+
+		resp := strategyTest.svr.AttachStrategyResources(ownerCtx, strategy.ID, []model.StrategyResource{
+			{
+				ResType:    int32(apisecurity.ResourceType_Services),
+				ResID:      strategyTest.services[1].ID,
+				ExpireTime: time.Now().Add(-time.Minute),
+			},
+		})
+		assert.Equal(t, api.InvalidParameter, resp.GetCode().GetValue())
+	})
+
+	t.Run("子账户不能修改鉴权策略", func(t *testing.T) {
+		subCtx := context.WithValue(context.Background(), utils.ContextAuthTokenKey, strategyTest.users[1].Token)
+		strategyTest.storage.EXPECT().LooseAddStrategyResources(gomock.Any()).Times(0)
+
+		resp := strategyTest.svr.AttachStrategyResources(subCtx, strategy.ID, []model.StrategyResource{
+			{
+				ResType: int32(apisecurity.ResourceType_Services),
+				ResID:   strategyTest.services[1].ID,
+			},
+		})
+		assert.NotEqual(t, api.ExecuteSuccess, resp.GetCode().GetValue())
+	})
+}
+
+func Test_AttachStrategyResources_DuplicateResourceMode(t *testing.T) {
+	strategyTest := newStrategyTest(t)
+	defer strategyTest.Clean()
+
+	initWithMode := func(mode string) error {
+		err := strategyTest.svr.Initialize(&auth.Config{
+			Strategy: &auth.StrategyConfig{
+				Name: auth.DefaultPolicyPluginName,
+				Option: map[string]interface{}{
+					"duplicateResourceMode": mode,
+				},
+			},
+		}, strategyTest.storage, strategyTest.cacheMgn, strategyTest.userSvr)
+		_ = strategyTest.cacheMgn.TestUpdate()
+		return err
+	}
+
+	strategy := strategyTest.strategies[0]
+	ownerCtx := context.WithValue(context.Background(), utils.ContextAuthTokenKey, strategyTest.users[0].Token)
+	// services[0] 已经关联到策略，services[1] 在本次导入中重复出现
+	newResources := func() []model.StrategyResource {
+		ret := make([]model.StrategyResource, 0, 4)
+		for _, svc := range []*model.Service{strategyTest.services[0], strategyTest.services[1],
+			strategyTest.services[1], strategyTest.services[2]} {
+			ret = append(ret, model.StrategyResource{
+				ResType: int32(apisecurity.ResourceType_Services),
+				ResID:   svc.ID,
+			})
+		}
+		return ret
+	}
+	strategyTest.storage.EXPECT().GetStrategyDetail(strategy.ID).Return(strategy, nil).AnyTimes()
+
+	t.Run("忽略重复的资源", func(t *testing.T) {
+		assert.NoError(t, initWithMode(policy.DuplicateResourceIgnore))
+		strategyTest.storage.EXPECT().LooseAddStrategyResources(gomock.Any()).Return(nil)
+
+		resp := strategyTest.svr.AttachStrategyResources(ownerCtx, strategy.ID, newResources())
+		assert.Equal(t, api.ExecuteSuccess, resp.GetCode().GetValue(), resp.GetInfo().GetValue())
+		assert.NotContains(t, resp.GetInfo().GetValue(), "duplicate")
+	})
+
+	t.Run("统计重复的资源数量", func(t *testing.T) {
+		assert.NoError(t, initWithMode(policy.DuplicateResourceCount))
+		strategyTest.storage.EXPECT().LooseAddStrategyResources(gomock.Any()).Return(nil)
+
+		resp := strategyTest.svr.AttachStrategyResources(ownerCtx, strategy.ID, newResources())
+		assert.Equal(t, api.ExecuteSuccess, resp.GetCode().GetValue(), resp.GetInfo().GetValue())
+		assert.Contains(t, resp.GetInfo().GetValue(), "2 duplicate resources")
+	})
+
+	t.Run("存在重复的资源时报错", func(t *testing.T) {
+		assert.NoError(t, initWithMode(policy.DuplicateResourceError))
+		strategyTest.storage.EXPECT().LooseAddStrategyResources(gomock.Any()).Times(0)
+
+		resp := strategyTest.svr.AttachStrategyResources(ownerCtx, strategy.ID, newResources())
+		assert.Equal(t, uint32(apimodel.Code_ExistedResource), resp.GetCode().GetValue())
+		assert.Contains(t, resp.GetInfo().GetValue(), "2 duplicate resources")
+
+		// 没有重复的资源时正常导入
+		strategyTest.storage.EXPECT().LooseAddStrategyResources(gomock.Any()).Return(nil)
+		resp = strategyTest.svr.AttachStrategyResources(ownerCtx, strategy.ID, newResources()[3:])
+		assert.Equal(t, api.ExecuteSuccess, resp.GetCode().GetValue(), resp.GetInfo().GetValue())
+	})
+
+	t.Run("不支持的处理方式", func(t *testing.T) {
+		assert.Error(t, initWithMode("unknown"))
+	})
+}
+
 func Test_DeleteStrategy(t *testing.T) {
 	strategyTest := newStrategyTest(t)
 	defer strategyTest.Clean()
@@ -954,3 +1137,291 @@ func Test_AuthServer_NormalOperateStrategy(t *testing.T) {
 		assert.Equal(t, resp.GetUser().GetAuthToken().GetValue(), qresp.GetUser().GetAuthToken().GetValue())
 	})
 }
+
+func Test_BulkEnsureDefaultStrategies(t *testing.T) {
+	strategyTest := newStrategyTest(t)
+	defer strategyTest.Clean()
+
+	_ = strategyTest.cacheMgn.TestUpdate()
+
+	ownerCtx := context.WithValue(context.Background(), utils.ContextAuthTokenKey, strategyTest.users[0].Token)
+	principals := []model.Principal{
+		{PrincipalID: strategyTest.users[1].ID, PrincipalRole: model.PrincipalUser},
+		{PrincipalID: strategyTest.users[2].ID, PrincipalRole: model.PrincipalUser},
+		{PrincipalID: strategyTest.users[2].ID, PrincipalRole: model.PrincipalUser},
+		{PrincipalID: strategyTest.groups[1].ID, PrincipalRole: model.PrincipalGroup},
+	}
+	// 记录已经存在默认策略的 principal
+	created := map[model.Principal]*model.StrategyDetail{
+		principals[0]: {ID: "exist-default", Default: true},
+	}
+	strategyTest.storage.EXPECT().GetDefaultStrategyDetailByPrincipal(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(id string, role model.PrincipalType) (*model.StrategyDetail, error) {
+			return created[model.Principal{PrincipalID: id, PrincipalRole: role}], nil
+		}).AnyTimes()
+
+	t.Run("仅创建缺失的默认策略", func(t *testing.T) {
+		strategyTest.storage.EXPECT().AddStrategies(gomock.Any()).Times(1).
+			DoAndReturn(func(strategies []*model.StrategyDetail) error {
+				assert.Equal(t, 2, len(strategies))
+				for _, strategy := range strategies {
+					assert.True(t, strategy.Default)
+					assert.Equal(t, strategyTest.users[0].ID, strategy.Owner)
+					assert.Equal(t, 1, len(strategy.Principals))
+					created[strategy.Principals[0]] = strategy
+				}
+				return nil
+			})
+
+		report, err := strategyTest.svr.BulkEnsureDefaultStrategies(ownerCtx, principals)
+		assert.NoError(t, err)
+		assert.Equal(t, []model.Principal{principals[1], principals[3]}, report.Created)
+		assert.Equal(t, []model.Principal{principals[0]}, report.Skipped)
+	})
+
+	t.Run("重复执行不会再次创建", func(t *testing.T) {
+		strategyTest.storage.EXPECT().AddStrategies(gomock.Any()).Times(0)
+
+		report, err := strategyTest.svr.BulkEnsureDefaultStrategies(ownerCtx, principals)
+		assert.NoError(t, err)
+		assert.Equal(t, 0, len(report.Created))
+		assert.Equal(t, 3, len(report.Skipped))
+	})
+
+	t.Run("并发创建导致唯一键冲突时视为已存在", func(t *testing.T) {
+		concurrent := []model.Principal{
+			{PrincipalID: strategyTest.users[3].ID, PrincipalRole: model.PrincipalUser},
+			{PrincipalID: strategyTest.users[4].ID, PrincipalRole: model.PrincipalUser},
+		}
+		duplicate := store.NewStatusError(store.DuplicateEntryErr, "Duplicate entry")
+		gomock.InOrder(
+			// 批量写入时 users[3] 的默认策略已经被并发创建
+			strategyTest.storage.EXPECT().AddStrategies(gomock.Any()).Times(1).
+				DoAndReturn(func(strategies []*model.StrategyDetail) error {
+					assert.Equal(t, 2, len(strategies))
+					created[concurrent[0]] = &model.StrategyDetail{ID: "concurrent-default", Default: true}
+					return duplicate
+				}),
+			strategyTest.storage.EXPECT().AddStrategies(gomock.Any()).Times(1).
+				DoAndReturn(func(strategies []*model.StrategyDetail) error {
+					assert.Equal(t, 1, len(strategies))
+					assert.Equal(t, concurrent[1], strategies[0].Principals[0])
+					created[concurrent[1]] = strategies[0]
+					return nil
+				}),
+		)
+
+		report, err := strategyTest.svr.BulkEnsureDefaultStrategies(ownerCtx, concurrent)
+		assert.NoError(t, err)
+		assert.Equal(t, []model.Principal{concurrent[1]}, report.Created)
+		assert.Equal(t, []model.Principal{concurrent[0]}, report.Skipped)
+	})
+
+	t.Run("principal 不存在", func(t *testing.T) {
+		_, err := strategyTest.svr.BulkEnsureDefaultStrategies(ownerCtx, []model.Principal{
+			{PrincipalID: "not-exist-user", PrincipalRole: model.PrincipalUser},
+		})
+		assert.Error(t, err)
+	})
+}
+
+func Test_RecomputeDefaultStrategies(t *testing.T) {
+	strategyTest := newStrategyTest(t)
+	defer strategyTest.Clean()
+
+	users, groups := strategyTest.users, strategyTest.groups
+	storeGroups := make([]*model.UserGroup, 0, len(groups))
+	for i := range groups {
+		storeGroups = append(storeGroups, groups[i].UserGroup)
+	}
+	strategyTest.storage.EXPECT().GetUsers(gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ map[string]string, offset, limit uint32) (uint32, []*model.User, error) {
+			if offset > 0 {
+				return uint32(len(users)), nil, nil
+			}
+			return uint32(len(users)), users, nil
+		}).AnyTimes()
+	strategyTest.storage.EXPECT().GetGroups(gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ map[string]string, offset, limit uint32) (uint32, []*model.UserGroup, error) {
+			if offset > 0 {
+				return uint32(len(storeGroups)), nil, nil
+			}
+			return uint32(len(storeGroups)), storeGroups, nil
+		}).AnyTimes()
+
+	// users[1]、groups[1] 缺失默认策略，users[3] 存在两个默认策略
+	duplicate := strategyTest.strategies[3]
+	duplicate.Default = true
+	duplicate.Revision = utils.NewUUID()
+	_ = strategyTest.cacheMgn.TestUpdate()
+	kept := &model.StrategyDetail{
+		ID:         "kept-default",
+		Default:    true,
+		Principals: duplicate.Principals,
+		Resources: []model.StrategyResource{
+			{StrategyID: "kept-default", ResType: duplicate.Resources[0].ResType, ResID: duplicate.Resources[0].ResID},
+		},
+	}
+	defaults := map[model.Principal]*model.StrategyDetail{}
+	for i := range users {
+		defaults[model.Principal{PrincipalID: users[i].ID, PrincipalRole: model.PrincipalUser}] =
+			strategyTest.defaultStrategies[i]
+	}
+	for i := range groups {
+		defaults[model.Principal{PrincipalID: groups[i].ID, PrincipalRole: model.PrincipalGroup}] =
+			strategyTest.defaultStrategies[len(users)+i]
+	}
+	missingUser := model.Principal{PrincipalID: users[1].ID, PrincipalRole: model.PrincipalUser}
+	missingGroup := model.Principal{PrincipalID: groups[1].ID, PrincipalRole: model.PrincipalGroup}
+	delete(defaults, missingUser)
+	delete(defaults, missingGroup)
+	defaults[model.Principal{PrincipalID: users[3].ID, PrincipalRole: model.PrincipalUser}] = kept
+	strategyTest.storage.EXPECT().GetDefaultStrategyDetailByPrincipal(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(id string, role model.PrincipalType) (*model.StrategyDetail, error) {
+			return defaults[model.Principal{PrincipalID: id, PrincipalRole: role}], nil
+		}).AnyTimes()
+
+	t.Run("仅允许超级管理员操作", func(t *testing.T) {
+		ownerCtx := context.WithValue(context.Background(), utils.ContextAuthTokenKey, users[0].Token)
+		_, err := strategyTest.svr.RecomputeDefaultStrategies(ownerCtx, true)
+		assert.Error(t, err)
+	})
+
+	t.Run("dryRun 不做任何修改", func(t *testing.T) {
+		strategyTest.storage.EXPECT().AddStrategies(gomock.Any()).Times(0)
+		strategyTest.storage.EXPECT().LooseAddStrategyResources(gomock.Any()).Times(0)
+		strategyTest.storage.EXPECT().DeleteStrategy(gomock.Any()).Times(0)
+
+		report, err := strategyTest.policySvr.RecomputeDefaultStrategies(context.Background(), true)
+		assert.NoError(t, err)
+		assert.True(t, report.DryRun)
+		assert.Equal(t, len(users)+len(groups), report.Scanned)
+		assert.Equal(t, []model.Principal{missingUser, missingGroup}, report.Created)
+		assert.Equal(t, 1, len(report.Merged))
+		assert.Equal(t, kept.ID, report.Merged[0].Kept)
+		assert.Equal(t, []string{duplicate.ID}, report.Merged[0].Removed)
+		assert.Equal(t, 1, report.Merged[0].Resources)
+	})
+
+	t.Run("创建缺失的默认策略并合并重复的默认策略", func(t *testing.T) {
+		strategyTest.storage.EXPECT().AddStrategies(gomock.Any()).Times(2).
+			DoAndReturn(func(strategies []*model.StrategyDetail) error {
+				// 已经存在默认策略的 principal 不受影响
+				assert.Equal(t, 1, len(strategies))
+				assert.True(t, strategies[0].Default)
+				defaults[strategies[0].Principals[0]] = strategies[0]
+				return nil
+			})
+		strategyTest.storage.EXPECT().LooseAddStrategyResources(gomock.Any()).Times(1).
+			DoAndReturn(func(resources []model.StrategyResource) error {
+				assert.Equal(t, []model.StrategyResource{{StrategyID: kept.ID, ResType: duplicate.Resources[1].ResType,
+					ResID: duplicate.Resources[1].ResID}}, resources)
+				return nil
+			})
+		strategyTest.storage.EXPECT().DeleteStrategy(duplicate.ID).Times(1).Return(nil)
+
+		report, err := strategyTest.policySvr.RecomputeDefaultStrategies(context.Background(), false)
+		assert.NoError(t, err)
+		assert.Equal(t, []model.Principal{missingUser, missingGroup}, report.Created)
+		assert.Equal(t, 1, len(report.Merged))
+	})
+
+	t.Run("重复执行不再修改", func(t *testing.T) {
+		// 被删除的重复默认策略已经从缓存中移除
+		duplicate.Default = false
+		duplicate.Revision = utils.NewUUID()
+		_ = strategyTest.cacheMgn.TestUpdate()
+		strategyTest.storage.EXPECT().AddStrategies(gomock.Any()).Times(0)
+		strategyTest.storage.EXPECT().LooseAddStrategyResources(gomock.Any()).Times(0)
+		strategyTest.storage.EXPECT().DeleteStrategy(gomock.Any()).Times(0)
+
+		report, err := strategyTest.policySvr.RecomputeDefaultStrategies(context.Background(), false)
+		assert.NoError(t, err)
+		assert.Equal(t, 0, len(report.Created))
+		assert.Equal(t, 0, len(report.Merged))
+	})
+}
+
+func Test_SearchStrategies(t *testing.T) {
+	strategyTest := newStrategyTest(t)
+	defer strategyTest.Clean()
+
+	// 默认策略与普通策略的 mock 数据 ID 相同，缓存中只保留后写入的普通策略
+	cached := strategyTest.allStrategies[len(strategyTest.allStrategies)/2:]
+	commentRule := cached[3]
+	commentRule.Comment = "允许订单团队发布 Payment 服务"
+	nameRule := cached[4]
+	nameRule.Name = "payment-admin"
+	_ = strategyTest.cacheMgn.TestUpdate()
+
+	ownerCtx := context.WithValue(context.Background(), utils.ContextAuthTokenKey, strategyTest.users[0].Token)
+	names := func(resp *apiservice.BatchQueryResponse) []string {
+		ret := make([]string, 0, len(resp.GetAuthStrategies()))
+		for _, item := range resp.GetAuthStrategies() {
+			ret = append(ret, item.GetName().GetValue())
+		}
+		return ret
+	}
+
+	t.Run("按照名称片段搜索", func(t *testing.T) {
+		resp := strategyTest.svr.SearchStrategies(ownerCtx, "USER_user-3", 10)
+		assert.Equal(t, api.ExecuteSuccess, resp.GetCode().GetValue(), resp.GetInfo().GetValue())
+		assert.Equal(t, []string{"strategy_user_user-3_3"}, names(resp))
+
+		// 名称完全一致的排在前缀命中之前，前缀命中的排在包含命中之前
+		resp = strategyTest.svr.SearchStrategies(ownerCtx, "test-group-1_1", 10)
+		assert.Equal(t, []string{"strategy_group_test-group-1_1"}, names(resp))
+		resp = strategyTest.svr.SearchStrategies(ownerCtx, "payment-admin", 10)
+		assert.Equal(t, "payment-admin", names(resp)[0])
+	})
+
+	t.Run("按照描述片段搜索", func(t *testing.T) {
+		resp := strategyTest.svr.SearchStrategies(ownerCtx, "订单团队", 10)
+		assert.Equal(t, api.ExecuteSuccess, resp.GetCode().GetValue(), resp.GetInfo().GetValue())
+		assert.Equal(t, []string{commentRule.Name}, names(resp))
+
+		// 名称命中的排在描述命中之前
+		resp = strategyTest.svr.SearchStrategies(ownerCtx, "payment", 10)
+		assert.Equal(t, []string{nameRule.Name, commentRule.Name}, names(resp))
+
+		// 多个词需要全部命中名称或者描述
+		resp = strategyTest.svr.SearchStrategies(ownerCtx, "订单团队 "+commentRule.Name, 10)
+		assert.Equal(t, []string{commentRule.Name}, names(resp))
+		resp = strategyTest.svr.SearchStrategies(ownerCtx, "订单团队 not-exist", 10)
+		assert.Empty(t, names(resp))
+	})
+
+	t.Run("限制返回数量", func(t *testing.T) {
+		resp := strategyTest.svr.SearchStrategies(ownerCtx, "strategy_", 3)
+		assert.Equal(t, 3, len(resp.GetAuthStrategies()))
+		assert.Equal(t, uint32(len(cached)-1), resp.GetAmount().GetValue())
+	})
+
+	t.Run("子账户只能搜索到关联自己的策略", func(t *testing.T) {
+		subUser := strategyTest.users[2]
+		linked := map[string]struct{}{subUser.ID: {}}
+		for _, groupID := range strategyTest.cacheMgn.User().GetUserLinkGroupIds(subUser.ID) {
+			linked[groupID] = struct{}{}
+		}
+		expect := make([]string, 0, 2)
+		for _, rule := range cached {
+			for _, principal := range rule.Principals {
+				if _, ok := linked[principal.PrincipalID]; ok {
+					expect = append(expect, rule.Name)
+				}
+			}
+		}
+		sort.Strings(expect)
+
+		subCtx := context.WithValue(context.Background(), utils.ContextAuthTokenKey, subUser.Token)
+		resp := strategyTest.svr.SearchStrategies(subCtx, "strategy_", 100)
+		assert.Equal(t, api.ExecuteSuccess, resp.GetCode().GetValue(), resp.GetInfo().GetValue())
+		assert.NotEmpty(t, expect)
+		assert.Equal(t, expect, names(resp))
+	})
+
+	t.Run("搜索内容为空", func(t *testing.T) {
+		resp := strategyTest.svr.SearchStrategies(ownerCtx, "  ", 10)
+		assert.Equal(t, uint32(apimodel.Code_EmptyQueryParameter), resp.GetCode().GetValue())
+	})
+}
@@ -0,0 +1,70 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package policy
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/polarismesh/polaris/auth"
+	"github.com/polarismesh/polaris/common/model"
+)
+
+func Test_checkTokenLifetime(t *testing.T) {
+	now := time.Now()
+	// 外部 token 两个小时之前签发，自身的有效期为 24 小时
+	external := auth.OperatorInfo{
+		OperatorID: "external",
+		IssuedAt:   now.Add(-2 * time.Hour),
+		ExpireAt:   now.Add(22 * time.Hour),
+	}
+
+	t.Run("未配置最长有效期时按照 token 自身的过期时间", func(t *testing.T) {
+		checker := &DefaultAuthChecker{conf: &AuthConfig{}}
+		assert.NoError(t, checker.checkTokenLifetime(external, now))
+		expired := external
+		expired.ExpireAt = now.Add(-time.Second)
+		assert.True(t, errors.Is(checker.checkTokenLifetime(expired, now), model.ErrorTokenExpired))
+	})
+
+	t.Run("超过最长有效期的长期 token 被拒绝", func(t *testing.T) {
+		checker := &DefaultAuthChecker{conf: &AuthConfig{MaxTokenLifetimeInSecs: 3600}}
+		assert.True(t, errors.Is(checker.checkTokenLifetime(external, now), model.ErrorTokenExpired))
+
+		// 最长有效期内的 token 正常放通
+		fresh := external
+		fresh.IssuedAt = now.Add(-30 * time.Minute)
+		assert.NoError(t, checker.checkTokenLifetime(fresh, now))
+		assert.True(t, errors.Is(checker.checkTokenLifetime(fresh, now.Add(30*time.Minute)), model.ErrorTokenExpired))
+	})
+
+	t.Run("token 自身的过期时间更早时以自身为准", func(t *testing.T) {
+		checker := &DefaultAuthChecker{conf: &AuthConfig{MaxTokenLifetimeInSecs: 24 * 3600}}
+		short := external
+		short.ExpireAt = now.Add(-time.Minute)
+		assert.True(t, errors.Is(checker.checkTokenLifetime(short, now), model.ErrorTokenExpired))
+	})
+
+	t.Run("北极星自身签发的 token 不受最长有效期限制", func(t *testing.T) {
+		checker := &DefaultAuthChecker{conf: &AuthConfig{MaxTokenLifetimeInSecs: 1}}
+		assert.NoError(t, checker.checkTokenLifetime(auth.OperatorInfo{OperatorID: "polaris"}, now))
+	})
+}
@@ -0,0 +1,320 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package policy
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+
+	apisecurity "github.com/polarismesh/specification/source/go/api/v1/security"
+	"go.uber.org/zap"
+
+	"github.com/polarismesh/polaris/auth"
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/common/utils"
+)
+
+// replayToken 重放携带了 token 的决策时注入的占位 token，仅用于跳过匿名读取
+const replayToken = "__decision_replay__"
+
+// DecisionDiff 重放时两个鉴权配置给出不同结果的决策
+type DecisionDiff struct {
+	Record    auth.DecisionTraceRecord `json:"record"`
+	Base      bool                     `json:"base"`
+	Candidate bool                     `json:"candidate"`
+}
+
+// decisionTracer 按照固定容量环形记录最近的鉴权决策
+type decisionTracer struct {
+	lock    sync.Mutex
+	key     []byte
+	records []auth.DecisionTraceRecord
+	// principals principal ID 哈希值到原始 principal 的映射，只用于本节点重放，随记录淘汰
+	principals map[string]*tracedPrincipal
+	next       int
+	full       bool
+}
+
+type tracedPrincipal struct {
+	principal model.Principal
+	refs      int
+}
+
+// newDecisionTracer size 小于等于 0 时不记录
+func newDecisionTracer(size int) *decisionTracer {
+	if size <= 0 {
+		return nil
+	}
+	key := make([]byte, sha256.Size)
+	if _, err := rand.Read(key); err != nil {
+		log.Error("[Auth][Trace] generate decision trace key fail, disable decision trace", zap.Error(err))
+		return nil
+	}
+	return &decisionTracer{
+		key:        key,
+		records:    make([]auth.DecisionTraceRecord, size),
+		principals: make(map[string]*tracedPrincipal),
+	}
+}
+
+// hashPrincipalID 使用节点密钥计算 principal ID 的哈希值，匿名用户的空 ID 保持不变
+func (t *decisionTracer) hashPrincipalID(id string) string {
+	if id == "" {
+		return ""
+	}
+	mac := hmac.New(sha256.New, t.key)
+	_, _ = mac.Write([]byte(id))
+	return hex.EncodeToString(mac.Sum(nil)[:16])
+}
+
+func (t *decisionTracer) record(authCtx *model.AcquireContext, allowed bool) {
+	if t == nil {
+		return
+	}
+	principalID, _ := authCtx.GetAttachments()[model.OperatorIDKey].(string)
+	principalType, _ := authCtx.GetAttachments()[model.OperatorPrincipalType].(model.PrincipalType)
+	operator, _ := authCtx.GetAttachments()[model.TokenDetailInfoKey].(auth.OperatorInfo)
+	resources := make(map[apisecurity.ResourceType][]string, len(authCtx.GetAccessResources()))
+	for resType, entries := range authCtx.GetAccessResources() {
+		ids := make([]string, 0, len(entries))
+		for i := range entries {
+			ids = append(ids, entries[i].ID)
+		}
+		resources[resType] = ids
+	}
+	hashed := model.Principal{
+		PrincipalID:   t.hashPrincipalID(principalID),
+		PrincipalRole: principalType,
+	}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if t.full {
+		t.release(t.records[t.next].Principal.PrincipalID)
+	}
+	if hashed.PrincipalID != "" {
+		traced, ok := t.principals[hashed.PrincipalID]
+		if !ok {
+			traced = &tracedPrincipal{principal: model.Principal{
+				PrincipalID:   principalID,
+				PrincipalRole: principalType,
+			}}
+			t.principals[hashed.PrincipalID] = traced
+		}
+		traced.refs++
+	}
+	t.records[t.next] = auth.DecisionTraceRecord{
+		Principal:  hashed,
+		Module:     authCtx.GetModule(),
+		Method:     authCtx.GetMethod(),
+		FromClient: authCtx.IsFromClient(),
+		WithToken:  utils.ParseAuthToken(authCtx.GetRequestContext()) != "",
+		Anonymous:  operator.Anonymous,
+		Operation:  authCtx.GetOperation(),
+		Resources:  resources,
+		Allowed:    allowed,
+	}
+	t.next = (t.next + 1) % len(t.records)
+	if t.next == 0 {
+		t.full = true
+	}
+}
+
+// release 被覆盖的记录不再引用 principal 时，删除哈希值的映射
+func (t *decisionTracer) release(hashedID string) {
+	traced, ok := t.principals[hashedID]
+	if !ok {
+		return
+	}
+	traced.refs--
+	if traced.refs <= 0 {
+		delete(t.principals, hashedID)
+	}
+}
+
+// resolve 还原记录中的 principal，不是本节点记录的哈希值原样返回，重放时不会匹配任何 principal
+func (t *decisionTracer) resolve(hashed model.Principal) model.Principal {
+	if t == nil || hashed.PrincipalID == "" {
+		return hashed
+	}
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if traced, ok := t.principals[hashed.PrincipalID]; ok {
+		return traced.principal
+	}
+	return hashed
+}
+
+// list 按照记录的先后顺序返回
+func (t *decisionTracer) list() []auth.DecisionTraceRecord {
+	if t == nil {
+		return nil
+	}
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if !t.full {
+		return append([]auth.DecisionTraceRecord(nil), t.records[:t.next]...)
+	}
+	ret := make([]auth.DecisionTraceRecord, 0, len(t.records))
+	ret = append(ret, t.records[t.next:]...)
+	return append(ret, t.records[:t.next]...)
+}
+
+// DecisionTrace 获取最近记录的鉴权决策
+func (d *DefaultAuthChecker) DecisionTrace() []auth.DecisionTraceRecord {
+	return d.tracer.list()
+}
+
+// decide 根据当前的鉴权配置以及策略数据，按照 CheckClientPermission、CheckConsolePermission 相同的流程
+// 重新对记录的决策输入进行判断，不包含身份校验、强制同步缓存以及 break-glass
+func (d *DefaultAuthChecker) decide(record auth.DecisionTraceRecord, principal model.Principal) bool {
+	resources := make(map[apisecurity.ResourceType][]model.ResourceEntry, len(record.Resources))
+	for resType, ids := range record.Resources {
+		entries := make([]model.ResourceEntry, 0, len(ids))
+		for i := range ids {
+			entries = append(entries, model.ResourceEntry{ID: ids[i]})
+		}
+		resources[resType] = entries
+	}
+	ctx := context.Background()
+	if record.WithToken {
+		ctx = context.WithValue(ctx, utils.ContextAuthTokenKey, replayToken)
+	}
+	authCtx := model.NewAcquireContext(
+		model.WithRequestContext(ctx),
+		model.WithModule(record.Module),
+		model.WithMethod(record.Method),
+		model.WithOperation(record.Operation),
+		model.WithAccessResources(resources),
+		model.WithAttachment(map[string]interface{}{
+			model.OperatorIDKey:         principal.PrincipalID,
+			model.OperatorPrincipalType: principal.PrincipalRole,
+		}),
+	)
+	if record.FromClient {
+		authCtx.SetFromClient()
+		if !d.IsOpenClientAuth() {
+			return true
+		}
+		authCtx.SetAllowAnonymous(!d.conf.ClientStrict)
+	} else {
+		authCtx.SetFromConsole()
+		if !d.IsOpenConsoleAuth() {
+			return true
+		}
+		authCtx.SetAllowAnonymous(!d.conf.ConsoleStrict)
+	}
+	if d.isAnonymousReadable(authCtx) {
+		return d.evaluate(authCtx, withAnonymousPrincipal(authCtx))
+	}
+	// 与 CheckCredential 降级匿名用户的规则保持一致，严格模式以及鉴权模块的请求在校验 token 时就会被拒绝
+	if record.Anonymous && (!authCtx.IsAllowAnonymous() || record.Module == model.AuthModule) {
+		return false
+	}
+	return d.evaluate(authCtx, principal)
+}
+
+// ReplayDecisionTrace 使用两个鉴权配置分别重放决策记录，返回结果不一致的决策，记录中的 principal 通过 base 的决策记录还原
+func ReplayDecisionTrace(trace []auth.DecisionTraceRecord, base, candidate *DefaultAuthChecker) []DecisionDiff {
+	return replayDecisionTrace(trace, base.tracer, base, candidate)
+}
+
+func replayDecisionTrace(trace []auth.DecisionTraceRecord, tracer *decisionTracer,
+	base, candidate *DefaultAuthChecker) []DecisionDiff {
+	diffs := make([]DecisionDiff, 0)
+	for i := range trace {
+		principal := tracer.resolve(trace[i].Principal)
+		baseRet := base.decide(trace[i], principal)
+		candidateRet := candidate.decide(trace[i], principal)
+		if baseRet != candidateRet {
+			diffs = append(diffs, DecisionDiff{
+				Record:    trace[i],
+				Base:      baseRet,
+				Candidate: candidateRet,
+			})
+		}
+	}
+	return diffs
+}
+
+// DiffAccess 使用当前配置以及待变更的配置分别重放决策记录，给出变更后新增拒绝以及新增放通的决策。
+// 重放使用独立的鉴权检查器，不会记录决策、更新资源关联关系的最近使用时间
+func (d *DefaultAuthChecker) DiffAccess(proposed *AuthConfig,
+	records []auth.DecisionTraceRecord) (*auth.AccessDelta, error) {
+	base, err := d.newReplayChecker(d.conf)
+	if err != nil {
+		return nil, err
+	}
+	candidate, err := d.newReplayChecker(proposed)
+	if err != nil {
+		return nil, err
+	}
+	delta := &auth.AccessDelta{
+		NewlyDenied:  make([]auth.DecisionTraceRecord, 0),
+		NewlyAllowed: make([]auth.DecisionTraceRecord, 0),
+	}
+	for _, diff := range replayDecisionTrace(records, d.tracer, base, candidate) {
+		if diff.Base {
+			delta.NewlyDenied = append(delta.NewlyDenied, diff.Record)
+		} else {
+			delta.NewlyAllowed = append(delta.NewlyAllowed, diff.Record)
+		}
+	}
+	return delta, nil
+}
+
+func (d *DefaultAuthChecker) newReplayChecker(conf *AuthConfig) (*DefaultAuthChecker, error) {
+	replayConf := *conf
+	replayConf.DecisionTraceSize = 0
+	replayConf.LastUsedMode = LastUsedOff
+	checker := &DefaultAuthChecker{}
+	if err := checker.Initialize(&replayConf, d.storage, d.cacheMgr, d.userSvr); err != nil {
+		return nil, err
+	}
+	// 置顶决策仅保存在当前节点的内存中，重放时需要与当前检查器保持一致
+	checker.pins = d.pins
+	return checker, nil
+}
+
+// handleReplayDecisionTrace 在当前鉴权配置的基础上覆盖 proposed 中的配置项，重放当前节点最近记录的鉴权决策
+func (svr *Server) handleReplayDecisionTrace(ctx context.Context,
+	proposed map[string]interface{}) (*auth.AccessDelta, error) {
+	// 通过序列化复制当前配置，避免覆盖配置项时修改正在生效的配置
+	current, err := json.Marshal(svr.checker.GetConfig())
+	if err != nil {
+		return nil, err
+	}
+	conf := &AuthConfig{}
+	if err := json.Unmarshal(current, conf); err != nil {
+		return nil, err
+	}
+	patch, err := json.Marshal(proposed)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(patch, conf); err != nil {
+		log.Error("[Auth][Trace] parse proposed auth config fail", utils.RequestID(ctx), zap.Error(err))
+		return nil, err
+	}
+	return svr.checker.DiffAccess(conf, svr.checker.DecisionTrace())
+}
@@ -0,0 +1,264 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package policy_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	apisecurity "github.com/polarismesh/specification/source/go/api/v1/security"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/polarismesh/polaris/auth"
+	"github.com/polarismesh/polaris/auth/policy"
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/common/utils"
+)
+
+func Test_ReplayDecisionTrace(t *testing.T) {
+	strategyTest := newStrategyTest(t)
+	defer strategyTest.Clean()
+
+	initWithOptions := func(options map[string]interface{}) *policy.DefaultAuthChecker {
+		err := strategyTest.svr.Initialize(&auth.Config{
+			Strategy: &auth.StrategyConfig{
+				Name:   auth.DefaultPolicyPluginName,
+				Option: options,
+			},
+		}, strategyTest.storage, strategyTest.cacheMgn, strategyTest.userSvr)
+		assert.NoError(t, err)
+		_ = strategyTest.cacheMgn.TestUpdate()
+		return strategyTest.policySvr.GetAuthChecker().(*policy.DefaultAuthChecker)
+	}
+	newChecker := func(conf *policy.AuthConfig) *policy.DefaultAuthChecker {
+		checker := &policy.DefaultAuthChecker{}
+		assert.NoError(t, checker.Initialize(conf, strategyTest.storage, strategyTest.cacheMgn, strategyTest.userSvr))
+		return checker
+	}
+	// runChecks 以不同的用户对不同的服务进行读写操作，返回被拒绝的次数
+	runChecks := func(checker *policy.DefaultAuthChecker) int {
+		denied := 0
+		for _, user := range strategyTest.users[1:4] {
+			for _, svc := range strategyTest.services[1:5] {
+				for _, op := range []model.ResourceOperation{model.Read, model.Modify} {
+					ctx := context.WithValue(context.Background(), utils.ContextAuthTokenKey, user.Token)
+					authCtx := model.NewAcquireContext(
+						model.WithRequestContext(ctx),
+						model.WithMethod("Test_ReplayDecisionTrace"),
+						model.WithOperation(op),
+						model.WithModule(model.DiscoverModule),
+						model.WithAccessResources(map[apisecurity.ResourceType][]model.ResourceEntry{
+							apisecurity.ResourceType_Services: {{ID: svc.ID, Owner: svc.Owner}},
+						}),
+					)
+					if pass, _ := checker.CheckConsolePermission(authCtx); !pass {
+						denied++
+					}
+				}
+			}
+		}
+		return denied
+	}
+
+	t.Run("记录决策并使用相同配置重放", func(t *testing.T) {
+		checker := initWithOptions(map[string]interface{}{
+			"consoleOpen":       true,
+			"decisionTraceSize": 100,
+		})
+		denied := runChecks(checker)
+		assert.True(t, denied > 0)
+
+		trace := checker.DecisionTrace()
+		assert.Equal(t, 3*4*2, len(trace))
+		deniedInTrace := 0
+		rawIDs := make(map[string]struct{}, len(strategyTest.users))
+		for _, user := range strategyTest.users {
+			rawIDs[user.ID] = struct{}{}
+		}
+		for _, record := range trace {
+			// 记录中只保留 principal ID 的哈希值
+			assert.NotEmpty(t, record.Principal.PrincipalID)
+			_, ok := rawIDs[record.Principal.PrincipalID]
+			assert.False(t, ok)
+			assert.True(t, record.WithToken)
+			assert.Equal(t, "Test_ReplayDecisionTrace", record.Method)
+			if !record.Allowed {
+				deniedInTrace++
+			}
+		}
+		assert.Equal(t, denied, deniedInTrace)
+
+		diffs := policy.ReplayDecisionTrace(trace, checker, newChecker(checker.GetConfig()))
+		assert.Empty(t, diffs)
+	})
+
+	t.Run("变更配置后重放得到差异", func(t *testing.T) {
+		checker := initWithOptions(map[string]interface{}{
+			"consoleOpen":       true,
+			"decisionTraceSize": 100,
+		})
+		denied := runChecks(checker)
+		trace := checker.DecisionTrace()
+
+		conf := *checker.GetConfig()
+		conf.ConsoleOpen = false
+		diffs := policy.ReplayDecisionTrace(trace, checker, newChecker(&conf))
+		assert.Equal(t, denied, len(diffs))
+		for _, diff := range diffs {
+			assert.False(t, diff.Record.Allowed)
+			assert.Equal(t, model.Modify, diff.Record.Operation)
+			assert.False(t, diff.Base)
+			assert.True(t, diff.Candidate)
+		}
+	})
+
+	t.Run("超出容量时仅保留最近的决策", func(t *testing.T) {
+		checker := initWithOptions(map[string]interface{}{
+			"consoleOpen":       true,
+			"decisionTraceSize": 5,
+		})
+		_ = runChecks(checker)
+		trace := checker.DecisionTrace()
+		assert.Equal(t, 5, len(trace))
+		last := trace[len(trace)-1]
+		// 同一个 principal 的哈希值保持一致
+		assert.NotEqual(t, strategyTest.users[3].ID, last.Principal.PrincipalID)
+		assert.Equal(t, trace[len(trace)-2].Principal.PrincipalID, last.Principal.PrincipalID)
+		assert.Equal(t, []string{strategyTest.services[4].ID}, last.Resources[apisecurity.ResourceType_Services])
+	})
+
+	t.Run("开启客户端严格模式前分析新增拒绝的请求", func(t *testing.T) {
+		checker := initWithOptions(map[string]interface{}{
+			"clientOpen":        true,
+			"decisionTraceSize": 100,
+		})
+		checkClient := func(token string, svc *model.Service) bool {
+			ctx := context.Background()
+			if token != "" {
+				ctx = context.WithValue(ctx, utils.ContextAuthTokenKey, token)
+			}
+			authCtx := model.NewAcquireContext(
+				model.WithRequestContext(ctx),
+				model.WithMethod("Test_ReplayDecisionTrace"),
+				model.WithOperation(model.Read),
+				model.WithModule(model.DiscoverModule),
+				model.WithAccessResources(map[apisecurity.ResourceType][]model.ResourceEntry{
+					apisecurity.ResourceType_Services: {{ID: svc.ID, Owner: svc.Owner}},
+				}),
+			)
+			pass, _ := checker.CheckClientPermission(authCtx)
+			return pass
+		}
+		// 携带 token 以及未携带 token 的客户端读请求在非严格模式下均放通
+		anonymous := make(map[string]struct{})
+		for i, svc := range strategyTest.services[1:5] {
+			assert.True(t, checkClient(strategyTest.users[1].Token, svc))
+			if i%2 == 0 {
+				assert.True(t, checkClient("", svc))
+				anonymous[svc.ID] = struct{}{}
+			}
+		}
+		trace := checker.DecisionTrace()
+		assert.Equal(t, 4+len(anonymous), len(trace))
+
+		proposed := *checker.GetConfig()
+		proposed.ClientStrict = true
+		delta, err := checker.DiffAccess(&proposed, trace)
+		assert.NoError(t, err)
+		assert.Empty(t, delta.NewlyAllowed)
+		assert.Equal(t, len(anonymous), len(delta.NewlyDenied))
+		for _, record := range delta.NewlyDenied {
+			assert.True(t, record.Anonymous)
+			assert.True(t, record.FromClient)
+			_, ok := anonymous[record.Resources[apisecurity.ResourceType_Services][0]]
+			assert.True(t, ok)
+		}
+
+		// 配置不变时不产生差异，重放不会记录新的决策
+		delta, err = checker.DiffAccess(checker.GetConfig(), trace)
+		assert.NoError(t, err)
+		assert.Empty(t, delta.NewlyDenied)
+		assert.Empty(t, delta.NewlyAllowed)
+		assert.Equal(t, len(trace), len(checker.DecisionTrace()))
+	})
+
+	t.Run("重放时置顶决策优先于鉴权策略", func(t *testing.T) {
+		checker := initWithOptions(map[string]interface{}{
+			"consoleOpen":       true,
+			"decisionTraceSize": 100,
+		})
+		_ = runChecks(checker)
+		trace := checker.DecisionTrace()
+
+		user, svc := strategyTest.users[1], strategyTest.services[1]
+		err := strategyTest.policySvr.CreateDecisionPin(context.Background(), &auth.DecisionPin{
+			Principal:    model.Principal{PrincipalID: user.ID, PrincipalRole: model.PrincipalUser},
+			ResourceType: apisecurity.ResourceType_Services,
+			ResourceID:   svc.ID,
+			Allow:        false,
+			Reason:       "incident",
+			ExpireTime:   time.Now().Add(time.Minute),
+		})
+		assert.NoError(t, err)
+
+		// 没有置顶决策的检查器上，该用户对该服务的读请求仍然放通
+		diffs := policy.ReplayDecisionTrace(trace, checker, newChecker(checker.GetConfig()))
+		assert.NotEmpty(t, diffs)
+		for _, diff := range diffs {
+			assert.Equal(t, []string{svc.ID}, diff.Record.Resources[apisecurity.ResourceType_Services])
+			assert.False(t, diff.Base)
+			assert.True(t, diff.Candidate)
+		}
+
+		// DiffAccess 的两个检查器使用相同的置顶决策，不产生差异
+		delta, err := checker.DiffAccess(checker.GetConfig(), trace)
+		assert.NoError(t, err)
+		assert.Empty(t, delta.NewlyDenied)
+		assert.Empty(t, delta.NewlyAllowed)
+	})
+
+	t.Run("通过接口查询以及重放决策记录", func(t *testing.T) {
+		checker := initWithOptions(map[string]interface{}{
+			"consoleOpen":       true,
+			"decisionTraceSize": 100,
+		})
+		denied := runChecks(checker)
+
+		trace, err := strategyTest.policySvr.ListDecisionTrace(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, 3*4*2, len(trace))
+
+		delta, err := strategyTest.policySvr.ReplayDecisionTrace(context.Background(), map[string]interface{}{
+			"consoleOpen": false,
+		})
+		assert.NoError(t, err)
+		assert.Empty(t, delta.NewlyDenied)
+		assert.Equal(t, denied, len(delta.NewlyAllowed))
+		// 重放不会修改当前生效的配置
+		assert.True(t, checker.GetConfig().ConsoleOpen)
+	})
+
+	t.Run("未开启时不记录", func(t *testing.T) {
+		checker := initWithOptions(map[string]interface{}{
+			"consoleOpen": true,
+		})
+		_ = runChecks(checker)
+		assert.Empty(t, checker.DecisionTrace())
+	})
+}
@@ -0,0 +1,286 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package policy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	apimodel "github.com/polarismesh/specification/source/go/api/v1/model"
+	apisecurity "github.com/polarismesh/specification/source/go/api/v1/security"
+	apiservice "github.com/polarismesh/specification/source/go/api/v1/service_manage"
+	"go.uber.org/zap"
+
+	"github.com/polarismesh/polaris/auth"
+	api "github.com/polarismesh/polaris/common/api/v1"
+	"github.com/polarismesh/polaris/common/model"
+	commonstore "github.com/polarismesh/polaris/common/store"
+	"github.com/polarismesh/polaris/common/utils"
+)
+
+// handleTransferOwnership 将资源从 from 的默认策略转移到 to 的默认策略
+// step 1. 查询双方的默认策略，资源必须关联在 from 的默认策略中
+// step 2. 在同一个存储事务中先关联到 to 的默认策略，再从 from 的默认策略中移除, 转移过程中始终有一方拥有权限
+// step 3. 强制同步策略缓存，并记录一条包含双方信息的操作记录
+func (svr *Server) handleTransferOwnership(ctx context.Context, resource model.StrategyResource,
+	from, to model.Principal) *apiservice.Response {
+	requestID := utils.ParseRequestID(ctx)
+	if resource.ResID == "" || from == to {
+		return api.NewAuthResponse(apimodel.Code_InvalidParameter)
+	}
+	switch apisecurity.ResourceType(resource.ResType) {
+	case apisecurity.ResourceType_Namespaces, apisecurity.ResourceType_Services,
+		apisecurity.ResourceType_ConfigGroups:
+	default:
+		return api.NewAuthResponse(apimodel.Code_InvalidParameter)
+	}
+
+	fromRule, err := svr.storage.GetDefaultStrategyDetailByPrincipal(from.PrincipalID, from.PrincipalRole)
+	if err != nil {
+		log.Error("[Auth][Strategy] get source default strategy from store", utils.ZapRequestID(requestID),
+			zap.String("principal", from.PrincipalID), zap.Error(err))
+		return api.NewAuthResponse(commonstore.StoreCode2APICode(err))
+	}
+	toRule, err := svr.storage.GetDefaultStrategyDetailByPrincipal(to.PrincipalID, to.PrincipalRole)
+	if err != nil {
+		log.Error("[Auth][Strategy] get target default strategy from store", utils.ZapRequestID(requestID),
+			zap.String("principal", to.PrincipalID), zap.Error(err))
+		return api.NewAuthResponse(commonstore.StoreCode2APICode(err))
+	}
+	if fromRule == nil || toRule == nil {
+		return api.NewAuthResponse(apimodel.Code_NotFoundAuthStrategyRule)
+	}
+
+	var linked *model.StrategyResource
+	for i := range fromRule.Resources {
+		res := fromRule.Resources[i]
+		if res.ResType == resource.ResType && res.ResID == resource.ResID {
+			linked = &res
+			break
+		}
+	}
+	if linked == nil {
+		log.Error("[Auth][Strategy] transfer resource not linked to source default strategy",
+			utils.ZapRequestID(requestID), zap.String("strategy", fromRule.ID), zap.String("resource", resource.ResID))
+		return api.NewAuthResponse(apimodel.Code_NotFoundResource)
+	}
+	linked.StrategyID = fromRule.ID
+
+	if err := svr.storage.TransferStrategyResource(*linked, toRule.ID); err != nil {
+		log.Error("[Auth][Strategy] transfer strategy resource in store", utils.ZapRequestID(requestID),
+			zap.String("from", fromRule.ID), zap.String("to", toRule.ID), zap.Error(err))
+		return api.NewAuthResponseWithMsg(commonstore.StoreCode2APICode(err), err.Error())
+	}
+	if err := svr.cacheMgr.AuthStrategy().ForceSync(); err != nil {
+		log.Error("[Auth][Strategy] force sync strategy cache after transfer", utils.ZapRequestID(requestID),
+			zap.Error(err))
+	}
+
+	log.Info("[Auth][Strategy] transfer resource ownership", utils.ZapRequestID(requestID),
+		zap.String("resource", resource.ResID), zap.String("from", fromRule.ID), zap.String("to", toRule.ID))
+	svr.RecordHistory(&model.RecordEntry{
+		ResourceType:  model.RAuthStrategy,
+		ResourceName:  fmt.Sprintf("%s(%s) -> %s(%s)", fromRule.Name, fromRule.ID, toRule.Name, toRule.ID),
+		OperationType: model.OUpdate,
+		Operator:      utils.ParseOperator(ctx),
+		ChangeSetID:   utils.ParseChangeSetID(ctx),
+		Detail: utils.MustJson(map[string]interface{}{
+			"resource": linked,
+			"from":     from,
+			"to":       to,
+		}),
+		HappenTime: time.Now(),
+	})
+	return api.NewAuthResponse(apimodel.Code_ExecuteSuccess)
+}
+
+var (
+	// ErrorInvalidTransferPrincipal 转移双方的 principal 不合法
+	ErrorInvalidTransferPrincipal = errors.New("invalid transfer principal")
+)
+
+// handleTransferAllOwnership 将 from 默认策略中的全部服务、配置分组转移到 to 的默认策略
+// step 1. 逐个转移资源的默认授权，单个资源的转移与 TransferOwnership 一样在同一个存储事务中完成
+// step 2. 双方均为用户时，将服务、配置分组负责人中 from 的用户名替换为 to 的用户名
+// step 3. 强制同步策略缓存，并记录一条包含转移结果的操作记录
+func (svr *Server) handleTransferAllOwnership(ctx context.Context,
+	from, to model.Principal) (*auth.OwnershipTransferReport, error) {
+	if from.PrincipalID == "" || to.PrincipalID == "" || from == to {
+		return nil, ErrorInvalidTransferPrincipal
+	}
+	fromRule, err := svr.storage.GetDefaultStrategyDetailByPrincipal(from.PrincipalID, from.PrincipalRole)
+	if err != nil {
+		log.Error("[Auth][Strategy] get source default strategy from store", utils.RequestID(ctx),
+			zap.String("principal", from.PrincipalID), zap.Error(err))
+		return nil, err
+	}
+	toRule, err := svr.storage.GetDefaultStrategyDetailByPrincipal(to.PrincipalID, to.PrincipalRole)
+	if err != nil {
+		log.Error("[Auth][Strategy] get target default strategy from store", utils.RequestID(ctx),
+			zap.String("principal", to.PrincipalID), zap.Error(err))
+		return nil, err
+	}
+	if fromRule == nil || toRule == nil {
+		return nil, errors.New(api.Code2Info(api.NotFoundAuthStrategyRule))
+	}
+
+	report := &auth.OwnershipTransferReport{
+		From:         from,
+		To:           to,
+		Services:     []string{},
+		ConfigGroups: []string{},
+	}
+	defer svr.recordTransferAllOwnership(ctx, fromRule, toRule, report)
+
+	fromName, toName := svr.transferOwnerNames(from, to)
+	for i := range fromRule.Resources {
+		res := fromRule.Resources[i]
+		resType := apisecurity.ResourceType(res.ResType)
+		if resType != apisecurity.ResourceType_Services && resType != apisecurity.ResourceType_ConfigGroups {
+			continue
+		}
+		res.StrategyID = fromRule.ID
+		if err := svr.storage.TransferStrategyResource(res, toRule.ID); err != nil {
+			log.Error("[Auth][Strategy] transfer strategy resource in store", utils.RequestID(ctx),
+				zap.String("from", fromRule.ID), zap.String("to", toRule.ID), zap.String("resource", res.ResID),
+				zap.Error(err))
+			return report, err
+		}
+		if resType == apisecurity.ResourceType_Services {
+			report.Services = append(report.Services, res.ResID)
+		} else {
+			report.ConfigGroups = append(report.ConfigGroups, res.ResID)
+		}
+		if fromName == "" || toName == "" {
+			continue
+		}
+		updated, err := svr.transferResourceOwner(resType, res.ResID, fromName, toName)
+		if err != nil {
+			log.Error("[Auth][Strategy] transfer resource owner in store", utils.RequestID(ctx),
+				zap.String("resource", res.ResID), zap.Error(err))
+			return report, err
+		}
+		if updated {
+			report.Owners++
+		}
+	}
+	return report, nil
+}
+
+// transferOwnerNames 服务、配置分组的负责人保存的是用户名，只有双方均为用户时才需要更新负责人
+func (svr *Server) transferOwnerNames(from, to model.Principal) (string, string) {
+	if from.PrincipalRole != model.PrincipalUser || to.PrincipalRole != model.PrincipalUser {
+		return "", ""
+	}
+	userCache := svr.cacheMgr.User()
+	fromUser, toUser := userCache.GetUserByID(from.PrincipalID), userCache.GetUserByID(to.PrincipalID)
+	if fromUser == nil || toUser == nil {
+		return "", ""
+	}
+	return fromUser.Name, toUser.Name
+}
+
+// transferResourceOwner 更新服务、配置分组的负责人，负责人中不包含 from 时不做修改
+func (svr *Server) transferResourceOwner(resType apisecurity.ResourceType, resID, from, to string) (bool, error) {
+	switch resType {
+	case apisecurity.ResourceType_Services:
+		svc, err := svr.storage.GetServiceByID(resID)
+		if err != nil || svc == nil {
+			return false, err
+		}
+		owner, ok := replaceOwnerName(svc.Owner, from, to)
+		if !ok {
+			return false, nil
+		}
+		svc.Owner = owner
+		svc.Revision = utils.NewUUID()
+		return true, svr.storage.UpdateService(svc, true)
+	case apisecurity.ResourceType_ConfigGroups:
+		groupID, err := strconv.ParseUint(resID, 10, 64)
+		if err != nil {
+			return false, nil
+		}
+		cached := svr.cacheMgr.ConfigGroup().GetGroupByID(groupID)
+		if cached == nil {
+			return false, nil
+		}
+		group, err := svr.storage.GetConfigFileGroup(cached.Namespace, cached.Name)
+		if err != nil || group == nil {
+			return false, err
+		}
+		owner, ok := replaceOwnerName(group.Owner, from, to)
+		if !ok {
+			return false, nil
+		}
+		return true, svr.storage.UpdateConfigFileGroupOwner(group.Namespace, group.Name, owner)
+	default:
+		return false, nil
+	}
+}
+
+// replaceOwnerName 将逗号分隔的负责人列表中的 from 替换为 to，to 已经是负责人时只移除 from
+func replaceOwnerName(owners, from, to string) (string, bool) {
+	names := strings.Split(owners, ",")
+	ret := make([]string, 0, len(names))
+	replaced, exist := false, false
+	for i := range names {
+		name := strings.TrimSpace(names[i])
+		switch name {
+		case from:
+			replaced = true
+			continue
+		case to:
+			exist = true
+		}
+		ret = append(ret, name)
+	}
+	if !replaced {
+		return owners, false
+	}
+	if !exist {
+		ret = append(ret, to)
+	}
+	return strings.Join(ret, ","), true
+}
+
+// recordTransferAllOwnership 强制同步策略缓存，并记录转移结果，转移中途失败时同样记录已经转移的资源
+func (svr *Server) recordTransferAllOwnership(ctx context.Context, fromRule, toRule *model.StrategyDetail,
+	report *auth.OwnershipTransferReport) {
+	if len(report.Services) == 0 && len(report.ConfigGroups) == 0 {
+		return
+	}
+	if err := svr.cacheMgr.AuthStrategy().ForceSync(); err != nil {
+		log.Error("[Auth][Strategy] force sync strategy cache after transfer", utils.RequestID(ctx), zap.Error(err))
+	}
+	log.Info("[Auth][Strategy] transfer all resource ownership", utils.RequestID(ctx),
+		zap.String("from", fromRule.ID), zap.String("to", toRule.ID), zap.Int("services", len(report.Services)),
+		zap.Int("config_groups", len(report.ConfigGroups)))
+	svr.RecordHistory(&model.RecordEntry{
+		ResourceType:  model.RAuthStrategy,
+		ResourceName:  fmt.Sprintf("%s(%s) -> %s(%s)", fromRule.Name, fromRule.ID, toRule.Name, toRule.ID),
+		OperationType: model.OUpdate,
+		Operator:      utils.ParseOperator(ctx),
+		ChangeSetID:   utils.ParseChangeSetID(ctx),
+		Detail:        utils.MustJson(report),
+		HappenTime:    time.Now(),
+	})
+}
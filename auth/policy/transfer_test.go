@@ -0,0 +1,366 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package policy_test
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	apimodel "github.com/polarismesh/specification/source/go/api/v1/model"
+	apisecurity "github.com/polarismesh/specification/source/go/api/v1/security"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/polarismesh/polaris/auth"
+	"github.com/polarismesh/polaris/auth/policy"
+	defaultuser "github.com/polarismesh/polaris/auth/user"
+	"github.com/polarismesh/polaris/cache"
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/common/utils"
+)
+
+func Test_TransferOwnership(t *testing.T) {
+	reset(true)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	users := createMockUser(10)
+	groups := createMockUserGroup(users)
+	namespaces := createMockNamespace(len(users)+len(groups)+10, users[0].ID)
+	services := createMockService(namespaces)
+	serviceMap := convertServiceSliceToMap(services)
+	svc := services[len(services)-1]
+
+	from := model.Principal{PrincipalID: users[1].ID, PrincipalRole: model.PrincipalUser}
+	to := model.Principal{PrincipalID: users[2].ID, PrincipalRole: model.PrincipalUser}
+	newDefault := func(principal model.Principal, resources ...model.StrategyResource) *model.StrategyDetail {
+		id := utils.NewUUID()
+		for i := range resources {
+			resources[i].StrategyID = id
+		}
+		return &model.StrategyDetail{
+			ID:         id,
+			Name:       "default_" + principal.PrincipalID,
+			Action:     apisecurity.AuthAction_READ_WRITE.String(),
+			Default:    true,
+			Owner:      users[0].ID,
+			Principals: []model.Principal{principal},
+			Resources:  resources,
+			Valid:      true,
+			ModifyTime: time.Now(),
+		}
+	}
+	svcRes := model.StrategyResource{ResType: int32(apisecurity.ResourceType_Services), ResID: svc.ID}
+
+	var lock sync.Mutex
+	strategies := []*model.StrategyDetail{
+		newDefault(from, svcRes),
+		newDefault(to),
+		// 资源同时关联了其他 principal 的策略，转移过程中资源不会因为没有关联策略而对所有人放通
+		newDefault(model.Principal{PrincipalID: users[3].ID, PrincipalRole: model.PrincipalUser}, svcRes),
+	}
+	findDefault := func(id string) *model.StrategyDetail {
+		lock.Lock()
+		defer lock.Unlock()
+		for _, rule := range strategies {
+			if rule.Principals[0].PrincipalID == id {
+				return rule
+			}
+		}
+		return nil
+	}
+
+	cfg, storage := initCache(ctrl)
+	storage.EXPECT().GetUsersForCache(gomock.Any(), gomock.Any()).AnyTimes().Return(users, nil)
+	storage.EXPECT().GetGroupsForCache(gomock.Any(), gomock.Any()).AnyTimes().Return(groups, nil)
+	storage.EXPECT().GetUserSessionsForCache(gomock.Any(), gomock.Any()).AnyTimes().Return(nil, nil)
+	storage.EXPECT().GetRolesForCache(gomock.Any(), gomock.Any()).AnyTimes().Return(nil, nil)
+	storage.EXPECT().GetStrategyDetailsForCache(gomock.Any(), gomock.Any()).AnyTimes().DoAndReturn(
+		func(_ interface{}, _ bool) ([]*model.StrategyDetail, error) {
+			lock.Lock()
+			defer lock.Unlock()
+			return append([]*model.StrategyDetail(nil), strategies...), nil
+		})
+	storage.EXPECT().GetMoreNamespaces(gomock.Any()).AnyTimes().Return(namespaces, nil)
+	storage.EXPECT().GetMoreServices(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes().Return(serviceMap, nil)
+	storage.EXPECT().GetDefaultStrategyDetailByPrincipal(gomock.Any(), gomock.Any()).AnyTimes().DoAndReturn(
+		func(id string, _ model.PrincipalType) (*model.StrategyDetail, error) {
+			return findDefault(id), nil
+		})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cacheMgr, err := cache.TestCacheInitialize(ctx, cfg, storage)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		cancel()
+		cacheMgr.Close()
+	})
+
+	_, proxySvr, err := defaultuser.BuildServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	proxySvr.Initialize(&auth.Config{
+		User: &auth.UserConfig{
+			Name:   auth.DefaultUserMgnPluginName,
+			Option: map[string]interface{}{"salt": "polarismesh@2021"},
+		},
+	}, storage, cacheMgr)
+
+	_, svr, err := newPolicyServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := svr.Initialize(&auth.Config{
+		Strategy: &auth.StrategyConfig{Name: auth.DefaultPolicyPluginName},
+	}, storage, cacheMgr, proxySvr); err != nil {
+		t.Fatal(err)
+	}
+	_ = cacheMgr.TestUpdate()
+
+	checker := svr.GetAuthChecker()
+	dchecker := checker.(*policy.DefaultAuthChecker)
+	oldConf := dchecker.GetConfig()
+	defer dchecker.SetConfig(oldConf)
+	dchecker.SetConfig(&policy.AuthConfig{ConsoleOpen: true, ConsoleStrict: true})
+
+	canModify := func(user *model.User) bool {
+		authCtx := model.NewAcquireContext(
+			model.WithRequestContext(context.WithValue(context.Background(), utils.ContextAuthTokenKey, user.Token)),
+			model.WithMethod("Test_TransferOwnership"),
+			model.WithOperation(model.Modify),
+			model.WithModule(model.DiscoverModule),
+			model.WithAccessResources(map[apisecurity.ResourceType][]model.ResourceEntry{
+				apisecurity.ResourceType_Services: {{ID: svc.ID, Owner: svc.Owner}},
+			}),
+		)
+		pass, _ := checker.CheckConsolePermission(authCtx)
+		return pass
+	}
+	assert.True(t, canModify(users[1]))
+	assert.False(t, canModify(users[2]))
+
+	ownerCtx := context.WithValue(context.Background(), utils.ContextAuthTokenKey, users[0].Token)
+
+	t.Run("资源未关联在原默认策略中", func(t *testing.T) {
+		rsp := svr.TransferOwnership(ownerCtx, model.StrategyResource{
+			ResType: int32(apisecurity.ResourceType_Services), ResID: services[0].ID}, from, to)
+		assert.Equal(t, uint32(apimodel.Code_NotFoundResource), rsp.GetCode().GetValue())
+	})
+
+	t.Run("子账户不能转移", func(t *testing.T) {
+		subCtx := context.WithValue(context.Background(), utils.ContextAuthTokenKey, users[1].Token)
+		rsp := svr.TransferOwnership(subCtx, svcRes, from, to)
+		assert.NotEqual(t, uint32(apimodel.Code_ExecuteSuccess), rsp.GetCode().GetValue())
+		assert.True(t, canModify(users[1]))
+	})
+
+	t.Run("转移过程中始终有一方拥有权限", func(t *testing.T) {
+		storage.EXPECT().TransferStrategyResource(gomock.Any(), gomock.Any()).Times(1).DoAndReturn(
+			func(res model.StrategyResource, toID string) error {
+				// 事务提交前，原 owner 的权限仍然生效
+				assert.True(t, canModify(users[1]))
+
+				lock.Lock()
+				defer lock.Unlock()
+				now := time.Now()
+				for i, rule := range strategies {
+					updated := *rule
+					updated.Resources = nil
+					for _, item := range rule.Resources {
+						if rule.ID != res.StrategyID || item.ResType != res.ResType || item.ResID != res.ResID {
+							updated.Resources = append(updated.Resources, item)
+						}
+					}
+					if rule.ID == toID {
+						moved := res
+						moved.StrategyID = toID
+						updated.Resources = append(updated.Resources, moved)
+					}
+					updated.ModifyTime = now
+					strategies[i] = &updated
+				}
+				return nil
+			})
+
+		var (
+			stop     int32
+			gaps     int32
+			checks   int32
+			checkers sync.WaitGroup
+		)
+		checkers.Add(1)
+		go func() {
+			defer checkers.Done()
+			for atomic.LoadInt32(&stop) == 0 {
+				if !canModify(users[1]) && !canModify(users[2]) {
+					atomic.AddInt32(&gaps, 1)
+				}
+				atomic.AddInt32(&checks, 1)
+			}
+		}()
+		// 转移期间持续刷新缓存，覆盖缓存更新与鉴权并发的场景
+		for i := 0; i < 10; i++ {
+			_ = cacheMgr.TestUpdate()
+		}
+		rsp := svr.TransferOwnership(ownerCtx, svcRes, from, to)
+		for i := 0; i < 10; i++ {
+			_ = cacheMgr.TestUpdate()
+		}
+		atomic.StoreInt32(&stop, 1)
+		checkers.Wait()
+
+		assert.Equal(t, uint32(apimodel.Code_ExecuteSuccess), rsp.GetCode().GetValue(), rsp.GetInfo().GetValue())
+		assert.True(t, atomic.LoadInt32(&checks) > 0)
+		assert.Equal(t, int32(0), atomic.LoadInt32(&gaps))
+		// 转移完成后立即生效，无需等待缓存的定时刷新
+		assert.True(t, canModify(users[2]))
+		assert.False(t, canModify(users[1]))
+	})
+}
+
+func Test_TransferAllOwnership(t *testing.T) {
+	reset(true)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	users := createMockUser(10)
+	groups := createMockUserGroup(users)
+	namespaces := createMockNamespace(len(users)+len(groups)+10, users[0].ID)
+	services := createMockService(namespaces)
+	serviceMap := convertServiceSliceToMap(services)
+
+	from := model.Principal{PrincipalID: users[1].ID, PrincipalRole: model.PrincipalUser}
+	to := model.Principal{PrincipalID: users[2].ID, PrincipalRole: model.PrincipalUser}
+	svcOwners := map[string]string{
+		services[0].ID: users[1].Name + ",polaris",
+		services[1].ID: "polaris",
+	}
+	fromRule := &model.StrategyDetail{
+		ID: utils.NewUUID(), Name: "default_from", Default: true, Owner: users[0].ID, Valid: true,
+		Principals: []model.Principal{from},
+		Resources: []model.StrategyResource{
+			{ResType: int32(apisecurity.ResourceType_Namespaces), ResID: namespaces[0].Name},
+			{ResType: int32(apisecurity.ResourceType_Services), ResID: services[0].ID},
+			{ResType: int32(apisecurity.ResourceType_Services), ResID: services[1].ID},
+		},
+	}
+	toRule := &model.StrategyDetail{
+		ID: utils.NewUUID(), Name: "default_to", Default: true, Owner: users[0].ID, Valid: true,
+		Principals: []model.Principal{to},
+	}
+
+	cfg, storage := initCache(ctrl)
+	storage.EXPECT().GetUsersForCache(gomock.Any(), gomock.Any()).AnyTimes().Return(users, nil)
+	storage.EXPECT().GetGroupsForCache(gomock.Any(), gomock.Any()).AnyTimes().Return(groups, nil)
+	storage.EXPECT().GetUserSessionsForCache(gomock.Any(), gomock.Any()).AnyTimes().Return(nil, nil)
+	storage.EXPECT().GetRolesForCache(gomock.Any(), gomock.Any()).AnyTimes().Return(nil, nil)
+	storage.EXPECT().GetStrategyDetailsForCache(gomock.Any(), gomock.Any()).AnyTimes().Return(
+		[]*model.StrategyDetail{fromRule, toRule}, nil)
+	storage.EXPECT().GetMoreNamespaces(gomock.Any()).AnyTimes().Return(namespaces, nil)
+	storage.EXPECT().GetMoreServices(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes().
+		Return(serviceMap, nil)
+	storage.EXPECT().GetDefaultStrategyDetailByPrincipal(gomock.Any(), gomock.Any()).AnyTimes().DoAndReturn(
+		func(id string, _ model.PrincipalType) (*model.StrategyDetail, error) {
+			switch id {
+			case from.PrincipalID:
+				return fromRule, nil
+			case to.PrincipalID:
+				return toRule, nil
+			}
+			return nil, nil
+		})
+	storage.EXPECT().GetServiceByID(gomock.Any()).AnyTimes().DoAndReturn(func(id string) (*model.Service, error) {
+		svc := *serviceMap[id]
+		svc.Owner = svcOwners[id]
+		return &svc, nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cacheMgr, err := cache.TestCacheInitialize(ctx, cfg, storage)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		cancel()
+		cacheMgr.Close()
+	})
+
+	_, proxySvr, err := defaultuser.BuildServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	proxySvr.Initialize(&auth.Config{
+		User: &auth.UserConfig{
+			Name:   auth.DefaultUserMgnPluginName,
+			Option: map[string]interface{}{"salt": "polarismesh@2021"},
+		},
+	}, storage, cacheMgr)
+
+	_, svr, err := newPolicyServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := svr.Initialize(&auth.Config{
+		Strategy: &auth.StrategyConfig{Name: auth.DefaultPolicyPluginName},
+	}, storage, cacheMgr, proxySvr); err != nil {
+		t.Fatal(err)
+	}
+	_ = cacheMgr.TestUpdate()
+
+	ownerCtx := context.WithValue(context.Background(), utils.ContextAuthTokenKey, users[0].Token)
+
+	t.Run("转移双方相同", func(t *testing.T) {
+		_, err := svr.TransferAllOwnership(ownerCtx, from, from)
+		assert.ErrorIs(t, err, policy.ErrorInvalidTransferPrincipal)
+	})
+
+	t.Run("子账户不能转移", func(t *testing.T) {
+		subCtx := context.WithValue(context.Background(), utils.ContextAuthTokenKey, users[1].Token)
+		_, err := svr.TransferAllOwnership(subCtx, from, to)
+		assert.Error(t, err)
+	})
+
+	t.Run("转移全部服务", func(t *testing.T) {
+		var moved []string
+		storage.EXPECT().TransferStrategyResource(gomock.Any(), toRule.ID).Times(2).DoAndReturn(
+			func(res model.StrategyResource, _ string) error {
+				assert.Equal(t, fromRule.ID, res.StrategyID)
+				moved = append(moved, res.ResID)
+				return nil
+			})
+		storage.EXPECT().UpdateService(gomock.Any(), true).Times(1).DoAndReturn(
+			func(svc *model.Service, _ bool) error {
+				assert.Equal(t, services[0].ID, svc.ID)
+				assert.Equal(t, "polaris,"+users[2].Name, svc.Owner)
+				return nil
+			})
+
+		report, err := svr.TransferAllOwnership(ownerCtx, from, to)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{services[0].ID, services[1].ID}, moved)
+		assert.Equal(t, []string{services[0].ID, services[1].ID}, report.Services)
+		assert.Empty(t, report.ConfigGroups)
+		assert.Equal(t, 1, report.Owners)
+	})
+}
@@ -0,0 +1,239 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package policy
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	apisecurity "github.com/polarismesh/specification/source/go/api/v1/security"
+	"go.uber.org/zap"
+
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/store"
+)
+
+const (
+	// LastUsedOff 不记录资源关联关系的最近使用时间
+	LastUsedOff = "off"
+	// LastUsedSampled 每个资源关联关系在一个时间窗口内最多写入一次存储
+	LastUsedSampled = "sampled"
+	// LastUsedBestEffort 最近使用时间先在内存中合并，按照时间窗口定期批量写入存储，进程退出时可能丢失
+	LastUsedBestEffort = "bestEffort"
+
+	// defaultLastUsedWindow 默认的时间窗口
+	defaultLastUsedWindow = time.Minute
+	// lastUsedQueueSize 待处理的使用记录队列长度，队列满时直接丢弃，不阻塞鉴权
+	lastUsedQueueSize = 1024
+)
+
+// grantKey 一条资源关联关系
+type grantKey struct {
+	strategyID string
+	resType    int32
+	resID      string
+}
+
+// usageTouch 鉴权放通时记录的一次使用
+type usageTouch struct {
+	principal model.Principal
+	resType   apisecurity.ResourceType
+	resID     string
+	at        time.Time
+}
+
+// grantResolver 找出 principal 操作资源时使用到的资源关联关系
+type grantResolver func(principal model.Principal, resType apisecurity.ResourceType, resID string,
+	now time.Time) []grantKey
+
+// usageTracker 异步记录资源关联关系的最近使用时间，鉴权链路只做非阻塞的入队
+type usageTracker struct {
+	mode    string
+	window  time.Duration
+	storage store.Store
+	resolve grantResolver
+	now     func() time.Time
+	queue   chan usageTouch
+	stopCh  chan struct{}
+	wg      sync.WaitGroup
+	// written sampled 模式下每个资源关联关系最近一次写入存储的时间
+	written map[grantKey]time.Time
+	// pending bestEffort 模式下尚未写入存储的最近使用时间
+	pending map[grantKey]time.Time
+}
+
+// newUsageTracker off 模式下返回 nil, windowInSecs 小于等于 0 时使用默认值
+func newUsageTracker(mode string, windowInSecs int, s store.Store, resolve grantResolver) (*usageTracker, error) {
+	switch mode {
+	case "", LastUsedOff:
+		return nil, nil
+	case LastUsedSampled, LastUsedBestEffort:
+	default:
+		return nil, fmt.Errorf("[Auth][Checker] unsupported last used mode: %s", mode)
+	}
+	t := &usageTracker{
+		mode:    mode,
+		window:  defaultLastUsedWindow,
+		storage: s,
+		resolve: resolve,
+		now:     time.Now,
+		queue:   make(chan usageTouch, lastUsedQueueSize),
+		stopCh:  make(chan struct{}),
+		written: make(map[grantKey]time.Time),
+		pending: make(map[grantKey]time.Time),
+	}
+	if windowInSecs > 0 {
+		t.window = time.Duration(windowInSecs) * time.Second
+	}
+	t.wg.Add(1)
+	go t.run()
+	return t, nil
+}
+
+// touch 记录 principal 使用了该资源的授权，队列满时丢弃
+func (t *usageTracker) touch(principal model.Principal, resType apisecurity.ResourceType, resID string) {
+	if t == nil {
+		return
+	}
+	select {
+	case t.queue <- usageTouch{principal: principal, resType: resType, resID: resID, at: t.now()}:
+	default:
+	}
+}
+
+// stop 处理完队列中剩余的使用记录，bestEffort 模式下写入尚未保存的数据
+func (t *usageTracker) stop() {
+	if t == nil {
+		return
+	}
+	close(t.stopCh)
+	t.wg.Wait()
+}
+
+func (t *usageTracker) run() {
+	defer t.wg.Done()
+	ticker := time.NewTicker(t.window)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case item := <-t.queue:
+			t.handle(item)
+		case <-ticker.C:
+			t.tick()
+		case <-t.stopCh:
+			for {
+				select {
+				case item := <-t.queue:
+					t.handle(item)
+				default:
+					t.flush()
+					return
+				}
+			}
+		}
+	}
+}
+
+func (t *usageTracker) handle(item usageTouch) {
+	grants := t.resolve(item.principal, item.resType, item.resID, item.at)
+	if t.mode == LastUsedBestEffort {
+		for _, grant := range grants {
+			if item.at.After(t.pending[grant]) {
+				t.pending[grant] = item.at
+			}
+		}
+		return
+	}
+
+	usages := make([]model.StrategyResourceUsage, 0, len(grants))
+	for _, grant := range grants {
+		if last, ok := t.written[grant]; ok && item.at.Sub(last) < t.window {
+			continue
+		}
+		t.written[grant] = item.at
+		usages = append(usages, grant.toUsage(item.at))
+	}
+	t.save(usages)
+}
+
+// tick bestEffort 模式下定期写入, sampled 模式下清理已经超出时间窗口的写入记录
+func (t *usageTracker) tick() {
+	if t.mode == LastUsedBestEffort {
+		t.flush()
+		return
+	}
+	now := t.now()
+	for grant, last := range t.written {
+		if now.Sub(last) >= t.window {
+			delete(t.written, grant)
+		}
+	}
+}
+
+func (t *usageTracker) flush() {
+	if len(t.pending) == 0 {
+		return
+	}
+	usages := make([]model.StrategyResourceUsage, 0, len(t.pending))
+	for grant, at := range t.pending {
+		usages = append(usages, grant.toUsage(at))
+	}
+	t.pending = make(map[grantKey]time.Time)
+	t.save(usages)
+}
+
+func (t *usageTracker) save(usages []model.StrategyResourceUsage) {
+	if len(usages) == 0 {
+		return
+	}
+	if err := t.storage.UpdateStrategyResourceUsage(usages); err != nil {
+		log.Error("[Auth][Checker] update strategy resource last used time", zap.Int("count", len(usages)),
+			zap.Error(err))
+	}
+}
+
+func (g grantKey) toUsage(at time.Time) model.StrategyResourceUsage {
+	return model.StrategyResourceUsage{
+		StrategyID:   g.strategyID,
+		ResType:      g.resType,
+		ResID:        g.resID,
+		LastUsedTime: at,
+	}
+}
+
+// resourceGrants principal 直接或者通过用户组关联的策略中，授予了该资源写权限且未过期的资源关联关系
+func (d *DefaultAuthChecker) resourceGrants(principal model.Principal, resType apisecurity.ResourceType,
+	resID string, now time.Time) []grantKey {
+	var grants []grantKey
+	for _, rule := range d.principalStrategies(principal) {
+		if rule.IsReadOnly() || rule.IsDeny() {
+			continue
+		}
+		for _, res := range rule.Resources {
+			if res.ResType != int32(resType) || res.IsExpired(now) {
+				continue
+			}
+			if d.conf.ResourceIDCanonical.Equal(res.ResID, resID) || res.ResID == "*" {
+				grants = append(grants, grantKey{strategyID: rule.ID, resType: res.ResType, resID: res.ResID})
+			}
+		}
+	}
+	return grants
+}
@@ -0,0 +1,138 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package policy
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	apisecurity "github.com/polarismesh/specification/source/go/api/v1/security"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/polarismesh/polaris/common/model"
+	storemock "github.com/polarismesh/polaris/store/mock"
+)
+
+func Test_usageTracker(t *testing.T) {
+	principal := model.Principal{PrincipalID: "user-1", PrincipalRole: model.PrincipalUser}
+	grant := grantKey{strategyID: "rule-1", resType: int32(apisecurity.ResourceType_Services), resID: "svc-1"}
+	resolve := func(model.Principal, apisecurity.ResourceType, string, time.Time) []grantKey {
+		return []grantKey{grant}
+	}
+	start := time.Unix(1700000000, 0)
+
+	newTracker := func(t *testing.T, mode string) (*usageTracker, *storemock.MockStore, *[][]model.StrategyResourceUsage) {
+		ctrl := gomock.NewController(t)
+		t.Cleanup(ctrl.Finish)
+		storage := storemock.NewMockStore(ctrl)
+		var (
+			lock   sync.Mutex
+			writes [][]model.StrategyResourceUsage
+		)
+		storage.EXPECT().UpdateStrategyResourceUsage(gomock.Any()).AnyTimes().DoAndReturn(
+			func(usages []model.StrategyResourceUsage) error {
+				lock.Lock()
+				defer lock.Unlock()
+				writes = append(writes, usages)
+				return nil
+			})
+		tracker, err := newUsageTracker(mode, 60, storage, resolve)
+		assert.NoError(t, err)
+		tracker.now = func() time.Time { return start }
+		return tracker, storage, &writes
+	}
+
+	t.Run("sampled 模式时间窗口内的多次使用只写入一次", func(t *testing.T) {
+		tracker, _, writes := newTracker(t, LastUsedSampled)
+		for i := 0; i < 100; i++ {
+			tracker.touch(principal, apisecurity.ResourceType_Services, "svc-1")
+		}
+		current := start.Add(59 * time.Second)
+		tracker.now = func() time.Time { return current }
+		tracker.touch(principal, apisecurity.ResourceType_Services, "svc-1")
+		tracker.stop()
+
+		assert.Equal(t, [][]model.StrategyResourceUsage{{grant.toUsage(start)}}, *writes)
+	})
+
+	t.Run("sampled 模式超出时间窗口后再次写入", func(t *testing.T) {
+		tracker, _, writes := newTracker(t, LastUsedSampled)
+		tracker.touch(principal, apisecurity.ResourceType_Services, "svc-1")
+		next := start.Add(time.Minute)
+		tracker.now = func() time.Time { return next }
+		tracker.touch(principal, apisecurity.ResourceType_Services, "svc-1")
+		tracker.touch(principal, apisecurity.ResourceType_Services, "svc-1")
+		tracker.stop()
+
+		assert.Equal(t, [][]model.StrategyResourceUsage{{grant.toUsage(start)}, {grant.toUsage(next)}}, *writes)
+	})
+
+	t.Run("bestEffort 模式合并后写入最近一次使用时间", func(t *testing.T) {
+		tracker, _, writes := newTracker(t, LastUsedBestEffort)
+		tracker.touch(principal, apisecurity.ResourceType_Services, "svc-1")
+		next := start.Add(10 * time.Second)
+		tracker.now = func() time.Time { return next }
+		tracker.touch(principal, apisecurity.ResourceType_Services, "svc-1")
+		tracker.stop()
+
+		assert.Equal(t, [][]model.StrategyResourceUsage{{grant.toUsage(next)}}, *writes)
+	})
+
+	t.Run("关闭以及不支持的模式", func(t *testing.T) {
+		tracker, err := newUsageTracker(LastUsedOff, 0, nil, resolve)
+		assert.NoError(t, err)
+		assert.Nil(t, tracker)
+		tracker.touch(principal, apisecurity.ResourceType_Services, "svc-1")
+		tracker.stop()
+
+		_, err = newUsageTracker("always", 0, nil, resolve)
+		assert.Error(t, err)
+	})
+
+	t.Run("队列已满时不阻塞鉴权", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		storage := storemock.NewMockStore(ctrl)
+		storage.EXPECT().UpdateStrategyResourceUsage(gomock.Any()).AnyTimes().Return(nil)
+
+		block := make(chan struct{})
+		tracker, err := newUsageTracker(LastUsedSampled, 60, storage,
+			func(model.Principal, apisecurity.ResourceType, string, time.Time) []grantKey {
+				<-block
+				return nil
+			})
+		assert.NoError(t, err)
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			for i := 0; i < lastUsedQueueSize*2; i++ {
+				tracker.touch(principal, apisecurity.ResourceType_Services, "svc-1")
+			}
+		}()
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Fatal("touch blocked by slow store writes")
+		}
+		close(block)
+		tracker.stop()
+	})
+}
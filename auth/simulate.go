@@ -0,0 +1,62 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package auth
+
+import (
+	"github.com/polarismesh/polaris/common/model"
+)
+
+// SimulateReason 模拟鉴权的决策原因，除以下服务端特有的原因外，取值与 evaluator.DecisionReason 一致
+type SimulateReason string
+
+const (
+	// SimulateReasonTokenDisabled principal 的 token 已被禁用，任何操作均拒绝
+	SimulateReasonTokenDisabled SimulateReason = "token_disabled"
+	// SimulateReasonPin 命中了放通的置顶决策
+	SimulateReasonPin SimulateReason = "pin"
+	// SimulateReasonDenyByDefault 资源类型默认拒绝，且资源没有被任何策略匹配
+	SimulateReasonDenyByDefault SimulateReason = "deny_by_default"
+	// SimulateReasonNamespaceAdmin principal 为资源所属命名空间的管理员
+	SimulateReasonNamespaceAdmin SimulateReason = "namespace_admin"
+)
+
+// SimulateRequest 模拟一次鉴权请求
+type SimulateRequest struct {
+	// Principal 发起请求的用户或者用户组
+	Principal model.Principal `json:"principal"`
+	// Resource 请求操作的资源
+	Resource CapabilityResource `json:"resource"`
+	// Operation 操作类型
+	Operation model.ResourceOperation `json:"operation"`
+	// Method 请求的接口名称，用于匹配策略生效的接口，为空时不按照接口过滤策略
+	Method string `json:"method"`
+	// Attributes 请求携带的属性，用于匹配策略的请求条件
+	Attributes map[string]string `json:"attributes"`
+}
+
+// StrategySimulation 模拟鉴权的结果，与开启 ConsoleStrict 后该请求实际的鉴权结果一致
+type StrategySimulation struct {
+	// Allowed 是否允许操作
+	Allowed bool `json:"allowed"`
+	// Reason 决策的原因
+	Reason SimulateReason `json:"reason"`
+	// StrategyID 授予或者拒绝权限的鉴权策略 ID, 决策不是由某个鉴权策略给出时为空
+	StrategyID string `json:"strategyId"`
+	// StrategyName 授予或者拒绝权限的鉴权策略名称
+	StrategyName string `json:"strategyName"`
+}
@@ -0,0 +1,108 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package auth
+
+import "time"
+
+// AuthModelSnapshot 鉴权模型在某一时间点的完整快照，用于提供给外部工具（例如 OPA/Rego）进行离线的策略分析
+// 快照中的全部数据均在同一个存储读事务中获取，保证内部数据的一致性；不包含密码、token 等敏感信息
+//
+//	{
+//	  "revision": "...",
+//	  "create_time": "2006-01-02T15:04:05Z",
+//	  "principals": [{"id": "...", "name": "...", "type": "user", "owner": "...", "role": "main"}],
+//	  "memberships": [{"group_id": "...", "user_id": "..."}],
+//	  "strategies": [{"id": "...", "name": "...", "principals": [{"id": "...", "type": "group"}]}],
+//	  "resource_links": [{"strategy_id": "...", "resource_type": "Services", "resource_id": "..."}]
+//	}
+type AuthModelSnapshot struct {
+	// Revision 快照的唯一标识
+	Revision string `json:"revision"`
+	// CreateTime 快照的生成时间
+	CreateTime time.Time `json:"create_time"`
+	// Principals 全部的用户以及用户组
+	Principals []SnapshotPrincipal `json:"principals"`
+	// Memberships 用户与用户组的归属关系
+	Memberships []SnapshotMembership `json:"memberships"`
+	// Strategies 全部的鉴权策略
+	Strategies []SnapshotStrategy `json:"strategies"`
+	// ResourceLinks 鉴权策略与资源的关联关系
+	ResourceLinks []SnapshotResourceLink `json:"resource_links"`
+}
+
+// SnapshotPrincipal 快照中的用户/用户组
+type SnapshotPrincipal struct {
+	// ID 用户/用户组 ID
+	ID string `json:"id"`
+	// Name 用户/用户组名称
+	Name string `json:"name"`
+	// Type principal 类型, user or group
+	Type string `json:"type"`
+	// Owner 所属的主账户 ID
+	Owner string `json:"owner"`
+	// Role 用户角色, admin / main / sub, 仅当 type 为 user 时存在
+	Role string `json:"role,omitempty"`
+	// TokenEnable token 是否可用
+	TokenEnable bool `json:"token_enable"`
+}
+
+// SnapshotPrincipalRef 快照中鉴权策略引用的 principal
+type SnapshotPrincipalRef struct {
+	// ID 用户/用户组 ID
+	ID string `json:"id"`
+	// Type principal 类型, user or group
+	Type string `json:"type"`
+}
+
+// SnapshotMembership 快照中用户与用户组的关系
+type SnapshotMembership struct {
+	// GroupID 用户组 ID
+	GroupID string `json:"group_id"`
+	// UserID 用户 ID
+	UserID string `json:"user_id"`
+}
+
+// SnapshotStrategy 快照中的鉴权策略
+type SnapshotStrategy struct {
+	// ID 策略 ID
+	ID string `json:"id"`
+	// Name 策略名称
+	Name string `json:"name"`
+	// Owner 策略所属的主账户 ID
+	Owner string `json:"owner"`
+	// Action 策略动作, READ_WRITE 等
+	Action string `json:"action"`
+	// Default 是否为默认策略
+	Default bool `json:"default"`
+	// Comment 策略描述
+	Comment string `json:"comment"`
+	// Revision 策略版本
+	Revision string `json:"revision"`
+	// Principals 策略关联的 principal
+	Principals []SnapshotPrincipalRef `json:"principals"`
+}
+
+// SnapshotResourceLink 快照中鉴权策略与资源的关系
+type SnapshotResourceLink struct {
+	// StrategyID 策略 ID
+	StrategyID string `json:"strategy_id"`
+	// ResourceType 资源类型, Namespaces / Services / ConfigGroups
+	ResourceType string `json:"resource_type"`
+	// ResourceID 资源 ID, * 表示该类型下的全部资源
+	ResourceID string `json:"resource_id"`
+}
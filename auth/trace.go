@@ -0,0 +1,47 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+package auth
+
+import (
+	apisecurity "github.com/polarismesh/specification/source/go/api/v1/security"
+
+	"github.com/polarismesh/polaris/common/model"
+)
+
+// DecisionTraceRecord 一次鉴权决策的输入以及结果，仅保留重放所需的信息，不包含 token、请求来源等信息
+// principal ID 为当前节点密钥计算的哈希值，只能在记录决策的节点上重放
+type DecisionTraceRecord struct {
+	Principal  model.Principal                       `json:"principal"`
+	Module     model.BzModule                        `json:"module"`
+	Method     string                                `json:"method"`
+	FromClient bool                                  `json:"fromClient"`
+	Operation  model.ResourceOperation               `json:"operation"`
+	Resources  map[apisecurity.ResourceType][]string `json:"resources"`
+	Allowed    bool                                  `json:"allowed"`
+	// WithToken 请求是否携带了 token，不记录 token 本身
+	WithToken bool `json:"withToken"`
+	// Anonymous 请求未携带合法的 token，在非严格模式下降级为匿名用户
+	Anonymous bool `json:"anonymous"`
+}
+
+// AccessDelta 同一批决策在当前配置与待变更配置下的差异
+type AccessDelta struct {
+	// NewlyDenied 当前放通、变更后拒绝的决策
+	NewlyDenied []DecisionTraceRecord `json:"newlyDenied"`
+	// NewlyAllowed 当前拒绝、变更后放通的决策
+	NewlyAllowed []DecisionTraceRecord `json:"newlyAllowed"`
+}
@@ -47,6 +47,11 @@ func init() {
 		panic(err)
 	}
 	_ = auth.RegisterUserServer(nextSvr)
+	_, ldapSvr, err := BuildLDAPServer()
+	if err != nil {
+		panic(err)
+	}
+	_ = auth.RegisterUserServer(ldapSvr)
 }
 
 func loadInteceptors() {
@@ -56,16 +61,33 @@ func loadInteceptors() {
 }
 
 func BuildServer() (*Server, auth.UserServer, error) {
-	loadInteceptors()
 	svr := &Server{}
-	var nextSvr auth.UserServer
-	nextSvr = svr
+	nextSvr, err := buildServerProxy(svr, svr)
+	if err != nil {
+		return nil, nil, err
+	}
+	return svr, nextSvr, nil
+}
+
+// BuildLDAPServer 构建基于 LDAP 登录的 UserServer，与 defaultUser 共用同一套代理
+func BuildLDAPServer() (*LDAPServer, auth.UserServer, error) {
+	svr := &LDAPServer{Server: &Server{}}
+	nextSvr, err := buildServerProxy(svr.Server, svr)
+	if err != nil {
+		return nil, nil, err
+	}
+	return svr, nextSvr, nil
+}
+
+func buildServerProxy(svr *Server, root auth.UserServer) (auth.UserServer, error) {
+	loadInteceptors()
+	nextSvr := root
 	// 需要返回包装代理的 DiscoverServer
 	order := []string{"auth"}
 	for i := range order {
 		factory, exist := serverProxyFactories[order[i]]
 		if !exist {
-			return nil, nil, fmt.Errorf("name(%s) not exist in serverProxyFactories", order[i])
+			return nil, fmt.Errorf("name(%s) not exist in serverProxyFactories", order[i])
 		}
 
 		proxySvr, err := factory(svr, nextSvr)
@@ -74,5 +96,5 @@ func BuildServer() (*Server, auth.UserServer, error) {
 		}
 		nextSvr = proxySvr
 	}
-	return svr, nextSvr, nil
+	return nextSvr, nil
 }
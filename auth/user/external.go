@@ -0,0 +1,141 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package defaultuser
+
+import (
+	"context"
+
+	apimodel "github.com/polarismesh/specification/source/go/api/v1/model"
+	apisecurity "github.com/polarismesh/specification/source/go/api/v1/security"
+	apiservice "github.com/polarismesh/specification/source/go/api/v1/service_manage"
+	"go.uber.org/zap"
+
+	api "github.com/polarismesh/polaris/common/api/v1"
+	"github.com/polarismesh/polaris/common/model"
+	commonstore "github.com/polarismesh/polaris/common/store"
+	"github.com/polarismesh/polaris/common/utils"
+)
+
+// 外部身份源(OIDC、LDAP)登录的用户由身份源管理，统一创建在配置的主账户下
+
+// newLoginResponse 登录成功的应答
+func newLoginResponse(user *model.User) *apiservice.Response {
+	return api.NewLoginResponse(apimodel.Code_ExecuteSuccess, &apisecurity.LoginResponse{
+		UserId:  utils.NewStringValue(user.ID),
+		OwnerId: utils.NewStringValue(user.Owner),
+		Token:   utils.NewStringValue(user.Token),
+		Name:    utils.NewStringValue(user.Name),
+		Role:    utils.NewStringValue(model.UserRoleNames[user.Type]),
+	})
+}
+
+// loadExternalUser 获取外部身份源登录的子账户，不存在时创建，来源不一致的同名用户不允许登录
+func (svr *Server) loadExternalUser(ctx context.Context, owner *model.User, name, source,
+	email string) (*model.User, *apiservice.Response) {
+	user, err := svr.storage.GetUserByName(name, owner.ID)
+	if err != nil {
+		log.Error("[Auth][User] get external user by name", utils.RequestID(ctx), zap.String("name", name),
+			zap.Error(err))
+		return nil, api.NewAuthResponse(commonstore.StoreCode2APICode(err))
+	}
+	if user != nil {
+		if user.Source != source {
+			log.Error("[Auth][User] external login conflicts with existing user", utils.RequestID(ctx),
+				zap.String("name", name), zap.String("source", user.Source), zap.String("login-source", source))
+			return nil, api.NewAuthResponseWithMsg(apimodel.Code_UserExisted, "user is not managed by "+source)
+		}
+		return user, nil
+	}
+
+	req := &apisecurity.User{
+		Name:     utils.NewStringValue(name),
+		Password: utils.NewStringValue(utils.NewUUID()),
+		Owner:    utils.NewStringValue(owner.ID),
+		Source:   utils.NewStringValue(source),
+		Comment:  utils.NewStringValue("created by " + source + " login"),
+	}
+	user, err = svr.createUserModel(req, model.OwnerUserRole)
+	if err != nil {
+		log.Error("[Auth][User] create external user model", utils.RequestID(ctx), zap.Error(err))
+		return nil, api.NewAuthResponse(apimodel.Code_ExecuteException)
+	}
+	user.Email = email
+	if err := svr.storage.AddUser(user); err != nil {
+		log.Error("[Auth][User] add external user into store", utils.RequestID(ctx), zap.Error(err))
+		return nil, api.NewAuthResponse(commonstore.StoreCode2APICode(err))
+	}
+	log.Info("[Auth][User] create external user", utils.RequestID(ctx), zap.String("name", name),
+		zap.String("source", source))
+	svr.RecordHistory(userRecordEntry(ctx, req, user, model.OCreate))
+	return user, nil
+}
+
+// createExternalGroup 创建外部身份源同步的用户组，创建时直接包含组内的用户
+func (svr *Server) createExternalGroup(ctx context.Context, owner *model.User, name, source string,
+	userIDs []string) (*model.UserGroup, error) {
+	req := &apisecurity.UserGroup{
+		Name:     utils.NewStringValue(name),
+		Owner:    utils.NewStringValue(owner.ID),
+		Comment:  utils.NewStringValue("synced from " + source),
+		Relation: &apisecurity.UserGroupRelation{Users: relationUsers(userIDs)},
+	}
+	data, err := svr.createGroupModel(req)
+	if err != nil {
+		return nil, err
+	}
+	if err := svr.storage.AddGroup(data); err != nil {
+		return nil, err
+	}
+	log.Info("[Auth][User] create external user group", utils.RequestID(ctx), zap.String("name", name),
+		zap.String("source", source))
+	svr.RecordHistory(userGroupRecordEntry(ctx, req, data.UserGroup, model.OCreate))
+	return data.UserGroup, nil
+}
+
+// updateGroupMembers 用户组加入以及移除用户，保留用户组原有的 token 以及备注
+func (svr *Server) updateGroupMembers(ctx context.Context, group *model.UserGroup, add, remove []string) error {
+	if len(add) == 0 && len(remove) == 0 {
+		return nil
+	}
+	if err := svr.storage.UpdateGroup(&model.ModifyUserGroup{
+		ID:            group.ID,
+		Owner:         group.Owner,
+		Token:         group.Token,
+		TokenEnable:   group.TokenEnable,
+		Comment:       group.Comment,
+		AddUserIds:    add,
+		RemoveUserIds: remove,
+	}); err != nil {
+		return err
+	}
+	req := &apisecurity.ModifyUserGroup{
+		Id:              utils.NewStringValue(group.ID),
+		AddRelations:    &apisecurity.UserGroupRelation{Users: relationUsers(add)},
+		RemoveRelations: &apisecurity.UserGroupRelation{Users: relationUsers(remove)},
+	}
+	svr.RecordHistory(modifyUserGroupRecordEntry(ctx, req, group, model.OUpdateGroup))
+	return nil
+}
+
+func relationUsers(ids []string) []*apisecurity.User {
+	users := make([]*apisecurity.User, 0, len(ids))
+	for i := range ids {
+		users = append(users, &apisecurity.User{Id: utils.NewStringValue(ids[i])})
+	}
+	return users
+}
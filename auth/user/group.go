@@ -535,6 +535,7 @@ func userGroupRecordEntry(ctx context.Context, req *apisecurity.UserGroup, md *m
 		ResourceName:  fmt.Sprintf("%s(%s)", md.Name, md.ID),
 		OperationType: operationType,
 		Operator:      utils.ParseOperator(ctx),
+		ChangeSetID:   utils.ParseChangeSetID(ctx),
 		Detail:        datail,
 		HappenTime:    time.Now(),
 	}
@@ -554,6 +555,7 @@ func modifyUserGroupRecordEntry(ctx context.Context, req *apisecurity.ModifyUser
 		ResourceName:  fmt.Sprintf("%s(%s)", md.Name, md.ID),
 		OperationType: operationType,
 		Operator:      utils.ParseOperator(ctx),
+		ChangeSetID:   utils.ParseChangeSetID(ctx),
 		Detail:        detail,
 		HappenTime:    time.Now(),
 	}
@@ -573,6 +575,7 @@ func userRelationRecordEntry(ctx context.Context, req *apisecurity.UserGroupRela
 		ResourceName:  fmt.Sprintf("%s(%s)", md.Name, md.ID),
 		OperationType: operationType,
 		Operator:      utils.ParseOperator(ctx),
+		ChangeSetID:   utils.ParseChangeSetID(ctx),
 		Detail:        detail,
 		HappenTime:    time.Now(),
 	}
@@ -0,0 +1,115 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+package defaultuser
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/common/utils"
+)
+
+var (
+	// ErrorGroupNestingCycle 设置父用户组后用户组的嵌套关系会形成环
+	ErrorGroupNestingCycle = errors.New("user group nesting cycle")
+	// ErrorGroupOwnerMismatch 父用户组与用户组不属于同一个主账户
+	ErrorGroupOwnerMismatch = errors.New("parent group belongs to another owner")
+)
+
+// UpdateGroupParent 设置用户组的父用户组，parentID 为空时移出父用户组
+// 父用户组必须与用户组属于同一个主账户，并且不能是用户组自身或者其子孙用户组
+func (svr *Server) UpdateGroupParent(ctx context.Context, groupID, parentID string) error {
+	group, err := svr.storage.GetGroup(groupID)
+	if err != nil {
+		log.Error("[Auth][Group] get group from store", utils.RequestID(ctx), zap.Error(err))
+		return err
+	}
+	if group == nil {
+		return ErrGroupNotExist
+	}
+	if group.ParentID == parentID {
+		return nil
+	}
+	if parentID != "" {
+		if err := svr.checkGroupParent(group, parentID); err != nil {
+			log.Error("[Auth][Group] check group parent", utils.RequestID(ctx), zap.String("group", groupID),
+				zap.String("parent", parentID), zap.Error(err))
+			return err
+		}
+	}
+
+	if err := svr.storage.UpdateGroupParent(groupID, parentID); err != nil {
+		log.Error("[Auth][Group] update group parent", utils.RequestID(ctx), zap.String("group", groupID),
+			zap.Error(err))
+		return err
+	}
+	if err := svr.cacheMgr.User().ForceSync(); err != nil {
+		log.Error("[Auth][Group] force sync user cache after update group parent", utils.RequestID(ctx),
+			zap.Error(err))
+	}
+
+	log.Info("[Auth][Group] update group parent", utils.RequestID(ctx), zap.String("group", groupID),
+		zap.String("from", group.ParentID), zap.String("to", parentID))
+	svr.RecordHistory(&model.RecordEntry{
+		ResourceType:  model.RUserGroup,
+		ResourceName:  fmt.Sprintf("%s(%s)", group.Name, group.ID),
+		OperationType: model.OUpdate,
+		Operator:      utils.ParseOperator(ctx),
+		ChangeSetID:   utils.ParseChangeSetID(ctx),
+		Detail: utils.MustJson(map[string]string{
+			"id":        group.ID,
+			"parent_id": parentID,
+		}),
+		HappenTime: time.Now(),
+	})
+	return nil
+}
+
+// checkGroupParent 从父用户组开始沿着存储中的嵌套关系向上查找，遇到用户组自身说明会形成环
+func (svr *Server) checkGroupParent(group *model.UserGroupDetail, parentID string) error {
+	visited := map[string]struct{}{}
+	for id := parentID; id != ""; {
+		if id == group.ID {
+			return ErrorGroupNestingCycle
+		}
+		if _, ok := visited[id]; ok {
+			// 存储中已有的环，与本次修改无关
+			return nil
+		}
+		visited[id] = struct{}{}
+		ancestor, err := svr.storage.GetGroup(id)
+		if err != nil {
+			return err
+		}
+		if ancestor == nil {
+			if id == parentID {
+				return ErrGroupNotExist
+			}
+			return nil
+		}
+		if id == parentID && ancestor.Owner != group.Owner {
+			return ErrorGroupOwnerMismatch
+		}
+		id = ancestor.ParentID
+	}
+	return nil
+}
@@ -19,6 +19,7 @@ package defaultuser_test
 
 import (
 	"context"
+	"sort"
 	"testing"
 	"time"
 
@@ -34,6 +35,7 @@ import (
 	v1 "github.com/polarismesh/polaris/common/api/v1"
 	"github.com/polarismesh/polaris/common/model"
 	"github.com/polarismesh/polaris/common/utils"
+	"github.com/polarismesh/polaris/store"
 	storemock "github.com/polarismesh/polaris/store/mock"
 )
 
@@ -76,6 +78,7 @@ func newGroupTest(t *testing.T) *GroupTest {
 	storage.EXPECT().UpdateUser(gomock.Any()).AnyTimes().Return(nil)
 	storage.EXPECT().GetUsersForCache(gomock.Any(), gomock.Any()).AnyTimes().Return(append(users, newUsers...), nil)
 	storage.EXPECT().GetGroupsForCache(gomock.Any(), gomock.Any()).AnyTimes().Return(allGroups, nil)
+	storage.EXPECT().GetUserSessionsForCache(gomock.Any(), gomock.Any()).AnyTimes().Return(nil, nil)
 
 	cfg := &cache.Config{}
 
@@ -820,3 +823,225 @@ func Test_AuthServer_NormalOperateUserGroup(t *testing.T) {
 		assert.Equal(t, v1.NotFoundUserGroup, qresp.GetCode().GetValue())
 	})
 }
+
+func Test_server_ExportMembershipGraph(t *testing.T) {
+	groupTest := newGroupTest(t)
+	defer groupTest.Clean()
+
+	// u1 -> gA -> u2 -> gB -> u3 -> gC -> u4
+	users := groupTest.users
+	newGroup := func(name string, members ...*model.User) *model.UserGroupDetail {
+		group := &model.UserGroupDetail{
+			UserGroup: &model.UserGroup{
+				ID:    utils.NewUUID(),
+				Name:  name,
+				Owner: users[0].ID,
+				Valid: true,
+			},
+			UserIds: map[string]struct{}{},
+		}
+		for i := range members {
+			group.UserIds[members[i].ID] = struct{}{}
+		}
+		return group
+	}
+	gA := newGroup("group-a", users[1], users[2])
+	gB := newGroup("group-b", users[2], users[3])
+	gC := newGroup("group-c", users[3], users[4])
+
+	mockTx := storemock.NewMockTx(groupTest.ctrl)
+	mockTx.EXPECT().Rollback().Return(nil).AnyTimes()
+	mockTx.EXPECT().CreateReadView().Return(nil).AnyTimes()
+	groupTest.storage.EXPECT().StartReadTx().Return(mockTx, nil).AnyTimes()
+
+	var txs []store.Tx
+	groupTest.storage.EXPECT().GetAllUsersTx(gomock.Any()).AnyTimes().DoAndReturn(
+		func(tx store.Tx) ([]*model.User, error) {
+			txs = append(txs, tx)
+			return users, nil
+		})
+	groupTest.storage.EXPECT().GetAllGroupsTx(gomock.Any()).AnyTimes().DoAndReturn(
+		func(tx store.Tx) ([]*model.UserGroupDetail, error) {
+			txs = append(txs, tx)
+			return []*model.UserGroupDetail{gA, gB, gC}, nil
+		})
+
+	groupTest.storage.EXPECT().GetGroup(gomock.Any()).AnyTimes().DoAndReturn(
+		func(id string) (*model.UserGroupDetail, error) {
+			for _, group := range []*model.UserGroupDetail{gA, gB, gC} {
+				if group.ID == id {
+					return group, nil
+				}
+			}
+			return nil, nil
+		})
+
+	root := model.Principal{PrincipalID: users[1].ID, PrincipalRole: model.PrincipalUser}
+	ownerCtx := context.WithValue(context.Background(), utils.ContextAuthTokenKey, users[0].Token)
+
+	node := func(p model.Principal, name string, depth int) auth.MembershipNode {
+		return auth.MembershipNode{
+			ID:    p.PrincipalID,
+			Name:  name,
+			Type:  model.PrincipalNames[p.PrincipalRole],
+			Owner: users[0].ID,
+			Depth: depth,
+		}
+	}
+	userNode := func(user *model.User, depth int) auth.MembershipNode {
+		return node(model.Principal{PrincipalID: user.ID, PrincipalRole: model.PrincipalUser}, user.Name, depth)
+	}
+	groupNode := func(group *model.UserGroupDetail, depth int) auth.MembershipNode {
+		return node(model.Principal{PrincipalID: group.ID, PrincipalRole: model.PrincipalGroup}, group.Name, depth)
+	}
+	edge := func(group *model.UserGroupDetail, user *model.User) auth.SnapshotMembership {
+		return auth.SnapshotMembership{GroupID: group.ID, UserID: user.ID}
+	}
+	sortEdges := func(edges []auth.SnapshotMembership) []auth.SnapshotMembership {
+		sort.Slice(edges, func(i, j int) bool {
+			if edges[i].GroupID != edges[j].GroupID {
+				return edges[i].GroupID < edges[j].GroupID
+			}
+			return edges[i].UserID < edges[j].UserID
+		})
+		return edges
+	}
+
+	t.Run("导出完整的嵌套归属关系", func(t *testing.T) {
+		txs = nil
+		graph, err := groupTest.svr.ExportMembershipGraph(ownerCtx, root, auth.MaxMembershipGraphDepth)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, len(txs))
+		assert.Same(t, txs[0], txs[1])
+
+		assert.Equal(t, root, graph.Root)
+		assert.Equal(t, []auth.MembershipNode{
+			userNode(users[1], 0),
+			groupNode(gA, 1),
+			userNode(users[2], 2),
+			groupNode(gB, 3),
+			userNode(users[3], 4),
+			groupNode(gC, 5),
+			userNode(users[4], 6),
+		}, graph.Nodes)
+		assert.Equal(t, sortEdges([]auth.SnapshotMembership{
+			edge(gA, users[1]), edge(gA, users[2]),
+			edge(gB, users[2]), edge(gB, users[3]),
+			edge(gC, users[3]), edge(gC, users[4]),
+		}), graph.Edges)
+	})
+
+	t.Run("遵守深度限制", func(t *testing.T) {
+		graph, err := groupTest.svr.ExportMembershipGraph(ownerCtx, root, 2)
+		assert.NoError(t, err)
+		assert.Equal(t, []auth.MembershipNode{
+			userNode(users[1], 0),
+			groupNode(gA, 1),
+			userNode(users[2], 2),
+		}, graph.Nodes)
+		assert.Equal(t, sortEdges([]auth.SnapshotMembership{
+			edge(gA, users[1]), edge(gA, users[2]),
+		}), graph.Edges)
+
+		groupRoot := model.Principal{PrincipalID: gB.ID, PrincipalRole: model.PrincipalGroup}
+		graph, err = groupTest.svr.ExportMembershipGraph(ownerCtx, groupRoot, 1)
+		assert.NoError(t, err)
+		assert.Equal(t, 3, len(graph.Nodes))
+		assert.Equal(t, groupNode(gB, 0), graph.Nodes[0])
+		assert.Equal(t, sortEdges([]auth.SnapshotMembership{
+			edge(gB, users[2]), edge(gB, users[3]),
+		}), graph.Edges)
+
+		_, err = groupTest.svr.ExportMembershipGraph(ownerCtx, root, 0)
+		assert.Error(t, err)
+		_, err = groupTest.svr.ExportMembershipGraph(ownerCtx, root, auth.MaxMembershipGraphDepth+1)
+		assert.Error(t, err)
+	})
+
+	t.Run("子账户以及其他主账户不能导出", func(t *testing.T) {
+		subCtx := context.WithValue(context.Background(), utils.ContextAuthTokenKey, users[1].Token)
+		_, err := groupTest.svr.ExportMembershipGraph(subCtx, root, 1)
+		assert.Error(t, err)
+
+		otherCtx := context.WithValue(context.Background(), utils.ContextAuthTokenKey, groupTest.ownerTwo.Token)
+		_, err = groupTest.svr.ExportMembershipGraph(otherCtx, root, 1)
+		assert.Error(t, err)
+	})
+}
+
+func Test_server_UpdateGroupParent(t *testing.T) {
+	newParentTest := func(t *testing.T) *GroupTest {
+		groupTest := newGroupTest(t)
+		t.Cleanup(func() {
+			groupTest.Clean()
+		})
+		groupTest.storage.EXPECT().GetGroup(gomock.Any()).AnyTimes().DoAndReturn(
+			func(id string) (*model.UserGroupDetail, error) {
+				for i := range groupTest.allGroups {
+					if groupTest.allGroups[i].ID == id {
+						return groupTest.allGroups[i], nil
+					}
+				}
+				return nil, nil
+			})
+		groupTest.storage.EXPECT().UpdateGroupParent(gomock.Any(), gomock.Any()).AnyTimes().DoAndReturn(
+			func(id, parentID string) error {
+				for i := range groupTest.allGroups {
+					if groupTest.allGroups[i].ID == id {
+						groupTest.allGroups[i].ParentID = parentID
+					}
+				}
+				return nil
+			})
+		return groupTest
+	}
+
+	t.Run("主账户设置父用户组", func(t *testing.T) {
+		groupTest := newParentTest(t)
+		reqCtx := context.WithValue(context.Background(), utils.ContextAuthTokenKey, groupTest.ownerOne.Token)
+
+		err := groupTest.svr.UpdateGroupParent(reqCtx, groupTest.groups[1].ID, groupTest.groups[2].ID)
+		assert.NoError(t, err)
+		assert.Equal(t, groupTest.groups[2].ID, groupTest.groups[1].ParentID)
+
+		userCache := groupTest.cacheMgn.User()
+		assert.True(t, userCache.IsUserInGroup(groupTest.users[1].ID, groupTest.groups[2].ID))
+
+		err = groupTest.svr.UpdateGroupParent(reqCtx, groupTest.groups[1].ID, "")
+		assert.NoError(t, err)
+		assert.Equal(t, "", groupTest.groups[1].ParentID)
+	})
+
+	t.Run("设置父用户组形成环", func(t *testing.T) {
+		groupTest := newParentTest(t)
+		reqCtx := context.WithValue(context.Background(), utils.ContextAuthTokenKey, groupTest.ownerOne.Token)
+
+		err := groupTest.svr.UpdateGroupParent(reqCtx, groupTest.groups[1].ID, groupTest.groups[1].ID)
+		assert.ErrorIs(t, err, defaultauth.ErrorGroupNestingCycle)
+
+		err = groupTest.svr.UpdateGroupParent(reqCtx, groupTest.groups[1].ID, groupTest.groups[2].ID)
+		assert.NoError(t, err)
+		err = groupTest.svr.UpdateGroupParent(reqCtx, groupTest.groups[2].ID, groupTest.groups[3].ID)
+		assert.NoError(t, err)
+		err = groupTest.svr.UpdateGroupParent(reqCtx, groupTest.groups[3].ID, groupTest.groups[1].ID)
+		assert.ErrorIs(t, err, defaultauth.ErrorGroupNestingCycle)
+		assert.Equal(t, "", groupTest.groups[3].ParentID)
+	})
+
+	t.Run("父用户组属于其他主账户", func(t *testing.T) {
+		groupTest := newParentTest(t)
+		reqCtx := context.WithValue(context.Background(), utils.ContextAuthTokenKey, groupTest.ownerOne.Token)
+
+		err := groupTest.svr.UpdateGroupParent(reqCtx, groupTest.groups[1].ID, groupTest.newGroups[1].ID)
+		assert.ErrorIs(t, err, defaultauth.ErrorGroupOwnerMismatch)
+	})
+
+	t.Run("子账户设置父用户组", func(t *testing.T) {
+		groupTest := newParentTest(t)
+		reqCtx := context.WithValue(context.Background(), utils.ContextAuthTokenKey, groupTest.users[1].Token)
+
+		err := groupTest.svr.UpdateGroupParent(reqCtx, groupTest.groups[1].ID, groupTest.groups[2].ID)
+		assert.Error(t, err)
+		assert.Equal(t, "", groupTest.groups[1].ParentID)
+	})
+}
@@ -19,7 +19,9 @@ package auth
 
 import (
 	"context"
+	"errors"
 	"strconv"
+	"time"
 
 	apimodel "github.com/polarismesh/specification/source/go/api/v1/model"
 	apisecurity "github.com/polarismesh/specification/source/go/api/v1/security"
@@ -72,6 +74,11 @@ func (svr *Server) Login(req *apisecurity.LoginRequest) *apiservice.Response {
 	return svr.nextSvr.Login(req)
 }
 
+// LoginOIDC 使用外部身份提供方签发的 ID Token 登录
+func (svr *Server) LoginOIDC(ctx context.Context, idToken string) *apiservice.Response {
+	return svr.nextSvr.LoginOIDC(ctx, idToken)
+}
+
 // CheckCredential 检查当前操作用户凭证
 func (svr *Server) CheckCredential(authCtx *model.AcquireContext) error {
 	return svr.nextSvr.CheckCredential(authCtx)
@@ -213,6 +220,66 @@ func (svr *Server) ResetUserToken(ctx context.Context, user *apisecurity.User) *
 	return svr.nextSvr.ResetUserToken(ctx, user)
 }
 
+// RefreshToken 轮换当前用户自身的token
+func (svr *Server) RefreshToken(ctx context.Context) *apiservice.Response {
+	ctx, rsp := svr.verifyAuth(ctx, WriteOp, NotOwner)
+	if rsp != nil {
+		return rsp
+	}
+	return svr.nextSvr.RefreshToken(ctx)
+}
+
+// UnlockUser 解除用户的锁定，只有超级管理员以及用户所属的主账户可以操作
+func (svr *Server) UnlockUser(ctx context.Context, user *apisecurity.User) *apiservice.Response {
+	ctx, rsp := svr.verifyAuth(ctx, WriteOp, MustOwner)
+	if rsp != nil {
+		return rsp
+	}
+	targetUser := svr.GetUserHelper().GetUserByID(ctx, user.GetId().GetValue())
+	if targetUser == nil {
+		return api.NewAuthResponse(apimodel.Code_NotFoundUser)
+	}
+	if !checkUserViewPermission(ctx, targetUser) {
+		return api.NewAuthResponse(apimodel.Code_NotAllowedAccess)
+	}
+	return svr.nextSvr.UnlockUser(ctx, user)
+}
+
+// ListUserSessions 查询用户的控制台登录会话，只能查看自己或者有权限查看的用户
+func (svr *Server) ListUserSessions(ctx context.Context, userID string) ([]*model.UserSession, error) {
+	ctx, rsp := svr.verifyAuth(ctx, ReadOp, NotOwner)
+	if rsp != nil {
+		return nil, errors.New(rsp.GetInfo().GetValue())
+	}
+	if err := svr.checkSessionUser(ctx, userID); err != nil {
+		return nil, err
+	}
+	return svr.nextSvr.ListUserSessions(ctx, userID)
+}
+
+// RevokeUserSessions 注销用户的控制台登录会话，只能注销自己或者有权限查看的用户的会话
+func (svr *Server) RevokeUserSessions(ctx context.Context, userID string, sessionIDs []string) error {
+	ctx, rsp := svr.verifyAuth(ctx, WriteOp, NotOwner)
+	if rsp != nil {
+		return errors.New(rsp.GetInfo().GetValue())
+	}
+	if err := svr.checkSessionUser(ctx, userID); err != nil {
+		return err
+	}
+	return svr.nextSvr.RevokeUserSessions(ctx, userID, sessionIDs)
+}
+
+func (svr *Server) checkSessionUser(ctx context.Context, userID string) error {
+	targetUser := svr.GetUserHelper().GetUserByID(ctx, userID)
+	if targetUser == nil {
+		return errors.New(api.Code2Info(api.NotFoundUser))
+	}
+	if !checkUserViewPermission(ctx, targetUser) {
+		return errors.New(api.Code2Info(api.NotAllowedAccess))
+	}
+	return nil
+}
+
 // CreateGroup 创建用户组
 func (svr *Server) CreateGroup(ctx context.Context, group *apisecurity.UserGroup) *apiservice.Response {
 	ctx, rsp := svr.verifyAuth(ctx, WriteOp, MustOwner)
@@ -333,6 +400,26 @@ func (svr *Server) UpdateGroupToken(ctx context.Context, group *apisecurity.User
 	return svr.nextSvr.UpdateGroupToken(ctx, group)
 }
 
+// UpdateGroupParent 设置用户组的父用户组，只允许超级管理员以及用户组所属的主账户操作
+func (svr *Server) UpdateGroupParent(ctx context.Context, groupID, parentID string) error {
+	ctx, rsp := svr.verifyAuth(ctx, WriteOp, MustOwner)
+	if rsp != nil {
+		return errors.New(rsp.GetInfo().GetValue())
+	}
+	saveGroup := svr.GetUserHelper().GetGroup(ctx, &apisecurity.UserGroup{
+		Id: wrapperspb.String(groupID),
+	})
+	if saveGroup == nil {
+		return errors.New(api.Code2Info(api.NotFoundUserGroup))
+	}
+	if authcommon.ParseUserRole(ctx) != model.AdminUserRole {
+		if saveGroup.GetOwner().GetValue() != utils.ParseUserID(ctx) {
+			return errors.New(api.Code2Info(api.NotAllowedAccess))
+		}
+	}
+	return svr.nextSvr.UpdateGroupParent(ctx, groupID, parentID)
+}
+
 // ResetGroupToken 重置用户组的 token
 func (svr *Server) ResetGroupToken(ctx context.Context, group *apisecurity.UserGroup) *apiservice.Response {
 	ctx, rsp := svr.verifyAuth(ctx, WriteOp, MustOwner)
@@ -353,6 +440,36 @@ func (svr *Server) ResetGroupToken(ctx context.Context, group *apisecurity.UserG
 	return svr.nextSvr.ResetGroupToken(ctx, group)
 }
 
+// ExportMembershipGraph 导出用户组归属关系子图，仅允许超级管理员以及主账户操作，主账户只能以自己名下的 principal 为起点
+func (svr *Server) ExportMembershipGraph(ctx context.Context, root model.Principal,
+	depth int) (*auth.MembershipGraph, error) {
+	ctx, rsp := svr.verifyAuth(ctx, ReadOp, MustOwner)
+	if rsp != nil {
+		return nil, errors.New(rsp.GetInfo().GetValue())
+	}
+	if authcommon.ParseUserRole(ctx) != model.AdminUserRole {
+		var owner string
+		switch root.PrincipalRole {
+		case model.PrincipalUser:
+			if user := svr.GetUserHelper().GetUserByID(ctx, root.PrincipalID); user != nil {
+				owner = user.GetOwner().GetValue()
+				if owner == "" {
+					owner = user.GetId().GetValue()
+				}
+			}
+		case model.PrincipalGroup:
+			group := svr.GetUserHelper().GetGroup(ctx, &apisecurity.UserGroup{Id: wrapperspb.String(root.PrincipalID)})
+			owner = group.GetOwner().GetValue()
+		}
+		if owner != utils.ParseOwnerID(ctx) {
+			log.Error("[Auth][Server] membership graph root not belong to current owner", utils.RequestID(ctx),
+				zap.String("root", root.PrincipalID))
+			return nil, errors.New(api.Code2Info(api.NotAllowedAccess))
+		}
+	}
+	return svr.nextSvr.ExportMembershipGraph(ctx, root, depth)
+}
+
 // verifyAuth 用于 user、group 以及 strategy 模块的鉴权工作检查
 func (svr *Server) verifyAuth(ctx context.Context, isWrite bool,
 	needOwner bool) (context.Context, *apiservice.Response) {
@@ -363,6 +480,10 @@ func (svr *Server) verifyAuth(ctx context.Context, isWrite bool,
 		return nil, api.NewAuthResponse(apimodel.Code_EmptyAutToken)
 	}
 
+	// 写操作作为一次顶层操作，本次操作产生的全部操作记录归属同一个变更集
+	if isWrite {
+		ctx = utils.WithChangeSetID(ctx)
+	}
 	authCtx := model.NewAcquireContext(
 		model.WithRequestContext(ctx),
 		model.WithModule(model.AuthModule),
@@ -389,6 +510,10 @@ func (svr *Server) verifyAuth(ctx context.Context, isWrite bool,
 			zap.String("operation", authCtx.GetMethod()))
 		return nil, api.NewAuthResponse(apimodel.Code_TokenDisabled)
 	}
+	if operateInfo.IsExpired(time.Now()) {
+		log.Error("[Auth][Server] token is expired", utils.RequestID(ctx), zap.Time("expire", operateInfo.ExpireAt))
+		return nil, api.NewAuthResponse(apimodel.Code(api.TokenExpired))
+	}
 
 	if !operateInfo.IsUserToken {
 		log.Error("[Auth][Server] only user role can access this API", utils.RequestID(ctx))
@@ -0,0 +1,392 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package defaultuser
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+	apimodel "github.com/polarismesh/specification/source/go/api/v1/model"
+	apisecurity "github.com/polarismesh/specification/source/go/api/v1/security"
+	apiservice "github.com/polarismesh/specification/source/go/api/v1/service_manage"
+	"go.uber.org/zap"
+
+	"github.com/polarismesh/polaris/auth"
+	cachetypes "github.com/polarismesh/polaris/cache/api"
+	api "github.com/polarismesh/polaris/common/api/v1"
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/store"
+)
+
+const (
+	// defaultLDAPSource 通过 LDAP 登录创建的用户的来源
+	defaultLDAPSource = "LDAP"
+	// defaultLDAPSyncInterval 同步 LDAP 用户组的默认间隔
+	defaultLDAPSyncInterval = 5 * time.Minute
+	// defaultLDAPTimeout 访问 LDAP 服务的默认超时时间
+	defaultLDAPTimeout = 10 * time.Second
+)
+
+// LDAPConfig ldapUser 插件的 LDAP/AD 配置
+type LDAPConfig struct {
+	// URL LDAP 服务地址，支持 ldap:// 以及 ldaps://
+	URL string `json:"url"`
+	// StartTLS 使用 ldap:// 时通过 StartTLS 升级为加密连接
+	StartTLS bool `json:"startTLS"`
+	// TLSCAFile 校验 LDAP 服务证书的 CA 文件，为空时使用系统的 CA 证书
+	TLSCAFile string `json:"tlsCAFile"`
+	// AllowInsecure 允许不开启 StartTLS 的 ldap:// 明文连接，仅用于测试环境
+	AllowInsecure bool `json:"allowInsecure"`
+	// BindDN 用于查询用户以及用户组的服务账号，为空时匿名查询
+	BindDN string `json:"bindDn"`
+	// BindPassword 服务账号的密码
+	BindPassword string `json:"bindPassword"`
+	// BaseDN 查询用户的根节点
+	BaseDN string `json:"baseDn"`
+	// UserFilter 查询用户的过滤条件，%s 替换为登录的用户名，默认 (uid=%s)
+	// 同步用户组时 %s 替换为 *，用于列出全部用户
+	UserFilter string `json:"userFilter"`
+	// UsernameAttribute 作为用户名的属性，默认 uid
+	UsernameAttribute string `json:"usernameAttribute"`
+	// MailAttribute 作为邮箱的属性，默认 mail
+	MailAttribute string `json:"mailAttribute"`
+	// GroupBaseDN 查询用户组的根节点，为空时与 BaseDN 一致
+	GroupBaseDN string `json:"groupBaseDn"`
+	// GroupFilter 查询用户组的过滤条件，默认 (objectClass=groupOfNames)
+	GroupFilter string `json:"groupFilter"`
+	// GroupNameAttribute 作为用户组名称的属性，默认 cn
+	GroupNameAttribute string `json:"groupNameAttribute"`
+	// GroupMemberAttribute 用户组成员 DN 的属性，默认 member
+	GroupMemberAttribute string `json:"groupMemberAttribute"`
+	// Owner 登录用户所属的主账户名称，首次登录时在该主账户下创建子账户
+	Owner string `json:"owner"`
+	// SyncIntervalInSecs 同步用户组的间隔，单位为秒，默认 300
+	SyncIntervalInSecs int `json:"syncIntervalInSecs"`
+	// TimeoutInSecs 访问 LDAP 服务的超时时间，单位为秒，默认 10
+	TimeoutInSecs int `json:"timeoutInSecs"`
+}
+
+// Verify 检查配置是否合法
+func (cfg *LDAPConfig) Verify() error {
+	if cfg.URL == "" || cfg.BaseDN == "" || cfg.Owner == "" {
+		return errors.New("[Auth][Config] ldap url, baseDn and owner must be set")
+	}
+	if err := cfg.verifyURL(); err != nil {
+		return err
+	}
+	if cfg.UserFilter != "" && !strings.Contains(cfg.UserFilter, "%s") {
+		return errors.New("[Auth][Config] ldap userFilter must contain %s")
+	}
+	if _, err := ldap.CompileFilter(cfg.userFilter("polaris")); err != nil {
+		return fmt.Errorf("[Auth][Config] ldap userFilter: %w", err)
+	}
+	if _, err := ldap.CompileFilter(cfg.groupFilter()); err != nil {
+		return fmt.Errorf("[Auth][Config] ldap groupFilter: %w", err)
+	}
+	return nil
+}
+
+func (cfg *LDAPConfig) userFilter(value string) string {
+	filter := cfg.UserFilter
+	if filter == "" {
+		filter = "(" + cfg.usernameAttribute() + "=%s)"
+	}
+	return strings.ReplaceAll(filter, "%s", value)
+}
+
+func (cfg *LDAPConfig) usernameAttribute() string {
+	return defaultString(cfg.UsernameAttribute, "uid")
+}
+
+func (cfg *LDAPConfig) mailAttribute() string {
+	return defaultString(cfg.MailAttribute, "mail")
+}
+
+func (cfg *LDAPConfig) groupBaseDN() string {
+	return defaultString(cfg.GroupBaseDN, cfg.BaseDN)
+}
+
+func (cfg *LDAPConfig) groupFilter() string {
+	return defaultString(cfg.GroupFilter, "(objectClass=groupOfNames)")
+}
+
+func (cfg *LDAPConfig) groupNameAttribute() string {
+	return defaultString(cfg.GroupNameAttribute, "cn")
+}
+
+func (cfg *LDAPConfig) groupMemberAttribute() string {
+	return defaultString(cfg.GroupMemberAttribute, "member")
+}
+
+func (cfg *LDAPConfig) syncInterval() time.Duration {
+	if cfg.SyncIntervalInSecs <= 0 {
+		return defaultLDAPSyncInterval
+	}
+	return time.Duration(cfg.SyncIntervalInSecs) * time.Second
+}
+
+func (cfg *LDAPConfig) timeout() time.Duration {
+	if cfg.TimeoutInSecs <= 0 {
+		return defaultLDAPTimeout
+	}
+	return time.Duration(cfg.TimeoutInSecs) * time.Second
+}
+
+func defaultString(val, def string) string {
+	if val == "" {
+		return def
+	}
+	return val
+}
+
+// LDAPServer 基于 LDAP/AD 登录的用户管理插件
+// 主账户以及非 LDAP 来源的用户仍然使用本地密码登录，用户、用户组的管理能力与 defaultUser 一致
+type LDAPServer struct {
+	*Server
+	ldap   *LDAPConfig
+	lock   sync.Mutex
+	stopCh chan struct{}
+}
+
+// Name of the user operator plugin
+func (svr *LDAPServer) Name() string {
+	return auth.LDAPUserMgnPluginName
+}
+
+// Initialize 初始化，并开始定期同步 LDAP 用户组
+func (svr *LDAPServer) Initialize(authOpt *auth.Config, storage store.Store,
+	cacheMgr cachetypes.CacheManager) error {
+	if err := svr.Server.Initialize(authOpt, storage, cacheMgr); err != nil {
+		return err
+	}
+	if svr.authOpt.LDAP == nil {
+		return errors.New("[Auth][Config] ldap must be set for ldapUser")
+	}
+	svr.lock.Lock()
+	defer svr.lock.Unlock()
+	if svr.stopCh != nil {
+		close(svr.stopCh)
+	}
+	svr.ldap = svr.authOpt.LDAP
+	svr.stopCh = make(chan struct{})
+	go svr.runGroupSync(svr.ldap.syncInterval(), svr.stopCh)
+	return nil
+}
+
+// Login 主账户以及非 LDAP 来源的用户使用本地密码登录，其余用户通过 LDAP 校验密码，首次登录时创建子账户
+func (svr *LDAPServer) Login(req *apisecurity.LoginRequest) *apiservice.Response {
+	cfg := svr.ldap
+	name := req.GetName().GetValue()
+	ownerName := req.GetOwner().GetValue()
+	userCache := svr.cacheMgr.User()
+	if ownerName != "" && ownerName != cfg.Owner {
+		return svr.Server.Login(req)
+	}
+	if ownerName == "" && userCache.GetUserByName(name, name) != nil {
+		return svr.Server.Login(req)
+	}
+	if user := userCache.GetUserByName(name, cfg.Owner); user != nil && user.Source != defaultLDAPSource {
+		return svr.Server.Login(req)
+	}
+
+	// LDAP 使用空密码 bind 视为匿名登录，必须拒绝
+	password := req.GetPassword().GetValue()
+	if CheckName(req.GetName()) != nil || password == "" {
+		return api.NewAuthResponseWithMsg(apimodel.Code_NotAllowedAccess, model.ErrorWrongUsernameOrPassword.Error())
+	}
+	entry, err := svr.authenticate(name, password)
+	if errors.Is(err, ErrorLDAPInvalidCredentials) {
+		return api.NewAuthResponseWithMsg(apimodel.Code_NotAllowedAccess, model.ErrorWrongUsernameOrPassword.Error())
+	}
+	if err != nil {
+		log.Error("[Auth][User] ldap authenticate", zap.String("name", name), zap.Error(err))
+		return api.NewAuthResponseWithMsg(apimodel.Code_ExecuteException, err.Error())
+	}
+
+	owner := userCache.GetUserByName(cfg.Owner, cfg.Owner)
+	if owner == nil {
+		log.Error("[Auth][User] ldap owner not found", zap.String("owner", cfg.Owner))
+		return api.NewAuthResponse(apimodel.Code_NotFoundOwnerUser)
+	}
+	user, errResp := svr.loadExternalUser(context.Background(), owner, name, defaultLDAPSource,
+		entry.GetEqualFoldAttributeValue(cfg.mailAttribute()))
+	if errResp != nil {
+		return errResp
+	}
+	return svr.loginResponse(user)
+}
+
+// authenticate 通过服务账号查询到用户的 DN 后，使用用户的密码 bind
+func (svr *LDAPServer) authenticate(name, password string) (*ldap.Entry, error) {
+	cfg := svr.ldap
+	conn, err := svr.connect()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	entries, err := searchLDAP(conn, cfg.BaseDN, cfg.userFilter(ldap.EscapeFilter(name)),
+		[]string{cfg.usernameAttribute(), cfg.mailAttribute()})
+	if err != nil {
+		return nil, err
+	}
+	switch len(entries) {
+	case 0:
+		return nil, ErrorLDAPInvalidCredentials
+	case 1:
+	default:
+		return nil, fmt.Errorf("ldap user %s is ambiguous, %d entries matched", name, len(entries))
+	}
+	if err := bindLDAP(conn, entries[0].DN, password); err != nil {
+		return nil, err
+	}
+	return entries[0], nil
+}
+
+// connect 建立连接并使用服务账号 bind
+func (svr *LDAPServer) connect() (*ldap.Conn, error) {
+	cfg := svr.ldap
+	conn, err := dialLDAP(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.BindDN != "" {
+		if err := bindLDAP(conn, cfg.BindDN, cfg.BindPassword); err != nil {
+			_ = conn.Close()
+			return nil, fmt.Errorf("ldap service account bind: %w", err)
+		}
+	}
+	return conn, nil
+}
+
+func (svr *LDAPServer) runGroupSync(interval time.Duration, stopCh chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := svr.syncGroups(context.Background()); err != nil {
+			log.Error("[Auth][User] sync ldap user groups", zap.Error(err))
+		}
+		select {
+		case <-ticker.C:
+		case <-stopCh:
+			return
+		}
+	}
+}
+
+// syncGroups 将 LDAP 用户组的成员同步到主账户下的同名用户组
+// 只处理已经登录过的 LDAP 用户，用户组中其他来源的用户以及 LDAP 中不存在的用户组保持不变
+func (svr *LDAPServer) syncGroups(ctx context.Context) error {
+	cfg := svr.ldap
+	userCache := svr.cacheMgr.User()
+	owner := userCache.GetUserByName(cfg.Owner, cfg.Owner)
+	if owner == nil {
+		return fmt.Errorf("ldap owner %s not found", cfg.Owner)
+	}
+	conn, err := svr.connect()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	users, err := searchLDAP(conn, cfg.BaseDN, cfg.userFilter("*"), []string{cfg.usernameAttribute()})
+	if err != nil {
+		return err
+	}
+	usernames := make(map[string]string, len(users))
+	for i := range users {
+		usernames[normalizeLDAPDN(users[i].DN)] = users[i].GetEqualFoldAttributeValue(cfg.usernameAttribute())
+	}
+	groups, err := searchLDAP(conn, cfg.groupBaseDN(), cfg.groupFilter(),
+		[]string{cfg.groupNameAttribute(), cfg.groupMemberAttribute()})
+	if err != nil {
+		return err
+	}
+
+	for i := range groups {
+		name := groups[i].GetEqualFoldAttributeValue(cfg.groupNameAttribute())
+		if name == "" {
+			continue
+		}
+		expect := map[string]struct{}{}
+		for _, member := range groups[i].GetEqualFoldAttributeValues(cfg.groupMemberAttribute()) {
+			user := userCache.GetUserByName(usernames[normalizeLDAPDN(member)], cfg.Owner)
+			if user != nil && user.Source == defaultLDAPSource {
+				expect[user.ID] = struct{}{}
+			}
+		}
+		if err := svr.syncGroupMembers(ctx, owner, name, expect); err != nil {
+			return err
+		}
+	}
+	log.Info("[Auth][User] sync ldap user groups", zap.Int("users", len(users)), zap.Int("groups", len(groups)))
+	return nil
+}
+
+// syncGroupMembers 同步一个用户组内 LDAP 来源的成员，用户组不存在时创建
+func (svr *LDAPServer) syncGroupMembers(ctx context.Context, owner *model.User, name string,
+	expect map[string]struct{}) error {
+	group, err := svr.storage.GetGroupByName(name, owner.ID)
+	if err != nil {
+		return err
+	}
+	if group == nil {
+		if len(expect) == 0 {
+			return nil
+		}
+		_, err := svr.createExternalGroup(ctx, owner, name, defaultLDAPSource, sortedKeys(expect))
+		return err
+	}
+
+	userCache := svr.cacheMgr.User()
+	current := map[string]struct{}{}
+	if detail := userCache.GetGroup(group.ID); detail != nil {
+		current = detail.UserIds
+	}
+	var add, remove []string
+	for id := range expect {
+		if _, ok := current[id]; !ok {
+			add = append(add, id)
+		}
+	}
+	for id := range current {
+		if _, ok := expect[id]; ok {
+			continue
+		}
+		if user := userCache.GetUserByID(id); user != nil && user.Source == defaultLDAPSource {
+			remove = append(remove, id)
+		}
+	}
+	sort.Strings(add)
+	sort.Strings(remove)
+	return svr.updateGroupMembers(ctx, group, add, remove)
+}
+
+func sortedKeys(m map[string]struct{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,141 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package defaultuser
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+var (
+	// ErrorLDAPInvalidCredentials LDAP 用户名或者密码错误
+	ErrorLDAPInvalidCredentials = errors.New("ldap invalid credentials")
+)
+
+// verifyURL 未加密的 ldap:// 连接会明文传输用户密码，必须开启 startTLS 或者显式配置 allowInsecure
+func (cfg *LDAPConfig) verifyURL() error {
+	u, err := url.Parse(cfg.URL)
+	if err != nil {
+		return fmt.Errorf("[Auth][Config] ldap url: %w", err)
+	}
+	switch u.Scheme {
+	case "ldap":
+		if !cfg.StartTLS && !cfg.AllowInsecure {
+			return errors.New("[Auth][Config] ldap:// sends passwords in plain text, " +
+				"use ldaps://, enable startTLS or set allowInsecure")
+		}
+	case "ldaps":
+		if cfg.StartTLS {
+			return errors.New("[Auth][Config] ldap startTLS only works with ldap://")
+		}
+	default:
+		return fmt.Errorf("[Auth][Config] unsupported ldap url scheme: %s", u.Scheme)
+	}
+	if _, err := cfg.tlsConfig(u.Hostname()); err != nil {
+		return fmt.Errorf("[Auth][Config] ldap tlsCAFile: %w", err)
+	}
+	return nil
+}
+
+// tlsConfig 配置了 tlsCAFile 时只信任该文件中的 CA 证书，否则使用系统的 CA 证书
+func (cfg *LDAPConfig) tlsConfig(serverName string) (*tls.Config, error) {
+	tlsCfg := &tls.Config{ServerName: serverName}
+	if cfg.TLSCAFile == "" {
+		return tlsCfg, nil
+	}
+	pem, err := os.ReadFile(cfg.TLSCAFile)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificate found in %s", cfg.TLSCAFile)
+	}
+	tlsCfg.RootCAs = pool
+	return tlsCfg, nil
+}
+
+// dialLDAP 支持 ldap:// 以及 ldaps:// 两种地址，ldap:// 开启 startTLS 时先升级为 TLS 连接
+func dialLDAP(cfg *LDAPConfig) (*ldap.Conn, error) {
+	u, err := url.Parse(cfg.URL)
+	if err != nil {
+		return nil, err
+	}
+	tlsCfg, err := cfg.tlsConfig(u.Hostname())
+	if err != nil {
+		return nil, err
+	}
+	conn, err := ldap.DialURL(cfg.URL, ldap.DialWithDialer(&net.Dialer{Timeout: cfg.timeout()}),
+		ldap.DialWithTLSConfig(tlsCfg))
+	if err != nil {
+		return nil, err
+	}
+	conn.SetTimeout(cfg.timeout())
+	if cfg.StartTLS {
+		if err := conn.StartTLS(tlsCfg); err != nil {
+			_ = conn.Close()
+			return nil, fmt.Errorf("ldap start tls: %w", err)
+		}
+	}
+	return conn, nil
+}
+
+// bindLDAP simple bind，用户名或者密码错误时返回 ErrorLDAPInvalidCredentials
+func bindLDAP(conn *ldap.Conn, dn, password string) error {
+	err := conn.Bind(dn, password)
+	if ldap.IsErrorWithCode(err, ldap.LDAPResultInvalidCredentials) {
+		return ErrorLDAPInvalidCredentials
+	}
+	return err
+}
+
+// searchLDAP 在 baseDN 下查询整个子树
+func searchLDAP(conn *ldap.Conn, baseDN, filter string, attrs []string) ([]*ldap.Entry, error) {
+	req := ldap.NewSearchRequest(baseDN, ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 0, 0, false,
+		filter, attrs, nil)
+	resp, err := conn.Search(req)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Entries, nil
+}
+
+// normalizeLDAPDN 用于比较 DN，忽略属性名、属性值的大小写以及 RDN 之间的空格
+func normalizeLDAPDN(dn string) string {
+	parsed, err := ldap.ParseDN(dn)
+	if err != nil {
+		return strings.ToLower(dn)
+	}
+	rdns := make([]string, 0, len(parsed.RDNs))
+	for _, rdn := range parsed.RDNs {
+		attrs := make([]string, 0, len(rdn.Attributes))
+		for _, attr := range rdn.Attributes {
+			attrs = append(attrs, strings.ToLower(attr.Type)+"="+strings.ToLower(attr.Value))
+		}
+		rdns = append(rdns, strings.Join(attrs, "+"))
+	}
+	return strings.Join(rdns, ",")
+}
@@ -0,0 +1,311 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package defaultuser
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	ber "github.com/go-asn1-ber/asn1-ber"
+	"github.com/go-ldap/ldap/v3"
+	"github.com/golang/mock/gomock"
+	apimodel "github.com/polarismesh/specification/source/go/api/v1/model"
+	apisecurity "github.com/polarismesh/specification/source/go/api/v1/security"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/polarismesh/polaris/cache"
+	cachetypes "github.com/polarismesh/polaris/cache/api"
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/common/utils"
+	storemock "github.com/polarismesh/polaris/store/mock"
+)
+
+// fakeLDAPServer 只用于测试的 LDAP 服务，search 按照过滤条件返回预置的条目
+type fakeLDAPServer struct {
+	listener  net.Listener
+	passwords map[string]string
+	entries   map[string][]*ldap.Entry
+}
+
+func newFakeLDAPServer(t *testing.T) *fakeLDAPServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &fakeLDAPServer{listener: listener, passwords: map[string]string{}, entries: map[string][]*ldap.Entry{}}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	t.Cleanup(func() {
+		_ = listener.Close()
+	})
+	return s
+}
+
+func (s *fakeLDAPServer) url() string {
+	return "ldap://" + s.listener.Addr().String()
+}
+
+func (s *fakeLDAPServer) addSearch(t *testing.T, filter string, entries ...*ldap.Entry) {
+	compiled, err := ldap.CompileFilter(filter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decompiled, err := ldap.DecompileFilter(compiled)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.entries[decompiled] = entries
+}
+
+func ldapString(value string) *ber.Packet {
+	return ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, value, "")
+}
+
+func ldapResult(tag ber.Tag, code int64) *ber.Packet {
+	p := ber.Encode(ber.ClassApplication, ber.TypeConstructed, tag, nil, "")
+	p.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagEnumerated, code, ""))
+	p.AppendChild(ldapString(""))
+	p.AppendChild(ldapString(""))
+	return p
+}
+
+func (s *fakeLDAPServer) serve(conn net.Conn) {
+	defer conn.Close()
+	for {
+		packet, err := ber.ReadPacket(conn)
+		if err != nil || len(packet.Children) < 2 {
+			return
+		}
+		msgID := packet.Children[0].Value
+		op := packet.Children[1]
+		reply := func(resp *ber.Packet) {
+			envelope := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "")
+			envelope.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, msgID, ""))
+			envelope.AppendChild(resp)
+			_, _ = conn.Write(envelope.Bytes())
+		}
+		switch op.Tag {
+		case ldap.ApplicationBindRequest:
+			dn, password := op.Children[1].Data.String(), op.Children[2].Data.String()
+			code := int64(ldap.LDAPResultInvalidCredentials)
+			if expect, ok := s.passwords[dn]; ok && expect == password {
+				code = ldap.LDAPResultSuccess
+			}
+			reply(ldapResult(ldap.ApplicationBindResponse, code))
+		case ldap.ApplicationSearchRequest:
+			filter, _ := ldap.DecompileFilter(op.Children[6])
+			for _, entry := range s.entries[filter] {
+				resp := ber.Encode(ber.ClassApplication, ber.TypeConstructed,
+					ldap.ApplicationSearchResultEntry, nil, "")
+				resp.AppendChild(ldapString(entry.DN))
+				attrs := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "")
+				for _, attr := range entry.Attributes {
+					item := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "")
+					item.AppendChild(ldapString(attr.Name))
+					values := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSet, nil, "")
+					for _, v := range attr.Values {
+						values.AppendChild(ldapString(v))
+					}
+					item.AppendChild(values)
+					attrs.AppendChild(item)
+				}
+				resp.AppendChild(attrs)
+				reply(resp)
+			}
+			reply(ldapResult(ldap.ApplicationSearchResultDone, ldap.LDAPResultSuccess))
+		case ldap.ApplicationUnbindRequest:
+			return
+		}
+	}
+}
+
+func Test_LDAPConfigVerify(t *testing.T) {
+	newConfig := func(url string) *LDAPConfig {
+		return &LDAPConfig{URL: url, BaseDN: "ou=people,dc=example,dc=com", Owner: "polaris"}
+	}
+	assert.NoError(t, newConfig("ldaps://ldap.example.com").Verify())
+
+	// 明文 ldap:// 必须开启 startTLS 或者显式允许
+	cfg := newConfig("ldap://ldap.example.com")
+	assert.Error(t, cfg.Verify())
+	cfg.StartTLS = true
+	assert.NoError(t, cfg.Verify())
+	cfg.StartTLS, cfg.AllowInsecure = false, true
+	assert.NoError(t, cfg.Verify())
+
+	cfg = newConfig("ldaps://ldap.example.com")
+	cfg.StartTLS = true
+	assert.Error(t, cfg.Verify())
+	assert.Error(t, newConfig("http://ldap.example.com").Verify())
+
+	cfg = newConfig("ldaps://ldap.example.com")
+	cfg.TLSCAFile = filepath.Join(t.TempDir(), "ca.pem")
+	assert.Error(t, cfg.Verify())
+	assert.NoError(t, os.WriteFile(cfg.TLSCAFile, []byte("not a certificate"), 0600))
+	assert.Error(t, cfg.Verify())
+
+	for _, filter := range []string{"uid=%s", "(uid=%s", "(&)", "(uid=\\zz%s)"} {
+		cfg := newConfig("ldaps://ldap.example.com")
+		cfg.UserFilter = filter
+		assert.Error(t, cfg.Verify(), filter)
+	}
+	assert.Equal(t, "a\\2a\\28b\\29", ldap.EscapeFilter("a*(b)"))
+}
+
+func Test_LDAPServer(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	const salt = "polarismesh@2021"
+	newUser := func(name, owner, source string) *model.User {
+		id := utils.NewUUID()
+		token, _ := createUserToken(id, salt)
+		pwd, _ := bcrypt.GenerateFromPassword([]byte("polaris"), bcrypt.MinCost)
+		role := model.SubAccountUserRole
+		if owner == "" {
+			role = model.OwnerUserRole
+		}
+		return &model.User{ID: id, Name: name, Owner: owner, Source: source, Type: role, Token: token,
+			TokenEnable: true, Valid: true, Password: string(pwd)}
+	}
+	owner := newUser("polaris", "", "Polaris")
+	local := newUser("bob", owner.ID, "Polaris")
+	carol := newUser("carol", owner.ID, defaultLDAPSource)
+	dave := newUser("dave", owner.ID, defaultLDAPSource)
+	dev := &model.UserGroupDetail{
+		UserGroup: &model.UserGroup{ID: utils.NewUUID(), Name: "dev", Owner: owner.ID, Token: "dev-token",
+			TokenEnable: true, Valid: true, Comment: "dev group"},
+		UserIds: map[string]struct{}{local.ID: {}, dave.ID: {}},
+	}
+
+	ldapSvr := newFakeLDAPServer(t)
+	ldapSvr.passwords["cn=admin,dc=example,dc=com"] = "secret"
+	ldapSvr.passwords["uid=alice,ou=people,dc=example,dc=com"] = "alice-pwd"
+	alice := ldap.NewEntry("uid=alice,ou=people,dc=example,dc=com",
+		map[string][]string{"uid": {"alice"}, "mail": {"alice@example.com"}})
+	ldapSvr.addSearch(t, "(uid=alice)", alice)
+	ldapSvr.addSearch(t, "(uid=*)", alice,
+		ldap.NewEntry("uid=carol,ou=people,dc=example,dc=com", map[string][]string{"uid": {"carol"}}),
+		ldap.NewEntry("uid=dave,ou=people,dc=example,dc=com", map[string][]string{"uid": {"dave"}}))
+	ldapSvr.addSearch(t, "(objectClass=groupOfNames)",
+		ldap.NewEntry("cn=dev,ou=groups,dc=example,dc=com", map[string][]string{
+			"cn": {"dev"}, "member": {"UID=carol, ou=people,dc=example,dc=com", alice.DN}}),
+		ldap.NewEntry("cn=ops,ou=groups,dc=example,dc=com", map[string][]string{
+			"cn": {"ops"}, "member": {"uid=carol,ou=people,dc=example,dc=com"}}))
+
+	storage := storemock.NewMockStore(ctrl)
+	storage.EXPECT().GetServicesCount().AnyTimes().Return(uint32(1), nil)
+	storage.EXPECT().GetUnixSecond(gomock.Any()).AnyTimes().Return(time.Now().Unix(), nil)
+	storage.EXPECT().GetUsersForCache(gomock.Any(), gomock.Any()).AnyTimes().
+		Return([]*model.User{owner, local, carol, dave}, nil)
+	storage.EXPECT().GetGroupsForCache(gomock.Any(), gomock.Any()).AnyTimes().
+		Return([]*model.UserGroupDetail{dev}, nil)
+	storage.EXPECT().GetUserSessionsForCache(gomock.Any(), gomock.Any()).AnyTimes().Return(nil, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cacheMgn, err := cache.TestCacheInitialize(ctx, &cache.Config{}, storage)
+	assert.NoError(t, err)
+	_ = cacheMgn.OpenResourceCache(cachetypes.ConfigEntry{Name: cachetypes.UsersName})
+	defer cacheMgn.Close()
+	_ = cacheMgn.TestUpdate()
+
+	cfg := &LDAPConfig{
+		URL:          ldapSvr.url(),
+		BindDN:       "cn=admin,dc=example,dc=com",
+		BindPassword: "secret",
+		BaseDN:       "ou=people,dc=example,dc=com",
+		GroupBaseDN:  "ou=groups,dc=example,dc=com",
+		Owner:        owner.Name,
+		// 测试使用的 LDAP 服务不支持 TLS
+		AllowInsecure: true,
+	}
+	assert.NoError(t, cfg.Verify())
+	svr := &LDAPServer{
+		Server: &Server{authOpt: &AuthConfig{Salt: salt, LDAP: cfg}, storage: storage, cacheMgr: cacheMgn},
+		ldap:   cfg,
+	}
+	login := func(name, password string) *apisecurity.LoginRequest {
+		return &apisecurity.LoginRequest{Name: utils.NewStringValue(name), Password: utils.NewStringValue(password)}
+	}
+
+	t.Run("首次登录创建LDAP用户", func(t *testing.T) {
+		var created *model.User
+		storage.EXPECT().GetUserByName("alice", owner.ID).Return(nil, nil)
+		storage.EXPECT().AddUser(gomock.Any()).DoAndReturn(func(user *model.User) error {
+			created = user
+			return nil
+		})
+		resp := svr.Login(login("alice", "alice-pwd"))
+		assert.Equal(t, uint32(apimodel.Code_ExecuteSuccess), resp.GetCode().GetValue(), resp.GetInfo().GetValue())
+		assert.Equal(t, defaultLDAPSource, created.Source)
+		assert.Equal(t, owner.ID, created.Owner)
+		assert.Equal(t, "alice@example.com", created.Email)
+		assert.Equal(t, created.Token, resp.GetLoginResponse().GetToken().GetValue())
+	})
+
+	t.Run("密码错误", func(t *testing.T) {
+		for _, req := range []*apisecurity.LoginRequest{login("alice", "wrong"), login("alice", ""),
+			login("nobody", "alice-pwd")} {
+			resp := svr.Login(req)
+			assert.Equal(t, uint32(apimodel.Code_NotAllowedAccess), resp.GetCode().GetValue())
+		}
+	})
+
+	t.Run("主账户以及本地用户使用本地密码登录", func(t *testing.T) {
+		resp := svr.Login(login(owner.Name, "polaris"))
+		assert.Equal(t, uint32(apimodel.Code_ExecuteSuccess), resp.GetCode().GetValue(), resp.GetInfo().GetValue())
+		req := login(local.Name, "polaris")
+		req.Owner = utils.NewStringValue(owner.Name)
+		resp = svr.Login(req)
+		assert.Equal(t, uint32(apimodel.Code_ExecuteSuccess), resp.GetCode().GetValue(), resp.GetInfo().GetValue())
+		assert.Equal(t, local.ID, resp.GetLoginResponse().GetUserId().GetValue())
+	})
+
+	t.Run("同步LDAP用户组", func(t *testing.T) {
+		storage.EXPECT().GetGroupByName("dev", owner.ID).Return(dev.UserGroup, nil)
+		storage.EXPECT().GetGroupByName("ops", owner.ID).Return(nil, nil)
+		storage.EXPECT().UpdateGroup(gomock.Any()).DoAndReturn(func(group *model.ModifyUserGroup) error {
+			assert.Equal(t, dev.ID, group.ID)
+			assert.Equal(t, "dev-token", group.Token)
+			assert.Equal(t, "dev group", group.Comment)
+			// alice 尚未出现在缓存中，下次同步时再加入; 本地用户 bob 保持不变
+			assert.Equal(t, []string{carol.ID}, group.AddUserIds)
+			assert.Equal(t, []string{dave.ID}, group.RemoveUserIds)
+			return nil
+		})
+		storage.EXPECT().AddGroup(gomock.Any()).DoAndReturn(func(group *model.UserGroupDetail) error {
+			assert.Equal(t, "ops", group.Name)
+			assert.Equal(t, map[string]struct{}{carol.ID: {}}, group.UserIds)
+			return nil
+		})
+		assert.NoError(t, svr.syncGroups(context.Background()))
+	})
+}
@@ -0,0 +1,195 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package defaultuser
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/polarismesh/polaris/auth"
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/common/utils"
+)
+
+// ExportMembershipGraph 在同一个读事务中加载用户以及用户组，导出以 root 为起点、深度不超过 depth 的归属关系子图
+func (svr *Server) ExportMembershipGraph(ctx context.Context, root model.Principal,
+	depth int) (*auth.MembershipGraph, error) {
+	if root.PrincipalID == "" || depth <= 0 || depth > auth.MaxMembershipGraphDepth {
+		return nil, fmt.Errorf("invalid membership graph request, root=%s depth=%d, depth must in [1, %d]",
+			root.PrincipalID, depth, auth.MaxMembershipGraphDepth)
+	}
+	if root.PrincipalRole != model.PrincipalUser && root.PrincipalRole != model.PrincipalGroup {
+		return nil, fmt.Errorf("invalid membership graph root type: %d", root.PrincipalRole)
+	}
+
+	tx, err := svr.storage.StartReadTx()
+	if err != nil {
+		if tx != nil {
+			_ = tx.Rollback()
+		}
+		log.Error("[Auth][Group] begin storage read tx", utils.RequestID(ctx), zap.Error(err))
+		return nil, err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	if err := tx.CreateReadView(); err != nil {
+		log.Error("[Auth][Group] create storage snapshot read view", utils.RequestID(ctx), zap.Error(err))
+		return nil, err
+	}
+	users, err := svr.storage.GetAllUsersTx(tx)
+	if err != nil {
+		log.Error("[Auth][Group] load users", utils.RequestID(ctx), zap.Error(err))
+		return nil, err
+	}
+	groups, err := svr.storage.GetAllGroupsTx(tx)
+	if err != nil {
+		log.Error("[Auth][Group] load user groups", utils.RequestID(ctx), zap.Error(err))
+		return nil, err
+	}
+
+	graph, err := buildMembershipGraph(root, depth, users, groups)
+	if err != nil {
+		log.Error("[Auth][Group] export membership graph", utils.RequestID(ctx), zap.Error(err))
+		return nil, err
+	}
+	log.Info("[Auth][Group] export membership graph", utils.RequestID(ctx),
+		zap.String("root", root.PrincipalID), zap.Int("depth", depth),
+		zap.Int("nodes", len(graph.Nodes)), zap.Int("edges", len(graph.Edges)))
+	return graph, nil
+}
+
+// buildMembershipGraph 从 root 开始按层展开，用户的相邻节点为其所在的用户组，用户组的相邻节点为组内的用户
+// 已经访问过的节点不再重复展开，因此环状的归属关系也只会输出一次
+func buildMembershipGraph(root model.Principal, depth int, users []*model.User,
+	groups []*model.UserGroupDetail) (*auth.MembershipGraph, error) {
+	userByID := make(map[string]*model.User, len(users))
+	for i := range users {
+		userByID[users[i].ID] = users[i]
+	}
+	groupByID := make(map[string]*model.UserGroupDetail, len(groups))
+	userGroups := make(map[string][]string)
+	for i := range groups {
+		group := groups[i]
+		groupByID[group.ID] = group
+		for uid := range group.UserIds {
+			userGroups[uid] = append(userGroups[uid], group.ID)
+		}
+	}
+
+	graph := &auth.MembershipGraph{
+		Root:       root,
+		Depth:      depth,
+		CreateTime: time.Now(),
+		Nodes:      make([]auth.MembershipNode, 0, 8),
+		Edges:      make([]auth.SnapshotMembership, 0, 8),
+	}
+	visited := make(map[model.Principal]struct{})
+	edges := make(map[auth.SnapshotMembership]struct{})
+
+	visit := func(p model.Principal, level int) bool {
+		if _, ok := visited[p]; ok {
+			return false
+		}
+		node := auth.MembershipNode{
+			ID:    p.PrincipalID,
+			Type:  model.PrincipalNames[p.PrincipalRole],
+			Depth: level,
+		}
+		if p.PrincipalRole == model.PrincipalUser {
+			user, ok := userByID[p.PrincipalID]
+			if !ok {
+				return false
+			}
+			node.Name, node.Owner = user.Name, user.Owner
+		} else {
+			group, ok := groupByID[p.PrincipalID]
+			if !ok {
+				return false
+			}
+			node.Name, node.Owner = group.Name, group.Owner
+		}
+		visited[p] = struct{}{}
+		graph.Nodes = append(graph.Nodes, node)
+		return true
+	}
+
+	if !visit(root, 0) {
+		return nil, fmt.Errorf("membership graph root %s(%s) not found", root.PrincipalID,
+			model.PrincipalNames[root.PrincipalRole])
+	}
+	frontier := []model.Principal{root}
+	for level := 1; level <= depth && len(frontier) > 0; level++ {
+		next := make([]model.Principal, 0, len(frontier))
+		for _, cur := range frontier {
+			var (
+				neighbors []string
+				role      model.PrincipalType
+			)
+			if cur.PrincipalRole == model.PrincipalUser {
+				neighbors, role = userGroups[cur.PrincipalID], model.PrincipalGroup
+			} else {
+				for uid := range groupByID[cur.PrincipalID].UserIds {
+					neighbors = append(neighbors, uid)
+				}
+				role = model.PrincipalUser
+			}
+			for _, id := range neighbors {
+				neighbor := model.Principal{PrincipalID: id, PrincipalRole: role}
+				if visit(neighbor, level) {
+					next = append(next, neighbor)
+				} else if _, ok := visited[neighbor]; !ok {
+					// 组内已经被删除的用户
+					continue
+				}
+				edge := auth.SnapshotMembership{GroupID: id, UserID: cur.PrincipalID}
+				if role == model.PrincipalUser {
+					edge = auth.SnapshotMembership{GroupID: cur.PrincipalID, UserID: id}
+				}
+				if _, ok := edges[edge]; !ok {
+					edges[edge] = struct{}{}
+					graph.Edges = append(graph.Edges, edge)
+				}
+			}
+		}
+		frontier = next
+	}
+
+	sort.Slice(graph.Nodes, func(i, j int) bool {
+		a, b := graph.Nodes[i], graph.Nodes[j]
+		if a.Depth != b.Depth {
+			return a.Depth < b.Depth
+		}
+		if a.Type != b.Type {
+			return a.Type > b.Type
+		}
+		return a.ID < b.ID
+	})
+	sort.Slice(graph.Edges, func(i, j int) bool {
+		if graph.Edges[i].GroupID != graph.Edges[j].GroupID {
+			return graph.Edges[i].GroupID < graph.Edges[j].GroupID
+		}
+		return graph.Edges[i].UserID < graph.Edges[j].UserID
+	})
+	return graph, nil
+}
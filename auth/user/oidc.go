@@ -0,0 +1,345 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package defaultuser
+
+import (
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultOIDCUsernameClaim 默认作为用户名的 claim
+	defaultOIDCUsernameClaim = "preferred_username"
+	// defaultOIDCGroupsClaim 默认作为用户组列表的 claim
+	defaultOIDCGroupsClaim = "groups"
+	// defaultOIDCSource 通过 OIDC 登录创建的用户的来源
+	defaultOIDCSource = "OIDC"
+	// defaultOIDCKeyRefresh 两次拉取 JWKS 之间的最小间隔
+	defaultOIDCKeyRefresh = 5 * time.Minute
+	// defaultOIDCClockSkew 校验 ID Token 有效期时允许的时钟偏差
+	defaultOIDCClockSkew = time.Minute
+	// oidcHTTPTimeout 访问身份提供方的超时时间
+	oidcHTTPTimeout = 10 * time.Second
+)
+
+var (
+	// ErrorOIDCDisabled 没有配置 OIDC 登录
+	ErrorOIDCDisabled = errors.New("oidc login is not enabled")
+	// ErrorInvalidIDToken ID Token 不合法
+	ErrorInvalidIDToken = errors.New("invalid id token")
+)
+
+// OIDCConfig 通过外部身份提供方(Keycloak、Dex 等)签发的 ID Token 登录控制台的配置
+type OIDCConfig struct {
+	// Issuer 身份提供方的 issuer, ID Token 的 iss 必须与之一致
+	Issuer string `json:"issuer"`
+	// ClientID 在身份提供方注册的客户端 ID, ID Token 的 aud 必须包含该值
+	ClientID string `json:"clientId"`
+	// JWKSURL 身份提供方的签名公钥地址，为空时通过 issuer 的 .well-known/openid-configuration 获取
+	JWKSURL string `json:"jwksUrl"`
+	// Owner 登录用户所属的主账户名称，首次登录时在该主账户下创建子账户
+	Owner string `json:"owner"`
+	// UsernameClaim 作为用户名的 claim，默认 preferred_username
+	UsernameClaim string `json:"usernameClaim"`
+	// GroupsClaim 作为用户组名称列表的 claim，默认 groups
+	GroupsClaim string `json:"groupsClaim"`
+	// AutoCreateGroups 用户组不存在时是否自动创建，默认忽略不存在的用户组
+	AutoCreateGroups bool `json:"autoCreateGroups"`
+	// GroupPrefix 由 OIDC 登录管理的用户组名称前缀，只同步名称带有该前缀的用户组，
+	// 用户只会被移出这些用户组。为空时只加入 groups claim 中的用户组，不会将用户移出任何用户组
+	GroupPrefix string `json:"groupPrefix"`
+	// KeyRefreshInSecs 遇到未知的签名公钥时重新拉取 JWKS 的最小间隔，单位为秒
+	KeyRefreshInSecs int `json:"keyRefreshInSecs"`
+	// ClockSkewInSecs 校验 ID Token 有效期时允许的时钟偏差，单位为秒
+	ClockSkewInSecs int `json:"clockSkewInSecs"`
+}
+
+// Verify 检查配置是否合法
+func (cfg *OIDCConfig) Verify() error {
+	if cfg.Issuer == "" || cfg.ClientID == "" || cfg.Owner == "" {
+		return errors.New("[Auth][Config] oidc issuer, clientId and owner must be set")
+	}
+	return nil
+}
+
+func (cfg *OIDCConfig) usernameClaim() string {
+	if cfg.UsernameClaim == "" {
+		return defaultOIDCUsernameClaim
+	}
+	return cfg.UsernameClaim
+}
+
+func (cfg *OIDCConfig) groupsClaim() string {
+	if cfg.GroupsClaim == "" {
+		return defaultOIDCGroupsClaim
+	}
+	return cfg.GroupsClaim
+}
+
+func (cfg *OIDCConfig) keyRefresh() time.Duration {
+	if cfg.KeyRefreshInSecs <= 0 {
+		return defaultOIDCKeyRefresh
+	}
+	return time.Duration(cfg.KeyRefreshInSecs) * time.Second
+}
+
+func (cfg *OIDCConfig) clockSkew() time.Duration {
+	if cfg.ClockSkewInSecs <= 0 {
+		return defaultOIDCClockSkew
+	}
+	return time.Duration(cfg.ClockSkewInSecs) * time.Second
+}
+
+// oidcVerifier 校验身份提供方签发的 ID Token，目前仅支持 RS256 签名
+type oidcVerifier struct {
+	cfg    *OIDCConfig
+	client *http.Client
+
+	lock    sync.Mutex
+	jwksURL string
+	keys    map[string]*rsa.PublicKey
+	// lastAttempt 最近一次拉取 JWKS 的时间，拉取失败同样记录，避免身份提供方不可用时频繁拉取
+	lastAttempt time.Time
+	// fetching 非空时表示正在拉取 JWKS，拉取结束后关闭
+	fetching chan struct{}
+}
+
+func newOIDCVerifier(cfg *OIDCConfig) *oidcVerifier {
+	return &oidcVerifier{
+		cfg:     cfg,
+		client:  &http.Client{Timeout: oidcHTTPTimeout},
+		jwksURL: cfg.JWKSURL,
+	}
+}
+
+// verify 校验 ID Token 的签名、issuer、audience 以及有效期，返回其中的 claims
+func (v *oidcVerifier) verify(rawToken string, now time.Time) (map[string]interface{}, error) {
+	parts := strings.Split(rawToken, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("%w: malformed token", ErrorInvalidIDToken)
+	}
+	header := struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}{}
+	if err := decodeJWTSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("%w: header: %s", ErrorInvalidIDToken, err.Error())
+	}
+	if header.Alg != "RS256" {
+		return nil, fmt.Errorf("%w: unsupported alg %s", ErrorInvalidIDToken, header.Alg)
+	}
+	key, err := v.key(header.Kid, now)
+	if err != nil {
+		return nil, err
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("%w: signature: %s", ErrorInvalidIDToken, err.Error())
+	}
+	hashed := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, hashed[:], signature); err != nil {
+		return nil, fmt.Errorf("%w: signature mismatch", ErrorInvalidIDToken)
+	}
+
+	claims := map[string]interface{}{}
+	if err := decodeJWTSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("%w: claims: %s", ErrorInvalidIDToken, err.Error())
+	}
+	if iss, _ := claims["iss"].(string); iss != v.cfg.Issuer {
+		return nil, fmt.Errorf("%w: unexpected issuer %s", ErrorInvalidIDToken, iss)
+	}
+	if !containsAudience(claims["aud"], v.cfg.ClientID) {
+		return nil, fmt.Errorf("%w: unexpected audience", ErrorInvalidIDToken)
+	}
+	skew := v.cfg.clockSkew()
+	exp, ok := claims["exp"].(float64)
+	if !ok || now.Add(-skew).After(time.Unix(int64(exp), 0)) {
+		return nil, fmt.Errorf("%w: token expired", ErrorInvalidIDToken)
+	}
+	if nbf, ok := claims["nbf"].(float64); ok && now.Add(skew).Before(time.Unix(int64(nbf), 0)) {
+		return nil, fmt.Errorf("%w: token not valid yet", ErrorInvalidIDToken)
+	}
+	return claims, nil
+}
+
+// key 获取签名公钥，遇到未知的 kid 时按照刷新间隔重新拉取 JWKS
+// 拉取在锁外进行，同一时间只有一个拉取，拉取期间已知的 kid 继续使用缓存的公钥，未知的 kid 等待本次拉取的结果
+func (v *oidcVerifier) key(kid string, now time.Time) (*rsa.PublicKey, error) {
+	v.lock.Lock()
+	if key, ok := v.lookupKey(kid); ok {
+		v.lock.Unlock()
+		return key, nil
+	}
+	if fetching := v.fetching; fetching != nil {
+		v.lock.Unlock()
+		<-fetching
+		return v.cachedKey(kid)
+	}
+	if !v.lastAttempt.IsZero() && now.Sub(v.lastAttempt) < v.cfg.keyRefresh() {
+		v.lock.Unlock()
+		return nil, fmt.Errorf("%w: unknown key %s", ErrorInvalidIDToken, kid)
+	}
+	fetching := make(chan struct{})
+	v.fetching = fetching
+	v.lastAttempt = now
+	jwksURL := v.jwksURL
+	v.lock.Unlock()
+
+	keys, jwksURL, err := v.fetchKeys(jwksURL)
+
+	v.lock.Lock()
+	if err == nil {
+		v.keys = keys
+		v.jwksURL = jwksURL
+	}
+	v.fetching = nil
+	close(fetching)
+	v.lock.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	return v.cachedKey(kid)
+}
+
+// cachedKey 从已经拉取的 JWKS 中获取签名公钥
+func (v *oidcVerifier) cachedKey(kid string) (*rsa.PublicKey, error) {
+	v.lock.Lock()
+	defer v.lock.Unlock()
+	if key, ok := v.lookupKey(kid); ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("%w: unknown key %s", ErrorInvalidIDToken, kid)
+}
+
+// lookupKey 没有携带 kid 时，只有 JWKS 中仅有一个公钥才能确定签名公钥, 调用方需要持有锁
+func (v *oidcVerifier) lookupKey(kid string) (*rsa.PublicKey, bool) {
+	if kid == "" && len(v.keys) == 1 {
+		for _, key := range v.keys {
+			return key, true
+		}
+	}
+	key, ok := v.keys[kid]
+	return key, ok
+}
+
+// fetchKeys 拉取 JWKS 中的 RSA 签名公钥, jwksURL 为空时先通过 discovery 获取，返回实际使用的 JWKS 地址
+func (v *oidcVerifier) fetchKeys(jwksURL string) (map[string]*rsa.PublicKey, string, error) {
+	if jwksURL == "" {
+		discovery := struct {
+			JWKSURI string `json:"jwks_uri"`
+		}{}
+		url := strings.TrimSuffix(v.cfg.Issuer, "/") + "/.well-known/openid-configuration"
+		if err := v.getJSON(url, &discovery); err != nil {
+			return nil, "", err
+		}
+		if discovery.JWKSURI == "" {
+			return nil, "", fmt.Errorf("oidc discovery %s missing jwks_uri", url)
+		}
+		jwksURL = discovery.JWKSURI
+	}
+	jwks := struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			Use string `json:"use"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}{}
+	if err := v.getJSON(jwksURL, &jwks); err != nil {
+		return nil, "", err
+	}
+	keys := make(map[string]*rsa.PublicKey, len(jwks.Keys))
+	for _, item := range jwks.Keys {
+		if item.Kty != "RSA" || (item.Use != "" && item.Use != "sig") {
+			continue
+		}
+		n, err := base64.RawURLEncoding.DecodeString(item.N)
+		if err != nil {
+			continue
+		}
+		e, err := base64.RawURLEncoding.DecodeString(item.E)
+		if err != nil || len(e) == 0 {
+			continue
+		}
+		keys[item.Kid] = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+	}
+	return keys, jwksURL, nil
+}
+
+func (v *oidcVerifier) getJSON(url string, out interface{}) error {
+	resp, err := v.client.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("get %s: unexpected status %d", url, resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+func decodeJWTSegment(segment string, out interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, out)
+}
+
+// containsAudience aud 可以是单个字符串或者字符串数组
+func containsAudience(aud interface{}, clientID string) bool {
+	switch val := aud.(type) {
+	case string:
+		return val == clientID
+	case []interface{}:
+		for i := range val {
+			if s, _ := val[i].(string); s == clientID {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// claimStrings 读取字符串或者字符串数组类型的 claim
+func claimStrings(claims map[string]interface{}, name string) []string {
+	switch val := claims[name].(type) {
+	case string:
+		return []string{val}
+	case []interface{}:
+		ret := make([]string, 0, len(val))
+		for i := range val {
+			if s, ok := val[i].(string); ok && s != "" {
+				ret = append(ret, s)
+			}
+		}
+		return ret
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package defaultuser
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_oidcVerifierKey(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+
+	var (
+		fetches int32
+		healthy int32 = 1
+		block         = make(chan struct{})
+		blocked int32
+	)
+	idp := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&fetches, 1)
+		if atomic.LoadInt32(&blocked) == 1 {
+			<-block
+		}
+		if atomic.LoadInt32(&healthy) == 0 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{{
+				"kty": "RSA",
+				"kid": "known",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	}))
+	defer idp.Close()
+
+	t.Run("拉取失败同样受到刷新间隔的限制", func(t *testing.T) {
+		atomic.StoreInt32(&healthy, 0)
+		atomic.StoreInt32(&fetches, 0)
+		v := newOIDCVerifier(&OIDCConfig{JWKSURL: idp.URL, KeyRefreshInSecs: 60})
+		now := time.Now()
+		_, err := v.key("known", now)
+		assert.Error(t, err)
+		for i := 0; i < 5; i++ {
+			_, err = v.key("attacker-chosen", now.Add(time.Second))
+			assert.True(t, errors.Is(err, ErrorInvalidIDToken))
+		}
+		assert.Equal(t, int32(1), atomic.LoadInt32(&fetches))
+
+		// 超过刷新间隔后重新拉取
+		atomic.StoreInt32(&healthy, 1)
+		got, err := v.key("known", now.Add(time.Minute))
+		assert.NoError(t, err)
+		assert.Equal(t, key.N, got.N)
+		assert.Equal(t, int32(2), atomic.LoadInt32(&fetches))
+	})
+
+	t.Run("拉取期间继续使用缓存的公钥", func(t *testing.T) {
+		atomic.StoreInt32(&healthy, 1)
+		v := newOIDCVerifier(&OIDCConfig{JWKSURL: idp.URL, KeyRefreshInSecs: 60})
+		now := time.Now()
+		_, err := v.key("known", now)
+		assert.NoError(t, err)
+
+		atomic.StoreInt32(&fetches, 0)
+		atomic.StoreInt32(&blocked, 1)
+		done := make(chan error, 2)
+		go func() {
+			_, err := v.key("rotated", now.Add(time.Minute))
+			done <- err
+		}()
+		assert.Eventually(t, func() bool { return atomic.LoadInt32(&fetches) == 1 }, time.Second, 10*time.Millisecond)
+		go func() {
+			_, err := v.key("other", now.Add(time.Minute))
+			done <- err
+		}()
+
+		// 已知的 kid 不等待正在进行的拉取
+		start := time.Now()
+		_, err = v.key("known", now.Add(time.Minute))
+		assert.NoError(t, err)
+		assert.Less(t, time.Since(start), 100*time.Millisecond)
+
+		close(block)
+		for i := 0; i < 2; i++ {
+			assert.True(t, errors.Is(<-done, ErrorInvalidIDToken))
+		}
+		// 等待中的请求共享同一次拉取
+		assert.Equal(t, int32(1), atomic.LoadInt32(&fetches))
+	})
+}
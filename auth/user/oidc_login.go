@@ -0,0 +1,134 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package defaultuser
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	apimodel "github.com/polarismesh/specification/source/go/api/v1/model"
+	apiservice "github.com/polarismesh/specification/source/go/api/v1/service_manage"
+	"go.uber.org/zap"
+
+	api "github.com/polarismesh/polaris/common/api/v1"
+	"github.com/polarismesh/polaris/common/model"
+	commonstore "github.com/polarismesh/polaris/common/store"
+	"github.com/polarismesh/polaris/common/utils"
+)
+
+// LoginOIDC 使用身份提供方签发的 ID Token 登录
+// 首次登录时在配置的主账户下创建子账户，每次登录都会按照 ID Token 中的用户组刷新用户所在的用户组
+func (svr *Server) LoginOIDC(ctx context.Context, idToken string) *apiservice.Response {
+	if svr.oidc == nil {
+		return api.NewAuthResponseWithMsg(apimodel.Code_NotAllowedAccess, ErrorOIDCDisabled.Error())
+	}
+	claims, err := svr.oidc.verify(idToken, time.Now())
+	if err != nil {
+		log.Error("[Auth][User] verify oidc id token", utils.RequestID(ctx), zap.Error(err))
+		return api.NewAuthResponseWithMsg(apimodel.Code_NotAllowedAccess, err.Error())
+	}
+	cfg := svr.oidc.cfg
+	names := claimStrings(claims, cfg.usernameClaim())
+	if len(names) != 1 || CheckName(utils.NewStringValue(names[0])) != nil {
+		log.Error("[Auth][User] invalid oidc username claim", utils.RequestID(ctx),
+			zap.String("claim", cfg.usernameClaim()), zap.Strings("value", names))
+		return api.NewAuthResponse(apimodel.Code_InvalidUserName)
+	}
+
+	owner := svr.cacheMgr.User().GetUserByName(cfg.Owner, cfg.Owner)
+	if owner == nil {
+		log.Error("[Auth][User] oidc owner not found", utils.RequestID(ctx), zap.String("owner", cfg.Owner))
+		return api.NewAuthResponse(apimodel.Code_NotFoundOwnerUser)
+	}
+	email, _ := claims["email"].(string)
+	user, errResp := svr.loadExternalUser(ctx, owner, names[0], defaultOIDCSource, email)
+	if errResp != nil {
+		return errResp
+	}
+	if err := svr.syncOIDCGroups(ctx, owner, user, claimStrings(claims, cfg.groupsClaim())); err != nil {
+		log.Error("[Auth][User] sync oidc user groups", utils.RequestID(ctx),
+			zap.String("user", user.Name), zap.Error(err))
+		return api.NewAuthResponseWithMsg(commonstore.StoreCode2APICode(err), err.Error())
+	}
+	return svr.loginResponse(user)
+}
+
+// syncOIDCGroups 以身份提供方为准刷新用户所在的用户组，加入 ID Token 中的用户组并退出其余由 OIDC 管理的用户组
+// 只有配置了 GroupPrefix 时，名称带有该前缀的用户组才由 OIDC 管理，手动加入的其他用户组不受影响
+// 当前所在的用户组以缓存为准，缓存尚未刷新时遗漏的变更会在下次登录时处理
+func (svr *Server) syncOIDCGroups(ctx context.Context, owner, user *model.User, names []string) error {
+	prefix := svr.oidc.cfg.GroupPrefix
+	userCache := svr.cacheMgr.User()
+	current := make(map[string]struct{})
+	for _, groupID := range userCache.GetUserDirectGroupIds(user.ID) {
+		current[groupID] = struct{}{}
+	}
+	expect := make(map[string]struct{}, len(names))
+	seen := make(map[string]struct{}, len(names))
+	for _, name := range names {
+		if _, ok := seen[name]; ok {
+			continue
+		}
+		seen[name] = struct{}{}
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		group, err := svr.storage.GetGroupByName(name, owner.ID)
+		if err != nil {
+			return err
+		}
+		if group == nil {
+			if !svr.oidc.cfg.AutoCreateGroups {
+				log.Debug("[Auth][User] oidc user group not found, ignore", utils.RequestID(ctx),
+					zap.String("group", name))
+				continue
+			}
+			if group, err = svr.createExternalGroup(ctx, owner, name, defaultOIDCSource,
+				[]string{user.ID}); err != nil {
+				return err
+			}
+			expect[group.ID] = struct{}{}
+			continue
+		}
+		expect[group.ID] = struct{}{}
+		if _, ok := current[group.ID]; ok {
+			continue
+		}
+		if err := svr.updateGroupMembers(ctx, group, []string{user.ID}, nil); err != nil {
+			return err
+		}
+	}
+
+	if prefix == "" {
+		return nil
+	}
+	for groupID := range current {
+		if _, ok := expect[groupID]; ok {
+			continue
+		}
+		group := userCache.GetGroup(groupID)
+		if group == nil || group.Owner != owner.ID || !strings.HasPrefix(group.Name, prefix) {
+			continue
+		}
+		if err := svr.updateGroupMembers(ctx, group.UserGroup, nil, []string{user.ID}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
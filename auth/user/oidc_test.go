@@ -0,0 +1,220 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package defaultuser_test
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	apimodel "github.com/polarismesh/specification/source/go/api/v1/model"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/polarismesh/polaris/auth"
+	defaultuser "github.com/polarismesh/polaris/auth/user"
+	"github.com/polarismesh/polaris/cache"
+	cachetypes "github.com/polarismesh/polaris/cache/api"
+	"github.com/polarismesh/polaris/common/model"
+	storemock "github.com/polarismesh/polaris/store/mock"
+)
+
+func signIDToken(t *testing.T, key *rsa.PrivateKey, kid string, claims map[string]interface{}) string {
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "kid": kid, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	signing := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	hashed := sha256.Sum256([]byte(signing))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, hashed[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signing + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func Test_LoginOIDC(t *testing.T) {
+	reset(false)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+
+	mux := http.NewServeMux()
+	idp := httptest.NewServer(mux)
+	defer idp.Close()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{"issuer": idp.URL, "jwks_uri": idp.URL + "/keys"})
+	})
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{{
+				"kty": "RSA",
+				"kid": "polaris-test",
+				"use": "sig",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	})
+
+	// users[2] 是此前通过 OIDC 登录创建的用户, 当前在 groups[2] 以及管理员手动加入的 manual 中
+	users := createMockUser(3)
+	users[2].Source = "OIDC"
+	groups := createMockUserGroup(users)
+	owner := users[0]
+	manual := createMockUserGroup(users[2:])[0]
+	manual.Name = "manual-group"
+	manual.Owner = owner.ID
+	groups = append(groups, manual)
+
+	storage := storemock.NewMockStore(ctrl)
+	storage.EXPECT().GetServicesCount().AnyTimes().Return(uint32(1), nil)
+	storage.EXPECT().GetUnixSecond(gomock.Any()).AnyTimes().Return(time.Now().Unix(), nil)
+	storage.EXPECT().GetUsersForCache(gomock.Any(), gomock.Any()).AnyTimes().Return(users, nil)
+	storage.EXPECT().GetGroupsForCache(gomock.Any(), gomock.Any()).AnyTimes().Return(groups, nil)
+	storage.EXPECT().GetUserSessionsForCache(gomock.Any(), gomock.Any()).AnyTimes().Return(nil, nil)
+	storage.EXPECT().GetGroupByName(groups[1].Name, owner.ID).AnyTimes().Return(groups[1].UserGroup, nil)
+	storage.EXPECT().GetGroupByName("not-exist", owner.ID).AnyTimes().Return(nil, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cacheMgn, err := cache.TestCacheInitialize(ctx, &cache.Config{}, storage)
+	assert.NoError(t, err)
+	_ = cacheMgn.OpenResourceCache(cachetypes.ConfigEntry{Name: cachetypes.UsersName})
+	defer cacheMgn.Close()
+
+	_, svr, err := defaultuser.BuildServer()
+	assert.NoError(t, err)
+	err = svr.Initialize(&auth.Config{
+		User: &auth.UserConfig{
+			Name: auth.DefaultUserMgnPluginName,
+			Option: map[string]interface{}{
+				"salt": "polarismesh@2021",
+				"oidc": map[string]interface{}{
+					"issuer":   idp.URL,
+					"clientId": "polaris-console",
+					"owner":    owner.Name,
+					// 只有 test-group- 前缀的用户组由 OIDC 管理
+					"groupPrefix": "test-group-",
+				},
+			},
+		},
+	}, storage, cacheMgn)
+	assert.NoError(t, err)
+	_ = cacheMgn.TestUpdate()
+
+	newClaims := func(name string, groupNames ...string) map[string]interface{} {
+		return map[string]interface{}{
+			"iss":                idp.URL,
+			"aud":                []string{"polaris-console", "other"},
+			"exp":                time.Now().Add(time.Hour).Unix(),
+			"preferred_username": name,
+			"email":              name + "@example.com",
+			"groups":             groupNames,
+		}
+	}
+
+	t.Run("首次登录创建用户并加入用户组", func(t *testing.T) {
+		var created *model.User
+		storage.EXPECT().GetUserByName("alice", owner.ID).Return(nil, nil)
+		storage.EXPECT().AddUser(gomock.Any()).DoAndReturn(func(user *model.User) error {
+			created = user
+			return nil
+		})
+		storage.EXPECT().UpdateGroup(gomock.Any()).DoAndReturn(func(group *model.ModifyUserGroup) error {
+			assert.Equal(t, groups[1].ID, group.ID)
+			assert.Equal(t, groups[1].Token, group.Token)
+			assert.Equal(t, []string{created.ID}, group.AddUserIds)
+			return nil
+		})
+
+		token := signIDToken(t, key, "polaris-test", newClaims("alice", groups[1].Name, "not-exist"))
+		resp := svr.LoginOIDC(context.Background(), token)
+		assert.Equal(t, uint32(apimodel.Code_ExecuteSuccess), resp.GetCode().GetValue(), resp.GetInfo().GetValue())
+		assert.Equal(t, "alice", resp.GetLoginResponse().GetName().GetValue())
+		assert.Equal(t, owner.ID, created.Owner)
+		assert.Equal(t, "OIDC", created.Source)
+		assert.Equal(t, "alice@example.com", created.Email)
+		assert.Equal(t, model.SubAccountUserRole, created.Type)
+		assert.Equal(t, created.Token, resp.GetLoginResponse().GetToken().GetValue())
+	})
+
+	t.Run("再次登录刷新用户组-手动加入的用户组不受影响", func(t *testing.T) {
+		storage.EXPECT().GetUserByName(users[2].Name, owner.ID).Return(users[2], nil)
+		storage.EXPECT().UpdateGroup(gomock.Any()).DoAndReturn(func(group *model.ModifyUserGroup) error {
+			assert.Equal(t, groups[1].ID, group.ID)
+			assert.Equal(t, []string{users[2].ID}, group.AddUserIds)
+			return nil
+		})
+		storage.EXPECT().UpdateGroup(gomock.Any()).DoAndReturn(func(group *model.ModifyUserGroup) error {
+			assert.Equal(t, groups[2].ID, group.ID)
+			assert.Equal(t, groups[2].Token, group.Token)
+			assert.Equal(t, groups[2].TokenEnable, group.TokenEnable)
+			assert.Equal(t, []string{users[2].ID}, group.RemoveUserIds)
+			return nil
+		})
+
+		token := signIDToken(t, key, "polaris-test", newClaims(users[2].Name, groups[1].Name))
+		resp := svr.LoginOIDC(context.Background(), token)
+		assert.Equal(t, uint32(apimodel.Code_ExecuteSuccess), resp.GetCode().GetValue(), resp.GetInfo().GetValue())
+		assert.Equal(t, users[2].ID, resp.GetLoginResponse().GetUserId().GetValue())
+	})
+
+	t.Run("同名的本地用户不允许通过OIDC登录", func(t *testing.T) {
+		storage.EXPECT().GetUserByName(users[1].Name, owner.ID).Return(users[1], nil)
+		token := signIDToken(t, key, "polaris-test", newClaims(users[1].Name))
+		resp := svr.LoginOIDC(context.Background(), token)
+		assert.Equal(t, uint32(apimodel.Code_UserExisted), resp.GetCode().GetValue())
+	})
+
+	t.Run("非法的ID Token", func(t *testing.T) {
+		storage.EXPECT().AddUser(gomock.Any()).Times(0)
+		storage.EXPECT().UpdateGroup(gomock.Any()).Times(0)
+
+		wrongAudience := newClaims("mallory")
+		wrongAudience["aud"] = "other"
+		expired := newClaims("mallory")
+		expired["exp"] = time.Now().Add(-time.Hour).Unix()
+		wrongIssuer := newClaims("mallory")
+		wrongIssuer["iss"] = "https://evil.example.com"
+
+		for _, token := range []string{
+			signIDToken(t, key, "polaris-test", wrongAudience),
+			signIDToken(t, key, "polaris-test", expired),
+			signIDToken(t, key, "polaris-test", wrongIssuer),
+			signIDToken(t, otherKey, "polaris-test", newClaims("mallory")),
+			signIDToken(t, otherKey, "unknown-key", newClaims("mallory")),
+			"not.a.token",
+		} {
+			resp := svr.LoginOIDC(context.Background(), token)
+			assert.Equal(t, uint32(apimodel.Code_NotAllowedAccess), resp.GetCode().GetValue(),
+				resp.GetInfo().GetValue())
+		}
+	})
+}
@@ -0,0 +1,188 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package defaultuser
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+	"unicode"
+
+	apimodel "github.com/polarismesh/specification/source/go/api/v1/model"
+	apisecurity "github.com/polarismesh/specification/source/go/api/v1/security"
+	apiservice "github.com/polarismesh/specification/source/go/api/v1/service_manage"
+	"go.uber.org/zap"
+
+	api "github.com/polarismesh/polaris/common/api/v1"
+	"github.com/polarismesh/polaris/common/model"
+	commonstore "github.com/polarismesh/polaris/common/store"
+	"github.com/polarismesh/polaris/common/utils"
+)
+
+const (
+	// defaultLockoutInSecs 未配置 lockoutInSecs 时账户被锁定的时长
+	defaultLockoutInSecs = 30 * 60
+)
+
+// PasswordPolicy defaultUser 插件的密码策略以及登录失败锁定配置
+type PasswordPolicy struct {
+	// MinLength 密码的最小长度，不能小于内置的 6 位
+	MinLength int `json:"minLength"`
+	// RequireUpper 密码必须包含大写字母
+	RequireUpper bool `json:"requireUpper"`
+	// RequireLower 密码必须包含小写字母
+	RequireLower bool `json:"requireLower"`
+	// RequireDigit 密码必须包含数字
+	RequireDigit bool `json:"requireDigit"`
+	// RequireSpecial 密码必须包含字母以及数字以外的字符
+	RequireSpecial bool `json:"requireSpecial"`
+	// ExpireInDays 密码的有效期，过期后无法登录，需要由管理员或者主账户重置密码，为 0 时永不过期
+	ExpireInDays int `json:"expireInDays"`
+	// MaxFailedLogins 连续登录失败达到该次数后锁定账户，为 0 时不锁定
+	MaxFailedLogins int `json:"maxFailedLogins"`
+	// LockoutInSecs 账户被锁定的时长，为 0 时默认 30 分钟
+	LockoutInSecs int64 `json:"lockoutInSecs"`
+}
+
+// Verify 检查密码策略是否合法
+func (p *PasswordPolicy) Verify() error {
+	if p.MinLength < 0 || p.MinLength > maxPasswordLength {
+		return fmt.Errorf("[Auth][Config] passwordPolicy.minLength must be 0 ~ %d", maxPasswordLength)
+	}
+	if p.ExpireInDays < 0 || p.MaxFailedLogins < 0 || p.LockoutInSecs < 0 {
+		return errors.New("[Auth][Config] passwordPolicy expireInDays, maxFailedLogins and lockoutInSecs " +
+			"can't be negative")
+	}
+	return nil
+}
+
+// check 检查密码是否满足复杂度要求，密码的基础长度限制由 CheckPassword 保证
+func (p *PasswordPolicy) check(password string) error {
+	if p == nil {
+		return nil
+	}
+	if len(password) < p.MinLength {
+		return fmt.Errorf("password len need at least %d", p.MinLength)
+	}
+	var upper, lower, digit, special bool
+	for _, c := range password {
+		switch {
+		case unicode.IsUpper(c):
+			upper = true
+		case unicode.IsLower(c):
+			lower = true
+		case unicode.IsDigit(c):
+			digit = true
+		default:
+			special = true
+		}
+	}
+	switch {
+	case p.RequireUpper && !upper:
+		return errors.New("password must contain upper case letters")
+	case p.RequireLower && !lower:
+		return errors.New("password must contain lower case letters")
+	case p.RequireDigit && !digit:
+		return errors.New("password must contain digits")
+	case p.RequireSpecial && !special:
+		return errors.New("password must contain special characters")
+	}
+	return nil
+}
+
+// isPasswordExpired 用户的密码在 now 时刻是否已经过期，未记录密码修改时间的历史用户不会过期
+func (p *PasswordPolicy) isPasswordExpired(user *model.User, now time.Time) bool {
+	if p == nil || p.ExpireInDays <= 0 || user.PasswordModifyTime.IsZero() {
+		return false
+	}
+	return !now.Before(user.PasswordModifyTime.AddDate(0, 0, p.ExpireInDays))
+}
+
+func (p *PasswordPolicy) lockoutDuration() time.Duration {
+	if p.LockoutInSecs <= 0 {
+		return defaultLockoutInSecs * time.Second
+	}
+	return time.Duration(p.LockoutInSecs) * time.Second
+}
+
+// checkPasswordPolicy 检查新密码是否满足配置的密码策略
+func (svr *Server) checkPasswordPolicy(password string) error {
+	return svr.authOpt.PasswordPolicy.check(password)
+}
+
+// recordLoginFailure 记录一次密码错误的登录，连续失败次数达到上限后锁定账户。失败次数在存储层原子累加，
+// 只有账户被锁定时才立即同步用户缓存，其余情况等待缓存的增量更新
+func (svr *Server) recordLoginFailure(user *model.User) {
+	policy := svr.authOpt.PasswordPolicy
+	if policy == nil || policy.MaxFailedLogins <= 0 {
+		return
+	}
+	lockedUntil := time.Now().Add(policy.lockoutDuration())
+	locked, err := svr.storage.IncrUserLoginFailures(user.ID, policy.MaxFailedLogins, lockedUntil)
+	if err != nil {
+		log.Error("[Auth][User] record user login failure", zap.String("name", user.Name), zap.Error(err))
+		return
+	}
+	if !locked {
+		return
+	}
+	log.Warn("[Auth][User] lock user due to too many failed logins", zap.String("name", user.Name),
+		zap.String("owner", user.Owner), zap.Time("locked-until", lockedUntil))
+	if err := svr.cacheMgr.User().ForceSync(); err != nil {
+		log.Warn("[Auth][User] sync user login state to cache", zap.String("name", user.Name), zap.Error(err))
+	}
+}
+
+// resetLoginFailures 登录成功后清空连续失败的次数
+func (svr *Server) resetLoginFailures(user *model.User) {
+	if user.LoginFailures == 0 && user.LockedUntil.IsZero() {
+		return
+	}
+	if err := svr.storage.ResetUserLoginFailures(user.ID); err != nil {
+		log.Error("[Auth][User] reset user login failures", zap.String("name", user.Name), zap.Error(err))
+	}
+}
+
+// UnlockUser 解除用户因为登录失败次数过多导致的锁定
+func (svr *Server) UnlockUser(ctx context.Context, req *apisecurity.User) *apiservice.Response {
+	user, err := svr.storage.GetUser(req.GetId().GetValue())
+	if err != nil {
+		log.Error("[Auth][User] get user", utils.RequestID(ctx), zap.String("user-id", req.GetId().GetValue()),
+			zap.Error(err))
+		return api.NewUserResponse(commonstore.StoreCode2APICode(err), req)
+	}
+	if user == nil {
+		return api.NewUserResponse(apimodel.Code_NotFoundUser, req)
+	}
+	if user.LoginFailures == 0 && user.LockedUntil.IsZero() {
+		return api.NewUserResponse(apimodel.Code_NoNeedUpdate, req)
+	}
+
+	if err := svr.storage.ResetUserLoginFailures(user.ID); err != nil {
+		log.Error("[Auth][User] unlock user", utils.RequestID(ctx), zap.String("user-id", user.ID), zap.Error(err))
+		return api.NewUserResponse(commonstore.StoreCode2APICode(err), req)
+	}
+	if err := svr.cacheMgr.User().ForceSync(); err != nil {
+		log.Warn("[Auth][User] sync user cache after unlock", utils.RequestID(ctx), zap.Error(err))
+	}
+
+	log.Info("[Auth][User] unlock user", utils.RequestID(ctx), zap.String("user-id", user.ID))
+	svr.RecordHistory(userRecordEntry(ctx, req, user, model.OUpdate))
+	return api.NewUserResponse(apimodel.Code_ExecuteSuccess, req)
+}
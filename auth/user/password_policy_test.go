@@ -0,0 +1,186 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package defaultuser_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	apisecurity "github.com/polarismesh/specification/source/go/api/v1/security"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/polarismesh/polaris/auth"
+	defaultuser "github.com/polarismesh/polaris/auth/user"
+	"github.com/polarismesh/polaris/cache"
+	cachetypes "github.com/polarismesh/polaris/cache/api"
+	api "github.com/polarismesh/polaris/common/api/v1"
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/common/utils"
+	storemock "github.com/polarismesh/polaris/store/mock"
+)
+
+func Test_PasswordPolicy(t *testing.T) {
+	reset(false)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	users := createMockUser(4)
+	owner := users[0]
+	// users[3] 的密码已经超过有效期
+	users[3].PasswordModifyTime = time.Now().AddDate(0, 0, -100)
+	saved := utils.NewSyncMap[string, *model.User]()
+	for i := range users {
+		saved.Store(users[i].ID, users[i])
+	}
+
+	storage := storemock.NewMockStore(ctrl)
+	storage.EXPECT().GetServicesCount().AnyTimes().Return(uint32(1), nil)
+	storage.EXPECT().GetUnixSecond(gomock.Any()).AnyTimes().Return(time.Now().Unix(), nil)
+	storage.EXPECT().GetUsersForCache(gomock.Any(), gomock.Any()).AnyTimes().DoAndReturn(
+		func(time.Time, bool) ([]*model.User, error) {
+			return saved.Values(), nil
+		})
+	storage.EXPECT().GetGroupsForCache(gomock.Any(), gomock.Any()).AnyTimes().Return(nil, nil)
+	storage.EXPECT().GetUserSessionsForCache(gomock.Any(), gomock.Any()).AnyTimes().Return(nil, nil)
+	storage.EXPECT().GetUser(gomock.Any()).AnyTimes().DoAndReturn(func(id string) (*model.User, error) {
+		user, _ := saved.Load(id)
+		copied := *user
+		return &copied, nil
+	})
+	storage.EXPECT().UpdateUser(gomock.Any()).AnyTimes().DoAndReturn(func(user *model.User) error {
+		copied := *user
+		saved.Store(user.ID, &copied)
+		return nil
+	})
+	storage.EXPECT().IncrUserLoginFailures(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes().DoAndReturn(
+		func(id string, maxFailures int, lockedUntil time.Time) (bool, error) {
+			user, _ := saved.Load(id)
+			copied := *user
+			copied.LoginFailures++
+			locked := copied.LoginFailures >= maxFailures
+			if locked {
+				copied.LoginFailures = 0
+				copied.LockedUntil = lockedUntil
+			}
+			saved.Store(id, &copied)
+			return locked, nil
+		})
+	storage.EXPECT().ResetUserLoginFailures(gomock.Any()).AnyTimes().DoAndReturn(func(id string) error {
+		user, _ := saved.Load(id)
+		copied := *user
+		copied.LoginFailures = 0
+		copied.LockedUntil = time.Time{}
+		saved.Store(id, &copied)
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cacheMgn, err := cache.TestCacheInitialize(ctx, &cache.Config{}, storage)
+	assert.NoError(t, err)
+	_ = cacheMgn.OpenResourceCache(cachetypes.ConfigEntry{Name: cachetypes.UsersName})
+	defer cacheMgn.Close()
+
+	_, svr, err := defaultuser.BuildServer()
+	assert.NoError(t, err)
+	err = svr.Initialize(&auth.Config{
+		User: &auth.UserConfig{
+			Name: auth.DefaultUserMgnPluginName,
+			Option: map[string]interface{}{
+				"salt": "polarismesh@2021",
+				"passwordPolicy": map[string]interface{}{
+					"minLength":       8,
+					"requireUpper":    true,
+					"requireDigit":    true,
+					"expireInDays":    90,
+					"maxFailedLogins": 2,
+				},
+			},
+		},
+	}, storage, cacheMgn)
+	assert.NoError(t, err)
+	_ = cacheMgn.TestUpdate()
+
+	login := func(user *model.User, password string) *apisecurity.LoginResponse {
+		resp := svr.Login(&apisecurity.LoginRequest{
+			Owner:    utils.NewStringValue(owner.Name),
+			Name:     utils.NewStringValue(user.Name),
+			Password: utils.NewStringValue(password),
+		})
+		if resp.GetCode().GetValue() != api.ExecuteSuccess {
+			t.Logf("login %s: %s", user.Name, resp.GetInfo().GetValue())
+			return nil
+		}
+		return resp.GetLoginResponse()
+	}
+	ownerCtx := context.WithValue(context.Background(), utils.ContextAuthTokenKey, owner.Token)
+
+	t.Run("新密码不满足复杂度要求", func(t *testing.T) {
+		for _, pwd := range []string{"Polar1s", "polaris123", "PolarisMesh"} {
+			resp := svr.UpdateUserPassword(ownerCtx, &apisecurity.ModifyUserPassword{
+				Id:          utils.NewStringValue(users[1].ID),
+				NewPassword: utils.NewStringValue(pwd),
+			})
+			assert.Equal(t, api.InvalidUserPassword, resp.GetCode().GetValue(), pwd)
+		}
+		resp := svr.UpdateUserPassword(ownerCtx, &apisecurity.ModifyUserPassword{
+			Id:          utils.NewStringValue(users[1].ID),
+			NewPassword: utils.NewStringValue("Polaris2021"),
+		})
+		assert.Equal(t, api.ExecuteSuccess, resp.GetCode().GetValue(), resp.GetInfo().GetValue())
+		_ = cacheMgn.TestUpdate()
+		assert.NotNil(t, login(users[1], "Polaris2021"))
+	})
+
+	t.Run("连续登录失败后锁定账户", func(t *testing.T) {
+		user := users[2]
+		assert.Nil(t, login(user, "wrong-password"))
+		assert.Nil(t, login(user, "wrong-password"))
+		// 账户已经被锁定，正确的密码同样无法登录
+		assert.Nil(t, login(user, "polaris"))
+		locked := cacheMgn.User().GetUserByID(user.ID)
+		assert.True(t, locked.IsLocked(time.Now()))
+		assert.WithinDuration(t, time.Now().Add(30*time.Minute), locked.LockedUntil, time.Minute)
+
+		resp := svr.UnlockUser(ownerCtx, &apisecurity.User{Id: utils.NewStringValue(user.ID)})
+		assert.Equal(t, api.ExecuteSuccess, resp.GetCode().GetValue(), resp.GetInfo().GetValue())
+		assert.NotNil(t, login(user, "polaris"))
+
+		resp = svr.UnlockUser(ownerCtx, &apisecurity.User{Id: utils.NewStringValue(user.ID)})
+		assert.Equal(t, api.NoNeedUpdate, resp.GetCode().GetValue(), resp.GetInfo().GetValue())
+	})
+
+	t.Run("子账户不能解除锁定", func(t *testing.T) {
+		userCtx := context.WithValue(context.Background(), utils.ContextAuthTokenKey, users[1].Token)
+		resp := svr.UnlockUser(userCtx, &apisecurity.User{Id: utils.NewStringValue(users[2].ID)})
+		assert.Equal(t, api.OperationRoleException, resp.GetCode().GetValue(), resp.GetInfo().GetValue())
+	})
+
+	t.Run("密码过期后无法登录", func(t *testing.T) {
+		assert.Nil(t, login(users[3], "polaris"))
+		resp := svr.UpdateUserPassword(ownerCtx, &apisecurity.ModifyUserPassword{
+			Id:          utils.NewStringValue(users[3].ID),
+			NewPassword: utils.NewStringValue("Polaris2021"),
+		})
+		assert.Equal(t, api.ExecuteSuccess, resp.GetCode().GetValue(), resp.GetInfo().GetValue())
+		_ = cacheMgn.TestUpdate()
+		assert.NotNil(t, login(users[3], "Polaris2021"))
+	})
+}
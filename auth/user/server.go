@@ -20,6 +20,9 @@ package defaultuser
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
+	"strings"
+	"time"
 
 	apimodel "github.com/polarismesh/specification/source/go/api/v1/model"
 	apisecurity "github.com/polarismesh/specification/source/go/api/v1/security"
@@ -29,8 +32,8 @@ import (
 	"github.com/polarismesh/polaris/auth"
 	cachetypes "github.com/polarismesh/polaris/cache/api"
 	api "github.com/polarismesh/polaris/common/api/v1"
+	"github.com/polarismesh/polaris/common/eventhub"
 	"github.com/polarismesh/polaris/common/model"
-	"github.com/polarismesh/polaris/common/utils"
 	"github.com/polarismesh/polaris/plugin"
 	"github.com/polarismesh/polaris/store"
 )
@@ -39,6 +42,20 @@ import (
 type AuthConfig struct {
 	// Salt 相关密码、token加密的salt
 	Salt string `json:"salt" xml:"salt"`
+	// HumanSources 用户来源(User.Source)属于这些来源时，视为人员身份
+	HumanSources []string `json:"humanSources"`
+	// ServiceSources 用户来源(User.Source)属于这些来源时，视为服务身份
+	ServiceSources []string `json:"serviceSources"`
+	// OIDC 通过外部身份提供方登录控制台，为空时不开启
+	OIDC *OIDCConfig `json:"oidc"`
+	// LDAP ldapUser 插件使用的 LDAP/AD 配置
+	LDAP *LDAPConfig `json:"ldap"`
+	// TokenTTLInSecs 用户 token 的有效期，过期后需要重新登录或者轮换 token，为 0 时永不过期
+	TokenTTLInSecs int64 `json:"tokenTTLInSecs"`
+	// SessionTTLInSecs 控制台登录会话的有效期，大于 0 时登录签发独立的会话 token，可以单独注销；为 0 时登录返回用户 token
+	SessionTTLInSecs int64 `json:"sessionTTLInSecs"`
+	// PasswordPolicy 密码复杂度、过期以及登录失败锁定的策略，为空时不开启
+	PasswordPolicy *PasswordPolicy `json:"passwordPolicy"`
 }
 
 // Verify 检查配置是否合法
@@ -50,6 +67,34 @@ func (cfg *AuthConfig) Verify() error {
 	default:
 		return errors.New("[Auth][Config] salt len must 16 | 24 | 32")
 	}
+	if cfg.TokenTTLInSecs < 0 {
+		return errors.New("[Auth][Config] tokenTTLInSecs can't be negative")
+	}
+	if cfg.SessionTTLInSecs < 0 {
+		return errors.New("[Auth][Config] sessionTTLInSecs can't be negative")
+	}
+	for _, human := range cfg.HumanSources {
+		for _, service := range cfg.ServiceSources {
+			if strings.EqualFold(human, service) {
+				return fmt.Errorf("[Auth][Config] source %s can't be both human and service", human)
+			}
+		}
+	}
+	if cfg.PasswordPolicy != nil {
+		if err := cfg.PasswordPolicy.Verify(); err != nil {
+			return err
+		}
+	}
+	if cfg.OIDC != nil {
+		if err := cfg.OIDC.Verify(); err != nil {
+			return err
+		}
+	}
+	if cfg.LDAP != nil {
+		if err := cfg.LDAP.Verify(); err != nil {
+			return err
+		}
+	}
 
 	return nil
 }
@@ -68,6 +113,7 @@ type Server struct {
 	history  plugin.History
 	cacheMgr cachetypes.CacheManager
 	helper   auth.UserHelper
+	oidc     *oidcVerifier
 }
 
 // Name of the user operator plugin
@@ -87,7 +133,11 @@ func (svr *Server) Initialize(authOpt *auth.Config, storage store.Store, cacheMg
 	})
 	// 获取History插件，注意：插件的配置在bootstrap已经设置好
 	svr.history = plugin.GetHistory()
-	if svr.history == nil {
+	if auth.IsHistoryAbsent(svr.history) {
+		if authOpt.StrictAudit {
+			log.Errorf("[Auth][Server] strict audit is enabled but no history plugin is available")
+			return auth.ErrorHistoryAbsent
+		}
 		log.Warnf("Not Found History Log Plugin")
 	}
 	svr.helper = &DefaultUserHelper{svr: svr}
@@ -131,6 +181,9 @@ func (svr *Server) parseOptions(options *auth.Config) error {
 		return err
 	}
 	svr.authOpt = cfg
+	if cfg.OIDC != nil {
+		svr.oidc = newOIDCVerifier(cfg.OIDC)
+	}
 	return nil
 }
 
@@ -145,35 +198,40 @@ func (svr *Server) Login(req *apisecurity.LoginRequest) *apiservice.Response {
 	if user == nil {
 		return api.NewAuthResponse(apimodel.Code_NotFoundUser)
 	}
+	now := time.Now()
+	if user.IsLocked(now) {
+		return api.NewAuthResponseWithMsg(apimodel.Code_NotAllowedAccess, model.ErrorUserLocked.Error())
+	}
 
 	err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.GetPassword().GetValue()))
 	if err != nil {
 		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			svr.recordLoginFailure(user)
 			return api.NewAuthResponseWithMsg(
 				apimodel.Code_NotAllowedAccess, model.ErrorWrongUsernameOrPassword.Error())
 		}
 		return api.NewAuthResponseWithMsg(apimodel.Code_ExecuteException, model.ErrorWrongUsernameOrPassword.Error())
 	}
+	svr.resetLoginFailures(user)
+	if svr.authOpt.PasswordPolicy.isPasswordExpired(user, now) {
+		return api.NewAuthResponseWithMsg(apimodel.Code_NotAllowedAccess, model.ErrorPasswordExpired.Error())
+	}
 
-	return api.NewLoginResponse(apimodel.Code_ExecuteSuccess, &apisecurity.LoginResponse{
-		UserId:  utils.NewStringValue(user.ID),
-		OwnerId: utils.NewStringValue(user.Owner),
-		Token:   utils.NewStringValue(user.Token),
-		Name:    utils.NewStringValue(user.Name),
-		Role:    utils.NewStringValue(model.UserRoleNames[user.Type]),
-	})
+	return svr.loginResponse(user)
 }
 
 // RecordHistory Server对外提供history插件的简单封装
 func (svr *Server) RecordHistory(entry *model.RecordEntry) {
-	// 如果插件没有初始化，那么不记录history
-	if svr.history == nil {
-		return
-	}
 	// 如果数据为空，则不需要打印了
 	if entry == nil {
 		return
 	}
+	// 通知订阅了鉴权操作记录的 webhook
+	_ = eventhub.Publish(eventhub.AuthRecordEventTopic, &eventhub.AuthRecordEvent{Entry: entry})
+	// 如果插件没有初始化，那么不记录history
+	if svr.history == nil {
+		return
+	}
 
 	// 调用插件记录history
 	svr.history.Record(entry)
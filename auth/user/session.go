@@ -0,0 +1,58 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package defaultuser
+
+import (
+	"context"
+	"errors"
+
+	"go.uber.org/zap"
+
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/common/utils"
+)
+
+// ListUserSessions 查询用户全部有效的控制台登录会话
+func (svr *Server) ListUserSessions(ctx context.Context, userID string) ([]*model.UserSession, error) {
+	if svr.cacheMgr.User().GetUserByID(userID) == nil {
+		return nil, model.ErrorNoUser
+	}
+	return svr.cacheMgr.User().ListUserSessions(userID), nil
+}
+
+// RevokeUserSessions 注销用户的控制台登录会话，注销后立即同步用户缓存，被注销的会话 token 随即无法通过鉴权
+func (svr *Server) RevokeUserSessions(ctx context.Context, userID string, sessionIDs []string) error {
+	if userID == "" {
+		return errors.New("user id is empty")
+	}
+	if svr.cacheMgr.User().GetUserByID(userID) == nil {
+		return model.ErrorNoUser
+	}
+	if err := svr.storage.RevokeUserSessions(userID, sessionIDs); err != nil {
+		log.Error("[Auth][User] revoke user sessions", utils.RequestID(ctx), zap.String("user", userID),
+			zap.Error(err))
+		return err
+	}
+	if err := svr.cacheMgr.User().ForceSync(); err != nil {
+		log.Error("[Auth][User] sync user cache after revoke sessions", utils.RequestID(ctx), zap.Error(err))
+		return err
+	}
+	log.Info("[Auth][User] revoke user sessions", utils.RequestID(ctx), zap.String("user", userID),
+		zap.Strings("sessions", sessionIDs))
+	return nil
+}
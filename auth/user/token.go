@@ -26,11 +26,18 @@ import (
 	"fmt"
 	"io"
 	"strings"
+	"time"
 
 	"github.com/google/uuid"
+	apimodel "github.com/polarismesh/specification/source/go/api/v1/model"
+	apiservice "github.com/polarismesh/specification/source/go/api/v1/service_manage"
+	"go.uber.org/zap"
 
 	"github.com/polarismesh/polaris/auth"
+	api "github.com/polarismesh/polaris/common/api/v1"
 	"github.com/polarismesh/polaris/common/model"
+	commonstore "github.com/polarismesh/polaris/common/store"
+	"github.com/polarismesh/polaris/common/utils"
 )
 
 // decodeToken 解析 token 信息，如果 t == ""，直接返回一个空对象
@@ -55,10 +62,14 @@ func (svr *Server) decodeToken(t string) (auth.OperatorInfo, error) {
 
 	tokenInfo := auth.OperatorInfo{
 		Origin:      t,
-		IsUserToken: detail[0] == model.TokenForUser,
+		IsUserToken: detail[0] == model.TokenForUser || detail[0] == model.TokenForSession,
 		OperatorID:  detail[1],
 		Role:        model.UnknownUserRole,
 	}
+	// 会话 token 的随机部分即为会话 ID
+	if detail[0] == model.TokenForSession {
+		tokenInfo.SessionID = tokenDetails[0]
+	}
 	return tokenInfo, nil
 }
 
@@ -76,8 +87,14 @@ func (svr *Server) checkToken(tokenInfo *auth.OperatorInfo) (string, bool, error
 			return "", false, model.ErrorNoUser
 		}
 
-		if tokenInfo.Origin != user.Token {
+		if tokenInfo.SessionID != "" {
+			if err := svr.checkSession(tokenInfo); err != nil {
+				return "", false, err
+			}
+		} else if tokenInfo.Origin != user.Token {
 			return "", false, model.ErrorTokenNotExist
+		} else {
+			tokenInfo.ExpireAt = user.TokenExpireAt
 		}
 
 		tokenInfo.Disable = !user.TokenEnable
@@ -100,6 +117,84 @@ func (svr *Server) checkToken(tokenInfo *auth.OperatorInfo) (string, bool, error
 	return group.Owner, false, nil
 }
 
+// checkSession 检查会话 token 对应的会话是否仍然有效，会话的过期时间即为 token 的过期时间
+// 缓存中不存在时回源存储，避免其他节点刚签发的会话在缓存同步之前无法使用
+func (svr *Server) checkSession(tokenInfo *auth.OperatorInfo) error {
+	session := svr.cacheMgr.User().GetUserSession(tokenInfo.SessionID)
+	if session == nil {
+		saved, err := svr.storage.GetUserSession(tokenInfo.SessionID)
+		if err != nil {
+			return err
+		}
+		session = saved
+	}
+	if session == nil || session.UserID != tokenInfo.OperatorID || !session.IsActive(time.Now()) {
+		return model.ErrorTokenNotExist
+	}
+	tokenInfo.ExpireAt = session.ExpireTime
+	return nil
+}
+
+// tokenExpireAt 新签发的用户 token 的过期时间，未配置 tokenTTLInSecs 时返回零值表示永不过期
+func (svr *Server) tokenExpireAt(now time.Time) time.Time {
+	if svr.authOpt.TokenTTLInSecs <= 0 {
+		return time.Time{}
+	}
+	return now.Add(time.Duration(svr.authOpt.TokenTTLInSecs) * time.Second)
+}
+
+// rotateUserToken 为用户签发新的 token 并重新计算过期时间，旧的 token 随即失效
+func (svr *Server) rotateUserToken(user *model.User) error {
+	newToken, err := createUserToken(user.ID, svr.authOpt.Salt)
+	if err != nil {
+		return err
+	}
+	user.Token = newToken
+	user.TokenExpireAt = svr.tokenExpireAt(time.Now())
+	return svr.storage.UpdateUser(user)
+}
+
+// loginResponse 登录成功的应答，用户的 token 已经过期时先轮换 token，避免返回一个无法使用的 token
+func (svr *Server) loginResponse(user *model.User) *apiservice.Response {
+	if svr.authOpt.SessionTTLInSecs > 0 {
+		return svr.sessionLoginResponse(user)
+	}
+	if !user.IsTokenExpired(time.Now()) {
+		return newLoginResponse(user)
+	}
+	// 缓存中的用户对象不能直接修改
+	renewed := *user
+	if err := svr.rotateUserToken(&renewed); err != nil {
+		log.Error("[Auth][User] rotate expired user token when login", zap.String("name", user.Name), zap.Error(err))
+		return api.NewAuthResponseWithMsg(commonstore.StoreCode2APICode(err), err.Error())
+	}
+	return newLoginResponse(&renewed)
+}
+
+// sessionLoginResponse 为本次登录创建会话，并返回会话 token 代替用户 token
+func (svr *Server) sessionLoginResponse(user *model.User) *apiservice.Response {
+	session := &model.UserSession{
+		ID:         utils.NewUUID(),
+		UserID:     user.ID,
+		ExpireTime: time.Now().Add(time.Duration(svr.authOpt.SessionTTLInSecs) * time.Second),
+	}
+	token, err := createSessionToken(session.ID, user.ID, svr.authOpt.Salt)
+	if err != nil {
+		log.Error("[Auth][User] create session token", zap.String("name", user.Name), zap.Error(err))
+		return api.NewAuthResponseWithMsg(apimodel.Code_ExecuteException, err.Error())
+	}
+	if err := svr.storage.AddUserSession(session); err != nil {
+		log.Error("[Auth][User] add user session", zap.String("name", user.Name), zap.Error(err))
+		return api.NewAuthResponseWithMsg(commonstore.StoreCode2APICode(err), err.Error())
+	}
+	if err := svr.cacheMgr.User().ForceSync(); err != nil {
+		log.Warn("[Auth][User] sync user session to cache", zap.String("name", user.Name), zap.Error(err))
+	}
+	loginUser := *user
+	loginUser.Token = token
+	return newLoginResponse(&loginUser)
+}
+
 const (
 	// TokenPattern token 的格式 随机字符串::[uid/xxx | groupid/xxx]
 	TokenPattern string = "%s::%s"
@@ -117,6 +212,12 @@ func createGroupToken(gid string, salt string) (string, error) {
 	return CreateToken("", gid, salt)
 }
 
+// createSessionToken 创建会话 token，格式为 {sessionid}::session/{uid}
+func createSessionToken(sessionID, uid string, salt string) (string, error) {
+	val := fmt.Sprintf("%s/%s", model.TokenForSession, uid)
+	return encryptMessage([]byte(salt), fmt.Sprintf(TokenPattern, sessionID, val))
+}
+
 // createToken Determine what type of Token created according to the incoming parameters
 func CreateToken(uid, gid string, salt string) (string, error) {
 	if uid == "" && gid == "" {
@@ -21,6 +21,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/gogo/protobuf/jsonpb"
@@ -79,6 +80,9 @@ func (svr *Server) CreateUser(ctx context.Context, req *apisecurity.User) *apise
 	if checkErrResp := checkCreateUser(req); checkErrResp != nil {
 		return checkErrResp
 	}
+	if err := svr.checkPasswordPolicy(req.GetPassword().GetValue()); err != nil {
+		return api.NewUserResponseWithMsg(apimodel.Code_InvalidUserPassword, err.Error(), req)
+	}
 
 	// 如果创建的目标账户类型是非子账户，则 ownerId 需要设置为 “”
 	if convertCreateUserRole(authcommon.ParseUserRole(ctx)) != model.SubAccountUserRole {
@@ -183,6 +187,12 @@ func (svr *Server) UpdateUserPassword(ctx context.Context, req *apisecurity.Modi
 		return api.NewAuthResponse(apimodel.Code_NotFoundUser)
 	}
 
+	if req.GetNewPassword().GetValue() != "" {
+		if err := svr.checkPasswordPolicy(req.GetNewPassword().GetValue()); err != nil {
+			return api.NewAuthResponseWithMsg(apimodel.Code_InvalidUserPassword, err.Error())
+		}
+	}
+
 	ignoreOrigin := authcommon.ParseUserRole(ctx) == model.AdminUserRole ||
 		authcommon.ParseUserRole(ctx) == model.OwnerUserRole
 	data, needUpdate, err := updateUserPasswordAttribute(ignoreOrigin, user, req)
@@ -392,15 +402,7 @@ func (svr *Server) ResetUserToken(ctx context.Context, req *apisecurity.User) *a
 		return api.NewUserResponse(apimodel.Code_NotFoundUser, req)
 	}
 
-	newToken, err := createUserToken(user.ID, svr.authOpt.Salt)
-	if err != nil {
-		log.Error("[Auth][User] update user token", utils.RequestID(ctx), zap.Error(err))
-		return api.NewUserResponse(apimodel.Code_ExecuteException, req)
-	}
-
-	user.Token = newToken
-
-	if err := svr.storage.UpdateUser(user); err != nil {
+	if err := svr.rotateUserToken(user); err != nil {
 		log.Error("[Auth][User] update user token into store", utils.RequestID(ctx), zap.Error(err))
 		return api.NewUserResponse(commonstore.StoreCode2APICode(err), req)
 	}
@@ -413,6 +415,31 @@ func (svr *Server) ResetUserToken(ctx context.Context, req *apisecurity.User) *a
 	return api.NewUserResponse(apimodel.Code_ExecuteSuccess, req)
 }
 
+// RefreshToken 轮换当前登录用户自身的 token，旧的 token 随即失效，新的 token 按照 tokenTTLInSecs 重新计算过期时间
+func (svr *Server) RefreshToken(ctx context.Context) *apiservice.Response {
+	userID := utils.ParseUserID(ctx)
+	user, err := svr.storage.GetUser(userID)
+	if err != nil {
+		log.Error("[Auth][User] get user from store", utils.RequestID(ctx), zap.Error(err))
+		return api.NewAuthResponse(commonstore.StoreCode2APICode(err))
+	}
+	if user == nil {
+		return api.NewAuthResponse(apimodel.Code_NotFoundUser)
+	}
+
+	if err := svr.rotateUserToken(user); err != nil {
+		log.Error("[Auth][User] update user token into store", utils.RequestID(ctx), zap.Error(err))
+		return api.NewAuthResponseWithMsg(commonstore.StoreCode2APICode(err), err.Error())
+	}
+
+	req := &apisecurity.User{Id: utils.NewStringValue(user.ID), Name: utils.NewStringValue(user.Name)}
+	log.Info("[Auth][User] refresh user token", utils.RequestID(ctx), zap.String("id", user.ID))
+	svr.RecordHistory(userRecordEntry(ctx, req, user, model.OUpdateToken))
+
+	req.AuthToken = utils.NewStringValue(user.Token)
+	return api.NewUserResponse(apimodel.Code_ExecuteSuccess, req)
+}
+
 // VerifyCredential 对 token 进行检查验证，并将 verify 过程中解析出的数据注入到 model.AcquireContext 中
 // step 1. 首先对 token 进行解析，获取相关的数据信息，注入到整个的 AcquireContext 中
 // step 2. 最后对 token 进行一些验证步骤的执行
@@ -478,7 +505,9 @@ func (svr *Server) parseOperatorInfo(operator auth.OperatorInfo, authCtx *model.
 		}
 	}
 
+	operator.Kind = svr.principalKind(operator)
 	authCtx.SetAttachment(model.OperatorRoleKey, operator.Role)
+	authCtx.SetAttachment(model.OperatorPrincipalKind, operator.Kind)
 	authCtx.SetAttachment(model.OperatorPrincipalType, func() model.PrincipalType {
 		if operator.IsUserToken {
 			return model.PrincipalUser
@@ -492,6 +521,28 @@ func (svr *Server) parseOperatorInfo(operator auth.OperatorInfo, authCtx *model.
 	authCtx.SetRequestContext(ctx)
 }
 
+// principalKind 根据用户来源解析操作者的身份类别，用户组以及未配置的来源均为 Unknown
+func (svr *Server) principalKind(operator auth.OperatorInfo) model.PrincipalKind {
+	if !operator.IsUserToken {
+		return model.PrincipalKindUnknown
+	}
+	user := svr.cacheMgr.User().GetUserByID(operator.OperatorID)
+	if user == nil {
+		return model.PrincipalKindUnknown
+	}
+	for _, source := range svr.authOpt.ServiceSources {
+		if strings.EqualFold(source, user.Source) {
+			return model.PrincipalKindService
+		}
+	}
+	for _, source := range svr.authOpt.HumanSources {
+		if strings.EqualFold(source, user.Source) {
+			return model.PrincipalKindHuman
+		}
+	}
+	return model.PrincipalKindUnknown
+}
+
 func canDowngradeAnonymous(authCtx *model.AcquireContext, err error) bool {
 	if authCtx.GetModule() == model.AuthModule {
 		return false
@@ -553,6 +604,7 @@ func userRecordEntry(ctx context.Context, req *apisecurity.User, md *model.User,
 		ResourceName:  fmt.Sprintf("%s(%s)", md.Name, md.ID),
 		OperationType: operationType,
 		Operator:      utils.ParseOperator(ctx),
+		ChangeSetID:   utils.ParseChangeSetID(ctx),
 		Detail:        detail,
 		HappenTime:    time.Now(),
 	}
@@ -637,6 +689,7 @@ func updateUserPasswordAttribute(
 		}
 		needUpdate = true
 		user.Password = string(pwd)
+		user.PasswordModifyTime = time.Now()
 	}
 	return user, needUpdate, nil
 }
@@ -678,6 +731,8 @@ func (svr *Server) createUserModel(req *apisecurity.User, role model.UserRoleTyp
 	}
 
 	user.Token = newToken
+	user.TokenExpireAt = svr.tokenExpireAt(user.CreateTime)
+	user.PasswordModifyTime = user.CreateTime
 
 	return user, nil
 }
@@ -19,6 +19,7 @@ package defaultuser_test
 
 import (
 	"context"
+	"slices"
 	"testing"
 	"time"
 
@@ -84,6 +85,34 @@ func newUserTest(t *testing.T) *UserTest {
 
 	storage.EXPECT().GetUsersForCache(gomock.Any(), gomock.Any()).AnyTimes().Return(allUsers, nil)
 	storage.EXPECT().GetGroupsForCache(gomock.Any(), gomock.Any()).AnyTimes().Return(groups, nil)
+	sessions := utils.NewSyncMap[string, *model.UserSession]()
+	storage.EXPECT().GetUserSessionsForCache(gomock.Any(), gomock.Any()).AnyTimes().DoAndReturn(
+		func(time.Time, bool) ([]*model.UserSession, error) {
+			return sessions.Values(), nil
+		})
+	storage.EXPECT().AddUserSession(gomock.Any()).AnyTimes().DoAndReturn(func(session *model.UserSession) error {
+		session.Valid = true
+		session.ModifyTime = time.Now()
+		sessions.Store(session.ID, session)
+		return nil
+	})
+	storage.EXPECT().RevokeUserSessions(gomock.Any(), gomock.Any()).AnyTimes().DoAndReturn(
+		func(userID string, ids []string) error {
+			sessions.Range(func(id string, session *model.UserSession) {
+				if session.UserID != userID || (len(ids) != 0 && !slices.Contains(ids, id)) {
+					return
+				}
+				revoked := *session
+				revoked.Valid = false
+				revoked.ModifyTime = time.Now()
+				sessions.Store(id, &revoked)
+			})
+			return nil
+		})
+	storage.EXPECT().GetUserSession(gomock.Any()).AnyTimes().DoAndReturn(func(id string) (*model.UserSession, error) {
+		session, _ := sessions.Load(id)
+		return session, nil
+	})
 	storage.EXPECT().UpdateUser(gomock.Any()).AnyTimes().Return(nil)
 	storage.EXPECT().DeleteUser(gomock.Any()).AnyTimes().Return(nil)
 
@@ -824,6 +853,140 @@ func Test_server_RefreshUserToken(t *testing.T) {
 	})
 }
 
+func Test_server_RotateUserToken(t *testing.T) {
+
+	userTest := newUserTest(t)
+	defer userTest.Clean()
+
+	err := userTest.svr.Initialize(&auth.Config{
+		User: &auth.UserConfig{
+			Name: auth.DefaultUserMgnPluginName,
+			Option: map[string]interface{}{
+				"salt":           "polarismesh@2021",
+				"tokenTTLInSecs": 3600,
+			},
+		},
+	}, userTest.storage, userTest.cacheMgn)
+	assert.NoError(t, err)
+	_ = userTest.cacheMgn.TestUpdate()
+
+	t.Run("轮换自己的Token", func(t *testing.T) {
+		user := userTest.users[1]
+		oldToken := user.Token
+		reqCtx := context.WithValue(context.Background(), utils.ContextAuthTokenKey, oldToken)
+		userTest.storage.EXPECT().GetUser(gomock.Eq(user.ID)).Return(user, nil)
+
+		resp := userTest.svr.RefreshToken(reqCtx)
+		assert.Equal(t, api.ExecuteSuccess, resp.GetCode().GetValue(), resp.GetInfo().GetValue())
+		assert.NotEqual(t, oldToken, resp.GetUser().GetAuthToken().GetValue())
+		assert.Equal(t, user.Token, resp.GetUser().GetAuthToken().GetValue())
+		assert.WithinDuration(t, time.Now().Add(time.Hour), user.TokenExpireAt, time.Minute)
+	})
+
+	t.Run("已经过期的Token被拒绝", func(t *testing.T) {
+		user := userTest.users[2]
+		user.TokenExpireAt = time.Now().Add(-time.Minute)
+		reqCtx := context.WithValue(context.Background(), utils.ContextAuthTokenKey, user.Token)
+
+		resp := userTest.svr.RefreshToken(reqCtx)
+		assert.Equal(t, api.TokenExpired, resp.GetCode().GetValue(), resp.GetInfo().GetValue())
+		resp = userTest.svr.GetUserToken(reqCtx, &apisecurity.User{Id: utils.NewStringValue(user.ID)})
+		assert.Equal(t, api.TokenExpired, resp.GetCode().GetValue(), resp.GetInfo().GetValue())
+	})
+
+	t.Run("Token过期后重新登录签发新的Token", func(t *testing.T) {
+		user := userTest.users[3]
+		user.TokenExpireAt = time.Now().Add(-time.Minute)
+		oldToken := user.Token
+
+		resp := userTest.svr.Login(&apisecurity.LoginRequest{
+			Owner:    utils.NewStringValue(userTest.ownerOne.Name),
+			Name:     utils.NewStringValue(user.Name),
+			Password: utils.NewStringValue("polaris"),
+		})
+		assert.Equal(t, api.ExecuteSuccess, resp.GetCode().GetValue(), resp.GetInfo().GetValue())
+		assert.NotEqual(t, oldToken, resp.GetLoginResponse().GetToken().GetValue())
+		// 缓存中的用户不会被直接修改
+		assert.Equal(t, oldToken, user.Token)
+	})
+}
+
+func Test_server_UserSessions(t *testing.T) {
+
+	userTest := newUserTest(t)
+	defer userTest.Clean()
+
+	err := userTest.svr.Initialize(&auth.Config{
+		User: &auth.UserConfig{
+			Name: auth.DefaultUserMgnPluginName,
+			Option: map[string]interface{}{
+				"salt":             "polarismesh@2021",
+				"sessionTTLInSecs": 3600,
+			},
+		},
+	}, userTest.storage, userTest.cacheMgn)
+	assert.NoError(t, err)
+	_ = userTest.cacheMgn.TestUpdate()
+
+	user := userTest.users[4]
+	login := func() string {
+		resp := userTest.svr.Login(&apisecurity.LoginRequest{
+			Owner:    utils.NewStringValue(userTest.ownerOne.Name),
+			Name:     utils.NewStringValue(user.Name),
+			Password: utils.NewStringValue("polaris"),
+		})
+		assert.Equal(t, api.ExecuteSuccess, resp.GetCode().GetValue(), resp.GetInfo().GetValue())
+		return resp.GetLoginResponse().GetToken().GetValue()
+	}
+	getToken := func(token string) uint32 {
+		reqCtx := context.WithValue(context.Background(), utils.ContextAuthTokenKey, token)
+		return userTest.svr.GetUserToken(reqCtx, &apisecurity.User{Id: utils.NewStringValue(user.ID)}).
+			GetCode().GetValue()
+	}
+
+	first, second := login(), login()
+	assert.NotEqual(t, user.Token, first)
+	assert.NotEqual(t, first, second)
+
+	t.Run("会话Token可以正常使用", func(t *testing.T) {
+		assert.Equal(t, api.ExecuteSuccess, getToken(first))
+		assert.Equal(t, api.ExecuteSuccess, getToken(second))
+
+		reqCtx := context.WithValue(context.Background(), utils.ContextAuthTokenKey, first)
+		sessions, err := userTest.svr.ListUserSessions(reqCtx, user.ID)
+		assert.NoError(t, err)
+		assert.Len(t, sessions, 2)
+	})
+
+	t.Run("不能查看其他主账户下用户的会话", func(t *testing.T) {
+		reqCtx := context.WithValue(context.Background(), utils.ContextAuthTokenKey, userTest.ownerTwo.Token)
+		_, err := userTest.svr.ListUserSessions(reqCtx, user.ID)
+		assert.Error(t, err)
+	})
+
+	t.Run("注销指定会话后立即失效", func(t *testing.T) {
+		reqCtx := context.WithValue(context.Background(), utils.ContextAuthTokenKey, user.Token)
+		sessions, err := userTest.svr.ListUserSessions(reqCtx, user.ID)
+		assert.NoError(t, err)
+		assert.NoError(t, userTest.svr.RevokeUserSessions(reqCtx, user.ID, []string{sessions[0].ID}))
+
+		sessions, err = userTest.svr.ListUserSessions(reqCtx, user.ID)
+		assert.NoError(t, err)
+		assert.Len(t, sessions, 1)
+		// 两个会话 token 中有且只有一个仍然可以使用
+		assert.NotEqual(t, getToken(first) == api.ExecuteSuccess, getToken(second) == api.ExecuteSuccess)
+	})
+
+	t.Run("主账户注销用户的全部会话", func(t *testing.T) {
+		reqCtx := context.WithValue(context.Background(), utils.ContextAuthTokenKey, userTest.ownerOne.Token)
+		assert.NoError(t, userTest.svr.RevokeUserSessions(reqCtx, user.ID, nil))
+		assert.Equal(t, api.AuthTokenVerifyException, getToken(first))
+		assert.Equal(t, api.AuthTokenVerifyException, getToken(second))
+		// 用户自身的 token 不受影响
+		assert.Equal(t, api.ExecuteSuccess, getToken(user.Token))
+	})
+}
+
 func Test_server_UpdateUserToken(t *testing.T) {
 	t.Run("主账户刷新自己的Token状态", func(t *testing.T) {
 		userTest := newUserTest(t)
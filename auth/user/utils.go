@@ -19,6 +19,7 @@ package defaultuser
 
 import (
 	"errors"
+	"fmt"
 	"regexp"
 	"unicode/utf8"
 
@@ -38,6 +39,13 @@ var (
 	ReadOp = false
 )
 
+const (
+	// minPasswordLength 密码的最小长度
+	minPasswordLength = 6
+	// maxPasswordLength 密码的最大长度
+	maxPasswordLength = 17
+)
+
 var (
 	regNameStr = regexp.MustCompile("^[\u4E00-\u9FA5A-Za-z0-9_\\-.]+$")
 	regEmail   = regexp.MustCompile(`^\w+([-+.]\w+)*@\w+([-.]\w+)*\.\w+([-.]\w+)*$`)
@@ -78,8 +86,8 @@ func CheckPassword(password *wrappers.StringValue) error {
 		return errors.New(utils.EmptyErrString)
 	}
 
-	if pLen := len(password.GetValue()); pLen < 6 || pLen > 17 {
-		return errors.New("password len need 6 ~ 17")
+	if pLen := len(password.GetValue()); pLen < minPasswordLength || pLen > maxPasswordLength {
+		return fmt.Errorf("password len need %d ~ %d", minPasswordLength, maxPasswordLength)
 	}
 
 	return nil
@@ -30,7 +30,10 @@ import (
 	"github.com/polarismesh/polaris/auth"
 	"github.com/polarismesh/polaris/cache"
 	"github.com/polarismesh/polaris/common/log"
+	"github.com/polarismesh/polaris/common/trace"
 	"github.com/polarismesh/polaris/config"
+	"github.com/polarismesh/polaris/federation"
+	"github.com/polarismesh/polaris/kubesync"
 	"github.com/polarismesh/polaris/namespace"
 	"github.com/polarismesh/polaris/plugin"
 	"github.com/polarismesh/polaris/service"
@@ -58,6 +61,11 @@ type Bootstrap struct {
 	Logger         map[string]*log.Options
 	StartInOrder   map[string]interface{} `yaml:"startInOrder"`
 	PolarisService PolarisService         `yaml:"polaris_service"`
+	Trace          trace.Config           `yaml:"trace"`
+	// Federation 跨集群同步服务以及实例的配置
+	Federation federation.Config `yaml:"federation"`
+	// KubernetesSync Kubernetes 服务同步的配置
+	KubernetesSync kubesync.Config `yaml:"kubernetes_sync"`
 }
 
 // PolarisService polaris-server的自注册配置
@@ -42,9 +42,13 @@ import (
 	"github.com/polarismesh/polaris/common/log"
 	"github.com/polarismesh/polaris/common/metrics"
 	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/common/readiness"
+	"github.com/polarismesh/polaris/common/trace"
 	"github.com/polarismesh/polaris/common/utils"
 	"github.com/polarismesh/polaris/common/version"
 	config_center "github.com/polarismesh/polaris/config"
+	"github.com/polarismesh/polaris/federation"
+	"github.com/polarismesh/polaris/kubesync"
 	"github.com/polarismesh/polaris/namespace"
 	"github.com/polarismesh/polaris/plugin"
 	"github.com/polarismesh/polaris/service"
@@ -84,6 +88,13 @@ func Start(configFilePath string) {
 		return
 	}
 
+	// 初始化链路追踪
+	if err = trace.Initialize(&cfg.Bootstrap.Trace); err != nil {
+		fmt.Printf("[ERROR] initialize trace fail: %v\n", err)
+		return
+	}
+	defer trace.Shutdown()
+
 	// 初始化
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -111,6 +122,10 @@ func Start(configFilePath string) {
 		fmt.Printf("[ERROR] get store fail: %v", err)
 		return
 	}
+	readiness.Register(readiness.ComponentStore, func() error {
+		_, err := s.GetUnixSecond(time.Second)
+		return err
+	})
 
 	// 开启进入启动流程，初始化插件，加载数据等
 	var tx store.Transaction
@@ -209,6 +224,18 @@ func StartComponents(ctx context.Context, cfg *boot_config.Config) error {
 		return err
 	}
 
+	// 初始化跨集群同步以及 Kubernetes 服务同步，同步数据的写入不经过鉴权
+	originNamingSvr, err := service.GetOriginServer()
+	if err != nil {
+		return err
+	}
+	if err := federation.Initialize(ctx, &cfg.Bootstrap.Federation, originNamingSvr, cacheMgn, s); err != nil {
+		return err
+	}
+	if err := kubesync.Initialize(ctx, &cfg.Bootstrap.KubernetesSync, originNamingSvr, cacheMgn, s); err != nil {
+		return err
+	}
+
 	// 初始化运维操作模块
 	if err := admin.Initialize(ctx, &cfg.Maintain, namingSvr, healthCheckServer, cacheMgn, s); err != nil {
 		return err
@@ -369,7 +396,9 @@ func StopServers(servers []apiserver.Apiserver) {
 	if nil != selfHeathChecker {
 		selfHeathChecker.Stop()
 	}
-	// sync stop servers
+	// 先反注册自身，已经建立长连接的客户端会收到北极星服务端实例的变更，切换到其他节点
+	SelfDeregister()
+	// sync stop servers, 各个 server 会排空已有的长连接后再退出
 	wg := &sync.WaitGroup{}
 	for _, s := range servers {
 		wg.Add(1)
@@ -381,8 +410,6 @@ func StopServers(servers []apiserver.Apiserver) {
 		}(s, wg)
 	}
 	wg.Wait()
-	// deregister instances
-	SelfDeregister()
 }
 
 // StartBootstrapInOrder 开始进入启动加锁
@@ -70,6 +70,8 @@ const (
 	ServiceContractName = "serviceContract"
 	// GrayName gray config name
 	GrayName = "gray"
+	// AuthRoleName auth role config name
+	AuthRoleName = "authRole"
 )
 
 type CacheIndex int
@@ -93,6 +95,7 @@ const (
 	CacheServiceContract
 	CacheGray
 	CacheLaneRule
+	CacheAuthRole
 
 	CacheLast
 )
@@ -145,6 +148,8 @@ type CacheManager interface {
 	User() UserCache
 	// AuthStrategy Get authentication cache information
 	AuthStrategy() StrategyCache
+	// AuthRole Get auth role cache information
+	AuthRole() RoleCache
 	// Namespace Get namespace cache information
 	Namespace() NamespaceCache
 	// Client Get client cache information
@@ -182,6 +187,8 @@ type (
 		Filter map[string]string
 		// Metadata 元数据条件
 		Metadata map[string]string
+		// LabelSelector 元数据标签选择器，优先通过标签索引查找候选服务
+		LabelSelector model.LabelSelector
 		// SvcIds 是否按照服务的ID进行等值查询
 		SvcIds map[string]struct{}
 		// WildName 是否进行名字的模糊匹配
@@ -233,10 +240,14 @@ type (
 		ListServiceAlias(namespace, name string) []*model.Service
 		// GetAliasFor get alias reference service info
 		GetAliasFor(name string, namespace string) *model.Service
+		// ResolveService get service by name, alias is resolved to the source service, which may be in other namespace
+		ResolveService(name string, namespace string) *model.Service
 		// GetRevisionWorker .
 		GetRevisionWorker() ServiceRevisionWorker
 		// GetVisibleServicesInOtherNamespace get same service in other namespace and it's visible
 		GetVisibleServicesInOtherNamespace(name string, namespace string) []*model.Service
+		// Version 服务集合的版本，任意服务发生变化都会单调递增
+		Version() uint64
 	}
 
 	// ServiceRevisionWorker
@@ -525,12 +536,24 @@ type (
 		GetUserByName(name, ownerName string) *model.User
 		// GetUserGroup
 		GetGroup(id string) *model.UserGroupDetail
-		// IsUserInGroup 判断 userid 是否在对应的 group 中
+		// IsUserInGroup 判断 userid 是否在对应的 group 中，所在用户组的祖先用户组同样视为所在的用户组
 		IsUserInGroup(userId, groupId string) bool
 		// IsOwner
 		IsOwner(id string) bool
-		// GetUserLinkGroupIds
+		// GetUserLinkGroupIds 查询用户所在的全部用户组ID，包含直接加入的用户组以及这些用户组的祖先用户组
 		GetUserLinkGroupIds(id string) []string
+		// GetUserDirectGroupIds 查询用户直接加入的用户组ID
+		GetUserDirectGroupIds(id string) []string
+		// GetGroupAncestorIds 查询用户组的全部祖先用户组ID，由近及远排列
+		GetGroupAncestorIds(id string) []string
+		// GetUserSession 根据会话ID获取有效的会话
+		GetUserSession(id string) *model.UserSession
+		// ListUserSessions 查询用户全部有效的会话
+		ListUserSessions(userId string) []*model.UserSession
+		// ForceSync 强制同步用户信息到cache (串行)
+		ForceSync() error
+		// Version 用户组集合的版本，任意用户组的成员以及父用户组发生变化都会单调递增
+		Version() uint64
 	}
 
 	// StrategyCache is a cache for strategy rules.
@@ -540,12 +563,34 @@ type (
 		GetStrategyDetailsByUID(uid string) []*model.StrategyDetail
 		// GetStrategyDetailsByGroupID returns all strategy details of a group.
 		GetStrategyDetailsByGroupID(groupId string) []*model.StrategyDetail
+		// GetStrategy 根据 ID 获取鉴权策略
+		GetStrategy(id string) *model.StrategyDetail
 		// IsResourceLinkStrategy 该资源是否关联了鉴权策略
 		IsResourceLinkStrategy(resType apisecurity.ResourceType, resId string) bool
+		// GetLabelSelectorStrategies 获取通过命名空间以及标签选择器关联了该类型资源的鉴权策略
+		GetLabelSelectorStrategies(resType apisecurity.ResourceType) []*model.StrategyDetail
 		// IsResourceEditable 判断该资源是否可以操作
 		IsResourceEditable(principal model.Principal, resType apisecurity.ResourceType, resId string) bool
 		// ForceSync 强制同步鉴权策略到cache (串行)
 		ForceSync() error
+		// Version 鉴权策略集合的版本，任意策略发生变化都会单调递增
+		Version() uint64
+		// IteratorStrategies 遍历缓存中的鉴权策略，iterProc 返回 false 时停止遍历
+		IteratorStrategies(iterProc StrategyIterProc)
+	}
+
+	// StrategyIterProc strategy iter proc func
+	StrategyIterProc func(rule *model.StrategyDetail) bool
+
+	// RoleCache 角色的 Cache 接口
+	RoleCache interface {
+		Cache
+		// GetRole 根据 ID 获取角色
+		GetRole(id string) *model.Role
+		// GetPrincipalRoles 获取直接授予 principal 的角色，不包含用户通过所属用户组获得的角色
+		GetPrincipalRoles(principal model.Principal) []*model.Role
+		// Version 角色集合的版本，任意角色发生变化都会单调递增
+		Version() uint64
 	}
 )
 
@@ -24,4 +24,5 @@ import (
 var (
 	_ types.UserCache     = (*userCache)(nil)
 	_ types.StrategyCache = (*strategyCache)(nil)
+	_ types.RoleCache     = (*roleCache)(nil)
 )
@@ -0,0 +1,157 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+package auth
+
+import (
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+	"golang.org/x/sync/singleflight"
+
+	types "github.com/polarismesh/polaris/cache/api"
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/common/utils"
+	"github.com/polarismesh/polaris/store"
+)
+
+// roleCache 角色缓存，按照 principal 建立角色索引
+type roleCache struct {
+	*types.BaseCache
+
+	storage store.Store
+	roles   *utils.SyncMap[string, *model.Role]
+	// principal2Role principal 到角色 ID 的索引
+	principal2Role *utils.SyncMap[string, *utils.SyncSet[string]]
+	singleFlight   *singleflight.Group
+	// version 角色集合的版本，先完成缓存数据的变更再递增
+	version uint64
+}
+
+// NewRoleCache 创建角色缓存
+func NewRoleCache(storage store.Store, cacheMgr types.CacheManager) types.RoleCache {
+	return &roleCache{
+		BaseCache: types.NewBaseCache(storage, cacheMgr),
+		storage:   storage,
+	}
+}
+
+func (rc *roleCache) Initialize(c map[string]interface{}) error {
+	rc.roles = utils.NewSyncMap[string, *model.Role]()
+	rc.principal2Role = utils.NewSyncMap[string, *utils.SyncSet[string]]()
+	rc.singleFlight = new(singleflight.Group)
+	return nil
+}
+
+func (rc *roleCache) Update() error {
+	// 多个线程竞争，只有一个线程进行更新
+	_, err, _ := rc.singleFlight.Do(rc.Name(), func() (interface{}, error) {
+		return nil, rc.DoCacheUpdate(rc.Name(), rc.realUpdate)
+	})
+	return err
+}
+
+func (rc *roleCache) realUpdate() (map[string]time.Time, int64, error) {
+	roles, err := rc.storage.GetRolesForCache(rc.LastFetchTime(), rc.IsFirstUpdate())
+	if err != nil {
+		log.Error("[Cache][AuthRole] refresh auth role cache", zap.Error(err))
+		return nil, -1, err
+	}
+	return rc.setRoles(roles), int64(len(roles)), nil
+}
+
+func (rc *roleCache) setRoles(roles []*model.Role) map[string]time.Time {
+	lastMtime := rc.LastMtime(rc.Name())
+	for i := range roles {
+		role := roles[i]
+		if old, ok := rc.roles.Load(role.ID); ok {
+			for j := range old.Principals {
+				rc.unlinkPrincipal(old.Principals[j], role.ID)
+			}
+		}
+		if !role.Valid {
+			rc.roles.Delete(role.ID)
+		} else {
+			rc.roles.Store(role.ID, role)
+			for j := range role.Principals {
+				rc.linkPrincipal(role.Principals[j], role.ID)
+			}
+		}
+		if role.ModifyTime.After(lastMtime) {
+			lastMtime = role.ModifyTime
+		}
+	}
+	if len(roles) > 0 {
+		atomic.AddUint64(&rc.version, 1)
+	}
+	return map[string]time.Time{rc.Name(): lastMtime}
+}
+
+func (rc *roleCache) linkPrincipal(principal model.Principal, roleID string) {
+	sets, _ := rc.principal2Role.ComputeIfAbsent(principalKey(principal), func(k string) *utils.SyncSet[string] {
+		return utils.NewSyncSet[string]()
+	})
+	sets.Add(roleID)
+}
+
+func (rc *roleCache) unlinkPrincipal(principal model.Principal, roleID string) {
+	if sets, ok := rc.principal2Role.Load(principalKey(principal)); ok {
+		sets.Remove(roleID)
+	}
+}
+
+func (rc *roleCache) Clear() error {
+	rc.BaseCache.Clear()
+	rc.roles = utils.NewSyncMap[string, *model.Role]()
+	rc.principal2Role = utils.NewSyncMap[string, *utils.SyncSet[string]]()
+	atomic.AddUint64(&rc.version, 1)
+	return nil
+}
+
+func (rc *roleCache) Name() string {
+	return types.AuthRoleName
+}
+
+// GetRole 根据 ID 获取角色
+func (rc *roleCache) GetRole(id string) *model.Role {
+	role, _ := rc.roles.Load(id)
+	return role
+}
+
+// GetPrincipalRoles 获取直接授予 principal 的角色
+func (rc *roleCache) GetPrincipalRoles(principal model.Principal) []*model.Role {
+	sets, ok := rc.principal2Role.Load(principalKey(principal))
+	if !ok {
+		return nil
+	}
+	ret := make([]*model.Role, 0, sets.Len())
+	sets.Range(func(roleID string) {
+		if role, ok := rc.roles.Load(roleID); ok {
+			ret = append(ret, role)
+		}
+	})
+	return ret
+}
+
+// Version 角色集合的版本
+func (rc *roleCache) Version() uint64 {
+	return atomic.LoadUint64(&rc.version)
+}
+
+func principalKey(principal model.Principal) string {
+	return principal.PrincipalRole.String() + "/" + principal.PrincipalID
+}
@@ -0,0 +1,69 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	cachemock "github.com/polarismesh/polaris/cache/mock"
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/store/mock"
+)
+
+func Test_roleCache_setRoles(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	t.Cleanup(ctrl.Finish)
+
+	roleCache := NewRoleCache(mock.NewMockStore(ctrl), cachemock.NewMockCacheManager(ctrl)).(*roleCache)
+	_ = roleCache.Initialize(map[string]interface{}{})
+
+	user := model.Principal{PrincipalID: "user-1", PrincipalRole: model.PrincipalUser}
+	group := model.Principal{PrincipalID: "user-1", PrincipalRole: model.PrincipalGroup}
+	role := &model.Role{
+		ID:         "role-1",
+		Strategies: []string{"strategy-1"},
+		Principals: []model.Principal{user},
+		Valid:      true,
+		ModifyTime: time.Now(),
+	}
+	roleCache.setRoles([]*model.Role{role})
+	version := roleCache.Version()
+	assert.Len(t, roleCache.GetPrincipalRoles(user), 1)
+	// 用户、用户组的 ID 相同时不会混淆
+	assert.Empty(t, roleCache.GetPrincipalRoles(group))
+
+	// 成员变更后需要移除原有的索引
+	role = &model.Role{
+		ID:         "role-1",
+		Strategies: []string{"strategy-1"},
+		Principals: []model.Principal{group},
+		Valid:      true,
+		ModifyTime: time.Now(),
+	}
+	roleCache.setRoles([]*model.Role{role})
+	assert.Greater(t, roleCache.Version(), version)
+	assert.Empty(t, roleCache.GetPrincipalRoles(user))
+	assert.Len(t, roleCache.GetPrincipalRoles(group), 1)
+
+	roleCache.setRoles([]*model.Role{{ID: "role-1", ModifyTime: time.Now()}})
+	assert.Nil(t, roleCache.GetRole("role-1"))
+	assert.Empty(t, roleCache.GetPrincipalRoles(group))
+}
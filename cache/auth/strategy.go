@@ -20,6 +20,8 @@ package auth
 import (
 	"fmt"
 	"math"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	apisecurity "github.com/polarismesh/specification/source/go/api/v1/security"
@@ -27,6 +29,8 @@ import (
 	"golang.org/x/sync/singleflight"
 
 	types "github.com/polarismesh/polaris/cache/api"
+	"github.com/polarismesh/polaris/common/eventhub"
+	"github.com/polarismesh/polaris/common/metrics"
 	"github.com/polarismesh/polaris/common/model"
 	"github.com/polarismesh/polaris/common/utils"
 	"github.com/polarismesh/polaris/store"
@@ -48,10 +52,20 @@ type strategyCache struct {
 	namespace2Strategy   *utils.SyncMap[string, *utils.SyncSet[string]]
 	service2Strategy     *utils.SyncMap[string, *utils.SyncSet[string]]
 	configGroup2Strategy *utils.SyncMap[string, *utils.SyncSet[string]]
+	// labelSelector2Strategy 按照命名空间以及标签选择器匹配资源的关联关系, key 为 {resType}_{resId}
+	labelSelector2Strategy *utils.SyncMap[string, *utils.SyncSet[string]]
 
 	lastMtime    int64
 	userCache    *userCache
 	singleFlight *singleflight.Group
+
+	// statsChanged 策略数据发生变化后才重新计算规模统计
+	statsChanged bool
+	stats        metrics.AuthStrategyStats
+	// version 策略集合的版本，先完成缓存数据的变更再递增
+	version uint64
+	// canonical 资源 ID 的规范化规则，建立资源索引以及查询时使用同一个规则
+	canonical *model.ResourceIDCanonicalizer
 }
 
 // NewStrategyCache
@@ -70,14 +84,18 @@ func (sc *strategyCache) Initialize(c map[string]interface{}) error {
 	sc.namespace2Strategy = utils.NewSyncMap[string, *utils.SyncSet[string]]()
 	sc.service2Strategy = utils.NewSyncMap[string, *utils.SyncSet[string]]()
 	sc.configGroup2Strategy = utils.NewSyncMap[string, *utils.SyncSet[string]]()
+	sc.labelSelector2Strategy = utils.NewSyncMap[string, *utils.SyncSet[string]]()
 	sc.singleFlight = new(singleflight.Group)
 	sc.lastMtime = 0
+	sc.statsChanged = true
+	sc.canonical, _ = c["resourceIdCanonical"].(*model.ResourceIDCanonicalizer)
 	return nil
 }
 
 func (sc *strategyCache) Update() error {
 	// 多个线程竞争，只有一个线程进行更新
 	_, err, _ := sc.singleFlight.Do(sc.Name(), func() (interface{}, error) {
+		defer sc.reportMetricsInfo()
 		return nil, sc.DoCacheUpdate(sc.Name(), sc.realUpdate)
 	})
 	return err
@@ -132,16 +150,20 @@ func (sc *strategyCache) setStrategys(strategies []*model.StrategyDetail) (map[s
 			} else {
 				update++
 			}
-			sc.strategys.Store(rule.ID, buildEnchanceStrategyDetail(rule))
+			sc.strategys.Store(rule.ID, sc.buildEnchanceStrategyDetail(rule))
 		}
 
 		lastMtime = int64(math.Max(float64(lastMtime), float64(rule.ModifyTime.Unix())))
 	}
 
+	if len(strategies) > 0 {
+		sc.statsChanged = true
+		sc.publishStrategyChange(atomic.AddUint64(&sc.version, 1))
+	}
 	return map[string]time.Time{sc.Name(): time.Unix(lastMtime, 0)}, add, update, remove
 }
 
-func buildEnchanceStrategyDetail(strategy *model.StrategyDetail) *model.StrategyDetailCache {
+func (sc *strategyCache) buildEnchanceStrategyDetail(strategy *model.StrategyDetail) *model.StrategyDetailCache {
 	users := make(map[string]model.Principal, 0)
 	groups := make(map[string]model.Principal, 0)
 
@@ -154,13 +176,27 @@ func buildEnchanceStrategyDetail(strategy *model.StrategyDetail) *model.Strategy
 		}
 	}
 
+	expires := make(map[string]time.Time, 0)
+	for index := range strategy.Resources {
+		res := strategy.Resources[index]
+		if !res.ExpireTime.IsZero() {
+			expires[sc.resourceLinkKey(res.ResType, res.ResID)] = res.ExpireTime
+		}
+	}
+
 	return &model.StrategyDetailCache{
-		StrategyDetail: strategy,
-		UserPrincipal:  users,
-		GroupPrincipal: groups,
+		StrategyDetail:  strategy,
+		UserPrincipal:   users,
+		GroupPrincipal:  groups,
+		ExpireResources: expires,
 	}
 }
 
+// resourceLinkKey 资源关联关系的 key，资源 ID 按照规范化后的结果计算
+func (sc *strategyCache) resourceLinkKey(resType int32, resId string) string {
+	return fmt.Sprintf("%d_%s", resType, sc.canonical.Canonical(resId))
+}
+
 func (sc *strategyCache) writeSet(linkContainers *utils.SyncMap[string, *utils.SyncSet[string]], key, val string, isDel bool) {
 	if isDel {
 		values, ok := linkContainers.Load(key)
@@ -177,9 +213,15 @@ func (sc *strategyCache) writeSet(linkContainers *utils.SyncMap[string, *utils.S
 }
 
 // handlerResourceStrategy 处理资源视角下策略的缓存
-// 根据新老策略的资源列表比对，计算出哪些资源不在和该策略存在关联关系，哪些资源新增了相关的策略
+// 根据新老策略的资源列表比对，计算出哪些资源不在和该策略存在关联关系，哪些资源新增了相关的策略。
+// 所有策略新增的关联关系先于被移除的关联关系生效，资源在策略之间转移时不会出现没有任何策略授权的间隙
 func (sc *strategyCache) handlerResourceStrategy(strategies []*model.StrategyDetail) {
 	operateLink := func(resType int32, resId, strategyId string, remove bool) {
+		if (model.StrategyResource{ResID: resId}).IsLabelSelector() {
+			sc.writeSet(sc.labelSelector2Strategy, sc.resourceLinkKey(resType, resId), strategyId, remove)
+			return
+		}
+		resId = sc.canonical.Canonical(resId)
 		switch resType {
 		case int32(apisecurity.ResourceType_Namespaces):
 			sc.writeSet(sc.namespace2Strategy, resId, strategyId, remove)
@@ -190,6 +232,12 @@ func (sc *strategyCache) handlerResourceStrategy(strategies []*model.StrategyDet
 		}
 	}
 
+	type resourceLink struct {
+		resource   model.StrategyResource
+		strategyId string
+	}
+	delLinks := make([]resourceLink, 0, 8)
+
 	for sIndex := range strategies {
 		rule := strategies[sIndex]
 		addRes := rule.Resources
@@ -197,35 +245,41 @@ func (sc *strategyCache) handlerResourceStrategy(strategies []*model.StrategyDet
 		if oldRule, exist := sc.strategys.Load(rule.ID); exist {
 			delRes := make([]model.StrategyResource, 0, 8)
 			// 计算前后对比， resource 的变化
+			// 规范化后相同的资源 ID 视为同一个资源，避免移除其中一个写法时误删仍然存在的关联关系
 			newRes := make(map[string]struct{}, len(addRes))
 			for i := range addRes {
-				newRes[fmt.Sprintf("%d_%s", addRes[i].ResType, addRes[i].ResID)] = struct{}{}
+				newRes[sc.resourceLinkKey(addRes[i].ResType, addRes[i].ResID)] = struct{}{}
 			}
 
 			// 筛选出从策略中被踢出的 resource 列表
 			for i := range oldRule.Resources {
 				item := oldRule.Resources[i]
-				if _, ok := newRes[fmt.Sprintf("%d_%s", item.ResType, item.ResID)]; !ok {
+				if _, ok := newRes[sc.resourceLinkKey(item.ResType, item.ResID)]; !ok {
 					delRes = append(delRes, item)
 				}
 			}
 
-			// 针对被剔除的 resource 列表，清理掉所关联的鉴权策略信息
+			// 针对被剔除的 resource 列表，在所有新增的关联关系生效后再清理掉所关联的鉴权策略信息
 			for rIndex := range delRes {
-				resource := delRes[rIndex]
-				operateLink(resource.ResType, resource.ResID, rule.ID, true)
+				delLinks = append(delLinks, resourceLink{resource: delRes[rIndex], strategyId: rule.ID})
 			}
 		}
 
+		// 只读策略以及拒绝策略不授予写权限，也不改变资源是否关联了策略
 		for rIndex := range addRes {
 			resource := addRes[rIndex]
-			if rule.Valid {
+			if rule.Valid && !rule.IsReadOnly() && !rule.IsDeny() {
 				operateLink(resource.ResType, resource.ResID, rule.ID, false)
 			} else {
 				operateLink(resource.ResType, resource.ResID, rule.ID, true)
 			}
 		}
 	}
+
+	for i := range delLinks {
+		link := delLinks[i]
+		operateLink(link.resource.ResType, link.resource.ResID, link.strategyId, true)
+	}
 }
 
 // handlerPrincipalStrategy
@@ -295,21 +349,46 @@ func (sc *strategyCache) Clear() error {
 	sc.namespace2Strategy = utils.NewSyncMap[string, *utils.SyncSet[string]]()
 	sc.service2Strategy = utils.NewSyncMap[string, *utils.SyncSet[string]]()
 	sc.configGroup2Strategy = utils.NewSyncMap[string, *utils.SyncSet[string]]()
+	sc.labelSelector2Strategy = utils.NewSyncMap[string, *utils.SyncSet[string]]()
 	sc.lastMtime = 0
+	sc.statsChanged = true
+	sc.publishStrategyChange(atomic.AddUint64(&sc.version, 1))
 	return nil
 }
 
+// publishStrategyChange 通知鉴权策略集合的版本变化
+func (sc *strategyCache) publishStrategyChange(version uint64) {
+	_ = eventhub.Publish(eventhub.CacheStrategyEventTopic, &eventhub.CacheStrategyEvent{Version: version})
+}
+
+// Version 鉴权策略集合的版本
+func (sc *strategyCache) Version() uint64 {
+	return atomic.LoadUint64(&sc.version)
+}
+
+// IteratorStrategies 遍历缓存中的鉴权策略
+func (sc *strategyCache) IteratorStrategies(iterProc types.StrategyIterProc) {
+	for _, rule := range sc.strategys.Values() {
+		if !iterProc(rule.StrategyDetail) {
+			return
+		}
+	}
+}
+
 func (sc *strategyCache) Name() string {
 	return types.StrategyRuleName
 }
 
 // 对于 check 逻辑，如果是计算 * 策略，则必须要求 * 资源下必须有策略
 // 如果是具体的资源ID，则该资源下不必有策略，如果没有策略就认为这个资源是可以被任何人编辑的
-func (sc *strategyCache) checkResourceEditable(strategIds *utils.SyncSet[string], principal model.Principal, mustCheck bool) bool {
+// 已经过期的资源关联关系不再授予权限，但是资源仍然视为关联了策略
+func (sc *strategyCache) checkResourceEditable(strategIds *utils.SyncSet[string], principal model.Principal,
+	resType apisecurity.ResourceType, resId string, mustCheck bool) bool {
 	// 是否可以编辑
 	editable := false
 	// 是否真的包含策略
 	isCheck := strategIds.Len() != 0
+	now := time.Now()
 
 	// 如果根本没有遍历过，则表示该资源下没有对应的策略列表，直接返回可编辑状态即可
 	if !isCheck && !mustCheck {
@@ -319,6 +398,10 @@ func (sc *strategyCache) checkResourceEditable(strategIds *utils.SyncSet[string]
 	strategIds.Range(func(strategyId string) {
 		isCheck = true
 		if rule, ok := sc.strategys.Load(strategyId); ok {
+			if expireTime, ok := rule.ExpireResources[sc.resourceLinkKey(int32(resType), resId)]; ok &&
+				!now.Before(expireTime) {
+				return
+			}
 			if principal.PrincipalRole == model.PrincipalUser {
 				_, exist := rule.UserPrincipal[principal.PrincipalID]
 				editable = editable || exist
@@ -336,6 +419,7 @@ func (sc *strategyCache) checkResourceEditable(strategIds *utils.SyncSet[string]
 // 这里需要考虑两种情况，一种是 “ * ” 策略，另一种是明确指出了具体的资源ID的策略
 func (sc *strategyCache) IsResourceEditable(
 	principal model.Principal, resType apisecurity.ResourceType, resId string) bool {
+	resId = sc.canonical.Canonical(resId)
 	var (
 		valAll, val *utils.SyncSet[string]
 		ok          bool
@@ -371,11 +455,11 @@ func (sc *strategyCache) IsResourceEditable(
 
 	for i := range principals {
 		item := principals[i]
-		if valAll != nil && sc.checkResourceEditable(valAll, item, true) {
+		if valAll != nil && sc.checkResourceEditable(valAll, item, resType, utils.MatchAll, true) {
 			return true
 		}
 
-		if sc.checkResourceEditable(val, item, false) {
+		if sc.checkResourceEditable(val, item, resType, resId, false) {
 			return true
 		}
 	}
@@ -391,6 +475,15 @@ func (sc *strategyCache) GetStrategyDetailsByGroupID(groupid string) []*model.St
 	return sc.getStrategyDetails("", groupid)
 }
 
+// GetStrategy 根据 ID 获取鉴权策略
+func (sc *strategyCache) GetStrategy(id string) *model.StrategyDetail {
+	rule, ok := sc.strategys.Load(id)
+	if !ok {
+		return nil
+	}
+	return rule.StrategyDetail
+}
+
 func (sc *strategyCache) getStrategyDetails(uid string, gid string) []*model.StrategyDetail {
 	var (
 		strategyIds []string
@@ -424,8 +517,30 @@ func (sc *strategyCache) getStrategyDetails(uid string, gid string) []*model.Str
 	return nil
 }
 
+// GetLabelSelectorStrategies 获取通过命名空间以及标签选择器关联了该类型资源的鉴权策略, 只读策略以及拒绝策略不返回
+func (sc *strategyCache) GetLabelSelectorStrategies(resType apisecurity.ResourceType) []*model.StrategyDetail {
+	prefix := fmt.Sprintf("%d_", resType)
+	ids := map[string]struct{}{}
+	sc.labelSelector2Strategy.ReadRange(func(key string, val *utils.SyncSet[string]) {
+		if !strings.HasPrefix(key, prefix) {
+			return
+		}
+		val.Range(func(id string) {
+			ids[id] = struct{}{}
+		})
+	})
+	ret := make([]*model.StrategyDetail, 0, len(ids))
+	for id := range ids {
+		if rule, ok := sc.strategys.Load(id); ok {
+			ret = append(ret, rule.StrategyDetail)
+		}
+	}
+	return ret
+}
+
 // IsResourceLinkStrategy 校验
 func (sc *strategyCache) IsResourceLinkStrategy(resType apisecurity.ResourceType, resId string) bool {
+	resId = sc.canonical.Canonical(resId)
 	switch resType {
 	case apisecurity.ResourceType_Namespaces:
 		val, ok := sc.namespace2Strategy.Load(resId)
@@ -0,0 +1,57 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package auth
+
+import (
+	"github.com/polarismesh/polaris/common/metrics"
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/common/utils"
+)
+
+// reportMetricsInfo 上报鉴权策略的规模统计，只有策略数据发生变化时才会重新遍历缓存计算
+func (sc *strategyCache) reportMetricsInfo() {
+	if sc.statsChanged {
+		sc.stats = sc.computeStats()
+		sc.statsChanged = false
+	}
+	metrics.ReportAuthStrategyStats(sc.stats)
+}
+
+func (sc *strategyCache) computeStats() metrics.AuthStrategyStats {
+	stats := metrics.AuthStrategyStats{
+		UserStrategies:    map[string]int64{},
+		GroupStrategies:   map[string]int64{},
+		StrategyResources: map[string]int64{},
+	}
+	sc.strategys.ReadRange(func(_ string, rule *model.StrategyDetailCache) {
+		stats.StrategyTotal++
+		stats.ResourceTotal += int64(len(rule.Resources))
+		stats.StrategyResources[metrics.AuthCountBucket(len(rule.Resources))]++
+	})
+	countPrincipals := func(links *utils.SyncMap[string, *utils.SyncSet[string]], dist map[string]int64) {
+		links.ReadRange(func(_ string, strategyIds *utils.SyncSet[string]) {
+			// principal 的策略全部解除关联后仍会保留空集合，不计入分布
+			if count := strategyIds.Len(); count > 0 {
+				dist[metrics.AuthCountBucket(count)]++
+			}
+		})
+	}
+	countPrincipals(sc.uid2Strategy, stats.UserStrategies)
+	countPrincipals(sc.groupid2Strategy, stats.GroupStrategies)
+	return stats
+}
@@ -20,13 +20,16 @@ package auth
 import (
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	apisecurity "github.com/polarismesh/specification/source/go/api/v1/security"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
 
 	types "github.com/polarismesh/polaris/cache/api"
 	cachemock "github.com/polarismesh/polaris/cache/mock"
+	"github.com/polarismesh/polaris/common/metrics"
 	"github.com/polarismesh/polaris/common/model"
 	"github.com/polarismesh/polaris/common/utils"
 	"github.com/polarismesh/polaris/store/mock"
@@ -385,6 +388,71 @@ func Test_strategyCache_IsResourceEditable_1(t *testing.T) {
 
 		assert.False(t, ret, "must be false")
 	})
+
+	t.Run("资源关联关系过期后不再授予权限-同策略下未过期的资源不受影响", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockCacheMgr := cachemock.NewMockCacheManager(ctrl)
+		mockStore := mock.NewMockStore(ctrl)
+
+		t.Cleanup(func() {
+			ctrl.Finish()
+		})
+
+		userCache := NewUserCache(mockStore, mockCacheMgr)
+		strategyCache := NewStrategyCache(mockStore, mockCacheMgr).(*strategyCache)
+
+		mockCacheMgr.EXPECT().GetCacher(types.CacheUser).Return(userCache).AnyTimes()
+
+		userCache.Initialize(map[string]interface{}{})
+		strategyCache.Initialize(map[string]interface{}{})
+
+		strategyCache.setStrategys([]*model.StrategyDetail{
+			{
+				ID:   "rule-1",
+				Name: "rule-1",
+				Principals: []model.Principal{
+					{
+						PrincipalID:   "user-1",
+						PrincipalRole: model.PrincipalUser,
+					},
+				},
+				Valid: true,
+				Resources: []model.StrategyResource{
+					{
+						StrategyID: "rule-1",
+						ResType:    int32(apisecurity.ResourceType_Services),
+						ResID:      "service-expired",
+						ExpireTime: time.Now().Add(-time.Minute),
+					},
+					{
+						StrategyID: "rule-1",
+						ResType:    int32(apisecurity.ResourceType_Services),
+						ResID:      "service-temporary",
+						ExpireTime: time.Now().Add(time.Hour),
+					},
+					{
+						StrategyID: "rule-1",
+						ResType:    int32(apisecurity.ResourceType_Services),
+						ResID:      "service-forever",
+					},
+				},
+			},
+		})
+
+		principal := model.Principal{
+			PrincipalID:   "user-1",
+			PrincipalRole: model.PrincipalUser,
+		}
+		assert.False(t, strategyCache.IsResourceEditable(principal, apisecurity.ResourceType_Services, "service-expired"))
+		assert.True(t, strategyCache.IsResourceEditable(principal, apisecurity.ResourceType_Services, "service-temporary"))
+		assert.True(t, strategyCache.IsResourceEditable(principal, apisecurity.ResourceType_Services, "service-forever"))
+		// 过期的资源仍然视为关联了策略，不会变成任何人都可以编辑
+		assert.True(t, strategyCache.IsResourceLinkStrategy(apisecurity.ResourceType_Services, "service-expired"))
+		assert.False(t, strategyCache.IsResourceEditable(model.Principal{
+			PrincipalID:   "user-2",
+			PrincipalRole: model.PrincipalUser,
+		}, apisecurity.ResourceType_Services, "service-expired"))
+	})
 }
 
 func buildStrategies(num int) []*model.StrategyDetail {
@@ -437,3 +505,185 @@ func testBuildPrincipalMap(principals []model.Principal, role model.PrincipalTyp
 
 	return ret
 }
+
+func Test_strategyCache_reportMetricsInfo(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockCacheMgr := cachemock.NewMockCacheManager(ctrl)
+	mockStore := mock.NewMockStore(ctrl)
+	t.Cleanup(func() {
+		ctrl.Finish()
+	})
+
+	metrics.InitMetrics()
+
+	userCache := NewUserCache(mockStore, mockCacheMgr)
+	strategyCache := NewStrategyCache(mockStore, mockCacheMgr).(*strategyCache)
+	mockCacheMgr.EXPECT().GetCacher(types.CacheUser).Return(userCache).AnyTimes()
+	_ = userCache.Initialize(map[string]interface{}{})
+	_ = strategyCache.Initialize(map[string]interface{}{})
+
+	buildResources := func(strategyID string, total int) []model.StrategyResource {
+		ret := make([]model.StrategyResource, 0, total)
+		for i := 0; i < total; i++ {
+			ret = append(ret, model.StrategyResource{
+				StrategyID: strategyID,
+				ResType:    int32(apisecurity.ResourceType_Services),
+				ResID:      fmt.Sprintf("service-%d", i),
+			})
+		}
+		return ret
+	}
+	user1 := model.Principal{PrincipalID: "user-1", PrincipalRole: model.PrincipalUser}
+	user2 := model.Principal{PrincipalID: "user-2", PrincipalRole: model.PrincipalUser}
+	group1 := model.Principal{PrincipalID: "group-1", PrincipalRole: model.PrincipalGroup}
+
+	// user-1 关联 3 个策略，user-2 关联 1 个策略，group-1 关联 1 个策略
+	strategyCache.setStrategys([]*model.StrategyDetail{
+		{
+			ID:         "rule-1",
+			Principals: []model.Principal{user1, group1},
+			Resources:  buildResources("rule-1", 20),
+			Valid:      true,
+		},
+		{
+			ID:         "rule-2",
+			Principals: []model.Principal{user1, user2},
+			Resources:  buildResources("rule-2", 1),
+			Valid:      true,
+		},
+		{
+			ID:         "rule-3",
+			Principals: []model.Principal{user1},
+			Valid:      true,
+		},
+	})
+	strategyCache.reportMetricsInfo()
+
+	assert.Equal(t, float64(3), testutil.ToFloat64(metrics.GetAuthStrategyTotal()))
+	assert.Equal(t, float64(21), testutil.ToFloat64(metrics.GetAuthStrategyResourceTotal()))
+
+	principalDist := metrics.GetAuthPrincipalStrategyDist()
+	assert.Equal(t, float64(1), testutil.ToFloat64(principalDist.WithLabelValues("user", "1")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(principalDist.WithLabelValues("user", "5")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(principalDist.WithLabelValues("group", "1")))
+	assert.Equal(t, float64(0), testutil.ToFloat64(principalDist.WithLabelValues("group", "5")))
+
+	resourceDist := metrics.GetAuthStrategyResourceDist()
+	assert.Equal(t, float64(1), testutil.ToFloat64(resourceDist.WithLabelValues("0")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(resourceDist.WithLabelValues("1")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(resourceDist.WithLabelValues("50")))
+
+	// 删除策略后重新计算，principal 解除全部关联后不再计入分布
+	strategyCache.setStrategys([]*model.StrategyDetail{
+		{
+			ID:         "rule-2",
+			Principals: []model.Principal{user1, user2},
+			Resources:  buildResources("rule-2", 1),
+			Valid:      false,
+		},
+	})
+	strategyCache.reportMetricsInfo()
+
+	assert.Equal(t, float64(2), testutil.ToFloat64(metrics.GetAuthStrategyTotal()))
+	assert.Equal(t, float64(20), testutil.ToFloat64(metrics.GetAuthStrategyResourceTotal()))
+	assert.Equal(t, float64(0), testutil.ToFloat64(principalDist.WithLabelValues("user", "1")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(principalDist.WithLabelValues("user", "5")))
+	assert.Equal(t, float64(0), testutil.ToFloat64(resourceDist.WithLabelValues("1")))
+
+	// 数据没有变化时复用上一次的统计结果
+	strategyCache.setStrategys(nil)
+	assert.False(t, strategyCache.statsChanged)
+}
+
+func Test_strategyCache_Version(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockCacheMgr := cachemock.NewMockCacheManager(ctrl)
+	mockStore := mock.NewMockStore(ctrl)
+	t.Cleanup(func() {
+		ctrl.Finish()
+	})
+
+	userCache := NewUserCache(mockStore, mockCacheMgr)
+	strategyCache := NewStrategyCache(mockStore, mockCacheMgr).(*strategyCache)
+	mockCacheMgr.EXPECT().GetCacher(types.CacheUser).Return(userCache).AnyTimes()
+	_ = userCache.Initialize(map[string]interface{}{})
+	_ = strategyCache.Initialize(map[string]interface{}{})
+
+	version := strategyCache.Version()
+	// 没有策略变化时版本不变
+	strategyCache.setStrategys(nil)
+	assert.Equal(t, version, strategyCache.Version())
+
+	strategyCache.setStrategys(buildStrategies(1))
+	assert.Greater(t, strategyCache.Version(), version)
+
+	// 清空缓存后版本继续递增，不会回到旧的版本
+	version = strategyCache.Version()
+	_ = strategyCache.Clear()
+	assert.Greater(t, strategyCache.Version(), version)
+}
+
+func Test_strategyCache_ResourceIDCanonical(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockCacheMgr := cachemock.NewMockCacheManager(ctrl)
+	mockStore := mock.NewMockStore(ctrl)
+	t.Cleanup(func() {
+		ctrl.Finish()
+	})
+
+	userCache := NewUserCache(mockStore, mockCacheMgr)
+	strategyCache := NewStrategyCache(mockStore, mockCacheMgr).(*strategyCache)
+	mockCacheMgr.EXPECT().GetCacher(types.CacheUser).Return(userCache).AnyTimes()
+	_ = userCache.Initialize(map[string]interface{}{})
+	_ = strategyCache.Initialize(map[string]interface{}{
+		"resourceIdCanonical": &model.ResourceIDCanonicalizer{
+			Trim:       true,
+			Separators: []string{".", ":"},
+			CaseFold:   true,
+		},
+	})
+
+	user1 := model.Principal{PrincipalID: "user-1", PrincipalRole: model.PrincipalUser}
+	user2 := model.Principal{PrincipalID: "user-2", PrincipalRole: model.PrincipalUser}
+	strategyCache.setStrategys([]*model.StrategyDetail{
+		{
+			ID:         "rule-1",
+			Principals: []model.Principal{user1},
+			Resources: []model.StrategyResource{
+				{StrategyID: "rule-1", ResType: int32(apisecurity.ResourceType_Services), ResID: "Prod/Order-Svc/"},
+				{
+					StrategyID: "rule-1",
+					ResType:    int32(apisecurity.ResourceType_Namespaces),
+					ResID:      " team.a ",
+					ExpireTime: time.Now().Add(-time.Minute),
+				},
+			},
+			Valid: true,
+		},
+	})
+
+	// 请求中的资源 ID 只在规范化的部分与策略中不同
+	for _, resID := range []string{"prod/order-svc", "prod.order-svc", "PROD:ORDER-SVC//", " prod/order-svc "} {
+		assert.True(t, strategyCache.IsResourceLinkStrategy(apisecurity.ResourceType_Services, resID), resID)
+		assert.True(t, strategyCache.IsResourceEditable(user1, apisecurity.ResourceType_Services, resID), resID)
+		assert.False(t, strategyCache.IsResourceEditable(user2, apisecurity.ResourceType_Services, resID), resID)
+	}
+	assert.False(t, strategyCache.IsResourceLinkStrategy(apisecurity.ResourceType_Services, "prod/order"))
+
+	// 过期时间同样按照规范化后的资源 ID 匹配
+	assert.False(t, strategyCache.IsResourceEditable(user1, apisecurity.ResourceType_Namespaces, "team/a"))
+
+	// 移除其中一种写法时，规范化后相同的关联关系仍然保留
+	strategyCache.setStrategys([]*model.StrategyDetail{
+		{
+			ID:         "rule-1",
+			Principals: []model.Principal{user1},
+			Resources: []model.StrategyResource{
+				{StrategyID: "rule-1", ResType: int32(apisecurity.ResourceType_Services), ResID: "prod.order-svc"},
+			},
+			Valid: true,
+		},
+	})
+	assert.True(t, strategyCache.IsResourceEditable(user1, apisecurity.ResourceType_Services, "Prod/Order-Svc/"))
+	assert.False(t, strategyCache.IsResourceEditable(user2, apisecurity.ResourceType_Services, "Prod/Order-Svc/"))
+}
@@ -27,6 +27,7 @@ import (
 	"golang.org/x/sync/singleflight"
 
 	types "github.com/polarismesh/polaris/cache/api"
+	"github.com/polarismesh/polaris/common/eventhub"
 	"github.com/polarismesh/polaris/common/model"
 	"github.com/polarismesh/polaris/common/utils"
 	"github.com/polarismesh/polaris/store"
@@ -61,9 +62,13 @@ type userCache struct {
 	groups *utils.SyncMap[string, *model.UserGroupDetail]
 	// userid -> groups
 	user2Groups *utils.SyncMap[string, *utils.SyncSet[string]]
+	// sessionid -> session，只保存有效的会话
+	sessions *utils.SyncMap[string, *model.UserSession]
 
 	lastUserMtime  int64
 	lastGroupMtime int64
+	// version 用户组集合的版本，先完成缓存数据的变更再递增
+	version uint64
 
 	singleFlight *singleflight.Group
 }
@@ -82,6 +87,7 @@ func (uc *userCache) Initialize(_ map[string]interface{}) error {
 	uc.name2Users = utils.NewSyncMap[string, *model.User]()
 	uc.groups = utils.NewSyncMap[string, *model.UserGroupDetail]()
 	uc.user2Groups = utils.NewSyncMap[string, *utils.SyncSet[string]]()
+	uc.sessions = utils.NewSyncMap[string, *model.UserSession]()
 	uc.adminUser = atomic.Value{}
 	uc.singleFlight = new(singleflight.Group)
 	return nil
@@ -109,7 +115,13 @@ func (uc *userCache) realUpdate() (map[string]time.Time, int64, error) {
 		log.Errorf("[Cache][Group] update group err: %s", err.Error())
 		return nil, -1, err
 	}
+	sessions, err := uc.storage.GetUserSessionsForCache(uc.LastFetchTime(), uc.IsFirstUpdate())
+	if err != nil {
+		log.Errorf("[Cache][UserSession] update user session err: %s", err.Error())
+		return nil, -1, err
+	}
 	lastMimes, refreshRet := uc.setUserAndGroups(users, groups)
+	uc.handlerSessionCacheUpdate(lastMimes, sessions)
 
 	timeDiff := time.Since(start)
 	if timeDiff > time.Second {
@@ -125,7 +137,12 @@ func (uc *userCache) realUpdate() (map[string]time.Time, int64, error) {
 			zap.Int("delete", refreshRet.groupDel),
 			zap.Time("last", time.Unix(uc.lastGroupMtime, 0)), zap.Duration("used", time.Since(start)))
 	}
-	return lastMimes, int64(len(users) + len(groups)), nil
+	return lastMimes, int64(len(users) + len(groups) + len(sessions)), nil
+}
+
+// ForceSync 强制同步用户、用户组以及会话到cache (串行)
+func (uc *userCache) ForceSync() error {
+	return uc.Update()
 }
 
 func (uc *userCache) setUserAndGroups(users []*model.User,
@@ -154,6 +171,9 @@ func (uc *userCache) setUserAndGroups(users []*model.User,
 	}, ownerSupplier)
 
 	uc.handlerGroupCacheUpdate(lastMimes, &ret, groups)
+	if len(groups) > 0 {
+		uc.publishGroupChange(atomic.AddUint64(&uc.version, 1))
+	}
 	return lastMimes, ret
 }
 
@@ -180,6 +200,7 @@ func (uc *userCache) handlerUserCacheUpdate(lastMimes map[string]time.Time, ret
 		}
 
 		owner := ownerSupplier(user)
+		oldUser, hasOld := uc.users.Load(user.ID)
 		if !user.Valid {
 			// 删除 user-id -> user 的缓存
 			// 删除 username + ownername -> user 的缓存
@@ -188,20 +209,41 @@ func (uc *userCache) handlerUserCacheUpdate(lastMimes map[string]time.Time, ret
 			uc.name2Users.Delete(fmt.Sprintf(NameLinkOwnerTemp, owner.Name, user.Name))
 			// uc.user2Groups.Delete(user.ID)
 			ret.userDel++
+			if hasOld {
+				uc.publishUserChange(eventhub.EventDeleted, oldUser, user)
+			}
 		} else {
-			if _, ok := uc.users.Load(user.ID); ok {
+			if hasOld {
 				ret.userUpdate++
 			} else {
 				ret.userAdd++
 			}
 			uc.users.Store(user.ID, user)
 			uc.name2Users.Store(fmt.Sprintf(NameLinkOwnerTemp, owner.Name, user.Name), user)
+			// 只有 owner 发生变化时才需要通知，避免全量加载时产生大量无意义的事件
+			if hasOld && oldUser.Owner != user.Owner {
+				uc.publishUserChange(eventhub.EventUpdated, oldUser, user)
+			}
 		}
 	}
 
 	lastMimes["users"] = time.Unix(lastUserMtime, 0)
 }
 
+// publishUserChange 通知用户的 owner 变更或者删除事件
+func (uc *userCache) publishUserChange(eventType eventhub.EventType, oldUser, user *model.User) {
+	_ = eventhub.Publish(eventhub.CacheUserEventTopic, &eventhub.CacheUserEvent{
+		OldItem:   oldUser,
+		Item:      user,
+		EventType: eventType,
+	})
+}
+
+// publishGroupChange 通知用户组集合的版本变化
+func (uc *userCache) publishGroupChange(version uint64) {
+	_ = eventhub.Publish(eventhub.CacheUserGroupEventTopic, &eventhub.CacheUserGroupEvent{Version: version})
+}
+
 // handlerGroupCacheUpdate 处理用户组信息更新
 func (uc *userCache) handlerGroupCacheUpdate(lastMimes map[string]time.Time, ret *userRefreshResult,
 	groups []*model.UserGroupDetail) {
@@ -258,15 +300,39 @@ func (uc *userCache) handlerGroupCacheUpdate(lastMimes map[string]time.Time, ret
 	lastMimes["group"] = time.Unix(lastGroupMtime, 0)
 }
 
+// handlerSessionCacheUpdate 处理会话信息更新，已经注销的会话从缓存中移除
+func (uc *userCache) handlerSessionCacheUpdate(lastMimes map[string]time.Time, sessions []*model.UserSession) {
+	lastSessionMtime := uc.LastMtime("session").Unix()
+	for i := range sessions {
+		session := sessions[i]
+		lastSessionMtime = int64(math.Max(float64(lastSessionMtime), float64(session.ModifyTime.Unix())))
+		if !session.Valid {
+			uc.sessions.Delete(session.ID)
+			continue
+		}
+		uc.sessions.Store(session.ID, session)
+	}
+	// 顺带清理已经过期的会话
+	now := time.Now()
+	uc.sessions.Range(func(id string, session *model.UserSession) {
+		if !session.IsActive(now) {
+			uc.sessions.Delete(id)
+		}
+	})
+	lastMimes["session"] = time.Unix(lastSessionMtime, 0)
+}
+
 func (uc *userCache) Clear() error {
 	uc.BaseCache.Clear()
 	uc.users = utils.NewSyncMap[string, *model.User]()
 	uc.name2Users = utils.NewSyncMap[string, *model.User]()
 	uc.groups = utils.NewSyncMap[string, *model.UserGroupDetail]()
 	uc.user2Groups = utils.NewSyncMap[string, *utils.SyncSet[string]]()
+	uc.sessions = utils.NewSyncMap[string, *model.UserSession]()
 	uc.adminUser = atomic.Value{}
 	uc.lastUserMtime = 0
 	uc.lastGroupMtime = 0
+	uc.publishGroupChange(atomic.AddUint64(&uc.version, 1))
 	return nil
 }
 
@@ -299,8 +365,15 @@ func (uc *userCache) IsUserInGroup(userId, groupId string) bool {
 	if group == nil {
 		return false
 	}
-	_, exist := group.UserIds[userId]
-	return exist
+	if _, exist := group.UserIds[userId]; exist {
+		return true
+	}
+	for _, id := range uc.GetUserLinkGroupIds(userId) {
+		if id == groupId {
+			return true
+		}
+	}
+	return false
 }
 
 // GetUserByID 根据用户ID获取用户缓存对象
@@ -340,8 +413,33 @@ func (uc *userCache) GetGroup(id string) *model.UserGroupDetail {
 	return val
 }
 
-// GetUserLinkGroupIds 根据用户ID查询该用户关联的用户组ID列表
+// GetUserLinkGroupIds 根据用户ID查询该用户关联的用户组ID列表，直接加入的用户组的祖先用户组同样包含在内
 func (uc *userCache) GetUserLinkGroupIds(userId string) []string {
+	direct := uc.GetUserDirectGroupIds(userId)
+	if len(direct) == 0 {
+		return direct
+	}
+	ret := make([]string, 0, len(direct))
+	seen := make(map[string]struct{}, len(direct))
+	for _, id := range direct {
+		if _, ok := seen[id]; !ok {
+			seen[id] = struct{}{}
+			ret = append(ret, id)
+		}
+	}
+	for _, id := range direct {
+		for _, ancestor := range uc.GetGroupAncestorIds(id) {
+			if _, ok := seen[ancestor]; !ok {
+				seen[ancestor] = struct{}{}
+				ret = append(ret, ancestor)
+			}
+		}
+	}
+	return ret
+}
+
+// GetUserDirectGroupIds 根据用户ID查询该用户直接加入的用户组ID列表
+func (uc *userCache) GetUserDirectGroupIds(userId string) []string {
 	if userId == "" {
 		return nil
 	}
@@ -351,3 +449,56 @@ func (uc *userCache) GetUserLinkGroupIds(userId string) []string {
 	}
 	return val.ToSlice()
 }
+
+// GetGroupAncestorIds 沿着父用户组向上查找全部祖先用户组，父用户组已经被删除时停止
+// 存储中的数据出现环时，遇到已经访问过的用户组同样停止
+func (uc *userCache) GetGroupAncestorIds(id string) []string {
+	group := uc.GetGroup(id)
+	if group == nil {
+		return nil
+	}
+	var ancestors []string
+	visited := map[string]struct{}{id: {}}
+	for group.ParentID != "" {
+		if _, ok := visited[group.ParentID]; ok {
+			break
+		}
+		parent := uc.GetGroup(group.ParentID)
+		if parent == nil {
+			break
+		}
+		visited[parent.ID] = struct{}{}
+		ancestors = append(ancestors, parent.ID)
+		group = parent
+	}
+	return ancestors
+}
+
+// GetUserSession 根据会话ID获取有效的会话，会话不存在或者已经注销时返回 nil
+func (uc *userCache) GetUserSession(id string) *model.UserSession {
+	if id == "" {
+		return nil
+	}
+	val, ok := uc.sessions.Load(id)
+	if !ok {
+		return nil
+	}
+	return val
+}
+
+// ListUserSessions 查询用户全部有效的会话
+func (uc *userCache) ListUserSessions(userId string) []*model.UserSession {
+	now := time.Now()
+	ret := make([]*model.UserSession, 0, 4)
+	uc.sessions.ReadRange(func(_ string, session *model.UserSession) {
+		if session.UserID == userId && session.IsActive(now) {
+			ret = append(ret, session)
+		}
+	})
+	return ret
+}
+
+// Version 用户组集合的版本
+func (uc *userCache) Version() uint64 {
+	return atomic.LoadUint64(&uc.version)
+}
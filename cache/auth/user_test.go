@@ -18,6 +18,7 @@
 package auth
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"math/rand"
@@ -28,6 +29,7 @@ import (
 	"github.com/stretchr/testify/assert"
 
 	types "github.com/polarismesh/polaris/cache/api"
+	"github.com/polarismesh/polaris/common/eventhub"
 	"github.com/polarismesh/polaris/common/model"
 	"github.com/polarismesh/polaris/common/utils"
 	"github.com/polarismesh/polaris/store/mock"
@@ -145,6 +147,7 @@ func TestUserCache_UpdateNormal(t *testing.T) {
 		}
 		store.EXPECT().GetUsersForCache(gomock.Any(), gomock.Any()).Return(copyUsers, nil).Times(1)
 		store.EXPECT().GetGroupsForCache(gomock.Any(), gomock.Any()).Return(copyGroups, nil).Times(1)
+		store.EXPECT().GetUserSessionsForCache(gomock.Any(), gomock.Any()).AnyTimes().Return(nil, nil)
 
 		assert.NoError(t, uc.Update())
 
@@ -202,6 +205,7 @@ func TestUserCache_UpdateNormal(t *testing.T) {
 
 		store.EXPECT().GetUsersForCache(gomock.Any(), gomock.Any()).Return(copyUsers, nil).Times(1)
 		store.EXPECT().GetGroupsForCache(gomock.Any(), gomock.Any()).Return(copyGroups, nil).Times(1)
+		store.EXPECT().GetUserSessionsForCache(gomock.Any(), gomock.Any()).AnyTimes().Return(nil, nil)
 
 		assert.NoError(t, uc.Update())
 
@@ -248,3 +252,93 @@ func TestUserCache_UpdateNormal(t *testing.T) {
 	})
 
 }
+
+func TestUserCache_PublishOwnerChange(t *testing.T) {
+	eventhub.InitEventHub()
+	ctrl, store, uc := newTestUserCache(t)
+	defer ctrl.Finish()
+
+	users := genModelUsers(10)
+	groups := genModelUserGroups(users)
+
+	events := make(chan *eventhub.CacheUserEvent, len(users))
+	subCtx, err := eventhub.SubscribeWithFunc(eventhub.CacheUserEventTopic, func(_ context.Context, args interface{}) error {
+		events <- args.(*eventhub.CacheUserEvent)
+		return nil
+	})
+	assert.NoError(t, err)
+	defer subCtx.Cancel()
+
+	copyUsers := func() []*model.User {
+		ret := make([]*model.User, 0, len(users))
+		for i := range users {
+			copyUser := *users[i]
+			ret = append(ret, &copyUser)
+		}
+		return ret
+	}
+
+	store.EXPECT().GetUsersForCache(gomock.Any(), gomock.Any()).Return(copyUsers(), nil).Times(1)
+	store.EXPECT().GetGroupsForCache(gomock.Any(), gomock.Any()).Return(groups, nil).Times(1)
+	store.EXPECT().GetUserSessionsForCache(gomock.Any(), gomock.Any()).AnyTimes().Return(nil, nil)
+	assert.NoError(t, uc.Update())
+
+	// 首次加载不会产生事件
+	select {
+	case event := <-events:
+		t.Fatalf("unexpect user event: %+v", event)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// 将子用户迁移到一个新的主账户下
+	newOwner := *users[0]
+	newOwner.ID = utils.NewUUID()
+	newOwner.Name = "new-owner"
+	users = append(users, &newOwner)
+	oldOwner := users[1].Owner
+	users[1].Owner = newOwner.ID
+	store.EXPECT().GetUsersForCache(gomock.Any(), gomock.Any()).Return(copyUsers(), nil).Times(1)
+	store.EXPECT().GetGroupsForCache(gomock.Any(), gomock.Any()).Return(groups, nil).Times(1)
+	store.EXPECT().GetUserSessionsForCache(gomock.Any(), gomock.Any()).AnyTimes().Return(nil, nil)
+	assert.NoError(t, uc.Update())
+
+	select {
+	case event := <-events:
+		assert.Equal(t, eventhub.EventUpdated, event.EventType)
+		assert.Equal(t, users[1].ID, event.Item.ID)
+		assert.Equal(t, oldOwner, event.OldItem.Owner)
+		assert.Equal(t, users[1].Owner, event.Item.Owner)
+	case <-time.After(5 * time.Second):
+		t.Fatal("wait user owner change event timeout")
+	}
+}
+
+func TestUserCache_NestedGroups(t *testing.T) {
+	ctrl, store, uc := newTestUserCache(t)
+	defer ctrl.Finish()
+
+	users := genModelUsers(10)
+	groups := genModelUserGroups(users)
+	// groups[1] -> groups[2] -> groups[3]，groups[4] 与 groups[5] 互为父用户组
+	groups[1].ParentID = groups[2].ID
+	groups[2].ParentID = groups[3].ID
+	groups[4].ParentID = groups[5].ID
+	groups[5].ParentID = groups[4].ID
+	// 父用户组已经被删除
+	groups[6].ParentID = utils.NewUUID()
+
+	store.EXPECT().GetUsersForCache(gomock.Any(), gomock.Any()).Return(users, nil).Times(1)
+	store.EXPECT().GetGroupsForCache(gomock.Any(), gomock.Any()).Return(groups, nil).Times(1)
+	store.EXPECT().GetUserSessionsForCache(gomock.Any(), gomock.Any()).AnyTimes().Return(nil, nil)
+	assert.NoError(t, uc.Update())
+
+	assert.Equal(t, []string{groups[2].ID, groups[3].ID}, uc.GetGroupAncestorIds(groups[1].ID))
+	assert.Equal(t, []string{groups[1].ID}, uc.GetUserDirectGroupIds(users[1].ID))
+	assert.Equal(t, []string{groups[1].ID, groups[2].ID, groups[3].ID}, uc.GetUserLinkGroupIds(users[1].ID))
+	assert.True(t, uc.IsUserInGroup(users[1].ID, groups[3].ID))
+	assert.False(t, uc.IsUserInGroup(users[3].ID, groups[1].ID))
+
+	assert.Equal(t, []string{groups[5].ID}, uc.GetGroupAncestorIds(groups[4].ID))
+	assert.Equal(t, []string{groups[4].ID, groups[5].ID}, uc.GetUserLinkGroupIds(users[4].ID))
+	assert.Equal(t, []string{groups[6].ID}, uc.GetUserLinkGroupIds(users[6].ID))
+}
@@ -20,6 +20,8 @@ package cache
 import (
 	"context"
 	"fmt"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
@@ -42,6 +44,9 @@ type CacheManager struct {
 	storage  store.Store
 	caches   []types.Cache
 	needLoad *utils.SyncSet[string]
+
+	// 至少成功加载过一次的缓存，全部加载完成后节点才就绪
+	warmed *utils.SyncSet[string]
 }
 
 // Initialize 缓存对象初始化
@@ -87,10 +92,10 @@ func (nc *CacheManager) warmUp() error {
 			return fmt.Errorf("cache resource %s not exists", name)
 		}
 		wg.Add(1)
-		go func(c types.Cache) {
+		go func(name string, c types.Cache) {
 			defer wg.Done()
-			_ = c.Update()
-		}(nc.caches[index])
+			nc.update(name, c)
+		}(name, nc.caches[index])
 	}
 
 	wg.Wait()
@@ -138,23 +143,46 @@ func (nc *CacheManager) Start(ctx context.Context) error {
 			return fmt.Errorf("cache resource %s not exists", name)
 		}
 		// 每个缓存各自在自己的协程内部按照期望的缓存更新时间完成数据缓存刷新
-		go func(c types.Cache) {
+		go func(name string, c types.Cache) {
 			ticker := time.NewTicker(nc.GetUpdateCacheInterval())
 			for {
 				select {
 				case <-ticker.C:
-					_ = c.Update()
+					nc.update(name, c)
 				case <-ctx.Done():
 					ticker.Stop()
 					return
 				}
 			}
-		}(nc.caches[index])
+		}(name, nc.caches[index])
 	}
 
 	return nil
 }
 
+// update 更新缓存，并记录缓存是否已经成功加载
+func (nc *CacheManager) update(name string, c types.Cache) {
+	if err := c.Update(); err != nil {
+		return
+	}
+	nc.warmed.Add(name)
+}
+
+// CheckWarmed 检查需要加载的缓存是否都已经成功加载过一次
+func (nc *CacheManager) CheckWarmed() error {
+	var pending []string
+	for _, name := range nc.needLoad.ToSlice() {
+		if !nc.warmed.Contains(name) {
+			pending = append(pending, name)
+		}
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+	sort.Strings(pending)
+	return fmt.Errorf("cache %s not warmed", strings.Join(pending, ","))
+}
+
 // Clear 主动清除缓存数据
 func (nc *CacheManager) Clear() error {
 	return nc.clear()
@@ -220,6 +248,11 @@ func (nc *CacheManager) AuthStrategy() types.StrategyCache {
 	return nc.caches[types.CacheAuthStrategy].(types.StrategyCache)
 }
 
+// AuthRole Get auth role cache information
+func (nc *CacheManager) AuthRole() types.RoleCache {
+	return nc.caches[types.CacheAuthRole].(types.RoleCache)
+}
+
 // Namespace Get namespace cache information
 func (nc *CacheManager) Namespace() types.NamespaceCache {
 	return nc.caches[types.CacheNamespace].(types.NamespaceCache)
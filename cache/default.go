@@ -29,6 +29,7 @@ import (
 	cachegray "github.com/polarismesh/polaris/cache/gray"
 	cachens "github.com/polarismesh/polaris/cache/namespace"
 	cachesvc "github.com/polarismesh/polaris/cache/service"
+	"github.com/polarismesh/polaris/common/readiness"
 	"github.com/polarismesh/polaris/common/utils"
 	"github.com/polarismesh/polaris/store"
 )
@@ -50,6 +51,7 @@ func init() {
 	RegisterCache(types.ServiceContractName, types.CacheServiceContract)
 	RegisterCache(types.GrayName, types.CacheGray)
 	RegisterCache(types.LaneRuleName, types.CacheLaneRule)
+	RegisterCache(types.AuthRoleName, types.CacheAuthRole)
 }
 
 var (
@@ -86,6 +88,7 @@ func newCacheManager(ctx context.Context, cacheOpt *Config, storage store.Store)
 		storage:  storage,
 		caches:   make([]types.Cache, types.CacheLast),
 		needLoad: utils.NewSyncSet[string](),
+		warmed:   utils.NewSyncSet[string](),
 	}
 
 	// 命名空间缓存
@@ -106,6 +109,7 @@ func newCacheManager(ctx context.Context, cacheOpt *Config, storage store.Store)
 	// 用户/用户组 & 鉴权规则缓存
 	mgr.RegisterCacher(types.CacheUser, cacheauth.NewUserCache(storage, mgr))
 	mgr.RegisterCacher(types.CacheAuthStrategy, cacheauth.NewStrategyCache(storage, mgr))
+	mgr.RegisterCacher(types.CacheAuthRole, cacheauth.NewRoleCache(storage, mgr))
 	// 北极星SDK Client
 	mgr.RegisterCacher(types.CacheClient, cacheclient.NewClientCache(storage, mgr))
 	mgr.RegisterCacher(types.CacheGray, cachegray.NewGrayCache(storage, mgr))
@@ -121,6 +125,7 @@ func newCacheManager(ctx context.Context, cacheOpt *Config, storage store.Store)
 }
 
 func Run(cacheMgr *CacheManager, ctx context.Context) error {
+	readiness.Register(readiness.ComponentCache, cacheMgr.CheckWarmed)
 	if startErr := cacheMgr.Start(ctx); startErr != nil {
 		log.Errorf("[Cache][Server] start cache err: %s", startErr.Error())
 		return startErr
@@ -134,6 +134,20 @@ func (m *MockCacheManager) EXPECT() *MockCacheManagerMockRecorder {
 	return m.recorder
 }
 
+// AuthRole mocks base method.
+func (m *MockCacheManager) AuthRole() api.RoleCache {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AuthRole")
+	ret0, _ := ret[0].(api.RoleCache)
+	return ret0
+}
+
+// AuthRole indicates an expected call of AuthRole.
+func (mr *MockCacheManagerMockRecorder) AuthRole() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AuthRole", reflect.TypeOf((*MockCacheManager)(nil).AuthRole))
+}
+
 // AuthStrategy mocks base method.
 func (m *MockCacheManager) AuthStrategy() api.StrategyCache {
 	m.ctrl.T.Helper()
@@ -614,6 +628,20 @@ func (mr *MockServiceCacheMockRecorder) GetAliasFor(name, namespace interface{})
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAliasFor", reflect.TypeOf((*MockServiceCache)(nil).GetAliasFor), name, namespace)
 }
 
+// ResolveService mocks base method.
+func (m *MockServiceCache) ResolveService(name, namespace string) *model.Service {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResolveService", name, namespace)
+	ret0, _ := ret[0].(*model.Service)
+	return ret0
+}
+
+// ResolveService indicates an expected call of ResolveService.
+func (mr *MockServiceCacheMockRecorder) ResolveService(name, namespace interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResolveService", reflect.TypeOf((*MockServiceCache)(nil).ResolveService), name, namespace)
+}
+
 // GetAllNamespaces mocks base method.
 func (m *MockServiceCache) GetAllNamespaces() []string {
 	m.ctrl.T.Helper()
@@ -842,6 +870,20 @@ func (mr *MockServiceCacheMockRecorder) Update() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockServiceCache)(nil).Update))
 }
 
+// Version mocks base method.
+func (m *MockServiceCache) Version() uint64 {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Version")
+	ret0, _ := ret[0].(uint64)
+	return ret0
+}
+
+// Version indicates an expected call of Version.
+func (mr *MockServiceCacheMockRecorder) Version() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Version", reflect.TypeOf((*MockServiceCache)(nil).Version))
+}
+
 // MockServiceRevisionWorker is a mock of ServiceRevisionWorker interface.
 type MockServiceRevisionWorker struct {
 	ctrl     *gomock.Controller
@@ -2529,6 +2571,90 @@ func (mr *MockUserCacheMockRecorder) GetUserLinkGroupIds(id interface{}) *gomock
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserLinkGroupIds", reflect.TypeOf((*MockUserCache)(nil).GetUserLinkGroupIds), id)
 }
 
+// GetUserDirectGroupIds mocks base method.
+func (m *MockUserCache) GetUserDirectGroupIds(id string) []string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserDirectGroupIds", id)
+	ret0, _ := ret[0].([]string)
+	return ret0
+}
+
+// GetUserDirectGroupIds indicates an expected call of GetUserDirectGroupIds.
+func (mr *MockUserCacheMockRecorder) GetUserDirectGroupIds(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserDirectGroupIds", reflect.TypeOf((*MockUserCache)(nil).GetUserDirectGroupIds), id)
+}
+
+// GetGroupAncestorIds mocks base method.
+func (m *MockUserCache) GetGroupAncestorIds(id string) []string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetGroupAncestorIds", id)
+	ret0, _ := ret[0].([]string)
+	return ret0
+}
+
+// GetGroupAncestorIds indicates an expected call of GetGroupAncestorIds.
+func (mr *MockUserCacheMockRecorder) GetGroupAncestorIds(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetGroupAncestorIds", reflect.TypeOf((*MockUserCache)(nil).GetGroupAncestorIds), id)
+}
+
+// GetUserSession mocks base method.
+func (m *MockUserCache) GetUserSession(id string) *model.UserSession {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserSession", id)
+	ret0, _ := ret[0].(*model.UserSession)
+	return ret0
+}
+
+// GetUserSession indicates an expected call of GetUserSession.
+func (mr *MockUserCacheMockRecorder) GetUserSession(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserSession", reflect.TypeOf((*MockUserCache)(nil).GetUserSession), id)
+}
+
+// ListUserSessions mocks base method.
+func (m *MockUserCache) ListUserSessions(userId string) []*model.UserSession {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListUserSessions", userId)
+	ret0, _ := ret[0].([]*model.UserSession)
+	return ret0
+}
+
+// ListUserSessions indicates an expected call of ListUserSessions.
+func (mr *MockUserCacheMockRecorder) ListUserSessions(userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUserSessions", reflect.TypeOf((*MockUserCache)(nil).ListUserSessions), userId)
+}
+
+// Version mocks base method.
+func (m *MockUserCache) Version() uint64 {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Version")
+	ret0, _ := ret[0].(uint64)
+	return ret0
+}
+
+// Version indicates an expected call of Version.
+func (mr *MockUserCacheMockRecorder) Version() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Version", reflect.TypeOf((*MockUserCache)(nil).Version))
+}
+
+// ForceSync mocks base method.
+func (m *MockUserCache) ForceSync() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ForceSync")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ForceSync indicates an expected call of ForceSync.
+func (mr *MockUserCacheMockRecorder) ForceSync() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ForceSync", reflect.TypeOf((*MockUserCache)(nil).ForceSync))
+}
+
 // Initialize mocks base method.
 func (m *MockUserCache) Initialize(c map[string]interface{}) error {
 	m.ctrl.T.Helper()
@@ -2678,6 +2804,20 @@ func (mr *MockStrategyCacheMockRecorder) GetStrategyDetailsByGroupID(groupId int
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetStrategyDetailsByGroupID", reflect.TypeOf((*MockStrategyCache)(nil).GetStrategyDetailsByGroupID), groupId)
 }
 
+// GetStrategy mocks base method.
+func (m *MockStrategyCache) GetStrategy(id string) *model.StrategyDetail {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetStrategy", id)
+	ret0, _ := ret[0].(*model.StrategyDetail)
+	return ret0
+}
+
+// GetStrategy indicates an expected call of GetStrategy.
+func (mr *MockStrategyCacheMockRecorder) GetStrategy(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetStrategy", reflect.TypeOf((*MockStrategyCache)(nil).GetStrategy), id)
+}
+
 // GetStrategyDetailsByUID mocks base method.
 func (m *MockStrategyCache) GetStrategyDetailsByUID(uid string) []*model.StrategyDetail {
 	m.ctrl.T.Helper()
@@ -2720,6 +2860,20 @@ func (mr *MockStrategyCacheMockRecorder) IsResourceEditable(principal, resType,
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsResourceEditable", reflect.TypeOf((*MockStrategyCache)(nil).IsResourceEditable), principal, resType, resId)
 }
 
+// GetLabelSelectorStrategies mocks base method.
+func (m *MockStrategyCache) GetLabelSelectorStrategies(resType security.ResourceType) []*model.StrategyDetail {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLabelSelectorStrategies", resType)
+	ret0, _ := ret[0].([]*model.StrategyDetail)
+	return ret0
+}
+
+// GetLabelSelectorStrategies indicates an expected call of GetLabelSelectorStrategies.
+func (mr *MockStrategyCacheMockRecorder) GetLabelSelectorStrategies(resType interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLabelSelectorStrategies", reflect.TypeOf((*MockStrategyCache)(nil).GetLabelSelectorStrategies), resType)
+}
+
 // IsResourceLinkStrategy mocks base method.
 func (m *MockStrategyCache) IsResourceLinkStrategy(resType security.ResourceType, resId string) bool {
 	m.ctrl.T.Helper()
@@ -2734,6 +2888,18 @@ func (mr *MockStrategyCacheMockRecorder) IsResourceLinkStrategy(resType, resId i
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsResourceLinkStrategy", reflect.TypeOf((*MockStrategyCache)(nil).IsResourceLinkStrategy), resType, resId)
 }
 
+// IteratorStrategies mocks base method.
+func (m *MockStrategyCache) IteratorStrategies(iterProc api.StrategyIterProc) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "IteratorStrategies", iterProc)
+}
+
+// IteratorStrategies indicates an expected call of IteratorStrategies.
+func (mr *MockStrategyCacheMockRecorder) IteratorStrategies(iterProc interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IteratorStrategies", reflect.TypeOf((*MockStrategyCache)(nil).IteratorStrategies), iterProc)
+}
+
 // Name mocks base method.
 func (m *MockStrategyCache) Name() string {
 	m.ctrl.T.Helper()
@@ -2762,6 +2928,155 @@ func (mr *MockStrategyCacheMockRecorder) Update() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockStrategyCache)(nil).Update))
 }
 
+// Version mocks base method.
+func (m *MockStrategyCache) Version() uint64 {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Version")
+	ret0, _ := ret[0].(uint64)
+	return ret0
+}
+
+// Version indicates an expected call of Version.
+func (mr *MockStrategyCacheMockRecorder) Version() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Version", reflect.TypeOf((*MockStrategyCache)(nil).Version))
+}
+
+// MockRoleCache is a mock of RoleCache interface.
+type MockRoleCache struct {
+	ctrl     *gomock.Controller
+	recorder *MockRoleCacheMockRecorder
+}
+
+// MockRoleCacheMockRecorder is the mock recorder for MockRoleCache.
+type MockRoleCacheMockRecorder struct {
+	mock *MockRoleCache
+}
+
+// NewMockRoleCache creates a new mock instance.
+func NewMockRoleCache(ctrl *gomock.Controller) *MockRoleCache {
+	mock := &MockRoleCache{ctrl: ctrl}
+	mock.recorder = &MockRoleCacheMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockRoleCache) EXPECT() *MockRoleCacheMockRecorder {
+	return m.recorder
+}
+
+// Clear mocks base method.
+func (m *MockRoleCache) Clear() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Clear")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Clear indicates an expected call of Clear.
+func (mr *MockRoleCacheMockRecorder) Clear() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Clear", reflect.TypeOf((*MockRoleCache)(nil).Clear))
+}
+
+// Close mocks base method.
+func (m *MockRoleCache) Close() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Close")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Close indicates an expected call of Close.
+func (mr *MockRoleCacheMockRecorder) Close() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockRoleCache)(nil).Close))
+}
+
+// GetPrincipalRoles mocks base method.
+func (m *MockRoleCache) GetPrincipalRoles(principal model.Principal) []*model.Role {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPrincipalRoles", principal)
+	ret0, _ := ret[0].([]*model.Role)
+	return ret0
+}
+
+// GetPrincipalRoles indicates an expected call of GetPrincipalRoles.
+func (mr *MockRoleCacheMockRecorder) GetPrincipalRoles(principal interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPrincipalRoles", reflect.TypeOf((*MockRoleCache)(nil).GetPrincipalRoles), principal)
+}
+
+// GetRole mocks base method.
+func (m *MockRoleCache) GetRole(id string) *model.Role {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRole", id)
+	ret0, _ := ret[0].(*model.Role)
+	return ret0
+}
+
+// GetRole indicates an expected call of GetRole.
+func (mr *MockRoleCacheMockRecorder) GetRole(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRole", reflect.TypeOf((*MockRoleCache)(nil).GetRole), id)
+}
+
+// Initialize mocks base method.
+func (m *MockRoleCache) Initialize(c map[string]interface{}) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Initialize", c)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Initialize indicates an expected call of Initialize.
+func (mr *MockRoleCacheMockRecorder) Initialize(c interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Initialize", reflect.TypeOf((*MockRoleCache)(nil).Initialize), c)
+}
+
+// Name mocks base method.
+func (m *MockRoleCache) Name() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Name")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// Name indicates an expected call of Name.
+func (mr *MockRoleCacheMockRecorder) Name() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Name", reflect.TypeOf((*MockRoleCache)(nil).Name))
+}
+
+// Update mocks base method.
+func (m *MockRoleCache) Update() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockRoleCacheMockRecorder) Update() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockRoleCache)(nil).Update))
+}
+
+// Version mocks base method.
+func (m *MockRoleCache) Version() uint64 {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Version")
+	ret0, _ := ret[0].(uint64)
+	return ret0
+}
+
+// Version indicates an expected call of Version.
+func (mr *MockRoleCacheMockRecorder) Version() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Version", reflect.TypeOf((*MockRoleCache)(nil).Version))
+}
+
 // MockClientCache is a mock of ClientCache interface.
 type MockClientCache struct {
 	ctrl     *gomock.Controller
@@ -326,6 +326,9 @@ func (ic *instanceCache) setInstances(ins map[string]*model.Instance) ([]*eventh
 		}
 		serviceInstances.UpsertInstance(item)
 		ic.instancePorts.appendPort(item.ServiceID, item.Protocol(), item.Port())
+		for _, port := range item.NamedPorts() {
+			ic.instancePorts.appendPort(item.ServiceID, port.Protocol, port.Port)
+		}
 	}
 
 	if ic.instanceCount != instanceCount {
@@ -24,6 +24,7 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"go.uber.org/zap"
@@ -51,6 +52,7 @@ type serviceCache struct {
 	cl5Names        *utils.SyncMap[string, *model.Service]
 	alias           *serviceAliasBucket
 	serviceList     *serviceNamespaceBucket
+	labelIndex      *serviceLabelIndex
 	disableBusiness bool
 	needMeta        bool
 	singleFlight    *singleflight.Group
@@ -78,6 +80,9 @@ type serviceCache struct {
 	exportServices *utils.SyncMap[string, *utils.SyncMap[string, *model.Service]]
 
 	subCtx *eventhub.SubscribtionContext
+
+	// version 服务集合的版本，先完成缓存数据的变更再递增
+	version uint64
 }
 
 // NewServiceCache 返回一个serviceCache
@@ -87,6 +92,7 @@ func NewServiceCache(storage store.Store, cacheMgr types.CacheManager) types.Ser
 		storage:     storage,
 		alias:       newServiceAliasBucket(),
 		serviceList: newServiceNamespaceBucket(),
+		labelIndex:  newServiceLabelIndex(),
 	}
 }
 
@@ -202,11 +208,23 @@ func (sc *serviceCache) Clear() error {
 	sc.namespaceServiceCnt = utils.NewSyncMap[string, *model.NamespaceServiceCount]()
 	sc.alias = newServiceAliasBucket()
 	sc.serviceList = newServiceNamespaceBucket()
+	sc.labelIndex = newServiceLabelIndex()
 	sc.exportNamespace = utils.NewSyncMap[string, *utils.SyncSet[string]]()
 	sc.exportServices = utils.NewSyncMap[string, *utils.SyncMap[string, *model.Service]]()
+	sc.publishServiceChange(atomic.AddUint64(&sc.version, 1))
 	return nil
 }
 
+// publishServiceChange 通知服务集合的版本变化
+func (sc *serviceCache) publishServiceChange(version uint64) {
+	_ = eventhub.Publish(eventhub.CacheServiceEventTopic, &eventhub.CacheServiceEvent{Version: version})
+}
+
+// Version 服务集合的版本
+func (sc *serviceCache) Version() uint64 {
+	return atomic.LoadUint64(&sc.version)
+}
+
 // name 获取资源名称
 func (sc *serviceCache) Name() string {
 	return types.ServiceName
@@ -220,7 +238,32 @@ func (sc *serviceCache) GetAliasFor(name string, namespace string) *model.Servic
 	if svc.Reference == "" {
 		return nil
 	}
-	return sc.GetServiceByID(svc.Reference)
+	return sc.resolveAlias(svc)
+}
+
+// ResolveService 根据服务名获取服务，如果是服务别名，沿着别名链找到源服务，源服务可以在其他命名空间下
+func (sc *serviceCache) ResolveService(name string, namespace string) *model.Service {
+	svc := sc.GetServiceByName(name, namespace)
+	if svc == nil {
+		return nil
+	}
+	return sc.resolveAlias(svc)
+}
+
+// resolveAlias 沿着别名链找到源服务，链路断开或者成环时返回 nil
+func (sc *serviceCache) resolveAlias(svc *model.Service) *model.Service {
+	for i := 0; i < maxAliasDepth; i++ {
+		if !svc.IsAlias() {
+			return svc
+		}
+		svc = sc.GetServiceByID(svc.Reference)
+		if svc == nil {
+			return nil
+		}
+	}
+	log.Warn("[Cache][Service] alias chain too deep", zap.String("service", svc.Name),
+		zap.String("namespace", svc.Namespace))
+	return nil
 }
 
 // GetServiceByID 根据服务ID获取服务数据
@@ -375,6 +418,7 @@ func (sc *serviceCache) removeServices(service *model.Service) {
 	sc.serviceList.removeService(service)
 	// delete service all link alias info
 	sc.alias.cleanServiceAlias(service)
+	sc.labelIndex.remove(service.ID)
 	// delete pending count service task
 	sc.pendingServices.Delete(service.ID)
 
@@ -448,6 +492,7 @@ func (sc *serviceCache) setServices(services map[string]*model.Service) (map[str
 
 		sc.ids.Store(service.ID, service)
 		sc.serviceList.addService(service)
+		sc.labelIndex.update(service)
 		sc.notifyRevisionWorker(service.ID, true)
 
 		spaces, ok := sc.names.Load(spaceName)
@@ -472,6 +517,7 @@ func (sc *serviceCache) setServices(services map[string]*model.Service) (map[str
 	sc.postProcessUpdatedServices(changeNs)
 	sc.postProcessServiceExports(services)
 	sc.serviceList.reloadRevision()
+	sc.publishServiceChange(atomic.AddUint64(&sc.version, 1))
 	return map[string]time.Time{
 		sc.Name(): time.Unix(lastMtime, 0),
 	}, update, del
@@ -738,6 +784,8 @@ const (
 	RevisionConcurrenceCount = 64
 	// RevisionChanCount 存储revision计算的通知管道，可以稍微设置大一点
 	RevisionChanCount = 102400
+	// maxAliasDepth 别名链的最大长度，避免别名成环时无限查找
+	maxAliasDepth = 8
 )
 
 // 更新revision的结构体
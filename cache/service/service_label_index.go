@@ -0,0 +1,143 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package service
+
+import (
+	"sync"
+
+	"github.com/polarismesh/polaris/common/model"
+)
+
+// serviceLabelIndex 服务 metadata 标签的倒排索引，key -> value -> service_id
+type serviceLabelIndex struct {
+	lock sync.RWMutex
+	// labels key -> value -> service_id
+	labels map[string]map[string]map[string]struct{}
+	// services service_id -> 建立索引时的标签，用于服务更新或者删除时清理旧的索引
+	services map[string]map[string]string
+}
+
+func newServiceLabelIndex() *serviceLabelIndex {
+	return &serviceLabelIndex{
+		labels:   map[string]map[string]map[string]struct{}{},
+		services: map[string]map[string]string{},
+	}
+}
+
+// update 更新服务的标签索引，别名服务不建立索引
+func (idx *serviceLabelIndex) update(svc *model.Service) {
+	idx.lock.Lock()
+	defer idx.lock.Unlock()
+
+	idx.removeLocked(svc.ID)
+	if svc.IsAlias() || len(svc.Meta) == 0 {
+		return
+	}
+	labels := make(map[string]string, len(svc.Meta))
+	for k, v := range svc.Meta {
+		labels[k] = v
+		values, ok := idx.labels[k]
+		if !ok {
+			values = map[string]map[string]struct{}{}
+			idx.labels[k] = values
+		}
+		ids, ok := values[v]
+		if !ok {
+			ids = map[string]struct{}{}
+			values[v] = ids
+		}
+		ids[svc.ID] = struct{}{}
+	}
+	idx.services[svc.ID] = labels
+}
+
+// remove 删除服务的标签索引
+func (idx *serviceLabelIndex) remove(svcID string) {
+	idx.lock.Lock()
+	defer idx.lock.Unlock()
+
+	idx.removeLocked(svcID)
+}
+
+func (idx *serviceLabelIndex) removeLocked(svcID string) {
+	labels, ok := idx.services[svcID]
+	if !ok {
+		return
+	}
+	delete(idx.services, svcID)
+	for k, v := range labels {
+		values := idx.labels[k]
+		delete(values[v], svcID)
+		if len(values[v]) == 0 {
+			delete(values, v)
+		}
+		if len(values) == 0 {
+			delete(idx.labels, k)
+		}
+	}
+}
+
+// candidates 根据选择器中要求标签存在的条件查找候选服务，返回的服务仍然需要匹配完整的选择器
+// 选择器中没有这类条件时无法使用索引，返回 false
+func (idx *serviceLabelIndex) candidates(selector model.LabelSelector) (map[string]struct{}, bool) {
+	idx.lock.RLock()
+	defer idx.lock.RUnlock()
+
+	var ret map[string]struct{}
+	indexed := false
+	for _, requirement := range selector {
+		if !requirement.Positive() {
+			continue
+		}
+		indexed = true
+		ids := idx.lookupLocked(requirement)
+		if ret == nil {
+			ret = ids
+		} else {
+			for id := range ret {
+				if _, ok := ids[id]; !ok {
+					delete(ret, id)
+				}
+			}
+		}
+		if len(ret) == 0 {
+			return ret, true
+		}
+	}
+	return ret, indexed
+}
+
+// lookupLocked 查找满足单个条件的服务，返回新的集合
+func (idx *serviceLabelIndex) lookupLocked(requirement model.LabelRequirement) map[string]struct{} {
+	ret := map[string]struct{}{}
+	values := idx.labels[requirement.Key]
+	if requirement.Operator == model.LabelOpExists {
+		for _, ids := range values {
+			for id := range ids {
+				ret[id] = struct{}{}
+			}
+		}
+		return ret
+	}
+	for _, v := range requirement.Values {
+		for id := range values[v] {
+			ret[id] = struct{}{}
+		}
+	}
+	return ret
+}
@@ -54,6 +54,8 @@ func (sc *serviceCache) GetServicesByFilter(serviceFilters *types.ServiceArgs,
 	// 如果具有名字条件，并且不是模糊查询，直接获取对应命名空间下面的服务，并检查是否匹配所有条件
 	if serviceFilters.Name != "" && !serviceFilters.WildName && !serviceFilters.WildNamespace {
 		matchServices, err = sc.getServicesFromCacheByName(serviceFilters, instanceFilters, offset, limit)
+	} else if ids, ok := sc.labelIndex.candidates(serviceFilters.LabelSelector); ok {
+		matchServices = sc.getServicesByLabelIndex(ids, serviceFilters, instanceFilters)
 	} else {
 		matchServices, err = sc.getServicesByIteratingCache(serviceFilters, instanceFilters, offset, limit)
 	}
@@ -174,14 +176,14 @@ func (sc *serviceCache) getServicesFromCacheByName(svcArgs *types.ServiceArgs, i
 	if svcArgs.Namespace != "" {
 		svc := sc.GetServiceByName(svcArgs.Name, svcArgs.Namespace)
 		if svc != nil && !svc.IsAlias() && matchService(svc, svcArgs.Filter, svcArgs.Metadata, false, false) &&
-			sc.matchInstance(svc, instArgs) {
+			svcArgs.LabelSelector.Matches(svc.Meta) && sc.matchInstance(svc, instArgs) {
 			res = append(res, svc)
 		}
 	} else {
 		for _, namespace := range sc.GetAllNamespaces() {
 			svc := sc.GetServiceByName(svcArgs.Name, namespace)
 			if svc != nil && !svc.IsAlias() && matchService(svc, svcArgs.Filter, svcArgs.Metadata, false, false) &&
-				sc.matchInstance(svc, instArgs) {
+				svcArgs.LabelSelector.Matches(svc.Meta) && sc.matchInstance(svc, instArgs) {
 				res = append(res, svc)
 			}
 		}
@@ -304,7 +306,7 @@ func (sc *serviceCache) getServicesByIteratingCache(
 				return
 			}
 		}
-		if !sc.matchInstance(svc, instArgs) {
+		if !svcArgs.LabelSelector.Matches(svc.Meta) || !sc.matchInstance(svc, instArgs) {
 			return
 		}
 		res = append(res, svc)
@@ -327,3 +329,30 @@ func (sc *serviceCache) getServicesByIteratingCache(
 	}
 	return res, nil
 }
+
+// getServicesByLabelIndex 通过标签索引得到的候选服务，检查是否匹配所有条件
+func (sc *serviceCache) getServicesByLabelIndex(ids map[string]struct{}, svcArgs *types.ServiceArgs,
+	instArgs *store.InstanceArgs) []*model.Service {
+	res := make([]*model.Service, 0, len(ids))
+	for id := range ids {
+		svc, ok := sc.ids.Load(id)
+		if !ok || svc.IsAlias() {
+			continue
+		}
+		// 非模糊查询时，遍历命名空间的方式不会检查命名空间以及服务名，这里需要单独检查
+		if svcArgs.Namespace != "" && !svcArgs.WildNamespace && svc.Namespace != svcArgs.Namespace {
+			continue
+		}
+		if svcArgs.Name != "" && !svcArgs.WildName && svc.Name != svcArgs.Name {
+			continue
+		}
+		if !matchService(svc, svcArgs.Filter, svcArgs.Metadata, svcArgs.WildName, svcArgs.WildNamespace) {
+			continue
+		}
+		if !svcArgs.LabelSelector.Matches(svc.Meta) || !sc.matchInstance(svc, instArgs) {
+			continue
+		}
+		res = append(res, svc)
+	}
+	return res
+}
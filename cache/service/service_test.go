@@ -22,6 +22,7 @@ import (
 	"fmt"
 	"math/rand"
 	"reflect"
+	"sort"
 	"testing"
 	"time"
 
@@ -331,6 +332,37 @@ func TestGetServiceByName(t *testing.T) {
 	})
 }
 
+// TestServiceCache_ResolveService 根据服务名获取服务，别名沿着别名链解析为源服务
+func TestServiceCache_ResolveService(t *testing.T) {
+	ctl, _, sc, _ := newTestServiceCache(t)
+	defer ctl.Finish()
+
+	_ = sc.Clear()
+	newService := func(id, name, namespace, reference string) *model.Service {
+		return &model.Service{ID: id, Name: name, Namespace: namespace, Reference: reference, Valid: true,
+			Revision: utils.NewUUID()}
+	}
+	sc.setServices(map[string]*model.Service{
+		"source":    newService("source", "source-svc", "ns-a", ""),
+		"alias":     newService("alias", "alias-svc", "ns-b", "source"),
+		"chain":     newService("chain", "chain-svc", "ns-c", "alias"),
+		"broken":    newService("broken", "broken-svc", "ns-c", "not-exist"),
+		"cycle-one": newService("cycle-one", "cycle-one", "ns-c", "cycle-two"),
+		"cycle-two": newService("cycle-two", "cycle-two", "ns-c", "cycle-one"),
+	})
+
+	assert.Equal(t, "source", sc.ResolveService("source-svc", "ns-a").ID)
+	// 跨命名空间的别名
+	assert.Equal(t, "source", sc.ResolveService("alias-svc", "ns-b").ID)
+	assert.Equal(t, "source", sc.GetAliasFor("alias-svc", "ns-b").ID)
+	// 别名链
+	assert.Equal(t, "source", sc.ResolveService("chain-svc", "ns-c").ID)
+	assert.Nil(t, sc.ResolveService("broken-svc", "ns-c"))
+	assert.Nil(t, sc.ResolveService("cycle-one", "ns-c"))
+	assert.Nil(t, sc.ResolveService("not-exist", "ns-c"))
+	assert.Nil(t, sc.GetAliasFor("source-svc", "ns-a"))
+}
+
 // TestServiceCache_GetServiceByID 根据服务ID获取服务缓存信息
 func TestServiceCache_GetServiceByID(t *testing.T) {
 	ctl, _, sc, _ := newTestServiceCache(t)
@@ -436,6 +468,62 @@ func TestServiceCache_GetServicesByFilter(t *testing.T) {
 	})
 }
 
+// TestServiceCache_LabelIndex 通过服务标签的倒排索引查询服务
+func TestServiceCache_LabelIndex(t *testing.T) {
+	ctl, _, sc, _ := newTestServiceCache(t)
+	defer ctl.Finish()
+
+	_ = sc.Clear()
+	newService := func(id, namespace string, meta map[string]string) *model.Service {
+		return &model.Service{ID: id, Name: id, Namespace: namespace, Meta: meta, Valid: true,
+			Revision: utils.NewUUID()}
+	}
+	sc.setServices(map[string]*model.Service{
+		"pay-prod":  newService("pay-prod", "prod", map[string]string{"team": "payments", "env": "prod"}),
+		"pay-test":  newService("pay-test", "test", map[string]string{"team": "payments", "env": "test"}),
+		"bill-prod": newService("bill-prod", "prod", map[string]string{"team": "billing", "env": "prod"}),
+		"no-label":  newService("no-label", "prod", nil),
+	})
+
+	query := func(expr string, svcArgs *types.ServiceArgs) []string {
+		selector, err := model.ParseLabelSelector(expr)
+		assert.NoError(t, err)
+		ids, ok := sc.labelIndex.candidates(selector)
+		assert.True(t, ok)
+		svcArgs.LabelSelector = selector
+		var ret []string
+		for _, svc := range sc.getServicesByLabelIndex(ids, svcArgs, nil) {
+			ret = append(ret, svc.ID)
+		}
+		sort.Strings(ret)
+		return ret
+	}
+
+	assert.Equal(t, []string{"pay-prod", "pay-test"}, query("team=payments", &types.ServiceArgs{}))
+	assert.Equal(t, []string{"bill-prod", "pay-prod", "pay-test"},
+		query("team in (payments,billing)", &types.ServiceArgs{}))
+	assert.Equal(t, []string{"pay-prod"}, query("team=payments,env!=test", &types.ServiceArgs{}))
+	assert.Equal(t, []string{"bill-prod", "pay-prod"}, query("team,env=prod", &types.ServiceArgs{}))
+	assert.Equal(t, []string{"pay-prod"}, query("team=payments", &types.ServiceArgs{Namespace: "prod"}))
+	assert.Nil(t, query("team=unknown", &types.ServiceArgs{}))
+
+	// 只有否定条件时无法使用索引
+	selector, err := model.ParseLabelSelector("team!=payments")
+	assert.NoError(t, err)
+	_, ok := sc.labelIndex.candidates(selector)
+	assert.False(t, ok)
+
+	// 服务标签变更以及服务删除时更新索引
+	deleted := newService("pay-test", "test", nil)
+	deleted.Valid = false
+	sc.setServices(map[string]*model.Service{
+		"pay-prod": newService("pay-prod", "prod", map[string]string{"team": "billing", "env": "prod"}),
+		"pay-test": deleted,
+	})
+	assert.Nil(t, query("team=payments", &types.ServiceArgs{}))
+	assert.Equal(t, []string{"bill-prod", "pay-prod"}, query("team=billing", &types.ServiceArgs{}))
+}
+
 func TestServiceCache_NamespaceCount(t *testing.T) {
 	ctl, _, sc, ic := newTestServiceCache(t)
 	defer ctl.Finish()
@@ -195,6 +195,10 @@ const (
 	EmptyAutToken   = uint32(apimodel.Code_EmptyAutToken)
 	TokenDisabled   = uint32(apimodel.Code_TokenDisabled)
 	TokenNotExisted = uint32(apimodel.Code_TokenNotExisted)
+	// TokenExpired token 已经过期，规范中暂未定义该错误码
+	TokenExpired = uint32(401005)
+	// TokenRateLimit 鉴权 token 被限流，规范中暂未定义该错误码
+	TokenRateLimit = uint32(429003)
 
 	AuthTokenVerifyException = uint32(apimodel.Code_AuthTokenForbidden)
 	OperationRoleException   = uint32(apimodel.Code_OperationRoleForbidden)
@@ -353,6 +357,8 @@ var code2info = map[uint32]string{
 	SubAccountExisted:         "some sub-account existed in owner",
 	InvalidUserID:             "invalid user-id",
 	TokenNotExisted:           "token not existed",
+	TokenExpired:              "token already expired",
+	TokenRateLimit:            "server limit the token access",
 
 	NotAllowModifyDefaultStrategyPrincipal: "not allow modify default strategy principal",
 	NotAllowModifyOwnerDefaultStrategy:     "not allow modify main account default strategy",
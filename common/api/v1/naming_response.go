@@ -151,6 +151,14 @@ func NewServiceResponse(code apimodel.Code, service *apiservice.Service) *apiser
 	}
 }
 
+// NewServiceRespWithError 创建带自定义error的服务回复
+func NewServiceRespWithError(code apimodel.Code, err error, service *apiservice.Service) *apiservice.Response {
+	resp := NewServiceResponse(code, service)
+	resp.Info.Value += " : " + err.Error()
+
+	return resp
+}
+
 // 创建带别名信息的答复
 func NewServiceAliasResponse(code apimodel.Code, alias *apiservice.ServiceAlias) *apiservice.Response {
 	resp := NewResponse(code)
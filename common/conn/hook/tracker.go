@@ -0,0 +1,163 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+package connhook
+
+import (
+	"net"
+	"sort"
+	"sync"
+	"time"
+)
+
+var (
+	trackerLock sync.RWMutex
+	trackers    = map[string]*ConnTracker{}
+)
+
+// GetConnTracker 获取协议对应的连接记录，apiserver 未启动时返回 nil
+func GetConnTracker(protocol string) *ConnTracker {
+	trackerLock.RLock()
+	defer trackerLock.RUnlock()
+	return trackers[protocol]
+}
+
+// RemoveConnTracker 删除协议对应的连接记录
+func RemoveConnTracker(protocol string) {
+	trackerLock.Lock()
+	defer trackerLock.Unlock()
+	delete(trackers, protocol)
+}
+
+// ListConnTrackers 获取全部协议的连接记录，按照协议名排序
+func ListConnTrackers() []*ConnTracker {
+	trackerLock.RLock()
+	defer trackerLock.RUnlock()
+	ret := make([]*ConnTracker, 0, len(trackers))
+	for _, tracker := range trackers {
+		ret = append(ret, tracker)
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		return ret[i].protocol < ret[j].protocol
+	})
+	return ret
+}
+
+// ConnTracker 记录 apiserver 当前持有的连接以及连接上的订阅，用于运维接口查看连接详情
+type ConnTracker struct {
+	protocol string
+	lock     sync.RWMutex
+	conns    map[string]*TrackedConn
+}
+
+// NewConnTracker 创建连接记录并按照协议注册，同一个协议重复创建时覆盖之前的记录
+func NewConnTracker(protocol string) *ConnTracker {
+	tracker := &ConnTracker{
+		protocol: protocol,
+		conns:    map[string]*TrackedConn{},
+	}
+	trackerLock.Lock()
+	defer trackerLock.Unlock()
+	trackers[protocol] = tracker
+	return tracker
+}
+
+// Protocol 连接记录所属的协议
+func (t *ConnTracker) Protocol() string {
+	return t.protocol
+}
+
+// OnAccept call when net.Conn accept
+func (t *ConnTracker) OnAccept(conn net.Conn) {
+	address := conn.RemoteAddr().String()
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		host = address
+	}
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.conns[address] = &TrackedConn{
+		Address:       address,
+		Host:          host,
+		ConnectedAt:   time.Now(),
+		subscriptions: map[string]struct{}{},
+	}
+}
+
+// OnRelease call when net.Conn release
+func (t *ConnTracker) OnRelease(conn net.Conn) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	delete(t.conns, conn.RemoteAddr().String())
+}
+
+// OnClose call when net.Listener close
+func (t *ConnTracker) OnClose() {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.conns = map[string]*TrackedConn{}
+}
+
+// Subscribe 记录连接上订阅的资源，连接不存在时忽略
+func (t *ConnTracker) Subscribe(address, resource string) {
+	t.lock.RLock()
+	conn, ok := t.conns[address]
+	t.lock.RUnlock()
+	if !ok {
+		return
+	}
+	conn.lock.Lock()
+	defer conn.lock.Unlock()
+	conn.subscriptions[resource] = struct{}{}
+}
+
+// Conns 获取当前的连接，host 不为空时只返回该 host 的连接，按照建立连接的时间排序
+func (t *ConnTracker) Conns(host string) []*TrackedConn {
+	t.lock.RLock()
+	ret := make([]*TrackedConn, 0, len(t.conns))
+	for _, conn := range t.conns {
+		if host == "" || conn.Host == host {
+			ret = append(ret, conn)
+		}
+	}
+	t.lock.RUnlock()
+	sort.Slice(ret, func(i, j int) bool {
+		return ret[i].ConnectedAt.Before(ret[j].ConnectedAt)
+	})
+	return ret
+}
+
+// TrackedConn 一个客户端连接
+type TrackedConn struct {
+	Address     string
+	Host        string
+	ConnectedAt time.Time
+
+	lock          sync.RWMutex
+	subscriptions map[string]struct{}
+}
+
+// Subscriptions 连接上订阅的资源，按照名称排序
+func (c *TrackedConn) Subscriptions() []string {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	ret := make([]string, 0, len(c.subscriptions))
+	for resource := range c.subscriptions {
+		ret = append(ret, resource)
+	}
+	sort.Strings(ret)
+	return ret
+}
@@ -0,0 +1,59 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+package connhook
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConnTracker(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	tracker := NewConnTracker("test-tracker")
+	defer RemoveConnTracker("test-tracker")
+	lis = NewHookListener(lis, tracker)
+	defer lis.Close()
+	assert.Equal(t, tracker, GetConnTracker("test-tracker"))
+
+	client, err := net.Dial("tcp", lis.Addr().String())
+	assert.NoError(t, err)
+	defer client.Close()
+	server, err := lis.Accept()
+	assert.NoError(t, err)
+
+	address := client.LocalAddr().String()
+	conns := tracker.Conns("")
+	assert.Len(t, conns, 1)
+	assert.Equal(t, address, conns[0].Address)
+	assert.Equal(t, "127.0.0.1", conns[0].Host)
+	assert.WithinDuration(t, time.Now(), conns[0].ConnectedAt, time.Second)
+	assert.Len(t, tracker.Conns("127.0.0.2"), 0)
+
+	tracker.Subscribe(address, "INSTANCE:default/svc-b")
+	tracker.Subscribe(address, "INSTANCE:default/svc-a")
+	tracker.Subscribe(address, "INSTANCE:default/svc-a")
+	// 不存在的连接直接忽略
+	tracker.Subscribe("127.0.0.1:1", "INSTANCE:default/svc-c")
+	assert.Equal(t, []string{"INSTANCE:default/svc-a", "INSTANCE:default/svc-b"}, conns[0].Subscriptions())
+
+	assert.NoError(t, server.Close())
+	assert.Len(t, tracker.Conns(""), 0)
+}
@@ -33,6 +33,16 @@ const (
 	CacheClientEventTopic = "cache_client_event"
 	// CacheNamespaceEventTopic record cache occur namespace add/update/del event
 	CacheNamespaceEventTopic = "cache_namespace_event"
+	// CacheUserEventTopic record cache occur user owner change/del event
+	CacheUserEventTopic = "cache_user_event"
+	// CacheStrategyEventTopic record cache occur auth strategy set change event
+	CacheStrategyEventTopic = "cache_strategy_event"
+	// CacheUserGroupEventTopic record cache occur user group set change event
+	CacheUserGroupEventTopic = "cache_user_group_event"
+	// CacheServiceEventTopic record cache occur service set change event
+	CacheServiceEventTopic = "cache_service_event"
+	// AuthRecordEventTopic record auth strategy/role/user/group operation event
+	AuthRecordEventTopic = "auth_record_event"
 	// ClientEventTopic .
 	ClientEventTopic = "client_event"
 )
@@ -69,3 +79,29 @@ type CacheNamespaceEvent struct {
 	Item      *model.Namespace
 	EventType EventType
 }
+
+type CacheUserEvent struct {
+	OldItem   *model.User
+	Item      *model.User
+	EventType EventType
+}
+
+// AuthRecordEvent 鉴权策略、角色、用户、用户组以及 token 的操作记录
+type AuthRecordEvent struct {
+	Entry *model.RecordEntry
+}
+
+// CacheStrategyEvent 鉴权策略集合发生变化，Version 为变化后的策略集合版本
+type CacheStrategyEvent struct {
+	Version uint64
+}
+
+// CacheUserGroupEvent 用户组集合发生变化，Version 为变化后的用户组集合版本
+type CacheUserGroupEvent struct {
+	Version uint64
+}
+
+// CacheServiceEvent 服务集合发生变化，Version 为变化后的服务集合版本
+type CacheServiceEvent struct {
+	Version uint64
+}
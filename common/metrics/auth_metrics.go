@@ -0,0 +1,338 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package metrics
+
+import (
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/polarismesh/polaris/common/utils"
+)
+
+const (
+	labelOwnerCacheResult = "result"
+	labelDecisionResult   = "result"
+	labelQuotaName        = "quota"
+	labelQuotaResult      = "result"
+	labelBreakGlassResult = "result"
+	labelPinDecision      = "decision"
+	labelPrincipalType    = "principal_type"
+	labelCountBucket      = "bucket"
+	labelOwnerChainReason = "reason"
+)
+
+// AuthCountBuckets 鉴权策略规模分布的分桶上界, 超出最大上界的计入 +Inf
+var AuthCountBuckets = []int{0, 1, 5, 10, 50, 100, 500}
+
+// AuthStrategyStats 鉴权策略的规模统计, 分布的 key 为 AuthCountBucket 计算的分桶
+type AuthStrategyStats struct {
+	// StrategyTotal 鉴权策略总数
+	StrategyTotal int64
+	// ResourceTotal 鉴权策略与资源的关联关系总数
+	ResourceTotal int64
+	// UserStrategies 用户关联的鉴权策略数量分布
+	UserStrategies map[string]int64
+	// GroupStrategies 用户组关联的鉴权策略数量分布
+	GroupStrategies map[string]int64
+	// StrategyResources 鉴权策略关联的资源数量分布
+	StrategyResources map[string]int64
+}
+
+// AuthCountBucket 计算数量所在的分桶, 即第一个不小于 count 的上界
+func AuthCountBucket(count int) string {
+	for _, bound := range AuthCountBuckets {
+		if count <= bound {
+			return strconv.Itoa(bound)
+		}
+	}
+	return "+Inf"
+}
+
+var (
+	// ownerCacheAccess 鉴权模块 principal owner 解析缓存的访问情况
+	ownerCacheAccess *prometheus.CounterVec
+	// decisionCacheAccess 鉴权模块鉴权决策缓存的访问情况
+	decisionCacheAccess *prometheus.CounterVec
+	// ownerCacheEviction 鉴权模块 principal owner 解析缓存的淘汰次数
+	ownerCacheEviction prometheus.Counter
+	// ownerChainReject 鉴权模块解析 owner 链时因为存在环或者超出最大深度而拒绝的次数
+	ownerChainReject *prometheus.CounterVec
+	// quotaExceed 鉴权模块配额超出的次数
+	quotaExceed *prometheus.CounterVec
+	// breakGlassUse 鉴权模块 break-glass token 的使用次数
+	breakGlassUse *prometheus.CounterVec
+	// expireGraceAllow 鉴权模块资源关联关系过期后在宽限期内放通的次数
+	expireGraceAllow prometheus.Counter
+	// decisionPinHit 鉴权模块置顶决策的命中次数
+	decisionPinHit *prometheus.CounterVec
+	// strategyTotal 鉴权策略总数
+	strategyTotal prometheus.Gauge
+	// strategyResourceTotal 鉴权策略与资源的关联关系总数
+	strategyResourceTotal prometheus.Gauge
+	// principalStrategyDist 每个 principal 关联的鉴权策略数量分布
+	principalStrategyDist *prometheus.GaugeVec
+	// strategyResourceDist 每个鉴权策略关联的资源数量分布
+	strategyResourceDist *prometheus.GaugeVec
+)
+
+func registerAuthMetrics() {
+	ownerCacheAccess = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "auth_owner_cache_access",
+		Help: "polaris auth principal owner resolution cache access, split by hit or miss",
+		ConstLabels: map[string]string{
+			LabelServerNode: utils.LocalHost,
+		},
+	}, []string{labelOwnerCacheResult})
+
+	decisionCacheAccess = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "auth_decision_cache_access",
+		Help: "polaris auth decision cache access, split by hit or miss",
+		ConstLabels: map[string]string{
+			LabelServerNode: utils.LocalHost,
+		},
+	}, []string{labelDecisionResult})
+
+	ownerCacheEviction = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "auth_owner_cache_eviction",
+		Help: "polaris auth principal owner resolution cache eviction by size limit",
+		ConstLabels: map[string]string{
+			LabelServerNode: utils.LocalHost,
+		},
+	})
+
+	ownerChainReject = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "auth_owner_chain_reject",
+		Help: "polaris auth user owner chain resolution reject, split by cycle or too deep",
+		ConstLabels: map[string]string{
+			LabelServerNode: utils.LocalHost,
+		},
+	}, []string{labelOwnerChainReason})
+
+	quotaExceed = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "auth_quota_exceed",
+		Help: "polaris auth quota exceed, split by warn in grace period or reject",
+		ConstLabels: map[string]string{
+			LabelServerNode: utils.LocalHost,
+		},
+	}, []string{labelQuotaName, labelQuotaResult})
+
+	breakGlassUse = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "auth_break_glass_use",
+		Help: "polaris auth break-glass token use, split by allow or invalid token",
+		ConstLabels: map[string]string{
+			LabelServerNode: utils.LocalHost,
+		},
+	}, []string{labelBreakGlassResult})
+
+	expireGraceAllow = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "auth_expire_grace_allow",
+		Help: "polaris auth operation allowed by expired strategy resource link within the grace window",
+		ConstLabels: map[string]string{
+			LabelServerNode: utils.LocalHost,
+		},
+	})
+
+	decisionPinHit = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "auth_decision_pin_hit",
+		Help: "polaris auth decision pin hit, split by allow or deny",
+		ConstLabels: map[string]string{
+			LabelServerNode: utils.LocalHost,
+		},
+	}, []string{labelPinDecision})
+
+	strategyTotal = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "auth_strategy_count",
+		Help: "polaris auth strategy total number",
+		ConstLabels: map[string]string{
+			LabelServerNode: utils.LocalHost,
+		},
+	})
+
+	strategyResourceTotal = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "auth_strategy_resource_count",
+		Help: "polaris auth strategy resource link total number",
+		ConstLabels: map[string]string{
+			LabelServerNode: utils.LocalHost,
+		},
+	})
+
+	principalStrategyDist = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "auth_principal_strategy_distribution",
+		Help: "number of principals split by the upper bound of linked strategy count",
+		ConstLabels: map[string]string{
+			LabelServerNode: utils.LocalHost,
+		},
+	}, []string{labelPrincipalType, labelCountBucket})
+
+	strategyResourceDist = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "auth_strategy_resource_distribution",
+		Help: "number of strategies split by the upper bound of linked resource count",
+		ConstLabels: map[string]string{
+			LabelServerNode: utils.LocalHost,
+		},
+	}, []string{labelCountBucket})
+
+	_ = GetRegistry().Register(ownerCacheAccess)
+	_ = GetRegistry().Register(decisionCacheAccess)
+	_ = GetRegistry().Register(ownerCacheEviction)
+	_ = GetRegistry().Register(ownerChainReject)
+	_ = GetRegistry().Register(quotaExceed)
+	_ = GetRegistry().Register(breakGlassUse)
+	_ = GetRegistry().Register(expireGraceAllow)
+	_ = GetRegistry().Register(decisionPinHit)
+	_ = GetRegistry().Register(strategyTotal)
+	_ = GetRegistry().Register(strategyResourceTotal)
+	_ = GetRegistry().Register(principalStrategyDist)
+	_ = GetRegistry().Register(strategyResourceDist)
+}
+
+// ReportOwnerCacheHit 记录 owner 解析缓存命中
+func ReportOwnerCacheHit() {
+	if ownerCacheAccess == nil {
+		return
+	}
+	ownerCacheAccess.With(map[string]string{labelOwnerCacheResult: "hit"}).Inc()
+}
+
+// ReportOwnerCacheMiss 记录 owner 解析缓存未命中
+func ReportOwnerCacheMiss() {
+	if ownerCacheAccess == nil {
+		return
+	}
+	ownerCacheAccess.With(map[string]string{labelOwnerCacheResult: "miss"}).Inc()
+}
+
+// ReportDecisionCacheHit 记录鉴权决策缓存命中
+func ReportDecisionCacheHit() {
+	if decisionCacheAccess == nil {
+		return
+	}
+	decisionCacheAccess.With(map[string]string{labelDecisionResult: "hit"}).Inc()
+}
+
+// ReportDecisionCacheMiss 记录鉴权决策缓存未命中
+func ReportDecisionCacheMiss() {
+	if decisionCacheAccess == nil {
+		return
+	}
+	decisionCacheAccess.With(map[string]string{labelDecisionResult: "miss"}).Inc()
+}
+
+// ReportOwnerCacheEviction 记录 owner 解析缓存因容量限制淘汰的条目
+func ReportOwnerCacheEviction() {
+	if ownerCacheEviction == nil {
+		return
+	}
+	ownerCacheEviction.Inc()
+}
+
+// ReportOwnerChainReject 记录解析 owner 链时的拒绝，reason 为 cycle 或者 depth
+func ReportOwnerChainReject(reason string) {
+	if ownerChainReject == nil {
+		return
+	}
+	ownerChainReject.With(map[string]string{labelOwnerChainReason: reason}).Inc()
+}
+
+// ReportQuotaExceed 记录配额超出，reject 为 false 表示处于宽限期内仅提示
+func ReportQuotaExceed(quota string, reject bool) {
+	if quotaExceed == nil {
+		return
+	}
+	result := "warn"
+	if reject {
+		result = "reject"
+	}
+	quotaExceed.With(map[string]string{labelQuotaName: quota, labelQuotaResult: result}).Inc()
+}
+
+// ReportBreakGlassUse 记录 break-glass token 的使用，allow 为 false 表示 token 校验失败
+func ReportBreakGlassUse(allow bool) {
+	if breakGlassUse == nil {
+		return
+	}
+	result := "invalid"
+	if allow {
+		result = "allow"
+	}
+	breakGlassUse.With(map[string]string{labelBreakGlassResult: result}).Inc()
+}
+
+// ReportExpireGraceAllow 记录资源关联关系过期后在宽限期内的放通
+func ReportExpireGraceAllow() {
+	if expireGraceAllow == nil {
+		return
+	}
+	expireGraceAllow.Inc()
+}
+
+// ReportDecisionPinHit 记录置顶决策的命中
+func ReportDecisionPinHit(allow bool) {
+	if decisionPinHit == nil {
+		return
+	}
+	decision := "deny"
+	if allow {
+		decision = "allow"
+	}
+	decisionPinHit.With(map[string]string{labelPinDecision: decision}).Inc()
+}
+
+// ReportAuthStrategyStats 上报鉴权策略的规模统计, 未出现的分桶置为 0
+func ReportAuthStrategyStats(stats AuthStrategyStats) {
+	if strategyTotal == nil {
+		return
+	}
+	strategyTotal.Set(float64(stats.StrategyTotal))
+	strategyResourceTotal.Set(float64(stats.ResourceTotal))
+
+	buckets := make([]string, 0, len(AuthCountBuckets)+1)
+	for _, bound := range AuthCountBuckets {
+		buckets = append(buckets, strconv.Itoa(bound))
+	}
+	buckets = append(buckets, "+Inf")
+	for _, bucket := range buckets {
+		principalStrategyDist.With(map[string]string{labelPrincipalType: "user", labelCountBucket: bucket}).
+			Set(float64(stats.UserStrategies[bucket]))
+		principalStrategyDist.With(map[string]string{labelPrincipalType: "group", labelCountBucket: bucket}).
+			Set(float64(stats.GroupStrategies[bucket]))
+		strategyResourceDist.With(map[string]string{labelCountBucket: bucket}).
+			Set(float64(stats.StrategyResources[bucket]))
+	}
+}
+
+// GetAuthStrategyTotal 获取鉴权策略总数指标
+func GetAuthStrategyTotal() prometheus.Gauge {
+	return strategyTotal
+}
+
+// GetAuthStrategyResourceTotal 获取鉴权策略资源关联关系总数指标
+func GetAuthStrategyResourceTotal() prometheus.Gauge {
+	return strategyResourceTotal
+}
+
+// GetAuthPrincipalStrategyDist 获取 principal 关联的鉴权策略数量分布指标
+func GetAuthPrincipalStrategyDist() *prometheus.GaugeVec {
+	return principalStrategyDist
+}
+
+// GetAuthStrategyResourceDist 获取鉴权策略关联的资源数量分布指标
+func GetAuthStrategyResourceDist() *prometheus.GaugeVec {
+	return strategyResourceDist
+}
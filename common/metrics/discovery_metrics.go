@@ -23,6 +23,40 @@ import (
 	"github.com/polarismesh/polaris/common/utils"
 )
 
+const (
+	labelStaleInstanceAction = "action"
+	labelFederationPeer      = "peer"
+	labelFederationAction    = "action"
+)
+
+const (
+	// StaleInstanceDelete 长时间不健康的实例被删除
+	StaleInstanceDelete = "delete"
+	// StaleInstanceDryRun 试运行模式下，长时间不健康的实例只记录而不删除
+	StaleInstanceDryRun = "dry_run"
+	// StaleInstanceFail 长时间不健康的实例删除失败
+	StaleInstanceFail = "fail"
+)
+
+// staleInstanceCleaned 清理长时间不健康实例的数量
+var staleInstanceCleaned *prometheus.CounterVec
+
+const (
+	// FederationCreate 新增的副本
+	FederationCreate = "create"
+	// FederationUpdate 更新的副本
+	FederationUpdate = "update"
+	// FederationDelete 归属集群中已经不存在而被删除的副本
+	FederationDelete = "delete"
+	// FederationConflict 与本集群或者其他集群的数据冲突而被丢弃的副本
+	FederationConflict = "conflict"
+	// FederationFail 写入失败的副本
+	FederationFail = "fail"
+)
+
+// federationReplicated 跨集群同步的副本数量
+var federationReplicated *prometheus.CounterVec
+
 func registerDiscoveryMetrics() {
 	clientInstanceTotal = prometheus.NewGauge(prometheus.GaugeOpts{
 		Name: "client_total",
@@ -96,6 +130,22 @@ func registerDiscoveryMetrics() {
 		},
 	}, []string{LabelNamespace, LabelService})
 
+	staleInstanceCleaned = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "stale_instance_cleaned",
+		Help: "polaris instances unhealthy longer than retention period, split by delete, dry_run or fail",
+		ConstLabels: map[string]string{
+			LabelServerNode: utils.LocalHost,
+		},
+	}, []string{labelStaleInstanceAction})
+
+	federationReplicated = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "federation_replicated",
+		Help: "polaris instances replicated from peer clusters, split by create, update, delete, conflict or fail",
+		ConstLabels: map[string]string{
+			LabelServerNode: utils.LocalHost,
+		},
+	}, []string{labelFederationPeer, labelFederationAction})
+
 	_ = GetRegistry().Register(serviceCount)
 	_ = GetRegistry().Register(serviceOnlineCount)
 	_ = GetRegistry().Register(serviceAbnormalCount)
@@ -105,6 +155,27 @@ func registerDiscoveryMetrics() {
 	_ = GetRegistry().Register(instanceAbnormalCount)
 	_ = GetRegistry().Register(instanceIsolateCount)
 	_ = GetRegistry().Register(clientInstanceTotal)
+	_ = GetRegistry().Register(staleInstanceCleaned)
+	_ = GetRegistry().Register(federationReplicated)
+}
+
+// ReportStaleInstanceCleaned 记录清理长时间不健康实例的数量
+func ReportStaleInstanceCleaned(action string, count int) {
+	if staleInstanceCleaned == nil {
+		return
+	}
+	staleInstanceCleaned.With(map[string]string{labelStaleInstanceAction: action}).Add(float64(count))
+}
+
+// ReportFederationReplicated 记录跨集群同步的副本数量
+func ReportFederationReplicated(peer, action string, count int) {
+	if federationReplicated == nil || count == 0 {
+		return
+	}
+	federationReplicated.With(map[string]string{
+		labelFederationPeer:   peer,
+		labelFederationAction: action,
+	}).Add(float64(count))
 }
 
 func GetClientInstanceTotal() prometheus.Gauge {
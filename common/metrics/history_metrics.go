@@ -0,0 +1,85 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/polarismesh/polaris/common/utils"
+)
+
+const (
+	labelHistoryPrunePolicy = "policy"
+	labelHistorySink        = "sink"
+	labelHistoryAction      = "action"
+)
+
+const (
+	// HistoryDeadLetterEnqueue 写入失败的操作记录进入死信队列
+	HistoryDeadLetterEnqueue = "enqueue"
+	// HistoryDeadLetterSpill 死信队列已满，操作记录写入本地磁盘
+	HistoryDeadLetterSpill = "spill"
+	// HistoryDeadLetterDrop 死信队列已满且未开启本地磁盘写入，操作记录被丢弃
+	HistoryDeadLetterDrop = "drop"
+	// HistoryDeadLetterDrain 死信队列中的操作记录重新写入成功
+	HistoryDeadLetterDrain = "drain"
+)
+
+var (
+	// historyPruned 按照保留策略清理的操作记录条数
+	historyPruned *prometheus.CounterVec
+	// historyDeadLetter 历史记录插件死信队列的操作记录条数
+	historyDeadLetter *prometheus.CounterVec
+)
+
+func registerHistoryMetrics() {
+	historyPruned = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "history_record_pruned",
+		Help: "polaris operation records pruned by retention policy, split by age or count",
+		ConstLabels: map[string]string{
+			LabelServerNode: utils.LocalHost,
+		},
+	}, []string{labelHistoryPrunePolicy})
+
+	historyDeadLetter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "history_record_dead_letter",
+		Help: "polaris operation records failed to write to history sink, split by enqueue, spill, drop or drain",
+		ConstLabels: map[string]string{
+			LabelServerNode: utils.LocalHost,
+		},
+	}, []string{labelHistorySink, labelHistoryAction})
+
+	_ = GetRegistry().Register(historyPruned)
+	_ = GetRegistry().Register(historyDeadLetter)
+}
+
+// ReportHistoryPruned 记录按照保留策略清理的操作记录条数
+func ReportHistoryPruned(policy string, count uint64) {
+	if historyPruned == nil {
+		return
+	}
+	historyPruned.With(map[string]string{labelHistoryPrunePolicy: policy}).Add(float64(count))
+}
+
+// ReportHistoryDeadLetter 记录历史记录插件死信队列的操作记录条数
+func ReportHistoryDeadLetter(sink, action string, count int) {
+	if historyDeadLetter == nil {
+		return
+	}
+	historyDeadLetter.With(map[string]string{labelHistorySink: sink, labelHistoryAction: action}).Add(float64(count))
+}
@@ -21,6 +21,9 @@ import (
 	"context"
 
 	apisecurity "github.com/polarismesh/specification/source/go/api/v1/security"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/polarismesh/polaris/common/utils"
 )
 
 type acquireContextOption func(authCtx *AcquireContext)
@@ -49,6 +52,8 @@ type AcquireContext struct {
 	fromClient bool
 	// allowAnonymous 是否允许匿名用户
 	allowAnonymous bool
+	// requestAttributes 请求携带的属性，例如请求头、标签，用于匹配鉴权策略的请求条件
+	requestAttributes map[string]string
 }
 
 // NewAcquireContext 创建一个请求响应
@@ -71,6 +76,11 @@ func NewAcquireContext(options ...acquireContextOption) *AcquireContext {
 		opt := options[index]
 		opt(authCtx)
 	}
+	// 写操作作为一次顶层操作，本次操作产生的全部操作记录归属同一个变更集
+	switch authCtx.operation {
+	case Create, Modify, Delete:
+		authCtx.requestContext = utils.WithChangeSetID(authCtx.requestContext)
+	}
 
 	return authCtx
 }
@@ -134,6 +144,13 @@ func WithAttachment(attachment map[string]interface{}) acquireContextOption {
 	}
 }
 
+// WithRequestAttributes 设置本次请求携带的属性
+func WithRequestAttributes(attrs map[string]string) acquireContextOption {
+	return func(authCtx *AcquireContext) {
+		authCtx.requestAttributes = attrs
+	}
+}
+
 // WithFromConsole 设置本次请求来自控制台
 func WithFromConsole() acquireContextOption {
 	return func(authCtx *AcquireContext) {
@@ -156,6 +173,14 @@ func (authCtx *AcquireContext) GetRequestContext() context.Context {
 	return authCtx.requestContext
 }
 
+// GetChangeSetID 获取本次操作的变更集 ID
+//
+//	@receiver authCtx
+//	@return string
+func (authCtx *AcquireContext) GetChangeSetID() string {
+	return utils.ParseChangeSetID(authCtx.requestContext)
+}
+
 // SetRequestContext 重新设置 context.Context
 //
 //	@receiver authCtx
@@ -246,6 +271,25 @@ func (authCtx *AcquireContext) IsAccessResourceEmpty() bool {
 	return nsEmpty && svcEmpty && cfgEmpty
 }
 
+// GetRequestAttributes 获取本次请求携带的属性，请求上下文中的 gRPC metadata 同样作为请求属性，
+// 同一个 key 存在多个值时取第一个，与显式设置的属性同名时以显式设置的为准
+func (authCtx *AcquireContext) GetRequestAttributes() map[string]string {
+	attrs := make(map[string]string, len(authCtx.requestAttributes))
+	if authCtx.requestContext != nil {
+		if meta, ok := metadata.FromIncomingContext(authCtx.requestContext); ok {
+			for k, v := range meta {
+				if len(v) > 0 {
+					attrs[k] = v[0]
+				}
+			}
+		}
+	}
+	for k, v := range authCtx.requestAttributes {
+		attrs[k] = v
+	}
+	return attrs
+}
+
 // AllowAnonymous 本次请求是否允许匿名访问
 func (authCtx *AcquireContext) IsAllowAnonymous() bool {
 	return authCtx.allowAnonymous
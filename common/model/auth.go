@@ -21,12 +21,14 @@ import (
 	"errors"
 	"fmt"
 	"strconv"
+	"strings"
 	"time"
 
 	apimodel "github.com/polarismesh/specification/source/go/api/v1/model"
 	apisecurity "github.com/polarismesh/specification/source/go/api/v1/security"
 	"google.golang.org/protobuf/types/known/wrapperspb"
 
+	api "github.com/polarismesh/polaris/common/api/v1"
 	commontime "github.com/polarismesh/polaris/common/time"
 )
 
@@ -46,6 +48,12 @@ var (
 	// ErrorWrongUsernameOrPassword 用户或者密码错误
 	ErrorWrongUsernameOrPassword error = errors.New("name or password is wrong")
 
+	// ErrorUserLocked 登录失败次数过多，账户已经被锁定
+	ErrorUserLocked error = errors.New("user is locked due to too many failed logins")
+
+	// ErrorPasswordExpired 密码已经过期，需要由管理员或者主账户重置密码
+	ErrorPasswordExpired error = errors.New("password is expired")
+
 	// ErrorTokenNotExist token 不存在
 	ErrorTokenNotExist error = errors.New("token not exist")
 
@@ -54,6 +62,9 @@ var (
 
 	// ErrorTokenDisabled token 已经被禁用
 	ErrorTokenDisabled error = errors.New("token already disabled")
+
+	// ErrorTokenExpired token 已经过期
+	ErrorTokenExpired error = errors.New("token already expired")
 )
 
 func ConvertToErrCode(err error) apimodel.Code {
@@ -65,12 +76,17 @@ func ConvertToErrCode(err error) apimodel.Code {
 		return apimodel.Code_TokenDisabled
 	}
 
+	if errors.Is(err, ErrorTokenExpired) {
+		return apimodel.Code(api.TokenExpired)
+	}
+
 	return apimodel.Code_NotAllowedAccess
 }
 
 const (
 	OperatorRoleKey       string = "operator_role"
 	OperatorPrincipalType string = "operator_principal"
+	OperatorPrincipalKind string = "operator_principal_kind"
 	OperatorIDKey         string = "operator_id"
 	OperatorOwnerKey      string = "operator_owner"
 	OperatorLinkStrategy  string = "operator_link_strategy"
@@ -82,6 +98,7 @@ const (
 	TokenDetailInfoKey string = "TokenInfo"
 	TokenForUser       string = "uid"
 	TokenForUserGroup  string = "groupid"
+	TokenForSession    string = "session"
 
 	ResourceAttachmentKey string = "resource_attachment"
 )
@@ -114,6 +131,18 @@ const (
 	PrincipalGroup PrincipalType = 2
 )
 
+// PrincipalKind principal 的身份类别，用于区分人员与服务身份
+type PrincipalKind string
+
+const (
+	// PrincipalKindUnknown 未区分身份类别的存量 principal，按照原有逻辑处理
+	PrincipalKindUnknown PrincipalKind = "Unknown"
+	// PrincipalKindHuman 人员身份
+	PrincipalKindHuman PrincipalKind = "Human"
+	// PrincipalKindService 服务身份
+	PrincipalKindService PrincipalKind = "Service"
+)
+
 // CheckPrincipalType 检查鉴权策略成员角色信息
 func CheckPrincipalType(role int) error {
 	switch PrincipalType(role) {
@@ -138,6 +167,8 @@ const (
 
 	// DefaultStrategySuffix 默认策略的名称前缀
 	DefaultStrategySuffix string = "的默认策略"
+	// AuditorStrategySuffix 审计只读策略的名称后缀
+	AuditorStrategySuffix string = "的审计策略"
 )
 
 // BuildDefaultStrategyName 构建默认鉴权策略的名称信息
@@ -148,6 +179,14 @@ func BuildDefaultStrategyName(role PrincipalType, name string) string {
 	return fmt.Sprintf("%s%s%s", "(用户组) ", name, DefaultStrategySuffix)
 }
 
+// BuildAuditorStrategyName 构建审计只读策略的名称信息
+func BuildAuditorStrategyName(role PrincipalType, name string) string {
+	if role == PrincipalUser {
+		return fmt.Sprintf("%s%s%s", "(用户) ", name, AuditorStrategySuffix)
+	}
+	return fmt.Sprintf("%s%s%s", "(用户组) ", name, AuditorStrategySuffix)
+}
+
 // ResourceOperation 资源操作
 type ResourceOperation int16
 
@@ -218,12 +257,30 @@ type User struct {
 	Type        UserRoleType
 	Token       string
 	TokenEnable bool
+	// TokenExpireAt token 的过期时间，零值表示永不过期
+	TokenExpireAt time.Time
+	// PasswordModifyTime 密码的最近修改时间，零值表示历史数据未记录，不参与密码过期的判断
+	PasswordModifyTime time.Time
+	// LoginFailures 连续登录失败的次数，登录成功或者账户被锁定后清零
+	LoginFailures int
+	// LockedUntil 账户被锁定到的时间，零值表示未被锁定
+	LockedUntil time.Time
 	Valid       bool
 	Comment     string
 	CreateTime  time.Time
 	ModifyTime  time.Time
 }
 
+// IsTokenExpired token 在 now 时刻是否已经过期
+func (u *User) IsTokenExpired(now time.Time) bool {
+	return !u.TokenExpireAt.IsZero() && !now.Before(u.TokenExpireAt)
+}
+
+// IsLocked 账户在 now 时刻是否处于锁定状态
+func (u *User) IsLocked(now time.Time) bool {
+	return !u.LockedUntil.IsZero() && now.Before(u.LockedUntil)
+}
+
 func (u *User) ToSpec() *apisecurity.User {
 	if u == nil {
 		return nil
@@ -241,6 +298,23 @@ func (u *User) ToSpec() *apisecurity.User {
 	}
 }
 
+// UserSession 控制台登录签发的会话，会话 token 与用户 token 相互独立，可以单独注销
+type UserSession struct {
+	ID     string `json:"id"`
+	UserID string `json:"user_id"`
+	// ExpireTime 会话的过期时间
+	ExpireTime time.Time `json:"expire_time"`
+	// Valid 会话被注销后置为 false
+	Valid      bool      `json:"valid"`
+	CreateTime time.Time `json:"create_time"`
+	ModifyTime time.Time `json:"modify_time"`
+}
+
+// IsActive 会话在 now 时刻是否可以使用
+func (s *UserSession) IsActive(now time.Time) bool {
+	return s.Valid && now.Before(s.ExpireTime)
+}
+
 // UserGroupDetail 用户组详细（带用户列表）
 type UserGroupDetail struct {
 	*UserGroup
@@ -292,9 +366,11 @@ func (ugd *UserGroupDetail) ToSpec() *apisecurity.UserGroup {
 
 // UserGroup 用户组
 type UserGroup struct {
-	ID          string
-	Name        string
-	Owner       string
+	ID    string
+	Name  string
+	Owner string
+	// ParentID 父用户组 ID，组内的成员同样属于全部祖先用户组，为空时为顶层用户组
+	ParentID    string
 	Token       string
 	TokenEnable bool
 	Valid       bool
@@ -332,17 +408,74 @@ type StrategyDetail struct {
 	Default    bool
 	Owner      string
 	Resources  []StrategyResource
+	// Effect 策略的授权效果，为空时视为 ALLOW
+	Effect StrategyEffect
+	// Priority 策略的优先级，数值越大优先级越高
+	Priority int32
+	// Functions 策略生效的接口名称，例如 DescribeServices，支持 Describe* 前缀匹配，为空时对全部接口生效
+	Functions  []string
 	Valid      bool
 	Revision   string
 	CreateTime time.Time
 	ModifyTime time.Time
 }
 
+// StrategyEffect 鉴权策略的授权效果
+type StrategyEffect string
+
+const (
+	// StrategyEffectAllow 授予关联资源的操作权限
+	StrategyEffectAllow StrategyEffect = "ALLOW"
+	// StrategyEffectDeny 拒绝对关联资源的读写操作
+	StrategyEffectDeny StrategyEffect = "DENY"
+)
+
+// IsReadOnly 只读策略仅授予读权限，不会授予任何写权限，拒绝策略不视为只读策略
+func (s *StrategyDetail) IsReadOnly() bool {
+	return !s.IsDeny() && s.Action == apisecurity.AuthAction_ONLY_READ.String()
+}
+
+// IsDeny 是否为拒绝策略，拒绝策略不授予任何权限
+func (s *StrategyDetail) IsDeny() bool {
+	return s.Effect == StrategyEffectDeny
+}
+
+// MatchFunction 策略是否对该接口生效，策略没有限制接口或者接口名称未知时视为生效
+func (s *StrategyDetail) MatchFunction(method string) bool {
+	if len(s.Functions) == 0 || method == "" {
+		return true
+	}
+	for _, fn := range s.Functions {
+		if fn == method {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(fn, "*"); ok && strings.HasPrefix(method, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// FormatStrategyFunctions 接口名称使用 , 分隔后持久化
+func FormatStrategyFunctions(functions []string) string {
+	return strings.Join(functions, ",")
+}
+
+// ParseStrategyFunctions 解析持久化的接口名称
+func ParseStrategyFunctions(val string) []string {
+	if val == "" {
+		return nil
+	}
+	return strings.Split(val, ",")
+}
+
 // StrategyDetailCache 鉴权策略详细
 type StrategyDetailCache struct {
 	*StrategyDetail
 	UserPrincipal  map[string]Principal
 	GroupPrincipal map[string]Principal
+	// ExpireResources 设置了过期时间的资源关联关系, key 为 {resType}_{resId}
+	ExpireResources map[string]time.Time
 }
 
 // ModifyStrategyDetail 修改鉴权策略详细
@@ -377,6 +510,86 @@ type StrategyResource struct {
 	StrategyID string
 	ResType    int32
 	ResID      string
+	// ExpireTime 资源关联关系的过期时间，零值表示永不过期
+	ExpireTime time.Time
+}
+
+// IsExpired 资源关联关系在 now 时刻是否已经过期
+func (s StrategyResource) IsExpired(now time.Time) bool {
+	return !s.ExpireTime.IsZero() && !now.Before(s.ExpireTime)
+}
+
+// ResourceLabelPrefix 按照命名空间以及标签选择器匹配资源的资源 ID 前缀, 例如 label:prod/env=prod,tier=gold
+// 命名空间为 * 时匹配全部命名空间下的资源，标签选择器的语法与属性匹配规则一致
+const ResourceLabelPrefix = "label:"
+
+// IsLabelSelector 资源关联关系是否按照命名空间以及标签选择器匹配资源
+func (s StrategyResource) IsLabelSelector() bool {
+	return strings.HasPrefix(s.ResID, ResourceLabelPrefix)
+}
+
+// LabelSelector 解析资源关联关系中的命名空间以及标签选择器，不是标签选择器或者缺少命名空间时返回 false
+func (s StrategyResource) LabelSelector() (string, string, bool) {
+	if !s.IsLabelSelector() {
+		return "", "", false
+	}
+	namespace, selector, ok := strings.Cut(strings.TrimPrefix(s.ResID, ResourceLabelPrefix), "/")
+	if !ok || namespace == "" {
+		return "", "", false
+	}
+	return namespace, selector, true
+}
+
+// ResourceIDCanonicalizer 资源 ID 的规范化规则，资源关联关系中保存的资源 ID 与请求中的资源 ID
+// 按照同一个规则规范化后再比较，避免格式不一致导致匹配失败
+type ResourceIDCanonicalizer struct {
+	// Trim 去除首尾的空白字符以及末尾的分隔符
+	Trim bool `json:"trim"`
+	// Separators 需要统一替换为 Separator 的分隔符
+	Separators []string `json:"separators"`
+	// Separator 规范化后的分隔符，为空时使用 /
+	Separator string `json:"separator"`
+	// CaseFold 比较时忽略大小写
+	CaseFold bool `json:"caseFold"`
+}
+
+// Canonical 规范化资源 ID，* 保持不变，c 为 nil 时返回原值
+func (c *ResourceIDCanonicalizer) Canonical(resID string) string {
+	if c == nil || resID == "*" {
+		return resID
+	}
+	separator := c.Separator
+	if separator == "" {
+		separator = "/"
+	}
+	if c.Trim {
+		resID = strings.TrimSpace(resID)
+	}
+	for _, item := range c.Separators {
+		if item != "" {
+			resID = strings.ReplaceAll(resID, item, separator)
+		}
+	}
+	for c.Trim && strings.HasSuffix(resID, separator) {
+		resID = strings.TrimSuffix(resID, separator)
+	}
+	if c.CaseFold {
+		resID = strings.ToLower(resID)
+	}
+	return resID
+}
+
+// Equal 两个资源 ID 规范化后是否相同
+func (c *ResourceIDCanonicalizer) Equal(a, b string) bool {
+	return a == b || c.Canonical(a) == c.Canonical(b)
+}
+
+// StrategyResourceUsage 资源关联关系最近一次被鉴权使用的时间
+type StrategyResourceUsage struct {
+	StrategyID   string
+	ResType      int32
+	ResID        string
+	LastUsedTime time.Time
 }
 
 // Principal 策略相关人
@@ -386,6 +599,21 @@ type Principal struct {
 	PrincipalRole PrincipalType
 }
 
+// Role 角色，将一组鉴权策略授予用户、用户组，被授予角色的用户、用户组拥有这些策略的全部权限
+type Role struct {
+	ID      string
+	Name    string
+	Owner   string
+	Comment string
+	// Strategies 角色包含的鉴权策略 ID
+	Strategies []string
+	// Principals 被授予角色的用户、用户组
+	Principals []Principal
+	Valid      bool
+	CreateTime time.Time
+	ModifyTime time.Time
+}
+
 type OperateResource struct {
 	ResOwner string
 	ResType  apisecurity.ResourceType
@@ -0,0 +1,57 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+package model
+
+import "time"
+
+// ServiceDependency 两个服务之间的一条依赖关系，Caller 调用 Callee
+type ServiceDependency struct {
+	CallerNamespace string `json:"callerNamespace"`
+	CallerService   string `json:"callerService"`
+	CalleeNamespace string `json:"calleeNamespace"`
+	CalleeService   string `json:"calleeService"`
+	// Calls 统计窗口内 Caller 发现 Callee 实例的次数
+	Calls    uint64    `json:"calls"`
+	LastSeen time.Time `json:"lastSeen"`
+	// Buckets 统计窗口内按照时间桶聚合的发现次数，按照时间先后排序
+	Buckets []*DependencyBucket `json:"buckets"`
+}
+
+// DependencyBucket 一个时间桶内的发现次数
+type DependencyBucket struct {
+	Start time.Time `json:"start"`
+	Calls uint64    `json:"calls"`
+}
+
+// ServiceDependencies 服务在统计窗口内的上游以及下游依赖
+type ServiceDependencies struct {
+	Code      uint32 `json:"code"`
+	Info      string `json:"info"`
+	Namespace string `json:"namespace"`
+	Service   string `json:"service"`
+	// Callers 调用该服务的上游服务
+	Callers []*ServiceDependency `json:"callers"`
+	// Callees 该服务调用的下游服务
+	Callees []*ServiceDependency `json:"callees"`
+}
+
+// WithCode 设置查询失败的错误码以及错误信息
+func (s *ServiceDependencies) WithCode(code uint32, info string) *ServiceDependencies {
+	s.Code = code
+	s.Info = info
+	return s
+}
@@ -0,0 +1,76 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package model
+
+import (
+	"strconv"
+	"time"
+
+	apiservice "github.com/polarismesh/specification/source/go/api/v1/service_manage"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	"github.com/polarismesh/polaris/common/utils"
+)
+
+// InstanceDrainingDeadline 获取实例优雅下线的截止时间，实例不处于下线状态时返回 false
+func InstanceDrainingDeadline(ins *apiservice.Instance) (time.Time, bool) {
+	val, ok := ins.GetMetadata()[MetadataInstanceDrainingDeadline]
+	if !ok {
+		return time.Time{}, false
+	}
+	sec, err := strconv.ParseInt(val, 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Unix(sec, 0), true
+}
+
+// IsInstanceDraining 实例是否处于优雅下线状态
+func IsInstanceDraining(ins *apiservice.Instance) bool {
+	_, ok := InstanceDrainingDeadline(ins)
+	return ok
+}
+
+// InstanceDiscoverWeight 实例在服务发现时下发的权重，优雅下线中的实例继续下发但不再分配流量
+func InstanceDiscoverWeight(ins *apiservice.Instance) *wrapperspb.UInt32Value {
+	if IsInstanceDraining(ins) {
+		return utils.NewUInt32Value(0)
+	}
+	return ins.GetWeight()
+}
+
+// FormatDrainingDeadline 格式化实例优雅下线的截止时间
+func FormatDrainingDeadline(deadline time.Time) string {
+	return strconv.FormatInt(deadline.Unix(), 10)
+}
+
+// DrainingDeadline 实例优雅下线的截止时间
+func (i *Instance) DrainingDeadline() (time.Time, bool) {
+	return InstanceDrainingDeadline(i.Proto)
+}
+
+// IsDraining 实例是否处于优雅下线状态
+func (i *Instance) IsDraining() bool {
+	return IsInstanceDraining(i.Proto)
+}
+
+// IsDrained 实例优雅下线是否已经到期，到期的实例可以被删除
+func (i *Instance) IsDrained(now time.Time) bool {
+	deadline, ok := i.DrainingDeadline()
+	return ok && !now.Before(deadline)
+}
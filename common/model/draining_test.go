@@ -0,0 +1,45 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package model
+
+import (
+	"testing"
+	"time"
+
+	apiservice "github.com/polarismesh/specification/source/go/api/v1/service_manage"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInstanceDraining(t *testing.T) {
+	ins := &Instance{Proto: &apiservice.Instance{}}
+	assert.False(t, ins.IsDraining())
+	assert.False(t, ins.IsDrained(time.Now()))
+
+	deadline := time.Unix(time.Now().Unix()+30, 0)
+	ins.Proto.Metadata = map[string]string{MetadataInstanceDrainingDeadline: FormatDrainingDeadline(deadline)}
+	ret, ok := ins.DrainingDeadline()
+	assert.True(t, ok)
+	assert.Equal(t, deadline, ret)
+	assert.True(t, ins.IsDraining())
+	assert.False(t, ins.IsDrained(time.Now()))
+	assert.True(t, ins.IsDrained(deadline))
+	assert.True(t, ins.IsDrained(deadline.Add(time.Second)))
+
+	ins.Proto.Metadata[MetadataInstanceDrainingDeadline] = "invalid"
+	assert.False(t, ins.IsDraining())
+}
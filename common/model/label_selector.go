@@ -0,0 +1,223 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package model
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	// MaxLabelRequirements 单个标签选择器最多的条件数
+	MaxLabelRequirements = 16
+	// MaxLabelRequirementValues 单个条件最多的候选值数
+	MaxLabelRequirementValues = 64
+)
+
+// LabelOperator 标签条件的操作符
+type LabelOperator string
+
+const (
+	// LabelOpEquals key=v 或者 key==v
+	LabelOpEquals LabelOperator = "="
+	// LabelOpNotEquals key!=v，没有该标签时同样满足
+	LabelOpNotEquals LabelOperator = "!="
+	// LabelOpIn key in (v1,v2)
+	LabelOpIn LabelOperator = "in"
+	// LabelOpNotIn key notin (v1,v2)，没有该标签时同样满足
+	LabelOpNotIn LabelOperator = "notin"
+	// LabelOpExists key
+	LabelOpExists LabelOperator = "exists"
+	// LabelOpNotExists !key
+	LabelOpNotExists LabelOperator = "!"
+)
+
+// ErrorInvalidLabelSelector 标签选择器不合法
+var ErrorInvalidLabelSelector = errors.New("invalid label selector")
+
+var labelSetRequirementRegex = regexp.MustCompile(`^([^\s!=(),]+)\s+(in|notin)\s*\((.*)\)$`)
+
+// LabelRequirement 单个标签条件
+type LabelRequirement struct {
+	Key      string
+	Operator LabelOperator
+	Values   []string
+}
+
+// Matches 标签是否满足条件
+func (r LabelRequirement) Matches(labels map[string]string) bool {
+	val, exist := labels[r.Key]
+	switch r.Operator {
+	case LabelOpExists:
+		return exist
+	case LabelOpNotExists:
+		return !exist
+	case LabelOpEquals, LabelOpIn:
+		return exist && r.hasValue(val)
+	case LabelOpNotEquals, LabelOpNotIn:
+		return !exist || !r.hasValue(val)
+	default:
+		return false
+	}
+}
+
+// Positive 条件是否要求标签存在，这类条件可以通过标签索引直接查找候选集
+func (r LabelRequirement) Positive() bool {
+	return r.Operator == LabelOpEquals || r.Operator == LabelOpIn || r.Operator == LabelOpExists
+}
+
+func (r LabelRequirement) hasValue(val string) bool {
+	for i := range r.Values {
+		if r.Values[i] == val {
+			return true
+		}
+	}
+	return false
+}
+
+// LabelSelector 标签选择器，需要同时满足全部条件
+type LabelSelector []LabelRequirement
+
+// Matches 标签是否满足全部条件
+func (s LabelSelector) Matches(labels map[string]string) bool {
+	for i := range s {
+		if !s[i].Matches(labels) {
+			return false
+		}
+	}
+	return true
+}
+
+// ParseLabelSelector 解析标签选择器，多个条件以逗号分隔，例如 team=payments,env in (prod,pre),tier!=test
+// 支持 key=v、key==v、key!=v、key in (v1,v2)、key notin (v1,v2)、key 以及 !key
+func ParseLabelSelector(expr string) (LabelSelector, error) {
+	items, err := splitLabelRequirements(expr)
+	if err != nil {
+		return nil, err
+	}
+	if len(items) == 0 || len(items) > MaxLabelRequirements {
+		return nil, fmt.Errorf("%w: %s", ErrorInvalidLabelSelector, expr)
+	}
+	selector := make(LabelSelector, 0, len(items))
+	for _, item := range items {
+		requirement, err := parseLabelRequirement(strings.TrimSpace(item))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", err, expr)
+		}
+		selector = append(selector, requirement)
+	}
+	return selector, nil
+}
+
+// splitLabelRequirements 按照括号之外的逗号拆分条件
+func splitLabelRequirements(expr string) ([]string, error) {
+	var (
+		items []string
+		depth int
+		start int
+	)
+	for i, c := range expr {
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth < 0 {
+				return nil, fmt.Errorf("%w: %s", ErrorInvalidLabelSelector, expr)
+			}
+		case ',':
+			if depth == 0 {
+				items = append(items, expr[start:i])
+				start = i + 1
+			}
+		}
+	}
+	if depth != 0 {
+		return nil, fmt.Errorf("%w: %s", ErrorInvalidLabelSelector, expr)
+	}
+	if strings.TrimSpace(expr) != "" {
+		items = append(items, expr[start:])
+	}
+	return items, nil
+}
+
+func parseLabelRequirement(item string) (LabelRequirement, error) {
+	if item == "" {
+		return LabelRequirement{}, ErrorInvalidLabelSelector
+	}
+	if match := labelSetRequirementRegex.FindStringSubmatch(item); match != nil {
+		values, err := parseLabelValues(strings.Split(match[3], ","))
+		if err != nil {
+			return LabelRequirement{}, err
+		}
+		return LabelRequirement{Key: match[1], Operator: LabelOperator(match[2]), Values: values}, nil
+	}
+	if strings.ContainsAny(item, "()") {
+		return LabelRequirement{}, ErrorInvalidLabelSelector
+	}
+	var (
+		key, val string
+		op       LabelOperator
+	)
+	switch {
+	case strings.Contains(item, "!="):
+		key, val, _ = strings.Cut(item, "!=")
+		op = LabelOpNotEquals
+	case strings.Contains(item, "=="):
+		key, val, _ = strings.Cut(item, "==")
+		op = LabelOpEquals
+	case strings.Contains(item, "="):
+		key, val, _ = strings.Cut(item, "=")
+		op = LabelOpEquals
+	case strings.HasPrefix(item, "!"):
+		key = strings.TrimSpace(strings.TrimPrefix(item, "!"))
+		op = LabelOpNotExists
+	default:
+		key = item
+		op = LabelOpExists
+	}
+	key = strings.TrimSpace(key)
+	if key == "" || strings.ContainsAny(key, " !=") {
+		return LabelRequirement{}, ErrorInvalidLabelSelector
+	}
+	requirement := LabelRequirement{Key: key, Operator: op}
+	if op == LabelOpEquals || op == LabelOpNotEquals {
+		values, err := parseLabelValues([]string{val})
+		if err != nil {
+			return LabelRequirement{}, err
+		}
+		requirement.Values = values
+	}
+	return requirement, nil
+}
+
+func parseLabelValues(items []string) ([]string, error) {
+	if len(items) > MaxLabelRequirementValues {
+		return nil, ErrorInvalidLabelSelector
+	}
+	values := make([]string, 0, len(items))
+	for _, item := range items {
+		if item = strings.TrimSpace(item); item == "" {
+			return nil, ErrorInvalidLabelSelector
+		}
+		values = append(values, item)
+	}
+	return values, nil
+}
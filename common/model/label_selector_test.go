@@ -0,0 +1,56 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseLabelSelector(t *testing.T) {
+	selector, err := ParseLabelSelector(
+		"team=payments, env in (prod, pre),tier!=test,region notin (eu),owner,!deprecated")
+	assert.NoError(t, err)
+	assert.Equal(t, LabelSelector{
+		{Key: "team", Operator: LabelOpEquals, Values: []string{"payments"}},
+		{Key: "env", Operator: LabelOpIn, Values: []string{"prod", "pre"}},
+		{Key: "tier", Operator: LabelOpNotEquals, Values: []string{"test"}},
+		{Key: "region", Operator: LabelOpNotIn, Values: []string{"eu"}},
+		{Key: "owner", Operator: LabelOpExists},
+		{Key: "deprecated", Operator: LabelOpNotExists},
+	}, selector)
+
+	assert.True(t, selector.Matches(map[string]string{"team": "payments", "env": "pre", "owner": "a"}))
+	assert.False(t, selector.Matches(map[string]string{"team": "payments", "env": "test", "owner": "a"}))
+	assert.False(t, selector.Matches(map[string]string{"team": "payments", "env": "prod", "owner": "a",
+		"tier": "test"}))
+	assert.False(t, selector.Matches(map[string]string{"team": "payments", "env": "prod", "owner": "a",
+		"deprecated": "true"}))
+	assert.False(t, selector.Matches(map[string]string{"team": "payments", "env": "prod"}))
+
+	selector, err = ParseLabelSelector("team==payments")
+	assert.NoError(t, err)
+	assert.True(t, selector[0].Positive())
+
+	for _, expr := range []string{"", "team=", "=payments", "env in ()", "env in (prod", "env in prod)",
+		"env in (prod,)", "a b=c", ","} {
+		_, err := ParseLabelSelector(expr)
+		assert.ErrorIs(t, err, ErrorInvalidLabelSelector, expr)
+	}
+}
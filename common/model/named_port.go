@@ -0,0 +1,155 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+package model
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	apiservice "github.com/polarismesh/specification/source/go/api/v1/service_manage"
+)
+
+const (
+	// MaxNamedPorts 单个实例最多声明的命名端口数
+	MaxNamedPorts = 16
+)
+
+var (
+	// namedPortNameRegex 端口名与 kubernetes 的端口名保持一致，小写字母、数字以及中划线，不超过 15 个字符
+	namedPortNameRegex = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]{0,13}[a-z0-9])?$`)
+	// namedPortProtocolRegex 端口协议
+	namedPortProtocolRegex = regexp.MustCompile(`^[A-Za-z0-9_.-]{1,32}$`)
+)
+
+// NamedPort 实例的命名端口，通过实例元数据 MetadataInstanceNamedPorts 声明
+type NamedPort struct {
+	Name     string
+	Port     uint32
+	Protocol string
+}
+
+// String name:port[/protocol]
+func (p *NamedPort) String() string {
+	if p.Protocol == "" {
+		return p.Name + ":" + strconv.FormatUint(uint64(p.Port), 10)
+	}
+	return p.Name + ":" + strconv.FormatUint(uint64(p.Port), 10) + "/" + p.Protocol
+}
+
+// ParseNamedPorts 解析实例元数据中的命名端口，端口名不允许重复
+func ParseNamedPorts(value string) ([]*NamedPort, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil, nil
+	}
+	items := strings.Split(value, ",")
+	if len(items) > MaxNamedPorts {
+		return nil, fmt.Errorf("named ports more than %d", MaxNamedPorts)
+	}
+	ports := make([]*NamedPort, 0, len(items))
+	names := make(map[string]struct{}, len(items))
+	for _, item := range items {
+		port, err := parseNamedPort(strings.TrimSpace(item))
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := names[port.Name]; ok {
+			return nil, fmt.Errorf("named port %s duplicated", port.Name)
+		}
+		names[port.Name] = struct{}{}
+		ports = append(ports, port)
+	}
+	return ports, nil
+}
+
+func parseNamedPort(item string) (*NamedPort, error) {
+	name, portStr, ok := strings.Cut(item, ":")
+	if !ok {
+		return nil, fmt.Errorf("named port %q must be name:port[/protocol]", item)
+	}
+	if !namedPortNameRegex.MatchString(name) {
+		return nil, fmt.Errorf("named port name %q is invalid", name)
+	}
+	portStr, protocol, hasProtocol := strings.Cut(portStr, "/")
+	if hasProtocol && !namedPortProtocolRegex.MatchString(protocol) {
+		return nil, fmt.Errorf("named port protocol %q is invalid", protocol)
+	}
+	port, err := strconv.ParseUint(portStr, 10, 32)
+	if err != nil || port == 0 || port > 65535 {
+		return nil, fmt.Errorf("named port %s port %q is invalid", name, portStr)
+	}
+	return &NamedPort{Name: name, Port: uint32(port), Protocol: protocol}, nil
+}
+
+// FormatNamedPorts 将命名端口转换为实例元数据中的格式
+func FormatNamedPorts(ports []*NamedPort) string {
+	items := make([]string, 0, len(ports))
+	for i := range ports {
+		items = append(items, ports[i].String())
+	}
+	return strings.Join(items, ",")
+}
+
+// CheckNamedPorts 检查实例元数据中的命名端口是否合法
+func CheckNamedPorts(metadata map[string]string) error {
+	value, ok := metadata[MetadataInstanceNamedPorts]
+	if !ok {
+		return nil
+	}
+	ports, err := ParseNamedPorts(value)
+	if err != nil {
+		return err
+	}
+	if len(ports) == 0 {
+		return errors.New("named ports is empty")
+	}
+	return nil
+}
+
+// InstanceNamedPorts 获取实例声明的命名端口，元数据不合法时忽略
+func InstanceNamedPorts(ins *apiservice.Instance) []*NamedPort {
+	value, ok := ins.GetMetadata()[MetadataInstanceNamedPorts]
+	if !ok {
+		return nil
+	}
+	ports, err := ParseNamedPorts(value)
+	if err != nil {
+		return nil
+	}
+	return ports
+}
+
+// NamedPorts 获取实例声明的命名端口
+func (i *Instance) NamedPorts() []*NamedPort {
+	if i.Proto == nil {
+		return nil
+	}
+	return InstanceNamedPorts(i.Proto)
+}
+
+// NamedPort 根据端口名获取实例的端口
+func (i *Instance) NamedPort(name string) (*NamedPort, bool) {
+	for _, port := range i.NamedPorts() {
+		if port.Name == name {
+			return port, true
+		}
+	}
+	return nil, false
+}
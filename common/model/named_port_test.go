@@ -0,0 +1,79 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+package model
+
+import (
+	"testing"
+
+	apiservice "github.com/polarismesh/specification/source/go/api/v1/service_manage"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseNamedPorts(t *testing.T) {
+	ports, err := ParseNamedPorts("http:8080, grpc:9090/GRPC,metrics:15020")
+	assert.NoError(t, err)
+	assert.Equal(t, []*NamedPort{
+		{Name: "http", Port: 8080},
+		{Name: "grpc", Port: 9090, Protocol: "GRPC"},
+		{Name: "metrics", Port: 15020},
+	}, ports)
+	assert.Equal(t, "http:8080,grpc:9090/GRPC,metrics:15020", FormatNamedPorts(ports))
+
+	ports, err = ParseNamedPorts("")
+	assert.NoError(t, err)
+	assert.Empty(t, ports)
+
+	for _, value := range []string{
+		"http",
+		"http:0",
+		"http:65536",
+		"http:abc",
+		"HTTP:8080",
+		"-http:8080",
+		"http:8080/",
+		"http:8080,http:8081",
+	} {
+		_, err = ParseNamedPorts(value)
+		assert.Error(t, err, value)
+	}
+}
+
+func TestCheckNamedPorts(t *testing.T) {
+	assert.NoError(t, CheckNamedPorts(nil))
+	assert.NoError(t, CheckNamedPorts(map[string]string{MetadataInstanceNamedPorts: "http:8080"}))
+	assert.Error(t, CheckNamedPorts(map[string]string{MetadataInstanceNamedPorts: ""}))
+	assert.Error(t, CheckNamedPorts(map[string]string{MetadataInstanceNamedPorts: "http:8080,http:8081"}))
+}
+
+func TestInstance_NamedPorts(t *testing.T) {
+	// 元数据 key 是客户端注册实例时使用的约定，不能修改
+	ins := &Instance{Proto: &apiservice.Instance{
+		Metadata: map[string]string{"internal-named-ports": "http:8080,grpc:9090/GRPC"},
+	}}
+	assert.Len(t, ins.NamedPorts(), 2)
+	port, ok := ins.NamedPort("grpc")
+	assert.True(t, ok)
+	assert.Equal(t, uint32(9090), port.Port)
+	assert.Equal(t, "GRPC", port.Protocol)
+	_, ok = ins.NamedPort("metrics")
+	assert.False(t, ok)
+
+	// 不合法的命名端口被忽略
+	ins.Proto.Metadata[MetadataInstanceNamedPorts] = "http"
+	assert.Empty(t, ins.NamedPorts())
+	assert.Empty(t, (&Instance{}).NamedPorts())
+}
@@ -527,6 +527,28 @@ const (
 	MetadataRegisterFrom                = "internal-register-from"
 	MetadataInternalMetaHealthCheckPath = "internal-healthcheck_path"
 	MetadataInternalMetaTraceSampling   = "internal-trace_sampling"
+	// MetadataInstanceNamedPorts 实例声明的多个命名端口，格式为 name:port[/protocol]，多个端口以逗号分隔
+	// 例如 http:8080,grpc:9090/GRPC,metrics:15020
+	// 实例的 proto 定义在 polarismesh/specification 中，无法增加端口列表字段，该 key 以及格式作为对外支持的约定，
+	// 客户端注册实例时直接写入该元数据，后续版本保持兼容，不随意修改 key 的名称以及格式
+	MetadataInstanceNamedPorts = "internal-named-ports"
+	// MetadataInstanceDrainingDeadline 实例优雅下线的截止时间，unix 秒级时间戳
+	// 下线期间服务发现仍然返回该实例，但是权重为 0，xDS 中标记为 DRAINING，截止时间之后由维护任务自动删除
+	MetadataInstanceDrainingDeadline = "internal-draining-deadline"
+	// MetadataFederationSource 从其他 Polaris 集群同步过来的服务、实例，value 为数据归属集群的 ID
+	MetadataFederationSource = "internal-federation-source"
+	// MetadataFederationRevision 数据在归属集群中的 revision
+	MetadataFederationRevision = "internal-federation-revision"
+	// MetadataFederationMtime 数据在归属集群中的修改时间，unix 毫秒级时间戳
+	MetadataFederationMtime = "internal-federation-mtime"
+	// MetadataKubernetesCluster 从 Kubernetes 同步过来的服务、实例，value 为来源 Kubernetes 集群的名称
+	MetadataKubernetesCluster = "internal-kubernetes-cluster"
+	// MetadataKubernetesNamespace 实例在 Kubernetes 中所属的命名空间
+	MetadataKubernetesNamespace = "internal-kubernetes-namespace"
+	// MetadataKubernetesPod 实例对应的 Pod
+	MetadataKubernetesPod = "internal-kubernetes-pod"
+	// MetadataKubernetesNode 实例所在的 Node
+	MetadataKubernetesNode = "internal-kubernetes-node"
 )
 
 // Instance 组合了api的Instance对象
@@ -37,6 +37,8 @@ const (
 	OUpdate OperationType = "Update"
 	// OUpdateIsolate Update isolation state
 	OUpdateIsolate OperationType = "UpdateIsolate"
+	// ODrain Start draining instance before deregistration
+	ODrain OperationType = "Drain"
 	// OUpdateToken Update token
 	OUpdateToken OperationType = "UpdateToken"
 	// OUpdateGroup Update user-user group association relationship
@@ -45,6 +47,16 @@ const (
 	OUpdateEnable OperationType = "UpdateEnable"
 	// ORollback Rollback resource
 	ORollback OperationType = "Rollback"
+	// OQuotaWarn Resource quota exceeded during the warn-only grace period
+	OQuotaWarn OperationType = "QuotaWarn"
+	// OBreakGlass High-severity operation allowed by a break-glass token overriding the auth strategies
+	OBreakGlass OperationType = "BreakGlass"
+	// OExpire Temporary resource removed automatically after expiration
+	OExpire OperationType = "Expire"
+	// ODryRun Operation evaluated by a maintain job in dry-run mode without changing the resource
+	ODryRun OperationType = "DryRun"
+	// OExpireGrace Operation allowed by a time-bounded strategy resource link within the grace window after expiration
+	OExpireGrace OperationType = "ExpireGrace"
 )
 
 // Resource Operating resources
@@ -62,16 +74,20 @@ const (
 	RUserGroup          Resource = "UserGroup"
 	RUserGroupRelation  Resource = "UserGroupRelation"
 	RAuthStrategy       Resource = "AuthStrategy"
+	RAuthDecisionPin    Resource = "AuthDecisionPin"
+	RAuthRole           Resource = "AuthRole"
 	RConfigGroup        Resource = "ConfigGroup"
 	RConfigFile         Resource = "ConfigFile"
 	RConfigFileRelease  Resource = "ConfigFileRelease"
 	RCircuitBreakerRule Resource = "CircuitBreakerRule"
 	RFaultDetectRule    Resource = "FaultDetectRule"
 	RServiceContract    Resource = "ServiceContract"
+	RRecordEntry        Resource = "RecordEntry"
 )
 
 // RecordEntry Operation records
 type RecordEntry struct {
+	ID            uint64
 	ResourceType  Resource
 	ResourceName  string
 	Namespace     string
@@ -80,6 +96,45 @@ type RecordEntry struct {
 	Detail        string
 	Server        string
 	HappenTime    time.Time
+	// ChangeSetID 产生该记录的顶层操作的变更集 ID，同一次操作产生的全部记录共享同一个 ID
+	ChangeSetID string
+	// Replayed 新添加的历史记录插件启动时重放的历史记录，不是实时产生的记录
+	Replayed bool
+}
+
+// RecordEntryFilter 操作记录的查询条件，零值的条件不参与过滤
+type RecordEntryFilter struct {
+	Operator      string
+	ResourceType  Resource
+	OperationType OperationType
+	// StartTime 查询 [StartTime, EndTime) 之间发生的操作记录
+	StartTime time.Time
+	EndTime   time.Time
+}
+
+// Match 操作记录是否满足查询条件
+func (f *RecordEntryFilter) Match(entry *RecordEntry) bool {
+	if f.Operator != "" && f.Operator != entry.Operator {
+		return false
+	}
+	if f.ResourceType != "" && f.ResourceType != entry.ResourceType {
+		return false
+	}
+	if f.OperationType != "" && f.OperationType != entry.OperationType {
+		return false
+	}
+	if !f.StartTime.IsZero() && entry.HappenTime.Before(f.StartTime) {
+		return false
+	}
+	if !f.EndTime.IsZero() && !entry.HappenTime.Before(f.EndTime) {
+		return false
+	}
+	return true
+}
+
+// MustAudit 必须写入的高危操作记录，写入队列已满时也不能丢弃
+func (r *RecordEntry) MustAudit() bool {
+	return r.OperationType == OBreakGlass
 }
 
 func (r *RecordEntry) String() string {
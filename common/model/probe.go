@@ -0,0 +1,148 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+package model
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// MetadataServiceProbePrefix 服务元数据中定义主动探测策略的 key 前缀
+	// 例如 internal-probe.type=http, internal-probe.path=/healthz, internal-probe.interval=5s
+	MetadataServiceProbePrefix = "internal-probe."
+
+	ProbeTypeHTTP  = "http"
+	ProbeTypeHTTPS = "https"
+	ProbeTypeTCP   = "tcp"
+	ProbeTypeGRPC  = "grpc"
+
+	probeKeyType               = MetadataServiceProbePrefix + "type"
+	probeKeyPort               = MetadataServiceProbePrefix + "port"
+	probeKeyPath               = MetadataServiceProbePrefix + "path"
+	probeKeyGRPCService        = MetadataServiceProbePrefix + "grpc-service"
+	probeKeyInterval           = MetadataServiceProbePrefix + "interval"
+	probeKeyTimeout            = MetadataServiceProbePrefix + "timeout"
+	probeKeyHealthyThreshold   = MetadataServiceProbePrefix + "healthy-threshold"
+	probeKeyUnhealthyThreshold = MetadataServiceProbePrefix + "unhealthy-threshold"
+
+	defaultProbeInterval           = 10 * time.Second
+	defaultProbeTimeout            = 3 * time.Second
+	minProbeInterval               = time.Second
+	defaultProbeHealthyThreshold   = 1
+	defaultProbeUnhealthyThreshold = 3
+	maxProbeThreshold              = 10
+)
+
+// ProbePolicy 服务的主动探测策略，服务端按照策略定期探测实例的端口，并根据探测结果修改实例的健康状态
+type ProbePolicy struct {
+	Type string
+	// Port 探测的端口，为 0 时使用实例的端口
+	Port uint32
+	// Path HTTP 探测的路径
+	Path string
+	// GRPCService gRPC 健康检查的服务名，为空时检查整个服务端
+	GRPCService string
+	Interval    time.Duration
+	Timeout     time.Duration
+	// HealthyThreshold 连续探测成功多少次后实例变为健康
+	HealthyThreshold int
+	// UnhealthyThreshold 连续探测失败多少次后实例变为不健康
+	UnhealthyThreshold int
+}
+
+// ParseProbePolicy 解析服务元数据中定义的主动探测策略，没有定义探测策略时返回 nil
+func ParseProbePolicy(metadata map[string]string) (*ProbePolicy, error) {
+	probeType, ok := metadata[probeKeyType]
+	if !ok {
+		for key := range metadata {
+			if strings.HasPrefix(key, MetadataServiceProbePrefix) {
+				return nil, fmt.Errorf("metadata %s requires %s", key, probeKeyType)
+			}
+		}
+		return nil, nil
+	}
+	policy := &ProbePolicy{
+		Type:               probeType,
+		Path:               "/",
+		Interval:           defaultProbeInterval,
+		Timeout:            defaultProbeTimeout,
+		HealthyThreshold:   defaultProbeHealthyThreshold,
+		UnhealthyThreshold: defaultProbeUnhealthyThreshold,
+	}
+	switch probeType {
+	case ProbeTypeHTTP, ProbeTypeHTTPS, ProbeTypeTCP, ProbeTypeGRPC:
+	default:
+		return nil, fmt.Errorf("metadata %s: unsupported probe type %q", probeKeyType, probeType)
+	}
+	var err error
+	for key, value := range metadata {
+		if !strings.HasPrefix(key, MetadataServiceProbePrefix) {
+			continue
+		}
+		switch key {
+		case probeKeyType:
+		case probeKeyPort:
+			var port uint64
+			if port, err = strconv.ParseUint(value, 10, 16); err == nil && port == 0 {
+				err = fmt.Errorf("port is zero")
+			}
+			policy.Port = uint32(port)
+		case probeKeyPath:
+			if !strings.HasPrefix(value, "/") {
+				err = fmt.Errorf("path must start with /")
+			}
+			policy.Path = value
+		case probeKeyGRPCService:
+			policy.GRPCService = value
+		case probeKeyInterval:
+			policy.Interval, err = time.ParseDuration(value)
+		case probeKeyTimeout:
+			policy.Timeout, err = time.ParseDuration(value)
+		case probeKeyHealthyThreshold:
+			policy.HealthyThreshold, err = parseProbeThreshold(value)
+		case probeKeyUnhealthyThreshold:
+			policy.UnhealthyThreshold, err = parseProbeThreshold(value)
+		default:
+			err = fmt.Errorf("unknown probe metadata")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("metadata %s=%s: %w", key, value, err)
+		}
+	}
+	if policy.Interval < minProbeInterval {
+		return nil, fmt.Errorf("metadata %s: interval must not be less than %s", probeKeyInterval, minProbeInterval)
+	}
+	if policy.Timeout <= 0 || policy.Timeout > policy.Interval {
+		return nil, fmt.Errorf("metadata %s: timeout must be positive and not greater than interval",
+			probeKeyTimeout)
+	}
+	return policy, nil
+}
+
+func parseProbeThreshold(value string) (int, error) {
+	threshold, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, err
+	}
+	if threshold < 1 || threshold > maxProbeThreshold {
+		return 0, fmt.Errorf("threshold must be between 1 and %d", maxProbeThreshold)
+	}
+	return threshold, nil
+}
@@ -0,0 +1,76 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseProbePolicy(t *testing.T) {
+	policy, err := ParseProbePolicy(map[string]string{"env": "prod"})
+	assert.NoError(t, err)
+	assert.Nil(t, policy)
+
+	policy, err = ParseProbePolicy(map[string]string{"internal-probe.type": "tcp"})
+	assert.NoError(t, err)
+	assert.Equal(t, &ProbePolicy{
+		Type:               ProbeTypeTCP,
+		Path:               "/",
+		Interval:           defaultProbeInterval,
+		Timeout:            defaultProbeTimeout,
+		HealthyThreshold:   defaultProbeHealthyThreshold,
+		UnhealthyThreshold: defaultProbeUnhealthyThreshold,
+	}, policy)
+
+	policy, err = ParseProbePolicy(map[string]string{
+		"internal-probe.type":                "http",
+		"internal-probe.port":                "15021",
+		"internal-probe.path":                "/healthz/ready",
+		"internal-probe.interval":            "5s",
+		"internal-probe.timeout":             "1s",
+		"internal-probe.healthy-threshold":   "2",
+		"internal-probe.unhealthy-threshold": "5",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(15021), policy.Port)
+	assert.Equal(t, "/healthz/ready", policy.Path)
+	assert.Equal(t, 5*time.Second, policy.Interval)
+	assert.Equal(t, time.Second, policy.Timeout)
+	assert.Equal(t, 2, policy.HealthyThreshold)
+	assert.Equal(t, 5, policy.UnhealthyThreshold)
+
+	invalid := []map[string]string{
+		{"internal-probe.path": "/healthz"},
+		{"internal-probe.type": "udp"},
+		{"internal-probe.type": "http", "internal-probe.port": "0"},
+		{"internal-probe.type": "http", "internal-probe.port": "65536"},
+		{"internal-probe.type": "http", "internal-probe.path": "healthz"},
+		{"internal-probe.type": "http", "internal-probe.interval": "500ms", "internal-probe.timeout": "100ms"},
+		{"internal-probe.type": "http", "internal-probe.interval": "5s", "internal-probe.timeout": "10s"},
+		{"internal-probe.type": "http", "internal-probe.healthy-threshold": "0"},
+		{"internal-probe.type": "http", "internal-probe.unhealthy-threshold": "11"},
+		{"internal-probe.type": "http", "internal-probe.unknown": "1"},
+	}
+	for _, metadata := range invalid {
+		_, err := ParseProbePolicy(metadata)
+		assert.Error(t, err, metadata)
+	}
+}
@@ -0,0 +1,133 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+package model
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	apimodel "github.com/polarismesh/specification/source/go/api/v1/model"
+	apiservice "github.com/polarismesh/specification/source/go/api/v1/service_manage"
+
+	"github.com/polarismesh/polaris/common/utils"
+)
+
+const (
+	// MetadataServiceSubsetPrefix 服务元数据中定义实例分组的 key 前缀，value 为实例元数据的标签选择器
+	// 例如 internal-subset.canary=version=v2,env in (pre,prod)
+	MetadataServiceSubsetPrefix = "internal-subset."
+	// SubsetLabelKey 路由规则的目标标签中引用实例分组的 key，value 为分组名称
+	SubsetLabelKey = "internal-subset"
+	// MaxServiceSubsets 单个服务最多定义的实例分组数
+	MaxServiceSubsets = 16
+)
+
+// subsetNameRegex 分组名称作为 cluster 名称的一部分，只允许小写字母、数字以及中划线
+var subsetNameRegex = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?$`)
+
+// ServiceSubset 服务下按照实例元数据划分的实例分组
+type ServiceSubset struct {
+	Name     string
+	Expr     string
+	Selector LabelSelector
+}
+
+// Matches 实例是否属于该分组
+func (s *ServiceSubset) Matches(ins *apiservice.Instance) bool {
+	return s.Selector.Matches(ins.GetMetadata())
+}
+
+// MatchStrings 将分组的标签选择器转换为路由规则的目标标签
+func (s *ServiceSubset) MatchStrings() map[string]*apimodel.MatchString {
+	ret := make(map[string]*apimodel.MatchString, len(s.Selector))
+	for _, requirement := range s.Selector {
+		matcher := &apimodel.MatchString{Value: utils.NewStringValue(strings.Join(requirement.Values, ","))}
+		switch requirement.Operator {
+		case LabelOpEquals:
+			matcher.Type = apimodel.MatchString_EXACT
+		case LabelOpNotEquals:
+			matcher.Type = apimodel.MatchString_NOT_EQUALS
+		case LabelOpIn:
+			matcher.Type = apimodel.MatchString_IN
+		case LabelOpNotIn:
+			matcher.Type = apimodel.MatchString_NOT_IN
+		case LabelOpExists:
+			matcher.Type = apimodel.MatchString_REGEX
+			matcher.Value = utils.NewStringValue(".+")
+		}
+		ret[requirement.Key] = matcher
+	}
+	return ret
+}
+
+// ParseServiceSubsets 解析服务元数据中定义的实例分组，按照分组名称排序
+// 分组需要能够转换为路由规则的目标标签，因此不支持 !key，同一个 key 也只能出现一次
+func ParseServiceSubsets(meta map[string]string) ([]*ServiceSubset, error) {
+	var subsets []*ServiceSubset
+	for key, value := range meta {
+		name, ok := strings.CutPrefix(key, MetadataServiceSubsetPrefix)
+		if !ok {
+			continue
+		}
+		if !subsetNameRegex.MatchString(name) {
+			return nil, fmt.Errorf("subset name %q is invalid", name)
+		}
+		selector, err := ParseLabelSelector(value)
+		if err != nil {
+			return nil, fmt.Errorf("subset %s: %w", name, err)
+		}
+		keys := make(map[string]struct{}, len(selector))
+		for _, requirement := range selector {
+			if requirement.Operator == LabelOpNotExists {
+				return nil, fmt.Errorf("subset %s: operator ! is not supported", name)
+			}
+			if _, ok := keys[requirement.Key]; ok {
+				return nil, fmt.Errorf("subset %s: label %s duplicated", name, requirement.Key)
+			}
+			keys[requirement.Key] = struct{}{}
+		}
+		subsets = append(subsets, &ServiceSubset{Name: name, Expr: value, Selector: selector})
+	}
+	if len(subsets) > MaxServiceSubsets {
+		return nil, fmt.Errorf("subsets more than %d", MaxServiceSubsets)
+	}
+	sort.Slice(subsets, func(i, j int) bool {
+		return subsets[i].Name < subsets[j].Name
+	})
+	return subsets, nil
+}
+
+// ServiceSubsets 获取服务定义的实例分组，元数据不合法时忽略
+func ServiceSubsets(meta map[string]string) []*ServiceSubset {
+	subsets, err := ParseServiceSubsets(meta)
+	if err != nil {
+		return nil
+	}
+	return subsets
+}
+
+// FindServiceSubset 根据名称查找实例分组
+func FindServiceSubset(subsets []*ServiceSubset, name string) (*ServiceSubset, bool) {
+	for i := range subsets {
+		if subsets[i].Name == name {
+			return subsets[i], true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,62 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+package model
+
+import (
+	"testing"
+
+	apimodel "github.com/polarismesh/specification/source/go/api/v1/model"
+	apiservice "github.com/polarismesh/specification/source/go/api/v1/service_manage"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseServiceSubsets(t *testing.T) {
+	subsets, err := ParseServiceSubsets(map[string]string{
+		"owner":                              "polaris",
+		MetadataServiceSubsetPrefix + "v2":   "version=v2",
+		MetadataServiceSubsetPrefix + "beta": "env in (pre,test),canary",
+	})
+	assert.NoError(t, err)
+	assert.Len(t, subsets, 2)
+	assert.Equal(t, "beta", subsets[0].Name)
+	assert.Equal(t, "v2", subsets[1].Name)
+
+	assert.True(t, subsets[0].Matches(&apiservice.Instance{Metadata: map[string]string{"env": "pre", "canary": "1"}}))
+	assert.False(t, subsets[0].Matches(&apiservice.Instance{Metadata: map[string]string{"env": "pre"}}))
+	assert.False(t, subsets[1].Matches(&apiservice.Instance{}))
+
+	matchers := subsets[0].MatchStrings()
+	assert.Equal(t, apimodel.MatchString_IN, matchers["env"].GetType())
+	assert.Equal(t, "pre,test", matchers["env"].GetValue().GetValue())
+	assert.Equal(t, apimodel.MatchString_REGEX, matchers["canary"].GetType())
+
+	for _, meta := range []map[string]string{
+		{MetadataServiceSubsetPrefix + "V2": "version=v2"},
+		{MetadataServiceSubsetPrefix + "v2": ""},
+		{MetadataServiceSubsetPrefix + "v2": "!version"},
+		{MetadataServiceSubsetPrefix + "v2": "version!=v1,version!=v3"},
+	} {
+		_, err = ParseServiceSubsets(meta)
+		assert.Error(t, err, meta)
+	}
+
+	subsets = ServiceSubsets(map[string]string{MetadataServiceSubsetPrefix + "v2": "version=v2"})
+	subset, ok := FindServiceSubset(subsets, "v2")
+	assert.True(t, ok)
+	assert.Equal(t, "version=v2", subset.Expr)
+	assert.Empty(t, ServiceSubsets(map[string]string{MetadataServiceSubsetPrefix + "v2": "!version"}))
+}
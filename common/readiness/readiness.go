@@ -0,0 +1,153 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+package readiness
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/polarismesh/polaris/common/model"
+)
+
+const (
+	// ComponentStore 存储层连接
+	ComponentStore = "store"
+	// ComponentCache 资源缓存的首次加载
+	ComponentCache = "cache"
+)
+
+// Checker 组件就绪检查，返回 nil 表示组件已经就绪
+type Checker func() error
+
+// ComponentStatus 单个组件的就绪状态
+type ComponentStatus struct {
+	Name    string `json:"name"`
+	Ready   bool   `json:"ready"`
+	Message string `json:"message,omitempty"`
+}
+
+// Report 全部组件的就绪状态，所有组件都就绪时节点才可以接收流量
+type Report struct {
+	Ready      bool               `json:"ready"`
+	Components []*ComponentStatus `json:"components"`
+}
+
+var (
+	lock     sync.RWMutex
+	checkers = map[string]Checker{}
+)
+
+// Register 注册组件的就绪检查，同名组件会被覆盖
+func Register(name string, checker Checker) {
+	lock.Lock()
+	defer lock.Unlock()
+	checkers[name] = checker
+}
+
+// Deregister 移除组件的就绪检查
+func Deregister(name string) {
+	lock.Lock()
+	defer lock.Unlock()
+	delete(checkers, name)
+}
+
+// Pending 注册一个初始未就绪的组件，调用返回的函数后组件就绪
+func Pending(name, reason string) func() {
+	var (
+		mutex sync.RWMutex
+		ready bool
+	)
+	Register(name, func() error {
+		mutex.RLock()
+		defer mutex.RUnlock()
+		if ready {
+			return nil
+		}
+		return errors.New(reason)
+	})
+	return func() {
+		mutex.Lock()
+		defer mutex.Unlock()
+		ready = true
+	}
+}
+
+// Check 执行全部组件的就绪检查，组件按照名称排序
+func Check() *Report {
+	lock.RLock()
+	names := make([]string, 0, len(checkers))
+	for name := range checkers {
+		names = append(names, name)
+	}
+	lock.RUnlock()
+	sort.Strings(names)
+
+	report := &Report{Ready: true, Components: make([]*ComponentStatus, 0, len(names))}
+	for _, name := range names {
+		component, ok := CheckComponent(name)
+		if !ok {
+			continue
+		}
+		report.Ready = report.Ready && component.Ready
+		report.Components = append(report.Components, component)
+	}
+	return report
+}
+
+// CheckComponent 执行单个组件的就绪检查，组件不存在时返回 false
+func CheckComponent(name string) (*ComponentStatus, bool) {
+	lock.RLock()
+	checker, ok := checkers[name]
+	lock.RUnlock()
+	if !ok {
+		return nil, false
+	}
+	component := &ComponentStatus{Name: name, Ready: true}
+	if err := checker(); err != nil {
+		component.Ready = false
+		component.Message = err.Error()
+	}
+	return component, true
+}
+
+// LeaderElections 能够查询选举记录的存储层
+type LeaderElections interface {
+	ListLeaderElections() ([]*model.LeaderElection, error)
+}
+
+// LeaderElected 选举已经产生有效的 leader 时就绪，leader 不要求是当前节点
+func LeaderElected(s LeaderElections, key string) Checker {
+	return func() error {
+		elections, err := s.ListLeaderElections()
+		if err != nil {
+			return err
+		}
+		for i := range elections {
+			if elections[i].ElectKey == key && elections[i].Valid {
+				return nil
+			}
+		}
+		return fmt.Errorf("leader of %s not elected", key)
+	}
+}
+
+// ComponentLeader 选举组件的名称
+func ComponentLeader(key string) string {
+	return "leader:" + key
+}
@@ -0,0 +1,68 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+package readiness
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/polarismesh/polaris/common/model"
+)
+
+type mockElections []*model.LeaderElection
+
+func (m mockElections) ListLeaderElections() ([]*model.LeaderElection, error) {
+	return m, nil
+}
+
+func TestCheck(t *testing.T) {
+	markReady := Pending("test-cache", "cache is warming up")
+	Register("test-store", func() error { return nil })
+	defer Deregister("test-cache")
+	defer Deregister("test-store")
+
+	report := Check()
+	assert.False(t, report.Ready)
+	assert.Len(t, report.Components, 2)
+	assert.Equal(t, "test-cache", report.Components[0].Name)
+	assert.False(t, report.Components[0].Ready)
+	assert.Equal(t, "cache is warming up", report.Components[0].Message)
+	assert.True(t, report.Components[1].Ready)
+
+	markReady()
+	assert.True(t, Check().Ready)
+
+	Register("test-store", func() error { return errors.New("store disconnected") })
+	component, ok := CheckComponent("test-store")
+	assert.True(t, ok)
+	assert.False(t, component.Ready)
+	assert.False(t, Check().Ready)
+
+	_, ok = CheckComponent("not-exist")
+	assert.False(t, ok)
+}
+
+func TestLeaderElected(t *testing.T) {
+	checker := LeaderElected(mockElections{{ElectKey: "polaris.checker", Valid: false}}, "polaris.checker")
+	assert.Error(t, checker())
+	checker = LeaderElected(mockElections{{ElectKey: "polaris.checker", Valid: true}}, "polaris.checker")
+	assert.NoError(t, checker())
+	checker = LeaderElected(mockElections{{ElectKey: "polaris.checker", Valid: true}}, "polaris.maintain.job")
+	assert.Error(t, checker())
+}
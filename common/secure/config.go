@@ -29,8 +29,10 @@ type TLSConfig struct {
 	CertFile string `mapstructure:"certFile"`
 	// KeyFile 密钥
 	KeyFile string `mapstructure:"keyFile"`
-	// TrustedCAFile CA 证书
+	// TrustedCAFile CA 证书，服务端用于校验客户端证书
 	TrustedCAFile string `mapstructure:"trustedCAFile"`
+	// ClientCertAuth 服务端要求客户端必须提供由 TrustedCAFile 签发的证书
+	ClientCertAuth bool `mapstructure:"clientCertAuth"`
 	// ServerName 客户端发送的 Server Name Indication 扩展的值
 	ServerName string `mapstructure:"serverName"`
 
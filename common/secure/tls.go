@@ -19,6 +19,10 @@ package secure
 
 import (
 	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
 )
 
 // TLSInfo tls 配置信息
@@ -74,3 +78,44 @@ func (t *TLSInfo) IsEmpty() bool {
 	}
 	return true
 }
+
+// ServerTLSConfig 创建服务端的 tls 配置。设置了 TrustedCAFile 时使用该 CA 校验客户端证书，
+// ClientCertAuth 为 true 时要求客户端必须提供证书，否则只校验客户端主动提供的证书
+func (t *TLSInfo) ServerTLSConfig() (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(t.CertFile, t.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+	config := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		CipherSuites: t.CipherSuites,
+	}
+	if t.TrustedCAFile == "" {
+		if t.ClientCertAuth {
+			return nil, errors.New("trustedCAFile must be set when clientCertAuth")
+		}
+		return config, nil
+	}
+	data, err := os.ReadFile(t.TrustedCAFile)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("no certificate found in trustedCAFile %s", t.TrustedCAFile)
+	}
+	config.ClientCAs = pool
+	config.ClientAuth = tls.VerifyClientCertIfGiven
+	if t.ClientCertAuth {
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return config, nil
+}
+
+// VerifiedPeerCertificates 返回经过校验的客户端证书链，叶子证书在第一个，未校验客户端证书时返回 nil
+func VerifiedPeerCertificates(state *tls.ConnectionState) []*x509.Certificate {
+	if state == nil || len(state.VerifiedChains) == 0 {
+		return nil
+	}
+	return state.VerifiedChains[0]
+}
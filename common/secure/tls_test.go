@@ -0,0 +1,162 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package secure
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func newTestCA(t *testing.T) *testCA {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	tpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: "polaris-test-ca"},
+		NotBefore:             time.Now().Add(-time.Minute),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tpl, tpl, &key.PublicKey, key)
+	assert.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	assert.NoError(t, err)
+	return &testCA{cert: cert, key: key}
+}
+
+// issue 签发证书，返回 tls 证书以及 PEM 编码的证书和私钥
+func (ca *testCA) issue(t *testing.T, commonName string, usage x509.ExtKeyUsage) (tls.Certificate, []byte, []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	tpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName},
+		DNSNames:     []string{commonName},
+		NotBefore:    time.Now().Add(-time.Minute),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tpl, ca.cert, &key.PublicKey, ca.key)
+	assert.NoError(t, err)
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	assert.NoError(t, err)
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer})
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	assert.NoError(t, err)
+	return cert, certPEM, keyPEM
+}
+
+// handshake 使用 net.Pipe 完成一次握手，返回服务端看到的连接状态
+func handshake(serverConf, clientConf *tls.Config) (tls.ConnectionState, error) {
+	serverConn, clientConn := net.Pipe()
+	defer serverConn.Close()
+	defer clientConn.Close()
+
+	server := tls.Server(serverConn, serverConf)
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- tls.Client(clientConn, clientConf).Handshake()
+		_ = clientConn.Close()
+	}()
+	err := server.Handshake()
+	<-errCh
+	return server.ConnectionState(), err
+}
+
+func Test_ServerTLSConfig(t *testing.T) {
+	ca := newTestCA(t)
+	dir := t.TempDir()
+	_, serverCert, serverKey := ca.issue(t, "polaris.test", x509.ExtKeyUsageServerAuth)
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "server.pem"), serverCert, 0600))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "server.key"), serverKey, 0600))
+	caFile := filepath.Join(dir, "ca.pem")
+	assert.NoError(t, os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE",
+		Bytes: ca.cert.Raw}), 0600))
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	clientConf := func(certs ...tls.Certificate) *tls.Config {
+		return &tls.Config{ServerName: "polaris.test", RootCAs: roots, Certificates: certs}
+	}
+	clientCert, _, _ := ca.issue(t, "client-a", x509.ExtKeyUsageClientAuth)
+	untrusted, _, _ := newTestCA(t).issue(t, "client-b", x509.ExtKeyUsageClientAuth)
+
+	info := &TLSInfo{
+		CertFile:       filepath.Join(dir, "server.pem"),
+		KeyFile:        filepath.Join(dir, "server.key"),
+		TrustedCAFile:  caFile,
+		ClientCertAuth: true,
+	}
+
+	t.Run("校验通过的客户端证书", func(t *testing.T) {
+		conf, err := info.ServerTLSConfig()
+		assert.NoError(t, err)
+		state, err := handshake(conf, clientConf(clientCert))
+		assert.NoError(t, err)
+		certs := VerifiedPeerCertificates(&state)
+		assert.NotEmpty(t, certs)
+		assert.Equal(t, "client-a", certs[0].Subject.CommonName)
+	})
+
+	t.Run("要求客户端证书时拒绝未提供证书以及不受信任的证书", func(t *testing.T) {
+		conf, err := info.ServerTLSConfig()
+		assert.NoError(t, err)
+		_, err = handshake(conf, clientConf())
+		assert.Error(t, err)
+		_, err = handshake(conf, clientConf(untrusted))
+		assert.Error(t, err)
+	})
+
+	t.Run("不要求客户端证书时允许未提供证书", func(t *testing.T) {
+		optional := *info
+		optional.ClientCertAuth = false
+		conf, err := optional.ServerTLSConfig()
+		assert.NoError(t, err)
+		state, err := handshake(conf, clientConf())
+		assert.NoError(t, err)
+		assert.Nil(t, VerifiedPeerCertificates(&state))
+	})
+
+	t.Run("要求客户端证书时必须设置 CA", func(t *testing.T) {
+		noCA := *info
+		noCA.TrustedCAFile = ""
+		_, err := noCA.ServerTLSConfig()
+		assert.Error(t, err)
+	})
+}
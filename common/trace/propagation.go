@@ -0,0 +1,32 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package trace
+
+import (
+	"go.opentelemetry.io/otel/propagation"
+)
+
+const (
+	// HeaderTraceparent W3C Trace Context 的 traceparent 请求头
+	HeaderTraceparent = "traceparent"
+	// HeaderTracestate W3C Trace Context 的 tracestate 请求头
+	HeaderTracestate = "tracestate"
+)
+
+// propagator 按照 W3C Trace Context 解析以及编码链路信息
+var propagator = propagation.TraceContext{}
@@ -0,0 +1,140 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package trace
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+// SpanKind span 的类型
+type SpanKind = oteltrace.SpanKind
+
+const (
+	// SpanKindInternal 进程内部的调用，比如缓存以及存储层的调用
+	SpanKindInternal = oteltrace.SpanKindInternal
+	// SpanKindServer apiserver 接收到的请求
+	SpanKindServer = oteltrace.SpanKindServer
+)
+
+// Span 对 otel span 的封装，nil 的 span 可以安全调用
+type Span struct {
+	span oteltrace.Span
+}
+
+// SpanContext 当前 span 的链路信息
+func (s *Span) SpanContext() oteltrace.SpanContext {
+	if s == nil {
+		return oteltrace.SpanContext{}
+	}
+	return s.span.SpanContext()
+}
+
+// Traceparent 编码为 traceparent 请求头
+func (s *Span) Traceparent() string {
+	if s == nil {
+		return ""
+	}
+	carrier := propagation.MapCarrier{}
+	propagator.Inject(oteltrace.ContextWithSpanContext(context.Background(), s.span.SpanContext()), carrier)
+	return carrier.Get(HeaderTraceparent)
+}
+
+// SetAttribute 设置 span 的属性
+func (s *Span) SetAttribute(key, value string) {
+	if s == nil {
+		return
+	}
+	s.span.SetAttributes(attribute.String(key, value))
+}
+
+// SetError 标记调用失败
+func (s *Span) SetError(msg string) {
+	if s == nil {
+		return
+	}
+	s.span.SetStatus(codes.Error, msg)
+}
+
+// End 结束 span 并交给 exporter 上报，重复调用只有第一次生效
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.span.End()
+}
+
+// ContextWithSpan 将 span 放入 ctx
+func ContextWithSpan(ctx context.Context, span *Span) context.Context {
+	if span == nil {
+		return ctx
+	}
+	return oteltrace.ContextWithSpan(ctx, span.span)
+}
+
+// SpanFromContext 获取 ctx 中的 span，不存在时返回 nil，nil 的 span 可以安全调用
+func SpanFromContext(ctx context.Context) *Span {
+	if ctx == nil {
+		return nil
+	}
+	span := oteltrace.SpanFromContext(ctx)
+	if !span.SpanContext().IsValid() {
+		return nil
+	}
+	return &Span{span: span}
+}
+
+// StartSpan 基于 ctx 中的 span 创建子 span，ctx 中没有 span 时不创建
+func StartSpan(ctx context.Context, name string, kind SpanKind) (context.Context, *Span) {
+	if SpanFromContext(ctx) == nil {
+		return ctx, nil
+	}
+	ctx, span := getTracer().tracer.Start(ctx, name, oteltrace.WithSpanKind(kind))
+	return ctx, &Span{span: span}
+}
+
+// WithSpan 以 ctx 中 span 的子 span 记录一次存储层或者缓存的调用，调用失败时标记 span 的错误
+func WithSpan(ctx context.Context, name string, call func() error) error {
+	_, span := StartSpan(ctx, name, SpanKindInternal)
+	defer span.End()
+	err := call()
+	if err != nil {
+		span.SetError(err.Error())
+	}
+	return err
+}
+
+// StartServerSpan 基于调用方传递的 traceparent 创建 apiserver 的 span，没有合法的 traceparent 时开启新的链路
+// 未开启链路追踪时仍然透传调用方的链路信息，保证下游可以串联
+func StartServerSpan(ctx context.Context, name, traceparent, tracestate string) (context.Context, *Span) {
+	ctx = propagator.Extract(ctx, propagation.MapCarrier{
+		HeaderTraceparent: traceparent,
+		HeaderTracestate:  tracestate,
+	})
+	tracer := getTracer()
+	if !tracer.enabled() {
+		// 未开启时 ctx 中只有调用方的链路信息，没有时不创建 span
+		return ctx, SpanFromContext(ctx)
+	}
+	ctx, span := tracer.tracer.Start(ctx, name, oteltrace.WithSpanKind(SpanKindServer))
+	return ctx, &Span{span: span}
+}
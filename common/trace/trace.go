@@ -0,0 +1,170 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package trace
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+const (
+	defaultServiceName  = "polaris-server"
+	instrumentationName = "github.com/polarismesh/polaris"
+
+	exportTimeout   = 5 * time.Second
+	shutdownTimeout = 5 * time.Second
+)
+
+// Config 链路追踪的配置，在 bootstrap 中配置
+type Config struct {
+	// Enable 是否开启链路追踪
+	Enable bool `yaml:"enable"`
+	// Endpoint OTLP/HTTP 的上报地址，比如 http://127.0.0.1:4318/v1/traces
+	Endpoint string `yaml:"endpoint"`
+	// ServiceName 上报的服务名
+	ServiceName string `yaml:"serviceName"`
+	// SampleRate 没有携带 traceparent 的请求的采样率，取值 (0, 1]，未配置时全部采样
+	SampleRate float64 `yaml:"sampleRate"`
+	// Headers 上报时携带的请求头，比如鉴权信息
+	Headers map[string]string `yaml:"headers"`
+}
+
+var (
+	globalTracer atomic.Value
+	tracerLock   sync.Mutex
+)
+
+func init() {
+	globalTracer.Store(&tracer{tracer: oteltrace.NewNoopTracerProvider().Tracer(instrumentationName)})
+	// 上报失败等异步错误由 otel 回调，记录到 apiserver 的日志中
+	otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) {
+		log.Errorf("[Trace] %s", err.Error())
+	}))
+}
+
+// tracer 全局的链路追踪器，未开启时使用 noop 的 tracer，只透传调用方的链路信息
+type tracer struct {
+	provider *sdktrace.TracerProvider
+	tracer   oteltrace.Tracer
+}
+
+func getTracer() *tracer {
+	return globalTracer.Load().(*tracer)
+}
+
+func (t *tracer) enabled() bool {
+	return t.provider != nil
+}
+
+// Initialize 初始化链路追踪，重复初始化时会先停止之前的 TracerProvider
+func Initialize(conf *Config) error {
+	if conf == nil || !conf.Enable {
+		Shutdown()
+		return nil
+	}
+	exporter, err := newOTLPExporter(conf)
+	if err != nil {
+		return err
+	}
+	return initialize(conf, sdktrace.NewBatchSpanProcessor(exporter))
+}
+
+// newOTLPExporter 按照 Endpoint 创建 OTLP/HTTP 的 exporter
+func newOTLPExporter(conf *Config) (sdktrace.SpanExporter, error) {
+	if conf.Endpoint == "" {
+		return nil, errors.New("trace endpoint is empty")
+	}
+	endpoint, err := url.Parse(conf.Endpoint)
+	if err != nil {
+		return nil, err
+	}
+	if endpoint.Host == "" || (endpoint.Scheme != "http" && endpoint.Scheme != "https") {
+		return nil, errors.New("trace endpoint must be an http or https url")
+	}
+	opts := []otlptracehttp.Option{
+		otlptracehttp.WithEndpoint(endpoint.Host),
+		otlptracehttp.WithTimeout(exportTimeout),
+	}
+	if endpoint.Path != "" {
+		opts = append(opts, otlptracehttp.WithURLPath(endpoint.Path))
+	}
+	if endpoint.Scheme == "http" {
+		opts = append(opts, otlptracehttp.WithInsecure())
+	}
+	if len(conf.Headers) > 0 {
+		opts = append(opts, otlptracehttp.WithHeaders(conf.Headers))
+	}
+	// New 不会连接上报地址，上报失败时由 otel 的 ErrorHandler 记录
+	return otlptracehttp.New(context.Background(), opts...)
+}
+
+func initialize(conf *Config, processor sdktrace.SpanProcessor) error {
+	if conf.SampleRate < 0 || conf.SampleRate > 1 {
+		return errors.New("trace sampleRate must be between 0 and 1")
+	}
+	if conf.ServiceName == "" {
+		conf.ServiceName = defaultServiceName
+	}
+	// 携带 traceparent 的请求沿用调用方的采样结果
+	sampler := sdktrace.AlwaysSample()
+	if conf.SampleRate > 0 && conf.SampleRate < 1 {
+		sampler = sdktrace.TraceIDRatioBased(conf.SampleRate)
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithSampler(sdktrace.ParentBased(sampler)),
+		sdktrace.WithSpanProcessor(processor),
+		sdktrace.WithResource(resource.NewSchemaless(semconv.ServiceName(conf.ServiceName))),
+	)
+
+	tracerLock.Lock()
+	defer tracerLock.Unlock()
+	shutdownLocked()
+	globalTracer.Store(&tracer{provider: provider, tracer: provider.Tracer(instrumentationName)})
+	return nil
+}
+
+// Shutdown 上报剩余的 span 后停止链路追踪
+func Shutdown() {
+	tracerLock.Lock()
+	defer tracerLock.Unlock()
+	shutdownLocked()
+}
+
+func shutdownLocked() {
+	old := getTracer()
+	if !old.enabled() {
+		return
+	}
+	globalTracer.Store(&tracer{tracer: oteltrace.NewNoopTracerProvider().Tracer(instrumentationName)})
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := old.provider.Shutdown(ctx); err != nil {
+		log.Errorf("[Trace] shutdown tracer provider: %s", err.Error())
+	}
+}
@@ -0,0 +1,134 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+package trace
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+const (
+	testTraceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+)
+
+func TestStartServerSpan_Disabled(t *testing.T) {
+	assert.NoError(t, Initialize(&Config{Enable: false}))
+
+	// 未开启时没有 traceparent 不创建 span
+	ctx, span := StartServerSpan(context.Background(), "GET /", "", "")
+	assert.Nil(t, span)
+	assert.Nil(t, SpanFromContext(ctx))
+
+	// 非法的 traceparent 被忽略
+	_, span = StartServerSpan(context.Background(), "GET /",
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01", "")
+	assert.Nil(t, span)
+
+	// 未开启时透传调用方的链路信息
+	ctx, span = StartServerSpan(context.Background(), "GET /", testTraceparent, "k=v")
+	assert.NotNil(t, span)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", span.SpanContext().TraceID().String())
+	assert.Equal(t, "k=v", span.SpanContext().TraceState().String())
+	assert.Equal(t, testTraceparent, span.Traceparent())
+
+	_, child := StartSpan(ctx, "cache", SpanKindInternal)
+	assert.Equal(t, span.SpanContext().TraceID(), child.SpanContext().TraceID())
+	child.SetAttribute("key", "value")
+	child.End()
+	span.End()
+
+	// nil 的 span 可以安全调用
+	var nilSpan *Span
+	nilSpan.SetAttribute("key", "value")
+	nilSpan.SetError("error")
+	nilSpan.End()
+	assert.Empty(t, nilSpan.Traceparent())
+}
+
+func TestStartServerSpan_Enabled(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	assert.NoError(t, initialize(&Config{Enable: true}, sdktrace.NewSimpleSpanProcessor(exporter)))
+	defer Shutdown()
+
+	ctx, span := StartServerSpan(context.Background(), "POST /naming/v1/instances", testTraceparent, "")
+	_, child := StartSpan(ctx, "store.CreateInstance", SpanKindInternal)
+	child.SetError("store error")
+	child.End()
+	span.SetAttribute("http.status_code", "200")
+	span.End()
+	// 重复结束不会重复上报
+	span.End()
+
+	spans := exporter.GetSpans()
+	assert.Len(t, spans, 2)
+	assert.Equal(t, "store.CreateInstance", spans[0].Name)
+	assert.Equal(t, codes.Error, spans[0].Status.Code)
+	assert.Equal(t, spans[1].SpanContext.SpanID(), spans[0].Parent.SpanID())
+	assert.Equal(t, "00f067aa0ba902b7", spans[1].Parent.SpanID().String())
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", spans[1].SpanContext.TraceID().String())
+	assert.Equal(t, oteltrace.SpanKindServer, spans[1].SpanKind)
+	assert.Equal(t, defaultServiceName, spans[1].Resource.Attributes()[0].Value.AsString())
+
+	// 没有 traceparent 时开启新的链路，根据采样率决定是否采样
+	_, root := StartServerSpan(context.Background(), "GET /", "", "")
+	assert.True(t, root.SpanContext().IsValid())
+	assert.True(t, root.SpanContext().IsSampled())
+	assert.NotEqual(t, "4bf92f3577b34da6a3ce929d0e0e4736", root.SpanContext().TraceID().String())
+	root.End()
+
+	// 调用方未采样时沿用调用方的采样结果
+	_, unsampled := StartServerSpan(context.Background(), "GET /",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00", "")
+	assert.False(t, unsampled.SpanContext().IsSampled())
+	unsampled.End()
+	assert.Len(t, exporter.GetSpans(), 3)
+}
+
+func TestExportSpans(t *testing.T) {
+	received := make(chan *http.Request, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- r
+	}))
+	defer server.Close()
+
+	assert.Error(t, Initialize(&Config{Enable: true}))
+	assert.Error(t, Initialize(&Config{Enable: true, Endpoint: "127.0.0.1:4318"}))
+	assert.Error(t, Initialize(&Config{Enable: true, Endpoint: server.URL, SampleRate: 2}))
+	assert.NoError(t, Initialize(&Config{
+		Enable:   true,
+		Endpoint: server.URL + "/v1/traces",
+		Headers:  map[string]string{"Authorization": "secret"},
+	}))
+
+	_, span := StartServerSpan(context.Background(), "POST /naming/v1/instances", testTraceparent, "")
+	span.End()
+
+	// 停止时上报剩余的 span
+	Shutdown()
+	req := <-received
+	assert.Equal(t, "/v1/traces", req.URL.Path)
+	assert.Equal(t, "application/x-protobuf", req.Header.Get("Content-Type"))
+	assert.Equal(t, "secret", req.Header.Get("Authorization"))
+}
@@ -20,6 +20,8 @@ package utils
 import (
 	"context"
 	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
 	"encoding/hex"
 	"errors"
 	"fmt"
@@ -341,6 +343,47 @@ func ParseClientIP(ctx context.Context) string {
 	return rid
 }
 
+// ParsePeerCertificates 从ctx中获取 mTLS 对端的客户端证书链
+func ParsePeerCertificates(ctx context.Context) []*x509.Certificate {
+	if ctx == nil {
+		return nil
+	}
+	certs, _ := ctx.Value(ContextPeerCertificates).([]*x509.Certificate)
+	return certs
+}
+
+// ParseBreakGlassToken 从ctx中获取 break-glass token
+func ParseBreakGlassToken(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	token, _ := ctx.Value(ContextBreakGlassTokenKey).(string)
+	return token
+}
+
+// ParseChangeSetID 从ctx中获取变更集 ID
+func ParseChangeSetID(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	changeSetID, _ := ctx.Value(ContextChangeSetIDKey).(string)
+	return changeSetID
+}
+
+// WithChangeSetID ctx 中没有变更集 ID 时生成一个新的变更集 ID，已经存在时保持不变
+func WithChangeSetID(ctx context.Context) context.Context {
+	if ctx == nil || ParseChangeSetID(ctx) != "" {
+		return ctx
+	}
+	return context.WithValue(ctx, ContextChangeSetIDKey, NewUUID())
+}
+
+// ScopedChangeSetID 将客户端指定的变更集 ID 绑定到调用方凭据上，不同调用方携带相同的 ID 也不会写入同一个变更集
+func ScopedChangeSetID(credential, changeSetID string) string {
+	sum := sha256.Sum256([]byte(credential + "\x00" + changeSetID))
+	return hex.EncodeToString(sum[:16])
+}
+
 // ParseAuthToken 从ctx中获取token
 func ParseAuthToken(ctx context.Context) string {
 	if ctx == nil {
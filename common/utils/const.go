@@ -46,6 +46,11 @@ const (
 	HeaderOwnerIDKey string = "X-Owner-ID"
 	// HeaderUserRoleKey user role key
 	HeaderUserRoleKey string = "X-Polaris-User-Role"
+	// HeaderBreakGlassTokenKey break-glass token key
+	HeaderBreakGlassTokenKey string = "X-Polaris-Break-Glass"
+	// HeaderChangeSetIDKey change-set id key, 同一调用方多次请求携带相同的变更集 ID 时，操作记录归属同一个变更集,
+	// 服务端实际使用的变更集 ID 由调用方凭据派生，并通过同名的响应头返回
+	HeaderChangeSetIDKey string = "X-Polaris-Change-Set"
 
 	// ContextAuthTokenKey auth token key
 	ContextAuthTokenKey = StringContext(HeaderAuthTokenKey)
@@ -73,4 +78,10 @@ const (
 	ContextIsFromSystem = StringContext("from-system")
 	// ContextOperator operator info
 	ContextOperator = StringContext("operator")
+	// ContextPeerCertificates mTLS peer certificates
+	ContextPeerCertificates = StringContext("peer-certificates")
+	// ContextBreakGlassTokenKey break-glass token key
+	ContextBreakGlassTokenKey = StringContext(HeaderBreakGlassTokenKey)
+	// ContextChangeSetIDKey change-set id key
+	ContextChangeSetIDKey = StringContext(HeaderChangeSetIDKey)
 )
@@ -19,13 +19,17 @@ package utils
 
 import (
 	"context"
+	"crypto/x509"
 	"encoding/hex"
 	"encoding/json"
 	"strings"
 
 	"github.com/google/uuid"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/peer"
+
+	"github.com/polarismesh/polaris/common/trace"
 )
 
 var emptyVal = struct{}{}
@@ -216,7 +220,7 @@ func IsNotEqualMap(req map[string]string, old map[string]string) bool {
 
 // ConvertGRPCContext 将GRPC上下文转换成内部上下文
 func ConvertGRPCContext(ctx context.Context) context.Context {
-	var requestID, userAgent, token string
+	var requestID, userAgent, token, breakGlassToken string
 
 	meta, exist := metadata.FromIncomingContext(ctx)
 	if exist {
@@ -231,13 +235,17 @@ func ConvertGRPCContext(ctx context.Context) context.Context {
 		if tokens := meta["x-polaris-token"]; len(tokens) > 0 {
 			token = tokens[0]
 		}
+		if tokens := meta["x-polaris-break-glass"]; len(tokens) > 0 {
+			breakGlassToken = tokens[0]
+		}
 	} else {
 		meta = metadata.MD{}
 	}
 
 	var (
-		clientIP = ""
-		address  = ""
+		clientIP  = ""
+		address   = ""
+		peerCerts []*x509.Certificate
 	)
 	if pr, ok := peer.FromContext(ctx); ok && pr.Addr != nil {
 		address = pr.Addr.String()
@@ -245,15 +253,26 @@ func ConvertGRPCContext(ctx context.Context) context.Context {
 		if len(addrSlice) == 2 {
 			clientIP = addrSlice[0]
 		}
+		// 只使用经过 CA 校验的证书链，未校验的客户端证书不能作为身份
+		if tlsInfo, ok := pr.AuthInfo.(credentials.TLSInfo); ok && len(tlsInfo.State.VerifiedChains) > 0 {
+			peerCerts = tlsInfo.State.VerifiedChains[0]
+		}
 	}
 
-	ctx = context.Background()
+	// 透传 apiserver 创建的 span
+	ctx = trace.ContextWithSpan(context.Background(), trace.SpanFromContext(ctx))
 	ctx = context.WithValue(ctx, ContextGrpcHeader, meta)
 	ctx = context.WithValue(ctx, StringContext("request-id"), requestID)
 	ctx = context.WithValue(ctx, StringContext("client-ip"), clientIP)
 	ctx = context.WithValue(ctx, ContextClientAddress, address)
 	ctx = context.WithValue(ctx, StringContext("user-agent"), userAgent)
 	ctx = context.WithValue(ctx, ContextAuthTokenKey, token)
+	if len(peerCerts) > 0 {
+		ctx = context.WithValue(ctx, ContextPeerCertificates, peerCerts)
+	}
+	if breakGlassToken != "" {
+		ctx = context.WithValue(ctx, ContextBreakGlassTokenKey, breakGlassToken)
+	}
 
 	return ctx
 }
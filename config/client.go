@@ -31,6 +31,7 @@ import (
 	"github.com/polarismesh/polaris/common/model"
 	"github.com/polarismesh/polaris/common/rsa"
 	commontime "github.com/polarismesh/polaris/common/time"
+	"github.com/polarismesh/polaris/common/trace"
 	"github.com/polarismesh/polaris/common/utils"
 )
 
@@ -41,6 +42,8 @@ type (
 // GetConfigFileWithCache 从缓存中获取配置文件，如果客户端的版本号大于服务端，则服务端重新加载缓存
 func (s *Server) GetConfigFileWithCache(ctx context.Context,
 	req *apiconfig.ClientConfigFileInfo) *apiconfig.ConfigClientResponse {
+	_, span := trace.StartSpan(ctx, "cache.GetConfigFileWithCache", trace.SpanKindInternal)
+	defer span.End()
 	namespace := req.GetNamespace().GetValue()
 	group := req.GetGroup().GetValue()
 	fileName := req.GetFileName().GetValue()
@@ -90,6 +93,8 @@ func formatClientRequest(ctx context.Context, client *apiconfig.ClientConfigFile
 // LongPullWatchFile .
 func (s *Server) LongPullWatchFile(ctx context.Context,
 	req *apiconfig.ClientWatchConfigFileRequest) (WatchCallback, error) {
+	_, span := trace.StartSpan(ctx, "cache.LongPullWatchFile", trace.SpanKindInternal)
+	defer span.End()
 	watchFiles := req.GetWatchFiles()
 
 	tmpWatchCtx := BuildTimeoutWatchCtx(ctx, 0)("", s.watchCenter.MatchBetaReleaseFile)
@@ -31,6 +31,7 @@ import (
 	api "github.com/polarismesh/polaris/common/api/v1"
 	"github.com/polarismesh/polaris/common/model"
 	commonstore "github.com/polarismesh/polaris/common/store"
+	"github.com/polarismesh/polaris/common/trace"
 	"github.com/polarismesh/polaris/common/utils"
 	"github.com/polarismesh/polaris/store"
 )
@@ -83,7 +84,9 @@ func (s *Server) handleCreateConfigFile(ctx context.Context, tx store.Tx,
 		return errResp
 	}
 	// 创建配置文件
-	if err := s.storage.CreateConfigFileTx(tx, savaData); err != nil {
+	if err := trace.WithSpan(ctx, "store.CreateConfigFileTx", func() error {
+		return s.storage.CreateConfigFileTx(tx, savaData)
+	}); err != nil {
 		log.Error("[Config][File] create config file error.", utils.RequestID(ctx),
 			utils.ZapNamespace(req.GetNamespace().GetValue()), utils.ZapGroup(req.GetGroup().GetValue()),
 			utils.ZapFileName(req.GetName().GetValue()), zap.Error(err))
@@ -140,7 +143,9 @@ func (s *Server) handleUpdateConfigFile(ctx context.Context, tx store.Tx,
 		return errResp
 	}
 
-	if err := s.storage.UpdateConfigFileTx(tx, updateData); err != nil {
+	if err := trace.WithSpan(ctx, "store.UpdateConfigFileTx", func() error {
+		return s.storage.UpdateConfigFileTx(tx, updateData)
+	}); err != nil {
 		log.Error("[Config][File] update config file error.", utils.RequestID(ctx),
 			utils.ZapNamespace(namespace), utils.ZapGroup(group), utils.ZapFileName(name), zap.Error(err))
 		return api.NewConfigFileResponse(commonstore.StoreCode2APICode(err), req)
@@ -522,6 +527,7 @@ func configFileRecordEntry(ctx context.Context, req *apiconfig.ConfigFile,
 		Namespace:     req.GetNamespace().GetValue(),
 		OperationType: operationType,
 		Operator:      utils.ParseOperator(ctx),
+		ChangeSetID:   utils.ParseChangeSetID(ctx),
 		Detail:        detail,
 		HappenTime:    time.Now(),
 	}
@@ -31,6 +31,7 @@ import (
 	api "github.com/polarismesh/polaris/common/api/v1"
 	"github.com/polarismesh/polaris/common/model"
 	commonstore "github.com/polarismesh/polaris/common/store"
+	"github.com/polarismesh/polaris/common/trace"
 	"github.com/polarismesh/polaris/common/utils"
 )
 
@@ -76,6 +77,8 @@ func (s *Server) CreateConfigFileGroup(ctx context.Context, req *apiconfig.Confi
 	if err := s.afterConfigGroupResource(ctx, req); err != nil {
 		log.Error("[Config][Group] create config_file_group after resource",
 			utils.RequestID(ctx), zap.Error(err))
+		// 鉴权等后置处理失败时，回滚已经创建的配置分组，保证配置分组与默认授权要么同时存在，要么同时不存在
+		s.rollbackCreateConfigFileGroup(ctx, namespace, groupName)
 		return api.NewConfigResponse(apimodel.Code_ExecuteException)
 	}
 
@@ -83,6 +86,17 @@ func (s *Server) CreateConfigFileGroup(ctx context.Context, req *apiconfig.Confi
 	return api.NewConfigResponse(apimodel.Code_ExecuteSuccess)
 }
 
+// rollbackCreateConfigFileGroup 回滚已创建的配置分组
+func (s *Server) rollbackCreateConfigFileGroup(ctx context.Context, namespace, groupName string) {
+	if err := s.storage.DeleteConfigFileGroup(namespace, groupName); err != nil {
+		log.Error("[Config][Group] rollback create config file group fail", utils.RequestID(ctx),
+			utils.ZapNamespace(namespace), utils.ZapGroup(groupName), zap.Error(err))
+		return
+	}
+	log.Info("[Config][Group] rollback create config file group", utils.RequestID(ctx),
+		utils.ZapNamespace(namespace), utils.ZapGroup(groupName))
+}
+
 // UpdateConfigFileGroup 更新配置文件组
 func (s *Server) UpdateConfigFileGroup(ctx context.Context, req *apiconfig.ConfigFileGroup) *apiconfig.ConfigResponse {
 	namespace := req.Namespace.GetValue()
@@ -105,7 +119,9 @@ func (s *Server) UpdateConfigFileGroup(ctx context.Context, req *apiconfig.Confi
 		return api.NewConfigResponse(apimodel.Code_NoNeedUpdate)
 	}
 
-	if err := s.storage.UpdateConfigFileGroup(updateData); err != nil {
+	if err := trace.WithSpan(ctx, "store.UpdateConfigFileGroup", func() error {
+		return s.storage.UpdateConfigFileGroup(updateData)
+	}); err != nil {
 		log.Error("[Config][Group] update config file group failed. ", utils.RequestID(ctx),
 			utils.ZapNamespace(namespace), utils.ZapGroup(groupName), zap.Error(err))
 		return api.NewConfigResponse(commonstore.StoreCode2APICode(err))
@@ -181,7 +197,9 @@ func (s *Server) DeleteConfigFileGroup(ctx context.Context, namespace, name stri
 		return errResp
 	}
 
-	if err := s.storage.DeleteConfigFileGroup(namespace, name); err != nil {
+	if err := trace.WithSpan(ctx, "store.DeleteConfigFileGroup", func() error {
+		return s.storage.DeleteConfigFileGroup(namespace, name)
+	}); err != nil {
 		log.Error("[Config][Group] delete config file group failed. ", utils.RequestID(ctx),
 			utils.ZapNamespace(namespace), utils.ZapGroup(name), zap.Error(err))
 		return api.NewConfigResponse(commonstore.StoreCode2APICode(err))
@@ -279,6 +297,7 @@ func configGroupRecordEntry(ctx context.Context, req *apiconfig.ConfigFileGroup,
 		Namespace:     req.GetNamespace().GetValue(),
 		OperationType: operationType,
 		Operator:      utils.ParseOperator(ctx),
+		ChangeSetID:   utils.ParseChangeSetID(ctx),
 		Detail:        detail,
 		HappenTime:    time.Now(),
 	}
@@ -18,6 +18,8 @@
 package config_test
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"testing"
 
@@ -26,7 +28,9 @@ import (
 	"google.golang.org/protobuf/types/known/wrapperspb"
 
 	api "github.com/polarismesh/polaris/common/api/v1"
+	"github.com/polarismesh/polaris/common/model"
 	"github.com/polarismesh/polaris/common/utils"
+	"github.com/polarismesh/polaris/config"
 )
 
 var (
@@ -220,3 +224,26 @@ func TestConfigFileGroupCRUD(t *testing.T) {
 		assert.Equal(t, randomGroupSize, rsp2.Total.GetValue())
 	})
 }
+
+// failResourceHook 模拟鉴权阶段的后置处理失败
+type failResourceHook struct{}
+
+func (h *failResourceHook) Before(ctx context.Context, resourceType model.Resource) {
+}
+
+func (h *failResourceHook) After(ctx context.Context, resourceType model.Resource, res *config.ResourceEvent) error {
+	return errors.New("mock auth after resource operation fail")
+}
+
+func TestCreateConfigFileGroupRollback(t *testing.T) {
+	testSuit := newConfigCenterTestSuit(t)
+
+	testSuit.OriginConfigServer().SetResourceHooks(&failResourceHook{})
+	rsp := testSuit.ConfigServer().CreateConfigFileGroup(testSuit.DefaultCtx, assembleConfigFileGroup())
+	assert.Equal(t, uint32(apimodel.Code_ExecuteException), rsp.Code.GetValue())
+
+	// 鉴权后置处理失败时配置分组已经回滚，可以重新创建
+	testSuit.OriginConfigServer().SetResourceHooks()
+	rsp = testSuit.ConfigServer().CreateConfigFileGroup(testSuit.DefaultCtx, assembleConfigFileGroup())
+	assert.Equal(t, api.ExecuteSuccess, rsp.Code.GetValue(), rsp.GetInfo().GetValue())
+}
@@ -47,6 +47,7 @@ func Test_Initialize(t *testing.T) {
 	cacheMgr.EXPECT().ConfigFile().Return(nil).AnyTimes()
 	cacheMgr.EXPECT().Gray().Return(nil).AnyTimes()
 	cacheMgr.EXPECT().ConfigGroup().Return(nil).AnyTimes()
+	cacheMgr.EXPECT().User().Return(nil).AnyTimes()
 
 	_, _, err := auth.TestInitialize(context.Background(), &auth.Config{}, mockStore, cacheMgr)
 	assert.NoError(t, err)
@@ -0,0 +1,137 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+package federation
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	// ModePull 定期从远端集群拉取数据
+	ModePull = "pull"
+	// ModePush 定期将本集群的数据推送到远端集群
+	ModePush = "push"
+	// ModeBoth 同时拉取以及推送
+	ModeBoth = "both"
+
+	defaultInterval = 30 * time.Second
+	defaultTimeout  = 10 * time.Second
+)
+
+// Config 跨集群同步的配置
+type Config struct {
+	Open bool `yaml:"open"`
+	// ClusterID 本集群在联邦中的唯一标识，同步过来的数据会记录其归属的集群
+	ClusterID string `yaml:"clusterID"`
+	// Interval 与远端集群同步的间隔
+	Interval time.Duration `yaml:"interval"`
+	// Timeout 单次请求远端集群的超时时间
+	Timeout time.Duration `yaml:"timeout"`
+	// Namespaces 参与同步的命名空间，为空时同步全部命名空间
+	Namespaces []string `yaml:"namespaces"`
+	// Peers 远端集群
+	Peers []*PeerConfig `yaml:"peers"`
+}
+
+// PeerConfig 远端集群的配置
+type PeerConfig struct {
+	// ClusterID 远端集群的唯一标识，需要与远端集群配置的 clusterID 一致
+	ClusterID string `yaml:"clusterID"`
+	// Address 远端集群 HTTP 服务的地址，例如 http://polaris.region-b:8090
+	Address string `yaml:"address"`
+	// Token 访问远端集群运维接口的 token
+	Token string `yaml:"token"`
+	// Mode pull、push 或者 both，默认为 pull
+	Mode string `yaml:"mode"`
+}
+
+// canPull 是否需要从该集群拉取数据
+func (p *PeerConfig) canPull() bool {
+	return p.Mode == ModePull || p.Mode == ModeBoth
+}
+
+// canPush 是否需要向该集群推送数据
+func (p *PeerConfig) canPush() bool {
+	return p.Mode == ModePush || p.Mode == ModeBoth
+}
+
+// setDefault 填充默认值并检查配置
+func (c *Config) setDefault() error {
+	if c.Interval <= 0 {
+		c.Interval = defaultInterval
+	}
+	if c.Timeout <= 0 {
+		c.Timeout = defaultTimeout
+	}
+	if !c.Open {
+		return nil
+	}
+	if c.ClusterID == "" {
+		return errors.New("federation clusterID is empty")
+	}
+	peers := make(map[string]struct{}, len(c.Peers))
+	for _, peer := range c.Peers {
+		if peer.ClusterID == "" || peer.ClusterID == c.ClusterID {
+			return fmt.Errorf("federation peer clusterID %q is invalid", peer.ClusterID)
+		}
+		if _, ok := peers[peer.ClusterID]; ok {
+			return fmt.Errorf("federation peer %s duplicated", peer.ClusterID)
+		}
+		peers[peer.ClusterID] = struct{}{}
+		if peer.Mode == "" {
+			peer.Mode = ModePull
+		}
+		if !peer.canPull() && !peer.canPush() {
+			return fmt.Errorf("federation peer %s mode %q is invalid", peer.ClusterID, peer.Mode)
+		}
+		if peer.Address == "" {
+			return fmt.Errorf("federation peer %s address is empty", peer.ClusterID)
+		}
+		peer.Address = strings.TrimSuffix(peer.Address, "/")
+	}
+	return nil
+}
+
+// findPeer 根据集群 ID 查找远端集群
+func (c *Config) findPeer(clusterID string) (*PeerConfig, bool) {
+	for _, peer := range c.Peers {
+		if peer.ClusterID == clusterID {
+			return peer, true
+		}
+	}
+	return nil, false
+}
+
+// inScope 命名空间是否参与同步
+func (c *Config) inScope(namespace string) bool {
+	return inNamespaces(c.Namespaces, namespace)
+}
+
+func inNamespaces(namespaces []string, namespace string) bool {
+	if len(namespaces) == 0 {
+		return true
+	}
+	for i := range namespaces {
+		if namespaces[i] == namespace {
+			return true
+		}
+	}
+	return false
+}
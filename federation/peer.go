@@ -0,0 +1,101 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+package federation
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/polarismesh/polaris/common/utils"
+)
+
+const (
+	// SnapshotPath 远端集群获取、接收快照的运维接口
+	SnapshotPath = "/maintain/v1/federation/snapshot"
+)
+
+// peer 远端集群
+type peer interface {
+	// Pull 拉取远端集群的快照
+	Pull(ctx context.Context) (*Snapshot, error)
+	// Push 将本集群的快照推送到远端集群
+	Push(ctx context.Context, snapshot *Snapshot) (*ApplyResult, error)
+}
+
+// httpPeer 通过远端集群的 HTTP 运维接口同步数据
+type httpPeer struct {
+	conf   *PeerConfig
+	client *http.Client
+}
+
+func newHTTPPeer(conf *PeerConfig, client *http.Client) *httpPeer {
+	return &httpPeer{conf: conf, client: client}
+}
+
+// Pull 拉取远端集群的快照
+func (p *httpPeer) Pull(ctx context.Context) (*Snapshot, error) {
+	snapshot := &Snapshot{}
+	if err := p.do(ctx, http.MethodGet, nil, snapshot); err != nil {
+		return nil, err
+	}
+	if snapshot.ClusterID != p.conf.ClusterID {
+		return nil, fmt.Errorf("peer %s return snapshot of cluster %q", p.conf.ClusterID, snapshot.ClusterID)
+	}
+	return snapshot, nil
+}
+
+// Push 将本集群的快照推送到远端集群
+func (p *httpPeer) Push(ctx context.Context, snapshot *Snapshot) (*ApplyResult, error) {
+	body, err := json.Marshal(snapshot)
+	if err != nil {
+		return nil, err
+	}
+	result := &ApplyResult{}
+	if err := p.do(ctx, http.MethodPost, body, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (p *httpPeer) do(ctx context.Context, method string, body []byte, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, method, p.conf.Address+SnapshotPath, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if p.conf.Token != "" {
+		req.Header.Set(utils.HeaderAuthTokenKey, p.conf.Token)
+	}
+	rsp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer rsp.Body.Close()
+	data, err := io.ReadAll(rsp.Body)
+	if err != nil {
+		return err
+	}
+	if rsp.StatusCode != http.StatusOK {
+		return fmt.Errorf("peer %s %s %s: %d %s", p.conf.ClusterID, method, SnapshotPath, rsp.StatusCode,
+			string(data))
+	}
+	return json.Unmarshal(data, out)
+}
@@ -0,0 +1,189 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+package federation
+
+import (
+	"strconv"
+
+	apiservice "github.com/polarismesh/specification/source/go/api/v1/service_manage"
+
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/common/utils"
+)
+
+// resolution 远端实例与本集群中已有实例的冲突处理结果
+type resolution int
+
+const (
+	// resolveCreate 本集群中不存在，新增副本
+	resolveCreate resolution = iota
+	// resolveUpdate 远端的数据更新，覆盖本集群中的副本
+	resolveUpdate
+	// resolveSkip 数据没有变化，或者远端的数据比本集群中的副本更旧
+	resolveSkip
+	// resolveConflict 保留本集群中的数据，丢弃远端的数据
+	resolveConflict
+)
+
+// resolveInstance 处理远端实例与本集群中已有实例的冲突
+// 1. 本集群自身注册的实例始终优先，同一个实例同时注册在多个集群时不会产生重复的副本，健康状态以本集群的健康检查为准
+// 2. 同一个归属集群的副本，revision 相同时跳过，修改时间更旧的数据被丢弃，避免拉取与推送乱序时数据回退
+// 3. 不同归属集群的副本，修改时间更新的一方优先，修改时间相同时集群 ID 更小的一方优先
+func resolveInstance(local *model.Instance, remote *ReplicaInstance, source string) resolution {
+	if local == nil {
+		return resolveCreate
+	}
+	localSource := replicaSource(local.Metadata())
+	if localSource == "" {
+		return resolveConflict
+	}
+	revision, mtime := replicaVersion(local.Metadata())
+	if localSource != source {
+		if remote.Mtime > mtime || (remote.Mtime == mtime && source < localSource) {
+			return resolveUpdate
+		}
+		return resolveConflict
+	}
+	if remote.Mtime < mtime {
+		return resolveSkip
+	}
+	if revision == remote.Revision && local.Healthy() == remote.Healthy && local.Isolate() == remote.Isolate {
+		return resolveSkip
+	}
+	return resolveUpdate
+}
+
+// replicationPlan 应用一个远端快照需要执行的变更
+type replicationPlan struct {
+	createServices   []*apiservice.Service
+	updateServices   []*apiservice.Service
+	createInstances  []*apiservice.Instance
+	updateInstances  []*apiservice.Instance
+	deleteInstances  []*apiservice.Instance
+	skipInstances    int
+	conflictInstance int
+}
+
+// localIndex 本集群中参与同步的服务以及实例
+type localIndex struct {
+	services  map[model.ServiceKey]*model.Service
+	instances map[string]*model.Instance
+	addresses map[model.ServiceKey]map[string]*model.Instance
+}
+
+func buildLocalIndex(namespaces []string, source localSource) (*localIndex, error) {
+	index := &localIndex{
+		services:  map[model.ServiceKey]*model.Service{},
+		instances: map[string]*model.Instance{},
+		addresses: map[model.ServiceKey]map[string]*model.Instance{},
+	}
+	err := source.IteratorServices(func(_ string, svc *model.Service) (bool, error) {
+		if svc.IsAlias() || !inNamespaces(namespaces, svc.Namespace) {
+			return true, nil
+		}
+		key := model.ServiceKey{Namespace: svc.Namespace, Name: svc.Name}
+		index.services[key] = svc
+		index.addresses[key] = map[string]*model.Instance{}
+		for _, ins := range source.GetInstancesByServiceID(svc.ID) {
+			index.instances[ins.ID()] = ins
+			index.addresses[key][ins.Host()+":"+strconv.FormatUint(uint64(ins.Port()), 10)] = ins
+		}
+		return true, nil
+	})
+	return index, err
+}
+
+// planReplication 对比远端快照与本集群中的数据，生成需要执行的变更
+func planReplication(snapshot *Snapshot, namespaces []string, source localSource) (*replicationPlan, error) {
+	index, err := buildLocalIndex(namespaces, source)
+	if err != nil {
+		return nil, err
+	}
+	inScope := func(namespace string) bool {
+		return inNamespaces(namespaces, namespace) && inNamespaces(snapshot.Namespaces, namespace)
+	}
+	plan := &replicationPlan{}
+	remoteIDs := map[string]struct{}{}
+	for _, svc := range snapshot.Services {
+		if !inScope(svc.Namespace) {
+			continue
+		}
+		key := model.ServiceKey{Namespace: svc.Namespace, Name: svc.Name}
+		plan.addService(index.services[key], svc, snapshot.ClusterID)
+		for _, ins := range svc.Instances {
+			remoteIDs[ins.ID] = struct{}{}
+			local := index.instances[ins.ID]
+			if local == nil {
+				// 相同地址的实例以不同的 ID 注册在了多个集群中，按照同一个实例处理冲突
+				local = index.addresses[key][ins.address()]
+			}
+			switch resolveInstance(local, ins, snapshot.ClusterID) {
+			case resolveCreate:
+				plan.createInstances = append(plan.createInstances, ins.toInstance(svc, snapshot.ClusterID))
+			case resolveUpdate:
+				if local.ID() != ins.ID {
+					plan.deleteInstances = append(plan.deleteInstances, &apiservice.Instance{
+						Id: utils.NewStringValue(local.ID()),
+					})
+					plan.createInstances = append(plan.createInstances, ins.toInstance(svc, snapshot.ClusterID))
+					continue
+				}
+				plan.updateInstances = append(plan.updateInstances, ins.toInstance(svc, snapshot.ClusterID))
+			case resolveSkip:
+				plan.skipInstances++
+			case resolveConflict:
+				plan.conflictInstance++
+			}
+		}
+	}
+	// 归属集群中已经不存在的副本需要删除
+	for id, ins := range index.instances {
+		if _, ok := remoteIDs[id]; ok {
+			continue
+		}
+		if replicaSource(ins.Metadata()) != snapshot.ClusterID || !inScope(ins.Namespace()) {
+			continue
+		}
+		plan.deleteInstances = append(plan.deleteInstances, &apiservice.Instance{Id: utils.NewStringValue(id)})
+	}
+	return plan, nil
+}
+
+// addService 远端创建的服务在本集群中不存在时创建，由该集群同步过来的服务跟随其元数据的变更
+// 本集群自身创建的服务不会被修改
+func (p *replicationPlan) addService(local *model.Service, remote *ReplicaService, source string) {
+	if !remote.Owned {
+		return
+	}
+	req := &apiservice.Service{
+		Name:      utils.NewStringValue(remote.Name),
+		Namespace: utils.NewStringValue(remote.Namespace),
+		Metadata:  replicaMetadata(remote.Metadata, source, remote.Revision, remote.Mtime),
+	}
+	if local == nil {
+		p.createServices = append(p.createServices, req)
+		return
+	}
+	if replicaSource(local.Meta) != source {
+		return
+	}
+	revision, mtime := replicaVersion(local.Meta)
+	if revision == remote.Revision || remote.Mtime < mtime {
+		return
+	}
+	p.updateServices = append(p.updateServices, req)
+}
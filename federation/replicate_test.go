@@ -0,0 +1,303 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package federation
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	apiservice "github.com/polarismesh/specification/source/go/api/v1/service_manage"
+	"github.com/stretchr/testify/assert"
+
+	cachetypes "github.com/polarismesh/polaris/cache/api"
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/common/utils"
+)
+
+type mockSource struct {
+	services  []*model.Service
+	instances map[string][]*model.Instance
+}
+
+func (m *mockSource) IteratorServices(iterProc cachetypes.ServiceIterProc) error {
+	for _, svc := range m.services {
+		if _, err := iterProc(svc.ID, svc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *mockSource) GetInstancesByServiceID(serviceID string) []*model.Instance {
+	return m.instances[serviceID]
+}
+
+func mockInstance(id, host string, port uint32, revision string, metadata map[string]string) *model.Instance {
+	return &model.Instance{
+		Proto: &apiservice.Instance{
+			Id:        utils.NewStringValue(id),
+			Namespace: utils.NewStringValue("default"),
+			Service:   utils.NewStringValue("svc"),
+			Host:      utils.NewStringValue(host),
+			Port:      utils.NewUInt32Value(port),
+			Healthy:   utils.NewBoolValue(true),
+			Isolate:   utils.NewBoolValue(false),
+			Revision:  utils.NewStringValue(revision),
+			Metadata:  metadata,
+		},
+		ServiceID:  "svc-id",
+		Valid:      true,
+		ModifyTime: time.UnixMilli(1000),
+	}
+}
+
+func Test_resolveInstance(t *testing.T) {
+	remote := &ReplicaInstance{ID: "ins-1", Revision: "v2", Mtime: 2000, Healthy: true}
+
+	t.Run("本集群不存在时新增", func(t *testing.T) {
+		assert.Equal(t, resolveCreate, resolveInstance(nil, remote, "b"))
+	})
+	t.Run("本集群自身注册的实例优先", func(t *testing.T) {
+		local := mockInstance("ins-1", "127.0.0.1", 8080, "v1", nil)
+		assert.Equal(t, resolveConflict, resolveInstance(local, remote, "b"))
+	})
+	t.Run("同一个归属集群的副本", func(t *testing.T) {
+		local := mockInstance("ins-1", "127.0.0.1", 8080, "v1", replicaMetadata(nil, "b", "v1", 1000))
+		assert.Equal(t, resolveUpdate, resolveInstance(local, remote, "b"))
+
+		local = mockInstance("ins-1", "127.0.0.1", 8080, "v2", replicaMetadata(nil, "b", "v2", 2000))
+		assert.Equal(t, resolveSkip, resolveInstance(local, remote, "b"))
+
+		local = mockInstance("ins-1", "127.0.0.1", 8080, "v3", replicaMetadata(nil, "b", "v3", 3000))
+		assert.Equal(t, resolveSkip, resolveInstance(local, remote, "b"))
+
+		// revision 相同但是健康状态发生了变化
+		local = mockInstance("ins-1", "127.0.0.1", 8080, "v2", replicaMetadata(nil, "b", "v2", 2000))
+		local.Proto.Healthy = utils.NewBoolValue(false)
+		assert.Equal(t, resolveUpdate, resolveInstance(local, remote, "b"))
+	})
+	t.Run("不同归属集群的副本", func(t *testing.T) {
+		local := mockInstance("ins-1", "127.0.0.1", 8080, "v1", replicaMetadata(nil, "c", "v1", 1000))
+		assert.Equal(t, resolveUpdate, resolveInstance(local, remote, "b"))
+
+		local = mockInstance("ins-1", "127.0.0.1", 8080, "v1", replicaMetadata(nil, "c", "v1", 3000))
+		assert.Equal(t, resolveConflict, resolveInstance(local, remote, "b"))
+
+		local = mockInstance("ins-1", "127.0.0.1", 8080, "v1", replicaMetadata(nil, "c", "v1", 2000))
+		assert.Equal(t, resolveUpdate, resolveInstance(local, remote, "b"))
+		assert.Equal(t, resolveConflict, resolveInstance(local, remote, "d"))
+	})
+}
+
+func Test_planReplication(t *testing.T) {
+	source := &mockSource{
+		services: []*model.Service{
+			{ID: "svc-id", Namespace: "default", Name: "svc", Meta: map[string]string{}},
+		},
+		instances: map[string][]*model.Instance{
+			"svc-id": {
+				mockInstance("local-1", "127.0.0.1", 8080, "v1", nil),
+				mockInstance("ins-2", "127.0.0.2", 8080, "v1", replicaMetadata(nil, "b", "v1", 1000)),
+				mockInstance("ins-3", "127.0.0.3", 8080, "v1", replicaMetadata(nil, "b", "v1", 1000)),
+				mockInstance("other-4", "127.0.0.4", 8080, "v1", replicaMetadata(nil, "c", "v1", 1000)),
+			},
+		},
+	}
+	snapshot := &Snapshot{
+		ClusterID: "b",
+		Services: []*ReplicaService{
+			{
+				Namespace: "default",
+				Name:      "svc",
+				Owned:     false,
+				Instances: []*ReplicaInstance{
+					// 与本集群自身注册的实例地址相同
+					{ID: "remote-1", Host: "127.0.0.1", Port: 8080, Revision: "v1", Mtime: 2000},
+					{ID: "ins-2", Host: "127.0.0.2", Port: 8080, Revision: "v2", Mtime: 2000},
+					// 与其他集群同步过来的副本地址相同，修改时间更新
+					{ID: "ins-4", Host: "127.0.0.4", Port: 8080, Revision: "v2", Mtime: 2000},
+					{ID: "ins-5", Host: "127.0.0.5", Port: 8080, Revision: "v1", Mtime: 2000},
+				},
+			},
+			{Namespace: "other", Name: "new-svc", Owned: true, Revision: "v1", Mtime: 1000},
+		},
+	}
+
+	plan, err := planReplication(snapshot, nil, source)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, plan.conflictInstance)
+	assert.Equal(t, 0, plan.skipInstances)
+	assert.Len(t, plan.createServices, 1)
+	assert.Equal(t, "new-svc", plan.createServices[0].GetName().GetValue())
+	assert.Equal(t, "b", plan.createServices[0].GetMetadata()[model.MetadataFederationSource])
+
+	assert.Len(t, plan.updateInstances, 1)
+	assert.Equal(t, "ins-2", plan.updateInstances[0].GetId().GetValue())
+	assert.False(t, plan.updateInstances[0].GetEnableHealthCheck().GetValue())
+
+	createIDs := []string{}
+	for _, ins := range plan.createInstances {
+		createIDs = append(createIDs, ins.GetId().GetValue())
+	}
+	assert.ElementsMatch(t, []string{"ins-4", "ins-5"}, createIDs)
+
+	// ins-3 已经不在归属集群中，other-4 被地址相同的 ins-4 接管
+	deleteIDs := []string{}
+	for _, ins := range plan.deleteInstances {
+		deleteIDs = append(deleteIDs, ins.GetId().GetValue())
+	}
+	assert.ElementsMatch(t, []string{"ins-3", "other-4"}, deleteIDs)
+}
+
+func Test_planReplicationNamespaces(t *testing.T) {
+	source := &mockSource{
+		services: []*model.Service{
+			{ID: "svc-id", Namespace: "default", Name: "svc", Meta: map[string]string{}},
+		},
+		instances: map[string][]*model.Instance{
+			"svc-id": {
+				mockInstance("ins-1", "127.0.0.1", 8080, "v1", replicaMetadata(nil, "b", "v1", 1000)),
+			},
+		},
+	}
+	snapshot := &Snapshot{
+		ClusterID:  "b",
+		Namespaces: []string{"other"},
+		Services: []*ReplicaService{
+			{Namespace: "other", Name: "svc", Owned: true, Revision: "v1", Mtime: 1000},
+		},
+	}
+	// 快照没有覆盖的命名空间中的副本不会被删除
+	plan, err := planReplication(snapshot, nil, source)
+	assert.NoError(t, err)
+	assert.Empty(t, plan.deleteInstances)
+	assert.Len(t, plan.createServices, 1)
+
+	// 本集群没有开启同步的命名空间不会写入
+	plan, err = planReplication(snapshot, []string{"default"}, source)
+	assert.NoError(t, err)
+	assert.Empty(t, plan.createServices)
+}
+
+func Test_buildSnapshot(t *testing.T) {
+	source := &mockSource{
+		services: []*model.Service{
+			{ID: "svc-2", Namespace: "default", Name: "b", Revision: "v1", Meta: map[string]string{}},
+			{ID: "svc-1", Namespace: "default", Name: "a", Revision: "v1", Meta: map[string]string{}},
+			{ID: "alias", Namespace: "default", Name: "alias", Reference: "svc-1"},
+			{ID: "replica", Namespace: "default", Name: "replica", Meta: replicaMetadata(nil, "b", "v1", 1000)},
+		},
+		instances: map[string][]*model.Instance{
+			"svc-1": {
+				mockInstance("ins-2", "127.0.0.2", 8080, "v1", nil),
+				mockInstance("ins-1", "127.0.0.1", 8080, "v1", nil),
+				mockInstance("ins-3", "127.0.0.3", 8080, "v1", replicaMetadata(nil, "b", "v1", 1000)),
+			},
+			"replica": {
+				mockInstance("ins-4", "127.0.0.4", 8080, "v1", replicaMetadata(nil, "b", "v1", 1000)),
+			},
+		},
+	}
+	snapshot, err := buildSnapshot("a", nil, source)
+	assert.NoError(t, err)
+	assert.Equal(t, "a", snapshot.ClusterID)
+	assert.Len(t, snapshot.Services, 2)
+	assert.Equal(t, "a", snapshot.Services[0].Name)
+	assert.True(t, snapshot.Services[0].Owned)
+	assert.Len(t, snapshot.Services[0].Instances, 2)
+	assert.Equal(t, "ins-1", snapshot.Services[0].Instances[0].ID)
+	assert.Equal(t, int64(1000), snapshot.Services[0].Instances[0].Mtime)
+	assert.Equal(t, "b", snapshot.Services[1].Name)
+	assert.Empty(t, snapshot.Services[1].Instances)
+}
+
+type mockRegistry struct {
+	created []*apiservice.Instance
+}
+
+func (m *mockRegistry) CreateServices(_ context.Context, req []*apiservice.Service) *apiservice.BatchWriteResponse {
+	return &apiservice.BatchWriteResponse{}
+}
+
+func (m *mockRegistry) UpdateServices(_ context.Context, req []*apiservice.Service) *apiservice.BatchWriteResponse {
+	return &apiservice.BatchWriteResponse{}
+}
+
+func (m *mockRegistry) CreateInstances(_ context.Context,
+	reqs []*apiservice.Instance) *apiservice.BatchWriteResponse {
+	m.created = append(m.created, reqs...)
+	return &apiservice.BatchWriteResponse{}
+}
+
+func (m *mockRegistry) UpdateInstances(_ context.Context,
+	req []*apiservice.Instance) *apiservice.BatchWriteResponse {
+	return &apiservice.BatchWriteResponse{}
+}
+
+func (m *mockRegistry) DeleteInstances(_ context.Context,
+	req []*apiservice.Instance) *apiservice.BatchWriteResponse {
+	return &apiservice.BatchWriteResponse{}
+}
+
+func TestServer_Apply(t *testing.T) {
+	snapshot := &Snapshot{
+		ClusterID: "b",
+		Services: []*ReplicaService{
+			{
+				Namespace: "default",
+				Name:      "svc",
+				Owned:     true,
+				Instances: []*ReplicaInstance{{ID: "ins-1", Host: "127.0.0.1", Port: 8080, Mtime: 1000}},
+			},
+		},
+	}
+
+	t.Run("未开启跨集群同步", func(t *testing.T) {
+		s := &Server{}
+		assert.NoError(t, s.initialize(context.Background(), &Config{}, &mockRegistry{}, &mockSource{}, nil))
+		_, err := s.Apply(context.Background(), snapshot)
+		assert.True(t, errors.Is(err, ErrorFederationClosed))
+		_, err = s.Snapshot(context.Background())
+		assert.True(t, errors.Is(err, ErrorFederationClosed))
+	})
+
+	registry := &mockRegistry{}
+	s := &Server{
+		cfg: &Config{
+			Open:      true,
+			ClusterID: "a",
+			Peers:     []*PeerConfig{{ClusterID: "b", Address: "http://127.0.0.1:8090"}},
+		},
+		registry: registry,
+		source:   &mockSource{},
+	}
+	t.Run("未配置的远端集群", func(t *testing.T) {
+		_, err := s.Apply(context.Background(), &Snapshot{ClusterID: "c"})
+		assert.True(t, errors.Is(err, ErrorUnknownPeer))
+	})
+	t.Run("写入副本", func(t *testing.T) {
+		result, err := s.Apply(context.Background(), snapshot)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, result.Created)
+		assert.Equal(t, 0, result.Failed)
+		assert.Len(t, registry.created, 1)
+		assert.Equal(t, "b", registry.created[0].GetMetadata()[model.MetadataFederationSource])
+	})
+}
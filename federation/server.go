@@ -0,0 +1,291 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+package federation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	apiservice "github.com/polarismesh/specification/source/go/api/v1/service_manage"
+	"go.uber.org/zap"
+
+	"github.com/polarismesh/polaris/cache"
+	cachetypes "github.com/polarismesh/polaris/cache/api"
+	api "github.com/polarismesh/polaris/common/api/v1"
+	"github.com/polarismesh/polaris/common/metrics"
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/common/utils"
+	"github.com/polarismesh/polaris/service"
+	"github.com/polarismesh/polaris/store"
+)
+
+const (
+	// applyBatchSize 单次写入的副本数
+	applyBatchSize = 100
+)
+
+var (
+	// ErrorFederationClosed 没有开启跨集群同步
+	ErrorFederationClosed = errors.New("federation is not open")
+	// ErrorUnknownPeer 快照不属于配置的远端集群
+	ErrorUnknownPeer = errors.New("unknown federation peer")
+
+	server     = &Server{}
+	finishInit bool
+)
+
+// localSource 本集群的服务以及实例
+type localSource interface {
+	IteratorServices(iterProc cachetypes.ServiceIterProc) error
+	GetInstancesByServiceID(serviceID string) []*model.Instance
+}
+
+// registry 副本的写入，由服务发现模块实现
+type registry interface {
+	CreateServices(ctx context.Context, req []*apiservice.Service) *apiservice.BatchWriteResponse
+	UpdateServices(ctx context.Context, req []*apiservice.Service) *apiservice.BatchWriteResponse
+	CreateInstances(ctx context.Context, reqs []*apiservice.Instance) *apiservice.BatchWriteResponse
+	UpdateInstances(ctx context.Context, req []*apiservice.Instance) *apiservice.BatchWriteResponse
+	DeleteInstances(ctx context.Context, req []*apiservice.Instance) *apiservice.BatchWriteResponse
+}
+
+// leaderElector 集群内只有 leader 节点与远端集群同步
+type leaderElector interface {
+	StartLeaderElection(key string) error
+	IsLeader(key string) bool
+}
+
+// cacheSource 基于缓存读取本集群的服务以及实例
+type cacheSource struct {
+	services  cachetypes.ServiceCache
+	instances cachetypes.InstanceCache
+}
+
+func (c *cacheSource) IteratorServices(iterProc cachetypes.ServiceIterProc) error {
+	return c.services.IteratorServices(iterProc)
+}
+
+func (c *cacheSource) GetInstancesByServiceID(serviceID string) []*model.Instance {
+	return c.instances.GetInstancesByServiceID(serviceID)
+}
+
+// ApplyResult 应用一个远端快照的结果
+type ApplyResult struct {
+	ClusterID string `json:"cluster_id"`
+	Created   int    `json:"created"`
+	Updated   int    `json:"updated"`
+	Deleted   int    `json:"deleted"`
+	Skipped   int    `json:"skipped"`
+	Conflicts int    `json:"conflicts"`
+	Failed    int    `json:"failed"`
+}
+
+// Server 跨集群同步服务，定期与远端集群交换快照，远端的服务以及实例以副本的形式写入本集群
+type Server struct {
+	cfg      *Config
+	registry registry
+	source   localSource
+	elector  leaderElector
+	peers    map[string]peer
+	// applyLock 同一时间只应用一个快照，避免拉取与推送并发写入相同的副本
+	applyLock sync.Mutex
+}
+
+// Initialize 初始化跨集群同步，namingServer 需要是没有经过鉴权的服务发现模块
+func Initialize(ctx context.Context, cfg *Config, namingServer service.DiscoverServer,
+	cacheMgn *cache.CacheManager, storage store.Store) error {
+	if finishInit {
+		return nil
+	}
+	source := &cacheSource{services: cacheMgn.Service(), instances: cacheMgn.Instance()}
+	if err := server.initialize(ctx, cfg, namingServer, source, storage); err != nil {
+		return err
+	}
+	finishInit = true
+	return nil
+}
+
+func (s *Server) initialize(ctx context.Context, cfg *Config, registry registry, source localSource,
+	elector leaderElector) error {
+	if err := cfg.setDefault(); err != nil {
+		return err
+	}
+	s.cfg = cfg
+	s.registry = registry
+	s.source = source
+	s.elector = elector
+	if !cfg.Open {
+		return nil
+	}
+	client := &http.Client{Timeout: cfg.Timeout}
+	s.peers = make(map[string]peer, len(cfg.Peers))
+	for _, conf := range cfg.Peers {
+		s.peers[conf.ClusterID] = newHTTPPeer(conf, client)
+	}
+	if err := elector.StartLeaderElection(store.ElectionKeyFederation); err != nil {
+		return err
+	}
+	go s.run(ctx)
+	return nil
+}
+
+// GetServer 获取已经初始化好的跨集群同步服务
+func GetServer() (*Server, error) {
+	if !finishInit {
+		return nil, errors.New("federation server has not done initialize")
+	}
+	return server, nil
+}
+
+// Snapshot 本集群自身拥有的服务以及实例
+func (s *Server) Snapshot(_ context.Context) (*Snapshot, error) {
+	if !s.cfg.Open {
+		return nil, ErrorFederationClosed
+	}
+	return buildSnapshot(s.cfg.ClusterID, s.cfg.Namespaces, s.source)
+}
+
+// Apply 将远端集群的快照以副本的形式写入本集群
+func (s *Server) Apply(ctx context.Context, snapshot *Snapshot) (*ApplyResult, error) {
+	if !s.cfg.Open {
+		return nil, ErrorFederationClosed
+	}
+	if _, ok := s.cfg.findPeer(snapshot.ClusterID); !ok {
+		return nil, fmt.Errorf("%w: %s", ErrorUnknownPeer, snapshot.ClusterID)
+	}
+	s.applyLock.Lock()
+	defer s.applyLock.Unlock()
+
+	plan, err := planReplication(snapshot, s.cfg.Namespaces, s.source)
+	if err != nil {
+		return nil, err
+	}
+	ctx = context.WithValue(ctx, utils.ContextOperator, "federation-"+snapshot.ClusterID)
+	result := &ApplyResult{
+		ClusterID: snapshot.ClusterID,
+		Skipped:   plan.skipInstances,
+		Conflicts: plan.conflictInstance,
+	}
+	// 服务需要在实例之前创建，携带服务的元数据；删除需要在创建之前，地址相同而 ID 不同的实例会被重新创建
+	result.Failed += writeBatch(ctx, plan.createServices, s.registry.CreateServices)
+	result.Failed += writeBatch(ctx, plan.updateServices, s.registry.UpdateServices)
+	deleteFailed := writeBatch(ctx, plan.deleteInstances, s.registry.DeleteInstances)
+	createFailed := writeBatch(ctx, plan.createInstances, s.registry.CreateInstances)
+	updateFailed := writeBatch(ctx, plan.updateInstances, s.registry.UpdateInstances)
+	result.Deleted = len(plan.deleteInstances) - deleteFailed
+	result.Created = len(plan.createInstances) - createFailed
+	result.Updated = len(plan.updateInstances) - updateFailed
+	result.Failed += deleteFailed + createFailed + updateFailed
+
+	metrics.ReportFederationReplicated(snapshot.ClusterID, metrics.FederationCreate, result.Created)
+	metrics.ReportFederationReplicated(snapshot.ClusterID, metrics.FederationUpdate, result.Updated)
+	metrics.ReportFederationReplicated(snapshot.ClusterID, metrics.FederationDelete, result.Deleted)
+	metrics.ReportFederationReplicated(snapshot.ClusterID, metrics.FederationConflict, result.Conflicts)
+	metrics.ReportFederationReplicated(snapshot.ClusterID, metrics.FederationFail, result.Failed)
+	return result, nil
+}
+
+// writeBatch 分批写入副本，返回写入失败的数量
+func writeBatch[T any](ctx context.Context, reqs []T,
+	write func(context.Context, []T) *apiservice.BatchWriteResponse) int {
+	failed := 0
+	for start := 0; start < len(reqs); start += applyBatchSize {
+		end := start + applyBatchSize
+		if end > len(reqs) {
+			end = len(reqs)
+		}
+		failed += countFailed(write(ctx, reqs[start:end]))
+	}
+	return failed
+}
+
+func countFailed(resp *apiservice.BatchWriteResponse) int {
+	failed := 0
+	for _, item := range resp.GetResponses() {
+		if api.CalcCode(item) != http.StatusOK {
+			log.Error("[Federation] write replica", zap.Uint32("code", item.GetCode().GetValue()),
+				zap.String("info", item.GetInfo().GetValue()))
+			failed++
+		}
+	}
+	return failed
+}
+
+func (s *Server) run(ctx context.Context) {
+	ticker := time.NewTicker(s.cfg.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if !s.elector.IsLeader(store.ElectionKeyFederation) {
+				continue
+			}
+			s.syncPeers(ctx)
+		}
+	}
+}
+
+// syncPeers 依次与每一个远端集群同步
+func (s *Server) syncPeers(ctx context.Context) {
+	for _, conf := range s.cfg.Peers {
+		p := s.peers[conf.ClusterID]
+		if conf.canPull() {
+			if err := s.pull(ctx, p); err != nil {
+				log.Error("[Federation] pull snapshot", zap.String("peer", conf.ClusterID), zap.Error(err))
+			}
+		}
+		if conf.canPush() {
+			if err := s.push(ctx, p); err != nil {
+				log.Error("[Federation] push snapshot", zap.String("peer", conf.ClusterID), zap.Error(err))
+			}
+		}
+	}
+}
+
+func (s *Server) pull(ctx context.Context, p peer) error {
+	pullCtx, cancel := context.WithTimeout(ctx, s.cfg.Timeout)
+	defer cancel()
+	snapshot, err := p.Pull(pullCtx)
+	if err != nil {
+		return err
+	}
+	result, err := s.Apply(ctx, snapshot)
+	if err != nil {
+		return err
+	}
+	log.Info("[Federation] apply snapshot", zap.String("peer", result.ClusterID), zap.Int("created", result.Created),
+		zap.Int("updated", result.Updated), zap.Int("deleted", result.Deleted),
+		zap.Int("conflicts", result.Conflicts), zap.Int("failed", result.Failed))
+	return nil
+}
+
+func (s *Server) push(ctx context.Context, p peer) error {
+	snapshot, err := s.Snapshot(ctx)
+	if err != nil {
+		return err
+	}
+	pushCtx, cancel := context.WithTimeout(ctx, s.cfg.Timeout)
+	defer cancel()
+	_, err = p.Push(pushCtx, snapshot)
+	return err
+}
@@ -0,0 +1,176 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+package federation
+
+import (
+	"sort"
+	"strconv"
+
+	apimodel "github.com/polarismesh/specification/source/go/api/v1/model"
+	apiservice "github.com/polarismesh/specification/source/go/api/v1/service_manage"
+
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/common/utils"
+)
+
+// Snapshot 某个集群自身拥有的服务以及实例，不包含从其他集群同步过来的副本，避免数据在集群之间循环同步
+type Snapshot struct {
+	ClusterID string `json:"cluster_id"`
+	// Namespaces 快照覆盖的命名空间，为空时覆盖全部命名空间
+	Namespaces []string          `json:"namespaces,omitempty"`
+	Services   []*ReplicaService `json:"services"`
+}
+
+// ReplicaService 快照中的服务
+type ReplicaService struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	// Owned 服务是否由快照所在的集群创建，只有 Owned 的服务才会同步元数据
+	Owned     bool               `json:"owned"`
+	Revision  string             `json:"revision,omitempty"`
+	Mtime     int64              `json:"mtime,omitempty"`
+	Metadata  map[string]string  `json:"metadata,omitempty"`
+	Instances []*ReplicaInstance `json:"instances,omitempty"`
+}
+
+// ReplicaInstance 快照中的实例，健康状态以归属集群的健康检查结果为准
+type ReplicaInstance struct {
+	ID       string            `json:"id"`
+	Host     string            `json:"host"`
+	Port     uint32            `json:"port"`
+	Protocol string            `json:"protocol,omitempty"`
+	Version  string            `json:"version,omitempty"`
+	Weight   uint32            `json:"weight"`
+	Healthy  bool              `json:"healthy"`
+	Isolate  bool              `json:"isolate"`
+	Region   string            `json:"region,omitempty"`
+	Zone     string            `json:"zone,omitempty"`
+	Campus   string            `json:"campus,omitempty"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+	Revision string            `json:"revision"`
+	Mtime    int64             `json:"mtime"`
+}
+
+func (r *ReplicaInstance) address() string {
+	return r.Host + ":" + strconv.FormatUint(uint64(r.Port), 10)
+}
+
+// toInstance 转换为本集群中的副本，副本不进行健康检查，健康状态跟随归属集群
+func (r *ReplicaInstance) toInstance(svc *ReplicaService, source string) *apiservice.Instance {
+	return &apiservice.Instance{
+		Id:                utils.NewStringValue(r.ID),
+		Service:           utils.NewStringValue(svc.Name),
+		Namespace:         utils.NewStringValue(svc.Namespace),
+		Host:              utils.NewStringValue(r.Host),
+		Port:              utils.NewUInt32Value(r.Port),
+		Protocol:          utils.NewStringValue(r.Protocol),
+		Version:           utils.NewStringValue(r.Version),
+		Weight:            utils.NewUInt32Value(r.Weight),
+		Healthy:           utils.NewBoolValue(r.Healthy),
+		Isolate:           utils.NewBoolValue(r.Isolate),
+		EnableHealthCheck: utils.NewBoolValue(false),
+		Location: &apimodel.Location{
+			Region: utils.NewStringValue(r.Region),
+			Zone:   utils.NewStringValue(r.Zone),
+			Campus: utils.NewStringValue(r.Campus),
+		},
+		Metadata: replicaMetadata(r.Metadata, source, r.Revision, r.Mtime),
+	}
+}
+
+// replicaMetadata 在元数据中记录副本的归属集群以及在归属集群中的 revision、修改时间
+func replicaMetadata(metadata map[string]string, source, revision string, mtime int64) map[string]string {
+	ret := make(map[string]string, len(metadata)+3)
+	for k, v := range metadata {
+		ret[k] = v
+	}
+	ret[model.MetadataFederationSource] = source
+	ret[model.MetadataFederationRevision] = revision
+	ret[model.MetadataFederationMtime] = strconv.FormatInt(mtime, 10)
+	return ret
+}
+
+// replicaSource 副本的归属集群，本集群自身的数据返回空字符串
+func replicaSource(metadata map[string]string) string {
+	return metadata[model.MetadataFederationSource]
+}
+
+// replicaVersion 副本在归属集群中的 revision 以及修改时间
+func replicaVersion(metadata map[string]string) (string, int64) {
+	mtime, _ := strconv.ParseInt(metadata[model.MetadataFederationMtime], 10, 64)
+	return metadata[model.MetadataFederationRevision], mtime
+}
+
+func newReplicaInstance(ins *model.Instance) *ReplicaInstance {
+	return &ReplicaInstance{
+		ID:       ins.ID(),
+		Host:     ins.Host(),
+		Port:     ins.Port(),
+		Protocol: ins.Protocol(),
+		Version:  ins.Version(),
+		Weight:   ins.Weight(),
+		Healthy:  ins.Healthy(),
+		Isolate:  ins.Isolate(),
+		Region:   ins.Location().GetRegion().GetValue(),
+		Zone:     ins.Location().GetZone().GetValue(),
+		Campus:   ins.Location().GetCampus().GetValue(),
+		Metadata: ins.Metadata(),
+		Revision: ins.Revision(),
+		Mtime:    ins.ModifyTime.UnixMilli(),
+	}
+}
+
+// buildSnapshot 构建本集群自身拥有的服务以及实例的快照
+func buildSnapshot(clusterID string, namespaces []string, source localSource) (*Snapshot, error) {
+	snapshot := &Snapshot{ClusterID: clusterID, Namespaces: namespaces}
+	err := source.IteratorServices(func(_ string, svc *model.Service) (bool, error) {
+		if svc.IsAlias() || !inNamespaces(namespaces, svc.Namespace) {
+			return true, nil
+		}
+		item := &ReplicaService{Namespace: svc.Namespace, Name: svc.Name}
+		if replicaSource(svc.Meta) == "" {
+			item.Owned = true
+			item.Revision = svc.Revision
+			item.Mtime = svc.ModifyTime.UnixMilli()
+			item.Metadata = svc.Meta
+		}
+		for _, ins := range source.GetInstancesByServiceID(svc.ID) {
+			if replicaSource(ins.Metadata()) != "" {
+				continue
+			}
+			item.Instances = append(item.Instances, newReplicaInstance(ins))
+		}
+		if !item.Owned && len(item.Instances) == 0 {
+			return true, nil
+		}
+		sort.Slice(item.Instances, func(i, j int) bool {
+			return item.Instances[i].ID < item.Instances[j].ID
+		})
+		snapshot.Services = append(snapshot.Services, item)
+		return true, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(snapshot.Services, func(i, j int) bool {
+		if snapshot.Services[i].Namespace != snapshot.Services[j].Namespace {
+			return snapshot.Services[i].Namespace < snapshot.Services[j].Namespace
+		}
+		return snapshot.Services[i].Name < snapshot.Services[j].Name
+	})
+	return snapshot, nil
+}
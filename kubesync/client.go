@@ -0,0 +1,230 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+package kubesync
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+const (
+	// fieldManager 反向同步时 server-side apply 使用的 field manager
+	fieldManager = "polaris"
+	// watchTimeoutSeconds 单次 watch 的最长时间，到期后从上一次的 resourceVersion 继续 watch
+	watchTimeoutSeconds = "300"
+)
+
+var (
+	// errResourceExpired watch 的 resourceVersion 已经过期，需要重新 list
+	errResourceExpired = errors.New("kubernetes resource version expired")
+)
+
+// resource 同步涉及的 Kubernetes 资源
+type resource struct {
+	group string
+	name  string
+}
+
+var (
+	resourceServices       = resource{group: "api/v1", name: "services"}
+	resourceEndpointSlices = resource{group: "apis/discovery.k8s.io/v1", name: "endpointslices"}
+)
+
+func (r resource) path(namespace, name string) string {
+	p := "/" + r.group + "/namespaces/" + url.PathEscape(namespace) + "/" + r.name
+	if name != "" {
+		p += "/" + url.PathEscape(name)
+	}
+	return p
+}
+
+// kubeClient 访问 Kubernetes APIServer
+type kubeClient interface {
+	// List 列出命名空间下的全部资源
+	List(ctx context.Context, res resource, namespace string) (*objectList, error)
+	// Watch 从 resourceVersion 开始监听命名空间下资源的变更，直到 watch 超时或者出错
+	Watch(ctx context.Context, res resource, namespace, resourceVersion string, handler func(*watchEvent)) error
+	// Apply 通过 server-side apply 创建或者更新资源
+	Apply(ctx context.Context, res resource, namespace, name string, obj interface{}) error
+	// Delete 删除资源，资源不存在时不返回错误
+	Delete(ctx context.Context, res resource, namespace, name string) error
+}
+
+// httpClient 基于 APIServer REST 接口的实现
+type httpClient struct {
+	cfg *Config
+	// client 普通请求，带有超时时间
+	client *http.Client
+	// watchClient watch 请求是长连接，由 APIServer 的 timeoutSeconds 控制超时
+	watchClient *http.Client
+}
+
+func newHTTPClient(cfg *Config) (*httpClient, error) {
+	tlsConfig := &tls.Config{InsecureSkipVerify: cfg.Insecure} //nolint:gosec
+	if cfg.CAFile != "" && !cfg.Insecure {
+		data, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("kubernetes ca file %s is invalid", cfg.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	return &httpClient{
+		cfg:         cfg,
+		client:      &http.Client{Transport: transport, Timeout: cfg.Timeout},
+		watchClient: &http.Client{Transport: transport},
+	}, nil
+}
+
+// List 列出命名空间下的全部资源
+func (c *httpClient) List(ctx context.Context, res resource, namespace string) (*objectList, error) {
+	list := &objectList{}
+	data, err := c.do(ctx, c.client, http.MethodGet, res.path(namespace, ""), "", nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// Watch 从 resourceVersion 开始监听命名空间下资源的变更
+func (c *httpClient) Watch(ctx context.Context, res resource, namespace, resourceVersion string,
+	handler func(*watchEvent)) error {
+	query := url.Values{}
+	query.Set("watch", "true")
+	query.Set("resourceVersion", resourceVersion)
+	query.Set("timeoutSeconds", watchTimeoutSeconds)
+	query.Set("allowWatchBookmarks", "true")
+	rsp, err := c.send(ctx, c.watchClient, http.MethodGet, res.path(namespace, "")+"?"+query.Encode(), "", nil)
+	if err != nil {
+		return err
+	}
+	defer rsp.Body.Close()
+	decoder := json.NewDecoder(bufio.NewReader(rsp.Body))
+	for {
+		event := &watchEvent{}
+		if err := decoder.Decode(event); err != nil {
+			if errors.Is(err, io.EOF) || ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		if event.Type == "ERROR" {
+			st := &status{}
+			_ = json.Unmarshal(event.Object, st)
+			if st.Code == http.StatusGone {
+				return errResourceExpired
+			}
+			return fmt.Errorf("watch %s/%s: %d %s", namespace, res.name, st.Code, st.Message)
+		}
+		handler(event)
+	}
+}
+
+// Apply 通过 server-side apply 创建或者更新资源，强制接管冲突的字段
+func (c *httpClient) Apply(ctx context.Context, res resource, namespace, name string, obj interface{}) error {
+	body, err := json.Marshal(obj)
+	if err != nil {
+		return err
+	}
+	query := url.Values{}
+	query.Set("fieldManager", fieldManager)
+	query.Set("force", "true")
+	_, err = c.do(ctx, c.client, http.MethodPatch, res.path(namespace, name)+"?"+query.Encode(),
+		"application/apply-patch+yaml", body)
+	return err
+}
+
+// Delete 删除资源
+func (c *httpClient) Delete(ctx context.Context, res resource, namespace, name string) error {
+	_, err := c.do(ctx, c.client, http.MethodDelete, res.path(namespace, name), "", nil)
+	var statusErr *statusError
+	if errors.As(err, &statusErr) && statusErr.code == http.StatusNotFound {
+		return nil
+	}
+	return err
+}
+
+// statusError APIServer 返回的错误
+type statusError struct {
+	method string
+	path   string
+	code   int
+	body   string
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("kubernetes %s %s: %d %s", e.method, e.path, e.code, e.body)
+}
+
+func (c *httpClient) do(ctx context.Context, client *http.Client, method, path, contentType string,
+	body []byte) ([]byte, error) {
+	rsp, err := c.send(ctx, client, method, path, contentType, body)
+	if err != nil {
+		return nil, err
+	}
+	defer rsp.Body.Close()
+	return io.ReadAll(rsp.Body)
+}
+
+func (c *httpClient) send(ctx context.Context, client *http.Client, method, path, contentType string,
+	body []byte) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.cfg.Address+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	// ServiceAccount token 会定期轮转，每次请求时重新读取
+	if c.cfg.TokenFile != "" {
+		token, err := os.ReadFile(c.cfg.TokenFile)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(string(token)))
+	}
+	rsp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if rsp.StatusCode/100 != 2 {
+		defer rsp.Body.Close()
+		data, _ := io.ReadAll(rsp.Body)
+		return nil, &statusError{method: method, path: path, code: rsp.StatusCode, body: string(data)}
+	}
+	return rsp, nil
+}
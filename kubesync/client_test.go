@@ -0,0 +1,120 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package kubesync
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestClient(t *testing.T, handler http.HandlerFunc) *httpClient {
+	svr := httptest.NewServer(handler)
+	t.Cleanup(svr.Close)
+	cfg := &Config{Address: svr.URL, Timeout: time.Second}
+	client, err := newHTTPClient(cfg)
+	assert.NoError(t, err)
+	return client
+}
+
+func TestInformer(t *testing.T) {
+	watched := make(chan struct{})
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v1/namespaces/default/services", r.URL.Path)
+		if r.URL.Query().Get("watch") == "" {
+			_, _ = io.WriteString(w, `{"metadata":{"resourceVersion":"10"},"items":[
+				{"metadata":{"name":"a","resourceVersion":"9"}},
+				{"metadata":{"name":"b","resourceVersion":"10"}}]}`)
+			return
+		}
+		if r.URL.Query().Get("resourceVersion") != "10" {
+			// 过期的 resourceVersion 需要重新 list
+			_, _ = io.WriteString(w, `{"type":"ERROR","object":{"code":410,"reason":"Expired"}}`)
+			return
+		}
+		events := []string{
+			`{"type":"ADDED","object":{"metadata":{"name":"c","resourceVersion":"11"}}}`,
+			`{"type":"DELETED","object":{"metadata":{"name":"a","resourceVersion":"12"}}}`,
+			`{"type":"BOOKMARK","object":{"metadata":{"resourceVersion":"13"}}}`,
+		}
+		for _, event := range events {
+			_, _ = fmt.Fprintln(w, event)
+		}
+		w.(http.Flusher).Flush()
+		close(watched)
+		<-r.Context().Done()
+	})
+
+	item := newInformer(client, resourceServices, "default", func() *Service { return &Service{} })
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	notified := make(chan struct{}, 10)
+	go item.run(ctx, func() { notified <- struct{}{} })
+
+	select {
+	case <-watched:
+	case <-time.After(5 * time.Second):
+		t.Fatal("watch not started")
+	}
+	assert.Eventually(t, func() bool {
+		names := map[string]struct{}{}
+		for _, svc := range item.List() {
+			names[svc.Metadata.Name] = struct{}{}
+			assert.Equal(t, "default", svc.Metadata.Namespace)
+		}
+		_, hasB := names["b"]
+		_, hasC := names["c"]
+		return len(names) == 2 && hasB && hasC
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.True(t, item.HasSynced())
+	// list 以及 ADDED、DELETED 事件会通知，BOOKMARK 不会
+	assert.Eventually(t, func() bool { return len(notified) == 3 }, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestHTTPClient_ApplyDelete(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPatch:
+			assert.Equal(t, "/apis/discovery.k8s.io/v1/namespaces/default/endpointslices/a", r.URL.Path)
+			assert.Equal(t, "application/apply-patch+yaml", r.Header.Get("Content-Type"))
+			assert.Equal(t, fieldManager, r.URL.Query().Get("fieldManager"))
+			slice := &EndpointSlice{}
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(slice))
+			assert.Equal(t, "a", slice.Metadata.Name)
+			_, _ = io.WriteString(w, `{}`)
+		case http.MethodDelete:
+			if r.URL.Path == "/api/v1/namespaces/default/services/missing" {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.WriteHeader(http.StatusForbidden)
+		}
+	})
+	ctx := context.Background()
+	assert.NoError(t, client.Apply(ctx, resourceEndpointSlices, "default", "a",
+		&EndpointSlice{Metadata: ObjectMeta{Name: "a", Namespace: "default"}}))
+	assert.NoError(t, client.Delete(ctx, resourceServices, "default", "missing"))
+	assert.Error(t, client.Delete(ctx, resourceServices, "default", "forbidden"))
+}
@@ -0,0 +1,137 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+package kubesync
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	defaultClusterName = "kubernetes"
+	defaultResync      = 30 * time.Second
+	defaultTimeout     = 10 * time.Second
+	defaultTokenFile   = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+	defaultCAFile      = "/var/run/secrets/kubernetes.io/serviceaccount/ca.crt"
+)
+
+// Config Kubernetes 服务同步的配置
+type Config struct {
+	Open bool `yaml:"open"`
+	// ClusterName Kubernetes 集群的名称，同步到 Polaris 的实例会记录其来源的集群
+	ClusterName string `yaml:"clusterName"`
+	// Address Kubernetes APIServer 的地址，为空时使用 Pod 内的 in-cluster 配置
+	Address string `yaml:"address"`
+	// TokenFile 访问 APIServer 的 ServiceAccount token 文件
+	TokenFile string `yaml:"tokenFile"`
+	// CAFile 校验 APIServer 证书的 CA 文件
+	CAFile string `yaml:"caFile"`
+	// Insecure 不校验 APIServer 的证书
+	Insecure bool `yaml:"insecure"`
+	// Resync 全量对账的间隔，Polaris 到 Kubernetes 方向的同步只在全量对账时进行
+	Resync time.Duration `yaml:"resync"`
+	// Timeout 单次请求 APIServer 的超时时间
+	Timeout time.Duration `yaml:"timeout"`
+	// SyncToKubernetes 是否将 Polaris 中注册的服务反向同步为 Kubernetes 中没有 selector 的 Service
+	SyncToKubernetes bool `yaml:"syncToKubernetes"`
+	// Namespaces Kubernetes 命名空间与 Polaris 命名空间的映射关系，只同步配置了映射的命名空间
+	Namespaces []*NamespaceMapping `yaml:"namespaces"`
+}
+
+// NamespaceMapping 命名空间的映射
+type NamespaceMapping struct {
+	Kubernetes string `yaml:"kubernetes"`
+	// Polaris 为空时与 Kubernetes 命名空间同名
+	Polaris string `yaml:"polaris"`
+}
+
+// setDefault 填充默认值并检查配置
+func (c *Config) setDefault() error {
+	if c.ClusterName == "" {
+		c.ClusterName = defaultClusterName
+	}
+	if c.Resync <= 0 {
+		c.Resync = defaultResync
+	}
+	if c.Timeout <= 0 {
+		c.Timeout = defaultTimeout
+	}
+	if !c.Open {
+		return nil
+	}
+	if c.Address == "" {
+		host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+		if host == "" || port == "" {
+			return errors.New("kubernetes address is empty and not running in cluster")
+		}
+		c.Address = "https://" + net.JoinHostPort(host, port)
+		if c.TokenFile == "" {
+			c.TokenFile = defaultTokenFile
+		}
+		if c.CAFile == "" {
+			c.CAFile = defaultCAFile
+		}
+	}
+	c.Address = strings.TrimSuffix(c.Address, "/")
+	if len(c.Namespaces) == 0 {
+		return errors.New("kubernetes sync namespaces is empty")
+	}
+	kubeNamespaces := make(map[string]struct{}, len(c.Namespaces))
+	polarisNamespaces := make(map[string]struct{}, len(c.Namespaces))
+	for _, mapping := range c.Namespaces {
+		if mapping.Kubernetes == "" {
+			return errors.New("kubernetes sync namespace is empty")
+		}
+		if mapping.Polaris == "" {
+			mapping.Polaris = mapping.Kubernetes
+		}
+		// 两个方向的同步都依赖映射关系，需要一一对应
+		if _, ok := kubeNamespaces[mapping.Kubernetes]; ok {
+			return fmt.Errorf("kubernetes namespace %s mapping duplicated", mapping.Kubernetes)
+		}
+		if _, ok := polarisNamespaces[mapping.Polaris]; ok {
+			return fmt.Errorf("polaris namespace %s mapping duplicated", mapping.Polaris)
+		}
+		kubeNamespaces[mapping.Kubernetes] = struct{}{}
+		polarisNamespaces[mapping.Polaris] = struct{}{}
+	}
+	return nil
+}
+
+// polarisNamespace Kubernetes 命名空间映射的 Polaris 命名空间
+func (c *Config) polarisNamespace(kubeNamespace string) (string, bool) {
+	for _, mapping := range c.Namespaces {
+		if mapping.Kubernetes == kubeNamespace {
+			return mapping.Polaris, true
+		}
+	}
+	return "", false
+}
+
+// kubeNamespace Polaris 命名空间映射的 Kubernetes 命名空间
+func (c *Config) kubeNamespace(polarisNamespace string) (string, bool) {
+	for _, mapping := range c.Namespaces {
+		if mapping.Polaris == polarisNamespace {
+			return mapping.Kubernetes, true
+		}
+	}
+	return "", false
+}
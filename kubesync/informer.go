@@ -0,0 +1,174 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+package kubesync
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// retryInterval list、watch 出错后重试的间隔
+	retryInterval = 5 * time.Second
+)
+
+// kubeObject 可以被 informer 缓存的资源
+type kubeObject interface {
+	*Service | *EndpointSlice
+}
+
+func objectMeta[T kubeObject](obj T) *ObjectMeta {
+	switch o := any(obj).(type) {
+	case *Service:
+		return &o.Metadata
+	case *EndpointSlice:
+		return &o.Metadata
+	}
+	return nil
+}
+
+// informer 通过 list/watch 在本地缓存一个命名空间下的资源，资源变更时通知同步任务
+type informer[T kubeObject] struct {
+	client    kubeClient
+	res       resource
+	namespace string
+	newObject func() T
+
+	lock   sync.RWMutex
+	items  map[string]T
+	synced bool
+}
+
+func newInformer[T kubeObject](client kubeClient, res resource, namespace string, newObject func() T) *informer[T] {
+	return &informer[T]{
+		client:    client,
+		res:       res,
+		namespace: namespace,
+		newObject: newObject,
+		items:     map[string]T{},
+	}
+}
+
+// HasSynced 是否已经完成了第一次 list，没有完成之前不能根据缓存删除数据
+func (i *informer[T]) HasSynced() bool {
+	i.lock.RLock()
+	defer i.lock.RUnlock()
+	return i.synced
+}
+
+// List 缓存中的全部资源
+func (i *informer[T]) List() []T {
+	i.lock.RLock()
+	defer i.lock.RUnlock()
+	ret := make([]T, 0, len(i.items))
+	for _, item := range i.items {
+		ret = append(ret, item)
+	}
+	return ret
+}
+
+// run 持续 list/watch，直到 ctx 结束
+func (i *informer[T]) run(ctx context.Context, notify func()) {
+	for ctx.Err() == nil {
+		resourceVersion, err := i.list(ctx)
+		if err != nil {
+			log.Error("[KubeSync] list resources", zap.String("namespace", i.namespace),
+				zap.String("resource", i.res.name), zap.Error(err))
+			sleep(ctx, retryInterval)
+			continue
+		}
+		notify()
+		for ctx.Err() == nil {
+			err = i.client.Watch(ctx, i.res, i.namespace, resourceVersion, func(event *watchEvent) {
+				if rv, changed := i.handle(event); rv != "" {
+					resourceVersion = rv
+					if changed {
+						notify()
+					}
+				}
+			})
+			if errors.Is(err, errResourceExpired) {
+				break
+			}
+			if err != nil {
+				log.Error("[KubeSync] watch resources", zap.String("namespace", i.namespace),
+					zap.String("resource", i.res.name), zap.Error(err))
+				sleep(ctx, retryInterval)
+			}
+		}
+	}
+}
+
+// list 全量替换本地缓存，返回列表的 resourceVersion
+func (i *informer[T]) list(ctx context.Context) (string, error) {
+	list, err := i.client.List(ctx, i.res, i.namespace)
+	if err != nil {
+		return "", err
+	}
+	items := make(map[string]T, len(list.Items))
+	for _, raw := range list.Items {
+		obj := i.newObject()
+		if err := json.Unmarshal(raw, obj); err != nil {
+			return "", err
+		}
+		meta := objectMeta(obj)
+		meta.Namespace = i.namespace
+		items[meta.key()] = obj
+	}
+	i.lock.Lock()
+	i.items = items
+	i.synced = true
+	i.lock.Unlock()
+	return list.Metadata.ResourceVersion, nil
+}
+
+// handle 处理一个 watch 事件，返回事件携带的 resourceVersion 以及缓存是否发生了变化
+func (i *informer[T]) handle(event *watchEvent) (string, bool) {
+	obj := i.newObject()
+	if err := json.Unmarshal(event.Object, obj); err != nil {
+		log.Error("[KubeSync] decode watch event", zap.String("resource", i.res.name), zap.Error(err))
+		return "", false
+	}
+	meta := objectMeta(obj)
+	meta.Namespace = i.namespace
+	i.lock.Lock()
+	defer i.lock.Unlock()
+	switch event.Type {
+	case "ADDED", "MODIFIED":
+		i.items[meta.key()] = obj
+	case "DELETED":
+		delete(i.items, meta.key())
+	default:
+		// BOOKMARK 只更新 resourceVersion
+		return meta.ResourceVersion, false
+	}
+	return meta.ResourceVersion, true
+}
+
+func sleep(ctx context.Context, d time.Duration) {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+	case <-timer.C:
+	}
+}
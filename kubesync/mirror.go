@@ -0,0 +1,223 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+package kubesync
+
+import (
+	"strconv"
+	"strings"
+
+	apimodel "github.com/polarismesh/specification/source/go/api/v1/model"
+	apiservice "github.com/polarismesh/specification/source/go/api/v1/service_manage"
+
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/common/utils"
+)
+
+const (
+	// registerFromKubernetes 从 Kubernetes 同步过来的实例在 internal-register-from 中的取值
+	registerFromKubernetes = "kubernetes"
+	// defaultWeight 从 Kubernetes 同步过来的实例的权重
+	defaultWeight = 100
+)
+
+// kubeState 同步涉及的全部 Kubernetes 资源，key 为 Kubernetes 命名空间
+type kubeState struct {
+	services map[string][]*Service
+	slices   map[string][]*EndpointSlice
+}
+
+// mirrorPlan Kubernetes 到 Polaris 方向需要执行的变更
+type mirrorPlan struct {
+	createServices  []*apiservice.Service
+	createInstances []*apiservice.Instance
+	updateInstances []*apiservice.Instance
+	deleteInstances []*apiservice.Instance
+}
+
+// planMirror 将 Kubernetes Service 的端点同步为 Polaris 中的实例
+// 1. 每一个 ready 或者状态未知的端点地址与端口的组合对应一个健康的实例，not ready 的端点对应不健康的实例，terminating 的端点被忽略
+// 2. Polaris 中已经以相同地址注册的实例优先，不会被覆盖
+// 3. 本集群同步过来的实例如果在 Kubernetes 中已经不存在，则被删除
+// 4. 由 Polaris 反向同步到 Kubernetes 的 Service 不会再同步回 Polaris
+func planMirror(cfg *Config, state *kubeState, source localSource) (*mirrorPlan, error) {
+	desired := map[model.ServiceKey]map[string]*apiservice.Instance{}
+	for _, mapping := range cfg.Namespaces {
+		for _, svc := range state.services[mapping.Kubernetes] {
+			if isPolarisManaged(svc.Metadata.Labels) || svc.Spec.Type == "ExternalName" {
+				continue
+			}
+			key := model.ServiceKey{Namespace: mapping.Polaris, Name: svc.Metadata.Name}
+			desired[key] = map[string]*apiservice.Instance{}
+		}
+		for _, slice := range state.slices[mapping.Kubernetes] {
+			if slice.Metadata.Labels[LabelEndpointSliceManagedBy] == EndpointSliceManagedByPolaris {
+				continue
+			}
+			key := model.ServiceKey{Namespace: mapping.Polaris, Name: slice.Metadata.Labels[LabelServiceName]}
+			instances, ok := desired[key]
+			if !ok {
+				continue
+			}
+			for _, ins := range sliceInstances(cfg.ClusterName, key, mapping.Kubernetes, slice) {
+				instances[instanceAddress(ins.GetHost().GetValue(), ins.GetPort().GetValue())] = ins
+			}
+		}
+	}
+
+	plan := &mirrorPlan{}
+	existServices := map[model.ServiceKey]struct{}{}
+	err := source.IteratorServices(func(_ string, svc *model.Service) (bool, error) {
+		if svc.IsAlias() {
+			return true, nil
+		}
+		if _, ok := cfg.kubeNamespace(svc.Namespace); !ok {
+			return true, nil
+		}
+		key := model.ServiceKey{Namespace: svc.Namespace, Name: svc.Name}
+		existServices[key] = struct{}{}
+		instances := desired[key]
+		for _, ins := range source.GetInstancesByServiceID(svc.ID) {
+			address := instanceAddress(ins.Host(), ins.Port())
+			want, ok := instances[address]
+			if ins.Metadata()[model.MetadataKubernetesCluster] != cfg.ClusterName {
+				// 相同地址的实例已经以其他方式注册，不再重复同步
+				delete(instances, address)
+				continue
+			}
+			if !ok {
+				plan.deleteInstances = append(plan.deleteInstances, &apiservice.Instance{
+					Id: utils.NewStringValue(ins.ID()),
+				})
+				continue
+			}
+			delete(instances, address)
+			if instanceChanged(ins, want) {
+				want.Id = utils.NewStringValue(ins.ID())
+				plan.updateInstances = append(plan.updateInstances, want)
+			}
+		}
+		return true, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	for key, instances := range desired {
+		if len(instances) == 0 {
+			continue
+		}
+		if _, ok := existServices[key]; !ok {
+			kubeNamespace, _ := cfg.kubeNamespace(key.Namespace)
+			plan.createServices = append(plan.createServices, &apiservice.Service{
+				Name:      utils.NewStringValue(key.Name),
+				Namespace: utils.NewStringValue(key.Namespace),
+				Metadata: map[string]string{
+					model.MetadataKubernetesCluster:   cfg.ClusterName,
+					model.MetadataKubernetesNamespace: kubeNamespace,
+				},
+			})
+		}
+		for _, ins := range instances {
+			plan.createInstances = append(plan.createInstances, ins)
+		}
+	}
+	return plan, nil
+}
+
+// sliceInstances EndpointSlice 中的端点对应的实例
+func sliceInstances(clusterName string, key model.ServiceKey, kubeNamespace string,
+	slice *EndpointSlice) []*apiservice.Instance {
+	if slice.AddressType != addressTypeIPv4 && slice.AddressType != addressTypeIPv6 {
+		return nil
+	}
+	var ret []*apiservice.Instance
+	for _, endpoint := range slice.Endpoints {
+		if endpoint.Conditions.Terminating != nil && *endpoint.Conditions.Terminating {
+			continue
+		}
+		healthy := endpoint.Conditions.Ready == nil || *endpoint.Conditions.Ready
+		metadata := map[string]string{
+			model.MetadataKubernetesCluster:   clusterName,
+			model.MetadataKubernetesNamespace: kubeNamespace,
+			model.MetadataRegisterFrom:        registerFromKubernetes,
+		}
+		if endpoint.TargetRef != nil && endpoint.TargetRef.Kind == "Pod" {
+			metadata[model.MetadataKubernetesPod] = endpoint.TargetRef.Name
+		}
+		if endpoint.NodeName != nil {
+			metadata[model.MetadataKubernetesNode] = *endpoint.NodeName
+		}
+		zone := ""
+		if endpoint.Zone != nil {
+			zone = *endpoint.Zone
+		}
+		for _, address := range endpoint.Addresses {
+			for _, port := range slice.Ports {
+				if port.Port == nil {
+					continue
+				}
+				ret = append(ret, &apiservice.Instance{
+					Service:           utils.NewStringValue(key.Name),
+					Namespace:         utils.NewStringValue(key.Namespace),
+					Host:              utils.NewStringValue(address),
+					Port:              utils.NewUInt32Value(uint32(*port.Port)),
+					Protocol:          utils.NewStringValue(portProtocol(port)),
+					Weight:            utils.NewUInt32Value(defaultWeight),
+					Healthy:           utils.NewBoolValue(healthy),
+					EnableHealthCheck: utils.NewBoolValue(false),
+					Location:          &apimodel.Location{Zone: utils.NewStringValue(zone)},
+					Metadata:          metadata,
+				})
+			}
+		}
+	}
+	return ret
+}
+
+// portProtocol 优先使用端口的名称作为实例的协议，例如 http、grpc
+func portProtocol(port EndpointPort) string {
+	if port.Name != nil && *port.Name != "" {
+		return *port.Name
+	}
+	if port.Protocol != nil {
+		return strings.ToLower(*port.Protocol)
+	}
+	return ""
+}
+
+// instanceChanged Kubernetes 中端点的状态是否与 Polaris 中的实例不一致，元数据只比较同步写入的部分
+func instanceChanged(local *model.Instance, want *apiservice.Instance) bool {
+	if local.Healthy() != want.GetHealthy().GetValue() || local.Protocol() != want.GetProtocol().GetValue() ||
+		local.Location().GetZone().GetValue() != want.GetLocation().GetZone().GetValue() {
+		return true
+	}
+	metadata := local.Metadata()
+	for k, v := range want.GetMetadata() {
+		if metadata[k] != v {
+			return true
+		}
+	}
+	return false
+}
+
+func instanceAddress(host string, port uint32) string {
+	return host + ":" + strconv.FormatUint(uint64(port), 10)
+}
+
+// isPolarisManaged 资源是否由 Polaris 反向同步到 Kubernetes
+func isPolarisManaged(labels map[string]string) bool {
+	return labels[LabelManagedBy] == ManagedByPolaris
+}
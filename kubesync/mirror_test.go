@@ -0,0 +1,283 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package kubesync
+
+import (
+	"testing"
+
+	apiservice "github.com/polarismesh/specification/source/go/api/v1/service_manage"
+	"github.com/stretchr/testify/assert"
+
+	cachetypes "github.com/polarismesh/polaris/cache/api"
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/common/utils"
+)
+
+type mockSource struct {
+	services  []*model.Service
+	instances map[string][]*model.Instance
+}
+
+func (m *mockSource) IteratorServices(iterProc cachetypes.ServiceIterProc) error {
+	for _, svc := range m.services {
+		if _, err := iterProc(svc.ID, svc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *mockSource) GetInstancesByServiceID(serviceID string) []*model.Instance {
+	return m.instances[serviceID]
+}
+
+func mockInstance(id, host string, port uint32, healthy bool, metadata map[string]string) *model.Instance {
+	return &model.Instance{
+		Proto: &apiservice.Instance{
+			Id:       utils.NewStringValue(id),
+			Host:     utils.NewStringValue(host),
+			Port:     utils.NewUInt32Value(port),
+			Protocol: utils.NewStringValue("http"),
+			Weight:   utils.NewUInt32Value(100),
+			Healthy:  utils.NewBoolValue(healthy),
+			Isolate:  utils.NewBoolValue(false),
+			Metadata: metadata,
+		},
+		Valid: true,
+	}
+}
+
+func kubeMetadata(pod string) map[string]string {
+	return map[string]string{
+		model.MetadataKubernetesCluster:   "test",
+		model.MetadataKubernetesNamespace: "default",
+		model.MetadataRegisterFrom:        registerFromKubernetes,
+		model.MetadataKubernetesPod:       pod,
+	}
+}
+
+func mockSlice(service string, port int32, endpoints ...Endpoint) *EndpointSlice {
+	name := "http"
+	return &EndpointSlice{
+		Metadata: ObjectMeta{
+			Name:      service + "-abc",
+			Namespace: "default",
+			Labels:    map[string]string{LabelServiceName: service},
+		},
+		AddressType: addressTypeIPv4,
+		Endpoints:   endpoints,
+		Ports:       []EndpointPort{{Name: &name, Port: &port}},
+	}
+}
+
+func mockEndpoint(address, pod string, ready bool, terminating bool) Endpoint {
+	return Endpoint{
+		Addresses:  []string{address},
+		Conditions: EndpointConditions{Ready: &ready, Terminating: &terminating},
+		TargetRef:  &ObjectReference{Kind: "Pod", Name: pod},
+	}
+}
+
+func testConfig() *Config {
+	return &Config{
+		Open:        true,
+		ClusterName: "test",
+		Address:     "http://127.0.0.1:6443",
+		Namespaces:  []*NamespaceMapping{{Kubernetes: "default", Polaris: "k8s"}},
+	}
+}
+
+func instanceIDs(instances []*apiservice.Instance) []string {
+	ret := []string{}
+	for _, ins := range instances {
+		if ins.GetId().GetValue() != "" {
+			ret = append(ret, ins.GetId().GetValue())
+			continue
+		}
+		ret = append(ret, instanceAddress(ins.GetHost().GetValue(), ins.GetPort().GetValue()))
+	}
+	return ret
+}
+
+func TestConfig_setDefault(t *testing.T) {
+	cfg := &Config{Open: true, Address: "http://127.0.0.1:6443/"}
+	assert.Error(t, cfg.setDefault())
+
+	cfg.Namespaces = []*NamespaceMapping{{Kubernetes: "default"}, {Kubernetes: "test", Polaris: "default"}}
+	assert.Error(t, cfg.setDefault())
+
+	cfg.Namespaces = []*NamespaceMapping{{Kubernetes: "default"}, {Kubernetes: "test", Polaris: "polaris-test"}}
+	assert.NoError(t, cfg.setDefault())
+	assert.Equal(t, "http://127.0.0.1:6443", cfg.Address)
+	assert.Equal(t, defaultClusterName, cfg.ClusterName)
+	namespace, ok := cfg.polarisNamespace("default")
+	assert.True(t, ok)
+	assert.Equal(t, "default", namespace)
+	namespace, ok = cfg.kubeNamespace("polaris-test")
+	assert.True(t, ok)
+	assert.Equal(t, "test", namespace)
+}
+
+func Test_planMirror(t *testing.T) {
+	cfg := testConfig()
+	state := &kubeState{
+		services: map[string][]*Service{
+			"default": {
+				{Metadata: ObjectMeta{Name: "exist", Namespace: "default"}},
+				{Metadata: ObjectMeta{Name: "new", Namespace: "default"}},
+				{Metadata: ObjectMeta{Name: "reverse", Namespace: "default", Labels: map[string]string{
+					LabelManagedBy: ManagedByPolaris,
+				}}},
+			},
+		},
+		slices: map[string][]*EndpointSlice{
+			"default": {
+				mockSlice("exist", 8080,
+					mockEndpoint("10.0.0.1", "pod-1", true, false),
+					mockEndpoint("10.0.0.2", "pod-2", false, false),
+					mockEndpoint("10.0.0.3", "pod-3", true, true),
+					mockEndpoint("10.0.0.4", "pod-4", true, false),
+					mockEndpoint("10.0.0.5", "pod-5", true, false),
+				),
+				mockSlice("new", 8080, mockEndpoint("10.0.1.1", "pod-6", true, false)),
+				mockSlice("reverse", 8080, mockEndpoint("10.0.2.1", "pod-7", true, false)),
+			},
+		},
+	}
+	source := &mockSource{
+		services: []*model.Service{
+			{ID: "exist", Namespace: "k8s", Name: "exist"},
+			{ID: "other", Namespace: "other", Name: "exist"},
+		},
+		instances: map[string][]*model.Instance{
+			"exist": {
+				// 状态没有变化
+				mockInstance("ins-1", "10.0.0.1", 8080, true, kubeMetadata("pod-1")),
+				// 端点变成了 not ready
+				mockInstance("ins-2", "10.0.0.2", 8080, true, kubeMetadata("pod-2")),
+				// 端点正在下线
+				mockInstance("ins-3", "10.0.0.3", 8080, true, kubeMetadata("pod-3")),
+				// 相同地址的实例已经通过其他方式注册
+				mockInstance("ins-4", "10.0.0.4", 8080, true, nil),
+				// Kubernetes 中已经不存在
+				mockInstance("ins-9", "10.0.0.9", 8080, true, kubeMetadata("pod-9")),
+			},
+			"other": {
+				mockInstance("ins-10", "10.0.0.10", 8080, true, kubeMetadata("pod-10")),
+			},
+		},
+	}
+	plan, err := planMirror(cfg, state, source)
+	assert.NoError(t, err)
+
+	assert.Len(t, plan.createServices, 1)
+	assert.Equal(t, "new", plan.createServices[0].GetName().GetValue())
+	assert.Equal(t, "k8s", plan.createServices[0].GetNamespace().GetValue())
+	assert.Equal(t, "test", plan.createServices[0].GetMetadata()[model.MetadataKubernetesCluster])
+
+	assert.ElementsMatch(t, []string{"10.0.0.5:8080", "10.0.1.1:8080"}, instanceIDs(plan.createInstances))
+	assert.ElementsMatch(t, []string{"ins-2"}, instanceIDs(plan.updateInstances))
+	assert.False(t, plan.updateInstances[0].GetHealthy().GetValue())
+	assert.ElementsMatch(t, []string{"ins-3", "ins-9"}, instanceIDs(plan.deleteInstances))
+	for _, ins := range plan.createInstances {
+		assert.False(t, ins.GetEnableHealthCheck().GetValue())
+		assert.Equal(t, "http", ins.GetProtocol().GetValue())
+		assert.Equal(t, "test", ins.GetMetadata()[model.MetadataKubernetesCluster])
+	}
+}
+
+func Test_planReverse(t *testing.T) {
+	cfg := testConfig()
+	source := &mockSource{
+		services: []*model.Service{
+			{ID: "vm", Namespace: "k8s", Name: "vm"},
+			{ID: "native", Namespace: "k8s", Name: "native"},
+			{ID: "mirror", Namespace: "k8s", Name: "mirror", Meta: map[string]string{
+				model.MetadataKubernetesCluster: "test",
+			}},
+			{ID: "invalid", Namespace: "k8s", Name: "Invalid.Name"},
+		},
+		instances: map[string][]*model.Instance{
+			"vm": {
+				mockInstance("ins-2", "192.168.0.2", 8080, false, nil),
+				mockInstance("ins-1", "192.168.0.1", 8080, true, nil),
+				mockInstance("ins-3", "192.168.0.3", 9090, true, nil),
+				mockInstance("ins-4", "vm.example.com", 8080, true, nil),
+				mockInstance("ins-5", "10.0.0.1", 8080, true, kubeMetadata("pod-1")),
+			},
+			"native":  {mockInstance("ins-6", "192.168.1.1", 8080, true, nil)},
+			"mirror":  {mockInstance("ins-7", "192.168.2.1", 8080, true, nil)},
+			"invalid": {mockInstance("ins-8", "192.168.3.1", 8080, true, nil)},
+		},
+	}
+	managed := map[string]string{LabelManagedBy: ManagedByPolaris}
+	state := &kubeState{
+		services: map[string][]*Service{
+			"default": {
+				{Metadata: ObjectMeta{Name: "native", Namespace: "default"}},
+				{Metadata: ObjectMeta{Name: "stale", Namespace: "default", Labels: managed}},
+			},
+		},
+		slices: map[string][]*EndpointSlice{
+			"default": {
+				{Metadata: ObjectMeta{Name: "stale-polaris-ipv4-8080", Namespace: "default", Labels: map[string]string{
+					LabelServiceName:            "stale",
+					LabelEndpointSliceManagedBy: EndpointSliceManagedByPolaris,
+				}}},
+			},
+		},
+	}
+	plan, err := planReverse(cfg, state, source)
+	assert.NoError(t, err)
+
+	assert.Len(t, plan.applyServices, 1)
+	svc := plan.applyServices[0]
+	assert.Equal(t, "vm", svc.Metadata.Name)
+	assert.Equal(t, "default", svc.Metadata.Namespace)
+	assert.Equal(t, "None", svc.Spec.ClusterIP)
+	assert.Equal(t, []ServicePort{
+		{Name: "http-8080", Protocol: "TCP", Port: 8080},
+		{Name: "http-9090", Protocol: "TCP", Port: 9090},
+	}, svc.Spec.Ports)
+
+	assert.Len(t, plan.applySlices, 2)
+	slice := plan.applySlices[0]
+	assert.Equal(t, "vm-polaris-ipv4-8080", slice.Metadata.Name)
+	assert.Len(t, slice.Endpoints, 2)
+	assert.Equal(t, []string{"192.168.0.1"}, slice.Endpoints[0].Addresses)
+	assert.True(t, *slice.Endpoints[0].Conditions.Ready)
+	assert.False(t, *slice.Endpoints[1].Conditions.Ready)
+
+	assert.Equal(t, []ObjectMeta{state.services["default"][1].Metadata}, plan.deleteServices)
+	assert.Equal(t, []ObjectMeta{state.slices["default"][0].Metadata}, plan.deleteSlices)
+
+	// 与 Kubernetes 中一致的资源不会重复写入
+	state.services["default"] = append(state.services["default"], svc)
+	state.slices["default"] = append(state.slices["default"], plan.applySlices...)
+	plan, err = planReverse(cfg, state, source)
+	assert.NoError(t, err)
+	assert.Empty(t, plan.applyServices)
+	assert.Empty(t, plan.applySlices)
+}
+
+func Test_servicePortName(t *testing.T) {
+	assert.Equal(t, "grpc-9090", servicePortName("gRPC", 9090))
+	assert.Equal(t, "port-8080", servicePortName("", 8080))
+	assert.Equal(t, "port-8080", servicePortName("dubbo.v2", 8080))
+	assert.Equal(t, "port-8080", servicePortName("very-long-protocol", 8080))
+}
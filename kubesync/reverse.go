@@ -0,0 +1,247 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+package kubesync
+
+import (
+	"net"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/polarismesh/polaris/common/model"
+)
+
+var (
+	// dnsLabel Kubernetes Service 名称需要满足 RFC 1035 label 的格式
+	dnsLabel = regexp.MustCompile(`^[a-z]([-a-z0-9]*[a-z0-9])?$`)
+)
+
+// reversePlan Polaris 到 Kubernetes 方向需要执行的变更
+type reversePlan struct {
+	applyServices  []*Service
+	applySlices    []*EndpointSlice
+	deleteServices []ObjectMeta
+	deleteSlices   []ObjectMeta
+}
+
+// planReverse 将 Polaris 中注册的服务同步为 Kubernetes 中没有 selector 的 headless Service 以及 EndpointSlice
+// 1. 从 Kubernetes 同步过来的服务以及实例不会被反向同步
+// 2. Kubernetes 中已经存在同名的 Service 且不由 Polaris 管理时，不会被覆盖
+// 3. 每一个地址类型与端口的组合对应一个 EndpointSlice，健康且没有被隔离的实例对应 ready 的端点
+// 4. 与缓存中一致的资源不会重复写入，Polaris 中已经不存在的服务对应的资源被删除
+func planReverse(cfg *Config, state *kubeState, source localSource) (*reversePlan, error) {
+	wantServices := map[string]*Service{}
+	wantSlices := map[string]*EndpointSlice{}
+	err := source.IteratorServices(func(_ string, svc *model.Service) (bool, error) {
+		kubeNamespace, ok := cfg.kubeNamespace(svc.Namespace)
+		if !ok || svc.IsAlias() || svc.Meta[model.MetadataKubernetesCluster] != "" || len(svc.Name) > 63 ||
+			!dnsLabel.MatchString(svc.Name) {
+			return true, nil
+		}
+		instances := source.GetInstancesByServiceID(svc.ID)
+		kubeSvc, slices := buildKubeResources(kubeNamespace, svc.Name, instances)
+		if kubeSvc == nil {
+			return true, nil
+		}
+		wantServices[kubeSvc.Metadata.key()] = kubeSvc
+		for _, slice := range slices {
+			wantSlices[slice.Metadata.key()] = slice
+		}
+		return true, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &reversePlan{}
+	for _, mapping := range cfg.Namespaces {
+		for _, svc := range state.services[mapping.Kubernetes] {
+			key := svc.Metadata.key()
+			want, ok := wantServices[key]
+			if !isPolarisManaged(svc.Metadata.Labels) {
+				// Kubernetes 原生的同名 Service 优先
+				if ok {
+					delete(wantServices, key)
+					for sliceKey, slice := range wantSlices {
+						if slice.Metadata.Namespace == svc.Metadata.Namespace &&
+							slice.Metadata.Labels[LabelServiceName] == svc.Metadata.Name {
+							delete(wantSlices, sliceKey)
+						}
+					}
+				}
+				continue
+			}
+			if !ok {
+				plan.deleteServices = append(plan.deleteServices, svc.Metadata)
+				continue
+			}
+			if serviceEqual(svc, want) {
+				delete(wantServices, key)
+			}
+		}
+		for _, slice := range state.slices[mapping.Kubernetes] {
+			if slice.Metadata.Labels[LabelEndpointSliceManagedBy] != EndpointSliceManagedByPolaris {
+				continue
+			}
+			key := slice.Metadata.key()
+			want, ok := wantSlices[key]
+			if !ok {
+				plan.deleteSlices = append(plan.deleteSlices, slice.Metadata)
+				continue
+			}
+			if sliceEqual(slice, want) {
+				delete(wantSlices, key)
+			}
+		}
+	}
+	for _, svc := range wantServices {
+		plan.applyServices = append(plan.applyServices, svc)
+	}
+	for _, slice := range wantSlices {
+		plan.applySlices = append(plan.applySlices, slice)
+	}
+	sort.Slice(plan.applyServices, func(i, j int) bool {
+		return plan.applyServices[i].Metadata.key() < plan.applyServices[j].Metadata.key()
+	})
+	sort.Slice(plan.applySlices, func(i, j int) bool {
+		return plan.applySlices[i].Metadata.key() < plan.applySlices[j].Metadata.key()
+	})
+	return plan, nil
+}
+
+// sliceGroup 同一个地址类型以及端口的实例
+type sliceGroup struct {
+	addressType string
+	port        uint32
+	protocol    string
+	endpoints   []Endpoint
+}
+
+// buildKubeResources 构建 Polaris 服务对应的 Service 以及 EndpointSlice，没有可以同步的实例时返回 nil
+func buildKubeResources(namespace, name string, instances []*model.Instance) (*Service, []*EndpointSlice) {
+	groups := map[string]*sliceGroup{}
+	for _, ins := range instances {
+		if ins.Metadata()[model.MetadataKubernetesCluster] != "" {
+			continue
+		}
+		ip := net.ParseIP(ins.Host())
+		if ip == nil {
+			continue
+		}
+		addressType := addressTypeIPv6
+		if ip.To4() != nil {
+			addressType = addressTypeIPv4
+		}
+		groupKey := addressType + "/" + strconv.FormatUint(uint64(ins.Port()), 10)
+		group, ok := groups[groupKey]
+		if !ok {
+			group = &sliceGroup{addressType: addressType, port: ins.Port(), protocol: ins.Protocol()}
+			groups[groupKey] = group
+		}
+		ready := ins.Healthy() && !ins.Isolate() && ins.Weight() > 0
+		endpoint := Endpoint{
+			Addresses:  []string{ins.Host()},
+			Conditions: EndpointConditions{Ready: &ready},
+		}
+		if zone := ins.Location().GetZone().GetValue(); zone != "" {
+			endpoint.Zone = &zone
+		}
+		group.endpoints = append(group.endpoints, endpoint)
+	}
+	if len(groups) == 0 {
+		return nil, nil
+	}
+
+	svc := &Service{
+		APIVersion: "v1",
+		Kind:       "Service",
+		Metadata: ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels:    map[string]string{LabelManagedBy: ManagedByPolaris},
+		},
+		Spec: ServiceSpec{ClusterIP: "None"},
+	}
+	ports := map[uint32]ServicePort{}
+	slices := make([]*EndpointSlice, 0, len(groups))
+	for _, group := range groups {
+		portName := servicePortName(group.protocol, group.port)
+		port := int32(group.port)
+		tcp := "TCP"
+		if _, ok := ports[group.port]; !ok {
+			ports[group.port] = ServicePort{Name: portName, Protocol: tcp, Port: port}
+		}
+		sort.Slice(group.endpoints, func(i, j int) bool {
+			return group.endpoints[i].Addresses[0] < group.endpoints[j].Addresses[0]
+		})
+		slices = append(slices, &EndpointSlice{
+			APIVersion: "discovery.k8s.io/v1",
+			Kind:       "EndpointSlice",
+			Metadata: ObjectMeta{
+				Name:      name + "-polaris-" + strings.ToLower(group.addressType) + "-" + strconv.Itoa(int(port)),
+				Namespace: namespace,
+				Labels: map[string]string{
+					LabelServiceName:            name,
+					LabelEndpointSliceManagedBy: EndpointSliceManagedByPolaris,
+				},
+			},
+			AddressType: group.addressType,
+			Endpoints:   group.endpoints,
+			Ports:       []EndpointPort{{Name: &portName, Protocol: &tcp, Port: &port}},
+		})
+	}
+	for _, port := range ports {
+		svc.Spec.Ports = append(svc.Spec.Ports, port)
+	}
+	sort.Slice(svc.Spec.Ports, func(i, j int) bool {
+		return svc.Spec.Ports[i].Port < svc.Spec.Ports[j].Port
+	})
+	return svc, slices
+}
+
+// servicePortName 实例的协议满足端口名称的格式时作为端口名称的前缀，便于 Kubernetes 中的网格识别协议
+func servicePortName(protocol string, port uint32) string {
+	prefix := strings.ToLower(protocol)
+	name := prefix + "-" + strconv.FormatUint(uint64(port), 10)
+	if prefix == "" || len(name) > 15 || !dnsLabel.MatchString(name) {
+		return "port-" + strconv.FormatUint(uint64(port), 10)
+	}
+	return name
+}
+
+func serviceEqual(current, want *Service) bool {
+	return labelsContain(current.Metadata.Labels, want.Metadata.Labels) &&
+		current.Spec.ClusterIP == want.Spec.ClusterIP && len(current.Spec.Selector) == 0 &&
+		reflect.DeepEqual(current.Spec.Ports, want.Spec.Ports)
+}
+
+func sliceEqual(current, want *EndpointSlice) bool {
+	return labelsContain(current.Metadata.Labels, want.Metadata.Labels) &&
+		current.AddressType == want.AddressType &&
+		reflect.DeepEqual(current.Endpoints, want.Endpoints) && reflect.DeepEqual(current.Ports, want.Ports)
+}
+
+func labelsContain(labels, want map[string]string) bool {
+	for k, v := range want {
+		if labels[k] != v {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,283 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+package kubesync
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	apimodel "github.com/polarismesh/specification/source/go/api/v1/model"
+	apiservice "github.com/polarismesh/specification/source/go/api/v1/service_manage"
+	"go.uber.org/zap"
+
+	"github.com/polarismesh/polaris/cache"
+	cachetypes "github.com/polarismesh/polaris/cache/api"
+	api "github.com/polarismesh/polaris/common/api/v1"
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/common/utils"
+	"github.com/polarismesh/polaris/service"
+	"github.com/polarismesh/polaris/store"
+)
+
+const (
+	// writeBatchSize 单次写入 Polaris 的实例数
+	writeBatchSize = 100
+	// debounceInterval Kubernetes 资源变更后等待一段时间再对账，合并短时间内的多次变更
+	debounceInterval = time.Second
+	// operator 同步写入 Polaris 时记录的操作人
+	operator = "kubernetes-sync"
+)
+
+var (
+	server     = &Server{}
+	finishInit bool
+)
+
+// localSource Polaris 中的服务以及实例
+type localSource interface {
+	IteratorServices(iterProc cachetypes.ServiceIterProc) error
+	GetInstancesByServiceID(serviceID string) []*model.Instance
+}
+
+// registry 同步到 Polaris 的服务以及实例的写入，由服务发现模块实现
+type registry interface {
+	CreateServices(ctx context.Context, req []*apiservice.Service) *apiservice.BatchWriteResponse
+	CreateInstances(ctx context.Context, reqs []*apiservice.Instance) *apiservice.BatchWriteResponse
+	UpdateInstances(ctx context.Context, req []*apiservice.Instance) *apiservice.BatchWriteResponse
+	DeleteInstances(ctx context.Context, req []*apiservice.Instance) *apiservice.BatchWriteResponse
+}
+
+// leaderElector 集群内只有 leader 节点执行同步
+type leaderElector interface {
+	StartLeaderElection(key string) error
+	IsLeader(key string) bool
+}
+
+// cacheSource 基于缓存读取 Polaris 中的服务以及实例
+type cacheSource struct {
+	services  cachetypes.ServiceCache
+	instances cachetypes.InstanceCache
+}
+
+func (c *cacheSource) IteratorServices(iterProc cachetypes.ServiceIterProc) error {
+	return c.services.IteratorServices(iterProc)
+}
+
+func (c *cacheSource) GetInstancesByServiceID(serviceID string) []*model.Instance {
+	return c.instances.GetInstancesByServiceID(serviceID)
+}
+
+// Server Kubernetes 服务同步，监听 Kubernetes 中的 Service 以及 EndpointSlice 并同步为 Polaris 中的服务以及实例
+// 开启 syncToKubernetes 后，Polaris 中注册的服务同时会同步为 Kubernetes 中的 Service
+type Server struct {
+	cfg      *Config
+	client   kubeClient
+	registry registry
+	source   localSource
+	elector  leaderElector
+	// services、slices key 为 Kubernetes 命名空间
+	services map[string]*informer[*Service]
+	slices   map[string]*informer[*EndpointSlice]
+	trigger  chan struct{}
+}
+
+// Initialize 初始化 Kubernetes 服务同步，namingServer 需要是没有经过鉴权的服务发现模块
+func Initialize(ctx context.Context, cfg *Config, namingServer service.DiscoverServer,
+	cacheMgn *cache.CacheManager, storage store.Store) error {
+	if finishInit {
+		return nil
+	}
+	if err := cfg.setDefault(); err != nil {
+		return err
+	}
+	if cfg.Open {
+		client, err := newHTTPClient(cfg)
+		if err != nil {
+			return err
+		}
+		source := &cacheSource{services: cacheMgn.Service(), instances: cacheMgn.Instance()}
+		if err := server.initialize(ctx, cfg, client, namingServer, source, storage); err != nil {
+			return err
+		}
+	}
+	finishInit = true
+	return nil
+}
+
+func (s *Server) initialize(ctx context.Context, cfg *Config, client kubeClient, registry registry,
+	source localSource, elector leaderElector) error {
+	s.cfg = cfg
+	s.client = client
+	s.registry = registry
+	s.source = source
+	s.elector = elector
+	s.services = make(map[string]*informer[*Service], len(cfg.Namespaces))
+	s.slices = make(map[string]*informer[*EndpointSlice], len(cfg.Namespaces))
+	s.trigger = make(chan struct{}, 1)
+	for _, mapping := range cfg.Namespaces {
+		s.services[mapping.Kubernetes] = newInformer(client, resourceServices, mapping.Kubernetes,
+			func() *Service { return &Service{} })
+		s.slices[mapping.Kubernetes] = newInformer(client, resourceEndpointSlices, mapping.Kubernetes,
+			func() *EndpointSlice { return &EndpointSlice{} })
+	}
+	if err := elector.StartLeaderElection(store.ElectionKeyKubernetesSync); err != nil {
+		return err
+	}
+	for _, mapping := range cfg.Namespaces {
+		go s.services[mapping.Kubernetes].run(ctx, s.notify)
+		go s.slices[mapping.Kubernetes].run(ctx, s.notify)
+	}
+	go s.run(ctx)
+	return nil
+}
+
+// notify 通知同步任务 Kubernetes 中的资源发生了变化
+func (s *Server) notify() {
+	select {
+	case s.trigger <- struct{}{}:
+	default:
+	}
+}
+
+func (s *Server) run(ctx context.Context) {
+	ticker := time.NewTicker(s.cfg.Resync)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.trigger:
+			sleep(ctx, debounceInterval)
+		case <-ticker.C:
+		}
+		if !s.elector.IsLeader(store.ElectionKeyKubernetesSync) {
+			continue
+		}
+		if err := s.reconcile(ctx); err != nil {
+			log.Error("[KubeSync] reconcile", zap.Error(err))
+		}
+	}
+}
+
+// synced 全部 informer 是否已经完成了第一次 list
+func (s *Server) synced() bool {
+	for _, item := range s.services {
+		if !item.HasSynced() {
+			return false
+		}
+	}
+	for _, item := range s.slices {
+		if !item.HasSynced() {
+			return false
+		}
+	}
+	return true
+}
+
+// state informer 缓存中的全部 Kubernetes 资源
+func (s *Server) state() *kubeState {
+	state := &kubeState{services: map[string][]*Service{}, slices: map[string][]*EndpointSlice{}}
+	for namespace, item := range s.services {
+		state.services[namespace] = item.List()
+	}
+	for namespace, item := range s.slices {
+		state.slices[namespace] = item.List()
+	}
+	return state
+}
+
+// reconcile 对比 Kubernetes 与 Polaris 中的数据并执行同步
+func (s *Server) reconcile(ctx context.Context) error {
+	if !s.synced() {
+		return nil
+	}
+	state := s.state()
+	plan, err := planMirror(s.cfg, state, s.source)
+	if err != nil {
+		return err
+	}
+	writeCtx := context.WithValue(ctx, utils.ContextOperator, operator)
+	// 服务需要在实例之前创建
+	failed := writeBatch(writeCtx, plan.createServices, s.registry.CreateServices)
+	failed += writeBatch(writeCtx, plan.deleteInstances, s.registry.DeleteInstances)
+	failed += writeBatch(writeCtx, plan.createInstances, s.registry.CreateInstances)
+	failed += writeBatch(writeCtx, plan.updateInstances, s.registry.UpdateInstances)
+	if len(plan.createInstances)+len(plan.updateInstances)+len(plan.deleteInstances) > 0 {
+		log.Info("[KubeSync] sync kubernetes endpoints to polaris", zap.Int("created", len(plan.createInstances)),
+			zap.Int("updated", len(plan.updateInstances)), zap.Int("deleted", len(plan.deleteInstances)),
+			zap.Int("failed", failed))
+	}
+	if !s.cfg.SyncToKubernetes {
+		return nil
+	}
+	reverse, err := planReverse(s.cfg, state, s.source)
+	if err != nil {
+		return err
+	}
+	return s.applyReverse(ctx, reverse)
+}
+
+// applyReverse 写入 Kubernetes，Service 需要在 EndpointSlice 之前创建、之后删除
+func (s *Server) applyReverse(ctx context.Context, plan *reversePlan) error {
+	var errs []error
+	for _, svc := range plan.applyServices {
+		errs = append(errs, s.client.Apply(ctx, resourceServices, svc.Metadata.Namespace, svc.Metadata.Name, svc))
+	}
+	for _, slice := range plan.applySlices {
+		errs = append(errs, s.client.Apply(ctx, resourceEndpointSlices, slice.Metadata.Namespace,
+			slice.Metadata.Name, slice))
+	}
+	for _, meta := range plan.deleteSlices {
+		errs = append(errs, s.client.Delete(ctx, resourceEndpointSlices, meta.Namespace, meta.Name))
+	}
+	for _, meta := range plan.deleteServices {
+		errs = append(errs, s.client.Delete(ctx, resourceServices, meta.Namespace, meta.Name))
+	}
+	return errors.Join(errs...)
+}
+
+// writeBatch 分批写入 Polaris，返回写入失败的数量
+func writeBatch[T any](ctx context.Context, reqs []T,
+	write func(context.Context, []T) *apiservice.BatchWriteResponse) int {
+	failed := 0
+	for start := 0; start < len(reqs); start += writeBatchSize {
+		end := start + writeBatchSize
+		if end > len(reqs) {
+			end = len(reqs)
+		}
+		failed += countFailed(write(ctx, reqs[start:end]))
+	}
+	return failed
+}
+
+// countFailed 缓存还没有刷新时可能重复创建、删除，已经存在以及已经删除的不计为失败
+func countFailed(resp *apiservice.BatchWriteResponse) int {
+	failed := 0
+	for _, item := range resp.GetResponses() {
+		code := apimodel.Code(item.GetCode().GetValue())
+		if api.CalcCode(item) == http.StatusOK || code == apimodel.Code_ExistedResource ||
+			code == apimodel.Code_NotFoundResource {
+			continue
+		}
+		log.Error("[KubeSync] write polaris", zap.Uint32("code", item.GetCode().GetValue()),
+			zap.String("info", item.GetInfo().GetValue()))
+		failed++
+	}
+	return failed
+}
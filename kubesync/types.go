@@ -0,0 +1,137 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+package kubesync
+
+import (
+	"encoding/json"
+)
+
+// 只保留同步需要的 Kubernetes 资源字段，避免引入 client-go
+
+const (
+	// LabelManagedBy 由 Polaris 反向同步到 Kubernetes 的 Service 使用的标签
+	LabelManagedBy = "app.kubernetes.io/managed-by"
+	// ManagedByPolaris LabelManagedBy 的取值
+	ManagedByPolaris = "polaris"
+	// LabelServiceName EndpointSlice 所属的 Service
+	LabelServiceName = "kubernetes.io/service-name"
+	// LabelEndpointSliceManagedBy EndpointSlice 的管理者
+	LabelEndpointSliceManagedBy = "endpointslice.kubernetes.io/managed-by"
+	// EndpointSliceManagedByPolaris LabelEndpointSliceManagedBy 的取值
+	EndpointSliceManagedByPolaris = "polaris.polarismesh.cn"
+
+	addressTypeIPv4 = "IPv4"
+	addressTypeIPv6 = "IPv6"
+)
+
+// ObjectMeta 资源的元数据
+type ObjectMeta struct {
+	Name            string            `json:"name"`
+	Namespace       string            `json:"namespace,omitempty"`
+	ResourceVersion string            `json:"resourceVersion,omitempty"`
+	Labels          map[string]string `json:"labels,omitempty"`
+}
+
+// key 资源在命名空间内的唯一标识
+func (m *ObjectMeta) key() string {
+	return m.Namespace + "/" + m.Name
+}
+
+// Service Kubernetes Service
+type Service struct {
+	APIVersion string      `json:"apiVersion,omitempty"`
+	Kind       string      `json:"kind,omitempty"`
+	Metadata   ObjectMeta  `json:"metadata"`
+	Spec       ServiceSpec `json:"spec"`
+}
+
+// ServiceSpec Service 的定义
+type ServiceSpec struct {
+	Type      string            `json:"type,omitempty"`
+	ClusterIP string            `json:"clusterIP,omitempty"`
+	Selector  map[string]string `json:"selector,omitempty"`
+	Ports     []ServicePort     `json:"ports,omitempty"`
+}
+
+// ServicePort Service 的端口
+type ServicePort struct {
+	Name     string `json:"name,omitempty"`
+	Protocol string `json:"protocol,omitempty"`
+	Port     int32  `json:"port"`
+}
+
+// EndpointSlice discovery.k8s.io/v1 EndpointSlice
+type EndpointSlice struct {
+	APIVersion  string         `json:"apiVersion,omitempty"`
+	Kind        string         `json:"kind,omitempty"`
+	Metadata    ObjectMeta     `json:"metadata"`
+	AddressType string         `json:"addressType"`
+	Endpoints   []Endpoint     `json:"endpoints"`
+	Ports       []EndpointPort `json:"ports"`
+}
+
+// Endpoint EndpointSlice 中的一个端点
+type Endpoint struct {
+	Addresses  []string           `json:"addresses"`
+	Conditions EndpointConditions `json:"conditions"`
+	NodeName   *string            `json:"nodeName,omitempty"`
+	Zone       *string            `json:"zone,omitempty"`
+	TargetRef  *ObjectReference   `json:"targetRef,omitempty"`
+}
+
+// EndpointConditions 端点的状态，为空时表示状态未知，按照 ready 处理
+type EndpointConditions struct {
+	Ready       *bool `json:"ready,omitempty"`
+	Terminating *bool `json:"terminating,omitempty"`
+}
+
+// ObjectReference 端点关联的 Pod
+type ObjectReference struct {
+	Kind string `json:"kind,omitempty"`
+	Name string `json:"name,omitempty"`
+}
+
+// EndpointPort EndpointSlice 的端口
+type EndpointPort struct {
+	Name     *string `json:"name,omitempty"`
+	Protocol *string `json:"protocol,omitempty"`
+	Port     *int32  `json:"port,omitempty"`
+}
+
+// listMeta 列表的元数据
+type listMeta struct {
+	ResourceVersion string `json:"resourceVersion"`
+}
+
+// objectList list 接口的返回
+type objectList struct {
+	Metadata listMeta          `json:"metadata"`
+	Items    []json.RawMessage `json:"items"`
+}
+
+// watchEvent watch 接口推送的事件
+type watchEvent struct {
+	Type   string          `json:"type"`
+	Object json.RawMessage `json:"object"`
+}
+
+// status watch 接口出错时推送的对象
+type status struct {
+	Code    int    `json:"code"`
+	Reason  string `json:"reason"`
+	Message string `json:"message"`
+}
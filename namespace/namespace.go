@@ -130,11 +130,32 @@ func (s *Server) CreateNamespace(ctx context.Context, req *apimodel.Namespace) *
 		Token: utils.NewStringValue(data.Token),
 	}
 
-	_ = s.afterNamespaceResource(ctx, req, data, false)
+	if err := s.afterNamespaceResource(ctx, req, data, false); err != nil {
+		// 鉴权等后置处理失败时，回滚已经创建的命名空间，保证命名空间与默认授权要么同时存在，要么同时不存在
+		s.rollbackCreateNamespace(ctx, data)
+		return api.NewResponseWithMsg(apimodel.Code_ExecuteException, err.Error())
+	}
 
 	return api.NewNamespaceResponse(apimodel.Code_ExecuteSuccess, out)
 }
 
+// rollbackCreateNamespace 回滚已创建的命名空间
+func (s *Server) rollbackCreateNamespace(ctx context.Context, data *model.Namespace) {
+	tx, err := s.storage.CreateTransaction()
+	if err != nil {
+		log.Error("[Namespace] rollback create namespace fail", utils.RequestID(ctx),
+			zap.String("name", data.Name), zap.Error(err))
+		return
+	}
+	defer func() { _ = tx.Commit() }()
+	if err := tx.DeleteNamespace(data.Name); err != nil {
+		log.Error("[Namespace] rollback create namespace fail", utils.RequestID(ctx),
+			zap.String("name", data.Name), zap.Error(err))
+		return
+	}
+	log.Info("[Namespace] rollback create namespace", utils.RequestID(ctx), zap.String("name", data.Name))
+}
+
 /**
  * @brief 创建存储层命名空间模型
  */
@@ -547,6 +568,7 @@ func namespaceRecordEntry(ctx context.Context, req *apimodel.Namespace, opt mode
 		Namespace:     req.GetName().GetValue(),
 		OperationType: opt,
 		Operator:      utils.ParseOperator(ctx),
+		ChangeSetID:   utils.ParseChangeSetID(ctx),
 		Detail:        datail,
 		HappenTime:    time.Now(),
 	}
@@ -26,6 +26,7 @@ import (
 	"go.uber.org/zap"
 
 	"github.com/polarismesh/polaris/auth"
+	api "github.com/polarismesh/polaris/common/api/v1"
 	"github.com/polarismesh/polaris/common/model"
 	"github.com/polarismesh/polaris/common/utils"
 )
@@ -97,5 +98,8 @@ func convertToErrCode(err error) apimodel.Code {
 	if errors.Is(err, model.ErrorTokenDisabled) {
 		return apimodel.Code_TokenDisabled
 	}
+	if errors.Is(err, model.ErrorTokenExpired) {
+		return apimodel.Code(api.TokenExpired)
+	}
 	return apimodel.Code_NotAllowedAccess
 }
@@ -18,12 +18,14 @@
 package main
 
 import (
+	_ "github.com/polarismesh/polaris/apiserver/consulserver"
 	_ "github.com/polarismesh/polaris/apiserver/eurekaserver"
 	_ "github.com/polarismesh/polaris/apiserver/grpcserver/config"
 	_ "github.com/polarismesh/polaris/apiserver/grpcserver/discover"
 	_ "github.com/polarismesh/polaris/apiserver/httpserver"
 	_ "github.com/polarismesh/polaris/apiserver/l5pbserver"
 	_ "github.com/polarismesh/polaris/apiserver/nacosserver"
+	_ "github.com/polarismesh/polaris/apiserver/udpserver"
 	_ "github.com/polarismesh/polaris/apiserver/xdsserverv3"
 	_ "github.com/polarismesh/polaris/auth/policy"
 	_ "github.com/polarismesh/polaris/auth/user"
@@ -41,6 +43,7 @@ import (
 	_ "github.com/polarismesh/polaris/plugin/healthchecker/memory"
 	_ "github.com/polarismesh/polaris/plugin/healthchecker/redis"
 	_ "github.com/polarismesh/polaris/plugin/history/logger"
+	_ "github.com/polarismesh/polaris/plugin/history/storage"
 	_ "github.com/polarismesh/polaris/plugin/password"
 	_ "github.com/polarismesh/polaris/plugin/ratelimit/token"
 	_ "github.com/polarismesh/polaris/plugin/statis/logger"
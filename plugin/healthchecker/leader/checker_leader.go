@@ -30,6 +30,7 @@ import (
 
 	"github.com/polarismesh/polaris/common/eventhub"
 	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/common/readiness"
 	commontime "github.com/polarismesh/polaris/common/time"
 	"github.com/polarismesh/polaris/common/utils"
 	"github.com/polarismesh/polaris/plugin"
@@ -143,6 +144,7 @@ func (c *LeaderHealthChecker) Initialize(entry *plugin.ConfigEntry) error {
 	if err := c.s.StartLeaderElection(electionKey); err != nil {
 		return err
 	}
+	readiness.Register(readiness.ComponentLeader(electionKey), readiness.LeaderElected(c.s, electionKey))
 	registerMetrics()
 	return nil
 }
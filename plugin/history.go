@@ -18,12 +18,36 @@
 package plugin
 
 import (
+	"errors"
+	"fmt"
 	"os"
 	"sync"
+	"time"
 
+	"github.com/polarismesh/polaris/common/metrics"
 	"github.com/polarismesh/polaris/common/model"
 )
 
+const (
+	// defaultHistorySinkQueueSize 每个历史记录插件待写入队列的默认长度
+	defaultHistorySinkQueueSize = 1024
+	// defaultHistoryRetryTimes 写入失败后的默认重试次数
+	defaultHistoryRetryTimes = 3
+	// defaultHistoryRetryInterval 第一次重试前的等待时间，之后每次翻倍
+	defaultHistoryRetryInterval = 100 * time.Millisecond
+	// maxHistoryRetryInterval 单次重试前的最长等待时间
+	maxHistoryRetryInterval = 5 * time.Second
+	// defaultHistoryDrainInterval 定时重新写入死信队列的默认间隔
+	defaultHistoryDrainInterval = time.Minute
+	// defaultHistoryReplayLimit 向新添加的插件重放的最大记录数
+	defaultHistoryReplayLimit = 1000
+)
+
+var (
+	// ErrorHistoryReplayUnsupported 没有可以读取历史记录的插件，无法重放
+	ErrorHistoryReplayUnsupported = errors.New("no history sink supports replay")
+)
+
 var (
 	// historyOnce Plugin initialization atomic variable
 	historyOnce      sync.Once
@@ -36,6 +60,19 @@ type History interface {
 	Record(entry *model.RecordEntry)
 }
 
+// FallibleHistory 可以返回写入结果的历史记录插件，组合插件会汇总各个插件的写入失败
+type FallibleHistory interface {
+	History
+	TryRecord(entry *model.RecordEntry) error
+}
+
+// HistoryReplayer 可以读取最近历史记录的插件，组合插件向新添加的插件重放历史记录时作为数据来源
+type HistoryReplayer interface {
+	History
+	// RecentRecords 读取 since 之后的记录，超过 limit 条时保留最新的 limit 条，按照写入顺序返回
+	RecentRecords(since time.Time, limit int) ([]*model.RecordEntry, error)
+}
+
 // GetHistory Get the historical record plugin
 func GetHistory() History {
 	if compositeHistory != nil {
@@ -45,10 +82,12 @@ func GetHistory() History {
 	historyOnce.Do(func() {
 		var (
 			entries []ConfigEntry
+			option  map[string]interface{}
 		)
 
 		if len(config.History.Entries) != 0 {
 			entries = append(entries, config.History.Entries...)
+			option = config.History.Option
 		} else {
 			entries = append(entries, ConfigEntry{
 				Name:   config.History.Name,
@@ -56,12 +95,8 @@ func GetHistory() History {
 			})
 		}
 
-		compositeHistory = &CompositeHistory{
-			chain:   make([]History, 0, len(entries)),
-			options: entries,
-		}
-
-		if err := compositeHistory.Initialize(nil); err != nil {
+		compositeHistory = NewCompositeHistory(entries)
+		if err := compositeHistory.Initialize(&ConfigEntry{Option: option}); err != nil {
 			log.Errorf("History plugin init err: %s", err.Error())
 			os.Exit(-1)
 		}
@@ -70,16 +105,101 @@ func GetHistory() History {
 	return compositeHistory
 }
 
+// CompositeHistory 将操作记录分发到多个历史记录插件，每个插件使用独立的队列异步写入，
+// 单个插件写入缓慢或者失败不会影响其他插件
+// 写入失败的记录按照退避时间有限次重试，仍然失败的记录进入死信队列，等待插件恢复后通过 DrainDeadLetter 重新写入
 type CompositeHistory struct {
-	chain   []History
-	options []ConfigEntry
+	// chainLock 运行期间可以通过 AttachSink 添加插件
+	chainLock sync.RWMutex
+	chain     []*historySink
+	options   []ConfigEntry
+	wg        sync.WaitGroup
+
+	retryTimes     int
+	retryInterval  time.Duration
+	deadLetterSize int
+	spillDir       string
+	drainInterval  time.Duration
+	stopDrain      chan struct{}
+	drainWg        sync.WaitGroup
+	replayLimit    int
+
+	// closed Destroy 之后不再接收新的记录，由 chainLock 保护
+	closed bool
+}
+
+// historySink 单个历史记录插件的待写入队列
+type historySink struct {
+	history       History
+	queue         chan *model.RecordEntry
+	lock          sync.Mutex
+	errs          []error
+	retryTimes    int
+	retryInterval time.Duration
+	deadLetter    *historyDeadLetter
+	// lastSuccess、lastFailure、lastErr 最近一次写入成功、失败的时间以及失败的原因，由 lock 保护
+	lastSuccess time.Time
+	lastFailure time.Time
+	lastErr     error
+}
+
+// NewCompositeHistory 创建组合历史记录插件，entries 为需要分发的历史记录插件配置
+func NewCompositeHistory(entries []ConfigEntry) *CompositeHistory {
+	return &CompositeHistory{
+		chain:          make([]*historySink, 0, len(entries)),
+		options:        entries,
+		retryTimes:     defaultHistoryRetryTimes,
+		retryInterval:  defaultHistoryRetryInterval,
+		deadLetterSize: defaultHistoryDeadLetterSize,
+		drainInterval:  defaultHistoryDrainInterval,
+		replayLimit:    defaultHistoryReplayLimit,
+	}
 }
 
 func (c *CompositeHistory) Name() string {
 	return "CompositeHistory"
 }
 
+// Initialize 初始化所有的历史记录插件, option 支持以下配置
+// sinkQueueSize 每个插件待写入队列的长度, sinkRetryTimes 写入失败后的重试次数,
+// sinkRetryInterval 第一次重试前的等待时间, deadLetterSize 每个插件死信队列的长度,
+// deadLetterSpillDir 死信队列已满时写入本地磁盘的目录，为空时丢弃,
+// deadLetterDrainInterval 定时重新写入死信队列的间隔，0 表示只能通过 DrainDeadLetter 手动写入,
+// replayLimit 向新添加的插件重放的最大记录数。单个插件的 option 中设置了 replayLookback 时，
+// 该插件添加时先重放之前的插件中 replayLookback 时间内的历史记录
 func (c *CompositeHistory) Initialize(config *ConfigEntry) error {
+	queueSize := defaultHistorySinkQueueSize
+	if config != nil {
+		if val, ok := config.Option["sinkQueueSize"].(int); ok && val > 0 {
+			queueSize = val
+		}
+		if val, ok := config.Option["sinkRetryTimes"].(int); ok && val >= 0 {
+			c.retryTimes = val
+		}
+		if val, ok := config.Option["sinkRetryInterval"].(string); ok && val != "" {
+			interval, err := time.ParseDuration(val)
+			if err != nil {
+				return fmt.Errorf("invalid history sinkRetryInterval: %w", err)
+			}
+			c.retryInterval = interval
+		}
+		if val, ok := config.Option["deadLetterSize"].(int); ok && val > 0 {
+			c.deadLetterSize = val
+		}
+		if val, ok := config.Option["deadLetterSpillDir"].(string); ok {
+			c.spillDir = val
+		}
+		if val, ok := config.Option["deadLetterDrainInterval"].(string); ok && val != "" {
+			interval, err := time.ParseDuration(val)
+			if err != nil {
+				return fmt.Errorf("invalid history deadLetterDrainInterval: %w", err)
+			}
+			c.drainInterval = interval
+		}
+		if val, ok := config.Option["replayLimit"].(int); ok && val > 0 {
+			c.replayLimit = val
+		}
+	}
 	for i := range c.options {
 		entry := c.options[i]
 		item, exist := pluginSet[entry.Name]
@@ -97,22 +217,346 @@ func (c *CompositeHistory) Initialize(config *ConfigEntry) error {
 		if err := history.Initialize(&entry); err != nil {
 			return err
 		}
-		c.chain = append(c.chain, history)
+		lookback, err := parseReplayLookback(entry.Option)
+		if err != nil {
+			return err
+		}
+		if lookback <= 0 {
+			c.AddSink(history, queueSize)
+			continue
+		}
+		// 重放失败不影响插件接收实时的记录
+		if _, err := c.AttachSink(history, queueSize, lookback); err != nil {
+			log.Errorf("plugin History %s replay err: %s", entry.Name, err.Error())
+			c.AddSink(history, queueSize)
+		}
+	}
+	if c.drainInterval > 0 {
+		c.stopDrain = make(chan struct{})
+		c.drainWg.Add(1)
+		go c.runDrain()
 	}
 	return nil
 }
 
+// runDrain 定时将死信队列中的记录重新写入插件
+func (c *CompositeHistory) runDrain() {
+	defer c.drainWg.Done()
+	ticker := time.NewTicker(c.drainInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.stopDrain:
+			return
+		case <-ticker.C:
+			drained, err := c.DrainDeadLetter()
+			if err != nil {
+				log.Errorf("[History] drain dead letter err: %s", err.Error())
+			}
+			if drained > 0 {
+				log.Infof("[History] drain %d dead letter records", drained)
+			}
+		}
+	}
+}
+
+func parseReplayLookback(option map[string]interface{}) (time.Duration, error) {
+	val, ok := option["replayLookback"].(string)
+	if !ok || val == "" {
+		return 0, nil
+	}
+	lookback, err := time.ParseDuration(val)
+	if err != nil {
+		return 0, fmt.Errorf("invalid history replayLookback: %w", err)
+	}
+	return lookback, nil
+}
+
+// AddSink 添加一个已经初始化的历史记录插件
+func (c *CompositeHistory) AddSink(history History, queueSize int) {
+	sink := c.startSink(history, queueSize)
+	c.chainLock.Lock()
+	defer c.chainLock.Unlock()
+	c.chain = append(c.chain, sink)
+}
+
+// AttachSink 添加一个已经初始化的历史记录插件，lookback 大于 0 时先从已有的、实现了 HistoryReplayer 的插件中
+// 读取 lookback 时间内的历史记录，标记为重放后写入新插件，全部重放的记录进入队列后新插件才开始接收实时的记录，
+// 返回重放的记录数。数据来源尚未保存的记录不会被重放，读取失败时不会添加插件
+func (c *CompositeHistory) AttachSink(history History, queueSize int, lookback time.Duration) (int, error) {
+	var replay []*model.RecordEntry
+	if lookback > 0 {
+		records, err := c.recentRecords(time.Now().Add(-lookback))
+		if err != nil {
+			return 0, err
+		}
+		replay = records
+	}
+	sink := c.startSink(history, queueSize)
+	for i := range replay {
+		entry := *replay[i]
+		entry.Replayed = true
+		sink.queue <- &entry
+	}
+	c.chainLock.Lock()
+	defer c.chainLock.Unlock()
+	c.chain = append(c.chain, sink)
+	return len(replay), nil
+}
+
+// recentRecords 从第一个实现了 HistoryReplayer 的插件中读取最近的历史记录
+func (c *CompositeHistory) recentRecords(since time.Time) ([]*model.RecordEntry, error) {
+	for _, sink := range c.sinks() {
+		if replayer, ok := sink.history.(HistoryReplayer); ok {
+			return replayer.RecentRecords(since, c.replayLimit)
+		}
+	}
+	return nil, ErrorHistoryReplayUnsupported
+}
+
+// startSink 创建插件的待写入队列，并启动写入协程
+func (c *CompositeHistory) startSink(history History, queueSize int) *historySink {
+	sink := &historySink{
+		history:       history,
+		queue:         make(chan *model.RecordEntry, queueSize),
+		retryTimes:    c.retryTimes,
+		retryInterval: c.retryInterval,
+		deadLetter:    newHistoryDeadLetter(history.Name(), c.deadLetterSize, c.spillDir),
+	}
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		for entry := range sink.queue {
+			sink.deliver(entry)
+		}
+	}()
+	return sink
+}
+
+// sinks 当前已经添加的插件
+func (c *CompositeHistory) sinks() []*historySink {
+	c.chainLock.RLock()
+	defer c.chainLock.RUnlock()
+	return c.chain
+}
+
+// Destroy 等待所有插件写完队列中的记录后销毁插件，死信队列中的记录写入本地磁盘，汇总销毁失败的错误
 func (c *CompositeHistory) Destroy() error {
-	for i := range c.chain {
-		if err := c.chain[i].Destroy(); err != nil {
-			return err
+	// Record 在持有读锁期间写入队列，标记关闭之后再关闭队列，避免向已经关闭的队列写入
+	c.chainLock.Lock()
+	if c.closed {
+		c.chainLock.Unlock()
+		return nil
+	}
+	c.closed = true
+	chain := c.chain
+	c.chainLock.Unlock()
+	if c.stopDrain != nil {
+		close(c.stopDrain)
+		c.drainWg.Wait()
+	}
+	for i := range chain {
+		close(chain[i].queue)
+	}
+	c.wg.Wait()
+	var errs []error
+	for i := range chain {
+		if err := chain[i].deadLetter.flush(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", chain[i].history.Name(), err))
 		}
 	}
-	return nil
+	for i := range chain {
+		if err := chain[i].history.Destroy(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", chain[i].history.Name(), err))
+		}
+	}
+	return errors.Join(errs...)
 }
 
+// Record 将记录的副本放入每个插件的待写入队列，插件之间不会共享同一个记录对象。队列已满的插件丢弃该记录，
+// MustAudit 的记录等待队列空出位置，Destroy 之后的记录被丢弃
 func (c *CompositeHistory) Record(entry *model.RecordEntry) {
+	c.chainLock.RLock()
+	defer c.chainLock.RUnlock()
+	if c.closed {
+		log.Errorf("[History] composite history destroyed, drop record: %s", entry.String())
+		return
+	}
 	for i := range c.chain {
-		c.chain[i].Record(entry)
+		sink := c.chain[i]
+		item := *entry
+		if entry.MustAudit() {
+			sink.queue <- &item
+			continue
+		}
+		select {
+		case sink.queue <- &item:
+		default:
+			sink.fail(fmt.Errorf("queue full, drop record: %s", entry.String()))
+			sink.toDeadLetter(&item)
+		}
+	}
+}
+
+// DrainDeadLetter 将各个插件死信队列中的记录按照顺序重新写入，插件仍然写入失败时停止写入该插件，
+// 剩余的记录放回死信队列，返回成功写入的记录数
+func (c *CompositeHistory) DrainDeadLetter() (int, error) {
+	var (
+		drained int
+		errs    []error
+	)
+	chain := c.sinks()
+	for i := range chain {
+		sink := chain[i]
+		entries, err := sink.deadLetter.take()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", sink.history.Name(), err))
+		}
+		for j := range entries {
+			if err := sink.tryRecord(entries[j]); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", sink.history.Name(), err))
+				if err := sink.deadLetter.restore(entries[j:]); err != nil {
+					errs = append(errs, fmt.Errorf("%s: %w", sink.history.Name(), err))
+				}
+				entries = entries[:j]
+				break
+			}
+		}
+		drained += len(entries)
+		metrics.ReportHistoryDeadLetter(sink.history.Name(), metrics.HistoryDeadLetterDrain, len(entries))
+	}
+	return drained, errors.Join(errs...)
+}
+
+// SinkCount 已经添加的历史记录插件数量
+func (c *CompositeHistory) SinkCount() int {
+	return len(c.sinks())
+}
+
+// DeadLetterSize 各个插件死信队列中等待重新写入的记录数，不包含已经写入本地磁盘的记录
+func (c *CompositeHistory) DeadLetterSize() int {
+	var size int
+	chain := c.sinks()
+	for i := range chain {
+		size += chain[i].deadLetter.len()
+	}
+	return size
+}
+
+// Errors 汇总各个插件写入失败的错误，读取后清空
+func (c *CompositeHistory) Errors() error {
+	var errs []error
+	chain := c.sinks()
+	for i := range chain {
+		sink := chain[i]
+		sink.lock.Lock()
+		for _, err := range sink.errs {
+			errs = append(errs, fmt.Errorf("%s: %w", sink.history.Name(), err))
+		}
+		sink.errs = nil
+		sink.lock.Unlock()
+	}
+	return errors.Join(errs...)
+}
+
+// HistorySinkStatus 单个历史记录插件的写入状态
+type HistorySinkStatus struct {
+	// Name 插件名称
+	Name string `json:"name"`
+	// QueueDepth 待写入队列中的记录数
+	QueueDepth int `json:"queueDepth"`
+	// QueueCapacity 待写入队列的长度
+	QueueCapacity int `json:"queueCapacity"`
+	// DeadLetterSize 死信队列中等待重新写入的记录数，不包含已经写入本地磁盘的记录
+	DeadLetterSize int `json:"deadLetterSize"`
+	// LastSuccess 最近一次写入成功的时间
+	LastSuccess time.Time `json:"lastSuccess"`
+	// LastFailure 最近一次写入失败的时间
+	LastFailure time.Time `json:"lastFailure"`
+	// LastError 最近一次写入失败的原因
+	LastError string `json:"lastError,omitempty"`
+	// Down 最近一次写入失败晚于最近一次写入成功
+	Down bool `json:"down"`
+}
+
+// SinkStatus 各个插件当前的写入状态，不会清空 Errors 汇总的错误
+func (c *CompositeHistory) SinkStatus() []HistorySinkStatus {
+	chain := c.sinks()
+	ret := make([]HistorySinkStatus, 0, len(chain))
+	for i := range chain {
+		sink := chain[i]
+		status := HistorySinkStatus{
+			Name:           sink.history.Name(),
+			QueueDepth:     len(sink.queue),
+			QueueCapacity:  cap(sink.queue),
+			DeadLetterSize: sink.deadLetter.len(),
+		}
+		sink.lock.Lock()
+		status.LastSuccess = sink.lastSuccess
+		status.LastFailure = sink.lastFailure
+		if sink.lastErr != nil {
+			status.LastError = sink.lastErr.Error()
+		}
+		sink.lock.Unlock()
+		status.Down = status.LastFailure.After(status.LastSuccess)
+		ret = append(ret, status)
+	}
+	return ret
+}
+
+// deliver 写入单条记录，失败时按照退避时间重试，重试后仍然失败的记录进入死信队列
+func (s *historySink) deliver(entry *model.RecordEntry) {
+	interval := s.retryInterval
+	for attempt := 0; ; attempt++ {
+		err := s.tryRecord(entry)
+		if err == nil {
+			s.lock.Lock()
+			s.lastSuccess = time.Now()
+			s.lock.Unlock()
+			return
+		}
+		if attempt >= s.retryTimes {
+			s.fail(err)
+			s.toDeadLetter(entry)
+			return
+		}
+		time.Sleep(interval)
+		if interval *= 2; interval > maxHistoryRetryInterval {
+			interval = maxHistoryRetryInterval
+		}
+	}
+}
+
+// tryRecord 写入单条记录，插件 panic 时按照写入失败处理
+func (s *historySink) tryRecord(entry *model.RecordEntry) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("record panic: %v", r)
+		}
+	}()
+	if fallible, ok := s.history.(FallibleHistory); ok {
+		return fallible.TryRecord(entry)
+	}
+	s.history.Record(entry)
+	return nil
+}
+
+func (s *historySink) toDeadLetter(entry *model.RecordEntry) {
+	if err := s.deadLetter.add(entry); err != nil {
+		s.fail(err)
+	}
+}
+
+// maxHistorySinkErrors 每个插件最多保留的写入失败错误数量
+const maxHistorySinkErrors = 128
+
+func (s *historySink) fail(err error) {
+	log.Errorf("plugin History %s record fail: %s", s.history.Name(), err.Error())
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.lastFailure = time.Now()
+	s.lastErr = err
+	if len(s.errs) < maxHistorySinkErrors {
+		s.errs = append(s.errs, err)
 	}
 }
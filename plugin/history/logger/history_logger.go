@@ -56,8 +56,12 @@ func (h *HistoryLogger) Initialize(c *plugin.ConfigEntry) error {
 	return nil
 }
 
-// Record 记录操作记录到日志中
+// Record 记录操作记录到日志中，重放的记录带上 [Replay] 前缀并保留原始的 Server
 func (h *HistoryLogger) Record(entry *model.RecordEntry) {
+	if entry.Replayed {
+		log.Info("[Replay] " + entry.String())
+		return
+	}
 	entry.Server = utils.LocalHost
 	log.Info(entry.String())
 }
@@ -0,0 +1,163 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"go.uber.org/zap"
+
+	commonLog "github.com/polarismesh/polaris/common/log"
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/common/utils"
+	"github.com/polarismesh/polaris/plugin"
+	"github.com/polarismesh/polaris/store"
+)
+
+// 把操作记录保存到存储层中，便于后续的查询以及按照保留策略进行清理
+const (
+	// PluginName plugin name
+	PluginName = "HistoryStorage"
+
+	defaultQueueSize = 1024
+	defaultBatchSize = 128
+)
+
+var log = commonLog.RegisterScope(PluginName, "", 0)
+
+// init 初始化注册函数
+func init() {
+	plugin.RegisterPlugin(PluginName, &HistoryStorage{})
+}
+
+// Config 插件配置
+type Config struct {
+	// QueueSize 等待写入存储层的操作记录队列长度，队列满时丢弃新的操作记录
+	QueueSize int `json:"queueSize"`
+	// BatchSize 单次批量写入存储层的最大记录数
+	BatchSize int `json:"batchSize"`
+}
+
+// HistoryStorage 历史记录存储
+type HistoryStorage struct {
+	cfg     *Config
+	storage store.Store
+	entryCh chan *model.RecordEntry
+	cancel  context.CancelFunc
+}
+
+// Name 返回插件名字
+func (h *HistoryStorage) Name() string {
+	return PluginName
+}
+
+// Initialize 插件初始化
+func (h *HistoryStorage) Initialize(c *plugin.ConfigEntry) error {
+	cfg := &Config{
+		QueueSize: defaultQueueSize,
+		BatchSize: defaultBatchSize,
+	}
+	if c != nil && len(c.Option) > 0 {
+		contentBytes, err := json.Marshal(c.Option)
+		if err != nil {
+			return err
+		}
+		if err := json.Unmarshal(contentBytes, cfg); err != nil {
+			return err
+		}
+	}
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = defaultQueueSize
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = defaultBatchSize
+	}
+	if h.storage == nil {
+		s, err := store.GetStore()
+		if err != nil {
+			return err
+		}
+		h.storage = s
+	}
+	h.cfg = cfg
+	h.entryCh = make(chan *model.RecordEntry, cfg.QueueSize)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	h.cancel = cancel
+	go h.run(ctx)
+	return nil
+}
+
+// Destroy 销毁插件
+func (h *HistoryStorage) Destroy() error {
+	if h.cancel != nil {
+		h.cancel()
+	}
+	return nil
+}
+
+// Record 异步将操作记录写入存储层，重放的记录已经保存过，不再重复写入
+func (h *HistoryStorage) Record(entry *model.RecordEntry) {
+	if entry.Replayed {
+		return
+	}
+	entry.Server = utils.LocalHost
+	select {
+	case h.entryCh <- entry:
+	default:
+		log.Error("[History][Storage] record queue is full, drop record", zap.String("record", entry.String()))
+	}
+}
+
+// RecentRecords 读取存储层中 since 之后的操作记录，作为向新添加的插件重放的数据来源
+func (h *HistoryStorage) RecentRecords(since time.Time, limit int) ([]*model.RecordEntry, error) {
+	return h.storage.GetRecordEntriesSince(since, uint64(limit))
+}
+
+func (h *HistoryStorage) run(ctx context.Context) {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	batch := make([]*model.RecordEntry, 0, h.cfg.BatchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if err := h.storage.AddRecordEntries(batch); err != nil {
+			log.Error("[History][Storage] save records", zap.Int("count", len(batch)), zap.Error(err))
+		}
+		batch = make([]*model.RecordEntry, 0, h.cfg.BatchSize)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			flush()
+			return
+		case entry := <-h.entryCh:
+			batch = append(batch, entry)
+			if len(batch) >= h.cfg.BatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
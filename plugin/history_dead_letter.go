@@ -0,0 +1,183 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package plugin
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/polarismesh/polaris/common/metrics"
+	"github.com/polarismesh/polaris/common/model"
+)
+
+const (
+	// defaultHistoryDeadLetterSize 每个历史记录插件死信队列的默认长度
+	defaultHistoryDeadLetterSize = 1024
+)
+
+// historyDeadLetter 单个历史记录插件重试后仍然写入失败的记录
+// 内存中的队列已满时，配置了 spillPath 则追加写入本地磁盘，否则丢弃
+type historyDeadLetter struct {
+	sink      string
+	size      int
+	spillPath string
+	lock      sync.Mutex
+	entries   []*model.RecordEntry
+}
+
+func newHistoryDeadLetter(sink string, size int, spillDir string) *historyDeadLetter {
+	d := &historyDeadLetter{sink: sink, size: size}
+	if spillDir != "" {
+		d.spillPath = filepath.Join(spillDir, sink+".deadletter")
+	}
+	return d
+}
+
+// add 记录进入死信队列，队列已满且无法写入本地磁盘时返回错误
+func (d *historyDeadLetter) add(entry *model.RecordEntry) error {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	if len(d.entries) < d.size {
+		d.entries = append(d.entries, entry)
+		metrics.ReportHistoryDeadLetter(d.sink, metrics.HistoryDeadLetterEnqueue, 1)
+		return nil
+	}
+	if d.spillPath == "" {
+		metrics.ReportHistoryDeadLetter(d.sink, metrics.HistoryDeadLetterDrop, 1)
+		return fmt.Errorf("dead letter full, drop record: %s", entry.String())
+	}
+	if err := d.spill([]*model.RecordEntry{entry}); err != nil {
+		metrics.ReportHistoryDeadLetter(d.sink, metrics.HistoryDeadLetterDrop, 1)
+		return fmt.Errorf("dead letter spill, drop record: %s: %w", entry.String(), err)
+	}
+	return nil
+}
+
+// restore 将重新写入失败的记录放回死信队列的头部，保持记录的先后顺序
+func (d *historyDeadLetter) restore(entries []*model.RecordEntry) error {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	all := append(append(make([]*model.RecordEntry, 0, len(entries)+len(d.entries)), entries...), d.entries...)
+	if len(all) <= d.size {
+		d.entries = all
+		return nil
+	}
+	d.entries = all[:d.size]
+	overflow := all[d.size:]
+	if d.spillPath == "" {
+		metrics.ReportHistoryDeadLetter(d.sink, metrics.HistoryDeadLetterDrop, len(overflow))
+		return fmt.Errorf("dead letter full, drop %d records", len(overflow))
+	}
+	if err := d.spill(overflow); err != nil {
+		metrics.ReportHistoryDeadLetter(d.sink, metrics.HistoryDeadLetterDrop, len(overflow))
+		return fmt.Errorf("dead letter spill, drop %d records: %w", len(overflow), err)
+	}
+	return nil
+}
+
+// take 取出死信队列以及本地磁盘中的全部记录
+func (d *historyDeadLetter) take() ([]*model.RecordEntry, error) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	entries := d.entries
+	d.entries = nil
+	if d.spillPath == "" {
+		return entries, nil
+	}
+	spilled, err := d.load()
+	if err != nil {
+		return entries, err
+	}
+	return append(entries, spilled...), nil
+}
+
+// flush 将内存中的记录全部写入本地磁盘，未配置本地磁盘时保留在内存中
+func (d *historyDeadLetter) flush() error {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	if d.spillPath == "" || len(d.entries) == 0 {
+		return nil
+	}
+	if err := d.spill(d.entries); err != nil {
+		return err
+	}
+	d.entries = nil
+	return nil
+}
+
+// len 内存中等待重新写入的记录数
+func (d *historyDeadLetter) len() int {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	return len(d.entries)
+}
+
+// spill 以 JSON Lines 的格式追加写入本地磁盘, 调用方需要持有锁
+func (d *historyDeadLetter) spill(entries []*model.RecordEntry) error {
+	if err := os.MkdirAll(filepath.Dir(d.spillPath), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(d.spillPath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	writer := bufio.NewWriter(f)
+	encoder := json.NewEncoder(writer)
+	for i := range entries {
+		if err := encoder.Encode(entries[i]); err != nil {
+			_ = f.Close()
+			return err
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		_ = f.Close()
+		return err
+	}
+	metrics.ReportHistoryDeadLetter(d.sink, metrics.HistoryDeadLetterSpill, len(entries))
+	return f.Close()
+}
+
+// load 读取并删除本地磁盘中的记录, 调用方需要持有锁
+func (d *historyDeadLetter) load() ([]*model.RecordEntry, error) {
+	f, err := os.Open(d.spillPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var entries []*model.RecordEntry
+	decoder := json.NewDecoder(f)
+	for decoder.More() {
+		entry := &model.RecordEntry{}
+		if err := decoder.Decode(entry); err != nil {
+			_ = f.Close()
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	if err := f.Close(); err != nil {
+		return nil, err
+	}
+	return entries, os.Remove(d.spillPath)
+}
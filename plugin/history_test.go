@@ -0,0 +1,406 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package plugin
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/polarismesh/polaris/common/model"
+)
+
+type testHistory struct {
+	name    string
+	lock    sync.Mutex
+	entries []*model.RecordEntry
+	err     error
+	panic   bool
+	block   chan struct{}
+}
+
+func (h *testHistory) Name() string {
+	return h.name
+}
+
+func (h *testHistory) Initialize(c *ConfigEntry) error {
+	return nil
+}
+
+func (h *testHistory) Destroy() error {
+	return h.err
+}
+
+func (h *testHistory) Record(entry *model.RecordEntry) {
+	_ = h.TryRecord(entry)
+}
+
+func (h *testHistory) TryRecord(entry *model.RecordEntry) error {
+	if h.block != nil {
+		<-h.block
+	}
+	if h.panic {
+		panic("sink broken")
+	}
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	if h.err != nil {
+		return h.err
+	}
+	h.entries = append(h.entries, entry)
+	return nil
+}
+
+func (h *testHistory) setErr(err error) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.err = err
+}
+
+func (h *testHistory) count() int {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	return len(h.entries)
+}
+
+// replayHistory 保存写入的记录，并可以作为重放的数据来源
+type replayHistory struct {
+	testHistory
+}
+
+func (h *replayHistory) RecentRecords(since time.Time, limit int) ([]*model.RecordEntry, error) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	var ret []*model.RecordEntry
+	for _, entry := range h.entries {
+		if !entry.HappenTime.Before(since) {
+			ret = append(ret, entry)
+		}
+	}
+	if len(ret) > limit {
+		ret = ret[len(ret)-limit:]
+	}
+	return ret, nil
+}
+
+func (h *testHistory) snapshot() []*model.RecordEntry {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	return append([]*model.RecordEntry(nil), h.entries...)
+}
+
+func Test_CompositeHistory_Record(t *testing.T) {
+	t.Run("记录分发到所有插件", func(t *testing.T) {
+		composite := NewCompositeHistory(nil)
+		first, second := &testHistory{name: "first"}, &testHistory{name: "second"}
+		composite.AddSink(first, 16)
+		composite.AddSink(second, 16)
+
+		for i := 0; i < 10; i++ {
+			composite.Record(&model.RecordEntry{ResourceName: "svc"})
+		}
+		assert.NoError(t, composite.Destroy())
+		assert.Equal(t, 10, first.count())
+		assert.Equal(t, 10, second.count())
+		assert.NoError(t, composite.Errors())
+	})
+
+	t.Run("单个插件失败不影响其他插件", func(t *testing.T) {
+		composite := NewCompositeHistory(nil)
+		broken := &testHistory{name: "broken", panic: true}
+		failed := &testHistory{name: "failed", err: errors.New("write fail")}
+		healthy := &testHistory{name: "healthy"}
+		composite.AddSink(broken, 16)
+		composite.AddSink(failed, 16)
+		composite.AddSink(healthy, 16)
+
+		composite.Record(&model.RecordEntry{ResourceName: "svc"})
+		err := composite.Destroy()
+		assert.Equal(t, 1, healthy.count())
+		// 销毁失败的插件错误被汇总
+		assert.ErrorContains(t, err, "failed: write fail")
+
+		recordErr := composite.Errors()
+		assert.ErrorContains(t, recordErr, "broken: record panic")
+		assert.ErrorContains(t, recordErr, "failed: write fail")
+	})
+
+	t.Run("慢插件不阻塞其他插件", func(t *testing.T) {
+		composite := NewCompositeHistory(nil)
+		slow := &testHistory{name: "slow", block: make(chan struct{})}
+		fast := &testHistory{name: "fast"}
+		composite.AddSink(slow, 1)
+		composite.AddSink(fast, 16)
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			for i := 0; i < 5; i++ {
+				composite.Record(&model.RecordEntry{ResourceName: "svc"})
+			}
+		}()
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("record blocked by slow sink")
+		}
+		assert.Eventually(t, func() bool {
+			return fast.count() == 5
+		}, time.Second, 10*time.Millisecond)
+
+		close(slow.block)
+		assert.NoError(t, composite.Destroy())
+		// 慢插件队列已满时丢弃的记录会被汇总为写入失败
+		assert.ErrorContains(t, composite.Errors(), "slow: queue full")
+		assert.Less(t, slow.count(), 5)
+	})
+}
+
+func Test_CompositeHistory_RecordIsolation(t *testing.T) {
+	t.Run("插件修改记录不影响其他插件", func(t *testing.T) {
+		composite := NewCompositeHistory(nil)
+		first, second := &testHistory{name: "first"}, &testHistory{name: "second"}
+		composite.AddSink(first, 16)
+		composite.AddSink(second, 16)
+
+		entry := &model.RecordEntry{ResourceName: "svc"}
+		composite.Record(entry)
+		assert.NoError(t, composite.Destroy())
+		first.snapshot()[0].Server = "first"
+		assert.Equal(t, "", second.snapshot()[0].Server)
+		assert.Equal(t, "", entry.Server)
+	})
+
+	t.Run("销毁之后的记录被丢弃", func(t *testing.T) {
+		composite := NewCompositeHistory(nil)
+		sink := &testHistory{name: "sink"}
+		composite.AddSink(sink, 16)
+		assert.NoError(t, composite.Destroy())
+		assert.NotPanics(t, func() {
+			composite.Record(&model.RecordEntry{ResourceName: "svc"})
+		})
+		assert.NoError(t, composite.Destroy())
+		assert.Equal(t, 0, sink.count())
+	})
+
+	t.Run("队列已满时高危操作记录不会被丢弃", func(t *testing.T) {
+		composite := NewCompositeHistory(nil)
+		slow := &testHistory{name: "slow", block: make(chan struct{})}
+		composite.AddSink(slow, 1)
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			for i := 0; i < 3; i++ {
+				composite.Record(&model.RecordEntry{ResourceName: "svc", OperationType: model.OBreakGlass})
+			}
+		}()
+		select {
+		case <-done:
+			t.Fatal("break-glass record should wait for the queue")
+		case <-time.After(100 * time.Millisecond):
+		}
+		close(slow.block)
+		<-done
+		assert.NoError(t, composite.Destroy())
+		assert.Equal(t, 3, slow.count())
+		assert.NoError(t, composite.Errors())
+	})
+}
+
+func Test_CompositeHistory_DeadLetter(t *testing.T) {
+	t.Run("暂时失败的插件恢复后重新写入死信队列中的记录", func(t *testing.T) {
+		composite := NewCompositeHistory(nil)
+		assert.NoError(t, composite.Initialize(&ConfigEntry{Option: map[string]interface{}{
+			"sinkRetryTimes":          2,
+			"sinkRetryInterval":       "1ms",
+			"deadLetterDrainInterval": "0s",
+		}}))
+		flaky := &testHistory{name: "flaky", err: errors.New("write fail")}
+		composite.AddSink(flaky, 16)
+
+		for i := 0; i < 5; i++ {
+			composite.Record(&model.RecordEntry{ResourceName: "svc"})
+		}
+		assert.Eventually(t, func() bool {
+			return composite.DeadLetterSize() == 5
+		}, time.Second, 10*time.Millisecond)
+		assert.ErrorContains(t, composite.Errors(), "flaky: write fail")
+
+		// 插件仍然失败时记录放回死信队列
+		drained, err := composite.DrainDeadLetter()
+		assert.ErrorContains(t, err, "flaky: write fail")
+		assert.Equal(t, 0, drained)
+		assert.Equal(t, 5, composite.DeadLetterSize())
+
+		flaky.setErr(nil)
+		drained, err = composite.DrainDeadLetter()
+		assert.NoError(t, err)
+		assert.Equal(t, 5, drained)
+		assert.Equal(t, 5, flaky.count())
+		assert.Equal(t, 0, composite.DeadLetterSize())
+		assert.NoError(t, composite.Destroy())
+	})
+
+	t.Run("死信队列已满时写入本地磁盘", func(t *testing.T) {
+		dir := t.TempDir()
+		composite := NewCompositeHistory(nil)
+		assert.NoError(t, composite.Initialize(&ConfigEntry{Option: map[string]interface{}{
+			"sinkRetryTimes":     0,
+			"deadLetterSize":     2,
+			"deadLetterSpillDir": dir,
+		}}))
+		flaky := &testHistory{name: "flaky", err: errors.New("write fail")}
+		composite.AddSink(flaky, 16)
+
+		for i := 0; i < 5; i++ {
+			composite.Record(&model.RecordEntry{ResourceName: "svc"})
+		}
+		assert.ErrorContains(t, composite.Destroy(), "flaky: write fail")
+		// 销毁时内存中的记录同样写入本地磁盘
+		assert.Equal(t, 0, composite.DeadLetterSize())
+		_, err := os.Stat(filepath.Join(dir, "flaky.deadletter"))
+		assert.NoError(t, err)
+
+		flaky.setErr(nil)
+		drained, err := composite.DrainDeadLetter()
+		assert.NoError(t, err)
+		assert.Equal(t, 5, drained)
+		assert.Equal(t, 5, flaky.count())
+		_, err = os.Stat(filepath.Join(dir, "flaky.deadletter"))
+		assert.True(t, errors.Is(err, os.ErrNotExist))
+	})
+
+	t.Run("未配置本地磁盘时死信队列已满丢弃记录", func(t *testing.T) {
+		composite := NewCompositeHistory(nil)
+		assert.NoError(t, composite.Initialize(&ConfigEntry{Option: map[string]interface{}{
+			"sinkRetryTimes": 0,
+			"deadLetterSize": 2,
+		}}))
+		failed := &testHistory{name: "failed", err: errors.New("write fail")}
+		composite.AddSink(failed, 16)
+		for i := 0; i < 5; i++ {
+			composite.Record(&model.RecordEntry{ResourceName: "svc"})
+		}
+		assert.ErrorContains(t, composite.Destroy(), "failed: write fail")
+		assert.Equal(t, 2, composite.DeadLetterSize())
+		assert.ErrorContains(t, composite.Errors(), "failed: dead letter full")
+	})
+
+	t.Run("定时重新写入死信队列", func(t *testing.T) {
+		composite := NewCompositeHistory(nil)
+		assert.NoError(t, composite.Initialize(&ConfigEntry{Option: map[string]interface{}{
+			"sinkRetryTimes":          0,
+			"deadLetterDrainInterval": "10ms",
+		}}))
+		flaky := &testHistory{name: "flaky", err: errors.New("write fail")}
+		composite.AddSink(flaky, 16)
+		composite.Record(&model.RecordEntry{ResourceName: "svc"})
+		flaky.setErr(nil)
+		assert.Eventually(t, func() bool {
+			return flaky.count() == 1
+		}, time.Second, 10*time.Millisecond)
+		assert.NoError(t, composite.Destroy())
+	})
+
+	t.Run("非法的重试间隔", func(t *testing.T) {
+		composite := NewCompositeHistory(nil)
+		assert.Error(t, composite.Initialize(&ConfigEntry{Option: map[string]interface{}{
+			"sinkRetryInterval": "abc",
+		}}))
+	})
+}
+
+func Test_CompositeHistory_Replay(t *testing.T) {
+	t.Run("新添加的插件先收到重放的记录，再收到实时的记录", func(t *testing.T) {
+		composite := NewCompositeHistory(nil)
+		source := &replayHistory{testHistory: testHistory{name: "source"}}
+		composite.AddSink(source, 16)
+		composite.Record(&model.RecordEntry{ResourceName: "expired", HappenTime: time.Now().Add(-time.Hour)})
+		for i := 0; i < 3; i++ {
+			composite.Record(&model.RecordEntry{ResourceName: "history", HappenTime: time.Now()})
+		}
+		assert.Eventually(t, func() bool {
+			return source.count() == 4
+		}, time.Second, 10*time.Millisecond)
+
+		consumer := &testHistory{name: "consumer"}
+		replayed, err := composite.AttachSink(consumer, 1, time.Minute)
+		assert.NoError(t, err)
+		assert.Equal(t, 3, replayed)
+		composite.Record(&model.RecordEntry{ResourceName: "live", HappenTime: time.Now()})
+		assert.NoError(t, composite.Destroy())
+
+		entries := consumer.snapshot()
+		if assert.Equal(t, 4, len(entries)) {
+			for _, entry := range entries[:3] {
+				assert.Equal(t, "history", entry.ResourceName)
+				assert.True(t, entry.Replayed)
+			}
+			assert.Equal(t, "live", entries[3].ResourceName)
+			assert.False(t, entries[3].Replayed)
+		}
+		// 数据来源中的记录不会被标记为重放
+		for _, entry := range source.snapshot() {
+			assert.False(t, entry.Replayed)
+		}
+	})
+
+	t.Run("重放的记录数受 replayLimit 限制", func(t *testing.T) {
+		composite := NewCompositeHistory(nil)
+		assert.NoError(t, composite.Initialize(&ConfigEntry{Option: map[string]interface{}{
+			"replayLimit":             2,
+			"deadLetterDrainInterval": "0s",
+		}}))
+		source := &replayHistory{testHistory: testHistory{name: "source"}}
+		composite.AddSink(source, 16)
+		for i := 0; i < 5; i++ {
+			composite.Record(&model.RecordEntry{ResourceName: "history", HappenTime: time.Now()})
+		}
+		assert.Eventually(t, func() bool {
+			return source.count() == 5
+		}, time.Second, 10*time.Millisecond)
+
+		consumer := &testHistory{name: "consumer"}
+		replayed, err := composite.AttachSink(consumer, 16, time.Minute)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, replayed)
+		assert.NoError(t, composite.Destroy())
+		assert.Equal(t, 2, consumer.count())
+	})
+
+	t.Run("没有可以重放的插件", func(t *testing.T) {
+		composite := NewCompositeHistory(nil)
+		composite.AddSink(&testHistory{name: "first"}, 16)
+		_, err := composite.AttachSink(&testHistory{name: "second"}, 16, time.Minute)
+		assert.ErrorIs(t, err, ErrorHistoryReplayUnsupported)
+		assert.Equal(t, 1, composite.SinkCount())
+
+		// 不需要重放时直接添加
+		replayed, err := composite.AttachSink(&testHistory{name: "third"}, 16, 0)
+		assert.NoError(t, err)
+		assert.Equal(t, 0, replayed)
+		assert.Equal(t, 2, composite.SinkCount())
+		assert.NoError(t, composite.Destroy())
+	})
+}
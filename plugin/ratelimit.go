@@ -37,6 +37,9 @@ const (
 
 	// InstanceRatelimit Based on Instance flow control
 	InstanceRatelimit
+
+	// TokenRatelimit Based on auth token flow control
+	TokenRatelimit
 )
 
 // RatelimitStr rate limit string map
@@ -45,6 +48,7 @@ var RatelimitStr = map[RatelimitType]string{
 	APIRatelimit:      "api-limit",
 	ServiceRatelimit:  "service-limit",
 	InstanceRatelimit: "instance-limit",
+	TokenRatelimit:    "token-limit",
 }
 
 var (
@@ -58,7 +62,7 @@ type Ratelimit interface {
 	// Allow Whether to allow access, true: allow, FALSE: not allowing Todo
 	// - Parameter ratingype is the type of current limits, and the ID is the key that limits the current
 	// - If RateType is Ratelimitip, the ID is IP, RateType is Ratelimitservice, and the ID is
-	//  IP_NAMESPACE_SERVICE or IP_SERVICEID
+	//  IP_NAMESPACE_SERVICE or IP_SERVICEID, RateType is TokenRatelimit, and the ID is the auth token
 	Allow(typ RatelimitType, key string) bool
 }
 
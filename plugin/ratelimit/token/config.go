@@ -39,6 +39,8 @@ type Config struct {
 	APILimitConf *APILimitConfig `yaml:"api-limit" mapstructure:"api-limit"`
 	// 基于实例的限流配置
 	InstanceLimitConf *ResourceLimitConfig `yaml:"instance-limit" mapstructure:"instance-limit"`
+	// 基于鉴权 token 的限流配置
+	TokenLimitConf *ResourceLimitConfig `yaml:"token-limit" mapstructure:"token-limit"`
 }
 
 // BucketRatelimit 针对令牌桶的具体配置
@@ -62,6 +64,16 @@ type ResourceLimitConfig struct {
 	MaxResourceCacheAmount int `yaml:"resource-cache-amount" mapstructure:"resource-cache-amount"`
 	// 白名单
 	WhiteList []string `yaml:"white-list" mapstructure:"white-list"`
+	// 针对部分资源单独设置的限制规则，未命中的资源使用全局规则
+	Rules []*ResourceLimitRule `yaml:"rules" mapstructure:"rules"`
+}
+
+// ResourceLimitRule 针对部分资源单独设置的限制规则
+type ResourceLimitRule struct {
+	// 规则作用的资源，比如 IP 或者 token
+	Resources []string `yaml:"resources" mapstructure:"resources"`
+	// 规则的限制，open 为 false 时这些资源不限流
+	Limit *BucketRatelimit `yaml:"limit" mapstructure:"limit"`
 }
 
 // APILimitConfig api限流配置
@@ -69,6 +69,13 @@ func (tb *tokenBucket) initialize(c *plugin.ConfigEntry) error {
 	}
 	tb.limiters[plugin.InstanceRatelimit] = instance
 
+	// 鉴权 token 限流
+	token, err := newResourceRatelimit(plugin.TokenRatelimit, config.TokenLimitConf)
+	if err != nil {
+		return err
+	}
+	tb.limiters[plugin.TokenRatelimit] = token
+
 	return nil
 }
 
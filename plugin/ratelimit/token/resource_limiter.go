@@ -31,6 +31,7 @@ type resourceRatelimit struct {
 	typStr    string
 	resources *lru.Cache
 	whiteList map[string]bool
+	rules     map[string]*BucketRatelimit
 	config    *ResourceLimitConfig
 }
 
@@ -75,6 +76,19 @@ func (r *resourceRatelimit) initialize(config *ResourceLimitConfig) error {
 		r.whiteList[item] = true
 	}
 
+	r.rules = make(map[string]*BucketRatelimit)
+	for _, rule := range config.Rules {
+		if rule == nil || rule.Limit == nil || len(rule.Resources) == 0 {
+			return fmt.Errorf("resource(%s) ratelimit rule resources or limit is empty", r.typStr)
+		}
+		if rule.Limit.Open && (rule.Limit.Bucket <= 0 || rule.Limit.Rate <= 0) {
+			return fmt.Errorf("resource(%s) ratelimit rule bucket or rate invalid", r.typStr)
+		}
+		for _, item := range rule.Resources {
+			r.rules[item] = rule.Limit
+		}
+	}
+
 	log.Infof("[Plugin][%s] resource(%s) ratelimit open", PluginName, r.typStr)
 	return nil
 }
@@ -98,11 +112,17 @@ func (r *resourceRatelimit) allow(key string) bool {
 	if ok := r.isWhiteList(key); ok {
 		return true
 	}
+	limit := r.config.Global
+	if rule, ok := r.rules[key]; ok {
+		if !rule.Open {
+			return true
+		}
+		limit = rule
+	}
 
 	value, ok := r.resources.Get(key)
 	if !ok {
-		r.resources.ContainsOrAdd(key,
-			rate.NewLimiter(rate.Limit(r.config.Global.Rate), r.config.Global.Bucket))
+		r.resources.ContainsOrAdd(key, rate.NewLimiter(rate.Limit(limit.Rate), limit.Bucket))
 		// 上面已经加了value，这里正常情况会有value
 		value, ok = r.resources.Get(key)
 		if !ok {
@@ -164,5 +164,41 @@ func TestResourceAllow(t *testing.T) {
 			}
 			So(cnt, ShouldEqual, limiter.config.Global.Rate*30)
 		})
+		Convey("单独规则测试", func() {
+			limiter, err := newResourceRatelimit(plugin.TokenRatelimit, &ResourceLimitConfig{
+				Open:                   true,
+				Global:                 &BucketRatelimit{true, 5, 5},
+				MaxResourceCacheAmount: 1024,
+				Rules: []*ResourceLimitRule{
+					{Resources: []string{"token-a"}, Limit: &BucketRatelimit{true, 10, 10}},
+					{Resources: []string{"token-b"}, Limit: &BucketRatelimit{Open: false}},
+				},
+			})
+			So(err, ShouldBeNil)
+
+			count := func(key string, times int) int {
+				cnt := 0
+				for i := 0; i < times; i++ {
+					if ok := limiter.allow(key); ok {
+						cnt++
+					}
+				}
+				return cnt
+			}
+			So(count("token-a", 30), ShouldEqual, 10)
+			So(count("token-b", 30), ShouldEqual, 30)
+			So(count("token-c", 30), ShouldEqual, 5)
+		})
+		Convey("单独规则不合法", func() {
+			_, err := newResourceRatelimit(plugin.TokenRatelimit, &ResourceLimitConfig{
+				Open:                   true,
+				Global:                 &BucketRatelimit{true, 5, 5},
+				MaxResourceCacheAmount: 1024,
+				Rules: []*ResourceLimitRule{
+					{Resources: []string{"token-a"}, Limit: &BucketRatelimit{Open: true}},
+				},
+			})
+			So(err, ShouldNotBeNil)
+		})
 	})
 }
@@ -23,3 +23,10 @@ func TestCleanCryptoPlugin() {
 	cryptoManagerOnce = sync.Once{}
 	cryptoManager = nil
 }
+
+// TestInjectHistory 替换历史记录插件，返回原有的插件用于恢复
+func TestInjectHistory(history *CompositeHistory) *CompositeHistory {
+	prev := compositeHistory
+	compositeHistory = history
+	return prev
+}
@@ -19,6 +19,7 @@ package service
 
 import (
 	"context"
+	"time"
 
 	apifault "github.com/polarismesh/specification/source/go/api/v1/fault_tolerance"
 	apiservice "github.com/polarismesh/specification/source/go/api/v1/service_manage"
@@ -124,6 +125,8 @@ type ServiceOperateServer interface {
 	GetServiceToken(ctx context.Context, req *apiservice.Service) *apiservice.Response
 	// GetServiceOwner Owner for obtaining service
 	GetServiceOwner(ctx context.Context, req []*apiservice.Service) *apiservice.BatchQueryResponse
+	// GetServiceDependencies Get the upstream and downstream dependencies of the service
+	GetServiceDependencies(ctx context.Context, query map[string]string) *model.ServiceDependencies
 }
 
 // ServiceAliasOperateServer Service alias related operations
@@ -150,6 +153,8 @@ type InstanceOperateServer interface {
 	UpdateInstances(ctx context.Context, req []*apiservice.Instance) *apiservice.BatchWriteResponse
 	// UpdateInstancesIsolate Batch update instance isolation state
 	UpdateInstancesIsolate(ctx context.Context, req []*apiservice.Instance) *apiservice.BatchWriteResponse
+	// DrainInstances Batch mark instances draining, which are removed automatically after ttl
+	DrainInstances(ctx context.Context, req []*apiservice.Instance, ttl time.Duration) *apiservice.BatchWriteResponse
 	// GetInstances Get an instance list
 	GetInstances(ctx context.Context, query map[string]string) *apiservice.BatchQueryResponse
 	// GetInstancesCount Get an instance quantity
@@ -162,6 +167,8 @@ type InstanceOperateServer interface {
 type ClientServer interface {
 	// RegisterInstance create one instance by client
 	RegisterInstance(ctx context.Context, req *apiservice.Instance) *apiservice.Response
+	// BatchRegisterInstance create instances by client, written in a single store transaction
+	BatchRegisterInstance(ctx context.Context, reqs []*apiservice.Instance) *apiservice.BatchWriteResponse
 	// DeregisterInstance delete onr instance by client
 	DeregisterInstance(ctx context.Context, req *apiservice.Instance) *apiservice.Response
 	// ReportClient Client gets geographic location information
@@ -151,6 +151,7 @@ func circuitBreakerRuleRecordEntry(ctx context.Context, req *apifault.CircuitBre
 		Namespace:     req.GetNamespace(),
 		OperationType: opt,
 		Operator:      utils.ParseOperator(ctx),
+		ChangeSetID:   utils.ParseChangeSetID(ctx),
 		Detail:        detail,
 		HappenTime:    time.Now(),
 	}
@@ -22,6 +22,7 @@ import (
 	"fmt"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/golang/protobuf/proto"
 	apimodel "github.com/polarismesh/specification/source/go/api/v1/model"
@@ -488,3 +489,136 @@ func TestServer_ReportServiceContract(t *testing.T) {
 	})
 
 }
+
+// 测试批量注册实例以及批量上报心跳
+func TestBatchRegisterInstance(t *testing.T) {
+	discoverSuit := &DiscoverTestSuit{}
+	if err := discoverSuit.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+	defer discoverSuit.Destroy()
+
+	_, svc := discoverSuit.createCommonService(t, 113)
+	defer discoverSuit.cleanServiceName(svc.GetName().GetValue(), svc.GetNamespace().GetValue())
+
+	newReq := func(port uint32) *apiservice.Instance {
+		return &apiservice.Instance{
+			ServiceToken:      utils.NewStringValue(svc.GetToken().GetValue()),
+			Service:           utils.NewStringValue(svc.GetName().GetValue()),
+			Namespace:         utils.NewStringValue(svc.GetNamespace().GetValue()),
+			Host:              utils.NewStringValue("10.10.10.10"),
+			Port:              utils.NewUInt32Value(port),
+			EnableHealthCheck: utils.NewBoolValue(true),
+			HealthCheck: &apiservice.HealthCheck{
+				Type:      apiservice.HealthCheck_HEARTBEAT,
+				Heartbeat: &apiservice.HeartbeatHealthCheck{Ttl: utils.NewUInt32Value(5)},
+			},
+		}
+	}
+
+	t.Run("批量注册实例", func(t *testing.T) {
+		invalid := newReq(8000)
+		invalid.Host = utils.NewStringValue("")
+		reqs := []*apiservice.Instance{newReq(8001), newReq(8002), newReq(8001), invalid}
+		resp := discoverSuit.DiscoverServer().BatchRegisterInstance(discoverSuit.DefaultCtx, reqs)
+		assert.Equal(t, 4, len(resp.GetResponses()))
+		assert.Equal(t, uint32(apimodel.Code_ExecuteSuccess), resp.GetResponses()[0].GetCode().GetValue())
+		assert.Equal(t, uint32(apimodel.Code_ExecuteSuccess), resp.GetResponses()[1].GetCode().GetValue())
+		assert.Equal(t, uint32(apimodel.Code_SameInstanceRequest), resp.GetResponses()[2].GetCode().GetValue())
+		assert.Equal(t, uint32(apimodel.Code_InvalidInstanceHost), resp.GetResponses()[3].GetCode().GetValue())
+		for _, item := range resp.GetResponses()[:2] {
+			defer discoverSuit.cleanInstance(item.GetInstance().GetId().GetValue())
+		}
+
+		_ = discoverSuit.DiscoverServer().Cache().TestUpdate()
+		for _, item := range resp.GetResponses()[:2] {
+			ins := discoverSuit.DiscoverServer().Cache().Instance().GetInstance(item.GetInstance().GetId().GetValue())
+			assert.NotNil(t, ins)
+		}
+
+		beats := make([]*apiservice.Instance, 0, 2)
+		for _, item := range resp.GetResponses()[:2] {
+			beats = append(beats, &apiservice.Instance{
+				ServiceToken: utils.NewStringValue(svc.GetToken().GetValue()),
+				Id:           item.GetInstance().GetId(),
+			})
+		}
+		beatResp := discoverSuit.HealthCheckServer().BatchReport(discoverSuit.DefaultCtx, beats)
+		assert.Equal(t, uint32(apimodel.Code_ExecuteSuccess), beatResp.GetCode().GetValue(),
+			beatResp.GetInfo().GetValue())
+		assert.Equal(t, 2, len(beatResp.GetResponses()))
+	})
+
+	t.Run("超过单次注册的实例上限", func(t *testing.T) {
+		reqs := make([]*apiservice.Instance, 0, service.MaxBatchRegisterSize+1)
+		for i := 0; i <= service.MaxBatchRegisterSize; i++ {
+			reqs = append(reqs, newReq(uint32(9000+i)))
+		}
+		resp := discoverSuit.DiscoverServer().BatchRegisterInstance(discoverSuit.DefaultCtx, reqs)
+		assert.Equal(t, uint32(apimodel.Code_BatchSizeOverLimit), resp.GetCode().GetValue())
+		assert.Equal(t, uint32(apimodel.Code_EmptyRequest),
+			discoverSuit.DiscoverServer().BatchRegisterInstance(discoverSuit.DefaultCtx, nil).GetCode().GetValue())
+	})
+}
+
+func TestGetServiceDependencies(t *testing.T) {
+	discoverSuit := &DiscoverTestSuit{}
+	if err := discoverSuit.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+	defer discoverSuit.Destroy()
+
+	_, caller := discoverSuit.createCommonService(t, 114)
+	defer discoverSuit.cleanServiceName(caller.GetName().GetValue(), caller.GetNamespace().GetValue())
+	_, callee := discoverSuit.createCommonService(t, 115)
+	defer discoverSuit.cleanServiceName(callee.GetName().GetValue(), callee.GetNamespace().GetValue())
+
+	resp := discoverSuit.DiscoverServer().CreateInstances(discoverSuit.DefaultCtx, []*apiservice.Instance{
+		{
+			ServiceToken: utils.NewStringValue(caller.GetToken().GetValue()),
+			Service:      utils.NewStringValue(caller.GetName().GetValue()),
+			Namespace:    utils.NewStringValue(caller.GetNamespace().GetValue()),
+			Host:         utils.NewStringValue("10.10.11.11"),
+			Port:         utils.NewUInt32Value(8080),
+		},
+		{
+			ServiceToken: utils.NewStringValue(callee.GetToken().GetValue()),
+			Service:      utils.NewStringValue(callee.GetName().GetValue()),
+			Namespace:    utils.NewStringValue(callee.GetNamespace().GetValue()),
+			Host:         utils.NewStringValue("10.10.11.12"),
+			Port:         utils.NewUInt32Value(8080),
+		},
+	})
+	assert.True(t, respSuccess(resp), resp.GetInfo().GetValue())
+	for _, item := range resp.GetResponses() {
+		defer discoverSuit.cleanInstance(item.GetInstance().GetId().GetValue())
+	}
+	_ = discoverSuit.DiscoverServer().Cache().TestUpdate()
+
+	query := map[string]string{
+		"namespace": callee.GetNamespace().GetValue(),
+		"service":   callee.GetName().GetValue(),
+	}
+	// 实例缓存的变化通过事件异步通知，等待调用方所在的 host 被识别
+	ctx := context.WithValue(discoverSuit.DefaultCtx, utils.ContextClientAddress, "10.10.11.11:52000")
+	assert.Eventually(t, func() bool {
+		_ = discoverSuit.DiscoverServer().ServiceInstancesCache(ctx, &apiservice.DiscoverFilter{},
+			&apiservice.Service{Name: callee.GetName(), Namespace: callee.GetNamespace()})
+		ret := discoverSuit.DiscoverServer().GetServiceDependencies(discoverSuit.DefaultCtx, query)
+		return len(ret.Callers) == 1 && ret.Callers[0].CallerService == caller.GetName().GetValue()
+	}, 5*time.Second, 100*time.Millisecond)
+
+	ret := discoverSuit.DiscoverServer().GetServiceDependencies(discoverSuit.DefaultCtx, query)
+	assert.Equal(t, uint32(apimodel.Code_ExecuteSuccess), ret.Code, ret.Info)
+	assert.Empty(t, ret.Callees)
+
+	query["service"] = caller.GetName().GetValue()
+	ret = discoverSuit.DiscoverServer().GetServiceDependencies(discoverSuit.DefaultCtx, query)
+	if assert.Equal(t, 1, len(ret.Callees), ret.Callees) {
+		assert.Equal(t, callee.GetName().GetValue(), ret.Callees[0].CalleeService)
+	}
+
+	query["window"] = "xx"
+	ret = discoverSuit.DiscoverServer().GetServiceDependencies(discoverSuit.DefaultCtx, query)
+	assert.Equal(t, uint32(apimodel.Code_InvalidParameter), ret.Code)
+}
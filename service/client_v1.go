@@ -21,7 +21,9 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"time"
 
+	"github.com/golang/protobuf/proto"
 	"github.com/golang/protobuf/ptypes/wrappers"
 	apimodel "github.com/polarismesh/specification/source/go/api/v1/model"
 	apiservice "github.com/polarismesh/specification/source/go/api/v1/service_manage"
@@ -33,6 +35,7 @@ import (
 	api "github.com/polarismesh/polaris/common/api/v1"
 	"github.com/polarismesh/polaris/common/metrics"
 	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/common/trace"
 	"github.com/polarismesh/polaris/common/utils"
 )
 
@@ -42,6 +45,19 @@ func (s *Server) RegisterInstance(ctx context.Context, req *apiservice.Instance)
 	return s.CreateInstance(ctx, req)
 }
 
+// BatchRegisterInstance 批量注册实例，供代理了大量本地进程的 agent 一次性注册
+func (s *Server) BatchRegisterInstance(ctx context.Context,
+	reqs []*apiservice.Instance) *apiservice.BatchWriteResponse {
+	if len(reqs) == 0 {
+		return api.NewBatchWriteResponse(apimodel.Code_EmptyRequest)
+	}
+	if len(reqs) > MaxBatchRegisterSize {
+		return api.NewBatchWriteResponse(apimodel.Code_BatchSizeOverLimit)
+	}
+	ctx = context.WithValue(ctx, utils.ContextIsFromClient, true)
+	return s.batchCreateInstances(ctx, reqs)
+}
+
 // DeregisterInstance delete one instance
 func (s *Server) DeregisterInstance(ctx context.Context, req *apiservice.Instance) *apiservice.Response {
 	ctx = context.WithValue(ctx, utils.ContextIsFromClient, true)
@@ -85,6 +101,9 @@ func isSuccessReportContract(rsp *apiservice.Response) bool {
 func (s *Server) ReportClient(ctx context.Context, req *apiservice.Client) *apiservice.Response {
 	// 客户端信息不写入到DB中
 	host := req.GetHost().GetValue()
+	if s.dependency != nil {
+		s.dependency.ReportClient(utils.ParseClientIP(ctx), host, time.Now())
+	}
 	// 从CMDB查询地理位置信息
 	if s.cmdb != nil {
 		location, err := s.cmdb.GetLocation(host)
@@ -171,6 +190,8 @@ func (s *Server) GetPrometheusTargets(ctx context.Context,
 
 // GetServiceWithCache 查询服务列表
 func (s *Server) GetServiceWithCache(ctx context.Context, req *apiservice.Service) *apiservice.DiscoverResponse {
+	_, span := trace.StartSpan(ctx, "cache.GetServiceWithCache", trace.SpanKindInternal)
+	defer span.End()
 	resp := api.NewDiscoverServiceResponse(apimodel.Code_ExecuteSuccess, req)
 	var (
 		revision string
@@ -214,6 +235,8 @@ func (s *Server) GetServiceWithCache(ctx context.Context, req *apiservice.Servic
 func (s *Server) ServiceInstancesCache(ctx context.Context, filter *apiservice.DiscoverFilter,
 	req *apiservice.Service) *apiservice.DiscoverResponse {
 
+	_, span := trace.StartSpan(ctx, "cache.ServiceInstancesCache", trace.SpanKindInternal)
+	defer span.End()
 	resp := createCommonDiscoverResponse(req, apiservice.DiscoverResponse_INSTANCE)
 	serviceName := req.GetName().GetValue()
 	namespaceName := req.GetNamespace().GetValue()
@@ -225,6 +248,7 @@ func (s *Server) ServiceInstancesCache(ctx context.Context, filter *apiservice.D
 			serviceName, namespaceName)
 		return api.NewDiscoverInstanceResponse(apimodel.Code_NotFoundResource, req)
 	}
+	s.recordDependency(ctx, aliasFor)
 
 	revisions := make([]string, 0, len(visibleServices)+1)
 	finalInstances := make(map[string]*apiservice.Instance, 128)
@@ -254,6 +278,8 @@ func (s *Server) ServiceInstancesCache(ctx context.Context, filter *apiservice.D
 		ret := s.caches.Instance().DiscoverServiceInstances(specSvc.GetId().GetValue(), filter.GetOnlyHealthyInstance())
 		for i := range ret {
 			copyIns := s.getInstance(req, ret[i].Proto)
+			// 优雅下线中的实例继续返回，但是不再分配流量
+			copyIns.Weight = model.InstanceDiscoverWeight(ret[i].Proto)
 			// 注意：这里的value是cache的，不修改cache的数据，通过getInstance，浅拷贝一份数据
 			finalInstances[copyIns.GetId().GetValue()] = copyIns
 		}
@@ -299,6 +325,8 @@ func (s *Server) findVisibleServices(serviceName, namespaceName string, req *api
 
 // GetRoutingConfigWithCache 获取缓存中的路由配置信息
 func (s *Server) GetRoutingConfigWithCache(ctx context.Context, req *apiservice.Service) *apiservice.DiscoverResponse {
+	_, span := trace.StartSpan(ctx, "cache.GetRoutingConfigWithCache", trace.SpanKindInternal)
+	defer span.End()
 	resp := createCommonDiscoverResponse(req, apiservice.DiscoverResponse_ROUTING)
 	aliasFor := s.findServiceAlias(req)
 
@@ -310,6 +338,7 @@ func (s *Server) GetRoutingConfigWithCache(ctx context.Context, req *apiservice.
 	if out == nil {
 		return resp
 	}
+	out = s.subsetRouting(out)
 
 	// 获取路由数据，并对比revision
 	if out.GetRevision().GetValue() == req.GetRevision().GetValue() {
@@ -319,7 +348,7 @@ func (s *Server) GetRoutingConfigWithCache(ctx context.Context, req *apiservice.
 	// 数据不一致，发生了改变
 	// 数据格式转换，service只需要返回二元组与routing的revision
 	resp.Service.Revision = out.GetRevision()
-	resp.Routing = out
+	resp.Routing = aliasRouting(out, aliasFor, req.GetName().GetValue(), req.GetNamespace().GetValue())
 	resp.AliasFor = &apiservice.Service{
 		Name:      utils.NewStringValue(aliasFor.Name),
 		Namespace: utils.NewStringValue(aliasFor.Namespace),
@@ -327,8 +356,88 @@ func (s *Server) GetRoutingConfigWithCache(ctx context.Context, req *apiservice.
 	return resp
 }
 
+// aliasRouting 通过别名获取路由规则时，将规则中的源服务替换为请求的别名，对客户端透明
+// 入规则替换被调方，出规则替换主调方，不修改缓存中的数据
+func aliasRouting(out *apitraffic.Routing, aliasFor *model.Service, name, namespace string) *apitraffic.Routing {
+	if aliasFor.Name == name && aliasFor.Namespace == namespace {
+		return out
+	}
+	isSource := func(svc, ns *wrappers.StringValue) bool {
+		return svc.GetValue() == aliasFor.Name && ns.GetValue() == aliasFor.Namespace
+	}
+	ret := proto.Clone(out).(*apitraffic.Routing)
+	ret.Service = utils.NewStringValue(name)
+	ret.Namespace = utils.NewStringValue(namespace)
+	for _, route := range ret.GetInbounds() {
+		for _, dest := range route.GetDestinations() {
+			if isSource(dest.GetService(), dest.GetNamespace()) {
+				dest.Service = utils.NewStringValue(name)
+				dest.Namespace = utils.NewStringValue(namespace)
+			}
+		}
+	}
+	for _, route := range ret.GetOutbounds() {
+		for _, source := range route.GetSources() {
+			if isSource(source.GetService(), source.GetNamespace()) {
+				source.Service = utils.NewStringValue(name)
+				source.Namespace = utils.NewStringValue(namespace)
+			}
+		}
+	}
+	return ret
+}
+
+// subsetRouting 将路由规则目标标签中引用的实例分组展开为分组的标签选择器，客户端无需感知实例分组
+// 引用的分组定义参与 revision 的计算，分组变更后客户端同样会重新拉取路由规则，不修改缓存中的数据
+func (s *Server) subsetRouting(out *apitraffic.Routing) *apitraffic.Routing {
+	subsetDestinations := func(routing *apitraffic.Routing) []*apitraffic.Destination {
+		var dests []*apitraffic.Destination
+		for _, routes := range [][]*apitraffic.Route{routing.GetInbounds(), routing.GetOutbounds()} {
+			for _, route := range routes {
+				for _, dest := range route.GetDestinations() {
+					if _, ok := dest.GetMetadata()[model.SubsetLabelKey]; ok {
+						dests = append(dests, dest)
+					}
+				}
+			}
+		}
+		return dests
+	}
+	if len(subsetDestinations(out)) == 0 {
+		return out
+	}
+	ret := proto.Clone(out).(*apitraffic.Routing)
+	revisions := []string{out.GetRevision().GetValue()}
+	for _, dest := range subsetDestinations(ret) {
+		name := dest.GetMetadata()[model.SubsetLabelKey].GetValue().GetValue()
+		svc := s.getServiceCache(dest.GetService().GetValue(), dest.GetNamespace().GetValue())
+		if svc == nil {
+			continue
+		}
+		subset, ok := model.FindServiceSubset(model.ServiceSubsets(svc.Meta), name)
+		if !ok {
+			// 分组不存在时保留引用，没有实例能够匹配该目标
+			continue
+		}
+		delete(dest.Metadata, model.SubsetLabelKey)
+		for key, matcher := range subset.MatchStrings() {
+			dest.Metadata[key] = matcher
+		}
+		revisions = append(revisions, svc.Namespace+"/"+svc.Name+"/"+subset.Name+"="+subset.Expr)
+	}
+	revision, err := cachetypes.CompositeComputeRevision(revisions)
+	if err != nil {
+		log.Warn("[Server][Service][Routing] compute subset routing revision", zap.Error(err))
+		return ret
+	}
+	ret.Revision = utils.NewStringValue(revision)
+	return ret
+}
+
 // GetRateLimitWithCache 获取缓存中的限流规则信息
 func (s *Server) GetRateLimitWithCache(ctx context.Context, req *apiservice.Service) *apiservice.DiscoverResponse {
+	_, span := trace.StartSpan(ctx, "cache.GetRateLimitWithCache", trace.SpanKindInternal)
+	defer span.End()
 	resp := createCommonDiscoverResponse(req, apiservice.DiscoverResponse_RATE_LIMIT)
 	aliasFor := s.findServiceAlias(req)
 
@@ -369,6 +478,8 @@ func (s *Server) GetRateLimitWithCache(ctx context.Context, req *apiservice.Serv
 }
 
 func (s *Server) GetFaultDetectWithCache(ctx context.Context, req *apiservice.Service) *apiservice.DiscoverResponse {
+	_, span := trace.StartSpan(ctx, "cache.GetFaultDetectWithCache", trace.SpanKindInternal)
+	defer span.End()
 	resp := createCommonDiscoverResponse(req, apiservice.DiscoverResponse_FAULT_DETECTOR)
 	aliasFor := s.findServiceAlias(req)
 
@@ -398,6 +509,8 @@ func (s *Server) GetFaultDetectWithCache(ctx context.Context, req *apiservice.Se
 
 // GetCircuitBreakerWithCache 获取缓存中的熔断规则信息
 func (s *Server) GetCircuitBreakerWithCache(ctx context.Context, req *apiservice.Service) *apiservice.DiscoverResponse {
+	_, span := trace.StartSpan(ctx, "cache.GetCircuitBreakerWithCache", trace.SpanKindInternal)
+	defer span.End()
 	resp := createCommonDiscoverResponse(req, apiservice.DiscoverResponse_CIRCUIT_BREAKER)
 	// 获取源服务
 	aliasFor := s.findServiceAlias(req)
@@ -534,8 +647,11 @@ func (s *Server) getSourceServiceID(service *model.Service) string {
 	if service == nil || service.ID == "" {
 		return ""
 	}
-	// 找到parent服务，最多两级，因此不用递归查找
+	// 别名链在缓存中解析，找不到源服务时使用别名直接指向的服务
 	if service.IsAlias() {
+		if source := s.caches.Service().ResolveService(service.Name, service.Namespace); source != nil {
+			return source.ID
+		}
 		return service.Reference
 	}
 
@@ -545,18 +661,11 @@ func (s *Server) getSourceServiceID(service *model.Service) string {
 // 根据服务名获取服务缓存数据
 // 注意，如果是服务别名查询，这里会返回别名的源服务，不会返回别名
 func (s *Server) getServiceCache(name string, namespace string) *model.Service {
-	sc := s.caches.Service()
-	service := sc.GetServiceByName(name, namespace)
+	// 如果是服务别名，缓存中会沿着别名链找到源服务
+	service := s.caches.Service().ResolveService(name, namespace)
 	if service == nil {
 		return nil
 	}
-	// 如果是服务别名，继续查找一下
-	if service.IsAlias() {
-		service = sc.GetServiceByID(service.Reference)
-		if service == nil {
-			return nil
-		}
-	}
 
 	if service.Meta == nil {
 		service.Meta = make(map[string]string)
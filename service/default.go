@@ -22,6 +22,7 @@ import (
 	"errors"
 	"fmt"
 	"sync"
+	"time"
 
 	"golang.org/x/sync/singleflight"
 
@@ -35,6 +36,8 @@ const (
 	MaxBatchSize = 100
 	// MaxQuerySize max query size
 	MaxQuerySize = 100
+	// MaxBatchRegisterSize 客户端单次批量注册的最大实例数
+	MaxBatchRegisterSize = 500
 )
 
 const (
@@ -46,6 +49,8 @@ const (
 	ProductionNamespace = "Production"
 	// DefaultTLL default ttl
 	DefaultTLL = 5
+	// MaxDrainingTTL 实例优雅下线的最长时间
+	MaxDrainingTTL = time.Hour
 )
 
 type ServerProxyFactory func(svr *Server, pre DiscoverServer) (DiscoverServer, error)
@@ -72,6 +77,7 @@ type Config struct {
 	L5Open       *bool                  `yaml:"l5Open"`
 	AutoCreate   *bool                  `yaml:"autoCreate"`
 	Batch        map[string]interface{} `yaml:"batch"`
+	Dependency   map[string]interface{} `yaml:"dependency"`
 	Interceptors []string               `yaml:"-"`
 }
 
@@ -124,6 +130,10 @@ func initialize(ctx context.Context, namingOpt *Config, opts ...InitOption) erro
 	// 插件初始化
 	pluginInitialize()
 
+	if err := initDependencyTracker(namingOpt); err != nil {
+		return err
+	}
+
 	// 需要返回包装代理的 DiscoverServer
 	order := namingOpt.Interceptors
 	for i := range order {
@@ -40,6 +40,7 @@ func Test_Initialize(t *testing.T) {
 	s := mock.NewMockStore(ctrl)
 	cacheMgr := cachemock.NewMockCacheManager(ctrl)
 	cacheMgr.EXPECT().OpenResourceCache(gomock.Any()).Return(nil).AnyTimes()
+	cacheMgr.EXPECT().User().Return(nil).AnyTimes()
 
 	_, _, err := auth.TestInitialize(context.Background(), &auth.Config{
 		Option: map[string]interface{}{},
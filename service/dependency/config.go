@@ -0,0 +1,73 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+package dependency
+
+import (
+	"errors"
+	"time"
+
+	"github.com/mitchellh/mapstructure"
+)
+
+const (
+	// maxBucketCount 统计窗口内最多的时间桶数量
+	maxBucketCount = 1440
+)
+
+// Config 服务依赖关系追踪配置
+type Config struct {
+	// Open 是否开启服务依赖关系追踪
+	Open bool `mapstructure:"open"`
+	// BucketSize 依赖关系聚合的时间桶大小
+	BucketSize time.Duration `mapstructure:"bucketSize"`
+	// Retention 依赖关系的保留时长，超过保留时长的时间桶被丢弃
+	Retention time.Duration `mapstructure:"retention"`
+}
+
+func defaultConfig() *Config {
+	return &Config{
+		Open:       true,
+		BucketSize: time.Minute,
+		Retention:  time.Hour,
+	}
+}
+
+// ParseConfig 解析服务依赖关系追踪配置，未配置时使用默认配置
+func ParseConfig(opt map[string]interface{}) (*Config, error) {
+	config := defaultConfig()
+	if opt == nil {
+		return config, nil
+	}
+	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		DecodeHook: mapstructure.StringToTimeDurationHookFunc(),
+		Result:     config,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := decoder.Decode(opt); err != nil {
+		log.Errorf("[Dependency] parse config(%+v) err: %s", opt, err.Error())
+		return nil, err
+	}
+	if config.BucketSize <= 0 || config.Retention < config.BucketSize {
+		return nil, errors.New("dependency retention must be not less than bucket size")
+	}
+	if config.Retention/config.BucketSize > maxBucketCount {
+		return nil, errors.New("dependency retention contains too many buckets")
+	}
+	return config, nil
+}
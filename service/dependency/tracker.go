@@ -0,0 +1,263 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+package dependency
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	cachetypes "github.com/polarismesh/polaris/cache/api"
+	"github.com/polarismesh/polaris/common/eventhub"
+	"github.com/polarismesh/polaris/common/model"
+)
+
+// Tracker 服务依赖关系追踪
+// 客户端发现某个服务的实例时，如果客户端所在的 host 上注册了其他服务的实例，则认为这些服务依赖被发现的服务
+// 客户端上报的 host 与连接的地址不一致时（例如经过了 NAT），以客户端上报的 host 为准
+type Tracker struct {
+	bucketSize time.Duration
+	retention  time.Duration
+
+	lock sync.Mutex
+	// buckets 按照时间桶聚合的依赖关系，环形复用
+	buckets []*bucket
+	// hosts host -> 实例 ID -> 实例所属的服务
+	hosts map[string]map[string]model.ServiceKey
+	// instanceHosts 实例 ID -> 实例所在的 host
+	instanceHosts map[string]string
+	// clients 客户端连接的地址 -> 客户端上报的 host
+	clients map[string]*clientHost
+}
+
+type edge struct {
+	caller model.ServiceKey
+	callee model.ServiceKey
+}
+
+type edgeStat struct {
+	calls    uint64
+	lastSeen time.Time
+}
+
+type bucket struct {
+	index int64
+	edges map[edge]*edgeStat
+}
+
+type clientHost struct {
+	host     string
+	lastSeen time.Time
+}
+
+// NewTracker 创建服务依赖关系追踪
+func NewTracker(cfg *Config) *Tracker {
+	count := int((cfg.Retention + cfg.BucketSize - 1) / cfg.BucketSize)
+	return &Tracker{
+		bucketSize:    cfg.BucketSize,
+		retention:     cfg.Retention,
+		buckets:       make([]*bucket, count),
+		hosts:         map[string]map[string]model.ServiceKey{},
+		instanceHosts: map[string]string{},
+		clients:       map[string]*clientHost{},
+	}
+}
+
+// Start 订阅实例缓存的变化事件，并且加载缓存中已有的实例
+func (t *Tracker) Start(instances cachetypes.InstanceCache) (*eventhub.SubscribtionContext, error) {
+	subCtx, err := eventhub.SubscribeWithFunc(eventhub.CacheInstanceEventTopic, t.handleInstanceEvent)
+	if err != nil {
+		return nil, err
+	}
+	_ = instances.IteratorInstances(func(_ string, ins *model.Instance) (bool, error) {
+		t.addInstance(ins)
+		return true, nil
+	})
+	return subCtx, nil
+}
+
+func (t *Tracker) handleInstanceEvent(_ context.Context, arg any) error {
+	event, ok := arg.(*eventhub.CacheInstanceEvent)
+	if !ok || event.Instance == nil {
+		return nil
+	}
+	if event.EventType == eventhub.EventDeleted {
+		t.removeInstance(event.Instance.ID())
+		return nil
+	}
+	t.addInstance(event.Instance)
+	return nil
+}
+
+func (t *Tracker) addInstance(ins *model.Instance) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.removeInstanceLocked(ins.ID())
+	host := ins.Host()
+	if _, ok := t.hosts[host]; !ok {
+		t.hosts[host] = map[string]model.ServiceKey{}
+	}
+	t.hosts[host][ins.ID()] = model.ServiceKey{Namespace: ins.Namespace(), Name: ins.Service()}
+	t.instanceHosts[ins.ID()] = host
+}
+
+func (t *Tracker) removeInstance(id string) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.removeInstanceLocked(id)
+}
+
+func (t *Tracker) removeInstanceLocked(id string) {
+	host, ok := t.instanceHosts[id]
+	if !ok {
+		return
+	}
+	delete(t.instanceHosts, id)
+	delete(t.hosts[host], id)
+	if len(t.hosts[host]) == 0 {
+		delete(t.hosts, host)
+	}
+}
+
+// ReportClient 记录客户端连接的地址与客户端上报的 host 之间的对应关系
+func (t *Tracker) ReportClient(clientIP, host string, now time.Time) {
+	if clientIP == "" || host == "" || clientIP == host {
+		return
+	}
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.clients[clientIP] = &clientHost{host: host, lastSeen: now}
+}
+
+// RecordDiscover 记录来自 clientIP 的客户端发现了 callee 的实例
+func (t *Tracker) RecordDiscover(clientIP string, callee model.ServiceKey, now time.Time) {
+	if clientIP == "" {
+		return
+	}
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	b := t.bucketLocked(now)
+	host := clientIP
+	if client, ok := t.clients[clientIP]; ok {
+		host = client.host
+	}
+	callers := t.hosts[host]
+	// 同一个 host 上有多个实例属于同一个服务时，只记录一次
+	recorded := make(map[model.ServiceKey]struct{}, len(callers))
+	for _, caller := range callers {
+		if _, ok := recorded[caller]; ok || caller == callee {
+			continue
+		}
+		recorded[caller] = struct{}{}
+		key := edge{caller: caller, callee: callee}
+		stat, ok := b.edges[key]
+		if !ok {
+			stat = &edgeStat{}
+			b.edges[key] = stat
+		}
+		stat.calls++
+		stat.lastSeen = now
+	}
+}
+
+// bucketLocked 获取 now 所在的时间桶，时间桶过期时重新创建, 调用方需要持有锁
+func (t *Tracker) bucketLocked(now time.Time) *bucket {
+	index := now.UnixNano() / int64(t.bucketSize)
+	slot := index % int64(len(t.buckets))
+	if b := t.buckets[slot]; b != nil && b.index == index {
+		return b
+	}
+	b := &bucket{index: index, edges: map[edge]*edgeStat{}}
+	t.buckets[slot] = b
+	// 切换时间桶时顺便清理长时间没有上报的客户端
+	for addr, client := range t.clients {
+		if now.Sub(client.lastSeen) > t.retention {
+			delete(t.clients, addr)
+		}
+	}
+	return b
+}
+
+// Dependencies 获取服务在统计窗口内的上游以及下游依赖，统计窗口不能超过保留时长
+func (t *Tracker) Dependencies(svc model.ServiceKey, window time.Duration, now time.Time) (
+	callers []*model.ServiceDependency, callees []*model.ServiceDependency) {
+	if window <= 0 || window > t.retention {
+		window = t.retention
+	}
+	current := now.UnixNano() / int64(t.bucketSize)
+	oldest := current - int64((window+t.bucketSize-1)/t.bucketSize)
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	dependencies := map[edge]*model.ServiceDependency{}
+	for _, b := range t.buckets {
+		if b == nil || b.index <= oldest || b.index > current {
+			continue
+		}
+		for key, stat := range b.edges {
+			if key.caller != svc && key.callee != svc {
+				continue
+			}
+			item, ok := dependencies[key]
+			if !ok {
+				item = &model.ServiceDependency{
+					CallerNamespace: key.caller.Namespace,
+					CallerService:   key.caller.Name,
+					CalleeNamespace: key.callee.Namespace,
+					CalleeService:   key.callee.Name,
+				}
+				dependencies[key] = item
+			}
+			item.Calls += stat.calls
+			if stat.lastSeen.After(item.LastSeen) {
+				item.LastSeen = stat.lastSeen
+			}
+			item.Buckets = append(item.Buckets, &model.DependencyBucket{
+				Start: time.Unix(0, b.index*int64(t.bucketSize)),
+				Calls: stat.calls,
+			})
+		}
+	}
+
+	for key, item := range dependencies {
+		sort.Slice(item.Buckets, func(i, j int) bool {
+			return item.Buckets[i].Start.Before(item.Buckets[j].Start)
+		})
+		if key.callee == svc {
+			callers = append(callers, item)
+		} else {
+			callees = append(callees, item)
+		}
+	}
+	sortDependencies(callers)
+	sortDependencies(callees)
+	return callers, callees
+}
+
+// sortDependencies 按照发现次数从多到少排序，次数相同时按照服务名排序
+func sortDependencies(items []*model.ServiceDependency) {
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].Calls != items[j].Calls {
+			return items[i].Calls > items[j].Calls
+		}
+		if items[i].CallerNamespace+items[i].CallerService != items[j].CallerNamespace+items[j].CallerService {
+			return items[i].CallerNamespace+items[i].CallerService < items[j].CallerNamespace+items[j].CallerService
+		}
+		return items[i].CalleeNamespace+items[i].CalleeService < items[j].CalleeNamespace+items[j].CalleeService
+	})
+}
@@ -0,0 +1,128 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+package dependency
+
+import (
+	"testing"
+	"time"
+
+	apiservice "github.com/polarismesh/specification/source/go/api/v1/service_manage"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/polarismesh/polaris/common/eventhub"
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/common/utils"
+)
+
+func newTestInstance(id, host, namespace, service string) *model.Instance {
+	return &model.Instance{Proto: &apiservice.Instance{
+		Id:        utils.NewStringValue(id),
+		Host:      utils.NewStringValue(host),
+		Namespace: utils.NewStringValue(namespace),
+		Service:   utils.NewStringValue(service),
+	}}
+}
+
+func TestParseConfig(t *testing.T) {
+	cfg, err := ParseConfig(nil)
+	assert.NoError(t, err)
+	assert.True(t, cfg.Open)
+	assert.Equal(t, time.Minute, cfg.BucketSize)
+	assert.Equal(t, time.Hour, cfg.Retention)
+
+	cfg, err = ParseConfig(map[string]interface{}{"bucketSize": "10s", "retention": "5m"})
+	assert.NoError(t, err)
+	assert.Equal(t, 10*time.Second, cfg.BucketSize)
+	assert.Equal(t, 5*time.Minute, cfg.Retention)
+
+	_, err = ParseConfig(map[string]interface{}{"bucketSize": "10m", "retention": "5m"})
+	assert.Error(t, err)
+	_, err = ParseConfig(map[string]interface{}{"bucketSize": "1s", "retention": "24h"})
+	assert.Error(t, err)
+}
+
+func TestTracker_Dependencies(t *testing.T) {
+	tracker := NewTracker(&Config{BucketSize: time.Minute, Retention: 10 * time.Minute})
+	order := model.ServiceKey{Namespace: "default", Name: "order"}
+	payment := model.ServiceKey{Namespace: "default", Name: "payment"}
+	stock := model.ServiceKey{Namespace: "default", Name: "stock"}
+
+	tracker.addInstance(newTestInstance("order-1", "10.0.0.1", "default", "order"))
+	tracker.addInstance(newTestInstance("order-2", "10.0.0.1", "default", "order"))
+	tracker.addInstance(newTestInstance("stock-1", "10.0.0.2", "default", "stock"))
+
+	now := time.Unix(1700000000, 0)
+	// 同一个 host 上的多个实例属于同一个服务，只记录一次
+	tracker.RecordDiscover("10.0.0.1", payment, now)
+	tracker.RecordDiscover("10.0.0.1", payment, now.Add(time.Minute))
+	tracker.RecordDiscover("10.0.0.1", stock, now.Add(time.Minute))
+	// 没有注册实例的 host 无法识别调用方
+	tracker.RecordDiscover("10.0.0.9", payment, now)
+	// 服务发现自身不产生依赖关系
+	tracker.RecordDiscover("10.0.0.2", stock, now)
+
+	callers, callees := tracker.Dependencies(payment, 0, now.Add(time.Minute))
+	assert.Empty(t, callees)
+	assert.Equal(t, 1, len(callers))
+	assert.Equal(t, "order", callers[0].CallerService)
+	assert.Equal(t, uint64(2), callers[0].Calls)
+	assert.Equal(t, 2, len(callers[0].Buckets))
+	assert.True(t, callers[0].Buckets[0].Start.Before(callers[0].Buckets[1].Start))
+	assert.Equal(t, now.Add(time.Minute), callers[0].LastSeen)
+
+	callers, callees = tracker.Dependencies(order, 0, now.Add(time.Minute))
+	assert.Empty(t, callers)
+	assert.Equal(t, 2, len(callees))
+	assert.Equal(t, "payment", callees[0].CalleeService)
+	assert.Equal(t, "stock", callees[1].CalleeService)
+
+	// 统计窗口只包含最近的时间桶
+	callers, _ = tracker.Dependencies(payment, time.Minute, now.Add(time.Minute))
+	assert.Equal(t, uint64(1), callers[0].Calls)
+
+	// 超过保留时长的时间桶被丢弃
+	callers, _ = tracker.Dependencies(payment, 0, now.Add(20*time.Minute))
+	assert.Empty(t, callers)
+}
+
+func TestTracker_ReportClient(t *testing.T) {
+	tracker := NewTracker(&Config{BucketSize: time.Minute, Retention: 10 * time.Minute})
+	payment := model.ServiceKey{Namespace: "default", Name: "payment"}
+	tracker.addInstance(newTestInstance("order-1", "10.0.0.1", "default", "order"))
+
+	now := time.Unix(1700000000, 0)
+	// 经过 NAT 之后连接地址与客户端上报的 host 不一致
+	tracker.ReportClient("192.168.0.1", "10.0.0.1", now)
+	tracker.RecordDiscover("192.168.0.1", payment, now)
+
+	callers, _ := tracker.Dependencies(payment, 0, now)
+	assert.Equal(t, 1, len(callers))
+	assert.Equal(t, "order", callers[0].CallerService)
+
+	// 实例删除后不再识别为调用方
+	assert.NoError(t, tracker.handleInstanceEvent(nil, &eventhub.CacheInstanceEvent{
+		Instance:  newTestInstance("order-1", "10.0.0.1", "default", "order"),
+		EventType: eventhub.EventDeleted,
+	}))
+	tracker.RecordDiscover("192.168.0.1", payment, now.Add(time.Minute))
+	callers, _ = tracker.Dependencies(payment, 0, now.Add(time.Minute))
+	assert.Equal(t, uint64(1), callers[0].Calls)
+
+	// 长时间没有上报的客户端被清理
+	tracker.RecordDiscover("192.168.0.1", payment, now.Add(time.Hour))
+	assert.Empty(t, tracker.clients)
+}
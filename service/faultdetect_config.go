@@ -140,6 +140,7 @@ func faultDetectRuleRecordEntry(ctx context.Context, req *apifault.FaultDetectRu
 		Namespace:     req.GetNamespace(),
 		OperationType: opt,
 		Operator:      utils.ParseOperator(ctx),
+		ChangeSetID:   utils.ParseChangeSetID(ctx),
 		Detail:        detail,
 		HappenTime:    time.Now(),
 	}
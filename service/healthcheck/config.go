@@ -36,6 +36,15 @@ type Config struct {
 	ClientCheckTtl      time.Duration          `yaml:"clientCheckTtl"`
 	Checkers            []plugin.ConfigEntry   `yaml:"checkers"`
 	Batch               map[string]interface{} `yaml:"batch"`
+	// Probe 主动探测的配置
+	Probe ProbeConfig `yaml:"probe"`
+}
+
+// ProbeConfig 主动探测的配置，服务通过元数据定义探测策略
+type ProbeConfig struct {
+	Open bool `yaml:"open"`
+	// Concurrency 单个节点同时进行的探测数
+	Concurrency int `yaml:"concurrency"`
 }
 
 const (
@@ -44,6 +53,7 @@ const (
 	defaultSlotNum             = 30
 	defaultClientReportTtl     = 120 * time.Second
 	defaultClientCheckInterval = 120 * time.Second
+	defaultProbeConcurrency    = 64
 )
 
 func (c *Config) IsOpen() bool {
@@ -80,4 +90,7 @@ func (c *Config) SetDefault() {
 	if c.ClientCheckTtl == 0 {
 		c.ClientCheckTtl = defaultClientReportTtl
 	}
+	if c.Probe.Concurrency <= 0 {
+		c.Probe.Concurrency = defaultProbeConcurrency
+	}
 }
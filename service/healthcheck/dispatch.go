@@ -141,10 +141,22 @@ func (d *Dispatcher) reloadSelfContinuum() bool {
 		d.noAvailableServers = false
 	}
 	d.selfServiceBuckets = nextBuckets
+	d.mutex.Lock()
 	d.continuum = commonhash.New(d.selfServiceBuckets)
+	d.mutex.Unlock()
 	return true
 }
 
+// isResponsible 对象是否分配给了本节点进行健康检查
+func (d *Dispatcher) isResponsible(hashValue uint) bool {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	if d.continuum == nil {
+		return false
+	}
+	return d.continuum.Hash(hashValue) == d.svr.localHost
+}
+
 func (d *Dispatcher) reloadManagedClients() {
 	nextClients := make(map[string]*ClientWithChecker)
 
@@ -23,6 +23,7 @@ import (
 
 	"github.com/polarismesh/polaris/cache"
 	"github.com/polarismesh/polaris/common/eventhub"
+	"github.com/polarismesh/polaris/common/readiness"
 	"github.com/polarismesh/polaris/plugin"
 	"github.com/polarismesh/polaris/service/batch"
 	"github.com/polarismesh/polaris/store"
@@ -115,6 +116,14 @@ func withDispatcher(ctx context.Context) serverOption {
 	}
 }
 
+// withProbeScheduler .
+func withProbeScheduler(concurrency int) serverOption {
+	return func(svr *Server) error {
+		svr.probeScheduler = newProbeScheduler(svr, concurrency)
+		return nil
+	}
+}
+
 // WithTimeAdjuster .
 func WithTimeAdjuster(adjuster *TimeAdjuster) serverOption {
 	return func(svr *Server) error {
@@ -175,6 +184,8 @@ func withSubscriber(ctx context.Context) serverOption {
 		if err := svr.storage.StartLeaderElection(store.ElectionKeySelfServiceChecker); err != nil {
 			return err
 		}
+		readiness.Register(readiness.ComponentLeader(store.ElectionKeySelfServiceChecker),
+			readiness.LeaderElected(svr.storage, store.ElectionKeySelfServiceChecker))
 		return nil
 	}
 }
@@ -0,0 +1,286 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+package healthcheck
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"reflect"
+	"strconv"
+	"sync"
+	"time"
+
+	apimodel "github.com/polarismesh/specification/source/go/api/v1/model"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+
+	commonhash "github.com/polarismesh/polaris/common/hash"
+	"github.com/polarismesh/polaris/common/model"
+)
+
+const (
+	// probeTickInterval 检查到期探测任务的间隔
+	probeTickInterval = time.Second
+	// probeReloadInterval 根据服务的探测策略以及实例分配结果重新加载探测任务的间隔
+	probeReloadInterval = eventInterval
+)
+
+// probeFunc 探测一个实例的端口，返回 nil 表示探测成功
+type probeFunc func(ctx context.Context, policy *model.ProbePolicy, address string) error
+
+var (
+	probers = map[string]probeFunc{
+		model.ProbeTypeHTTP:  probeHTTP,
+		model.ProbeTypeHTTPS: probeHTTP,
+		model.ProbeTypeTCP:   probeTCP,
+		model.ProbeTypeGRPC:  probeGRPC,
+	}
+
+	// probeHTTPClient 与 Kubernetes 的 HTTP 探针保持一致，不校验证书，不跟随重定向
+	probeHTTPClient = &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig:   &tls.Config{InsecureSkipVerify: true}, //nolint:gosec
+			DisableKeepAlives: true,
+		},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+)
+
+// probeHTTP 返回 2xx、3xx 状态码视为探测成功
+func probeHTTP(ctx context.Context, policy *model.ProbePolicy, address string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, policy.Type+"://"+address+policy.Path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("User-Agent", "polaris-probe")
+	rsp, err := probeHTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	_ = rsp.Body.Close()
+	if rsp.StatusCode < http.StatusOK || rsp.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("http probe status code %d", rsp.StatusCode)
+	}
+	return nil
+}
+
+// probeTCP 能够建立连接视为探测成功
+func probeTCP(ctx context.Context, _ *model.ProbePolicy, address string) error {
+	dialer := &net.Dialer{}
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+// probeGRPC 通过 grpc.health.v1.Health 检查，返回 SERVING 视为探测成功
+func probeGRPC(ctx context.Context, policy *model.ProbePolicy, address string) error {
+	conn, err := grpc.DialContext(ctx, address, grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithBlock())
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	rsp, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{Service: policy.GRPCService})
+	if err != nil {
+		return err
+	}
+	if rsp.GetStatus() != healthpb.HealthCheckResponse_SERVING {
+		return fmt.Errorf("grpc probe status %s", rsp.GetStatus())
+	}
+	return nil
+}
+
+// probeTarget 一个实例的探测任务
+type probeTarget struct {
+	instanceID string
+	address    string
+	policy     *model.ProbePolicy
+	nextProbe  time.Time
+	running    bool
+	successes  int
+	failures   int
+}
+
+// record 记录一次探测结果，连续成功、失败的次数达到阈值时返回实例应当具有的健康状态
+func (t *probeTarget) record(success bool) (bool, bool) {
+	if success {
+		t.failures = 0
+		t.successes++
+		return true, t.successes >= t.policy.HealthyThreshold
+	}
+	t.successes = 0
+	t.failures++
+	return false, t.failures >= t.policy.UnhealthyThreshold
+}
+
+// ProbeScheduler 主动探测调度，按照服务元数据中定义的探测策略定期探测实例的端口，并根据探测结果修改实例的健康状态
+// 1. 只探测没有开启心跳健康检查的实例，避免与心跳上报的结果冲突
+// 2. 实例与心跳检查一样通过一致性哈希分配到健康检查节点，每个实例只由一个节点探测
+type ProbeScheduler struct {
+	svr   *Server
+	probe probeFunc
+	// sem 限制同时进行的探测数
+	sem chan struct{}
+
+	lock    sync.Mutex
+	targets map[string]*probeTarget
+}
+
+func newProbeScheduler(svr *Server, concurrency int) *ProbeScheduler {
+	return &ProbeScheduler{
+		svr: svr,
+		probe: func(ctx context.Context, policy *model.ProbePolicy, address string) error {
+			return probers[policy.Type](ctx, policy, address)
+		},
+		sem:     make(chan struct{}, concurrency),
+		targets: map[string]*probeTarget{},
+	}
+}
+
+func (p *ProbeScheduler) run(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(probeTickInterval)
+		defer ticker.Stop()
+		var lastReload time.Time
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				if now.Sub(lastReload) >= probeReloadInterval {
+					p.reload(now)
+					lastReload = now
+				}
+				p.dispatch(ctx, now)
+			}
+		}
+	}()
+}
+
+// reload 重新加载分配到本节点的探测任务，探测策略以及地址没有变化的任务保留连续成功、失败的次数
+func (p *ProbeScheduler) reload(now time.Time) {
+	if p.svr.serviceCache == nil || p.svr.instanceCache == nil {
+		return
+	}
+	next := map[string]*probeTarget{}
+	p.lock.Lock()
+	origin := p.targets
+	p.lock.Unlock()
+	_ = p.svr.serviceCache.IteratorServices(func(_ string, svc *model.Service) (bool, error) {
+		if svc.IsAlias() {
+			return true, nil
+		}
+		policy, err := model.ParseProbePolicy(svc.Meta)
+		if err != nil || policy == nil {
+			return true, nil
+		}
+		for _, ins := range p.svr.instanceCache.GetInstancesByServiceID(svc.ID) {
+			if ins.EnableHealthCheck() {
+				continue
+			}
+			hashValue := commonhash.HashString(ins.ID())
+			if !p.svr.dispatcher.isResponsible(hashValue) {
+				continue
+			}
+			port := ins.Port()
+			if policy.Port != 0 {
+				port = policy.Port
+			}
+			address := net.JoinHostPort(ins.Host(), strconv.FormatUint(uint64(port), 10))
+			if target, ok := origin[ins.ID()]; ok && target.address == address &&
+				reflect.DeepEqual(target.policy, policy) {
+				next[ins.ID()] = target
+				continue
+			}
+			next[ins.ID()] = &probeTarget{
+				instanceID: ins.ID(),
+				address:    address,
+				policy:     policy,
+				// 按照实例打散第一次探测的时间，避免同一时刻集中探测
+				nextProbe: now.Add(time.Duration(hashValue % uint(policy.Interval))),
+			}
+		}
+		return true, nil
+	})
+	p.lock.Lock()
+	p.targets = next
+	p.lock.Unlock()
+	log.Debugf("[Health Check][Probe]count %d instances has been dispatched to %s", len(next), p.svr.localHost)
+}
+
+// dispatch 执行已经到期的探测任务，同时进行的探测数达到上限时推迟到下一次检查
+func (p *ProbeScheduler) dispatch(ctx context.Context, now time.Time) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	for _, target := range p.targets {
+		if target.running || now.Before(target.nextProbe) {
+			continue
+		}
+		select {
+		case p.sem <- struct{}{}:
+		default:
+			return
+		}
+		target.running = true
+		target.nextProbe = now.Add(target.policy.Interval)
+		go p.doProbe(ctx, target)
+	}
+}
+
+func (p *ProbeScheduler) doProbe(ctx context.Context, target *probeTarget) {
+	defer func() {
+		<-p.sem
+	}()
+	probeCtx, cancel := context.WithTimeout(ctx, target.policy.Timeout)
+	err := p.probe(probeCtx, target.policy, target.address)
+	cancel()
+	if err != nil && errors.Is(ctx.Err(), context.Canceled) {
+		return
+	}
+
+	p.lock.Lock()
+	target.running = false
+	healthy, reached := target.record(err == nil)
+	// 任务已经被重新加载或者不再由本节点负责
+	current := p.targets[target.instanceID] == target
+	p.lock.Unlock()
+	if !reached || !current {
+		return
+	}
+	ins := p.svr.instanceCache.GetInstance(target.instanceID)
+	if ins == nil || ins.Healthy() == healthy {
+		return
+	}
+	if err != nil {
+		log.Info("[Health Check][Probe] probe failed", zap.String("id", target.instanceID),
+			zap.String("address", target.address), zap.Error(err))
+	}
+	if code := setInsDbStatus(p.svr, ins, healthy, time.Now().Unix()); code != apimodel.Code_ExecuteSuccess {
+		log.Error("[Health Check][Probe] set instance health status", zap.String("id", target.instanceID),
+			zap.Bool("healthy", healthy), zap.Uint32("code", uint32(code)))
+	}
+}
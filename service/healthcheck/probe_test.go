@@ -0,0 +1,152 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package healthcheck
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+
+	"github.com/polarismesh/polaris/common/model"
+)
+
+func probeContext(t *testing.T) context.Context {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	t.Cleanup(cancel)
+	return ctx
+}
+
+func Test_probeHTTP(t *testing.T) {
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/healthz":
+			w.WriteHeader(http.StatusOK)
+		case "/redirect":
+			http.Redirect(w, r, "/notfound", http.StatusFound)
+		default:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer svr.Close()
+	address := strings.TrimPrefix(svr.URL, "http://")
+
+	policy := &model.ProbePolicy{Type: model.ProbeTypeHTTP, Path: "/healthz"}
+	assert.NoError(t, probeHTTP(probeContext(t), policy, address))
+	// 不跟随重定向，3xx 视为成功
+	policy.Path = "/redirect"
+	assert.NoError(t, probeHTTP(probeContext(t), policy, address))
+	policy.Path = "/unavailable"
+	assert.Error(t, probeHTTP(probeContext(t), policy, address))
+}
+
+func Test_probeTCP(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	address := ln.Addr().String()
+	policy := &model.ProbePolicy{Type: model.ProbeTypeTCP}
+	assert.NoError(t, probeTCP(probeContext(t), policy, address))
+	_ = ln.Close()
+	assert.Error(t, probeTCP(probeContext(t), policy, address))
+}
+
+func Test_probeGRPC(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	svr := grpc.NewServer()
+	healthSvr := health.NewServer()
+	healthpb.RegisterHealthServer(svr, healthSvr)
+	go func() {
+		_ = svr.Serve(ln)
+	}()
+	defer svr.Stop()
+
+	policy := &model.ProbePolicy{Type: model.ProbeTypeGRPC}
+	assert.NoError(t, probeGRPC(probeContext(t), policy, ln.Addr().String()))
+
+	policy.GRPCService = "echo"
+	healthSvr.SetServingStatus("echo", healthpb.HealthCheckResponse_NOT_SERVING)
+	assert.Error(t, probeGRPC(probeContext(t), policy, ln.Addr().String()))
+	healthSvr.SetServingStatus("echo", healthpb.HealthCheckResponse_SERVING)
+	assert.NoError(t, probeGRPC(probeContext(t), policy, ln.Addr().String()))
+}
+
+func Test_probeTargetRecord(t *testing.T) {
+	target := &probeTarget{policy: &model.ProbePolicy{HealthyThreshold: 2, UnhealthyThreshold: 3}}
+
+	healthy, reached := target.record(false)
+	assert.False(t, healthy)
+	assert.False(t, reached)
+	_, reached = target.record(false)
+	assert.False(t, reached)
+	healthy, reached = target.record(false)
+	assert.False(t, healthy)
+	assert.True(t, reached)
+
+	// 探测成功后重新计数
+	healthy, reached = target.record(true)
+	assert.True(t, healthy)
+	assert.False(t, reached)
+	healthy, reached = target.record(true)
+	assert.True(t, healthy)
+	assert.True(t, reached)
+	_, reached = target.record(false)
+	assert.False(t, reached)
+}
+
+func TestProbeScheduler_dispatch(t *testing.T) {
+	scheduler := newProbeScheduler(&Server{}, 1)
+	probed := make(chan string, 10)
+	block := make(chan struct{})
+	scheduler.probe = func(ctx context.Context, policy *model.ProbePolicy, address string) error {
+		probed <- address
+		<-block
+		return nil
+	}
+	policy := &model.ProbePolicy{Interval: time.Second, Timeout: time.Second, HealthyThreshold: 3}
+	now := time.Now()
+	scheduler.targets = map[string]*probeTarget{
+		"ins-1": {instanceID: "ins-1", address: "127.0.0.1:8080", policy: policy, nextProbe: now},
+		"ins-2": {instanceID: "ins-2", address: "127.0.0.2:8080", policy: policy, nextProbe: now},
+		"ins-3": {instanceID: "ins-3", address: "127.0.0.3:8080", policy: policy, nextProbe: now.Add(time.Hour)},
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// 同时进行的探测数达到上限时推迟到下一次检查
+	scheduler.dispatch(ctx, now)
+	first := <-probed
+	scheduler.dispatch(ctx, now)
+	assert.Len(t, probed, 0)
+
+	close(block)
+	assert.Eventually(t, func() bool {
+		scheduler.dispatch(ctx, now)
+		return len(probed) == 1
+	}, 3*time.Second, 10*time.Millisecond)
+	second := <-probed
+	assert.ElementsMatch(t, []string{"127.0.0.1:8080", "127.0.0.2:8080"}, []string{first, second})
+}
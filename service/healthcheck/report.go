@@ -48,6 +48,9 @@ func checkHeartbeatInstance(req *apiservice.Instance) (string, *apiservice.Respo
 
 const max404Count = 3
 
+// MaxBatchHeartbeatSize 单次批量上报心跳的最大实例数
+const MaxBatchHeartbeatSize = 500
+
 func (s *Server) checkInstanceExists(ctx context.Context, id string) (int64, *model.Instance, apimodel.Code) {
 	ins := s.instanceCache.GetInstance(id)
 	if ins != nil {
@@ -136,6 +139,23 @@ func (s *Server) doReports(ctx context.Context, beats []*apiservice.InstanceHear
 	return api.NewResponse(apimodel.Code_ExecuteSuccess)
 }
 
+func (s *Server) doBatchReport(ctx context.Context, reqs []*apiservice.Instance) *apiservice.BatchWriteResponse {
+	if len(reqs) == 0 {
+		return api.NewBatchWriteResponse(apimodel.Code_EmptyRequest)
+	}
+	if len(reqs) > MaxBatchHeartbeatSize {
+		return api.NewBatchWriteResponse(apimodel.Code_BatchSizeOverLimit)
+	}
+	if !s.hcOpt.IsOpen() || len(s.checkers) == 0 {
+		return api.NewBatchWriteResponse(apimodel.Code_HealthCheckNotOpen)
+	}
+	responses := api.NewBatchWriteResponse(apimodel.Code_ExecuteSuccess)
+	for i := range reqs {
+		api.Collect(responses, s.doReport(ctx, reqs[i]))
+	}
+	return api.FormatBatchWriteResponse(responses)
+}
+
 func (s *Server) baseReport(ctx context.Context, id string, reportReq *plugin.ReportRequest) (apimodel.Code, error) {
 	count, ins, code := s.checkInstanceExists(ctx, id)
 	checker := s.getHealthChecker(id)
@@ -54,6 +54,7 @@ type Server struct {
 	timeAdjuster   *TimeAdjuster
 	dispatcher     *Dispatcher
 	checkScheduler *CheckScheduler
+	probeScheduler *ProbeScheduler
 	history        plugin.History
 	discoverEvent  plugin.DiscoverChannel
 	localHost      string
@@ -78,6 +79,7 @@ func NewHealthServer(ctx context.Context, hcOpt *Config, options ...serverOption
 		withCheckScheduler(newCheckScheduler(ctx, hcOpt.SlotNum, hcOpt.MinCheckInterval,
 			hcOpt.MaxCheckInterval, hcOpt.ClientCheckInterval, hcOpt.ClientCheckTtl)),
 		withDispatcher(ctx),
+		withProbeScheduler(hcOpt.Probe.Concurrency),
 		// 这个必须保证在最后一个 option
 		withSubscriber(ctx),
 	)
@@ -139,6 +141,9 @@ func (s *Server) run(ctx context.Context) error {
 	s.checkScheduler.run(ctx)
 	s.timeAdjuster.doTimeAdjust(ctx)
 	s.dispatcher.startDispatchingJob(ctx)
+	if s.hcOpt.Probe.Open {
+		s.probeScheduler.run(ctx)
+	}
 	return nil
 }
 
@@ -157,6 +162,11 @@ func (s *Server) Reports(ctx context.Context, req []*apiservice.InstanceHeartbea
 	return s.doReports(ctx, req)
 }
 
+// BatchReport batch report heartbeat request, each instance gets its own response
+func (s *Server) BatchReport(ctx context.Context, reqs []*apiservice.Instance) *apiservice.BatchWriteResponse {
+	return s.doBatchReport(ctx, reqs)
+}
+
 // ReportByClient report heartbeat request by client
 func (s *Server) ReportByClient(ctx context.Context, req *apiservice.Client) *apiservice.Response {
 	return s.doReportByClient(ctx, req)
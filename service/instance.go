@@ -37,6 +37,7 @@ import (
 	"github.com/polarismesh/polaris/common/model"
 	commonstore "github.com/polarismesh/polaris/common/store"
 	commontime "github.com/polarismesh/polaris/common/time"
+	"github.com/polarismesh/polaris/common/trace"
 	"github.com/polarismesh/polaris/common/utils"
 	"github.com/polarismesh/polaris/plugin"
 )
@@ -142,6 +143,134 @@ func (s *Server) CreateInstance(ctx context.Context, req *apiservice.Instance) *
 	return api.NewInstanceResponse(apimodel.Code_ExecuteSuccess, out)
 }
 
+// pendingInstance 批量创建中校验通过，等待写入存储的实例
+type pendingInstance struct {
+	index int
+	svcId string
+	ins   *apiservice.Instance
+}
+
+// batchCreateInstances 批量创建实例，校验通过的实例在同一个存储事务中写入，校验失败的实例单独返回错误
+func (s *Server) batchCreateInstances(ctx context.Context, reqs []*apiservice.Instance) *apiservice.BatchWriteResponse {
+	rid := utils.ParseRequestID(ctx)
+	pid := utils.ParsePlatformID(ctx)
+	start := time.Now()
+
+	responses := make([]*apiservice.Response, len(reqs))
+	pendings := make(map[string]*pendingInstance, len(reqs))
+	svcIds := make(map[string]string)
+	for i, req := range reqs {
+		instanceID, checkError := checkCreateInstance(req)
+		if checkError != nil {
+			responses[i] = checkError
+			continue
+		}
+		if _, ok := pendings[instanceID]; ok {
+			responses[i] = api.NewInstanceResponse(apimodel.Code_SameInstanceRequest, req)
+			continue
+		}
+		if ok := s.allowInstanceAccess(instanceID); !ok {
+			log.Error("batch create instance not allowed to access: exceed ratelimit",
+				utils.ZapRequestID(rid), utils.ZapPlatformID(pid), utils.ZapInstanceID(instanceID))
+			responses[i] = api.NewInstanceResponse(apimodel.Code_InstanceTooManyRequests, req)
+			continue
+		}
+		svcKey := req.GetNamespace().GetValue() + "/" + req.GetService().GetValue()
+		svcId, ok := svcIds[svcKey]
+		if !ok {
+			var errResp *apiservice.Response
+			if svcId, errResp = s.createWrapServiceIfAbsent(ctx, req); errResp != nil {
+				log.Errorf("[Instance] create service if absent fail : %+v, req : %+v", errResp.String(), req)
+				responses[i] = errResp
+				continue
+			}
+			svcIds[svcKey] = svcId
+		}
+
+		// Prevent pollution api.Instance struct, copy and fill token
+		ins := *req
+		ins.ServiceToken = utils.NewStringValue(parseInstanceReqToken(ctx, req))
+		ins.Id = utils.NewStringValue(instanceID)
+		s.packCmdb(&ins)
+		pendings[instanceID] = &pendingInstance{index: i, svcId: svcId, ins: &ins}
+	}
+
+	if len(pendings) > 0 {
+		s.doBatchCreateInstances(ctx, reqs, pendings, responses)
+		log.Info("batch create instances", utils.ZapRequestID(rid), utils.ZapPlatformID(pid),
+			zap.Int("count", len(pendings)), zap.Duration("cost", time.Since(start)))
+	}
+
+	batchResp := api.NewBatchWriteResponse(apimodel.Code_ExecuteSuccess)
+	for i := range responses {
+		api.Collect(batchResp, responses[i])
+	}
+	return api.FormatBatchWriteResponse(batchResp)
+}
+
+// doBatchCreateInstances 保留已有实例的隔离状态后，在同一个存储事务中写入全部实例
+func (s *Server) doBatchCreateInstances(ctx context.Context, reqs []*apiservice.Instance,
+	pendings map[string]*pendingInstance, responses []*apiservice.Response) {
+	ids := make(map[string]bool, len(pendings))
+	for id := range pendings {
+		ids[id] = false
+	}
+	id2Isolate, err := s.storage.BatchGetInstanceIsolate(ids)
+	if err != nil {
+		log.Error("[Instance] batch get instance isolate from store", utils.RequestID(ctx), zap.Error(err))
+		for _, item := range pendings {
+			responses[item.index] = api.NewInstanceResponse(commonstore.StoreCode2APICode(err), reqs[item.index])
+		}
+		return
+	}
+
+	instances := make([]*model.Instance, 0, len(pendings))
+	for id, item := range pendings {
+		// 如果存在，则替换实例的属性数据，但是需要保留用户设置的隔离状态，以免出现关键状态丢失
+		if isolate, ok := id2Isolate[id]; ok && item.ins.Isolate == nil {
+			item.ins.Isolate = utils.NewBoolValue(isolate)
+		}
+		instances = append(instances, model.CreateInstanceModel(item.svcId, item.ins))
+	}
+	if err := trace.WithSpan(ctx, "store.BatchAddInstances", func() error {
+		return s.storage.BatchAddInstances(instances)
+	}); err != nil {
+		log.Error("[Instance] batch add instances", utils.RequestID(ctx), zap.Error(err))
+		for _, item := range pendings {
+			responses[item.index] = wrapperInstanceStoreResponse(reqs[item.index], err)
+		}
+		return
+	}
+
+	for _, data := range instances {
+		req := reqs[pendings[data.ID()].index]
+		svc := &model.Service{
+			Name:      req.GetService().GetValue(),
+			Namespace: req.GetNamespace().GetValue(),
+		}
+		event := &model.InstanceEvent{
+			Id:         data.ID(),
+			Namespace:  svc.Namespace,
+			Service:    svc.Name,
+			Instance:   data.Proto,
+			EType:      model.EventInstanceOnline,
+			CreateTime: time.Time{},
+		}
+		event.InjectMetadata(ctx)
+		s.sendDiscoverEvent(*event)
+		s.RecordHistory(ctx, instanceRecordEntry(ctx, req, svc, data, model.OCreate))
+		responses[pendings[data.ID()].index] = api.NewInstanceResponse(apimodel.Code_ExecuteSuccess,
+			&apiservice.Instance{
+				Id:        utils.NewStringValue(data.ID()),
+				Service:   utils.NewStringValue(svc.Name),
+				Namespace: utils.NewStringValue(svc.Namespace),
+				VpcId:     data.Proto.GetVpcId(),
+				Host:      data.Proto.GetHost(),
+				Port:      data.Proto.GetPort(),
+			})
+	}
+}
+
 // createInstance store operate
 func (s *Server) createInstance(ctx context.Context, req *apiservice.Instance, ins *apiservice.Instance) (
 	*model.Instance, *apiservice.Response) {
@@ -173,6 +302,8 @@ func (s *Server) asyncCreateInstance(
 	ctx context.Context, svcId string, req *apiservice.Instance, ins *apiservice.Instance) (
 	*model.Instance, *apiservice.Response) {
 	allowAsyncRegis, _ := ctx.Value(utils.ContextOpenAsyncRegis).(bool)
+	_, span := trace.StartSpan(ctx, "store.AsyncCreateInstance", trace.SpanKindInternal)
+	defer span.End()
 	future := s.bc.AsyncCreateInstance(svcId, ins, !allowAsyncRegis)
 
 	if err := future.Wait(); err != nil {
@@ -191,6 +322,8 @@ func (s *Server) asyncCreateInstance(
 func (s *Server) serialCreateInstance(
 	ctx context.Context, svcId string, req *apiservice.Instance, ins *apiservice.Instance) (
 	*model.Instance, *apiservice.Response) {
+	_, span := trace.StartSpan(ctx, "store.CreateInstance", trace.SpanKindInternal)
+	defer span.End()
 	rid := utils.ParseRequestID(ctx)
 	pid := utils.ParsePlatformID(ctx)
 
@@ -281,7 +414,9 @@ func (s *Server) serialDeleteInstance(
 	}
 
 	// 存储层操作
-	if err := s.storage.DeleteInstance(instance.ID()); err != nil {
+	if err := trace.WithSpan(ctx, "store.DeleteInstance", func() error {
+		return s.storage.DeleteInstance(instance.ID())
+	}); err != nil {
 		log.Error(err.Error(), utils.ZapRequestID(rid), utils.ZapPlatformID(pid))
 		return wrapperInstanceStoreResponse(req, err)
 	}
@@ -378,7 +513,9 @@ func (s *Server) DeleteInstanceByHost(ctx context.Context, req *apiservice.Insta
 		ids = append(ids, instance.ID())
 	}
 
-	if err := s.storage.BatchDeleteInstances(ids); err != nil {
+	if err := trace.WithSpan(ctx, "store.BatchDeleteInstances", func() error {
+		return s.storage.BatchDeleteInstances(ids)
+	}); err != nil {
 		log.Error(err.Error(), utils.ZapRequestID(requestID), utils.ZapPlatformID(platformID))
 		return wrapperInstanceStoreResponse(req, err)
 	}
@@ -432,7 +569,9 @@ func (s *Server) UpdateInstance(ctx context.Context, req *apiservice.Instance) *
 			utils.ZapRequestID(requestID), utils.ZapPlatformID(platformID), zap.String("instance", req.String()))
 		return api.NewInstanceResponse(apimodel.Code_NoNeedUpdate, req)
 	}
-	if err := s.storage.UpdateInstance(instance); err != nil {
+	if err := trace.WithSpan(ctx, "store.UpdateInstance", func() error {
+		return s.storage.UpdateInstance(instance)
+	}); err != nil {
 		log.Error(err.Error(), utils.ZapRequestID(requestID), utils.ZapPlatformID(platformID))
 		return wrapperInstanceStoreResponse(req, err)
 	}
@@ -521,7 +660,9 @@ func (s *Server) UpdateInstanceIsolate(ctx context.Context, req *apiservice.Inst
 		ids = append(ids, instance.ID())
 	}
 
-	if err := s.storage.BatchSetInstanceIsolate(ids, isolate, utils.NewUUID()); err != nil {
+	if err := trace.WithSpan(ctx, "store.BatchSetInstanceIsolate", func() error {
+		return s.storage.BatchSetInstanceIsolate(ids, isolate, utils.NewUUID())
+	}); err != nil {
 		log.Error(err.Error(), utils.ZapRequestID(requestID), utils.ZapPlatformID(platformID))
 		return wrapperInstanceStoreResponse(req, err)
 	}
@@ -556,6 +697,73 @@ func (s *Server) UpdateInstanceIsolate(ctx context.Context, req *apiservice.Inst
 	return api.NewInstanceResponse(apimodel.Code_ExecuteSuccess, req)
 }
 
+// DrainInstances 批量将服务实例置为优雅下线状态
+// 下线期间服务发现仍然返回实例但权重为 0，保证已经缓存了实例的客户端处理完存量请求，ttl 到期后实例被自动删除
+func (s *Server) DrainInstances(ctx context.Context, req []*apiservice.Instance,
+	ttl time.Duration) *apiservice.BatchWriteResponse {
+	if checkError := checkBatchInstance(req); checkError != nil {
+		return checkError
+	}
+
+	return batchOperateInstances(ctx, req, func(ctx context.Context, req *apiservice.Instance) *apiservice.Response {
+		return s.DrainInstance(ctx, req, ttl)
+	})
+}
+
+// DrainInstance 将单个服务实例置为优雅下线状态，已经处于下线状态的实例刷新下线的截止时间
+func (s *Server) DrainInstance(ctx context.Context, req *apiservice.Instance, ttl time.Duration) *apiservice.Response {
+	if ttl <= 0 || ttl > MaxDrainingTTL {
+		return api.NewInstanceRespWithError(apimodel.Code_InvalidParameter,
+			fmt.Errorf("draining ttl must be in (0, %s]", MaxDrainingTTL), req)
+	}
+	service, instance, preErr := s.execInstancePreStep(ctx, req)
+	if preErr != nil {
+		return preErr
+	}
+
+	requestID := utils.ParseRequestID(ctx)
+	platformID := utils.ParsePlatformID(ctx)
+	deadline := time.Now().Add(ttl)
+
+	instance.MallocProto()
+	insProto := instance.Proto
+	// 不修改缓存中的 metadata
+	metadata := make(map[string]string, len(insProto.GetMetadata())+1)
+	for k, v := range insProto.GetMetadata() {
+		metadata[k] = v
+	}
+	metadata[model.MetadataInstanceDrainingDeadline] = model.FormatDrainingDeadline(deadline)
+	insProto.Metadata = metadata
+	insProto.Revision = utils.NewStringValue(utils.NewUUID())
+	if err := trace.WithSpan(ctx, "store.UpdateInstance", func() error {
+		return s.storage.UpdateInstance(instance)
+	}); err != nil {
+		log.Error(err.Error(), utils.ZapRequestID(requestID), utils.ZapPlatformID(platformID))
+		return wrapperInstanceStoreResponse(req, err)
+	}
+
+	msg := fmt.Sprintf("drain instance: id=%v, namespace=%v, service=%v, host=%v, port=%v, deadline=%v",
+		instance.ID(), service.Namespace, service.Name, instance.Host(), instance.Port(), deadline)
+	log.Info(msg, utils.ZapRequestID(requestID), utils.ZapPlatformID(platformID))
+	s.RecordHistory(ctx, instanceRecordEntry(ctx, req, service, instance, model.ODrain))
+
+	event := &model.InstanceEvent{
+		Id:         instance.ID(),
+		Namespace:  service.Namespace,
+		Service:    service.Name,
+		Instance:   instance.Proto,
+		EType:      model.EventInstanceUpdate,
+		CreateTime: time.Time{},
+	}
+	event.InjectMetadata(ctx)
+	s.sendDiscoverEvent(*event)
+
+	for i := range s.instanceChains {
+		s.instanceChains[i].AfterUpdate(ctx, instance)
+	}
+	return api.NewInstanceResponse(apimodel.Code_ExecuteSuccess, req)
+}
+
 /**
  * @brief 根据ip隔离和删除服务实例的参数检查
  */
@@ -613,6 +821,7 @@ func (s *Server) updateInstanceAttribute(
 	needUpdate := false
 	insProto := instance.Proto
 	var updateEvents = make(map[model.InstanceEventType]bool)
+	keepDrainingDeadline(req, instance)
 	if ok := utils.IsNotEqualMap(req.GetMetadata(), instance.Metadata()); ok {
 		insProto.Metadata = req.GetMetadata()
 		needUpdate = true
@@ -688,6 +897,23 @@ func (s *Server) updateInstanceAttribute(
 	return needUpdate, updateEvents
 }
 
+// keepDrainingDeadline 修改实例的 metadata 时保留优雅下线的状态，处于下线状态的实例只能被删除
+func keepDrainingDeadline(req *apiservice.Instance, instance *model.Instance) {
+	deadline, ok := instance.Metadata()[model.MetadataInstanceDrainingDeadline]
+	if !ok || req.GetMetadata() == nil {
+		return
+	}
+	if _, exist := req.GetMetadata()[model.MetadataInstanceDrainingDeadline]; exist {
+		return
+	}
+	metadata := make(map[string]string, len(req.GetMetadata())+1)
+	for k, v := range req.GetMetadata() {
+		metadata[k] = v
+	}
+	metadata[model.MetadataInstanceDrainingDeadline] = deadline
+	req.Metadata = metadata
+}
+
 func instanceLocationNeedUpdate(req *apimodel.Location, old *apimodel.Location) bool {
 	if req.GetRegion().GetValue() != old.GetRegion().GetValue() {
 		return true
@@ -771,7 +997,9 @@ func (s *Server) GetInstances(ctx context.Context, query map[string]string) *api
 		return api.NewBatchQueryResponse(apimodel.Code_InvalidParameter)
 	}
 
+	_, span := trace.StartSpan(ctx, "cache.QueryInstances", trace.SpanKindInternal)
 	total, instances, err := s.Cache().Instance().QueryInstances(filters, metaFilter, offset, limit)
+	span.End()
 	if err != nil {
 		log.Errorf("[Server][Instances][Query] instances store err: %s", err.Error())
 		return api.NewBatchQueryResponse(commonstore.StoreCode2APICode(err))
@@ -1339,6 +1567,7 @@ func instanceRecordEntry(ctx context.Context, req *apiservice.Instance, service
 		Namespace:     service.Namespace,
 		OperationType: opt,
 		Operator:      utils.ParseOperator(ctx),
+		ChangeSetID:   utils.ParseChangeSetID(ctx),
 		Detail:        datail,
 		HappenTime:    time.Now(),
 	}
@@ -1368,6 +1597,9 @@ func CheckDbInstanceFieldLen(req *apiservice.Instance) (*apiservice.Response, bo
 	if err := utils.CheckDbMetaDataFieldLen(req.GetMetadata()); err != nil {
 		return api.NewInstanceResponse(apimodel.Code_InvalidMetadata, req), true
 	}
+	if err := model.CheckNamedPorts(req.GetMetadata()); err != nil {
+		return api.NewInstanceRespWithError(apimodel.Code_InvalidMetadata, err, req), true
+	}
 	if req.GetPort().GetValue() > 65535 {
 		return api.NewInstanceResponse(apimodel.Code_InvalidInstancePort, req), true
 	}
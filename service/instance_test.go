@@ -1274,6 +1274,74 @@ func TestUpdateInstance(t *testing.T) {
 	})
 }
 
+// 测试实例优雅下线
+func TestDrainInstances(t *testing.T) {
+	discoverSuit := &DiscoverTestSuit{}
+	if err := discoverSuit.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+	defer discoverSuit.Destroy()
+
+	_, serviceResp := discoverSuit.createCommonService(t, 112)
+	defer discoverSuit.cleanServiceName(serviceResp.GetName().GetValue(), serviceResp.GetNamespace().GetValue())
+	_, instanceResp := discoverSuit.createCommonInstance(t, serviceResp, 112)
+	defer discoverSuit.cleanInstance(instanceResp.GetId().GetValue())
+
+	drainReq := &apiservice.Instance{
+		ServiceToken: utils.NewStringValue(serviceResp.GetToken().GetValue()),
+		Id:           instanceResp.GetId(),
+	}
+	t.Run("下线时间不合法", func(t *testing.T) {
+		for _, ttl := range []time.Duration{0, 2 * time.Hour} {
+			resp := discoverSuit.DiscoverServer().DrainInstances(discoverSuit.DefaultCtx,
+				[]*apiservice.Instance{drainReq}, ttl)
+			assert.Equal(t, uint32(apimodel.Code_InvalidParameter), resp.GetResponses()[0].GetCode().GetValue())
+		}
+	})
+	t.Run("下线期间实例权重为0", func(t *testing.T) {
+		resp := discoverSuit.DiscoverServer().DrainInstances(discoverSuit.DefaultCtx,
+			[]*apiservice.Instance{drainReq}, time.Minute)
+		if !respSuccess(resp) {
+			t.Fatalf("error: %s", resp.GetInfo().GetValue())
+		}
+		_ = discoverSuit.DiscoverServer().Cache().TestUpdate()
+
+		ins := discoverSuit.DiscoverServer().Cache().Instance().GetInstance(instanceResp.GetId().GetValue())
+		assert.NotNil(t, ins)
+		assert.True(t, ins.IsDraining())
+		assert.False(t, ins.IsDrained(time.Now()))
+		assert.NotZero(t, ins.Weight())
+
+		out := discoverSuit.DiscoverServer().ServiceInstancesCache(discoverSuit.DefaultCtx,
+			&apiservice.DiscoverFilter{}, &apiservice.Service{
+				Name:      serviceResp.GetName(),
+				Namespace: serviceResp.GetNamespace(),
+			})
+		if !respSuccess(out) {
+			t.Fatalf("error: %s", out.GetInfo().GetValue())
+		}
+		assert.Equal(t, 1, len(out.GetInstances()))
+		assert.Equal(t, uint32(0), out.GetInstances()[0].GetWeight().GetValue())
+	})
+	t.Run("修改实例metadata保留下线状态", func(t *testing.T) {
+		updateReq := &apiservice.Instance{
+			ServiceToken: utils.NewStringValue(serviceResp.GetToken().GetValue()),
+			Id:           instanceResp.GetId(),
+			Metadata:     map[string]string{"key": "value"},
+		}
+		resp := discoverSuit.DiscoverServer().UpdateInstances(discoverSuit.DefaultCtx,
+			[]*apiservice.Instance{updateReq})
+		if !respSuccess(resp) {
+			t.Fatalf("error: %s", resp.GetInfo().GetValue())
+		}
+		_ = discoverSuit.DiscoverServer().Cache().TestUpdate()
+
+		ins := discoverSuit.DiscoverServer().Cache().Instance().GetInstance(instanceResp.GetId().GetValue())
+		assert.Equal(t, "value", ins.Metadata()["key"])
+		assert.True(t, ins.IsDraining())
+	})
+}
+
 /**
  * @brief 根据ip修改隔离状态
  */
@@ -2625,3 +2693,21 @@ func Test_OperateInstanceMetadata(t *testing.T) {
 	})
 
 }
+
+// TestCheckInstanceNamedPorts 测试实例命名端口的校验
+func TestCheckInstanceNamedPorts(t *testing.T) {
+	req := &apiservice.Instance{
+		Service:   utils.NewStringValue("test-svc"),
+		Namespace: utils.NewStringValue("default"),
+		Host:      utils.NewStringValue("127.0.0.1"),
+		Port:      utils.NewUInt32Value(8080),
+		Metadata:  map[string]string{model.MetadataInstanceNamedPorts: "http:8080,grpc:9090/GRPC"},
+	}
+	_, notOk := service.CheckDbInstanceFieldLen(req)
+	assert.False(t, notOk)
+
+	req.Metadata[model.MetadataInstanceNamedPorts] = "http:8080,http:9090"
+	resp, notOk := service.CheckDbInstanceFieldLen(req)
+	assert.True(t, notOk)
+	assert.Equal(t, uint32(apimodel.Code_InvalidMetadata), resp.GetCode().GetValue())
+}
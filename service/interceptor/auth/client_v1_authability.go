@@ -45,6 +45,22 @@ func (svr *ServerAuthAbility) RegisterInstance(ctx context.Context, req *apiserv
 	return svr.nextSvr.RegisterInstance(ctx, req)
 }
 
+// BatchRegisterInstance create instances
+func (svr *ServerAuthAbility) BatchRegisterInstance(ctx context.Context,
+	reqs []*apiservice.Instance) *apiservice.BatchWriteResponse {
+	authCtx := svr.collectClientInstanceAuthContext(ctx, reqs, model.Create, "BatchRegisterInstance")
+
+	_, err := svr.policyMgr.GetAuthChecker().CheckClientPermission(authCtx)
+	if err != nil {
+		return api.NewBatchWriteResponseWithMsg(convertToErrCode(err), err.Error())
+	}
+
+	ctx = authCtx.GetRequestContext()
+	ctx = context.WithValue(ctx, utils.ContextAuthContextKey, authCtx)
+
+	return svr.nextSvr.BatchRegisterInstance(ctx, reqs)
+}
+
 // DeregisterInstance delete onr instance
 func (svr *ServerAuthAbility) DeregisterInstance(ctx context.Context, req *apiservice.Instance) *apiservice.Response {
 	authCtx := svr.collectClientInstanceAuthContext(
@@ -19,6 +19,7 @@ package service_auth
 
 import (
 	"context"
+	"time"
 
 	apimodel "github.com/polarismesh/specification/source/go/api/v1/model"
 	apiservice "github.com/polarismesh/specification/source/go/api/v1/service_manage"
@@ -118,6 +119,22 @@ func (svr *ServerAuthAbility) UpdateInstancesIsolate(ctx context.Context,
 	return svr.nextSvr.UpdateInstancesIsolate(ctx, reqs)
 }
 
+// DrainInstances drain instances
+func (svr *ServerAuthAbility) DrainInstances(ctx context.Context,
+	reqs []*apiservice.Instance, ttl time.Duration) *apiservice.BatchWriteResponse {
+	authCtx := svr.collectInstanceAuthContext(ctx, reqs, model.Modify, "DrainInstances")
+
+	_, err := svr.policyMgr.GetAuthChecker().CheckConsolePermission(authCtx)
+	if err != nil {
+		return api.NewBatchWriteResponseWithMsg(convertToErrCode(err), err.Error())
+	}
+
+	ctx = authCtx.GetRequestContext()
+	ctx = context.WithValue(ctx, utils.ContextAuthContextKey, authCtx)
+
+	return svr.nextSvr.DrainInstances(ctx, reqs, ttl)
+}
+
 // GetInstances get instances
 func (svr *ServerAuthAbility) GetInstances(ctx context.Context,
 	query map[string]string) *apiservice.BatchQueryResponse {
@@ -30,6 +30,7 @@ import (
 
 	"github.com/polarismesh/polaris/auth"
 	"github.com/polarismesh/polaris/cache"
+	api "github.com/polarismesh/polaris/common/api/v1"
 	"github.com/polarismesh/polaris/common/model"
 	"github.com/polarismesh/polaris/common/utils"
 	"github.com/polarismesh/polaris/service"
@@ -484,5 +485,8 @@ func convertToErrCode(err error) apimodel.Code {
 	if errors.Is(err, model.ErrorTokenDisabled) {
 		return apimodel.Code_TokenDisabled
 	}
+	if errors.Is(err, model.ErrorTokenExpired) {
+		return apimodel.Code(api.TokenExpired)
+	}
 	return apimodel.Code_NotAllowedAccess
 }
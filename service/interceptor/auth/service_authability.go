@@ -193,3 +193,23 @@ func (svr *ServerAuthAbility) GetServiceOwner(
 	ctx = context.WithValue(ctx, utils.ContextAuthContextKey, authCtx)
 	return svr.nextSvr.GetServiceOwner(ctx, req)
 }
+
+// GetServiceDependencies 获取服务的上下游依赖关系
+func (svr *ServerAuthAbility) GetServiceDependencies(
+	ctx context.Context, query map[string]string) *model.ServiceDependencies {
+	authCtx := svr.collectServiceAuthContext(ctx, []*apiservice.Service{
+		{
+			Name:      utils.NewStringValue(query["service"]),
+			Namespace: utils.NewStringValue(query["namespace"]),
+		},
+	}, model.Read, "GetServiceDependencies")
+
+	if _, err := svr.policyMgr.GetAuthChecker().CheckConsolePermission(authCtx); err != nil {
+		ret := &model.ServiceDependencies{Namespace: query["namespace"], Service: query["service"]}
+		return ret.WithCode(uint32(convertToErrCode(err)), err.Error())
+	}
+
+	ctx = authCtx.GetRequestContext()
+	ctx = context.WithValue(ctx, utils.ContextAuthContextKey, authCtx)
+	return svr.nextSvr.GetServiceDependencies(ctx, query)
+}
@@ -19,6 +19,7 @@ package paramcheck
 
 import (
 	"context"
+	"time"
 
 	"github.com/polarismesh/specification/source/go/api/v1/fault_tolerance"
 	"github.com/polarismesh/specification/source/go/api/v1/service_manage"
@@ -310,6 +311,12 @@ func (svr *Server) GetServiceOwner(ctx context.Context,
 	return svr.nextSvr.GetServiceOwner(ctx, req)
 }
 
+// GetServiceDependencies implements service.DiscoverServer.
+func (svr *Server) GetServiceDependencies(ctx context.Context,
+	query map[string]string) *model.ServiceDependencies {
+	return svr.nextSvr.GetServiceDependencies(ctx, query)
+}
+
 // GetServiceToken implements service.DiscoverServer.
 func (svr *Server) GetServiceToken(ctx context.Context, req *service_manage.Service) *service_manage.Response {
 	return svr.nextSvr.GetServiceToken(ctx, req)
@@ -375,6 +382,12 @@ func (svr *Server) UpdateInstancesIsolate(ctx context.Context, req []*service_ma
 	return svr.nextSvr.UpdateInstancesIsolate(ctx, req)
 }
 
+// DrainInstances implements service.DiscoverServer.
+func (svr *Server) DrainInstances(ctx context.Context, req []*service_manage.Instance,
+	ttl time.Duration) *service_manage.BatchWriteResponse {
+	return svr.nextSvr.DrainInstances(ctx, req, ttl)
+}
+
 // UpdateRateLimits implements service.DiscoverServer.
 func (svr *Server) UpdateRateLimits(ctx context.Context, request []*traffic_manage.Rule) *service_manage.BatchWriteResponse {
 	return svr.nextSvr.UpdateRateLimits(ctx, request)
@@ -34,6 +34,12 @@ func (s *Server) RegisterInstance(ctx context.Context, req *apiservice.Instance)
 	return s.nextSvr.RegisterInstance(ctx, req)
 }
 
+// BatchRegisterInstance create instances by client
+func (s *Server) BatchRegisterInstance(ctx context.Context,
+	reqs []*apiservice.Instance) *apiservice.BatchWriteResponse {
+	return s.nextSvr.BatchRegisterInstance(ctx, reqs)
+}
+
 // DeregisterInstance delete onr instance by client
 func (s *Server) DeregisterInstance(ctx context.Context, req *apiservice.Instance) *apiservice.Response {
 	return s.nextSvr.DeregisterInstance(ctx, req)
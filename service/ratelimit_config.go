@@ -571,6 +571,7 @@ func rateLimitRecordEntry(ctx context.Context, req *apitraffic.Rule, md *model.R
 		ResourceName:  fmt.Sprintf("%s(%s)", md.Name, md.ID),
 		Namespace:     req.GetNamespace().GetValue(),
 		Operator:      utils.ParseOperator(ctx),
+		ChangeSetID:   utils.ParseChangeSetID(ctx),
 		OperationType: opt,
 		Detail:        detail,
 		HappenTime:    time.Now(),
@@ -387,6 +387,7 @@ func routingRecordEntry(ctx context.Context, req *apitraffic.Routing, svc *model
 		Namespace:     svc.Namespace,
 		OperationType: opt,
 		Operator:      utils.ParseOperator(ctx),
+		ChangeSetID:   utils.ParseChangeSetID(ctx),
 		Detail:        detail,
 		HappenTime:    time.Now(),
 	}
@@ -407,6 +408,7 @@ func routingV2RecordEntry(ctx context.Context, req *apitraffic.RouteRule, md *mo
 		Namespace:     req.GetNamespace(),
 		OperationType: opt,
 		Operator:      utils.ParseOperator(ctx),
+		ChangeSetID:   utils.ParseChangeSetID(ctx),
 		Detail:        detail,
 		HappenTime:    time.Now(),
 	}
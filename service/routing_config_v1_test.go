@@ -24,7 +24,6 @@ import (
 	"testing"
 
 	"github.com/golang/protobuf/ptypes"
-	"github.com/polarismesh/specification/source/go/api/v1/model"
 	apimodel "github.com/polarismesh/specification/source/go/api/v1/model"
 	apiservice "github.com/polarismesh/specification/source/go/api/v1/service_manage"
 	apitraffic "github.com/polarismesh/specification/source/go/api/v1/traffic_manage"
@@ -32,6 +31,7 @@ import (
 	"google.golang.org/protobuf/types/known/wrapperspb"
 
 	api "github.com/polarismesh/polaris/common/api/v1"
+	"github.com/polarismesh/polaris/common/model"
 	"github.com/polarismesh/polaris/common/utils"
 	testsuit "github.com/polarismesh/polaris/test/suit"
 )
@@ -425,7 +425,7 @@ func TestGetRoutingConfigWithCache(t *testing.T) {
 		if secondResp.GetService().GetRevision().GetValue() != serviceResp.GetRevision().GetValue() {
 			t.Fatalf("error")
 		}
-		assert.Equal(t, model.Code(secondResp.GetCode().GetValue()), apimodel.Code_DataNoChange)
+		assert.Equal(t, apimodel.Code(secondResp.GetCode().GetValue()), apimodel.Code_DataNoChange)
 	})
 	t.Run("路由不存在，不会出异常", func(t *testing.T) {
 		discoverSuit := &DiscoverTestSuit{}
@@ -441,6 +441,80 @@ func TestGetRoutingConfigWithCache(t *testing.T) {
 			t.Fatalf("error: %s", resp.GetInfo().GetValue())
 		}
 	})
+	t.Run("路由目标引用了实例分组，返回分组的标签选择器", func(t *testing.T) {
+		discoverSuit := &DiscoverTestSuit{}
+		if err := discoverSuit.Initialize(); err != nil {
+			t.Fatal(err)
+		}
+		defer discoverSuit.Destroy()
+
+		serviceReq := genMainService(11)
+		serviceReq.Metadata[model.MetadataServiceSubsetPrefix+"canary"] = "!version"
+		discoverSuit.cleanServiceName(serviceReq.GetName().GetValue(), serviceReq.GetNamespace().GetValue())
+		resp := discoverSuit.DiscoverServer().CreateServices(discoverSuit.DefaultCtx, []*apiservice.Service{serviceReq})
+		assert.Equal(t, apimodel.Code_InvalidMetadata, apimodel.Code(resp.GetCode().GetValue()))
+
+		serviceReq.Metadata[model.MetadataServiceSubsetPrefix+"canary"] = "version in (v2,v3)"
+		resp = discoverSuit.DiscoverServer().CreateServices(discoverSuit.DefaultCtx, []*apiservice.Service{serviceReq})
+		if !respSuccess(resp) {
+			t.Fatalf("error: %s", resp.GetInfo().GetValue())
+		}
+		serviceResp := resp.Responses[0].GetService()
+		defer discoverSuit.cleanServiceName(serviceResp.GetName().GetValue(), serviceResp.GetNamespace().GetValue())
+
+		routing := &apitraffic.Routing{
+			Service:      serviceResp.GetName(),
+			Namespace:    serviceResp.GetNamespace(),
+			ServiceToken: serviceResp.GetToken(),
+			Inbounds: []*apitraffic.Route{{
+				Sources: []*apitraffic.Source{{
+					Service:   utils.NewStringValue("*"),
+					Namespace: utils.NewStringValue("*"),
+				}},
+				Destinations: []*apitraffic.Destination{{
+					Service:   serviceResp.GetName(),
+					Namespace: serviceResp.GetNamespace(),
+					Metadata: map[string]*apimodel.MatchString{
+						model.SubsetLabelKey: {Value: utils.NewStringValue("canary")},
+					},
+					Weight: utils.NewUInt32Value(100),
+				}},
+			}},
+		}
+		routingResp := discoverSuit.DiscoverServer().CreateRoutingConfigs(discoverSuit.DefaultCtx,
+			[]*apitraffic.Routing{routing})
+		if !respSuccess(routingResp) {
+			t.Fatalf("error: %+v", routingResp)
+		}
+		defer discoverSuit.cleanCommonRoutingConfig(serviceResp.GetName().GetValue(),
+			serviceResp.GetNamespace().GetValue())
+
+		_ = discoverSuit.DiscoverServer().Cache().TestUpdate()
+		out := discoverSuit.DiscoverServer().GetRoutingConfigWithCache(discoverSuit.DefaultCtx, serviceResp)
+		if !respSuccess(out) {
+			t.Fatalf("error: %s", out.GetInfo().GetValue())
+		}
+		assert.Len(t, out.GetRouting().GetInbounds(), 1)
+		metadata := out.GetRouting().GetInbounds()[0].GetDestinations()[0].GetMetadata()
+		assert.NotContains(t, metadata, model.SubsetLabelKey)
+		assert.Equal(t, apimodel.MatchString_IN, metadata["version"].GetType())
+		assert.Equal(t, "v2,v3", metadata["version"].GetValue().GetValue())
+
+		// 分组定义变更后，路由规则的 revision 随之变化
+		serviceReq.Metadata[model.MetadataServiceSubsetPrefix+"canary"] = "version=v2"
+		serviceReq.Token = serviceResp.GetToken()
+		updateResp := discoverSuit.DiscoverServer().UpdateServices(discoverSuit.DefaultCtx,
+			[]*apiservice.Service{serviceReq})
+		if !respSuccess(updateResp) {
+			t.Fatalf("error: %s", updateResp.GetInfo().GetValue())
+		}
+		_ = discoverSuit.DiscoverServer().Cache().TestUpdate()
+		serviceResp.Revision = out.GetService().GetRevision()
+		out = discoverSuit.DiscoverServer().GetRoutingConfigWithCache(discoverSuit.DefaultCtx, serviceResp)
+		assert.Equal(t, apimodel.Code_ExecuteSuccess, apimodel.Code(out.GetCode().GetValue()))
+		metadata = out.GetRouting().GetInbounds()[0].GetDestinations()[0].GetMetadata()
+		assert.Equal(t, apimodel.MatchString_EXACT, metadata["version"].GetType())
+	})
 }
 
 // test对routing字段进行校验
@@ -31,6 +31,7 @@ import (
 	"github.com/polarismesh/polaris/namespace"
 	"github.com/polarismesh/polaris/plugin"
 	"github.com/polarismesh/polaris/service/batch"
+	"github.com/polarismesh/polaris/service/dependency"
 	"github.com/polarismesh/polaris/service/healthcheck"
 	"github.com/polarismesh/polaris/store"
 )
@@ -61,6 +62,9 @@ type Server struct {
 
 	// instanceChains 实例信息变化回调
 	instanceChains []InstanceChain
+
+	// dependency 服务依赖关系追踪，未开启时为 nil
+	dependency *dependency.Tracker
 }
 
 func (s *Server) isSupportL5() bool {
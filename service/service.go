@@ -34,6 +34,7 @@ import (
 	"github.com/polarismesh/polaris/common/model"
 	commonstore "github.com/polarismesh/polaris/common/store"
 	commontime "github.com/polarismesh/polaris/common/time"
+	"github.com/polarismesh/polaris/common/trace"
 	"github.com/polarismesh/polaris/common/utils"
 )
 
@@ -49,6 +50,7 @@ var (
 	instanceFilter          = 2 // 过滤实例的
 	serviceMetaFilter       = 3 // 过滤service Metadata的
 	instanceMetaFilter      = 4 // 过滤instance Metadata的
+	serviceLabelFilter      = 5 // 按照标签选择器过滤service Metadata的
 	ServiceFilterAttributes = map[string]int{
 		"name":        serviceFilter,
 		"namespace":   serviceFilter,
@@ -69,6 +71,8 @@ var (
 		"values":                     serviceMetaFilter,
 		"instance_keys":              instanceMetaFilter,
 		"instance_values":            instanceMetaFilter,
+		// 标签选择器，例如 team=payments,env in (prod,pre),tier!=test
+		"label_selector": serviceLabelFilter,
 	}
 )
 
@@ -128,7 +132,9 @@ func (s *Server) CreateService(ctx context.Context, req *apiservice.Service) *ap
 
 	// 存储层操作
 	data := s.createServiceModel(req)
-	if err := s.storage.AddService(data); err != nil {
+	if err := trace.WithSpan(ctx, "store.AddService", func() error {
+		return s.storage.AddService(data)
+	}); err != nil {
 		log.Error("[Service] save service fail",
 			utils.ZapRequestID(requestID), utils.ZapPlatformID(platformID), zap.Error(err))
 		// 如果在存储层发现资源存在错误，则需要再一次从存储层获取响应的信息，填充响应的 svc_id 信息
@@ -160,12 +166,27 @@ func (s *Server) CreateService(ctx context.Context, req *apiservice.Service) *ap
 	}
 
 	if err := s.afterServiceResource(ctx, req, data, false); err != nil {
+		// 鉴权等后置处理失败时，回滚已经创建的服务，保证服务与默认授权要么同时存在，要么同时不存在
+		s.rollbackCreateService(ctx, req, data)
 		return api.NewResponseWithMsg(apimodel.Code_ExecuteException, err.Error())
 	}
 
 	return api.NewServiceResponse(apimodel.Code_ExecuteSuccess, out)
 }
 
+// rollbackCreateService 回滚已创建的服务
+func (s *Server) rollbackCreateService(ctx context.Context, req *apiservice.Service, data *model.Service) {
+	requestID := utils.ParseRequestID(ctx)
+	if err := s.storage.DeleteService(data.ID, data.Name, data.Namespace); err != nil {
+		log.Error("[Service] rollback create service fail", utils.ZapRequestID(requestID),
+			zap.String("namespace", data.Namespace), zap.String("name", data.Name), zap.Error(err))
+		return
+	}
+	log.Info("[Service] rollback create service", utils.ZapRequestID(requestID),
+		zap.String("namespace", data.Namespace), zap.String("name", data.Name))
+	s.RecordHistory(ctx, serviceRecordEntry(ctx, req, nil, model.ODelete))
+}
+
 // DeleteServices 批量删除服务
 func (s *Server) DeleteServices(ctx context.Context, req []*apiservice.Service) *apiservice.BatchWriteResponse {
 	if checkError := checkBatchService(req); checkError != nil {
@@ -212,7 +233,9 @@ func (s *Server) DeleteService(ctx context.Context, req *apiservice.Service) *ap
 		return resp
 	}
 
-	if err := s.storage.DeleteService(service.ID, serviceName, namespaceName); err != nil {
+	if err := trace.WithSpan(ctx, "store.DeleteService", func() error {
+		return s.storage.DeleteService(service.ID, serviceName, namespaceName)
+	}); err != nil {
 		log.Error(err.Error(), utils.ZapRequestID(requestID), utils.ZapPlatformID(platformID))
 		return wrapperServiceStoreResponse(req, err)
 	}
@@ -281,7 +304,9 @@ func (s *Server) UpdateService(ctx context.Context, req *apiservice.Service) *ap
 	}
 
 	// 存储层操作
-	if err := s.storage.UpdateService(service, needUpdateOwner); err != nil {
+	if err := trace.WithSpan(ctx, "store.UpdateService", func() error {
+		return s.storage.UpdateService(service, needUpdateOwner)
+	}); err != nil {
 		log.Error(err.Error(), utils.ZapRequestID(requestID))
 		return wrapperServiceStoreResponse(req, err)
 	}
@@ -375,6 +400,8 @@ func (s *Server) GetServices(ctx context.Context, query map[string]string) *apis
 	var (
 		metaKeys, metaValues                   string
 		inputInstMetaKeys, inputInstMetaValues string
+		labelSelector                          model.LabelSelector
+		err                                    error
 	)
 	for key, value := range query {
 		typ, ok := ServiceFilterAttributes[key]
@@ -403,6 +430,11 @@ func (s *Server) GetServices(ctx context.Context, query map[string]string) *apis
 			} else {
 				inputInstMetaValues = value
 			}
+		case typ == serviceLabelFilter:
+			if labelSelector, err = model.ParseLabelSelector(value); err != nil {
+				log.Errorf("[Server][Service][Query] label selector(%s) error: %s", value, err.Error())
+				return api.NewBatchQueryResponseWithMsg(apimodel.Code_InvalidParameter, err.Error())
+			}
 		default:
 			instanceFilters[key] = value
 		}
@@ -442,7 +474,13 @@ func (s *Server) GetServices(ctx context.Context, query map[string]string) *apis
 	}
 
 	serviceArgs := parseServiceArgs(serviceFilters, serviceMetas, ctx)
+	if len(labelSelector) > 0 {
+		serviceArgs.LabelSelector = labelSelector
+		serviceArgs.EmptyCondition = false
+	}
+	_, span := trace.StartSpan(ctx, "cache.GetServicesByFilter", trace.SpanKindInternal)
 	total, services, err := s.caches.Service().GetServicesByFilter(serviceArgs, instanceArgs, offset, limit)
+	span.End()
 	if err != nil {
 		log.Errorf("[Server][Service][Query] req(%+v) store err: %s", query, err.Error())
 		return api.NewBatchQueryResponse(commonstore.StoreCode2APICode(err))
@@ -992,6 +1030,13 @@ func checkCreateService(req *apiservice.Service) *apiservice.Response {
 		return api.NewServiceResponse(apimodel.Code_InvalidMetadata, req)
 	}
 
+	if _, err := model.ParseServiceSubsets(req.GetMetadata()); err != nil {
+		return api.NewServiceRespWithError(apimodel.Code_InvalidMetadata, err, req)
+	}
+	if _, err := model.ParseProbePolicy(req.GetMetadata()); err != nil {
+		return api.NewServiceRespWithError(apimodel.Code_InvalidMetadata, err, req)
+	}
+
 	// 检查字段长度是否大于DB中对应字段长
 	err, notOk := CheckDbServiceFieldLen(req)
 	if notOk {
@@ -1015,6 +1060,13 @@ func checkReviseService(req *apiservice.Service) *apiservice.Response {
 		return api.NewServiceResponse(apimodel.Code_InvalidNamespaceName, req)
 	}
 
+	if _, err := model.ParseServiceSubsets(req.GetMetadata()); err != nil {
+		return api.NewServiceRespWithError(apimodel.Code_InvalidMetadata, err, req)
+	}
+	if _, err := model.ParseProbePolicy(req.GetMetadata()); err != nil {
+		return api.NewServiceRespWithError(apimodel.Code_InvalidMetadata, err, req)
+	}
+
 	// 检查字段长度是否大于DB中对应字段长
 	err, notOk := CheckDbServiceFieldLen(req)
 	if notOk {
@@ -1056,6 +1108,7 @@ func serviceRecordEntry(ctx context.Context, req *apiservice.Service, md *model.
 		Namespace:     req.GetNamespace().GetValue(),
 		OperationType: operationType,
 		Operator:      utils.ParseOperator(ctx),
+		ChangeSetID:   utils.ParseChangeSetID(ctx),
 		Detail:        detail,
 		HappenTime:    time.Now(),
 	}
@@ -457,6 +457,7 @@ func serviceContractRecordEntry(ctx context.Context, req *apiservice.ServiceCont
 		Namespace:     req.GetNamespace(),
 		OperationType: operationType,
 		Operator:      utils.ParseOperator(ctx),
+		ChangeSetID:   utils.ParseChangeSetID(ctx),
 		Detail:        detail,
 		HappenTime:    time.Now(),
 	}
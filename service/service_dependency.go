@@ -0,0 +1,95 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+package service
+
+import (
+	"context"
+	"time"
+
+	apimodel "github.com/polarismesh/specification/source/go/api/v1/model"
+
+	api "github.com/polarismesh/polaris/common/api/v1"
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/common/utils"
+	"github.com/polarismesh/polaris/service/dependency"
+)
+
+// initDependencyTracker 初始化服务依赖关系追踪，依赖实例缓存识别调用方所属的服务
+func initDependencyTracker(namingOpt *Config) error {
+	cfg, err := dependency.ParseConfig(namingOpt.Dependency)
+	if err != nil {
+		return err
+	}
+	if !cfg.Open || namingServer.caches == nil {
+		return nil
+	}
+	tracker := dependency.NewTracker(cfg)
+	subCtx, err := tracker.Start(namingServer.caches.Instance())
+	if err != nil {
+		log.Warnf("[Server][Dependency] subscribe instance event err: %v", err)
+		return nil
+	}
+	namingServer.subCtxs = append(namingServer.subCtxs, subCtx)
+	namingServer.dependency = tracker
+	return nil
+}
+
+// recordDependency 记录客户端发现服务实例产生的依赖关系
+func (s *Server) recordDependency(ctx context.Context, callee *model.Service) {
+	if s.dependency == nil {
+		return
+	}
+	s.dependency.RecordDiscover(utils.ParseClientIP(ctx),
+		model.ServiceKey{Namespace: callee.Namespace, Name: callee.Name}, time.Now())
+}
+
+// GetServiceDependencies 查询服务在统计窗口内的上下游依赖关系
+// query参数：service，必填；namespace，默认为 default；window，统计窗口，默认为依赖关系的保留时长
+func (s *Server) GetServiceDependencies(ctx context.Context, query map[string]string) *model.ServiceDependencies {
+	ret := &model.ServiceDependencies{
+		Code:      uint32(apimodel.Code_ExecuteSuccess),
+		Namespace: query["namespace"],
+		Service:   query["service"],
+		Callers:   []*model.ServiceDependency{},
+		Callees:   []*model.ServiceDependency{},
+	}
+	if ret.Namespace == "" {
+		ret.Namespace = DefaultNamespace
+	}
+	if ret.Service == "" {
+		return ret.WithCode(uint32(apimodel.Code_InvalidServiceName), "service is empty")
+	}
+	var window time.Duration
+	if val := query["window"]; val != "" {
+		parsed, err := time.ParseDuration(val)
+		if err != nil || parsed <= 0 {
+			return ret.WithCode(uint32(apimodel.Code_InvalidParameter), "invalid window: "+val)
+		}
+		window = parsed
+	}
+	if s.caches.Service().GetServiceByName(ret.Service, ret.Namespace) == nil {
+		return ret.WithCode(uint32(apimodel.Code_NotFoundService), api.Code2Info(uint32(apimodel.Code_NotFoundService)))
+	}
+	if s.dependency == nil {
+		return ret
+	}
+	callers, callees := s.dependency.Dependencies(
+		model.ServiceKey{Namespace: ret.Namespace, Name: ret.Service}, window, time.Now())
+	ret.Callers = append(ret.Callers, callers...)
+	ret.Callees = append(ret.Callees, callees...)
+	return ret
+}
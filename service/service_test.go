@@ -697,6 +697,38 @@ func TestGetServices4(t *testing.T) {
 			t.Fatalf("error: %d", len(resps.GetServices()))
 		}
 	})
+	t.Run("根据标签选择器可以过滤services", func(t *testing.T) {
+		service1 := genMainService(11)
+		service1.Metadata = map[string]string{"team": "payments", "env": "prod"}
+		service2 := genMainService(12)
+		service2.Metadata = map[string]string{"team": "payments", "env": "test"}
+		service3 := genMainService(13)
+		service3.Metadata = map[string]string{"team": "billing", "env": "prod"}
+		if resp := discoverSuit.DiscoverServer().CreateServices(discoverSuit.DefaultCtx,
+			[]*apiservice.Service{service1, service2, service3}); !respSuccess(resp) {
+			t.Fatalf("error: %+v", resp)
+		}
+		defer discoverSuit.cleanServiceName(service1.GetName().GetValue(), service1.GetNamespace().GetValue())
+		defer discoverSuit.cleanServiceName(service2.GetName().GetValue(), service2.GetNamespace().GetValue())
+		defer discoverSuit.cleanServiceName(service3.GetName().GetValue(), service3.GetNamespace().GetValue())
+
+		cases := map[string]uint32{
+			"team=payments":                  2,
+			"team in (payments, billing)":    3,
+			"team=payments,env!=test":        1,
+			"team notin (payments),env=prod": 1,
+		}
+		for expr, expect := range cases {
+			resps := discoverSuit.DiscoverServer().GetServices(discoverSuit.DefaultCtx,
+				map[string]string{"label_selector": expr, "offset": "0", "limit": "1"})
+			assert.True(t, respSuccess(resps), resps.GetInfo().GetValue())
+			assert.Equal(t, expect, resps.GetAmount().GetValue(), expr)
+			assert.Equal(t, 1, len(resps.GetServices()), expr)
+		}
+		resps := discoverSuit.DiscoverServer().GetServices(discoverSuit.DefaultCtx,
+			map[string]string{"label_selector": "team in (payments"})
+		assert.Equal(t, uint32(apimodel.Code_InvalidParameter), resps.GetCode().GetValue())
+	})
 }
 
 // 联合查询场景
@@ -1464,6 +1496,42 @@ func TestConcurrencyCreateSameService(t *testing.T) {
 		assert.Equal(t, apimodel.Code_ExistedResource, apimodel.Code(resp.GetCode().GetValue()))
 		assert.Equal(t, mockSvcId, resp.GetService().GetId().GetValue())
 	})
+
+	t.Run("创建服务-鉴权后置处理失败-回滚服务", func(t *testing.T) {
+		svr, mockStore := createMockResource()
+		svr.SetResourceHooks(&failResourceHook{})
+
+		mockStore.EXPECT().GetNamespace(gomock.Any()).Return(&model.Namespace{
+			Name: "mock_ns",
+		}, nil).AnyTimes()
+		mockStore.EXPECT().GetService(gomock.Any(), gomock.Any()).Return(nil, nil).AnyTimes()
+
+		var saveSvc *model.Service
+		mockStore.EXPECT().AddService(gomock.Any()).DoAndReturn(func(svc *model.Service) error {
+			saveSvc = svc
+			return nil
+		}).Times(1)
+		mockStore.EXPECT().DeleteService(gomock.Any(), gomock.Any(), gomock.Any()).
+			DoAndReturn(func(id, name, namespace string) error {
+				assert.Equal(t, saveSvc.ID, id)
+				assert.Equal(t, req.GetName().GetValue(), name)
+				assert.Equal(t, req.GetNamespace().GetValue(), namespace)
+				return nil
+			}).Times(1)
+
+		resp := svr.CreateService(context.TODO(), req)
+		assert.Equal(t, apimodel.Code_ExecuteException, apimodel.Code(resp.GetCode().GetValue()))
+	})
+}
+
+// failResourceHook 模拟鉴权阶段的后置处理失败
+type failResourceHook struct{}
+
+func (h *failResourceHook) Before(ctx context.Context, resourceType model.Resource) {
+}
+
+func (h *failResourceHook) After(ctx context.Context, resourceType model.Resource, res *service.ResourceEvent) error {
+	return errors.New("mock auth after resource operation fail")
 }
 
 func Test_ServiceVisible(t *testing.T) {
@@ -86,6 +86,9 @@ func TestInitialize(ctx context.Context, namingOpt *Config, cacheOpt *cache.Conf
 	namingServer.createServiceSingle = &singleflight.Group{}
 	// 插件初始化
 	pluginInitialize()
+	if err := initDependencyTracker(namingOpt); err != nil {
+		return nil, nil, err
+	}
 
 	var proxySvr DiscoverServer
 	var err error
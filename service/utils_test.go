@@ -16,3 +16,50 @@
  */
 
 package service
+
+import (
+	"testing"
+
+	apitraffic "github.com/polarismesh/specification/source/go/api/v1/traffic_manage"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/common/utils"
+)
+
+func Test_aliasRouting(t *testing.T) {
+	aliasFor := &model.Service{Name: "source-svc", Namespace: "ns-a"}
+	out := &apitraffic.Routing{
+		Service:   utils.NewStringValue("source-svc"),
+		Namespace: utils.NewStringValue("ns-a"),
+		Inbounds: []*apitraffic.Route{{
+			Sources: []*apitraffic.Source{{Service: utils.NewStringValue("*"), Namespace: utils.NewStringValue("*")}},
+			Destinations: []*apitraffic.Destination{
+				{Service: utils.NewStringValue("source-svc"), Namespace: utils.NewStringValue("ns-a")},
+			},
+		}},
+		Outbounds: []*apitraffic.Route{{
+			Sources: []*apitraffic.Source{
+				{Service: utils.NewStringValue("source-svc"), Namespace: utils.NewStringValue("ns-a")},
+			},
+			Destinations: []*apitraffic.Destination{
+				{Service: utils.NewStringValue("other-svc"), Namespace: utils.NewStringValue("ns-a")},
+			},
+		}},
+	}
+
+	// 非别名请求原样返回
+	assert.Same(t, out, aliasRouting(out, aliasFor, "source-svc", "ns-a"))
+
+	ret := aliasRouting(out, aliasFor, "alias-svc", "ns-b")
+	assert.Equal(t, "alias-svc", ret.GetService().GetValue())
+	assert.Equal(t, "ns-b", ret.GetNamespace().GetValue())
+	assert.Equal(t, "alias-svc", ret.GetInbounds()[0].GetDestinations()[0].GetService().GetValue())
+	assert.Equal(t, "ns-b", ret.GetInbounds()[0].GetDestinations()[0].GetNamespace().GetValue())
+	assert.Equal(t, "*", ret.GetInbounds()[0].GetSources()[0].GetService().GetValue())
+	assert.Equal(t, "alias-svc", ret.GetOutbounds()[0].GetSources()[0].GetService().GetValue())
+	assert.Equal(t, "other-svc", ret.GetOutbounds()[0].GetDestinations()[0].GetService().GetValue())
+	// 不修改缓存中的数据
+	assert.Equal(t, "source-svc", out.GetService().GetValue())
+	assert.Equal(t, "source-svc", out.GetInbounds()[0].GetDestinations()[0].GetService().GetValue())
+}
@@ -26,6 +26,8 @@ import (
 const (
 	ElectionKeySelfServiceChecker = "polaris.checker"
 	ElectionKeyMaintainJob        = "MaintainJob"
+	ElectionKeyFederation         = "Federation"
+	ElectionKeyKubernetesSync     = "KubernetesSync"
 )
 
 type AdminStore interface {
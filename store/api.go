@@ -49,6 +49,8 @@ type Store interface {
 	AdminStore
 	// GrayStore mgr gray resource
 	GrayStore
+	// HistoryStore operation record store
+	HistoryStore
 }
 
 // NamespaceStore Namespace storage interface
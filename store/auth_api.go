@@ -27,8 +27,13 @@ import (
 type UserStore interface {
 	// AddUser Create a user
 	AddUser(user *model.User) error
-	// UpdateUser Update user
+	// UpdateUser Update user, 不会修改用户的登录失败次数以及锁定状态
 	UpdateUser(user *model.User) error
+	// IncrUserLoginFailures 原子地累加用户连续登录失败的次数，达到 maxFailures 后清零并锁定到 lockedUntil，
+	// 返回本次是否锁定了用户
+	IncrUserLoginFailures(id string, maxFailures int, lockedUntil time.Time) (bool, error)
+	// ResetUserLoginFailures 清空用户连续登录失败的次数以及锁定状态
+	ResetUserLoginFailures(id string) error
 	// DeleteUser delete users
 	DeleteUser(user *model.User) error
 	// GetSubCount Number of getting a child account
@@ -44,6 +49,8 @@ type UserStore interface {
 	// GetUsersForCache Used to refresh user cache
 	// 此方法用于 cache 增量更新，需要注意 mtime 应为数据库时间戳
 	GetUsersForCache(mtime time.Time, firstUpdate bool) ([]*model.User, error)
+	// GetAllUsersTx Get all valid users within the given read transaction
+	GetAllUsersTx(tx Tx) ([]*model.User, error)
 }
 
 // GroupStore User group storage operation interface
@@ -55,6 +62,9 @@ type GroupStore interface {
 	// UpdateGroup Update user group
 	UpdateGroup(group *model.ModifyUserGroup) error
 
+	// UpdateGroupParent Update the parent group of user group, empty parentID means top level group
+	UpdateGroupParent(id, parentID string) error
+
 	// DeleteGroup Delete user group
 	DeleteGroup(group *model.UserGroupDetail) error
 
@@ -70,6 +80,9 @@ type GroupStore interface {
 	// GetUserGroupsForCache Refresh of getting user groups for cache
 	// 此方法用于 cache 增量更新，需要注意 mtime 应为数据库时间戳
 	GetGroupsForCache(mtime time.Time, firstUpdate bool) ([]*model.UserGroupDetail, error)
+
+	// GetAllGroupsTx Get all valid user groups (with members) within the given read transaction
+	GetAllGroupsTx(tx Tx) ([]*model.UserGroupDetail, error)
 }
 
 // StrategyStore Authentication policy related storage operation interface
@@ -78,9 +91,17 @@ type StrategyStore interface {
 	// AddStrategy Create authentication strategy
 	AddStrategy(strategy *model.StrategyDetail) error
 
+	// AddStrategies Create authentication strategies in one transaction
+	AddStrategies(strategies []*model.StrategyDetail) error
+
 	// UpdateStrategy Update authentication strategy
 	UpdateStrategy(strategy *model.ModifyStrategyDetail) error
 
+	// UpdateStrategyEffect Update the effect and priority of authentication strategy
+	UpdateStrategyEffect(id string, effect model.StrategyEffect, priority int32) error
+	// UpdateStrategyFunctions Update the functions that authentication strategy applies to
+	UpdateStrategyFunctions(id string, functions []string) error
+
 	// DeleteStrategy Delete authentication strategy
 	DeleteStrategy(id string) error
 
@@ -108,4 +129,52 @@ type StrategyStore interface {
 	// GetStrategyDetailsForCache Used to refresh policy cache
 	// 此方法用于 cache 增量更新，需要注意 mtime 应为数据库时间戳
 	GetStrategyDetailsForCache(mtime time.Time, firstUpdate bool) ([]*model.StrategyDetail, error)
+
+	// GetAllStrategyDetailsTx Get all valid strategies (with principals and resources) within the given read transaction
+	GetAllStrategyDetailsTx(tx Tx) ([]*model.StrategyDetail, error)
+
+	// RemoveExpiredStrategyResources Remove at most limit resource links which expired before now and return them,
+	//   the expiry condition is checked in the same transaction so that renewed links will not be removed
+	RemoveExpiredStrategyResources(now time.Time, limit uint32) ([]model.StrategyResource, error)
+
+	// UpdateStrategyResourceUsage Update the last used time of resource links without changing the strategy mtime,
+	//   links which not exist will be ignored
+	UpdateStrategyResourceUsage(usages []model.StrategyResourceUsage) error
+
+	// GetStrategyResourceUsage Get the last used time of the resource links of a strategy
+	GetStrategyResourceUsage(strategyID string) ([]model.StrategyResourceUsage, error)
+
+	// TransferStrategyResource Move the resource link from resource.StrategyID to toStrategyID in one transaction,
+	//   the link is attached to the target strategy before being detached from the source strategy
+	TransferStrategyResource(resource model.StrategyResource, toStrategyID string) error
+}
+
+// UserSessionStore Console login session related storage operation interface
+type UserSessionStore interface {
+	// AddUserSession Create a console login session
+	AddUserSession(session *model.UserSession) error
+	// RevokeUserSessions Revoke the sessions of a user, revoke all sessions of the user when ids is empty
+	RevokeUserSessions(userID string, ids []string) error
+	// GetUserSession Get a session by id, including the revoked session
+	GetUserSession(id string) (*model.UserSession, error)
+	// GetUserSessionsForCache Used to refresh user cache
+	GetUserSessionsForCache(mtime time.Time, firstUpdate bool) ([]*model.UserSession, error)
+}
+
+// RoleStore Role related storage operation interface
+type RoleStore interface {
+	// AddRole Create a role
+	AddRole(role *model.Role) error
+	// UpdateRole Update the comment, strategies and principals of a role
+	UpdateRole(role *model.Role) error
+	// DeleteRole Delete a role
+	DeleteRole(id string) error
+	// GetRole Get a role by id
+	GetRole(id string) (*model.Role, error)
+	// GetRoleByName Get a role by name and owner
+	GetRoleByName(name, owner string) (*model.Role, error)
+	// GetRoles Get a list of roles
+	GetRoles(filters map[string]string, offset uint32, limit uint32) (uint32, []*model.Role, error)
+	// GetRolesForCache Used to refresh role cache
+	GetRolesForCache(mtime time.Time, firstUpdate bool) ([]*model.Role, error)
 }
@@ -175,7 +175,8 @@ func (m *adminStore) getUnHealthyInstancesBefore(mtime time.Time, limit uint32)
 				return false
 			}
 
-			if insMtime.Before(mtime) {
+			// 只返回在 mtime 之前就已经不健康的实例
+			if !insMtime.Before(mtime) {
 				return false
 			}
 
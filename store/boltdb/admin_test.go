@@ -282,7 +282,8 @@ func TestAdminStore_getUnHealthyInstancesBefore(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	beforeTime := time.Date(2023, 3, 4, 11, 1, 0, 0, time.Local)
+	// 设置健康状态时会刷新 mtime，只有在此之后的时间点才能查询到不健康的实例
+	beforeTime := time.Now().Add(time.Minute)
 	ids, err := store.getUnHealthyInstancesBefore(beforeTime, 2)
 	if err != nil {
 		t.Fatal(err)
@@ -290,6 +291,15 @@ func TestAdminStore_getUnHealthyInstancesBefore(t *testing.T) {
 	if len(ids) != 2 {
 		t.Fatalf("count not match, expect cnt=%d, actual cnt=%d", 2, len(ids))
 	}
+
+	beforeTime = time.Date(2023, 3, 4, 11, 1, 0, 0, time.Local)
+	ids, err = store.getUnHealthyInstancesBefore(beforeTime, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ids) != 0 {
+		t.Fatalf("count not match, expect cnt=%d, actual cnt=%d", 0, len(ids))
+	}
 }
 
 func TestMain(m *testing.M) {
@@ -46,6 +46,7 @@ const (
 	FileGroupFieldBusiness   string = "Business"
 	FileGroupFieldDepartment string = "Department"
 	FileGroupFieldMetadata   string = "Metadata"
+	FileGroupFieldOwner      string = "Owner"
 )
 
 var (
@@ -201,6 +202,24 @@ func (fg *configFileGroupStore) UpdateConfigFileGroup(fileGroup *model.ConfigFil
 	return nil
 }
 
+// UpdateConfigFileGroupOwner 更新配置文件组的负责人
+func (fg *configFileGroupStore) UpdateConfigFileGroupOwner(namespace, name, owner string) error {
+	if namespace == "" || name == "" {
+		return store.NewStatusError(store.EmptyParamsErr, "ConfigFileGroup miss some param")
+	}
+
+	key := fmt.Sprintf("%s@@%s", namespace, name)
+	properties := map[string]interface{}{
+		FileGroupFieldOwner:      owner,
+		FileGroupFieldModifyTime: time.Now(),
+	}
+	if err := fg.handler.UpdateValue(tblConfigFileGroup, key, properties); err != nil {
+		log.Error("[ConfigFileGroup] do update owner", zap.Error(err))
+		return store.Error(err)
+	}
+	return nil
+}
+
 func (fg *configFileGroupStore) GetMoreConfigGroup(firstUpdate bool,
 	mtime time.Time) ([]*model.ConfigFileGroup, error) {
 
@@ -83,12 +83,16 @@ type boltStore struct {
 
 	// adminStore store
 	*adminStore
+	// 操作记录
+	*historyStore
 	// 工具
 	*toolStore
 	// 鉴权模块相关
 	*userStore
 	*groupStore
 	*strategyStore
+	*roleStore
+	*userSessionStore
 	*grayStore
 
 	handler BoltHandler
@@ -338,6 +342,8 @@ func (m *boltStore) newAuthModuleStore() {
 	m.userStore = &userStore{handler: m.handler}
 	m.strategyStore = &strategyStore{handler: m.handler}
 	m.groupStore = &groupStore{handler: m.handler}
+	m.roleStore = &roleStore{handler: m.handler}
+	m.userSessionStore = &userSessionStore{handler: m.handler}
 }
 
 func (m *boltStore) newConfigModuleStore() {
@@ -350,6 +356,7 @@ func (m *boltStore) newConfigModuleStore() {
 
 func (m *boltStore) newMaintainModuleStore() {
 	m.adminStore = &adminStore{handler: m.handler, leMap: make(map[string]bool)}
+	m.historyStore = &historyStore{handler: m.handler}
 }
 
 // Destroy store
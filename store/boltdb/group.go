@@ -52,12 +52,14 @@ const (
 	GroupFieldCreateTime  string = "CreateTime"
 	GroupFieldModifyTime  string = "ModifyTime"
 	GroupFieldUserIds     string = "UserIds"
+	GroupFieldParentID    string = "ParentID"
 )
 
 type groupForStore struct {
 	ID          string
 	Name        string
 	Owner       string
+	ParentID    string
 	Token       string
 	TokenEnable bool
 	Valid       bool
@@ -192,6 +194,23 @@ func (gs *groupStore) updateGroup(group *model.ModifyUserGroup) error {
 	return nil
 }
 
+// UpdateGroupParent 更新用户组的父用户组
+func (gs *groupStore) UpdateGroupParent(id, parentID string) error {
+	if id == "" {
+		return store.NewStatusError(store.EmptyParamsErr, "update usergroup parent missing group id")
+	}
+
+	properties := map[string]interface{}{
+		GroupFieldParentID:   parentID,
+		GroupFieldModifyTime: time.Now(),
+	}
+	if err := gs.handler.UpdateValue(tblGroup, id, properties); err != nil {
+		log.Error("[Store][Group] update usergroup parent", zap.Error(err), zap.String("id", id))
+		return store.Error(err)
+	}
+	return nil
+}
+
 // updateGroupRelation 更新用户组的关联关系数据
 func updateGroupRelation(group *model.UserGroupDetail, modify *model.ModifyUserGroup) {
 	for i := range modify.AddUserIds {
@@ -480,6 +499,27 @@ func (gs *groupStore) GetGroupsForCache(mtime time.Time, firstUpdate bool) ([]*m
 	return groups, nil
 }
 
+// GetAllGroupsTx 在读事务中获取全部有效的用户组
+func (gs *groupStore) GetAllGroupsTx(tx store.Tx) ([]*model.UserGroupDetail, error) {
+	dbTx, _ := tx.GetDelegateTx().(*bolt.Tx)
+	ret := make(map[string]interface{})
+	err := loadValuesByFilter(dbTx, tblGroup, []string{GroupFieldValid}, &groupForStore{},
+		func(m map[string]interface{}) bool {
+			valid, ok := m[GroupFieldValid].(bool)
+			return ok && valid
+		}, ret)
+	if err != nil {
+		log.Error("[Store][Group] get all groups in tx", zap.Error(err))
+		return nil, err
+	}
+
+	groups := make([]*model.UserGroupDetail, 0, len(ret))
+	for k := range ret {
+		groups = append(groups, convertForGroupDetail(ret[k].(*groupForStore)))
+	}
+	return groups, nil
+}
+
 // cleanInValidGroup 清理无效的用户组数据
 func (gs *groupStore) cleanInValidGroup(tx *bolt.Tx, name, owner string) error {
 	log.Infof("[Store][User] clean usergroup(%s)", name)
@@ -530,6 +570,7 @@ func convertForGroupStore(group *model.UserGroupDetail) *groupForStore {
 		ID:          group.ID,
 		Name:        group.Name,
 		Owner:       group.Owner,
+		ParentID:    group.ParentID,
 		Token:       group.Token,
 		TokenEnable: group.TokenEnable,
 		Valid:       group.Valid,
@@ -551,6 +592,7 @@ func convertForGroupDetail(group *groupForStore) *model.UserGroupDetail {
 			ID:          group.ID,
 			Name:        group.Name,
 			Owner:       group.Owner,
+			ParentID:    group.ParentID,
 			Token:       group.Token,
 			TokenEnable: group.TokenEnable,
 			Valid:       group.Valid,
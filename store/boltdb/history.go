@@ -0,0 +1,330 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package boltdb
+
+import (
+	"sort"
+	"strconv"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+	"go.uber.org/zap"
+
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/store"
+)
+
+const (
+	tblRecordEntry string = "RecordEntry"
+	// tblRecordEntryChangeSet 变更集 ID 到操作记录 ID 的索引，按变更集查询时不需要扫描整张表
+	tblRecordEntryChangeSet string = "RecordEntryChangeSet"
+
+	RecordEntryFieldID            string = "ID"
+	RecordEntryFieldOperationType string = "OperationType"
+	RecordEntryFieldHappenTime    string = "HappenTime"
+	RecordEntryFieldChangeSetID   string = "ChangeSetID"
+)
+
+// recordEntryObject 操作记录的存储对象，boltdb 的序列化不支持自定义的 string 类型
+type recordEntryObject struct {
+	ID            uint64
+	ResourceType  string
+	ResourceName  string
+	Namespace     string
+	Operator      string
+	OperationType string
+	Detail        string
+	Server        string
+	HappenTime    time.Time
+	ChangeSetID   string
+}
+
+type historyStore struct {
+	handler BoltHandler
+}
+
+// AddRecordEntries 批量保存操作记录
+func (h *historyStore) AddRecordEntries(entries []*model.RecordEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	err := h.handler.Execute(true, func(tx *bolt.Tx) error {
+		table, err := tx.CreateBucketIfNotExists([]byte(tblRecordEntry))
+		if err != nil {
+			return err
+		}
+		index, err := tx.CreateBucketIfNotExists([]byte(tblRecordEntryChangeSet))
+		if err != nil {
+			return err
+		}
+		for i := range entries {
+			entry := entries[i]
+			nextId, err := table.NextSequence()
+			if err != nil {
+				return err
+			}
+			entry.ID = nextId
+			if err := saveValue(tx, tblRecordEntry, strconv.FormatUint(nextId, 10), &recordEntryObject{
+				ID:            entry.ID,
+				ResourceType:  string(entry.ResourceType),
+				ResourceName:  entry.ResourceName,
+				Namespace:     entry.Namespace,
+				Operator:      entry.Operator,
+				OperationType: string(entry.OperationType),
+				Detail:        entry.Detail,
+				Server:        entry.Server,
+				HappenTime:    entry.HappenTime,
+				ChangeSetID:   entry.ChangeSetID,
+			}); err != nil {
+				log.Error("[RecordEntry] save info", zap.Error(err))
+				return err
+			}
+			if entry.ChangeSetID == "" {
+				continue
+			}
+			changeSet, err := index.CreateBucketIfNotExists([]byte(entry.ChangeSetID))
+			if err != nil {
+				return err
+			}
+			if err := changeSet.Put([]byte(strconv.FormatUint(nextId, 10)), []byte{}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return store.Error(err)
+}
+
+// deleteRecordEntries 删除操作记录并同步清理变更集索引, needDel 为操作记录 ID 到变更集 ID 的映射
+func (h *historyStore) deleteRecordEntries(needDel map[string]string) error {
+	if len(needDel) == 0 {
+		return nil
+	}
+	return h.handler.Execute(true, func(tx *bolt.Tx) error {
+		keys := make([]string, 0, len(needDel))
+		for id := range needDel {
+			keys = append(keys, id)
+		}
+		if err := deleteValues(tx, tblRecordEntry, keys); err != nil {
+			return err
+		}
+		index := tx.Bucket([]byte(tblRecordEntryChangeSet))
+		if index == nil {
+			return nil
+		}
+		for id, changeSetID := range needDel {
+			if changeSetID == "" {
+				continue
+			}
+			changeSet := index.Bucket([]byte(changeSetID))
+			if changeSet == nil {
+				continue
+			}
+			if err := changeSet.Delete([]byte(id)); err != nil {
+				return err
+			}
+			if k, _ := changeSet.Cursor().First(); k == nil {
+				if err := index.DeleteBucket([]byte(changeSetID)); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// CleanRecordEntries 清理 endTime 之前发生的操作记录
+func (h *historyStore) CleanRecordEntries(endTime time.Time, exemptTypes []model.OperationType,
+	limit uint64) (uint64, error) {
+	exempt := toExemptSet(exemptTypes)
+	needDel := make(map[string]string, limit)
+	fields := []string{RecordEntryFieldID, RecordEntryFieldOperationType, RecordEntryFieldHappenTime,
+		RecordEntryFieldChangeSetID}
+	_, err := h.handler.LoadValuesByFilter(tblRecordEntry, fields, &recordEntryObject{},
+		func(m map[string]interface{}) bool {
+			if uint64(len(needDel)) >= limit {
+				return false
+			}
+			opType, _ := m[RecordEntryFieldOperationType].(string)
+			if _, ok := exempt[opType]; ok {
+				return false
+			}
+			happenTime, _ := m[RecordEntryFieldHappenTime].(time.Time)
+			if endTime.After(happenTime) {
+				changeSetID, _ := m[RecordEntryFieldChangeSetID].(string)
+				needDel[strconv.FormatUint(m[RecordEntryFieldID].(uint64), 10)] = changeSetID
+			}
+			return false
+		})
+	if err != nil {
+		return 0, store.Error(err)
+	}
+	if err := h.deleteRecordEntries(needDel); err != nil {
+		return 0, store.Error(err)
+	}
+	return uint64(len(needDel)), nil
+}
+
+// CleanExceedRecordEntries 仅保留最新的 retainCount 条操作记录
+func (h *historyStore) CleanExceedRecordEntries(retainCount uint64, exemptTypes []model.OperationType,
+	limit uint64) (uint64, error) {
+	exempt := toExemptSet(exemptTypes)
+	ids := make([]uint64, 0, 128)
+	changeSets := make(map[uint64]string, 128)
+	fields := []string{RecordEntryFieldID, RecordEntryFieldOperationType, RecordEntryFieldChangeSetID}
+	_, err := h.handler.LoadValuesByFilter(tblRecordEntry, fields, &recordEntryObject{},
+		func(m map[string]interface{}) bool {
+			opType, _ := m[RecordEntryFieldOperationType].(string)
+			if _, ok := exempt[opType]; ok {
+				return false
+			}
+			id := m[RecordEntryFieldID].(uint64)
+			ids = append(ids, id)
+			if changeSetID, _ := m[RecordEntryFieldChangeSetID].(string); changeSetID != "" {
+				changeSets[id] = changeSetID
+			}
+			return false
+		})
+	if err != nil {
+		return 0, store.Error(err)
+	}
+	if uint64(len(ids)) <= retainCount {
+		return 0, nil
+	}
+
+	// ID 按照写入顺序递增, 从最旧的记录开始清理
+	sort.Slice(ids, func(i, j int) bool {
+		return ids[i] < ids[j]
+	})
+	exceed := uint64(len(ids)) - retainCount
+	if exceed > limit {
+		exceed = limit
+	}
+	needDel := make(map[string]string, exceed)
+	for i := uint64(0); i < exceed; i++ {
+		needDel[strconv.FormatUint(ids[i], 10)] = changeSets[ids[i]]
+	}
+	if err := h.deleteRecordEntries(needDel); err != nil {
+		return 0, store.Error(err)
+	}
+	return exceed, nil
+}
+
+// GetRecordEntriesByChangeSet 查询同一个变更集下的全部操作记录
+func (h *historyStore) GetRecordEntriesByChangeSet(changeSetID string) ([]*model.RecordEntry, error) {
+	values := make(map[string]interface{})
+	err := h.handler.Execute(false, func(tx *bolt.Tx) error {
+		index := tx.Bucket([]byte(tblRecordEntryChangeSet))
+		if index == nil {
+			return nil
+		}
+		changeSet := index.Bucket([]byte(changeSetID))
+		if changeSet == nil {
+			return nil
+		}
+		keys, err := getKeys(changeSet)
+		if err != nil {
+			return err
+		}
+		return loadValues(tx, tblRecordEntry, keys, &recordEntryObject{}, values)
+	})
+	if err != nil {
+		return nil, store.Error(err)
+	}
+	return toRecordEntries(values), nil
+}
+
+// GetRecordEntriesSince 查询 since 之后发生的操作记录，超过 limit 条时保留最新的 limit 条
+func (h *historyStore) GetRecordEntriesSince(since time.Time, limit uint64) ([]*model.RecordEntry, error) {
+	fields := []string{RecordEntryFieldHappenTime}
+	values, err := h.handler.LoadValuesByFilter(tblRecordEntry, fields, &recordEntryObject{},
+		func(m map[string]interface{}) bool {
+			happenTime, _ := m[RecordEntryFieldHappenTime].(time.Time)
+			return !happenTime.Before(since)
+		})
+	if err != nil {
+		return nil, store.Error(err)
+	}
+	entries := toRecordEntries(values)
+	if uint64(len(entries)) > limit {
+		entries = entries[uint64(len(entries))-limit:]
+	}
+	return entries, nil
+}
+
+// GetRecordEntries 分页查询满足条件的操作记录，按照发生时间从新到旧返回
+func (h *historyStore) GetRecordEntries(filter *model.RecordEntryFilter, offset,
+	limit uint32) (uint32, []*model.RecordEntry, error) {
+	values, err := h.handler.LoadValuesAll(tblRecordEntry, &recordEntryObject{})
+	if err != nil {
+		return 0, nil, store.Error(err)
+	}
+	matched := make([]*model.RecordEntry, 0, len(values))
+	for _, entry := range toRecordEntries(values) {
+		if filter.Match(entry) {
+			matched = append(matched, entry)
+		}
+	}
+	// ID 按照写入顺序递增，与发生时间的先后顺序一致
+	total := uint32(len(matched))
+	if offset >= total {
+		return total, []*model.RecordEntry{}, nil
+	}
+	end := total - offset
+	begin := uint32(0)
+	if end > limit {
+		begin = end - limit
+	}
+	ret := make([]*model.RecordEntry, 0, end-begin)
+	for i := end; i > begin; i-- {
+		ret = append(ret, matched[i-1])
+	}
+	return total, ret, nil
+}
+
+// toRecordEntries 按照写入顺序转换为操作记录
+func toRecordEntries(values map[string]interface{}) []*model.RecordEntry {
+	entries := make([]*model.RecordEntry, 0, len(values))
+	for _, value := range values {
+		obj := value.(*recordEntryObject)
+		entries = append(entries, &model.RecordEntry{
+			ID:            obj.ID,
+			ResourceType:  model.Resource(obj.ResourceType),
+			ResourceName:  obj.ResourceName,
+			Namespace:     obj.Namespace,
+			Operator:      obj.Operator,
+			OperationType: model.OperationType(obj.OperationType),
+			Detail:        obj.Detail,
+			Server:        obj.Server,
+			HappenTime:    obj.HappenTime,
+			ChangeSetID:   obj.ChangeSetID,
+		})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].ID < entries[j].ID
+	})
+	return entries
+}
+
+func toExemptSet(exemptTypes []model.OperationType) map[string]struct{} {
+	exempt := make(map[string]struct{}, len(exemptTypes))
+	for i := range exemptTypes {
+		exempt[string(exemptTypes[i])] = struct{}{}
+	}
+	return exempt
+}
@@ -0,0 +1,236 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package boltdb
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/polarismesh/polaris/common/model"
+)
+
+func mockRecordEntries(total int, opType model.OperationType, happenTime time.Time) []*model.RecordEntry {
+	ret := make([]*model.RecordEntry, 0, total)
+	for i := 0; i < total; i++ {
+		ret = append(ret, &model.RecordEntry{
+			ResourceType:  model.RService,
+			ResourceName:  fmt.Sprintf("service-%d", i),
+			Namespace:     "default",
+			Operator:      "polaris",
+			OperationType: opType,
+			HappenTime:    happenTime,
+		})
+	}
+	return ret
+}
+
+// loadRecordOperationTypes 返回剩余的操作记录中，每种操作类型的数量
+func loadRecordOperationTypes(t *testing.T, handler BoltHandler) map[string]int {
+	ret, err := handler.LoadValuesAll(tblRecordEntry, &recordEntryObject{})
+	assert.NoError(t, err)
+	counts := map[string]int{}
+	for _, v := range ret {
+		counts[v.(*recordEntryObject).OperationType]++
+	}
+	return counts
+}
+
+func Test_historyStore(t *testing.T) {
+	t.Run("按照保留时长清理，豁免的操作类型保留", func(t *testing.T) {
+		CreateTableDBHandlerAndRun(t, tblRecordEntry, func(t *testing.T, handler BoltHandler) {
+			hs := &historyStore{handler: handler}
+			expired := time.Now().Add(-48 * time.Hour)
+			assert.NoError(t, hs.AddRecordEntries(mockRecordEntries(5, model.OCreate, expired)))
+			assert.NoError(t, hs.AddRecordEntries(mockRecordEntries(3, model.ODelete, expired)))
+			assert.NoError(t, hs.AddRecordEntries(mockRecordEntries(4, model.OCreate, time.Now())))
+
+			count, err := hs.CleanRecordEntries(time.Now().Add(-24*time.Hour),
+				[]model.OperationType{model.ODelete}, 100)
+			assert.NoError(t, err)
+			assert.Equal(t, uint64(5), count)
+
+			counts := loadRecordOperationTypes(t, handler)
+			assert.Equal(t, 4, counts[string(model.OCreate)])
+			assert.Equal(t, 3, counts[string(model.ODelete)])
+		})
+	})
+
+	t.Run("按照保留时长清理，单次清理数量受限", func(t *testing.T) {
+		CreateTableDBHandlerAndRun(t, tblRecordEntry, func(t *testing.T, handler BoltHandler) {
+			hs := &historyStore{handler: handler}
+			assert.NoError(t, hs.AddRecordEntries(mockRecordEntries(10, model.OCreate, time.Now().Add(-time.Hour))))
+
+			count, err := hs.CleanRecordEntries(time.Now(), nil, 4)
+			assert.NoError(t, err)
+			assert.Equal(t, uint64(4), count)
+			assert.Equal(t, 6, loadRecordOperationTypes(t, handler)[string(model.OCreate)])
+		})
+	})
+
+	t.Run("按照保留条数清理，保留最新的记录", func(t *testing.T) {
+		CreateTableDBHandlerAndRun(t, tblRecordEntry, func(t *testing.T, handler BoltHandler) {
+			hs := &historyStore{handler: handler}
+			olds := mockRecordEntries(6, model.OUpdate, time.Now())
+			assert.NoError(t, hs.AddRecordEntries(olds))
+			assert.NoError(t, hs.AddRecordEntries(mockRecordEntries(2, model.OUpdateToken, time.Now())))
+			news := mockRecordEntries(3, model.OUpdate, time.Now())
+			assert.NoError(t, hs.AddRecordEntries(news))
+
+			count, err := hs.CleanExceedRecordEntries(4, []model.OperationType{model.OUpdateToken}, 100)
+			assert.NoError(t, err)
+			assert.Equal(t, uint64(5), count)
+
+			ret, err := handler.LoadValuesAll(tblRecordEntry, &recordEntryObject{})
+			assert.NoError(t, err)
+			assert.Equal(t, 6, len(ret))
+			// 最旧的记录被清理，最新的记录以及豁免的记录保留
+			for _, entry := range olds[:5] {
+				_, ok := ret[fmt.Sprintf("%d", entry.ID)]
+				assert.False(t, ok)
+			}
+			for _, entry := range news {
+				_, ok := ret[fmt.Sprintf("%d", entry.ID)]
+				assert.True(t, ok)
+			}
+			assert.Equal(t, 2, loadRecordOperationTypes(t, handler)[string(model.OUpdateToken)])
+
+			// 未超出保留条数时不清理
+			count, err = hs.CleanExceedRecordEntries(4, []model.OperationType{model.OUpdateToken}, 100)
+			assert.NoError(t, err)
+			assert.Equal(t, uint64(0), count)
+		})
+	})
+
+	t.Run("按照变更集查询操作记录", func(t *testing.T) {
+		CreateTableDBHandlerAndRun(t, tblRecordEntry, func(t *testing.T, handler BoltHandler) {
+			hs := &historyStore{handler: handler}
+			changeSet := mockRecordEntries(3, model.OUpdate, time.Now())
+			for i := range changeSet {
+				changeSet[i].ChangeSetID = "change-set-1"
+			}
+			assert.NoError(t, hs.AddRecordEntries(mockRecordEntries(2, model.OUpdate, time.Now())))
+			assert.NoError(t, hs.AddRecordEntries(changeSet))
+
+			ret, err := hs.GetRecordEntriesByChangeSet("change-set-1")
+			assert.NoError(t, err)
+			assert.Equal(t, 3, len(ret))
+			for i := range ret {
+				assert.Equal(t, changeSet[i].ID, ret[i].ID)
+				assert.Equal(t, changeSet[i].ResourceName, ret[i].ResourceName)
+				assert.Equal(t, "change-set-1", ret[i].ChangeSetID)
+			}
+
+			ret, err = hs.GetRecordEntriesByChangeSet("change-set-2")
+			assert.NoError(t, err)
+			assert.Empty(t, ret)
+
+			// 清理操作记录时同步清理变更集索引
+			count, err := hs.CleanExceedRecordEntries(1, nil, 100)
+			assert.NoError(t, err)
+			assert.Equal(t, uint64(4), count)
+			ret, err = hs.GetRecordEntriesByChangeSet("change-set-1")
+			assert.NoError(t, err)
+			assert.Equal(t, 1, len(ret))
+			assert.Equal(t, changeSet[2].ID, ret[0].ID)
+
+			count, err = hs.CleanRecordEntries(time.Now().Add(time.Minute), nil, 100)
+			assert.NoError(t, err)
+			assert.Equal(t, uint64(1), count)
+			ret, err = hs.GetRecordEntriesByChangeSet("change-set-1")
+			assert.NoError(t, err)
+			assert.Empty(t, ret)
+			assert.NoError(t, handler.Execute(false, func(tx *bolt.Tx) error {
+				assert.Nil(t, tx.Bucket([]byte(tblRecordEntryChangeSet)).Bucket([]byte("change-set-1")))
+				return nil
+			}))
+		})
+	})
+
+	t.Run("查询最近的操作记录，超出数量时保留最新的记录", func(t *testing.T) {
+		CreateTableDBHandlerAndRun(t, tblRecordEntry, func(t *testing.T, handler BoltHandler) {
+			hs := &historyStore{handler: handler}
+			assert.NoError(t, hs.AddRecordEntries(mockRecordEntries(3, model.OUpdate, time.Now().Add(-time.Hour))))
+			recent := mockRecordEntries(4, model.OUpdate, time.Now())
+			assert.NoError(t, hs.AddRecordEntries(recent))
+
+			ret, err := hs.GetRecordEntriesSince(time.Now().Add(-time.Minute), 10)
+			assert.NoError(t, err)
+			assert.Equal(t, 4, len(ret))
+			for i := range ret {
+				assert.Equal(t, recent[i].ID, ret[i].ID)
+			}
+
+			ret, err = hs.GetRecordEntriesSince(time.Now().Add(-time.Minute), 2)
+			assert.NoError(t, err)
+			if assert.Equal(t, 2, len(ret)) {
+				assert.Equal(t, recent[2].ID, ret[0].ID)
+				assert.Equal(t, recent[3].ID, ret[1].ID)
+			}
+		})
+	})
+
+	t.Run("按照条件分页查询操作记录，从新到旧返回", func(t *testing.T) {
+		CreateTableDBHandlerAndRun(t, tblRecordEntry, func(t *testing.T, handler BoltHandler) {
+			hs := &historyStore{handler: handler}
+			old := mockRecordEntries(3, model.OCreate, time.Now().Add(-time.Hour))
+			assert.NoError(t, hs.AddRecordEntries(old))
+			recent := mockRecordEntries(5, model.OCreate, time.Now())
+			recent[4].Operator = "other"
+			assert.NoError(t, hs.AddRecordEntries(recent))
+			assert.NoError(t, hs.AddRecordEntries(mockRecordEntries(2, model.ODelete, time.Now())))
+
+			filter := &model.RecordEntryFilter{
+				Operator:      "polaris",
+				ResourceType:  model.RService,
+				OperationType: model.OCreate,
+				StartTime:     time.Now().Add(-time.Minute),
+			}
+			total, ret, err := hs.GetRecordEntries(filter, 0, 10)
+			assert.NoError(t, err)
+			assert.Equal(t, uint32(4), total)
+			if assert.Equal(t, 4, len(ret)) {
+				assert.Equal(t, recent[3].ID, ret[0].ID)
+				assert.Equal(t, recent[0].ID, ret[3].ID)
+			}
+
+			total, ret, err = hs.GetRecordEntries(filter, 1, 2)
+			assert.NoError(t, err)
+			assert.Equal(t, uint32(4), total)
+			if assert.Equal(t, 2, len(ret)) {
+				assert.Equal(t, recent[2].ID, ret[0].ID)
+				assert.Equal(t, recent[1].ID, ret[1].ID)
+			}
+
+			total, ret, err = hs.GetRecordEntries(&model.RecordEntryFilter{
+				EndTime: time.Now().Add(-time.Minute),
+			}, 0, 10)
+			assert.NoError(t, err)
+			assert.Equal(t, uint32(3), total)
+			assert.Equal(t, 3, len(ret))
+
+			total, ret, err = hs.GetRecordEntries(&model.RecordEntryFilter{}, 20, 10)
+			assert.NoError(t, err)
+			assert.Equal(t, uint32(10), total)
+			assert.Empty(t, ret)
+		})
+	})
+}
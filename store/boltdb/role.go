@@ -0,0 +1,363 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+package boltdb
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+	"go.uber.org/zap"
+
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/common/utils"
+	"github.com/polarismesh/polaris/store"
+)
+
+var (
+	// ErrorMultipleRoleFound is returned when multiple roles are found.
+	ErrorMultipleRoleFound error = errors.New("multiple role found")
+	// ErrorRoleNotFound is returned when a role is not found.
+	ErrorRoleNotFound error = errors.New("role not found")
+)
+
+const (
+	tblRole string = "role"
+
+	RoleFieldID         string = "ID"
+	RoleFieldName       string = "Name"
+	RoleFieldOwner      string = "Owner"
+	RoleFieldComment    string = "Comment"
+	RoleFieldStrategies string = "Strategies"
+	RoleFieldPrincipals string = "Principals"
+	RoleFieldValid      string = "Valid"
+	RoleFieldModifyTime string = "ModifyTime"
+)
+
+// roleForStore 角色的存储对象，boltdb 的序列化不支持切片，策略以及成员以 JSON 的格式保存
+type roleForStore struct {
+	ID         string
+	Name       string
+	Owner      string
+	Comment    string
+	Strategies string
+	Principals string
+	Valid      bool
+	CreateTime time.Time
+	ModifyTime time.Time
+}
+
+// rolePrincipal 角色成员的存储格式
+type rolePrincipal struct {
+	ID   string `json:"id"`
+	Type int    `json:"type"`
+}
+
+type roleStore struct {
+	handler BoltHandler
+}
+
+// AddRole 新增角色，同名的无效角色会被清理
+func (rs *roleStore) AddRole(role *model.Role) error {
+	if role.ID == "" || role.Name == "" || role.Owner == "" {
+		return store.NewStatusError(store.EmptyParamsErr, fmt.Sprintf(
+			"add role missing some params, id is %s, name is %s, owner is %s", role.ID, role.Name, role.Owner))
+	}
+
+	err := rs.handler.Execute(true, func(tx *bolt.Tx) error {
+		if err := rs.cleanInvalidRole(tx, role.Name, role.Owner); err != nil {
+			return err
+		}
+		role.Valid = true
+		role.CreateTime = time.Now()
+		role.ModifyTime = role.CreateTime
+		data, err := convertForRoleStore(role)
+		if err != nil {
+			return err
+		}
+		return saveValue(tx, tblRole, data.ID, data)
+	})
+	if err != nil {
+		log.Error("[Store][Role] add role", zap.String("name", role.Name), zap.Error(err))
+	}
+	return store.Error(err)
+}
+
+// UpdateRole 更新角色的描述、策略以及成员
+func (rs *roleStore) UpdateRole(role *model.Role) error {
+	if role.ID == "" {
+		return store.NewStatusError(store.EmptyParamsErr, "update role missing id")
+	}
+
+	err := rs.handler.Execute(true, func(tx *bolt.Tx) error {
+		values := make(map[string]interface{})
+		if err := loadValues(tx, tblRole, []string{role.ID}, &roleForStore{}, values); err != nil {
+			return err
+		}
+		saved, ok := values[role.ID].(*roleForStore)
+		if !ok || !saved.Valid {
+			return ErrorRoleNotFound
+		}
+		data, err := convertForRoleStore(role)
+		if err != nil {
+			return err
+		}
+		return updateValue(tx, tblRole, role.ID, map[string]interface{}{
+			RoleFieldComment:    data.Comment,
+			RoleFieldStrategies: data.Strategies,
+			RoleFieldPrincipals: data.Principals,
+			RoleFieldModifyTime: time.Now(),
+		})
+	})
+	if err != nil {
+		log.Error("[Store][Role] update role", zap.String("id", role.ID), zap.Error(err))
+	}
+	return store.Error(err)
+}
+
+// DeleteRole 删除角色
+func (rs *roleStore) DeleteRole(id string) error {
+	if id == "" {
+		return store.NewStatusError(store.EmptyParamsErr, "delete role missing id")
+	}
+
+	err := rs.handler.Execute(true, func(tx *bolt.Tx) error {
+		return updateValue(tx, tblRole, id, map[string]interface{}{
+			RoleFieldValid:      false,
+			RoleFieldModifyTime: time.Now(),
+		})
+	})
+	if err != nil {
+		log.Error("[Store][Role] delete role", zap.String("id", id), zap.Error(err))
+	}
+	return store.Error(err)
+}
+
+// GetRole 查询角色，角色不存在或者已经删除时返回 nil
+func (rs *roleStore) GetRole(id string) (*model.Role, error) {
+	if id == "" {
+		return nil, store.NewStatusError(store.EmptyParamsErr, "get role missing id")
+	}
+
+	values, err := rs.handler.LoadValues(tblRole, []string{id}, &roleForStore{})
+	if err != nil {
+		log.Error("[Store][Role] get role by id", zap.String("id", id), zap.Error(err))
+		return nil, store.Error(err)
+	}
+	saved, ok := values[id].(*roleForStore)
+	if !ok || !saved.Valid {
+		return nil, nil
+	}
+	return convertForRoleModel(saved)
+}
+
+// GetRoleByName 根据名称以及主账户查询角色
+func (rs *roleStore) GetRoleByName(name, owner string) (*model.Role, error) {
+	if name == "" || owner == "" {
+		return nil, store.NewStatusError(store.EmptyParamsErr, fmt.Sprintf(
+			"get role missing some params, name=%s, owner=%s", name, owner))
+	}
+
+	fields := []string{RoleFieldName, RoleFieldOwner, RoleFieldValid}
+	values, err := rs.handler.LoadValuesByFilter(tblRole, fields, &roleForStore{},
+		func(m map[string]interface{}) bool {
+			valid, _ := m[RoleFieldValid].(bool)
+			return valid && m[RoleFieldName] == name && m[RoleFieldOwner] == owner
+		})
+	if err != nil {
+		return nil, store.Error(err)
+	}
+	if len(values) == 0 {
+		return nil, nil
+	}
+	if len(values) > 1 {
+		return nil, ErrorMultipleRoleFound
+	}
+	for _, v := range values {
+		return convertForRoleModel(v.(*roleForStore))
+	}
+	return nil, nil
+}
+
+// GetRoles 查询角色列表，支持 id、name（前缀匹配）、owner 过滤
+func (rs *roleStore) GetRoles(filters map[string]string, offset uint32,
+	limit uint32) (uint32, []*model.Role, error) {
+	fields := []string{RoleFieldID, RoleFieldName, RoleFieldOwner, RoleFieldValid}
+	values, err := rs.handler.LoadValuesByFilter(tblRole, fields, &roleForStore{},
+		func(m map[string]interface{}) bool {
+			valid, _ := m[RoleFieldValid].(bool)
+			if !valid {
+				return false
+			}
+			if id, ok := filters["id"]; ok && id != m[RoleFieldID] {
+				return false
+			}
+			if owner, ok := filters["owner"]; ok && owner != m[RoleFieldOwner] {
+				return false
+			}
+			if name, ok := filters["name"]; ok {
+				saveName, _ := m[RoleFieldName].(string)
+				if utils.IsPrefixWildName(name) {
+					name = name[:len(name)-1]
+				}
+				if !strings.Contains(saveName, name) {
+					return false
+				}
+			}
+			return true
+		})
+	if err != nil {
+		return 0, nil, store.Error(err)
+	}
+
+	roles := make([]*model.Role, 0, len(values))
+	for k := range values {
+		role, err := convertForRoleModel(values[k].(*roleForStore))
+		if err != nil {
+			return 0, nil, err
+		}
+		roles = append(roles, role)
+	}
+	sort.Slice(roles, func(i, j int) bool {
+		return roles[i].ModifyTime.After(roles[j].ModifyTime)
+	})
+
+	total := uint32(len(roles))
+	if offset >= total {
+		return total, []*model.Role{}, nil
+	}
+	end := offset + limit
+	if end > total {
+		end = total
+	}
+	return total, roles[offset:end], nil
+}
+
+// GetRolesForCache 查询 mtime 之后发生变化的角色，包含已经删除的角色，主要用于 Cache 更新
+func (rs *roleStore) GetRolesForCache(mtime time.Time, firstUpdate bool) ([]*model.Role, error) {
+	values, err := rs.handler.LoadValuesByFilter(tblRole, []string{RoleFieldModifyTime, RoleFieldValid},
+		&roleForStore{}, func(m map[string]interface{}) bool {
+			if firstUpdate {
+				valid, _ := m[RoleFieldValid].(bool)
+				return valid
+			}
+			mt, _ := m[RoleFieldModifyTime].(time.Time)
+			return mt.After(mtime)
+		})
+	if err != nil {
+		return nil, store.Error(err)
+	}
+
+	roles := make([]*model.Role, 0, len(values))
+	for k := range values {
+		role, err := convertForRoleModel(values[k].(*roleForStore))
+		if err != nil {
+			return nil, err
+		}
+		roles = append(roles, role)
+	}
+	return roles, nil
+}
+
+// cleanInvalidRole 清理同名的无效角色
+func (rs *roleStore) cleanInvalidRole(tx *bolt.Tx, name, owner string) error {
+	fields := []string{RoleFieldName, RoleFieldOwner, RoleFieldValid}
+	values := make(map[string]interface{})
+	err := loadValuesByFilter(tx, tblRole, fields, &roleForStore{},
+		func(m map[string]interface{}) bool {
+			valid, _ := m[RoleFieldValid].(bool)
+			return !valid && m[RoleFieldName] == name && m[RoleFieldOwner] == owner
+		}, values)
+	if err != nil {
+		return err
+	}
+	if len(values) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	return deleteValues(tx, tblRole, keys)
+}
+
+func convertForRoleStore(role *model.Role) (*roleForStore, error) {
+	strategies := role.Strategies
+	if strategies == nil {
+		strategies = []string{}
+	}
+	strategiesData, err := json.Marshal(strategies)
+	if err != nil {
+		return nil, err
+	}
+	principals := make([]rolePrincipal, 0, len(role.Principals))
+	for i := range role.Principals {
+		principals = append(principals, rolePrincipal{
+			ID:   role.Principals[i].PrincipalID,
+			Type: int(role.Principals[i].PrincipalRole),
+		})
+	}
+	principalsData, err := json.Marshal(principals)
+	if err != nil {
+		return nil, err
+	}
+	return &roleForStore{
+		ID:         role.ID,
+		Name:       role.Name,
+		Owner:      role.Owner,
+		Comment:    role.Comment,
+		Strategies: string(strategiesData),
+		Principals: string(principalsData),
+		Valid:      role.Valid,
+		CreateTime: role.CreateTime,
+		ModifyTime: role.ModifyTime,
+	}, nil
+}
+
+func convertForRoleModel(data *roleForStore) (*model.Role, error) {
+	role := &model.Role{
+		ID:         data.ID,
+		Name:       data.Name,
+		Owner:      data.Owner,
+		Comment:    data.Comment,
+		Valid:      data.Valid,
+		CreateTime: data.CreateTime,
+		ModifyTime: data.ModifyTime,
+	}
+	if data.Strategies != "" {
+		if err := json.Unmarshal([]byte(data.Strategies), &role.Strategies); err != nil {
+			return nil, err
+		}
+	}
+	if data.Principals != "" {
+		principals := make([]rolePrincipal, 0, 4)
+		if err := json.Unmarshal([]byte(data.Principals), &principals); err != nil {
+			return nil, err
+		}
+		for i := range principals {
+			role.Principals = append(role.Principals, model.Principal{
+				PrincipalID:   principals[i].ID,
+				PrincipalRole: model.PrincipalType(principals[i].Type),
+			})
+		}
+	}
+	return role, nil
+}
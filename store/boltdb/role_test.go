@@ -0,0 +1,92 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+package boltdb
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/polarismesh/polaris/common/model"
+)
+
+func Test_roleStore(t *testing.T) {
+	CreateTableDBHandlerAndRun(t, tblRole, func(t *testing.T, handler BoltHandler) {
+		rs := &roleStore{handler: handler}
+		start := time.Now().Add(-time.Second)
+
+		role := &model.Role{
+			ID:         "role-1",
+			Name:       "ops",
+			Owner:      "polaris",
+			Comment:    "ops role",
+			Strategies: []string{"strategy-1", "strategy-2"},
+			Principals: []model.Principal{
+				{PrincipalID: "user-1", PrincipalRole: model.PrincipalUser},
+				{PrincipalID: "group-1", PrincipalRole: model.PrincipalGroup},
+			},
+		}
+		assert.NoError(t, rs.AddRole(role))
+
+		saved, err := rs.GetRole(role.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, role.Strategies, saved.Strategies)
+		assert.Equal(t, role.Principals, saved.Principals)
+		assert.True(t, saved.Valid)
+
+		byName, err := rs.GetRoleByName("ops", "polaris")
+		assert.NoError(t, err)
+		assert.Equal(t, role.ID, byName.ID)
+
+		saved.Comment = "changed"
+		saved.Strategies = []string{"strategy-3"}
+		saved.Principals = nil
+		assert.NoError(t, rs.UpdateRole(saved))
+		updated, err := rs.GetRole(role.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, "changed", updated.Comment)
+		assert.Equal(t, []string{"strategy-3"}, updated.Strategies)
+		assert.Empty(t, updated.Principals)
+
+		total, roles, err := rs.GetRoles(map[string]string{"owner": "polaris", "name": "op*"}, 0, 10)
+		assert.NoError(t, err)
+		assert.Equal(t, uint32(1), total)
+		assert.Len(t, roles, 1)
+
+		assert.NoError(t, rs.DeleteRole(role.ID))
+		deleted, err := rs.GetRole(role.ID)
+		assert.NoError(t, err)
+		assert.Nil(t, deleted)
+
+		// 增量更新需要拿到已经删除的角色
+		changed, err := rs.GetRolesForCache(start, false)
+		assert.NoError(t, err)
+		assert.Len(t, changed, 1)
+		assert.False(t, changed[0].Valid)
+		all, err := rs.GetRolesForCache(time.Time{}, true)
+		assert.NoError(t, err)
+		assert.Empty(t, all)
+
+		// 删除后可以重新创建同名角色
+		role.ID = "role-2"
+		assert.NoError(t, rs.AddRole(role))
+		byName, err = rs.GetRoleByName("ops", "polaris")
+		assert.NoError(t, err)
+		assert.Equal(t, "role-2", byName.ID)
+	})
+}
@@ -21,6 +21,7 @@ import (
 	"errors"
 	"fmt"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -51,6 +52,10 @@ const (
 	StrategyFieldRevision        string = "Revision"
 	StrategyFieldCreateTime      string = "CreateTime"
 	StrategyFieldModifyTime      string = "ModifyTime"
+	StrategyFieldResourceUsages  string = "ResourceUsages"
+	StrategyFieldEffect          string = "Effect"
+	StrategyFieldPriority        string = "Priority"
+	StrategyFieldFunctions       string = "Functions"
 )
 
 var (
@@ -74,6 +79,12 @@ type strategyForStore struct {
 	Revision     string
 	CreateTime   time.Time
 	ModifyTime   time.Time
+	// ResourceUsages 资源关联关系最近一次被使用的 unix 秒, key 为 {resType}_{resId}
+	ResourceUsages map[string]string
+	Effect         string
+	Priority       int32
+	// Functions 策略生效的接口名称，使用 , 分隔
+	Functions string
 }
 
 // StrategyStore
@@ -104,7 +115,60 @@ func (ss *strategyStore) AddStrategy(strategy *model.StrategyDetail) error {
 	return ss.addStrategy(tx, strategy)
 }
 
+// AddStrategies 在同一个事务中批量创建鉴权策略
+func (ss *strategyStore) AddStrategies(strategies []*model.StrategyDetail) error {
+	for i := range strategies {
+		strategy := strategies[i]
+		if strategy.ID == "" || strategy.Name == "" || strategy.Owner == "" {
+			return store.NewStatusError(store.EmptyParamsErr, fmt.Sprintf(
+				"add auth_strategy missing some params, id is %s, name is %s, owner is %s",
+				strategy.ID, strategy.Name, strategy.Owner))
+		}
+		initStrategy(strategy)
+	}
+	if len(strategies) == 0 {
+		return nil
+	}
+
+	proxy, err := ss.handler.StartTx()
+	if err != nil {
+		return err
+	}
+	tx := proxy.GetDelegateTx().(*bolt.Tx)
+
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	for i := range strategies {
+		if err := ss.saveStrategy(tx, strategies[i]); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error("[Store][Strategy] add auth_strategies tx commit", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
 func (ss *strategyStore) addStrategy(tx *bolt.Tx, strategy *model.StrategyDetail) error {
+	if err := ss.saveStrategy(tx, strategy); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error("[Store][Strategy] clean invalid auth_strategy tx commit", zap.Error(err),
+			zap.String("name", strategy.Name), zap.String("owner", strategy.Owner))
+		return err
+	}
+
+	return nil
+}
+
+// saveStrategy 在事务中清理同名的无效策略并保存策略
+func (ss *strategyStore) saveStrategy(tx *bolt.Tx, strategy *model.StrategyDetail) error {
 	if err := ss.cleanInvalidStrategy(tx, strategy.Name, strategy.Owner); err != nil {
 		log.Error("[Store][Strategy] clean invalid auth_strategy", zap.Error(err),
 			zap.String("name", strategy.Name), zap.Any("owner", strategy.Owner))
@@ -117,12 +181,6 @@ func (ss *strategyStore) addStrategy(tx *bolt.Tx, strategy *model.StrategyDetail
 		return err
 	}
 
-	if err := tx.Commit(); err != nil {
-		log.Error("[Store][Strategy] clean invalid auth_strategy tx commit", zap.Error(err),
-			zap.String("name", strategy.Name), zap.String("owner", strategy.Owner))
-		return err
-	}
-
 	return nil
 }
 
@@ -154,6 +212,63 @@ func (ss *strategyStore) UpdateStrategy(strategy *model.ModifyStrategyDetail) er
 	return ss.updateStrategy(tx, strategy, ret)
 }
 
+// UpdateStrategyEffect 更新策略的授权效果以及优先级
+func (ss *strategyStore) UpdateStrategyEffect(id string, effect model.StrategyEffect, priority int32) error {
+	if id == "" {
+		return store.NewStatusError(store.EmptyParamsErr, fmt.Sprintf(
+			"update auth_strategy effect missing some params, id is %s", id))
+	}
+
+	err := ss.handler.Execute(true, func(tx *bolt.Tx) error {
+		ret, err := loadStrategyById(tx, id)
+		if err != nil {
+			return err
+		}
+		if ret == nil {
+			return ErrorStrategyNotFound
+		}
+		if err := updateValue(tx, tblStrategy, id, map[string]interface{}{
+			StrategyFieldEffect:     string(effect),
+			StrategyFieldPriority:   priority,
+			StrategyFieldRevision:   utils.NewUUID(),
+			StrategyFieldModifyTime: time.Now(),
+		}); err != nil {
+			log.Error("[Store][Strategy] update auth_strategy effect", zap.Error(err), zap.String("id", id))
+			return err
+		}
+		return nil
+	})
+	return store.Error(err)
+}
+
+// UpdateStrategyFunctions 更新策略生效的接口名称
+func (ss *strategyStore) UpdateStrategyFunctions(id string, functions []string) error {
+	if id == "" {
+		return store.NewStatusError(store.EmptyParamsErr, fmt.Sprintf(
+			"update auth_strategy functions missing some params, id is %s", id))
+	}
+
+	err := ss.handler.Execute(true, func(tx *bolt.Tx) error {
+		ret, err := loadStrategyById(tx, id)
+		if err != nil {
+			return err
+		}
+		if ret == nil {
+			return ErrorStrategyNotFound
+		}
+		if err := updateValue(tx, tblStrategy, id, map[string]interface{}{
+			StrategyFieldFunctions:  model.FormatStrategyFunctions(functions),
+			StrategyFieldRevision:   utils.NewUUID(),
+			StrategyFieldModifyTime: time.Now(),
+		}); err != nil {
+			log.Error("[Store][Strategy] update auth_strategy functions", zap.Error(err), zap.String("id", id))
+			return err
+		}
+		return nil
+	})
+	return store.Error(err)
+}
+
 // updateStrategy
 func (ss *strategyStore) updateStrategy(tx *bolt.Tx, modify *model.ModifyStrategyDetail,
 	saveVal *strategyForStore) error {
@@ -207,11 +322,14 @@ func computePrincipals(remove bool, principals []model.Principal, saveVal *strat
 func computeResources(remove bool, resources []model.StrategyResource, saveVal *strategyForStore) {
 	for i := range resources {
 		resource := resources[i]
+		if remove {
+			delete(saveVal.ResourceUsages, resourceUsageKey(resource.ResType, resource.ResID))
+		}
 		if resource.ResType == int32(apisecurity.ResourceType_Namespaces) {
 			if remove {
 				delete(saveVal.NsResources, resource.ResID)
 			} else {
-				saveVal.NsResources[resource.ResID] = ""
+				saveVal.NsResources[resource.ResID] = encodeResourceExpire(resource.ExpireTime)
 			}
 			continue
 		}
@@ -219,7 +337,7 @@ func computeResources(remove bool, resources []model.StrategyResource, saveVal *
 			if remove {
 				delete(saveVal.SvcResources, resource.ResID)
 			} else {
-				saveVal.SvcResources[resource.ResID] = ""
+				saveVal.SvcResources[resource.ResID] = encodeResourceExpire(resource.ExpireTime)
 			}
 			continue
 		}
@@ -227,7 +345,7 @@ func computeResources(remove bool, resources []model.StrategyResource, saveVal *
 			if remove {
 				delete(saveVal.CfgResources, resource.ResID)
 			} else {
-				saveVal.CfgResources[resource.ResID] = ""
+				saveVal.CfgResources[resource.ResID] = encodeResourceExpire(resource.ExpireTime)
 			}
 			continue
 		}
@@ -276,20 +394,32 @@ func (ss *strategyStore) operateStrategyResources(remove bool, resources []model
 
 	resMap := buildResMap(resources)
 	for id, ress := range resMap {
-		rule, err := loadStrategyById(tx, id)
-		if err != nil {
-			return err
-		}
-		if rule == nil {
-			return ErrorStrategyNotFound
+		var rules []*strategyForStore
+		if remove && id == "" {
+			// 未指定策略 ID 时，清理资源与所有策略的关联关系
+			rules, err = loadAllValidStrategies(tx)
+			if err != nil {
+				return err
+			}
+		} else {
+			rule, err := loadStrategyById(tx, id)
+			if err != nil {
+				return err
+			}
+			if rule == nil {
+				return ErrorStrategyNotFound
+			}
+			rules = []*strategyForStore{rule}
 		}
 
-		computeResources(remove, ress, rule)
-		rule.ModifyTime = time.Now()
-		if err := saveValue(tx, tblStrategy, rule.ID, rule); err != nil {
-			log.Error("[Store][Strategy] operate strategy resource", zap.Error(err),
-				zap.Bool("remove", remove), zap.String("id", id))
-			return err
+		for _, rule := range rules {
+			computeResources(remove, ress, rule)
+			rule.ModifyTime = time.Now()
+			if err := saveValue(tx, tblStrategy, rule.ID, rule); err != nil {
+				log.Error("[Store][Strategy] operate strategy resource", zap.Error(err),
+					zap.Bool("remove", remove), zap.String("id", rule.ID))
+				return err
+			}
 		}
 	}
 
@@ -302,6 +432,45 @@ func (ss *strategyStore) operateStrategyResources(remove bool, resources []model
 	return nil
 }
 
+// TransferStrategyResource 在同一个事务中将资源关联关系从 resource.StrategyID 转移到 toStrategyID,
+// 两个策略使用相同的修改时间，保证缓存在同一次刷新中感知到变化
+func (ss *strategyStore) TransferStrategyResource(resource model.StrategyResource, toStrategyID string) error {
+	if resource.StrategyID == "" || toStrategyID == "" || resource.ResID == "" {
+		return store.NewStatusError(store.EmptyParamsErr, fmt.Sprintf(
+			"transfer auth_strategy resource missing some params, from is %s, to is %s, res is %s",
+			resource.StrategyID, toStrategyID, resource.ResID))
+	}
+	err := ss.handler.Execute(true, func(tx *bolt.Tx) error {
+		from, err := loadStrategyById(tx, resource.StrategyID)
+		if err != nil {
+			return err
+		}
+		to, err := loadStrategyById(tx, toStrategyID)
+		if err != nil {
+			return err
+		}
+		if from == nil || to == nil {
+			return ErrorStrategyNotFound
+		}
+
+		attach := resource
+		attach.StrategyID = toStrategyID
+		computeResources(false, []model.StrategyResource{attach}, to)
+		computeResources(true, []model.StrategyResource{resource}, from)
+
+		now := time.Now()
+		for _, rule := range []*strategyForStore{to, from} {
+			rule.ModifyTime = now
+			if err := saveValue(tx, tblStrategy, rule.ID, rule); err != nil {
+				log.Error("[Store][Strategy] transfer strategy resource", zap.Error(err), zap.String("id", rule.ID))
+				return err
+			}
+		}
+		return nil
+	})
+	return store.Error(err)
+}
+
 func loadStrategyById(tx *bolt.Tx, id string) (*strategyForStore, error) {
 	values := make(map[string]interface{})
 
@@ -331,6 +500,25 @@ func loadStrategyById(tx *bolt.Tx, id string) (*strategyForStore, error) {
 	return ret, nil
 }
 
+func loadAllValidStrategies(tx *bolt.Tx) ([]*strategyForStore, error) {
+	values := make(map[string]interface{})
+	err := loadValuesByFilter(tx, tblStrategy, []string{StrategyFieldValid}, &strategyForStore{},
+		func(m map[string]interface{}) bool {
+			valid, ok := m[StrategyFieldValid].(bool)
+			return ok && valid
+		}, values)
+	if err != nil {
+		log.Error("[Store][Strategy] get all valid auth_strategy", zap.Error(err))
+		return nil, err
+	}
+
+	ret := make([]*strategyForStore, 0, len(values))
+	for k := range values {
+		ret = append(ret, values[k].(*strategyForStore))
+	}
+	return ret, nil
+}
+
 func buildResMap(resources []model.StrategyResource) map[string][]model.StrategyResource {
 	ret := make(map[string][]model.StrategyResource)
 
@@ -423,27 +611,30 @@ func (ss *strategyStore) GetStrategyResources(principalId string,
 func collectStrategyResources(rule *strategyForStore) []model.StrategyResource {
 	ret := make([]model.StrategyResource, 0, len(rule.NsResources)+len(rule.SvcResources)+len(rule.CfgResources))
 
-	for id := range rule.NsResources {
+	for id, expire := range rule.NsResources {
 		ret = append(ret, model.StrategyResource{
 			StrategyID: rule.ID,
 			ResType:    int32(apisecurity.ResourceType_Namespaces),
 			ResID:      id,
+			ExpireTime: decodeResourceExpire(expire),
 		})
 	}
 
-	for id := range rule.SvcResources {
+	for id, expire := range rule.SvcResources {
 		ret = append(ret, model.StrategyResource{
 			StrategyID: rule.ID,
 			ResType:    int32(apisecurity.ResourceType_Services),
 			ResID:      id,
+			ExpireTime: decodeResourceExpire(expire),
 		})
 	}
 
-	for id := range rule.CfgResources {
+	for id, expire := range rule.CfgResources {
 		ret = append(ret, model.StrategyResource{
 			StrategyID: rule.ID,
 			ResType:    int32(apisecurity.ResourceType_ConfigGroups),
 			ResID:      id,
+			ExpireTime: decodeResourceExpire(expire),
 		})
 	}
 
@@ -698,6 +889,27 @@ func (ss *strategyStore) GetStrategyDetailsForCache(mtime time.Time,
 	return strategies, nil
 }
 
+// GetAllStrategyDetailsTx get all valid strategy details in the read transaction
+func (ss *strategyStore) GetAllStrategyDetailsTx(tx store.Tx) ([]*model.StrategyDetail, error) {
+	dbTx, _ := tx.GetDelegateTx().(*bolt.Tx)
+	ret := make(map[string]interface{})
+	err := loadValuesByFilter(dbTx, tblStrategy, []string{StrategyFieldValid}, &strategyForStore{},
+		func(m map[string]interface{}) bool {
+			valid, ok := m[StrategyFieldValid].(bool)
+			return ok && valid
+		}, ret)
+	if err != nil {
+		log.Error("[Store][Strategy] get all auth_strategy in tx", zap.Error(err))
+		return nil, err
+	}
+
+	strategies := make([]*model.StrategyDetail, 0, len(ret))
+	for k := range ret {
+		strategies = append(strategies, convertForStrategyDetail(ret[k].(*strategyForStore)))
+	}
+	return strategies, nil
+}
+
 // cleanInvalidStrategy clean up authentication strategy by name
 func (ss *strategyStore) cleanInvalidStrategy(tx *bolt.Tx, name, owner string) error {
 
@@ -840,11 +1052,11 @@ func convertForStrategyStore(strategy *model.StrategyDetail) *strategyForStore {
 		res := resources[i]
 		switch res.ResType {
 		case int32(apisecurity.ResourceType_Namespaces):
-			ns[res.ResID] = ""
+			ns[res.ResID] = encodeResourceExpire(res.ExpireTime)
 		case int32(apisecurity.ResourceType_Services):
-			svc[res.ResID] = ""
+			svc[res.ResID] = encodeResourceExpire(res.ExpireTime)
 		case int32(apisecurity.ResourceType_ConfigGroups):
-			cfg[res.ResID] = ""
+			cfg[res.ResID] = encodeResourceExpire(res.ExpireTime)
 		}
 	}
 
@@ -864,6 +1076,9 @@ func convertForStrategyStore(strategy *model.StrategyDetail) *strategyForStore {
 		Revision:     strategy.Revision,
 		CreateTime:   strategy.CreateTime,
 		ModifyTime:   strategy.ModifyTime,
+		Effect:       string(strategy.Effect),
+		Priority:     strategy.Priority,
+		Functions:    model.FormatStrategyFunctions(strategy.Functions),
 	}
 }
 
@@ -891,11 +1106,12 @@ func convertForStrategyDetail(strategy *strategyForStore) *model.StrategyDetail
 	fillRes := func(idMap map[string]string, resType apisecurity.ResourceType) []model.StrategyResource {
 		res := make([]model.StrategyResource, 0, len(idMap))
 
-		for id := range idMap {
+		for id, expire := range idMap {
 			res = append(res, model.StrategyResource{
 				StrategyID: strategy.ID,
 				ResType:    int32(resType),
 				ResID:      id,
+				ExpireTime: decodeResourceExpire(expire),
 			})
 		}
 
@@ -916,12 +1132,171 @@ func convertForStrategyDetail(strategy *strategyForStore) *model.StrategyDetail
 		Default:    strategy.Default,
 		Owner:      strategy.Owner,
 		Valid:      strategy.Valid,
+		Effect:     model.StrategyEffect(strategy.Effect),
+		Priority:   strategy.Priority,
+		Functions:  model.ParseStrategyFunctions(strategy.Functions),
 		Revision:   strategy.Revision,
 		CreateTime: strategy.CreateTime,
 		ModifyTime: strategy.ModifyTime,
 	}
 }
 
+// RemoveExpiredStrategyResources 在同一个写事务中删除已经过期的资源关联关系，并返回被删除的关联关系
+func (ss *strategyStore) RemoveExpiredStrategyResources(now time.Time,
+	limit uint32) ([]model.StrategyResource, error) {
+	ret := make([]model.StrategyResource, 0, 4)
+	err := ss.handler.Execute(true, func(tx *bolt.Tx) error {
+		rules, err := loadAllValidStrategies(tx)
+		if err != nil {
+			return err
+		}
+		for _, rule := range rules {
+			if uint32(len(ret)) >= limit {
+				break
+			}
+			expired := make([]model.StrategyResource, 0, 1)
+			for _, res := range collectStrategyResources(rule) {
+				if uint32(len(ret)+len(expired)) >= limit {
+					break
+				}
+				if res.IsExpired(now) {
+					expired = append(expired, res)
+				}
+			}
+			if len(expired) == 0 {
+				continue
+			}
+			computeResources(true, expired, rule)
+			rule.ModifyTime = time.Now()
+			if err := saveValue(tx, tblStrategy, rule.ID, rule); err != nil {
+				log.Error("[Store][Strategy] remove expired strategy resources", zap.Error(err),
+					zap.String("id", rule.ID))
+				return err
+			}
+			ret = append(ret, expired...)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, store.Error(err)
+	}
+	return ret, nil
+}
+
+// UpdateStrategyResourceUsage 更新资源关联关系最近一次被使用的时间，不修改策略的修改时间，避免触发缓存刷新
+func (ss *strategyStore) UpdateStrategyResourceUsage(usages []model.StrategyResourceUsage) error {
+	if len(usages) == 0 {
+		return nil
+	}
+	byStrategy := make(map[string][]model.StrategyResourceUsage)
+	for i := range usages {
+		byStrategy[usages[i].StrategyID] = append(byStrategy[usages[i].StrategyID], usages[i])
+	}
+
+	err := ss.handler.Execute(true, func(tx *bolt.Tx) error {
+		for id, items := range byStrategy {
+			rule, err := loadStrategyById(tx, id)
+			if err != nil {
+				return err
+			}
+			if rule == nil {
+				continue
+			}
+			if rule.ResourceUsages == nil {
+				rule.ResourceUsages = make(map[string]string)
+			}
+			changed := false
+			for _, item := range items {
+				if !isResourceLinked(rule, item.ResType, item.ResID) {
+					continue
+				}
+				key := resourceUsageKey(item.ResType, item.ResID)
+				if !item.LastUsedTime.After(decodeResourceExpire(rule.ResourceUsages[key])) {
+					continue
+				}
+				rule.ResourceUsages[key] = encodeResourceExpire(item.LastUsedTime)
+				changed = true
+			}
+			if !changed {
+				continue
+			}
+			if err := updateValue(tx, tblStrategy, id, map[string]interface{}{
+				StrategyFieldResourceUsages: rule.ResourceUsages,
+			}); err != nil {
+				log.Error("[Store][Strategy] update strategy resource usage", zap.Error(err), zap.String("id", id))
+				return err
+			}
+		}
+		return nil
+	})
+	return store.Error(err)
+}
+
+// GetStrategyResourceUsage 获取策略下资源关联关系最近一次被使用的时间，未被使用过的关联关系不返回
+func (ss *strategyStore) GetStrategyResourceUsage(strategyID string) ([]model.StrategyResourceUsage, error) {
+	ret := make([]model.StrategyResourceUsage, 0, 4)
+	err := ss.handler.Execute(false, func(tx *bolt.Tx) error {
+		rule, err := loadStrategyById(tx, strategyID)
+		if err != nil || rule == nil {
+			return err
+		}
+		for _, res := range collectStrategyResources(rule) {
+			lastUsed := decodeResourceExpire(rule.ResourceUsages[resourceUsageKey(res.ResType, res.ResID)])
+			if lastUsed.IsZero() {
+				continue
+			}
+			ret = append(ret, model.StrategyResourceUsage{
+				StrategyID:   res.StrategyID,
+				ResType:      res.ResType,
+				ResID:        res.ResID,
+				LastUsedTime: lastUsed,
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, store.Error(err)
+	}
+	return ret, nil
+}
+
+func isResourceLinked(rule *strategyForStore, resType int32, resID string) bool {
+	var resources map[string]string
+	switch resType {
+	case int32(apisecurity.ResourceType_Namespaces):
+		resources = rule.NsResources
+	case int32(apisecurity.ResourceType_Services):
+		resources = rule.SvcResources
+	case int32(apisecurity.ResourceType_ConfigGroups):
+		resources = rule.CfgResources
+	}
+	_, ok := resources[resID]
+	return ok
+}
+
+func resourceUsageKey(resType int32, resID string) string {
+	return strconv.Itoa(int(resType)) + "_" + resID
+}
+
+// encodeResourceExpire 资源关联关系的过期时间以 unix 秒保存在资源 map 的 value 中, 空字符串表示永不过期
+func encodeResourceExpire(expireTime time.Time) string {
+	if expireTime.IsZero() {
+		return ""
+	}
+	return strconv.FormatInt(expireTime.Unix(), 10)
+}
+
+func decodeResourceExpire(val string) time.Time {
+	if val == "" {
+		return time.Time{}
+	}
+	sec, err := strconv.ParseInt(val, 10, 64)
+	if err != nil || sec <= 0 {
+		return time.Time{}
+	}
+	return time.Unix(sec, 0)
+}
+
 func initStrategy(rule *model.StrategyDetail) {
 	if rule != nil {
 		rule.Valid = true
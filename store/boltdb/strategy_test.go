@@ -76,6 +76,26 @@ func Test_strategyStore_AddStrategy(t *testing.T) {
 	})
 }
 
+func Test_strategyStore_AddStrategies(t *testing.T) {
+	CreateTableDBHandlerAndRun(t, "test_strategy", func(t *testing.T, handler BoltHandler) {
+		ss := &strategyStore{handler: handler}
+
+		rules := createTestStrategy(3)
+		err := ss.AddStrategies(rules)
+		assert.Nil(t, err, "add strategies must success")
+
+		for i := range rules {
+			ret, err := ss.GetDefaultStrategyDetailByPrincipal(fmt.Sprintf("user-%d", i), model.PrincipalUser)
+			assert.Nil(t, err, "get default strategy must success")
+			assert.Equal(t, rules[i].ID, ret.ID)
+		}
+
+		rules[1].ID = ""
+		err = ss.AddStrategies(rules)
+		assert.NotNil(t, err, "add strategies without id must fail")
+	})
+}
+
 func Test_strategyStore_UpdateStrategy(t *testing.T) {
 	CreateTableDBHandlerAndRun(t, "test_strategy", func(t *testing.T, handler BoltHandler) {
 		ss := &strategyStore{handler: handler}
@@ -118,6 +138,37 @@ func Test_strategyStore_UpdateStrategy(t *testing.T) {
 	})
 }
 
+func Test_strategyStore_UpdateStrategyEffect(t *testing.T) {
+	CreateTableDBHandlerAndRun(t, "test_strategy", func(t *testing.T, handler BoltHandler) {
+		ss := &strategyStore{handler: handler}
+
+		rules := createTestStrategy(1)
+		err := ss.AddStrategy(rules[0])
+		assert.Nil(t, err, "add strategy must success")
+
+		err = ss.UpdateStrategyEffect(rules[0].ID, model.StrategyEffectDeny, 10)
+		assert.Nil(t, err, "update strategy effect must success")
+
+		v, err := ss.GetStrategyDetail(rules[0].ID)
+		assert.Nil(t, err, "get strategy must success")
+		assert.True(t, v.IsDeny(), "effect")
+		assert.Equal(t, int32(10), v.Priority, "priority")
+		assert.NotEqual(t, rules[0].Revision, v.Revision, "revision")
+
+		// 修改策略的其他信息时保留授权效果以及优先级
+		err = ss.UpdateStrategy(&model.ModifyStrategyDetail{ID: rules[0].ID, Action: rules[0].Action,
+			Comment: "update-strategy"})
+		assert.Nil(t, err, "update strategy must success")
+		v, err = ss.GetStrategyDetail(rules[0].ID)
+		assert.Nil(t, err, "get strategy must success")
+		assert.True(t, v.IsDeny(), "effect")
+		assert.Equal(t, int32(10), v.Priority, "priority")
+
+		err = ss.UpdateStrategyEffect("not-exist", model.StrategyEffectDeny, 10)
+		assert.Error(t, err, "strategy not found")
+	})
+}
+
 func Test_strategyStore_DeleteStrategy(t *testing.T) {
 	CreateTableDBHandlerAndRun(t, "test_strategy", func(t *testing.T, handler BoltHandler) {
 		ss := &strategyStore{handler: handler}
@@ -166,6 +217,45 @@ func Test_strategyStore_RemoveStrategyResources(t *testing.T) {
 	})
 }
 
+func Test_strategyStore_RemoveStrategyResourcesWithoutStrategyID(t *testing.T) {
+	CreateTableDBHandlerAndRun(t, "test_strategy", func(t *testing.T, handler BoltHandler) {
+		ss := &strategyStore{handler: handler}
+
+		rules := createTestStrategy(2)
+		for i := range rules {
+			err := ss.AddStrategy(rules[i])
+			assert.Nil(t, err, "add strategy must success")
+		}
+
+		err := ss.LooseAddStrategyResources([]model.StrategyResource{
+			{
+				StrategyID: rules[1].ID,
+				ResType:    int32(apisecurity.ResourceType_Namespaces),
+				ResID:      "namespace_0",
+			},
+		})
+		assert.Nil(t, err, "LooseAddStrategyResources must success")
+
+		// 不指定策略 ID，清理资源与所有策略的关联关系
+		err = ss.RemoveStrategyResources([]model.StrategyResource{
+			{
+				ResType: int32(apisecurity.ResourceType_Namespaces),
+				ResID:   "namespace_0",
+			},
+		})
+		assert.Nil(t, err, "RemoveStrategyResources must success")
+
+		for i := range rules {
+			ret, err := ss.GetStrategyDetail(rules[i].ID)
+			assert.Nil(t, err, "get strategy must success")
+			for _, res := range ret.Resources {
+				assert.False(t, res.ResType == int32(apisecurity.ResourceType_Namespaces) && res.ResID == "namespace_0",
+					"resource=%#v", res)
+			}
+		}
+	})
+}
+
 func Test_strategyStore_LooseAddStrategyResources(t *testing.T) {
 	CreateTableDBHandlerAndRun(t, "test_strategy", func(t *testing.T, handler BoltHandler) {
 		ss := &strategyStore{handler: handler}
@@ -203,6 +293,156 @@ func Test_strategyStore_LooseAddStrategyResources(t *testing.T) {
 	})
 }
 
+func Test_strategyStore_RemoveExpiredStrategyResources(t *testing.T) {
+	CreateTableDBHandlerAndRun(t, "test_strategy", func(t *testing.T, handler BoltHandler) {
+		ss := &strategyStore{handler: handler}
+
+		rules := createTestStrategy(1)
+		err := ss.AddStrategy(rules[0])
+		assert.Nil(t, err, "add strategy must success")
+
+		expireTime := time.Unix(time.Now().Add(-time.Minute).Unix(), 0)
+		err = ss.LooseAddStrategyResources([]model.StrategyResource{
+			{
+				StrategyID: rules[0].ID,
+				ResType:    int32(apisecurity.ResourceType_Services),
+				ResID:      "service_expired",
+				ExpireTime: expireTime,
+			},
+			{
+				StrategyID: rules[0].ID,
+				ResType:    int32(apisecurity.ResourceType_Services),
+				ResID:      "service_temporary",
+				ExpireTime: time.Now().Add(time.Hour),
+			},
+		})
+		assert.Nil(t, err, "LooseAddStrategyResources must success")
+
+		before, err := ss.GetStrategyDetail(rules[0].ID)
+		assert.Nil(t, err, "get strategy must success")
+		assert.Equal(t, 3, len(before.Resources))
+
+		// 宽限期之内的关联关系不清理
+		expired, err := ss.RemoveExpiredStrategyResources(time.Now().Add(-time.Hour), 10)
+		assert.Nil(t, err, "RemoveExpiredStrategyResources must success")
+		assert.Empty(t, expired)
+
+		expired, err = ss.RemoveExpiredStrategyResources(time.Now(), 10)
+		assert.Nil(t, err, "RemoveExpiredStrategyResources must success")
+		assert.Equal(t, []model.StrategyResource{
+			{
+				StrategyID: rules[0].ID,
+				ResType:    int32(apisecurity.ResourceType_Services),
+				ResID:      "service_expired",
+				ExpireTime: expireTime,
+			},
+		}, expired)
+
+		// 清理过期的关联关系后，其余的资源保留
+		ret, err := ss.GetStrategyDetail(rules[0].ID)
+		assert.Nil(t, err, "get strategy must success")
+		assert.Equal(t, 2, len(ret.Resources))
+		expired, err = ss.RemoveExpiredStrategyResources(time.Now(), 10)
+		assert.Nil(t, err, "RemoveExpiredStrategyResources must success")
+		assert.Empty(t, expired)
+	})
+}
+
+func Test_strategyStore_StrategyResourceUsage(t *testing.T) {
+	CreateTableDBHandlerAndRun(t, "test_strategy", func(t *testing.T, handler BoltHandler) {
+		ss := &strategyStore{handler: handler}
+
+		rules := createTestStrategy(1)
+		err := ss.AddStrategy(rules[0])
+		assert.Nil(t, err, "add strategy must success")
+		before, err := ss.GetStrategyDetail(rules[0].ID)
+		assert.Nil(t, err, "get strategy must success")
+
+		lastUsed := time.Unix(time.Now().Unix(), 0)
+		usage := model.StrategyResourceUsage{
+			StrategyID:   rules[0].ID,
+			ResType:      int32(apisecurity.ResourceType_Namespaces),
+			ResID:        "namespace_0",
+			LastUsedTime: lastUsed,
+		}
+		err = ss.UpdateStrategyResourceUsage([]model.StrategyResourceUsage{
+			usage,
+			// 未关联的资源忽略
+			{
+				StrategyID:   rules[0].ID,
+				ResType:      int32(apisecurity.ResourceType_Services),
+				ResID:        "service_not_linked",
+				LastUsedTime: lastUsed,
+			},
+			// 更早的使用时间不会覆盖
+			{
+				StrategyID:   rules[0].ID,
+				ResType:      int32(apisecurity.ResourceType_Namespaces),
+				ResID:        "namespace_0",
+				LastUsedTime: lastUsed.Add(-time.Minute),
+			},
+		})
+		assert.Nil(t, err, "UpdateStrategyResourceUsage must success")
+
+		usages, err := ss.GetStrategyResourceUsage(rules[0].ID)
+		assert.Nil(t, err, "GetStrategyResourceUsage must success")
+		assert.Equal(t, []model.StrategyResourceUsage{usage}, usages)
+
+		// 不修改策略的修改时间，避免触发缓存刷新
+		after, err := ss.GetStrategyDetail(rules[0].ID)
+		assert.Nil(t, err, "get strategy must success")
+		assert.Equal(t, before.ModifyTime, after.ModifyTime)
+
+		// 解除关联后不再返回
+		err = ss.RemoveStrategyResources([]model.StrategyResource{
+			{StrategyID: rules[0].ID, ResType: usage.ResType, ResID: usage.ResID},
+		})
+		assert.Nil(t, err, "RemoveStrategyResources must success")
+		usages, err = ss.GetStrategyResourceUsage(rules[0].ID)
+		assert.Nil(t, err, "GetStrategyResourceUsage must success")
+		assert.Empty(t, usages)
+	})
+}
+
+func Test_strategyStore_TransferStrategyResource(t *testing.T) {
+	CreateTableDBHandlerAndRun(t, "test_strategy", func(t *testing.T, handler BoltHandler) {
+		ss := &strategyStore{handler: handler}
+
+		rules := createTestStrategy(2)
+		for i := range rules {
+			err := ss.AddStrategy(rules[i])
+			assert.Nil(t, err, "add strategy must success")
+		}
+
+		res := model.StrategyResource{
+			StrategyID: rules[0].ID,
+			ResType:    int32(apisecurity.ResourceType_Namespaces),
+			ResID:      "namespace_0",
+		}
+		err := ss.TransferStrategyResource(res, rules[1].ID)
+		assert.Nil(t, err, "TransferStrategyResource must success")
+
+		from, err := ss.GetStrategyDetail(rules[0].ID)
+		assert.Nil(t, err, "get strategy must success")
+		to, err := ss.GetStrategyDetail(rules[1].ID)
+		assert.Nil(t, err, "get strategy must success")
+		for _, item := range from.Resources {
+			assert.False(t, item.ResType == res.ResType && item.ResID == res.ResID, "resource must be removed")
+		}
+		found := false
+		for _, item := range to.Resources {
+			if item.ResType == res.ResType && item.ResID == res.ResID {
+				found = true
+			}
+		}
+		assert.True(t, found, "resource must be attached to target")
+		assert.Equal(t, from.ModifyTime, to.ModifyTime)
+
+		err = ss.TransferStrategyResource(res, "not_exist")
+		assert.EqualError(t, err, ErrorStrategyNotFound.Error())
+	})
+}
+
 func Test_strategyStore_GetStrategyDetail(t *testing.T) {
 	CreateTableDBHandlerAndRun(t, "test_strategy", func(t *testing.T, handler BoltHandler) {
 		ss := &strategyStore{handler: handler}
@@ -268,3 +508,61 @@ func Test_strategyStore_GetDefaultStrategyDetailByPrincipal(t *testing.T) {
 		assert.Equal(t, rules[1], res)
 	})
 }
+
+func Test_strategyStore_GetAllStrategyDetailsTx(t *testing.T) {
+	CreateTableDBHandlerAndRun(t, "test_strategy", func(t *testing.T, handler BoltHandler) {
+		ss := &strategyStore{handler: handler}
+
+		rules := createTestStrategy(3)
+		for i := range rules {
+			err := ss.AddStrategy(rules[i])
+			assert.Nil(t, err, "add strategy must success")
+		}
+		err := ss.DeleteStrategy(rules[2].ID)
+		assert.Nil(t, err, "delete strategy must success")
+
+		tx, err := handler.StartTx()
+		assert.Nil(t, err)
+		defer func() {
+			_ = tx.Rollback()
+		}()
+
+		ret, err := ss.GetAllStrategyDetailsTx(tx)
+		assert.Nil(t, err, "GetAllStrategyDetailsTx must success")
+		assert.Equal(t, 2, len(ret))
+		for i := range ret {
+			assert.True(t, ret[i].Valid)
+			assert.Equal(t, 1, len(ret[i].Principals))
+			assert.Equal(t, 1, len(ret[i].Resources))
+		}
+	})
+}
+
+func Test_strategyStore_UpdateStrategyFunctions(t *testing.T) {
+	CreateTableDBHandlerAndRun(t, "test_strategy", func(t *testing.T, handler BoltHandler) {
+		ss := &strategyStore{handler: handler}
+
+		rules := createTestStrategy(1)
+		err := ss.AddStrategy(rules[0])
+		assert.Nil(t, err, "add strategy must success")
+
+		functions := []string{"DescribeServices", "Describe*"}
+		err = ss.UpdateStrategyFunctions(rules[0].ID, functions)
+		assert.Nil(t, err, "update strategy functions must success")
+
+		v, err := ss.GetStrategyDetail(rules[0].ID)
+		assert.Nil(t, err, "get strategy must success")
+		assert.Equal(t, functions, v.Functions, "functions")
+		assert.NotEqual(t, rules[0].Revision, v.Revision, "revision")
+
+		// 清空后对全部接口生效
+		err = ss.UpdateStrategyFunctions(rules[0].ID, nil)
+		assert.Nil(t, err, "update strategy functions must success")
+		v, err = ss.GetStrategyDetail(rules[0].ID)
+		assert.Nil(t, err, "get strategy must success")
+		assert.Empty(t, v.Functions, "functions")
+
+		err = ss.UpdateStrategyFunctions("not-exist", functions)
+		assert.Error(t, err, "strategy not found")
+	})
+}
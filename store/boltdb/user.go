@@ -51,6 +51,14 @@ const (
 	UserFieldToken string = "Token"
 	// UserFieldTokenEnable 用户Token是否可用字段
 	UserFieldTokenEnable string = "TokenEnable"
+	// UserFieldTokenExpireAt 用户Token过期时间字段
+	UserFieldTokenExpireAt string = "TokenExpireAt"
+	// UserFieldPasswordModifyTime 用户密码修改时间字段
+	UserFieldPasswordModifyTime string = "PasswordModifyTime"
+	// UserFieldLoginFailures 用户连续登录失败次数字段
+	UserFieldLoginFailures string = "LoginFailures"
+	// UserFieldLockedUntil 用户锁定时间字段
+	UserFieldLockedUntil string = "LockedUntil"
 	// UserFieldValid 用户逻辑删除字段
 	UserFieldValid string = "Valid"
 	// UserFieldComment 用户备注字段
@@ -143,6 +151,8 @@ func (us *userStore) UpdateUser(user *model.User) error {
 	properties[UserFieldComment] = user.Comment
 	properties[UserFieldToken] = user.Token
 	properties[UserFieldTokenEnable] = user.TokenEnable
+	properties[UserFieldTokenExpireAt] = encodeResourceExpire(user.TokenExpireAt)
+	properties[UserFieldPasswordModifyTime] = encodeResourceExpire(user.PasswordModifyTime)
 	properties[UserFieldEmail] = user.Email
 	properties[UserFieldMobile] = user.Mobile
 	properties[UserFieldPassword] = user.Password
@@ -157,6 +167,54 @@ func (us *userStore) UpdateUser(user *model.User) error {
 	return nil
 }
 
+// IncrUserLoginFailures 在同一个写事务中读取并累加登录失败次数，boltdb 的写事务是串行执行的
+func (us *userStore) IncrUserLoginFailures(id string, maxFailures int, lockedUntil time.Time) (bool, error) {
+	if id == "" {
+		return false, store.NewStatusError(store.EmptyParamsErr, "incr user login failures missing id")
+	}
+
+	var locked bool
+	err := us.handler.Execute(true, func(tx *bolt.Tx) error {
+		user, err := us.getUser(tx, id)
+		if err != nil || user == nil {
+			return err
+		}
+		properties := map[string]interface{}{
+			UserFieldLoginFailures: user.LoginFailures + 1,
+			UserFieldModifyTime:    time.Now(),
+		}
+		if user.LoginFailures+1 >= maxFailures {
+			locked = true
+			properties[UserFieldLoginFailures] = 0
+			properties[UserFieldLockedUntil] = encodeResourceExpire(lockedUntil)
+		}
+		return updateValue(tx, tblUser, id, properties)
+	})
+	if err != nil {
+		log.Error("[Store][User] incr user login failures", zap.Error(err), zap.String("id", id))
+		return false, err
+	}
+	return locked, nil
+}
+
+// ResetUserLoginFailures 清空登录失败次数以及锁定状态
+func (us *userStore) ResetUserLoginFailures(id string) error {
+	if id == "" {
+		return store.NewStatusError(store.EmptyParamsErr, "reset user login failures missing id")
+	}
+
+	properties := map[string]interface{}{
+		UserFieldLoginFailures: 0,
+		UserFieldLockedUntil:   encodeResourceExpire(time.Time{}),
+		UserFieldModifyTime:    time.Now(),
+	}
+	if err := us.handler.UpdateValue(tblUser, id, properties); err != nil {
+		log.Error("[Store][User] reset user login failures", zap.Error(err), zap.String("id", id))
+		return err
+	}
+	return nil
+}
+
 // DeleteUser 删除用户
 func (us *userStore) DeleteUser(user *model.User) error {
 	if user.ID == "" {
@@ -512,6 +570,27 @@ func (us *userStore) GetUsersForCache(mtime time.Time, firstUpdate bool) ([]*mod
 	return users, nil
 }
 
+// GetAllUsersTx 在读事务中获取全部有效的用户
+func (us *userStore) GetAllUsersTx(tx store.Tx) ([]*model.User, error) {
+	dbTx, _ := tx.GetDelegateTx().(*bolt.Tx)
+	ret := make(map[string]interface{})
+	err := loadValuesByFilter(dbTx, tblUser, []string{UserFieldValid}, &userForStore{},
+		func(m map[string]interface{}) bool {
+			valid, ok := m[UserFieldValid].(bool)
+			return ok && valid
+		}, ret)
+	if err != nil {
+		log.Error("[Store][User] get all users in tx", zap.Error(err))
+		return nil, err
+	}
+
+	users := make([]*model.User, 0, len(ret))
+	for k := range ret {
+		users = append(users, converToUserModel(ret[k].(*userForStore)))
+	}
+	return users, nil
+}
+
 // doPage 进行分页
 func doUserPage(ret map[string]interface{}, offset, limit uint32) []*model.User {
 	users := make([]*model.User, 0, len(ret))
@@ -544,35 +623,43 @@ func doUserPage(ret map[string]interface{}, offset, limit uint32) []*model.User
 
 func converToUserStore(user *model.User) *userForStore {
 	return &userForStore{
-		ID:          user.ID,
-		Name:        user.Name,
-		Password:    user.Password,
-		Owner:       user.Owner,
-		Source:      user.Source,
-		Type:        int(user.Type),
-		Token:       user.Token,
-		TokenEnable: user.TokenEnable,
-		Valid:       user.Valid,
-		Comment:     user.Comment,
-		CreateTime:  user.CreateTime,
-		ModifyTime:  user.ModifyTime,
+		ID:                 user.ID,
+		Name:               user.Name,
+		Password:           user.Password,
+		Owner:              user.Owner,
+		Source:             user.Source,
+		Type:               int(user.Type),
+		Token:              user.Token,
+		TokenEnable:        user.TokenEnable,
+		TokenExpireAt:      encodeResourceExpire(user.TokenExpireAt),
+		PasswordModifyTime: encodeResourceExpire(user.PasswordModifyTime),
+		LoginFailures:      user.LoginFailures,
+		LockedUntil:        encodeResourceExpire(user.LockedUntil),
+		Valid:              user.Valid,
+		Comment:            user.Comment,
+		CreateTime:         user.CreateTime,
+		ModifyTime:         user.ModifyTime,
 	}
 }
 
 func converToUserModel(user *userForStore) *model.User {
 	return &model.User{
-		ID:          user.ID,
-		Name:        user.Name,
-		Password:    user.Password,
-		Owner:       user.Owner,
-		Source:      user.Source,
-		Type:        model.UserRoleType(user.Type),
-		Token:       user.Token,
-		TokenEnable: user.TokenEnable,
-		Valid:       user.Valid,
-		Comment:     user.Comment,
-		CreateTime:  user.CreateTime,
-		ModifyTime:  user.ModifyTime,
+		ID:                 user.ID,
+		Name:               user.Name,
+		Password:           user.Password,
+		Owner:              user.Owner,
+		Source:             user.Source,
+		Type:               model.UserRoleType(user.Type),
+		Token:              user.Token,
+		TokenEnable:        user.TokenEnable,
+		TokenExpireAt:      decodeResourceExpire(user.TokenExpireAt),
+		PasswordModifyTime: decodeResourceExpire(user.PasswordModifyTime),
+		LoginFailures:      user.LoginFailures,
+		LockedUntil:        decodeResourceExpire(user.LockedUntil),
+		Valid:              user.Valid,
+		Comment:            user.Comment,
+		CreateTime:         user.CreateTime,
+		ModifyTime:         user.ModifyTime,
 	}
 }
 
@@ -596,6 +683,13 @@ type userForStore struct {
 	Email       string
 	Token       string
 	TokenEnable bool
+	// TokenExpireAt 过期时间的 unix 秒，空字符串表示永不过期
+	TokenExpireAt string
+	// PasswordModifyTime 密码修改时间的 unix 秒，空字符串表示未记录
+	PasswordModifyTime string
+	LoginFailures      int
+	// LockedUntil 锁定到期时间的 unix 秒，空字符串表示未被锁定
+	LockedUntil string
 	Valid       bool
 	Comment     string
 	CreateTime  time.Time
@@ -0,0 +1,143 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package boltdb
+
+import (
+	"fmt"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+	"go.uber.org/zap"
+
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/store"
+)
+
+const (
+	tblUserSession string = "user_session"
+
+	UserSessionFieldUserID     string = "UserID"
+	UserSessionFieldValid      string = "Valid"
+	UserSessionFieldModifyTime string = "ModifyTime"
+)
+
+type userSessionStore struct {
+	handler BoltHandler
+}
+
+// AddUserSession 新增控制台登录会话
+func (ss *userSessionStore) AddUserSession(session *model.UserSession) error {
+	if session.ID == "" || session.UserID == "" {
+		return store.NewStatusError(store.EmptyParamsErr, fmt.Sprintf(
+			"add user session missing some params, id is %s, user is %s", session.ID, session.UserID))
+	}
+
+	session.Valid = true
+	session.CreateTime = time.Now()
+	session.ModifyTime = session.CreateTime
+	err := ss.handler.Execute(true, func(tx *bolt.Tx) error {
+		return saveValue(tx, tblUserSession, session.ID, session)
+	})
+	if err != nil {
+		log.Error("[Store][UserSession] add user session", zap.String("user", session.UserID), zap.Error(err))
+	}
+	return store.Error(err)
+}
+
+// RevokeUserSessions 注销用户的会话，ids 为空时注销该用户的全部会话
+func (ss *userSessionStore) RevokeUserSessions(userID string, ids []string) error {
+	if userID == "" {
+		return store.NewStatusError(store.EmptyParamsErr, "revoke user sessions missing user id")
+	}
+
+	targets := make(map[string]struct{}, len(ids))
+	for i := range ids {
+		targets[ids[i]] = struct{}{}
+	}
+	err := ss.handler.Execute(true, func(tx *bolt.Tx) error {
+		fields := []string{UserSessionFieldUserID, UserSessionFieldValid}
+		values := make(map[string]interface{})
+		err := loadValuesByFilter(tx, tblUserSession, fields, &model.UserSession{},
+			func(m map[string]interface{}) bool {
+				valid, _ := m[UserSessionFieldValid].(bool)
+				return valid && m[UserSessionFieldUserID] == userID
+			}, values)
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		for id := range values {
+			if _, ok := targets[id]; len(targets) != 0 && !ok {
+				continue
+			}
+			if err := updateValue(tx, tblUserSession, id, map[string]interface{}{
+				UserSessionFieldValid:      false,
+				UserSessionFieldModifyTime: now,
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		log.Error("[Store][UserSession] revoke user sessions", zap.String("user", userID), zap.Error(err))
+	}
+	return store.Error(err)
+}
+
+// GetUserSession 查询会话，包含已经注销的会话，会话不存在时返回 nil
+func (ss *userSessionStore) GetUserSession(id string) (*model.UserSession, error) {
+	if id == "" {
+		return nil, store.NewStatusError(store.EmptyParamsErr, "get user session missing id")
+	}
+
+	values, err := ss.handler.LoadValues(tblUserSession, []string{id}, &model.UserSession{})
+	if err != nil {
+		log.Error("[Store][UserSession] get user session", zap.String("id", id), zap.Error(err))
+		return nil, store.Error(err)
+	}
+	session, ok := values[id].(*model.UserSession)
+	if !ok {
+		return nil, nil
+	}
+	return session, nil
+}
+
+// GetUserSessionsForCache 查询 mtime 之后发生变化的会话，包含已经注销的会话，主要用于 Cache 更新
+func (ss *userSessionStore) GetUserSessionsForCache(mtime time.Time,
+	firstUpdate bool) ([]*model.UserSession, error) {
+	fields := []string{UserSessionFieldModifyTime, UserSessionFieldValid}
+	values, err := ss.handler.LoadValuesByFilter(tblUserSession, fields, &model.UserSession{},
+		func(m map[string]interface{}) bool {
+			if firstUpdate {
+				valid, _ := m[UserSessionFieldValid].(bool)
+				return valid
+			}
+			mt, _ := m[UserSessionFieldModifyTime].(time.Time)
+			return mt.After(mtime)
+		})
+	if err != nil {
+		return nil, store.Error(err)
+	}
+
+	sessions := make([]*model.UserSession, 0, len(values))
+	for k := range values {
+		sessions = append(sessions, values[k].(*model.UserSession))
+	}
+	return sessions, nil
+}
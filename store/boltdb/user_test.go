@@ -114,6 +114,78 @@ func Test_userStore_UpdateUser(t *testing.T) {
 	})
 }
 
+func Test_userStore_UpdateUserTokenExpireAt(t *testing.T) {
+	CreateTableDBHandlerAndRun(t, "test_user", func(t *testing.T, handler BoltHandler) {
+		us := &userStore{handler: handler}
+
+		users := createTestUsers(1)
+		if err := us.AddUser(users[0]); err != nil {
+			t.Fatal(err)
+		}
+
+		// 未设置过期时间的 token 永不过期
+		ret, err := us.GetUser(users[0].ID)
+		assert.NoError(t, err)
+		assert.True(t, ret.TokenExpireAt.IsZero())
+
+		expireAt := time.Unix(time.Now().Add(time.Hour).Unix(), 0)
+		users[0].TokenExpireAt = expireAt
+		assert.NoError(t, us.UpdateUser(users[0]))
+		ret, err = us.GetUser(users[0].ID)
+		assert.NoError(t, err)
+		assert.True(t, expireAt.Equal(ret.TokenExpireAt))
+
+		users[0].TokenExpireAt = time.Time{}
+		assert.NoError(t, us.UpdateUser(users[0]))
+		ret, err = us.GetUser(users[0].ID)
+		assert.NoError(t, err)
+		assert.True(t, ret.TokenExpireAt.IsZero())
+	})
+}
+
+func Test_userStore_UpdateUserLoginState(t *testing.T) {
+	CreateTableDBHandlerAndRun(t, "test_user", func(t *testing.T, handler BoltHandler) {
+		us := &userStore{handler: handler}
+
+		users := createTestUsers(1)
+		users[0].PasswordModifyTime = time.Unix(time.Now().Unix(), 0)
+		if err := us.AddUser(users[0]); err != nil {
+			t.Fatal(err)
+		}
+
+		ret, err := us.GetUser(users[0].ID)
+		assert.NoError(t, err)
+		assert.True(t, users[0].PasswordModifyTime.Equal(ret.PasswordModifyTime))
+		assert.False(t, ret.IsLocked(time.Now()))
+
+		lockedUntil := time.Unix(time.Now().Add(time.Hour).Unix(), 0)
+		locked, err := us.IncrUserLoginFailures(users[0].ID, 3, lockedUntil)
+		assert.NoError(t, err)
+		assert.False(t, locked)
+		locked, err = us.IncrUserLoginFailures(users[0].ID, 3, lockedUntil)
+		assert.NoError(t, err)
+		assert.False(t, locked)
+		ret, err = us.GetUser(users[0].ID)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, ret.LoginFailures)
+
+		// UpdateUser 使用的旧对象不会覆盖登录失败次数
+		assert.NoError(t, us.UpdateUser(users[0]))
+		locked, err = us.IncrUserLoginFailures(users[0].ID, 3, lockedUntil)
+		assert.NoError(t, err)
+		assert.True(t, locked)
+		ret, err = us.GetUser(users[0].ID)
+		assert.NoError(t, err)
+		assert.Equal(t, 0, ret.LoginFailures)
+		assert.True(t, lockedUntil.Equal(ret.LockedUntil))
+
+		assert.NoError(t, us.ResetUserLoginFailures(users[0].ID))
+		ret, err = us.GetUser(users[0].ID)
+		assert.NoError(t, err)
+		assert.False(t, ret.IsLocked(time.Now()))
+	})
+}
+
 func Test_userStore_DeleteUser(t *testing.T) {
 	CreateTableDBHandlerAndRun(t, "test_user", func(t *testing.T, handler BoltHandler) {
 		us := &userStore{handler: handler}
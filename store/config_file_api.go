@@ -38,6 +38,8 @@ type ConfigFileGroupStore interface {
 	CreateConfigFileGroup(fileGroup *model.ConfigFileGroup) (*model.ConfigFileGroup, error)
 	// UpdateConfigFileGroup 更新配置文件组
 	UpdateConfigFileGroup(fileGroup *model.ConfigFileGroup) error
+	// UpdateConfigFileGroupOwner 更新配置文件组的负责人
+	UpdateConfigFileGroupOwner(namespace, name, owner string) error
 	// GetConfigFileGroup 获取单个配置文件组
 	GetConfigFileGroup(namespace, name string) (*model.ConfigFileGroup, error)
 	// DeleteConfigFileGroup 删除配置文件组
@@ -46,6 +46,10 @@ type NamingModuleStore interface {
 	GroupStore
 	// StrategyStore 鉴权策略接口
 	StrategyStore
+	// RoleStore 角色接口
+	RoleStore
+	// UserSessionStore 控制台登录会话接口
+	UserSessionStore
 	// RoutingConfigStoreV2 路由策略 v2 接口
 	RoutingConfigStoreV2
 	// FaultDetectRuleStore fault detect rule interface
@@ -0,0 +1,40 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package store
+
+import (
+	"time"
+
+	"github.com/polarismesh/polaris/common/model"
+)
+
+// HistoryStore 操作记录存储接口
+type HistoryStore interface {
+	// AddRecordEntries 批量保存操作记录
+	AddRecordEntries(entries []*model.RecordEntry) error
+	// CleanRecordEntries 清理 endTime 之前发生的操作记录，exemptTypes 中的操作类型不清理，返回清理的条数
+	CleanRecordEntries(endTime time.Time, exemptTypes []model.OperationType, limit uint64) (uint64, error)
+	// CleanExceedRecordEntries 仅保留最新的 retainCount 条操作记录，exemptTypes 中的操作类型不清理也不参与计数，返回清理的条数
+	CleanExceedRecordEntries(retainCount uint64, exemptTypes []model.OperationType, limit uint64) (uint64, error)
+	// GetRecordEntriesByChangeSet 查询同一个变更集下的全部操作记录，按照写入顺序返回
+	GetRecordEntriesByChangeSet(changeSetID string) ([]*model.RecordEntry, error)
+	// GetRecordEntriesSince 查询 since 之后发生的操作记录，超过 limit 条时保留最新的 limit 条，按照写入顺序返回
+	GetRecordEntriesSince(since time.Time, limit uint64) ([]*model.RecordEntry, error)
+	// GetRecordEntries 分页查询满足条件的操作记录，返回满足条件的总数，按照发生时间从新到旧返回
+	GetRecordEntries(filter *model.RecordEntryFilter, offset, limit uint32) (uint32, []*model.RecordEntry, error)
+}
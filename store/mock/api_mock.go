@@ -107,6 +107,20 @@ func (mr *MockStoreMockRecorder) AddNamespace(namespace interface{}) *gomock.Cal
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddNamespace", reflect.TypeOf((*MockStore)(nil).AddNamespace), namespace)
 }
 
+// AddRecordEntries mocks base method.
+func (m *MockStore) AddRecordEntries(entries []*model.RecordEntry) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddRecordEntries", entries)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddRecordEntries indicates an expected call of AddRecordEntries.
+func (mr *MockStoreMockRecorder) AddRecordEntries(entries interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddRecordEntries", reflect.TypeOf((*MockStore)(nil).AddRecordEntries), entries)
+}
+
 // AddService mocks base method.
 func (m *MockStore) AddService(service *model.Service) error {
 	m.ctrl.T.Helper()
@@ -135,6 +149,20 @@ func (mr *MockStoreMockRecorder) AddServiceContractInterfaces(contract interface
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddServiceContractInterfaces", reflect.TypeOf((*MockStore)(nil).AddServiceContractInterfaces), contract)
 }
 
+// AddStrategies mocks base method.
+func (m *MockStore) AddStrategies(strategies []*model.StrategyDetail) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddStrategies", strategies)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddStrategies indicates an expected call of AddStrategies.
+func (mr *MockStoreMockRecorder) AddStrategies(strategies interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddStrategies", reflect.TypeOf((*MockStore)(nil).AddStrategies), strategies)
+}
+
 // AddStrategy mocks base method.
 func (m *MockStore) AddStrategy(strategy *model.StrategyDetail) error {
 	m.ctrl.T.Helper()
@@ -407,6 +435,21 @@ func (mr *MockStoreMockRecorder) CleanConfigFileReleasesTx(tx, namespace, group,
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CleanConfigFileReleasesTx", reflect.TypeOf((*MockStore)(nil).CleanConfigFileReleasesTx), tx, namespace, group, fileName)
 }
 
+// CleanExceedRecordEntries mocks base method.
+func (m *MockStore) CleanExceedRecordEntries(retainCount uint64, exemptTypes []model.OperationType, limit uint64) (uint64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CleanExceedRecordEntries", retainCount, exemptTypes, limit)
+	ret0, _ := ret[0].(uint64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CleanExceedRecordEntries indicates an expected call of CleanExceedRecordEntries.
+func (mr *MockStoreMockRecorder) CleanExceedRecordEntries(retainCount, exemptTypes, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CleanExceedRecordEntries", reflect.TypeOf((*MockStore)(nil).CleanExceedRecordEntries), retainCount, exemptTypes, limit)
+}
+
 // CleanGrayResource mocks base method.
 func (m *MockStore) CleanGrayResource(tx store.Tx, data *model.GrayResource) error {
 	m.ctrl.T.Helper()
@@ -435,6 +478,21 @@ func (mr *MockStoreMockRecorder) CleanInstance(instanceID interface{}) *gomock.C
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CleanInstance", reflect.TypeOf((*MockStore)(nil).CleanInstance), instanceID)
 }
 
+// CleanRecordEntries mocks base method.
+func (m *MockStore) CleanRecordEntries(endTime time.Time, exemptTypes []model.OperationType, limit uint64) (uint64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CleanRecordEntries", endTime, exemptTypes, limit)
+	ret0, _ := ret[0].(uint64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CleanRecordEntries indicates an expected call of CleanRecordEntries.
+func (mr *MockStoreMockRecorder) CleanRecordEntries(endTime, exemptTypes, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CleanRecordEntries", reflect.TypeOf((*MockStore)(nil).CleanRecordEntries), endTime, exemptTypes, limit)
+}
+
 // CountConfigFileEachGroup mocks base method.
 func (m *MockStore) CountConfigFileEachGroup() (map[string]map[string]int64, error) {
 	m.ctrl.T.Helper()
@@ -1017,6 +1075,51 @@ func (mr *MockStoreMockRecorder) GenNextL5Sid(layoutID interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GenNextL5Sid", reflect.TypeOf((*MockStore)(nil).GenNextL5Sid), layoutID)
 }
 
+// GetAllGroupsTx mocks base method.
+func (m *MockStore) GetAllGroupsTx(tx store.Tx) ([]*model.UserGroupDetail, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAllGroupsTx", tx)
+	ret0, _ := ret[0].([]*model.UserGroupDetail)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAllGroupsTx indicates an expected call of GetAllGroupsTx.
+func (mr *MockStoreMockRecorder) GetAllGroupsTx(tx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllGroupsTx", reflect.TypeOf((*MockStore)(nil).GetAllGroupsTx), tx)
+}
+
+// GetAllStrategyDetailsTx mocks base method.
+func (m *MockStore) GetAllStrategyDetailsTx(tx store.Tx) ([]*model.StrategyDetail, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAllStrategyDetailsTx", tx)
+	ret0, _ := ret[0].([]*model.StrategyDetail)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAllStrategyDetailsTx indicates an expected call of GetAllStrategyDetailsTx.
+func (mr *MockStoreMockRecorder) GetAllStrategyDetailsTx(tx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllStrategyDetailsTx", reflect.TypeOf((*MockStore)(nil).GetAllStrategyDetailsTx), tx)
+}
+
+// GetAllUsersTx mocks base method.
+func (m *MockStore) GetAllUsersTx(tx store.Tx) ([]*model.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAllUsersTx", tx)
+	ret0, _ := ret[0].([]*model.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAllUsersTx indicates an expected call of GetAllUsersTx.
+func (mr *MockStoreMockRecorder) GetAllUsersTx(tx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllUsersTx", reflect.TypeOf((*MockStore)(nil).GetAllUsersTx), tx)
+}
+
 // GetCircuitBreakerRules mocks base method.
 func (m *MockStore) GetCircuitBreakerRules(filter map[string]string, offset, limit uint32) (uint32, []*model.CircuitBreakerRule, error) {
 	m.ctrl.T.Helper()
@@ -1276,6 +1379,181 @@ func (mr *MockStoreMockRecorder) GetGroup(id interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetGroup", reflect.TypeOf((*MockStore)(nil).GetGroup), id)
 }
 
+// AddUserSession mocks base method.
+func (m *MockStore) AddUserSession(session *model.UserSession) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddUserSession", session)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddUserSession indicates an expected call of AddUserSession.
+func (mr *MockStoreMockRecorder) AddUserSession(session interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddUserSession", reflect.TypeOf((*MockStore)(nil).AddUserSession), session)
+}
+
+// ResetUserLoginFailures mocks base method.
+func (m *MockStore) ResetUserLoginFailures(id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResetUserLoginFailures", id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ResetUserLoginFailures indicates an expected call of ResetUserLoginFailures.
+func (mr *MockStoreMockRecorder) ResetUserLoginFailures(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResetUserLoginFailures", reflect.TypeOf((*MockStore)(nil).ResetUserLoginFailures), id)
+}
+
+// RevokeUserSessions mocks base method.
+func (m *MockStore) RevokeUserSessions(userID string, ids []string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeUserSessions", userID, ids)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeUserSessions indicates an expected call of RevokeUserSessions.
+func (mr *MockStoreMockRecorder) RevokeUserSessions(userID, ids interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeUserSessions", reflect.TypeOf((*MockStore)(nil).RevokeUserSessions), userID, ids)
+}
+
+// GetUserSession mocks base method.
+func (m *MockStore) GetUserSession(id string) (*model.UserSession, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserSession", id)
+	ret0, _ := ret[0].(*model.UserSession)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserSession indicates an expected call of GetUserSession.
+func (mr *MockStoreMockRecorder) GetUserSession(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserSession", reflect.TypeOf((*MockStore)(nil).GetUserSession), id)
+}
+
+// GetUserSessionsForCache mocks base method.
+func (m *MockStore) GetUserSessionsForCache(mtime time.Time, firstUpdate bool) ([]*model.UserSession, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserSessionsForCache", mtime, firstUpdate)
+	ret0, _ := ret[0].([]*model.UserSession)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserSessionsForCache indicates an expected call of GetUserSessionsForCache.
+func (mr *MockStoreMockRecorder) GetUserSessionsForCache(mtime, firstUpdate interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserSessionsForCache", reflect.TypeOf((*MockStore)(nil).GetUserSessionsForCache), mtime, firstUpdate)
+}
+
+// AddRole mocks base method.
+func (m *MockStore) AddRole(role *model.Role) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddRole", role)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddRole indicates an expected call of AddRole.
+func (mr *MockStoreMockRecorder) AddRole(role interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddRole", reflect.TypeOf((*MockStore)(nil).AddRole), role)
+}
+
+// UpdateRole mocks base method.
+func (m *MockStore) UpdateRole(role *model.Role) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateRole", role)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateRole indicates an expected call of UpdateRole.
+func (mr *MockStoreMockRecorder) UpdateRole(role interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateRole", reflect.TypeOf((*MockStore)(nil).UpdateRole), role)
+}
+
+// DeleteRole mocks base method.
+func (m *MockStore) DeleteRole(id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteRole", id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteRole indicates an expected call of DeleteRole.
+func (mr *MockStoreMockRecorder) DeleteRole(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteRole", reflect.TypeOf((*MockStore)(nil).DeleteRole), id)
+}
+
+// GetRole mocks base method.
+func (m *MockStore) GetRole(id string) (*model.Role, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRole", id)
+	ret0, _ := ret[0].(*model.Role)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRole indicates an expected call of GetRole.
+func (mr *MockStoreMockRecorder) GetRole(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRole", reflect.TypeOf((*MockStore)(nil).GetRole), id)
+}
+
+// GetRoleByName mocks base method.
+func (m *MockStore) GetRoleByName(name, owner string) (*model.Role, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRoleByName", name, owner)
+	ret0, _ := ret[0].(*model.Role)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRoleByName indicates an expected call of GetRoleByName.
+func (mr *MockStoreMockRecorder) GetRoleByName(name, owner interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRoleByName", reflect.TypeOf((*MockStore)(nil).GetRoleByName), name, owner)
+}
+
+// GetRoles mocks base method.
+func (m *MockStore) GetRoles(filters map[string]string, offset, limit uint32) (uint32, []*model.Role, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRoles", filters, offset, limit)
+	ret0, _ := ret[0].(uint32)
+	ret1, _ := ret[1].([]*model.Role)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetRoles indicates an expected call of GetRoles.
+func (mr *MockStoreMockRecorder) GetRoles(filters, offset, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRoles", reflect.TypeOf((*MockStore)(nil).GetRoles), filters, offset, limit)
+}
+
+// GetRolesForCache mocks base method.
+func (m *MockStore) GetRolesForCache(mtime time.Time, firstUpdate bool) ([]*model.Role, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRolesForCache", mtime, firstUpdate)
+	ret0, _ := ret[0].([]*model.Role)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRolesForCache indicates an expected call of GetRolesForCache.
+func (mr *MockStoreMockRecorder) GetRolesForCache(mtime, firstUpdate interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRolesForCache", reflect.TypeOf((*MockStore)(nil).GetRolesForCache), mtime, firstUpdate)
+}
+
 // GetGroupByName mocks base method.
 func (m *MockStore) GetGroupByName(name, owner string) (*model.UserGroup, error) {
 	m.ctrl.T.Helper()
@@ -1760,6 +2038,52 @@ func (mr *MockStoreMockRecorder) GetRateLimitsForCache(mtime, firstUpdate interf
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRateLimitsForCache", reflect.TypeOf((*MockStore)(nil).GetRateLimitsForCache), mtime, firstUpdate)
 }
 
+// GetRecordEntriesByChangeSet mocks base method.
+func (m *MockStore) GetRecordEntriesByChangeSet(changeSetID string) ([]*model.RecordEntry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRecordEntriesByChangeSet", changeSetID)
+	ret0, _ := ret[0].([]*model.RecordEntry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRecordEntriesByChangeSet indicates an expected call of GetRecordEntriesByChangeSet.
+func (mr *MockStoreMockRecorder) GetRecordEntriesByChangeSet(changeSetID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRecordEntriesByChangeSet", reflect.TypeOf((*MockStore)(nil).GetRecordEntriesByChangeSet), changeSetID)
+}
+
+// GetRecordEntriesSince mocks base method.
+func (m *MockStore) GetRecordEntriesSince(since time.Time, limit uint64) ([]*model.RecordEntry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRecordEntriesSince", since, limit)
+	ret0, _ := ret[0].([]*model.RecordEntry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRecordEntriesSince indicates an expected call of GetRecordEntriesSince.
+func (mr *MockStoreMockRecorder) GetRecordEntriesSince(since, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRecordEntriesSince", reflect.TypeOf((*MockStore)(nil).GetRecordEntriesSince), since, limit)
+}
+
+// GetRecordEntries mocks base method.
+func (m *MockStore) GetRecordEntries(filter *model.RecordEntryFilter, offset, limit uint32) (uint32, []*model.RecordEntry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRecordEntries", filter, offset, limit)
+	ret0, _ := ret[0].(uint32)
+	ret1, _ := ret[1].([]*model.RecordEntry)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetRecordEntries indicates an expected call of GetRecordEntries.
+func (mr *MockStoreMockRecorder) GetRecordEntries(filter, offset, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRecordEntries", reflect.TypeOf((*MockStore)(nil).GetRecordEntries), filter, offset, limit)
+}
+
 // GetRoutingConfigV2WithID mocks base method.
 func (m *MockStore) GetRoutingConfigV2WithID(id string) (*model.RouterConfig, error) {
 	m.ctrl.T.Helper()
@@ -2050,6 +2374,21 @@ func (mr *MockStoreMockRecorder) GetStrategyDetailsForCache(mtime, firstUpdate i
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetStrategyDetailsForCache", reflect.TypeOf((*MockStore)(nil).GetStrategyDetailsForCache), mtime, firstUpdate)
 }
 
+// GetStrategyResourceUsage mocks base method.
+func (m *MockStore) GetStrategyResourceUsage(strategyID string) ([]model.StrategyResourceUsage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetStrategyResourceUsage", strategyID)
+	ret0, _ := ret[0].([]model.StrategyResourceUsage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetStrategyResourceUsage indicates an expected call of GetStrategyResourceUsage.
+func (mr *MockStoreMockRecorder) GetStrategyResourceUsage(strategyID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetStrategyResourceUsage", reflect.TypeOf((*MockStore)(nil).GetStrategyResourceUsage), strategyID)
+}
+
 // GetStrategyResources mocks base method.
 func (m *MockStore) GetStrategyResources(principalId string, principalRole model.PrincipalType) ([]model.StrategyResource, error) {
 	m.ctrl.T.Helper()
@@ -2291,6 +2630,21 @@ func (mr *MockStoreMockRecorder) HasFaultDetectRuleByNameExcludeId(name, namespa
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HasFaultDetectRuleByNameExcludeId", reflect.TypeOf((*MockStore)(nil).HasFaultDetectRuleByNameExcludeId), name, namespace, id)
 }
 
+// IncrUserLoginFailures mocks base method.
+func (m *MockStore) IncrUserLoginFailures(id string, maxFailures int, lockedUntil time.Time) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IncrUserLoginFailures", id, maxFailures, lockedUntil)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IncrUserLoginFailures indicates an expected call of IncrUserLoginFailures.
+func (mr *MockStoreMockRecorder) IncrUserLoginFailures(id, maxFailures, lockedUntil interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IncrUserLoginFailures", reflect.TypeOf((*MockStore)(nil).IncrUserLoginFailures), id, maxFailures, lockedUntil)
+}
+
 // InactiveConfigFileReleaseTx mocks base method.
 func (m *MockStore) InactiveConfigFileReleaseTx(tx store.Tx, release *model.ConfigFileRelease) error {
 	m.ctrl.T.Helper()
@@ -2482,6 +2836,21 @@ func (mr *MockStoreMockRecorder) ReleaseLeaderElection(key interface{}) *gomock.
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReleaseLeaderElection", reflect.TypeOf((*MockStore)(nil).ReleaseLeaderElection), key)
 }
 
+// RemoveExpiredStrategyResources mocks base method.
+func (m *MockStore) RemoveExpiredStrategyResources(now time.Time, limit uint32) ([]model.StrategyResource, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveExpiredStrategyResources", now, limit)
+	ret0, _ := ret[0].([]model.StrategyResource)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RemoveExpiredStrategyResources indicates an expected call of RemoveExpiredStrategyResources.
+func (mr *MockStoreMockRecorder) RemoveExpiredStrategyResources(now, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveExpiredStrategyResources", reflect.TypeOf((*MockStore)(nil).RemoveExpiredStrategyResources), now, limit)
+}
+
 // RemoveStrategyResources mocks base method.
 func (m *MockStore) RemoveStrategyResources(resources []model.StrategyResource) error {
 	m.ctrl.T.Helper()
@@ -2569,6 +2938,20 @@ func (mr *MockStoreMockRecorder) StartTx() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StartTx", reflect.TypeOf((*MockStore)(nil).StartTx))
 }
 
+// TransferStrategyResource mocks base method.
+func (m *MockStore) TransferStrategyResource(resource model.StrategyResource, toStrategyID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TransferStrategyResource", resource, toStrategyID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// TransferStrategyResource indicates an expected call of TransferStrategyResource.
+func (mr *MockStoreMockRecorder) TransferStrategyResource(resource, toStrategyID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TransferStrategyResource", reflect.TypeOf((*MockStore)(nil).TransferStrategyResource), resource, toStrategyID)
+}
+
 // UpdateCircuitBreakerRule mocks base method.
 func (m *MockStore) UpdateCircuitBreakerRule(cbRule *model.CircuitBreakerRule) error {
 	m.ctrl.T.Helper()
@@ -2597,6 +2980,20 @@ func (mr *MockStoreMockRecorder) UpdateConfigFileGroup(fileGroup interface{}) *g
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateConfigFileGroup", reflect.TypeOf((*MockStore)(nil).UpdateConfigFileGroup), fileGroup)
 }
 
+// UpdateConfigFileGroupOwner mocks base method.
+func (m *MockStore) UpdateConfigFileGroupOwner(namespace, name, owner string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateConfigFileGroupOwner", namespace, name, owner)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateConfigFileGroupOwner indicates an expected call of UpdateConfigFileGroupOwner.
+func (mr *MockStoreMockRecorder) UpdateConfigFileGroupOwner(namespace, name, owner interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateConfigFileGroupOwner", reflect.TypeOf((*MockStore)(nil).UpdateConfigFileGroupOwner), namespace, name, owner)
+}
+
 // UpdateConfigFileTx mocks base method.
 func (m *MockStore) UpdateConfigFileTx(tx store.Tx, file *model.ConfigFile) error {
 	m.ctrl.T.Helper()
@@ -2625,6 +3022,20 @@ func (mr *MockStoreMockRecorder) UpdateFaultDetectRule(conf interface{}) *gomock
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateFaultDetectRule", reflect.TypeOf((*MockStore)(nil).UpdateFaultDetectRule), conf)
 }
 
+// UpdateGroupParent mocks base method.
+func (m *MockStore) UpdateGroupParent(id, parentID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateGroupParent", id, parentID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateGroupParent indicates an expected call of UpdateGroupParent.
+func (mr *MockStoreMockRecorder) UpdateGroupParent(id, parentID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateGroupParent", reflect.TypeOf((*MockStore)(nil).UpdateGroupParent), id, parentID)
+}
+
 // UpdateGroup mocks base method.
 func (m *MockStore) UpdateGroup(group *model.ModifyUserGroup) error {
 	m.ctrl.T.Helper()
@@ -2821,6 +3232,48 @@ func (mr *MockStoreMockRecorder) UpdateStrategy(strategy interface{}) *gomock.Ca
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateStrategy", reflect.TypeOf((*MockStore)(nil).UpdateStrategy), strategy)
 }
 
+// UpdateStrategyEffect mocks base method.
+func (m *MockStore) UpdateStrategyEffect(id string, effect model.StrategyEffect, priority int32) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateStrategyEffect", id, effect, priority)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateStrategyEffect indicates an expected call of UpdateStrategyEffect.
+func (mr *MockStoreMockRecorder) UpdateStrategyEffect(id, effect, priority interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateStrategyEffect", reflect.TypeOf((*MockStore)(nil).UpdateStrategyEffect), id, effect, priority)
+}
+
+// UpdateStrategyFunctions mocks base method.
+func (m *MockStore) UpdateStrategyFunctions(id string, functions []string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateStrategyFunctions", id, functions)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateStrategyFunctions indicates an expected call of UpdateStrategyFunctions.
+func (mr *MockStoreMockRecorder) UpdateStrategyFunctions(id, functions interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateStrategyFunctions", reflect.TypeOf((*MockStore)(nil).UpdateStrategyFunctions), id, functions)
+}
+
+// UpdateStrategyResourceUsage mocks base method.
+func (m *MockStore) UpdateStrategyResourceUsage(usages []model.StrategyResourceUsage) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateStrategyResourceUsage", usages)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateStrategyResourceUsage indicates an expected call of UpdateStrategyResourceUsage.
+func (mr *MockStoreMockRecorder) UpdateStrategyResourceUsage(usages interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateStrategyResourceUsage", reflect.TypeOf((*MockStore)(nil).UpdateStrategyResourceUsage), usages)
+}
+
 // UpdateUser mocks base method.
 func (m *MockStore) UpdateUser(user *model.User) error {
 	m.ctrl.T.Helper()
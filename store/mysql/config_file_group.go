@@ -79,6 +79,15 @@ func (fg *configFileGroupStore) UpdateConfigFileGroup(fileGroup *model.ConfigFil
 	return nil
 }
 
+// UpdateConfigFileGroupOwner 更新配置文件组的负责人
+func (fg *configFileGroupStore) UpdateConfigFileGroupOwner(namespace, name, owner string) error {
+	updateSql := "UPDATE config_file_group SET owner = ?, modify_time = sysdate() WHERE namespace = ? and name = ?"
+	if _, err := fg.master.Exec(updateSql, owner, namespace, name); err != nil {
+		return store.Error(err)
+	}
+	return nil
+}
+
 // GetConfigFileGroup 获取配置文件组
 func (fg *configFileGroupStore) GetConfigFileGroup(namespace, name string) (*model.ConfigFileGroup, error) {
 	querySql := fg.genConfigFileGroupSelectSql() + " WHERE namespace = ? AND name = ? AND flag = 0 "
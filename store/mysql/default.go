@@ -69,10 +69,13 @@ type stableStore struct {
 
 	*clientStore
 	*adminStore
+	*historyStore
 	*toolStore
 	*userStore
 	*groupStore
 	*strategyStore
+	*roleStore
+	*userSessionStore
 	*grayStore
 
 	// 主数据库，可以进行读写
@@ -271,10 +274,13 @@ func (s *stableStore) newStore() {
 	s.clientStore = &clientStore{master: s.master, slave: s.slave}
 
 	s.adminStore = newAdminStore(s.master)
+	s.historyStore = &historyStore{master: s.master, slave: s.slave}
 	s.toolStore = &toolStore{db: s.master}
 	s.userStore = &userStore{master: s.master, slave: s.slave}
 	s.groupStore = &groupStore{master: s.master, slave: s.slave}
 	s.strategyStore = &strategyStore{master: s.master, slave: s.slave}
+	s.roleStore = &roleStore{master: s.master, slave: s.slave}
+	s.userSessionStore = &userSessionStore{master: s.master, slave: s.slave}
 	s.grayStore = &grayStore{master: s.master, slave: s.slave}
 }
 
@@ -185,6 +185,19 @@ func (u *groupStore) updateGroup(group *model.ModifyUserGroup) error {
 	return nil
 }
 
+// UpdateGroupParent 更新用户组的父用户组
+func (u *groupStore) UpdateGroupParent(id, parentID string) error {
+	if id == "" {
+		return store.NewStatusError(store.EmptyParamsErr, "update usergroup parent missing group id")
+	}
+	updateSql := "UPDATE user_group SET parent_id = ?, mtime = sysdate() WHERE id = ? AND flag = 0"
+	if _, err := u.master.Exec(updateSql, parentID, id); err != nil {
+		log.Errorf("[Store][Group] update usergroup parent err: %s", err.Error())
+		return store.Error(err)
+	}
+	return nil
+}
+
 // DeleteGroup 删除用户组
 func (u *groupStore) DeleteGroup(group *model.UserGroupDetail) error {
 	if group.ID == "" || group.Name == "" {
@@ -242,7 +255,7 @@ func (u *groupStore) GetGroup(groupId string) (*model.UserGroupDetail, error) {
 
 	getSql := `
 	  SELECT ug.id, ug.name, ug.owner, ug.comment, ug.token, ug.token_enable
-		  , UNIX_TIMESTAMP(ug.ctime), UNIX_TIMESTAMP(ug.mtime)
+		  , UNIX_TIMESTAMP(ug.ctime), UNIX_TIMESTAMP(ug.mtime), IFNULL(ug.parent_id, '')
 	  FROM user_group ug
 	  WHERE ug.flag = 0
 		  AND ug.id = ? 
@@ -258,7 +271,7 @@ func (u *groupStore) GetGroup(groupId string) (*model.UserGroupDetail, error) {
 	)
 
 	if err := row.Scan(&group.ID, &group.Name, &group.Owner, &group.Comment, &group.Token, &tokenEnable,
-		&ctime, &mtime); err != nil {
+		&ctime, &mtime, &group.ParentID); err != nil {
 		switch err {
 		case sql.ErrNoRows:
 			return nil, nil
@@ -266,7 +279,7 @@ func (u *groupStore) GetGroup(groupId string) (*model.UserGroupDetail, error) {
 			return nil, store.Error(err)
 		}
 	}
-	uids, err := u.getGroupLinkUserIds(group.ID)
+	uids, err := u.getGroupLinkUserIds(u.slave.Query, group.ID)
 	if err != nil {
 		return nil, store.Error(err)
 	}
@@ -344,7 +357,7 @@ func (u *groupStore) listSimpleGroups(filters map[string]string, offset uint32,
 	getSql := `
 	  SELECT ug.id, ug.name, ug.owner, ug.comment, ug.token, ug.token_enable
 		  , UNIX_TIMESTAMP(ug.ctime), UNIX_TIMESTAMP(ug.mtime)
-		  , ug.flag
+		  , ug.flag, IFNULL(ug.parent_id, '')
 	  FROM user_group ug
 	  WHERE ug.flag = 0 
 	  `
@@ -392,7 +405,7 @@ func (u *groupStore) listGroupByUser(filters map[string]string, offset uint32, l
 	countSql := "SELECT COUNT(*) FROM user_group_relation ul LEFT JOIN user_group ug ON " +
 		" ul.group_id = ug.id WHERE ug.flag = 0 "
 	getSql := "SELECT ug.id, ug.name, ug.owner, ug.comment, ug.token, ug.token_enable, UNIX_TIMESTAMP(ug.ctime), " +
-		" UNIX_TIMESTAMP(ug.mtime), ug.flag " +
+		" UNIX_TIMESTAMP(ug.mtime), ug.flag, IFNULL(ug.parent_id, '') " +
 		" FROM user_group_relation ul LEFT JOIN user_group ug ON ul.group_id = ug.id WHERE ug.flag = 0 "
 
 	args := make([]interface{}, 0)
@@ -439,7 +452,7 @@ func (u *groupStore) listGroupByUser(filters map[string]string, offset uint32, l
 // collectGroupsFromRows 查询用户组列表
 func (u *groupStore) collectGroupsFromRows(handler QueryHandler, querySql string,
 	args []interface{}) ([]*model.UserGroup, error) {
-	rows, err := u.master.Query(querySql, args...)
+	rows, err := handler(querySql, args...)
 	if err != nil {
 		log.Error("[Store][Group] list group", zap.String("query sql", querySql), zap.Any("args", args))
 		return nil, err
@@ -470,7 +483,7 @@ func (u *groupStore) GetGroupsForCache(mtime time.Time, firstUpdate bool) ([]*mo
 
 	args := make([]interface{}, 0)
 	querySql := "SELECT id, name, owner, comment, token, token_enable, UNIX_TIMESTAMP(ctime), UNIX_TIMESTAMP(mtime), " +
-		" flag FROM user_group "
+		" flag, IFNULL(parent_id, '') FROM user_group "
 	if !firstUpdate {
 		querySql += " WHERE mtime >= FROM_UNIXTIME(?)"
 		args = append(args, timeToTimestamp(mtime))
@@ -491,7 +504,7 @@ func (u *groupStore) GetGroupsForCache(mtime time.Time, firstUpdate bool) ([]*mo
 		if err != nil {
 			return nil, store.Error(err)
 		}
-		uids, err := u.getGroupLinkUserIds(group.ID)
+		uids, err := u.getGroupLinkUserIds(u.slave.Query, group.ID)
 		if err != nil {
 			return nil, store.Error(err)
 		}
@@ -505,6 +518,32 @@ func (u *groupStore) GetGroupsForCache(mtime time.Time, firstUpdate bool) ([]*mo
 	return ret, nil
 }
 
+// GetAllGroupsTx 在读事务中获取全部有效的用户组以及其成员
+func (u *groupStore) GetAllGroupsTx(tx store.Tx) ([]*model.UserGroupDetail, error) {
+	dbTx, _ := tx.GetDelegateTx().(*BaseTx)
+	querySql := "SELECT id, name, owner, comment, token, token_enable, UNIX_TIMESTAMP(ctime), UNIX_TIMESTAMP(mtime), " +
+		" flag, IFNULL(parent_id, '') FROM user_group WHERE flag = 0"
+
+	groups, err := u.collectGroupsFromRows(dbTx.Query, querySql, nil)
+	if err != nil {
+		return nil, store.Error(err)
+	}
+
+	// 需要在 rows 关闭之后再查询成员信息，同一个事务连接不允许并行存在多个结果集
+	ret := make([]*model.UserGroupDetail, 0, len(groups))
+	for i := range groups {
+		uids, err := u.getGroupLinkUserIds(dbTx.Query, groups[i].ID)
+		if err != nil {
+			return nil, store.Error(err)
+		}
+		ret = append(ret, &model.UserGroupDetail{
+			UserGroup: groups[i],
+			UserIds:   uids,
+		})
+	}
+	return ret, nil
+}
+
 func (u *groupStore) addGroupRelation(tx *BaseTx, groupId string, userIds []string) error {
 	if groupId == "" {
 		return store.NewStatusError(store.EmptyParamsErr, fmt.Sprintf(
@@ -554,12 +593,12 @@ func (u *groupStore) removeGroupRelation(tx *BaseTx, groupId string, userIds []s
 	return nil
 }
 
-func (u *groupStore) getGroupLinkUserIds(groupId string) (map[string]struct{}, error) {
+func (u *groupStore) getGroupLinkUserIds(handler QueryHandler, groupId string) (map[string]struct{}, error) {
 
 	ids := make(map[string]struct{})
 
 	// 拉取该分组下的所有 user
-	idRows, err := u.slave.Query("SELECT user_id FROM user u JOIN user_group_relation ug ON "+
+	idRows, err := handler("SELECT user_id FROM user u JOIN user_group_relation ug ON "+
 		" u.id = ug.user_id WHERE ug.group_id = ?", groupId)
 	if err != nil {
 		return nil, err
@@ -581,7 +620,7 @@ func fetchRown2UserGroup(rows *sql.Rows) (*model.UserGroup, error) {
 	var flag, tokenEnable int
 	group := new(model.UserGroup)
 	if err := rows.Scan(&group.ID, &group.Name, &group.Owner, &group.Comment, &group.Token, &tokenEnable,
-		&ctime, &mtime, &flag); err != nil {
+		&ctime, &mtime, &flag, &group.ParentID); err != nil {
 		return nil, err
 	}
 
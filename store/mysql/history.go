@@ -0,0 +1,225 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package sqldb
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/store"
+)
+
+type historyStore struct {
+	master *BaseDB
+	slave  *BaseDB
+}
+
+// AddRecordEntries 批量保存操作记录
+func (h *historyStore) AddRecordEntries(entries []*model.RecordEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	insertSql := "INSERT INTO record_entry(resource_type, resource_name, namespace, operator, " +
+		" operation_type, detail, server, happen_time, change_set_id) VALUES "
+	args := make([]interface{}, 0, len(entries)*9)
+	for i := range entries {
+		if i > 0 {
+			insertSql += ", "
+		}
+		insertSql += "(?, ?, ?, ?, ?, ?, ?, ?, ?)"
+		entry := entries[i]
+		args = append(args, string(entry.ResourceType), entry.ResourceName, entry.Namespace, entry.Operator,
+			string(entry.OperationType), entry.Detail, entry.Server, entry.HappenTime, entry.ChangeSetID)
+	}
+	if _, err := h.master.Exec(insertSql, args...); err != nil {
+		log.Errorf("[Store][database] add record entries err: %s", err.Error())
+		return store.Error(err)
+	}
+	return nil
+}
+
+// CleanRecordEntries 清理 endTime 之前发生的操作记录
+func (h *historyStore) CleanRecordEntries(endTime time.Time, exemptTypes []model.OperationType,
+	limit uint64) (uint64, error) {
+	delSql := "DELETE FROM record_entry WHERE happen_time < ? "
+	args := []interface{}{endTime}
+	exemptSql, exemptArgs := buildExemptTypesFilter(exemptTypes)
+	delSql += exemptSql + " LIMIT ?"
+	args = append(args, exemptArgs...)
+	args = append(args, limit)
+
+	result, err := h.master.Exec(delSql, args...)
+	if err != nil {
+		log.Errorf("[Store][database] clean record entries err: %s", err.Error())
+		return 0, store.Error(err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return 0, store.Error(err)
+	}
+	return uint64(rows), nil
+}
+
+// CleanExceedRecordEntries 仅保留最新的 retainCount 条操作记录
+func (h *historyStore) CleanExceedRecordEntries(retainCount uint64, exemptTypes []model.OperationType,
+	limit uint64) (uint64, error) {
+	exemptSql, exemptArgs := buildExemptTypesFilter(exemptTypes)
+
+	// 找到需要保留的最旧一条记录的前一条，在此之前(包含)的记录都需要清理
+	querySql := "SELECT id FROM record_entry WHERE 1=1 " + exemptSql + " ORDER BY id DESC LIMIT ?, 1"
+	args := append(append([]interface{}{}, exemptArgs...), retainCount)
+	var maxDelID uint64
+	if err := h.master.QueryRow(querySql, args...).Scan(&maxDelID); err != nil {
+		if err == sql.ErrNoRows {
+			return 0, nil
+		}
+		log.Errorf("[Store][database] query exceed record entries err: %s", err.Error())
+		return 0, store.Error(err)
+	}
+
+	delSql := "DELETE FROM record_entry WHERE id <= ? " + exemptSql + " ORDER BY id LIMIT ?"
+	args = append(append([]interface{}{maxDelID}, exemptArgs...), limit)
+	result, err := h.master.Exec(delSql, args...)
+	if err != nil {
+		log.Errorf("[Store][database] clean exceed record entries err: %s", err.Error())
+		return 0, store.Error(err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return 0, store.Error(err)
+	}
+	return uint64(rows), nil
+}
+
+const recordEntryColumns = "id, resource_type, resource_name, namespace, operator, operation_type, detail, " +
+	" server, UNIX_TIMESTAMP(happen_time), change_set_id"
+
+// GetRecordEntriesByChangeSet 查询同一个变更集下的全部操作记录
+func (h *historyStore) GetRecordEntriesByChangeSet(changeSetID string) ([]*model.RecordEntry, error) {
+	querySql := "SELECT " + recordEntryColumns + " FROM record_entry WHERE change_set_id = ? ORDER BY id"
+	rows, err := h.slave.Query(querySql, changeSetID)
+	if err != nil {
+		log.Errorf("[Store][database] query change set record entries err: %s", err.Error())
+		return nil, store.Error(err)
+	}
+	defer rows.Close()
+	return scanRecordEntries(rows)
+}
+
+// GetRecordEntriesSince 查询 since 之后发生的操作记录，超过 limit 条时保留最新的 limit 条
+func (h *historyStore) GetRecordEntriesSince(since time.Time, limit uint64) ([]*model.RecordEntry, error) {
+	querySql := "SELECT " + recordEntryColumns + " FROM record_entry WHERE happen_time >= ? ORDER BY id DESC LIMIT ?"
+	rows, err := h.slave.Query(querySql, since, limit)
+	if err != nil {
+		log.Errorf("[Store][database] query record entries since %s err: %s", since, err.Error())
+		return nil, store.Error(err)
+	}
+	defer rows.Close()
+	entries, err := scanRecordEntries(rows)
+	if err != nil {
+		return nil, err
+	}
+	// 按照写入顺序返回
+	for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+		entries[i], entries[j] = entries[j], entries[i]
+	}
+	return entries, nil
+}
+
+// GetRecordEntries 分页查询满足条件的操作记录，按照发生时间从新到旧返回
+func (h *historyStore) GetRecordEntries(filter *model.RecordEntryFilter, offset,
+	limit uint32) (uint32, []*model.RecordEntry, error) {
+	whereSql := " WHERE 1=1 "
+	args := make([]interface{}, 0, 5)
+	if filter.Operator != "" {
+		whereSql += " AND operator = ? "
+		args = append(args, filter.Operator)
+	}
+	if filter.ResourceType != "" {
+		whereSql += " AND resource_type = ? "
+		args = append(args, string(filter.ResourceType))
+	}
+	if filter.OperationType != "" {
+		whereSql += " AND operation_type = ? "
+		args = append(args, string(filter.OperationType))
+	}
+	if !filter.StartTime.IsZero() {
+		whereSql += " AND happen_time >= ? "
+		args = append(args, filter.StartTime)
+	}
+	if !filter.EndTime.IsZero() {
+		whereSql += " AND happen_time < ? "
+		args = append(args, filter.EndTime)
+	}
+
+	count, err := queryEntryCount(h.slave, "SELECT COUNT(*) FROM record_entry "+whereSql, args)
+	if err != nil {
+		log.Errorf("[Store][database] count record entries err: %s", err.Error())
+		return 0, nil, store.Error(err)
+	}
+
+	querySql := "SELECT " + recordEntryColumns + " FROM record_entry " + whereSql + " ORDER BY id DESC LIMIT ?, ?"
+	rows, err := h.slave.Query(querySql, append(args, offset, limit)...)
+	if err != nil {
+		log.Errorf("[Store][database] query record entries err: %s", err.Error())
+		return 0, nil, store.Error(err)
+	}
+	defer rows.Close()
+	entries, err := scanRecordEntries(rows)
+	if err != nil {
+		return 0, nil, err
+	}
+	return count, entries, nil
+}
+
+func scanRecordEntries(rows *sql.Rows) ([]*model.RecordEntry, error) {
+	entries := make([]*model.RecordEntry, 0, 8)
+	for rows.Next() {
+		var (
+			entry                       = &model.RecordEntry{}
+			resourceType, operationType string
+			detail                      sql.NullString
+			happenTime                  int64
+		)
+		if err := rows.Scan(&entry.ID, &resourceType, &entry.ResourceName, &entry.Namespace, &entry.Operator,
+			&operationType, &detail, &entry.Server, &happenTime, &entry.ChangeSetID); err != nil {
+			return nil, store.Error(err)
+		}
+		entry.ResourceType = model.Resource(resourceType)
+		entry.OperationType = model.OperationType(operationType)
+		entry.Detail = detail.String
+		entry.HappenTime = time.Unix(happenTime, 0)
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, store.Error(err)
+	}
+	return entries, nil
+}
+
+func buildExemptTypesFilter(exemptTypes []model.OperationType) (string, []interface{}) {
+	if len(exemptTypes) == 0 {
+		return "", nil
+	}
+	args := make([]interface{}, 0, len(exemptTypes))
+	for i := range exemptTypes {
+		args = append(args, string(exemptTypes[i]))
+	}
+	return " AND operation_type NOT IN (" + PlaceholdersN(len(exemptTypes)) + ") ", args
+}
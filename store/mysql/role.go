@@ -0,0 +1,265 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+package sqldb
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/common/utils"
+	"github.com/polarismesh/polaris/store"
+)
+
+const roleQueryFields = "id, name, owner, comment, strategies, principals, flag, " +
+	"UNIX_TIMESTAMP(ctime), UNIX_TIMESTAMP(mtime)"
+
+// rolePrincipal 角色成员在 principals 字段中的存储格式
+type rolePrincipal struct {
+	ID   string `json:"id"`
+	Type int    `json:"type"`
+}
+
+type roleStore struct {
+	master *BaseDB
+	slave  *BaseDB
+}
+
+// AddRole 创建角色
+func (r *roleStore) AddRole(role *model.Role) error {
+	if role.ID == "" || role.Name == "" || role.Owner == "" {
+		return store.NewStatusError(store.EmptyParamsErr, fmt.Sprintf(
+			"add role missing some params, id is %s, name is %s, owner is %s", role.ID, role.Name, role.Owner))
+	}
+	strategies, principals, err := marshalRoleRelation(role)
+	if err != nil {
+		return err
+	}
+
+	err = r.master.processWithTransaction("addRole", func(tx *BaseTx) error {
+		// 先清理同名的无效数据
+		if _, err := tx.Exec("DELETE FROM auth_role WHERE name = ? AND owner = ? AND flag = 1",
+			role.Name, role.Owner); err != nil {
+			return err
+		}
+		addSql := "INSERT INTO auth_role (id, name, owner, comment, strategies, principals, flag, ctime, mtime) " +
+			" VALUES (?, ?, ?, ?, ?, ?, 0, sysdate(), sysdate())"
+		if _, err := tx.Exec(addSql, role.ID, role.Name, role.Owner, role.Comment, strategies,
+			principals); err != nil {
+			return err
+		}
+		return tx.Commit()
+	})
+	if err != nil {
+		log.Errorf("[Store][Role] add role(%s) err: %s", role.Name, err.Error())
+	}
+	return store.Error(err)
+}
+
+// UpdateRole 更新角色的描述、策略以及成员
+func (r *roleStore) UpdateRole(role *model.Role) error {
+	if role.ID == "" {
+		return store.NewStatusError(store.EmptyParamsErr, "update role missing id")
+	}
+	strategies, principals, err := marshalRoleRelation(role)
+	if err != nil {
+		return err
+	}
+
+	updateSql := "UPDATE auth_role SET comment = ?, strategies = ?, principals = ?, mtime = sysdate() " +
+		" WHERE id = ? AND flag = 0"
+	if _, err := r.master.Exec(updateSql, role.Comment, strategies, principals, role.ID); err != nil {
+		log.Errorf("[Store][Role] update role(%s) err: %s", role.ID, err.Error())
+		return store.Error(err)
+	}
+	return nil
+}
+
+// DeleteRole 删除角色
+func (r *roleStore) DeleteRole(id string) error {
+	if id == "" {
+		return store.NewStatusError(store.EmptyParamsErr, "delete role missing id")
+	}
+	if _, err := r.master.Exec("UPDATE auth_role SET flag = 1, mtime = sysdate() WHERE id = ?", id); err != nil {
+		log.Errorf("[Store][Role] delete role(%s) err: %s", id, err.Error())
+		return store.Error(err)
+	}
+	return nil
+}
+
+// GetRole 查询角色，角色不存在或者已经删除时返回 nil
+func (r *roleStore) GetRole(id string) (*model.Role, error) {
+	if id == "" {
+		return nil, store.NewStatusError(store.EmptyParamsErr, "get role missing id")
+	}
+	rows, err := r.master.Query("SELECT "+roleQueryFields+" FROM auth_role WHERE flag = 0 AND id = ?", id)
+	if err != nil {
+		return nil, store.Error(err)
+	}
+	roles, err := fetchRoleRows(rows)
+	if err != nil || len(roles) == 0 {
+		return nil, err
+	}
+	return roles[0], nil
+}
+
+// GetRoleByName 根据名称以及主账户查询角色
+func (r *roleStore) GetRoleByName(name, owner string) (*model.Role, error) {
+	if name == "" || owner == "" {
+		return nil, store.NewStatusError(store.EmptyParamsErr, fmt.Sprintf(
+			"get role missing some params, name=%s, owner=%s", name, owner))
+	}
+	rows, err := r.master.Query("SELECT "+roleQueryFields+
+		" FROM auth_role WHERE flag = 0 AND name = ? AND owner = ?", name, owner)
+	if err != nil {
+		return nil, store.Error(err)
+	}
+	roles, err := fetchRoleRows(rows)
+	if err != nil || len(roles) == 0 {
+		return nil, err
+	}
+	return roles[0], nil
+}
+
+// GetRoles 查询角色列表，支持 id、name（前缀匹配）、owner 过滤
+func (r *roleStore) GetRoles(filters map[string]string, offset uint32,
+	limit uint32) (uint32, []*model.Role, error) {
+	where := []string{"flag = 0"}
+	args := make([]interface{}, 0, len(filters))
+	for _, key := range []string{"id", "owner", "name"} {
+		val, ok := filters[key]
+		if !ok {
+			continue
+		}
+		if key == "name" && utils.IsPrefixWildName(val) {
+			where = append(where, "name LIKE ?")
+			args = append(args, val[:len(val)-1]+"%")
+			continue
+		}
+		where = append(where, key+" = ?")
+		args = append(args, val)
+	}
+	whereSql := " WHERE " + strings.Join(where, " AND ")
+
+	var total uint32
+	if err := r.slave.QueryRow("SELECT COUNT(*) FROM auth_role"+whereSql, args...).Scan(&total); err != nil {
+		return 0, nil, store.Error(err)
+	}
+	rows, err := r.slave.Query("SELECT "+roleQueryFields+" FROM auth_role"+whereSql+
+		" ORDER BY mtime DESC LIMIT ?, ?", append(args, offset, limit)...)
+	if err != nil {
+		return 0, nil, store.Error(err)
+	}
+	roles, err := fetchRoleRows(rows)
+	if err != nil {
+		return 0, nil, err
+	}
+	return total, roles, nil
+}
+
+// GetRolesForCache 查询 mtime 之后发生变化的角色，包含已经删除的角色
+func (r *roleStore) GetRolesForCache(mtime time.Time, firstUpdate bool) ([]*model.Role, error) {
+	querySql := "SELECT " + roleQueryFields + " FROM auth_role "
+	args := make([]interface{}, 0, 1)
+	if firstUpdate {
+		querySql += " WHERE flag = 0"
+	} else {
+		querySql += " WHERE mtime >= FROM_UNIXTIME(?)"
+		args = append(args, timeToTimestamp(mtime))
+	}
+	rows, err := r.slave.Query(querySql, args...)
+	if err != nil {
+		return nil, store.Error(err)
+	}
+	return fetchRoleRows(rows)
+}
+
+func fetchRoleRows(rows *sql.Rows) ([]*model.Role, error) {
+	defer rows.Close()
+	ret := make([]*model.Role, 0, 4)
+	for rows.Next() {
+		var (
+			role                   = &model.Role{}
+			strategies, principals sql.NullString
+			flag                   int
+			ctime, mtime           int64
+		)
+		if err := rows.Scan(&role.ID, &role.Name, &role.Owner, &role.Comment, &strategies, &principals,
+			&flag, &ctime, &mtime); err != nil {
+			return nil, store.Error(err)
+		}
+		if err := unmarshalRoleRelation(role, strategies.String, principals.String); err != nil {
+			return nil, err
+		}
+		role.Valid = flag == 0
+		role.CreateTime = time.Unix(ctime, 0)
+		role.ModifyTime = time.Unix(mtime, 0)
+		ret = append(ret, role)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, store.Error(err)
+	}
+	return ret, nil
+}
+
+func marshalRoleRelation(role *model.Role) (string, string, error) {
+	strategyIDs := role.Strategies
+	if strategyIDs == nil {
+		strategyIDs = []string{}
+	}
+	strategies, err := json.Marshal(strategyIDs)
+	if err != nil {
+		return "", "", err
+	}
+	items := make([]rolePrincipal, 0, len(role.Principals))
+	for i := range role.Principals {
+		items = append(items, rolePrincipal{
+			ID:   role.Principals[i].PrincipalID,
+			Type: int(role.Principals[i].PrincipalRole),
+		})
+	}
+	principals, err := json.Marshal(items)
+	if err != nil {
+		return "", "", err
+	}
+	return string(strategies), string(principals), nil
+}
+
+func unmarshalRoleRelation(role *model.Role, strategies, principals string) error {
+	if strategies != "" {
+		if err := json.Unmarshal([]byte(strategies), &role.Strategies); err != nil {
+			return err
+		}
+	}
+	if principals == "" {
+		return nil
+	}
+	items := make([]rolePrincipal, 0, 4)
+	if err := json.Unmarshal([]byte(principals), &items); err != nil {
+		return err
+	}
+	for i := range items {
+		role.Principals = append(role.Principals, model.Principal{
+			PrincipalID:   items[i].ID,
+			PrincipalRole: model.PrincipalType(items[i].Type),
+		})
+	}
+	return nil
+}
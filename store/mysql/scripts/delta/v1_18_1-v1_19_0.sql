@@ -0,0 +1,111 @@
+/*
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+--
+-- Database: `polaris_server`
+--
+USE `polaris_server`;
+
+-- 操作记录
+CREATE TABLE
+    `record_entry` (
+        `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT COMMENT '主键',
+        `resource_type` VARCHAR(64) NOT NULL COMMENT '操作的资源类型',
+        `resource_name` VARCHAR(256) NOT NULL DEFAULT '' COMMENT '操作的资源名称',
+        `namespace` VARCHAR(64) NOT NULL DEFAULT '' COMMENT '资源所属的命名空间',
+        `operator` VARCHAR(128) NOT NULL DEFAULT '' COMMENT '操作人',
+        `operation_type` VARCHAR(32) NOT NULL COMMENT '操作类型',
+        `detail` LONGTEXT COMMENT '操作详情',
+        `server` VARCHAR(64) NOT NULL DEFAULT '' COMMENT '处理请求的服务端节点',
+        `happen_time` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '操作发生时间',
+        `change_set_id` VARCHAR(64) NOT NULL DEFAULT '' COMMENT '产生该记录的顶层操作的变更集 ID',
+        PRIMARY KEY (`id`),
+        KEY `idx_happen_time` (`happen_time`),
+        KEY `idx_operation_type` (`operation_type`),
+        KEY `idx_operator` (`operator`),
+        KEY `idx_change_set_id` (`change_set_id`)
+    ) ENGINE = InnoDB AUTO_INCREMENT = 1 COMMENT = '操作记录表';
+
+-- 鉴权策略资源关联关系支持单独设置过期时间
+ALTER TABLE `auth_strategy_resource`
+    ADD COLUMN `expire_time` BIGINT NOT NULL DEFAULT 0 COMMENT 'Link expire time in unix seconds, 0 means never expire';
+
+ALTER TABLE `auth_strategy_resource`
+    ADD INDEX `idx_expire_time` (`expire_time`);
+
+-- 记录鉴权策略资源关联关系最近一次被使用的时间
+ALTER TABLE `auth_strategy_resource`
+    ADD COLUMN `last_used_time` BIGINT NOT NULL DEFAULT 0 COMMENT 'Link last used time in unix seconds, 0 means never used';
+
+-- 角色，将一组鉴权策略授予用户、用户组
+CREATE TABLE
+    `auth_role` (
+        `id` VARCHAR(128) NOT NULL COMMENT 'Role ID',
+        `name` VARCHAR(100) NOT NULL COMMENT 'Role name',
+        `owner` VARCHAR(128) NOT NULL COMMENT 'The account ID to which this role is',
+        `comment` VARCHAR(255) NOT NULL DEFAULT '' COMMENT 'describe',
+        `strategies` TEXT COMMENT 'JSON array of the strategy IDs granted by this role',
+        `principals` TEXT COMMENT 'JSON array of the users and groups assigned to this role',
+        `flag` TINYINT(4) NOT NULL DEFAULT '0' COMMENT 'Whether the role is valid, 0 is valid, 1 is deleted',
+        `ctime` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT 'Create time',
+        `mtime` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT 'Last updated time',
+        PRIMARY KEY (`id`),
+        UNIQUE KEY (`name`, `owner`),
+        KEY `mtime` (`mtime`)
+    ) ENGINE = InnoDB;
+
+-- 鉴权策略支持拒绝规则以及优先级
+ALTER TABLE `auth_strategy`
+    ADD COLUMN `effect` VARCHAR(32) NOT NULL DEFAULT '' COMMENT 'Effect of this policy, ALLOW or DENY, empty is ALLOW';
+
+ALTER TABLE `auth_strategy`
+    ADD COLUMN `priority` INT NOT NULL DEFAULT 0 COMMENT 'Priority of this policy, the larger the higher';
+
+-- 鉴权策略支持限制生效的接口
+ALTER TABLE `auth_strategy`
+    ADD COLUMN `functions` VARCHAR(2048) NOT NULL DEFAULT '' COMMENT 'Comma separated API names this policy applies to, empty is all';
+
+-- 用户 token 支持过期时间
+ALTER TABLE `user`
+    ADD COLUMN `token_expires_at` BIGINT NOT NULL DEFAULT 0 COMMENT 'Token expire time in unix seconds, 0 means never expire';
+
+-- 用户支持密码过期以及登录失败锁定
+ALTER TABLE `user`
+    ADD COLUMN `password_mtime` BIGINT NOT NULL DEFAULT 0 COMMENT 'Password last modified time in unix seconds, 0 means unknown';
+
+ALTER TABLE `user`
+    ADD COLUMN `login_failures` INT NOT NULL DEFAULT 0 COMMENT 'Consecutive failed login attempts';
+
+ALTER TABLE `user`
+    ADD COLUMN `locked_until` BIGINT NOT NULL DEFAULT 0 COMMENT 'Account locked until this time in unix seconds, 0 means not locked';
+
+-- 控制台登录会话
+CREATE TABLE
+    `user_session` (
+        `id` VARCHAR(128) NOT NULL COMMENT 'Session ID',
+        `user_id` VARCHAR(128) NOT NULL COMMENT 'The user ID to which this session is',
+        `expire_time` BIGINT NOT NULL DEFAULT 0 COMMENT 'Session expire time in unix seconds',
+        `flag` TINYINT(4) NOT NULL DEFAULT '0' COMMENT 'Whether the session is valid, 0 is valid, 1 is revoked',
+        `ctime` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT 'Create time',
+        `mtime` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT 'Last updated time',
+        PRIMARY KEY (`id`),
+        KEY `user_id` (`user_id`),
+        KEY `mtime` (`mtime`)
+    ) ENGINE = InnoDB;
+
+-- 用户组支持嵌套
+ALTER TABLE `user_group`
+    ADD COLUMN `parent_id` VARCHAR(128) NOT NULL DEFAULT '' COMMENT 'Parent user group ID, empty is top level group';
@@ -580,6 +580,10 @@ CREATE TABLE
         `email` VARCHAR(64) NOT NULL DEFAULT '' COMMENT 'Account mailbox',
         `token` VARCHAR(255) NOT NULL COMMENT 'The token information owned by the account can be used for SDK access authentication',
         `token_enable` TINYINT(4) NOT NULL DEFAULT 1,
+        `token_expires_at` BIGINT NOT NULL DEFAULT 0 COMMENT 'Token expire time in unix seconds, 0 means never expire',
+        `password_mtime` BIGINT NOT NULL DEFAULT 0 COMMENT 'Password last modified time in unix seconds, 0 means unknown',
+        `login_failures` INT NOT NULL DEFAULT 0 COMMENT 'Consecutive failed login attempts',
+        `locked_until` BIGINT NOT NULL DEFAULT 0 COMMENT 'Account locked until this time in unix seconds, 0 means not locked',
         `user_type` INT NOT NULL DEFAULT 20 COMMENT 'Account type, 0 is the admin super account, 20 is the primary account, 50 for the child account',
         `comment` VARCHAR(255) NOT NULL COMMENT 'describe',
         `flag` TINYINT(4) NOT NULL DEFAULT '0' COMMENT 'Whether the rules are valid, 0 is valid, 1 is invalid, it is deleted',
@@ -599,6 +603,7 @@ CREATE TABLE
         `token` VARCHAR(255) NOT NULL COMMENT 'TOKEN information of this user group',
         `comment` VARCHAR(255) NOT NULL COMMENT 'Description',
         `token_enable` TINYINT(4) NOT NULL DEFAULT 1,
+        `parent_id` VARCHAR(128) NOT NULL DEFAULT '' COMMENT 'Parent user group ID, empty is top level group',
         `flag` TINYINT(4) NOT NULL DEFAULT '0' COMMENT 'Whether the rules are valid, 0 is valid, 1 is invalid, it is deleted',
         `ctime` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT 'Create time',
         `mtime` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT 'Last updated time',
@@ -628,6 +633,9 @@ CREATE TABLE
         `default` TINYINT(4) NOT NULL DEFAULT '0',
         `revision` VARCHAR(128) NOT NULL COMMENT 'Authentication rule version',
         `flag` TINYINT(4) NOT NULL DEFAULT '0' COMMENT 'Whether the rules are valid, 0 is valid, 1 is invalid, it is deleted',
+        `effect` VARCHAR(32) NOT NULL DEFAULT '' COMMENT 'Effect of this policy, ALLOW or DENY, empty is ALLOW',
+        `priority` INT NOT NULL DEFAULT 0 COMMENT 'Priority of this policy, the larger the higher',
+        `functions` VARCHAR(2048) NOT NULL DEFAULT '' COMMENT 'Comma separated API names this policy applies to, empty is all',
         `ctime` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT 'Create time',
         `mtime` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT 'Last updated time',
         PRIMARY KEY (`id`),
@@ -649,9 +657,41 @@ CREATE TABLE
         `strategy_id` VARCHAR(128) NOT NULL COMMENT 'Strategy ID',
         `res_type` INT NOT NULL COMMENT 'Resource Type, Namespaces = 0, Service = 1, configgroups = 2',
         `res_id` VARCHAR(128) NOT NULL COMMENT 'Resource ID',
+        `expire_time` BIGINT NOT NULL DEFAULT 0 COMMENT 'Link expire time in unix seconds, 0 means never expire',
+        `last_used_time` BIGINT NOT NULL DEFAULT 0 COMMENT 'Link last used time in unix seconds, 0 means never used',
         `ctime` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT 'Create time',
         `mtime` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT 'Last updated time',
         PRIMARY KEY (`strategy_id`, `res_type`, `res_id`),
+        KEY `mtime` (`mtime`),
+        KEY `idx_expire_time` (`expire_time`)
+    ) ENGINE = InnoDB;
+
+CREATE TABLE
+    `auth_role` (
+        `id` VARCHAR(128) NOT NULL COMMENT 'Role ID',
+        `name` VARCHAR(100) NOT NULL COMMENT 'Role name',
+        `owner` VARCHAR(128) NOT NULL COMMENT 'The account ID to which this role is',
+        `comment` VARCHAR(255) NOT NULL DEFAULT '' COMMENT 'describe',
+        `strategies` TEXT COMMENT 'JSON array of the strategy IDs granted by this role',
+        `principals` TEXT COMMENT 'JSON array of the users and groups assigned to this role',
+        `flag` TINYINT(4) NOT NULL DEFAULT '0' COMMENT 'Whether the role is valid, 0 is valid, 1 is deleted',
+        `ctime` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT 'Create time',
+        `mtime` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT 'Last updated time',
+        PRIMARY KEY (`id`),
+        UNIQUE KEY (`name`, `owner`),
+        KEY `mtime` (`mtime`)
+    ) ENGINE = InnoDB;
+
+CREATE TABLE
+    `user_session` (
+        `id` VARCHAR(128) NOT NULL COMMENT 'Session ID',
+        `user_id` VARCHAR(128) NOT NULL COMMENT 'The user ID to which this session is',
+        `expire_time` BIGINT NOT NULL DEFAULT 0 COMMENT 'Session expire time in unix seconds',
+        `flag` TINYINT(4) NOT NULL DEFAULT '0' COMMENT 'Whether the session is valid, 0 is valid, 1 is revoked',
+        `ctime` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT 'Create time',
+        `mtime` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT 'Last updated time',
+        PRIMARY KEY (`id`),
+        KEY `user_id` (`user_id`),
         KEY `mtime` (`mtime`)
     ) ENGINE = InnoDB;
 
@@ -1005,4 +1045,26 @@ CREATE TABLE
         `mtime` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
         PRIMARY KEY (`id`),
         UNIQUE KEY `name` (`group_name`, `name`)
-    ) ENGINE = InnoDB;
\ No newline at end of file
+    ) ENGINE = InnoDB;
+-- --------------------------------------------------------
+--
+-- Table structure `record_entry`
+--
+CREATE TABLE
+    `record_entry` (
+        `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT COMMENT '主键',
+        `resource_type` VARCHAR(64) NOT NULL COMMENT '操作的资源类型',
+        `resource_name` VARCHAR(256) NOT NULL DEFAULT '' COMMENT '操作的资源名称',
+        `namespace` VARCHAR(64) NOT NULL DEFAULT '' COMMENT '资源所属的命名空间',
+        `operator` VARCHAR(128) NOT NULL DEFAULT '' COMMENT '操作人',
+        `operation_type` VARCHAR(32) NOT NULL COMMENT '操作类型',
+        `detail` LONGTEXT COMMENT '操作详情',
+        `server` VARCHAR(64) NOT NULL DEFAULT '' COMMENT '处理请求的服务端节点',
+        `happen_time` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '操作发生时间',
+        `change_set_id` VARCHAR(64) NOT NULL DEFAULT '' COMMENT '产生该记录的顶层操作的变更集 ID',
+        PRIMARY KEY (`id`),
+        KEY `idx_happen_time` (`happen_time`),
+        KEY `idx_operation_type` (`operation_type`),
+        KEY `idx_operator` (`operator`),
+        KEY `idx_change_set_id` (`change_set_id`)
+    ) ENGINE = InnoDB AUTO_INCREMENT = 1 COMMENT = '操作记录表';
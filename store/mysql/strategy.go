@@ -76,6 +76,63 @@ func (s *strategyStore) addStrategy(strategy *model.StrategyDetail) error {
 	}
 	defer func() { _ = tx.Rollback() }()
 
+	if err := s.insertStrategy(tx, strategy); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Errorf("[Store][Strategy] add auth_strategy tx commit err: %s", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// AddStrategies 在同一个事务中批量创建鉴权策略
+func (s *strategyStore) AddStrategies(strategies []*model.StrategyDetail) error {
+	for i := range strategies {
+		strategy := strategies[i]
+		if strategy.ID == "" || strategy.Name == "" || strategy.Owner == "" {
+			return store.NewStatusError(store.EmptyParamsErr, fmt.Sprintf(
+				"add auth_strategy missing some params, id is %s, name is %s, owner is %s",
+				strategy.ID, strategy.Name, strategy.Owner))
+		}
+	}
+	if len(strategies) == 0 {
+		return nil
+	}
+
+	// 先清理无效数据
+	for i := range strategies {
+		if err := s.cleanInvalidStrategy(strategies[i].Name, strategies[i].Owner); err != nil {
+			return store.Error(err)
+		}
+	}
+
+	err := RetryTransaction("addStrategies", func() error {
+		tx, err := s.master.Begin()
+		if err != nil {
+			return err
+		}
+		defer func() { _ = tx.Rollback() }()
+
+		for i := range strategies {
+			if err := s.insertStrategy(tx, strategies[i]); err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Commit(); err != nil {
+			log.Errorf("[Store][Strategy] add auth_strategies tx commit err: %s", err.Error())
+			return err
+		}
+		return nil
+	})
+	return store.Error(err)
+}
+
+// insertStrategy 在事务中保存策略主信息以及 principal、资源的关联关系
+func (s *strategyStore) insertStrategy(tx *BaseTx, strategy *model.StrategyDetail) error {
 	isDefault := 0
 	if strategy.Default {
 		isDefault = 1
@@ -93,21 +150,17 @@ func (s *strategyStore) addStrategy(strategy *model.StrategyDetail) error {
 
 	// 保存策略主信息
 	saveMainSql := "INSERT INTO auth_strategy(`id`, `name`, `action`, `owner`, `comment`, `flag`, " +
-		" `default`, `revision`) VALUES (?,?,?,?,?,?,?,?)"
-	if _, err = tx.Exec(saveMainSql,
+		" `default`, `revision`, `effect`, `priority`, `functions`) VALUES (?,?,?,?,?,?,?,?,?,?,?)"
+	if _, err := tx.Exec(saveMainSql,
 		[]interface{}{
 			strategy.ID, strategy.Name, strategy.Action, strategy.Owner, strategy.Comment,
-			0, isDefault, strategy.Revision}...,
+			0, isDefault, strategy.Revision, string(strategy.Effect), strategy.Priority,
+			model.FormatStrategyFunctions(strategy.Functions)}...,
 	); err != nil {
 		log.Error("[Store][Strategy] add auth_strategy main info", zap.Error(err))
 		return err
 	}
 
-	if err := tx.Commit(); err != nil {
-		log.Errorf("[Store][Strategy] add auth_strategy tx commit err: %s", err.Error())
-		return err
-	}
-
 	return nil
 }
 
@@ -166,6 +219,44 @@ func (s *strategyStore) updateStrategy(strategy *model.ModifyStrategyDetail) err
 	return nil
 }
 
+// UpdateStrategyEffect 更新策略的授权效果以及优先级
+func (s *strategyStore) UpdateStrategyEffect(id string, effect model.StrategyEffect, priority int32) error {
+	if id == "" {
+		return store.NewStatusError(store.EmptyParamsErr, fmt.Sprintf(
+			"update auth_strategy effect missing some params, id is %s", id))
+	}
+
+	err := RetryTransaction("updateStrategyEffect", func() error {
+		updateSql := "UPDATE auth_strategy SET effect = ?, priority = ?, revision = ?, mtime = sysdate() " +
+			" WHERE id = ? AND flag = 0"
+		if _, err := s.master.Exec(updateSql, string(effect), priority, utils.NewUUID(), id); err != nil {
+			log.Error("[Store][Strategy] update auth_strategy effect", zap.String("sql", updateSql), zap.Error(err))
+			return err
+		}
+		return nil
+	})
+	return store.Error(err)
+}
+
+// UpdateStrategyFunctions 更新策略生效的接口名称
+func (s *strategyStore) UpdateStrategyFunctions(id string, functions []string) error {
+	if id == "" {
+		return store.NewStatusError(store.EmptyParamsErr, fmt.Sprintf(
+			"update auth_strategy functions missing some params, id is %s", id))
+	}
+
+	err := RetryTransaction("updateStrategyFunctions", func() error {
+		updateSql := "UPDATE auth_strategy SET functions = ?, revision = ?, mtime = sysdate() WHERE id = ? AND flag = 0"
+		val := model.FormatStrategyFunctions(functions)
+		if _, err := s.master.Exec(updateSql, val, utils.NewUUID(), id); err != nil {
+			log.Error("[Store][Strategy] update auth_strategy functions", zap.String("sql", updateSql), zap.Error(err))
+			return err
+		}
+		return nil
+	})
+	return store.Error(err)
+}
+
 func (s *strategyStore) DeleteStrategy(id string) error {
 	if id == "" {
 		return store.NewStatusError(store.EmptyParamsErr, fmt.Sprintf(
@@ -262,14 +353,15 @@ func (s *strategyStore) addStrategyResources(tx *BaseTx, id string, resources []
 		return nil
 	}
 
-	saveResSql := "REPLACE INTO auth_strategy_resource(strategy_id, res_type, res_id) VALUES "
+	saveResSql := "REPLACE INTO auth_strategy_resource(strategy_id, res_type, res_id, expire_time) VALUES "
 	values := make([]string, 0)
 	args := make([]interface{}, 0)
 
 	for i := range resources {
 		resource := resources[i]
-		values = append(values, "(?,?,?)")
-		args = append(args, resource.StrategyID, resource.ResType, resource.ResID)
+		values = append(values, "(?,?,?,?)")
+		args = append(args, resource.StrategyID, resource.ResType, resource.ResID,
+			resourceExpireToUnix(resource.ExpireTime))
 	}
 
 	if len(values) == 0 {
@@ -316,9 +408,11 @@ func (s *strategyStore) LooseAddStrategyResources(resources []model.StrategyReso
 	for i := range resources {
 		resource := resources[i]
 
-		saveResSql := "REPLACE INTO auth_strategy_resource(strategy_id, res_type, res_id) VALUES (?,?,?)"
+		saveResSql := "REPLACE INTO auth_strategy_resource(strategy_id, res_type, res_id, expire_time) " +
+			"VALUES (?,?,?,?)"
 		args := make([]interface{}, 0)
-		args = append(args, resource.StrategyID, resource.ResType, resource.ResID)
+		args = append(args, resource.StrategyID, resource.ResType, resource.ResID,
+			resourceExpireToUnix(resource.ExpireTime))
 
 		if _, err = tx.Exec(saveResSql, args...); err != nil {
 			err = store.Error(err)
@@ -358,7 +452,7 @@ func (s *strategyStore) RemoveStrategyResources(resources []model.StrategyResour
 		args = append(args, resource.StrategyID, resource.ResID, resource.ResType)
 		if resource.StrategyID == "" {
 			saveResSql = "DELETE FROM auth_strategy_resource WHERE res_id = ? AND res_type = ?"
-			args = append(args, resource.ResID, resource.ResType)
+			args = []interface{}{resource.ResID, resource.ResType}
 		}
 		if _, err := tx.Exec(saveResSql, args...); err != nil {
 			return err
@@ -386,7 +480,8 @@ func (s *strategyStore) GetStrategyDetail(id string) (*model.StrategyDetail, err
 	}
 
 	querySql := "SELECT ag.id, ag.name, ag.action, ag.owner, ag.default, ag.comment, ag.revision, ag.flag, " +
-		" UNIX_TIMESTAMP(ag.ctime), UNIX_TIMESTAMP(ag.mtime) FROM auth_strategy AS ag WHERE ag.flag = 0 AND ag.id = ?"
+		" UNIX_TIMESTAMP(ag.ctime), UNIX_TIMESTAMP(ag.mtime), ag.effect, ag.priority, ag.functions " +
+		" FROM auth_strategy AS ag WHERE ag.flag = 0 AND ag.id = ?"
 
 	row := s.master.QueryRow(querySql, id)
 
@@ -405,7 +500,7 @@ func (s *strategyStore) GetDefaultStrategyDetailByPrincipal(principalId string,
 	querySql := `
 	 SELECT ag.id, ag.name, ag.action, ag.owner, ag.default
 		 , ag.comment, ag.revision, ag.flag, UNIX_TIMESTAMP(ag.ctime)
-		 , UNIX_TIMESTAMP(ag.mtime)
+		 , UNIX_TIMESTAMP(ag.mtime), ag.effect, ag.priority, ag.functions
 	 FROM auth_strategy ag
 	 WHERE ag.flag = 0
 		 AND ag.default = 1
@@ -426,10 +521,11 @@ func (s *strategyStore) getStrategyDetail(row *sql.Row) (*model.StrategyDetail,
 	var (
 		ctime, mtime    int64
 		isDefault, flag int16
+		functions       string
 	)
 	ret := new(model.StrategyDetail)
 	if err := row.Scan(&ret.ID, &ret.Name, &ret.Action, &ret.Owner, &isDefault, &ret.Comment,
-		&ret.Revision, &flag, &ctime, &mtime); err != nil {
+		&ret.Revision, &flag, &ctime, &mtime, &ret.Effect, &ret.Priority, &functions); err != nil {
 		switch err {
 		case sql.ErrNoRows:
 			return nil, nil
@@ -442,6 +538,7 @@ func (s *strategyStore) getStrategyDetail(row *sql.Row) (*model.StrategyDetail,
 	ret.ModifyTime = time.Unix(mtime, 0)
 	ret.Valid = flag == 0
 	ret.Default = isDefault == 1
+	ret.Functions = model.ParseStrategyFunctions(functions)
 
 	resArr, err := s.getStrategyResources(s.slave.Query, ret.ID)
 	if err != nil {
@@ -483,7 +580,10 @@ func (s *strategyStore) listStrategies(filters map[string]string, offset uint32,
 			 ag.revision,
 			 ag.flag,
 			 UNIX_TIMESTAMP(ag.ctime),
-			 UNIX_TIMESTAMP(ag.mtime)
+			 UNIX_TIMESTAMP(ag.mtime),
+			 ag.effect,
+			 ag.priority,
+			 ag.functions
 		   FROM
 			 (
 			   auth_strategy ag
@@ -629,7 +729,8 @@ func (s *strategyStore) GetStrategyDetailsForCache(mtime time.Time,
 
 	args := make([]interface{}, 0)
 	querySql := "SELECT ag.id, ag.name, ag.action, ag.owner, ag.comment, ag.default, ag.revision, ag.flag, " +
-		" UNIX_TIMESTAMP(ag.ctime), UNIX_TIMESTAMP(ag.mtime) FROM auth_strategy ag "
+		" UNIX_TIMESTAMP(ag.ctime), UNIX_TIMESTAMP(ag.mtime), ag.effect, ag.priority, ag.functions " +
+		" FROM auth_strategy ag "
 
 	if !firstUpdate {
 		querySql += " WHERE ag.mtime >= FROM_UNIXTIME(?)"
@@ -669,11 +770,39 @@ func (s *strategyStore) GetStrategyDetailsForCache(mtime time.Time,
 	return ret, nil
 }
 
+// GetAllStrategyDetailsTx 在读事务中获取全部有效的鉴权策略以及其关联的 principal、资源
+func (s *strategyStore) GetAllStrategyDetailsTx(tx store.Tx) ([]*model.StrategyDetail, error) {
+	dbTx, _ := tx.GetDelegateTx().(*BaseTx)
+	querySql := "SELECT ag.id, ag.name, ag.action, ag.owner, ag.comment, ag.default, ag.revision, ag.flag, " +
+		" UNIX_TIMESTAMP(ag.ctime), UNIX_TIMESTAMP(ag.mtime), ag.effect, ag.priority, ag.functions " +
+		" FROM auth_strategy ag WHERE ag.flag = 0"
+
+	ret, err := s.collectStrategies(dbTx.Query, querySql, nil, false)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range ret {
+		detail := ret[i]
+		resArr, err := s.getStrategyResources(dbTx.Query, detail.ID)
+		if err != nil {
+			return nil, store.Error(err)
+		}
+		principals, err := s.getStrategyPrincipals(dbTx.Query, detail.ID)
+		if err != nil {
+			return nil, store.Error(err)
+		}
+		detail.Resources = resArr
+		detail.Principals = principals
+	}
+	return ret, nil
+}
+
 // GetStrategyResources 获取对应 principal 能操作的所有资源
 func (s *strategyStore) GetStrategyResources(principalId string,
 	principalRole model.PrincipalType) ([]model.StrategyResource, error) {
 
-	querySql := "SELECT res_id, res_type FROM auth_strategy_resource WHERE strategy_id IN (SELECT DISTINCT " +
+	querySql := "SELECT res_id, res_type, expire_time FROM auth_strategy_resource WHERE strategy_id IN (SELECT DISTINCT " +
 		" ap.strategy_id FROM auth_principal ap join auth_strategy ar ON ap.strategy_id = ar.id WHERE ar.flag = 0 " +
 		" AND ap.principal_id = ? AND ap.principal_role = ? )"
 
@@ -694,10 +823,12 @@ func (s *strategyStore) GetStrategyResources(principalId string,
 	resArr := make([]model.StrategyResource, 0)
 
 	for rows.Next() {
+		var expireTime int64
 		res := new(model.StrategyResource)
-		if err := rows.Scan(&res.ResID, &res.ResType); err != nil {
+		if err := rows.Scan(&res.ResID, &res.ResType, &expireTime); err != nil {
 			return nil, store.Error(err)
 		}
+		res.ExpireTime = unixToResourceExpire(expireTime)
 		resArr = append(resArr, *res)
 	}
 
@@ -732,7 +863,7 @@ func (s *strategyStore) getStrategyPrincipals(queryHander QueryHandler, id strin
 }
 
 func (s *strategyStore) getStrategyResources(queryHander QueryHandler, id string) ([]model.StrategyResource, error) {
-	querySql := "SELECT res_id, res_type FROM auth_strategy_resource WHERE strategy_id = ?"
+	querySql := "SELECT res_id, res_type, expire_time FROM auth_strategy_resource WHERE strategy_id = ?"
 	rows, err := queryHander(querySql, id)
 	if err != nil {
 		switch err {
@@ -748,33 +879,218 @@ func (s *strategyStore) getStrategyResources(queryHander QueryHandler, id string
 	resArr := make([]model.StrategyResource, 0)
 
 	for rows.Next() {
+		var expireTime int64
 		res := new(model.StrategyResource)
-		if err := rows.Scan(&res.ResID, &res.ResType); err != nil {
+		if err := rows.Scan(&res.ResID, &res.ResType, &expireTime); err != nil {
 			return nil, store.Error(err)
 		}
+		res.ExpireTime = unixToResourceExpire(expireTime)
 		resArr = append(resArr, *res)
 	}
 
 	return resArr, nil
 }
 
+// RemoveExpiredStrategyResources 在同一个事务中删除已经过期的资源关联关系，并返回被删除的关联关系
+// 删除语句同样携带过期条件，查询之后被续期的关联关系不会被删除
+func (s *strategyStore) RemoveExpiredStrategyResources(now time.Time,
+	limit uint32) ([]model.StrategyResource, error) {
+	var removed []model.StrategyResource
+	err := RetryTransaction("removeExpiredStrategyResources", func() error {
+		tx, err := s.master.Begin()
+		if err != nil {
+			return err
+		}
+		defer func() { _ = tx.Rollback() }()
+
+		expired, err := s.lockExpiredStrategyResources(tx, now, limit)
+		if err != nil {
+			return err
+		}
+		removed = make([]model.StrategyResource, 0, len(expired))
+		strategyIds := make(map[string]struct{}, len(expired))
+		delSql := "DELETE FROM auth_strategy_resource WHERE strategy_id = ? AND res_id = ? AND res_type = ? " +
+			" AND expire_time > 0 AND expire_time <= ?"
+		for i := range expired {
+			res := expired[i]
+			result, err := tx.Exec(delSql, res.StrategyID, res.ResID, res.ResType, now.Unix())
+			if err != nil {
+				log.Error("[Store][Strategy] remove expired strategy resource", zap.String("sql", delSql),
+					zap.Error(err))
+				return err
+			}
+			if affected, err := result.RowsAffected(); err != nil || affected == 0 {
+				continue
+			}
+			removed = append(removed, res)
+			strategyIds[res.StrategyID] = struct{}{}
+		}
+		// 主要是为了能够触发 StrategyCache 的刷新逻辑
+		updateStrategySql := "UPDATE auth_strategy SET mtime = sysdate() WHERE id = ?"
+		for id := range strategyIds {
+			if _, err := tx.Exec(updateStrategySql, id); err != nil {
+				return err
+			}
+		}
+		return tx.Commit()
+	})
+	if err != nil {
+		log.Error("[Store][Strategy] remove expired strategy resources", zap.Error(err))
+		return nil, store.Error(err)
+	}
+	return removed, nil
+}
+
+// lockExpiredStrategyResources 查询并锁定已经过期的资源关联关系
+func (s *strategyStore) lockExpiredStrategyResources(tx *BaseTx, now time.Time,
+	limit uint32) ([]model.StrategyResource, error) {
+	querySql := "SELECT strategy_id, res_id, res_type, expire_time FROM auth_strategy_resource " +
+		" WHERE expire_time > 0 AND expire_time <= ? LIMIT ? FOR UPDATE"
+	rows, err := tx.Query(querySql, now.Unix(), limit)
+	if err != nil {
+		log.Error("[Store][Strategy] get expired strategy resources", zap.String("sql", querySql), zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	resArr := make([]model.StrategyResource, 0)
+	for rows.Next() {
+		var expireTime int64
+		res := new(model.StrategyResource)
+		if err := rows.Scan(&res.StrategyID, &res.ResID, &res.ResType, &expireTime); err != nil {
+			return nil, err
+		}
+		res.ExpireTime = unixToResourceExpire(expireTime)
+		resArr = append(resArr, *res)
+	}
+	return resArr, rows.Err()
+}
+
+// UpdateStrategyResourceUsage 更新资源关联关系最近一次被使用的时间，显式保留 mtime，避免触发缓存刷新
+func (s *strategyStore) UpdateStrategyResourceUsage(usages []model.StrategyResourceUsage) error {
+	if len(usages) == 0 {
+		return nil
+	}
+	err := RetryTransaction("updateStrategyResourceUsage", func() error {
+		tx, err := s.master.Begin()
+		if err != nil {
+			return err
+		}
+		defer func() { _ = tx.Rollback() }()
+
+		updateSql := "UPDATE auth_strategy_resource SET last_used_time = ?, mtime = mtime " +
+			" WHERE strategy_id = ? AND res_type = ? AND res_id = ? AND last_used_time < ?"
+		for i := range usages {
+			usage := usages[i]
+			lastUsed := resourceExpireToUnix(usage.LastUsedTime)
+			if _, err := tx.Exec(updateSql, lastUsed, usage.StrategyID, usage.ResType, usage.ResID,
+				lastUsed); err != nil {
+				log.Error("[Store][Strategy] update strategy resource usage", zap.String("sql", updateSql),
+					zap.Error(err))
+				return err
+			}
+		}
+		return tx.Commit()
+	})
+	return store.Error(err)
+}
+
+// GetStrategyResourceUsage 获取策略下资源关联关系最近一次被使用的时间，未被使用过的关联关系不返回
+func (s *strategyStore) GetStrategyResourceUsage(strategyID string) ([]model.StrategyResourceUsage, error) {
+	querySql := "SELECT strategy_id, res_id, res_type, last_used_time FROM auth_strategy_resource " +
+		" WHERE strategy_id = ? AND last_used_time > 0"
+	rows, err := s.master.Query(querySql, strategyID)
+	if err != nil {
+		log.Error("[Store][Strategy] get strategy resource usage", zap.String("sql", querySql), zap.Error(err))
+		return nil, store.Error(err)
+	}
+	defer rows.Close()
+
+	ret := make([]model.StrategyResourceUsage, 0)
+	for rows.Next() {
+		var lastUsed int64
+		usage := model.StrategyResourceUsage{}
+		if err := rows.Scan(&usage.StrategyID, &usage.ResID, &usage.ResType, &lastUsed); err != nil {
+			return nil, store.Error(err)
+		}
+		usage.LastUsedTime = unixToResourceExpire(lastUsed)
+		ret = append(ret, usage)
+	}
+	return ret, nil
+}
+
+// TransferStrategyResource 在同一个事务中将资源关联关系从 resource.StrategyID 转移到 toStrategyID
+func (s *strategyStore) TransferStrategyResource(resource model.StrategyResource, toStrategyID string) error {
+	if resource.StrategyID == "" || toStrategyID == "" || resource.ResID == "" {
+		return store.NewStatusError(store.EmptyParamsErr, fmt.Sprintf(
+			"transfer auth_strategy resource missing some params, from is %s, to is %s, res is %s",
+			resource.StrategyID, toStrategyID, resource.ResID))
+	}
+	err := RetryTransaction("transferStrategyResource", func() error {
+		tx, err := s.master.Begin()
+		if err != nil {
+			return err
+		}
+		defer func() { _ = tx.Rollback() }()
+
+		// 先关联到新的策略，再从原策略中移除
+		addSql := "REPLACE INTO auth_strategy_resource(strategy_id, res_type, res_id, expire_time) VALUES (?,?,?,?)"
+		if _, err := tx.Exec(addSql, toStrategyID, resource.ResType, resource.ResID,
+			resourceExpireToUnix(resource.ExpireTime)); err != nil {
+			return err
+		}
+		delSql := "DELETE FROM auth_strategy_resource WHERE strategy_id = ? AND res_id = ? AND res_type = ?"
+		if _, err := tx.Exec(delSql, resource.StrategyID, resource.ResID, resource.ResType); err != nil {
+			return err
+		}
+		// 主要是为了能够触发 StrategyCache 的刷新逻辑
+		updateStrategySql := "UPDATE auth_strategy SET mtime = sysdate() WHERE id IN (?, ?)"
+		if _, err := tx.Exec(updateStrategySql, resource.StrategyID, toStrategyID); err != nil {
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			log.Error("[Store][Strategy] transfer strategy resource tx commit", zap.Error(err))
+			return err
+		}
+		return nil
+	})
+	return store.Error(err)
+}
+
+// resourceExpireToUnix 资源关联关系的过期时间以 unix 秒保存, 0 表示永不过期
+func resourceExpireToUnix(expireTime time.Time) int64 {
+	if expireTime.IsZero() {
+		return 0
+	}
+	return expireTime.Unix()
+}
+
+func unixToResourceExpire(expireTime int64) time.Time {
+	if expireTime <= 0 {
+		return time.Time{}
+	}
+	return time.Unix(expireTime, 0)
+}
+
 func fetchRown2StrategyDetail(rows *sql.Rows) (*model.StrategyDetail, error) {
 	var (
 		ctime, mtime    int64
 		isDefault, flag int16
+		functions       string
 	)
 	ret := &model.StrategyDetail{
 		Resources: make([]model.StrategyResource, 0),
 	}
 
 	if err := rows.Scan(&ret.ID, &ret.Name, &ret.Action, &ret.Owner, &ret.Comment, &isDefault, &ret.Revision, &flag,
-		&ctime, &mtime); err != nil {
+		&ctime, &mtime, &ret.Effect, &ret.Priority, &functions); err != nil {
 		return nil, store.Error(err)
 	}
 
 	ret.CreateTime = time.Unix(ctime, 0)
 	ret.ModifyTime = time.Unix(mtime, 0)
 	ret.Valid = flag == 0
+	ret.Functions = model.ParseStrategyFunctions(functions)
 
 	if isDefault == 1 {
 		ret.Default = true
@@ -0,0 +1,63 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package sqldb
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	apisecurity "github.com/polarismesh/specification/source/go/api/v1/security"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_strategyStore_RemoveExpiredStrategyResources(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	now := time.Now()
+	svcType := int32(apisecurity.ResourceType_Services)
+	rows := sqlmock.NewRows([]string{"strategy_id", "res_id", "res_type", "expire_time"}).
+		AddRow("strategy-1", "service-1", svcType, now.Add(-time.Minute).Unix()).
+		AddRow("strategy-1", "service-2", svcType, now.Add(-time.Minute).Unix())
+	delSql := "DELETE FROM auth_strategy_resource WHERE strategy_id = ? AND res_id = ? AND res_type = ? " +
+		" AND expire_time > 0 AND expire_time <= ?"
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT strategy_id, res_id, res_type, expire_time FROM auth_strategy_resource "+
+		" WHERE expire_time > 0 AND expire_time <= ? LIMIT ? FOR UPDATE").
+		WithArgs(now.Unix(), 10).WillReturnRows(rows)
+	mock.ExpectExec(delSql).WithArgs("strategy-1", "service-1", svcType, now.Unix()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	// 已经被续期的关联关系不满足过期条件，不会被删除
+	mock.ExpectExec(delSql).WithArgs("strategy-1", "service-2", svcType, now.Unix()).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("UPDATE auth_strategy SET mtime = sysdate() WHERE id = ?").WithArgs("strategy-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	s := &strategyStore{master: &BaseDB{DB: db}}
+	removed, err := s.RemoveExpiredStrategyResources(now, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(removed))
+	assert.Equal(t, "service-1", removed[0].ResID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -83,7 +83,8 @@ func (u *userStore) addUser(user *model.User) error {
 
 	addSql := "INSERT INTO user(`id`, `name`, `password`, `owner`, `source`, `token`, " +
 		" `comment`, `flag`, `user_type`, " +
-		" `ctime`, `mtime`, `mobile`, `email`) VALUES (?,?,?,?,?,?,?,?,?,sysdate(),sysdate(),?,?)"
+		" `ctime`, `mtime`, `mobile`, `email`, `token_expires_at`, `password_mtime`) " +
+		" VALUES (?,?,?,?,?,?,?,?,?,sysdate(),sysdate(),?,?,?,?)"
 
 	_, err = tx.Exec(addSql, []interface{}{
 		user.ID,
@@ -97,6 +98,8 @@ func (u *userStore) addUser(user *model.User) error {
 		user.Type,
 		user.Mobile,
 		user.Email,
+		resourceExpireToUnix(user.TokenExpireAt),
+		resourceExpireToUnix(user.PasswordModifyTime),
 	}...)
 
 	if err != nil {
@@ -149,7 +152,8 @@ func (u *userStore) updateUser(user *model.User) error {
 	}
 
 	modifySql := "UPDATE user SET password = ?, token = ?, comment = ?, token_enable = ?, mobile = ?, email = ?, " +
-		" mtime = sysdate() WHERE id = ? AND flag = 0"
+		" token_expires_at = ?, password_mtime = ?, mtime = sysdate() " +
+		" WHERE id = ? AND flag = 0"
 
 	_, err = tx.Exec(modifySql, []interface{}{
 		user.Password,
@@ -158,6 +162,8 @@ func (u *userStore) updateUser(user *model.User) error {
 		tokenEnable,
 		user.Mobile,
 		user.Email,
+		resourceExpireToUnix(user.TokenExpireAt),
+		resourceExpireToUnix(user.PasswordModifyTime),
 		user.ID,
 	}...)
 
@@ -173,6 +179,65 @@ func (u *userStore) updateUser(user *model.User) error {
 	return nil
 }
 
+// IncrUserLoginFailures 在同一条语句中累加登录失败次数，避免并发的登录请求互相覆盖
+func (u *userStore) IncrUserLoginFailures(id string, maxFailures int, lockedUntil time.Time) (bool, error) {
+	if id == "" {
+		return false, store.NewStatusError(store.EmptyParamsErr, "incr user login failures missing id")
+	}
+
+	var locked bool
+	err := RetryTransaction("incrUserLoginFailures", func() error {
+		var err error
+		locked, err = u.incrUserLoginFailures(id, maxFailures, lockedUntil)
+		return err
+	})
+	return locked, store.Error(err)
+}
+
+func (u *userStore) incrUserLoginFailures(id string, maxFailures int, lockedUntil time.Time) (bool, error) {
+	tx, err := u.master.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	// 单表 UPDATE 的赋值按照从左到右的顺序执行，locked_until 需要先于 login_failures 使用累加前的值
+	modifySql := "UPDATE user SET locked_until = IF(login_failures + 1 >= ?, ?, locked_until), " +
+		" login_failures = IF(login_failures + 1 >= ?, 0, login_failures + 1), mtime = sysdate() " +
+		" WHERE id = ? AND flag = 0"
+	if _, err := tx.Exec(modifySql, maxFailures, resourceExpireToUnix(lockedUntil), maxFailures, id); err != nil {
+		return false, err
+	}
+	var failures int
+	if err := tx.QueryRow("SELECT login_failures FROM user WHERE id = ? AND flag = 0", id).
+		Scan(&failures); err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, err
+	}
+	if err := tx.Commit(); err != nil {
+		log.Errorf("[Store][User] incr user login failures tx commit err: %s", err.Error())
+		return false, err
+	}
+	// 累加后清零说明本次达到了上限
+	return failures == 0, nil
+}
+
+// ResetUserLoginFailures 清空登录失败次数以及锁定状态，只修改这两列
+func (u *userStore) ResetUserLoginFailures(id string) error {
+	if id == "" {
+		return store.NewStatusError(store.EmptyParamsErr, "reset user login failures missing id")
+	}
+
+	err := RetryTransaction("resetUserLoginFailures", func() error {
+		_, err := u.master.Exec("UPDATE user SET login_failures = 0, locked_until = 0, mtime = sysdate() "+
+			" WHERE id = ? AND flag = 0", id)
+		return err
+	})
+	return store.Error(err)
+}
+
 // DeleteUser delete user by user id
 func (u *userStore) DeleteUser(user *model.User) error {
 	if user.ID == "" || user.Name == "" {
@@ -245,10 +310,14 @@ func (u *userStore) GetSubCount(user *model.User) (uint32, error) {
 
 // GetUser get user by user id
 func (u *userStore) GetUser(id string) (*model.User, error) {
-	var tokenEnable, userType int
+	var (
+		tokenEnable, userType      int
+		tokenExpireAt              int64
+		passwordMtime, lockedUntil int64
+	)
 	getSql := `
 		 SELECT u.id, u.name, u.password, u.owner, u.comment, u.source, u.token, u.token_enable, 
-		 	u.user_type, u.mobile, u.email
+		 	u.user_type, u.mobile, u.email, u.token_expires_at, u.password_mtime, u.login_failures, u.locked_until
 		 FROM user u
 		 WHERE u.flag = 0 AND u.id = ? 
 	  `
@@ -258,7 +327,8 @@ func (u *userStore) GetUser(id string) (*model.User, error) {
 	)
 
 	if err := row.Scan(&user.ID, &user.Name, &user.Password, &user.Owner, &user.Comment, &user.Source,
-		&user.Token, &tokenEnable, &userType, &user.Mobile, &user.Email); err != nil {
+		&user.Token, &tokenEnable, &userType, &user.Mobile, &user.Email, &tokenExpireAt, &passwordMtime,
+		&user.LoginFailures, &lockedUntil); err != nil {
 		switch err {
 		case sql.ErrNoRows:
 			return nil, nil
@@ -268,6 +338,9 @@ func (u *userStore) GetUser(id string) (*model.User, error) {
 	}
 
 	user.TokenEnable = tokenEnable == 1
+	user.TokenExpireAt = unixToResourceExpire(tokenExpireAt)
+	user.PasswordModifyTime = unixToResourceExpire(passwordMtime)
+	user.LockedUntil = unixToResourceExpire(lockedUntil)
 	user.Type = model.UserRoleType(userType)
 	// 北极星后续不在保存用户的 mobile 以及 email 信息，这里针对原来保存的数据也不进行对外展示，强制屏蔽数据
 	user.Mobile = ""
@@ -279,7 +352,7 @@ func (u *userStore) GetUser(id string) (*model.User, error) {
 func (u *userStore) GetUserByName(name, ownerId string) (*model.User, error) {
 	getSql := `
 		 SELECT u.id, u.name, u.password, u.owner, u.comment, u.source, u.token, u.token_enable, 
-		 	u.user_type, u.mobile, u.email
+		 	u.user_type, u.mobile, u.email, u.token_expires_at, u.password_mtime, u.login_failures, u.locked_until
 		 FROM user u
 		 WHERE u.flag = 0
 			  AND u.name = ?
@@ -287,13 +360,16 @@ func (u *userStore) GetUserByName(name, ownerId string) (*model.User, error) {
 	  `
 
 	var (
-		row                   = u.master.QueryRow(getSql, name, ownerId)
-		user                  = new(model.User)
-		tokenEnable, userType int
+		row                        = u.master.QueryRow(getSql, name, ownerId)
+		user                       = new(model.User)
+		tokenEnable, userType      int
+		tokenExpireAt              int64
+		passwordMtime, lockedUntil int64
 	)
 
 	if err := row.Scan(&user.ID, &user.Name, &user.Password, &user.Owner, &user.Comment, &user.Source,
-		&user.Token, &tokenEnable, &userType, &user.Mobile, &user.Email); err != nil {
+		&user.Token, &tokenEnable, &userType, &user.Mobile, &user.Email, &tokenExpireAt, &passwordMtime,
+		&user.LoginFailures, &lockedUntil); err != nil {
 		switch err {
 		case sql.ErrNoRows:
 			return nil, nil
@@ -303,6 +379,9 @@ func (u *userStore) GetUserByName(name, ownerId string) (*model.User, error) {
 	}
 
 	user.TokenEnable = tokenEnable == 1
+	user.TokenExpireAt = unixToResourceExpire(tokenExpireAt)
+	user.PasswordModifyTime = unixToResourceExpire(passwordMtime)
+	user.LockedUntil = unixToResourceExpire(lockedUntil)
 	user.Type = model.UserRoleType(userType)
 	// 北极星后续不在保存用户的 mobile 以及 email 信息，这里针对原来保存的数据也不进行对外展示，强制屏蔽数据
 	user.Mobile = ""
@@ -319,7 +398,8 @@ func (u *userStore) GetUserByIds(ids []string) ([]*model.User, error) {
 	getSql := `
 	  SELECT u.id, u.name, u.password, u.owner, u.comment, u.source
 		  , u.token, u.token_enable, u.user_type, UNIX_TIMESTAMP(u.ctime)
-		  , UNIX_TIMESTAMP(u.mtime), u.flag, u.mobile, u.email
+		  , UNIX_TIMESTAMP(u.mtime), u.flag, u.mobile, u.email, u.token_expires_at
+		  , u.password_mtime, u.login_failures, u.locked_until
 	  FROM user u
 	  WHERE u.flag = 0 
 		  AND u.id IN ( 
@@ -377,7 +457,8 @@ func (u *userStore) listUsers(filters map[string]string, offset uint32, limit ui
 	getSql := `
 	  SELECT id, name, password, owner, comment, source
 		  , token, token_enable, user_type, UNIX_TIMESTAMP(ctime)
-		  , UNIX_TIMESTAMP(mtime), flag, mobile, email
+		  , UNIX_TIMESTAMP(mtime), flag, mobile, email, token_expires_at
+		  , password_mtime, login_failures, locked_until
 	  FROM user
 	  WHERE flag = 0 
 	  `
@@ -443,7 +524,8 @@ func (u *userStore) listGroupUsers(filters map[string]string, offset uint32, lim
 	querySql := `
 		  SELECT u.id, name, password, owner, u.comment, source
 			  , token, token_enable, user_type, UNIX_TIMESTAMP(u.ctime)
-			  , UNIX_TIMESTAMP(u.mtime), u.flag, u.mobile, u.email
+			  , UNIX_TIMESTAMP(u.mtime), u.flag, u.mobile, u.email, u.token_expires_at
+			  , u.password_mtime, u.login_failures, u.locked_until
 		  FROM user_group_relation ug
 			  LEFT JOIN user u ON ug.user_id = u.id AND u.flag = 0
 		  WHERE 1=1 
@@ -503,7 +585,8 @@ func (u *userStore) GetUsersForCache(mtime time.Time, firstUpdate bool) ([]*mode
 	querySql := `
 	  SELECT u.id, u.name, u.password, u.owner, u.comment, u.source
 		  , u.token, u.token_enable, user_type, UNIX_TIMESTAMP(u.ctime)
-		  , UNIX_TIMESTAMP(u.mtime), u.flag, u.mobile, u.email
+		  , UNIX_TIMESTAMP(u.mtime), u.flag, u.mobile, u.email, u.token_expires_at
+		  , u.password_mtime, u.login_failures, u.locked_until
 	  FROM user u 
 	  `
 
@@ -520,9 +603,23 @@ func (u *userStore) GetUsersForCache(mtime time.Time, firstUpdate bool) ([]*mode
 	return users, nil
 }
 
+// GetAllUsersTx 在读事务中获取全部有效的用户
+func (u *userStore) GetAllUsersTx(tx store.Tx) ([]*model.User, error) {
+	dbTx, _ := tx.GetDelegateTx().(*BaseTx)
+	querySql := `
+	  SELECT u.id, u.name, u.password, u.owner, u.comment, u.source
+		  , u.token, u.token_enable, user_type, UNIX_TIMESTAMP(u.ctime)
+		  , UNIX_TIMESTAMP(u.mtime), u.flag, u.mobile, u.email, u.token_expires_at
+		  , u.password_mtime, u.login_failures, u.locked_until
+	  FROM user u
+	  WHERE u.flag = 0
+	  `
+	return u.collectUsers(dbTx.Query, querySql, nil)
+}
+
 // collectUsers General query user list
 func (u *userStore) collectUsers(handler QueryHandler, querySql string, args []interface{}) ([]*model.User, error) {
-	rows, err := u.master.Query(querySql, args...)
+	rows, err := handler(querySql, args...)
 	if err != nil {
 		log.Error("[Store][User] list user ", zap.String("query sql", querySql), zap.Any("args", args), zap.Error(err))
 		return nil, store.Error(err)
@@ -585,12 +682,13 @@ func createDefaultStrategy(tx *BaseTx, role model.PrincipalType, id, name, owner
 
 func fetchRown2User(rows *sql.Rows) (*model.User, error) {
 	var (
-		ctime, mtime                int64
+		ctime, mtime, tokenExpireAt int64
+		passwordMtime, lockedUntil  int64
 		flag, tokenEnable, userType int
 		user                        = new(model.User)
 		err                         = rows.Scan(&user.ID, &user.Name, &user.Password, &user.Owner,
 			&user.Comment, &user.Source, &user.Token, &tokenEnable, &userType, &ctime, &mtime,
-			&flag, &user.Mobile, &user.Email)
+			&flag, &user.Mobile, &user.Email, &tokenExpireAt, &passwordMtime, &user.LoginFailures, &lockedUntil)
 	)
 
 	if err != nil {
@@ -599,6 +697,9 @@ func fetchRown2User(rows *sql.Rows) (*model.User, error) {
 
 	user.Valid = flag == 0
 	user.TokenEnable = tokenEnable == 1
+	user.TokenExpireAt = unixToResourceExpire(tokenExpireAt)
+	user.PasswordModifyTime = unixToResourceExpire(passwordMtime)
+	user.LockedUntil = unixToResourceExpire(lockedUntil)
 	user.CreateTime = time.Unix(ctime, 0)
 	user.ModifyTime = time.Unix(mtime, 0)
 	user.Type = model.UserRoleType(userType)
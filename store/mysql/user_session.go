@@ -0,0 +1,129 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package sqldb
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/store"
+)
+
+const userSessionQueryFields = "id, user_id, expire_time, flag, UNIX_TIMESTAMP(ctime), UNIX_TIMESTAMP(mtime)"
+
+type userSessionStore struct {
+	master *BaseDB
+	slave  *BaseDB
+}
+
+// AddUserSession 新增控制台登录会话
+func (ss *userSessionStore) AddUserSession(session *model.UserSession) error {
+	if session.ID == "" || session.UserID == "" {
+		return store.NewStatusError(store.EmptyParamsErr, fmt.Sprintf(
+			"add user session missing some params, id is %s, user is %s", session.ID, session.UserID))
+	}
+
+	addSql := "INSERT INTO user_session (id, user_id, expire_time, flag, ctime, mtime) " +
+		" VALUES (?, ?, ?, 0, sysdate(), sysdate())"
+	if _, err := ss.master.Exec(addSql, session.ID, session.UserID, session.ExpireTime.Unix()); err != nil {
+		log.Errorf("[Store][UserSession] add user(%s) session err: %s", session.UserID, err.Error())
+		return store.Error(err)
+	}
+	return nil
+}
+
+// RevokeUserSessions 注销用户的会话，ids 为空时注销该用户的全部会话
+func (ss *userSessionStore) RevokeUserSessions(userID string, ids []string) error {
+	if userID == "" {
+		return store.NewStatusError(store.EmptyParamsErr, "revoke user sessions missing user id")
+	}
+
+	revokeSql := "UPDATE user_session SET flag = 1, mtime = sysdate() WHERE user_id = ? AND flag = 0"
+	args := []interface{}{userID}
+	if len(ids) != 0 {
+		revokeSql += " AND id IN (" + PlaceholdersN(len(ids)) + ")"
+		for i := range ids {
+			args = append(args, ids[i])
+		}
+	}
+	if _, err := ss.master.Exec(revokeSql, args...); err != nil {
+		log.Errorf("[Store][UserSession] revoke user(%s) sessions err: %s", userID, err.Error())
+		return store.Error(err)
+	}
+	return nil
+}
+
+// GetUserSession 查询会话，包含已经注销的会话，会话不存在时返回 nil
+func (ss *userSessionStore) GetUserSession(id string) (*model.UserSession, error) {
+	if id == "" {
+		return nil, store.NewStatusError(store.EmptyParamsErr, "get user session missing id")
+	}
+	rows, err := ss.master.Query("SELECT "+userSessionQueryFields+" FROM user_session WHERE id = ?", id)
+	if err != nil {
+		return nil, store.Error(err)
+	}
+	sessions, err := fetchUserSessionRows(rows)
+	if err != nil || len(sessions) == 0 {
+		return nil, err
+	}
+	return sessions[0], nil
+}
+
+// GetUserSessionsForCache 查询 mtime 之后发生变化的会话，包含已经注销的会话
+func (ss *userSessionStore) GetUserSessionsForCache(mtime time.Time,
+	firstUpdate bool) ([]*model.UserSession, error) {
+	querySql := "SELECT " + userSessionQueryFields + " FROM user_session "
+	args := make([]interface{}, 0, 1)
+	if firstUpdate {
+		querySql += " WHERE flag = 0"
+	} else {
+		querySql += " WHERE mtime >= FROM_UNIXTIME(?)"
+		args = append(args, timeToTimestamp(mtime))
+	}
+	rows, err := ss.slave.Query(querySql, args...)
+	if err != nil {
+		return nil, store.Error(err)
+	}
+	return fetchUserSessionRows(rows)
+}
+
+func fetchUserSessionRows(rows *sql.Rows) ([]*model.UserSession, error) {
+	defer rows.Close()
+	ret := make([]*model.UserSession, 0, 4)
+	for rows.Next() {
+		var (
+			session                  = &model.UserSession{}
+			flag                     int
+			expireTime, ctime, mtime int64
+		)
+		if err := rows.Scan(&session.ID, &session.UserID, &expireTime, &flag, &ctime, &mtime); err != nil {
+			return nil, store.Error(err)
+		}
+		session.Valid = flag == 0
+		session.ExpireTime = time.Unix(expireTime, 0)
+		session.CreateTime = time.Unix(ctime, 0)
+		session.ModifyTime = time.Unix(mtime, 0)
+		ret = append(ret, session)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, store.Error(err)
+	}
+	return ret, nil
+}